@@ -0,0 +1,280 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+var adminParticipantFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "room",
+		Usage:    "name of the room",
+		Required: true,
+	},
+	&cli.StringFlag{
+		Name:     "identity",
+		Usage:    "identity of the participant",
+		Required: true,
+	},
+}
+
+var adminCommand = &cli.Command{
+	Name:  "admin",
+	Usage: "inspect the rooms and participants of a running node through its admin port",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "url",
+			Usage: "admin URL of the node, defaults to the admin port on localhost",
+		},
+		&cli.StringFlag{
+			Name:    "api-key",
+			Usage:   "API key the requests are signed with, defaults to the first admin key of the config",
+			EnvVars: []string{"LIVEKIT_API_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "api-secret",
+			Usage:   "secret of the API key",
+			EnvVars: []string{"LIVEKIT_API_SECRET"},
+		},
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:   "list-rooms",
+			Usage:  "list the rooms hosted by the node",
+			Action: adminListRooms,
+		},
+		{
+			Name:   "participant-info",
+			Usage:  "print the debug info of a participant",
+			Flags:  adminParticipantFlags,
+			Action: adminParticipantInfo,
+		},
+		{
+			Name:   "ice-restart",
+			Usage:  "restart the ICE connections of a participant",
+			Flags:  adminParticipantFlags,
+			Action: adminRestartICE,
+		},
+		{
+			Name:   "remove-participant",
+			Usage:  "remove a participant from its room",
+			Flags:  adminParticipantFlags,
+			Action: adminRemoveParticipant,
+		},
+	},
+}
+
+// adminClient calls the admin handlers of a node with short lived node admin tokens
+type adminClient struct {
+	url       string
+	apiKey    string
+	apiSecret string
+	client    *http.Client
+}
+
+func newAdminClient(c *cli.Context) (*adminClient, error) {
+	conf, err := getConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	adminURL := c.String("url")
+	if adminURL == "" {
+		if conf.Admin.Port == 0 {
+			return nil, errors.New("admin port is not configured, set it or pass --url")
+		}
+		adminURL = "http://127.0.0.1:" + strconv.Itoa(int(conf.Admin.Port))
+	}
+
+	apiKey, apiSecret := c.String("api-key"), c.String("api-secret")
+	if apiKey == "" || apiSecret == "" {
+		if apiKey, apiSecret, err = adminKey(conf, apiKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return &adminClient{
+		url:       strings.TrimSuffix(adminURL, "/"),
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// adminKey returns apiKey, or the first admin key, with its secret from the config keys
+func adminKey(conf *config.Config, apiKey string) (string, string, error) {
+	if err := conf.ValidateKeys(); err != nil {
+		return "", "", err
+	}
+
+	if apiKey == "" {
+		keys := maps.Keys(conf.Keys)
+		slices.Sort(keys)
+		for _, key := range keys {
+			if len(conf.Admin.APIKeys) == 0 || slices.Contains(conf.Admin.APIKeys, key) {
+				apiKey = key
+				break
+			}
+		}
+	}
+	apiSecret, ok := conf.Keys[apiKey]
+	if !ok {
+		return "", "", fmt.Errorf("no secret for API key %q", apiKey)
+	}
+	return apiKey, apiSecret, nil
+}
+
+func (a *adminClient) do(method string, path string, query url.Values) ([]byte, error) {
+	token, err := auth.NewAccessToken(a.apiKey, a.apiSecret).
+		AddGrant(&auth.VideoGrant{RoomAdmin: true, RoomList: true}).
+		SetValidFor(time.Minute).
+		ToJWT()
+	if err != nil {
+		return nil, err
+	}
+
+	target := a.url + path
+	if len(query) != 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+func (a *adminClient) listRooms() ([]*livekit.Room, error) {
+	body, err := a.do(http.MethodGet, "/admin/rooms", nil)
+	if err != nil {
+		return nil, err
+	}
+	res := &livekit.ListRoomsResponse{}
+	if err = protojson.Unmarshal(body, res); err != nil {
+		return nil, err
+	}
+	return res.Rooms, nil
+}
+
+func participantQuery(c *cli.Context) url.Values {
+	return url.Values{"room": {c.String("room")}, "identity": {c.String("identity")}}
+}
+
+func adminListRooms(c *cli.Context) error {
+	client, err := newAdminClient(c)
+	if err != nil {
+		return err
+	}
+	rooms, err := client.listRooms()
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "SID", "Participants", "Publishers", "Created At"})
+	for _, room := range rooms {
+		table.Append([]string{
+			room.Name,
+			room.Sid,
+			strconv.Itoa(int(room.NumParticipants)),
+			strconv.Itoa(int(room.NumPublishers)),
+			time.Unix(room.CreationTime, 0).UTC().Format("2006-01-02 15:04:05"),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+func adminParticipantInfo(c *cli.Context) error {
+	client, err := newAdminClient(c)
+	if err != nil {
+		return err
+	}
+	body, err := client.do(http.MethodGet, "/admin/participant", participantQuery(c))
+	if err != nil {
+		return err
+	}
+
+	var info map[string]interface{}
+	if err = json.Unmarshal(body, &info); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func adminRestartICE(c *cli.Context) error {
+	client, err := newAdminClient(c)
+	if err != nil {
+		return err
+	}
+	if _, err = client.do(http.MethodPost, "/admin/ice-restart", participantQuery(c)); err != nil {
+		return err
+	}
+	fmt.Println("ICE restart requested")
+	return nil
+}
+
+func adminRemoveParticipant(c *cli.Context) error {
+	client, err := newAdminClient(c)
+	if err != nil {
+		return err
+	}
+	if _, err = client.do(http.MethodPost, "/admin/remove-participant", participantQuery(c)); err != nil {
+		return err
+	}
+	fmt.Println("participant removed")
+	return nil
+}
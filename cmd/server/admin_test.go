@@ -0,0 +1,65 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestAdminClient(t *testing.T) {
+	conf, err := config.NewConfig(`keys:
+  key1: secret1secret1secret1secret1secret1
+  key2: secret2secret2secret2secret2secret2
+admin:
+  port: 6060
+  enabled: true
+  api_keys: [key2]`, true, nil, nil)
+	require.NoError(t, err)
+
+	apiKey, apiSecret, err := adminKey(conf, "")
+	require.NoError(t, err)
+	require.Equal(t, "key2", apiKey)
+	require.Equal(t, "secret2secret2secret2secret2secret2", apiSecret)
+	_, _, err = adminKey(conf, "key3")
+	require.Error(t, err)
+
+	m := service.NewAPIKeyAuthMiddleware(service.NewReloadableKeyProvider(conf), nil)
+	h := service.NewAdminHandler(conf, nil, nil)
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.ServeHTTP(w, r, h.ServeHTTP)
+	}))
+	defer node.Close()
+
+	client := &adminClient{url: node.URL, apiKey: apiKey, apiSecret: apiSecret, client: node.Client()}
+	rooms, err := client.listRooms()
+	require.NoError(t, err)
+	require.Empty(t, rooms)
+
+	_, err = client.do(http.MethodPost, "/admin/remove-participant", url.Values{"room": {"myroom"}, "identity": {"alice"}})
+	require.ErrorContains(t, err, "404")
+
+	// keys outside of the admin keys are rejected
+	client.apiKey, client.apiSecret = "key1", "secret1secret1secret1secret1secret1"
+	_, err = client.listRooms()
+	require.ErrorContains(t, err, "403")
+}
@@ -32,11 +32,14 @@ import (
 	"github.com/livekit/protocol/logger"
 
 	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/privacy"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/livekit-server/version"
 )
 
+const configWatchInterval = 5 * time.Second
+
 var baseFlags = []cli.Flag{
 	&cli.StringSliceFlag{
 		Name:  "bind",
@@ -65,6 +68,11 @@ var baseFlags = []cli.Flag{
 		Usage:   "region of the current node. Used by regionaware node selector",
 		EnvVars: []string{"LIVEKIT_REGION"},
 	},
+	&cli.StringFlag{
+		Name:    "node-role",
+		Usage:   "role of the current node, server or relay. Relay nodes only run a TURN server registered with the cluster",
+		EnvVars: []string{"LIVEKIT_NODE_ROLE"},
+	},
 	&cli.StringFlag{
 		Name:    "node-ip",
 		Usage:   "IP address of the current node, used to advertise to clients. Automatically determined by default",
@@ -104,6 +112,10 @@ var baseFlags = []cli.Flag{
 		Name:  "dev",
 		Usage: "sets log-level to debug, console formatter, and /debug/pprof. insecure for production",
 	},
+	&cli.BoolFlag{
+		Name:  "watch-config",
+		Usage: "reload the config file when it changes, as on SIGHUP",
+	},
 	&cli.BoolFlag{
 		Name:   "disable-strict-config",
 		Usage:  "disables strict config parsing",
@@ -173,6 +185,7 @@ func main() {
 				Usage:  "list all nodes",
 				Action: listNodes,
 			},
+			adminCommand,
 			{
 				Name:   "help-verbose",
 				Usage:  "prints app help, including all generated configuration flags",
@@ -188,6 +201,15 @@ func main() {
 }
 
 func getConfig(c *cli.Context) (*config.Config, error) {
+	conf, err := loadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	privacy.InitLoggerFromConfig(conf)
+	return conf, nil
+}
+
+func loadConfig(c *cli.Context) (*config.Config, error) {
 	confString, err := getConfigString(c.String("config"), c.String("config-body"))
 	if err != nil {
 		return nil, err
@@ -202,7 +224,6 @@ func getConfig(c *cli.Context) (*config.Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	config.InitLoggerFromConfig(&conf.Logging)
 
 	if conf.Development {
 		logger.Infow("starting in development mode")
@@ -274,7 +295,12 @@ func startServer(c *cli.Context) error {
 
 	prometheus.Init(currentNode.Id, currentNode.Type, conf.Environment)
 
-	server, err := service.InitializeServer(conf, currentNode)
+	var server nodeServer
+	if conf.IsRelayNode() {
+		server, err = service.InitializeRelayServer(conf, currentNode)
+	} else {
+		server, err = service.InitializeServer(conf, currentNode)
+	}
 	if err != nil {
 		return err
 	}
@@ -291,9 +317,64 @@ func startServer(c *cli.Context) error {
 		}
 	}()
 
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	if c.Bool("watch-config") && c.String("config") != "" {
+		go watchFile(c.String("config"), hupChan)
+	}
+	// keys can be added and revoked without a restart, e.g. by updating a mounted secret
+	if conf.KeyFile != "" {
+		go watchFile(conf.KeyFile, hupChan)
+	}
+	go func() {
+		for range hupChan {
+			reloadConfig(c, server)
+		}
+	}()
+
 	return server.Start()
 }
 
+// nodeServer runs the node for its role, a full server or a relay node
+type nodeServer interface {
+	Start() error
+	Stop(force bool)
+	ReloadConfig(next *config.Config) error
+}
+
+func reloadConfig(c *cli.Context, server nodeServer) {
+	logger.Infow("reloading config")
+	next, err := loadConfig(c)
+	if err == nil {
+		err = next.ValidateKeys()
+	}
+	if err == nil {
+		err = server.ReloadConfig(next)
+	}
+	if err != nil {
+		logger.Errorw("could not reload config", err)
+	}
+}
+
+// watchFile requests a reload when the modification time of the file changes
+func watchFile(path string, reload chan<- os.Signal) {
+	var modTime time.Time
+	if st, err := os.Stat(path); err == nil {
+		modTime = st.ModTime()
+	}
+	for range time.Tick(configWatchInterval) {
+		st, err := os.Stat(path)
+		if err != nil || st.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = st.ModTime()
+		select {
+		case reload <- syscall.SIGHUP:
+		default:
+		}
+	}
+}
+
 func getConfigString(configFile string, inConfigBody string) (string, error) {
 	if inConfigBody != "" || configFile == "" {
 		return inConfigBody, nil
@@ -16,8 +16,10 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
@@ -25,17 +27,31 @@ import (
 	"github.com/pion/webrtc/v3"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v3"
 
 	"github.com/livekit/mediatransportutil/pkg/rtcconfig"
+	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 	redisLiveKit "github.com/livekit/protocol/redis"
 	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/protocol/webhook"
 )
 
 type (
-	CongestionControlProbeMode string
-	StreamTrackerType          string
+	CongestionControlProbeMode    string
+	CongestionControlBWEAlgorithm string
+	StreamTrackerType             string
+	ICECandidatePolicy            string
+	NodeRole                      string
+	ConnectionQualityScorer       string
+	UnicodeNormalization          string
+	LossyDataDropPolicy           string
+	ForwardingPolicy              string
+	JoinAuthorizationPolicy       string
+	TelemetryExportFormat         string
+	PrivacyPolicy                 string
+	AuditLogSink                  string
 )
 
 const (
@@ -43,10 +59,75 @@ const (
 
 	CongestionControlProbeModePadding CongestionControlProbeMode = "padding"
 	CongestionControlProbeModeMedia   CongestionControlProbeMode = "media"
+	// no probing, allocations only go up when the estimate grows on its own
+	CongestionControlProbeModeDisabled CongestionControlProbeMode = "disabled"
+
+	// Google congestion control, the default
+	CongestionControlBWEAlgorithmGCC CongestionControlBWEAlgorithm = "gcc"
+	// network-assisted dynamic adaptation, RFC 8698
+	CongestionControlBWEAlgorithmNADA CongestionControlBWEAlgorithm = "nada"
+
+	// simplified E-model, lenient with moderate delay, the default
+	ConnectionQualityScorerEModel ConnectionQualityScorer = "emodel"
+	// points taken off in proportion to loss, delay and bitrate shortfall
+	ConnectionQualityScorerLinear ConnectionQualityScorer = "linear"
+
+	// canonical composition, visually identical strings compare equal
+	UnicodeNormalizationNFC UnicodeNormalization = "nfc"
+	// compatibility composition, also folds compatibility characters like ligatures and full width forms
+	UnicodeNormalizationNFKC UnicodeNormalization = "nfkc"
 
 	StreamTrackerTypePacket StreamTrackerType = "packet"
 	StreamTrackerTypeFrame  StreamTrackerType = "frame"
 
+	// all candidates, the default
+	ICECandidatePolicyAll ICECandidatePolicy = "all"
+	// only relay candidates of the participant, which reach the SFU through a TURN server and keep
+	// the addresses of the participant out of the SFU
+	ICECandidatePolicyRelay ICECandidatePolicy = "relay"
+	// only host candidates, for participants on the same network as the SFU
+	ICECandidatePolicyHost ICECandidatePolicy = "host"
+
+	// hosts rooms and signaling, the default
+	NodeRoleServer NodeRole = "server"
+	// only runs a TURN server registered with the cluster, relaying the media of participants to nodes
+	// hosting rooms. Relay nodes do not host rooms or handle signaling
+	NodeRoleRelay NodeRole = "relay"
+
+	// drops the message being sent when the lossy data queue is full, the default
+	LossyDataDropPolicyNewest LossyDataDropPolicy = "drop_newest"
+	// drops the oldest queued message to make room for the message being sent
+	LossyDataDropPolicyOldest LossyDataDropPolicy = "drop_oldest"
+
+	// lowers the spatial or temporal layer, whichever saves bits at the least cost to quality, the default
+	ForwardingPolicyBalanced ForwardingPolicy = "balanced"
+	// lowers the frame rate before the resolution, for content like slides and text
+	ForwardingPolicyMaintainResolution ForwardingPolicy = "maintain_resolution"
+
+	// rejects joins when the authorization service fails or does not answer in time, the default
+	JoinAuthorizationFailClosed JoinAuthorizationPolicy = "fail_closed"
+	// lets participants join with the grants of their token when the authorization service fails
+	JoinAuthorizationFailOpen JoinAuthorizationPolicy = "fail_open"
+
+	// inserts rows into ClickHouse tables through its HTTP interface, as JSONEachRow
+	TelemetryExportFormatClickHouse TelemetryExportFormat = "clickhouse"
+	// posts newline delimited JSON, one row per line
+	TelemetryExportFormatJSONL TelemetryExportFormat = "jsonl"
+
+	// personal data is kept as is, the default
+	PrivacyPolicyKeep PrivacyPolicy = ""
+	// replaced by a salted hash, records of the same value can still be matched
+	PrivacyPolicyHash PrivacyPolicy = "hash"
+	// removed
+	PrivacyPolicyOmit PrivacyPolicy = "omit"
+
+	// appends JSON lines to a file
+	AuditLogSinkFile AuditLogSink = "file"
+	// sends each record as a JSON message to syslog
+	AuditLogSinkSyslog AuditLogSink = "syslog"
+	// posts each record as JSON
+	AuditLogSinkHTTP AuditLogSink = "http"
+
 	StatsUpdateInterval          = time.Second * 10
 	TelemetryStatsUpdateInterval = time.Second * 30
 )
@@ -60,9 +141,12 @@ type Config struct {
 	Port           uint32                   `yaml:"port,omitempty"`
 	BindAddresses  []string                 `yaml:"bind_addresses,omitempty"`
 	PrometheusPort uint32                   `yaml:"prometheus_port,omitempty"`
+	GRPCPort       uint32                   `yaml:"grpc_port,omitempty"`
 	Environment    string                   `yaml:"environment,omitempty"`
 	RTC            RTCConfig                `yaml:"rtc,omitempty"`
 	Redis          redisLiveKit.RedisConfig `yaml:"redis,omitempty"`
+	NATS           NATSConfig               `yaml:"nats,omitempty"`
+	ClusterTLS     ClusterTLSConfig         `yaml:"cluster_tls,omitempty"`
 	Audio          AudioConfig              `yaml:"audio,omitempty"`
 	Video          VideoConfig              `yaml:"video,omitempty"`
 	Room           RoomConfig               `yaml:"room,omitempty"`
@@ -73,7 +157,10 @@ type Config struct {
 	NodeSelector   NodeSelectorConfig       `yaml:"node_selector,omitempty"`
 	KeyFile        string                   `yaml:"key_file,omitempty"`
 	Keys           map[string]string        `yaml:"keys,omitempty"`
+	OIDC           OIDCConfig               `yaml:"oidc,omitempty"`
 	Region         string                   `yaml:"region,omitempty"`
+	NodeRole       NodeRole                 `yaml:"node_role,omitempty"`
+	RelayNodes     RelayNodesConfig         `yaml:"relay_nodes,omitempty"`
 	SignalRelay    SignalRelayConfig        `yaml:"signal_relay,omitempty"`
 	PSRPC          rpc.PSRPCConfig          `yaml:"psrpc,omitempty"`
 	// Deprecated: LogLevel is deprecated
@@ -81,7 +168,43 @@ type Config struct {
 	Logging  LoggingConfig `yaml:"logging,omitempty"`
 	Limit    LimitConfig   `yaml:"limit,omitempty"`
 
+	Attachments AttachmentsConfig `yaml:"attachments,omitempty"`
+
+	PacketCapture PacketCaptureConfig `yaml:"packet_capture,omitempty"`
+
+	Transcription TranscriptionConfig `yaml:"transcription,omitempty"`
+
+	AudioProcessing AudioProcessingConfig `yaml:"audio_processing,omitempty"`
+
+	Admin AdminConfig `yaml:"admin,omitempty"`
+
+	EventStream EventStreamConfig `yaml:"event_stream,omitempty"`
+
+	ParticipantValidation ParticipantValidationConfig `yaml:"participant_validation,omitempty"`
+
+	JoinAuthorization JoinAuthorizationConfig `yaml:"join_authorization,omitempty"`
+
+	TelemetryExport TelemetryExportConfig `yaml:"telemetry_export,omitempty"`
+
+	// client IPs and participant identities scrubbed from logs and analytics events
+	Privacy PrivacyConfig `yaml:"privacy,omitempty"`
+
+	AuditLog AuditLogConfig `yaml:"audit_log,omitempty"`
+
+	// countries of client IPs, for join restrictions by country
+	GeoIP GeoIPConfig `yaml:"geoip,omitempty"`
+
+	// CIDR ranges of the proxies in front of the server. Client IPs are taken from forwarding headers only when
+	// the request comes from one of them, otherwise they are the address of the connection
+	TrustedProxies []string `yaml:"trusted_proxies,omitempty"`
+
+	Startup StartupConfig `yaml:"startup,omitempty"`
+
+	Drain DrainConfig `yaml:"drain,omitempty"`
+
 	Development bool `yaml:"development,omitempty"`
+
+	reload reloadState
 }
 
 type RTCConfig struct {
@@ -103,6 +226,24 @@ type RTCConfig struct {
 
 	CongestionControl CongestionControlConfig `yaml:"congestion_control,omitempty"`
 
+	// how the EXCELLENT/GOOD/POOR connection quality of tracks is computed
+	ConnectionQuality ConnectionQualityConfig `yaml:"connection_quality,omitempty"`
+
+	// RTP stats of the tracks of each participant kept in memory, exported with GetTrackStatsHistory
+	TrackStatsHistory TrackStatsHistoryConfig `yaml:"track_stats_history,omitempty"`
+
+	// checks of the payloads of video packets received from publishers, against corrupted streams of buggy encoders
+	PayloadIntegrity PayloadIntegrityConfig `yaml:"payload_integrity,omitempty"`
+
+	// packets since the last key frame of video layers replayed to new subscribers
+	KeyFrameCache KeyFrameCacheConfig `yaml:"keyframe_cache,omitempty"`
+
+	// how the layers forwarded of video tracks of each source are lowered when subscribers are short of bandwidth
+	Forwarding ForwardingConfig `yaml:"forwarding,omitempty"`
+
+	// packets received out of order from publishers held back to be forwarded in order, by track kind
+	Reorder ReorderConfig `yaml:"reorder,omitempty"`
+
 	// allow TCP and TURN/TLS fallback
 	AllowTCPFallback *bool `yaml:"allow_tcp_fallback,omitempty"`
 
@@ -117,6 +258,29 @@ type RTCConfig struct {
 
 	// max number of bytes to buffer for data channel. 0 means unlimited
 	DataChannelMaxBufferedAmount uint64 `yaml:"data_channel_max_buffered_amount,omitempty"`
+
+	// messages held back, and dropped, when the lossy data channel of a subscriber is congested
+	LossyData LossyDataConfig `yaml:"lossy_data,omitempty"`
+
+	// max bitrate, in bps, of a video track forwarded to a subscriber, layers over it are not forwarded. 0 means unlimited
+	MaxTrackBitrate int64 `yaml:"max_track_bitrate,omitempty"`
+
+	// forward packets of tracks with many subscribers through a shared worker pool
+	FanOut FanOutConfig `yaml:"fan_out,omitempty"`
+
+	// transcode tracks for subscribers that cannot decode any of their published codecs
+	TranscodeFallback TranscodeFallbackConfig `yaml:"transcode_fallback,omitempty"`
+
+	// ICE candidate policies of participants by the kind of their token, e.g. {privacy: relay, agent: host}
+	ICECandidatePolicies map[string]ICECandidatePolicy `yaml:"ice_candidate_policies,omitempty"`
+
+	// negotiate H.264 with publishers and subscribers using profiles other than constrained baseline and high, e.g.
+	// baseline or main, instead of falling back to another codec
+	LenientH264ProfileMatching bool `yaml:"lenient_h264_profile_matching,omitempty"`
+
+	// how long a track restored without a receiver, e.g. after a migration, waits for its publisher to publish it
+	// again before its subscribers are released
+	PotentialCodecTimeout time.Duration `yaml:"potential_codec_timeout,omitempty"`
 }
 
 type TURNServer struct {
@@ -131,6 +295,122 @@ type PLIThrottleConfig struct {
 	LowQuality  time.Duration `yaml:"low_quality,omitempty"`
 	MidQuality  time.Duration `yaml:"mid_quality,omitempty"`
 	HighQuality time.Duration `yaml:"high_quality,omitempty"`
+	// minimum time between key frame requests of a track across its layers, 0 paces the layers only
+	Track time.Duration `yaml:"track,omitempty"`
+}
+
+type ConnectionQualityConfig struct {
+	Scorer  ConnectionQualityScorer  `yaml:"scorer,omitempty"`
+	Weights ConnectionQualityWeights `yaml:"weights,omitempty"`
+	// webhooks sent when the quality of participants stays degraded
+	Alert ConnectionQualityAlertConfig `yaml:"alert,omitempty"`
+}
+
+// ConnectionQualityAlertConfig notifies participant_connection_quality_degraded once the quality of a participant
+// has been at or below the threshold for the duration, and participant_connection_quality_recovered once it goes back above
+type ConnectionQualityAlertConfig struct {
+	// good, poor or lost, alerts are disabled when empty
+	Threshold string `yaml:"threshold,omitempty"`
+	// defaults to 30s
+	Duration time.Duration `yaml:"duration,omitempty"`
+}
+
+// ThresholdQuality returns the quality of the threshold, ok is false when alerts are disabled
+func (c ConnectionQualityAlertConfig) ThresholdQuality() (livekit.ConnectionQuality, bool) {
+	if c.Threshold == "" {
+		return livekit.ConnectionQuality_EXCELLENT, false
+	}
+	quality, ok := livekit.ConnectionQuality_value[strings.ToUpper(c.Threshold)]
+	if !ok || livekit.ConnectionQuality(quality) == livekit.ConnectionQuality_EXCELLENT {
+		return livekit.ConnectionQuality_EXCELLENT, false
+	}
+	return livekit.ConnectionQuality(quality), true
+}
+
+type TrackStatsHistoryConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// how long snapshots are kept, including those of tracks that have been unpublished or unsubscribed
+	Retention time.Duration `yaml:"retention,omitempty"`
+	// snapshots are taken at most once per interval, along with the connection quality updates every 5s
+	SampleInterval time.Duration `yaml:"sample_interval,omitempty"`
+	// how long the history of a participant can still be queried after it left the room, 0 drops it right away
+	RetainAfterLeave time.Duration `yaml:"retain_after_leave,omitempty"`
+}
+
+// PayloadIntegrityConfig checks that the headers of the video payloads of publishers can be decoded and that their
+// picture ids are consistent. The error rate is the fraction of the packets of a stream failing the checks over a window.
+type PayloadIntegrityConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// a key frame is requested from the publisher when the error rate goes over it
+	PLIThreshold float64 `yaml:"pli_threshold,omitempty"`
+	// the track_payload_corrupted webhook is sent when the error rate goes over it, and track_payload_recovered
+	// once it goes below the PLI threshold
+	FlagThreshold float64       `yaml:"flag_threshold,omitempty"`
+	Window        time.Duration `yaml:"window,omitempty"`
+}
+
+// KeyFrameCacheConfig keeps the packets of each video layer since its last key frame, a new subscriber starts with
+// them instead of waiting for the key frame of a PLI. Layers with more packets between key frames than the
+// maximum are not cached.
+type KeyFrameCacheConfig struct {
+	Enabled    bool `yaml:"enabled,omitempty"`
+	MaxPackets int  `yaml:"max_packets,omitempty"`
+}
+
+// ReorderConfig holds back packets received after a gap in sequence numbers, for the missing packets to arrive or
+// to be retransmitted, so that they are forwarded in order. Held packets are forwarded once the window is full or
+// once one of them has waited the max latency. Packets are forwarded as they are received when the depth is 0.
+type ReorderConfig struct {
+	Audio ReorderWindowConfig `yaml:"audio,omitempty"`
+	Video ReorderWindowConfig `yaml:"video,omitempty"`
+}
+
+type ReorderWindowConfig struct {
+	// packets held back at most, it has to be less than the packets buffered for NACK
+	Depth int `yaml:"depth,omitempty"`
+	// longest a packet is held back, the latency added to the forwarding of a loss
+	MaxLatency time.Duration `yaml:"max_latency,omitempty"`
+}
+
+func (c ReorderWindowConfig) validate(kind string, packetBufferSize int) error {
+	if c.Depth < 0 || c.MaxLatency < 0 {
+		return fmt.Errorf("%s reorder depth and max latency cannot be negative", kind)
+	}
+	if c.Depth == 0 {
+		return nil
+	}
+	if c.MaxLatency == 0 {
+		return fmt.Errorf("%s reorder needs a max latency", kind)
+	}
+	if packetBufferSize > 0 && c.Depth >= packetBufferSize {
+		return fmt.Errorf("%s reorder depth must be less than the packet buffer size of %d", kind, packetBufferSize)
+	}
+	return nil
+}
+
+// ForwardingConfig selects the forwarding policy of video tracks by their source
+type ForwardingConfig struct {
+	// by source, camera, screen_share or unknown, balanced applies to the sources that are not listed
+	Policies map[string]ForwardingPolicy `yaml:"policies,omitempty"`
+	// packets of video kept for retransmission of tracks forwarded with maintain_resolution, losses take longer
+	// to repair when frames are infrequent. packet_buffer_size_video applies when 0
+	MaintainResolutionPacketBufferSize int `yaml:"maintain_resolution_packet_buffer_size,omitempty"`
+}
+
+// PolicyFor returns the forwarding policy of tracks of the source
+func (c ForwardingConfig) PolicyFor(source livekit.TrackSource) ForwardingPolicy {
+	if policy, ok := c.Policies[strings.ToLower(source.String())]; ok && policy != "" {
+		return policy
+	}
+	return ForwardingPolicyBalanced
+}
+
+// ConnectionQualityWeights multiply the effect of each aspect on the connection quality score, 0 ignores it
+type ConnectionQualityWeights struct {
+	Loss    float64 `yaml:"loss"`
+	Jitter  float64 `yaml:"jitter"`
+	RTT     float64 `yaml:"rtt"`
+	Bitrate float64 `yaml:"bitrate"`
 }
 
 type CongestionControlProbeConfig struct {
@@ -149,6 +429,14 @@ type CongestionControlProbeConfig struct {
 	MaxDuration            time.Duration `yaml:"max_duration,omitempty"`
 	DurationOverflowFactor float64       `yaml:"duration_overflow_factor,omitempty"`
 	DurationIncreaseFactor float64       `yaml:"duration_increase_factor,omitempty"`
+
+	// caps the padding sent by a probe to this ratio of the expected bandwidth usage, no cap when 0.
+	// The probe still sends at least MinBps of padding
+	MaxPaddingRatio float64 `yaml:"max_padding_ratio,omitempty"`
+	// caps the bitrate a probe aims for, media and padding included, no cap when 0
+	MaxBps int64 `yaml:"max_bps,omitempty"`
+	// minimum wait before the next probe once congestion from loss is detected
+	LossCooldown time.Duration `yaml:"loss_cooldown,omitempty"`
 }
 
 type CongestionControlChannelObserverConfig struct {
@@ -169,12 +457,86 @@ type CongestionControlConfig struct {
 	NackRatioAttenuator              float64                                `yaml:"nack_ratio_attenuator,omitempty"`
 	ExpectedUsageThreshold           float64                                `yaml:"expected_usage_threshold,omitempty"`
 	UseSendSideBWE                   bool                                   `yaml:"send_side_bandwidth_estimation,omitempty"`
+	SendSideBWEAlgorithm             CongestionControlBWEAlgorithm          `yaml:"send_side_bandwidth_estimator,omitempty"`
 	ProbeMode                        CongestionControlProbeMode             `yaml:"probe_mode,omitempty"`
 	MinChannelCapacity               int64                                  `yaml:"min_channel_capacity,omitempty"`
 	ProbeConfig                      CongestionControlProbeConfig           `yaml:"probe_config,omitempty"`
 	ChannelObserverProbeConfig       CongestionControlChannelObserverConfig `yaml:"channel_observer_probe_config,omitempty"`
 	ChannelObserverNonProbeConfig    CongestionControlChannelObserverConfig `yaml:"channel_observer_non_probe_config,omitempty"`
 	DisableEstimationUnmanagedTracks bool                                   `yaml:"disable_etimation_unmanaged_tracks,omitempty"`
+	// estimates and decisions of the allocator of each subscriber kept in memory, exported with GetCongestionTrace
+	Trace CongestionControlTraceConfig `yaml:"trace,omitempty"`
+	// pauses all video of subscribers whose channel capacity collapses, keeping their audio
+	AudioOnly CongestionControlAudioOnlyConfig `yaml:"audio_only,omitempty"`
+}
+
+// CongestionControlAudioOnlyConfig pauses every video track of a subscriber when its committed channel capacity drops
+// below Threshold. Video resumes once the estimate stays at or above ResumeThreshold for ResumeAfter.
+type CongestionControlAudioOnlyConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// channel capacity in bps below which video is paused
+	Threshold int64 `yaml:"threshold,omitempty"`
+	// estimate in bps to recover to before video resumes, above Threshold
+	ResumeThreshold int64         `yaml:"resume_threshold,omitempty"`
+	ResumeAfter     time.Duration `yaml:"resume_after,omitempty"`
+}
+
+func (c CongestionControlAudioOnlyConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Threshold <= 0 || c.ResumeThreshold <= c.Threshold || c.ResumeAfter < 0 {
+		return errors.New("audio only mode needs a positive threshold below the resume threshold")
+	}
+	return nil
+}
+
+type CongestionControlTraceConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// entries kept per subscriber, the oldest ones are dropped
+	Size int `yaml:"size,omitempty"`
+	// estimates are recorded at most once per interval, allocations, state changes and probes are always recorded
+	SampleInterval time.Duration `yaml:"sample_interval,omitempty"`
+}
+
+type FanOutConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// number of down tracks of a track above which its packets are written by the workers
+	Threshold int `yaml:"threshold,omitempty"`
+	// number of workers, defaults to the number of CPUs
+	Workers int `yaml:"workers,omitempty"`
+	// number of down tracks written by a worker for one dispatch
+	BatchSize int `yaml:"batch_size,omitempty"`
+	// number of batches queued per worker, a batch is written by the forwarding goroutine when the queue is full
+	QueueSize int `yaml:"queue_size,omitempty"`
+}
+
+// TranscodeFallbackConfig sets up a receiver transcoding a track into a codec the subscriber decodes, instead of
+// failing the subscription. The server has no codecs of its own, the transcoder is set on the RoomManager
+type TranscodeFallbackConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// transcoded codecs per track, 0 for no limit
+	MaxCodecsPerTrack int `yaml:"max_codecs_per_track,omitempty"`
+}
+
+// LossyDataConfig queues lossy data messages while the data channel of a subscriber buffers more than
+// MaxBufferedAmount, instead of buffering them in the data channel and delaying every later message
+type LossyDataConfig struct {
+	// bytes buffered in the lossy data channel above which it is congested, 0 disables the queue
+	MaxBufferedAmount uint64 `yaml:"max_buffered_amount,omitempty"`
+	// number of messages queued while congested, messages are dropped right away when 0
+	QueueSize int `yaml:"queue_size,omitempty"`
+	// message dropped when the queue is full, among the messages with the lowest priority
+	DropPolicy LossyDataDropPolicy `yaml:"drop_policy,omitempty"`
+	// priorities of the messages on matching topics, the first match applies, other messages have priority 0.
+	// Messages with a higher priority are dropped last
+	TopicPriorities []LossyDataTopicPriority `yaml:"topic_priorities,omitempty"`
+}
+
+type LossyDataTopicPriority struct {
+	// a trailing * matches the topics with the prefix, * alone matches every topic and messages without a topic
+	Topic    string `yaml:"topic,omitempty"`
+	Priority int    `yaml:"priority,omitempty"`
 }
 
 type AudioConfig struct {
@@ -223,6 +585,86 @@ type PlayoutDelayConfig struct {
 type VideoConfig struct {
 	DynacastPauseDelay time.Duration        `yaml:"dynacast_pause_delay,omitempty"`
 	StreamTracker      StreamTrackersConfig `yaml:"stream_tracker,omitempty"`
+	// bitrates expected of each video quality, the measured bitrates are used when not set
+	Bitrates VideoBitrateConfig `yaml:"bitrates,omitempty"`
+}
+
+// VideoBitrateConfig are the bitrates expected of the video qualities of each codec. The stream allocator brings the
+// measured bitrates of layers into the range of their quality, and the connection quality of subscribers drops
+// when they get less than its minimum.
+type VideoBitrateConfig struct {
+	// by codec, vp8, h264, vp9 or av1, default applies to the codecs that are not listed
+	Codecs map[string]VideoQualityBitrates `yaml:"codecs,omitempty"`
+	// by codec for screen share tracks, the ranges of the codecs apply when not set
+	ScreenShare map[string]VideoQualityBitrates `yaml:"screen_share,omitempty"`
+}
+
+type VideoQualityBitrates struct {
+	Low    BitrateRange `yaml:"low,omitempty"`
+	Medium BitrateRange `yaml:"medium,omitempty"`
+	High   BitrateRange `yaml:"high,omitempty"`
+}
+
+// BitrateRange is in bps, 0 leaves a bound open
+type BitrateRange struct {
+	Min int64 `yaml:"min,omitempty"`
+	Max int64 `yaml:"max,omitempty"`
+}
+
+// Range returns the range of the quality, an open one when none is configured
+func (b *VideoQualityBitrates) Range(quality livekit.VideoQuality) BitrateRange {
+	if b == nil {
+		return BitrateRange{}
+	}
+	switch quality {
+	case livekit.VideoQuality_LOW:
+		return b.Low
+	case livekit.VideoQuality_MEDIUM:
+		return b.Medium
+	case livekit.VideoQuality_HIGH:
+		return b.High
+	}
+	return BitrateRange{}
+}
+
+// For returns the ranges of a codec, e.g. video/av1, nil when none are configured
+func (c VideoBitrateConfig) For(mimeType string, screenShare bool) *VideoQualityBitrates {
+	codec := strings.TrimPrefix(strings.ToLower(mimeType), "video/")
+	lookup := func(ranges map[string]VideoQualityBitrates) *VideoQualityBitrates {
+		if b, ok := ranges[codec]; ok {
+			return &b
+		}
+		if b, ok := ranges[videoBitrateDefaultCodec]; ok {
+			return &b
+		}
+		return nil
+	}
+	if screenShare {
+		if b := lookup(c.ScreenShare); b != nil {
+			return b
+		}
+	}
+	return lookup(c.Codecs)
+}
+
+const videoBitrateDefaultCodec = "default"
+
+func (c VideoBitrateConfig) validate() error {
+	for _, ranges := range []map[string]VideoQualityBitrates{c.Codecs, c.ScreenShare} {
+		for codec, b := range ranges {
+			switch codec {
+			case videoBitrateDefaultCodec, "vp8", "h264", "vp9", "av1":
+			default:
+				return fmt.Errorf("unknown codec %q in video bitrates", codec)
+			}
+			for _, r := range []BitrateRange{b.Low, b.Medium, b.High} {
+				if r.Min < 0 || r.Max < 0 || (r.Max > 0 && r.Min > r.Max) {
+					return fmt.Errorf("video bitrates of %s must have a min below the max", codec)
+				}
+			}
+		}
+	}
+	return nil
 }
 
 type RoomConfig struct {
@@ -235,6 +677,151 @@ type RoomConfig struct {
 	MaxMetadataSize    uint32             `yaml:"max_metadata_size,omitempty"`
 	PlayoutDelay       PlayoutDelayConfig `yaml:"playout_delay,omitempty"`
 	SyncStreams        bool               `yaml:"sync_streams,omitempty"`
+	// fmtp parameters merged into the negotiated fmtp line of a codec, e.g. profile-level-id for video/h264
+	FmtpOverrides []CodecSpec `yaml:"fmtp_overrides,omitempty"`
+	// named presets that CreateRoom can reference instead of passing the settings in every request
+	Templates map[string]RoomTemplate `yaml:"templates,omitempty"`
+	// goroutines and timers a room is expected to run at most, a warning is logged when it goes over. 0 disables
+	GoroutineBudget uint32 `yaml:"goroutine_budget,omitempty"`
+	// codecs slated for removal, publishers still using them are reported until the codec is cut off
+	DeprecatedCodecs []CodecDeprecationConfig `yaml:"deprecated_codecs,omitempty"`
+	// lockout of participants failing to present the passcode of rooms created with one
+	Passcode RoomPasscodeConfig `yaml:"passcode,omitempty"`
+	// rules evaluated on the events of every room, they apply to rooms created after a reload
+	Rules []RoomRuleConfig `yaml:"rules,omitempty"`
+	// keep the sessions of participants in the store, so that they can resume on another node when theirs is lost
+	// instead of joining again. Requires a store shared by the nodes
+	ResumeAcrossNodes bool `yaml:"resume_across_nodes,omitempty"`
+	// limits of the data messages a participant publishes on a topic, they apply to rooms created after a reload
+	DataTopicLimits []DataTopicLimitConfig `yaml:"data_topic_limits,omitempty"`
+	// reliable data messages retained and replayed to participants joining later, they apply to rooms created
+	// after a reload
+	DataReplay []DataReplayConfig `yaml:"data_replay,omitempty"`
+	// settings subscriptions start with by track source, until the subscriber sends its own. They apply to
+	// participants joining after a reload
+	SubscribeDefaults SubscribeDefaultsConfig `yaml:"subscribe_defaults,omitempty"`
+}
+
+// SubscribeDefaultsConfig are the subscribe defaults by track source, camera, microphone, screen_share or
+// screen_share_audio
+type SubscribeDefaultsConfig map[string]SubscribeDefaults
+
+// SubscribeDefaults are the settings a subscription to a track starts with
+type SubscribeDefaults struct {
+	// low, medium or high, video only. Low with adaptive stream and high otherwise when not set
+	Quality string `yaml:"quality,omitempty" json:"quality,omitempty"`
+	// subscriptions start disabled until the subscriber enables them, ignored for video with adaptive stream
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+}
+
+// VideoQuality returns the quality, ok is false when not set
+func (d SubscribeDefaults) VideoQuality() (livekit.VideoQuality, bool) {
+	quality, ok := livekit.VideoQuality_value[strings.ToUpper(d.Quality)]
+	if !ok || livekit.VideoQuality(quality) == livekit.VideoQuality_OFF {
+		return livekit.VideoQuality_HIGH, false
+	}
+	return livekit.VideoQuality(quality), true
+}
+
+// For returns the defaults of tracks of the source, nil when there are none
+func (c SubscribeDefaultsConfig) For(source livekit.TrackSource) *SubscribeDefaults {
+	if d, ok := c[strings.ToLower(source.String())]; ok {
+		return &d
+	}
+	return nil
+}
+
+// Validate returns an error for unknown sources and qualities
+func (c SubscribeDefaultsConfig) Validate() error {
+	for source, d := range c {
+		if s, ok := livekit.TrackSource_value[strings.ToUpper(source)]; !ok || livekit.TrackSource(s) == livekit.TrackSource_UNKNOWN {
+			return fmt.Errorf("unknown track source %q in subscribe defaults", source)
+		}
+		if _, ok := d.VideoQuality(); d.Quality != "" && !ok {
+			return fmt.Errorf("unknown quality %q in subscribe defaults of %s", d.Quality, source)
+		}
+	}
+	return nil
+}
+
+// DataTopicLimitConfig limits the rate of the data messages each participant publishes on matching topics,
+// messages over the limit are dropped. The first limit matching a topic applies to it
+type DataTopicLimitConfig struct {
+	// a trailing * matches the topics with the prefix, * alone matches every topic and messages without a topic
+	Topic string `yaml:"topic,omitempty"`
+	// messages per second
+	Rate float64 `yaml:"rate,omitempty"`
+	// messages sent at once above the rate, the rate rounded up when 0
+	Burst int `yaml:"burst,omitempty"`
+}
+
+// DataReplayConfig retains the reliable data messages broadcast on matching topics, within both limits when
+// they are set, and replays them to the participants joining the room. The first config matching a topic applies to it
+type DataReplayConfig struct {
+	// a trailing * matches the topics with the prefix, * alone matches every topic and messages without a topic
+	Topic string `yaml:"topic,omitempty"`
+	// number of messages retained, the oldest are dropped first
+	MaxMessages int `yaml:"max_messages,omitempty"`
+	// how long messages are retained
+	MaxAge time.Duration `yaml:"max_age,omitempty"`
+}
+
+// RoomRuleConfig runs an action when its condition holds on a room event, e.g. closing a room
+// when no host has been present for 5 minutes:
+//
+//	when: room.hosts == 0
+//	for: 5m
+//	action: {type: close_room}
+type RoomRuleConfig struct {
+	Name string `yaml:"name,omitempty"`
+	// participant_joined, participant_left, track_published or track_unpublished. Rules without an event are
+	// evaluated on every event, their action runs once the condition has held for For
+	Event string `yaml:"event,omitempty"`
+	// expression on the room, and on the participant and track of the event, the rule always applies when empty
+	When   string               `yaml:"when,omitempty"`
+	For    time.Duration        `yaml:"for,omitempty"`
+	Action RoomRuleActionConfig `yaml:"action,omitempty"`
+}
+
+type RoomRuleActionConfig struct {
+	// close_room, mute_track, send_data, start_egress or webhook
+	Type string `yaml:"type,omitempty"`
+	// send_data, sent to the room or only to the participant of the event
+	Topic         string `yaml:"topic,omitempty"`
+	Payload       string `yaml:"payload,omitempty"`
+	ToParticipant bool   `yaml:"to_participant,omitempty"`
+	// start_egress, room composite recording uploaded using the storage configured on the egress service
+	Filepath string `yaml:"filepath,omitempty"`
+	Layout   string `yaml:"layout,omitempty"`
+	// webhook, name of the event sent, room_rule_triggered when not set
+	WebhookEvent string `yaml:"webhook_event,omitempty"`
+}
+
+type RoomPasscodeConfig struct {
+	// failed attempts in a row after which an identity is locked out of the room
+	MaxFailures     int           `yaml:"max_failures,omitempty"`
+	LockoutDuration time.Duration `yaml:"lockout_duration,omitempty"`
+}
+
+// RoomTemplate overrides the default room configuration for rooms created from it,
+// settings passed in the CreateRoom request take precedence
+type RoomTemplate struct {
+	EnabledCodecs   []CodecSpec        `yaml:"enabled_codecs,omitempty"`
+	MaxParticipants uint32             `yaml:"max_participants,omitempty"`
+	EmptyTimeout    uint32             `yaml:"empty_timeout,omitempty"`
+	Egress          RoomTemplateEgress `yaml:"egress,omitempty"`
+	// evaluated in addition to the rules of the room config
+	Rules []RoomRuleConfig `yaml:"rules,omitempty"`
+}
+
+// RoomTemplateEgress holds egress started for rooms created from a template, outputs are uploaded
+// using the storage configured on the egress service
+type RoomTemplateEgress struct {
+	// room composite recording, started when the room is created
+	RoomFilepath string `yaml:"room_filepath,omitempty"`
+	RoomLayout   string `yaml:"room_layout,omitempty"`
+	// recording of every track published to the room
+	TrackFilepath string `yaml:"track_filepath,omitempty"`
 }
 
 type CodecSpec struct {
@@ -242,6 +829,22 @@ type CodecSpec struct {
 	FmtpLine string `yaml:"fmtp_line,omitempty"`
 }
 
+// CodecDeprecationConfig marks a codec as deprecated. Tracks published with it are counted and
+// reported with a track_codec_deprecated webhook, tracks published after Cutoff cannot use it
+type CodecDeprecationConfig struct {
+	Mime string `yaml:"mime,omitempty"`
+	// RFC 3339 timestamp, the codec is only reported when it is not set
+	Cutoff time.Time `yaml:"cutoff,omitempty"`
+	// send the publisher a notice on the lk.codec-deprecation data topic
+	Notify  bool   `yaml:"notify,omitempty"`
+	Message string `yaml:"message,omitempty"`
+}
+
+// IsCutOff returns true when new publishes of the codec are rejected at t
+func (c *CodecDeprecationConfig) IsCutOff(t time.Time) bool {
+	return !c.Cutoff.IsZero() && !t.Before(c.Cutoff)
+}
+
 type LoggingConfig struct {
 	logger.Config `yaml:",inline"`
 	PionLevel     string `yaml:"pion_level,omitempty"`
@@ -257,12 +860,80 @@ type TURNConfig struct {
 	RelayPortRangeStart uint16 `yaml:"relay_range_start,omitempty"`
 	RelayPortRangeEnd   uint16 `yaml:"relay_range_end,omitempty"`
 	ExternalTLS         bool   `yaml:"external_tls,omitempty"`
+	// ACME obtains and renews the certificate of Domain, instead of CertFile and KeyFile
+	ACME TURNACMEConfig `yaml:"acme,omitempty"`
+}
+
+type TURNACMEConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// contact for expiry and account notices of the certificate authority
+	Email string `yaml:"email,omitempty"`
+	// directory of the ACME server, Let's Encrypt production when not set
+	DirectoryURL string `yaml:"directory_url,omitempty"`
+	// certificates and the account key are stored in CacheDir, it should persist across restarts
+	// to stay within the rate limits of the certificate authority
+	CacheDir string `yaml:"cache_dir,omitempty"`
+	// HTTPPort answers http-01 challenges, it has to be reachable on port 80.
+	// When not set, tls-alpn-01 challenges are answered on the TLS port, which has to be reachable on port 443
+	HTTPPort int `yaml:"http_port,omitempty"`
 }
 
 type WebHookConfig struct {
 	URLs []string `yaml:"urls,omitempty"`
 	// key to use for webhook
 	APIKey string `yaml:"api_key,omitempty"`
+	// notified in addition to URLs, each with its own events and signing key
+	Endpoints []WebHookEndpointConfig `yaml:"endpoints,omitempty"`
+}
+
+type WebHookEndpointConfig struct {
+	URL string `yaml:"url,omitempty"`
+	// key to sign the events sent to the endpoint with, api_key when not set
+	APIKey string `yaml:"api_key,omitempty"`
+	// events sent to the endpoint, e.g. room_finished or track_published, all events when empty. Names have to be
+	// one of WebHookEvents or the webhook_event of a room rule
+	Events []string `yaml:"events,omitempty"`
+}
+
+// WebHookEvents are the events the server sends to webhooks, on top of the events named by room rules.
+// Kept in sync with the events defined in pkg/telemetry
+var WebHookEvents = []string{
+	webhook.EventRoomStarted,
+	webhook.EventRoomFinished,
+	webhook.EventParticipantJoined,
+	webhook.EventParticipantLeft,
+	webhook.EventTrackPublished,
+	webhook.EventTrackUnpublished,
+	webhook.EventEgressStarted,
+	webhook.EventEgressUpdated,
+	webhook.EventEgressEnded,
+	webhook.EventIngressStarted,
+	webhook.EventIngressEnded,
+	"participant_pending",
+	"participant_admitted",
+	"participant_join_restricted",
+	"participant_connection_quality_degraded",
+	"participant_connection_quality_recovered",
+	"room_activated",
+	"room_expired",
+	"room_merged",
+	"room_rule_triggered",
+	"track_codec_deprecated",
+	"track_payload_corrupted",
+	"track_payload_recovered",
+	"track_first_subscriber",
+	"track_no_subscribers",
+	"track_subscription_codec_regressed",
+	"negotiation_failed",
+	"ice_connection_failed",
+}
+
+// SigningKey returns the API key the events of the endpoint are signed with
+func (e *WebHookEndpointConfig) SigningKey(defaultKey string) string {
+	if e.APIKey != "" {
+		return e.APIKey
+	}
+	return defaultKey
 }
 
 type NodeSelectorConfig struct {
@@ -286,6 +957,11 @@ type RegionConfig struct {
 	Name string  `yaml:"name,omitempty"`
 	Lat  float64 `yaml:"lat,omitempty"`
 	Lon  float64 `yaml:"lon,omitempty"`
+	// signal URL of the region, participants redirected to a node of the region resume their sessions through it
+	URL string `yaml:"url,omitempty"`
+	// ISO 3166-1 alpha-2 codes of the countries served from the region, relay nodes are offered to clients of
+	// these countries, by the GeoIP database, from the region first
+	Countries []string `yaml:"countries,omitempty"`
 }
 
 type LimitConfig struct {
@@ -293,14 +969,432 @@ type LimitConfig struct {
 	BytesPerSec            float32 `yaml:"bytes_per_sec,omitempty"`
 	SubscriptionLimitVideo int32   `yaml:"subscription_limit_video,omitempty"`
 	SubscriptionLimitAudio int32   `yaml:"subscription_limit_audio,omitempty"`
+	// quotas of API keys, keyed by API key. Keys without an entry are not limited
+	KeyQuotas map[string]KeyQuotaConfig `yaml:"key_quotas,omitempty"`
+	// networks participants join from, keyed by the API key that signed their token. Keys without an entry are
+	// not restricted
+	KeyJoinRestrictions map[string]JoinRestrictionConfig `yaml:"key_join_restrictions,omitempty"`
+}
+
+// KeyQuotaConfig limits the usage of rooms owned by an API key, the key that created them. 0 means no limit
+type KeyQuotaConfig struct {
+	// rooms open at the same time
+	MaxRooms int32 `yaml:"max_rooms,omitempty"`
+	// participants in all rooms at the same time
+	MaxParticipants int32 `yaml:"max_participants,omitempty"`
+	// egress minutes per calendar month, in UTC. Egress running when the quota is exhausted is not stopped
+	EgressMinutes int64 `yaml:"egress_minutes,omitempty"`
+}
+
+// JoinRestrictionConfig restricts the networks participants join from, by the country or the CIDR range of their IP.
+// Denied countries and ranges take precedence. Once anything is allowed, participants have to join from an allowed
+// country or range, and participants whose country is not known are rejected unless their range is allowed
+type JoinRestrictionConfig struct {
+	// ISO 3166-1 alpha-2 codes, e.g. US
+	AllowCountries []string `yaml:"allow_countries,omitempty" json:"allow_countries,omitempty"`
+	DenyCountries  []string `yaml:"deny_countries,omitempty" json:"deny_countries,omitempty"`
+	// e.g. 203.0.113.0/24 or 2001:db8::/32
+	AllowCIDRs []string `yaml:"allow_cidrs,omitempty" json:"allow_cidrs,omitempty"`
+	DenyCIDRs  []string `yaml:"deny_cidrs,omitempty" json:"deny_cidrs,omitempty"`
+}
+
+// IsEnabled returns true when joins are restricted
+func (c *JoinRestrictionConfig) IsEnabled() bool {
+	return c != nil && (len(c.AllowCountries) != 0 || len(c.DenyCountries) != 0 || len(c.AllowCIDRs) != 0 || len(c.DenyCIDRs) != 0)
+}
+
+func (c *JoinRestrictionConfig) Clone() *JoinRestrictionConfig {
+	if c == nil {
+		return nil
+	}
+
+	return &JoinRestrictionConfig{
+		AllowCountries: slices.Clone(c.AllowCountries),
+		DenyCountries:  slices.Clone(c.DenyCountries),
+		AllowCIDRs:     slices.Clone(c.AllowCIDRs),
+		DenyCIDRs:      slices.Clone(c.DenyCIDRs),
+	}
+}
+
+// Validate returns an error for countries that are not two letter codes and for ranges that cannot be parsed
+func (c *JoinRestrictionConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for _, country := range append(slices.Clone(c.AllowCountries), c.DenyCountries...) {
+		if len(country) != 2 || !isLetter(country[0]) || !isLetter(country[1]) {
+			return fmt.Errorf("country %q of join restriction must be an ISO 3166-1 alpha-2 code", country)
+		}
+	}
+	for _, cidr := range append(slices.Clone(c.AllowCIDRs), c.DenyCIDRs...) {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid CIDR range %q in join restriction: %v", cidr, err)
+		}
+	}
+	return nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// GeoIPConfig is the database countries of client IPs are looked up in
+type GeoIPConfig struct {
+	// CSV file of network,country rows, e.g. 203.0.113.0/24,AU, loaded at startup. Restrictions by country cannot
+	// be evaluated without it, unless another GeoIP provider has been wired in
+	Database string `yaml:"database,omitempty"`
+}
+
+// OIDCConfig lets clients use access tokens issued by external identity providers, verified with the public
+// keys the provider publishes, in addition to tokens signed with API keys
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig `yaml:"providers,omitempty"`
+}
+
+type OIDCProviderConfig struct {
+	// issuer of tokens, the iss claim. Its keys are discovered from <issuer>/.well-known/openid-configuration
+	Issuer string `yaml:"issuer,omitempty"`
+	// JWKS of the provider, skips discovery when set
+	JWKSURL string `yaml:"jwks_url,omitempty"`
+	// tokens have to be issued for one of the audiences, the aud claim. Not checked when empty
+	Audiences []string `yaml:"audiences,omitempty"`
+	// API key requests with tokens of the provider are made as, for key quotas and telemetry. Required
+	APIKey string `yaml:"api_key,omitempty"`
+	// how often keys are fetched again, keys are also fetched for tokens signed with an unknown key
+	RefreshInterval time.Duration    `yaml:"refresh_interval,omitempty"`
+	ClaimMappings   OIDCClaimMapping `yaml:"claim_mappings,omitempty"`
+}
+
+// OIDCClaimMapping maps the claims of identity provider tokens to grants. Claims are named by their path,
+// nested claims are separated with dots, e.g. realm_access.roles
+type OIDCClaimMapping struct {
+	// identity of the participant, sub when empty
+	Identity string `yaml:"identity,omitempty"`
+	// name of the participant, name when empty
+	Name     string `yaml:"name,omitempty"`
+	Metadata string `yaml:"metadata,omitempty"`
+	// video grants in the format of tokens signed with API keys, e.g. {"roomJoin": true, "room": "myroom"}
+	Video string `yaml:"video,omitempty"`
+	// room the grants are for, replaces the room of mapped video grants
+	Room string `yaml:"room,omitempty"`
+	// role, or list of roles, of the user
+	Roles string `yaml:"roles,omitempty"`
+	// video grants of roles, in the format of tokens signed with API keys. Grants of the roles of a user are
+	// combined, and video grants of the token are applied on top
+	RoleGrants map[string]map[string]any `yaml:"role_grants,omitempty"`
+}
+
+// NATSConfig coordinates the nodes through NATS instead of redis. Signal and RPC messages go through NATS, nodes
+// and rooms are kept in JetStream key-value buckets, which requires JetStream to be enabled on the servers.
+// Egress, ingress and SIP state is only kept in redis.
+type NATSConfig struct {
+	URLs      []string `yaml:"urls,omitempty"`
+	Username  string   `yaml:"username,omitempty"`
+	Password  string   `yaml:"password,omitempty"`
+	Token     string   `yaml:"token,omitempty"`
+	CredsFile string   `yaml:"creds_file,omitempty"`
+	// prefix of the names of the key-value buckets
+	BucketPrefix string `yaml:"bucket_prefix,omitempty"`
+	// replicas of each bucket within the JetStream cluster
+	Replicas int `yaml:"replicas,omitempty"`
+}
+
+func (c *NATSConfig) IsConfigured() bool {
+	return len(c.URLs) > 0
+}
+
+// ClusterTLSConfig requires mutual TLS on the links between the nodes of a cluster. Nodes reach each other through
+// redis or NATS, signal relay and RPC included, those connections present the certificate of the node and verify
+// the certificate of the server. The servers have to require client certificates from the same CA for both sides
+// of the link to be authenticated. It takes precedence over the TLS config of redis
+type ClusterTLSConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// certificate and key presented by the node
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+	// CA certificates the certificates of servers are verified against
+	CACertFile string `yaml:"ca_cert_file,omitempty"`
+	// name verified in the certificates of servers, the host of the address when empty. Not used with peer_spiffe_ids
+	ServerName string `yaml:"server_name,omitempty"`
+	// SPIFFE ID the certificate of the node must carry, e.g. spiffe://example.org/livekit, checked at startup
+	SPIFFEID string `yaml:"spiffe_id,omitempty"`
+	// SPIFFE IDs accepted in the certificates of servers, in place of their names
+	PeerSPIFFEIDs []string `yaml:"peer_spiffe_ids,omitempty"`
+}
+
+// AttachmentsConfig enables room attachments, small files shared with the participants of a room. Files are
+// uploaded to and downloaded from object storage directly, with URLs signed by the server
+type AttachmentsConfig struct {
+	// S3 or S3 compatible bucket attachments are stored in, attachments are disabled without one.
+	// Objects are not removed when rooms end, a lifecycle rule on the bucket should expire them
+	S3 *S3StorageConfig `yaml:"s3,omitempty"`
+	// max size of an attachment in bytes
+	MaxSize int64 `yaml:"max_size,omitempty"`
+	// max number of attachments in a room
+	MaxPerRoom int `yaml:"max_per_room,omitempty"`
+	// content types that can be shared, e.g. application/pdf or image/*. Any content type when empty
+	ContentTypes []string `yaml:"content_types,omitempty"`
+	// how long upload and download URLs are valid for
+	URLExpiry time.Duration `yaml:"url_expiry,omitempty"`
+}
+
+// PacketCaptureConfig enables captures of the RTP and RTCP packets of published tracks started with
+// RoomService.StartPacketCapture, for debugging codec and header extension issues
+type PacketCaptureConfig struct {
+	// directory captures are written to, captures are disabled when empty
+	Directory string `yaml:"directory,omitempty"`
+	// bucket captures are uploaded to once they stop, they are removed from the directory after the upload
+	S3 *S3StorageConfig `yaml:"s3,omitempty"`
+	// captures stop after this long, or earlier when requested
+	MaxDuration time.Duration `yaml:"max_duration,omitempty"`
+	// captures stop once this many bytes of packets are written
+	MaxBytes int64 `yaml:"max_bytes,omitempty"`
+	// max number of captures running on a node at the same time
+	MaxActive int `yaml:"max_active,omitempty"`
+}
+
+// TranscriptionConfig forks the microphone audio published in rooms to a speech to text service, through a
+// webhook or a gRPC stream, and publishes the transcripts in the room as data messages attributed to the speaker.
+//
+// The audio is not decoded by the server. Services receive the Opus packets of the publisher as they are, one
+// RTP payload per frame at the 48kHz Opus clock, and decode them. Silence skipped by DTX shows as gaps between
+// frame offsets rather than as frames. Tracks of other codecs are not transcribed.
+type TranscriptionConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// URL segments of audio are posted to, the response carries the transcripts of the segment
+	WebhookURL string `yaml:"webhook_url,omitempty"`
+	// host:port of a gRPC service streaming audio in and transcripts out, used instead of the webhook when set
+	GRPCAddress string `yaml:"grpc_address,omitempty"`
+	// connect to the gRPC service without TLS
+	GRPCInsecure bool `yaml:"grpc_insecure,omitempty"`
+	// sent as a bearer token to the service
+	AuthToken string `yaml:"auth_token,omitempty"`
+	// BCP 47 language of the speakers, detected by the service when empty
+	Language string `yaml:"language,omitempty"`
+	// duration of the audio in each webhook request
+	SegmentDuration time.Duration `yaml:"segment_duration,omitempty"`
+	// number of audio frames buffered for a track, frames are dropped when the service falls behind
+	QueueSize int `yaml:"queue_size,omitempty"`
+}
+
+// AudioProcessingConfig processes the microphone audio published in rooms that enable it in their options, e.g. with
+// noise suppression, before it is forwarded to subscribers. The processor is compiled into the server, or a gRPC
+// service the opus packets of each track are streamed to and returned processed
+type AudioProcessingConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// host:port of the gRPC service, the processor compiled into the server is used when empty
+	GRPCAddress string `yaml:"grpc_address,omitempty"`
+	// connect to the gRPC service without TLS
+	GRPCInsecure bool `yaml:"grpc_insecure,omitempty"`
+	// sent as a bearer token to the gRPC service
+	AuthToken string `yaml:"auth_token,omitempty"`
+	// how long a packet waits for the gRPC service, it is forwarded unprocessed after
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// ParticipantValidationConfig are the rules identities and names in the tokens of joining participants must follow.
+// Identities and names are always rejected when they are not valid UTF-8 or contain control characters. The identity
+// rules do not apply to participants the server creates, such as egress, ingress, SIP and agent participants.
+type ParticipantValidationConfig struct {
+	Identity ParticipantFieldValidationConfig `yaml:"identity,omitempty"`
+	Name     ParticipantFieldValidationConfig `yaml:"name,omitempty"`
+}
+
+type ParticipantFieldValidationConfig struct {
+	// applied before the other rules, the participant joins with the normalized value. Not normalized when empty
+	Normalization UnicodeNormalization `yaml:"normalization,omitempty"`
+	// bounds of the length in characters, no bound when 0
+	MinLength int `yaml:"min_length,omitempty"`
+	MaxLength int `yaml:"max_length,omitempty"`
+	// regular expression the value must match, e.g. ^[a-zA-Z0-9_-]+$
+	Pattern string `yaml:"pattern,omitempty"`
+	// prefixes kept for participants the server creates, e.g. EG_ for egress
+	ReservedPrefixes []string `yaml:"reserved_prefixes,omitempty"`
+}
+
+// JoinAuthorizationConfig asks a service whether a participant may join before it is admitted. The service can reject
+// the join, change the permission of the participant or set its metadata. Joins wait for it, reconnects do not.
+type JoinAuthorizationConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// URL joins are posted to
+	URL string `yaml:"url,omitempty"`
+	// sent as a bearer token to the service
+	AuthToken string `yaml:"auth_token,omitempty"`
+	// how long a join waits for the service, keep it short as the client is waiting to connect
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// fail_closed or fail_open, what happens to joins when the service fails or times out
+	FailurePolicy JoinAuthorizationPolicy `yaml:"failure_policy,omitempty"`
+}
+
+// TelemetryExportConfig exports per-track quality stats and session events in batches for long-term analytics.
+// Rows are buffered and dropped when the destination falls behind, the media path never waits for it.
+type TelemetryExportConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// clickhouse or jsonl
+	Format TelemetryExportFormat `yaml:"format,omitempty"`
+	// URL of the ClickHouse HTTP interface, or the URL JSON lines are posted to
+	URL string `yaml:"url,omitempty"`
+	// sent as a bearer token with jsonl
+	AuthToken string `yaml:"auth_token,omitempty"`
+	// ClickHouse credentials and tables
+	Username    string `yaml:"username,omitempty"`
+	Password    string `yaml:"password,omitempty"`
+	Database    string `yaml:"database,omitempty"`
+	StatsTable  string `yaml:"stats_table,omitempty"`
+	EventsTable string `yaml:"events_table,omitempty"`
+	// a batch is sent once it has this many rows, or when the flush interval elapses
+	BatchSize     int           `yaml:"batch_size,omitempty"`
+	FlushInterval time.Duration `yaml:"flush_interval,omitempty"`
+	// rows waiting to be sent, new rows are dropped while it is full
+	QueueSize int `yaml:"queue_size,omitempty"`
+	// timeout of a single request, and how many times a failed batch is retried before it is dropped
+	Timeout    time.Duration `yaml:"timeout,omitempty"`
+	MaxRetries int           `yaml:"max_retries,omitempty"`
+}
+
+// PrivacyConfig hashes or omits the client IPs and participant identities in logs, analytics events and telemetry
+// exports. Room, participant and track SIDs are kept, records of a session can still be correlated through them.
+type PrivacyConfig struct {
+	// hash or omit, kept when empty. IPs are also scrubbed from the ICE candidates logged
+	ClientIPs PrivacyPolicy `yaml:"client_ips,omitempty"`
+	// hash or omit, kept when empty. It applies to the names of participants too
+	Identities PrivacyPolicy `yaml:"identities,omitempty"`
+	// mixed into the hashes so that they cannot be matched against the hashes of guessed values, it has to be the
+	// same on every node for the hashes of a value to match across nodes
+	HashSalt string `yaml:"hash_salt,omitempty"`
+}
+
+// IsEnabled returns true when some personal data is scrubbed
+func (c PrivacyConfig) IsEnabled() bool {
+	return c.ClientIPs != PrivacyPolicyKeep || c.Identities != PrivacyPolicyKeep
+}
+
+// AuditLogConfig records the administrative mutations of the room and egress APIs, with the API key that made them
+// and their arguments, to a sink. Records are queued without blocking the APIs and dropped while the queue is full.
+type AuditLogConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// file, syslog or http
+	Sink AuditLogSink `yaml:"sink,omitempty"`
+	// file records are appended to
+	Path string `yaml:"path,omitempty"`
+	// syslog server, e.g. udp and syslog.example.com:514, the local syslog when the address is empty
+	SyslogNetwork string `yaml:"syslog_network,omitempty"`
+	SyslogAddress string `yaml:"syslog_address,omitempty"`
+	// URL records are posted to, with the token as a bearer token when set
+	URL       string        `yaml:"url,omitempty"`
+	AuthToken string        `yaml:"auth_token,omitempty"`
+	Timeout   time.Duration `yaml:"timeout,omitempty"`
+	QueueSize int           `yaml:"queue_size,omitempty"`
+	// records of each room kept in redis, or in memory on a single node, for ListAuditRecords. The latest
+	// max_records_per_room are kept for the retention after the last record of the room
+	Retention         time.Duration `yaml:"retention,omitempty"`
+	MaxRecordsPerRoom int           `yaml:"max_records_per_room,omitempty"`
+}
+
+// AdminConfig serves pprof, expvar and runtime snapshots on a separate port, to tokens signed with an API key
+// that have the room admin and room list grants for all rooms
+type AdminConfig struct {
+	// admin handlers are not served when 0, the port cannot be changed without a restart
+	Port uint32 `yaml:"port,omitempty"`
+	// requests are rejected while disabled, can be toggled with a config reload
+	Enabled bool `yaml:"enabled,omitempty"`
+	// API keys whose tokens are accepted, all keys when empty
+	APIKeys []string `yaml:"api_keys,omitempty"`
+	// contention sampling while enabled, see runtime.SetMutexProfileFraction and runtime.SetBlockProfileRate
+	MutexProfileFraction int `yaml:"mutex_profile_fraction,omitempty"`
+	BlockProfileRate     int `yaml:"block_profile_rate,omitempty"`
+}
+
+// EventStreamConfig streams the room events of the cluster to backend services, as an alternative to webhooks.
+// Every node of the cluster has to enable it, events are published by the node they happen on
+type EventStreamConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// interval of keep-alives, so that proxies do not close idle streams, default 15s
+	KeepAliveInterval time.Duration `yaml:"keep_alive_interval,omitempty"`
+}
+
+// RelayNodesConfig gives participants the TURN servers of the relay nodes registered with the cluster
+type RelayNodesConfig struct {
+	// relay nodes given to a participant, those nearest to the region of the node first. 0 gives none
+	MaxPerParticipant int `yaml:"max_per_participant,omitempty"`
+	// TURN UDP port of relay nodes
+	UDPPort int `yaml:"udp_port,omitempty"`
+}
+
+// StartupConfig shortens the time until a node accepts sessions, for nodes started by autoscaling
+type StartupConfig struct {
+	// start the embedded TURN server and the redis egress worker in the background once the node serves requests.
+	// Clients can be given the TURN server a moment before it accepts allocations
+	LazyInit bool `yaml:"lazy_init,omitempty"`
+	// load the node is expected to take soon after starting, packet buffers are allocated for it at startup
+	ExpectedLoad ExpectedLoadConfig `yaml:"expected_load,omitempty"`
+}
+
+// DrainConfig controls how a node stops taking participants, on a termination signal or RoomService.DrainNode.
+// New participants of its rooms are sent to other nodes, a node that is the only one left keeps its rooms open.
+type DrainConfig struct {
+	// participants still on the node after this long are disconnected, they are waited for when 0
+	Deadline time.Duration `yaml:"deadline,omitempty"`
+	// move the rooms to other nodes, their participants are redirected there with resume tokens and keep their
+	// sessions
+	MigrateParticipants bool `yaml:"migrate_participants,omitempty"`
+	// time between moving two rooms, so that the nodes taking them over are not flooded with reconnects
+	MigrationInterval time.Duration `yaml:"migration_interval,omitempty"`
+}
+
+type ExpectedLoadConfig struct {
+	// received streams, a simulcast track has one stream per layer
+	AudioStreams int `yaml:"audio_streams,omitempty"`
+	VideoStreams int `yaml:"video_streams,omitempty"`
+}
+
+type S3StorageConfig struct {
+	AccessKey string `yaml:"access_key,omitempty"`
+	Secret    string `yaml:"secret,omitempty"`
+	Region    string `yaml:"region,omitempty"`
+	// endpoint of S3 compatible storage, AWS S3 in the region when empty
+	Endpoint string `yaml:"endpoint,omitempty"`
+	Bucket   string `yaml:"bucket,omitempty"`
+	// address the bucket in the path instead of the host name, required by most S3 compatible storage
+	ForcePathStyle bool `yaml:"force_path_style,omitempty"`
+	// prepended to the keys of objects
+	Prefix string `yaml:"prefix,omitempty"`
 }
 
 type IngressConfig struct {
-	RTMPBaseURL string `yaml:"rtmp_base_url,omitempty"`
-	WHIPBaseURL string `yaml:"whip_base_url,omitempty"`
+	RTMPBaseURL string            `yaml:"rtmp_base_url,omitempty"`
+	WHIPBaseURL string            `yaml:"whip_base_url,omitempty"`
+	RTSP        RTSPIngressConfig `yaml:"rtsp,omitempty"`
 }
 
-type SIPConfig struct{}
+// RTSPIngressConfig controls how URL ingresses pulling rtsp:// streams, IP cameras, are restarted by the server
+// when the ingress service loses the stream
+type RTSPIngressConfig struct {
+	DisableReconnect bool `yaml:"disable_reconnect,omitempty"`
+	// delay before the first restart, doubled on each failed attempt up to MaxReconnectInterval
+	ReconnectInterval    time.Duration `yaml:"reconnect_interval,omitempty"`
+	MaxReconnectInterval time.Duration `yaml:"max_reconnect_interval,omitempty"`
+	// restarts without the stream publishing in between before giving up, 0 for no limit
+	MaxReconnectAttempts int `yaml:"max_reconnect_attempts,omitempty"`
+}
+
+type SIPConfig struct {
+	// URL of this deployment the SIP service connects the participants of outbound calls to with a token from
+	// the server. The SIP service creates the tokens itself when empty
+	WsURL string `yaml:"ws_url,omitempty"`
+	// settings of the calls of trunks, by trunk ID
+	Trunks map[string]SIPTrunkConfig `yaml:"trunks,omitempty"`
+}
+
+type SIPTrunkConfig struct {
+	// prefix of the identities of the participants called through the trunk, when the request has no identity
+	IdentityPrefix string `yaml:"identity_prefix,omitempty"`
+	// metadata of the participants called through the trunk
+	Metadata string `yaml:"metadata,omitempty"`
+	// participants called through the trunk do not forward DTMF tones as data messages
+	DisableDTMF bool `yaml:"disable_dtmf,omitempty"`
+}
 
 type APIConfig struct {
 	// amount of time to wait for API to execute, default 2s
@@ -311,13 +1405,17 @@ type APIConfig struct {
 
 	// max amount of time to wait before checking for operation complete
 	MaxCheckInterval time.Duration `yaml:"max_check_interval,omitempty"`
+
+	// amount of time a cluster concurrency snapshot is served before the nodes are asked again, default 5s
+	ConcurrencyCacheTTL time.Duration `yaml:"concurrency_cache_ttl,omitempty"`
 }
 
 func DefaultAPIConfig() APIConfig {
 	return APIConfig{
-		ExecutionTimeout: 2 * time.Second,
-		CheckInterval:    100 * time.Millisecond,
-		MaxCheckInterval: 300 * time.Second,
+		ExecutionTimeout:    2 * time.Second,
+		CheckInterval:       100 * time.Millisecond,
+		MaxCheckInterval:    300 * time.Second,
+		ConcurrencyCacheTTL: 5 * time.Second,
 	}
 }
 
@@ -339,13 +1437,60 @@ var DefaultConfig = Config{
 			LowQuality:  500 * time.Millisecond,
 			MidQuality:  time.Second,
 			HighQuality: time.Second,
+			Track:       200 * time.Millisecond,
+		},
+		ConnectionQuality: ConnectionQualityConfig{
+			Scorer: ConnectionQualityScorerEModel,
+			Weights: ConnectionQualityWeights{
+				Loss:    1.0,
+				Jitter:  1.0,
+				RTT:     1.0,
+				Bitrate: 1.0,
+			},
+		},
+		TrackStatsHistory: TrackStatsHistoryConfig{
+			Retention:        10 * time.Minute,
+			SampleInterval:   10 * time.Second,
+			RetainAfterLeave: 30 * time.Minute,
+		},
+		PayloadIntegrity: PayloadIntegrityConfig{
+			PLIThreshold:  0.01,
+			FlagThreshold: 0.05,
+			Window:        2 * time.Second,
+		},
+		KeyFrameCache: KeyFrameCacheConfig{
+			MaxPackets: 300,
+		},
+		Forwarding: ForwardingConfig{
+			Policies: map[string]ForwardingPolicy{
+				"screen_share": ForwardingPolicyMaintainResolution,
+			},
+			MaintainResolutionPacketBufferSize: 1000,
 		},
+		FanOut: FanOutConfig{
+			Threshold: 20,
+			BatchSize: 16,
+			QueueSize: 64,
+		},
+		TranscodeFallback: TranscodeFallbackConfig{
+			MaxCodecsPerTrack: 1,
+		},
+		PotentialCodecTimeout: 30 * time.Second,
 		CongestionControl: CongestionControlConfig{
 			Enabled:                true,
 			AllowPause:             false,
 			NackRatioAttenuator:    0.4,
 			ExpectedUsageThreshold: 0.95,
 			ProbeMode:              CongestionControlProbeModePadding,
+			Trace: CongestionControlTraceConfig{
+				Size:           4096,
+				SampleInterval: 500 * time.Millisecond,
+			},
+			AudioOnly: CongestionControlAudioOnlyConfig{
+				Threshold:       100_000,
+				ResumeThreshold: 250_000,
+				ResumeAfter:     10 * time.Second,
+			},
 			ProbeConfig: CongestionControlProbeConfig{
 				BaseInterval:  3 * time.Second,
 				BackoffFactor: 1.5,
@@ -471,6 +1616,10 @@ var DefaultConfig = Config{
 		},
 	},
 	Redis: redisLiveKit.RedisConfig{},
+	NATS: NATSConfig{
+		BucketPrefix: "livekit",
+		Replicas:     1,
+	},
 	Room: RoomConfig{
 		AutoCreate: true,
 		EnabledCodecs: []CodecSpec{
@@ -482,12 +1631,22 @@ var DefaultConfig = Config{
 			{Mime: webrtc.MimeTypeAV1},
 		},
 		EmptyTimeout: 5 * 60,
+		Passcode: RoomPasscodeConfig{
+			MaxFailures:     5,
+			LockoutDuration: 5 * time.Minute,
+		},
 	},
 	Logging: LoggingConfig{
 		PionLevel: "error",
 	},
 	TURN: TURNConfig{
 		Enabled: false,
+		ACME: TURNACMEConfig{
+			CacheDir: "turn-certs",
+		},
+	},
+	RelayNodes: RelayNodesConfig{
+		UDPPort: 3478,
 	},
 	NodeSelector: NodeSelectorConfig{
 		Kind:         "any",
@@ -501,6 +1660,56 @@ var DefaultConfig = Config{
 		MaxRetryInterval: 4 * time.Second,
 		StreamBufferSize: 1000,
 	},
+	Attachments: AttachmentsConfig{
+		MaxSize:    25 << 20,
+		MaxPerRoom: 100,
+		URLExpiry:  15 * time.Minute,
+	},
+	PacketCapture: PacketCaptureConfig{
+		MaxDuration: time.Minute,
+		MaxBytes:    100 << 20,
+		MaxActive:   4,
+	},
+	Ingress: IngressConfig{
+		RTSP: RTSPIngressConfig{
+			ReconnectInterval:    2 * time.Second,
+			MaxReconnectInterval: time.Minute,
+		},
+	},
+	Transcription: TranscriptionConfig{
+		SegmentDuration: 2 * time.Second,
+		QueueSize:       500,
+	},
+	AudioProcessing: AudioProcessingConfig{
+		Timeout: 20 * time.Millisecond,
+	},
+	Drain: DrainConfig{
+		MigrationInterval: time.Second,
+	},
+	EventStream: EventStreamConfig{
+		KeepAliveInterval: 15 * time.Second,
+	},
+	JoinAuthorization: JoinAuthorizationConfig{
+		Timeout:       time.Second,
+		FailurePolicy: JoinAuthorizationFailClosed,
+	},
+	TelemetryExport: TelemetryExportConfig{
+		Format:        TelemetryExportFormatClickHouse,
+		Database:      "default",
+		StatsTable:    "track_stats",
+		EventsTable:   "session_events",
+		BatchSize:     1000,
+		FlushInterval: 5 * time.Second,
+		QueueSize:     10000,
+		Timeout:       10 * time.Second,
+		MaxRetries:    2,
+	},
+	AuditLog: AuditLogConfig{
+		Timeout:           5 * time.Second,
+		QueueSize:         1000,
+		Retention:         7 * 24 * time.Hour,
+		MaxRecordsPerRoom: 1000,
+	},
 	PSRPC: rpc.DefaultPSRPCConfig,
 	Keys:  map[string]string{},
 }
@@ -535,6 +1744,163 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 	if err := conf.RTC.Validate(conf.Development); err != nil {
 		return nil, fmt.Errorf("could not validate RTC config: %v", err)
 	}
+	if !conf.RTC.CongestionControl.SendSideBWEAlgorithm.IsValid() {
+		return nil, fmt.Errorf("unknown send side bandwidth estimator %q", conf.RTC.CongestionControl.SendSideBWEAlgorithm)
+	}
+	if err := conf.RTC.CongestionControl.AudioOnly.Validate(); err != nil {
+		return nil, err
+	}
+	for kind, policy := range conf.RTC.ICECandidatePolicies {
+		if !policy.IsValid() {
+			return nil, fmt.Errorf("unknown ICE candidate policy %q for %q participants", policy, kind)
+		}
+	}
+	if !conf.RTC.LossyData.DropPolicy.IsValid() {
+		return nil, fmt.Errorf("unknown lossy data drop policy %q", conf.RTC.LossyData.DropPolicy)
+	}
+	for source, policy := range conf.RTC.Forwarding.Policies {
+		if !policy.IsValid() {
+			return nil, fmt.Errorf("unknown forwarding policy %q for %s tracks", policy, source)
+		}
+		if _, ok := livekit.TrackSource_value[strings.ToUpper(source)]; !ok {
+			return nil, fmt.Errorf("unknown track source %q in forwarding policies", source)
+		}
+	}
+	if conf.RTC.Forwarding.MaintainResolutionPacketBufferSize < 0 {
+		return nil, errors.New("forwarding packet buffer size cannot be negative")
+	}
+	if conf.RTC.LossyData.QueueSize < 0 {
+		return nil, errors.New("lossy data queue size cannot be negative")
+	}
+	if !conf.RTC.ConnectionQuality.Scorer.IsValid() {
+		return nil, fmt.Errorf("unknown connection quality scorer %q", conf.RTC.ConnectionQuality.Scorer)
+	}
+	if alert := conf.RTC.ConnectionQuality.Alert; alert.Threshold != "" {
+		if _, ok := alert.ThresholdQuality(); !ok {
+			return nil, fmt.Errorf("connection quality alert threshold must be good, poor or lost, got %q", alert.Threshold)
+		}
+	}
+	if w := conf.RTC.ConnectionQuality.Weights; w.Loss < 0 || w.Jitter < 0 || w.RTT < 0 || w.Bitrate < 0 {
+		return nil, errors.New("connection quality weights cannot be negative")
+	}
+	if h := conf.RTC.TrackStatsHistory; h.Enabled && (h.Retention <= 0 || h.SampleInterval <= 0 || h.SampleInterval > h.Retention) {
+		return nil, errors.New("track stats history needs a retention longer than its sample interval")
+	}
+	if p := conf.RTC.PayloadIntegrity; p.Enabled && (p.PLIThreshold <= 0 || p.PLIThreshold > p.FlagThreshold || p.FlagThreshold > 1 || p.Window <= 0) {
+		return nil, errors.New("payload integrity thresholds must be within (0, 1] with the PLI threshold at most the flag threshold, over a window")
+	}
+	if err := conf.Video.Bitrates.validate(); err != nil {
+		return nil, err
+	}
+	if err := conf.RTC.Reorder.Audio.validate("audio", conf.RTC.PacketBufferSizeAudio); err != nil {
+		return nil, err
+	}
+	if err := conf.RTC.Reorder.Video.validate("video", conf.RTC.PacketBufferSizeVideo); err != nil {
+		return nil, err
+	}
+	if k := conf.RTC.KeyFrameCache; k.Enabled && k.MaxPackets <= 0 {
+		return nil, errors.New("keyframe cache needs a positive max_packets")
+	}
+	if conf.Drain.Deadline < 0 || conf.Drain.MigrationInterval < 0 {
+		return nil, errors.New("drain deadline and migration interval cannot be negative")
+	}
+	if err := conf.Room.SubscribeDefaults.Validate(); err != nil {
+		return nil, err
+	}
+	for _, limit := range conf.Room.DataTopicLimits {
+		if limit.Topic == "" || limit.Rate <= 0 || limit.Burst < 0 {
+			return nil, fmt.Errorf("data topic limit %q needs a topic and a positive rate", limit.Topic)
+		}
+	}
+	for _, replay := range conf.Room.DataReplay {
+		if replay.Topic == "" || replay.MaxMessages < 0 || replay.MaxAge < 0 || (replay.MaxMessages == 0 && replay.MaxAge == 0) {
+			return nil, fmt.Errorf("data replay %q needs a topic and a message count or age limit", replay.Topic)
+		}
+	}
+	if t := conf.Transcription; t.Enabled && ((t.WebhookURL == "" && t.GRPCAddress == "") || t.SegmentDuration <= 0 || t.QueueSize <= 0) {
+		return nil, errors.New("transcription needs a webhook URL or gRPC address, a segment duration and a queue size")
+	}
+	if r := conf.Ingress.RTSP; r.ReconnectInterval < 0 || r.MaxReconnectInterval < 0 || r.MaxReconnectAttempts < 0 {
+		return nil, errors.New("rtsp ingress reconnect intervals and attempts cannot be negative")
+	}
+	if conf.AudioProcessing.Enabled && conf.AudioProcessing.Timeout <= 0 {
+		return nil, errors.New("audio processing needs a timeout")
+	}
+	if conf.EventStream.Enabled && conf.EventStream.KeepAliveInterval <= 0 {
+		return nil, errors.New("event stream needs a keep-alive interval")
+	}
+	if err := conf.validateWebHookEndpoints(); err != nil {
+		return nil, err
+	}
+	if j := conf.JoinAuthorization; j.Enabled {
+		if j.URL == "" || j.Timeout <= 0 {
+			return nil, errors.New("join authorization needs a URL and a timeout")
+		}
+		if j.FailurePolicy != JoinAuthorizationFailClosed && j.FailurePolicy != JoinAuthorizationFailOpen {
+			return nil, fmt.Errorf("unknown join authorization failure policy %q", j.FailurePolicy)
+		}
+	}
+	if p := conf.Privacy; !p.ClientIPs.IsValid() || !p.Identities.IsValid() {
+		return nil, fmt.Errorf("privacy policies must be hash or omit, got %q and %q", p.ClientIPs, p.Identities)
+	}
+	if a := conf.AuditLog; a.Enabled {
+		switch {
+		case a.Sink == AuditLogSinkFile && a.Path == "":
+			return nil, errors.New("audit log file sink needs a path")
+		case a.Sink == AuditLogSinkHTTP && (a.URL == "" || a.Timeout <= 0):
+			return nil, errors.New("audit log http sink needs a URL and a timeout")
+		case a.Sink != AuditLogSinkFile && a.Sink != AuditLogSinkSyslog && a.Sink != AuditLogSinkHTTP:
+			return nil, fmt.Errorf("unknown audit log sink %q", a.Sink)
+		case a.QueueSize <= 0 || a.Retention < 0 || a.MaxRecordsPerRoom < 0:
+			return nil, errors.New("audit log needs a queue, retention and records per room cannot be negative")
+		}
+	}
+	for apiKey, restriction := range conf.Limit.KeyJoinRestrictions {
+		if err := restriction.Validate(); err != nil {
+			return nil, fmt.Errorf("join restriction of API key %s: %v", apiKey, err)
+		}
+	}
+	for _, cidr := range conf.TrustedProxies {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q in trusted proxies: %v", cidr, err)
+		}
+	}
+	if e := conf.TelemetryExport; e.Enabled {
+		if e.Format != TelemetryExportFormatClickHouse && e.Format != TelemetryExportFormatJSONL {
+			return nil, fmt.Errorf("unknown telemetry export format %q", e.Format)
+		}
+		if e.URL == "" {
+			return nil, errors.New("telemetry export needs a URL")
+		}
+		if e.BatchSize <= 0 || e.FlushInterval <= 0 || e.QueueSize < e.BatchSize || e.Timeout <= 0 {
+			return nil, errors.New("telemetry export needs a batch size, a flush interval, a timeout and a queue at least as large as a batch")
+		}
+	}
+	if err := conf.validateNodeRole(); err != nil {
+		return nil, err
+	}
+	if err := conf.validateRedis(); err != nil {
+		return nil, err
+	}
+	if conf.NATS.IsConfigured() && (conf.NATS.BucketPrefix == "" || conf.NATS.Replicas < 1) {
+		return nil, errors.New("nats needs a bucket prefix and at least one replica")
+	}
+	if c := conf.ClusterTLS; c.Enabled {
+		if c.CertFile == "" || c.KeyFile == "" || c.CACertFile == "" {
+			return nil, errors.New("cluster TLS needs a certificate, a key and a CA certificate")
+		}
+		for _, id := range append([]string{c.SPIFFEID}, c.PeerSPIFFEIDs...) {
+			if id != "" && !strings.HasPrefix(id, "spiffe://") {
+				return nil, fmt.Errorf("SPIFFE ID %q of cluster TLS must start with spiffe://", id)
+			}
+		}
+	}
+	if err := conf.ParticipantValidation.Identity.validate("identity"); err != nil {
+		return nil, err
+	}
+	if err := conf.ParticipantValidation.Name.validate("name"); err != nil {
+		return nil, err
+	}
 
 	// expand env vars in filenames
 	file, err := homedir.Expand(os.ExpandEnv(conf.KeyFile))
@@ -576,6 +1942,173 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 	return &conf, nil
 }
 
+func (a CongestionControlBWEAlgorithm) IsValid() bool {
+	switch a {
+	case "", CongestionControlBWEAlgorithmGCC, CongestionControlBWEAlgorithmNADA:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s ConnectionQualityScorer) IsValid() bool {
+	switch s {
+	case "", ConnectionQualityScorerEModel, ConnectionQualityScorerLinear:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p ICECandidatePolicy) IsValid() bool {
+	switch p {
+	case "", ICECandidatePolicyAll, ICECandidatePolicyRelay, ICECandidatePolicyHost:
+		return true
+	default:
+		return false
+	}
+}
+
+// AllowsCandidate returns true for candidates of the type, e.g. host or relay, the policy allows. Relay only applies
+// to remote candidates, the SFU does not gather relay candidates itself.
+func (p LossyDataDropPolicy) IsValid() bool {
+	switch p {
+	case "", LossyDataDropPolicyNewest, LossyDataDropPolicyOldest:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p PrivacyPolicy) IsValid() bool {
+	switch p {
+	case PrivacyPolicyKeep, PrivacyPolicyHash, PrivacyPolicyOmit:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p ForwardingPolicy) IsValid() bool {
+	switch p {
+	case "", ForwardingPolicyBalanced, ForwardingPolicyMaintainResolution:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p ICECandidatePolicy) AllowsCandidate(candidateType string, remote bool) bool {
+	switch p {
+	case ICECandidatePolicyRelay:
+		return !remote || candidateType == "relay"
+	case ICECandidatePolicyHost:
+		return candidateType == "host"
+	default:
+		return true
+	}
+}
+
+func (r NodeRole) IsValid() bool {
+	switch r {
+	case "", NodeRoleServer, NodeRoleRelay:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsRelayNode returns true when the node only relays media, without hosting rooms
+func (conf *Config) IsRelayNode() bool {
+	return conf.NodeRole == NodeRoleRelay
+}
+
+func (conf *Config) validateNodeRole() error {
+	if !conf.NodeRole.IsValid() {
+		return fmt.Errorf("unknown node role %q", conf.NodeRole)
+	}
+	if !conf.IsRelayNode() {
+		return nil
+	}
+	if !conf.TURN.Enabled {
+		return errors.New("relay nodes require TURN to be enabled")
+	}
+	if !conf.IsDistributed() {
+		return errors.New("relay nodes require redis or nats to register with the cluster")
+	}
+	return nil
+}
+
+// IsDistributed returns true when the node coordinates with others through redis or NATS
+// validateWebHookEndpoints rejects endpoints filtering for events that are never sent, a typo would otherwise
+// silently leave the endpoint without events
+func (conf *Config) validateWebHookEndpoints() error {
+	events := make(map[string]struct{}, len(WebHookEvents))
+	for _, event := range WebHookEvents {
+		events[event] = struct{}{}
+	}
+	addRuleEvents := func(rules []RoomRuleConfig) {
+		for _, rule := range rules {
+			if rule.Action.WebhookEvent != "" {
+				events[rule.Action.WebhookEvent] = struct{}{}
+			}
+		}
+	}
+	addRuleEvents(conf.Room.Rules)
+	for _, template := range conf.Room.Templates {
+		addRuleEvents(template.Rules)
+	}
+
+	for _, endpoint := range conf.WebHook.Endpoints {
+		if endpoint.URL == "" {
+			return errors.New("webhook endpoints need a URL")
+		}
+		for _, event := range endpoint.Events {
+			if _, ok := events[event]; !ok {
+				return fmt.Errorf("webhook endpoint %s filters for unknown event %s", endpoint.URL, event)
+			}
+		}
+	}
+	return nil
+}
+
+func (conf *Config) IsDistributed() bool {
+	return conf.Redis.IsConfigured() || conf.NATS.IsConfigured()
+}
+
+// validateRedis rejects settings mixing topologies, which would otherwise silently connect to one of them
+func (conf *Config) validateRedis() error {
+	r := &conf.Redis
+	if r.IsConfigured() && conf.NATS.IsConfigured() {
+		return errors.New("redis and nats cannot be configured together")
+	}
+	if len(r.SentinelAddresses) > 0 && len(r.ClusterAddresses) > 0 {
+		return errors.New("redis sentinel and cluster addresses cannot be set together")
+	}
+	if len(r.SentinelAddresses) > 0 && r.MasterName == "" {
+		return errors.New("redis sentinel requires sentinel_master_name")
+	}
+	if len(r.ClusterAddresses) > 0 && r.DB != 0 {
+		return errors.New("redis cluster only supports db 0")
+	}
+	return nil
+}
+
+func (c *ParticipantFieldValidationConfig) validate(field string) error {
+	switch c.Normalization {
+	case "", UnicodeNormalizationNFC, UnicodeNormalizationNFKC:
+	default:
+		return fmt.Errorf("unknown unicode normalization %q of participant %s", c.Normalization, field)
+	}
+	if c.MinLength < 0 || c.MaxLength < 0 || (c.MaxLength > 0 && c.MinLength > c.MaxLength) {
+		return fmt.Errorf("invalid length bounds of participant %s", field)
+	}
+	if _, err := regexp.Compile(c.Pattern); err != nil {
+		return errors.Wrapf(err, "invalid pattern of participant %s", field)
+	}
+	return nil
+}
+
 func (conf *Config) IsTURNSEnabled() bool {
 	if conf.TURN.Enabled && conf.TURN.TLSPort != 0 {
 		return true
@@ -827,6 +2360,9 @@ func (conf *Config) updateFromCLI(c *cli.Context, baseFlags []cli.Flag) error {
 	if c.IsSet("region") {
 		conf.Region = c.String("region")
 	}
+	if c.IsSet("node-role") {
+		conf.NodeRole = NodeRole(c.String("node-role"))
+	}
 	if c.IsSet("redis-host") {
 		conf.Redis.Address = c.String("redis-host")
 	}
@@ -17,9 +17,12 @@ package config
 import (
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli/v2"
+
+	"github.com/livekit/protocol/livekit"
 )
 
 func TestConfig_UnmarshalKeys(t *testing.T) {
@@ -30,6 +33,21 @@ func TestConfig_UnmarshalKeys(t *testing.T) {
 	require.Equal(t, "secret1", conf.Keys["key1"])
 }
 
+func TestConfig_FirstKey(t *testing.T) {
+	conf, err := NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	_, _, ok := conf.Reloadable().FirstKey()
+	require.False(t, ok)
+
+	rc := &ReloadableConfig{Keys: map[string]string{"key3": "secret3", "key1": "secret1", "key2": "secret2"}}
+	for i := 0; i < 10; i++ {
+		key, secret, ok := rc.FirstKey()
+		require.True(t, ok)
+		require.Equal(t, "key1", key)
+		require.Equal(t, "secret1", secret)
+	}
+}
+
 func TestConfig_DefaultsKept(t *testing.T) {
 	const content = `room:
   empty_timeout: 10`
@@ -39,6 +57,503 @@ func TestConfig_DefaultsKept(t *testing.T) {
 	require.Equal(t, uint32(10), conf.Room.EmptyTimeout)
 }
 
+func TestConfig_DeprecatedCodecs(t *testing.T) {
+	const content = `room:
+  deprecated_codecs:
+    - mime: video/vp8
+      cutoff: 2027-01-01T00:00:00Z
+      notify: true
+    - mime: video/h264`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, conf.Room.DeprecatedCodecs, 2)
+
+	cutoff := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	vp8 := conf.Room.DeprecatedCodecs[0]
+	require.True(t, vp8.Cutoff.Equal(cutoff))
+	require.True(t, vp8.Notify)
+	require.False(t, vp8.IsCutOff(cutoff.Add(-time.Second)))
+	require.True(t, vp8.IsCutOff(cutoff))
+	require.False(t, conf.Room.DeprecatedCodecs[1].IsCutOff(cutoff))
+}
+
+func TestConfig_ICECandidatePolicies(t *testing.T) {
+	const content = `rtc:
+  ice_candidate_policies:
+    privacy: relay
+    agent: host`
+	conf, err := NewConfig(content, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ICECandidatePolicyRelay, conf.RTC.ICECandidatePolicies["privacy"])
+	require.Equal(t, ICECandidatePolicyHost, conf.RTC.ICECandidatePolicies["agent"])
+
+	_, err = NewConfig(`rtc:
+  ice_candidate_policies:
+    privacy: srflx`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_NodeRole(t *testing.T) {
+	conf, err := NewConfig(`node_role: relay
+redis:
+  address: localhost:6379
+turn:
+  enabled: true
+  udp_port: 3478`, true, nil, nil)
+	require.NoError(t, err)
+	require.True(t, conf.IsRelayNode())
+
+	conf, err = NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	require.False(t, conf.IsRelayNode())
+
+	_, err = NewConfig(`node_role: edge`, true, nil, nil)
+	require.Error(t, err)
+
+	// relay nodes register with the cluster and relay through TURN
+	_, err = NewConfig(`node_role: relay
+turn:
+  enabled: true`, true, nil, nil)
+	require.Error(t, err)
+	_, err = NewConfig(`node_role: relay
+redis:
+  address: localhost:6379`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_ConnectionQuality(t *testing.T) {
+	// weights not set keep their defaults
+	conf, err := NewConfig(`rtc:
+  connection_quality:
+    scorer: linear
+    weights:
+      jitter: 0`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ConnectionQualityScorerLinear, conf.RTC.ConnectionQuality.Scorer)
+	require.Equal(t, ConnectionQualityWeights{Loss: 1, Jitter: 0, RTT: 1, Bitrate: 1}, conf.RTC.ConnectionQuality.Weights)
+
+	_, err = NewConfig(`rtc:
+  connection_quality:
+    scorer: mos`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`rtc:
+  connection_quality:
+    weights:
+      loss: -1`, true, nil, nil)
+	require.Error(t, err)
+
+	conf, err = NewConfig(`rtc:
+  connection_quality:
+    alert:
+      threshold: poor
+      duration: 1m`, true, nil, nil)
+	require.NoError(t, err)
+	threshold, ok := conf.RTC.ConnectionQuality.Alert.ThresholdQuality()
+	require.True(t, ok)
+	require.Equal(t, livekit.ConnectionQuality_POOR, threshold)
+
+	_, err = NewConfig(`rtc:
+  connection_quality:
+    alert:
+      threshold: excellent`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_TrackStatsHistory(t *testing.T) {
+	conf, err := NewConfig(`rtc:
+  track_stats_history:
+    enabled: true
+    retention: 30m`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, conf.RTC.TrackStatsHistory.Retention)
+	require.Equal(t, 10*time.Second, conf.RTC.TrackStatsHistory.SampleInterval)
+
+	_, err = NewConfig(`rtc:
+  track_stats_history:
+    enabled: true
+    retention: 5s`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_PayloadIntegrity(t *testing.T) {
+	conf, err := NewConfig(`rtc:
+  payload_integrity:
+    enabled: true
+    flag_threshold: 0.2`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0.01, conf.RTC.PayloadIntegrity.PLIThreshold)
+	require.Equal(t, 0.2, conf.RTC.PayloadIntegrity.FlagThreshold)
+	require.Equal(t, 2*time.Second, conf.RTC.PayloadIntegrity.Window)
+
+	_, err = NewConfig(`rtc:
+  payload_integrity:
+    enabled: true
+    pli_threshold: 0.5
+    flag_threshold: 0.2`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_VideoBitrates(t *testing.T) {
+	conf, err := NewConfig(`video:
+  bitrates:
+    codecs:
+      default:
+        high: { min: 800000, max: 2500000 }
+      av1:
+        high: { min: 500000 }
+    screen_share:
+      vp8:
+        high: { max: 3000000 }`, true, nil, nil)
+	require.NoError(t, err)
+
+	bitrates := conf.Video.Bitrates
+	require.Equal(t, BitrateRange{Min: 500_000}, bitrates.For("video/AV1", false).Range(livekit.VideoQuality_HIGH))
+	require.Equal(t, BitrateRange{Min: 800_000, Max: 2_500_000}, bitrates.For("video/vp9", false).Range(livekit.VideoQuality_HIGH))
+	require.Equal(t, BitrateRange{Max: 3_000_000}, bitrates.For("video/vp8", true).Range(livekit.VideoQuality_HIGH))
+	// screen share of codecs without screen share ranges
+	require.Equal(t, BitrateRange{Min: 500_000}, bitrates.For("video/av1", true).Range(livekit.VideoQuality_HIGH))
+	require.Equal(t, BitrateRange{}, bitrates.For("video/av1", false).Range(livekit.VideoQuality_LOW))
+	require.Nil(t, VideoBitrateConfig{}.For("video/vp8", false))
+
+	_, err = NewConfig(`video:
+  bitrates:
+    codecs:
+      vp8:
+        low: { min: 300000, max: 100000 }`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`video:
+  bitrates:
+    codecs:
+      theora:
+        low: { min: 100000 }`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_Forwarding(t *testing.T) {
+	conf, err := NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ForwardingPolicyMaintainResolution, conf.RTC.Forwarding.PolicyFor(livekit.TrackSource_SCREEN_SHARE))
+	require.Equal(t, ForwardingPolicyBalanced, conf.RTC.Forwarding.PolicyFor(livekit.TrackSource_CAMERA))
+
+	conf, err = NewConfig(`rtc:
+  forwarding:
+    policies:
+      camera: maintain_resolution`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ForwardingPolicyMaintainResolution, conf.RTC.Forwarding.PolicyFor(livekit.TrackSource_CAMERA))
+	require.Equal(t, ForwardingPolicyMaintainResolution, conf.RTC.Forwarding.PolicyFor(livekit.TrackSource_SCREEN_SHARE))
+
+	_, err = NewConfig(`rtc:
+  forwarding:
+    policies:
+      camera: sharpest`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`rtc:
+  forwarding:
+    policies:
+      webcam: balanced`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_Reorder(t *testing.T) {
+	conf, err := NewConfig(`rtc:
+  reorder:
+    video:
+      depth: 50
+      max_latency: 80ms`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ReorderWindowConfig{Depth: 50, MaxLatency: 80 * time.Millisecond}, conf.RTC.Reorder.Video)
+	require.Equal(t, ReorderWindowConfig{}, conf.RTC.Reorder.Audio)
+
+	_, err = NewConfig(`rtc:
+  reorder:
+    audio:
+      depth: 10`, true, nil, nil)
+	require.Error(t, err)
+
+	// held packets have to stay in the buffer kept for NACK until they are forwarded
+	_, err = NewConfig(`rtc:
+  packet_buffer_size_audio: 100
+  reorder:
+    audio:
+      depth: 100
+      max_latency: 40ms`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_Privacy(t *testing.T) {
+	conf, err := NewConfig(`privacy:
+  client_ips: omit
+  identities: hash`, true, nil, nil)
+	require.NoError(t, err)
+	require.True(t, conf.Privacy.IsEnabled())
+	require.Equal(t, PrivacyPolicyOmit, conf.Privacy.ClientIPs)
+	require.Equal(t, PrivacyPolicyHash, conf.Privacy.Identities)
+
+	_, err = NewConfig(`privacy:
+  identities: encrypt`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_AuditLog(t *testing.T) {
+	conf, err := NewConfig(`audit_log:
+  enabled: true
+  sink: file
+  path: /var/log/livekit/audit.log`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, AuditLogSinkFile, conf.AuditLog.Sink)
+	require.Equal(t, 1000, conf.AuditLog.MaxRecordsPerRoom)
+
+	_, err = NewConfig(`audit_log:
+  enabled: true
+  sink: http`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`audit_log:
+  enabled: true
+  sink: kafka`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_KeyJoinRestrictions(t *testing.T) {
+	conf, err := NewConfig(`limit:
+  key_join_restrictions:
+    key1:
+      allow_countries: [DE]
+      deny_cidrs: [192.0.2.0/24]`, true, nil, nil)
+	require.NoError(t, err)
+	restriction := conf.Limit.KeyJoinRestrictions["key1"]
+	require.True(t, restriction.IsEnabled())
+
+	_, err = NewConfig(`limit:
+  key_join_restrictions:
+    key1:
+      allow_countries: [Germany]`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`limit:
+  key_join_restrictions:
+    key1:
+      deny_cidrs: [192.0.2.0]`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_ClusterTLS(t *testing.T) {
+	_, err := NewConfig(`cluster_tls:
+  enabled: true
+  cert_file: node.crt
+  key_file: node.key
+  ca_cert_file: ca.crt
+  peer_spiffe_ids: [spiffe://example.org/redis]`, true, nil, nil)
+	require.NoError(t, err)
+
+	_, err = NewConfig(`cluster_tls:
+  enabled: true
+  cert_file: node.crt
+  key_file: node.key`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`cluster_tls:
+  enabled: true
+  cert_file: node.crt
+  key_file: node.key
+  ca_cert_file: ca.crt
+  spiffe_id: example.org/livekit`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_AudioOnly(t *testing.T) {
+	conf, err := NewConfig(`rtc:
+  congestion_control:
+    audio_only:
+      enabled: true`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(100_000), conf.RTC.CongestionControl.AudioOnly.Threshold)
+	require.Equal(t, int64(250_000), conf.RTC.CongestionControl.AudioOnly.ResumeThreshold)
+
+	// resuming at or below the threshold would flap
+	_, err = NewConfig(`rtc:
+  congestion_control:
+    audio_only:
+      enabled: true
+      threshold: 300000
+      resume_threshold: 300000`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_WebHookEndpoints(t *testing.T) {
+	conf, err := NewConfig(`webhook:
+  api_key: key
+  endpoints:
+    - url: https://billing.example.com
+      api_key: billing
+      events: [room_finished]`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "billing", conf.WebHook.Endpoints[0].SigningKey(conf.WebHook.APIKey))
+	require.Equal(t, []string{"room_finished"}, conf.Reloadable().WebHookEndpoints[0].Events)
+
+	_, err = NewConfig(`webhook:
+  endpoints:
+    - events: [room_finished]`, true, nil, nil)
+	require.Error(t, err)
+
+	// event names are checked, including those of room rules
+	_, err = NewConfig(`webhook:
+  endpoints:
+    - url: https://billing.example.com
+      events: [room_finshed]`, true, nil, nil)
+	require.Error(t, err)
+	_, err = NewConfig(`room:
+  rules:
+    - event: participant_joined
+      action: {type: webhook, webhook_event: vip_joined}
+webhook:
+  endpoints:
+    - url: https://billing.example.com
+      events: [vip_joined, track_first_subscriber]`, true, nil, nil)
+	require.NoError(t, err)
+}
+
+func TestConfig_JoinAuthorization(t *testing.T) {
+	conf, err := NewConfig(`join_authorization:
+  enabled: true
+  url: https://auth.example.com/join`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, time.Second, conf.JoinAuthorization.Timeout)
+	require.Equal(t, JoinAuthorizationFailClosed, conf.JoinAuthorization.FailurePolicy)
+
+	_, err = NewConfig(`join_authorization:
+  enabled: true`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`join_authorization:
+  enabled: true
+  url: https://auth.example.com/join
+  failure_policy: retry`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_TelemetryExport(t *testing.T) {
+	conf, err := NewConfig(`telemetry_export:
+  enabled: true
+  url: http://clickhouse:8123`, true, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, TelemetryExportFormatClickHouse, conf.TelemetryExport.Format)
+	require.Equal(t, "track_stats", conf.TelemetryExport.StatsTable)
+	require.Equal(t, 1000, conf.TelemetryExport.BatchSize)
+
+	_, err = NewConfig(`telemetry_export:
+  enabled: true
+  format: parquet
+  url: http://clickhouse:8123`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`telemetry_export:
+  enabled: true
+  url: http://clickhouse:8123
+  queue_size: 10`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_SubscribeDefaults(t *testing.T) {
+	conf, err := NewConfig(`room:
+  subscribe_defaults:
+    camera:
+      quality: low
+    screen_share:
+      quality: high
+    microphone:
+      disabled: false`, true, nil, nil)
+	require.NoError(t, err)
+
+	defaults := conf.Room.SubscribeDefaults
+	quality, ok := defaults.For(livekit.TrackSource_CAMERA).VideoQuality()
+	require.True(t, ok)
+	require.Equal(t, livekit.VideoQuality_LOW, quality)
+	_, ok = defaults.For(livekit.TrackSource_MICROPHONE).VideoQuality()
+	require.False(t, ok)
+	require.Nil(t, defaults.For(livekit.TrackSource_SCREEN_SHARE_AUDIO))
+
+	_, err = NewConfig(`room:
+  subscribe_defaults:
+    camera:
+      quality: off`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`room:
+  subscribe_defaults:
+    webcam:
+      quality: low`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_RedisTopology(t *testing.T) {
+	_, err := NewConfig(`redis:
+  sentinel_master_name: livekit
+  sentinel_addresses: [sentinel-0:26379, sentinel-1:26379]`, true, nil, nil)
+	require.NoError(t, err)
+
+	_, err = NewConfig(`redis:
+  sentinel_addresses: [sentinel-0:26379, sentinel-1:26379]`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`redis:
+  sentinel_master_name: livekit
+  sentinel_addresses: [sentinel-0:26379]
+  cluster_addresses: [redis-0:6379]`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`redis:
+  db: 1
+  cluster_addresses: [redis-0:6379]`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_NATS(t *testing.T) {
+	conf, err := NewConfig(`nats:
+  urls: [nats://nats-0:4222, nats://nats-1:4222]
+  creds_file: /etc/nats/livekit.creds`, true, nil, nil)
+	require.NoError(t, err)
+	require.True(t, conf.IsDistributed())
+	require.Equal(t, "livekit", conf.NATS.BucketPrefix)
+	require.Equal(t, 1, conf.NATS.Replicas)
+
+	_, err = NewConfig(`nats:
+  urls: [nats://nats-0:4222]
+redis:
+  address: redis:6379`, true, nil, nil)
+	require.Error(t, err)
+}
+
+func TestConfig_ParticipantValidation(t *testing.T) {
+	_, err := NewConfig(`participant_validation:
+  identity:
+    normalization: nfkc
+    max_length: 64
+    pattern: ^[a-z0-9_-]+$
+    reserved_prefixes: [EG_]`, true, nil, nil)
+	require.NoError(t, err)
+
+	_, err = NewConfig(`participant_validation:
+  identity:
+    pattern: "[a-z"`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`participant_validation:
+  name:
+    normalization: nfd`, true, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig(`participant_validation:
+  name:
+    min_length: 10
+    max_length: 5`, true, nil, nil)
+	require.Error(t, err)
+}
+
 func TestConfig_UnknownKeys(t *testing.T) {
 	const content = `unknown: 10
 room:
@@ -80,3 +595,118 @@ func TestGeneratedFlags(t *testing.T) {
 	require.NotNil(t, conf.RTC.ReconnectOnSubscriptionError)
 	require.False(t, *conf.RTC.ReconnectOnSubscriptionError)
 }
+
+func TestConfig_Reload(t *testing.T) {
+	conf, err := NewConfig(`room:
+  empty_timeout: 10
+webhook:
+  api_key: key
+  urls:
+    - https://a.example.com`, true, nil, nil)
+	require.NoError(t, err)
+
+	var reloaded *ReloadableConfig
+	conf.OnReload(func(rc *ReloadableConfig) {
+		reloaded = rc
+	})
+
+	t.Run("reloadable changes are applied", func(t *testing.T) {
+		next, err := NewConfig(`logging:
+  level: debug
+room:
+  empty_timeout: 20
+limit:
+  num_tracks: 5
+webhook:
+  api_key: key
+  urls:
+    - https://b.example.com`, true, nil, nil)
+		require.NoError(t, err)
+
+		changed, ignored, err := conf.Reload(next)
+		require.NoError(t, err)
+		require.False(t, ignored)
+		require.Equal(t, []string{"logging", "room", "limit", "webhook.urls"}, changed)
+
+		require.NotNil(t, reloaded)
+		require.Equal(t, reloaded, conf.Reloadable())
+		require.Equal(t, uint32(20), conf.Reloadable().Room.EmptyTimeout)
+		require.Equal(t, int32(5), conf.Reloadable().Limit.NumTracks)
+		require.Equal(t, []string{"https://b.example.com"}, conf.Reloadable().WebHookURLs)
+		require.Equal(t, "debug", conf.Logging.Level)
+
+		// startup values are kept in the fields
+		require.Equal(t, uint32(10), conf.Room.EmptyTimeout)
+	})
+
+	t.Run("other changes are ignored", func(t *testing.T) {
+		reloaded = nil
+		next, err := NewConfig(`port: 9999
+logging:
+  level: debug
+room:
+  empty_timeout: 20
+limit:
+  num_tracks: 5
+webhook:
+  api_key: key
+  urls:
+    - https://b.example.com`, true, nil, nil)
+		require.NoError(t, err)
+
+		changed, ignored, err := conf.Reload(next)
+		require.NoError(t, err)
+		require.True(t, ignored)
+		require.Empty(t, changed)
+		require.Nil(t, reloaded)
+		require.Equal(t, uint32(7880), conf.Port)
+	})
+
+	t.Run("api keys are reloaded", func(t *testing.T) {
+		next, err := NewConfig(`logging:
+  level: debug
+room:
+  empty_timeout: 20
+limit:
+  num_tracks: 5
+webhook:
+  api_key: key
+  urls:
+    - https://b.example.com
+keys:
+  key1: secret1`, true, nil, nil)
+		require.NoError(t, err)
+
+		changed, ignored, err := conf.Reload(next)
+		require.NoError(t, err)
+		require.False(t, ignored)
+		require.Equal(t, []string{"keys"}, changed)
+		require.Equal(t, map[string]string{"key1": "secret1"}, conf.Reloadable().Keys)
+	})
+
+	t.Run("admin is toggled without its port", func(t *testing.T) {
+		next, err := NewConfig(`logging:
+  level: debug
+room:
+  empty_timeout: 20
+limit:
+  num_tracks: 5
+webhook:
+  api_key: key
+  urls:
+    - https://b.example.com
+keys:
+  key1: secret1
+admin:
+  port: 6060
+  enabled: true`, true, nil, nil)
+		require.NoError(t, err)
+
+		changed, ignored, err := conf.Reload(next)
+		require.NoError(t, err)
+		require.True(t, ignored)
+		require.Equal(t, []string{"admin"}, changed)
+		require.True(t, conf.Reloadable().Admin.Enabled)
+		require.Zero(t, conf.Reloadable().Admin.Port)
+	})
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"sync"
+
+	"go.uber.org/atomic"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	redisLiveKit "github.com/livekit/protocol/redis"
+)
+
+// ReloadableConfig is the part of the config that can be changed while the node is running,
+// without restarting it or dropping sessions. Changes apply to rooms and participants created afterwards.
+type ReloadableConfig struct {
+	Room        RoomConfig
+	Limit       LimitConfig
+	TURNServers []TURNServer
+	WebHookURLs []string
+	// signing keys of the endpoints have to be in Keys
+	WebHookEndpoints []WebHookEndpointConfig
+	// API keys and secrets, from the key file when one is set
+	Keys map[string]string
+	// the admin port keeps the value the node was started with
+	Admin AdminConfig
+}
+
+// FirstKey returns the API key sorting first and its secret. Tokens the server issues itself are signed with it,
+// so every node with the same keys uses the same one.
+func (rc *ReloadableConfig) FirstKey() (key string, secret string, ok bool) {
+	if len(rc.Keys) == 0 {
+		return "", "", false
+	}
+	keys := maps.Keys(rc.Keys)
+	slices.Sort(keys)
+	return keys[0], rc.Keys[keys[0]], true
+}
+
+type ReloadObserver func(rc *ReloadableConfig)
+
+type reloadState struct {
+	current   atomic.Pointer[ReloadableConfig]
+	lock      sync.Mutex
+	observers []ReloadObserver
+}
+
+func (conf *Config) reloadableFromFields() *ReloadableConfig {
+	return &ReloadableConfig{
+		Room:        conf.Room,
+		Limit:       conf.Limit,
+		TURNServers: conf.RTC.TURNServers,
+		WebHookURLs: conf.WebHook.URLs,
+		Keys:        conf.Keys,
+		Admin:       conf.Admin,
+
+		WebHookEndpoints: conf.WebHook.Endpoints,
+	}
+}
+
+// Reloadable returns the current values of the settings that can be reloaded. The Room, Limit, RTC.TURNServers,
+// WebHook.URLs, WebHook.Endpoints, Keys and Admin fields keep the values the node was started with, components read them through Reloadable.
+// The returned config must not be modified.
+func (conf *Config) Reloadable() *ReloadableConfig {
+	if rc := conf.reload.current.Load(); rc != nil {
+		return rc
+	}
+	conf.reload.current.CompareAndSwap(nil, conf.reloadableFromFields())
+	return conf.reload.current.Load()
+}
+
+// OnReload registers an observer called with the new settings after every reload that changed them
+func (conf *Config) OnReload(observer ReloadObserver) {
+	conf.reload.lock.Lock()
+	defer conf.reload.lock.Unlock()
+	conf.reload.observers = append(conf.reload.observers, observer)
+}
+
+// Reload applies the reloadable settings of next, along with the log levels. It returns the sections that changed,
+// settings outside of those in next are ignored, ignored reports whether any of them differ from the running config.
+func (conf *Config) Reload(next *Config) (changed []string, ignored bool, err error) {
+	conf.reload.lock.Lock()
+	defer conf.reload.lock.Unlock()
+
+	// pion_level is folded into the component levels when the config is parsed
+	if conf.Logging.Level != next.Logging.Level ||
+		!reflect.DeepEqual(conf.Logging.ComponentLevels, next.Logging.ComponentLevels) {
+		if err := conf.Logging.Config.Update(&next.Logging.Config); err != nil {
+			return nil, false, err
+		}
+		changed = append(changed, "logging")
+	}
+
+	prev := conf.Reloadable()
+	rc := next.reloadableFromFields()
+	rc.Admin.Port = prev.Admin.Port
+	numChanged := len(changed)
+	if !reflect.DeepEqual(prev.Room, rc.Room) {
+		changed = append(changed, "room")
+	}
+	if !reflect.DeepEqual(prev.Limit, rc.Limit) {
+		changed = append(changed, "limit")
+	}
+	if !reflect.DeepEqual(prev.TURNServers, rc.TURNServers) {
+		changed = append(changed, "rtc.turn_servers")
+	}
+	if !reflect.DeepEqual(prev.WebHookURLs, rc.WebHookURLs) {
+		changed = append(changed, "webhook.urls")
+	}
+	if !reflect.DeepEqual(prev.WebHookEndpoints, rc.WebHookEndpoints) {
+		changed = append(changed, "webhook.endpoints")
+	}
+	if !reflect.DeepEqual(prev.Keys, rc.Keys) {
+		changed = append(changed, "keys")
+	}
+	if !reflect.DeepEqual(prev.Admin, rc.Admin) {
+		changed = append(changed, "admin")
+	}
+	ignored = conf.differsOutsideReloadable(next)
+
+	if len(changed) == numChanged {
+		return changed, ignored, nil
+	}
+	conf.reload.current.Store(rc)
+	for _, observer := range conf.reload.observers {
+		observer(rc)
+	}
+	return changed, ignored, nil
+}
+
+func (conf *Config) differsOutsideReloadable(next *Config) bool {
+	type fixed struct {
+		Port          uint32
+		BindAddresses []string
+		RTC           RTCConfig
+		Redis         redisLiveKit.RedisConfig
+		Audio         AudioConfig
+		Video         VideoConfig
+		TURN          TURNConfig
+		WebHookAPIKey string
+		NodeSelector  NodeSelectorConfig
+		Region        string
+		SignalRelay   SignalRelayConfig
+		Attachments   AttachmentsConfig
+		OIDC          OIDCConfig
+		AdminPort     uint32
+		Startup       StartupConfig
+		Development   bool
+	}
+	fixedOf := func(c *Config) fixed {
+		rtcConf := c.RTC
+		rtcConf.TURNServers = nil
+		return fixed{
+			Port:          c.Port,
+			BindAddresses: c.BindAddresses,
+			RTC:           rtcConf,
+			Redis:         c.Redis,
+			Audio:         c.Audio,
+			Video:         c.Video,
+			TURN:          c.TURN,
+			WebHookAPIKey: c.WebHook.APIKey,
+			NodeSelector:  c.NodeSelector,
+			Region:        c.Region,
+			SignalRelay:   c.SignalRelay,
+			Attachments:   c.Attachments,
+			OIDC:          c.OIDC,
+			AdminPort:     c.Admin.Port,
+			Startup:       c.Startup,
+			Development:   c.Development,
+		}
+	}
+	return !reflect.DeepEqual(fixedOf(conf), fixedOf(next))
+}
@@ -0,0 +1,230 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privacy
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/logger/zaputil"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// keys of logged values that hold participant identities or client IPs
+var (
+	identityLogKeys = map[string]bool{
+		"participant":      true,
+		"identity":         true,
+		"publisher":        true,
+		"subscriber":       true,
+		"speaker":          true,
+		"otherParticipant": true,
+	}
+	// the IPs within the values are scrubbed, e.g. the addresses of the candidates of an SDP
+	clientIPLogKeys = map[string]bool{
+		"candidate":       true,
+		"remoteCandidate": true,
+		"localCandidate":  true,
+		"pair":            true,
+		"clientIP":        true,
+		"remoteAddr":      true,
+		"sdp":             true,
+		"offer":           true,
+		"remoteSDP":       true,
+		"localSDP":        true,
+	}
+
+	ipToken = regexp.MustCompile(`[0-9A-Fa-f:.]{2,}`)
+)
+
+// InitLoggerFromConfig initializes the logger like config.InitLoggerFromConfig, the values it logs are scrubbed
+// when the privacy config asks for it. It sets the default scrubber too
+func InitLoggerFromConfig(conf *config.Config) {
+	s := NewScrubber(conf.Privacy)
+	SetDefault(s)
+	if s == nil {
+		config.InitLoggerFromConfig(&conf.Logging)
+		return
+	}
+
+	l, err := logger.NewZapLogger(&conf.Logging.Config)
+	if err != nil {
+		return
+	}
+	config.SetLogger(s.Logger(l))
+}
+
+// Logger returns a logger that scrubs the identities and client IPs among the values logged through it, by key.
+// Values logged under other keys, or within the messages, are not scrubbed
+func (s *Scrubber) Logger(l logger.Logger) logger.Logger {
+	if s == nil {
+		return l
+	}
+	// one frame for the scrubbing logger
+	return &scrubbingLogger{Logger: l.WithCallDepth(1), s: s}
+}
+
+type scrubbingLogger struct {
+	logger.Logger
+	s *Scrubber
+}
+
+func (l *scrubbingLogger) wrap(inner logger.Logger) logger.Logger {
+	return &scrubbingLogger{Logger: inner, s: l.s}
+}
+
+func (l *scrubbingLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.Logger.Debugw(msg, l.s.scrubLogValues(keysAndValues)...)
+}
+
+func (l *scrubbingLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.Logger.Infow(msg, l.s.scrubLogValues(keysAndValues)...)
+}
+
+func (l *scrubbingLogger) Warnw(msg string, err error, keysAndValues ...interface{}) {
+	l.Logger.Warnw(msg, err, l.s.scrubLogValues(keysAndValues)...)
+}
+
+func (l *scrubbingLogger) Errorw(msg string, err error, keysAndValues ...interface{}) {
+	l.Logger.Errorw(msg, err, l.s.scrubLogValues(keysAndValues)...)
+}
+
+func (l *scrubbingLogger) WithValues(keysAndValues ...interface{}) logger.Logger {
+	return l.wrap(l.Logger.WithValues(l.s.scrubLogValues(keysAndValues)...))
+}
+
+func (l *scrubbingLogger) WithName(name string) logger.Logger {
+	return l.wrap(l.Logger.WithName(name))
+}
+
+func (l *scrubbingLogger) WithComponent(component string) logger.Logger {
+	return l.wrap(l.Logger.WithComponent(component))
+}
+
+func (l *scrubbingLogger) WithCallDepth(depth int) logger.Logger {
+	return l.wrap(l.Logger.WithCallDepth(depth))
+}
+
+func (l *scrubbingLogger) WithItemSampler() logger.Logger {
+	return l.wrap(l.Logger.WithItemSampler())
+}
+
+func (l *scrubbingLogger) WithoutSampler() logger.Logger {
+	return l.wrap(l.Logger.WithoutSampler())
+}
+
+func (l *scrubbingLogger) WithDeferredValues() (logger.Logger, logger.DeferredFieldResolver) {
+	inner, resolve := l.Logger.WithDeferredValues()
+	return l.wrap(inner), func(args ...any) {
+		resolve(l.s.scrubLogValues(args)...)
+	}
+}
+
+func (l *scrubbingLogger) WithTap(we *zaputil.WriteEnabler) logger.Logger {
+	return l.wrap(l.Logger.WithTap(we))
+}
+
+// scrubLogValues returns the key value pairs with the values of identity and client IP keys scrubbed, the pairs
+// themselves when there is nothing to scrub
+func (s *Scrubber) scrubLogValues(keysAndValues []interface{}) []interface{} {
+	var scrubbed []interface{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			continue
+		}
+
+		var value interface{}
+		switch {
+		case identityLogKeys[key] && s.scrubsIdentities():
+			value, ok = s.scrubLogValue(keysAndValues[i+1], s.Identity)
+		case clientIPLogKeys[key] && s.scrubsClientIPs():
+			value, ok = s.scrubLogValue(keysAndValues[i+1], s.ClientIPsIn)
+		default:
+			ok = false
+		}
+		if !ok {
+			continue
+		}
+
+		if scrubbed == nil {
+			scrubbed = make([]interface{}, len(keysAndValues))
+			copy(scrubbed, keysAndValues)
+		}
+		scrubbed[i+1] = value
+	}
+	if scrubbed == nil {
+		return keysAndValues
+	}
+	return scrubbed
+}
+
+// scrubLogValue scrubs values that are strings or print as strings, other values like the booleans logged under
+// some of the keys are left alone
+func (s *Scrubber) scrubLogValue(value interface{}, scrub func(string) string) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		return scrub(v), true
+	case livekit.ParticipantIdentity:
+		return scrub(string(v)), true
+	case livekit.ParticipantName:
+		return scrub(string(v)), true
+	case []livekit.ParticipantIdentity:
+		identities := make([]string, 0, len(v))
+		for _, identity := range v {
+			identities = append(identities, scrub(string(identity)))
+		}
+		return identities, true
+	case fmt.Stringer:
+		return scrub(v.String()), true
+	case func() interface{}:
+		// lazily evaluated values stay lazy
+		return func() interface{} {
+			scrubbed, _ := s.scrubLogValue(v(), scrub)
+			return scrubbed
+		}, true
+	}
+	return value, false
+}
+
+// ClientIPsIn scrubs the IPs within text, like candidates, SDPs or host:port addresses. Omitted IPs are replaced
+// by a dash so that the text can still be read
+func (s *Scrubber) ClientIPsIn(text string) string {
+	if !s.scrubsClientIPs() {
+		return text
+	}
+	return ipToken.ReplaceAllStringFunc(text, func(token string) string {
+		ip := token
+		if net.ParseIP(ip) == nil {
+			// an IPv4 address with a port
+			host, _, err := net.SplitHostPort(token)
+			if err != nil || net.ParseIP(host) == nil {
+				return token
+			}
+			ip = host
+		}
+
+		scrubbed := s.ClientIP(ip)
+		if scrubbed == "" {
+			scrubbed = "-"
+		}
+		return strings.Replace(token, ip, scrubbed, 1)
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privacy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"go.uber.org/atomic"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// length of the hex encoded hashes, enough to tell values apart without making the records much longer
+const hashLength = 16
+
+var defaultScrubber atomic.Pointer[Scrubber]
+
+// SetDefault sets the scrubber of the package level functions, nil keeps personal data
+func SetDefault(s *Scrubber) {
+	defaultScrubber.Store(s)
+}
+
+// Default returns the scrubber set with SetDefault, nil when none is set
+func Default() *Scrubber {
+	return defaultScrubber.Load()
+}
+
+// Scrubber hashes or omits client IPs and participant identities according to the privacy config. A nil Scrubber
+// keeps them.
+type Scrubber struct {
+	conf config.PrivacyConfig
+}
+
+// NewScrubber returns nil when the config does not scrub anything
+func NewScrubber(conf config.PrivacyConfig) *Scrubber {
+	if !conf.IsEnabled() {
+		return nil
+	}
+	return &Scrubber{conf: conf}
+}
+
+func (s *Scrubber) scrubsIdentities() bool {
+	return s != nil && s.conf.Identities != config.PrivacyPolicyKeep
+}
+
+func (s *Scrubber) scrubsClientIPs() bool {
+	return s != nil && s.conf.ClientIPs != config.PrivacyPolicyKeep
+}
+
+// Identity scrubs a participant identity or name
+func (s *Scrubber) Identity(identity string) string {
+	if !s.scrubsIdentities() {
+		return identity
+	}
+	return s.apply(s.conf.Identities, identity)
+}
+
+// ClientIP scrubs a client IP
+func (s *Scrubber) ClientIP(ip string) string {
+	if !s.scrubsClientIPs() {
+		return ip
+	}
+	return s.apply(s.conf.ClientIPs, ip)
+}
+
+func (s *Scrubber) apply(policy config.PrivacyPolicy, value string) string {
+	if value == "" || policy == config.PrivacyPolicyOmit {
+		return ""
+	}
+
+	h := sha256.New()
+	h.Write([]byte(s.conf.HashSalt))
+	h.Write([]byte(value))
+	return hex.EncodeToString(h.Sum(nil))[:hashLength]
+}
+
+// ClientInfo returns a copy of the client info with its address scrubbed, the client info itself when it is kept
+func (s *Scrubber) ClientInfo(ci *livekit.ClientInfo) *livekit.ClientInfo {
+	if ci == nil || ci.Address == "" || !s.scrubsClientIPs() {
+		return ci
+	}
+
+	clone := proto.Clone(ci).(*livekit.ClientInfo)
+	clone.Address = s.ClientIP(ci.Address)
+	return clone
+}
+
+// ParticipantInfo returns a copy of the participant info with its identity and name scrubbed, the participant info
+// itself when they are kept
+func (s *Scrubber) ParticipantInfo(pi *livekit.ParticipantInfo) *livekit.ParticipantInfo {
+	if pi == nil || !s.scrubsIdentities() {
+		return pi
+	}
+
+	clone := proto.Clone(pi).(*livekit.ParticipantInfo)
+	clone.Identity = s.Identity(pi.Identity)
+	clone.Name = s.Identity(pi.Name)
+	return clone
+}
+
+// AnalyticsEvent scrubs the participants and the client info of an event before it leaves the node, the messages
+// it refers to are replaced by scrubbed copies
+func (s *Scrubber) AnalyticsEvent(event *livekit.AnalyticsEvent) {
+	if event == nil || s == nil {
+		return
+	}
+
+	event.Participant = s.ParticipantInfo(event.Participant)
+	event.Publisher = s.ParticipantInfo(event.Publisher)
+	event.ClientInfo = s.ClientInfo(event.ClientInfo)
+	if s.scrubsIdentities() && event.Egress.GetParticipant() != nil {
+		event.Egress = proto.Clone(event.Egress).(*livekit.EgressInfo)
+		event.Egress.GetParticipant().Identity = s.Identity(event.Egress.GetParticipant().Identity)
+	}
+	if s.scrubsIdentities() && event.Ingress.GetParticipantIdentity() != "" {
+		event.Ingress = proto.Clone(event.Ingress).(*livekit.IngressInfo)
+		event.Ingress.ParticipantIdentity = s.Identity(event.Ingress.ParticipantIdentity)
+		event.Ingress.ParticipantName = s.Identity(event.Ingress.ParticipantName)
+	}
+}
+
+// ClientInfo scrubs the address of the client info with the default scrubber, for client infos that are logged
+func ClientInfo(ci *livekit.ClientInfo) *livekit.ClientInfo {
+	return Default().ClientInfo(ci)
+}
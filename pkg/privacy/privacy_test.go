@@ -0,0 +1,130 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privacy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestScrubber(t *testing.T) {
+	t.Run("nothing is scrubbed by default", func(t *testing.T) {
+		s := NewScrubber(config.PrivacyConfig{})
+		require.Nil(t, s)
+		require.Equal(t, "alice", s.Identity("alice"))
+		require.Equal(t, "10.0.0.1", s.ClientIP("10.0.0.1"))
+
+		ci := &livekit.ClientInfo{Address: "10.0.0.1"}
+		require.Same(t, ci, s.ClientInfo(ci))
+	})
+
+	t.Run("hashes are stable and salted", func(t *testing.T) {
+		s := NewScrubber(config.PrivacyConfig{Identities: config.PrivacyPolicyHash, HashSalt: "salt"})
+		hashed := s.Identity("alice")
+		require.Len(t, hashed, hashLength)
+		require.NotEqual(t, "alice", hashed)
+		require.Equal(t, hashed, s.Identity("alice"))
+		require.NotEqual(t, hashed, s.Identity("bob"))
+
+		other := NewScrubber(config.PrivacyConfig{Identities: config.PrivacyPolicyHash, HashSalt: "pepper"})
+		require.NotEqual(t, hashed, other.Identity("alice"))
+
+		// client IPs are kept
+		require.Equal(t, "10.0.0.1", s.ClientIP("10.0.0.1"))
+	})
+
+	t.Run("omitted values are empty", func(t *testing.T) {
+		s := NewScrubber(config.PrivacyConfig{ClientIPs: config.PrivacyPolicyOmit})
+		require.Empty(t, s.ClientIP("10.0.0.1"))
+		require.Equal(t, "alice", s.Identity("alice"))
+	})
+
+	t.Run("analytics events are scrubbed on copies", func(t *testing.T) {
+		s := NewScrubber(config.PrivacyConfig{
+			ClientIPs:  config.PrivacyPolicyOmit,
+			Identities: config.PrivacyPolicyHash,
+		})
+		participant := &livekit.ParticipantInfo{Sid: "PA_alice", Identity: "alice", Name: "Alice"}
+		clientInfo := &livekit.ClientInfo{Address: "10.0.0.1", Os: "linux"}
+		event := &livekit.AnalyticsEvent{
+			Participant: participant,
+			ClientInfo:  clientInfo,
+			Ingress:     &livekit.IngressInfo{ParticipantIdentity: "bob", ParticipantName: "Bob"},
+		}
+		s.AnalyticsEvent(event)
+
+		require.Equal(t, "PA_alice", event.Participant.Sid)
+		require.Equal(t, s.Identity("alice"), event.Participant.Identity)
+		require.Equal(t, s.Identity("Alice"), event.Participant.Name)
+		require.Empty(t, event.ClientInfo.Address)
+		require.Equal(t, "linux", event.ClientInfo.Os)
+		require.Equal(t, s.Identity("bob"), event.Ingress.ParticipantIdentity)
+
+		// the messages the event referred to are left untouched
+		require.Equal(t, "alice", participant.Identity)
+		require.Equal(t, "10.0.0.1", clientInfo.Address)
+	})
+}
+
+func TestScrubLogValues(t *testing.T) {
+	s := NewScrubber(config.PrivacyConfig{
+		ClientIPs:  config.PrivacyPolicyHash,
+		Identities: config.PrivacyPolicyOmit,
+	})
+
+	values := s.scrubLogValues([]interface{}{
+		"participant", livekit.ParticipantIdentity("alice"),
+		"pID", livekit.ParticipantID("PA_alice"),
+		"candidate", "candidate:1 1 udp 2130706431 10.0.0.1 50000 typ host",
+		"remoteAddr", "10.0.0.2:7881",
+		"trackID", "TR_video",
+	})
+	require.Equal(t, "participant", values[0])
+	require.Equal(t, "", values[1])
+	require.Equal(t, livekit.ParticipantID("PA_alice"), values[3])
+	require.Equal(t, "candidate:1 1 udp 2130706431 "+s.ClientIP("10.0.0.1")+" 50000 typ host", values[5])
+	require.Equal(t, s.ClientIP("10.0.0.2")+":7881", values[7])
+	require.Equal(t, "TR_video", values[9])
+
+	t.Run("lazy values stay lazy", func(t *testing.T) {
+		values := s.scrubLogValues([]interface{}{
+			"candidate", func() interface{} { return "10.0.0.1" },
+		})
+		lazy, ok := values[1].(func() interface{})
+		require.True(t, ok)
+		require.Equal(t, s.ClientIP("10.0.0.1"), lazy())
+	})
+
+	t.Run("IPs in candidate pairs", func(t *testing.T) {
+		values := s.scrubLogValues([]interface{}{
+			"pair", "(local) udp host 10.0.0.1:7882 <-> (remote) udp srflx 203.0.113.1:50000",
+		})
+		require.Equal(t, "(local) udp host "+s.ClientIP("10.0.0.1")+":7882 <-> (remote) udp srflx "+s.ClientIP("203.0.113.1")+":50000", values[1])
+	})
+
+	t.Run("IPs in SDPs", func(t *testing.T) {
+		sdp := "c=IN IP6 2001:db8::1\r\na=fingerprint:sha-256 AB:CD:EF\r\na=mid:0\r\n"
+		require.Equal(
+			t,
+			"c=IN IP6 "+s.ClientIP("2001:db8::1")+"\r\na=fingerprint:sha-256 AB:CD:EF\r\na=mid:0\r\n",
+			s.ClientIPsIn(sdp),
+		)
+	})
+}
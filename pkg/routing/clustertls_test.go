@@ -0,0 +1,189 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cluster CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, spiffeID string, dnsName string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if spiffeID != "" {
+		uri, err := url.Parse(spiffeID)
+		require.NoError(t, err)
+		template.URIs = []*url.URL{uri}
+	}
+	if dnsName != "" {
+		template.DNSNames = []string{dnsName}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func writeKeyPair(t *testing.T, dir string, name string, cert tls.Certificate) (string, string) {
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+// handshake connects a client with clientConfig to a server requiring client certificates of ca
+func handshake(t *testing.T, ca *testCA, serverCert tls.Certificate, clientConfig *tls.Config) error {
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	serverDone := make(chan error, 1)
+	go func() {
+		server := tls.Server(serverConn, &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs,
+		})
+		err := server.Handshake()
+		_ = serverConn.Close()
+		serverDone <- err
+	}()
+
+	err := tls.Client(clientConn, clientConfig).Handshake()
+	_ = clientConn.Close()
+	if serverErr := <-serverDone; err == nil {
+		err = serverErr
+	}
+	return err
+}
+
+func TestClusterTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0600))
+
+	certFile, keyFile := writeKeyPair(t, dir, "node", ca.issue(t, 2, "spiffe://example.org/livekit", ""))
+	serverCert := ca.issue(t, 3, "spiffe://example.org/redis", "redis.example.org")
+
+	conf := config.ClusterTLSConfig{
+		Enabled:    true,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		CACertFile: caFile,
+		SPIFFEID:   "spiffe://example.org/livekit",
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		tlsConfig, err := routing.NewClusterTLSConfig(&config.ClusterTLSConfig{})
+		require.NoError(t, err)
+		require.Nil(t, tlsConfig)
+	})
+
+	t.Run("certificate of the node must carry its SPIFFE ID", func(t *testing.T) {
+		c := conf
+		c.SPIFFEID = "spiffe://example.org/other"
+		_, err := routing.NewClusterTLSConfig(&c)
+		require.Error(t, err)
+	})
+
+	t.Run("servers verified by name", func(t *testing.T) {
+		c := conf
+		c.ServerName = "redis.example.org"
+		tlsConfig, err := routing.NewClusterTLSConfig(&c)
+		require.NoError(t, err)
+		require.NoError(t, handshake(t, ca, serverCert, tlsConfig))
+
+		c.ServerName = "other.example.org"
+		tlsConfig, err = routing.NewClusterTLSConfig(&c)
+		require.NoError(t, err)
+		require.Error(t, handshake(t, ca, serverCert, tlsConfig))
+	})
+
+	t.Run("servers verified by SPIFFE ID", func(t *testing.T) {
+		c := conf
+		c.PeerSPIFFEIDs = []string{"spiffe://example.org/redis"}
+		tlsConfig, err := routing.NewClusterTLSConfig(&c)
+		require.NoError(t, err)
+		require.NoError(t, handshake(t, ca, serverCert, tlsConfig))
+
+		c.PeerSPIFFEIDs = []string{"spiffe://example.org/nats"}
+		tlsConfig, err = routing.NewClusterTLSConfig(&c)
+		require.NoError(t, err)
+		require.Error(t, handshake(t, ca, serverCert, tlsConfig))
+
+		// certificates of another CA
+		other := newTestCA(t)
+		c.PeerSPIFFEIDs = []string{"spiffe://example.org/redis"}
+		tlsConfig, err = routing.NewClusterTLSConfig(&c)
+		require.NoError(t, err)
+		require.Error(t, handshake(t, ca, other.issue(t, 4, "spiffe://example.org/redis", ""), tlsConfig))
+	})
+}
@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/redis/go-redis/v9"
 	"go.uber.org/atomic"
 	"google.golang.org/protobuf/proto"
 
@@ -40,68 +39,65 @@ const (
 	statsUpdateInterval   = 2 * time.Second
 	statsMaxDelaySeconds  = 30
 
-	// hash of node_id => Node proto
-	NodesKey = "nodes"
-
-	// hash of room_name => node_id
-	NodeRoomKey = "room_node_map"
+	// the node keepalive goes through pub/sub, when it stops arriving the subscriptions are assumed to be left
+	// on a failed redis node and the client reconnects
+	pubsubStallTimeout = 5 * statsUpdateInterval
 )
 
-var _ Router = (*RedisRouter)(nil)
+var _ Router = (*DistributedRouter)(nil)
 
-// RedisRouter uses Redis pub/sub to route signaling messages across different nodes
+// DistributedRouter routes signaling messages across different nodes through the message bus, nodes and the
+// rooms they host are shared in a NodeStore.
 // It relies on the RTC node to be the primary driver of the participant connection.
-// Because
-type RedisRouter struct {
+type DistributedRouter struct {
 	*LocalRouter
 
-	rc        redis.UniversalClient
+	store     NodeStore
 	kps       rpc.KeepalivePubSub
 	ctx       context.Context
 	isStarted atomic.Bool
 	nodeMu    sync.RWMutex
 	// previous stats for computing averages
 	prevStats *livekit.NodeStats
+	// unix nanos of the last keepalive received
+	lastPingAt atomic.Int64
 
 	cancel func()
 }
 
-func NewRedisRouter(lr *LocalRouter, rc redis.UniversalClient, kps rpc.KeepalivePubSub) *RedisRouter {
-	rr := &RedisRouter{
+func NewDistributedRouter(lr *LocalRouter, store NodeStore, kps rpc.KeepalivePubSub) *DistributedRouter {
+	rr := &DistributedRouter{
 		LocalRouter: lr,
-		rc:          rc,
+		store:       store,
 		kps:         kps,
 	}
 	rr.ctx, rr.cancel = context.WithCancel(context.Background())
 	return rr
 }
 
-func (r *RedisRouter) RegisterNode() error {
+func (r *DistributedRouter) RegisterNode() error {
 	r.nodeMu.RLock()
-	data, err := proto.Marshal((*livekit.Node)(r.currentNode))
+	node := proto.Clone((*livekit.Node)(r.currentNode)).(*livekit.Node)
 	r.nodeMu.RUnlock()
-	if err != nil {
-		return err
-	}
-	if err := r.rc.HSet(r.ctx, NodesKey, r.currentNode.Id, data).Err(); err != nil {
+	if err := r.store.StoreNode(r.ctx, node); err != nil {
 		return errors.Wrap(err, "could not register node")
 	}
 	return nil
 }
 
-func (r *RedisRouter) UnregisterNode() error {
+func (r *DistributedRouter) UnregisterNode() error {
 	// could be called after Stop(), so we'd want to use an unrelated context
-	return r.rc.HDel(context.Background(), NodesKey, r.currentNode.Id).Err()
+	return r.store.DeleteNode(context.Background(), livekit.NodeID(r.currentNode.Id))
 }
 
-func (r *RedisRouter) RemoveDeadNodes() error {
+func (r *DistributedRouter) RemoveDeadNodes() error {
 	nodes, err := r.ListNodes()
 	if err != nil {
 		return err
 	}
 	for _, n := range nodes {
 		if !selector.IsAvailable(n) {
-			if err := r.rc.HDel(context.Background(), NodesKey, n.Id).Err(); err != nil {
+			if err := r.store.DeleteNode(context.Background(), livekit.NodeID(n.Id)); err != nil {
 				return err
 			}
 		}
@@ -109,60 +105,42 @@ func (r *RedisRouter) RemoveDeadNodes() error {
 	return nil
 }
 
-func (r *RedisRouter) GetNodeForRoom(_ context.Context, roomName livekit.RoomName) (*livekit.Node, error) {
-	nodeID, err := r.rc.HGet(r.ctx, NodeRoomKey, string(roomName)).Result()
-	if err == redis.Nil {
+func (r *DistributedRouter) GetNodeForRoom(_ context.Context, roomName livekit.RoomName) (*livekit.Node, error) {
+	nodeID, err := r.store.LoadRoomNode(r.ctx, roomName)
+	if err == ErrNotFound {
 		return nil, ErrNotFound
 	} else if err != nil {
 		return nil, errors.Wrap(err, "could not get node for room")
 	}
 
-	return r.GetNode(livekit.NodeID(nodeID))
+	return r.GetNode(nodeID)
 }
 
-func (r *RedisRouter) SetNodeForRoom(_ context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error {
-	return r.rc.HSet(r.ctx, NodeRoomKey, string(roomName), string(nodeID)).Err()
+func (r *DistributedRouter) SetNodeForRoom(_ context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error {
+	return r.store.StoreRoomNode(r.ctx, roomName, nodeID)
 }
 
-func (r *RedisRouter) ClearRoomState(_ context.Context, roomName livekit.RoomName) error {
-	if err := r.rc.HDel(context.Background(), NodeRoomKey, string(roomName)).Err(); err != nil {
+func (r *DistributedRouter) ClearRoomState(_ context.Context, roomName livekit.RoomName) error {
+	if err := r.store.DeleteRoomNode(context.Background(), roomName); err != nil {
 		return errors.Wrap(err, "could not clear room state")
 	}
 	return nil
 }
 
-func (r *RedisRouter) GetNode(nodeID livekit.NodeID) (*livekit.Node, error) {
-	data, err := r.rc.HGet(r.ctx, NodesKey, string(nodeID)).Result()
-	if err == redis.Nil {
-		return nil, ErrNotFound
-	} else if err != nil {
-		return nil, err
-	}
-	n := livekit.Node{}
-	if err = proto.Unmarshal([]byte(data), &n); err != nil {
-		return nil, err
-	}
-	return &n, nil
+func (r *DistributedRouter) GetNode(nodeID livekit.NodeID) (*livekit.Node, error) {
+	return r.store.LoadNode(r.ctx, nodeID)
 }
 
-func (r *RedisRouter) ListNodes() ([]*livekit.Node, error) {
-	items, err := r.rc.HVals(r.ctx, NodesKey).Result()
+func (r *DistributedRouter) ListNodes() ([]*livekit.Node, error) {
+	nodes, err := r.store.ListNodes(r.ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not list nodes")
 	}
-	nodes := make([]*livekit.Node, 0, len(items))
-	for _, item := range items {
-		n := livekit.Node{}
-		if err := proto.Unmarshal([]byte(item), &n); err != nil {
-			return nil, err
-		}
-		nodes = append(nodes, &n)
-	}
 	return nodes, nil
 }
 
 // StartParticipantSignal signal connection sets up paths to the RTC node, and starts to route messages to that message queue
-func (r *RedisRouter) StartParticipantSignal(ctx context.Context, roomName livekit.RoomName, pi ParticipantInit) (res StartParticipantSignalResults, err error) {
+func (r *DistributedRouter) StartParticipantSignal(ctx context.Context, roomName livekit.RoomName, pi ParticipantInit) (res StartParticipantSignalResults, err error) {
 	// find the node where the room is hosted at
 	rtcNode, err := r.GetNodeForRoom(ctx, roomName)
 	if err != nil {
@@ -172,12 +150,13 @@ func (r *RedisRouter) StartParticipantSignal(ctx context.Context, roomName livek
 	return r.StartParticipantSignalWithNodeID(ctx, roomName, pi, livekit.NodeID(rtcNode.Id))
 }
 
-func (r *RedisRouter) Start() error {
+func (r *DistributedRouter) Start() error {
 	if r.isStarted.Swap(true) {
 		return nil
 	}
 
 	workerStarted := make(chan error)
+	r.lastPingAt.Store(time.Now().UnixNano())
 	go r.statsWorker()
 	go r.keepaliveWorker(workerStarted)
 
@@ -185,7 +164,7 @@ func (r *RedisRouter) Start() error {
 	return <-workerStarted
 }
 
-func (r *RedisRouter) Drain() {
+func (r *DistributedRouter) Drain() {
 	r.nodeMu.Lock()
 	r.currentNode.State = livekit.NodeState_SHUTTING_DOWN
 	r.nodeMu.Unlock()
@@ -194,23 +173,24 @@ func (r *RedisRouter) Drain() {
 	}
 }
 
-func (r *RedisRouter) Stop() {
+func (r *DistributedRouter) Stop() {
 	if !r.isStarted.Swap(false) {
 		return
 	}
-	logger.Debugw("stopping RedisRouter")
+	logger.Debugw("stopping DistributedRouter")
 	_ = r.UnregisterNode()
 	r.cancel()
 }
 
 // update node stats and cleanup
-func (r *RedisRouter) statsWorker() {
+func (r *DistributedRouter) statsWorker() {
 	goroutineDumped := false
 	for r.ctx.Err() == nil {
 		// update periodically
 		select {
 		case <-time.After(statsUpdateInterval):
 			r.kps.PublishPing(r.ctx, livekit.NodeID(r.currentNode.Id), &rpc.KeepalivePing{Timestamp: time.Now().Unix()})
+			r.checkPubSubStalled()
 
 			r.nodeMu.RLock()
 			stats := r.currentNode.Stats
@@ -235,7 +215,23 @@ func (r *RedisRouter) statsWorker() {
 	}
 }
 
-func (r *RedisRouter) keepaliveWorker(startedChan chan error) {
+func (r *DistributedRouter) checkPubSubStalled() {
+	since := time.Since(time.Unix(0, r.lastPingAt.Load()))
+	if since < pubsubStallTimeout {
+		return
+	}
+	rc, ok := r.store.(redisReconnector)
+	if !ok {
+		return
+	}
+
+	logger.Warnw("keepalive not received, reconnecting to redis", nil, "nodeID", r.currentNode.Id, "since", since)
+	// give the subscriptions another timeout to recover before reconnecting again
+	r.lastPingAt.Store(time.Now().UnixNano())
+	rc.reconnect(r.ctx)
+}
+
+func (r *DistributedRouter) keepaliveWorker(startedChan chan error) {
 	pings, err := r.kps.SubscribePing(r.ctx, livekit.NodeID(r.currentNode.Id))
 	if err != nil {
 		startedChan <- err
@@ -244,9 +240,11 @@ func (r *RedisRouter) keepaliveWorker(startedChan chan error) {
 	close(startedChan)
 
 	for ping := range pings.Channel() {
+		r.lastPingAt.Store(time.Now().UnixNano())
 		if time.Since(time.Unix(ping.Timestamp, 0)) > statsUpdateInterval {
+			// delivered late, e.g. while resubscribing after a failover, the next one updates the stats
 			logger.Infow("keep alive too old, skipping", "timestamp", ping.Timestamp)
-			break
+			continue
 		}
 
 		r.nodeMu.Lock()
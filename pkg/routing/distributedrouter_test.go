@@ -0,0 +1,146 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+func TestDistributedRouter(t *testing.T) {
+	store := newMemoryNodeStore()
+	node := &livekit.Node{
+		Id:    "ND_node",
+		State: livekit.NodeState_SERVING,
+		Stats: &livekit.NodeStats{UpdatedAt: time.Now().Unix()},
+	}
+	r := routing.NewDistributedRouter(routing.NewLocalRouter(node, nil), store, nil)
+
+	require.NoError(t, r.RegisterNode())
+	nodes, err := r.ListNodes()
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	require.Equal(t, "ND_node", nodes[0].Id)
+
+	_, err = r.GetNodeForRoom(context.Background(), "room")
+	require.ErrorIs(t, err, routing.ErrNotFound)
+
+	require.NoError(t, r.SetNodeForRoom(context.Background(), "room", "ND_node"))
+	roomNode, err := r.GetNodeForRoom(context.Background(), "room")
+	require.NoError(t, err)
+	require.Equal(t, "ND_node", roomNode.Id)
+
+	require.NoError(t, r.ClearRoomState(context.Background(), "room"))
+	_, err = r.GetNodeForRoom(context.Background(), "room")
+	require.ErrorIs(t, err, routing.ErrNotFound)
+
+	// nodes that stopped updating their stats are removed
+	store.nodes["ND_dead"] = &livekit.Node{Id: "ND_dead", State: livekit.NodeState_SERVING, Stats: &livekit.NodeStats{}}
+	require.NoError(t, r.RemoveDeadNodes())
+	nodes, err = r.ListNodes()
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+
+	require.NoError(t, r.UnregisterNode())
+	_, err = r.GetNode("ND_node")
+	require.ErrorIs(t, err, routing.ErrNotFound)
+}
+
+func TestNATSKey(t *testing.T) {
+	require.Equal(t, "nodes", routing.NATSKey("nodes"))
+	// parts are encoded to the characters allowed in keys
+	require.Equal(t, "room_participants.cm9vbSAx.Pz8_", routing.NATSKey("room_participants", "room 1", "???"))
+	require.Equal(t, "rooms.=", routing.NATSKey("rooms", ""))
+}
+
+type memoryNodeStore struct {
+	lock      sync.Mutex
+	nodes     map[livekit.NodeID]*livekit.Node
+	roomNodes map[livekit.RoomName]livekit.NodeID
+}
+
+func newMemoryNodeStore() *memoryNodeStore {
+	return &memoryNodeStore{
+		nodes:     make(map[livekit.NodeID]*livekit.Node),
+		roomNodes: make(map[livekit.RoomName]livekit.NodeID),
+	}
+}
+
+func (s *memoryNodeStore) StoreNode(_ context.Context, node *livekit.Node) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nodes[livekit.NodeID(node.Id)] = proto.Clone(node).(*livekit.Node)
+	return nil
+}
+
+func (s *memoryNodeStore) DeleteNode(_ context.Context, nodeID livekit.NodeID) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.nodes, nodeID)
+	return nil
+}
+
+func (s *memoryNodeStore) LoadNode(_ context.Context, nodeID livekit.NodeID) (*livekit.Node, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	node, ok := s.nodes[nodeID]
+	if !ok {
+		return nil, routing.ErrNotFound
+	}
+	return node, nil
+}
+
+func (s *memoryNodeStore) ListNodes(_ context.Context) ([]*livekit.Node, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	nodes := make([]*livekit.Node, 0, len(s.nodes))
+	for _, node := range s.nodes {
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func (s *memoryNodeStore) LoadRoomNode(_ context.Context, roomName livekit.RoomName) (livekit.NodeID, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	nodeID, ok := s.roomNodes[roomName]
+	if !ok {
+		return "", routing.ErrNotFound
+	}
+	return nodeID, nil
+}
+
+func (s *memoryNodeStore) StoreRoomNode(_ context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.roomNodes[roomName] = nodeID
+	return nil
+}
+
+func (s *memoryNodeStore) DeleteRoomNode(_ context.Context, roomName livekit.RoomName) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.roomNodes, roomName)
+	return nil
+}
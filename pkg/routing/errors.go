@@ -29,6 +29,8 @@ var (
 	ErrChannelClosed        = errors.New("channel closed")
 	ErrChannelFull          = errors.New("channel is full")
 
+	ErrInvalidSubscribeFilter = errors.New("invalid subscribe filter")
+
 	// errors when starting signal connection
 	ErrRequestChannelClosed       = errors.New("request channel closed")
 	ErrCouldNotMigrateParticipant = errors.New("could not migrate participant")
@@ -17,8 +17,8 @@ package routing
 import (
 	"context"
 	"encoding/json"
+	"time"
 
-	"github.com/redis/go-redis/v9"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/protocol/auth"
@@ -61,6 +61,30 @@ type ParticipantInit struct {
 	AdaptiveStream       bool
 	ID                   livekit.ParticipantID
 	SubscriberAllowPause *bool
+	// downstream bandwidth, in bps, the client expects, 0 when unknown
+	BandwidthHint int64
+	// API key the join token was signed with
+	APIKey string
+	// tracks the participant may subscribe to, nil when not limited by the join token
+	SubscribeFilter *SubscribeFilter
+	// node a redirected participant was handed off to by its resume token, its session can resume there
+	ResumeNodeID livekit.NodeID
+	// when the signal node received the join request and how long validating it took, zero for reconnects
+	JoinRequestedAt time.Time
+	TokenValidation time.Duration
+}
+
+// startSessionGrants is the grants JSON of livekit.StartSession. It carries the session fields not part of
+// livekit.StartSession, nodes that do not know about them ignore them.
+type startSessionGrants struct {
+	*auth.ClaimGrants
+	BandwidthHint   int64            `json:"bandwidthHint,omitempty"`
+	APIKey          string           `json:"apiKey,omitempty"`
+	SubscribeFilter *SubscribeFilter `json:"subscribeFilter,omitempty"`
+	ResumeNodeID    livekit.NodeID   `json:"resumeNodeId,omitempty"`
+	// unix nanoseconds
+	JoinRequestedAt int64         `json:"joinRequestedAt,omitempty"`
+	TokenValidation time.Duration `json:"tokenValidation,omitempty"`
 }
 
 // Router allows multiple nodes to coordinate the participant session
@@ -94,16 +118,29 @@ type StartParticipantSignalResults struct {
 	NodeSelectionReason string
 }
 
+// NodeStore keeps the nodes of the cluster and the node hosting each room, shared by all the nodes.
+// Lookups of missing nodes and rooms return ErrNotFound.
+type NodeStore interface {
+	StoreNode(ctx context.Context, node *livekit.Node) error
+	DeleteNode(ctx context.Context, nodeID livekit.NodeID) error
+	LoadNode(ctx context.Context, nodeID livekit.NodeID) (*livekit.Node, error)
+	ListNodes(ctx context.Context) ([]*livekit.Node, error)
+
+	LoadRoomNode(ctx context.Context, roomName livekit.RoomName) (livekit.NodeID, error)
+	StoreRoomNode(ctx context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error
+	DeleteRoomNode(ctx context.Context, roomName livekit.RoomName) error
+}
+
 type MessageRouter interface {
 	// StartParticipantSignal participant signal connection is ready to start
 	StartParticipantSignal(ctx context.Context, roomName livekit.RoomName, pi ParticipantInit) (res StartParticipantSignalResults, err error)
 }
 
-func CreateRouter(rc redis.UniversalClient, node LocalNode, signalClient SignalClient, kps rpc.KeepalivePubSub) Router {
+func CreateRouter(store NodeStore, node LocalNode, signalClient SignalClient, kps rpc.KeepalivePubSub) Router {
 	lr := NewLocalRouter(node, signalClient)
 
-	if rc != nil {
-		return NewRedisRouter(lr, rc, kps)
+	if store != nil {
+		return NewDistributedRouter(lr, store, kps)
 	}
 
 	// local routing and store
@@ -112,7 +149,18 @@ func CreateRouter(rc redis.UniversalClient, node LocalNode, signalClient SignalC
 }
 
 func (pi *ParticipantInit) ToStartSession(roomName livekit.RoomName, connectionID livekit.ConnectionID) (*livekit.StartSession, error) {
-	claims, err := json.Marshal(pi.Grants)
+	grants := startSessionGrants{
+		ClaimGrants:     pi.Grants,
+		BandwidthHint:   pi.BandwidthHint,
+		APIKey:          pi.APIKey,
+		SubscribeFilter: pi.SubscribeFilter,
+		ResumeNodeID:    pi.ResumeNodeID,
+		TokenValidation: pi.TokenValidation,
+	}
+	if !pi.JoinRequestedAt.IsZero() {
+		grants.JoinRequestedAt = pi.JoinRequestedAt.UnixNano()
+	}
+	claims, err := json.Marshal(grants)
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +189,8 @@ func (pi *ParticipantInit) ToStartSession(roomName livekit.RoomName, connectionI
 
 func ParticipantInitFromStartSession(ss *livekit.StartSession, region string) (*ParticipantInit, error) {
 	claims := &auth.ClaimGrants{}
-	if err := json.Unmarshal([]byte(ss.GrantsJson), claims); err != nil {
+	grants := startSessionGrants{ClaimGrants: claims}
+	if err := json.Unmarshal([]byte(ss.GrantsJson), &grants); err != nil {
 		return nil, err
 	}
 
@@ -156,6 +205,14 @@ func ParticipantInitFromStartSession(ss *livekit.StartSession, region string) (*
 		Region:          region,
 		AdaptiveStream:  ss.AdaptiveStream,
 		ID:              livekit.ParticipantID(ss.ParticipantId),
+		BandwidthHint:   grants.BandwidthHint,
+		APIKey:          grants.APIKey,
+		SubscribeFilter: grants.SubscribeFilter,
+		ResumeNodeID:    grants.ResumeNodeID,
+		TokenValidation: grants.TokenValidation,
+	}
+	if grants.JoinRequestedAt != 0 {
+		pi.JoinRequestedAt = time.Unix(0, grants.JoinRequestedAt)
 	}
 	if ss.SubscriberAllowPause != nil {
 		subscriberAllowPause := *ss.SubscriberAllowPause
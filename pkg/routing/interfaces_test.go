@@ -0,0 +1,69 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+func TestParticipantInitStartSession(t *testing.T) {
+	pi := &routing.ParticipantInit{
+		Identity:      "participant",
+		Name:          "name",
+		AutoSubscribe: true,
+		Grants: &auth.ClaimGrants{
+			Identity: "participant",
+			Video:    &auth.VideoGrant{RoomJoin: true, Room: "room"},
+		},
+		BandwidthHint:   5_000_000,
+		APIKey:          "key",
+		SubscribeFilter: &routing.SubscribeFilter{Identities: []string{"host-*"}},
+		ResumeNodeID:    "ND_target",
+		JoinRequestedAt: time.Unix(1700000000, 5),
+		TokenValidation: 3 * time.Millisecond,
+	}
+
+	ss, err := pi.ToStartSession("room", livekit.ConnectionID("conn"))
+	require.NoError(t, err)
+
+	decoded, err := routing.ParticipantInitFromStartSession(ss, "region")
+	require.NoError(t, err)
+	require.Equal(t, pi.Identity, decoded.Identity)
+	require.Equal(t, pi.Grants.Video, decoded.Grants.Video)
+	require.Equal(t, int64(5_000_000), decoded.BandwidthHint)
+	require.Equal(t, "key", decoded.APIKey)
+	require.Equal(t, pi.SubscribeFilter, decoded.SubscribeFilter)
+	require.Equal(t, livekit.NodeID("ND_target"), decoded.ResumeNodeID)
+	require.True(t, pi.JoinRequestedAt.Equal(decoded.JoinRequestedAt))
+	require.Equal(t, 3*time.Millisecond, decoded.TokenValidation)
+
+	// grants written by nodes without session extensions still decode
+	ss.GrantsJson = `{"identity":"participant","video":{"roomJoin":true,"room":"room"}}`
+	decoded, err = routing.ParticipantInitFromStartSession(ss, "region")
+	require.NoError(t, err)
+	require.Equal(t, pi.Grants.Video, decoded.Grants.Video)
+	require.Zero(t, decoded.BandwidthHint)
+	require.Nil(t, decoded.SubscribeFilter)
+	require.Empty(t, decoded.ResumeNodeID)
+	require.True(t, decoded.JoinRequestedAt.IsZero())
+}
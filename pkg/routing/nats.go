@@ -0,0 +1,205 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const natsMaxUpdateRetries = 5
+
+// NewNATSConn connects to NATS. The connection fails over to the other servers of the cluster on its own and
+// resubscribes there, publishes are buffered while it reconnects. The connection requires TLS with clusterTLS
+// when it is set.
+func NewNATSConn(conf *config.NATSConfig, clusterTLS *tls.Config) (*nats.Conn, error) {
+	opts := []nats.Option{
+		nats.Name("livekit-server"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.Warnw("disconnected from nats", err)
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			logger.Infow("reconnected to nats", "url", nc.ConnectedUrl())
+		}),
+	}
+	if conf.Username != "" {
+		opts = append(opts, nats.UserInfo(conf.Username, conf.Password))
+	}
+	if conf.Token != "" {
+		opts = append(opts, nats.Token(conf.Token))
+	}
+	if conf.CredsFile != "" {
+		opts = append(opts, nats.UserCredentials(conf.CredsFile))
+	}
+	if clusterTLS != nil {
+		opts = append(opts, nats.Secure(clusterTLS))
+	}
+
+	logger.Infow("connecting to nats", "addr", conf.URLs)
+	nc, err := nats.Connect(strings.Join(conf.URLs, ","), opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to nats")
+	}
+	return nc, nil
+}
+
+// NATSBucket opens a JetStream key-value bucket, it is created by the first node that needs it
+func NATSBucket(ctx context.Context, nc *nats.Conn, conf *config.NATSConfig, name string) (jetstream.KeyValue, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := conf.BucketPrefix + "_" + name
+	kv, err := js.KeyValue(ctx, bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+			Bucket:   bucket,
+			Storage:  jetstream.FileStorage,
+			Replicas: conf.Replicas,
+		})
+		if errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+			// created meanwhile by another node
+			kv, err = js.KeyValue(ctx, bucket)
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open nats bucket %s", bucket)
+	}
+	return kv, nil
+}
+
+// NATSKey returns the key of parts within group. Keys only allow a few characters, parts are encoded.
+func NATSKey(group string, parts ...string) string {
+	var b strings.Builder
+	b.WriteString(group)
+	for _, part := range parts {
+		b.WriteByte('.')
+		if part == "" {
+			// never produced by the unpadded encoding
+			b.WriteByte('=')
+		} else {
+			b.WriteString(base64.RawURLEncoding.EncodeToString([]byte(part)))
+		}
+	}
+	return b.String()
+}
+
+func decodeNATSKeyPart(part string) (string, error) {
+	if part == "=" {
+		return "", nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	return string(b), err
+}
+
+// NATSList returns the values of the keys directly under the key prefix, by their decoded last part
+func NATSList(ctx context.Context, kv jetstream.KeyValue, prefix string) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+	err := watchNATS(ctx, kv, prefix, func(entry jetstream.KeyValueEntry) error {
+		part, err := decodeNATSKeyPart(entry.Key()[len(prefix)+1:])
+		if err != nil {
+			return err
+		}
+		values[part] = entry.Value()
+		return nil
+	})
+	return values, err
+}
+
+// NATSDeleteAll deletes the keys directly under the key prefix
+func NATSDeleteAll(ctx context.Context, kv jetstream.KeyValue, prefix string) error {
+	var keys []string
+	err := watchNATS(ctx, kv, prefix, func(entry jetstream.KeyValueEntry) error {
+		keys = append(keys, entry.Key())
+		return nil
+	}, jetstream.MetaOnly())
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err = kv.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func watchNATS(ctx context.Context, kv jetstream.KeyValue, prefix string, fn func(entry jetstream.KeyValueEntry) error, opts ...jetstream.WatchOpt) error {
+	w, err := kv.Watch(ctx, prefix+".*", append(opts, jetstream.IgnoreDeletes())...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = w.Stop()
+	}()
+
+	for {
+		select {
+		case entry := <-w.Updates():
+			// nil once the current values have been delivered
+			if entry == nil {
+				return nil
+			}
+			if err = fn(entry); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// NATSUpdate replaces the value of key with the one returned by fn, retrying when the key changes meanwhile.
+// value is nil when the key does not exist.
+func NATSUpdate(ctx context.Context, kv jetstream.KeyValue, key string, fn func(value []byte) ([]byte, error)) error {
+	for i := 0; i < natsMaxUpdateRetries; i++ {
+		var value []byte
+		var revision uint64
+		entry, err := kv.Get(ctx, key)
+		switch {
+		case err == nil:
+			value, revision = entry.Value(), entry.Revision()
+		case !errors.Is(err, jetstream.ErrKeyNotFound):
+			return err
+		}
+
+		updated, err := fn(value)
+		if err != nil {
+			return err
+		}
+		if revision == 0 {
+			_, err = kv.Create(ctx, key, updated)
+		} else {
+			_, err = kv.Update(ctx, key, updated, revision)
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) {
+			return err
+		}
+		// changed meanwhile, retry
+	}
+	return errors.New("nats key changed concurrently")
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	NATSRoutingBucket = "routing"
+
+	// key group of node_id => Node proto
+	natsNodesGroup = "nodes"
+
+	// key group of room_name => node_id
+	natsRoomNodeGroup = "room_node_map"
+)
+
+var _ NodeStore = (*natsNodeStore)(nil)
+
+type natsNodeStore struct {
+	kv jetstream.KeyValue
+}
+
+// NewNATSNodeStore keeps nodes and the rooms they host in a JetStream key-value bucket
+func NewNATSNodeStore(kv jetstream.KeyValue) NodeStore {
+	return &natsNodeStore{kv: kv}
+}
+
+func (s *natsNodeStore) StoreNode(ctx context.Context, node *livekit.Node) error {
+	data, err := proto.Marshal(node)
+	if err != nil {
+		return err
+	}
+	_, err = s.kv.Put(ctx, NATSKey(natsNodesGroup, node.Id), data)
+	return err
+}
+
+func (s *natsNodeStore) DeleteNode(ctx context.Context, nodeID livekit.NodeID) error {
+	return s.kv.Delete(ctx, NATSKey(natsNodesGroup, string(nodeID)))
+}
+
+func (s *natsNodeStore) LoadNode(ctx context.Context, nodeID livekit.NodeID) (*livekit.Node, error) {
+	entry, err := s.kv.Get(ctx, NATSKey(natsNodesGroup, string(nodeID)))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	n := livekit.Node{}
+	if err = proto.Unmarshal(entry.Value(), &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (s *natsNodeStore) ListNodes(ctx context.Context) ([]*livekit.Node, error) {
+	items, err := NATSList(ctx, s.kv, natsNodesGroup)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*livekit.Node, 0, len(items))
+	for _, item := range items {
+		n := livekit.Node{}
+		if err := proto.Unmarshal(item, &n); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, &n)
+	}
+	return nodes, nil
+}
+
+func (s *natsNodeStore) LoadRoomNode(ctx context.Context, roomName livekit.RoomName) (livekit.NodeID, error) {
+	entry, err := s.kv.Get(ctx, NATSKey(natsRoomNodeGroup, string(roomName)))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return livekit.NodeID(entry.Value()), nil
+}
+
+func (s *natsNodeStore) StoreRoomNode(ctx context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error {
+	_, err := s.kv.Put(ctx, NATSKey(natsRoomNodeGroup, string(roomName)), []byte(nodeID))
+	return err
+}
+
+func (s *natsNodeStore) DeleteRoomNode(ctx context.Context, roomName livekit.RoomName) error {
+	return s.kv.Delete(ctx, NATSKey(natsRoomNodeGroup, string(roomName)))
+}
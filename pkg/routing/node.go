@@ -42,6 +42,9 @@ func NewLocalNode(conf *config.Config) (LocalNode, error) {
 			UpdatedAt: time.Now().Unix(),
 		},
 	}
+	if conf.IsRelayNode() {
+		node.Type = livekit.NodeType_TURN
+	}
 
 	return node, nil
 }
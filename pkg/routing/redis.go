@@ -0,0 +1,255 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/livekit/protocol/logger"
+	redisLiveKit "github.com/livekit/protocol/redis"
+)
+
+const (
+	// keepalive probes detect connections left half open by a failed redis node, a blocked pub/sub read
+	// would otherwise wait on them forever
+	redisKeepAlive = 10 * time.Second
+
+	// sentinel defaults, matching the ones of the protocol client
+	sentinelDialTimeout  = 2000
+	sentinelReadTimeout  = 200
+	sentinelWriteTimeout = 200
+)
+
+type RedisTopology string
+
+const (
+	RedisTopologySingle   RedisTopology = "single"
+	RedisTopologySentinel RedisTopology = "sentinel"
+	RedisTopologyCluster  RedisTopology = "cluster"
+)
+
+func GetRedisTopology(conf *redisLiveKit.RedisConfig) RedisTopology {
+	switch {
+	case len(conf.SentinelAddresses) > 0:
+		return RedisTopologySentinel
+	case len(conf.ClusterAddresses) > 0:
+		return RedisTopologyCluster
+	default:
+		return RedisTopologySingle
+	}
+}
+
+// NewRedisClient connects to a single redis, a sentinel managed master or a cluster. Unlike a universal client
+// a cluster is detected from its config, so that a single seed address still follows MOVED redirections.
+// The returned client can drop all of its connections, the router does so when its own keepalive stops going
+// through pub/sub, which resubscribes the signal relay channels on the node that currently serves them.
+// clusterTLS replaces the TLS config of conf when set.
+func NewRedisClient(conf *redisLiveKit.RedisConfig, clusterTLS *tls.Config) (redis.UniversalClient, error) {
+	if !conf.IsConfigured() {
+		return nil, redisLiveKit.ErrNotConfigured
+	}
+
+	tlsConfig := clusterTLS
+	switch {
+	case tlsConfig != nil:
+		// cluster TLS takes precedence
+	case conf.TLS != nil && conf.TLS.Enabled:
+		var err error
+		if tlsConfig, err = conf.TLS.ClientTLSConfig(); err != nil {
+			return nil, err
+		}
+	case conf.UseTLS:
+		tlsConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	conns := &redisConnTracker{conns: make(map[*trackedRedisConn]struct{})}
+	dialTimeout, readTimeout, writeTimeout := conf.DialTimeout, conf.ReadTimeout, conf.WriteTimeout
+
+	var rc redis.UniversalClient
+	topology := GetRedisTopology(conf)
+	switch topology {
+	case RedisTopologySentinel:
+		logger.Infow("connecting to redis", "topology", topology, "addr", conf.SentinelAddresses, "masterName", conf.MasterName)
+		if dialTimeout == 0 {
+			dialTimeout = sentinelDialTimeout
+		}
+		if readTimeout == 0 {
+			readTimeout = sentinelReadTimeout
+		}
+		if writeTimeout == 0 {
+			writeTimeout = sentinelWriteTimeout
+		}
+		rc = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       conf.MasterName,
+			SentinelAddrs:    conf.SentinelAddresses,
+			SentinelUsername: conf.SentinelUsername,
+			SentinelPassword: conf.SentinelPassword,
+			Dialer:           conns.dialer(dialTimeout, tlsConfig),
+			Username:         conf.Username,
+			Password:         conf.Password,
+			DB:               conf.DB,
+			DialTimeout:      millis(dialTimeout),
+			ReadTimeout:      millis(readTimeout),
+			WriteTimeout:     millis(writeTimeout),
+			PoolTimeout:      conf.PoolTimeout,
+			PoolSize:         conf.PoolSize,
+			TLSConfig:        tlsConfig,
+		})
+
+	case RedisTopologyCluster:
+		logger.Infow("connecting to redis", "topology", topology, "addr", conf.ClusterAddresses)
+		rc = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        conf.ClusterAddresses,
+			Dialer:       conns.dialer(dialTimeout, tlsConfig),
+			Username:     conf.Username,
+			Password:     conf.Password,
+			MaxRedirects: conf.GetMaxRedirects(),
+			DialTimeout:  millis(dialTimeout),
+			ReadTimeout:  millis(readTimeout),
+			WriteTimeout: millis(writeTimeout),
+			PoolTimeout:  conf.PoolTimeout,
+			PoolSize:     conf.PoolSize,
+			TLSConfig:    tlsConfig,
+		})
+
+	default:
+		logger.Infow("connecting to redis", "topology", topology, "addr", conf.Address)
+		rc = redis.NewClient(&redis.Options{
+			Addr:         conf.Address,
+			Dialer:       conns.dialer(dialTimeout, tlsConfig),
+			Username:     conf.Username,
+			Password:     conf.Password,
+			DB:           conf.DB,
+			DialTimeout:  millis(dialTimeout),
+			ReadTimeout:  millis(readTimeout),
+			WriteTimeout: millis(writeTimeout),
+			PoolTimeout:  conf.PoolTimeout,
+			PoolSize:     conf.PoolSize,
+			TLSConfig:    tlsConfig,
+		})
+	}
+
+	if err := rc.Ping(context.Background()).Err(); err != nil {
+		_ = rc.Close()
+		return nil, errors.Wrap(err, "unable to connect to redis")
+	}
+
+	return &redisClient{
+		UniversalClient: rc,
+		conns:           conns,
+	}, nil
+}
+
+// timeouts are configured in milliseconds, zero keeps the client default
+func millis(ms int) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+// redisReconnector is implemented by the clients of NewRedisClient
+type redisReconnector interface {
+	reconnect(ctx context.Context)
+}
+
+type redisClient struct {
+	redis.UniversalClient
+	conns *redisConnTracker
+}
+
+// reconnect closes every connection of the client, pools and subscriptions dial again on their next use.
+// A cluster reloads its slots first so that subscriptions move to the node now serving their channel.
+func (c *redisClient) reconnect(ctx context.Context) {
+	if cc, ok := c.UniversalClient.(*redis.ClusterClient); ok {
+		cc.ReloadState(ctx)
+	}
+	c.conns.closeAll()
+}
+
+type redisConnTracker struct {
+	lock  sync.Mutex
+	conns map[*trackedRedisConn]struct{}
+}
+
+// dialer replaces the default one of the client, which then leaves TLS to it
+func (t *redisConnTracker) dialer(timeoutMillis int, tlsConfig *tls.Config) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{
+		Timeout:   millis(timeoutMillis),
+		KeepAlive: redisKeepAlive,
+	}
+	if d.Timeout == 0 {
+		d.Timeout = 5 * time.Second
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := d.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		tc := &trackedRedisConn{Conn: conn, tracker: t}
+		t.lock.Lock()
+		t.conns[tc] = struct{}{}
+		t.lock.Unlock()
+		if tlsConfig == nil {
+			return tc, nil
+		}
+
+		config := tlsConfig.Clone()
+		if config.ServerName == "" {
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				config.ServerName = host
+			}
+		}
+		tlsConn := tls.Client(tc, config)
+		_ = tc.SetDeadline(time.Now().Add(d.Timeout))
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = tc.Close()
+			return nil, err
+		}
+		_ = tc.SetDeadline(time.Time{})
+		return tlsConn, nil
+	}
+}
+
+func (t *redisConnTracker) closeAll() {
+	t.lock.Lock()
+	conns := make([]*trackedRedisConn, 0, len(t.conns))
+	for c := range t.conns {
+		conns = append(conns, c)
+	}
+	t.lock.Unlock()
+
+	for _, c := range conns {
+		_ = c.Close()
+	}
+}
+
+type trackedRedisConn struct {
+	net.Conn
+	tracker *redisConnTracker
+}
+
+func (c *trackedRedisConn) Close() error {
+	c.tracker.lock.Lock()
+	delete(c.tracker.conns, c)
+	c.tracker.lock.Unlock()
+	return c.Conn.Close()
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	redisLiveKit "github.com/livekit/protocol/redis"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+func TestGetRedisTopology(t *testing.T) {
+	require.Equal(t, routing.RedisTopologySingle, routing.GetRedisTopology(&redisLiveKit.RedisConfig{Address: "localhost:6379"}))
+	require.Equal(t, routing.RedisTopologySentinel, routing.GetRedisTopology(&redisLiveKit.RedisConfig{
+		MasterName:        "livekit",
+		SentinelAddresses: []string{"localhost:26379"},
+	}))
+	// a single seed address is still a cluster
+	require.Equal(t, routing.RedisTopologyCluster, routing.GetRedisTopology(&redisLiveKit.RedisConfig{
+		ClusterAddresses: []string{"localhost:7000"},
+	}))
+}
+
+func TestNewRedisClient(t *testing.T) {
+	addr := startFakeRedis(t)
+
+	rc, err := routing.NewRedisClient(&redisLiveKit.RedisConfig{Address: addr}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rc.Close() })
+	require.NoError(t, rc.Set(context.Background(), "key", "value", 0).Err())
+
+	_, err = routing.NewRedisClient(&redisLiveKit.RedisConfig{}, nil)
+	require.ErrorIs(t, err, redisLiveKit.ErrNotConfigured)
+}
+
+// startFakeRedis answers PING with PONG and any other command with OK, refusing RESP3
+func startFakeRedis(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeRedis(conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func serveFakeRedis(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+		if err != nil {
+			return
+		}
+
+		var args []string
+		for i := 0; i < n; i++ {
+			if _, err = r.ReadString('\n'); err != nil {
+				return
+			}
+			arg, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			args = append(args, strings.ToUpper(strings.TrimSpace(arg)))
+		}
+
+		reply := "+OK\r\n"
+		switch {
+		case len(args) == 0:
+		case args[0] == "HELLO":
+			reply = "-ERR unknown command\r\n"
+		case args[0] == "PING":
+			reply = "+PONG\r\n"
+		}
+		if _, err = conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
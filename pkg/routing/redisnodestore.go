@@ -0,0 +1,109 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// hash of node_id => Node proto
+	NodesKey = "nodes"
+
+	// hash of room_name => node_id
+	NodeRoomKey = "room_node_map"
+)
+
+var _ NodeStore = (*redisNodeStore)(nil)
+
+type redisNodeStore struct {
+	rc redis.UniversalClient
+}
+
+func NewRedisNodeStore(rc redis.UniversalClient) NodeStore {
+	return &redisNodeStore{rc: rc}
+}
+
+func (s *redisNodeStore) StoreNode(ctx context.Context, node *livekit.Node) error {
+	data, err := proto.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return s.rc.HSet(ctx, NodesKey, node.Id, data).Err()
+}
+
+func (s *redisNodeStore) DeleteNode(ctx context.Context, nodeID livekit.NodeID) error {
+	return s.rc.HDel(ctx, NodesKey, string(nodeID)).Err()
+}
+
+func (s *redisNodeStore) LoadNode(ctx context.Context, nodeID livekit.NodeID) (*livekit.Node, error) {
+	data, err := s.rc.HGet(ctx, NodesKey, string(nodeID)).Result()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	n := livekit.Node{}
+	if err = proto.Unmarshal([]byte(data), &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (s *redisNodeStore) ListNodes(ctx context.Context) ([]*livekit.Node, error) {
+	items, err := s.rc.HVals(ctx, NodesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*livekit.Node, 0, len(items))
+	for _, item := range items {
+		n := livekit.Node{}
+		if err := proto.Unmarshal([]byte(item), &n); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, &n)
+	}
+	return nodes, nil
+}
+
+func (s *redisNodeStore) LoadRoomNode(ctx context.Context, roomName livekit.RoomName) (livekit.NodeID, error) {
+	nodeID, err := s.rc.HGet(ctx, NodeRoomKey, string(roomName)).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return livekit.NodeID(nodeID), nil
+}
+
+func (s *redisNodeStore) StoreRoomNode(ctx context.Context, roomName livekit.RoomName, nodeID livekit.NodeID) error {
+	return s.rc.HSet(ctx, NodeRoomKey, string(roomName), string(nodeID)).Err()
+}
+
+func (s *redisNodeStore) DeleteRoomNode(ctx context.Context, roomName livekit.RoomName) error {
+	return s.rc.HDel(ctx, NodeRoomKey, string(roomName)).Err()
+}
+
+// reconnect drops the connections of clients built by NewRedisClient
+func (s *redisNodeStore) reconnect(ctx context.Context) {
+	if rc, ok := s.rc.(redisReconnector); ok {
+		rc.reconnect(ctx)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"math"
+	"sort"
+
+	"github.com/thoas/go-funk"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// GetAvailableRelayNodes returns the relay nodes that accept TURN allocations
+func GetAvailableRelayNodes(nodes []*livekit.Node) []*livekit.Node {
+	return funk.Filter(nodes, func(node *livekit.Node) bool {
+		return IsAvailable(node) && node.State == livekit.NodeState_SERVING && node.Type == livekit.NodeType_TURN
+	}).([]*livekit.Node)
+}
+
+// SelectRelayNodes returns up to limit available relay nodes, those in region first, then those nearest to it.
+// Relay nodes in regions without coordinates come last, the least loaded first among nodes at the same distance
+func SelectRelayNodes(nodes []*livekit.Node, region string, regions []config.RegionConfig, limit int) []*livekit.Node {
+	nodes = GetAvailableRelayNodes(nodes)
+	if limit <= 0 || len(nodes) == 0 {
+		return nil
+	}
+
+	var current *config.RegionConfig
+	for i := range regions {
+		if regions[i].Name == region {
+			current = &regions[i]
+			break
+		}
+	}
+	distances := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		if _, ok := distances[node.Region]; ok {
+			continue
+		}
+		dist := math.MaxFloat64
+		if node.Region == region {
+			dist = 0
+		} else if current != nil {
+			for _, rc := range regions {
+				if rc.Name == node.Region {
+					dist = distanceBetween(current.Lat, current.Lon, rc.Lat, rc.Lon)
+					break
+				}
+			}
+		}
+		distances[node.Region] = dist
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		di, dj := distances[nodes[i].Region], distances[nodes[j].Region]
+		if di != dj {
+			return di < dj
+		}
+		return GetNodeSysload(nodes[i]) < GetNodeSysload(nodes[j])
+	})
+	if len(nodes) > limit {
+		nodes = nodes[:limit]
+	}
+	return nodes
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/selector"
+)
+
+func TestRelayNodes(t *testing.T) {
+	rc := []config.RegionConfig{
+		{Name: regionWest, Lat: 37.64046607830567, Lon: -120.88026233189062},
+		{Name: regionEast, Lat: 40.68914362140307, Lon: -74.04445748616385},
+		{Name: regionSeattle, Lat: 47.620426730945454, Lon: -122.34938468973702},
+	}
+
+	t.Run("relay nodes do not host rooms", func(t *testing.T) {
+		server := newTestNodeInRegion(regionWest, true)
+		nodes := []*livekit.Node{server, newTestRelayNode(regionWest, 0.1)}
+
+		require.Equal(t, []*livekit.Node{server}, selector.GetAvailableNodes(nodes))
+		s := &selector.AnySelector{SortBy: sortBy}
+		for i := 0; i < 10; i++ {
+			node, err := s.SelectNode(nodes)
+			require.NoError(t, err)
+			require.Equal(t, server, node)
+		}
+	})
+
+	t.Run("nearest relay nodes first", func(t *testing.T) {
+		east := newTestRelayNode(regionEast, 0.1)
+		seattle := newTestRelayNode(regionSeattle, 0.1)
+		westBusy := newTestRelayNode(regionWest, 0.8)
+		westIdle := newTestRelayNode(regionWest, 0.2)
+		unknown := newTestRelayNode("eu-central", 0)
+		nodes := []*livekit.Node{
+			unknown,
+			east,
+			westBusy,
+			newTestNodeInRegion(regionWest, true),
+			seattle,
+			westIdle,
+		}
+
+		require.Equal(t,
+			[]*livekit.Node{westIdle, westBusy, seattle, east, unknown},
+			selector.SelectRelayNodes(nodes, regionWest, rc, 10),
+		)
+		require.Equal(t, []*livekit.Node{westIdle, westBusy}, selector.SelectRelayNodes(nodes, regionWest, rc, 2))
+		require.Nil(t, selector.SelectRelayNodes(nodes, regionWest, rc, 0))
+	})
+
+	t.Run("draining relay nodes are skipped", func(t *testing.T) {
+		draining := newTestRelayNode(regionWest, 0.1)
+		draining.State = livekit.NodeState_SHUTTING_DOWN
+		east := newTestRelayNode(regionEast, 0.1)
+
+		require.Equal(t, []*livekit.Node{east}, selector.SelectRelayNodes([]*livekit.Node{draining, east}, regionWest, rc, 2))
+	})
+}
+
+func newTestRelayNode(region string, load float32) *livekit.Node {
+	node := newTestNodeInRegion(region, true)
+	node.Type = livekit.NodeType_TURN
+	node.Stats.LoadAvgLast1Min = load
+	return node
+}
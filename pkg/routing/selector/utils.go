@@ -38,9 +38,10 @@ func IsAvailable(node *livekit.Node) bool {
 	return int(delta) < AvailableSeconds
 }
 
+// GetAvailableNodes returns the nodes that can host rooms, relay nodes only relay media
 func GetAvailableNodes(nodes []*livekit.Node) []*livekit.Node {
 	return funk.Filter(nodes, func(node *livekit.Node) bool {
-		return IsAvailable(node) && node.State == livekit.NodeState_SERVING
+		return IsAvailable(node) && node.State == livekit.NodeState_SERVING && node.Type == livekit.NodeType_SERVER
 	}).([]*livekit.Node)
 }
 
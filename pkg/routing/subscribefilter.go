@@ -0,0 +1,75 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"path"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// SubscribeFilter limits the tracks a participant may subscribe to, it is set in the subscribeFilter claim of the
+// join token. Identities and Names are patterns as in path.Match, e.g. "host-*", Sources are lowercase track
+// source names, e.g. "screen_share". A track has to match every field that is set, and any entry of a field.
+type SubscribeFilter struct {
+	Identities []string `json:"identities,omitempty"`
+	Sources    []string `json:"sources,omitempty"`
+	Names      []string `json:"names,omitempty"`
+}
+
+func (f *SubscribeFilter) Validate() error {
+	if f == nil {
+		return nil
+	}
+	for _, pattern := range append(append([]string{}, f.Identities...), f.Names...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return ErrInvalidSubscribeFilter
+		}
+	}
+	for _, source := range f.Sources {
+		if _, ok := livekit.TrackSource_value[strings.ToUpper(source)]; !ok {
+			return ErrInvalidSubscribeFilter
+		}
+	}
+	return nil
+}
+
+// Allows returns true when the participant may subscribe to track published by publisher. A nil filter allows
+// every track.
+func (f *SubscribeFilter) Allows(publisher livekit.ParticipantIdentity, track *livekit.TrackInfo) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Identities) != 0 && !matchesAny(f.Identities, string(publisher)) {
+		return false
+	}
+	if len(f.Sources) != 0 && !matchesAny(f.Sources, strings.ToLower(track.GetSource().String())) {
+		return false
+	}
+	if len(f.Names) != 0 && !matchesAny(f.Names, track.GetName()) {
+		return false
+	}
+	return true
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
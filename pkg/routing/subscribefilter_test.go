@@ -0,0 +1,60 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+func TestSubscribeFilter(t *testing.T) {
+	camera := &livekit.TrackInfo{Name: "main camera", Source: livekit.TrackSource_CAMERA}
+	screen := &livekit.TrackInfo{Name: "slides", Source: livekit.TrackSource_SCREEN_SHARE}
+
+	t.Run("nil filter allows every track", func(t *testing.T) {
+		var f *routing.SubscribeFilter
+		require.NoError(t, f.Validate())
+		require.True(t, f.Allows("viewer", camera))
+	})
+
+	t.Run("identities", func(t *testing.T) {
+		f := &routing.SubscribeFilter{Identities: []string{"host-*", "moderator"}}
+		require.True(t, f.Allows("host-1", camera))
+		require.True(t, f.Allows("moderator", camera))
+		require.False(t, f.Allows("viewer-1", camera))
+	})
+
+	t.Run("every field has to match", func(t *testing.T) {
+		f := &routing.SubscribeFilter{
+			Identities: []string{"host-*"},
+			Sources:    []string{"screen_share", "screen_share_audio"},
+			Names:      []string{"slides*"},
+		}
+		require.True(t, f.Allows("host-1", screen))
+		require.False(t, f.Allows("host-1", camera))
+		require.False(t, f.Allows("viewer-1", screen))
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		require.NoError(t, (&routing.SubscribeFilter{Sources: []string{"camera"}, Names: []string{"*"}}).Validate())
+		require.ErrorIs(t, (&routing.SubscribeFilter{Sources: []string{"webcam"}}).Validate(), routing.ErrInvalidSubscribeFilter)
+		require.ErrorIs(t, (&routing.SubscribeFilter{Identities: []string{"host-["}}).Validate(), routing.ErrInvalidSubscribeFilter)
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// AudioOnlyTopic is the data topic subscribers are notified on when all their video is paused to keep audio going
+// on a collapsed channel, and when video resumes
+const AudioOnlyTopic = "lk.audio-only"
+
+// AudioOnlyNotice is sent to a subscriber on AudioOnlyTopic when it enters or leaves audio only mode
+type AudioOnlyNotice struct {
+	AudioOnly bool `json:"audio_only"`
+}
+
+func (p *ParticipantImpl) onAudioOnlyChange(audioOnly bool) {
+	p.subLogger.Infow("audio only mode changed", "audioOnly", audioOnly)
+
+	payload, err := json.Marshal(&AudioOnlyNotice{AudioOnly: audioOnly})
+	if err != nil {
+		p.subLogger.Errorw("could not marshal audio only notice", err)
+		return
+	}
+
+	topic := AudioOnlyTopic
+	dp := &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload: payload,
+				Topic:   &topic,
+			},
+		},
+	}
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		p.subLogger.Errorw("could not marshal audio only notice", err)
+		return
+	}
+	if err := p.SendDataPacket(dp, data); err != nil {
+		p.subLogger.Warnw("could not send audio only notice", err)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+type avSyncTrack struct {
+	publisherID       livekit.ParticipantID
+	publisherIdentity livekit.ParticipantIdentity
+	trackID           livekit.TrackID
+	kind              livekit.TrackType
+	source            livekit.TrackSource
+	offset            time.Duration
+}
+
+// getAVSyncStats compares the sync offsets of the audio and video tracks subscribed from each publisher. Tracks
+// without a sender report on both sides yet are left out.
+func getAVSyncStats(subscribedTracks []types.SubscribedTrack) []*types.AVSyncStats {
+	tracks := make([]avSyncTrack, 0, len(subscribedTracks))
+	for _, st := range subscribedTracks {
+		dt := st.DownTrack()
+		if dt == nil {
+			continue
+		}
+		offset, ok := dt.GetSyncOffset()
+		if !ok {
+			continue
+		}
+		tracks = append(tracks, avSyncTrack{
+			publisherID:       st.PublisherID(),
+			publisherIdentity: st.PublisherIdentity(),
+			trackID:           st.ID(),
+			kind:              st.MediaTrack().Kind(),
+			source:            st.MediaTrack().Source(),
+			offset:            offset,
+		})
+	}
+	return pairAVSyncTracks(tracks)
+}
+
+// pairAVSyncTracks pairs an audio track with a video track of each publisher, the microphone and the camera
+// when subscribed to
+func pairAVSyncTracks(tracks []avSyncTrack) []*types.AVSyncStats {
+	type pair struct {
+		audio *avSyncTrack
+		video *avSyncTrack
+	}
+	pairs := make(map[livekit.ParticipantID]*pair)
+	for i := range tracks {
+		t := &tracks[i]
+		p := pairs[t.publisherID]
+		if p == nil {
+			p = &pair{}
+			pairs[t.publisherID] = p
+		}
+		switch t.kind {
+		case livekit.TrackType_AUDIO:
+			if p.audio == nil || (p.audio.source != livekit.TrackSource_MICROPHONE && t.source == livekit.TrackSource_MICROPHONE) {
+				p.audio = t
+			}
+		case livekit.TrackType_VIDEO:
+			if p.video == nil || (p.video.source != livekit.TrackSource_CAMERA && t.source == livekit.TrackSource_CAMERA) {
+				p.video = t
+			}
+		}
+	}
+
+	stats := make([]*types.AVSyncStats, 0, len(pairs))
+	for _, p := range pairs {
+		if p.audio == nil || p.video == nil {
+			continue
+		}
+		stats = append(stats, &types.AVSyncStats{
+			PublisherID:       string(p.audio.publisherID),
+			PublisherIdentity: string(p.audio.publisherIdentity),
+			AudioTrackID:      string(p.audio.trackID),
+			VideoTrackID:      string(p.video.trackID),
+			AudioOffsetMs:     durationMs(p.audio.offset),
+			VideoOffsetMs:     durationMs(p.video.offset),
+			SkewMs:            durationMs(p.audio.offset - p.video.offset),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].PublisherIdentity < stats[j].PublisherIdentity
+	})
+	return stats
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestPairAVSyncTracks(t *testing.T) {
+	tracks := []avSyncTrack{
+		{publisherID: "PA_b", publisherIdentity: "bob", trackID: "TR_bs", kind: livekit.TrackType_AUDIO, source: livekit.TrackSource_SCREEN_SHARE_AUDIO, offset: 300 * time.Millisecond},
+		{publisherID: "PA_b", publisherIdentity: "bob", trackID: "TR_bm", kind: livekit.TrackType_AUDIO, source: livekit.TrackSource_MICROPHONE, offset: 40 * time.Millisecond},
+		{publisherID: "PA_b", publisherIdentity: "bob", trackID: "TR_bc", kind: livekit.TrackType_VIDEO, source: livekit.TrackSource_CAMERA, offset: 100 * time.Millisecond},
+		{publisherID: "PA_a", publisherIdentity: "alice", trackID: "TR_am", kind: livekit.TrackType_AUDIO, source: livekit.TrackSource_MICROPHONE, offset: 150 * time.Millisecond},
+		{publisherID: "PA_a", publisherIdentity: "alice", trackID: "TR_ac", kind: livekit.TrackType_VIDEO, source: livekit.TrackSource_CAMERA, offset: 30 * time.Millisecond},
+		// no video subscribed, nothing to compare with
+		{publisherID: "PA_c", publisherIdentity: "carol", trackID: "TR_cm", kind: livekit.TrackType_AUDIO, source: livekit.TrackSource_MICROPHONE},
+	}
+
+	stats := pairAVSyncTracks(tracks)
+	require.Len(t, stats, 2)
+
+	require.Equal(t, "alice", stats[0].PublisherIdentity)
+	require.Equal(t, "TR_am", stats[0].AudioTrackID)
+	require.Equal(t, "TR_ac", stats[0].VideoTrackID)
+	require.Equal(t, float64(120), stats[0].SkewMs)
+
+	// the microphone is preferred over screen share audio, audio ahead of the video gives a negative skew
+	require.Equal(t, "bob", stats[1].PublisherIdentity)
+	require.Equal(t, "TR_bm", stats[1].AudioTrackID)
+	require.Equal(t, float64(40), stats[1].AudioOffsetMs)
+	require.Equal(t, float64(100), stats[1].VideoOffsetMs)
+	require.Equal(t, float64(-60), stats[1].SkewMs)
+}
@@ -0,0 +1,223 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// CodecDeprecationTopic is the data topic publishers of a deprecated codec are notified on
+const CodecDeprecationTopic = "lk.codec-deprecation"
+
+// CodecDeprecationNotice is sent to a publisher on CodecDeprecationTopic for each deprecated codec a track is published with,
+// and for each track that is not published as its codec has been cut off
+type CodecDeprecationNotice struct {
+	TrackID livekit.TrackID `json:"track_id,omitempty"`
+	// client ID of a track rejected before it was given a track ID
+	Cid  string `json:"cid,omitempty"`
+	Mime string `json:"mime"`
+	// unix time in seconds after which the codec cannot be published anymore, 0 if not set
+	Cutoff  int64  `json:"cutoff,omitempty"`
+	Message string `json:"message,omitempty"`
+	// the track is not published, Error says why
+	Rejected bool   `json:"rejected,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// FilterCutOffCodecs returns codecs without the deprecated ones that have been cut off at t
+func FilterCutOffCodecs(codecs []*livekit.Codec, deprecations []config.CodecDeprecationConfig, t time.Time) []*livekit.Codec {
+	if len(deprecations) == 0 {
+		return codecs
+	}
+
+	filtered := make([]*livekit.Codec, 0, len(codecs))
+	for _, c := range codecs {
+		if d := findCodecDeprecation(deprecations, c.Mime); d != nil && d.IsCutOff(t) {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
+
+func findCodecDeprecation(deprecations []config.CodecDeprecationConfig, mime string) *config.CodecDeprecationConfig {
+	for i := range deprecations {
+		if strings.EqualFold(deprecations[i].Mime, mime) {
+			return &deprecations[i]
+		}
+	}
+	return nil
+}
+
+func (p *ParticipantImpl) cutOffCodec(mime string, t time.Time) *config.CodecDeprecationConfig {
+	if d := findCodecDeprecation(p.params.CodecDeprecations, mime); d != nil && d.IsCutOff(t) {
+		return d
+	}
+	return nil
+}
+
+// filterCutOffPublishCodecs removes the codecs cut off at t from the codecs a track is requested with. Codecs are
+// negotiated when participants join, participants connected before a cutoff are held to it from the next track they
+// publish. A track requested with cut off codecs only is rejected with ErrCodecCutOff, and the publisher notified.
+func (p *ParticipantImpl) filterCutOffPublishCodecs(req *livekit.AddTrackRequest, t time.Time) (*livekit.AddTrackRequest, error) {
+	if len(p.params.CodecDeprecations) == 0 || len(req.SimulcastCodecs) == 0 {
+		return req, nil
+	}
+
+	var cutOff []string
+	codecs := make([]*livekit.SimulcastCodec, 0, len(req.SimulcastCodecs))
+	for _, codec := range req.SimulcastCodecs {
+		if mime := publishCodecMime(req.Type, codec.Codec); p.cutOffCodec(mime, t) != nil {
+			cutOff = append(cutOff, mime)
+			continue
+		}
+		codecs = append(codecs, codec)
+	}
+	if len(cutOff) == 0 {
+		return req, nil
+	}
+
+	if len(codecs) == 0 {
+		for _, mime := range cutOff {
+			p.sendCodecDeprecationNotice(&CodecDeprecationNotice{
+				Cid:      req.Cid,
+				Mime:     mime,
+				Rejected: true,
+				Error:    ErrCodecCutOff.Error(),
+			}, findCodecDeprecation(p.params.CodecDeprecations, mime))
+		}
+		return nil, ErrCodecCutOff
+	}
+
+	p.pubLogger.Infow("removed cut off codecs from track", "cid", req.Cid, "codecs", cutOff)
+	req = proto.Clone(req).(*livekit.AddTrackRequest)
+	req.SimulcastCodecs = codecs
+	return req, nil
+}
+
+// rejectCutOffTrack returns true when the track was negotiated with a codec cut off at t, it is then unpublished.
+// Tracks are only requested with their codecs by clients supporting simulcast codecs.
+func (p *ParticipantImpl) rejectCutOffTrack(track types.MediaTrack, mime string, t time.Time) bool {
+	d := p.cutOffCodec(mime, t)
+	if d == nil {
+		return false
+	}
+
+	p.pubLogger.Infow("cannot publish track, codec cut off", "trackID", track.ID(), "mime", mime)
+	p.sendCodecDeprecationNotice(&CodecDeprecationNotice{
+		TrackID:  track.ID(),
+		Mime:     mime,
+		Rejected: true,
+		Error:    ErrCodecCutOff.Error(),
+	}, d)
+	p.removePublishedTrack(track)
+	return true
+}
+
+// publishCodecMime returns the mime type of a codec named in a track request
+func publishCodecMime(trackType livekit.TrackType, codec string) string {
+	prefix := "audio/"
+	if trackType == livekit.TrackType_VIDEO {
+		prefix = "video/"
+	}
+	if codec == "" || strings.HasPrefix(strings.ToLower(codec), prefix) {
+		return codec
+	}
+	return prefix + codec
+}
+
+// trackMimeTypes returns the distinct mime types a track is published with, simulcast codecs included
+func trackMimeTypes(ti *livekit.TrackInfo) []string {
+	var mimes []string
+	add := func(mime string) {
+		if mime == "" {
+			return
+		}
+		for _, m := range mimes {
+			if strings.EqualFold(m, mime) {
+				return
+			}
+		}
+		mimes = append(mimes, mime)
+	}
+
+	add(ti.MimeType)
+	for _, c := range ti.Codecs {
+		add(c.MimeType)
+	}
+	return mimes
+}
+
+// reportDeprecatedCodecs reports each deprecated codec the track is published with and notifies the publisher if configured
+func (p *ParticipantImpl) reportDeprecatedCodecs(track types.MediaTrack) {
+	if len(p.params.CodecDeprecations) == 0 {
+		return
+	}
+
+	ti := track.ToProto()
+	for _, mime := range trackMimeTypes(ti) {
+		d := findCodecDeprecation(p.params.CodecDeprecations, mime)
+		if d == nil {
+			continue
+		}
+
+		p.params.Logger.Infow("track published with deprecated codec", "trackID", ti.Sid, "mime", mime, "cutoff", d.Cutoff)
+		p.params.Telemetry.TrackCodecDeprecated(context.Background(), p.ID(), p.Identity(), ti, mime)
+		if d.Notify {
+			p.sendCodecDeprecationNotice(&CodecDeprecationNotice{TrackID: livekit.TrackID(ti.Sid), Mime: mime}, d)
+		}
+	}
+}
+
+func (p *ParticipantImpl) sendCodecDeprecationNotice(notice *CodecDeprecationNotice, d *config.CodecDeprecationConfig) {
+	notice.Message = d.Message
+	if !d.Cutoff.IsZero() {
+		notice.Cutoff = d.Cutoff.Unix()
+	}
+	payload, err := json.Marshal(notice)
+	if err != nil {
+		p.params.Logger.Errorw("could not marshal codec deprecation notice", err)
+		return
+	}
+
+	topic := CodecDeprecationTopic
+	dp := &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload: payload,
+				Topic:   &topic,
+			},
+		},
+	}
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		p.params.Logger.Errorw("could not marshal codec deprecation notice", err)
+		return
+	}
+	if err := p.SendDataPacket(dp, data); err != nil {
+		p.params.Logger.Warnw("could not send codec deprecation notice", err)
+	}
+}
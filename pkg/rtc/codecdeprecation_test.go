@@ -0,0 +1,114 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
+)
+
+func TestCodecDeprecation(t *testing.T) {
+	now := time.Now()
+	deprecations := []config.CodecDeprecationConfig{
+		{Mime: "video/VP8", Cutoff: now.Add(-time.Minute)},
+		{Mime: "video/h264", Cutoff: now.Add(time.Hour)},
+		{Mime: "audio/red"},
+	}
+
+	t.Run("codecs are removed once cut off", func(t *testing.T) {
+		codecs := []*livekit.Codec{
+			{Mime: "audio/opus"},
+			{Mime: "audio/red"},
+			{Mime: "video/vp8"},
+			{Mime: "video/h264"},
+		}
+		filtered := FilterCutOffCodecs(codecs, deprecations, now)
+		require.Equal(t, []*livekit.Codec{codecs[0], codecs[1], codecs[3]}, filtered)
+
+		filtered = FilterCutOffCodecs(codecs, deprecations, now.Add(2*time.Hour))
+		require.Equal(t, []*livekit.Codec{codecs[0], codecs[1]}, filtered)
+
+		require.Equal(t, codecs, FilterCutOffCodecs(codecs, nil, now))
+	})
+
+	t.Run("publishes of deprecated codecs are reported", func(t *testing.T) {
+		p := newParticipantForTest("publisher")
+		p.params.CodecDeprecations = deprecations
+		telemetry := p.params.Telemetry.(*telemetryfakes.FakeTelemetryService)
+
+		track := &typesfakes.FakeMediaTrack{}
+		track.ToProtoReturns(&livekit.TrackInfo{
+			Sid:      "TR_video",
+			Type:     livekit.TrackType_VIDEO,
+			MimeType: "video/H264",
+			Codecs: []*livekit.SimulcastCodecInfo{
+				{MimeType: "video/H264"},
+				{MimeType: "video/AV1"},
+				{MimeType: "video/VP8"},
+			},
+		})
+		p.reportDeprecatedCodecs(track)
+
+		require.Equal(t, 2, telemetry.TrackCodecDeprecatedCallCount())
+		_, participantID, identity, ti, mime := telemetry.TrackCodecDeprecatedArgsForCall(0)
+		require.Equal(t, p.ID(), participantID)
+		require.Equal(t, p.Identity(), identity)
+		require.Equal(t, "TR_video", ti.Sid)
+		require.Equal(t, "video/H264", mime)
+		_, _, _, _, mime = telemetry.TrackCodecDeprecatedArgsForCall(1)
+		require.Equal(t, "video/VP8", mime)
+
+		track.ToProtoReturns(&livekit.TrackInfo{Sid: "TR_audio", Type: livekit.TrackType_AUDIO, MimeType: "audio/opus"})
+		p.reportDeprecatedCodecs(track)
+		require.Equal(t, 2, telemetry.TrackCodecDeprecatedCallCount())
+	})
+
+	t.Run("tracks of cut off codecs are not published", func(t *testing.T) {
+		// the participant joined before the cutoff, it applies to the tracks it publishes since
+		p := newParticipantForTest("publisher")
+		p.params.CodecDeprecations = deprecations
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+
+		p.AddTrack(&livekit.AddTrackRequest{
+			Cid:             "vp8",
+			Type:            livekit.TrackType_VIDEO,
+			SimulcastCodecs: []*livekit.SimulcastCodec{{Codec: "vp8", Cid: "vp8"}},
+		})
+		require.Zero(t, sink.WriteMessageCallCount())
+		require.Nil(t, p.pendingTracks["vp8"])
+
+		// the codecs left are published
+		p.AddTrack(&livekit.AddTrackRequest{
+			Cid:             "backup",
+			Type:            livekit.TrackType_VIDEO,
+			SimulcastCodecs: []*livekit.SimulcastCodec{{Codec: "vp8", Cid: "backup"}, {Codec: "h264", Cid: "h264"}},
+		})
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+		res := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse)
+		published := res.Message.(*livekit.SignalResponse_TrackPublished).TrackPublished
+		require.Equal(t, "backup", published.Cid)
+		require.Len(t, published.Track.Codecs, 1)
+		require.Equal(t, "video/h264", published.Track.Codecs[0].MimeType)
+	})
+}
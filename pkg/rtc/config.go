@@ -15,12 +15,19 @@
 package rtc
 
 import (
+	"time"
+
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v3"
 
+	"github.com/livekit/protocol/livekit"
+
 	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	"github.com/livekit/mediatransportutil/pkg/rtcconfig"
 )
 
@@ -36,11 +43,52 @@ type WebRTCConfig struct {
 	Receiver      ReceiverConfig
 	Publisher     DirectionConfig
 	Subscriber    DirectionConfig
+
+	ConnectionQualityAlert config.ConnectionQualityAlertConfig
+	TrackStatsHistory      config.TrackStatsHistoryConfig
+	// H.264 profiles other than the default ones are negotiated
+	LenientH264ProfileMatching bool
+	// how long tracks restored without a receiver wait for their publisher
+	PotentialCodecTimeout time.Duration
 }
 
 type ReceiverConfig struct {
 	PacketBufferSizeVideo int
 	PacketBufferSizeAudio int
+	// packet buffers shared by the rooms of the node, each room has its own when nil
+	BufferPools *buffer.FactoryOfBufferFactory
+	// shared by all receivers of the node, nil when fan out is disabled
+	FanOutPool      *sfu.FanOutPool
+	FanOutThreshold int
+	// scores the connection quality of tracks, the default scorer when nil
+	ConnectionQualityScorer connectionquality.Scorer
+	// checks of the payloads of video packets, nil when disabled
+	PayloadIntegrity *buffer.PayloadIntegrityParams
+	// packets kept per video layer since its last key frame, 0 when the cache is disabled
+	KeyFrameCacheSize int
+	// bitrates expected of the video qualities of each codec
+	VideoBitrates config.VideoBitrateConfig
+	// forwarding policies of video tracks by source
+	Forwarding config.ForwardingConfig
+	// reordering of the packets received from publishers, packets are forwarded as received when the depth is 0
+	ReorderAudio buffer.ReorderParams
+	ReorderVideo buffer.ReorderParams
+}
+
+// reorderParams returns how the packets of tracks of the kind are reordered
+func (c ReceiverConfig) reorderParams(kind livekit.TrackType) buffer.ReorderParams {
+	if kind == livekit.TrackType_AUDIO {
+		return c.ReorderAudio
+	}
+	return c.ReorderVideo
+}
+
+// videoPacketBufferSize returns the number of video packets kept for NACK of tracks of the source
+func (c ReceiverConfig) videoPacketBufferSize(source livekit.TrackSource) int {
+	if c.Forwarding.PolicyFor(source) == config.ForwardingPolicyMaintainResolution && c.Forwarding.MaintainResolutionPacketBufferSize > 0 {
+		return c.Forwarding.MaintainResolutionPacketBufferSize
+	}
+	return c.PacketBufferSizeVideo
 }
 
 type RTPHeaderExtensionConfig struct {
@@ -133,14 +181,58 @@ func NewWebRTCConfig(conf *config.Config) (*WebRTCConfig, error) {
 		subscriberConfig.RTCPFeedback.Video = append(subscriberConfig.RTCPFeedback.Video, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBGoogREMB})
 	}
 
+	receiverConfig := ReceiverConfig{
+		PacketBufferSizeVideo: rtcConf.PacketBufferSizeVideo,
+		PacketBufferSizeAudio: rtcConf.PacketBufferSizeAudio,
+		BufferPools:           buffer.NewFactoryOfBufferFactory(rtcConf.PacketBufferSizeVideo, rtcConf.PacketBufferSizeAudio),
+		VideoBitrates:         conf.Video.Bitrates,
+		Forwarding:            rtcConf.Forwarding,
+		ReorderAudio: buffer.ReorderParams{
+			Depth:      rtcConf.Reorder.Audio.Depth,
+			MaxLatency: rtcConf.Reorder.Audio.MaxLatency,
+		},
+		ReorderVideo: buffer.ReorderParams{
+			Depth:      rtcConf.Reorder.Video.Depth,
+			MaxLatency: rtcConf.Reorder.Video.MaxLatency,
+		},
+	}
+	receiverConfig.ConnectionQualityScorer, err = connectionquality.NewScorer(rtcConf.ConnectionQuality)
+	if err != nil {
+		return nil, err
+	}
+	if pi := rtcConf.PayloadIntegrity; pi.Enabled {
+		receiverConfig.PayloadIntegrity = &buffer.PayloadIntegrityParams{
+			PLIThreshold:  pi.PLIThreshold,
+			FlagThreshold: pi.FlagThreshold,
+			Window:        pi.Window,
+		}
+	}
+	if kc := rtcConf.KeyFrameCache; kc.Enabled {
+		receiverConfig.KeyFrameCacheSize = kc.MaxPackets
+	}
+	if expected := conf.Startup.ExpectedLoad; expected.VideoStreams > 0 || expected.AudioStreams > 0 {
+		receiverConfig.BufferPools.Prewarm(expected.VideoStreams, expected.AudioStreams)
+	}
+	if rtcConf.FanOut.Enabled {
+		receiverConfig.FanOutPool = sfu.NewFanOutPool(sfu.FanOutPoolParams{
+			Workers:   rtcConf.FanOut.Workers,
+			BatchSize: rtcConf.FanOut.BatchSize,
+			QueueSize: rtcConf.FanOut.QueueSize,
+			OnBatch:   prometheus.RecordFanOutBatch,
+		})
+		receiverConfig.FanOutThreshold = rtcConf.FanOut.Threshold
+	}
+
 	return &WebRTCConfig{
 		WebRTCConfig: *webRTCConfig,
-		Receiver: ReceiverConfig{
-			PacketBufferSizeVideo: rtcConf.PacketBufferSizeVideo,
-			PacketBufferSizeAudio: rtcConf.PacketBufferSizeAudio,
-		},
-		Publisher:  publisherConfig,
-		Subscriber: subscriberConfig,
+		Receiver:     receiverConfig,
+		Publisher:    publisherConfig,
+		Subscriber:   subscriberConfig,
+
+		ConnectionQualityAlert:     rtcConf.ConnectionQuality.Alert,
+		TrackStatsHistory:          rtcConf.TrackStatsHistory,
+		LenientH264ProfileMatching: rtcConf.LenientH264ProfileMatching,
+		PotentialCodecTimeout:      rtcConf.PotentialCodecTimeout,
 	}, nil
 }
 
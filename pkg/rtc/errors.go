@@ -14,7 +14,12 @@
 
 package rtc
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/webrtc/v3"
+)
 
 var (
 	ErrRoomClosed              = errors.New("room has already closed")
@@ -29,6 +34,23 @@ var (
 	ErrEmptyParticipantID      = errors.New("participant ID cannot be empty")
 	ErrMissingGrants           = errors.New("VideoGrant is missing")
 	ErrInternalError           = errors.New("internal error")
+	ErrParticipantNotPending   = errors.New("participant is not waiting to be admitted")
+	ErrParticipantNotInRoom    = errors.New("participant is not in the room")
+	ErrParticipantPending      = errors.New("participant is waiting to be admitted")
+	ErrRoomNotActive           = errors.New("room is not active yet")
+	ErrRoomExpired             = errors.New("room has expired")
+	ErrRoomLocked              = errors.New("room is locked")
+	ErrParticipantClosing      = errors.New("participant is closing")
+	ErrInvalidTransition       = errors.New("invalid participant lifecycle transition")
+	ErrInvalidRoomCommand      = errors.New("invalid room command")
+	ErrPushToTalkDisabled      = errors.New("push to talk is not enabled in the room")
+	ErrFloorQueueEmpty         = errors.New("no participant is waiting for the floor")
+	ErrNotFloorHolder          = errors.New("participant does not hold or wait for the floor")
+	ErrMetadataVersionMismatch = errors.New("metadata has changed since the expected version")
+	ErrMetadataNotObject       = errors.New("metadata is not a JSON object")
+	ErrMetadataPatchInvalid    = errors.New("metadata patch is not valid JSON")
+	ErrMetadataTooLarge        = errors.New("patched metadata exceeds the max metadata size")
+	ErrCodecCutOff             = errors.New("codec has been cut off and cannot be published anymore")
 
 	// Track subscription related
 	ErrNoTrackPermission         = errors.New("participant is not allowed to subscribe to this track")
@@ -37,4 +59,6 @@ var (
 	ErrTrackNotAttached          = errors.New("track is not yet attached")
 	ErrTrackNotBound             = errors.New("track not bound")
 	ErrSubscriptionLimitExceeded = errors.New("participant has exceeded its subscription limit")
+	ErrSubscriptionFiltered      = errors.New("track is excluded by the subscribe filter of the participant")
+	ErrCodecBitDepthUnsupported  = fmt.Errorf("%w: video bit depth is higher than the codec profile carries", webrtc.ErrUnsupportedCodec)
 )
@@ -0,0 +1,312 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
+)
+
+type FloorPolicy string
+
+const (
+	// FloorPolicyFIFO grants the floor to requests in order, as soon as it is free
+	FloorPolicyFIFO FloorPolicy = "fifo"
+	// FloorPolicyHostApproval queues requests until a host grants the floor
+	FloorPolicyHostApproval FloorPolicy = "host_approval"
+)
+
+type FloorState struct {
+	PushToTalk bool                        `json:"push_to_talk"`
+	Policy     FloorPolicy                 `json:"policy,omitempty"`
+	Holder     livekit.ParticipantIdentity `json:"holder,omitempty"`
+	// ExpiresAt is in unix seconds, 0 when the grant does not expire
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+	// Queue holds the participants waiting for the floor, in order
+	Queue []livekit.ParticipantIdentity `json:"queue,omitempty"`
+	// Version increases with every change, announcements can arrive out of order
+	Version uint64 `json:"version"`
+}
+
+// QueuePosition returns the 1-based position of identity in the queue, 0 when it is not waiting
+func (s *FloorState) QueuePosition(identity livekit.ParticipantIdentity) int {
+	if s == nil {
+		return 0
+	}
+	return slices.Index(s.Queue, identity) + 1
+}
+
+type floorRequest struct {
+	identity livekit.ParticipantIdentity
+	duration time.Duration
+}
+
+// FloorControl decides who holds the floor of a push to talk room and keeps the queue of participants
+// waiting for it. Changes are reported to the OnChange callback, outside of its lock.
+type FloorControl struct {
+	resources *sutils.ResourceTracker
+
+	lock      sync.Mutex
+	opts      *PushToTalkOptions
+	holder    livekit.ParticipantIdentity
+	expiresAt time.Time
+	timer     *sutils.TrackedTimer
+	queue     []floorRequest
+	version   uint64
+	// grant changes whenever the holder does, to ignore expiry timers of earlier grants
+	grant uint64
+
+	onChange func(state *FloorState)
+}
+
+func NewFloorControl(resources *sutils.ResourceTracker) *FloorControl {
+	return &FloorControl{
+		resources: resources,
+	}
+}
+
+func (f *FloorControl) OnChange(fn func(state *FloorState)) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.onChange = fn
+}
+
+// Configure enables floor control with opts, or disables it when opts is nil or not enabled.
+// The floor is released and the queue is cleared.
+func (f *FloorControl) Configure(opts *PushToTalkOptions) *FloorState {
+	f.lock.Lock()
+	f.opts = nil
+	if opts != nil && opts.Enabled {
+		o := *opts
+		f.opts = &o
+	}
+	f.clearHolderLocked()
+	f.queue = nil
+	return f.changedLocked()
+}
+
+func (f *FloorControl) Enabled() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.opts != nil
+}
+
+func (f *FloorControl) State() *FloorState {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.stateLocked()
+}
+
+// CanSpeak returns false for participants whose audio is not forwarded, when floor control is enabled
+// and they do not hold the floor. Recorders, agents and hidden participants are not limited.
+func (f *FloorControl) CanSpeak(p types.LocalParticipant) bool {
+	if p.IsRecorder() || p.IsAgent() || p.Hidden() {
+		return true
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.opts == nil || (f.opts.ExcludeHosts && isHost(p)) {
+		return true
+	}
+	return p.Identity() == f.holder
+}
+
+// Request gives the floor to identity when the policy allows it, or adds it to the end of the queue
+func (f *FloorControl) Request(identity livekit.ParticipantIdentity, duration time.Duration) (*FloorState, error) {
+	f.lock.Lock()
+	if f.opts == nil {
+		f.lock.Unlock()
+		return nil, ErrPushToTalkDisabled
+	}
+	if f.holder == identity || f.indexLocked(identity) >= 0 {
+		state := f.stateLocked()
+		f.lock.Unlock()
+		return state, nil
+	}
+
+	if f.opts.GetPolicy() == FloorPolicyFIFO && f.holder == "" && len(f.queue) == 0 {
+		f.grantLocked(identity, duration)
+	} else {
+		f.queue = append(f.queue, floorRequest{identity: identity, duration: duration})
+	}
+	return f.changedLocked(), nil
+}
+
+// Grant gives the floor to identity, replacing the current holder, or to the first participant in the queue
+// when identity is empty
+func (f *FloorControl) Grant(identity livekit.ParticipantIdentity, duration time.Duration) (*FloorState, error) {
+	f.lock.Lock()
+	if f.opts == nil {
+		f.lock.Unlock()
+		return nil, ErrPushToTalkDisabled
+	}
+	if identity == "" {
+		if len(f.queue) == 0 {
+			f.lock.Unlock()
+			return nil, ErrFloorQueueEmpty
+		}
+		identity = f.queue[0].identity
+		if duration == 0 {
+			duration = f.queue[0].duration
+		}
+	}
+
+	f.grantLocked(identity, duration)
+	return f.changedLocked(), nil
+}
+
+// Release takes the floor away from identity or removes it from the queue, or releases the floor of
+// whoever holds it when identity is empty. With the FIFO policy the floor goes to the next in the queue.
+func (f *FloorControl) Release(identity livekit.ParticipantIdentity) (*FloorState, error) {
+	f.lock.Lock()
+	if f.opts == nil {
+		f.lock.Unlock()
+		return nil, ErrPushToTalkDisabled
+	}
+	if identity == "" {
+		identity = f.holder
+	}
+	if identity == "" || !f.removeLocked(identity) {
+		f.lock.Unlock()
+		return nil, ErrNotFloorHolder
+	}
+	return f.changedLocked(), nil
+}
+
+// Remove drops identity from the floor and the queue, for participants leaving the room
+func (f *FloorControl) Remove(identity livekit.ParticipantIdentity) {
+	f.lock.Lock()
+	if !f.removeLocked(identity) {
+		f.lock.Unlock()
+		return
+	}
+	f.changedLocked()
+}
+
+// Stop cancels the expiry of the current grant, without reporting a change
+func (f *FloorControl) Stop() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.clearHolderLocked()
+	f.queue = nil
+	f.onChange = nil
+}
+
+func (f *FloorControl) expire(grant uint64) {
+	f.lock.Lock()
+	if f.grant != grant || f.holder == "" {
+		// released or granted again in the meantime
+		f.lock.Unlock()
+		return
+	}
+	f.clearHolderLocked()
+	f.advanceLocked()
+	f.changedLocked()
+}
+
+func (f *FloorControl) removeLocked(identity livekit.ParticipantIdentity) bool {
+	if i := f.indexLocked(identity); i >= 0 {
+		f.queue = slices.Delete(f.queue, i, i+1)
+		return true
+	}
+	if f.holder == "" || f.holder != identity {
+		return false
+	}
+	f.clearHolderLocked()
+	f.advanceLocked()
+	return true
+}
+
+// advanceLocked gives a free floor to the next in the queue, with the FIFO policy
+func (f *FloorControl) advanceLocked() {
+	if f.opts == nil || f.opts.GetPolicy() != FloorPolicyFIFO || f.holder != "" || len(f.queue) == 0 {
+		return
+	}
+	f.grantLocked(f.queue[0].identity, f.queue[0].duration)
+}
+
+func (f *FloorControl) grantLocked(identity livekit.ParticipantIdentity, duration time.Duration) {
+	if i := f.indexLocked(identity); i >= 0 {
+		f.queue = slices.Delete(f.queue, i, i+1)
+	}
+	if maxDuration := time.Duration(f.opts.MaxFloorDuration) * time.Second; maxDuration > 0 && (duration <= 0 || duration > maxDuration) {
+		duration = maxDuration
+	}
+
+	f.clearHolderLocked()
+	f.holder = identity
+	if duration > 0 {
+		grant := f.grant
+		f.expiresAt = time.Now().Add(duration)
+		f.timer = f.resources.AfterFunc("room.floorExpiry", duration, func() {
+			f.expire(grant)
+		})
+	}
+}
+
+func (f *FloorControl) clearHolderLocked() {
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	f.holder = ""
+	f.expiresAt = time.Time{}
+	f.grant++
+}
+
+func (f *FloorControl) indexLocked(identity livekit.ParticipantIdentity) int {
+	return slices.IndexFunc(f.queue, func(r floorRequest) bool {
+		return r.identity == identity
+	})
+}
+
+func (f *FloorControl) stateLocked() *FloorState {
+	state := &FloorState{
+		PushToTalk: f.opts != nil,
+		Holder:     f.holder,
+		Version:    f.version,
+	}
+	if f.opts != nil {
+		state.Policy = f.opts.GetPolicy()
+	}
+	if !f.expiresAt.IsZero() {
+		state.ExpiresAt = f.expiresAt.Unix()
+	}
+	for _, r := range f.queue {
+		state.Queue = append(state.Queue, r.identity)
+	}
+	return state
+}
+
+// changedLocked records a change and reports it, it unlocks the floor control
+func (f *FloorControl) changedLocked() *FloorState {
+	f.version++
+	state := f.stateLocked()
+	onChange := f.onChange
+	f.lock.Unlock()
+
+	if onChange != nil {
+		onChange(state)
+	}
+	return state
+}
@@ -0,0 +1,161 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
+)
+
+func TestFloorControl(t *testing.T) {
+	newFloorControl := func(opts *PushToTalkOptions) (*FloorControl, *[]*FloorState) {
+		f := NewFloorControl(sutils.NewResourceTracker(logger.GetLogger()))
+		f.Configure(opts)
+		var changes []*FloorState
+		f.OnChange(func(state *FloorState) {
+			changes = append(changes, state)
+		})
+		return f, &changes
+	}
+
+	t.Run("fifo grants requests in order", func(t *testing.T) {
+		f, changes := newFloorControl(&PushToTalkOptions{Enabled: true})
+
+		state, err := f.Request("a", 0)
+		require.NoError(t, err)
+		require.Equal(t, livekit.ParticipantIdentity("a"), state.Holder)
+
+		_, err = f.Request("b", 0)
+		require.NoError(t, err)
+		state, err = f.Request("c", 0)
+		require.NoError(t, err)
+		require.Equal(t, []livekit.ParticipantIdentity{"b", "c"}, state.Queue)
+		require.Equal(t, 2, state.QueuePosition("c"))
+
+		// repeated requests keep their place
+		state, err = f.Request("b", 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, state.QueuePosition("b"))
+		require.Len(t, *changes, 3)
+
+		// withdrawn requests leave the queue
+		state, err = f.Release("b")
+		require.NoError(t, err)
+		require.Equal(t, []livekit.ParticipantIdentity{"c"}, state.Queue)
+
+		state, err = f.Release("a")
+		require.NoError(t, err)
+		require.Equal(t, livekit.ParticipantIdentity("c"), state.Holder)
+		require.Empty(t, state.Queue)
+
+		_, err = f.Release("a")
+		require.ErrorIs(t, err, ErrNotFloorHolder)
+
+		// versions increase with every change
+		for i := 1; i < len(*changes); i++ {
+			require.Greater(t, (*changes)[i].Version, (*changes)[i-1].Version)
+		}
+	})
+
+	t.Run("host approval queues requests", func(t *testing.T) {
+		f, _ := newFloorControl(&PushToTalkOptions{Enabled: true, Policy: FloorPolicyHostApproval})
+
+		state, err := f.Request("a", 0)
+		require.NoError(t, err)
+		require.Empty(t, state.Holder)
+		require.Equal(t, FloorPolicyHostApproval, state.Policy)
+		_, err = f.Request("b", 0)
+		require.NoError(t, err)
+
+		state, err = f.Grant("", 0)
+		require.NoError(t, err)
+		require.Equal(t, livekit.ParticipantIdentity("a"), state.Holder)
+
+		// the floor is not passed on without approval
+		state, err = f.Release("")
+		require.NoError(t, err)
+		require.Empty(t, state.Holder)
+		require.Equal(t, []livekit.ParticipantIdentity{"b"}, state.Queue)
+
+		state, err = f.Grant("b", 0)
+		require.NoError(t, err)
+		require.Equal(t, livekit.ParticipantIdentity("b"), state.Holder)
+		require.Empty(t, state.Queue)
+
+		_, err = f.Grant("", 0)
+		require.ErrorIs(t, err, ErrFloorQueueEmpty)
+	})
+
+	t.Run("expired grants pass the floor on", func(t *testing.T) {
+		f, _ := newFloorControl(&PushToTalkOptions{Enabled: true})
+
+		state, err := f.Request("a", 20*time.Millisecond)
+		require.NoError(t, err)
+		require.NotZero(t, state.ExpiresAt)
+		_, err = f.Request("b", 0)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return f.State().Holder == "b"
+		}, time.Second, 5*time.Millisecond)
+		require.Zero(t, f.State().ExpiresAt)
+	})
+
+	t.Run("participants leaving are removed", func(t *testing.T) {
+		f, changes := newFloorControl(&PushToTalkOptions{Enabled: true})
+		_, _ = f.Request("a", 0)
+		_, _ = f.Request("b", 0)
+		_, _ = f.Request("c", 0)
+
+		f.Remove("b")
+		f.Remove("a")
+		require.Equal(t, livekit.ParticipantIdentity("c"), f.State().Holder)
+		require.Empty(t, f.State().Queue)
+
+		numChanges := len(*changes)
+		f.Remove("unknown")
+		require.Len(t, *changes, numChanges)
+	})
+
+	t.Run("only the holder can speak", func(t *testing.T) {
+		f, _ := newFloorControl(&PushToTalkOptions{Enabled: true, ExcludeHosts: true})
+		speaker := NewMockParticipant("speaker", types.CurrentProtocol, false, false)
+		listener := NewMockParticipant("listener", types.CurrentProtocol, false, false)
+		host := NewMockParticipant("host", types.CurrentProtocol, false, false)
+		host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+		agent := NewMockParticipant("agent", types.CurrentProtocol, false, false)
+		agent.IsAgentReturns(true)
+
+		_, _ = f.Request("speaker", 0)
+		require.True(t, f.CanSpeak(speaker))
+		require.False(t, f.CanSpeak(listener))
+		require.True(t, f.CanSpeak(host))
+		require.True(t, f.CanSpeak(agent))
+
+		f.Configure(nil)
+		require.True(t, f.CanSpeak(listener))
+		_, err := f.Request("listener", 0)
+		require.ErrorIs(t, err, ErrPushToTalkDisabled)
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+// the join completes without the first media stage when nothing is published or subscribed to
+// within this time of the transport connecting, e.g. a participant only sending data
+const joinFirstMediaTimeout = 10 * time.Second
+
+// joinLatencyTracker times the stages of a join from the start of the session, each stage starting when
+// the previous one ended. The breakdown is passed on once, when media flows, when it is clear that it
+// does not or when the participant leaves during the join.
+type joinLatencyTracker struct {
+	onCompleted func(latency telemetry.JoinLatency)
+
+	lock         sync.Mutex
+	latency      telemetry.JoinLatency
+	stageStart   time.Time
+	answered     bool
+	connected    bool
+	timer        *time.Timer
+	hasCompleted bool
+}
+
+func newJoinLatencyTracker(
+	sessionStart time.Time,
+	signalLatency telemetry.JoinLatency,
+	onCompleted func(latency telemetry.JoinLatency),
+) *joinLatencyTracker {
+	return &joinLatencyTracker{
+		onCompleted: onCompleted,
+		latency: telemetry.JoinLatency{
+			TokenValidation: signalLatency.TokenValidation,
+			Routing:         signalLatency.Routing,
+		},
+		stageStart: sessionStart,
+	}
+}
+
+// offerAnswered marks the first offer answered, by the server for the publisher or by the client for the subscriber
+func (j *joinLatencyTracker) offerAnswered() {
+	if j == nil {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.hasCompleted || j.answered {
+		return
+	}
+	j.answered = true
+	j.latency.OfferAnswer = j.endStageLocked()
+}
+
+func (j *joinLatencyTracker) transportConnected() {
+	if j == nil {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.transportConnectedLocked()
+	if !j.hasCompleted && j.timer == nil {
+		j.timer = time.AfterFunc(joinFirstMediaTimeout, j.complete)
+	}
+}
+
+func (j *joinLatencyTracker) transportConnectedLocked() {
+	if j.hasCompleted || j.connected {
+		return
+	}
+	j.answered = true
+	j.connected = true
+	j.latency.ICE = j.endStageLocked()
+}
+
+// mediaStarted marks the first packet of a published track or the first subscribed track bound, it completes the join
+func (j *joinLatencyTracker) mediaStarted() {
+	if j == nil {
+		return
+	}
+	j.lock.Lock()
+	if j.hasCompleted {
+		j.lock.Unlock()
+		return
+	}
+	// the transport of the media can connect before the primary transport
+	j.transportConnectedLocked()
+	j.latency.FirstMedia = j.endStageLocked()
+	j.lock.Unlock()
+
+	j.complete()
+}
+
+// complete passes on the stages reached so far
+func (j *joinLatencyTracker) complete() {
+	if j == nil {
+		return
+	}
+	j.lock.Lock()
+	if j.hasCompleted {
+		j.lock.Unlock()
+		return
+	}
+	j.hasCompleted = true
+	if j.timer != nil {
+		j.timer.Stop()
+		j.timer = nil
+	}
+	latency := j.latency
+	j.lock.Unlock()
+
+	j.onCompleted(latency)
+}
+
+func (j *joinLatencyTracker) endStageLocked() time.Duration {
+	now := time.Now()
+	d := now.Sub(j.stageStart)
+	j.stageStart = now
+	return d
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+func TestJoinLatencyTracker(t *testing.T) {
+	t.Run("completes on first media", func(t *testing.T) {
+		var completed []telemetry.JoinLatency
+		j := newJoinLatencyTracker(
+			time.Now().Add(-30*time.Millisecond),
+			telemetry.JoinLatency{TokenValidation: time.Millisecond, Routing: 2 * time.Millisecond, ICE: time.Hour},
+			func(latency telemetry.JoinLatency) {
+				completed = append(completed, latency)
+			},
+		)
+
+		j.offerAnswered()
+		j.transportConnected()
+		j.mediaStarted()
+		// later stages and the participant leaving do not complete again
+		j.mediaStarted()
+		j.complete()
+
+		require.Len(t, completed, 1)
+		latency := completed[0]
+		require.Equal(t, time.Millisecond, latency.TokenValidation)
+		require.Equal(t, 2*time.Millisecond, latency.Routing)
+		require.GreaterOrEqual(t, latency.OfferAnswer, 30*time.Millisecond)
+		require.Less(t, latency.ICE, time.Second)
+		require.Len(t, latency.Stages(), 5)
+	})
+
+	t.Run("leaving completes with the stages reached", func(t *testing.T) {
+		var completed []telemetry.JoinLatency
+		j := newJoinLatencyTracker(time.Now(), telemetry.JoinLatency{}, func(latency telemetry.JoinLatency) {
+			completed = append(completed, latency)
+		})
+
+		j.offerAnswered()
+		j.offerAnswered()
+		j.complete()
+		j.transportConnected()
+
+		require.Len(t, completed, 1)
+		require.NotContains(t, completed[0].Stages(), telemetry.JoinStageICE)
+		require.Zero(t, completed[0].FirstMedia)
+	})
+
+	t.Run("nil tracker", func(t *testing.T) {
+		var j *joinLatencyTracker
+		j.offerAnswered()
+		j.transportConnected()
+		j.mediaStarted()
+		j.complete()
+	})
+}
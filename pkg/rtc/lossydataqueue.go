@@ -0,0 +1,134 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// lossyDataChannel is the part of a data channel used by the lossy data queue
+type lossyDataChannel interface {
+	BufferedAmount() uint64
+	Send(data []byte) error
+}
+
+type lossyDataMessage struct {
+	data     []byte
+	priority int
+}
+
+// lossyDataQueue holds back lossy data messages while the data channel buffers more than the configured amount,
+// and sends them once it drains. When the queue is full, the drop policy and the topic priorities pick the message
+// that is dropped, so a congested subscriber loses the least important messages instead of receiving every
+// message late.
+type lossyDataQueue struct {
+	conf   config.LossyDataConfig
+	dc     lossyDataChannel
+	logger logger.Logger
+
+	lock  sync.Mutex
+	queue []lossyDataMessage
+}
+
+func newLossyDataQueue(conf config.LossyDataConfig, dc lossyDataChannel, logger logger.Logger) *lossyDataQueue {
+	return &lossyDataQueue{
+		conf:   conf,
+		dc:     dc,
+		logger: logger,
+	}
+}
+
+// Send sends the message right away when nothing is queued and the data channel is not congested, and queues it
+// otherwise. It returns ErrDataChannelBufferFull when the message itself is dropped.
+func (q *lossyDataQueue) Send(dp *livekit.DataPacket, data []byte) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.queue) == 0 && !q.congested() {
+		return q.dc.Send(data)
+	}
+
+	msg := lossyDataMessage{data: data, priority: q.priority(dp.GetUser().GetTopic())}
+	if len(q.queue) < q.conf.QueueSize {
+		q.queue = append(q.queue, msg)
+		return nil
+	}
+
+	drop := q.dropIndex(msg.priority)
+	if drop < 0 {
+		return ErrDataChannelBufferFull
+	}
+	q.queue = append(slices.Delete(q.queue, drop, drop+1), msg)
+	return nil
+}
+
+// Flush sends the queued messages until the data channel is congested again, it runs when the buffered amount
+// of the data channel drops to the threshold.
+func (q *lossyDataQueue) Flush() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	sent := 0
+	for _, msg := range q.queue {
+		if q.congested() {
+			break
+		}
+		if err := q.dc.Send(msg.data); err != nil {
+			q.logger.Debugw("could not send queued lossy data message", "error", err)
+		}
+		sent++
+	}
+	q.queue = slices.Delete(q.queue, 0, sent)
+}
+
+func (q *lossyDataQueue) congested() bool {
+	return q.dc.BufferedAmount() > q.conf.MaxBufferedAmount
+}
+
+func (q *lossyDataQueue) priority(topic string) int {
+	for _, tp := range q.conf.TopicPriorities {
+		if dataTopicMatches(tp.Topic, topic) {
+			return tp.Priority
+		}
+	}
+	return 0
+}
+
+// dropIndex returns the index of the queued message to drop for a new message with the priority, or -1 to drop
+// the new message. Only messages with the lowest priority are dropped, among them the oldest with the
+// drop_oldest policy and the newest, the new message when it has that priority, otherwise.
+func (q *lossyDataQueue) dropIndex(priority int) int {
+	drop := -1
+	for i, msg := range q.queue {
+		switch {
+		case msg.priority > priority:
+		case drop < 0 || msg.priority < q.queue[drop].priority:
+			drop = i
+		case msg.priority == q.queue[drop].priority && q.conf.DropPolicy != config.LossyDataDropPolicyOldest:
+			drop = i
+		}
+	}
+	if drop >= 0 && q.queue[drop].priority == priority && q.conf.DropPolicy != config.LossyDataDropPolicyOldest {
+		return -1
+	}
+	return drop
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+type testLossyDataChannel struct {
+	bufferedAmount uint64
+	sent           []string
+}
+
+func (dc *testLossyDataChannel) BufferedAmount() uint64 {
+	return dc.bufferedAmount
+}
+
+func (dc *testLossyDataChannel) Send(data []byte) error {
+	dc.sent = append(dc.sent, string(data))
+	return nil
+}
+
+func lossyDataPacket(topic string) *livekit.DataPacket {
+	return &livekit.DataPacket{
+		Kind: livekit.DataPacket_LOSSY,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{Topic: proto.String(topic)},
+		},
+	}
+}
+
+func TestLossyDataQueue(t *testing.T) {
+	conf := config.LossyDataConfig{
+		MaxBufferedAmount: 100,
+		QueueSize:         2,
+		TopicPriorities: []config.LossyDataTopicPriority{
+			{Topic: "chat", Priority: 10},
+			{Topic: "cursor.*", Priority: -1},
+		},
+	}
+
+	t.Run("sends right away when not congested", func(t *testing.T) {
+		dc := &testLossyDataChannel{}
+		q := newLossyDataQueue(conf, dc, logger.GetLogger())
+		require.NoError(t, q.Send(lossyDataPacket("state"), []byte("a")))
+		require.Equal(t, []string{"a"}, dc.sent)
+	})
+
+	t.Run("drops the newest message by default", func(t *testing.T) {
+		dc := &testLossyDataChannel{bufferedAmount: 200}
+		q := newLossyDataQueue(conf, dc, logger.GetLogger())
+		require.NoError(t, q.Send(lossyDataPacket("state"), []byte("a")))
+		require.NoError(t, q.Send(lossyDataPacket("state"), []byte("b")))
+		require.ErrorIs(t, q.Send(lossyDataPacket("state"), []byte("c")), ErrDataChannelBufferFull)
+		require.Empty(t, dc.sent)
+
+		dc.bufferedAmount = 0
+		q.Flush()
+		require.Equal(t, []string{"a", "b"}, dc.sent)
+
+		// nothing left queued, messages go out right away again
+		require.NoError(t, q.Send(lossyDataPacket("state"), []byte("d")))
+		require.Equal(t, []string{"a", "b", "d"}, dc.sent)
+	})
+
+	t.Run("drops the oldest message", func(t *testing.T) {
+		conf := conf
+		conf.DropPolicy = config.LossyDataDropPolicyOldest
+		dc := &testLossyDataChannel{bufferedAmount: 200}
+		q := newLossyDataQueue(conf, dc, logger.GetLogger())
+		for _, data := range []string{"a", "b", "c"} {
+			require.NoError(t, q.Send(lossyDataPacket("state"), []byte(data)))
+		}
+
+		dc.bufferedAmount = 0
+		q.Flush()
+		require.Equal(t, []string{"b", "c"}, dc.sent)
+	})
+
+	t.Run("drops lower priority messages first", func(t *testing.T) {
+		dc := &testLossyDataChannel{bufferedAmount: 200}
+		q := newLossyDataQueue(conf, dc, logger.GetLogger())
+		require.NoError(t, q.Send(lossyDataPacket("cursor.move"), []byte("a")))
+		require.NoError(t, q.Send(lossyDataPacket("state"), []byte("b")))
+		require.NoError(t, q.Send(lossyDataPacket("chat"), []byte("c")))
+		require.NoError(t, q.Send(lossyDataPacket("chat"), []byte("d")))
+		require.ErrorIs(t, q.Send(lossyDataPacket("cursor.move"), []byte("e")), ErrDataChannelBufferFull)
+
+		dc.bufferedAmount = 0
+		q.Flush()
+		require.Equal(t, []string{"c", "d"}, dc.sent)
+	})
+
+	t.Run("flush stops when congested again", func(t *testing.T) {
+		dc := &testLossyDataChannel{bufferedAmount: 200}
+		q := newLossyDataQueue(conf, dc, logger.GetLogger())
+		require.NoError(t, q.Send(lossyDataPacket("state"), []byte("a")))
+		require.NoError(t, q.Send(lossyDataPacket("state"), []byte("b")))
+
+		dc.bufferedAmount = 100
+		q.Flush()
+		require.Equal(t, []string{"a", "b"}, dc.sent)
+
+		dc.bufferedAmount = 200
+		require.NoError(t, q.Send(lossyDataPacket("state"), []byte("c")))
+		require.Equal(t, []string{"a", "b"}, dc.sent)
+	})
+}
@@ -33,12 +33,20 @@ var opusCodecCapability = webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpu
 var redCodecCapability = webrtc.RTPCodecCapability{MimeType: sfu.MimeTypeAudioRed, ClockRate: 48000, Channels: 2, SDPFmtpLine: "111/111"}
 var videoRTX = webrtc.RTPCodecCapability{MimeType: videoRTXMimeType, ClockRate: 90000}
 
-func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedback RTCPFeedbackConfig, filterOutH264HighProfile bool) error {
+func registerCodecs(
+	me *webrtc.MediaEngine,
+	codecs []*livekit.Codec,
+	fmtpOverrides []*livekit.Codec,
+	rtcpFeedback RTCPFeedbackConfig,
+	filterOutH264HighProfile bool,
+	lenientH264ProfileMatching bool,
+) error {
 	opusCodec := opusCodecCapability
 	opusCodec.RTCPFeedback = rtcpFeedback.Audio
 	var opusPayload webrtc.PayloadType
 	if IsCodecEnabled(codecs, opusCodec) {
 		opusPayload = 111
+		opusCodec.SDPFmtpLine = applyFmtpOverrides(opusCodec.MimeType, opusCodec.SDPFmtpLine, fmtpOverrides)
 		if err := me.RegisterCodec(webrtc.RTPCodecParameters{
 			RTPCodecCapability: opusCodec,
 			PayloadType:        opusPayload,
@@ -59,7 +67,8 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 	rtxEnabled := IsCodecEnabled(codecs, videoRTX)
 
 	h264HighProfileFmtp := "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640032"
-	for _, codec := range []webrtc.RTPCodecParameters{
+	h264ConstrainedHighProfileFmtp := "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640c1f"
+	videoCodecs := []webrtc.RTPCodecParameters{
 		{
 			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000, RTCPFeedback: rtcpFeedback.Video},
 			PayloadType:        96,
@@ -72,6 +81,11 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, ClockRate: 90000, SDPFmtpLine: "profile-id=1", RTCPFeedback: rtcpFeedback.Video},
 			PayloadType:        100,
 		},
+		{
+			// 10 bit, used for HDR. Without it, offers for profile 2 are answered with another profile
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, ClockRate: 90000, SDPFmtpLine: "profile-id=2", RTCPFeedback: rtcpFeedback.Video},
+			PayloadType:        102,
+		},
 		{
 			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f", RTCPFeedback: rtcpFeedback.Video},
 			PayloadType:        125,
@@ -88,14 +102,48 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeAV1, ClockRate: 90000, RTCPFeedback: rtcpFeedback.Video},
 			PayloadType:        35,
 		},
-	} {
-		if filterOutH264HighProfile && codec.RTPCodecCapability.SDPFmtpLine == h264HighProfileFmtp {
+	}
+	if lenientH264ProfileMatching {
+		// profile-level-id of offers is matched on the profile and its constraints, not the level. Registering the
+		// profiles encoders commonly send keeps those offers on H.264 instead of them falling back to another codec,
+		// the streams are forwarded to subscribers of any of the profiles
+		videoCodecs = append(videoCodecs,
+			webrtc.RTPCodecParameters{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f", RTCPFeedback: rtcpFeedback.Video},
+				PayloadType:        39,
+			},
+			webrtc.RTPCodecParameters{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=0;profile-level-id=42001f", RTCPFeedback: rtcpFeedback.Video},
+				PayloadType:        41,
+			},
+			webrtc.RTPCodecParameters{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=4d001f", RTCPFeedback: rtcpFeedback.Video},
+				PayloadType:        43,
+			},
+			webrtc.RTPCodecParameters{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: h264ConstrainedHighProfileFmtp, RTCPFeedback: rtcpFeedback.Video},
+				PayloadType:        45,
+			},
+		)
+	}
+
+	registered := make(map[string]bool)
+	for _, codec := range videoCodecs {
+		if filterOutH264HighProfile && (codec.RTPCodecCapability.SDPFmtpLine == h264HighProfileFmtp || codec.RTPCodecCapability.SDPFmtpLine == h264ConstrainedHighProfileFmtp) {
 			continue
 		}
 		if codec.MimeType == videoRTXMimeType {
 			continue
 		}
 		if IsCodecEnabled(codecs, codec.RTPCodecCapability) {
+			// overrides can make variants of a codec identical, register those once
+			codec.SDPFmtpLine = applyFmtpOverrides(codec.MimeType, codec.SDPFmtpLine, fmtpOverrides)
+			key := strings.ToLower(codec.MimeType + " " + codec.SDPFmtpLine)
+			if registered[key] {
+				continue
+			}
+			registered[key] = true
+
 			if err := me.RegisterCodec(codec, webrtc.RTPCodecTypeVideo); err != nil {
 				return err
 			}
@@ -132,9 +180,15 @@ func registerHeaderExtensions(me *webrtc.MediaEngine, rtpHeaderExtension RTPHead
 	return nil
 }
 
-func createMediaEngine(codecs []*livekit.Codec, config DirectionConfig, filterOutH264HighProfile bool) (*webrtc.MediaEngine, error) {
+func createMediaEngine(
+	codecs []*livekit.Codec,
+	fmtpOverrides []*livekit.Codec,
+	config DirectionConfig,
+	filterOutH264HighProfile bool,
+	lenientH264ProfileMatching bool,
+) (*webrtc.MediaEngine, error) {
 	me := &webrtc.MediaEngine{}
-	if err := registerCodecs(me, codecs, config.RTCPFeedback, filterOutH264HighProfile); err != nil {
+	if err := registerCodecs(me, codecs, fmtpOverrides, config.RTCPFeedback, filterOutH264HighProfile, lenientH264ProfileMatching); err != nil {
 		return nil, err
 	}
 
@@ -157,6 +211,39 @@ func IsCodecEnabled(codecs []*livekit.Codec, cap webrtc.RTPCodecCapability) bool
 	return false
 }
 
+// applyFmtpOverrides merges the fmtp lines of overrides matching the mime type into fmtpLine, in order.
+// Parameters of an override replace parameters with the same name, others are appended.
+func applyFmtpOverrides(mime string, fmtpLine string, overrides []*livekit.Codec) string {
+	for _, o := range overrides {
+		if strings.EqualFold(o.Mime, mime) && o.FmtpLine != "" {
+			fmtpLine = mergeFmtpLine(fmtpLine, o.FmtpLine)
+		}
+	}
+	return fmtpLine
+}
+
+func mergeFmtpLine(fmtpLine string, override string) string {
+	var params []string
+	index := make(map[string]int)
+	for _, line := range []string{fmtpLine, override} {
+		for _, param := range strings.Split(line, ";") {
+			param = strings.TrimSpace(param)
+			if param == "" {
+				continue
+			}
+			key, _, _ := strings.Cut(param, "=")
+			key = strings.ToLower(strings.TrimSpace(key))
+			if i, ok := index[key]; ok {
+				params[i] = param
+			} else {
+				index[key] = len(params)
+				params = append(params, param)
+			}
+		}
+	}
+	return strings.Join(params, ";")
+}
+
 func selectAlternativeVideoCodec(enabledCodecs []*livekit.Codec) string {
 	// sort these by compatibility, since we are looking for backups
 	if slices.ContainsFunc(enabledCodecs, func(c *livekit.Codec) bool {
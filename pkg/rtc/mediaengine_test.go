@@ -38,3 +38,67 @@ func TestIsCodecEnabled(t *testing.T) {
 		require.False(t, IsCodecEnabled(enabledCodecs, webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}))
 	})
 }
+
+func TestApplyFmtpOverrides(t *testing.T) {
+	overrides := []*livekit.Codec{
+		{Mime: "video/h264", FmtpLine: "profile-level-id=640c1f"},
+		{Mime: "audio/opus", FmtpLine: "maxaveragebitrate=64000"},
+		{Mime: "video/vp9", FmtpLine: "profile-id=2"},
+		{Mime: "video/h264", FmtpLine: "profile-level-id=42e01f"},
+	}
+
+	// later overrides of the same parameter win, order of parameters is kept
+	require.Equal(t,
+		"level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+		applyFmtpOverrides(webrtc.MimeTypeH264, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640032", overrides),
+	)
+	require.Equal(t, "minptime=10;useinbandfec=1;maxaveragebitrate=64000", applyFmtpOverrides(webrtc.MimeTypeOpus, "minptime=10;useinbandfec=1", overrides))
+	require.Equal(t, "profile-id=2", applyFmtpOverrides(webrtc.MimeTypeVP9, "profile-id=0", overrides))
+	require.Equal(t, "", applyFmtpOverrides(webrtc.MimeTypeVP8, "", overrides))
+
+	_, err := createMediaEngine([]*livekit.Codec{{Mime: "video/vp9"}, {Mime: "video/h264"}}, overrides, DirectionConfig{}, false, false)
+	require.NoError(t, err)
+}
+
+func TestLenientH264ProfileMatching(t *testing.T) {
+	// a publisher preferring H.264 baseline, as some hardware encoders do, over VP8
+	offerer := &webrtc.MediaEngine{}
+	require.NoError(t, offerer.RegisterCodec(webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f"},
+		PayloadType:        102,
+	}, webrtc.RTPCodecTypeVideo))
+	require.NoError(t, offerer.RegisterCodec(webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+		PayloadType:        96,
+	}, webrtc.RTPCodecTypeVideo))
+	pub, err := webrtc.NewAPI(webrtc.WithMediaEngine(offerer)).NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pub.Close()
+	_, err = pub.AddTransceiverFromKind(webrtc.RTPCodecTypeVideo, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+	require.NoError(t, err)
+	offer, err := pub.CreateOffer(nil)
+	require.NoError(t, err)
+
+	answerCodecs := func(lenient bool) []webrtc.RTPCodecParameters {
+		me, err := createMediaEngine([]*livekit.Codec{{Mime: "video/vp8"}, {Mime: "video/h264"}}, nil, DirectionConfig{}, false, lenient)
+		require.NoError(t, err)
+		pc, err := webrtc.NewAPI(webrtc.WithMediaEngine(me)).NewPeerConnection(webrtc.Configuration{})
+		require.NoError(t, err)
+		defer pc.Close()
+
+		require.NoError(t, pc.SetRemoteDescription(offer))
+		_, err = pc.CreateAnswer(nil)
+		require.NoError(t, err)
+		return pc.GetTransceivers()[0].Receiver().GetParameters().Codecs
+	}
+
+	// the baseline profile does not match the registered ones, the publisher falls back to VP8
+	codecs := answerCodecs(false)
+	require.Len(t, codecs, 1)
+	require.Equal(t, webrtc.MimeTypeVP8, codecs[0].MimeType)
+
+	codecs = answerCodecs(true)
+	require.Len(t, codecs, 2)
+	require.Equal(t, webrtc.MimeTypeH264, codecs[0].MimeType)
+	require.Equal(t, webrtc.MimeTypeVP8, codecs[1].MimeType)
+}
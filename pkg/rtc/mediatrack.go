@@ -18,6 +18,7 @@ import (
 	"context"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pion/rtcp"
 	"github.com/pion/webrtc/v3"
@@ -31,9 +32,14 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/livekit-server/pkg/sfu/packetcapture"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
 )
 
+const defaultLoadBalanceThreshold = 20
+
 // MediaTrack represents a WebRTC track that needs to be forwarded
 // Implements MediaTrack and PublishedTrack interface
 type MediaTrack struct {
@@ -46,7 +52,9 @@ type MediaTrack struct {
 
 	dynacastManager *DynacastManager
 
-	lock sync.RWMutex
+	lock                    sync.RWMutex
+	capture                 *packetcapture.Capture
+	onSubscriberCountChange func(count int)
 }
 
 type MediaTrackParams struct {
@@ -65,6 +73,14 @@ type MediaTrackParams struct {
 	Logger              logger.Logger
 	SimTracks           map[uint32]SimulcastTrackInfo
 	OnRTCP              func([]rtcp.Packet)
+	ResourceTracker     *sutils.ResourceTracker
+
+	PotentialCodecTimeout time.Duration
+
+	Transcoder          types.Transcoder
+	MaxTranscodedCodecs int
+	VideoProcessor      types.VideoProcessor
+	CodecRestriction    *CodecRestriction
 }
 
 func NewMediaTrack(params MediaTrackParams, ti *livekit.TrackInfo) *MediaTrack {
@@ -83,6 +99,13 @@ func NewMediaTrack(params MediaTrackParams, ti *livekit.TrackInfo) *MediaTrack {
 		AudioConfig:         params.AudioConfig,
 		Telemetry:           params.Telemetry,
 		Logger:              params.Logger,
+		ResourceTracker:     params.ResourceTracker,
+
+		PotentialCodecTimeout: params.PotentialCodecTimeout,
+
+		Transcoder:          params.Transcoder,
+		MaxTranscodedCodecs: params.MaxTranscodedCodecs,
+		VideoProcessor:      params.VideoProcessor,
 	}, ti)
 
 	if ti.Type == livekit.TrackType_AUDIO {
@@ -98,6 +121,18 @@ func NewMediaTrack(params MediaTrackParams, ti *livekit.TrackInfo) *MediaTrack {
 		t.MediaTrackReceiver.OnMediaLossFeedback(t.MediaLossProxy.HandleMaxLossFeedback)
 	}
 
+	// a track with only potential codecs, e.g. migrated and never published again, has nothing to close it
+	t.MediaTrackReceiver.OnPotentialCodecsExpired(func() {
+		t.MediaTrackReceiver.SetClosing()
+		if t.MediaTrackReceiver.TryClose() {
+			if t.dynacastManager != nil {
+				t.dynacastManager.Close()
+			}
+		}
+	})
+
+	t.MediaTrackReceiver.OnSubscriberCountChange(t.handleSubscriberCountChange)
+
 	if ti.Type == livekit.TrackType_VIDEO {
 		t.dynacastManager = NewDynacastManager(DynacastManagerParams{
 			DynacastPauseDelay: params.VideoConfig.DynacastPauseDelay,
@@ -148,6 +183,28 @@ func (t *MediaTrack) OnSubscribedMaxQualityChange(
 	t.dynacastManager.OnSubscribedMaxQualityChange(handler)
 }
 
+// OnSubscriberCountChange is called with the number of subscribers of the track whenever it changes
+func (t *MediaTrack) OnSubscriberCountChange(f func(count int)) {
+	t.lock.Lock()
+	t.onSubscriberCountChange = f
+	t.lock.Unlock()
+}
+
+// handleSubscriberCountChange flags the track in a webhook when it gets its first subscriber or loses the last one,
+// not when subscribers go away because the track is closing
+func (t *MediaTrack) handleSubscriberCountChange(previous int, count int) {
+	if t.IsOpen() && (previous == 0 || count == 0) {
+		t.params.Telemetry.TrackSubscriberCount(context.Background(), t.PublisherID(), t.PublisherIdentity(), t.ToProto(), count)
+	}
+
+	t.lock.RLock()
+	onSubscriberCountChange := t.onSubscriberCountChange
+	t.lock.RUnlock()
+	if onSubscriberCountChange != nil {
+		onSubscriberCountChange(count)
+	}
+}
+
 func (t *MediaTrack) NotifySubscriberNodeMaxQuality(nodeID livekit.NodeID, qualities []types.SubscribedCodecQuality) {
 	if t.dynacastManager != nil {
 		t.dynacastManager.NotifySubscriberNodeMaxQuality(nodeID, qualities)
@@ -181,6 +238,17 @@ func (t *MediaTrack) UpdateCodecCid(codecs []*livekit.SimulcastCodec) {
 }
 
 // AddReceiver adds a new RTP receiver to the track, returns true when receiver represents a new codec
+// SetPotentialCodecs sets the codecs the track may be published with later, leaving out those the room does not allow
+func (t *MediaTrack) SetPotentialCodecs(codecs []webrtc.RTPCodecParameters, headers []webrtc.RTPHeaderExtensionParameter) {
+	allowed := make([]webrtc.RTPCodecParameters, 0, len(codecs))
+	for _, c := range codecs {
+		if t.params.CodecRestriction.Allows(c.MimeType) {
+			allowed = append(allowed, c)
+		}
+	}
+	t.MediaTrackReceiver.SetPotentialCodecs(allowed, headers)
+}
+
 func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.TrackRemote, mid string) bool {
 	var newCodec bool
 	buff, rtcpReader := t.params.BufferFactory.GetBufferPair(uint32(track.SSRC()))
@@ -190,6 +258,8 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 	}
 
 	rtcpReader.OnPacket(func(bytes []byte) {
+		buff.CaptureRTCP(bytes)
+
 		pkts, err := rtcp.Unmarshal(bytes)
 		if err != nil {
 			t.params.Logger.Errorw("could not unmarshal RTCP", err)
@@ -252,9 +322,17 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 			t.params.OnRTCP,
 			t.params.VideoConfig.StreamTracker,
 			sfu.WithPliThrottleConfig(t.params.PLIThrottleConfig),
+			sfu.WithOnKeyFrameRequest(prometheus.RecordKeyFrameRequest),
 			sfu.WithAudioConfig(t.params.AudioConfig),
-			sfu.WithLoadBalanceThreshold(20),
+			sfu.WithLoadBalanceThreshold(t.loadBalanceThreshold()),
+			sfu.WithFanOutPool(t.params.ReceiverConfig.FanOutPool),
+			sfu.WithConnectionQualityScorer(t.params.ReceiverConfig.ConnectionQualityScorer),
+			sfu.WithPayloadIntegrity(t.params.ReceiverConfig.PayloadIntegrity),
+			sfu.WithKeyFrameCache(t.params.ReceiverConfig.KeyFrameCacheSize),
+			sfu.WithPacketBufferSize(t.packetBufferSize()),
+			sfu.WithReorder(t.params.ReceiverConfig.reorderParams(t.Kind())),
 			sfu.WithStreamTrackers(),
+			sfu.WithResourceTracker(t.params.ResourceTracker),
 		)
 		newWR.OnCloseHandler(func() {
 			t.MediaTrackReceiver.SetClosing()
@@ -265,11 +343,18 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 				}
 			}
 		})
+		newWR.OnPayloadIntegrityReport(func(layer int32, report *buffer.PayloadIntegrityReport) {
+			t.onPayloadIntegrityReport(mime, layer, report)
+		})
 		// SIMULCAST-CODEC-TODO: these need to be receiver/mime aware, setting it up only for primary now
 		if priority == 0 {
-			newWR.OnStatsUpdate(func(_ *sfu.WebRTCReceiver, stat *livekit.AnalyticsStat) {
+			newWR.OnStatsUpdate(func(w *sfu.WebRTCReceiver, stat *livekit.AnalyticsStat) {
 				key := telemetry.StatsKeyForTrack(livekit.StreamType_UPSTREAM, t.PublisherID(), t.ID(), ti.Source, ti.Type)
 				t.params.Telemetry.TrackStats(key, stat)
+
+				if subScores, ok := w.GetConnectionSubScores(); ok {
+					prometheus.RecordQualitySubScores("up", subScores.Loss, subScores.Delay, subScores.Bitrate, subScores.Layer)
+				}
 			})
 
 			newWR.OnMaxLayerChange(t.onMaxLayerChange)
@@ -289,7 +374,7 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 
 			if len(potentialCodecs) > 0 {
 				t.params.Logger.Debugw("primary codec published, set potential codecs", "potential", potentialCodecs)
-				t.MediaTrackReceiver.SetPotentialCodecs(potentialCodecs, parameters.HeaderExtensions)
+				t.SetPotentialCodecs(potentialCodecs, parameters.HeaderExtensions)
 			}
 		}
 
@@ -302,6 +387,7 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 				t.MediaTrackReceiver.SetLayerSsrc(mime, info.Rid, ssrc)
 			}
 		}
+		newWR.SetPacketCapture(t.capture)
 		wr = newWR
 		newCodec = true
 	}
@@ -349,6 +435,20 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 	return newCodec
 }
 
+// SetPacketCapture writes the packets received for the track, of every codec and layer, and the RTCP
+// exchanged with its publisher to capture. Capture stops when set to nil.
+func (t *MediaTrack) SetPacketCapture(capture *packetcapture.Capture) {
+	t.lock.Lock()
+	t.capture = capture
+	t.lock.Unlock()
+
+	for _, receiver := range t.MediaTrackReceiver.Receivers() {
+		if wr, ok := receiver.(*sfu.WebRTCReceiver); ok {
+			wr.SetPacketCapture(capture)
+		}
+	}
+}
+
 func (t *MediaTrack) GetConnectionScoreAndQuality() (float32, livekit.ConnectionQuality) {
 	receiver := t.PrimaryReceiver()
 	if rtcReceiver, ok := receiver.(*sfu.WebRTCReceiver); ok {
@@ -366,10 +466,53 @@ func (t *MediaTrack) HasPendingCodec() bool {
 	return t.MediaTrackReceiver.PrimaryReceiver() == nil
 }
 
+func (t *MediaTrack) loadBalanceThreshold() int {
+	if t.params.ReceiverConfig.FanOutPool != nil && t.params.ReceiverConfig.FanOutThreshold > 0 {
+		return t.params.ReceiverConfig.FanOutThreshold
+	}
+	return defaultLoadBalanceThreshold
+}
+
+// packetBufferSize returns the video packets kept for NACK when the policy of the track source needs more than
+// the buffer pool, 0 to use the pool
+func (t *MediaTrack) packetBufferSize() int {
+	conf := t.params.ReceiverConfig
+	if size := conf.videoPacketBufferSize(t.Source()); size != conf.PacketBufferSizeVideo {
+		return size
+	}
+	return 0
+}
+
 func (t *MediaTrack) onMaxLayerChange(maxLayer int32) {
 	t.MediaTrackReceiver.NotifyMaxLayerChange(maxLayer)
 }
 
+// onPayloadIntegrityReport is called from the packet processing of a layer, the track is flagged in a webhook
+// when the payloads of the layer go corrupted or recover
+func (t *MediaTrack) onPayloadIntegrityReport(mime string, layer int32, report *buffer.PayloadIntegrityReport) {
+	errorCounts := make(map[string]int, len(report.Errors))
+	for reason, count := range report.Errors {
+		prometheus.AddPayloadIntegrityErrors(mime, string(reason), count)
+		errorCounts[string(reason)] = count
+	}
+	if !report.FlagChanged {
+		return
+	}
+
+	if report.Flagged {
+		t.params.Logger.Warnw("payload corruption detected", nil, "mime", mime, "layer", layer, "errorRate", report.ErrorRate, "errors", errorCounts)
+	} else {
+		t.params.Logger.Infow("payload corruption cleared", "mime", mime, "layer", layer, "errorRate", report.ErrorRate)
+	}
+	t.params.Telemetry.TrackPayloadIntegrity(context.Background(), t.PublisherID(), t.PublisherIdentity(), t.ToProto(), &telemetry.TrackPayloadIntegrity{
+		Corrupted: report.Flagged,
+		Mime:      mime,
+		Layer:     layer,
+		ErrorRate: report.ErrorRate,
+		Errors:    errorCounts,
+	})
+}
+
 func (t *MediaTrack) Restart() {
 	t.MediaTrackReceiver.Restart()
 
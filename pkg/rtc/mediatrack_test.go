@@ -15,11 +15,20 @@
 package rtc
 
 import (
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 
 	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
 )
 
 func TestTrackInfo(t *testing.T) {
@@ -172,3 +181,221 @@ func TestGetQualityForDimension(t *testing.T) {
 	})
 
 }
+
+func TestPotentialCodecExpiry(t *testing.T) {
+	codec := func(mime string) webrtc.RTPCodecParameters {
+		return webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: mime, ClockRate: 90000}}
+	}
+	newTrack := func() (*MediaTrack, *atomic.Bool) {
+		mt := NewMediaTrack(MediaTrackParams{
+			Logger:                logger.GetLogger(),
+			PotentialCodecTimeout: 10 * time.Millisecond,
+		}, &livekit.TrackInfo{
+			Sid:  "TR_potential",
+			Type: livekit.TrackType_VIDEO,
+		})
+
+		closed := atomic.NewBool(false)
+		mt.AddOnClose(func() {
+			closed.Store(true)
+		})
+		return mt, closed
+	}
+
+	t.Run("restored track not published again closes", func(t *testing.T) {
+		mt, closed := newTrack()
+		mt.SetPotentialCodecs([]webrtc.RTPCodecParameters{codec(webrtc.MimeTypeVP8)}, nil)
+		require.Len(t, mt.Receivers(), 1)
+
+		// codec never published, placeholder is dropped and the track closes
+		require.Eventually(t, func() bool {
+			return closed.Load()
+		}, time.Second, 5*time.Millisecond)
+		require.Empty(t, mt.Receivers())
+	})
+
+	t.Run("restored track published again is kept", func(t *testing.T) {
+		mt, closed := newTrack()
+		mt.SetPotentialCodecs([]webrtc.RTPCodecParameters{codec(webrtc.MimeTypeVP8), codec(webrtc.MimeTypeH264)}, nil)
+		mt.SetupReceiver(&testCodecReceiver{codec: codec(webrtc.MimeTypeVP8)}, 0, "")
+
+		time.Sleep(50 * time.Millisecond)
+		require.False(t, closed.Load())
+		require.Len(t, mt.Receivers(), 2)
+	})
+
+	t.Run("backup codecs of a published track are kept", func(t *testing.T) {
+		mt, closed := newTrack()
+		mt.SetupReceiver(&testCodecReceiver{codec: codec(webrtc.MimeTypeAV1)}, 0, "")
+		mt.SetPotentialCodecs([]webrtc.RTPCodecParameters{codec(webrtc.MimeTypeAV1), codec(webrtc.MimeTypeVP8)}, nil)
+
+		time.Sleep(50 * time.Millisecond)
+		require.False(t, closed.Load())
+		require.Len(t, mt.Receivers(), 2)
+	})
+}
+
+type testCodecReceiver struct {
+	testTrackReceiver
+	codec webrtc.RTPCodecParameters
+}
+
+func (r *testCodecReceiver) Codec() webrtc.RTPCodecParameters {
+	return r.codec
+}
+
+func TestTranscodeFallback(t *testing.T) {
+	codec := func(mime string) webrtc.RTPCodecParameters {
+		return webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: mime, ClockRate: 90000}}
+	}
+	transcoder := &typesfakes.FakeTranscoder{}
+	transcoder.TranscodeStub = func(_ sfu.TrackReceiver, c webrtc.RTPCodecParameters) (sfu.TrackReceiver, error) {
+		return &testCodecReceiver{codec: c}, nil
+	}
+	mt := NewMediaTrack(MediaTrackParams{
+		Logger:              logger.GetLogger(),
+		Transcoder:          transcoder,
+		MaxTranscodedCodecs: 1,
+	}, &livekit.TrackInfo{
+		Sid:  "TR_av1",
+		Type: livekit.TrackType_VIDEO,
+	})
+	mt.SetupReceiver(&testCodecReceiver{codec: codec(webrtc.MimeTypeAV1)}, 0, "")
+
+	// retransmission formats are skipped, the first media codec the subscriber decodes is transcoded
+	require.True(t, mt.TranscodeFallback([]webrtc.RTPCodecParameters{codec("video/rtx"), codec(webrtc.MimeTypeH264)}))
+	require.Equal(t, 1, transcoder.TranscodeCallCount())
+	source, target := transcoder.TranscodeArgsForCall(0)
+	require.Equal(t, webrtc.MimeTypeAV1, source.Codec().MimeType)
+	require.Equal(t, webrtc.MimeTypeH264, target.MimeType)
+	require.Len(t, mt.Receivers(), 2)
+	require.NotNil(t, mt.Receiver(webrtc.MimeTypeH264))
+
+	// other subscribers decoding H.264 share the transcoded receiver
+	require.True(t, mt.TranscodeFallback([]webrtc.RTPCodecParameters{codec(webrtc.MimeTypeH264)}))
+	require.Equal(t, 1, transcoder.TranscodeCallCount())
+
+	// a published codec is not transcoded again
+	require.False(t, mt.TranscodeFallback([]webrtc.RTPCodecParameters{codec(webrtc.MimeTypeAV1)}))
+
+	// only one codec is transcoded per track
+	require.False(t, mt.TranscodeFallback([]webrtc.RTPCodecParameters{codec(webrtc.MimeTypeVP8)}))
+	require.Equal(t, 1, transcoder.TranscodeCallCount())
+
+	// fallback is disabled without a transcoder
+	plain := NewMediaTrack(MediaTrackParams{Logger: logger.GetLogger()}, &livekit.TrackInfo{Sid: "TR_plain", Type: livekit.TrackType_VIDEO})
+	plain.SetupReceiver(&testCodecReceiver{codec: codec(webrtc.MimeTypeAV1)}, 0, "")
+	require.False(t, plain.TranscodeFallback([]webrtc.RTPCodecParameters{codec(webrtc.MimeTypeH264)}))
+}
+
+func TestVideoProcessingForEgress(t *testing.T) {
+	codec := webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}}
+	processor := &typesfakes.FakeVideoProcessor{}
+	processor.ProcessStub = func(source sfu.TrackReceiver, _ *livekit.TrackInfo) (sfu.TrackReceiver, error) {
+		return &testCodecReceiver{codec: source.Codec()}, nil
+	}
+	mt := NewMediaTrack(MediaTrackParams{
+		Logger:         logger.GetLogger(),
+		VideoProcessor: processor,
+	}, &livekit.TrackInfo{
+		Sid:  "TR_camera",
+		Type: livekit.TrackType_VIDEO,
+	})
+	mt.SetupReceiver(&testCodecReceiver{codec: codec}, 0, "")
+
+	processed := mt.getProcessedReceiver()
+	require.NotNil(t, processed)
+	require.Equal(t, webrtc.MimeTypeVP8, processed.Codec().MimeType)
+	require.Equal(t, 1, processor.ProcessCallCount())
+	source, info := processor.ProcessArgsForCall(0)
+	require.Equal(t, codec.MimeType, source.Codec().MimeType)
+	require.Equal(t, "TR_camera", info.Sid)
+
+	// egress subscribers share the processed receiver, forwarding keeps the published one
+	require.Same(t, processed, mt.getProcessedReceiver())
+	require.Equal(t, 1, processor.ProcessCallCount())
+	require.Len(t, mt.Receivers(), 1)
+
+	// tracks the processor does not select are not processed
+	skipped := &typesfakes.FakeVideoProcessor{}
+	unprocessed := NewMediaTrack(MediaTrackParams{Logger: logger.GetLogger(), VideoProcessor: skipped}, &livekit.TrackInfo{Sid: "TR_screen", Type: livekit.TrackType_VIDEO})
+	unprocessed.SetupReceiver(&testCodecReceiver{codec: codec}, 0, "")
+	require.Nil(t, unprocessed.getProcessedReceiver())
+	require.Equal(t, 1, skipped.ProcessCallCount())
+
+	// audio is never processed
+	audio := NewMediaTrack(MediaTrackParams{Logger: logger.GetLogger(), VideoProcessor: processor}, &livekit.TrackInfo{Sid: "TR_mic", Type: livekit.TrackType_AUDIO})
+	audio.SetupReceiver(&testCodecReceiver{codec: codec}, 0, "")
+	require.Nil(t, audio.getProcessedReceiver())
+	require.Equal(t, 1, processor.ProcessCallCount())
+}
+
+func TestSubscriberCountChange(t *testing.T) {
+	telemetry := &telemetryfakes.FakeTelemetryService{}
+	mt := NewMediaTrack(MediaTrackParams{
+		ParticipantID:       "PA_host",
+		ParticipantIdentity: "host",
+		Telemetry:           telemetry,
+		Logger:              logger.GetLogger(),
+	}, &livekit.TrackInfo{Sid: "TR_cam", Type: livekit.TrackType_VIDEO})
+	var counts []int
+	mt.OnSubscriberCountChange(func(count int) {
+		counts = append(counts, count)
+	})
+
+	// only the first subscriber and the last one leaving are flagged
+	mt.notifySubscriberCount(1)
+	mt.notifySubscriberCount(1)
+	mt.notifySubscriberCount(-1)
+	require.Equal(t, 1, telemetry.TrackSubscriberCountCallCount())
+	_, pID, identity, ti, count := telemetry.TrackSubscriberCountArgsForCall(0)
+	require.Equal(t, livekit.ParticipantID("PA_host"), pID)
+	require.Equal(t, livekit.ParticipantIdentity("host"), identity)
+	require.Equal(t, "TR_cam", ti.Sid)
+	require.Equal(t, 1, count)
+
+	mt.notifySubscriberCount(-1)
+	require.Equal(t, 2, telemetry.TrackSubscriberCountCallCount())
+	_, _, _, _, count = telemetry.TrackSubscriberCountArgsForCall(1)
+	require.Equal(t, 0, count)
+	require.Equal(t, []int{1, 2, 1, 0}, counts)
+
+	// subscribers going away with the track are not flagged
+	mt.notifySubscriberCount(1)
+	mt.SetClosing()
+	mt.notifySubscriberCount(-1)
+	require.Equal(t, 3, telemetry.TrackSubscriberCountCallCount())
+	require.Equal(t, []int{1, 2, 1, 0, 1, 0}, counts)
+}
+
+func TestSubscriberCountChangeConcurrent(t *testing.T) {
+	telemetry := &telemetryfakes.FakeTelemetryService{}
+	mt := NewMediaTrack(MediaTrackParams{
+		Telemetry: telemetry,
+		Logger:    logger.GetLogger(),
+	}, &livekit.TrackInfo{Sid: "TR_cam", Type: livekit.TrackType_VIDEO})
+
+	// a subscriber joining and leaving concurrently with others must never report
+	// the first subscriber and no subscribers out of order
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mt.notifySubscriberCount(1)
+			mt.notifySubscriberCount(-1)
+		}()
+	}
+	wg.Wait()
+
+	numCalls := telemetry.TrackSubscriberCountCallCount()
+	require.NotZero(t, numCalls)
+	for i := 0; i < numCalls; i++ {
+		_, _, _, _, count := telemetry.TrackSubscriberCountArgsForCall(i)
+		if i%2 == 0 {
+			require.Equal(t, 1, count)
+		} else {
+			require.Equal(t, 0, count)
+		}
+	}
+}
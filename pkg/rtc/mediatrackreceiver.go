@@ -21,6 +21,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pion/rtcp"
 	"github.com/pion/webrtc/v3"
@@ -36,10 +37,14 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
 )
 
 const (
 	layerSelectionTolerance = 0.9
+
+	// potential codecs of a simulcast codec track that are not published within this time are dropped
+	defaultPotentialCodecTimeout = 30 * time.Second
 )
 
 var (
@@ -81,6 +86,11 @@ func (r *simulcastReceiver) Priority() int {
 	return r.priority
 }
 
+// transcodedReceiver is a receiver set up by transcode fallback, it is not signalled as a codec of the track
+type transcodedReceiver struct {
+	sfu.TrackReceiver
+}
+
 type MediaTrackReceiverParams struct {
 	MediaTrack          types.MediaTrack
 	IsRelayed           bool
@@ -92,6 +102,17 @@ type MediaTrackReceiverParams struct {
 	AudioConfig         config.AudioConfig
 	Telemetry           telemetry.TelemetryService
 	Logger              logger.Logger
+	ResourceTracker     *sutils.ResourceTracker
+
+	// how long a track without a receiver waits for its codecs to be published, defaults to defaultPotentialCodecTimeout
+	PotentialCodecTimeout time.Duration
+
+	// transcodes the track for subscribers that decode none of its codecs, fallback is disabled when nil
+	Transcoder          types.Transcoder
+	MaxTranscodedCodecs int
+
+	// processes the video delivered to egress participants, the track is delivered as published when nil
+	VideoProcessor types.VideoProcessor
 }
 
 type MediaTrackReceiver struct {
@@ -103,9 +124,18 @@ type MediaTrackReceiver struct {
 	potentialCodecs []webrtc.RTPCodecParameters
 	state           mediaTrackReceiverState
 
-	onSetupReceiver     func(mime string)
-	onMediaLossFeedback func(dt *sfu.DownTrack, report *rtcp.ReceiverReport)
-	onClose             []func()
+	potentialCodecsTimer *time.Timer
+
+	transcodeLock sync.Mutex
+
+	// receiver of the video processed for egress, set up when the first egress participant subscribes
+	processLock       sync.Mutex
+	processedReceiver sfu.TrackReceiver
+
+	onSetupReceiver          func(mime string)
+	onPotentialCodecsExpired func()
+	onMediaLossFeedback      func(dt *sfu.DownTrack, report *rtcp.ReceiverReport)
+	onClose                  []func()
 
 	*MediaTrackSubscriptions
 }
@@ -124,6 +154,7 @@ func NewMediaTrackReceiver(params MediaTrackReceiverParams, ti *livekit.TrackInf
 		SubscriberConfig: params.SubscriberConfig,
 		Telemetry:        params.Telemetry,
 		Logger:           params.Logger,
+		ResourceTracker:  params.ResourceTracker,
 	})
 	t.MediaTrackSubscriptions.OnDownTrackCreated(t.onDownTrackCreated)
 
@@ -149,6 +180,13 @@ func (t *MediaTrackReceiver) OnSetupReceiver(f func(mime string)) {
 	t.lock.Unlock()
 }
 
+// OnPotentialCodecsExpired is called when potential codecs expire and the track is left without any receiver
+func (t *MediaTrackReceiver) OnPotentialCodecsExpired(f func()) {
+	t.lock.Lock()
+	t.onPotentialCodecsExpired = f
+	t.lock.Unlock()
+}
+
 func (t *MediaTrackReceiver) SetupReceiver(receiver sfu.TrackReceiver, priority int, mid string) {
 	t.lock.Lock()
 	if t.state != mediaTrackReceiverStateOpen {
@@ -194,6 +232,8 @@ func (t *MediaTrackReceiver) SetupReceiver(receiver sfu.TrackReceiver, priority
 	}
 
 	t.receivers = receivers
+	// the publisher is publishing the track again
+	t.stopPotentialCodecsTimerLocked()
 	onSetupReceiver := t.onSetupReceiver
 	t.lock.Unlock()
 
@@ -214,6 +254,100 @@ func (t *MediaTrackReceiver) SetupReceiver(receiver sfu.TrackReceiver, priority
 	}
 }
 
+// TranscodeFallback sets up a receiver transcoding the primary receiver into the first of codecs the transcoder
+// produces. A receiver transcoded earlier into one of codecs is reused. It returns false when fallback is disabled,
+// the track already has a receiver in one of codecs that is not transcoded, or nothing could be transcoded.
+func (t *MediaTrackReceiver) TranscodeFallback(codecs []webrtc.RTPCodecParameters) bool {
+	if t.params.Transcoder == nil {
+		return false
+	}
+
+	// one transcoder set up at a time, subscribers failing together would each set up the same codec
+	t.transcodeLock.Lock()
+	defer t.transcodeLock.Unlock()
+
+	candidates := make([]webrtc.RTPCodecParameters, 0, len(codecs))
+	for _, c := range codecs {
+		if isTranscodableCodec(c.MimeType) {
+			candidates = append(candidates, c)
+		}
+	}
+	receivers := t.loadReceivers()
+	numTranscoded := 0
+	for _, r := range receivers {
+		_, transcoded := r.TrackReceiver.(*transcodedReceiver)
+		if transcoded {
+			numTranscoded++
+		}
+		for _, c := range candidates {
+			if strings.EqualFold(r.Codec().MimeType, c.MimeType) {
+				return transcoded
+			}
+		}
+	}
+	if t.params.MaxTranscodedCodecs > 0 && numTranscoded >= t.params.MaxTranscodedCodecs {
+		t.params.Logger.Infow("not transcoding track, max transcoded codecs reached", "numTranscoded", numTranscoded)
+		return false
+	}
+
+	source := t.PrimaryReceiver()
+	if source == nil || !t.IsOpen() {
+		return false
+	}
+	for _, c := range candidates {
+		receiver, err := t.params.Transcoder.Transcode(source, c)
+		if err != nil {
+			t.params.Logger.Debugw("could not transcode track", "error", err, "from", source.Codec().MimeType, "to", c.MimeType)
+			continue
+		}
+
+		t.params.Logger.Infow("transcoding track", "from", source.Codec().MimeType, "to", receiver.Codec().MimeType)
+		t.SetupReceiver(&transcodedReceiver{TrackReceiver: receiver}, len(receivers), "")
+		return true
+	}
+	return false
+}
+
+// getProcessedReceiver returns the receiver of the video processed for egress, nil when the track is not processed.
+// Once set up, the video is processed until the track closes.
+func (t *MediaTrackReceiver) getProcessedReceiver() sfu.TrackReceiver {
+	if t.params.VideoProcessor == nil || t.Kind() != livekit.TrackType_VIDEO {
+		return nil
+	}
+
+	t.processLock.Lock()
+	defer t.processLock.Unlock()
+	if t.processedReceiver != nil && !t.processedReceiver.IsClosed() {
+		return t.processedReceiver
+	}
+	t.processedReceiver = nil
+
+	source := t.PrimaryReceiver()
+	if source == nil || !t.IsOpen() {
+		return nil
+	}
+	receiver, err := t.params.VideoProcessor.Process(source, t.TrackInfo())
+	if err != nil {
+		t.params.Logger.Warnw("could not process video for egress", err)
+		return nil
+	}
+	if receiver != nil {
+		t.params.Logger.Infow("processing video for egress", "codec", receiver.Codec().MimeType)
+		t.processedReceiver = receiver
+	}
+	return receiver
+}
+
+// isTranscodableCodec is false for the retransmission, redundancy and FEC formats negotiated alongside media codecs
+func isTranscodableCodec(mime string) bool {
+	_, format, _ := strings.Cut(strings.ToLower(mime), "/")
+	switch format {
+	case "rtx", "red", "ulpfec", "flexfec-03", "":
+		return false
+	}
+	return true
+}
+
 func (t *MediaTrackReceiver) SetPotentialCodecs(codecs []webrtc.RTPCodecParameters, headers []webrtc.RTPHeaderExtensionParameter) {
 	// The potential codecs have not published yet, so we can't get the actual Extensions, the client/browser uses same extensions
 	// for all video codecs so we assume they will have same extensions as the primary codec except for the dependency descriptor
@@ -227,6 +361,7 @@ func (t *MediaTrackReceiver) SetPotentialCodecs(codecs []webrtc.RTPCodecParamete
 	t.lock.Lock()
 	receivers := slices.Clone(t.receivers)
 	t.potentialCodecs = codecs
+	addedDummy := false
 	for i, c := range codecs {
 		var exist bool
 		for _, r := range receivers {
@@ -240,17 +375,103 @@ func (t *MediaTrackReceiver) SetPotentialCodecs(codecs []webrtc.RTPCodecParamete
 			if !sfu.IsSvcCodec(c.MimeType) {
 				extHeaders = headersWithoutDD
 			}
+			dummy := NewDummyReceiver(livekit.TrackID(t.trackInfo.Sid), string(t.PublisherID()), c, extHeaders)
+			dummy.SetMigrationPriority(t.MediaTrackSubscriptions.migrationPriority)
 			receivers = append(receivers, &simulcastReceiver{
-				TrackReceiver: NewDummyReceiver(livekit.TrackID(t.trackInfo.Sid), string(t.PublisherID()), c, extHeaders),
+				TrackReceiver: dummy,
 				priority:      i,
 			})
+			addedDummy = true
 		}
 	}
 	sort.Slice(receivers, func(i, j int) bool {
 		return receivers[i].Priority() < receivers[j].Priority()
 	})
 	t.receivers = receivers
+	// only a track without any receiver, restored before its publisher publishes it again, can be left waiting.
+	// Potential codecs of a published track are simulcast codecs the publisher may never send
+	if addedDummy && !t.hasReceiverLocked() {
+		t.startPotentialCodecsTimerLocked()
+	}
+	t.lock.Unlock()
+}
+
+// hasReceiverLocked returns whether the track has a receiver receiving from the publisher
+func (t *MediaTrackReceiver) hasReceiverLocked() bool {
+	for _, r := range t.receivers {
+		if dr, ok := r.TrackReceiver.(*DummyReceiver); !ok || dr.Receiver() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *MediaTrackReceiver) startPotentialCodecsTimerLocked() {
+	if t.potentialCodecsTimer != nil {
+		t.potentialCodecsTimer.Stop()
+	}
+
+	timeout := t.params.PotentialCodecTimeout
+	if timeout == 0 {
+		timeout = defaultPotentialCodecTimeout
+	}
+	t.potentialCodecsTimer = time.AfterFunc(timeout, t.expirePotentialCodecs)
+}
+
+func (t *MediaTrackReceiver) stopPotentialCodecsTimerLocked() {
+	if t.potentialCodecsTimer != nil {
+		t.potentialCodecsTimer.Stop()
+		t.potentialCodecsTimer = nil
+	}
+}
+
+// expirePotentialCodecs removes DummyReceivers that have not been upgraded, subscribers of those codecs
+// are released as the publisher is not going to send them
+func (t *MediaTrackReceiver) expirePotentialCodecs() {
+	t.lock.Lock()
+	t.potentialCodecsTimer = nil
+	if t.state == mediaTrackReceiverStateClosed {
+		t.lock.Unlock()
+		return
+	}
+
+	var expired []string
+	receivers := make([]*simulcastReceiver, 0, len(t.receivers))
+	for _, r := range t.receivers {
+		if dr, ok := r.TrackReceiver.(*DummyReceiver); ok && dr.Receiver() == nil {
+			expired = append(expired, r.Codec().MimeType)
+			continue
+		}
+		receivers = append(receivers, r)
+	}
+	if len(expired) == 0 {
+		t.lock.Unlock()
+		return
+	}
+
+	potentialCodecs := make([]webrtc.RTPCodecParameters, 0, len(t.potentialCodecs))
+	for _, c := range t.potentialCodecs {
+		if !slices.ContainsFunc(expired, func(mime string) bool { return strings.EqualFold(mime, c.MimeType) }) {
+			potentialCodecs = append(potentialCodecs, c)
+		}
+	}
+	t.receivers = receivers
+	t.potentialCodecs = potentialCodecs
+	onPotentialCodecsExpired := t.onPotentialCodecsExpired
 	t.lock.Unlock()
+
+	for _, mime := range expired {
+		t.params.Logger.Infow(
+			"potential codec not published in time, releasing subscribers",
+			"mime", mime,
+			"reason", "potential codec expired",
+		)
+		t.removeAllSubscribersForMime(mime, false)
+	}
+
+	if len(receivers) == 0 && onPotentialCodecsExpired != nil {
+		onPotentialCodecsExpired()
+	}
 }
 
 func (t *MediaTrackReceiver) ClearReceiver(mime string, willBeResumed bool) {
@@ -336,6 +557,7 @@ func (t *MediaTrackReceiver) Close() {
 	}
 
 	t.state = mediaTrackReceiverStateClosed
+	t.stopPotentialCodecsTimerLocked()
 	onclose := t.onClose
 	t.lock.Unlock()
 
@@ -452,6 +674,13 @@ func (t *MediaTrackReceiver) AddSubscriber(sub types.LocalParticipant) (types.Su
 		return nil, ErrNoReceiver
 	}
 
+	if sub.IsRecorder() {
+		if processed := t.getProcessedReceiver(); processed != nil {
+			receivers = []*simulcastReceiver{{TrackReceiver: processed}}
+			potentialCodecs = []webrtc.RTPCodecParameters{processed.Codec()}
+		}
+	}
+
 	for _, receiver := range receivers {
 		codec := receiver.Codec()
 		var found bool
@@ -499,28 +728,29 @@ func (t *MediaTrackReceiver) removeAllSubscribersForMime(mime string, willBeResu
 }
 
 func (t *MediaTrackReceiver) RevokeDisallowedSubscribers(allowedSubscriberIdentities []livekit.ParticipantIdentity) []livekit.ParticipantIdentity {
-	var revokedSubscriberIdentities []livekit.ParticipantIdentity
+	allowed := make(map[livekit.ParticipantIdentity]struct{}, len(allowedSubscriberIdentities))
+	for _, identity := range allowedSubscriberIdentities {
+		allowed[identity] = struct{}{}
+	}
 
-	// LK-TODO: large number of subscribers needs to be solved for this loop
-	for _, subTrack := range t.MediaTrackSubscriptions.getAllSubscribedTracks() {
-		found := false
-		for _, allowedIdentity := range allowedSubscriberIdentities {
-			if subTrack.SubscriberIdentity() == allowedIdentity {
-				found = true
-				break
-			}
+	// collected first, removing a subscriber cannot happen while its shard is locked
+	var revoked []types.SubscribedTrack
+	t.MediaTrackSubscriptions.rangeSubscribedTracks(func(subTrack types.SubscribedTrack) bool {
+		if _, ok := allowed[subTrack.SubscriberIdentity()]; !ok {
+			revoked = append(revoked, subTrack)
 		}
+		return true
+	})
 
-		if !found {
-			t.params.Logger.Infow("revoking subscription",
-				"subscriber", subTrack.SubscriberIdentity(),
-				"subscriberID", subTrack.SubscriberID(),
-			)
-			t.RemoveSubscriber(subTrack.SubscriberID(), false)
-			revokedSubscriberIdentities = append(revokedSubscriberIdentities, subTrack.SubscriberIdentity())
-		}
+	var revokedSubscriberIdentities []livekit.ParticipantIdentity
+	for _, subTrack := range revoked {
+		t.params.Logger.Infow("revoking subscription",
+			"subscriber", subTrack.SubscriberIdentity(),
+			"subscriberID", subTrack.SubscriberID(),
+		)
+		t.RemoveSubscriber(subTrack.SubscriberID(), false)
+		revokedSubscriberIdentities = append(revokedSubscriberIdentities, subTrack.SubscriberIdentity())
 	}
-
 	return revokedSubscriberIdentities
 }
 
@@ -832,6 +1062,23 @@ func (t *MediaTrackReceiver) loadReceivers() []*simulcastReceiver {
 	return t.receivers
 }
 
+// getStreamTrackStats returns the stats of each layer received of every codec, keyed by SSRC
+func (t *MediaTrackReceiver) getStreamTrackStats() map[uint32]receivedStreamStats {
+	stats := make(map[uint32]receivedStreamStats)
+	for _, r := range t.loadReceivers() {
+		wr, ok := r.TrackReceiver.(*sfu.WebRTCReceiver)
+		if !ok {
+			continue
+		}
+
+		mimeType := wr.Codec().MimeType
+		for ssrc, rs := range wr.GetStreamTrackStats() {
+			stats[ssrc] = receivedStreamStats{mimeType: mimeType, stats: rs}
+		}
+	}
+	return stats
+}
+
 func (t *MediaTrackReceiver) SetRTT(rtt uint32) {
 	for _, r := range t.loadReceivers() {
 		if wr, ok := r.TrackReceiver.(*sfu.WebRTCReceiver); ok {
@@ -15,7 +15,9 @@
 package rtc
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"sync"
 
 	"github.com/pion/rtcp"
@@ -29,6 +31,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 var (
@@ -40,11 +43,19 @@ var (
 type MediaTrackSubscriptions struct {
 	params MediaTrackSubscriptionsParams
 
-	subscribedTracksMu sync.RWMutex
-	subscribedTracks   map[livekit.ParticipantID]types.SubscribedTrack
+	subscribedTracks subscribedTrackMap
+	// counted apart from the map, so that each subscriber added or removed yields its own count,
+	// the lock is held across the notification so that the changes are reported in order
+	subscriberCountLock sync.Mutex
+	numSubscribers      int
+
+	// codecs each subscriber failed to decode, left out when it subscribes again
+	regressionLock  sync.Mutex
+	regressedCodecs map[livekit.ParticipantID][]string
 
 	onDownTrackCreated           func(downTrack *sfu.DownTrack)
 	onSubscriberMaxQualityChange func(subscriberID livekit.ParticipantID, codec webrtc.RTPCodecCapability, layer int32)
+	onSubscriberCountChange      func(previous int, count int)
 }
 
 type MediaTrackSubscriptionsParams struct {
@@ -56,13 +67,14 @@ type MediaTrackSubscriptionsParams struct {
 
 	Telemetry telemetry.TelemetryService
 
-	Logger logger.Logger
+	Logger          logger.Logger
+	ResourceTracker *sutils.ResourceTracker
 }
 
 func NewMediaTrackSubscriptions(params MediaTrackSubscriptionsParams) *MediaTrackSubscriptions {
 	return &MediaTrackSubscriptions{
-		params:           params,
-		subscribedTracks: make(map[livekit.ParticipantID]types.SubscribedTrack),
+		params:          params,
+		regressedCodecs: make(map[livekit.ParticipantID][]string),
 	}
 }
 
@@ -74,6 +86,12 @@ func (t *MediaTrackSubscriptions) OnSubscriberMaxQualityChange(f func(subscriber
 	t.onSubscriberMaxQualityChange = f
 }
 
+// OnSubscriberCountChange is called with the number of subscribers before and after each subscriber is added
+// or removed, calls are serialized in the order of the changes
+func (t *MediaTrackSubscriptions) OnSubscriberCountChange(f func(previous int, count int)) {
+	t.onSubscriberCountChange = f
+}
+
 func (t *MediaTrackSubscriptions) SetMuted(muted bool) {
 	// update mute of all subscribed tracks
 	for _, st := range t.getAllSubscribedTracks() {
@@ -82,11 +100,7 @@ func (t *MediaTrackSubscriptions) SetMuted(muted bool) {
 }
 
 func (t *MediaTrackSubscriptions) IsSubscriber(subID livekit.ParticipantID) bool {
-	t.subscribedTracksMu.RLock()
-	defer t.subscribedTracksMu.RUnlock()
-
-	_, ok := t.subscribedTracks[subID]
-	return ok
+	return t.subscribedTracks.Get(subID) != nil
 }
 
 // AddSubscriber subscribes sub to current mediaTrack
@@ -95,12 +109,9 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 	subscriberID := sub.ID()
 
 	// don't subscribe to the same track multiple times
-	t.subscribedTracksMu.Lock()
-	if _, ok := t.subscribedTracks[subscriberID]; ok {
-		t.subscribedTracksMu.Unlock()
+	if t.subscribedTracks.Get(subscriberID) != nil {
 		return nil, errAlreadySubscribed
 	}
-	t.subscribedTracksMu.Unlock()
 
 	var rtcpFeedback []webrtc.RTCPFeedback
 	var maxTrack int
@@ -110,9 +121,13 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 		maxTrack = t.params.ReceiverConfig.PacketBufferSizeAudio
 	case livekit.TrackType_VIDEO:
 		rtcpFeedback = t.params.SubscriberConfig.RTCPFeedback.Video
-		maxTrack = t.params.ReceiverConfig.PacketBufferSizeVideo
+		maxTrack = t.params.ReceiverConfig.videoPacketBufferSize(t.params.MediaTrack.Source())
+	}
+	codecs := t.withoutRegressedCodecs(subscriberID, wr.Codecs())
+	codecs = withoutBitDepthMismatch(wr, codecs)
+	if len(codecs) == 0 {
+		return nil, ErrCodecBitDepthUnsupported
 	}
-	codecs := wr.Codecs()
 	for _, c := range codecs {
 		c.RTCPFeedback = rtcpFeedback
 	}
@@ -135,9 +150,16 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 		StreamID:          streamID,
 		MaxTrack:          maxTrack,
 		PlayoutDelayLimit: sub.GetPlayoutDelayConfig(),
+		MaxTrackBitrate:   sub.GetMaxTrackBitrate(),
+		VideoBitrates:     t.params.ReceiverConfig.VideoBitrates,
+		ScreenShare:       t.params.MediaTrack.Source() == livekit.TrackSource_SCREEN_SHARE,
+		ForwardingPolicy:  t.params.ReceiverConfig.Forwarding.PolicyFor(t.params.MediaTrack.Source()),
 		Pacer:             sub.GetPacer(),
 		Trailer:           trailer,
 		Logger:            LoggerWithTrack(sub.GetLogger().WithComponent(sutils.ComponentSub), trackID, t.params.IsRelayed),
+		ResourceTracker:   t.params.ResourceTracker,
+
+		ConnectionQualityScorer: t.params.ReceiverConfig.ConnectionQualityScorer,
 	})
 	if err != nil {
 		return nil, err
@@ -155,6 +177,7 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 		MediaTrack:        t.params.MediaTrack,
 		DownTrack:         downTrack,
 		AdaptiveStream:    sub.GetAdaptiveStream(),
+		SubscribeDefaults: sub.GetSubscribeDefaults(t.params.MediaTrack.Source()),
 	})
 
 	// Bind callback can happen from replaceTrack, so set it up early
@@ -183,9 +206,13 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 		subTrack.SetPublisherMuted(t.params.MediaTrack.IsMuted())
 	})
 
-	downTrack.OnStatsUpdate(func(_ *sfu.DownTrack, stat *livekit.AnalyticsStat) {
+	downTrack.OnStatsUpdate(func(dt *sfu.DownTrack, stat *livekit.AnalyticsStat) {
 		key := telemetry.StatsKeyForTrack(livekit.StreamType_DOWNSTREAM, subscriberID, trackID, t.params.MediaTrack.Source(), t.params.MediaTrack.Kind())
 		t.params.Telemetry.TrackStats(key, stat)
+
+		if subScores, ok := dt.GetConnectionSubScores(); ok {
+			prometheus.RecordQualitySubScores("down", subScores.Loss, subScores.Delay, subScores.Bitrate, subScores.Layer)
+		}
 	})
 
 	downTrack.OnMaxLayerChanged(func(dt *sfu.DownTrack, layer int32) {
@@ -202,6 +229,12 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 		sub.HandleReceiverReport(dt, report)
 	})
 
+	if t.params.MediaTrack.Kind() == livekit.TrackType_VIDEO && len(codecs) > 1 {
+		downTrack.OnDecodeFailure(func(dt *sfu.DownTrack, reason sfu.DecodeFailureReason) {
+			go t.regressSubscriberCodec(sub, dt.Codec().MimeType, codecs, reason)
+		})
+	}
+
 	var transceiver *webrtc.RTPTransceiver
 	var sender *webrtc.RTPSender
 
@@ -293,9 +326,8 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 		go t.downTrackClosed(sub, willBeResumed)
 	})
 
-	t.subscribedTracksMu.Lock()
-	t.subscribedTracks[subscriberID] = subTrack
-	t.subscribedTracksMu.Unlock()
+	t.subscribedTracks.Set(subTrack)
+	t.notifySubscriberCount(1)
 
 	return subTrack, nil
 }
@@ -303,6 +335,17 @@ func (t *MediaTrackSubscriptions) AddSubscriber(sub types.LocalParticipant, wr *
 // RemoveSubscriber removes participant from subscription
 // stop all forwarders to the client
 func (t *MediaTrackSubscriptions) RemoveSubscriber(subscriberID livekit.ParticipantID, willBeResumed bool) error {
+	if !willBeResumed {
+		// codecs the subscriber failed to decode are tried again on a new subscription
+		t.regressionLock.Lock()
+		delete(t.regressedCodecs, subscriberID)
+		t.regressionLock.Unlock()
+	}
+
+	return t.removeSubscriber(subscriberID, willBeResumed)
+}
+
+func (t *MediaTrackSubscriptions) removeSubscriber(subscriberID livekit.ParticipantID, willBeResumed bool) error {
 	subTrack := t.getSubscribedTrack(subscriberID)
 	if subTrack == nil {
 		return errNotFound
@@ -334,6 +377,84 @@ func (t *MediaTrackSubscriptions) closeSubscribedTrack(subTrack types.Subscribed
 	}
 }
 
+func (t *MediaTrackSubscriptions) withoutRegressedCodecs(subscriberID livekit.ParticipantID, codecs []webrtc.RTPCodecParameters) []webrtc.RTPCodecParameters {
+	t.regressionLock.Lock()
+	regressed := t.regressedCodecs[subscriberID]
+	t.regressionLock.Unlock()
+	if len(regressed) == 0 {
+		return codecs
+	}
+
+	filtered := make([]webrtc.RTPCodecParameters, 0, len(codecs))
+	for _, c := range codecs {
+		if !containsMime(regressed, c.MimeType) {
+			filtered = append(filtered, c)
+		}
+	}
+	if len(filtered) == 0 {
+		return codecs
+	}
+	return filtered
+}
+
+// withoutBitDepthMismatch drops the codecs receiving video with a higher bit depth than their negotiated profile
+// carries, subscribers fall back to the backup codec of multi-codec simulcast, none is left when there is no backup
+func withoutBitDepthMismatch(wr *WrappedReceiver, codecs []webrtc.RTPCodecParameters) []webrtc.RTPCodecParameters {
+	filtered := make([]webrtc.RTPCodecParameters, 0, len(codecs))
+	for _, c := range codecs {
+		if !wr.HasCodecBitDepthMismatch(c.MimeType) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// regressSubscriberCodec moves a subscriber failing to decode the codec it was bound to over to the codec following
+// it in codecs, the backup codec of multi-codec simulcast. The subscription is torn down and set up again without
+// the failing codec, the subscription manager subscribes again once the down track is closed.
+func (t *MediaTrackSubscriptions) regressSubscriberCodec(
+	sub types.LocalParticipant,
+	mime string,
+	codecs []webrtc.RTPCodecParameters,
+	reason sfu.DecodeFailureReason,
+) {
+	var backup string
+	for i, c := range codecs {
+		if strings.EqualFold(c.MimeType, mime) && i+1 < len(codecs) {
+			backup = codecs[i+1].MimeType
+			break
+		}
+	}
+	if backup == "" {
+		return
+	}
+
+	t.regressionLock.Lock()
+	t.regressedCodecs[sub.ID()] = append(t.regressedCodecs[sub.ID()], mime)
+	t.regressionLock.Unlock()
+
+	t.params.Logger.Infow("regressing subscriber to backup codec",
+		"subscriberID", sub.ID(),
+		"fromMime", mime,
+		"toMime", backup,
+		"reason", reason,
+	)
+	t.params.Telemetry.TrackSubscribeCodecRegressed(
+		context.Background(),
+		sub.ID(),
+		sub.Identity(),
+		t.params.MediaTrack.ToProto(),
+		&telemetry.CodecRegression{
+			FromMime: mime,
+			ToMime:   backup,
+			Reason:   string(reason),
+		},
+	)
+
+	// keeps the regressed codec, the subscription manager subscribes again
+	_ = t.removeSubscriber(sub.ID(), false)
+}
+
 func (t *MediaTrackSubscriptions) ResyncAllSubscribers() {
 	t.params.Logger.Debugw("resyncing all subscribers")
 
@@ -343,36 +464,43 @@ func (t *MediaTrackSubscriptions) ResyncAllSubscribers() {
 }
 
 func (t *MediaTrackSubscriptions) GetAllSubscribers() []livekit.ParticipantID {
-	t.subscribedTracksMu.RLock()
-	defer t.subscribedTracksMu.RUnlock()
+	subs := make([]livekit.ParticipantID, 0, t.subscribedTracks.Len())
+	t.subscribedTracks.Range(func(subTrack types.SubscribedTrack) bool {
+		subs = append(subs, subTrack.SubscriberID())
+		return true
+	})
+	return subs
+}
 
-	subs := make([]livekit.ParticipantID, 0, len(t.subscribedTracks))
-	for id := range t.subscribedTracks {
-		subs = append(subs, id)
+// migrationPriority ranks the subscribers moved to the receiver of the track once it is published, active speakers
+// first, then the subscribers that gave the track a priority in their track settings
+func (t *MediaTrackSubscriptions) migrationPriority(subscriberID livekit.ParticipantID) int {
+	subTrack := t.subscribedTracks.Get(subscriberID)
+	if subTrack == nil {
+		return 0
 	}
-	return subs
+	if _, active := subTrack.Subscriber().GetAudioLevel(); active {
+		return 2
+	}
+	if subTrack.SubscriberRank() > 0 {
+		return 1
+	}
+	return 0
 }
 
 func (t *MediaTrackSubscriptions) GetAllSubscribersForMime(mime string) []livekit.ParticipantID {
-	t.subscribedTracksMu.RLock()
-	defer t.subscribedTracksMu.RUnlock()
-
-	subs := make([]livekit.ParticipantID, 0, len(t.subscribedTracks))
-	for id, subTrack := range t.subscribedTracks {
-		if subTrack.DownTrack().Codec().MimeType != mime {
-			continue
+	subs := make([]livekit.ParticipantID, 0, t.subscribedTracks.Len())
+	t.subscribedTracks.Range(func(subTrack types.SubscribedTrack) bool {
+		if subTrack.DownTrack().Codec().MimeType == mime {
+			subs = append(subs, subTrack.SubscriberID())
 		}
-
-		subs = append(subs, id)
-	}
+		return true
+	})
 	return subs
 }
 
 func (t *MediaTrackSubscriptions) GetNumSubscribers() int {
-	t.subscribedTracksMu.RLock()
-	defer t.subscribedTracksMu.RUnlock()
-
-	return len(t.subscribedTracks)
+	return t.subscribedTracks.Len()
 }
 
 func (t *MediaTrackSubscriptions) UpdateVideoLayers() {
@@ -382,25 +510,16 @@ func (t *MediaTrackSubscriptions) UpdateVideoLayers() {
 }
 
 func (t *MediaTrackSubscriptions) getSubscribedTrack(subscriberID livekit.ParticipantID) types.SubscribedTrack {
-	t.subscribedTracksMu.RLock()
-	defer t.subscribedTracksMu.RUnlock()
-
-	return t.subscribedTracks[subscriberID]
+	return t.subscribedTracks.Get(subscriberID)
 }
 
 func (t *MediaTrackSubscriptions) getAllSubscribedTracks() []types.SubscribedTrack {
-	t.subscribedTracksMu.RLock()
-	defer t.subscribedTracksMu.RUnlock()
-
-	return t.getAllSubscribedTracksLocked()
+	return t.subscribedTracks.Values()
 }
 
-func (t *MediaTrackSubscriptions) getAllSubscribedTracksLocked() []types.SubscribedTrack {
-	subTracks := make([]types.SubscribedTrack, 0, len(t.subscribedTracks))
-	for _, subTrack := range t.subscribedTracks {
-		subTracks = append(subTracks, subTrack)
-	}
-	return subTracks
+// rangeSubscribedTracks calls f for each subscribed track until it returns false, f must not subscribe or unsubscribe
+func (t *MediaTrackSubscriptions) rangeSubscribedTracks(f func(subTrack types.SubscribedTrack) bool) {
+	t.subscribedTracks.Range(f)
 }
 
 func (t *MediaTrackSubscriptions) DebugInfo() []map[string]interface{} {
@@ -418,13 +537,19 @@ func (t *MediaTrackSubscriptions) downTrackClosed(
 	sub types.LocalParticipant,
 	willBeResumed bool,
 ) {
-	subscriberID := sub.ID()
-	t.subscribedTracksMu.Lock()
-	subTrack := t.subscribedTracks[subscriberID]
-	delete(t.subscribedTracks, subscriberID)
-	t.subscribedTracksMu.Unlock()
-
-	if subTrack != nil {
+	if subTrack := t.subscribedTracks.Delete(sub.ID()); subTrack != nil {
+		t.notifySubscriberCount(-1)
 		subTrack.Close(willBeResumed)
 	}
 }
+
+func (t *MediaTrackSubscriptions) notifySubscriberCount(delta int) {
+	t.subscriberCountLock.Lock()
+	defer t.subscriberCountLock.Unlock()
+
+	previous := t.numSubscribers
+	t.numSubscribers += delta
+	if t.onSubscriberCountChange != nil {
+		t.onSubscriberCountChange(previous, t.numSubscribers)
+	}
+}
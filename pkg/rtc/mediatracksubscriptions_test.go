@@ -0,0 +1,78 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
+)
+
+func TestCodecRegression(t *testing.T) {
+	mt := &typesfakes.FakeMediaTrack{}
+	mt.ToProtoReturns(&livekit.TrackInfo{Sid: "TR_video"})
+	ts := &telemetryfakes.FakeTelemetryService{}
+	subs := NewMediaTrackSubscriptions(MediaTrackSubscriptionsParams{
+		MediaTrack: mt,
+		Telemetry:  ts,
+		Logger:     logger.GetLogger(),
+	})
+
+	sub := &typesfakes.FakeLocalParticipant{}
+	sub.IDReturns("PA_sub")
+	sub.IdentityReturns("sub")
+
+	codecs := []webrtc.RTPCodecParameters{
+		{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeAV1}},
+		{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}},
+	}
+	require.Equal(t, codecs, subs.withoutRegressedCodecs("PA_sub", codecs))
+
+	// nothing to regress to from the backup codec
+	subs.regressSubscriberCodec(sub, webrtc.MimeTypeVP8, codecs, sfu.DecodeFailureReasonKeyFrameRequests)
+	require.Equal(t, 0, ts.TrackSubscribeCodecRegressedCallCount())
+
+	subs.regressSubscriberCodec(sub, webrtc.MimeTypeAV1, codecs, sfu.DecodeFailureReasonReceiverStalled)
+	require.Equal(t, 1, ts.TrackSubscribeCodecRegressedCallCount())
+	_, participantID, identity, ti, regression := ts.TrackSubscribeCodecRegressedArgsForCall(0)
+	require.Equal(t, livekit.ParticipantID("PA_sub"), participantID)
+	require.Equal(t, livekit.ParticipantIdentity("sub"), identity)
+	require.Equal(t, "TR_video", ti.Sid)
+	require.Equal(t, &telemetry.CodecRegression{
+		FromMime: webrtc.MimeTypeAV1,
+		ToMime:   webrtc.MimeTypeVP8,
+		Reason:   string(sfu.DecodeFailureReasonReceiverStalled),
+	}, regression)
+
+	// the subscriber subscribes again with the backup codec only, others keep every codec
+	require.Equal(t, codecs[1:], subs.withoutRegressedCodecs("PA_sub", codecs))
+	require.Equal(t, codecs, subs.withoutRegressedCodecs("PA_other", codecs))
+
+	// a resuming subscriber keeps the regression, one unsubscribing drops it
+	_ = subs.RemoveSubscriber("PA_sub", true)
+	require.Equal(t, codecs[1:], subs.withoutRegressedCodecs("PA_sub", codecs))
+	_ = subs.RemoveSubscriber("PA_sub", false)
+	require.Equal(t, codecs, subs.withoutRegressedCodecs("PA_sub", codecs))
+	require.Empty(t, subs.regressedCodecs)
+}
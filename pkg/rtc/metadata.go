@@ -0,0 +1,143 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// MetadataVersion identifies the content of room or participant metadata. Patches are applied only when the
+// version the caller read matches the current one, so the version of any metadata received in room and
+// participant updates can be used.
+func MetadataVersion(metadata string) string {
+	sum := sha256.Sum256([]byte(metadata))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ApplyMergePatch applies a JSON merge patch (RFC 7386) to metadata. Empty metadata is patched as an empty object,
+// other metadata has to be a JSON object. Keys of the result are sorted.
+func ApplyMergePatch(metadata string, patch []byte) (string, error) {
+	p, err := decodeJSON(patch)
+	if err != nil {
+		return "", ErrMetadataPatchInvalid
+	}
+
+	var target any = map[string]any{}
+	if metadata != "" {
+		if target, err = decodeJSON([]byte(metadata)); err != nil {
+			return "", ErrMetadataNotObject
+		}
+		if _, ok := target.(map[string]any); !ok {
+			return "", ErrMetadataNotObject
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(mergePatch(target, p)); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+func mergePatch(target any, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// numbers are kept as they are written, without a round trip through float64
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+// ApplyMetadataPatch checks version against the version of metadata and applies the patch, the patched metadata
+// must not exceed maxSize when it is set
+func ApplyMetadataPatch(metadata string, patch []byte, version string, maxSize int) (string, error) {
+	if version != "" && version != MetadataVersion(metadata) {
+		return "", ErrMetadataVersionMismatch
+	}
+	patched, err := ApplyMergePatch(metadata, patch)
+	if err != nil {
+		return "", err
+	}
+	if maxSize > 0 && len(patched) > maxSize {
+		return "", ErrMetadataTooLarge
+	}
+	return patched, nil
+}
+
+// PatchMetadata applies a JSON merge patch to the room metadata when its current version matches version, or
+// unconditionally when version is empty. It returns the patched metadata and a channel closed once the
+// update is applied.
+func (r *Room) PatchMetadata(patch []byte, version string, maxSize int) (string, <-chan struct{}, error) {
+	r.lock.Lock()
+	metadata, err := ApplyMetadataPatch(r.protoRoom.Metadata, patch, version, maxSize)
+	if err != nil {
+		r.lock.Unlock()
+		return "", nil, err
+	}
+	r.protoRoom.Metadata = metadata
+	r.lock.Unlock()
+	return metadata, r.protoProxy.MarkDirty(true), nil
+}
+
+// PatchParticipantMetadata applies a JSON merge patch to the metadata of the participant when its current version
+// matches version, or unconditionally when version is empty.
+func (r *Room) PatchParticipantMetadata(
+	participant types.LocalParticipant,
+	patch []byte,
+	version string,
+	maxSize int,
+) (string, error) {
+	r.metadataLock.Lock()
+	defer r.metadataLock.Unlock()
+
+	metadata, err := ApplyMetadataPatch(participant.ToProto().Metadata, patch, version, maxSize)
+	if err != nil {
+		return "", err
+	}
+	participant.SetMetadata(metadata)
+	return metadata, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	t.Run("patches follow RFC 7386", func(t *testing.T) {
+		cases := []struct {
+			metadata string
+			patch    string
+			expected string
+		}{
+			{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+			{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+			{`{"a":"b"}`, `{"a":null}`, `{}`},
+			{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+			{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+			{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+			{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+			{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+			{``, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+			{`{"a":"b"}`, `["c"]`, `["c"]`},
+		}
+		for _, c := range cases {
+			patched, err := ApplyMergePatch(c.metadata, []byte(c.patch))
+			require.NoError(t, err)
+			require.Equal(t, c.expected, patched, "patch %s of %s", c.patch, c.metadata)
+		}
+	})
+
+	t.Run("numbers and markup are kept as written", func(t *testing.T) {
+		patched, err := ApplyMergePatch(`{"id":12345678901234567890}`, []byte(`{"note":"<b>&</b>"}`))
+		require.NoError(t, err)
+		require.Equal(t, `{"id":12345678901234567890,"note":"<b>&</b>"}`, patched)
+	})
+
+	t.Run("metadata must be an object and the patch valid JSON", func(t *testing.T) {
+		_, err := ApplyMergePatch("plain text", []byte(`{"a":1}`))
+		require.ErrorIs(t, err, ErrMetadataNotObject)
+		_, err = ApplyMergePatch(`["a"]`, []byte(`{"a":1}`))
+		require.ErrorIs(t, err, ErrMetadataNotObject)
+		_, err = ApplyMergePatch(`{}`, []byte(`{"a":`))
+		require.ErrorIs(t, err, ErrMetadataPatchInvalid)
+		_, err = ApplyMergePatch(`{}`, []byte(`{} {}`))
+		require.ErrorIs(t, err, ErrMetadataPatchInvalid)
+	})
+}
+
+func TestApplyMetadataPatch(t *testing.T) {
+	metadata := `{"stage":"lobby"}`
+
+	patched, err := ApplyMetadataPatch(metadata, []byte(`{"stage":"live"}`), MetadataVersion(metadata), 0)
+	require.NoError(t, err)
+	require.Equal(t, `{"stage":"live"}`, patched)
+
+	// a writer holding the previous version has to read the metadata again
+	_, err = ApplyMetadataPatch(patched, []byte(`{"topic":"q&a"}`), MetadataVersion(metadata), 0)
+	require.ErrorIs(t, err, ErrMetadataVersionMismatch)
+
+	patched, err = ApplyMetadataPatch(patched, []byte(`{"topic":"q&a"}`), "", 0)
+	require.NoError(t, err)
+	require.Equal(t, `{"stage":"live","topic":"q&a"}`, patched)
+
+	_, err = ApplyMetadataPatch(patched, []byte(`{"notes":"a long note"}`), "", len(patched))
+	require.ErrorIs(t, err, ErrMetadataTooLarge)
+}
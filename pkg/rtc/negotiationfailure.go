@@ -0,0 +1,356 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const (
+	negotiationStageRemoteDescription = "remote_description"
+	negotiationStageCreateAnswer      = "create_answer"
+	negotiationStageLocalDescription  = "local_description"
+)
+
+// header extensions kept when a remote description is constrained, media sections cannot be matched to
+// transceivers and simulcast layers cannot be told apart without them
+var requiredHeaderExtensions = map[string]bool{
+	sdp.SDESMidURI:         true,
+	sdp.SDESRTPStreamIDURI: true,
+	repairedRTPStreamID:    true,
+}
+
+var negotiationAttributes = []string{
+	sdp.AttrKeyExtMap,
+	"rtpmap",
+	"fmtp",
+	"rtcp-fb",
+	"fingerprint",
+	"ice-ufrag",
+	"ice-pwd",
+	"setup",
+	"simulcast",
+	"rid",
+	sdp.AttrKeySSRC,
+	sdp.AttrKeySSRCGroup,
+	sdp.AttrKeyMID,
+	sdp.AttrKeyMsid,
+}
+
+// checkSessionDescription parses the codecs and header extensions of a session description like pion does when
+// applying it. pion does so after moving the signaling state and cannot roll back a remote offer, a description
+// failing here can still be retried. It returns the attribute the error points to.
+func checkSessionDescription(sd webrtc.SessionDescription) (string, error) {
+	parsed, err := sd.Unmarshal()
+	if err != nil {
+		return negotiationFailureAttribute(err), err
+	}
+
+	for _, m := range parsed.MediaDescriptions {
+		if m.MediaName.Media != "audio" && m.MediaName.Media != "video" {
+			continue
+		}
+
+		media := &sdp.SessionDescription{MediaDescriptions: []*sdp.MediaDescription{m}}
+		for _, format := range m.MediaName.Formats {
+			pt, err := strconv.ParseUint(format, 10, 8)
+			if err != nil {
+				return "rtpmap", fmt.Errorf("invalid payload type %q: %w", format, err)
+			}
+			codec, err := media.GetCodecForPayloadType(uint8(pt))
+			if err != nil {
+				if pt == 0 {
+					continue
+				}
+				return "rtpmap", fmt.Errorf("payload type %d: %w", pt, err)
+			}
+			if strings.EqualFold(codec.Name, "rtx") {
+				if _, err := rtxAssociatedPayloadType(codec.Fmtp); err != nil {
+					return "fmtp", fmt.Errorf("payload type %d: %w", pt, err)
+				}
+			}
+		}
+
+		for _, a := range m.Attributes {
+			if a.Key != sdp.AttrKeyExtMap {
+				continue
+			}
+			e := sdp.ExtMap{}
+			if err := e.Unmarshal(a.String()); err != nil {
+				return sdp.AttrKeyExtMap, err
+			}
+		}
+	}
+	return "", nil
+}
+
+// constrainSessionDescription drops the optional parts of a session description, the header extensions
+// other than the ones identifying media sections and simulcast layers, and the codecs of each media section
+// other than the first usable one and its retransmission codec. It works on the lines of the description
+// as one failing to parse cannot be unmarshalled. It returns false when nothing is dropped.
+func constrainSessionDescription(sd webrtc.SessionDescription) (webrtc.SessionDescription, bool) {
+	lineBreak := "\r\n"
+	if !strings.Contains(sd.SDP, lineBreak) {
+		lineBreak = "\n"
+	}
+	lines := strings.Split(strings.TrimSuffix(sd.SDP, lineBreak), lineBreak)
+
+	out := make([]string, 0, len(lines))
+	changed := false
+	for start := 0; start < len(lines); {
+		end := start + 1
+		for end < len(lines) && !strings.HasPrefix(lines[end], "m=") {
+			end++
+		}
+
+		section, dropped := constrainSection(lines[start:end])
+		out = append(out, section...)
+		changed = changed || dropped
+		start = end
+	}
+	if !changed {
+		return sd, false
+	}
+
+	return webrtc.SessionDescription{
+		Type: sd.Type,
+		SDP:  strings.Join(out, lineBreak) + lineBreak,
+	}, true
+}
+
+func constrainSection(lines []string) ([]string, bool) {
+	keep := map[string]bool(nil)
+	var mediaLine []string
+	if strings.HasPrefix(lines[0], "m=audio ") || strings.HasPrefix(lines[0], "m=video ") {
+		mediaLine = strings.Fields(lines[0])
+		if len(mediaLine) > 4 {
+			keep = usablePayloadTypes(mediaLine[3:], lines[1:])
+		}
+	}
+
+	out := make([]string, 0, len(lines))
+	dropped := false
+	for i, line := range lines {
+		switch {
+		case i == 0 && keep != nil:
+			formats := make([]string, 0, len(keep))
+			for _, pt := range mediaLine[3:] {
+				if keep[pt] {
+					formats = append(formats, pt)
+				}
+			}
+			if len(formats) != len(mediaLine[3:]) {
+				dropped = true
+			}
+			out = append(out, strings.Join(append(mediaLine[:3:3], formats...), " "))
+			continue
+
+		case line == "a=extmap-allow-mixed":
+			dropped = true
+			continue
+
+		case strings.HasPrefix(line, "a=extmap:"):
+			e := sdp.ExtMap{}
+			if err := e.Unmarshal(strings.TrimPrefix(line, "a=")); err != nil || !requiredHeaderExtensions[e.URI.String()] {
+				dropped = true
+				continue
+			}
+
+		case keep != nil:
+			if pt, ok := codecAttributePayloadType(line); ok && !keep[pt] {
+				dropped = true
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	return out, dropped
+}
+
+// usablePayloadTypes returns the first payload type of a media section with a parseable rtpmap, along with its
+// retransmission payload type
+func usablePayloadTypes(formats []string, attributes []string) map[string]bool {
+	rtpmaps := make(map[string]string)
+	fmtps := make(map[string]string)
+	for _, line := range attributes {
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "a="), ":")
+		if !found || (key != "rtpmap" && key != "fmtp") {
+			continue
+		}
+		pt, params, found := strings.Cut(value, " ")
+		if !found {
+			continue
+		}
+		if key == "rtpmap" {
+			rtpmaps[pt] = params
+		} else {
+			fmtps[pt] = params
+		}
+	}
+
+	primary := ""
+	for _, pt := range formats {
+		if _, err := strconv.ParseUint(pt, 10, 8); err != nil {
+			continue
+		}
+		name, _, found := strings.Cut(rtpmaps[pt], "/")
+		if !found {
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "rtx", "red", "ulpfec", "flexfec-03":
+			continue
+		}
+		primary = pt
+		break
+	}
+	if primary == "" {
+		// nothing usable, leave the codecs to the error
+		return nil
+	}
+
+	keep := map[string]bool{primary: true}
+	for _, pt := range formats {
+		name, _, _ := strings.Cut(rtpmaps[pt], "/")
+		if !strings.EqualFold(name, "rtx") {
+			continue
+		}
+		if apt, err := rtxAssociatedPayloadType(fmtps[pt]); err == nil && strconv.Itoa(int(apt)) == primary {
+			keep[pt] = true
+		}
+	}
+	return keep
+}
+
+// codecAttributePayloadType returns the payload type of a rtpmap, fmtp or rtcp-fb line
+func codecAttributePayloadType(line string) (string, bool) {
+	for _, prefix := range []string{"a=rtpmap:", "a=fmtp:", "a=rtcp-fb:"} {
+		if strings.HasPrefix(line, prefix) {
+			pt, _, _ := strings.Cut(strings.TrimPrefix(line, prefix), " ")
+			return pt, pt != "*"
+		}
+	}
+	return "", false
+}
+
+func rtxAssociatedPayloadType(fmtp string) (uint8, error) {
+	for _, param := range strings.Split(fmtp, ";") {
+		k, v, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found || k != "apt" {
+			continue
+		}
+		apt, err := strconv.ParseUint(v, 10, 8)
+		if err != nil {
+			return 0, fmt.Errorf("invalid apt %q: %w", v, err)
+		}
+		return uint8(apt), nil
+	}
+	return 0, errors.New("missing apt")
+}
+
+// negotiationFailureAttribute tells the attribute an error of pion points to, from the line quoted in the error
+// or the attribute named in it
+func negotiationFailureAttribute(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	if start := strings.IndexByte(msg, '`'); start >= 0 {
+		if end := strings.IndexByte(msg[start+1:], '`'); end >= 0 {
+			quoted := msg[start+1 : start+1+end]
+			if key, value, found := strings.Cut(quoted, "="); found {
+				if key == "a" {
+					name, _, _ := strings.Cut(value, ":")
+					return name
+				}
+				return key
+			}
+			return quoted
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(msg), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && r != '-'
+	})
+	for _, word := range words {
+		for _, attribute := range negotiationAttributes {
+			if word == attribute {
+				return attribute
+			}
+		}
+	}
+	if errors.Is(err, webrtc.ErrCodecNotFound) || errors.Is(err, webrtc.ErrUnsupportedCodec) {
+		return "rtpmap"
+	}
+	return ""
+}
+
+func (t *PCTransport) reportSessionDescriptionFailure(stage string, remote *webrtc.SessionDescription, attribute string, err error, recovered bool) {
+	if remote == nil {
+		remote = &webrtc.SessionDescription{}
+	}
+	if attribute == "" {
+		attribute = negotiationFailureAttribute(err)
+	}
+	local := ""
+	if ld := t.pc.LocalDescription(); ld != nil {
+		local = ld.SDP
+	}
+
+	t.params.Logger.Infow("session description failed",
+		"stage", stage,
+		"sdpType", remote.Type,
+		"attribute", attribute,
+		"error", err,
+		"recovered", recovered,
+	)
+	t.params.Handler.OnSessionDescriptionFailed(&telemetry.NegotiationFailure{
+		Transport: t.params.Transport,
+		Stage:     stage,
+		SDPType:   remote.Type.String(),
+		Attribute: attribute,
+		Error:     err.Error(),
+		RemoteSDP: remote.SDP,
+		LocalSDP:  local,
+		Recovered: recovered,
+	})
+}
+
+// checkRemoteDescription checks a remote description before it is applied. One failing the check is retried
+// without the optional header extensions and codecs, the failure is reported whether or not that recovers it.
+func (t *PCTransport) checkRemoteDescription(sd webrtc.SessionDescription) (webrtc.SessionDescription, error) {
+	attribute, err := checkSessionDescription(sd)
+	if err == nil {
+		return sd, nil
+	}
+
+	if constrained, ok := constrainSessionDescription(sd); ok {
+		if _, cerr := checkSessionDescription(constrained); cerr == nil {
+			t.reportSessionDescriptionFailure(negotiationStageRemoteDescription, &sd, attribute, err, true)
+			return constrained, nil
+		}
+	}
+
+	t.reportSessionDescriptionFailure(negotiationStageRemoteDescription, &sd, attribute, err, false)
+	return sd, err
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/transport/transportfakes"
+)
+
+const negotiationTestOffer = "v=0\r\n" +
+	"o=- 4215775240449105457 2 IN IP4 127.0.0.1\r\n" +
+	"s=-\r\n" +
+	"t=0 0\r\n" +
+	"a=group:BUNDLE 0 1\r\n" +
+	"a=extmap-allow-mixed\r\n" +
+	"m=audio 9 UDP/TLS/RTP/SAVPF 111 63\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=ice-ufrag:abcd\r\n" +
+	"a=ice-pwd:abcdefghijklmnopqrstuvwx\r\n" +
+	"a=fingerprint:sha-256 00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00\r\n" +
+	"a=setup:actpass\r\n" +
+	"a=mid:0\r\n" +
+	"a=extmap:1 urn:ietf:params:rtp-hdrext:ssrc-audio-level\r\n" +
+	"a=extmap:4 urn:ietf:params:rtp-hdrext:sdes:mid\r\n" +
+	"a=sendonly\r\n" +
+	"a=rtpmap:111 opus/48000/2\r\n" +
+	"a=rtcp-fb:111 transport-cc\r\n" +
+	"a=fmtp:111 minptime=10;useinbandfec=1\r\n" +
+	"a=rtpmap:63 red/48000/2\r\n" +
+	"a=fmtp:63 111/111\r\n" +
+	"m=video 9 UDP/TLS/RTP/SAVPF 96 97 98 99\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=ice-ufrag:abcd\r\n" +
+	"a=ice-pwd:abcdefghijklmnopqrstuvwx\r\n" +
+	"a=fingerprint:sha-256 00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00\r\n" +
+	"a=setup:actpass\r\n" +
+	"a=mid:1\r\n" +
+	"a=extmap:4 urn:ietf:params:rtp-hdrext:sdes:mid\r\n" +
+	"a=extmap:x http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time\r\n" +
+	"a=sendonly\r\n" +
+	"a=rtpmap:96 VP8/90000\r\n" +
+	"a=rtcp-fb:96 nack\r\n" +
+	"a=rtpmap:97 rtx/90000\r\n" +
+	"a=fmtp:97 apt=96\r\n" +
+	"a=rtpmap:98 H264/90000\r\n" +
+	"a=fmtp:98 profile-level-id=42e01f\r\n" +
+	"a=rtpmap:99 rtx/90000\r\n" +
+	"a=fmtp:99 apt=98\r\n"
+
+func TestCheckSessionDescription(t *testing.T) {
+	attribute, err := checkSessionDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: negotiationTestOffer})
+	require.Error(t, err)
+	require.Equal(t, "extmap", attribute)
+
+	valid := strings.Replace(negotiationTestOffer, "a=extmap:x ", "a=extmap:3 ", 1)
+	_, err = checkSessionDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: valid})
+	require.NoError(t, err)
+
+	badRTX := strings.Replace(valid, "a=fmtp:99 apt=98", "a=fmtp:99 apt=x", 1)
+	attribute, err = checkSessionDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: badRTX})
+	require.Error(t, err)
+	require.Equal(t, "fmtp", attribute)
+}
+
+func TestConstrainSessionDescription(t *testing.T) {
+	constrained, ok := constrainSessionDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: negotiationTestOffer})
+	require.True(t, ok)
+	require.Equal(t, webrtc.SDPTypeOffer, constrained.Type)
+
+	_, err := checkSessionDescription(constrained)
+	require.NoError(t, err)
+
+	parsed, err := constrained.Unmarshal()
+	require.NoError(t, err)
+	require.Len(t, parsed.MediaDescriptions, 2)
+
+	audio := parsed.MediaDescriptions[0]
+	require.Equal(t, []string{"111"}, audio.MediaName.Formats)
+	require.Equal(t, []string{"4 urn:ietf:params:rtp-hdrext:sdes:mid"}, attributeValues(audio.Attributes, "extmap"))
+	require.Empty(t, attributeValues(parsed.Attributes, "extmap-allow-mixed"))
+
+	video := parsed.MediaDescriptions[1]
+	require.Equal(t, []string{"96", "97"}, video.MediaName.Formats)
+	require.Equal(t, []string{"96 VP8/90000", "97 rtx/90000"}, attributeValues(video.Attributes, "rtpmap"))
+	require.Equal(t, []string{"97 apt=96"}, attributeValues(video.Attributes, "fmtp"))
+	require.Equal(t, []string{"4 urn:ietf:params:rtp-hdrext:sdes:mid"}, attributeValues(video.Attributes, "extmap"))
+
+	// nothing optional left to drop
+	_, ok = constrainSessionDescription(constrained)
+	require.False(t, ok)
+}
+
+func TestNegotiationFailureAttribute(t *testing.T) {
+	sd := webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: "v=0\r\no=- 1 2 IN IP4 127.0.0.1\r\ns=-\r\nb=AS\r\nt=0 0\r\n"}
+	_, err := sd.Unmarshal()
+	require.Error(t, err)
+	require.Equal(t, "b", negotiationFailureAttribute(err))
+
+	require.Equal(t, "fingerprint", negotiationFailureAttribute(errors.New("failed to parse fingerprint")))
+	require.Equal(t, "mid", negotiationFailureAttribute(errors.New("RemoteDescription contained media section without mid value")))
+	require.Equal(t, "rtpmap", negotiationFailureAttribute(webrtc.ErrCodecNotFound))
+	require.Equal(t, "", negotiationFailureAttribute(errors.New("invalid state")))
+}
+
+func TestConstrainedRemoteOffer(t *testing.T) {
+	handler := &transportfakes.FakeHandler{}
+	transport, err := NewPCTransport(TransportParams{
+		ParticipantID:       "id",
+		ParticipantIdentity: "identity",
+		Config:              &WebRTCConfig{},
+		Handler:             handler,
+	})
+	require.NoError(t, err)
+	defer transport.Close()
+
+	transport.HandleRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: negotiationTestOffer})
+
+	require.Eventually(t, func() bool {
+		return handler.OnAnswerCallCount() == 1
+	}, 5*time.Second, 10*time.Millisecond, "answer not sent")
+	require.Equal(t, 0, handler.OnNegotiationFailedCallCount())
+
+	require.Equal(t, 1, handler.OnSessionDescriptionFailedCallCount())
+	failure := handler.OnSessionDescriptionFailedArgsForCall(0)
+	require.True(t, failure.Recovered)
+	require.Equal(t, negotiationStageRemoteDescription, failure.Stage)
+	require.Equal(t, "offer", failure.SDPType)
+	require.Equal(t, "extmap", failure.Attribute)
+	require.Equal(t, negotiationTestOffer, failure.RemoteSDP)
+}
+
+func attributeValues(attributes []sdp.Attribute, key string) []string {
+	var values []string
+	for _, a := range attributes {
+		if a.Key == key {
+			values = append(values, a.Value)
+		}
+	}
+	return values
+}
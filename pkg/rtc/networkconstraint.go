@@ -0,0 +1,181 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// intervals shorter than this are merged into the next one
+	networkPathMinInterval = time.Second
+	// fewer packets in an interval do not tell anything about the path
+	networkPathMinPackets = 50
+
+	networkPathLossLimitPercentage = 3.0
+	networkPathJitterLimitMs       = 30.0
+	// the downlink is limited when the estimate covers less than this share of the expected bandwidth
+	downlinkEstimateLimitRatio = 0.8
+	// round trip time this much above the minimum, and at least twice the minimum, with losses under the limit
+	bufferbloatRTTIncreaseMs = 150
+)
+
+var networkConstraintAdvice = map[types.NetworkConstraint]string{
+	types.NetworkConstraintUplinkLimited: "the upload of the network of the participant loses or delays packets, " +
+		"e.g. a weak Wi-Fi signal or other uploads. Moving closer to the access point, using a wired connection " +
+		"or publishing at a lower resolution helps",
+	types.NetworkConstraintDownlinkLimited: "the download of the network of the participant cannot carry the " +
+		"subscribed streams. Stopping other downloads, using a wired connection or subscribing to fewer videos helps",
+	types.NetworkConstraintBufferbloat: "the round trip time grows under load, a router on the path queues packets " +
+		"instead of dropping them. Enabling smart queue management (SQM) on the router or limiting other " +
+		"transfers helps",
+}
+
+// networkPathTracker computes the stats of one direction of the network path of a participant from the
+// cumulative stats of the tracks using it, sampled at every connection quality update
+type networkPathTracker struct {
+	// stats of sent streams count lost packets in the packets sent
+	isSender bool
+	prev     map[livekit.TrackID]*livekit.RTPStats
+	prevAt   time.Time
+	minRTTMs uint32
+	stats    *types.NetworkPathStats
+}
+
+func newNetworkPathTracker(isSender bool) *networkPathTracker {
+	return &networkPathTracker{
+		isSender: isSender,
+		prev:     make(map[livekit.TrackID]*livekit.RTPStats),
+	}
+}
+
+func (n *networkPathTracker) update(tracks map[livekit.TrackID]*livekit.RTPStats, at time.Time) {
+	if !n.prevAt.IsZero() && at.Sub(n.prevAt) < networkPathMinInterval {
+		return
+	}
+
+	var packets, lost uint32
+	var bytes uint64
+	var jitterMs float64
+	var rttMs uint32
+	for trackID, cur := range tracks {
+		prev := n.prev[trackID]
+		if prev == nil || cur.Packets < prev.Packets || cur.PacketsLost < prev.PacketsLost || cur.Bytes < prev.Bytes {
+			// new, or restarted since the previous sample, counted from the next interval
+			continue
+		}
+		packets += cur.Packets - prev.Packets
+		lost += cur.PacketsLost - prev.PacketsLost
+		bytes += cur.Bytes - prev.Bytes
+		if j := cur.JitterCurrent / 1e3; j > jitterMs {
+			jitterMs = j
+		}
+		if cur.RttCurrent > rttMs {
+			rttMs = cur.RttCurrent
+		}
+	}
+	if rttMs != 0 && (n.minRTTMs == 0 || rttMs < n.minRTTMs) {
+		n.minRTTMs = rttMs
+	}
+
+	stats := &types.NetworkPathStats{
+		Packets:  packets,
+		JitterMs: jitterMs,
+		RTTMs:    rttMs,
+		MinRTTMs: n.minRTTMs,
+	}
+	expected := packets + lost
+	if n.isSender {
+		expected = packets
+	}
+	if expected > 0 && lost <= expected {
+		stats.PacketLossPercentage = float32(lost) * 100 / float32(expected)
+	}
+	if !n.prevAt.IsZero() {
+		stats.BitrateBps = float64(bytes*8) / at.Sub(n.prevAt).Seconds()
+	}
+
+	n.stats = stats
+	n.prev = tracks
+	n.prevAt = at
+}
+
+// getStats returns the stats of the last interval, nil if too little media flowed in it
+func (n *networkPathTracker) getStats() *types.NetworkPathStats {
+	if n.stats == nil || n.stats.Packets < networkPathMinPackets {
+		return nil
+	}
+	stats := *n.stats
+	return &stats
+}
+
+// classifyNetworkConstraint returns the probable constraint of the network of a participant, the direction
+// whose losses, jitter or, for the downlink, bandwidth estimate are the furthest beyond their limits
+func classifyNetworkConstraint(uplink, downlink *types.NetworkPathStats) types.NetworkConstraint {
+	if uplink == nil && downlink == nil {
+		return types.NetworkConstraintUnknown
+	}
+
+	for _, path := range []*types.NetworkPathStats{uplink, downlink} {
+		if path == nil || path.MinRTTMs == 0 || path.PacketLossPercentage >= networkPathLossLimitPercentage {
+			continue
+		}
+		if path.RTTMs >= path.MinRTTMs+bufferbloatRTTIncreaseMs && path.RTTMs >= 2*path.MinRTTMs {
+			return types.NetworkConstraintBufferbloat
+		}
+	}
+
+	upSeverity := networkPathSeverity(uplink)
+	downSeverity := networkPathSeverity(downlink)
+	if downlink != nil && downlink.EstimateBps > 0 && downlink.ExpectedBps > 0 {
+		if s := float64(downlink.ExpectedBps) * downlinkEstimateLimitRatio / float64(downlink.EstimateBps); s > downSeverity {
+			downSeverity = s
+		}
+	}
+	switch {
+	case upSeverity < 1 && downSeverity < 1:
+		return types.NetworkConstraintNone
+	case upSeverity >= downSeverity:
+		return types.NetworkConstraintUplinkLimited
+	default:
+		return types.NetworkConstraintDownlinkLimited
+	}
+}
+
+// networkPathSeverity is 1 or more for a path beyond its limits
+func networkPathSeverity(path *types.NetworkPathStats) float64 {
+	if path == nil {
+		return 0
+	}
+	severity := float64(path.PacketLossPercentage) / networkPathLossLimitPercentage
+	if s := path.JitterMs / networkPathJitterLimitMs; s > severity {
+		severity = s
+	}
+	return severity
+}
+
+func getNetworkAsymmetry(uplink, downlink *types.NetworkPathStats) *types.NetworkAsymmetry {
+	if uplink == nil || downlink == nil {
+		return nil
+	}
+	return &types.NetworkAsymmetry{
+		PacketLossPercentage: uplink.PacketLossPercentage - downlink.PacketLossPercentage,
+		JitterMs:             uplink.JitterMs - downlink.JitterMs,
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestNetworkPathTracker(t *testing.T) {
+	t.Run("stats are the difference between samples", func(t *testing.T) {
+		tracker := newNetworkPathTracker(false)
+		now := time.Now()
+		tracker.update(map[livekit.TrackID]*livekit.RTPStats{
+			"TR_audio": {Packets: 100, PacketsLost: 10, Bytes: 10_000},
+		}, now)
+		// the first sample is the baseline
+		require.Nil(t, tracker.getStats())
+
+		tracker.update(map[livekit.TrackID]*livekit.RTPStats{
+			"TR_audio": {Packets: 290, PacketsLost: 20, Bytes: 60_000, JitterCurrent: 12_000, RttCurrent: 80},
+			// counted from the next sample
+			"TR_video": {Packets: 1000, PacketsLost: 500, Bytes: 1_000_000},
+		}, now.Add(5*time.Second))
+		stats := tracker.getStats()
+		require.NotNil(t, stats)
+		require.Equal(t, uint32(190), stats.Packets)
+		require.InDelta(t, 5.0, stats.PacketLossPercentage, 0.01)
+		require.InDelta(t, 80_000, stats.BitrateBps, 0.01)
+		require.InDelta(t, 12.0, stats.JitterMs, 0.01)
+		require.Equal(t, uint32(80), stats.RTTMs)
+		require.Equal(t, uint32(80), stats.MinRTTMs)
+
+		// samples too close to the previous one are merged into the next
+		tracker.update(map[livekit.TrackID]*livekit.RTPStats{
+			"TR_audio": {Packets: 300, PacketsLost: 20, Bytes: 61_000},
+		}, now.Add(5*time.Second+100*time.Millisecond))
+		require.Equal(t, uint32(190), tracker.getStats().Packets)
+	})
+
+	t.Run("sent packets include lost packets", func(t *testing.T) {
+		tracker := newNetworkPathTracker(true)
+		now := time.Now()
+		tracker.update(map[livekit.TrackID]*livekit.RTPStats{"TR_video": {}}, now)
+		tracker.update(map[livekit.TrackID]*livekit.RTPStats{
+			"TR_video": {Packets: 200, PacketsLost: 10, RttCurrent: 300},
+		}, now.Add(5*time.Second))
+		require.InDelta(t, 5.0, tracker.getStats().PacketLossPercentage, 0.01)
+
+		tracker.update(map[livekit.TrackID]*livekit.RTPStats{
+			"TR_video": {Packets: 400, PacketsLost: 10, RttCurrent: 60},
+		}, now.Add(10*time.Second))
+		require.Equal(t, uint32(60), tracker.getStats().MinRTTMs)
+
+		tracker.update(map[livekit.TrackID]*livekit.RTPStats{
+			"TR_video": {Packets: 600, PacketsLost: 10, RttCurrent: 400},
+		}, now.Add(15*time.Second))
+		require.Equal(t, uint32(60), tracker.getStats().MinRTTMs)
+	})
+}
+
+func TestClassifyNetworkConstraint(t *testing.T) {
+	good := &types.NetworkPathStats{Packets: 1000, PacketLossPercentage: 0.2, JitterMs: 5, RTTMs: 40, MinRTTMs: 35}
+
+	require.Equal(t, types.NetworkConstraintUnknown, classifyNetworkConstraint(nil, nil))
+	require.Equal(t, types.NetworkConstraintNone, classifyNetworkConstraint(good, good))
+
+	lossyUplink := &types.NetworkPathStats{Packets: 1000, PacketLossPercentage: 8, JitterMs: 20}
+	require.Equal(t, types.NetworkConstraintUplinkLimited, classifyNetworkConstraint(lossyUplink, good))
+	require.Equal(t, types.NetworkConstraintUplinkLimited, classifyNetworkConstraint(lossyUplink, nil))
+
+	jitteryDownlink := &types.NetworkPathStats{Packets: 1000, PacketLossPercentage: 1, JitterMs: 90}
+	require.Equal(t, types.NetworkConstraintDownlinkLimited, classifyNetworkConstraint(lossyUplink, jitteryDownlink))
+
+	shortDownlink := *good
+	shortDownlink.EstimateBps = 500_000
+	shortDownlink.ExpectedBps = 2_000_000
+	require.Equal(t, types.NetworkConstraintDownlinkLimited, classifyNetworkConstraint(good, &shortDownlink))
+	shortDownlink.EstimateBps = 1_800_000
+	require.Equal(t, types.NetworkConstraintNone, classifyNetworkConstraint(good, &shortDownlink))
+
+	bloated := &types.NetworkPathStats{Packets: 1000, PacketLossPercentage: 0.5, JitterMs: 40, RTTMs: 450, MinRTTMs: 40}
+	require.Equal(t, types.NetworkConstraintBufferbloat, classifyNetworkConstraint(good, bloated))
+	// losing packets, the queue is not what limits the path
+	bloated.PacketLossPercentage = 10
+	require.Equal(t, types.NetworkConstraintDownlinkLimited, classifyNetworkConstraint(good, bloated))
+
+	asymmetry := getNetworkAsymmetry(lossyUplink, good)
+	require.InDelta(t, 7.8, asymmetry.PacketLossPercentage, 0.01)
+	require.InDelta(t, 15.0, asymmetry.JitterMs, 0.01)
+	require.Nil(t, getNetworkAsymmetry(lossyUplink, nil))
+}
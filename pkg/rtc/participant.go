@@ -60,6 +60,7 @@ const (
 
 	disconnectCleanupDuration = 5 * time.Second
 	migrationWaitDuration     = 3 * time.Second
+	participantDrainTimeout   = time.Second
 
 	PingIntervalSeconds = 5
 	PingTimeoutSeconds  = 15
@@ -105,11 +106,15 @@ type ParticipantParams struct {
 	PLIThrottleConfig       config.PLIThrottleConfig
 	CongestionControlConfig config.CongestionControlConfig
 	// codecs that are enabled for this room
-	PublishEnabledCodecs         []*livekit.Codec
-	SubscribeEnabledCodecs       []*livekit.Codec
-	Logger                       logger.Logger
-	SimTracks                    map[uint32]SimulcastTrackInfo
-	Grants                       *auth.ClaimGrants
+	PublishEnabledCodecs   []*livekit.Codec
+	SubscribeEnabledCodecs []*livekit.Codec
+	// fmtp parameters that override the negotiated ones, applied to both publisher and subscriber
+	FmtpOverrides []*livekit.Codec
+	Logger        logger.Logger
+	SimTracks     map[uint32]SimulcastTrackInfo
+	Grants        *auth.ClaimGrants
+	// API key the join token of the participant was signed with
+	APIKey                       string
 	InitialVersion               uint32
 	ClientConf                   *livekit.ClientConfiguration
 	ClientInfo                   ClientInfo
@@ -120,6 +125,7 @@ type ParticipantParams struct {
 	TCPFallbackRTTThreshold      int
 	AllowUDPUnstableFallback     bool
 	TURNSEnabled                 bool
+	ICECandidatePolicy           config.ICECandidatePolicy
 	GetParticipantInfo           func(pID livekit.ParticipantID) *livekit.ParticipantInfo
 	GetRegionSettings            func(ip string) *livekit.RegionSettings
 	DisableSupervisor            bool
@@ -127,6 +133,10 @@ type ParticipantParams struct {
 	ReconnectOnSubscriptionError bool
 	ReconnectOnDataChannelError  bool
 	DataChannelMaxBufferedAmount uint64
+	LossyData                    config.LossyDataConfig
+	Transcoder                   types.Transcoder
+	MaxTranscodedCodecs          int
+	VideoProcessor               types.VideoProcessor
 	VersionGenerator             utils.TimedVersionGenerator
 	TrackResolver                types.MediaTrackResolver
 	DisableDynacast              bool
@@ -135,6 +145,23 @@ type ParticipantParams struct {
 	SubscriptionLimitVideo       int32
 	PlayoutDelay                 *livekit.PlayoutDelay
 	SyncStreams                  bool
+	MaxTrackBitrate              int64
+	// settings subscriptions of the participant start with by track source
+	SubscribeDefaults config.SubscribeDefaultsConfig
+	// tracks the participant may subscribe to, set by the join token
+	SubscribeFilter *routing.SubscribeFilter
+	// bandwidth in bps the client expects to have downstream, seeds the initial channel capacity estimate
+	BandwidthHint int64
+	// codecs reported when a track is published with them
+	CodecDeprecations []config.CodecDeprecationConfig
+	// codecs the room allows to be negotiated, publisher offers are stripped of the others
+	CodecRestriction *CodecRestriction
+	// counts goroutines and timers of the participant and its tracks against the room it joined first
+	ResourceTracker *sutils.ResourceTracker
+	// stages of the join on the signal node, and the callback the breakdown of the join is passed to once
+	// it completes, not set for resumed sessions
+	JoinLatency     telemetry.JoinLatency
+	OnJoinCompleted func(participant types.LocalParticipant, latency telemetry.JoinLatency)
 }
 
 type ParticipantImpl struct {
@@ -147,7 +174,10 @@ type ParticipantImpl struct {
 	isClosed    atomic.Bool
 	closeReason atomic.Value // types.ParticipantCloseReason
 
-	state atomic.Value // livekit.ParticipantInfo_State
+	// stateLock orders updates of state against lifecycle transitions
+	stateLock sync.Mutex
+	state     atomic.Value // livekit.ParticipantInfo_State
+	lifecycle *participantLifecycle
 
 	resSinkMu sync.Mutex
 	resSink   routing.MessageSink
@@ -160,8 +190,8 @@ type ParticipantImpl struct {
 	// when first connected
 	connectedAt time.Time
 	// timer that's set when disconnect is detected on primary PC
-	disconnectTimer *time.Timer
-	migrationTimer  *time.Timer
+	disconnectTimer *sutils.TrackedTimer
+	migrationTimer  *sutils.TrackedTimer
 
 	pubRTCPQueue *sutils.OpsQueue
 
@@ -204,6 +234,9 @@ type ParticipantImpl struct {
 	dirty   atomic.Bool
 	version atomic.Uint32
 
+	// audio is kept muted while blocked, in push to talk rooms without the floor
+	audioBlocked atomic.Bool
+
 	// callbacks & handlers
 	onTrackPublished     func(types.LocalParticipant, types.MediaTrack)
 	onTrackUpdated       func(types.LocalParticipant, types.MediaTrack)
@@ -224,6 +257,14 @@ type ParticipantImpl struct {
 	supervisor *supervisor.ParticipantSupervisor
 
 	tracksQuality map[livekit.TrackID]livekit.ConnectionQuality
+	// network path stats, sampled with the connection quality
+	uplinkPath      *networkPathTracker
+	downlinkPath    *networkPathTracker
+	lastQualityInfo *livekit.ConnectionQualityInfo
+	// nil when disabled
+	trackStatsHistory *trackStatsHistory
+	subscriberCounts  *subscriberCountNotifier
+	joinLatency       *joinLatencyTracker
 
 	// loggers for publisher and subscriber
 	pubLogger logger.Logger
@@ -253,9 +294,20 @@ func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 			params.SID,
 			params.Telemetry),
 		tracksQuality: make(map[livekit.TrackID]livekit.ConnectionQuality),
+		uplinkPath:    newNetworkPathTracker(false),
+		downlinkPath:  newNetworkPathTracker(true),
 		pubLogger:     params.Logger.WithComponent(sutils.ComponentPub),
 		subLogger:     params.Logger.WithComponent(sutils.ComponentSub),
 	}
+	if params.Config != nil {
+		p.trackStatsHistory = newTrackStatsHistory(params.Config.TrackStatsHistory)
+	}
+	p.subscriberCounts = newSubscriberCountNotifier(subscriberCountNoticeInterval, p.sendSubscriberCountNotice)
+	if params.OnJoinCompleted != nil {
+		p.joinLatency = newJoinLatencyTracker(params.SessionStartTime, params.JoinLatency, func(latency telemetry.JoinLatency) {
+			params.OnJoinCompleted(p, latency)
+		})
+	}
 	if !params.DisableSupervisor {
 		p.supervisor = supervisor.NewParticipantSupervisor(supervisor.ParticipantSupervisorParams{Logger: params.Logger})
 	}
@@ -264,8 +316,20 @@ func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 	p.timedVersion.Update(params.VersionGenerator.New())
 	p.migrateState.Store(types.MigrateStateInit)
 	p.state.Store(livekit.ParticipantInfo_JOINING)
+	p.lifecycle = newParticipantLifecycle()
+	p.lifecycle.AddHook(p.onLifecycleTransition)
+	prometheus.RecordParticipantLifecycleTransition("", types.ParticipantLifecycleStateJoining.String())
 	p.grants = params.Grants
 	p.hidden.Store(p.grants.Video.Hidden)
+	if params.ICECandidatePolicy == config.ICECandidatePolicyRelay {
+		// only relay candidates are accepted, tell the client to gather nothing else
+		clientConf := &livekit.ClientConfiguration{}
+		if params.ClientConf != nil {
+			clientConf = proto.Clone(params.ClientConf).(*livekit.ClientConfiguration)
+		}
+		clientConf.ForceRelay = livekit.ClientConfigSetting_ENABLED
+		p.params.ClientConf = clientConf
+	}
 	p.SetResponseSink(params.Sink)
 	p.setupEnabledCodecs(params.PublishEnabledCodecs, params.SubscribeEnabledCodecs, params.ClientConf.GetDisabledCodecs())
 
@@ -342,6 +406,25 @@ func (p *ParticipantImpl) IsDisconnected() bool {
 	return p.State() == livekit.ParticipantInfo_DISCONNECTED
 }
 
+func (p *ParticipantImpl) LifecycleState() types.ParticipantLifecycleState {
+	return p.lifecycle.State()
+}
+
+func (p *ParticipantImpl) EnterOperation() (func(), bool) {
+	return p.lifecycle.Enter()
+}
+
+func (p *ParticipantImpl) AddLifecycleHook(hook func(p types.LocalParticipant, from, to types.ParticipantLifecycleState)) {
+	p.lifecycle.AddHook(func(from, to types.ParticipantLifecycleState) {
+		hook(p, from, to)
+	})
+}
+
+func (p *ParticipantImpl) onLifecycleTransition(from, to types.ParticipantLifecycleState) {
+	p.params.Logger.Debugw("participant lifecycle transition", "from", from.String(), "to", to.String())
+	prometheus.RecordParticipantLifecycleTransition(from.String(), to.String())
+}
+
 func (p *ParticipantImpl) IsIdle() bool {
 	// check if there are any published tracks that are subscribed
 	for _, t := range p.GetPublishedTracks() {
@@ -373,6 +456,10 @@ func (p *ParticipantImpl) GetBufferFactory() *buffer.Factory {
 	return p.params.Config.BufferFactory
 }
 
+func (p *ParticipantImpl) GetResourceTracker() *sutils.ResourceTracker {
+	return p.params.ResourceTracker
+}
+
 // SetName attaches name to the participant
 func (p *ParticipantImpl) SetName(name string) {
 	p.lock.Lock()
@@ -420,6 +507,26 @@ func (p *ParticipantImpl) SetMetadata(metadata string) {
 	}
 }
 
+func (p *ParticipantImpl) MoveToRoom(roomName livekit.RoomName) {
+	p.lock.Lock()
+	if p.grants.Video.Room == string(roomName) {
+		p.lock.Unlock()
+		return
+	}
+
+	p.params.Logger.Infow("moving participant", "destination", roomName)
+	p.grants.Video.Room = string(roomName)
+	p.dirty.Store(true)
+
+	onClaimsChanged := p.onClaimsChanged
+	p.lock.Unlock()
+
+	// client needs a token for the new room to be able to resume
+	if onClaimsChanged != nil {
+		onClaimsChanged(p)
+	}
+}
+
 func (p *ParticipantImpl) ClaimGrants() *auth.ClaimGrants {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -504,7 +611,7 @@ func (p *ParticipantImpl) ToProtoWithVersion() (*livekit.ParticipantInfo, utils.
 		Sid:         string(p.params.SID),
 		Identity:    string(p.params.Identity),
 		Name:        p.grants.Name,
-		State:       p.State(),
+		State:       p.protoState(),
 		JoinedAt:    p.ConnectedAt().Unix(),
 		Version:     v,
 		Permission:  p.grants.Video.ToPermission(),
@@ -548,6 +655,15 @@ func (p *ParticipantImpl) ToProtoWithVersion() (*livekit.ParticipantInfo, utils.
 	return pi, piv
 }
 
+// protoState maps the lifecycle to the state in ParticipantInfo, a joining participant is JOINING or JOINED,
+// an active or resuming participant is ACTIVE and a draining participant is already DISCONNECTED.
+func (p *ParticipantImpl) protoState() livekit.ParticipantInfo_State {
+	if p.lifecycle.State().IsClosing() {
+		return livekit.ParticipantInfo_DISCONNECTED
+	}
+	return p.State()
+}
+
 func (p *ParticipantImpl) ToProto() *livekit.ParticipantInfo {
 	pi, _ := p.ToProtoWithVersion()
 	return pi
@@ -644,6 +760,25 @@ func (p *ParticipantImpl) HandleSignalSourceClose() {
 
 	if !p.HasConnected() {
 		_ = p.Close(false, types.ParticipantCloseReasonSignalSourceClose, false)
+		return
+	}
+
+	p.stateLock.Lock()
+	if p.lifecycle.State() == types.ParticipantLifecycleStateActive {
+		_ = p.lifecycle.Transition(types.ParticipantLifecycleStateResuming)
+	}
+	p.stateLock.Unlock()
+}
+
+func (p *ParticipantImpl) SetSignalSourceValid(valid bool) {
+	p.TransportManager.SetSignalSourceValid(valid)
+
+	if valid {
+		p.stateLock.Lock()
+		if p.lifecycle.State() == types.ParticipantLifecycleStateResuming {
+			_ = p.lifecycle.Transition(types.ParticipantLifecycleStateActive)
+		}
+		p.stateLock.Unlock()
 	}
 }
 
@@ -655,6 +790,7 @@ func (p *ParticipantImpl) HandleOffer(offer webrtc.SessionDescription) {
 		shouldPend = true
 	}
 
+	offer = p.stripRestrictedCodecsForPublisher(offer)
 	offer = p.setCodecPreferencesForPublisher(offer)
 
 	p.TransportManager.HandleOffer(offer, shouldPend)
@@ -674,6 +810,7 @@ func (p *ParticipantImpl) HandleAnswer(answer webrtc.SessionDescription) {
 	p.TransportManager.UpdateSignalingRTT(uint32(signalConnCost))
 
 	p.TransportManager.HandleAnswer(answer)
+	p.joinLatency.offerAnswered()
 }
 
 func (p *ParticipantImpl) onPublisherAnswer(answer webrtc.SessionDescription) error {
@@ -683,11 +820,15 @@ func (p *ParticipantImpl) onPublisherAnswer(answer webrtc.SessionDescription) er
 
 	p.pubLogger.Debugw("sending answer", "transport", livekit.SignalTarget_PUBLISHER)
 	answer = p.configurePublisherAnswer(answer)
-	return p.writeMessage(&livekit.SignalResponse{
+	if err := p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_Answer{
 			Answer: ToProtoSessionDescription(answer),
 		},
-	})
+	}); err != nil {
+		return err
+	}
+	p.joinLatency.offerAnswered()
+	return nil
 }
 
 func (p *ParticipantImpl) handleMigrateTracks() {
@@ -734,6 +875,11 @@ func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
 		p.pubLogger.Warnw("no permission to publish track", nil)
 		return
 	}
+	req, err := p.filterCutOffPublishCodecs(req, time.Now())
+	if err != nil {
+		p.pubLogger.Infow("cannot publish track", "error", err)
+		return
+	}
 
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -784,11 +930,14 @@ func (p *ParticipantImpl) Close(sendLeave bool, reason types.ParticipantCloseRea
 		"isExpectedToResume", isExpectedToResume,
 	)
 	p.closeReason.Store(reason)
+	_ = p.lifecycle.Transition(types.ParticipantLifecycleStateDraining)
 	p.clearDisconnectTimer()
 	p.clearMigrationTimer()
+	p.subscriberCounts.close()
+	p.joinLatency.complete()
 
 	if sendLeave {
-		p.sendLeaveRequest(reason, isExpectedToResume, false, false)
+		p.sendLeaveRequest(reason, isExpectedToResume, false, false, nil)
 	}
 
 	if p.supervisor != nil {
@@ -800,6 +949,11 @@ func (p *ParticipantImpl) Close(sendLeave bool, reason types.ParticipantCloseRea
 	p.pendingPublishingTracks = make(map[livekit.TrackID]*pendingTrackInfo)
 	p.pendingTracksLock.Unlock()
 
+	// let operations in progress, like a track being published, complete before tearing down
+	if !p.lifecycle.WaitDrained(participantDrainTimeout) {
+		p.params.Logger.Warnw("participant operations did not complete before close", nil)
+	}
+
 	p.UpTrackManager.Close(isExpectedToResume)
 
 	p.updateState(livekit.ParticipantInfo_DISCONNECTED)
@@ -815,11 +969,12 @@ func (p *ParticipantImpl) Close(sendLeave bool, reason types.ParticipantCloseRea
 
 	// Close peer connections without blocking participant Close. If peer connections are gathering candidates
 	// Close will block.
-	go func() {
+	p.params.ResourceTracker.Go("participant.closeTransports", func() {
 		p.SubscriptionManager.Close(isExpectedToResume)
 		p.TransportManager.Close()
 		p.ParticipantTrafficLoad.Close()
-	}()
+		_ = p.lifecycle.Transition(types.ParticipantLifecycleStateDisconnected)
+	})
 
 	p.dataChannelStats.Stop()
 	return nil
@@ -851,11 +1006,7 @@ func (p *ParticipantImpl) clearMigrationTimer() {
 }
 
 func (p *ParticipantImpl) MaybeStartMigration(force bool, onStart func()) bool {
-	allTransportConnected := p.TransportManager.HasSubscriberEverConnected()
-	if p.IsPublisher() {
-		allTransportConnected = allTransportConnected && p.TransportManager.HasPublisherEverConnected()
-	}
-	if !force && !allTransportConnected {
+	if !force && !p.hasTransportsEverConnected() {
 		return false
 	}
 
@@ -863,9 +1014,21 @@ func (p *ParticipantImpl) MaybeStartMigration(force bool, onStart func()) bool {
 		onStart()
 	}
 
-	p.sendLeaveRequest(types.ParticipantCloseReasonMigrationRequested, true, false, true)
+	p.sendLeaveRequest(types.ParticipantCloseReasonMigrationRequested, true, false, true, nil)
 	p.CloseSignalConnection(types.SignallingCloseReasonMigration)
+	p.startMigrationTimer()
+	return true
+}
 
+func (p *ParticipantImpl) hasTransportsEverConnected() bool {
+	allTransportConnected := p.TransportManager.HasSubscriberEverConnected()
+	if p.IsPublisher() {
+		allTransportConnected = allTransportConnected && p.TransportManager.HasPublisherEverConnected()
+	}
+	return allTransportConnected
+}
+
+func (p *ParticipantImpl) startMigrationTimer() {
 	//
 	// On subscriber peer connection, remote side will try ICE on both
 	// pre- and post-migration ICE candidates as the migrating out
@@ -880,7 +1043,7 @@ func (p *ParticipantImpl) MaybeStartMigration(force bool, onStart func()) bool {
 	p.clearMigrationTimer()
 
 	p.lock.Lock()
-	p.migrationTimer = time.AfterFunc(migrationWaitDuration, func() {
+	p.migrationTimer = p.params.ResourceTracker.AfterFunc("participant.migrationTimer", migrationWaitDuration, func() {
 		p.clearMigrationTimer()
 
 		if p.IsClosed() || p.IsDisconnected() {
@@ -899,8 +1062,6 @@ func (p *ParticipantImpl) MaybeStartMigration(force bool, onStart func()) bool {
 		p.TransportManager.SubscriberClose()
 	})
 	p.lock.Unlock()
-
-	return true
 }
 
 func (p *ParticipantImpl) SetMigrateState(s types.MigrateState) {
@@ -959,10 +1120,15 @@ func (p *ParticipantImpl) GetConnectionQuality() *livekit.ConnectionQualityInfo
 	numDownDrops := 0
 
 	availableTracks := make(map[livekit.TrackID]bool)
+	uplinkStats := make(map[livekit.TrackID]*livekit.RTPStats)
+	downlinkStats := make(map[livekit.TrackID]*livekit.RTPStats)
 
 	for _, pt := range p.GetPublishedTracks() {
 		numTracks++
 
+		if stats := pt.(types.LocalMediaTrack).GetTrackStats(); stats != nil {
+			uplinkStats[pt.ID()] = stats
+		}
 		score, quality := pt.(types.LocalMediaTrack).GetConnectionScoreAndQuality()
 		if utils.IsConnectionQualityLower(minQuality, quality) {
 			minQuality = quality
@@ -988,6 +1154,9 @@ func (p *ParticipantImpl) GetConnectionQuality() *livekit.ConnectionQualityInfo
 	for _, subTrack := range subscribedTracks {
 		numTracks++
 
+		if stats := subTrack.DownTrack().GetTrackStats(); stats != nil {
+			downlinkStats[subTrack.ID()] = stats
+		}
 		score, quality := subTrack.DownTrack().GetConnectionScoreAndQuality()
 		if utils.IsConnectionQualityLower(minQuality, quality) {
 			minQuality = quality
@@ -1018,8 +1187,22 @@ func (p *ParticipantImpl) GetConnectionQuality() *livekit.ConnectionQualityInfo
 			delete(p.tracksQuality, trackID)
 		}
 	}
+	now := time.Now()
+	p.uplinkPath.update(uplinkStats, now)
+	p.downlinkPath.update(downlinkStats, now)
+	p.lastQualityInfo = &livekit.ConnectionQualityInfo{
+		ParticipantSid: string(p.ID()),
+		Quality:        minQuality,
+		Score:          minScore,
+	}
 	p.lock.Unlock()
 
+	p.trackStatsHistory.add(uplinkStats, downlinkStats, now)
+
+	for _, stats := range getAVSyncStats(subscribedTracks) {
+		prometheus.RecordAVSyncSkew(stats.SkewMs)
+	}
+
 	if minQuality == livekit.ConnectionQuality_LOST && !p.ProtocolVersion().SupportsConnectionQualityLost() {
 		minQuality = livekit.ConnectionQuality_POOR
 	}
@@ -1031,6 +1214,54 @@ func (p *ParticipantImpl) GetConnectionQuality() *livekit.ConnectionQualityInfo
 	}
 }
 
+// GetConnectionQualityDetails returns the connection quality of the last update along with the stats of
+// the uplink and downlink of the participant, and the probable constraint of its network
+func (p *ParticipantImpl) GetConnectionQualityDetails() *types.ConnectionQualityDetails {
+	p.lock.RLock()
+	info := p.lastQualityInfo
+	uplink := p.uplinkPath.getStats()
+	downlink := p.downlinkPath.getStats()
+	p.lock.RUnlock()
+
+	details := &types.ConnectionQualityDetails{
+		Quality: livekit.ConnectionQuality_EXCELLENT.String(),
+		Score:   connectionquality.MaxMOS,
+	}
+	if info != nil {
+		details.Quality = info.Quality.String()
+		details.Score = info.Score
+	}
+
+	if downlink != nil {
+		if allocation := p.TransportManager.GetSubscriberAllocationInfo(0); allocation != nil {
+			downlink.EstimateBps = allocation.LastReceivedEstimate
+			downlink.ExpectedBps = allocation.ExpectedBandwidthUsage
+		}
+	}
+	details.Uplink = uplink
+	details.Downlink = downlink
+	details.Asymmetry = getNetworkAsymmetry(uplink, downlink)
+	details.Constraint = classifyNetworkConstraint(uplink, downlink)
+	details.Advice = networkConstraintAdvice[details.Constraint]
+	return details
+}
+
+// GetTrackStatsHistory returns the RTP stats snapshots of the tracks published and subscribed by the participant
+// over the last window, of a single track when trackID is set. It returns nil when the history is disabled.
+func (p *ParticipantImpl) GetTrackStatsHistory(trackID livekit.TrackID, window time.Duration) []*types.TrackStatsSeries {
+	if p.trackStatsHistory == nil {
+		return nil
+	}
+	return p.trackStatsHistory.get(trackID, window, time.Now())
+}
+
+// GetAVSyncDetails returns the audio/video sync of the publishers the participant subscribes to, as forwarded to it
+func (p *ParticipantImpl) GetAVSyncDetails() *types.AVSyncDetails {
+	return &types.AVSyncDetails{
+		Publishers: getAVSyncStats(p.SubscriptionManager.GetSubscribedTracks()),
+	}
+}
+
 func (p *ParticipantImpl) IsPublisher() bool {
 	return p.isPublisher.Load()
 }
@@ -1103,6 +1334,11 @@ func (p *ParticipantImpl) onTrackSubscribed(subTrack types.SubscribedTrack) {
 			subTrack.DownTrack().SetConnected()
 		}
 		p.TransportManager.AddSubscribedTrack(subTrack)
+		p.joinLatency.mediaStarted()
+	})
+	// the stream allocator reads the rank when the track is added on bind, changes before that are no-ops
+	subTrack.OnSubscriberRankChange(func() {
+		p.TransportManager.UpdateSubscribedTrackRank(subTrack)
 	})
 }
 
@@ -1159,6 +1395,14 @@ func (h AnyTransportHandler) OnNegotiationFailed() {
 	h.p.onAnyTransportNegotiationFailed()
 }
 
+func (h AnyTransportHandler) OnSessionDescriptionFailed(failure *telemetry.NegotiationFailure) {
+	h.p.params.Telemetry.NegotiationFailed(context.Background(), h.p.ID(), h.p.Identity(), h.p.GetClientInfo(), failure)
+}
+
+func (h AnyTransportHandler) OnICEConnectivity(connectivity *telemetry.ICEConnectivity) {
+	h.p.params.Telemetry.ICEConnectivity(context.Background(), h.p.ID(), h.p.Identity(), h.p.GetClientInfo(), connectivity)
+}
+
 func (h AnyTransportHandler) OnICECandidate(c *webrtc.ICECandidate, target livekit.SignalTarget) error {
 	return h.p.onICECandidate(c, target)
 }
@@ -1199,6 +1443,10 @@ func (h SubscriberTransportHandler) OnStreamStateChange(update *streamallocator.
 	return h.p.onStreamStateChange(update)
 }
 
+func (h SubscriberTransportHandler) OnAudioOnlyChange(audioOnly bool) {
+	h.p.onAudioOnlyChange(audioOnly)
+}
+
 func (h SubscriberTransportHandler) OnInitialConnected() {
 	h.p.onSubscriberInitialConnected()
 }
@@ -1249,6 +1497,7 @@ func (p *ParticipantImpl) setupTransportManager() error {
 		CongestionControlConfig:      p.params.CongestionControlConfig,
 		EnabledPublishCodecs:         p.enabledPublishCodecs,
 		EnabledSubscribeCodecs:       p.enabledSubscribeCodecs,
+		FmtpOverrides:                p.params.FmtpOverrides,
 		SimTracks:                    p.params.SimTracks,
 		ClientInfo:                   p.params.ClientInfo,
 		Migration:                    p.params.Migration,
@@ -1258,6 +1507,9 @@ func (p *ParticipantImpl) setupTransportManager() error {
 		TURNSEnabled:                 p.params.TURNSEnabled,
 		AllowPlayoutDelay:            p.params.PlayoutDelay.GetEnabled(),
 		DataChannelMaxBufferedAmount: p.params.DataChannelMaxBufferedAmount,
+		LossyData:                    p.params.LossyData,
+		BandwidthHint:                p.params.BandwidthHint,
+		ICECandidatePolicy:           p.params.ICECandidatePolicy,
 		Logger:                       p.params.Logger.WithComponent(sutils.ComponentTransport),
 		PublisherHandler:             pth,
 		SubscriberHandler:            sth,
@@ -1279,7 +1531,7 @@ func (p *ParticipantImpl) setupTransportManager() error {
 		if p.params.ClientConf == nil {
 			p.params.ClientConf = &livekit.ClientConfiguration{}
 		}
-		if iceConfig.PreferenceSubscriber == livekit.ICECandidateType_ICT_TLS {
+		if iceConfig.PreferenceSubscriber == livekit.ICECandidateType_ICT_TLS || p.params.ICECandidatePolicy == config.ICECandidatePolicyRelay {
 			p.params.ClientConf.ForceRelay = livekit.ClientConfigSetting_ENABLED
 		} else {
 			// UNSET indicates that clients could override RTCConfiguration to forceRelay
@@ -1336,8 +1588,26 @@ func (p *ParticipantImpl) setupParticipantTrafficLoad() {
 	})
 }
 
+// updateState updates the state reported in ParticipantInfo. It has to agree with the lifecycle, an update
+// racing with Close, such as the transport becoming connected as the participant closes, is dropped.
 func (p *ParticipantImpl) updateState(state livekit.ParticipantInfo_State) {
+	p.stateLock.Lock()
+	var err error
+	switch state {
+	case livekit.ParticipantInfo_JOINING, livekit.ParticipantInfo_JOINED:
+		if lifecycleState := p.lifecycle.State(); lifecycleState != types.ParticipantLifecycleStateJoining {
+			err = fmt.Errorf("%w: %s in %s", ErrInvalidTransition, state, lifecycleState)
+		}
+	case livekit.ParticipantInfo_ACTIVE:
+		err = p.lifecycle.Transition(types.ParticipantLifecycleStateActive)
+	}
+	if err != nil {
+		p.stateLock.Unlock()
+		p.params.Logger.Debugw("ignoring participant state update", "state", state.String(), "error", err)
+		return
+	}
 	oldState := p.state.Swap(state).(livekit.ParticipantInfo_State)
+	p.stateLock.Unlock()
 	if oldState == state {
 		return
 	}
@@ -1395,9 +1665,11 @@ func (p *ParticipantImpl) removePublishedTrack(track types.MediaTrack) {
 
 // when a new remoteTrack is created, creates a Track and adds it to room
 func (p *ParticipantImpl) onMediaTrack(track *webrtc.TrackRemote, rtpReceiver *webrtc.RTPReceiver) {
-	if p.IsDisconnected() {
+	done, ok := p.EnterOperation()
+	if !ok {
 		return
 	}
+	defer done()
 
 	publishedTrack, isNewTrack := p.mediaTrackReceived(track, rtpReceiver)
 	if publishedTrack == nil {
@@ -1418,9 +1690,14 @@ func (p *ParticipantImpl) onMediaTrack(track *webrtc.TrackRemote, rtpReceiver *w
 		p.removePublishedTrack(publishedTrack)
 		return
 	}
+	if isNewTrack && p.rejectCutOffTrack(publishedTrack, track.Codec().MimeType, time.Now()) {
+		return
+	}
 
 	p.setIsPublisher(true)
 	p.dirty.Store(true)
+	// the first packet of the track has been read by the time it is received
+	p.joinLatency.mediaStarted()
 
 	p.pubLogger.Infow("mediaTrack published",
 		"kind", track.Kind().String(),
@@ -1501,7 +1778,7 @@ func (p *ParticipantImpl) onPublisherInitialConnected() {
 }
 
 func (p *ParticipantImpl) onSubscriberInitialConnected() {
-	go p.subscriberRTCPWorker()
+	p.params.ResourceTracker.Go("participant.subscriberRTCPWorker", p.subscriberRTCPWorker)
 
 	p.setDowntracksConnected()
 }
@@ -1518,6 +1795,7 @@ func (p *ParticipantImpl) onPrimaryTransportFullyEstablished() {
 	if !p.sessionStartRecorded.Swap(true) {
 		prometheus.RecordSessionStartTime(int(p.ProtocolVersion()), time.Since(p.params.SessionStartTime))
 	}
+	p.joinLatency.transportConnected()
 	p.updateState(livekit.ParticipantInfo_ACTIVE)
 }
 
@@ -1534,7 +1812,7 @@ func (p *ParticipantImpl) setupDisconnectTimer() {
 	p.clearDisconnectTimer()
 
 	p.lock.Lock()
-	p.disconnectTimer = time.AfterFunc(disconnectCleanupDuration, func() {
+	p.disconnectTimer = p.params.ResourceTracker.AfterFunc("participant.disconnectTimer", disconnectCleanupDuration, func() {
 		p.clearDisconnectTimer()
 
 		if p.IsClosed() || p.IsDisconnected() {
@@ -1547,7 +1825,7 @@ func (p *ParticipantImpl) setupDisconnectTimer() {
 
 func (p *ParticipantImpl) onAnyTransportFailed() {
 	// clients support resuming of connections when websocket becomes disconnected
-	p.sendLeaveRequest(types.ParticipantCloseReasonPeerConnectionDisconnected, true, false, true)
+	p.sendLeaveRequest(types.ParticipantCloseReasonPeerConnectionDisconnected, true, false, true, nil)
 	p.CloseSignalConnection(types.SignallingCloseReasonTransportFailure)
 
 	// detect when participant has actually left.
@@ -1738,6 +2016,9 @@ func (p *ParticipantImpl) addPendingTrackLocked(req *livekit.AddTrackRequest) *l
 	if ti.Stream == "" {
 		ti.Stream = StreamFromTrackSource(ti.Source)
 	}
+	if ti.Type == livekit.TrackType_AUDIO && p.audioBlocked.Load() {
+		ti.Muted = true
+	}
 	p.setStableTrackID(req.Cid, ti)
 
 	if len(req.SimulcastCodecs) == 0 {
@@ -1837,6 +2118,16 @@ func (p *ParticipantImpl) sendTrackPublished(cid string, ti *livekit.TrackInfo)
 }
 
 func (p *ParticipantImpl) SetTrackMuted(trackID livekit.TrackID, muted bool, fromAdmin bool) *livekit.TrackInfo {
+	if !muted && p.audioBlocked.Load() && p.isAudioTrack(trackID) {
+		p.pubLogger.Infow("refusing to unmute blocked audio track", "trackID", trackID, "fromAdmin", fromAdmin)
+		// the client may have unmuted locally, keep it in sync with what is forwarded
+		p.sendTrackMuted(trackID, true)
+		if track := p.GetPublishedTrack(trackID); track != nil {
+			return track.ToProto()
+		}
+		return nil
+	}
+
 	// when request is coming from admin, send message to current participant
 	if fromAdmin {
 		p.sendTrackMuted(trackID, muted)
@@ -1845,6 +2136,81 @@ func (p *ParticipantImpl) SetTrackMuted(trackID livekit.TrackID, muted bool, fro
 	return p.setTrackMuted(trackID, muted)
 }
 
+// SetTracksMuted mutes or unmutes published tracks with a single participant update to the room, instead of one
+// per track. It returns the tracks whose mute status changed
+func (p *ParticipantImpl) SetTracksMuted(trackIDs []livekit.TrackID, muted bool, fromAdmin bool) []*livekit.TrackInfo {
+	toChange := make([]livekit.TrackID, 0, len(trackIDs))
+	for _, trackID := range trackIDs {
+		if !muted && p.audioBlocked.Load() && p.isAudioTrack(trackID) {
+			p.pubLogger.Infow("refusing to unmute blocked audio track", "trackID", trackID, "fromAdmin", fromAdmin)
+			p.sendTrackMuted(trackID, true)
+			continue
+		}
+		toChange = append(toChange, trackID)
+	}
+
+	if fromAdmin {
+		for _, trackID := range toChange {
+			p.sendTrackMuted(trackID, muted)
+		}
+	}
+
+	p.dirty.Store(true)
+	if p.supervisor != nil {
+		for _, trackID := range toChange {
+			p.supervisor.SetPublicationMute(trackID, muted)
+		}
+	}
+
+	changed := p.UpTrackManager.SetPublishedTracksMuted(toChange, muted)
+	trackInfos := make([]*livekit.TrackInfo, 0, len(changed))
+	for _, track := range changed {
+		trackInfo := track.ToProto()
+		if muted {
+			p.params.Telemetry.TrackMuted(context.Background(), p.ID(), trackInfo)
+		} else {
+			p.params.Telemetry.TrackUnmuted(context.Background(), p.ID(), trackInfo)
+		}
+		trackInfos = append(trackInfos, trackInfo)
+	}
+	return trackInfos
+}
+
+// BlockAudio keeps the audio tracks of the participant muted while blocked, published tracks are muted
+// as if by an admin and requests to unmute are refused. Lifting the block does not unmute, that is left to the client.
+func (p *ParticipantImpl) BlockAudio(blocked bool) {
+	if p.audioBlocked.Swap(blocked) == blocked || !blocked {
+		return
+	}
+
+	for _, track := range p.GetPublishedTracks() {
+		if track.Kind() == livekit.TrackType_AUDIO && !track.IsMuted() {
+			p.SetTrackMuted(track.ID(), true, true)
+		}
+	}
+}
+
+func (p *ParticipantImpl) IsAudioBlocked() bool {
+	return p.audioBlocked.Load()
+}
+
+func (p *ParticipantImpl) isAudioTrack(trackID livekit.TrackID) bool {
+	if track := p.GetPublishedTrack(trackID); track != nil {
+		return track.Kind() == livekit.TrackType_AUDIO
+	}
+
+	p.pendingTracksLock.RLock()
+	defer p.pendingTracksLock.RUnlock()
+	for _, pti := range p.pendingTracks {
+		for _, ti := range pti.trackInfos {
+			if livekit.TrackID(ti.Sid) == trackID {
+				return ti.Type == livekit.TrackType_AUDIO
+			}
+		}
+	}
+	return false
+}
+
 func (p *ParticipantImpl) setTrackMuted(trackID livekit.TrackID, muted bool) *livekit.TrackInfo {
 	p.dirty.Store(true)
 	if p.supervisor != nil {
@@ -2032,9 +2398,19 @@ func (p *ParticipantImpl) addMediaTrack(signalCid string, sdpCid string, ti *liv
 		PLIThrottleConfig:   p.params.PLIThrottleConfig,
 		SimTracks:           p.params.SimTracks,
 		OnRTCP:              p.postRtcp,
+		ResourceTracker:     p.params.ResourceTracker,
+		Transcoder:          p.params.Transcoder,
+		MaxTranscodedCodecs: p.params.MaxTranscodedCodecs,
+		VideoProcessor:      p.params.VideoProcessor,
+		CodecRestriction:    p.params.CodecRestriction,
+
+		PotentialCodecTimeout: p.params.Config.PotentialCodecTimeout,
 	}, ti)
 
 	mt.OnSubscribedMaxQualityChange(p.onSubscribedMaxQualityChange)
+	mt.OnSubscriberCountChange(func(count int) {
+		p.subscriberCounts.update(livekit.TrackID(ti.Sid), count)
+	})
 
 	// add to published and clean up pending
 	if p.supervisor != nil {
@@ -2094,6 +2470,11 @@ func (p *ParticipantImpl) addMediaTrack(signalCid string, sdpCid string, ti *liv
 }
 
 func (p *ParticipantImpl) handleTrackPublished(track types.MediaTrack) {
+	if track.Kind() == livekit.TrackType_AUDIO && p.audioBlocked.Load() {
+		// published muted while blocked, the client has to be told
+		p.sendTrackMuted(track.ID(), true)
+	}
+
 	if onTrackPublished := p.getOnTrackPublished(); onTrackPublished != nil {
 		onTrackPublished(p, track)
 	}
@@ -2106,6 +2487,7 @@ func (p *ParticipantImpl) handleTrackPublished(track types.MediaTrack) {
 		p.Identity(),
 		track.ToProto(),
 	)
+	p.reportDeprecatedCodecs(track)
 
 	p.pendingTracksLock.Lock()
 	delete(p.pendingPublishingTracks, track.ID())
@@ -2258,8 +2640,9 @@ func (p *ParticipantImpl) getPublishedTrackBySdpCid(clientId string) types.Media
 
 func (p *ParticipantImpl) DebugInfo() map[string]interface{} {
 	info := map[string]interface{}{
-		"ID":    p.params.SID,
-		"State": p.State().String(),
+		"ID":        p.params.SID,
+		"State":     p.State().String(),
+		"Lifecycle": p.LifecycleState().String(),
 	}
 
 	pendingTrackInfo := make(map[string]interface{})
@@ -2279,6 +2662,8 @@ func (p *ParticipantImpl) DebugInfo() map[string]interface{} {
 	info["PendingTracks"] = pendingTrackInfo
 
 	info["UpTrackManager"] = p.UpTrackManager.DebugInfo()
+	info["Transports"] = p.TransportManager.DebugInfo()
+	info["AVSync"] = getAVSyncStats(p.SubscriptionManager.GetSubscribedTracks())
 
 	return info
 }
@@ -2332,7 +2717,7 @@ func (p *ParticipantImpl) GetCachedDownTrack(trackID livekit.TrackID) (*webrtc.R
 }
 
 func (p *ParticipantImpl) IssueFullReconnect(reason types.ParticipantCloseReason) {
-	p.sendLeaveRequest(reason, false, true, false)
+	p.sendLeaveRequest(reason, false, true, false, nil)
 
 	scr := types.SignallingCloseReasonUnknown
 	switch reason {
@@ -2351,6 +2736,35 @@ func (p *ParticipantImpl) IssueFullReconnect(reason types.ParticipantCloseReason
 	p.Close(false, reason, false)
 }
 
+// IssueRedirect asks the client to resume its session against target, the URL of another node or region. The
+// resume token is sent ahead, the client resumes with it there. Clients that cannot be told where to go resume
+// against the URL they are connected to. It returns false when the transports never connected, there is no
+// session to resume then.
+func (p *ParticipantImpl) IssueRedirect(target *livekit.RegionInfo, resumeToken string) bool {
+	if !p.hasTransportsEverConnected() {
+		return false
+	}
+
+	p.params.Logger.Infow("redirecting participant", "region", target.GetRegion(), "url", target.GetUrl())
+
+	// token goes out first so that it is in place by the time client acts on the leave request
+	if resumeToken != "" {
+		if err := p.SendRefreshToken(resumeToken); err != nil {
+			p.params.Logger.Warnw("could not send resume token for redirect", err)
+		}
+	}
+
+	var regions *livekit.RegionSettings
+	if target != nil {
+		// explicit target, client should not pick a region on its own
+		regions = &livekit.RegionSettings{Regions: []*livekit.RegionInfo{target}}
+	}
+	p.sendLeaveRequest(types.ParticipantCloseReasonRedirectRequested, true, false, true, regions)
+	p.CloseSignalConnection(types.SignallingCloseReasonRedirect)
+	p.startMigrationTimer()
+	return true
+}
+
 func (p *ParticipantImpl) onPublicationError(trackID livekit.TrackID) {
 	if p.params.ReconnectOnPublicationError {
 		p.pubLogger.Infow("issuing full reconnect on publication error", "trackID", trackID)
@@ -2415,6 +2829,22 @@ func (p *ParticipantImpl) GetPlayoutDelayConfig() *livekit.PlayoutDelay {
 	return p.params.PlayoutDelay
 }
 
+func (p *ParticipantImpl) GetMaxTrackBitrate() int64 {
+	return p.params.MaxTrackBitrate
+}
+
+func (p *ParticipantImpl) GetSubscribeDefaults(source livekit.TrackSource) *config.SubscribeDefaults {
+	return p.params.SubscribeDefaults.For(source)
+}
+
+func (p *ParticipantImpl) GetSubscribeFilter() *routing.SubscribeFilter {
+	return p.params.SubscribeFilter
+}
+
+func (p *ParticipantImpl) GetAPIKey() string {
+	return p.params.APIKey
+}
+
 func (p *ParticipantImpl) SupportsSyncStreamID() bool {
 	return p.ProtocolVersion().SupportSyncStreamID() && !p.params.ClientInfo.isFirefox() && p.params.SyncStreams
 }
@@ -2478,7 +2908,7 @@ func (p *ParticipantImpl) setupEnabledCodecs(publishEnabledCodecs []*livekit.Cod
 
 	publishCodecs := make([]*livekit.Codec, 0, len(publishEnabledCodecs))
 	for _, c := range publishEnabledCodecs {
-		if shouldDisable(c, disabledCodecs.GetCodecs()) || shouldDisable(c, disabledCodecs.GetPublish()) {
+		if shouldDisable(c, disabledCodecs.GetCodecs()) || shouldDisable(c, disabledCodecs.GetPublish()) || !p.params.CodecRestriction.Allows(c.Mime) {
 			continue
 		}
 		publishCodecs = append(publishCodecs, c)
@@ -2487,7 +2917,7 @@ func (p *ParticipantImpl) setupEnabledCodecs(publishEnabledCodecs []*livekit.Cod
 
 	subscribeCodecs := make([]*livekit.Codec, 0, len(subscribeEnabledCodecs))
 	for _, c := range subscribeEnabledCodecs {
-		if shouldDisable(c, disabledCodecs.GetCodecs()) {
+		if shouldDisable(c, disabledCodecs.GetCodecs()) || !p.params.CodecRestriction.Allows(c.Mime) {
 			continue
 		}
 		subscribeCodecs = append(subscribeCodecs, c)
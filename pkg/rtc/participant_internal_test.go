@@ -240,6 +240,49 @@ func TestOutOfOrderUpdates(t *testing.T) {
 	require.Equal(t, "second update", sent.GetUpdate().Participants[0].Metadata)
 }
 
+func TestIssueRedirect(t *testing.T) {
+	t.Run("sends resume token and leave with target region", func(t *testing.T) {
+		p := newParticipantForTestWithOpts("test", &participantOpts{protocolVersion: 13})
+		p.TransportManager.subscriber.setConnectedAt(time.Now())
+		sink := p.getResponseSink().(*routingfakes.FakeMessageSink)
+		target := &livekit.RegionInfo{Region: "us-west", Url: "wss://us-west.example.com"}
+
+		require.True(t, p.IssueRedirect(target, "token"))
+
+		require.Equal(t, 2, sink.WriteMessageCallCount())
+		require.Equal(t, "token", sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse).GetRefreshToken())
+		leave := sink.WriteMessageArgsForCall(1).(*livekit.SignalResponse).GetLeave()
+		require.NotNil(t, leave)
+		require.Equal(t, livekit.LeaveRequest_RESUME, leave.Action)
+		require.Equal(t, livekit.DisconnectReason_MIGRATION, leave.Reason)
+		require.Len(t, leave.Regions.GetRegions(), 1)
+		require.Equal(t, target.Url, leave.Regions.Regions[0].Url)
+		require.Equal(t, 1, sink.CloseCallCount())
+		// the session carries on, on this node or the one the client resumes on
+		require.False(t, p.IsClosed())
+	})
+
+	t.Run("older clients resume against their url", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.TransportManager.subscriber.setConnectedAt(time.Now())
+		sink := p.getResponseSink().(*routingfakes.FakeMessageSink)
+
+		require.True(t, p.IssueRedirect(&livekit.RegionInfo{Url: "wss://other.example.com"}, "token"))
+
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+		require.Equal(t, "token", sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse).GetRefreshToken())
+		require.Equal(t, 1, sink.CloseCallCount())
+	})
+
+	t.Run("nothing to resume before transports connect", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		sink := p.getResponseSink().(*routingfakes.FakeMessageSink)
+
+		require.False(t, p.IssueRedirect(&livekit.RegionInfo{Url: "wss://other.example.com"}, "token"))
+		require.Zero(t, sink.WriteMessageCallCount())
+	})
+}
+
 // after disconnection, things should continue to function and not panic
 func TestDisconnectTiming(t *testing.T) {
 	t.Run("Negotiate doesn't panic after channel closed", func(t *testing.T) {
@@ -289,6 +332,26 @@ func TestMuteSetting(t *testing.T) {
 		require.NotNil(t, ti)
 		require.True(t, ti.Muted)
 	})
+
+	t.Run("blocked audio stays muted", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.BlockAudio(true)
+		p.AddTrack(&livekit.AddTrackRequest{
+			Cid:  "cid",
+			Type: livekit.TrackType_AUDIO,
+		})
+
+		_, ti, _ := p.getPendingTrack("cid", livekit.TrackType_AUDIO)
+		require.NotNil(t, ti)
+		require.True(t, ti.Muted)
+
+		p.SetTrackMuted(livekit.TrackID(ti.Sid), false, false)
+		require.True(t, ti.Muted)
+
+		p.BlockAudio(false)
+		p.SetTrackMuted(livekit.TrackID(ti.Sid), false, false)
+		require.False(t, ti.Muted)
+	})
 }
 
 func TestSubscriberAsPrimary(t *testing.T) {
@@ -532,6 +595,74 @@ func TestDisablePublishCodec(t *testing.T) {
 	require.Eventually(t, func() bool { return publishReceived.Load() }, 5*time.Second, 10*time.Millisecond)
 }
 
+func TestCodecRestriction(t *testing.T) {
+	t.Run("allows and denies by mime type", func(t *testing.T) {
+		var none *CodecRestriction
+		require.True(t, none.Allows("video/H264"))
+		require.True(t, none.IsValid())
+
+		vp8Only := &CodecRestriction{Allow: []string{"video/vp8"}, Deny: []string{"audio/red"}}
+		require.True(t, vp8Only.IsValid())
+		require.True(t, vp8Only.Allows("video/VP8"))
+		require.False(t, vp8Only.Allows("video/H264"))
+		require.True(t, vp8Only.Allows("video/rtx"))
+		require.True(t, vp8Only.Allows("audio/opus"))
+		require.False(t, vp8Only.Allows("audio/red"))
+
+		require.False(t, (&CodecRestriction{Allow: []string{"vp8"}}).IsValid())
+		require.False(t, (&CodecRestriction{Allow: []string{"video/vp8"}, Deny: []string{"video/VP8"}}).IsValid())
+	})
+
+	t.Run("publisher negotiates allowed codecs only", func(t *testing.T) {
+		participant := newParticipantForTestWithOpts("123", &participantOpts{
+			publisher:        true,
+			codecRestriction: &CodecRestriction{Allow: []string{"video/vp8"}},
+		})
+		participant.SetMigrateState(types.MigrateStateComplete)
+		for _, codec := range participant.enabledPublishCodecs {
+			require.NotEqual(t, "video/h264", strings.ToLower(codec.Mime))
+		}
+
+		pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+		require.NoError(t, err)
+		defer pc.Close()
+		transceiver, err := pc.AddTransceiverFromKind(webrtc.RTPCodecTypeVideo, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+		require.NoError(t, err)
+		offer, err := pc.CreateOffer(nil)
+		require.NoError(t, err)
+		require.NoError(t, pc.SetLocalDescription(offer))
+
+		strippedOffer := participant.stripRestrictedCodecsForPublisher(offer)
+		stripped, err := strippedOffer.Unmarshal()
+		require.NoError(t, err)
+		codecs, err := codecsFromMediaDescription(stripped.MediaDescriptions[0])
+		require.NoError(t, err)
+		require.NotEmpty(t, codecs)
+		for _, c := range codecs {
+			require.Contains(t, []string{"vp8", "rtx"}, strings.ToLower(c.Name))
+		}
+
+		sink := &routingfakes.FakeMessageSink{}
+		participant.SetResponseSink(sink)
+		var answer webrtc.SessionDescription
+		var answerReceived atomic.Bool
+		sink.WriteMessageCalls(func(msg proto.Message) error {
+			if res, ok := msg.(*livekit.SignalResponse); ok && res.GetAnswer() != nil {
+				answer = FromProtoSessionDescription(res.GetAnswer())
+				answerReceived.Store(true)
+			}
+			return nil
+		})
+		participant.HandleOffer(offer)
+		require.Eventually(t, func() bool { return answerReceived.Load() }, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, pc.SetRemoteDescription(answer))
+
+		for _, c := range transceiver.Sender().GetParameters().Codecs {
+			require.Contains(t, []string{"video/vp8", "video/rtx"}, strings.ToLower(c.MimeType))
+		}
+	})
+}
+
 func TestPreferVideoCodecForPublisher(t *testing.T) {
 	participant := newParticipantForTestWithOpts("123", &participantOpts{
 		publisher: true,
@@ -710,11 +841,12 @@ func TestPreferAudioCodecForRed(t *testing.T) {
 }
 
 type participantOpts struct {
-	permissions     *livekit.ParticipantPermission
-	protocolVersion types.ProtocolVersion
-	publisher       bool
-	clientConf      *livekit.ClientConfiguration
-	clientInfo      *livekit.ClientInfo
+	permissions      *livekit.ParticipantPermission
+	protocolVersion  types.ProtocolVersion
+	publisher        bool
+	clientConf       *livekit.ClientConfiguration
+	clientInfo       *livekit.ClientInfo
+	codecRestriction *CodecRestriction
 }
 
 func newParticipantForTestWithOpts(identity livekit.ParticipantIdentity, opts *participantOpts) *ParticipantImpl {
@@ -766,6 +898,7 @@ func newParticipantForTestWithOpts(identity livekit.ParticipantIdentity, opts *p
 		Logger:                 LoggerWithParticipant(logger.GetLogger(), identity, sid, false),
 		Telemetry:              &telemetryfakes.FakeTelemetryService{},
 		VersionGenerator:       utils.NewDefaultTimedVersionGenerator(),
+		CodecRestriction:       opts.codecRestriction,
 	})
 	p.isPublisher.Store(opts.publisher)
 	p.updateState(livekit.ParticipantInfo_ACTIVE)
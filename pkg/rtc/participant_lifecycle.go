@@ -0,0 +1,127 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+type lifecycleHook func(from, to types.ParticipantLifecycleState)
+
+// participantLifecycle is the state machine of a participant session. Operations that must not race with
+// teardown are admitted through Enter, once draining no new operations are admitted and WaitDrained
+// waits for the admitted ones to complete.
+type participantLifecycle struct {
+	lock     sync.Mutex
+	state    types.ParticipantLifecycleState
+	inflight int
+	drained  chan struct{}
+
+	// hooks are run in transition order
+	hooksLock sync.Mutex
+	hooks     []lifecycleHook
+}
+
+func newParticipantLifecycle() *participantLifecycle {
+	return &participantLifecycle{
+		state:   types.ParticipantLifecycleStateJoining,
+		drained: make(chan struct{}),
+	}
+}
+
+func (l *participantLifecycle) State() types.ParticipantLifecycleState {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.state
+}
+
+func (l *participantLifecycle) AddHook(hook lifecycleHook) {
+	l.hooksLock.Lock()
+	defer l.hooksLock.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+// Transition moves to state to, a transition to the current state is a no-op
+func (l *participantLifecycle) Transition(to types.ParticipantLifecycleState) error {
+	// held across the hooks so that they observe transitions in order
+	l.hooksLock.Lock()
+	defer l.hooksLock.Unlock()
+
+	l.lock.Lock()
+	from := l.state
+	if from == to {
+		l.lock.Unlock()
+		return nil
+	}
+	if !from.CanTransitionTo(to) {
+		l.lock.Unlock()
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	l.state = to
+	if to.IsClosing() && l.inflight == 0 {
+		l.closeDrainedLocked()
+	}
+	l.lock.Unlock()
+
+	for _, hook := range l.hooks {
+		hook(from, to)
+	}
+	return nil
+}
+
+func (l *participantLifecycle) Enter() (func(), bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.state.IsClosing() {
+		return nil, false
+	}
+
+	l.inflight++
+	var once sync.Once
+	return func() {
+		once.Do(l.exit)
+	}, true
+}
+
+func (l *participantLifecycle) exit() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.inflight--
+	if l.inflight == 0 && l.state.IsClosing() {
+		l.closeDrainedLocked()
+	}
+}
+
+func (l *participantLifecycle) closeDrainedLocked() {
+	select {
+	case <-l.drained:
+	default:
+		close(l.drained)
+	}
+}
+
+// WaitDrained waits for the operations admitted before draining to complete, it returns false on timeout
+func (l *participantLifecycle) WaitDrained(timeout time.Duration) bool {
+	select {
+	case <-l.drained:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
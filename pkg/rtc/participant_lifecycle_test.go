@@ -0,0 +1,76 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestParticipantLifecycle(t *testing.T) {
+	t.Run("validates transitions", func(t *testing.T) {
+		l := newParticipantLifecycle()
+		var transitions [][2]types.ParticipantLifecycleState
+		l.AddHook(func(from, to types.ParticipantLifecycleState) {
+			transitions = append(transitions, [2]types.ParticipantLifecycleState{from, to})
+		})
+
+		require.True(t, errors.Is(l.Transition(types.ParticipantLifecycleStateResuming), ErrInvalidTransition))
+		require.NoError(t, l.Transition(types.ParticipantLifecycleStateActive))
+		require.NoError(t, l.Transition(types.ParticipantLifecycleStateActive))
+		require.NoError(t, l.Transition(types.ParticipantLifecycleStateResuming))
+		require.NoError(t, l.Transition(types.ParticipantLifecycleStateActive))
+		require.NoError(t, l.Transition(types.ParticipantLifecycleStateDraining))
+		require.True(t, errors.Is(l.Transition(types.ParticipantLifecycleStateActive), ErrInvalidTransition))
+		require.NoError(t, l.Transition(types.ParticipantLifecycleStateDisconnected))
+		require.Equal(t, types.ParticipantLifecycleStateDisconnected, l.State())
+
+		require.Equal(t, [][2]types.ParticipantLifecycleState{
+			{types.ParticipantLifecycleStateJoining, types.ParticipantLifecycleStateActive},
+			{types.ParticipantLifecycleStateActive, types.ParticipantLifecycleStateResuming},
+			{types.ParticipantLifecycleStateResuming, types.ParticipantLifecycleStateActive},
+			{types.ParticipantLifecycleStateActive, types.ParticipantLifecycleStateDraining},
+			{types.ParticipantLifecycleStateDraining, types.ParticipantLifecycleStateDisconnected},
+		}, transitions)
+	})
+
+	t.Run("drains operations", func(t *testing.T) {
+		l := newParticipantLifecycle()
+		require.NoError(t, l.Transition(types.ParticipantLifecycleStateActive))
+
+		done, ok := l.Enter()
+		require.True(t, ok)
+
+		require.NoError(t, l.Transition(types.ParticipantLifecycleStateDraining))
+		_, ok = l.Enter()
+		require.False(t, ok)
+		require.False(t, l.WaitDrained(10*time.Millisecond))
+
+		done()
+		done()
+		require.True(t, l.WaitDrained(time.Second))
+	})
+
+	t.Run("drained without operations", func(t *testing.T) {
+		l := newParticipantLifecycle()
+		require.NoError(t, l.Transition(types.ParticipantLifecycleStateDraining))
+		require.True(t, l.WaitDrained(time.Second))
+	})
+}
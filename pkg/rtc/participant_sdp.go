@@ -33,6 +33,75 @@ func (p *ParticipantImpl) setCodecPreferencesForPublisher(offer webrtc.SessionDe
 	return offer
 }
 
+// stripRestrictedCodecsForPublisher removes the codecs the room does not allow from the media sections of the offer,
+// along with their retransmission codecs. Sections left without a codec are kept as they are, to be rejected in the answer.
+func (p *ParticipantImpl) stripRestrictedCodecsForPublisher(offer webrtc.SessionDescription) webrtc.SessionDescription {
+	if p.params.CodecRestriction == nil {
+		return offer
+	}
+	parsed, err := offer.Unmarshal()
+	if err != nil {
+		return offer
+	}
+
+	stripped := false
+	for _, m := range parsed.MediaDescriptions {
+		kind := m.MediaName.Media
+		if kind != "audio" && kind != "video" {
+			continue
+		}
+		codecs, err := codecsFromMediaDescription(m)
+		if err != nil {
+			p.pubLogger.Errorw("extract codecs from media section failed", err, "media", m)
+			continue
+		}
+
+		denied := make(map[string]bool)
+		for _, c := range codecs {
+			if !p.params.CodecRestriction.Allows(kind + "/" + c.Name) {
+				denied[strconv.FormatInt(int64(c.PayloadType), 10)] = true
+			}
+		}
+		if len(denied) == 0 {
+			continue
+		}
+		for _, c := range codecs {
+			if !strings.EqualFold(c.Name, "rtx") {
+				continue
+			}
+			if apt, err := rtxAssociatedPayloadType(c.Fmtp); err == nil && denied[strconv.FormatInt(int64(apt), 10)] {
+				denied[strconv.FormatInt(int64(c.PayloadType), 10)] = true
+			}
+		}
+
+		formats := make([]string, 0, len(m.MediaName.Formats))
+		for _, format := range m.MediaName.Formats {
+			if !denied[format] {
+				formats = append(formats, format)
+			}
+		}
+		if len(formats) == 0 {
+			continue
+		}
+		m.MediaName.Formats = formats
+		stripped = true
+	}
+	if !stripped {
+		return offer
+	}
+
+	bytes, err := parsed.Marshal()
+	if err != nil {
+		p.pubLogger.Errorw("failed to marshal offer", err)
+		return offer
+	}
+
+	return webrtc.SessionDescription{
+		Type: offer.Type,
+		SDP:  string(bytes),
+	}
+}
+
 func (p *ParticipantImpl) setCodecPreferencesOpusRedForPublisher(offer webrtc.SessionDescription) webrtc.SessionDescription {
 	parsed, unmatchAudios, err := p.TransportManager.GetUnmatchMediaForOffer(offer, "audio")
 	if err != nil || len(unmatchAudios) == 0 {
@@ -323,6 +323,7 @@ func (p *ParticipantImpl) sendLeaveRequest(
 	isExpectedToResume bool,
 	isExpectedToReconnect bool,
 	sendOnlyIfSupportingLeaveRequestWithAction bool,
+	regions *livekit.RegionSettings,
 ) error {
 	var leave *livekit.LeaveRequest
 	if p.ProtocolVersion().SupportsRegionsInLeaveRequest() {
@@ -337,9 +338,14 @@ func (p *ParticipantImpl) sendLeaveRequest(
 		default:
 			leave.Action = livekit.LeaveRequest_DISCONNECT
 		}
-		if leave.Action != livekit.LeaveRequest_DISCONNECT && p.params.GetRegionSettings != nil {
-			// sending region settings even for RESUME just in case client wants to a full reconnect despite server saying RESUME
-			leave.Regions = p.params.GetRegionSettings(p.params.ClientInfo.Address)
+		if leave.Action != livekit.LeaveRequest_DISCONNECT {
+			if regions != nil {
+				// explicit target, client should not pick a region on its own
+				leave.Regions = regions
+			} else if p.params.GetRegionSettings != nil {
+				// sending region settings even for RESUME just in case client wants to a full reconnect despite server saying RESUME
+				leave.Regions = p.params.GetRegionSettings(p.params.ClientInfo.Address)
+			}
 		}
 	} else {
 		if !sendOnlyIfSupportingLeaveRequestWithAction {
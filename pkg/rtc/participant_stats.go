@@ -0,0 +1,174 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+type receivedStreamStats struct {
+	mimeType string
+	stats    *livekit.RTPStats
+}
+
+// GetTransportStats returns a snapshot of the stats of the publisher or subscriber transport in the dictionaries
+// of the W3C getStats() API. The report of the peer connection is completed with the RTP streams, inbound-rtp for
+// the publisher, outbound-rtp with the remote-inbound-rtp reported by the client for the subscriber.
+func (p *ParticipantImpl) GetTransportStats(target livekit.SignalTarget) *types.TransportStats {
+	report := p.TransportManager.GetTransportStats(target)
+	if report == nil {
+		return nil
+	}
+
+	timestamp := statsTimestamp(time.Now())
+	rb := &streamStatsBuilder{
+		report:      report,
+		timestamp:   timestamp,
+		transportID: reportTransportID(report),
+	}
+
+	switch target {
+	case livekit.SignalTarget_PUBLISHER:
+		for _, track := range p.GetPublishedTracks() {
+			mt, ok := track.(*MediaTrack)
+			if !ok {
+				continue
+			}
+
+			kind := strings.ToLower(track.Kind().String())
+			for ssrc, rs := range mt.getStreamTrackStats() {
+				rb.addInbound(track.ID(), kind, ssrc, rs.mimeType, rs.stats)
+			}
+		}
+
+	case livekit.SignalTarget_SUBSCRIBER:
+		for _, subTrack := range p.GetSubscribedTracks() {
+			dt := subTrack.DownTrack()
+			if dt == nil {
+				continue
+			}
+
+			if stats := dt.GetTrackStats(); stats != nil {
+				rb.addOutbound(subTrack.ID(), dt.Kind().String(), dt.SSRC(), dt.Codec().MimeType, stats)
+			}
+		}
+	}
+
+	return &types.TransportStats{
+		Transport: strings.ToLower(target.String()),
+		Report:    report,
+	}
+}
+
+type streamStatsBuilder struct {
+	report      webrtc.StatsReport
+	timestamp   webrtc.StatsTimestamp
+	transportID string
+}
+
+func (b *streamStatsBuilder) addInbound(trackID livekit.TrackID, kind string, ssrc uint32, mimeType string, stats *livekit.RTPStats) {
+	id := fmt.Sprintf("inbound-rtp-%d", ssrc)
+	b.report[id] = webrtc.InboundRTPStreamStats{
+		Timestamp:         b.timestamp,
+		Type:              webrtc.StatsTypeInboundRTP,
+		ID:                id,
+		SSRC:              webrtc.SSRC(ssrc),
+		Kind:              kind,
+		TransportID:       b.transportID,
+		CodecID:           reportCodecID(b.report, mimeType),
+		FIRCount:          stats.Firs,
+		PLICount:          stats.Plis,
+		NACKCount:         stats.Nacks,
+		PacketsReceived:   stats.Packets,
+		PacketsLost:       int32(stats.PacketsLost),
+		Jitter:            stats.JitterCurrent / 1e6, // microseconds to seconds
+		TrackID:           string(trackID),
+		BytesReceived:     stats.Bytes,
+		PacketsDuplicated: stats.PacketsDuplicate,
+	}
+}
+
+func (b *streamStatsBuilder) addOutbound(trackID livekit.TrackID, kind string, ssrc uint32, mimeType string, stats *livekit.RTPStats) {
+	id := fmt.Sprintf("outbound-rtp-%d", ssrc)
+	remoteID := fmt.Sprintf("remote-inbound-rtp-%d", ssrc)
+	codecID := reportCodecID(b.report, mimeType)
+	b.report[id] = webrtc.OutboundRTPStreamStats{
+		Timestamp:   b.timestamp,
+		Type:        webrtc.StatsTypeOutboundRTP,
+		ID:          id,
+		SSRC:        webrtc.SSRC(ssrc),
+		Kind:        kind,
+		TransportID: b.transportID,
+		CodecID:     codecID,
+		FIRCount:    stats.Firs,
+		PLICount:    stats.Plis,
+		NACKCount:   stats.Nacks,
+		PacketsSent: stats.Packets,
+		BytesSent:   stats.Bytes,
+		TrackID:     string(trackID),
+		RemoteID:    remoteID,
+	}
+	b.report[remoteID] = webrtc.RemoteInboundRTPStreamStats{
+		Timestamp:     b.timestamp,
+		Type:          webrtc.StatsTypeRemoteInboundRTP,
+		ID:            remoteID,
+		SSRC:          webrtc.SSRC(ssrc),
+		Kind:          kind,
+		TransportID:   b.transportID,
+		CodecID:       codecID,
+		PacketsLost:   int32(stats.PacketsLost),
+		Jitter:        stats.JitterCurrent / 1e6, // microseconds to seconds
+		LocalID:       id,
+		RoundTripTime: float64(stats.RttCurrent) / 1e3, // milliseconds to seconds
+		FractionLost:  float64(stats.PacketLossPercentage) / 100,
+	}
+}
+
+func statsTimestamp(t time.Time) webrtc.StatsTimestamp {
+	return webrtc.StatsTimestamp(float64(t.UnixNano()) / float64(time.Millisecond))
+}
+
+func reportTransportID(report webrtc.StatsReport) string {
+	for id, stats := range report {
+		if _, ok := stats.(webrtc.TransportStats); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// the first codec with the mime type by id, codecs with the same mime type differ only in their fmtp line
+func reportCodecID(report webrtc.StatsReport, mimeType string) string {
+	var ids []string
+	for id, stats := range report {
+		if cs, ok := stats.(webrtc.CodecStats); ok && strings.EqualFold(cs.MimeType, mimeType) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	sort.Strings(ids)
+	return ids[0]
+}
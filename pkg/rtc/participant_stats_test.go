@@ -0,0 +1,77 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestStreamStatsBuilder(t *testing.T) {
+	report := webrtc.StatsReport{
+		"iceTransport": webrtc.TransportStats{Type: webrtc.StatsTypeTransport, ID: "iceTransport"},
+		"RTPCodec-2":   webrtc.CodecStats{Type: webrtc.StatsTypeCodec, ID: "RTPCodec-2", MimeType: "video/VP8"},
+		"RTPCodec-1":   webrtc.CodecStats{Type: webrtc.StatsTypeCodec, ID: "RTPCodec-1", MimeType: "video/VP8"},
+		"RTPCodec-3":   webrtc.CodecStats{Type: webrtc.StatsTypeCodec, ID: "RTPCodec-3", MimeType: "audio/opus"},
+	}
+	b := &streamStatsBuilder{
+		report:      report,
+		timestamp:   1000,
+		transportID: reportTransportID(report),
+	}
+
+	b.addInbound("TR_audio", "audio", 1111, "audio/opus", &livekit.RTPStats{
+		Packets:       500,
+		Bytes:         40_000,
+		PacketsLost:   5,
+		JitterCurrent: 2500,
+		Nacks:         3,
+	})
+	inbound, ok := report["inbound-rtp-1111"].(webrtc.InboundRTPStreamStats)
+	require.True(t, ok)
+	require.Equal(t, webrtc.SSRC(1111), inbound.SSRC)
+	require.Equal(t, "iceTransport", inbound.TransportID)
+	require.Equal(t, "RTPCodec-3", inbound.CodecID)
+	require.Equal(t, uint32(500), inbound.PacketsReceived)
+	require.Equal(t, int32(5), inbound.PacketsLost)
+	require.Equal(t, uint32(3), inbound.NACKCount)
+	require.InDelta(t, 0.0025, inbound.Jitter, 1e-9)
+
+	b.addOutbound("TR_video", "video", 2222, "video/vp8", &livekit.RTPStats{
+		Packets:              1000,
+		Bytes:                900_000,
+		PacketsLost:          20,
+		PacketLossPercentage: 2,
+		RttCurrent:           80,
+		Plis:                 2,
+	})
+	outbound, ok := report["outbound-rtp-2222"].(webrtc.OutboundRTPStreamStats)
+	require.True(t, ok)
+	require.Equal(t, "RTPCodec-1", outbound.CodecID)
+	require.Equal(t, uint32(1000), outbound.PacketsSent)
+	require.Equal(t, uint32(2), outbound.PLICount)
+	require.Equal(t, "remote-inbound-rtp-2222", outbound.RemoteID)
+
+	remote, ok := report["remote-inbound-rtp-2222"].(webrtc.RemoteInboundRTPStreamStats)
+	require.True(t, ok)
+	require.Equal(t, "outbound-rtp-2222", remote.LocalID)
+	require.Equal(t, int32(20), remote.PacketsLost)
+	require.InDelta(t, 0.08, remote.RoundTripTime, 1e-9)
+	require.InDelta(t, 0.02, remote.FractionLost, 1e-9)
+}
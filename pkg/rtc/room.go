@@ -35,8 +35,10 @@ import (
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/rpc"
 	"github.com/livekit/protocol/utils"
+	"github.com/livekit/protocol/webhook"
 
 	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/privacy"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
@@ -55,6 +57,10 @@ const (
 	dataForwardLoadBalanceThreshold = 20
 
 	simulateDisconnectSignalTimeout = 5 * time.Second
+
+	joinTimeout = time.Minute
+	// goroutines and timers of a room still running this long after it closed are reported as leaked
+	roomResourceLeakTimeout = 30 * time.Second
 )
 
 var (
@@ -88,11 +94,14 @@ type Room struct {
 	// time that the last participant left the room
 	leftAt atomic.Int64
 	holds  atomic.Int32
+	// activation of a scheduled room has been notified
+	activated atomic.Bool
 
 	lock sync.RWMutex
 
 	protoRoom  *livekit.Room
 	internal   *livekit.RoomInternal
+	options    *RoomOptions
 	protoProxy *utils.ProtoProxy[*livekit.Room]
 	Logger     logger.Logger
 
@@ -114,17 +123,46 @@ type Room struct {
 	hasPublished              map[livekit.ParticipantIdentity]bool
 	bufferFactory             *buffer.FactoryOfBufferFactory
 
+	// waiting room, identity -> permission granted when admitted
+	pendingParticipants map[livekit.ParticipantIdentity]*livekit.ParticipantPermission
+
+	// push to talk floor and its queue
+	floor *FloorControl
+
+	// topic subscriptions and rate limits of data messages
+	dataTopics *DataTopics
+	// reliable data messages replayed to participants joining later
+	dataReplay *DataReplay
+	// loudest speaker changes recorded for recordings of the room
+	speakerMarkers *SpeakerMarkers
+
+	// serializes bulk moderation of the tracks of the room
+	moderationLock sync.Mutex
+	// serializes updates of participant metadata, so that patches are applied to the version they were checked against
+	metadataLock sync.Mutex
+
+	// rules evaluated on the events of the room
+	rulesLock sync.Mutex
+	rules     []*roomRuleState
+
 	// batch update participant info for non-publishers
 	batchedUpdates   map[livekit.ParticipantIdentity]*participantUpdate
 	batchedUpdatesMu sync.Mutex
 
 	closed chan struct{}
 
+	// goroutines and timers started for the room, its participants and their tracks
+	resources           *sutils.ResourceTracker
+	resourceLeakTimeout time.Duration
+	onResourceLeak      func(counts sutils.ResourceCounts)
+
 	trailer []byte
 
-	onParticipantChanged func(p types.LocalParticipant)
-	onRoomUpdated        func()
-	onClose              func()
+	onParticipantChanged    func(p types.LocalParticipant)
+	onTrackPublishedHandler func(p types.LocalParticipant, track types.MediaTrack)
+	onRoomUpdated           func()
+	onOptionsChanged        func(options *RoomOptions)
+	onClose                 func()
 
 	simulationLock                                 sync.Mutex
 	disconnectSignalOnResumeParticipants           map[livekit.ParticipantIdentity]time.Time
@@ -138,6 +176,7 @@ type ParticipantOptions struct {
 func NewRoom(
 	room *livekit.Room,
 	internal *livekit.RoomInternal,
+	options *RoomOptions,
 	config WebRTCConfig,
 	audioConfig *config.AudioConfig,
 	serverInfo *livekit.ServerInfo,
@@ -148,6 +187,7 @@ func NewRoom(
 	r := &Room{
 		protoRoom: proto.Clone(room).(*livekit.Room),
 		internal:  internal,
+		options:   options.Clone(),
 		Logger: LoggerWithRoom(
 			logger.GetLogger().WithComponent(sutils.ComponentRoom),
 			livekit.RoomName(room.Name),
@@ -164,7 +204,7 @@ func NewRoom(
 		participantOpts:                      make(map[livekit.ParticipantIdentity]*ParticipantOptions),
 		participantRequestSources:            make(map[livekit.ParticipantIdentity]routing.MessageSource),
 		hasPublished:                         make(map[livekit.ParticipantIdentity]bool),
-		bufferFactory:                        buffer.NewFactoryOfBufferFactory(config.Receiver.PacketBufferSizeVideo, config.Receiver.PacketBufferSizeAudio),
+		pendingParticipants:                  make(map[livekit.ParticipantIdentity]*livekit.ParticipantPermission),
 		batchedUpdates:                       make(map[livekit.ParticipantIdentity]*participantUpdate),
 		closed:                               make(chan struct{}),
 		trailer:                              []byte(utils.RandomSecret()),
@@ -172,6 +212,10 @@ func NewRoom(
 		disconnectSignalOnResumeNoMessagesParticipants: make(map[livekit.ParticipantIdentity]*disconnectSignalOnResumeNoMessages),
 	}
 
+	r.bufferFactory = config.Receiver.BufferPools
+	if r.bufferFactory == nil {
+		r.bufferFactory = buffer.NewFactoryOfBufferFactory(config.Receiver.PacketBufferSizeVideo, config.Receiver.PacketBufferSizeAudio)
+	}
 	r.protoProxy = utils.NewProtoProxy[*livekit.Room](roomUpdateInterval, r.updateProto)
 	if r.protoRoom.EmptyTimeout == 0 {
 		r.protoRoom.EmptyTimeout = DefaultEmptyTimeout
@@ -179,9 +223,22 @@ func NewRoom(
 	if r.protoRoom.CreationTime == 0 {
 		r.protoRoom.CreationTime = time.Now().Unix()
 	}
+	if r.options == nil {
+		r.options = &RoomOptions{}
+	}
+	r.resources = sutils.NewResourceTracker(r.Logger)
+	r.resourceLeakTimeout = roomResourceLeakTimeout
+
+	r.floor = NewFloorControl(r.resources)
+	r.floor.Configure(r.options.PushToTalk)
+	r.floor.OnChange(r.onFloorChanged)
+
+	r.dataTopics = NewDataTopics()
+	r.dataReplay = NewDataReplay()
+	r.speakerMarkers = NewSpeakerMarkers()
 
 	if agentClient != nil {
-		go func() {
+		r.resources.Go("room.checkAgents", func() {
 			res := r.agentClient.CheckEnabled(context.Background(), &rpc.CheckEnabledRequest{})
 			if res.PublisherEnabled {
 				r.lock.Lock()
@@ -192,13 +249,13 @@ func NewRoom(
 				}
 				r.lock.Unlock()
 			}
-		}()
+		})
 	}
 
-	go r.audioUpdateWorker()
-	go r.connectionQualityWorker()
-	go r.changeUpdateWorker()
-	go r.simulationCleanupWorker()
+	r.resources.Go("room.audioUpdateWorker", r.audioUpdateWorker)
+	r.resources.Go("room.connectionQualityWorker", r.connectionQualityWorker)
+	r.resources.Go("room.changeUpdateWorker", r.changeUpdateWorker)
+	r.resources.Go("room.simulationCleanupWorker", r.simulationCleanupWorker)
 
 	return r
 }
@@ -304,6 +361,27 @@ func (r *Room) Internal() *livekit.RoomInternal {
 	return r.internal
 }
 
+func (r *Room) Options() *RoomOptions {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.options.Clone()
+}
+
+// SetLocked locks or unlocks the room against new participants, participants already in the room are not affected
+func (r *Room) SetLocked(locked bool) *RoomOptions {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.options.IsLocked() != locked {
+		// options are replaced rather than modified, readers hold on to them outside the lock
+		options := r.options.Clone()
+		options.Locked = locked
+		r.options = options
+		r.Logger.Infow("room lock updated", "locked", locked)
+	}
+	return r.options.Clone()
+}
+
 func (r *Room) Hold() bool {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -327,6 +405,12 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 	if r.IsClosed() {
 		return ErrRoomClosed
 	}
+	if err := r.checkActivationWindowLocked(); err != nil {
+		return err
+	}
+	if r.options.IsLocked() && !bypassesWaitingRoom(participant) {
+		return ErrRoomLocked
+	}
 
 	if r.participants[participant.Identity()] != nil {
 		return ErrAlreadyJoined
@@ -347,6 +431,85 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 		r.joinedAt.Store(time.Now().Unix())
 	}
 
+	// hold in waiting room before callbacks are in place and join response goes out,
+	// client starts out without media permissions and is not visible to others
+	isPending := r.options.WaitingRoom && !bypassesWaitingRoom(participant)
+	if isPending {
+		r.pendingParticipants[participant.Identity()] = participant.ClaimGrants().Video.ToPermission()
+		participant.SetPermission(&livekit.ParticipantPermission{Hidden: true})
+		participant.GetLogger().Infow("participant waiting to be admitted")
+	}
+
+	r.setParticipantCallbacks(participant)
+
+	r.Logger.Debugw("new participant joined",
+		"pID", participant.ID(),
+		"participant", participant.Identity(),
+		"clientInfo", logger.Proto(privacy.ClientInfo(participant.GetClientInfo())),
+		"options", opts,
+		"numParticipants", len(r.participants),
+	)
+
+	if participant.IsRecorder() && !r.protoRoom.ActiveRecording {
+		r.protoRoom.ActiveRecording = true
+		r.protoProxy.MarkDirty(true)
+	} else {
+		r.protoProxy.MarkDirty(false)
+	}
+
+	r.participants[participant.Identity()] = participant
+	r.participantOpts[participant.Identity()] = opts
+	r.participantRequestSources[participant.Identity()] = requestSource
+	participant.BlockAudio(!r.floor.CanSpeak(participant))
+
+	if r.onParticipantChanged != nil {
+		r.onParticipantChanged(participant)
+	}
+
+	r.resources.Go("room.joinTimeout", func() {
+		select {
+		case <-r.closed:
+			return
+		case <-time.After(joinTimeout):
+		}
+		state := participant.State()
+		if state == livekit.ParticipantInfo_JOINING || state == livekit.ParticipantInfo_JOINED {
+			r.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonJoinTimeout)
+		}
+	})
+
+	joinResponse := r.createJoinResponseLocked(participant, iceServers)
+	if err := participant.SendJoinResponse(joinResponse); err != nil {
+		prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "error", "send_response").Add(1)
+		return err
+	}
+
+	if isPending {
+		r.telemetry.ParticipantPending(context.Background(), r.ToProto(), participant.ToProto())
+	}
+
+	participant.SetMigrateState(types.MigrateStateComplete)
+
+	if participant.SubscriberAsPrimary() {
+		// initiates sub connection as primary
+		if participant.ProtocolVersion().SupportFastStart() {
+			r.resources.Go("room.subscribeToExistingTracks", func() {
+				r.subscribeToExistingTracks(participant)
+				participant.Negotiate(true)
+			})
+		} else {
+			participant.Negotiate(true)
+		}
+	}
+
+	prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "success", "").Add(1)
+
+	return nil
+}
+
+// sets the callbacks connecting a participant to the room, it's important to set these before connection,
+// we don't want to miss out on any published tracks
+func (r *Room) setParticipantCallbacks(participant types.LocalParticipant) {
 	participant.OnStateChange(func(p types.LocalParticipant, state livekit.ParticipantInfo_State) {
 		if r.onParticipantChanged != nil {
 			r.onParticipantChanged(p)
@@ -356,6 +519,7 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 		if state == livekit.ParticipantInfo_ACTIVE {
 			// subscribe participant to existing published tracks
 			r.subscribeToExistingTracks(p)
+			r.replayDataPackets(p)
 
 			meta := &livekit.AnalyticsClientMeta{
 				ClientConnectTime: uint32(time.Since(p.ConnectedAt()).Milliseconds()),
@@ -375,13 +539,13 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 			)
 
 			p.GetLogger().Infow("participant active", connectionDetailsFields(cds)...)
+			r.onRuleEvent(webhook.EventParticipantJoined, p, nil)
 		} else if state == livekit.ParticipantInfo_DISCONNECTED {
 			// remove participant from room
 			// participant should already be closed and have a close reason, so NONE is fine here
 			go r.RemoveParticipant(p.Identity(), p.ID(), types.ParticipantCloseReasonNone)
 		}
 	})
-	// it's important to set this before connection, we don't want to miss out on any published tracks
 	participant.OnTrackPublished(r.onTrackPublished)
 	participant.OnTrackUpdated(r.onTrackUpdated)
 	participant.OnTrackUnpublished(r.onTrackUnpublished)
@@ -421,60 +585,16 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 			}, true)
 		}
 	})
+}
 
-	r.Logger.Debugw("new participant joined",
-		"pID", participant.ID(),
-		"participant", participant.Identity(),
-		"clientInfo", logger.Proto(participant.GetClientInfo()),
-		"options", opts,
-		"numParticipants", len(r.participants),
-	)
-
-	if participant.IsRecorder() && !r.protoRoom.ActiveRecording {
-		r.protoRoom.ActiveRecording = true
-		r.protoProxy.MarkDirty(true)
-	} else {
-		r.protoProxy.MarkDirty(false)
-	}
-
-	r.participants[participant.Identity()] = participant
-	r.participantOpts[participant.Identity()] = opts
-	r.participantRequestSources[participant.Identity()] = requestSource
-
-	if r.onParticipantChanged != nil {
-		r.onParticipantChanged(participant)
-	}
-
-	time.AfterFunc(time.Minute, func() {
-		state := participant.State()
-		if state == livekit.ParticipantInfo_JOINING || state == livekit.ParticipantInfo_JOINED {
-			r.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonJoinTimeout)
-		}
-	})
-
-	joinResponse := r.createJoinResponseLocked(participant, iceServers)
-	if err := participant.SendJoinResponse(joinResponse); err != nil {
-		prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "error", "send_response").Add(1)
-		return err
-	}
-
-	participant.SetMigrateState(types.MigrateStateComplete)
-
-	if participant.SubscriberAsPrimary() {
-		// initiates sub connection as primary
-		if participant.ProtocolVersion().SupportFastStart() {
-			go func() {
-				r.subscribeToExistingTracks(participant)
-				participant.Negotiate(true)
-			}()
-		} else {
-			participant.Negotiate(true)
-		}
-	}
-
-	prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "success", "").Add(1)
-
-	return nil
+func (r *Room) clearParticipantCallbacks(p types.LocalParticipant) {
+	p.OnTrackUpdated(nil)
+	p.OnTrackPublished(nil)
+	p.OnTrackUnpublished(nil)
+	p.OnStateChange(nil)
+	p.OnParticipantUpdate(nil)
+	p.OnDataPacket(nil)
+	p.OnSubscribeStatusChanged(nil)
 }
 
 func (r *Room) ReplaceParticipantRequestSource(identity livekit.ParticipantIdentity, reqSource routing.MessageSource) {
@@ -528,7 +648,10 @@ func (r *Room) ResumeParticipant(p types.LocalParticipant, requestSource routing
 	}
 
 	// include the local participant's info as well, since metadata could have been changed
-	updates := r.getOtherParticipantInfo("")
+	updates := []*livekit.ParticipantInfo{p.ToProto()}
+	if !r.IsPendingAdmission(p.Identity()) {
+		updates = r.getOtherParticipantInfo("")
+	}
 	if err := p.SendParticipantUpdate(updates); err != nil {
 		return err
 	}
@@ -567,6 +690,7 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 	delete(r.participantOpts, identity)
 	delete(r.participantRequestSources, identity)
 	delete(r.hasPublished, identity)
+	delete(r.pendingParticipants, identity)
 	if !p.Hidden() {
 		r.protoRoom.NumParticipants--
 	}
@@ -589,6 +713,12 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 	r.lock.Unlock()
 	r.protoProxy.MarkDirty(immediateChange)
 
+	r.floor.Remove(identity)
+	r.dataTopics.RemoveParticipant(identity)
+	if p.IsRecorder() {
+		r.speakerMarkers.Stop(string(identity))
+	}
+
 	if !p.HasConnected() {
 		fields := append(connectionDetailsFields(p.GetICEConnectionDetails()),
 			"reason", reason.String(),
@@ -604,13 +734,7 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 		r.trackManager.RemoveTrack(t)
 	}
 
-	p.OnTrackUpdated(nil)
-	p.OnTrackPublished(nil)
-	p.OnTrackUnpublished(nil)
-	p.OnStateChange(nil)
-	p.OnParticipantUpdate(nil)
-	p.OnDataPacket(nil)
-	p.OnSubscribeStatusChanged(nil)
+	r.clearParticipantCallbacks(p)
 
 	// close participant as well
 	_ = p.Close(true, reason, false)
@@ -623,6 +747,8 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 		}
 		r.broadcastParticipantState(p, broadcastOptions{skipSource: true})
 	}
+
+	r.onRuleEvent(webhook.EventParticipantLeft, p, nil)
 }
 
 func (r *Room) UpdateSubscriptions(
@@ -655,6 +781,8 @@ func (r *Room) SyncState(participant types.LocalParticipant, state *livekit.Sync
 	pLogger := participant.GetLogger()
 	pLogger.Infow("setting sync state", "state", logger.Proto(state))
 
+	migrated := syncMigratedState(participant, state)
+
 	shouldReconnect := false
 	pubTracks := state.GetPublishTracks()
 	existingPubTracks := participant.GetPublishedTracks()
@@ -703,6 +831,12 @@ func (r *Room) SyncState(participant types.LocalParticipant, state *livekit.Sync
 		state.Subscription.ParticipantTracks,
 		state.Subscription.Subscribe,
 	)
+
+	if migrated {
+		// the pending publisher offer is answered, the subscriber is offered again from the previous SDP
+		participant.SetMigrateState(types.MigrateStateSync)
+		participant.Negotiate(true)
+	}
 	return nil
 }
 
@@ -713,6 +847,9 @@ func (r *Room) UpdateSubscriptionPermission(participant types.LocalParticipant,
 	for _, track := range participant.GetPublishedTracks() {
 		r.trackManager.NotifyTrackChanged(track.ID())
 	}
+	if r.onParticipantChanged != nil {
+		r.onParticipantChanged(participant)
+	}
 	return nil
 }
 
@@ -769,6 +906,12 @@ func (r *Room) CloseIfEmpty() {
 		}
 	}
 
+	// scheduled rooms wait for their window, the empty timeout starts when it does
+	if r.options.IsPendingActivation(time.Now()) {
+		r.lock.Unlock()
+		return
+	}
+
 	var timeout uint32
 	var elapsed int64
 	if r.FirstJoinedAt() > 0 && r.LastLeftAt() > 0 {
@@ -776,7 +919,11 @@ func (r *Room) CloseIfEmpty() {
 		// need to give time in case participant is reconnecting
 		timeout = RoomDepartureGrace
 	} else {
-		elapsed = time.Now().Unix() - r.protoRoom.CreationTime
+		startedAt := r.protoRoom.CreationTime
+		if r.options.NotBefore > startedAt {
+			startedAt = r.options.NotBefore
+		}
+		elapsed = time.Now().Unix() - startedAt
 		timeout = r.protoRoom.EmptyTimeout
 	}
 	r.lock.Unlock()
@@ -786,6 +933,42 @@ func (r *Room) CloseIfEmpty() {
 	}
 }
 
+// CheckSchedule notifies when the activation window of a scheduled room starts,
+// and closes the room when the window ends
+func (r *Room) CheckSchedule() {
+	r.lock.RLock()
+	options := r.options
+	r.lock.RUnlock()
+
+	if !options.IsScheduled() || r.IsClosed() {
+		return
+	}
+
+	now := time.Now()
+	if options.IsExpired(now) {
+		r.Logger.Infow("room activation window ended, closing room", "notAfter", time.Unix(options.NotAfter, 0))
+		r.telemetry.RoomExpired(context.Background(), r.ToProto())
+		r.Close(types.ParticipantCloseReasonRoomExpired)
+		return
+	}
+
+	if !options.IsPendingActivation(now) && r.activated.CompareAndSwap(false, true) {
+		r.Logger.Infow("room activation window started")
+		r.telemetry.RoomActivated(context.Background(), r.ToProto())
+	}
+}
+
+func (r *Room) checkActivationWindowLocked() error {
+	now := time.Now()
+	if r.options.IsPendingActivation(now) {
+		return ErrRoomNotActive
+	}
+	if r.options.IsExpired(now) {
+		return ErrRoomExpired
+	}
+	return nil
+}
+
 func (r *Room) Close(reason types.ParticipantCloseReason) {
 	r.lock.Lock()
 	select {
@@ -797,27 +980,78 @@ func (r *Room) Close(reason types.ParticipantCloseReason) {
 	}
 	close(r.closed)
 	r.lock.Unlock()
+	r.floor.Stop()
+	r.stopRules()
 
 	r.Logger.Infow("closing room")
 	for _, p := range r.GetParticipants() {
 		_ = p.Close(true, reason, false)
 	}
+	r.speakerMarkers.StopAll()
 
 	r.protoProxy.Stop()
 
 	if r.onClose != nil {
 		r.onClose()
 	}
+
+	go r.detectResourceLeaks()
 }
 
 func (r *Room) OnClose(f func()) {
 	r.onClose = f
 }
 
+// OnOptionsChanged is called when participants change room options with a room command
+func (r *Room) OnOptionsChanged(f func(options *RoomOptions)) {
+	r.onOptionsChanged = f
+}
+
+// Resources returns the tracker of goroutines and timers started for the room
+func (r *Room) Resources() *sutils.ResourceTracker {
+	return r.resources
+}
+
+// NewParticipantResources returns a tracker for the goroutines and timers of a participant, counted in the room
+// until the participant moves to another room
+func (r *Room) NewParticipantResources() *sutils.ResourceTracker {
+	resources := sutils.NewResourceTracker(r.Logger)
+	resources.Bind(r.resources)
+	return resources
+}
+
+// OnResourceLeak is called with the goroutines and timers still running a while after the room closed
+func (r *Room) OnResourceLeak(f func(counts sutils.ResourceCounts)) {
+	r.lock.Lock()
+	r.onResourceLeak = f
+	r.lock.Unlock()
+}
+
+func (r *Room) detectResourceLeaks() {
+	if r.resources.WaitIdle(r.resourceLeakTimeout) {
+		return
+	}
+
+	counts := r.resources.Counts()
+	r.Logger.Warnw("room resources still running after close", nil, "resources", counts, "labels", counts.Labels())
+
+	r.lock.RLock()
+	onResourceLeak := r.onResourceLeak
+	r.lock.RUnlock()
+	if onResourceLeak != nil {
+		onResourceLeak(counts)
+	}
+}
+
 func (r *Room) OnParticipantChanged(f func(participant types.LocalParticipant)) {
 	r.onParticipantChanged = f
 }
 
+// OnTrackPublished is called once a track published by a participant is available to the room
+func (r *Room) OnTrackPublished(f func(participant types.LocalParticipant, track types.MediaTrack)) {
+	r.onTrackPublishedHandler = f
+}
+
 func (r *Room) SendDataPacket(up *livekit.UserPacket, kind livekit.DataPacket_Kind) {
 	dp := &livekit.DataPacket{
 		Kind: kind,
@@ -835,9 +1069,42 @@ func (r *Room) SetMetadata(metadata string) <-chan struct{} {
 	return r.protoProxy.MarkDirty(true)
 }
 
+// AdmitParticipant lets a participant out of the waiting room, restoring the permissions it joined with
+func (r *Room) AdmitParticipant(identity livekit.ParticipantIdentity) (types.LocalParticipant, error) {
+	r.lock.Lock()
+	participant := r.participants[identity]
+	permission, ok := r.pendingParticipants[identity]
+	if participant == nil || !ok {
+		r.lock.Unlock()
+		return participant, ErrParticipantNotPending
+	}
+	delete(r.pendingParticipants, identity)
+	r.lock.Unlock()
+
+	participant.GetLogger().Infow("admitting participant")
+	participant.SetPermission(permission)
+	// the roster was held back while waiting
+	if err := participant.SendParticipantUpdate(r.getOtherParticipantInfo(identity)); err != nil {
+		participant.GetLogger().Errorw("could not send update to participant", err)
+	}
+
+	r.telemetry.ParticipantAdmitted(context.Background(), r.ToProto(), participant.ToProto())
+	return participant, nil
+}
+
+func (r *Room) IsPendingAdmission(identity livekit.ParticipantIdentity) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	_, ok := r.pendingParticipants[identity]
+	return ok
+}
+
 func (r *Room) UpdateParticipantMetadata(participant types.LocalParticipant, name string, metadata string) {
 	if metadata != "" {
+		r.metadataLock.Lock()
 		participant.SetMetadata(metadata)
+		r.metadataLock.Unlock()
 	}
 	if name != "" {
 		participant.SetName(name)
@@ -867,14 +1134,14 @@ func (r *Room) SimulateScenario(participant types.LocalParticipant, simulateScen
 	switch scenario := simulateScenario.Scenario.(type) {
 	case *livekit.SimulateScenario_SpeakerUpdate:
 		r.Logger.Infow("simulating speaker update", "participant", participant.Identity(), "duration", scenario.SpeakerUpdate)
-		go func() {
+		r.resources.Go("room.simulateSpeakerUpdate", func() {
 			<-time.After(time.Duration(scenario.SpeakerUpdate) * time.Second)
 			r.sendSpeakerChanges([]*livekit.SpeakerInfo{{
 				Sid:    string(participant.ID()),
 				Active: false,
 				Level:  0,
 			}})
-		}()
+		})
 		r.sendSpeakerChanges([]*livekit.SpeakerInfo{{
 			Sid:    string(participant.ID()),
 			Active: true,
@@ -948,12 +1215,20 @@ func (r *Room) autoSubscribe(participant types.LocalParticipant) bool {
 	return true
 }
 
+// admins and non-interactive participants are never held in waiting room
+func bypassesWaitingRoom(participant types.LocalParticipant) bool {
+	video := participant.ClaimGrants().Video
+	return video.RoomAdmin || video.Hidden || video.Recorder || video.Agent
+}
+
 func (r *Room) createJoinResponseLocked(participant types.LocalParticipant, iceServers []*livekit.ICEServer) *livekit.JoinResponse {
-	// gather other participants and send join response
+	// gather other participants and send join response, participants in the waiting room get them once admitted
 	otherParticipants := make([]*livekit.ParticipantInfo, 0, len(r.participants))
-	for _, p := range r.participants {
-		if p.ID() != participant.ID() && !p.Hidden() {
-			otherParticipants = append(otherParticipants, p.ToProto())
+	if _, isPending := r.pendingParticipants[participant.Identity()]; !isPending {
+		for _, p := range r.participants {
+			if p.ID() != participant.ID() && !p.Hidden() {
+				otherParticipants = append(otherParticipants, p.ToProto())
+			}
 		}
 	}
 
@@ -994,6 +1269,9 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 		if !r.autoSubscribe(existingParticipant) {
 			continue
 		}
+		if !existingParticipant.GetSubscribeFilter().Allows(participant.Identity(), track.ToProto()) {
+			continue
+		}
 
 		r.Logger.Debugw("subscribing to new track",
 			"participant", existingParticipant.Identity(),
@@ -1024,7 +1302,7 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 			r.launchPublisherAgent(participant)
 		}
 		if r.internal != nil && r.internal.ParticipantEgress != nil {
-			go func() {
+			r.resources.Go("room.startParticipantEgress", func() {
 				if err := StartParticipantEgress(
 					context.Background(),
 					r.egressLauncher,
@@ -1036,11 +1314,11 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 				); err != nil {
 					r.Logger.Errorw("failed to launch participant egress", err)
 				}
-			}()
+			})
 		}
 	}
 	if r.internal != nil && r.internal.TrackEgress != nil {
-		go func() {
+		r.resources.Go("room.startTrackEgress", func() {
 			if err := StartTrackEgress(
 				context.Background(),
 				r.egressLauncher,
@@ -1052,7 +1330,13 @@ func (r *Room) onTrackPublished(participant types.LocalParticipant, track types.
 			); err != nil {
 				r.Logger.Errorw("failed to launch track egress", err)
 			}
-		}()
+		})
+	}
+
+	r.onRuleEvent(webhook.EventTrackPublished, participant, track)
+
+	if r.onTrackPublishedHandler != nil {
+		r.onTrackPublishedHandler(participant, track)
 	}
 }
 
@@ -1072,6 +1356,7 @@ func (r *Room) onTrackUnpublished(p types.LocalParticipant, track types.MediaTra
 	if r.onParticipantChanged != nil {
 		r.onParticipantChanged(p)
 	}
+	r.onRuleEvent(webhook.EventTrackUnpublished, p, track)
 }
 
 func (r *Room) onParticipantUpdate(p types.LocalParticipant) {
@@ -1084,7 +1369,21 @@ func (r *Room) onParticipantUpdate(p types.LocalParticipant) {
 }
 
 func (r *Room) onDataPacket(source types.LocalParticipant, dp *livekit.DataPacket) {
-	BroadcastDataPacketForRoom(r, source, dp, r.Logger)
+	topic := dp.GetUser().GetTopic()
+	if source != nil && !r.dataTopics.Allow(source.Identity(), topic, time.Now()) {
+		source.GetLogger().Debugw("dropping data packet over the rate limit of its topic", "topic", topic)
+		return
+	}
+	if source != nil && topic == RoomCommandTopic {
+		r.handleRoomCommand(source, dp.GetUser())
+		return
+	}
+	if topic == SIPDTMFTopic && !r.allowSIPDTMF(source, dp.GetUser()) {
+		r.Logger.Debugw("dropping DTMF data packet", "participant", source.Identity())
+		return
+	}
+	BroadcastDataPacketForRoom(r, source, dp, r.dataTopics.Receivers(topic), r.Logger)
+	r.dataReplay.Record(dp, time.Now())
 }
 
 func (r *Room) subscribeToExistingTracks(p types.LocalParticipant) {
@@ -1104,6 +1403,9 @@ func (r *Room) subscribeToExistingTracks(p types.LocalParticipant) {
 
 		// subscribe to all
 		for _, track := range op.GetPublishedTracks() {
+			if !p.GetSubscribeFilter().Allows(op.Identity(), track.ToProto()) {
+				continue
+			}
 			trackIDs = append(trackIDs, track.ID())
 			p.SubscribeToTrack(track.ID())
 		}
@@ -1157,6 +1459,11 @@ func (r *Room) sendParticipantUpdates(updates []*participantUpdate) {
 	}
 
 	for _, op := range r.GetParticipants() {
+		if r.IsPendingAdmission(op.Identity()) {
+			// the waiting room does not see who is in the room
+			continue
+		}
+
 		var err error
 		if op.ProtocolVersion().SupportsIdentityBasedReconnection() {
 			err = op.SendParticipantUpdate(filteredUpdates)
@@ -1322,6 +1629,7 @@ func (r *Room) audioUpdateWorker() {
 		}
 
 		activeSpeakers := r.GetActiveSpeakers()
+		r.updateSpeakerMarkers(activeSpeakers)
 		changedSpeakers := make([]*livekit.SpeakerInfo, 0, len(activeSpeakers))
 		nextActiveMap := make(map[livekit.ParticipantID]*livekit.SpeakerInfo, len(activeSpeakers))
 		for _, speaker := range activeSpeakers {
@@ -1359,6 +1667,7 @@ func (r *Room) connectionQualityWorker() {
 	defer ticker.Stop()
 
 	prevConnectionInfos := make(map[livekit.ParticipantID]*livekit.ConnectionQualityInfo)
+	alerter := newConnectionQualityAlerter(r.config.ConnectionQualityAlert)
 	// send updates to only users that are subscribed to each other
 	for !r.IsClosed() {
 		<-ticker.C
@@ -1375,6 +1684,7 @@ func (r *Room) connectionQualityWorker() {
 				nowConnectionInfos[p.ID()] = q
 			}
 		}
+		r.notifyConnectionQualityAlerts(alerter, nowConnectionInfos, time.Now())
 
 		// send an update if there is a change
 		//   - new participant
@@ -1464,14 +1774,14 @@ func (r *Room) launchPublisherAgent(p types.Participant) {
 		return
 	}
 
-	go func() {
+	r.resources.Go("room.publisherAgentJob", func() {
 		r.agentClient.JobRequest(context.Background(), &livekit.Job{
 			Id:          utils.NewGuid("JP_"),
 			Type:        livekit.JobType_JT_PUBLISHER,
 			Room:        r.ToProto(),
 			Participant: p.ToProto(),
 		})
-	}()
+	})
 }
 
 func (r *Room) DebugInfo() map[string]interface{} {
@@ -1487,13 +1797,22 @@ func (r *Room) DebugInfo() map[string]interface{} {
 		participantInfo[string(p.Identity())] = p.DebugInfo()
 	}
 	info["Participants"] = participantInfo
+	info["Resources"] = r.resources.Counts()
 
 	return info
 }
 
 // ------------------------------------------------------------
 
-func BroadcastDataPacketForRoom(r types.Room, source types.LocalParticipant, dp *livekit.DataPacket, logger logger.Logger) {
+// BroadcastDataPacketForRoom sends a data packet to its destinations in the room, or to every participant other
+// than its source when it has none. Only participants accepted by receivers get it, when it is not nil
+func BroadcastDataPacketForRoom(
+	r types.Room,
+	source types.LocalParticipant,
+	dp *livekit.DataPacket,
+	receivers func(types.LocalParticipant) bool,
+	logger logger.Logger,
+) {
 	dest := dp.GetUser().GetDestinationSids()
 	var dpData []byte
 	destIdentities := dp.GetUser().GetDestinationIdentities()
@@ -1512,6 +1831,9 @@ func BroadcastDataPacketForRoom(r types.Room, source types.LocalParticipant, dp
 		if source != nil && op.ID() == source.ID() {
 			continue
 		}
+		if receivers != nil && !receivers(op) {
+			continue
+		}
 		if len(dest) > 0 || len(destIdentities) > 0 {
 			found := false
 			for _, dID := range dest {
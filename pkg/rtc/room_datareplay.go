@@ -0,0 +1,148 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// DataReplay retains the reliable data messages broadcast in a room on the topics of its configs, so that they
+// can be replayed to participants joining later. Messages sent to specific participants, room commands and
+// server topics are not retained.
+type DataReplay struct {
+	lock     sync.Mutex
+	configs  []config.DataReplayConfig
+	messages []dataReplayMessage
+}
+
+type dataReplayMessage struct {
+	// index of the config retaining the message
+	config int
+	packet *livekit.DataPacket
+	at     time.Time
+}
+
+func NewDataReplay() *DataReplay {
+	return &DataReplay{}
+}
+
+// SetConfigs replaces the configs, messages retained so far are dropped
+func (d *DataReplay) SetConfigs(configs []config.DataReplayConfig) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.configs = configs
+	d.messages = nil
+}
+
+// Record retains a data packet when a config matches its topic
+func (d *DataReplay) Record(dp *livekit.DataPacket, now time.Time) {
+	user := dp.GetUser()
+	if dp.Kind != livekit.DataPacket_RELIABLE || user == nil ||
+		len(user.DestinationSids) != 0 || len(user.DestinationIdentities) != 0 ||
+		strings.HasPrefix(user.GetTopic(), serverDataTopicPrefix) {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for i, conf := range d.configs {
+		if dataTopicMatches(conf.Topic, user.GetTopic()) {
+			d.messages = append(d.messages, dataReplayMessage{config: i, packet: dp, at: now})
+			d.pruneLocked(now)
+			return
+		}
+	}
+}
+
+// Messages returns the retained data packets, oldest first
+func (d *DataReplay) Messages(now time.Time) []*livekit.DataPacket {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.pruneLocked(now)
+	packets := make([]*livekit.DataPacket, 0, len(d.messages))
+	for _, msg := range d.messages {
+		packets = append(packets, msg.packet)
+	}
+	return packets
+}
+
+// pruneLocked drops the messages older than the age of their config and the oldest messages of the configs
+// retaining more than their count
+func (d *DataReplay) pruneLocked(now time.Time) {
+	counts := make([]int, len(d.configs))
+	for _, msg := range d.messages {
+		counts[msg.config]++
+	}
+
+	retained := d.messages[:0]
+	for _, msg := range d.messages {
+		conf := d.configs[msg.config]
+		expired := conf.MaxAge > 0 && now.Sub(msg.at) > conf.MaxAge
+		overCount := conf.MaxMessages > 0 && counts[msg.config] > conf.MaxMessages
+		if expired || overCount {
+			counts[msg.config]--
+			continue
+		}
+		retained = append(retained, msg)
+	}
+	for i := len(retained); i < len(d.messages); i++ {
+		d.messages[i] = dataReplayMessage{}
+	}
+	d.messages = retained
+}
+
+// SetDataReplay replaces the configs of the data messages replayed to participants joining the room
+func (r *Room) SetDataReplay(configs []config.DataReplayConfig) {
+	r.dataReplay.SetConfigs(configs)
+}
+
+// replayDataPackets sends the retained data packets to a participant that just became active, skipping the
+// topics it does not receive
+func (r *Room) replayDataPackets(p types.LocalParticipant) {
+	packets := r.dataReplay.Messages(time.Now())
+	if len(packets) == 0 {
+		return
+	}
+
+	sent := 0
+	for _, dp := range packets {
+		if receivers := r.dataTopics.Receivers(dp.GetUser().GetTopic()); receivers != nil && !receivers(p) {
+			continue
+		}
+		data, err := proto.Marshal(dp)
+		if err != nil {
+			r.Logger.Errorw("failed to marshal data packet", err)
+			continue
+		}
+		if err := p.SendDataPacket(dp, data); err != nil {
+			p.GetLogger().Infow("could not replay data packet", "error", err)
+			return
+		}
+		sent++
+	}
+	p.GetLogger().Debugw("replayed data packets", "count", sent)
+}
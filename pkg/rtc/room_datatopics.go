@@ -0,0 +1,190 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// topics of the server, delivered to every participant whatever the topics it subscribed to
+const serverDataTopicPrefix = "lk."
+
+// DataTopics routes the data messages of a room by topic. Participants that subscribed to topics only receive
+// messages on those topics, messages without a topic and on server topics are delivered to everyone. The messages
+// each participant publishes on a topic are limited by the rate limits of the room, those over the limit are dropped.
+type DataTopics struct {
+	lock          sync.Mutex
+	limits        []config.DataTopicLimitConfig
+	buckets       map[dataTopicBucketKey]*dataTopicBucket
+	subscriptions map[livekit.ParticipantIdentity][]string
+}
+
+type dataTopicBucketKey struct {
+	identity livekit.ParticipantIdentity
+	// topic of the limit, a prefix for wildcard limits
+	topic string
+}
+
+func NewDataTopics() *DataTopics {
+	return &DataTopics{
+		buckets:       make(map[dataTopicBucketKey]*dataTopicBucket),
+		subscriptions: make(map[livekit.ParticipantIdentity][]string),
+	}
+}
+
+// SetLimits replaces the rate limits, the first limit matching a topic applies to it
+func (d *DataTopics) SetLimits(limits []config.DataTopicLimitConfig) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.limits = limits
+	d.buckets = make(map[dataTopicBucketKey]*dataTopicBucket)
+}
+
+// Allow returns false when a participant publishing on topic goes over the rate limit of the topic
+func (d *DataTopics) Allow(identity livekit.ParticipantIdentity, topic string, now time.Time) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for _, limit := range d.limits {
+		if !dataTopicMatches(limit.Topic, topic) {
+			continue
+		}
+
+		key := dataTopicBucketKey{identity: identity, topic: limit.Topic}
+		bucket := d.buckets[key]
+		if bucket == nil {
+			bucket = newDataTopicBucket(limit, now)
+			d.buckets[key] = bucket
+		}
+		return bucket.take(now)
+	}
+	return true
+}
+
+// Subscribe sets the topics a participant receives messages on, every topic when topics is empty
+func (d *DataTopics) Subscribe(identity livekit.ParticipantIdentity, topics []string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(topics) == 0 {
+		delete(d.subscriptions, identity)
+	} else {
+		d.subscriptions[identity] = slices.Clone(topics)
+	}
+}
+
+func (d *DataTopics) Subscriptions(identity livekit.ParticipantIdentity) []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return slices.Clone(d.subscriptions[identity])
+}
+
+// Receivers returns a filter of the participants receiving messages on topic, nil when every participant does
+func (d *DataTopics) Receivers(topic string) func(types.LocalParticipant) bool {
+	if topic == "" || strings.HasPrefix(topic, serverDataTopicPrefix) {
+		return nil
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(d.subscriptions) == 0 {
+		return nil
+	}
+	excluded := make(map[livekit.ParticipantIdentity]struct{})
+	for identity, topics := range d.subscriptions {
+		if !slices.ContainsFunc(topics, func(t string) bool { return dataTopicMatches(t, topic) }) {
+			excluded[identity] = struct{}{}
+		}
+	}
+	if len(excluded) == 0 {
+		return nil
+	}
+	return func(p types.LocalParticipant) bool {
+		_, ok := excluded[p.Identity()]
+		return !ok
+	}
+}
+
+func (d *DataTopics) RemoveParticipant(identity livekit.ParticipantIdentity) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.subscriptions, identity)
+	for key := range d.buckets {
+		if key.identity == identity {
+			delete(d.buckets, key)
+		}
+	}
+}
+
+// SetDataTopicLimits replaces the rate limits of the data messages participants publish
+func (r *Room) SetDataTopicLimits(limits []config.DataTopicLimitConfig) {
+	r.dataTopics.SetLimits(limits)
+}
+
+// dataTopicMatches matches a topic against a pattern, a trailing * matches topics with the prefix
+// and * alone matches every topic, including messages without a topic
+func dataTopicMatches(pattern string, topic string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(topic, prefix)
+	}
+	return pattern == topic
+}
+
+// dataTopicBucket is a token bucket, refilled at the rate of the limit up to its burst
+type dataTopicBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newDataTopicBucket(limit config.DataTopicLimitConfig, now time.Time) *dataTopicBucket {
+	burst := float64(limit.Burst)
+	if burst == 0 {
+		burst = math.Ceil(limit.Rate)
+	}
+	return &dataTopicBucket{
+		rate:   limit.Rate,
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+func (b *dataTopicBucket) take(now time.Time) bool {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
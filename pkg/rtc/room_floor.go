@@ -0,0 +1,231 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// RoomCommandTopic carries RoomCommand from participants to the room, the result is sent back to the
+	// sender on the same topic. Commands are handled by the server and not forwarded.
+	RoomCommandTopic = "lk.room-command"
+	// FloorTopic carries FloorState to every participant when push to talk, the floor or its queue change
+	FloorTopic = "lk.floor"
+)
+
+const (
+	RoomCommandMuteAll      = "mute_all"
+	RoomCommandPushToTalk   = "push_to_talk"
+	RoomCommandGrantFloor   = "grant_floor"
+	RoomCommandRequestFloor = "request_floor"
+	RoomCommandReleaseFloor = "release_floor"
+	RoomCommandRevokeFloor  = "revoke_floor"
+	// RoomCommandSubscribeDataTopics sets the topics of the data messages the sender receives
+	RoomCommandSubscribeDataTopics = "subscribe_data_topics"
+)
+
+// RoomCommand is a moderation command sent by a participant. Hosts can run every command,
+// other participants can only request the floor and release their own or withdraw their request, and set
+// the data topics they receive.
+type RoomCommand struct {
+	Command string `json:"command"`
+	// mute_all
+	Except       []livekit.ParticipantIdentity `json:"except,omitempty"`
+	ExcludeHosts bool                          `json:"exclude_hosts,omitempty"`
+	// push_to_talk, disabled when not set
+	PushToTalk *PushToTalkOptions `json:"push_to_talk,omitempty"`
+	// grant_floor gives the floor to the first in the queue without identity,
+	// revoke_floor releases the floor of whoever holds it without identity
+	Identity livekit.ParticipantIdentity `json:"identity,omitempty"`
+	// grant_floor and request_floor, in seconds, bounded by the max floor duration of the room
+	Duration uint32 `json:"duration,omitempty"`
+	// subscribe_data_topics, every topic when empty. A trailing * matches the topics with the prefix
+	Topics []string `json:"topics,omitempty"`
+}
+
+type RoomCommandResult struct {
+	Command string                        `json:"command"`
+	Error   string                        `json:"error,omitempty"`
+	Muted   []livekit.ParticipantIdentity `json:"muted,omitempty"`
+	Floor   *FloorState                   `json:"floor,omitempty"`
+	// QueuePosition of the sender, 1-based, 0 when it is not waiting for the floor
+	QueuePosition int `json:"queue_position,omitempty"`
+	// DataTopics the sender is subscribed to, every topic when empty
+	DataTopics []string `json:"data_topics,omitempty"`
+}
+
+// SetPushToTalk enables push to talk with opts, or disables it when opts is nil or not enabled.
+// The floor is released and its queue cleared, participants that cannot speak have their audio muted.
+func (r *Room) SetPushToTalk(opts *PushToTalkOptions) *RoomOptions {
+	r.lock.Lock()
+	// options are replaced rather than modified, readers hold on to them outside the lock
+	options := r.options.Clone()
+	options.PushToTalk = nil
+	if opts != nil && opts.Enabled {
+		ptt := *opts
+		options.PushToTalk = &ptt
+	}
+	r.options = options
+	r.lock.Unlock()
+
+	r.Logger.Infow("push to talk updated", "pushToTalk", options.PushToTalk)
+	r.floor.Configure(options.PushToTalk)
+	return options.Clone()
+}
+
+// GrantFloor gives the floor to a participant for duration, replacing the current holder, or to the
+// first participant in the queue when identity is empty.
+// A zero duration, or one above the max floor duration of the room, is limited to the max floor duration.
+func (r *Room) GrantFloor(identity livekit.ParticipantIdentity, duration time.Duration) (*FloorState, error) {
+	if identity != "" && r.GetParticipant(identity) == nil {
+		return nil, ErrParticipantNotInRoom
+	}
+	return r.floor.Grant(identity, duration)
+}
+
+// RequestFloor gives the floor to a participant when the policy of the room allows it,
+// or queues the participant until it is its turn or a host grants it the floor
+func (r *Room) RequestFloor(identity livekit.ParticipantIdentity, duration time.Duration) (*FloorState, error) {
+	if r.GetParticipant(identity) == nil {
+		return nil, ErrParticipantNotInRoom
+	}
+	return r.floor.Request(identity, duration)
+}
+
+// ReleaseFloor releases the floor held by identity or withdraws its request, or releases the floor of
+// whoever holds it when identity is empty
+func (r *Room) ReleaseFloor(identity livekit.ParticipantIdentity) (*FloorState, error) {
+	return r.floor.Release(identity)
+}
+
+func (r *Room) FloorState() *FloorState {
+	return r.floor.State()
+}
+
+func (r *Room) onFloorChanged(state *FloorState) {
+	r.Logger.Infow("floor updated", "holder", state.Holder, "queueLength", len(state.Queue), "version", state.Version)
+	r.applyAudioBlocks()
+	r.announceFloor(state)
+}
+
+func (r *Room) applyAudioBlocks() {
+	for _, p := range r.GetParticipants() {
+		p.BlockAudio(!r.floor.CanSpeak(p))
+	}
+}
+
+func (r *Room) announceFloor(state *FloorState) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		r.Logger.Errorw("could not marshal floor state", err)
+		return
+	}
+
+	topic := FloorTopic
+	r.SendDataPacket(&livekit.UserPacket{
+		Payload: data,
+		Topic:   &topic,
+	}, livekit.DataPacket_RELIABLE)
+}
+
+// handleRoomCommand runs a command sent on RoomCommandTopic and sends the result back to its sender
+func (r *Room) handleRoomCommand(source types.LocalParticipant, up *livekit.UserPacket) {
+	cmd := &RoomCommand{}
+	result := &RoomCommandResult{}
+	if err := json.Unmarshal(up.Payload, cmd); err != nil {
+		result.Error = ErrInvalidRoomCommand.Error()
+	} else {
+		result.Command = cmd.Command
+		if err := r.runRoomCommand(source, cmd, result); err != nil {
+			source.GetLogger().Infow("room command failed", "command", cmd.Command, "error", err)
+			result.Error = err.Error()
+		}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		r.Logger.Errorw("could not marshal room command result", err)
+		return
+	}
+	topic := RoomCommandTopic
+	r.SendDataPacket(&livekit.UserPacket{
+		Payload:               data,
+		Topic:                 &topic,
+		DestinationIdentities: []string{string(source.Identity())},
+	}, livekit.DataPacket_RELIABLE)
+}
+
+func (r *Room) runRoomCommand(source types.LocalParticipant, cmd *RoomCommand, result *RoomCommandResult) error {
+	host := isHost(source)
+	duration := time.Duration(cmd.Duration) * time.Second
+
+	var err error
+	switch cmd.Command {
+	case RoomCommandMuteAll:
+		if !host {
+			return ErrPermissionDenied
+		}
+		for _, p := range r.MuteAllMicrophones(cmd.ExcludeHosts, cmd.Except) {
+			result.Muted = append(result.Muted, p.Identity())
+		}
+
+	case RoomCommandPushToTalk:
+		if !host {
+			return ErrPermissionDenied
+		}
+		if !cmd.PushToTalk.IsValid() {
+			return ErrInvalidRoomCommand
+		}
+		options := r.SetPushToTalk(cmd.PushToTalk)
+		if r.onOptionsChanged != nil {
+			r.onOptionsChanged(options)
+		}
+		result.Floor = r.FloorState()
+
+	case RoomCommandGrantFloor:
+		if !host {
+			return ErrPermissionDenied
+		}
+		result.Floor, err = r.GrantFloor(cmd.Identity, duration)
+
+	case RoomCommandRequestFloor:
+		result.Floor, err = r.RequestFloor(source.Identity(), duration)
+
+	case RoomCommandReleaseFloor:
+		result.Floor, err = r.ReleaseFloor(source.Identity())
+
+	case RoomCommandRevokeFloor:
+		if !host {
+			return ErrPermissionDenied
+		}
+		result.Floor, err = r.ReleaseFloor(cmd.Identity)
+
+	case RoomCommandSubscribeDataTopics:
+		r.dataTopics.Subscribe(source.Identity(), cmd.Topics)
+		result.DataTopics = r.dataTopics.Subscriptions(source.Identity())
+		return nil
+
+	default:
+		return ErrInvalidRoomCommand
+	}
+	result.QueuePosition = result.Floor.QueuePosition(source.Identity())
+	return err
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"golang.org/x/exp/slices"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// MuteAllMicrophones mutes the microphone tracks published in the room, as if muted by an admin.
+// Hosts, participants with the room admin grant, keep their microphones when excludeHosts is set,
+// and so do the participants in except. It returns the participants that had tracks muted.
+func (r *Room) MuteAllMicrophones(excludeHosts bool, except []livekit.ParticipantIdentity) []types.LocalParticipant {
+	var muted []types.LocalParticipant
+	for _, p := range r.GetParticipants() {
+		if (excludeHosts && isHost(p)) || slices.Contains(except, p.Identity()) {
+			continue
+		}
+
+		mutedTrack := false
+		for _, track := range p.GetPublishedTracks() {
+			if track.Kind() != livekit.TrackType_AUDIO || track.Source() != livekit.TrackSource_MICROPHONE || track.IsMuted() {
+				continue
+			}
+			if p.SetTrackMuted(track.ID(), true, true) != nil {
+				mutedTrack = true
+			}
+		}
+		if mutedTrack {
+			p.GetLogger().Infow("microphone muted by room moderation")
+			muted = append(muted, p)
+		}
+	}
+	return muted
+}
+
+// MuteTracksBySource mutes or unmutes every track of the source published in the room, as if by an admin, e.g. all
+// cameras during a presentation. Each participant gets a single participant update for all of its tracks. Hosts are
+// left untouched when excludeHosts is set, and so are the participants in except. It returns the participants
+// that had tracks changed.
+func (r *Room) MuteTracksBySource(
+	source livekit.TrackSource,
+	muted bool,
+	excludeHosts bool,
+	except []livekit.ParticipantIdentity,
+) []types.LocalParticipant {
+	// a single moderation at a time, so that concurrent requests do not leave a mix of muted and unmuted tracks
+	r.moderationLock.Lock()
+	defer r.moderationLock.Unlock()
+
+	var changed []types.LocalParticipant
+	for _, p := range r.GetParticipants() {
+		if (excludeHosts && isHost(p)) || slices.Contains(except, p.Identity()) {
+			continue
+		}
+
+		var trackIDs []livekit.TrackID
+		for _, track := range p.GetPublishedTracks() {
+			if track.Source() == source && track.IsMuted() != muted {
+				trackIDs = append(trackIDs, track.ID())
+			}
+		}
+		if len(trackIDs) == 0 {
+			continue
+		}
+		if len(p.SetTracksMuted(trackIDs, muted, true)) != 0 {
+			p.GetLogger().Infow("tracks muted by room moderation", "source", source, "muted", muted, "trackIDs", trackIDs)
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// UpdateParticipantsPermission applies permission to the participants with the given identities,
+// or to every participant other than recorders, agents and hidden participants when identities is empty.
+// Hosts are left untouched when excludeHosts is set. It returns the participants that were updated.
+func (r *Room) UpdateParticipantsPermission(
+	identities []livekit.ParticipantIdentity,
+	permission *livekit.ParticipantPermission,
+	excludeHosts bool,
+) []types.LocalParticipant {
+	var participants []types.LocalParticipant
+	if len(identities) == 0 {
+		for _, p := range r.GetParticipants() {
+			if !p.Hidden() && !p.IsRecorder() && !p.IsAgent() {
+				participants = append(participants, p)
+			}
+		}
+	} else {
+		for _, identity := range identities {
+			if p := r.GetParticipant(identity); p != nil {
+				participants = append(participants, p)
+			}
+		}
+	}
+
+	updated := make([]types.LocalParticipant, 0, len(participants))
+	for _, p := range participants {
+		if excludeHosts && isHost(p) {
+			continue
+		}
+		if r.IsPendingAdmission(p.Identity()) {
+			// admission restores the permission participants in the waiting room joined with
+			continue
+		}
+		p.SetPermission(permission)
+		updated = append(updated, p)
+	}
+	return updated
+}
+
+func isHost(participant types.LocalParticipant) bool {
+	grants := participant.ClaimGrants()
+	return grants != nil && grants.Video != nil && grants.Video.RoomAdmin
+}
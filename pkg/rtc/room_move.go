@@ -0,0 +1,232 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// DetachedParticipant is a participant that has left a room without closing its session,
+// to be attached to another room on the same node
+type DetachedParticipant struct {
+	Participant   types.LocalParticipant
+	RequestSource routing.MessageSource
+	Options       *ParticipantOptions
+}
+
+// DetachParticipant removes a participant from the room while keeping its transports open.
+// Its published tracks are unpublished from the room and its subscriptions are released,
+// the client is told that everyone else in the room has left.
+func (r *Room) DetachParticipant(identity livekit.ParticipantIdentity) (*DetachedParticipant, error) {
+	r.lock.Lock()
+	p, ok := r.participants[identity]
+	if !ok {
+		r.lock.Unlock()
+		return nil, ErrParticipantNotInRoom
+	}
+	if _, ok := r.pendingParticipants[identity]; ok {
+		r.lock.Unlock()
+		return nil, ErrParticipantPending
+	}
+
+	detached := &DetachedParticipant{
+		Participant:   p,
+		RequestSource: r.participantRequestSources[identity],
+		Options:       r.participantOpts[identity],
+	}
+	delete(r.participants, identity)
+	delete(r.participantOpts, identity)
+	delete(r.participantRequestSources, identity)
+	delete(r.hasPublished, identity)
+
+	immediateChange := false
+	if p.IsRecorder() {
+		activeRecording := false
+		for _, op := range r.participants {
+			if op.IsRecorder() {
+				activeRecording = true
+				break
+			}
+		}
+
+		if r.protoRoom.ActiveRecording != activeRecording {
+			r.protoRoom.ActiveRecording = activeRecording
+			immediateChange = true
+		}
+	}
+	r.lock.Unlock()
+	r.protoProxy.MarkDirty(immediateChange)
+
+	r.floor.Remove(identity)
+	r.dataTopics.RemoveParticipant(identity)
+
+	r.clearParticipantCallbacks(p)
+
+	// release everything in this room that the participant is subscribed to or publishes
+	publishedTracks := p.GetPublishedTracks()
+	for _, track := range publishedTracks {
+		r.trackManager.RemoveTrack(track)
+	}
+	for _, st := range p.GetSubscribedTracks() {
+		p.UnsubscribeFromTrack(st.ID())
+	}
+
+	var departed []*livekit.ParticipantInfo
+	for _, op := range r.GetParticipants() {
+		for _, track := range publishedTracks {
+			op.UnsubscribeFromTrack(track.ID())
+		}
+		if !op.Hidden() {
+			pi := op.ToProto()
+			pi.State = livekit.ParticipantInfo_DISCONNECTED
+			departed = append(departed, pi)
+		}
+	}
+	if len(departed) > 0 {
+		if err := p.SendParticipantUpdate(departed); err != nil {
+			p.GetLogger().Warnw("could not send participant updates on detach", err)
+		}
+	}
+
+	r.leftAt.Store(time.Now().Unix())
+
+	if !p.Hidden() {
+		pi := p.ToProto()
+		pi.State = livekit.ParticipantInfo_DISCONNECTED
+		r.sendParticipantUpdates(r.pushAndDequeueUpdates(pi, types.ParticipantCloseReasonNone, true))
+	}
+
+	r.onRuleEvent(webhook.EventParticipantLeft, p, nil)
+	return detached, nil
+}
+
+// CheckAttachParticipant returns the error AttachParticipant would fail with, so that a participant is only
+// detached from its room when it can be attached to this one
+func (r *Room) CheckAttachParticipant(participant types.LocalParticipant) error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.checkAttachLocked(participant)
+}
+
+func (r *Room) checkAttachLocked(participant types.LocalParticipant) error {
+	if r.IsClosed() {
+		return ErrRoomClosed
+	}
+	if err := r.checkActivationWindowLocked(); err != nil {
+		return err
+	}
+	if r.options.IsLocked() && !bypassesWaitingRoom(participant) {
+		return ErrRoomLocked
+	}
+	if r.participants[participant.Identity()] != nil {
+		return ErrAlreadyJoined
+	}
+	if r.protoRoom.MaxParticipants > 0 && !participant.IsRecorder() {
+		numParticipants := uint32(0)
+		for _, p := range r.participants {
+			if !p.IsRecorder() {
+				numParticipants++
+			}
+		}
+		if numParticipants >= r.protoRoom.MaxParticipants {
+			return ErrMaxParticipantsExceeded
+		}
+	}
+	return nil
+}
+
+// AttachParticipant adds a participant detached from another room. Unlike Join there is no join response,
+// the client receives the new room and its participants as updates, and tracks it publishes are made
+// available to the room.
+func (r *Room) AttachParticipant(detached *DetachedParticipant) error {
+	participant := detached.Participant
+
+	r.lock.Lock()
+	if err := r.checkAttachLocked(participant); err != nil {
+		r.lock.Unlock()
+		return err
+	}
+
+	if r.FirstJoinedAt() == 0 {
+		r.joinedAt.Store(time.Now().Unix())
+	}
+
+	r.setParticipantCallbacks(participant)
+	// goroutines and timers of the participant are counted in this room from now on
+	participant.GetResourceTracker().Bind(r.resources)
+
+	if participant.IsRecorder() && !r.protoRoom.ActiveRecording {
+		r.protoRoom.ActiveRecording = true
+		r.protoProxy.MarkDirty(true)
+	} else {
+		r.protoProxy.MarkDirty(false)
+	}
+
+	r.participants[participant.Identity()] = participant
+	r.participantOpts[participant.Identity()] = detached.Options
+	r.participantRequestSources[participant.Identity()] = detached.RequestSource
+
+	others := make([]*livekit.ParticipantInfo, 0, len(r.participants))
+	for _, op := range r.participants {
+		if op != participant && !op.Hidden() {
+			others = append(others, op.ToProto())
+		}
+	}
+	r.lock.Unlock()
+
+	// blocks of the previous room do not carry over
+	participant.BlockAudio(!r.floor.CanSpeak(participant))
+
+	participant.GetLogger().Infow("participant attached to room",
+		"room", r.Name(),
+		"roomID", r.ID(),
+		"numParticipants", len(others)+1,
+	)
+
+	if r.onParticipantChanged != nil {
+		r.onParticipantChanged(participant)
+	}
+
+	if err := participant.SendRoomUpdate(r.ToProto()); err != nil {
+		participant.GetLogger().Warnw("could not send room update on attach", err)
+	}
+	if len(others) > 0 {
+		if err := participant.SendParticipantUpdate(others); err != nil {
+			participant.GetLogger().Warnw("could not send participant updates on attach", err)
+		}
+	}
+
+	publishedTracks := participant.GetPublishedTracks()
+	if len(publishedTracks) == 0 {
+		r.broadcastParticipantState(participant, broadcastOptions{skipSource: true})
+	}
+	for _, track := range publishedTracks {
+		r.onTrackPublished(participant, track)
+	}
+
+	if participant.State() == livekit.ParticipantInfo_ACTIVE {
+		r.subscribeToExistingTracks(participant)
+		r.replayDataPackets(participant)
+		r.onRuleEvent(webhook.EventParticipantJoined, participant, nil)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const defaultConnectionQualityAlertDuration = 30 * time.Second
+
+type connectionQualityAlertState struct {
+	degradedAt time.Time
+	alerted    bool
+}
+
+// connectionQualityAlerter tells when the connection quality of participants has been at or below the threshold
+// for long enough to alert, and when alerted participants recover
+type connectionQualityAlerter struct {
+	threshold livekit.ConnectionQuality
+	enabled   bool
+	duration  time.Duration
+
+	states map[livekit.ParticipantID]*connectionQualityAlertState
+}
+
+func newConnectionQualityAlerter(conf config.ConnectionQualityAlertConfig) *connectionQualityAlerter {
+	threshold, enabled := conf.ThresholdQuality()
+	duration := conf.Duration
+	if duration <= 0 {
+		duration = defaultConnectionQualityAlertDuration
+	}
+	return &connectionQualityAlerter{
+		threshold: threshold,
+		enabled:   enabled,
+		duration:  duration,
+		states:    make(map[livekit.ParticipantID]*connectionQualityAlertState),
+	}
+}
+
+// update returns an alert when the participant crossed the duration at or below the threshold, or recovered after an alert
+func (a *connectionQualityAlerter) update(pID livekit.ParticipantID, info *livekit.ConnectionQualityInfo, at time.Time) *telemetry.ConnectionQualityAlert {
+	if !a.enabled {
+		return nil
+	}
+
+	state := a.states[pID]
+	degraded := info.Quality == a.threshold || utils.IsConnectionQualityLower(a.threshold, info.Quality)
+	if !degraded {
+		if state == nil {
+			return nil
+		}
+		delete(a.states, pID)
+		if !state.alerted {
+			return nil
+		}
+		return &telemetry.ConnectionQualityAlert{
+			Degraded: false,
+			Quality:  info.Quality,
+			Score:    info.Score,
+			Duration: at.Sub(state.degradedAt),
+		}
+	}
+
+	if state == nil {
+		state = &connectionQualityAlertState{degradedAt: at}
+		a.states[pID] = state
+	}
+	if state.alerted || at.Sub(state.degradedAt) < a.duration {
+		return nil
+	}
+	state.alerted = true
+	return &telemetry.ConnectionQualityAlert{
+		Degraded: true,
+		Quality:  info.Quality,
+		Score:    info.Score,
+		Duration: at.Sub(state.degradedAt),
+	}
+}
+
+// retain forgets participants that are no longer active, they do not get a recovery alert
+func (a *connectionQualityAlerter) retain(infos map[livekit.ParticipantID]*livekit.ConnectionQualityInfo) {
+	for pID := range a.states {
+		if _, ok := infos[pID]; !ok {
+			delete(a.states, pID)
+		}
+	}
+}
+
+func (r *Room) notifyConnectionQualityAlerts(
+	alerter *connectionQualityAlerter,
+	participants map[livekit.ParticipantID]*livekit.ConnectionQualityInfo,
+	at time.Time,
+) {
+	for pID, info := range participants {
+		alert := alerter.update(pID, info, at)
+		if alert == nil {
+			continue
+		}
+		p := r.GetParticipantByID(pID)
+		if p == nil {
+			continue
+		}
+		if details := p.GetConnectionQualityDetails(); details != nil {
+			alert.NetworkConstraint = string(details.Constraint)
+		}
+		r.telemetry.ConnectionQualityAlert(context.Background(), pID, p.Identity(), alert)
+	}
+	alerter.retain(participants)
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestConnectionQualityAlerter(t *testing.T) {
+	quality := func(q livekit.ConnectionQuality) *livekit.ConnectionQualityInfo {
+		return &livekit.ConnectionQualityInfo{Quality: q}
+	}
+	now := time.Now()
+
+	t.Run("disabled", func(t *testing.T) {
+		a := newConnectionQualityAlerter(config.ConnectionQualityAlertConfig{})
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_LOST), now))
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_LOST), now.Add(time.Hour)))
+	})
+
+	t.Run("degraded for the duration", func(t *testing.T) {
+		a := newConnectionQualityAlerter(config.ConnectionQualityAlertConfig{Threshold: "poor", Duration: 10 * time.Second})
+
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_GOOD), now))
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_POOR), now.Add(5*time.Second)))
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_LOST), now.Add(10*time.Second)))
+
+		alert := a.update("p1", quality(livekit.ConnectionQuality_POOR), now.Add(15*time.Second))
+		require.NotNil(t, alert)
+		require.True(t, alert.Degraded)
+		require.Equal(t, 10*time.Second, alert.Duration)
+
+		// alerted once while degraded
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_POOR), now.Add(20*time.Second)))
+
+		alert = a.update("p1", quality(livekit.ConnectionQuality_GOOD), now.Add(25*time.Second))
+		require.NotNil(t, alert)
+		require.False(t, alert.Degraded)
+		require.Equal(t, livekit.ConnectionQuality_GOOD, alert.Quality)
+		require.Equal(t, 20*time.Second, alert.Duration)
+
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_EXCELLENT), now.Add(30*time.Second)))
+	})
+
+	t.Run("recovered before the duration", func(t *testing.T) {
+		a := newConnectionQualityAlerter(config.ConnectionQualityAlertConfig{Threshold: "good"})
+
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_GOOD), now))
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_EXCELLENT), now.Add(20*time.Second)))
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_GOOD), now.Add(25*time.Second)))
+		// the default duration counts from when it degraded again
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_GOOD), now.Add(50*time.Second)))
+		require.NotNil(t, a.update("p1", quality(livekit.ConnectionQuality_POOR), now.Add(55*time.Second)))
+	})
+
+	t.Run("participants that left are forgotten", func(t *testing.T) {
+		a := newConnectionQualityAlerter(config.ConnectionQualityAlertConfig{Threshold: "poor", Duration: time.Second})
+
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_POOR), now))
+		require.NotNil(t, a.update("p1", quality(livekit.ConnectionQuality_POOR), now.Add(time.Second)))
+
+		a.retain(map[livekit.ParticipantID]*livekit.ConnectionQualityInfo{})
+		require.Nil(t, a.update("p1", quality(livekit.ConnectionQuality_EXCELLENT), now.Add(2*time.Second)))
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"time"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// JoinResumed adds a participant resuming a session lost with the node that hosted the room before. The participant
+// has to be created as migrating with its previous SID. Unlike Join there is no join response, the client gets a
+// reconnect response and keeps its peer connections, it then replays its tracks and subscriptions with a sync state.
+// Tracks it published keep their IDs. The participant was admitted before, room lock and capacity do not apply.
+func (r *Room) JoinResumed(
+	participant types.LocalParticipant,
+	requestSource routing.MessageSource,
+	opts *ParticipantOptions,
+	iceServers []*livekit.ICEServer,
+	reason livekit.ReconnectReason,
+) error {
+	r.lock.Lock()
+	if r.IsClosed() {
+		r.lock.Unlock()
+		return ErrRoomClosed
+	}
+	if r.participants[participant.Identity()] != nil {
+		r.lock.Unlock()
+		return ErrAlreadyJoined
+	}
+
+	if r.FirstJoinedAt() == 0 {
+		r.joinedAt.Store(time.Now().Unix())
+	}
+
+	r.setParticipantCallbacks(participant)
+
+	if participant.IsRecorder() && !r.protoRoom.ActiveRecording {
+		r.protoRoom.ActiveRecording = true
+		r.protoProxy.MarkDirty(true)
+	} else {
+		r.protoProxy.MarkDirty(false)
+	}
+
+	r.participants[participant.Identity()] = participant
+	r.participantOpts[participant.Identity()] = opts
+	r.participantRequestSources[participant.Identity()] = requestSource
+	r.lock.Unlock()
+
+	participant.BlockAudio(!r.floor.CanSpeak(participant))
+
+	participant.GetLogger().Infow("participant resumed into room",
+		"room", r.Name(),
+		"roomID", r.ID(),
+		"reason", reason,
+		"numParticipants", r.GetParticipantCount(),
+	)
+
+	if r.onParticipantChanged != nil {
+		r.onParticipantChanged(participant)
+	}
+
+	r.resources.Go("room.joinTimeout", func() {
+		select {
+		case <-r.closed:
+			return
+		case <-time.After(joinTimeout):
+		}
+		if participant.State() == livekit.ParticipantInfo_JOINING {
+			r.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonJoinTimeout)
+		}
+	})
+
+	if err := participant.HandleReconnectAndSendResponse(reason, &livekit.ReconnectResponse{
+		IceServers:          iceServers,
+		ClientConfiguration: participant.GetClientConfiguration(),
+	}); err != nil {
+		return err
+	}
+
+	// include the local participant's info as well, the client knows it from before
+	if err := participant.SendParticipantUpdate(r.getOtherParticipantInfo("")); err != nil {
+		return err
+	}
+	_ = participant.SendRoomUpdate(r.ToProto())
+	return nil
+}
+
+// syncMigratedState sets up a participant that resumed into the room with JoinResumed from the state replayed by
+// its client: the tracks it publishes, keeping their IDs, and its transports, so that they carry on from the
+// previous node's SDP. It returns false for participants that did not resume from another node.
+func syncMigratedState(participant types.LocalParticipant, state *livekit.SyncState) bool {
+	if participant.MigrateState() != types.MigrateStateInit {
+		return false
+	}
+
+	var previousOffer, previousAnswer *webrtc.SessionDescription
+	if state.Offer != nil {
+		offer := FromProtoSessionDescription(state.Offer)
+		previousOffer = &offer
+	}
+	if state.Answer != nil {
+		answer := FromProtoSessionDescription(state.Answer)
+		previousAnswer = &answer
+	}
+	participant.SetMigrateInfo(previousOffer, previousAnswer, state.PublishTracks, state.DataChannels)
+	return true
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// SIPDTMFTopic is the topic of the DTMF tones of phone calls. The SIP participant of a call publishes the tones of
+// the caller, other participants send tones to the caller with the SIP participant as destination.
+const SIPDTMFTopic = "lk.sip.dtmf"
+
+// digits of DTMF tones
+const sipDTMFDigits = "0123456789*#ABCD"
+
+// SIPDTMF is the payload of the data messages on SIPDTMFTopic
+type SIPDTMF struct {
+	Digit string `json:"digit"`
+	// in milliseconds, the tone lasts as long as the SIP service decides when 0
+	Duration uint32 `json:"duration,omitempty"`
+}
+
+// allowSIPDTMF returns true for valid tones published by SIP participants or the server, and for tones that other
+// participants send to SIP participants only, so that a participant cannot pass its tones for those of a caller
+func (r *Room) allowSIPDTMF(source types.LocalParticipant, up *livekit.UserPacket) bool {
+	var dtmf SIPDTMF
+	if err := json.Unmarshal(up.Payload, &dtmf); err != nil || len(dtmf.Digit) != 1 || !strings.Contains(sipDTMFDigits, dtmf.Digit) {
+		return false
+	}
+	if source == nil || isSIPParticipant(source) {
+		return true
+	}
+
+	if len(up.DestinationSids) == 0 && len(up.DestinationIdentities) == 0 {
+		return false
+	}
+	for _, sid := range up.DestinationSids {
+		if p := r.GetParticipantByID(livekit.ParticipantID(sid)); p == nil || !isSIPParticipant(p) {
+			return false
+		}
+	}
+	for _, identity := range up.DestinationIdentities {
+		if p := r.GetParticipant(livekit.ParticipantIdentity(identity)); p == nil || !isSIPParticipant(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSIPParticipant(participant types.LocalParticipant) bool {
+	grants := participant.ClaimGrants()
+	return grants != nil && grants.GetParticipantKind() == livekit.ParticipantInfo_SIP
+}
@@ -0,0 +1,179 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// markers kept per recording, later changes are dropped
+const maxSpeakerMarkers = 10000
+
+// SpeakerMarker is a change of the loudest speaker of a room during a recording
+type SpeakerMarker struct {
+	// milliseconds since the recording of markers started, the service measures them from the start of the egress
+	// once it is known
+	OffsetMs int64 `json:"offset_ms"`
+	// unix time in milliseconds
+	Time     int64                       `json:"time"`
+	Identity livekit.ParticipantIdentity `json:"identity"`
+}
+
+// SpeakerMarkers records the changes of the loudest speaker of a room for recordings, e.g. audio mix egresses,
+// by their ID. A recording stops when its recorder participant, which has the ID as identity, leaves or the room
+// closes.
+type SpeakerMarkers struct {
+	lock       sync.Mutex
+	recordings map[string]*speakerMarkerRecording
+	numActive  int
+	onStopped  func(id string, markers []SpeakerMarker)
+}
+
+type speakerMarkerRecording struct {
+	startedAt time.Time
+	active    bool
+	last      livekit.ParticipantIdentity
+	markers   []SpeakerMarker
+}
+
+func NewSpeakerMarkers() *SpeakerMarkers {
+	return &SpeakerMarkers{
+		recordings: make(map[string]*speakerMarkerRecording),
+	}
+}
+
+// Start starts recording markers for id, a recording already started is left as is
+func (s *SpeakerMarkers) Start(id string, now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.recordings[id]; ok {
+		return
+	}
+	s.recordings[id] = &speakerMarkerRecording{startedAt: now, active: true}
+	s.numActive++
+}
+
+// OnStopped is called with the markers of a recording once it stopped
+func (s *SpeakerMarkers) OnStopped(f func(id string, markers []SpeakerMarker)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onStopped = f
+}
+
+// Stop stops recording markers for id, its markers are kept
+func (s *SpeakerMarkers) Stop(id string) {
+	s.lock.Lock()
+	rec := s.recordings[id]
+	if rec == nil || !rec.active {
+		s.lock.Unlock()
+		return
+	}
+	rec.active = false
+	s.numActive--
+	markers := slices.Clone(rec.markers)
+	onStopped := s.onStopped
+	s.lock.Unlock()
+
+	if onStopped != nil {
+		onStopped(id, markers)
+	}
+}
+
+// StopAll stops the active recordings
+func (s *SpeakerMarkers) StopAll() {
+	s.lock.Lock()
+	ids := make([]string, 0, s.numActive)
+	for id, rec := range s.recordings {
+		if rec.active {
+			ids = append(ids, id)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, id := range ids {
+		s.Stop(id)
+	}
+}
+
+func (s *SpeakerMarkers) IsRecording() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.numActive > 0
+}
+
+// Update adds a marker to the active recordings when the loudest speaker changed, silence does not end a speaker
+func (s *SpeakerMarkers) Update(loudest livekit.ParticipantIdentity, now time.Time) {
+	if loudest == "" {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, rec := range s.recordings {
+		if !rec.active || rec.last == loudest || len(rec.markers) >= maxSpeakerMarkers {
+			continue
+		}
+		rec.last = loudest
+		rec.markers = append(rec.markers, SpeakerMarker{
+			OffsetMs: now.Sub(rec.startedAt).Milliseconds(),
+			Time:     now.UnixMilli(),
+			Identity: loudest,
+		})
+	}
+}
+
+// Markers returns the markers recorded for id, false when markers were never recorded for it
+func (s *SpeakerMarkers) Markers(id string) ([]SpeakerMarker, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rec := s.recordings[id]
+	if rec == nil {
+		return nil, false
+	}
+	return slices.Clone(rec.markers), true
+}
+
+// RecordSpeakerMarkers starts recording the speaker changes of the room for the recording with the ID
+func (r *Room) RecordSpeakerMarkers(id string) {
+	r.speakerMarkers.Start(id, time.Now())
+}
+
+// SpeakerMarkers returns the speaker changes recorded for the recording with the ID
+func (r *Room) SpeakerMarkers(id string) ([]SpeakerMarker, bool) {
+	return r.speakerMarkers.Markers(id)
+}
+
+// OnSpeakerMarkersStopped is called with the speaker changes of a recording once its recorder left or the room closed
+func (r *Room) OnSpeakerMarkersStopped(f func(id string, markers []SpeakerMarker)) {
+	r.speakerMarkers.OnStopped(f)
+}
+
+func (r *Room) updateSpeakerMarkers(activeSpeakers []*livekit.SpeakerInfo) {
+	if len(activeSpeakers) == 0 || !r.speakerMarkers.IsRecording() {
+		return
+	}
+	// speakers are sorted by level, loudest first
+	if p := r.GetParticipantByID(livekit.ParticipantID(activeSpeakers[0].Sid)); p != nil {
+		r.speakerMarkers.Update(p.Identity(), time.Now())
+	}
+}
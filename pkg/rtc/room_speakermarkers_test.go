@@ -0,0 +1,78 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpeakerMarkers(t *testing.T) {
+	s := NewSpeakerMarkers()
+	stopped := make(map[string][]SpeakerMarker)
+	s.OnStopped(func(id string, markers []SpeakerMarker) {
+		stopped[id] = markers
+	})
+	start := time.Now()
+	require.False(t, s.IsRecording())
+
+	// nothing is recorded before a recording starts
+	s.Update("alice", start)
+	_, ok := s.Markers("EG_1")
+	require.False(t, ok)
+
+	s.Start("EG_1", start)
+	require.True(t, s.IsRecording())
+	s.Update("alice", start.Add(time.Second))
+	s.Update("alice", start.Add(2*time.Second))
+	// silence does not end a speaker
+	s.Update("", start.Add(3*time.Second))
+	s.Update("bob", start.Add(4*time.Second))
+	s.Update("alice", start.Add(5*time.Second))
+
+	markers, ok := s.Markers("EG_1")
+	require.True(t, ok)
+	require.Len(t, markers, 3)
+	require.EqualValues(t, "alice", markers[0].Identity)
+	require.EqualValues(t, 1000, markers[0].OffsetMs)
+	require.EqualValues(t, "bob", markers[1].Identity)
+	require.EqualValues(t, 4000, markers[1].OffsetMs)
+	require.Equal(t, start.Add(5*time.Second).UnixMilli(), markers[2].Time)
+
+	// a later recording has its own offsets and speakers
+	s.Start("EG_2", start.Add(5*time.Second))
+	s.Update("alice", start.Add(6*time.Second))
+	markers, _ = s.Markers("EG_2")
+	require.Len(t, markers, 1)
+	require.EqualValues(t, 1000, markers[0].OffsetMs)
+
+	// stopped recordings keep their markers
+	s.Stop("EG_1")
+	require.Len(t, stopped["EG_1"], 3)
+	s.Update("bob", start.Add(7*time.Second))
+	markers, _ = s.Markers("EG_1")
+	require.Len(t, markers, 3)
+	markers, _ = s.Markers("EG_2")
+	require.Len(t, markers, 2)
+
+	// recordings are stopped once
+	s.Stop("EG_1")
+	s.StopAll()
+	require.Len(t, stopped, 2)
+	require.Len(t, stopped["EG_2"], 2)
+	require.False(t, s.IsRecording())
+}
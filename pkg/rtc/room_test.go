@@ -15,6 +15,7 @@
 package rtc
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -23,6 +24,7 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/livekit-server/version"
+	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/webhook"
 
@@ -34,6 +36,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
 	"github.com/livekit/livekit-server/pkg/testutils"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
 )
 
 func init() {
@@ -413,6 +416,42 @@ func TestRoomClosure(t *testing.T) {
 	})
 }
 
+func TestRoomResources(t *testing.T) {
+	t.Run("goroutines complete when room closes", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		counts := rm.Resources().Counts()
+		require.Equal(t, 1, counts.Goroutines["room.audioUpdateWorker"])
+		require.Equal(t, 2, counts.Goroutines["room.joinTimeout"])
+		require.NotNil(t, rm.DebugInfo()["Resources"])
+
+		rm.Close(types.ParticipantCloseReasonNone)
+		require.True(t, rm.Resources().WaitIdle(time.Second))
+	})
+
+	t.Run("leaks are reported after close", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		rm.resourceLeakTimeout = 100 * time.Millisecond
+		leaked := make(chan sutils.ResourceCounts, 1)
+		rm.OnResourceLeak(func(counts sutils.ResourceCounts) {
+			leaked <- counts
+		})
+		block := make(chan struct{})
+		defer close(block)
+		rm.Resources().Go("test.forwarder", func() {
+			<-block
+		})
+
+		rm.Close(types.ParticipantCloseReasonNone)
+		select {
+		case counts := <-leaked:
+			require.Equal(t, 1, counts.Total)
+			require.Equal(t, []string{"test.forwarder"}, counts.Labels())
+		case <-time.After(5 * time.Second):
+			t.Fatal("leak not reported")
+		}
+	})
+}
+
 func TestNewTrack(t *testing.T) {
 	t.Run("new track should be added to ready participants", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
@@ -632,6 +671,148 @@ func TestDataChannel(t *testing.T) {
 		require.Equal(t, packet.Value, dp.Value)
 	})
 
+	t.Run("participants receive the topics they subscribed to", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		participants := rm.GetParticipants()
+		p := participants[0].(*typesfakes.FakeLocalParticipant)
+		p1 := participants[1].(*typesfakes.FakeLocalParticipant)
+		p2 := participants[2].(*typesfakes.FakeLocalParticipant)
+
+		send := func(source *typesfakes.FakeLocalParticipant, topic string, payload []byte) {
+			source.OnDataPacketArgsForCall(0)(source, &livekit.DataPacket{
+				Kind: livekit.DataPacket_RELIABLE,
+				Value: &livekit.DataPacket_User{
+					User: &livekit.UserPacket{Payload: payload, Topic: &topic},
+				},
+			})
+		}
+
+		cmd, err := json.Marshal(&RoomCommand{Command: RoomCommandSubscribeDataTopics, Topics: []string{"game.*"}})
+		require.NoError(t, err)
+		send(p1, RoomCommandTopic, cmd)
+		require.Equal(t, 1, p1.SendDataPacketCallCount())
+		dp, _ := p1.SendDataPacketArgsForCall(0)
+		result := &RoomCommandResult{}
+		require.NoError(t, json.Unmarshal(dp.GetUser().Payload, result))
+		require.Empty(t, result.Error)
+		require.Equal(t, []string{"game.*"}, result.DataTopics)
+
+		send(p, "chat", []byte("hello"))
+		require.Equal(t, 1, p1.SendDataPacketCallCount())
+		require.Equal(t, 1, p2.SendDataPacketCallCount())
+
+		send(p, "game.state", []byte("state"))
+		require.Equal(t, 2, p1.SendDataPacketCallCount())
+		require.Equal(t, 2, p2.SendDataPacketCallCount())
+
+		// server topics are delivered to everyone
+		rm.SendDataPacket(&livekit.UserPacket{Payload: []byte("{}"), Topic: proto.String(FloorTopic)}, livekit.DataPacket_RELIABLE)
+		require.Equal(t, 3, p1.SendDataPacketCallCount())
+	})
+
+	t.Run("topic rate limits", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		rm.SetDataTopicLimits([]config.DataTopicLimitConfig{{Topic: "cursor.*", Rate: 0.1, Burst: 2}})
+		participants := rm.GetParticipants()
+		p := participants[0].(*typesfakes.FakeLocalParticipant)
+		p1 := participants[1].(*typesfakes.FakeLocalParticipant)
+
+		for _, topic := range []string{"cursor.move", "cursor.move", "cursor.move", "chat"} {
+			topic := topic
+			p.OnDataPacketArgsForCall(0)(p, &livekit.DataPacket{
+				Kind: livekit.DataPacket_LOSSY,
+				Value: &livekit.DataPacket_User{
+					User: &livekit.UserPacket{Payload: []byte("message"), Topic: &topic},
+				},
+			})
+		}
+		// the third message on the limited topic is dropped
+		require.Equal(t, 3, p1.SendDataPacketCallCount())
+	})
+
+	t.Run("late joiners receive retained messages", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		rm.SetDataReplay([]config.DataReplayConfig{{Topic: "state", MaxMessages: 2}})
+		p := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+
+		for _, msg := range []struct {
+			kind    livekit.DataPacket_Kind
+			topic   string
+			payload string
+		}{
+			{livekit.DataPacket_RELIABLE, "state", "a"},
+			{livekit.DataPacket_RELIABLE, "state", "b"},
+			{livekit.DataPacket_RELIABLE, "chat", "c"},
+			{livekit.DataPacket_LOSSY, "state", "d"},
+			{livekit.DataPacket_RELIABLE, "state", "e"},
+		} {
+			topic := msg.topic
+			p.OnDataPacketArgsForCall(0)(p, &livekit.DataPacket{
+				Kind: msg.kind,
+				Value: &livekit.DataPacket_User{
+					User: &livekit.UserPacket{Payload: []byte(msg.payload), Topic: &topic},
+				},
+			})
+		}
+
+		pNew := NewMockParticipant("new", types.CurrentProtocol, false, false)
+		require.NoError(t, rm.Join(pNew, nil, nil, iceServersForRoom))
+		pNew.OnStateChangeArgsForCall(0)(pNew, livekit.ParticipantInfo_ACTIVE)
+
+		// only the last two reliable messages on the topic are replayed
+		require.Equal(t, 2, pNew.SendDataPacketCallCount())
+		for i, payload := range []string{"b", "e"} {
+			dp, _ := pNew.SendDataPacketArgsForCall(i)
+			require.Equal(t, payload, string(dp.GetUser().Payload))
+		}
+	})
+
+	t.Run("DTMF tones of callers and to callers", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		participants := rm.GetParticipants()
+		caller := participants[0].(*typesfakes.FakeLocalParticipant)
+		grants := &auth.ClaimGrants{}
+		grants.SetParticipantKind(livekit.ParticipantInfo_SIP)
+		caller.ClaimGrantsReturns(grants)
+		p1 := participants[1].(*typesfakes.FakeLocalParticipant)
+		p2 := participants[2].(*typesfakes.FakeLocalParticipant)
+
+		send := func(source *typesfakes.FakeLocalParticipant, digit string, destinations ...string) {
+			source.OnDataPacketArgsForCall(0)(source, &livekit.DataPacket{
+				Kind: livekit.DataPacket_RELIABLE,
+				Value: &livekit.DataPacket_User{
+					User: &livekit.UserPacket{
+						Payload:               []byte(fmt.Sprintf(`{"digit":%q}`, digit)),
+						Topic:                 proto.String(SIPDTMFTopic),
+						DestinationIdentities: destinations,
+					},
+				},
+			})
+		}
+
+		send(caller, "5")
+		require.Equal(t, 1, p1.SendDataPacketCallCount())
+		require.Equal(t, 1, p2.SendDataPacketCallCount())
+
+		// not a digit
+		send(caller, "x")
+		require.Equal(t, 1, p1.SendDataPacketCallCount())
+
+		// other participants only send tones to callers
+		send(p1, "1")
+		send(p1, "1", string(p2.Identity()))
+		require.Equal(t, 1, p2.SendDataPacketCallCount())
+		require.Equal(t, 0, caller.SendDataPacketCallCount())
+
+		send(p1, "#", string(caller.Identity()))
+		require.Equal(t, 1, caller.SendDataPacketCallCount())
+		require.Equal(t, 1, p2.SendDataPacketCallCount())
+	})
+
 	t.Run("publishing disallowed", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
 		defer rm.Close(types.ParticipantCloseReasonNone)
@@ -691,6 +872,514 @@ func TestHiddenParticipants(t *testing.T) {
 	})
 }
 
+func TestWaitingRoom(t *testing.T) {
+	t.Run("participants are held until admitted", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0, options: &RoomOptions{WaitingRoom: true}})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		p := NewMockParticipant("waiting", types.CurrentProtocol, false, false)
+		grants := &auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}}
+		grants.Video.SetCanPublish(true)
+		p.ClaimGrantsReturns(grants)
+		require.NoError(t, rm.Join(p, nil, nil, iceServersForRoom))
+		require.True(t, rm.IsPendingAdmission(p.Identity()))
+		require.Equal(t, 1, p.SetPermissionCallCount())
+		require.True(t, p.SetPermissionArgsForCall(0).Hidden)
+		require.False(t, p.SetPermissionArgsForCall(0).CanPublish)
+
+		_, err := rm.AdmitParticipant(p.Identity())
+		require.NoError(t, err)
+		require.False(t, rm.IsPendingAdmission(p.Identity()))
+		require.Equal(t, 2, p.SetPermissionCallCount())
+		require.False(t, p.SetPermissionArgsForCall(1).Hidden)
+		require.True(t, p.SetPermissionArgsForCall(1).CanPublish)
+
+		_, err = rm.AdmitParticipant(p.Identity())
+		require.ErrorIs(t, err, ErrParticipantNotPending)
+	})
+
+	t.Run("participants waiting do not see who is in the room", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0, options: &RoomOptions{WaitingRoom: true}})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		host := NewMockParticipant("p0", types.CurrentProtocol, false, false)
+		host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true, RoomAdmin: true}})
+		require.NoError(t, rm.Join(host, nil, nil, iceServersForRoom))
+
+		p := NewMockParticipant("waiting", types.CurrentProtocol, false, false)
+		p.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}})
+		require.NoError(t, rm.Join(p, nil, nil, iceServersForRoom))
+		require.Empty(t, p.SendJoinResponseArgsForCall(0).OtherParticipants)
+
+		// updates of others are held back as well
+		rm.broadcastParticipantState(rm.GetParticipant("p0"), broadcastOptions{skipSource: true, immediate: true})
+		require.Zero(t, p.SendParticipantUpdateCallCount())
+
+		// admission sends the roster
+		_, err := rm.AdmitParticipant(p.Identity())
+		require.NoError(t, err)
+		require.Equal(t, 1, p.SendParticipantUpdateCallCount())
+		updates := p.SendParticipantUpdateArgsForCall(0)
+		require.Len(t, updates, 1)
+		require.Equal(t, "p0", updates[0].Identity)
+
+		// bulk permission updates do not let it out either
+		other := NewMockParticipant("other", types.CurrentProtocol, false, false)
+		other.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}})
+		require.NoError(t, rm.Join(other, nil, nil, iceServersForRoom))
+		require.Empty(t, rm.UpdateParticipantsPermission([]livekit.ParticipantIdentity{"other"}, &livekit.ParticipantPermission{CanPublish: true}, false))
+		require.True(t, rm.IsPendingAdmission("other"))
+	})
+
+	t.Run("admins bypass the waiting room", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0, options: &RoomOptions{WaitingRoom: true}})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		p := NewMockParticipant("admin", types.CurrentProtocol, false, false)
+		p.ClaimGrantsReturns(&auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomJoin: true, RoomAdmin: true},
+		})
+		require.NoError(t, rm.Join(p, nil, nil, iceServersForRoom))
+		require.False(t, rm.IsPendingAdmission(p.Identity()))
+		require.Zero(t, p.SetPermissionCallCount())
+	})
+}
+
+func TestMoveParticipant(t *testing.T) {
+	t.Run("participant is moved with its tracks", func(t *testing.T) {
+		source := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer source.Close(types.ParticipantCloseReasonNone)
+		destination := newRoomWithParticipants(t, testRoomOpts{num: 0})
+		defer destination.Close(types.ParticipantCloseReasonNone)
+
+		other := NewMockParticipant("other", types.CurrentProtocol, false, false)
+		require.NoError(t, destination.Join(other, nil, &ParticipantOptions{AutoSubscribe: true}, iceServersForRoom))
+		other.StateReturns(livekit.ParticipantInfo_ACTIVE)
+
+		p0 := source.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		p1 := source.GetParticipant("p1").(*typesfakes.FakeLocalParticipant)
+		detached, err := source.DetachParticipant("p0")
+		require.NoError(t, err)
+		require.Nil(t, source.GetParticipant("p0"))
+		require.Equal(t, p0, detached.Participant)
+
+		// tracks of the moved participant are released in the source room
+		require.Equal(t, 1, p1.UnsubscribeFromTrackCallCount())
+		updates := p0.SendParticipantUpdateArgsForCall(p0.SendParticipantUpdateCallCount() - 1)
+		require.Len(t, updates, 1)
+		require.Equal(t, "p1", updates[0].Identity)
+		require.Equal(t, livekit.ParticipantInfo_DISCONNECTED, updates[0].State)
+
+		require.NoError(t, destination.AttachParticipant(detached))
+		require.Equal(t, p0, destination.GetParticipant("p0"))
+		require.Equal(t, 1, p0.SendRoomUpdateCallCount())
+		receivedOther := false
+		for i := 0; i < p0.SendParticipantUpdateCallCount(); i++ {
+			for _, pi := range p0.SendParticipantUpdateArgsForCall(i) {
+				if pi.Identity == "other" {
+					receivedOther = true
+				}
+			}
+		}
+		require.True(t, receivedOther)
+		require.NotNil(t, p0.OnStateChangeArgsForCall(p0.OnStateChangeCallCount()-1))
+
+		// existing participants in the destination subscribe to the moved tracks
+		require.Equal(t, 1, other.SubscribeToTrackCallCount())
+	})
+
+	t.Run("resources of the moved participant are counted in the destination", func(t *testing.T) {
+		source := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		defer source.Close(types.ParticipantCloseReasonNone)
+		destination := newRoomWithParticipants(t, testRoomOpts{num: 0})
+		defer destination.Close(types.ParticipantCloseReasonNone)
+
+		p0 := source.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		resources := source.NewParticipantResources()
+		p0.GetResourceTrackerReturns(resources)
+		block := make(chan struct{})
+		defer close(block)
+		resources.Go("test.forwarder", func() {
+			<-block
+		})
+		require.Equal(t, 1, source.Resources().Counts().Goroutines["test.forwarder"])
+
+		detached, err := source.DetachParticipant("p0")
+		require.NoError(t, err)
+		require.NoError(t, destination.AttachParticipant(detached))
+
+		require.Zero(t, source.Resources().Counts().Goroutines["test.forwarder"])
+		require.Equal(t, 1, destination.Resources().Counts().Goroutines["test.forwarder"])
+	})
+
+	t.Run("pending participant cannot be moved", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0, options: &RoomOptions{WaitingRoom: true}})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		p := NewMockParticipant("waiting", types.CurrentProtocol, false, false)
+		p.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}})
+		require.NoError(t, rm.Join(p, nil, nil, iceServersForRoom))
+
+		_, err := rm.DetachParticipant(p.Identity())
+		require.ErrorIs(t, err, ErrParticipantPending)
+		require.NotNil(t, rm.GetParticipant(p.Identity()))
+	})
+
+	t.Run("destination is checked before the participant leaves", func(t *testing.T) {
+		source := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		defer source.Close(types.ParticipantCloseReasonNone)
+		destination := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		defer destination.Close(types.ParticipantCloseReasonNone)
+
+		p := source.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		p.IdentityReturns("moved")
+		p.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}})
+		require.NoError(t, destination.CheckAttachParticipant(p))
+
+		destination.SetLocked(true)
+		require.ErrorIs(t, destination.CheckAttachParticipant(p), ErrRoomLocked)
+		destination.SetLocked(false)
+
+		destination.protoRoom.MaxParticipants = 1
+		require.ErrorIs(t, destination.CheckAttachParticipant(p), ErrMaxParticipantsExceeded)
+	})
+}
+
+func TestJoinResumed(t *testing.T) {
+	t.Run("participant resumes without a join response", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		// admitted on the previous node
+		rm.SetLocked(true)
+
+		p := NewMockParticipant("resumed", types.CurrentProtocol, false, true)
+		require.NoError(t, rm.JoinResumed(p, nil, &ParticipantOptions{AutoSubscribe: true}, iceServersForRoom, livekit.ReconnectReason_RR_SIGNAL_DISCONNECTED))
+		require.Equal(t, p, rm.GetParticipant("resumed"))
+		require.Zero(t, p.SendJoinResponseCallCount())
+		require.Equal(t, 1, p.HandleReconnectAndSendResponseCallCount())
+		updates := p.SendParticipantUpdateArgsForCall(0)
+		require.Len(t, updates, 2)
+		require.Equal(t, 1, p.SendRoomUpdateCallCount())
+
+		require.ErrorIs(t, rm.JoinResumed(p, nil, nil, iceServersForRoom, livekit.ReconnectReason_RR_SIGNAL_DISCONNECTED), ErrAlreadyJoined)
+	})
+
+	t.Run("sync state sets up the tracks and transports", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		p := NewMockParticipant("resumed", types.CurrentProtocol, false, true)
+		p.MigrateStateReturns(types.MigrateStateInit)
+		p.GetPendingTrackReturns(&livekit.TrackInfo{Sid: "TR_camera"})
+		require.NoError(t, rm.JoinResumed(p, nil, &ParticipantOptions{AutoSubscribe: true}, iceServersForRoom, livekit.ReconnectReason_RR_SIGNAL_DISCONNECTED))
+
+		state := &livekit.SyncState{
+			Answer:        &livekit.SessionDescription{Type: "answer", Sdp: "answer"},
+			Subscription:  &livekit.UpdateSubscription{},
+			PublishTracks: []*livekit.TrackPublishedResponse{{Cid: "camera", Track: &livekit.TrackInfo{Sid: "TR_camera"}}},
+		}
+		require.NoError(t, rm.SyncState(p, state))
+		require.Equal(t, 1, p.SetMigrateInfoCallCount())
+		offer, answer, tracks, _ := p.SetMigrateInfoArgsForCall(0)
+		require.Nil(t, offer)
+		require.Equal(t, "answer", answer.SDP)
+		require.Equal(t, state.PublishTracks, tracks)
+		require.Equal(t, types.MigrateStateSync, p.SetMigrateStateArgsForCall(p.SetMigrateStateCallCount()-1))
+		require.Equal(t, 1, p.NegotiateCallCount())
+		require.Zero(t, p.IssueFullReconnectCallCount())
+
+		// participants that did not resume from another node only sync their subscriptions
+		p.MigrateStateReturns(types.MigrateStateComplete)
+		require.NoError(t, rm.SyncState(p, state))
+		require.Equal(t, 1, p.SetMigrateInfoCallCount())
+		require.Equal(t, 1, p.NegotiateCallCount())
+	})
+}
+
+func TestScheduledRoom(t *testing.T) {
+	t.Run("joins are rejected before the window starts", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0, options: &RoomOptions{
+			NotBefore: time.Now().Add(time.Hour).Unix(),
+		}})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		p := NewMockParticipant("early", types.CurrentProtocol, false, false)
+		require.ErrorIs(t, rm.Join(p, nil, nil, iceServersForRoom), ErrRoomNotActive)
+
+		// kept open until activation even though empty timeout has passed
+		rm.protoRoom.CreationTime = time.Now().Add(-time.Hour).Unix()
+		rm.CheckSchedule()
+		rm.CloseIfEmpty()
+		require.False(t, rm.IsClosed())
+		require.False(t, rm.activated.Load())
+	})
+
+	t.Run("room is activated and closed when the window ends", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1, options: &RoomOptions{
+			NotBefore: time.Now().Add(-time.Minute).Unix(),
+			NotAfter:  time.Now().Add(time.Hour).Unix(),
+		}})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		rm.CheckSchedule()
+		require.True(t, rm.activated.Load())
+		require.False(t, rm.IsClosed())
+
+		rm.options.NotAfter = time.Now().Unix()
+		rm.CheckSchedule()
+		require.True(t, rm.IsClosed())
+
+		p := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+		require.Equal(t, 1, p.CloseCallCount())
+		_, reason, _ := p.CloseArgsForCall(0)
+		require.Equal(t, types.ParticipantCloseReasonRoomExpired, reason)
+	})
+}
+
+func TestRoomModeration(t *testing.T) {
+	t.Run("locked room only accepts hosts", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		options := rm.SetLocked(true)
+		require.True(t, options.IsLocked())
+
+		p := NewMockParticipant("late", types.CurrentProtocol, false, false)
+		p.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}})
+		require.ErrorIs(t, rm.Join(p, nil, nil, iceServersForRoom), ErrRoomLocked)
+
+		host := NewMockParticipant("host", types.CurrentProtocol, false, false)
+		host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true, RoomAdmin: true}})
+		require.NoError(t, rm.Join(host, nil, nil, iceServersForRoom))
+
+		rm.SetLocked(false)
+		require.NoError(t, rm.Join(p, nil, nil, iceServersForRoom))
+	})
+
+	t.Run("mute all microphones", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		var participants []*typesfakes.FakeLocalParticipant
+		for _, op := range rm.GetParticipants() {
+			p := op.(*typesfakes.FakeLocalParticipant)
+			mic := NewMockTrack(livekit.TrackType_AUDIO, "mic")
+			mic.SourceReturns(livekit.TrackSource_MICROPHONE)
+			camera := NewMockTrack(livekit.TrackType_VIDEO, "camera")
+			camera.SourceReturns(livekit.TrackSource_CAMERA)
+			p.GetPublishedTracksReturns([]types.MediaTrack{mic, camera})
+			p.SetTrackMutedReturns(&livekit.TrackInfo{Muted: true})
+			participants = append(participants, p)
+		}
+		host := participants[0]
+		host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+
+		muted := rm.MuteAllMicrophones(true, nil)
+		require.Len(t, muted, 1)
+		require.Zero(t, host.SetTrackMutedCallCount())
+
+		p := participants[1]
+		require.Equal(t, p, muted[0])
+		require.Equal(t, 1, p.SetTrackMutedCallCount())
+		trackID, isMuted, fromAdmin := p.SetTrackMutedArgsForCall(0)
+		require.Equal(t, p.GetPublishedTracks()[0].ID(), trackID)
+		require.True(t, isMuted)
+		require.True(t, fromAdmin)
+
+		require.Len(t, rm.MuteAllMicrophones(false, nil), 2)
+		require.Equal(t, 1, host.SetTrackMutedCallCount())
+
+		require.Equal(t, []types.LocalParticipant{host}, rm.MuteAllMicrophones(false, []livekit.ParticipantIdentity{p.Identity()}))
+		require.Equal(t, 2, p.SetTrackMutedCallCount())
+	})
+
+	t.Run("mute tracks by source", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		var participants []*typesfakes.FakeLocalParticipant
+		for _, op := range rm.GetParticipants() {
+			p := op.(*typesfakes.FakeLocalParticipant)
+			mic := NewMockTrack(livekit.TrackType_AUDIO, "mic")
+			mic.SourceReturns(livekit.TrackSource_MICROPHONE)
+			camera := NewMockTrack(livekit.TrackType_VIDEO, "camera")
+			camera.SourceReturns(livekit.TrackSource_CAMERA)
+			mutedCamera := NewMockTrack(livekit.TrackType_VIDEO, "muted camera")
+			mutedCamera.SourceReturns(livekit.TrackSource_CAMERA)
+			mutedCamera.IsMutedReturns(true)
+			p.GetPublishedTracksReturns([]types.MediaTrack{mic, camera, mutedCamera})
+			p.SetTracksMutedReturns([]*livekit.TrackInfo{{Muted: true}})
+			participants = append(participants, p)
+		}
+		host := participants[0]
+		host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+
+		changed := rm.MuteTracksBySource(livekit.TrackSource_CAMERA, true, true, nil)
+		require.Len(t, changed, 1)
+		require.Zero(t, host.SetTracksMutedCallCount())
+
+		// only the unmuted camera is muted, in a single call
+		p := participants[1]
+		require.Equal(t, p, changed[0])
+		require.Equal(t, 1, p.SetTracksMutedCallCount())
+		trackIDs, isMuted, fromAdmin := p.SetTracksMutedArgsForCall(0)
+		require.Equal(t, []livekit.TrackID{p.GetPublishedTracks()[1].ID()}, trackIDs)
+		require.True(t, isMuted)
+		require.True(t, fromAdmin)
+
+		// unmuting only touches the muted camera
+		require.Len(t, rm.MuteTracksBySource(livekit.TrackSource_CAMERA, false, false, []livekit.ParticipantIdentity{p.Identity()}), 1)
+		trackIDs, isMuted, _ = host.SetTracksMutedArgsForCall(0)
+		require.Equal(t, []livekit.TrackID{host.GetPublishedTracks()[2].ID()}, trackIDs)
+		require.False(t, isMuted)
+
+		require.Empty(t, rm.MuteTracksBySource(livekit.TrackSource_SCREEN_SHARE, true, false, nil))
+		require.Equal(t, 1, p.SetTracksMutedCallCount())
+	})
+
+	t.Run("push to talk floor", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		host := rm.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+		p1 := rm.GetParticipant("p1").(*typesfakes.FakeLocalParticipant)
+		p2 := rm.GetParticipant("p2").(*typesfakes.FakeLocalParticipant)
+
+		_, err := rm.RequestFloor("p1", 0)
+		require.ErrorIs(t, err, ErrPushToTalkDisabled)
+		require.False(t, lastAudioBlock(p1))
+
+		options := rm.SetPushToTalk(&PushToTalkOptions{Enabled: true, ExcludeHosts: true})
+		require.True(t, options.IsPushToTalk())
+		require.False(t, lastAudioBlock(host))
+		require.True(t, lastAudioBlock(p1))
+		require.True(t, lastAudioBlock(p2))
+
+		state, err := rm.RequestFloor("p1", 0)
+		require.NoError(t, err)
+		require.Equal(t, livekit.ParticipantIdentity("p1"), state.Holder)
+		require.False(t, lastAudioBlock(p1))
+		require.True(t, lastAudioBlock(p2))
+
+		// requests wait for the floor, and get it when it is released
+		state, err = rm.RequestFloor("p2", 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, state.QueuePosition("p2"))
+		require.True(t, lastAudioBlock(p2))
+		_, err = rm.ReleaseFloor("p1")
+		require.NoError(t, err)
+		require.True(t, lastAudioBlock(p1))
+		require.False(t, lastAudioBlock(p2))
+
+		// the floor is released when its holder leaves
+		rm.RemoveParticipant("p2", "", types.ParticipantCloseReasonClientRequestLeave)
+		require.Empty(t, rm.FloorState().Holder)
+
+		_, err = rm.GrantFloor("p1", 50*time.Millisecond)
+		require.NoError(t, err)
+		require.False(t, lastAudioBlock(p1))
+		require.NotZero(t, rm.FloorState().ExpiresAt)
+		require.Eventually(t, func() bool {
+			return rm.FloorState().Holder == "" && lastAudioBlock(p1)
+		}, time.Second, 10*time.Millisecond)
+
+		rm.SetPushToTalk(nil)
+		require.False(t, lastAudioBlock(p1))
+		require.False(t, rm.FloorState().PushToTalk)
+	})
+
+	t.Run("room commands", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		var changed *RoomOptions
+		rm.OnOptionsChanged(func(options *RoomOptions) {
+			changed = options
+		})
+
+		host := rm.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+		p1 := rm.GetParticipant("p1").(*typesfakes.FakeLocalParticipant)
+
+		send := func(p *typesfakes.FakeLocalParticipant, cmd *RoomCommand) {
+			payload, err := json.Marshal(cmd)
+			require.NoError(t, err)
+			topic := RoomCommandTopic
+			p.OnDataPacketArgsForCall(0)(p, &livekit.DataPacket{
+				Kind:  livekit.DataPacket_RELIABLE,
+				Value: &livekit.DataPacket_User{User: &livekit.UserPacket{Payload: payload, Topic: &topic}},
+			})
+		}
+		lastPacket := func(p *typesfakes.FakeLocalParticipant) (string, []byte) {
+			dp, _ := p.SendDataPacketArgsForCall(p.SendDataPacketCallCount() - 1)
+			return dp.GetUser().GetTopic(), dp.GetUser().GetPayload()
+		}
+
+		// only hosts can change push to talk, commands are not forwarded
+		send(p1, &RoomCommand{Command: RoomCommandPushToTalk, PushToTalk: &PushToTalkOptions{Enabled: true}})
+		require.Zero(t, host.SendDataPacketCallCount())
+		topic, payload := lastPacket(p1)
+		require.Equal(t, RoomCommandTopic, topic)
+		result := &RoomCommandResult{}
+		require.NoError(t, json.Unmarshal(payload, result))
+		require.Equal(t, ErrPermissionDenied.Error(), result.Error)
+		require.Nil(t, changed)
+
+		send(host, &RoomCommand{Command: RoomCommandPushToTalk, PushToTalk: &PushToTalkOptions{Enabled: true}})
+		require.True(t, changed.IsPushToTalk())
+		topic, payload = lastPacket(p1)
+		require.Equal(t, FloorTopic, topic)
+		floor := &FloorState{}
+		require.NoError(t, json.Unmarshal(payload, floor))
+		require.True(t, floor.PushToTalk)
+		require.True(t, lastAudioBlock(p1))
+
+		send(p1, &RoomCommand{Command: RoomCommandRequestFloor})
+		require.Equal(t, livekit.ParticipantIdentity("p1"), rm.FloorState().Holder)
+		require.False(t, lastAudioBlock(p1))
+
+		send(p1, &RoomCommand{Command: RoomCommandRevokeFloor})
+		require.Equal(t, livekit.ParticipantIdentity("p1"), rm.FloorState().Holder)
+
+		send(host, &RoomCommand{Command: RoomCommandRevokeFloor})
+		require.Empty(t, rm.FloorState().Holder)
+		require.True(t, lastAudioBlock(p1))
+
+		// hosts approve requests with the host approval policy
+		send(host, &RoomCommand{Command: RoomCommandPushToTalk, PushToTalk: &PushToTalkOptions{Enabled: true, Policy: FloorPolicyHostApproval}})
+		send(p1, &RoomCommand{Command: RoomCommandRequestFloor})
+		_, payload = lastPacket(p1)
+		require.NoError(t, json.Unmarshal(payload, result))
+		require.Equal(t, 1, result.QueuePosition)
+		require.Empty(t, rm.FloorState().Holder)
+
+		send(host, &RoomCommand{Command: RoomCommandGrantFloor})
+		require.Equal(t, livekit.ParticipantIdentity("p1"), rm.FloorState().Holder)
+		require.Empty(t, rm.FloorState().Queue)
+	})
+
+	t.Run("update permissions of all participants", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3, numHidden: 1})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		host := rm.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+
+		permission := &livekit.ParticipantPermission{CanSubscribe: true}
+		updated := rm.UpdateParticipantsPermission(nil, permission, true)
+		require.Len(t, updated, 2)
+		require.Zero(t, host.SetPermissionCallCount())
+		require.Zero(t, rm.GetParticipant("p3").(*typesfakes.FakeLocalParticipant).SetPermissionCallCount())
+		for _, p := range updated {
+			require.Equal(t, permission, p.(*typesfakes.FakeLocalParticipant).SetPermissionArgsForCall(0))
+		}
+
+		updated = rm.UpdateParticipantsPermission([]livekit.ParticipantIdentity{"p0", "p3", "unknown"}, permission, false)
+		require.Len(t, updated, 2)
+		require.Equal(t, 1, host.SetPermissionCallCount())
+	})
+}
+
 func TestRoomUpdate(t *testing.T) {
 	t.Run("updates are sent when participant joined", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
@@ -730,12 +1419,18 @@ type testRoomOpts struct {
 	numHidden            int
 	protocol             types.ProtocolVersion
 	audioSmoothIntervals uint32
+	options              *RoomOptions
+}
+
+func lastAudioBlock(p *typesfakes.FakeLocalParticipant) bool {
+	return p.BlockAudioArgsForCall(p.BlockAudioCallCount() - 1)
 }
 
 func newRoomWithParticipants(t *testing.T, opts testRoomOpts) *Room {
 	rm := NewRoom(
 		&livekit.Room{Name: "room"},
 		nil,
+		opts.options,
 		WebRTCConfig{},
 		&config.AudioConfig{
 			UpdateInterval:  audioUpdateInterval,
@@ -0,0 +1,136 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/logger/zaputil"
+)
+
+// RoomLogger logs with the fields of a room like LoggerWithRoom, for participants that can be moved to another
+// room. The room fields of the loggers derived from it, e.g. those of the transports and tracks of a participant,
+// follow MoveTo.
+type RoomLogger struct {
+	movableLogger
+
+	base logger.Logger
+}
+
+func NewRoomLogger(l logger.Logger, name livekit.RoomName, roomID livekit.RoomID) *RoomLogger {
+	r := &RoomLogger{
+		movableLogger: movableLogger{
+			room:   &atomic.Pointer[roomLoggerState]{},
+			derive: func(l logger.Logger) logger.Logger { return l },
+		},
+		base: l,
+	}
+	r.MoveTo(name, roomID)
+	return r
+}
+
+// MoveTo replaces the room fields of the logger and of the loggers derived from it
+func (r *RoomLogger) MoveTo(name livekit.RoomName, roomID livekit.RoomID) {
+	r.room.Store(&roomLoggerState{logger: LoggerWithRoom(r.base, name, roomID)})
+}
+
+type roomLoggerState struct {
+	logger logger.Logger
+}
+
+type derivedLogger struct {
+	room   *roomLoggerState
+	logger logger.Logger
+}
+
+// movableLogger derives its logger from the logger of the room it is in, again after the room has changed
+type movableLogger struct {
+	room    *atomic.Pointer[roomLoggerState]
+	derive  func(l logger.Logger) logger.Logger
+	derived atomic.Pointer[derivedLogger]
+}
+
+func (m *movableLogger) current() logger.Logger {
+	room := m.room.Load()
+	if d := m.derived.Load(); d != nil && d.room == room {
+		return d.logger
+	}
+
+	// one frame for the movable logger
+	d := &derivedLogger{room: room, logger: m.derive(room.logger).WithCallDepth(1)}
+	m.derived.Store(d)
+	return d.logger
+}
+
+func (m *movableLogger) with(derive func(l logger.Logger) logger.Logger) logger.Logger {
+	parent := m.derive
+	return &movableLogger{
+		room: m.room,
+		derive: func(l logger.Logger) logger.Logger {
+			return derive(parent(l))
+		},
+	}
+}
+
+func (m *movableLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	m.current().Debugw(msg, keysAndValues...)
+}
+
+func (m *movableLogger) Infow(msg string, keysAndValues ...interface{}) {
+	m.current().Infow(msg, keysAndValues...)
+}
+
+func (m *movableLogger) Warnw(msg string, err error, keysAndValues ...interface{}) {
+	m.current().Warnw(msg, err, keysAndValues...)
+}
+
+func (m *movableLogger) Errorw(msg string, err error, keysAndValues ...interface{}) {
+	m.current().Errorw(msg, err, keysAndValues...)
+}
+
+func (m *movableLogger) WithValues(keysAndValues ...interface{}) logger.Logger {
+	return m.with(func(l logger.Logger) logger.Logger { return l.WithValues(keysAndValues...) })
+}
+
+func (m *movableLogger) WithName(name string) logger.Logger {
+	return m.with(func(l logger.Logger) logger.Logger { return l.WithName(name) })
+}
+
+func (m *movableLogger) WithComponent(component string) logger.Logger {
+	return m.with(func(l logger.Logger) logger.Logger { return l.WithComponent(component) })
+}
+
+func (m *movableLogger) WithCallDepth(depth int) logger.Logger {
+	return m.with(func(l logger.Logger) logger.Logger { return l.WithCallDepth(depth) })
+}
+
+func (m *movableLogger) WithItemSampler() logger.Logger {
+	return m.with(func(l logger.Logger) logger.Logger { return l.WithItemSampler() })
+}
+
+func (m *movableLogger) WithoutSampler() logger.Logger {
+	return m.with(func(l logger.Logger) logger.Logger { return l.WithoutSampler() })
+}
+
+// WithDeferredValues is resolved once, the logger returned keeps the room it was created in
+func (m *movableLogger) WithDeferredValues() (logger.Logger, logger.DeferredFieldResolver) {
+	return m.derive(m.room.Load().logger).WithDeferredValues()
+}
+
+func (m *movableLogger) WithTap(we *zaputil.WriteEnabler) logger.Logger {
+	return m.with(func(l logger.Logger) logger.Logger { return l.WithTap(we) })
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+)
+
+func TestRoomLogger(t *testing.T) {
+	var logged []string
+	roomLogger := NewRoomLogger(&recordingLogger{logged: &logged}, "breakout", "RM_breakout")
+	pLogger := LoggerWithParticipant(roomLogger, "guest", "PA_guest", false)
+	trackLogger := LoggerWithTrack(pLogger.WithComponent("pub"), "TR_camera", false)
+
+	pLogger.Infow("joined")
+	trackLogger.Infow("published")
+	require.Equal(t, []string{
+		"[room breakout roomID RM_breakout participant guest pID PA_guest remote false]",
+		"[room breakout roomID RM_breakout participant guest pID PA_guest remote false trackID TR_camera relayed false]",
+	}, logged)
+
+	// loggers derived before the move log with the room the participant was moved to
+	logged = nil
+	roomLogger.MoveTo("main", "RM_main")
+	pLogger.Infow("moved")
+	trackLogger.Infow("published")
+	require.Equal(t, []string{
+		"[room main roomID RM_main participant guest pID PA_guest remote false]",
+		"[room main roomID RM_main participant guest pID PA_guest remote false trackID TR_camera relayed false]",
+	}, logged)
+}
+
+// recordingLogger records the values of the entries logged through it
+type recordingLogger struct {
+	logger.Logger
+
+	values []interface{}
+	logged *[]string
+}
+
+func (l *recordingLogger) Infow(msg string, keysAndValues ...interface{}) {
+	*l.logged = append(*l.logged, fmt.Sprint(append(l.values[:len(l.values):len(l.values)], keysAndValues...)))
+}
+
+func (l *recordingLogger) WithValues(keysAndValues ...interface{}) logger.Logger {
+	return &recordingLogger{values: append(l.values[:len(l.values):len(l.values)], keysAndValues...), logged: l.logged}
+}
+
+func (l *recordingLogger) WithComponent(string) logger.Logger {
+	return l
+}
+
+func (l *recordingLogger) WithCallDepth(int) logger.Logger {
+	return l
+}
+
+func (l *recordingLogger) WithItemSampler() logger.Logger {
+	return l
+}
@@ -0,0 +1,526 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu/audioprocessing"
+)
+
+const passcodeSaltSize = 16
+
+// RoomOptions holds room settings that are not part of livekit.Room or livekit.RoomInternal.
+// They are set at room creation, stored with the room and applied by the node hosting it.
+type RoomOptions struct {
+	// WaitingRoom holds new participants in a pending state until they are admitted by an admin
+	WaitingRoom bool `json:"waiting_room,omitempty"`
+	// Template is the name of the room template from the server config the room was created from
+	Template string `json:"template,omitempty"`
+	// FmtpOverrides are merged into the fmtp line of the codecs negotiated in the room, e.g.
+	// {"mime": "video/vp9", "fmtp_line": "profile-id=2"}. They are applied after the server config overrides.
+	FmtpOverrides []*livekit.Codec `json:"fmtp_overrides,omitempty"`
+	// NotBefore and NotAfter bound the window, in unix seconds, in which participants can join the room.
+	// The room is kept open until the window starts, and is closed when it ends.
+	NotBefore int64 `json:"not_before,omitempty"`
+	NotAfter  int64 `json:"not_after,omitempty"`
+	// Locked rooms only accept new participants with the room admin grant, or recorders, agents and hidden participants
+	Locked bool `json:"locked,omitempty"`
+	// ConfigOverrides replace server config values for the participants of the room
+	ConfigOverrides *RoomConfigOverrides `json:"config_overrides,omitempty"`
+	// APIKey is the key the room was created with, its quotas apply to the room. It is set by the server
+	APIKey string `json:"api_key,omitempty"`
+	// PushToTalk only forwards the audio of the participant holding the floor
+	PushToTalk *PushToTalkOptions `json:"push_to_talk,omitempty"`
+	// Passcode has to be presented in the join request by participants other than hosts, recorders, agents
+	// and hidden participants. It is only set in requests, the room stores PasscodeHash
+	Passcode     string `json:"passcode,omitempty"`
+	PasscodeHash string `json:"passcode_hash,omitempty"`
+	// AudioProcessing is the processing applied by the server to the microphone audio of participants, when the
+	// server has audio processing enabled. It applies to tracks published after it is set
+	AudioProcessing *audioprocessing.Features `json:"audio_processing,omitempty"`
+	// VideoProcessing selects the video tracks processed before they are delivered to egress, when the server
+	// has a video processor
+	VideoProcessing *VideoProcessingOptions `json:"video_processing,omitempty"`
+	// SubscribeDefaults are the settings subscriptions start with by track source, e.g. {"camera": {"quality": "low"}}.
+	// They replace the defaults of the server config for their sources
+	SubscribeDefaults config.SubscribeDefaultsConfig `json:"subscribe_defaults,omitempty"`
+	// Codecs restricts the codecs negotiated by the participants of the room, e.g. {"allow": ["video/vp8"]} for
+	// recordings that only take VP8. It applies to participants joining after it is set
+	Codecs *CodecRestriction `json:"codecs,omitempty"`
+	// LatencyProfile tunes the playout delay, the probing and the pausing of video of the room together for
+	// conversations or for broadcasts. ConfigOverrides take precedence over it. It applies to participants
+	// joining after it is set
+	LatencyProfile LatencyProfile `json:"latency_profile,omitempty"`
+	// JoinRestriction restricts the networks participants join the room from, on top of the restrictions of
+	// the API key of their token
+	JoinRestriction *config.JoinRestrictionConfig `json:"join_restriction,omitempty"`
+}
+
+func (o *RoomOptions) Clone() *RoomOptions {
+	if o == nil {
+		return nil
+	}
+
+	clone := *o
+	clone.FmtpOverrides = slices.Clone(o.FmtpOverrides)
+	clone.ConfigOverrides = o.ConfigOverrides.Clone()
+	if o.PushToTalk != nil {
+		ptt := *o.PushToTalk
+		clone.PushToTalk = &ptt
+	}
+	if o.AudioProcessing != nil {
+		features := *o.AudioProcessing
+		clone.AudioProcessing = &features
+	}
+	clone.VideoProcessing = o.VideoProcessing.Clone()
+	clone.SubscribeDefaults = maps.Clone(o.SubscribeDefaults)
+	clone.Codecs = o.Codecs.Clone()
+	clone.JoinRestriction = o.JoinRestriction.Clone()
+	return &clone
+}
+
+// IsScheduled returns true when the room has an activation window
+func (o *RoomOptions) IsScheduled() bool {
+	return o != nil && (o.NotBefore > 0 || o.NotAfter > 0)
+}
+
+// IsPendingActivation returns true when the activation window has not started yet
+func (o *RoomOptions) IsPendingActivation(now time.Time) bool {
+	return o != nil && o.NotBefore > 0 && now.Unix() < o.NotBefore
+}
+
+// IsExpired returns true when the activation window has ended
+func (o *RoomOptions) IsExpired(now time.Time) bool {
+	return o != nil && o.NotAfter > 0 && now.Unix() >= o.NotAfter
+}
+
+// IsLocked returns true when the room does not accept new participants
+func (o *RoomOptions) IsLocked() bool {
+	return o != nil && o.Locked
+}
+
+// IsPushToTalk returns true when audio is only forwarded for the floor holder
+func (o *RoomOptions) IsPushToTalk() bool {
+	return o != nil && o.PushToTalk != nil && o.PushToTalk.Enabled
+}
+
+// GetAudioProcessing returns the audio processing enabled in the room
+func (o *RoomOptions) GetAudioProcessing() audioprocessing.Features {
+	if o == nil || o.AudioProcessing == nil {
+		return audioprocessing.Features{}
+	}
+	return *o.AudioProcessing
+}
+
+// GetLatencyProfile returns the latency profile of the room, LatencyProfileDefault when not set
+func (o *RoomOptions) GetLatencyProfile() LatencyProfile {
+	if o == nil {
+		return LatencyProfileDefault
+	}
+	return o.LatencyProfile
+}
+
+// ApplySubscribeDefaults returns the subscribe defaults of the server config with those of the room in place
+func (o *RoomOptions) ApplySubscribeDefaults(defaults config.SubscribeDefaultsConfig) config.SubscribeDefaultsConfig {
+	if o == nil || len(o.SubscribeDefaults) == 0 {
+		return defaults
+	}
+
+	applied := maps.Clone(defaults)
+	if applied == nil {
+		applied = make(config.SubscribeDefaultsConfig, len(o.SubscribeDefaults))
+	}
+	for source, d := range o.SubscribeDefaults {
+		applied[strings.ToLower(source)] = d
+	}
+	return applied
+}
+
+// SetPasscode replaces Passcode with a salted hash of passcode, an empty passcode removes it
+func (o *RoomOptions) SetPasscode(passcode string) {
+	o.Passcode = ""
+	o.PasscodeHash = ""
+	if passcode == "" {
+		return
+	}
+
+	salt := make([]byte, passcodeSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+	o.PasscodeHash = hex.EncodeToString(salt) + ":" + hex.EncodeToString(hashPasscode(salt, passcode))
+}
+
+// HasPasscode returns true when participants have to present a passcode to join
+func (o *RoomOptions) HasPasscode() bool {
+	return o != nil && o.PasscodeHash != ""
+}
+
+// CheckPasscode returns true when passcode matches the one the room was created with, or the room has none
+func (o *RoomOptions) CheckPasscode(passcode string) bool {
+	if !o.HasPasscode() {
+		return true
+	}
+
+	saltHex, hashHex, ok := strings.Cut(o.PasscodeHash, ":")
+	if !ok {
+		return false
+	}
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return false
+	}
+	hash, err := hex.DecodeString(hashHex)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(hash, hashPasscode(salt, passcode)) == 1
+}
+
+func hashPasscode(salt []byte, passcode string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(passcode))
+	return h.Sum(nil)
+}
+
+// IsZero returns true when no option has been set
+func (o *RoomOptions) IsZero() bool {
+	return o == nil || reflect.ValueOf(*o).IsZero()
+}
+
+// ---------------------------------------------
+
+type PushToTalkOptions struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// ExcludeHosts lets participants with the room admin grant speak without the floor
+	ExcludeHosts bool `json:"exclude_hosts,omitempty"`
+	// MaxFloorDuration, in seconds, releases the floor of a holder that did not release it. 0 does not limit grants
+	MaxFloorDuration uint32 `json:"max_floor_duration,omitempty"`
+	// Policy decides how requests for the floor are granted, FloorPolicyFIFO when not set
+	Policy FloorPolicy `json:"policy,omitempty"`
+}
+
+// IsValid returns false for unknown policies
+func (o *PushToTalkOptions) IsValid() bool {
+	if o == nil {
+		return true
+	}
+	switch o.Policy {
+	case "", FloorPolicyFIFO, FloorPolicyHostApproval:
+		return true
+	}
+	return false
+}
+
+// GetPolicy returns the floor policy, FloorPolicyFIFO when not set
+func (o *PushToTalkOptions) GetPolicy() FloorPolicy {
+	if o == nil || o.Policy == "" {
+		return FloorPolicyFIFO
+	}
+	return o.Policy
+}
+
+// ---------------------------------------------
+
+// CodecRestriction allows or denies codecs by mime type. Allowing codecs of a kind restricts that kind only, the audio
+// codecs are left alone when only video codecs are allowed. Retransmission is never restricted.
+type CodecRestriction struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+func (c *CodecRestriction) Clone() *CodecRestriction {
+	if c == nil {
+		return nil
+	}
+
+	return &CodecRestriction{
+		Allow: slices.Clone(c.Allow),
+		Deny:  slices.Clone(c.Deny),
+	}
+}
+
+// IsValid returns false for codecs that are not audio or video mime types, or that are both allowed and denied
+func (c *CodecRestriction) IsValid() bool {
+	if c == nil {
+		return true
+	}
+	for _, mime := range append(slices.Clone(c.Allow), c.Deny...) {
+		kind, name, _ := strings.Cut(strings.ToLower(mime), "/")
+		if (kind != "audio" && kind != "video") || name == "" {
+			return false
+		}
+	}
+	for _, mime := range c.Allow {
+		if containsMime(c.Deny, mime) {
+			return false
+		}
+	}
+	return true
+}
+
+// Allows returns true when the codec with the mime type, e.g. video/VP8, may be negotiated
+func (c *CodecRestriction) Allows(mime string) bool {
+	if c == nil || strings.EqualFold(mime, videoRTXMimeType) {
+		return true
+	}
+	if containsMime(c.Deny, mime) {
+		return false
+	}
+
+	kind, _, _ := strings.Cut(mime, "/")
+	restricted := false
+	for _, allowed := range c.Allow {
+		if strings.EqualFold(allowed, mime) {
+			return true
+		}
+		if allowedKind, _, _ := strings.Cut(allowed, "/"); strings.EqualFold(allowedKind, kind) {
+			restricted = true
+		}
+	}
+	return !restricted
+}
+
+func containsMime(mimes []string, mime string) bool {
+	return slices.ContainsFunc(mimes, func(m string) bool {
+		return strings.EqualFold(m, mime)
+	})
+}
+
+// ---------------------------------------------
+
+type LatencyProfile string
+
+const (
+	// the server config applies
+	LatencyProfileDefault LatencyProfile = ""
+	// conversations, latency is kept low at the expense of quality. Receivers play out as soon as they can,
+	// probes are small and infrequent not to build up queues, and video is paused rather than delayed when
+	// subscribers are short of bandwidth
+	LatencyProfileInteractive LatencyProfile = "interactive"
+	// broadcasts to an audience, smoothness over latency. Receivers buffer enough to absorb retransmissions,
+	// probes are larger and more frequent to reach the best layers sooner, and video is never paused
+	LatencyProfileStreaming LatencyProfile = "streaming"
+)
+
+const (
+	interactiveMaxPlayoutDelay = 100
+	streamingMinPlayoutDelay   = 400
+	streamingMaxPlayoutDelay   = 2000
+)
+
+// IsValid returns false for unknown profiles
+func (p LatencyProfile) IsValid() bool {
+	switch p {
+	case LatencyProfileDefault, LatencyProfileInteractive, LatencyProfileStreaming:
+		return true
+	}
+	return false
+}
+
+// ApplyCongestionControl returns the congestion control of the server config with the pausing and probing of the profile
+func (p LatencyProfile) ApplyCongestionControl(conf config.CongestionControlConfig) config.CongestionControlConfig {
+	switch p {
+	case LatencyProfileInteractive:
+		conf.AllowPause = true
+		conf.ProbeConfig.BaseInterval = 5 * time.Second
+		conf.ProbeConfig.OveragePct = 110
+		conf.ProbeConfig.MaxDuration = 10 * time.Second
+
+	case LatencyProfileStreaming:
+		conf.AllowPause = false
+		conf.ProbeConfig.BaseInterval = 2 * time.Second
+		conf.ProbeConfig.MaxInterval = 30 * time.Second
+		conf.ProbeConfig.OveragePct = 150
+	}
+	return conf
+}
+
+// ApplyPlayoutDelay returns the playout delay, in milliseconds, of the profile in place of the one of the room
+func (p LatencyProfile) ApplyPlayoutDelay(playoutDelay *livekit.PlayoutDelay) *livekit.PlayoutDelay {
+	switch p {
+	case LatencyProfileInteractive:
+		return &livekit.PlayoutDelay{Enabled: true, Min: 0, Max: interactiveMaxPlayoutDelay}
+
+	case LatencyProfileStreaming:
+		return &livekit.PlayoutDelay{Enabled: true, Min: streamingMinPlayoutDelay, Max: streamingMaxPlayoutDelay}
+	}
+	return playoutDelay
+}
+
+// ---------------------------------------------
+
+// VideoProcessingOptions selects the video tracks of a room processed for egress, every video track when no
+// source or identity is given
+type VideoProcessingOptions struct {
+	// track sources, camera or screen_share
+	Sources []string `json:"sources,omitempty"`
+	// identities of the publishers
+	Identities []livekit.ParticipantIdentity `json:"identities,omitempty"`
+	// passed to the processor, e.g. the text of a watermark
+	Params map[string]string `json:"params,omitempty"`
+}
+
+func (o *VideoProcessingOptions) Clone() *VideoProcessingOptions {
+	if o == nil {
+		return nil
+	}
+
+	return &VideoProcessingOptions{
+		Sources:    slices.Clone(o.Sources),
+		Identities: slices.Clone(o.Identities),
+		Params:     maps.Clone(o.Params),
+	}
+}
+
+// Selects returns true when the track published by identity is processed
+func (o *VideoProcessingOptions) Selects(identity livekit.ParticipantIdentity, track *livekit.TrackInfo) bool {
+	if o == nil || track.GetType() != livekit.TrackType_VIDEO {
+		return false
+	}
+	if len(o.Identities) != 0 && !slices.Contains(o.Identities, identity) {
+		return false
+	}
+	if len(o.Sources) == 0 {
+		return true
+	}
+	for _, source := range o.Sources {
+		if strings.EqualFold(source, track.GetSource().String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------
+
+// RoomConfigOverrides are server config values overridden for a room, unset values keep the server config.
+// They apply to participants joining after the room was created or updated.
+type RoomConfigOverrides struct {
+	// audio.active_red_encoding
+	ActiveREDEncoding *bool `json:"active_red_encoding,omitempty"`
+	// rtc.congestion_control
+	CongestionControl *RoomCongestionControlOverrides `json:"congestion_control,omitempty"`
+	// rtc.max_track_bitrate, in bps
+	MaxTrackBitrate *int64 `json:"max_track_bitrate,omitempty"`
+	// room.playout_delay, takes precedence over min_playout_delay and max_playout_delay of CreateRoom
+	PlayoutDelay *livekit.PlayoutDelay `json:"playout_delay,omitempty"`
+}
+
+type RoomCongestionControlOverrides struct {
+	Enabled              *bool                                 `json:"enabled,omitempty"`
+	AllowPause           *bool                                 `json:"allow_pause,omitempty"`
+	UseSendSideBWE       *bool                                 `json:"send_side_bandwidth_estimation,omitempty"`
+	SendSideBWEAlgorithm *config.CongestionControlBWEAlgorithm `json:"send_side_bandwidth_estimator,omitempty"`
+	MinChannelCapacity   *int64                                `json:"min_channel_capacity,omitempty"`
+}
+
+func (o *RoomConfigOverrides) Clone() *RoomConfigOverrides {
+	if o == nil {
+		return nil
+	}
+
+	clone := *o
+	if o.CongestionControl != nil {
+		cc := *o.CongestionControl
+		clone.CongestionControl = &cc
+	}
+	if o.PlayoutDelay != nil {
+		clone.PlayoutDelay = proto.Clone(o.PlayoutDelay).(*livekit.PlayoutDelay)
+	}
+	return &clone
+}
+
+// IsValid returns false when a value is out of range
+func (o *RoomConfigOverrides) IsValid() bool {
+	if o == nil {
+		return true
+	}
+	if o.MaxTrackBitrate != nil && *o.MaxTrackBitrate < 0 {
+		return false
+	}
+	if cc := o.CongestionControl; cc != nil {
+		if cc.MinChannelCapacity != nil && *cc.MinChannelCapacity < 0 {
+			return false
+		}
+		if cc.SendSideBWEAlgorithm != nil && !cc.SendSideBWEAlgorithm.IsValid() {
+			return false
+		}
+	}
+	if pd := o.PlayoutDelay; pd != nil && pd.Max != 0 && pd.Max < pd.Min {
+		return false
+	}
+	return true
+}
+
+func (o *RoomConfigOverrides) ApplyAudio(conf config.AudioConfig) config.AudioConfig {
+	if o != nil && o.ActiveREDEncoding != nil {
+		conf.ActiveREDEncoding = *o.ActiveREDEncoding
+	}
+	return conf
+}
+
+func (o *RoomConfigOverrides) ApplyCongestionControl(conf config.CongestionControlConfig) config.CongestionControlConfig {
+	if o == nil || o.CongestionControl == nil {
+		return conf
+	}
+
+	cc := o.CongestionControl
+	if cc.Enabled != nil {
+		conf.Enabled = *cc.Enabled
+	}
+	if cc.AllowPause != nil {
+		conf.AllowPause = *cc.AllowPause
+	}
+	if cc.UseSendSideBWE != nil {
+		conf.UseSendSideBWE = *cc.UseSendSideBWE
+	}
+	if cc.SendSideBWEAlgorithm != nil {
+		conf.SendSideBWEAlgorithm = *cc.SendSideBWEAlgorithm
+	}
+	if cc.MinChannelCapacity != nil {
+		conf.MinChannelCapacity = *cc.MinChannelCapacity
+	}
+	return conf
+}
+
+func (o *RoomConfigOverrides) ApplyMaxTrackBitrate(maxTrackBitrate int64) int64 {
+	if o != nil && o.MaxTrackBitrate != nil {
+		return *o.MaxTrackBitrate
+	}
+	return maxTrackBitrate
+}
+
+func (o *RoomConfigOverrides) ApplyPlayoutDelay(playoutDelay *livekit.PlayoutDelay) *livekit.PlayoutDelay {
+	if o != nil && o.PlayoutDelay != nil {
+		return o.PlayoutDelay
+	}
+	return playoutDelay
+}
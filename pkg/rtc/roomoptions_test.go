@@ -0,0 +1,61 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestLatencyProfile(t *testing.T) {
+	roomDelay := &livekit.PlayoutDelay{Enabled: true, Min: 200, Max: 500}
+	cc := config.DefaultConfig.RTC.CongestionControl
+
+	var options *RoomOptions
+	profile := options.GetLatencyProfile()
+	require.Equal(t, LatencyProfileDefault, profile)
+	require.Equal(t, cc, profile.ApplyCongestionControl(cc))
+	require.Equal(t, roomDelay, profile.ApplyPlayoutDelay(roomDelay))
+
+	interactive := LatencyProfileInteractive.ApplyCongestionControl(cc)
+	streaming := LatencyProfileStreaming.ApplyCongestionControl(cc)
+	require.True(t, interactive.AllowPause)
+	require.False(t, streaming.AllowPause)
+	require.Greater(t, interactive.ProbeConfig.BaseInterval, streaming.ProbeConfig.BaseInterval)
+	require.Less(t, interactive.ProbeConfig.OveragePct, streaming.ProbeConfig.OveragePct)
+	// values the profile does not tune are kept
+	require.Equal(t, cc.ProbeConfig.MinBps, interactive.ProbeConfig.MinBps)
+	require.Equal(t, cc.UseSendSideBWE, streaming.UseSendSideBWE)
+
+	require.Zero(t, LatencyProfileInteractive.ApplyPlayoutDelay(roomDelay).Min)
+	require.Greater(t, LatencyProfileStreaming.ApplyPlayoutDelay(nil).Min, roomDelay.Min)
+
+	// overrides of the room take precedence over its profile
+	allowPause := false
+	overrides := &RoomConfigOverrides{
+		CongestionControl: &RoomCongestionControlOverrides{AllowPause: &allowPause},
+		PlayoutDelay:      roomDelay,
+	}
+	require.False(t, overrides.ApplyCongestionControl(LatencyProfileInteractive.ApplyCongestionControl(cc)).AllowPause)
+	require.Equal(t, roomDelay, overrides.ApplyPlayoutDelay(LatencyProfileStreaming.ApplyPlayoutDelay(nil)))
+
+	require.True(t, LatencyProfileStreaming.IsValid())
+	require.False(t, LatencyProfile("realtime").IsValid())
+}
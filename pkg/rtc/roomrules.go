@@ -0,0 +1,410 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
+)
+
+const (
+	RoomRuleActionCloseRoom   = "close_room"
+	RoomRuleActionMuteTrack   = "mute_track"
+	RoomRuleActionSendData    = "send_data"
+	RoomRuleActionStartEgress = "start_egress"
+	RoomRuleActionWebhook     = "webhook"
+)
+
+var (
+	// variables of the room, set for every rule
+	roomRuleRoomVars = []string{"room.name", "room.participants", "room.hosts", "room.publishers", "room.recording"}
+	// variables of the participant of the event
+	roomRuleParticipantVars = []string{"participant.identity", "participant.name", "participant.kind", "participant.host", "participant.hidden"}
+	// variables of the track of the event
+	roomRuleTrackVars = []string{"track.sid", "track.name", "track.type", "track.source", "track.mime", "track.muted"}
+
+	roomRuleEvents = map[string]struct {
+		participant bool
+		track       bool
+	}{
+		webhook.EventParticipantJoined: {participant: true},
+		webhook.EventParticipantLeft:   {participant: true},
+		webhook.EventTrackPublished:    {participant: true, track: true},
+		webhook.EventTrackUnpublished:  {participant: true, track: true},
+	}
+)
+
+// RoomRule is a rule of the room config with its condition parsed
+type RoomRule struct {
+	Name string
+	conf config.RoomRuleConfig
+	when ruleExpr
+}
+
+// NewRoomRules parses and checks rules. Rules that are not valid are left out and reported in the error,
+// along with the rules that are.
+func NewRoomRules(confs []config.RoomRuleConfig) ([]*RoomRule, error) {
+	var rules []*RoomRule
+	var errs []error
+	for i, conf := range confs {
+		rule, err := newRoomRule(conf)
+		if err != nil {
+			name := conf.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i)
+			}
+			errs = append(errs, fmt.Errorf("room rule %s: %w", name, err))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, errors.Join(errs...)
+}
+
+func newRoomRule(conf config.RoomRuleConfig) (*RoomRule, error) {
+	event, ok := roomRuleEvents[conf.Event]
+	if conf.Event != "" && !ok {
+		return nil, fmt.Errorf("unknown event %q", conf.Event)
+	}
+	if conf.Event != "" && conf.For > 0 {
+		return nil, errors.New("for only applies to rules without an event")
+	}
+
+	vars := map[string]bool{}
+	for _, v := range roomRuleRoomVars {
+		vars[v] = true
+	}
+	if event.participant {
+		for _, v := range roomRuleParticipantVars {
+			vars[v] = true
+		}
+	}
+	if event.track {
+		for _, v := range roomRuleTrackVars {
+			vars[v] = true
+		}
+	}
+
+	rule := &RoomRule{
+		Name: conf.Name,
+		conf: conf,
+	}
+	if strings.TrimSpace(conf.When) != "" {
+		when, err := parseRuleExpr(conf.When)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition: %w", err)
+		}
+		var unknown []string
+		when.identifiers(func(name string) {
+			if !vars[name] {
+				unknown = append(unknown, name)
+			}
+		})
+		if len(unknown) > 0 {
+			return nil, fmt.Errorf("condition uses %s, not set on %s", strings.Join(unknown, ", "), describeRoomRuleEvent(conf.Event))
+		}
+		rule.when = when
+	}
+
+	action := conf.Action
+	switch action.Type {
+	case RoomRuleActionCloseRoom, RoomRuleActionWebhook:
+	case RoomRuleActionMuteTrack:
+		if conf.Event != webhook.EventTrackPublished {
+			return nil, fmt.Errorf("%s only applies to %s events", action.Type, webhook.EventTrackPublished)
+		}
+	case RoomRuleActionSendData:
+		if action.ToParticipant && !event.participant {
+			return nil, fmt.Errorf("to_participant is not set on %s", describeRoomRuleEvent(conf.Event))
+		}
+	case RoomRuleActionStartEgress:
+		if action.Filepath == "" {
+			return nil, fmt.Errorf("%s needs a filepath", action.Type)
+		}
+	default:
+		return nil, fmt.Errorf("unknown action %q", action.Type)
+	}
+	return rule, nil
+}
+
+func describeRoomRuleEvent(event string) string {
+	if event == "" {
+		return "rules without an event"
+	}
+	return event + " events"
+}
+
+// ---------------------------------------------
+
+type roomRuleEvent struct {
+	name        string
+	participant types.LocalParticipant
+	track       types.MediaTrack
+}
+
+type roomRuleState struct {
+	rule *RoomRule
+	// rules without an event, the action ran since the condition started to hold
+	fired bool
+	// rules without an event, the condition holds and the action runs when the timer fires
+	timer *sutils.TrackedTimer
+	// started timers, a timer firing after it was stopped is ignored
+	generation uint64
+}
+
+// SetRules replaces the rules evaluated on the events of the room. Rules without an event are evaluated
+// right away, their duration starts when the rules are set.
+func (r *Room) SetRules(rules []*RoomRule) {
+	r.rulesLock.Lock()
+	r.stopRuleTimersLocked()
+	r.rules = make([]*roomRuleState, 0, len(rules))
+	for _, rule := range rules {
+		r.rules = append(r.rules, &roomRuleState{rule: rule})
+	}
+	r.rulesLock.Unlock()
+
+	r.evaluateRules(roomRuleEvent{})
+}
+
+func (r *Room) stopRules() {
+	r.rulesLock.Lock()
+	defer r.rulesLock.Unlock()
+
+	r.stopRuleTimersLocked()
+	r.rules = nil
+}
+
+func (r *Room) stopRuleTimersLocked() {
+	for _, s := range r.rules {
+		if s.timer != nil {
+			s.timer.Stop()
+			s.timer = nil
+		}
+	}
+}
+
+func (r *Room) onRuleEvent(name string, participant types.LocalParticipant, track types.MediaTrack) {
+	r.evaluateRules(roomRuleEvent{name: name, participant: participant, track: track})
+}
+
+func (r *Room) evaluateRules(event roomRuleEvent) {
+	if r.IsClosed() {
+		return
+	}
+
+	r.rulesLock.Lock()
+	defer r.rulesLock.Unlock()
+	if len(r.rules) == 0 {
+		return
+	}
+
+	vars := r.ruleVars(event)
+	for _, s := range r.rules {
+		rule := s.rule
+		if rule.conf.Event != "" {
+			if rule.conf.Event == event.name && r.checkRule(rule, vars) {
+				r.runRuleAction(rule, event)
+			}
+			continue
+		}
+
+		if !r.checkRule(rule, vars) {
+			if s.timer != nil {
+				s.timer.Stop()
+				s.timer = nil
+			}
+			s.fired = false
+			continue
+		}
+		if s.fired || s.timer != nil {
+			continue
+		}
+		if rule.conf.For <= 0 {
+			s.fired = true
+			r.runRuleAction(rule, roomRuleEvent{})
+			continue
+		}
+
+		s.generation++
+		generation := s.generation
+		s.timer = r.resources.AfterFunc("room.ruleTimer", rule.conf.For, func() {
+			r.onRuleTimer(s, generation)
+		})
+	}
+}
+
+func (r *Room) onRuleTimer(s *roomRuleState, generation uint64) {
+	if r.IsClosed() {
+		return
+	}
+
+	r.rulesLock.Lock()
+	defer r.rulesLock.Unlock()
+	if s.timer == nil || s.generation != generation {
+		// condition stopped holding or rules were replaced
+		return
+	}
+	s.timer = nil
+
+	// checked again, the room can change without an event, e.g. when a participant is granted room admin
+	if r.checkRule(s.rule, r.ruleVars(roomRuleEvent{})) {
+		s.fired = true
+		r.runRuleAction(s.rule, roomRuleEvent{})
+	}
+}
+
+func (r *Room) checkRule(rule *RoomRule, vars ruleVars) bool {
+	if rule.when == nil {
+		return true
+	}
+	ok, err := evalRuleCondition(rule.when, vars)
+	if err != nil {
+		r.Logger.Warnw("could not evaluate room rule", err, "rule", rule.Name)
+		return false
+	}
+	return ok
+}
+
+func (r *Room) ruleVars(event roomRuleEvent) ruleVars {
+	var participants, hosts, publishers int64
+	for _, p := range r.GetParticipants() {
+		if p.Hidden() || p.IsRecorder() {
+			continue
+		}
+		participants++
+		if isHost(p) {
+			hosts++
+		}
+		if len(p.GetPublishedTracks()) > 0 {
+			publishers++
+		}
+	}
+
+	r.lock.RLock()
+	recording := r.protoRoom.ActiveRecording
+	r.lock.RUnlock()
+
+	vars := ruleVars{
+		"room.name":         string(r.Name()),
+		"room.participants": participants,
+		"room.hosts":        hosts,
+		"room.publishers":   publishers,
+		"room.recording":    recording,
+	}
+	if p := event.participant; p != nil {
+		vars["participant.identity"] = string(p.Identity())
+		vars["participant.name"] = ""
+		vars["participant.kind"] = ""
+		if grants := p.ClaimGrants(); grants != nil {
+			vars["participant.name"] = grants.Name
+			vars["participant.kind"] = grants.Kind
+		}
+		vars["participant.host"] = isHost(p)
+		vars["participant.hidden"] = p.Hidden()
+	}
+	if t := event.track; t != nil {
+		ti := t.ToProto()
+		vars["track.sid"] = ti.Sid
+		vars["track.name"] = ti.Name
+		vars["track.type"] = ti.Type.String()
+		vars["track.source"] = ti.Source.String()
+		vars["track.mime"] = ti.MimeType
+		vars["track.muted"] = ti.Muted
+	}
+	return vars
+}
+
+// runRuleAction runs the action in its own goroutine, actions like closing the room trigger events of their own
+func (r *Room) runRuleAction(rule *RoomRule, event roomRuleEvent) {
+	fields := []interface{}{"rule", rule.Name, "action", rule.conf.Action.Type, "event", event.name}
+	if event.participant != nil {
+		fields = append(fields, "participant", event.participant.Identity())
+	}
+	if event.track != nil {
+		fields = append(fields, "trackID", event.track.ID())
+	}
+	r.Logger.Infow("room rule triggered", fields...)
+
+	r.resources.Go("room.ruleAction", func() {
+		action := rule.conf.Action
+		switch action.Type {
+		case RoomRuleActionCloseRoom:
+			r.Close(types.ParticipantCloseReasonServiceRequestDeleteRoom)
+
+		case RoomRuleActionMuteTrack:
+			event.participant.SetTrackMuted(event.track.ID(), true, true)
+
+		case RoomRuleActionSendData:
+			up := &livekit.UserPacket{
+				Payload: []byte(action.Payload),
+			}
+			if action.Topic != "" {
+				up.Topic = &action.Topic
+			}
+			if action.ToParticipant {
+				up.DestinationIdentities = []string{string(event.participant.Identity())}
+			}
+			r.SendDataPacket(up, livekit.DataPacket_RELIABLE)
+
+		case RoomRuleActionStartEgress:
+			if r.egressLauncher == nil {
+				r.Logger.Warnw("could not start egress of room rule", errors.New("egress launcher not found"), "rule", rule.Name)
+				return
+			}
+			_, err := r.egressLauncher.StartEgress(context.Background(), &rpc.StartEgressRequest{
+				Request: &rpc.StartEgressRequest_RoomComposite{
+					RoomComposite: &livekit.RoomCompositeEgressRequest{
+						RoomName: string(r.Name()),
+						Layout:   action.Layout,
+						FileOutputs: []*livekit.EncodedFileOutput{
+							{Filepath: action.Filepath},
+						},
+					},
+				},
+				RoomId: string(r.ID()),
+			})
+			if err != nil {
+				r.Logger.Errorw("could not start egress of room rule", err, "rule", rule.Name)
+			}
+
+		case RoomRuleActionWebhook:
+			ev := &livekit.WebhookEvent{
+				Event: action.WebhookEvent,
+				Room:  r.ToProto(),
+			}
+			if ev.Event == "" {
+				ev.Event = telemetry.EventRoomRuleTriggered
+			}
+			if event.participant != nil {
+				ev.Participant = event.participant.ToProto()
+			}
+			if event.track != nil {
+				ev.Track = event.track.ToProto()
+			}
+			r.telemetry.NotifyEvent(context.Background(), ev)
+		}
+	})
+}
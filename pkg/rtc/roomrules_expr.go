@@ -0,0 +1,380 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ruleExpr is a condition of a room rule. The syntax is a subset of CEL: identifiers, string, integer and
+// boolean literals, comparisons with == != < <= > >=, and ! && || with parentheses, e.g.
+//
+//	room.hosts == 0 && (track.source == "SCREEN_SHARE" || !participant.host)
+type ruleExpr interface {
+	eval(vars ruleVars) (any, error)
+	identifiers(add func(string))
+}
+
+// ruleVars holds the values of the identifiers, strings, int64 or bools
+type ruleVars map[string]any
+
+func parseRuleExpr(src string) (ruleExpr, error) {
+	tokens, err := tokenizeRuleExpr(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ruleExprParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+// evalRuleCondition returns the value of expr, which has to be a boolean
+func evalRuleCondition(expr ruleExpr, vars ruleVars) (bool, error) {
+	v, err := expr.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition is a %T, not a bool", v)
+	}
+	return b, nil
+}
+
+// ---------------------------------------------
+
+type ruleTokenKind int
+
+const (
+	ruleTokenIdent ruleTokenKind = iota
+	ruleTokenString
+	ruleTokenInt
+	ruleTokenOp
+)
+
+type ruleToken struct {
+	kind ruleTokenKind
+	text string
+}
+
+var ruleExprOps = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")"}
+
+func tokenizeRuleExpr(src string) ([]ruleToken, error) {
+	var tokens []ruleToken
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(src[i+1:], src[i])
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, ruleToken{kind: ruleTokenString, text: src[i+1 : i+1+end]})
+			i += end + 2
+
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+				i++
+			}
+			tokens = append(tokens, ruleToken{kind: ruleTokenInt, text: src[start:i]})
+
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, ruleToken{kind: ruleTokenIdent, text: src[start:i]})
+
+		default:
+			op := ""
+			for _, o := range ruleExprOps {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			tokens = append(tokens, ruleToken{kind: ruleTokenOp, text: op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+type ruleExprParser struct {
+	tokens []ruleToken
+	pos    int
+}
+
+func (p *ruleExprParser) peekOp(ops ...string) string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != ruleTokenOp {
+		return ""
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *ruleExprParser) parseOr() (ruleExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") != "" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &ruleLogical{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *ruleExprParser) parseAnd() (ruleExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") != "" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &ruleLogical{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *ruleExprParser) parseUnary() (ruleExpr, error) {
+	if p.peekOp("!") != "" {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &ruleNot{x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *ruleExprParser) parseComparison() (ruleExpr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if op := p.peekOp("==", "!=", "<=", ">=", "<", ">"); op != "" {
+		p.pos++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &ruleComparison{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *ruleExprParser) parsePrimary() (ruleExpr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	t := p.tokens[p.pos]
+	p.pos++
+	switch t.kind {
+	case ruleTokenString:
+		return &ruleLiteral{value: t.text}, nil
+
+	case ruleTokenInt:
+		v, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &ruleLiteral{value: v}, nil
+
+	case ruleTokenIdent:
+		switch t.text {
+		case "true":
+			return &ruleLiteral{value: true}, nil
+		case "false":
+			return &ruleLiteral{value: false}, nil
+		}
+		return &ruleIdent{name: t.text}, nil
+
+	default:
+		if t.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if p.peekOp(")") == "" {
+				return nil, fmt.Errorf("missing )")
+			}
+			p.pos++
+			return x, nil
+		}
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	}
+}
+
+// ---------------------------------------------
+
+type ruleLiteral struct {
+	value any
+}
+
+func (l *ruleLiteral) eval(_ ruleVars) (any, error) {
+	return l.value, nil
+}
+
+func (l *ruleLiteral) identifiers(_ func(string)) {}
+
+type ruleIdent struct {
+	name string
+}
+
+func (i *ruleIdent) eval(vars ruleVars) (any, error) {
+	v, ok := vars[i.name]
+	if !ok {
+		return nil, fmt.Errorf("%s is not set", i.name)
+	}
+	return v, nil
+}
+
+func (i *ruleIdent) identifiers(add func(string)) {
+	add(i.name)
+}
+
+type ruleNot struct {
+	x ruleExpr
+}
+
+func (n *ruleNot) eval(vars ruleVars) (any, error) {
+	b, err := evalRuleCondition(n.x, vars)
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+func (n *ruleNot) identifiers(add func(string)) {
+	n.x.identifiers(add)
+}
+
+type ruleLogical struct {
+	and         bool
+	left, right ruleExpr
+}
+
+func (l *ruleLogical) eval(vars ruleVars) (any, error) {
+	left, err := evalRuleCondition(l.left, vars)
+	if err != nil {
+		return nil, err
+	}
+	if left != l.and {
+		// short circuit, false for && and true for ||
+		return left, nil
+	}
+	return evalRuleCondition(l.right, vars)
+}
+
+func (l *ruleLogical) identifiers(add func(string)) {
+	l.left.identifiers(add)
+	l.right.identifiers(add)
+}
+
+type ruleComparison struct {
+	op          string
+	left, right ruleExpr
+}
+
+func (c *ruleComparison) eval(vars ruleVars) (any, error) {
+	left, err := c.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch l := left.(type) {
+	case int64:
+		r, ok := right.(int64)
+		if !ok {
+			break
+		}
+		switch c.op {
+		case "==":
+			return l == r, nil
+		case "!=":
+			return l != r, nil
+		case "<":
+			return l < r, nil
+		case "<=":
+			return l <= r, nil
+		case ">":
+			return l > r, nil
+		case ">=":
+			return l >= r, nil
+		}
+
+	case string:
+		if r, ok := right.(string); ok {
+			return compareEquality(c.op, l == r)
+		}
+
+	case bool:
+		if r, ok := right.(bool); ok {
+			return compareEquality(c.op, l == r)
+		}
+	}
+	return nil, fmt.Errorf("cannot compare a %T with a %T", left, right)
+}
+
+func compareEquality(op string, equal bool) (any, error) {
+	switch op {
+	case "==":
+		return equal, nil
+	case "!=":
+		return !equal, nil
+	}
+	return nil, fmt.Errorf("%s only compares integers", op)
+}
+
+func (c *ruleComparison) identifiers(add func(string)) {
+	c.left.identifiers(add)
+	c.right.identifiers(add)
+}
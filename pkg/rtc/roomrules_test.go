@@ -0,0 +1,186 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestRuleExpr(t *testing.T) {
+	vars := ruleVars{
+		"room.hosts":        int64(0),
+		"room.participants": int64(3),
+		"track.source":      "SCREEN_SHARE",
+		"participant.host":  false,
+	}
+
+	tests := []struct {
+		expr     string
+		expected bool
+		err      bool
+	}{
+		{expr: "room.hosts == 0", expected: true},
+		{expr: "room.participants >= 2 && room.participants < 3", expected: false},
+		{expr: `track.source == "SCREEN_SHARE" && !participant.host`, expected: true},
+		{expr: `track.source != 'CAMERA' || room.unknown == 1`, expected: true},
+		{expr: "!(room.hosts > 0 || participant.host)", expected: true},
+		{expr: "participant.host == false", expected: true},
+		{expr: "room.participants", err: true},
+		{expr: `room.hosts == "0"`, err: true},
+		{expr: `track.source < "A"`, err: true},
+		{expr: "room.unknown == 1", err: true},
+	}
+	for _, test := range tests {
+		expr, err := parseRuleExpr(test.expr)
+		require.NoError(t, err, test.expr)
+		ok, err := evalRuleCondition(expr, vars)
+		if test.err {
+			require.Error(t, err, test.expr)
+			continue
+		}
+		require.NoError(t, err, test.expr)
+		require.Equal(t, test.expected, ok, test.expr)
+	}
+
+	for _, invalid := range []string{"room.hosts ==", "(room.hosts == 0", `track.source == "SCREEN`, "room.hosts = 0", "room.hosts == 0 0"} {
+		_, err := parseRuleExpr(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestNewRoomRules(t *testing.T) {
+	rules, err := NewRoomRules([]config.RoomRuleConfig{
+		{Name: "close", When: "room.hosts == 0", For: time.Minute, Action: config.RoomRuleActionConfig{Type: RoomRuleActionCloseRoom}},
+		{Name: "screen", Event: webhook.EventTrackPublished, When: `track.source == "SCREEN_SHARE"`, Action: config.RoomRuleActionConfig{Type: RoomRuleActionMuteTrack}},
+		{Name: "track vars", Event: webhook.EventParticipantJoined, When: `track.source == "CAMERA"`, Action: config.RoomRuleActionConfig{Type: RoomRuleActionWebhook}},
+		{Name: "mute", When: "room.hosts == 0", Action: config.RoomRuleActionConfig{Type: RoomRuleActionMuteTrack}},
+		{Name: "for", Event: webhook.EventParticipantLeft, For: time.Minute, Action: config.RoomRuleActionConfig{Type: RoomRuleActionCloseRoom}},
+		{Event: "room_started", Action: config.RoomRuleActionConfig{Type: RoomRuleActionCloseRoom}},
+		{Name: "egress", When: "room.participants > 1", Action: config.RoomRuleActionConfig{Type: RoomRuleActionStartEgress}},
+	})
+	require.Len(t, rules, 2)
+	require.Equal(t, "close", rules[0].Name)
+	require.Equal(t, "screen", rules[1].Name)
+	require.ErrorContains(t, err, "room rule track vars: condition uses track.source")
+	require.ErrorContains(t, err, "room rule mute:")
+	require.ErrorContains(t, err, "room rule for:")
+	require.ErrorContains(t, err, `room rule #5: unknown event "room_started"`)
+	require.ErrorContains(t, err, "room rule egress: start_egress needs a filepath")
+}
+
+func TestRoomRules(t *testing.T) {
+	closeWithoutHost := func(d time.Duration) *RoomRule {
+		rules, err := NewRoomRules([]config.RoomRuleConfig{
+			{Name: "no host", When: "room.participants > 0 && room.hosts == 0", For: d, Action: config.RoomRuleActionConfig{Type: RoomRuleActionCloseRoom}},
+		})
+		require.NoError(t, err)
+		return rules[0]
+	}
+
+	t.Run("room closes once no host has been present for the duration", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		host := rm.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+
+		rm.SetRules([]*RoomRule{closeWithoutHost(50 * time.Millisecond)})
+		time.Sleep(100 * time.Millisecond)
+		require.False(t, rm.IsClosed())
+
+		rm.RemoveParticipant("p0", "", types.ParticipantCloseReasonClientRequestLeave)
+		require.Eventually(t, rm.IsClosed, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("condition is checked again when the duration ends", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+
+		rm.SetRules([]*RoomRule{closeWithoutHost(50 * time.Millisecond)})
+		// granted room admin without an event
+		p0 := rm.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		p0.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+		time.Sleep(100 * time.Millisecond)
+		require.False(t, rm.IsClosed())
+	})
+
+	t.Run("screen shares of participants are muted", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		host := rm.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+		p1 := rm.GetParticipant("p1").(*typesfakes.FakeLocalParticipant)
+
+		rules, err := NewRoomRules([]config.RoomRuleConfig{{
+			Event:  webhook.EventTrackPublished,
+			When:   `track.source == "SCREEN_SHARE" && !participant.host`,
+			Action: config.RoomRuleActionConfig{Type: RoomRuleActionMuteTrack},
+		}})
+		require.NoError(t, err)
+		rm.SetRules(rules)
+
+		newTrack := func(source livekit.TrackSource) *typesfakes.FakeMediaTrack {
+			track := NewMockTrack(livekit.TrackType_VIDEO, "video")
+			track.ToProtoReturns(&livekit.TrackInfo{Sid: string(track.ID()), Type: livekit.TrackType_VIDEO, Source: source})
+			return track
+		}
+		rm.onTrackPublished(host, newTrack(livekit.TrackSource_SCREEN_SHARE))
+		rm.onTrackPublished(p1, newTrack(livekit.TrackSource_CAMERA))
+		screen := newTrack(livekit.TrackSource_SCREEN_SHARE)
+		rm.onTrackPublished(p1, screen)
+
+		require.Eventually(t, func() bool {
+			return p1.SetTrackMutedCallCount() == 1
+		}, time.Second, 10*time.Millisecond)
+		trackID, muted, fromAdmin := p1.SetTrackMutedArgsForCall(0)
+		require.Equal(t, screen.ID(), trackID)
+		require.True(t, muted)
+		require.True(t, fromAdmin)
+		require.Zero(t, host.SetTrackMutedCallCount())
+	})
+
+	t.Run("data is sent to participants that join", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		p0 := rm.GetParticipant("p0").(*typesfakes.FakeLocalParticipant)
+		p1 := rm.GetParticipant("p1").(*typesfakes.FakeLocalParticipant)
+
+		rules, err := NewRoomRules([]config.RoomRuleConfig{{
+			Event:  webhook.EventParticipantJoined,
+			Action: config.RoomRuleActionConfig{Type: RoomRuleActionSendData, Topic: "welcome", Payload: "hello", ToParticipant: true},
+		}})
+		require.NoError(t, err)
+		rm.SetRules(rules)
+
+		rm.onRuleEvent(webhook.EventParticipantJoined, p1, nil)
+		require.Eventually(t, func() bool {
+			return p1.SendDataPacketCallCount() == 1
+		}, time.Second, 10*time.Millisecond)
+		dp, _ := p1.SendDataPacketArgsForCall(0)
+		require.Equal(t, "welcome", dp.GetUser().GetTopic())
+		require.Equal(t, []byte("hello"), dp.GetUser().GetPayload())
+		require.Zero(t, p0.SendDataPacketCallCount())
+	})
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/utils"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
@@ -45,6 +46,8 @@ type SubscribedTrackParams struct {
 	MediaTrack        types.MediaTrack
 	DownTrack         *sfu.DownTrack
 	AdaptiveStream    bool
+	// settings the subscription starts with until the subscriber sends its own, nil for the defaults
+	SubscribeDefaults *config.SubscribeDefaults
 }
 
 type SubscribedTrack struct {
@@ -62,7 +65,8 @@ type SubscribedTrack struct {
 	bound           bool
 	onBindCallbacks []func(error)
 
-	onClose atomic.Value // func(bool)
+	onClose                atomic.Value // func(bool)
+	onSubscriberRankChange atomic.Value // func()
 
 	debouncer func(func())
 }
@@ -128,6 +132,20 @@ func (t *SubscribedTrack) Bound(err error) {
 			} else {
 				t.settings = &livekit.UpdateTrackSettings{Quality: livekit.VideoQuality_HIGH}
 			}
+			if defaults := t.params.SubscribeDefaults; defaults != nil {
+				if quality, ok := defaults.VideoQuality(); ok {
+					t.settings.Quality = quality
+				}
+				// disabled would leave an adaptive stream stuck off, as above
+				t.settings.Disabled = defaults.Disabled && !t.params.AdaptiveStream
+			}
+		}
+		t.settingsLock.Unlock()
+		t.applySettings()
+	} else if err == nil && t.params.SubscribeDefaults != nil && t.params.SubscribeDefaults.Disabled {
+		t.settingsLock.Lock()
+		if t.settings == nil {
+			t.settings = &livekit.UpdateTrackSettings{Disabled: true}
 		}
 		t.settingsLock.Unlock()
 		t.applySettings()
@@ -149,6 +167,20 @@ func (t *SubscribedTrack) OnClose(f func(bool)) {
 	t.onClose.Store(f)
 }
 
+// OnSubscriberRankChange sets the callback fired when the subscriber ranks the track differently
+func (t *SubscribedTrack) OnSubscriberRankChange(f func()) {
+	t.onSubscriberRankChange.Store(f)
+}
+
+// SubscriberRank returns the rank the subscriber gave the track among its subscriptions in the priority of its
+// track settings, 1 being its most important track, 0 when not ranked
+func (t *SubscribedTrack) SubscriberRank() uint32 {
+	t.settingsLock.Lock()
+	defer t.settingsLock.Unlock()
+
+	return t.settings.GetPriority()
+}
+
 func (t *SubscribedTrack) IsBound() bool {
 	t.bindLock.Lock()
 	defer t.bindLock.Unlock()
@@ -220,9 +252,16 @@ func (t *SubscribedTrack) UpdateSubscriberSettings(settings *livekit.UpdateTrack
 	}
 
 	isImmediate = isImmediate || (!settings.Disabled && settings.Disabled != t.isMutedLocked())
+	rankChanged := settings.GetPriority() != t.settings.GetPriority()
 	t.settings = proto.Clone(settings).(*livekit.UpdateTrackSettings)
 	t.settingsLock.Unlock()
 
+	if rankChanged {
+		if onSubscriberRankChange, ok := t.onSubscriberRankChange.Load().(func()); ok && onSubscriberRankChange != nil {
+			onSubscriberRankChange()
+		}
+	}
+
 	if isImmediate {
 		t.applySettings()
 	} else {
@@ -0,0 +1,114 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const subscribedTrackShards = 16
+
+// subscribedTrackMap holds the subscribed tracks of a media track keyed by subscriber. It is sharded by subscriber
+// so that subscribing, unsubscribing and looking up subscribers of tracks with thousands of them do not
+// serialize on one lock. Iterations lock one shard at a time and do not see a consistent snapshot of all shards.
+type subscribedTrackMap struct {
+	shards [subscribedTrackShards]subscribedTrackShard
+}
+
+type subscribedTrackShard struct {
+	lock   sync.RWMutex
+	tracks map[livekit.ParticipantID]types.SubscribedTrack
+}
+
+func (m *subscribedTrackMap) shard(subscriberID livekit.ParticipantID) *subscribedTrackShard {
+	// FNV-1a
+	h := uint32(2166136261)
+	for i := 0; i < len(subscriberID); i++ {
+		h ^= uint32(subscriberID[i])
+		h *= 16777619
+	}
+	return &m.shards[h%subscribedTrackShards]
+}
+
+func (m *subscribedTrackMap) Get(subscriberID livekit.ParticipantID) types.SubscribedTrack {
+	s := m.shard(subscriberID)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.tracks[subscriberID]
+}
+
+// Set stores subTrack, replacing the subscribed track the subscriber may have
+func (m *subscribedTrackMap) Set(subTrack types.SubscribedTrack) {
+	s := m.shard(subTrack.SubscriberID())
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.tracks == nil {
+		s.tracks = make(map[livekit.ParticipantID]types.SubscribedTrack)
+	}
+	s.tracks[subTrack.SubscriberID()] = subTrack
+}
+
+// Delete removes and returns the subscribed track of the subscriber
+func (m *subscribedTrackMap) Delete(subscriberID livekit.ParticipantID) types.SubscribedTrack {
+	s := m.shard(subscriberID)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	subTrack := s.tracks[subscriberID]
+	delete(s.tracks, subscriberID)
+	return subTrack
+}
+
+func (m *subscribedTrackMap) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.lock.RLock()
+		n += len(s.tracks)
+		s.lock.RUnlock()
+	}
+	return n
+}
+
+// Range calls f for each subscribed track until it returns false, while holding the read lock of its shard.
+// f must not call back into the map.
+func (m *subscribedTrackMap) Range(f func(subTrack types.SubscribedTrack) bool) {
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.lock.RLock()
+		for _, subTrack := range s.tracks {
+			if !f(subTrack) {
+				s.lock.RUnlock()
+				return
+			}
+		}
+		s.lock.RUnlock()
+	}
+}
+
+func (m *subscribedTrackMap) Values() []types.SubscribedTrack {
+	subTracks := make([]types.SubscribedTrack, 0, m.Len())
+	m.Range(func(subTrack types.SubscribedTrack) bool {
+		subTracks = append(subTracks, subTrack)
+		return true
+	})
+	return subTracks
+}
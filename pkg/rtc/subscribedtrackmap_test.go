@@ -0,0 +1,70 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestSubscribedTrackMap(t *testing.T) {
+	newSubTrack := func(i int) *typesfakes.FakeSubscribedTrack {
+		st := &typesfakes.FakeSubscribedTrack{}
+		st.SubscriberIDReturns(livekit.ParticipantID(fmt.Sprintf("PA_%d", i)))
+		st.SubscriberIdentityReturns(livekit.ParticipantIdentity(fmt.Sprintf("sub%d", i)))
+		return st
+	}
+
+	var m subscribedTrackMap
+	require.Nil(t, m.Get("PA_0"))
+	require.Zero(t, m.Len())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Set(newSubTrack(i))
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 200, m.Len())
+	require.Len(t, m.Values(), 200)
+	require.Equal(t, livekit.ParticipantIdentity("sub42"), m.Get("PA_42").SubscriberIdentity())
+
+	// replaced, not added
+	m.Set(newSubTrack(42))
+	require.Equal(t, 200, m.Len())
+
+	require.NotNil(t, m.Delete("PA_42"))
+	require.Nil(t, m.Delete("PA_42"))
+	require.Nil(t, m.Get("PA_42"))
+	require.Equal(t, 199, m.Len())
+
+	visited := 0
+	m.Range(func(_ types.SubscribedTrack) bool {
+		visited++
+		return visited < 10
+	})
+	require.Equal(t, 10, visited)
+}
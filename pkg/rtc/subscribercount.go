@@ -0,0 +1,132 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// SubscriberCountTopic is the data topic publishers are notified on when the number of subscribers of their tracks changes
+const SubscriberCountTopic = "lk.subscriber-count"
+
+// changes within the interval are coalesced into one notice, so that a crowd joining does not flood the publisher
+const subscriberCountNoticeInterval = 500 * time.Millisecond
+
+// SubscriberCountNotice is sent to a publisher on SubscriberCountTopic with the tracks whose subscriber count changed
+type SubscriberCountNotice struct {
+	Tracks []TrackSubscriberCount `json:"tracks"`
+}
+
+type TrackSubscriberCount struct {
+	TrackID     livekit.TrackID `json:"track_id"`
+	Subscribers int             `json:"subscribers"`
+}
+
+// subscriberCountNotifier collects the subscriber counts of the tracks of a publisher and sends them at most once per interval
+type subscriberCountNotifier struct {
+	interval time.Duration
+	send     func(notice *SubscriberCountNotice)
+
+	lock    sync.Mutex
+	pending map[livekit.TrackID]int
+	timer   *time.Timer
+	closed  bool
+}
+
+func newSubscriberCountNotifier(interval time.Duration, send func(notice *SubscriberCountNotice)) *subscriberCountNotifier {
+	return &subscriberCountNotifier{
+		interval: interval,
+		send:     send,
+		pending:  make(map[livekit.TrackID]int),
+	}
+}
+
+func (n *subscriberCountNotifier) update(trackID livekit.TrackID, count int) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.closed {
+		return
+	}
+	n.pending[trackID] = count
+	if n.timer == nil {
+		n.timer = time.AfterFunc(n.interval, n.flush)
+	}
+}
+
+func (n *subscriberCountNotifier) flush() {
+	n.lock.Lock()
+	n.timer = nil
+	if n.closed || len(n.pending) == 0 {
+		n.lock.Unlock()
+		return
+	}
+	notice := &SubscriberCountNotice{Tracks: make([]TrackSubscriberCount, 0, len(n.pending))}
+	for trackID, count := range n.pending {
+		notice.Tracks = append(notice.Tracks, TrackSubscriberCount{TrackID: trackID, Subscribers: count})
+	}
+	n.pending = make(map[livekit.TrackID]int)
+	n.lock.Unlock()
+
+	sort.Slice(notice.Tracks, func(i, j int) bool {
+		return notice.Tracks[i].TrackID < notice.Tracks[j].TrackID
+	})
+	n.send(notice)
+}
+
+func (n *subscriberCountNotifier) close() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.closed = true
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer = nil
+	}
+}
+
+func (p *ParticipantImpl) sendSubscriberCountNotice(notice *SubscriberCountNotice) {
+	payload, err := json.Marshal(notice)
+	if err != nil {
+		p.pubLogger.Errorw("could not marshal subscriber count notice", err)
+		return
+	}
+
+	topic := SubscriberCountTopic
+	dp := &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload: payload,
+				Topic:   &topic,
+			},
+		},
+	}
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		p.pubLogger.Errorw("could not marshal subscriber count notice", err)
+		return
+	}
+	if err := p.SendDataPacket(dp, data); err != nil {
+		p.pubLogger.Debugw("could not send subscriber count notice", "error", err)
+	}
+}
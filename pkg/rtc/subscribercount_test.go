@@ -0,0 +1,51 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriberCountNotifier(t *testing.T) {
+	notices := make(chan *SubscriberCountNotice, 2)
+	n := newSubscriberCountNotifier(20*time.Millisecond, func(notice *SubscriberCountNotice) {
+		notices <- notice
+	})
+
+	// changes within the interval are sent together, with the last count of each track
+	n.update("TR_mic", 1)
+	n.update("TR_cam", 1)
+	n.update("TR_mic", 2)
+	select {
+	case notice := <-notices:
+		require.Equal(t, []TrackSubscriberCount{
+			{TrackID: "TR_cam", Subscribers: 1},
+			{TrackID: "TR_mic", Subscribers: 2},
+		}, notice.Tracks)
+	case <-time.After(time.Second):
+		t.Fatal("no subscriber count notice")
+	}
+
+	n.update("TR_cam", 0)
+	n.close()
+	select {
+	case <-notices:
+		t.Fatal("subscriber count notice after close")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -330,6 +330,20 @@ func (m *SubscriptionManager) reconcileSubscription(s *trackSubscription) {
 				if s.durationSinceStart() > subscriptionTimeout {
 					s.maybeRecordError(m.params.Telemetry, m.params.Participant.ID(), err, true)
 				}
+			case ErrParticipantClosing:
+				// subscriber is closing, subscriptions are torn down with it
+			case ErrSubscriptionFiltered:
+				// the filter is set by the join token and does not change for the session, give up right away
+				s.logger.Infow("unsubscribing from track excluded by subscribe filter")
+				s.setDesired(false)
+				m.queueReconcile(s.trackID)
+				m.params.OnSubscriptionError(s.trackID, false, err)
+			case ErrCodecBitDepthUnsupported:
+				// the publisher sends video none of the codecs of the track can carry to the subscriber, give up right away
+				s.logger.Infow("unsubscribing from track with unsupported video bit depth")
+				s.setDesired(false)
+				m.queueReconcile(s.trackID)
+				m.params.OnSubscriptionError(s.trackID, false, err)
 			case ErrTrackNotFound:
 				// source track was never published or closed
 				// if after timeout we'd unsubscribe from it.
@@ -502,8 +516,17 @@ func (m *SubscriptionManager) subscribe(s *trackSubscription) error {
 	if !res.HasPermission {
 		return ErrNoTrackPermission
 	}
+	if !m.params.Participant.GetSubscribeFilter().Allows(res.PublisherIdentity, track.ToProto()) {
+		return ErrSubscriptionFiltered
+	}
 
+	// the subscriber must not be torn down while the down track is being set up
+	done, ok := m.params.Participant.EnterOperation()
+	if !ok {
+		return ErrParticipantClosing
+	}
 	subTrack, err := track.AddSubscriber(m.params.Participant)
+	done()
 	if err != nil && !errors.Is(err, errAlreadySubscribed) {
 		// ignore error(s): already subscribed
 		if !errors.Is(err, ErrTrackNotAttached) && !errors.Is(err, ErrNoReceiver) {
@@ -527,6 +550,9 @@ func (m *SubscriptionManager) subscribe(s *trackSubscription) error {
 		subTrack.AddOnBind(func(err error) {
 			if err != nil {
 				s.logger.Infow("failed to bind track", "err", err)
+				if m.subscribeToTranscodedTrack(s, track, err) {
+					return
+				}
 				s.maybeRecordError(m.params.Telemetry, m.params.Participant.ID(), err, true)
 				m.UnsubscribeFromTrack(trackID)
 				m.params.OnSubscriptionError(trackID, false, err)
@@ -613,6 +639,23 @@ func (m *SubscriptionManager) handleSourceTrackRemoved(trackID livekit.TrackID)
 // - subscriber-initiated unsubscribe
 // - UpTrack was closed
 // - publisher revoked permissions for the participant
+// subscribeToTranscodedTrack has the track transcoded into a codec the subscriber decodes when it supports none of
+// the published codecs. The subscription is torn down and set up again with the transcoded receiver, once.
+func (m *SubscriptionManager) subscribeToTranscodedTrack(s *trackSubscription, track types.MediaTrack, err error) bool {
+	var codecErr *sfu.UnsupportedCodecError
+	if !errors.As(err, &codecErr) || s.transcodeRequested.Swap(true) {
+		return false
+	}
+	if !track.TranscodeFallback(codecErr.Codecs) {
+		return false
+	}
+
+	s.logger.Infow("subscribing to transcoded track")
+	// closing the subscribed track queues a reconcile, which subscribes again
+	track.RemoveSubscriber(m.params.Participant.ID(), false)
+	return true
+}
+
 func (m *SubscriptionManager) handleSubscribedTrackClose(s *trackSubscription, willBeResumed bool) {
 	s.logger.Debugw(
 		"subscribed track closed",
@@ -730,6 +773,9 @@ type trackSubscription struct {
 	bound             bool
 	kind              atomic.Pointer[livekit.TrackType]
 
+	// a transcoded receiver was requested after the subscriber could not decode the track
+	transcodeRequested atomic.Bool
+
 	// the later of when subscription was requested OR when the first failure was encountered OR when permission is granted
 	// this timestamp determines when failures are reported
 	subStartedAt atomic.Pointer[time.Time]
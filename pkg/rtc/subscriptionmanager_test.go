@@ -21,11 +21,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 
+	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
 	"github.com/livekit/livekit-server/pkg/utils"
 	"github.com/livekit/protocol/livekit"
@@ -117,6 +120,41 @@ func TestSubscribe(t *testing.T) {
 		require.Equal(t, int32(1), numParticipantUnsubscribed.Load())
 	})
 
+	t.Run("subscribes again to a transcoded track", func(t *testing.T) {
+		sm := newTestSubscriptionManager(t)
+		defer sm.Close(false)
+		resolver := newTestResolver(true, true, "pub", "pubID")
+		resolver.transcodeFallback = true
+		sm.params.TrackResolver = resolver.Resolve
+		failed := atomic.Bool{}
+		sm.params.OnSubscriptionError = func(trackID livekit.TrackID, fatal bool, err error) {
+			failed.Store(true)
+		}
+
+		sm.SubscribeToTrack("track")
+		s := sm.subscriptions["track"]
+		require.Eventually(t, func() bool {
+			return s.getSubscribedTrack() != nil
+		}, subSettleTimeout, subCheckInterval, "track was not subscribed")
+
+		st := s.getSubscribedTrack().(*typesfakes.FakeSubscribedTrack)
+		mt := st.MediaTrack().(*typesfakes.FakeMediaTrack)
+		codecErr := &sfu.UnsupportedCodecError{Codecs: []webrtc.RTPCodecParameters{
+			{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264}},
+		}}
+		st.AddOnBindArgsForCall(0)(codecErr)
+		require.Equal(t, 1, mt.TranscodeFallbackCallCount())
+		require.Equal(t, codecErr.Codecs, mt.TranscodeFallbackArgsForCall(0))
+		require.Equal(t, 1, mt.RemoveSubscriberCallCount())
+		require.True(t, s.isDesired())
+		require.False(t, failed.Load())
+
+		// transcoding is requested once per subscription
+		st.AddOnBindArgsForCall(0)(codecErr)
+		require.Equal(t, 1, mt.TranscodeFallbackCallCount())
+		require.True(t, failed.Load())
+	})
+
 	t.Run("no track permission", func(t *testing.T) {
 		sm := newTestSubscriptionManager(t)
 		defer sm.Close(false)
@@ -158,6 +196,31 @@ func TestSubscribe(t *testing.T) {
 		require.Len(t, sm.GetSubscribedTracks(), 1)
 	})
 
+	t.Run("excluded by subscribe filter", func(t *testing.T) {
+		sm := newTestSubscriptionManager(t)
+		defer sm.Close(false)
+		sm.params.Participant.(*typesfakes.FakeLocalParticipant).GetSubscribeFilterReturns(&routing.SubscribeFilter{
+			Identities: []string{"host-*"},
+		})
+		resolver := newTestResolver(true, true, "viewer", "viewerID")
+		sm.params.TrackResolver = resolver.Resolve
+		var subErr atomic.Error
+		sm.params.OnSubscriptionError = func(trackID livekit.TrackID, fatal bool, err error) {
+			subErr.Store(err)
+		}
+
+		sm.SubscribeToTrack("track")
+		s := sm.subscriptions["track"]
+		// gives up without waiting for the subscription timeout
+		require.Eventually(t, func() bool {
+			return subErr.Load() == ErrSubscriptionFiltered
+		}, subSettleTimeout, subCheckInterval, "subscription error should be reported")
+		require.Eventually(t, func() bool {
+			return !s.isDesired()
+		}, subSettleTimeout, subCheckInterval, "should not be desired")
+		require.Len(t, sm.GetSubscribedTracks(), 0)
+	})
+
 	t.Run("publisher left", func(t *testing.T) {
 		sm := newTestSubscriptionManager(t)
 		defer sm.Close(false)
@@ -461,6 +524,7 @@ func newTestSubscriptionManagerWithParams(t *testing.T, params testSubscriptionP
 	p.CanSubscribeReturns(true)
 	p.IDReturns("subID")
 	p.IdentityReturns("sub")
+	p.EnterOperationReturns(func() {}, true)
 	return NewSubscriptionManager(SubscriptionManagerParams{
 		Participant:         p,
 		Logger:              logger.GetLogger(),
@@ -483,7 +547,8 @@ type testResolver struct {
 	pubIdentity   livekit.ParticipantIdentity
 	pubID         livekit.ParticipantID
 
-	paused bool
+	paused            bool
+	transcodeFallback bool
 }
 
 func newTestResolver(hasPermission bool, hasTrack bool, pubIdentity livekit.ParticipantIdentity, pubID livekit.ParticipantID) *testResolver {
@@ -518,6 +583,7 @@ func (t *testResolver) Resolve(identity livekit.ParticipantIdentity, trackID liv
 		st.PublisherIDReturns(t.pubID)
 		st.PublisherIdentityReturns(t.pubIdentity)
 		mt.AddSubscriberReturns(st, nil)
+		mt.TranscodeFallbackReturns(t.transcodeFallback)
 		st.MediaTrackReturns(mt)
 		res.Track = mt
 	}
@@ -0,0 +1,161 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+type trackStatsKey struct {
+	trackID   livekit.TrackID
+	direction types.TrackStatsDirection
+}
+
+// trackStatsRing holds the snapshots of a track, the oldest ones are overwritten once it is full
+type trackStatsRing struct {
+	samples []types.TrackStatsSample
+	head    int
+}
+
+func (r *trackStatsRing) add(sample types.TrackStatsSample) {
+	if len(r.samples) < cap(r.samples) {
+		r.samples = append(r.samples, sample)
+		return
+	}
+	r.samples[r.head] = sample
+	r.head = (r.head + 1) % len(r.samples)
+}
+
+func (r *trackStatsRing) last() types.TrackStatsSample {
+	return r.samples[(r.head+len(r.samples)-1)%len(r.samples)]
+}
+
+// since returns the samples taken after the given time, oldest first
+func (r *trackStatsRing) since(since time.Time) []*types.TrackStatsSample {
+	samples := make([]*types.TrackStatsSample, 0, len(r.samples))
+	for i := range r.samples {
+		sample := r.samples[(r.head+i)%len(r.samples)]
+		if sample.At.After(since) {
+			samples = append(samples, &sample)
+		}
+	}
+	return samples
+}
+
+// trackStatsHistory keeps snapshots of the RTP stats of the tracks published and subscribed by a participant
+// over the retention, so that they can be looked at after an incident. Snapshots are taken with the
+// connection quality updates, which already read the stats of every track.
+type trackStatsHistory struct {
+	retention      time.Duration
+	sampleInterval time.Duration
+	size           int
+
+	lock         sync.Mutex
+	lastSampleAt time.Time
+	tracks       map[trackStatsKey]*trackStatsRing
+}
+
+func newTrackStatsHistory(conf config.TrackStatsHistoryConfig) *trackStatsHistory {
+	if !conf.Enabled || conf.Retention <= 0 || conf.SampleInterval <= 0 {
+		return nil
+	}
+
+	return &trackStatsHistory{
+		retention:      conf.Retention,
+		sampleInterval: conf.SampleInterval,
+		size:           int((conf.Retention + conf.SampleInterval - 1) / conf.SampleInterval),
+		tracks:         make(map[trackStatsKey]*trackStatsRing),
+	}
+}
+
+// add records the stats of the tracks when a sample is due, and drops the tracks that have had
+// no stats for longer than the retention
+func (h *trackStatsHistory) add(uplink, downlink map[livekit.TrackID]*livekit.RTPStats, at time.Time) {
+	if h == nil {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if at.Sub(h.lastSampleAt) < h.sampleInterval {
+		return
+	}
+	h.lastSampleAt = at
+
+	h.addLocked(types.TrackStatsDirectionUplink, uplink, at)
+	h.addLocked(types.TrackStatsDirectionDownlink, downlink, at)
+
+	for key, ring := range h.tracks {
+		if at.Sub(ring.last().At) > h.retention {
+			delete(h.tracks, key)
+		}
+	}
+}
+
+func (h *trackStatsHistory) addLocked(direction types.TrackStatsDirection, stats map[livekit.TrackID]*livekit.RTPStats, at time.Time) {
+	for trackID, s := range stats {
+		key := trackStatsKey{trackID: trackID, direction: direction}
+		ring := h.tracks[key]
+		if ring == nil {
+			ring = &trackStatsRing{samples: make([]types.TrackStatsSample, 0, h.size)}
+			h.tracks[key] = ring
+		}
+		ring.add(types.TrackStatsSample{At: at, Stats: s})
+	}
+}
+
+// get returns the samples of the last window, all the retained ones when window is 0, of one track or of
+// all the tracks when trackID is empty. Tracks are ordered by direction and id.
+func (h *trackStatsHistory) get(trackID livekit.TrackID, window time.Duration, now time.Time) []*types.TrackStatsSeries {
+	if h == nil {
+		return nil
+	}
+	if window <= 0 || window > h.retention {
+		window = h.retention
+	}
+	since := now.Add(-window)
+
+	h.lock.Lock()
+	series := make([]*types.TrackStatsSeries, 0, len(h.tracks))
+	for key, ring := range h.tracks {
+		if trackID != "" && key.trackID != trackID {
+			continue
+		}
+		if samples := ring.since(since); len(samples) != 0 {
+			series = append(series, &types.TrackStatsSeries{
+				TrackID:   key.trackID,
+				Direction: key.direction,
+				Samples:   samples,
+			})
+		}
+	}
+	h.lock.Unlock()
+
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Direction != series[j].Direction {
+			return series[i].Direction > series[j].Direction // uplink first
+		}
+		return series[i].TrackID < series[j].TrackID
+	})
+	return series
+}
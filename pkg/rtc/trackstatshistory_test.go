@@ -0,0 +1,73 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestTrackStatsHistory(t *testing.T) {
+	require.Nil(t, newTrackStatsHistory(config.TrackStatsHistoryConfig{Retention: time.Minute, SampleInterval: 10 * time.Second}))
+
+	h := newTrackStatsHistory(config.TrackStatsHistoryConfig{
+		Enabled:        true,
+		Retention:      time.Minute,
+		SampleInterval: 10 * time.Second,
+	})
+	start := time.Now()
+	stats := func(packets uint32) map[livekit.TrackID]*livekit.RTPStats {
+		return map[livekit.TrackID]*livekit.RTPStats{"TR_mic": {Packets: packets}}
+	}
+
+	// updates within the sample interval are skipped
+	h.add(stats(1), stats(1), start)
+	h.add(stats(2), nil, start.Add(5*time.Second))
+	series := h.get("", 0, start.Add(5*time.Second))
+	require.Len(t, series, 2)
+	require.Equal(t, types.TrackStatsDirectionUplink, series[0].Direction)
+	require.Equal(t, types.TrackStatsDirectionDownlink, series[1].Direction)
+	require.Len(t, series[0].Samples, 1)
+
+	// the ring keeps the samples of the retention
+	for i := 1; i <= 10; i++ {
+		h.add(stats(uint32(i*10)), nil, start.Add(time.Duration(i)*10*time.Second))
+	}
+	now := start.Add(100 * time.Second)
+	series = h.get("TR_mic", 0, now)
+	require.Len(t, series, 1)
+	require.Len(t, series[0].Samples, 6)
+	require.Equal(t, uint32(50), series[0].Samples[0].Stats.Packets)
+	require.Equal(t, uint32(100), series[0].Samples[5].Stats.Packets)
+
+	// samples of the last window, oldest first
+	series = h.get("TR_mic", 25*time.Second, now)
+	require.Len(t, series[0].Samples, 3)
+	require.Equal(t, uint32(80), series[0].Samples[0].Stats.Packets)
+
+	require.Empty(t, h.get("TR_camera", 0, now))
+
+	// tracks without stats over the retention are dropped, the downlink one stopped after the first sample
+	require.Len(t, h.get("", 0, now), 1)
+	h.add(nil, nil, start.Add(200*time.Second))
+	require.Empty(t, h.tracks)
+}
@@ -17,6 +17,7 @@ package rtc
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -26,7 +27,6 @@ import (
 	"github.com/pion/dtls/v2/pkg/crypto/elliptic"
 	"github.com/pion/interceptor"
 	"github.com/pion/interceptor/pkg/cc"
-	"github.com/pion/interceptor/pkg/gcc"
 	"github.com/pion/interceptor/pkg/twcc"
 	"github.com/pion/rtcp"
 	"github.com/pion/sctp"
@@ -38,11 +38,13 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/rtc/transport"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/bwe"
 	lkinterceptor "github.com/livekit/livekit-server/pkg/sfu/interceptor"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
 	"github.com/livekit/livekit-server/pkg/sfu/rtpextension"
 	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 	sfuutils "github.com/livekit/livekit-server/pkg/sfu/utils"
+	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	sutils "github.com/livekit/livekit-server/pkg/utils"
 	lktwcc "github.com/livekit/mediatransportutil/pkg/twcc"
@@ -74,6 +76,10 @@ const (
 	maxICECandidates = 20
 
 	shortConnectionThreshold = 90 * time.Second
+
+	defaultInitialBitrate = 1 * 1000 * 1000
+	minBandwidthHint      = 100 * 1000
+	maxBandwidthHint      = 20 * 1000 * 1000
 )
 
 var (
@@ -151,6 +157,7 @@ type PCTransport struct {
 	reliableDCOpened bool
 	lossyDC          *webrtc.DataChannel
 	lossyDCOpened    bool
+	lossyDataQueue   *lossyDataQueue
 
 	iceStartedAt               time.Time
 	iceConnectedAt             time.Time
@@ -158,6 +165,7 @@ type PCTransport struct {
 	connectedAt                time.Time
 	tcpICETimer                *time.Timer
 	connectAfterICETimer       *time.Timer // timer to wait for pc to connect after ice connected
+	iceFailureReason           string
 	resetShortConnOnICERestart atomic.Bool
 	signalingRTT               atomic.Uint32 // milliseconds
 
@@ -207,6 +215,7 @@ type TransportParams struct {
 	DirectionConfig              DirectionConfig
 	CongestionControlConfig      config.CongestionControlConfig
 	EnabledCodecs                []*livekit.Codec
+	FmtpOverrides                []*livekit.Codec
 	Logger                       logger.Logger
 	Transport                    livekit.SignalTarget
 	SimTracks                    map[uint32]SimulcastTrackInfo
@@ -215,6 +224,24 @@ type TransportParams struct {
 	IsSendSide                   bool
 	AllowPlayoutDelay            bool
 	DataChannelMaxBufferedAmount uint64
+	LossyData                    config.LossyDataConfig
+	BandwidthHint                int64
+	ICECandidatePolicy           config.ICECandidatePolicy
+}
+
+// initialBitrate returns the bitrate the bandwidth estimator starts from, the client hint clamped to a sane range
+// so that a bad hint cannot starve or flood the subscriber before the first estimate.
+func initialBitrate(bandwidthHint int64) int64 {
+	switch {
+	case bandwidthHint <= 0:
+		return defaultInitialBitrate
+	case bandwidthHint < minBandwidthHint:
+		return minBandwidthHint
+	case bandwidthHint > maxBandwidthHint:
+		return maxBandwidthHint
+	default:
+		return bandwidthHint
+	}
 }
 
 func newPeerConnection(params TransportParams, onBandwidthEstimator func(estimator cc.BandwidthEstimator)) (*webrtc.PeerConnection, *webrtc.MediaEngine, error) {
@@ -227,7 +254,7 @@ func newPeerConnection(params TransportParams, onBandwidthEstimator func(estimat
 	// Some of the browser clients do not handle H.264 High Profile in signalling properly.
 	// They still decode if the actual stream is H.264 High Profile, but do not handle it well in signalling.
 	// So, disable H.264 High Profile for SUBSCRIBER peer connection to ensure it is not offered.
-	me, err := createMediaEngine(params.EnabledCodecs, directionConfig, params.IsOfferer)
+	me, err := createMediaEngine(params.EnabledCodecs, params.FmtpOverrides, directionConfig, params.IsOfferer, params.Config.LenientH264ProfileMatching)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -303,10 +330,9 @@ func newPeerConnection(params TransportParams, onBandwidthEstimator func(estimat
 	if params.IsSendSide {
 		if params.CongestionControlConfig.UseSendSideBWE {
 			gf, err := cc.NewInterceptor(func() (cc.BandwidthEstimator, error) {
-				return gcc.NewSendSideBWE(
-					gcc.SendSideBWEInitialBitrate(1*1000*1000),
-					gcc.SendSideBWEPacer(gcc.NewNoOpPacer()),
-				)
+				return bwe.NewSendSideBWE(params.CongestionControlConfig.SendSideBWEAlgorithm, bwe.Params{
+					InitialBitrate: int(initialBitrate(params.BandwidthHint)),
+				})
 			})
 			if err == nil {
 				gf.OnNewPeerConnection(func(id string, estimator cc.BandwidthEstimator) {
@@ -388,11 +414,17 @@ func NewPCTransport(params TransportParams) (*PCTransport, error) {
 		connectionDetails:        types.NewICEConnectionDetails(params.Transport, params.Logger),
 	}
 	if params.IsSendSide {
+		var initialChannelCapacity int64
+		if params.BandwidthHint > 0 {
+			initialChannelCapacity = initialBitrate(params.BandwidthHint)
+		}
 		t.streamAllocator = streamallocator.NewStreamAllocator(streamallocator.StreamAllocatorParams{
-			Config: params.CongestionControlConfig,
-			Logger: params.Logger.WithComponent(sutils.ComponentCongestionControl),
+			Config:                 params.CongestionControlConfig,
+			InitialChannelCapacity: initialChannelCapacity,
+			Logger:                 params.Logger.WithComponent(sutils.ComponentCongestionControl),
 		})
 		t.streamAllocator.OnStreamStateChange(params.Handler.OnStreamStateChange)
+		t.streamAllocator.OnAudioOnlyChange(params.Handler.OnAudioOnlyChange)
 		t.streamAllocator.Start()
 		t.pacer = pacer.NewPassThrough(params.Logger)
 	}
@@ -461,7 +493,7 @@ func (t *PCTransport) setICEStartedAt(at time.Time) {
 				t.tcpICETimer = time.AfterFunc(tcpICETimeout, func() {
 					if t.pc.ICEConnectionState() == webrtc.ICEConnectionStateChecking {
 						t.params.Logger.Infow("TCP ICE connect timeout", "timeout", tcpICETimeout, "signalRTT", signalingRTT)
-						t.handleConnectionFailed(true)
+						t.handleConnectionFailed(true, types.ICEFailureReasonTCPTimeout)
 					}
 				})
 			}
@@ -494,7 +526,7 @@ func (t *PCTransport) setICEConnectedAt(at time.Time) {
 			// if pc is still checking or connected but not fully established after timeout, then fire connection fail
 			if state != webrtc.PeerConnectionStateClosed && state != webrtc.PeerConnectionStateFailed && !t.isFullyEstablished() {
 				t.params.Logger.Infow("connect timeout after ICE connected", "timeout", connTimeoutAfterICE, "iceDuration", iceDuration)
-				t.handleConnectionFailed(false)
+				t.handleConnectionFailed(false, types.ICEFailureReasonEstablishTimeout)
 			}
 		})
 
@@ -596,7 +628,25 @@ func (t *PCTransport) onICECandidateTrickle(c *webrtc.ICECandidate) {
 	})
 }
 
-func (t *PCTransport) handleConnectionFailed(forceShortConn bool) {
+// handleConnectionFailed reports the failure of the transport, the reason is told from the candidates exchanged
+// when it is not given
+func (t *PCTransport) handleConnectionFailed(forceShortConn bool, reason string) {
+	t.lock.Lock()
+	if reason == "" {
+		if t.iceConnectedAt.IsZero() {
+			reason = t.connectionDetails.FailureReason()
+		} else {
+			reason = types.ICEFailureReasonConnectionLost
+		}
+	}
+	t.iceFailureReason = reason
+	t.lock.Unlock()
+
+	t.params.Handler.OnICEConnectivity(&telemetry.ICEConnectivity{
+		Transport:     t.params.Transport,
+		FailureReason: reason,
+	})
+
 	isShort := forceShortConn
 	if !isShort {
 		var duration time.Duration
@@ -628,6 +678,7 @@ func (t *PCTransport) onICEConnectionStateChange(state webrtc.ICEConnectionState
 				return
 			}
 			t.connectionDetails.SetSelectedPair(pair)
+			t.reportICEConnected()
 		}()
 
 	case webrtc.ICEConnectionStateChecking:
@@ -635,6 +686,25 @@ func (t *PCTransport) onICEConnectionStateChange(state webrtc.ICEConnectionState
 	}
 }
 
+func (t *PCTransport) reportICEConnected() {
+	t.lock.Lock()
+	t.iceFailureReason = ""
+	connectTime := t.iceConnectedAt.Sub(t.iceStartedAt)
+	if t.iceStartedAt.IsZero() {
+		connectTime = 0
+	}
+	t.lock.Unlock()
+
+	local, remote := t.connectionDetails.SelectedCandidateTypes()
+	t.params.Handler.OnICEConnectivity(&telemetry.ICEConnectivity{
+		Transport:           t.params.Transport,
+		ConnectionType:      string(t.connectionDetails.Clone().Type),
+		LocalCandidateType:  local,
+		RemoteCandidateType: remote,
+		ConnectTime:         connectTime,
+	})
+}
+
 func (t *PCTransport) onPeerConnectionStateChange(state webrtc.PeerConnectionState) {
 	t.params.Logger.Debugw("peer connection state change", "state", state.String())
 	switch state {
@@ -648,7 +718,7 @@ func (t *PCTransport) onPeerConnectionStateChange(state webrtc.PeerConnectionSta
 		}
 	case webrtc.PeerConnectionStateFailed:
 		t.clearConnTimer()
-		t.handleConnectionFailed(false)
+		t.handleConnectionFailed(false, "")
 	}
 }
 
@@ -669,6 +739,7 @@ func (t *PCTransport) onDataChannel(dc *webrtc.DataChannel) {
 		t.lock.Lock()
 		t.lossyDC = dc
 		t.lossyDCOpened = true
+		t.setupLossyDataQueueLocked(dc)
 		t.lock.Unlock()
 		dc.OnMessage(func(msg webrtc.DataChannelMessage) {
 			t.params.Handler.OnDataPacket(livekit.DataPacket_LOSSY, msg.Data)
@@ -834,6 +905,7 @@ func (t *PCTransport) CreateDataChannel(label string, dci *webrtc.DataChannelIni
 		t.reliableDC.OnError(dcErrorHandler)
 	case LossyDataChannel:
 		t.lossyDC = dc
+		t.setupLossyDataQueueLocked(dc)
 		if t.params.DirectionConfig.StrictACKs {
 			t.lossyDC.OnOpen(dcReadyHandler)
 		} else {
@@ -849,6 +921,17 @@ func (t *PCTransport) CreateDataChannel(label string, dci *webrtc.DataChannelIni
 	return nil
 }
 
+// setupLossyDataQueueLocked queues the messages sent on the lossy data channel while it is congested
+func (t *PCTransport) setupLossyDataQueueLocked(dc *webrtc.DataChannel) {
+	if t.params.LossyData.MaxBufferedAmount == 0 {
+		return
+	}
+
+	t.lossyDataQueue = newLossyDataQueue(t.params.LossyData, dc, t.params.Logger)
+	dc.SetBufferedAmountLowThreshold(t.params.LossyData.MaxBufferedAmount)
+	dc.OnBufferedAmountLow(t.lossyDataQueue.Flush)
+}
+
 func (t *PCTransport) CreateDataChannelIfEmpty(dcLabel string, dci *webrtc.DataChannelInit) (label string, id uint16, existing bool, err error) {
 	t.lock.RLock()
 	var dc *webrtc.DataChannel
@@ -901,11 +984,13 @@ func (t *PCTransport) WriteRTCP(pkts []rtcp.Packet) error {
 
 func (t *PCTransport) SendDataPacket(dp *livekit.DataPacket, data []byte) error {
 	var dc *webrtc.DataChannel
+	var lossyDataQueue *lossyDataQueue
 	t.lock.RLock()
 	if dp.Kind == livekit.DataPacket_RELIABLE {
 		dc = t.reliableDC
 	} else {
 		dc = t.lossyDC
+		lossyDataQueue = t.lossyDataQueue
 	}
 	t.lock.RUnlock()
 
@@ -917,6 +1002,10 @@ func (t *PCTransport) SendDataPacket(dp *livekit.DataPacket, data []byte) error
 		return ErrTransportFailure
 	}
 
+	if lossyDataQueue != nil {
+		return lossyDataQueue.Send(dp, data)
+	}
+
 	if t.params.DataChannelMaxBufferedAmount > 0 && dc.BufferedAmount() > t.params.DataChannelMaxBufferedAmount {
 		return ErrDataChannelBufferFull
 	}
@@ -1033,12 +1122,21 @@ func (t *PCTransport) AddTrackToStreamAllocator(subTrack types.SubscribedTrack)
 	}
 
 	t.streamAllocator.AddTrack(subTrack.DownTrack(), streamallocator.AddTrackParams{
-		Source:      subTrack.MediaTrack().Source(),
-		IsSimulcast: subTrack.MediaTrack().IsSimulcast(),
-		PublisherID: subTrack.MediaTrack().PublisherID(),
+		Source:         subTrack.MediaTrack().Source(),
+		IsSimulcast:    subTrack.MediaTrack().IsSimulcast(),
+		PublisherID:    subTrack.MediaTrack().PublisherID(),
+		SubscriberRank: subTrack.SubscriberRank(),
 	})
 }
 
+func (t *PCTransport) UpdateTrackRankInStreamAllocator(subTrack types.SubscribedTrack) {
+	if t.streamAllocator == nil {
+		return
+	}
+
+	t.streamAllocator.SetTrackSubscriberRank(subTrack.DownTrack(), subTrack.SubscriberRank())
+}
+
 func (t *PCTransport) RemoveTrackFromStreamAllocator(subTrack types.SubscribedTrack) {
 	if t.streamAllocator == nil {
 		return
@@ -1055,6 +1153,121 @@ func (t *PCTransport) SetAllowPauseOfStreamAllocator(allowPause bool) {
 	t.streamAllocator.SetAllowPause(allowPause)
 }
 
+func (t *PCTransport) GetStreamAllocatorInfo(maxDecisions int) *streamallocator.AllocationInfo {
+	if t.streamAllocator == nil {
+		return nil
+	}
+
+	return t.streamAllocator.GetAllocationInfo(maxDecisions)
+}
+
+func (t *PCTransport) GetStreamAllocatorCongestionTrace() *streamallocator.CongestionTrace {
+	if t.streamAllocator == nil {
+		return nil
+	}
+
+	return t.streamAllocator.GetCongestionTrace()
+}
+
+func (t *PCTransport) GetStats() webrtc.StatsReport {
+	return t.pc.GetStats()
+}
+
+func (t *PCTransport) DebugInfo() map[string]interface{} {
+	info := map[string]interface{}{
+		"ConnectionState":    t.pc.ConnectionState().String(),
+		"ICEConnectionState": t.pc.ICEConnectionState().String(),
+		"SignalingState":     t.pc.SignalingState().String(),
+		"ICEConnectionType":  t.connectionDetails.Clone().Type,
+		"PreferTCP":          t.preferTCP.Load(),
+	}
+
+	report := t.pc.GetStats()
+	candidateInfo := func(id string, remote bool) map[string]interface{} {
+		c, ok := report[id].(webrtc.ICECandidateStats)
+		if !ok {
+			return nil
+		}
+		ci := map[string]interface{}{
+			"Type":     c.CandidateType.String(),
+			"Protocol": c.Protocol,
+		}
+		// the address of the client is not revealed when it has to connect through TURN
+		if !remote || t.params.ICECandidatePolicy != config.ICECandidatePolicyRelay {
+			ci["Address"] = net.JoinHostPort(c.IP, strconv.Itoa(int(c.Port)))
+		}
+		return ci
+	}
+	var pairs []map[string]interface{}
+	for _, stats := range report {
+		pair, ok := stats.(webrtc.ICECandidatePairStats)
+		if !ok {
+			continue
+		}
+		pairs = append(pairs, map[string]interface{}{
+			"ID":                   pair.ID,
+			"Local":                candidateInfo(pair.LocalCandidateID, false),
+			"Remote":               candidateInfo(pair.RemoteCandidateID, true),
+			"State":                string(pair.State),
+			"Nominated":            pair.Nominated,
+			"BytesSent":            pair.BytesSent,
+			"BytesReceived":        pair.BytesReceived,
+			"CurrentRoundTripTime": pair.CurrentRoundTripTime,
+		})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i]["ID"].(string) < pairs[j]["ID"].(string)
+	})
+	info["ICECandidatePairs"] = pairs
+
+	return info
+}
+
+// GetICEDiagnostics returns the candidates exchanged by the transport, the candidate pair in use with its round
+// trip time and the reason the transport last failed to connect
+func (t *PCTransport) GetICEDiagnostics() *types.ICEDiagnostics {
+	local, remote, filtered := t.connectionDetails.CandidateCounts()
+	diagnostics := &types.ICEDiagnostics{
+		Transport:                strings.ToLower(t.params.Transport.String()),
+		State:                    t.pc.ICEConnectionState().String(),
+		ConnectionType:           t.connectionDetails.Clone().Type,
+		LocalCandidates:          local,
+		RemoteCandidates:         remote,
+		FilteredRemoteCandidates: filtered,
+	}
+
+	t.lock.RLock()
+	if !t.iceStartedAt.IsZero() && !t.iceConnectedAt.IsZero() {
+		diagnostics.ConnectTimeMs = t.iceConnectedAt.Sub(t.iceStartedAt).Milliseconds()
+	}
+	diagnostics.FailureReason = t.iceFailureReason
+	t.lock.RUnlock()
+
+	report := t.pc.GetStats()
+	for _, stats := range report {
+		pair, ok := stats.(webrtc.ICECandidatePairStats)
+		if !ok || !pair.Nominated || pair.State != webrtc.StatsICECandidatePairStateSucceeded {
+			continue
+		}
+		localCandidate, _ := report[pair.LocalCandidateID].(webrtc.ICECandidateStats)
+		remoteCandidate, _ := report[pair.RemoteCandidateID].(webrtc.ICECandidateStats)
+		diagnostics.SelectedPair = &types.ICECandidatePairInfo{
+			LocalType:      localCandidate.CandidateType.String(),
+			LocalProtocol:  localCandidate.Protocol,
+			RemoteType:     remoteCandidate.CandidateType.String(),
+			RemoteProtocol: remoteCandidate.Protocol,
+			RTTMs:          pair.CurrentRoundTripTime * 1000,
+			BytesSent:      pair.BytesSent,
+			BytesReceived:  pair.BytesReceived,
+		}
+		if t.params.ICECandidatePolicy != config.ICECandidatePolicyRelay && remoteCandidate.IP != "" {
+			diagnostics.SelectedPair.RemoteAddress = net.JoinHostPort(remoteCandidate.IP, strconv.Itoa(int(remoteCandidate.Port)))
+		}
+		break
+	}
+	return diagnostics
+}
+
 func (t *PCTransport) SetChannelCapacityOfStreamAllocator(channelCapacity int64) {
 	if t.streamAllocator == nil {
 		return
@@ -1063,6 +1276,14 @@ func (t *PCTransport) SetChannelCapacityOfStreamAllocator(channelCapacity int64)
 	t.streamAllocator.SetChannelCapacity(channelCapacity)
 }
 
+func (t *PCTransport) SetBandwidthHintOfStreamAllocator(bandwidthHint int64) {
+	if t.streamAllocator == nil || bandwidthHint <= 0 {
+		return
+	}
+
+	t.streamAllocator.SetChannelCapacityHint(initialBitrate(bandwidthHint))
+}
+
 func (t *PCTransport) preparePC(previousAnswer webrtc.SessionDescription) error {
 	// sticky data channel to first m-lines, if someday we don't send sdp without media streams to
 	// client's subscribe pc after joining, should change this step
@@ -1334,7 +1555,7 @@ func (t *PCTransport) handleLocalICECandidate(e *event) error {
 
 	filtered := false
 	if c != nil {
-		if t.preferTCP.Load() && c.Protocol != webrtc.ICEProtocolTCP {
+		if (t.preferTCP.Load() && c.Protocol != webrtc.ICEProtocolTCP) || !t.params.ICECandidatePolicy.AllowsCandidate(c.Typ.String(), false) {
 			t.params.Logger.Debugw("filtering out local candidate",
 				"candidate", func() interface{} {
 					return c.String()
@@ -1359,6 +1580,12 @@ func (t *PCTransport) handleLocalICECandidate(e *event) error {
 func (t *PCTransport) handleRemoteICECandidate(e *event) error {
 	c := e.data.(*webrtc.ICECandidateInit)
 
+	if candidateType := iceCandidateType(c.Candidate); !t.params.ICECandidatePolicy.AllowsCandidate(candidateType, true) {
+		// not logged or recorded, the policy can be there to keep the address of the participant out of the SFU
+		t.params.Logger.Debugw("filtering out remote candidate by policy", "type", candidateType)
+		return nil
+	}
+
 	filtered := false
 	if t.preferTCP.Load() && !strings.Contains(c.Candidate, "tcp") {
 		t.params.Logger.Debugw("filtering out remote candidate", "candidate", c.Candidate)
@@ -1389,7 +1616,7 @@ func (t *PCTransport) setNegotiationState(state transport.NegotiationState) {
 	}
 }
 
-func (t *PCTransport) filterCandidates(sd webrtc.SessionDescription, preferTCP bool) webrtc.SessionDescription {
+func (t *PCTransport) filterCandidates(sd webrtc.SessionDescription, preferTCP bool, remote bool) webrtc.SessionDescription {
 	parsed, err := sd.Unmarshal()
 	if err != nil {
 		t.params.Logger.Warnw("could not unmarshal SDP to filter candidates", err)
@@ -1400,6 +1627,9 @@ func (t *PCTransport) filterCandidates(sd webrtc.SessionDescription, preferTCP b
 		filteredAttrs := make([]sdp.Attribute, 0, len(attrs))
 		for _, a := range attrs {
 			if a.Key == sdp.AttrKeyCandidate {
+				if !t.params.ICECandidatePolicy.AllowsCandidate(iceCandidateType(a.Value), remote) {
+					continue
+				}
 				if preferTCP {
 					if strings.Contains(a.Value, "tcp") {
 						filteredAttrs = append(filteredAttrs, a)
@@ -1429,6 +1659,17 @@ func (t *PCTransport) filterCandidates(sd webrtc.SessionDescription, preferTCP b
 	return sd
 }
 
+// iceCandidateType returns the type of a candidate attribute, e.g. host or relay
+func iceCandidateType(candidate string) string {
+	fields := strings.Fields(candidate)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "typ" {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
 func (t *PCTransport) clearSignalStateCheckTimer() {
 	if t.signalStateCheckTimer != nil {
 		t.signalStateCheckTimer.Stop()
@@ -1533,7 +1774,7 @@ func (t *PCTransport) createAndSendOffer(options *webrtc.OfferOptions) error {
 	// Filtered offer is sent to remote so that remote does not
 	// see filtered candidates.
 	//
-	offer = t.filterCandidates(offer, preferTCP)
+	offer = t.filterCandidates(offer, preferTCP, false)
 	if preferTCP {
 		t.params.Logger.Debugw("local offer (filtered)", "sdp", offer.SDP)
 	}
@@ -1580,23 +1821,37 @@ func (t *PCTransport) isRemoteOfferRestartICE(parsed *sdp.SessionDescription) (s
 func (t *PCTransport) setRemoteDescription(sd webrtc.SessionDescription) error {
 	// filter before setting remote description so that pion does not see filtered remote candidates
 	preferTCP := t.preferTCP.Load()
-	if preferTCP {
+	if preferTCP && t.params.ICECandidatePolicy != config.ICECandidatePolicyRelay {
 		t.params.Logger.Debugw("remote description (unfiltered)", "type", sd.Type, "sdp", sd.SDP)
 	}
-	sd = t.filterCandidates(sd, preferTCP)
+	sd = t.filterCandidates(sd, preferTCP, true)
 	if preferTCP {
 		t.params.Logger.Debugw("remote description (filtered)", "type", sd.Type, "sdp", sd.SDP)
 	}
 
+	sdpType := "offer"
+	if sd.Type == webrtc.SDPTypeAnswer {
+		sdpType = "answer"
+	}
+
+	checked, err := t.checkRemoteDescription(sd)
+	if err != nil {
+		prometheus.ServiceOperationCounter.WithLabelValues(sdpType, "error", "remote_description").Add(1)
+		return errors.Wrap(err, "invalid remote description")
+	}
+	if checked.SDP != sd.SDP {
+		prometheus.ServiceOperationCounter.WithLabelValues(sdpType, "constrained", "remote_description").Add(1)
+		sd = checked
+	}
+
 	if err := t.pc.SetRemoteDescription(sd); err != nil {
 		if errors.Is(err, webrtc.ErrConnectionClosed) {
 			t.params.Logger.Warnw("trying to set remote description on closed peer connection", nil)
 			return nil
 		}
 
-		sdpType := "offer"
-		if sd.Type == webrtc.SDPTypeAnswer {
-			sdpType = "answer"
+		if !errors.Is(err, webrtc.ErrUnsupportedCodec) {
+			t.reportSessionDescriptionFailure(negotiationStageRemoteDescription, &sd, "", err, false)
 		}
 		prometheus.ServiceOperationCounter.WithLabelValues(sdpType, "error", "remote_description").Add(1)
 		return errors.Wrap(err, "setting remote description failed")
@@ -1627,6 +1882,7 @@ func (t *PCTransport) createAndSendAnswer() error {
 			return nil
 		}
 
+		t.reportSessionDescriptionFailure(negotiationStageCreateAnswer, t.pc.RemoteDescription(), "", err, false)
 		prometheus.ServiceOperationCounter.WithLabelValues("answer", "error", "create").Add(1)
 		return errors.Wrap(err, "create answer failed")
 	}
@@ -1637,6 +1893,7 @@ func (t *PCTransport) createAndSendAnswer() error {
 	}
 
 	if err = t.pc.SetLocalDescription(answer); err != nil {
+		t.reportSessionDescriptionFailure(negotiationStageLocalDescription, t.pc.RemoteDescription(), "", err, false)
 		prometheus.ServiceOperationCounter.WithLabelValues("answer", "error", "local_description").Add(1)
 		return errors.Wrap(err, "setting local description failed")
 	}
@@ -1647,7 +1904,7 @@ func (t *PCTransport) createAndSendAnswer() error {
 	// Filtered answer is sent to remote so that remote does not
 	// see filtered candidates.
 	//
-	answer = t.filterCandidates(answer, preferTCP)
+	answer = t.filterCandidates(answer, preferTCP, false)
 	if preferTCP {
 		t.params.Logger.Debugw("local answer (filtered)", "sdp", answer.SDP)
 	}
@@ -1664,6 +1921,7 @@ func (t *PCTransport) createAndSendAnswer() error {
 func (t *PCTransport) handleRemoteOfferReceived(sd *webrtc.SessionDescription) error {
 	parsed, err := sd.Unmarshal()
 	if err != nil {
+		t.reportSessionDescriptionFailure(negotiationStageRemoteDescription, sd, "", err, false)
 		return nil
 	}
 	iceCredential, offerRestartICE, err := t.isRemoteOfferRestartICE(parsed)
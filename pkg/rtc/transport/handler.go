@@ -20,6 +20,7 @@ import (
 	"github.com/pion/webrtc/v3"
 
 	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/protocol/livekit"
 )
 
@@ -43,7 +44,10 @@ type Handler interface {
 	OnAnswer(sd webrtc.SessionDescription) error
 	OnNegotiationStateChanged(state NegotiationState)
 	OnNegotiationFailed()
+	OnSessionDescriptionFailed(failure *telemetry.NegotiationFailure)
+	OnICEConnectivity(connectivity *telemetry.ICEConnectivity)
 	OnStreamStateChange(update *streamallocator.StreamStateUpdate) error
+	OnAudioOnlyChange(audioOnly bool)
 }
 
 type UnimplementedHandler struct{}
@@ -62,8 +66,11 @@ func (h UnimplementedHandler) OnOffer(sd webrtc.SessionDescription) error {
 func (h UnimplementedHandler) OnAnswer(sd webrtc.SessionDescription) error {
 	return ErrNoAnswerHandler
 }
-func (h UnimplementedHandler) OnNegotiationStateChanged(state NegotiationState) {}
-func (h UnimplementedHandler) OnNegotiationFailed()                             {}
+func (h UnimplementedHandler) OnNegotiationStateChanged(state NegotiationState)                 {}
+func (h UnimplementedHandler) OnNegotiationFailed()                                             {}
+func (h UnimplementedHandler) OnSessionDescriptionFailed(failure *telemetry.NegotiationFailure) {}
+func (h UnimplementedHandler) OnICEConnectivity(connectivity *telemetry.ICEConnectivity)        {}
 func (h UnimplementedHandler) OnStreamStateChange(update *streamallocator.StreamStateUpdate) error {
 	return nil
 }
+func (h UnimplementedHandler) OnAudioOnlyChange(audioOnly bool) {}
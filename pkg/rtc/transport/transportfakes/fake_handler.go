@@ -6,6 +6,7 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/rtc/transport"
 	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/protocol/livekit"
 	webrtc "github.com/pion/webrtc/v3"
 )
@@ -22,6 +23,11 @@ type FakeHandler struct {
 	onAnswerReturnsOnCall map[int]struct {
 		result1 error
 	}
+	OnAudioOnlyChangeStub        func(bool)
+	onAudioOnlyChangeMutex       sync.RWMutex
+	onAudioOnlyChangeArgsForCall []struct {
+		arg1 bool
+	}
 	OnDataPacketStub        func(livekit.DataPacket_Kind, []byte)
 	onDataPacketMutex       sync.RWMutex
 	onDataPacketArgsForCall []struct {
@@ -49,6 +55,11 @@ type FakeHandler struct {
 	onICECandidateReturnsOnCall map[int]struct {
 		result1 error
 	}
+	OnICEConnectivityStub        func(*telemetry.ICEConnectivity)
+	onICEConnectivityMutex       sync.RWMutex
+	onICEConnectivityArgsForCall []struct {
+		arg1 *telemetry.ICEConnectivity
+	}
 	OnInitialConnectedStub        func()
 	onInitialConnectedMutex       sync.RWMutex
 	onInitialConnectedArgsForCall []struct {
@@ -73,6 +84,11 @@ type FakeHandler struct {
 	onOfferReturnsOnCall map[int]struct {
 		result1 error
 	}
+	OnSessionDescriptionFailedStub        func(*telemetry.NegotiationFailure)
+	onSessionDescriptionFailedMutex       sync.RWMutex
+	onSessionDescriptionFailedArgsForCall []struct {
+		arg1 *telemetry.NegotiationFailure
+	}
 	OnStreamStateChangeStub        func(*streamallocator.StreamStateUpdate) error
 	onStreamStateChangeMutex       sync.RWMutex
 	onStreamStateChangeArgsForCall []struct {
@@ -155,6 +171,38 @@ func (fake *FakeHandler) OnAnswerReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeHandler) OnAudioOnlyChange(arg1 bool) {
+	fake.onAudioOnlyChangeMutex.Lock()
+	fake.onAudioOnlyChangeArgsForCall = append(fake.onAudioOnlyChangeArgsForCall, struct {
+		arg1 bool
+	}{arg1})
+	stub := fake.OnAudioOnlyChangeStub
+	fake.recordInvocation("OnAudioOnlyChange", []interface{}{arg1})
+	fake.onAudioOnlyChangeMutex.Unlock()
+	if stub != nil {
+		fake.OnAudioOnlyChangeStub(arg1)
+	}
+}
+
+func (fake *FakeHandler) OnAudioOnlyChangeCallCount() int {
+	fake.onAudioOnlyChangeMutex.RLock()
+	defer fake.onAudioOnlyChangeMutex.RUnlock()
+	return len(fake.onAudioOnlyChangeArgsForCall)
+}
+
+func (fake *FakeHandler) OnAudioOnlyChangeCalls(stub func(bool)) {
+	fake.onAudioOnlyChangeMutex.Lock()
+	defer fake.onAudioOnlyChangeMutex.Unlock()
+	fake.OnAudioOnlyChangeStub = stub
+}
+
+func (fake *FakeHandler) OnAudioOnlyChangeArgsForCall(i int) bool {
+	fake.onAudioOnlyChangeMutex.RLock()
+	defer fake.onAudioOnlyChangeMutex.RUnlock()
+	argsForCall := fake.onAudioOnlyChangeArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeHandler) OnDataPacket(arg1 livekit.DataPacket_Kind, arg2 []byte) {
 	var arg2Copy []byte
 	if arg2 != nil {
@@ -311,6 +359,38 @@ func (fake *FakeHandler) OnICECandidateReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeHandler) OnICEConnectivity(arg1 *telemetry.ICEConnectivity) {
+	fake.onICEConnectivityMutex.Lock()
+	fake.onICEConnectivityArgsForCall = append(fake.onICEConnectivityArgsForCall, struct {
+		arg1 *telemetry.ICEConnectivity
+	}{arg1})
+	stub := fake.OnICEConnectivityStub
+	fake.recordInvocation("OnICEConnectivity", []interface{}{arg1})
+	fake.onICEConnectivityMutex.Unlock()
+	if stub != nil {
+		fake.OnICEConnectivityStub(arg1)
+	}
+}
+
+func (fake *FakeHandler) OnICEConnectivityCallCount() int {
+	fake.onICEConnectivityMutex.RLock()
+	defer fake.onICEConnectivityMutex.RUnlock()
+	return len(fake.onICEConnectivityArgsForCall)
+}
+
+func (fake *FakeHandler) OnICEConnectivityCalls(stub func(*telemetry.ICEConnectivity)) {
+	fake.onICEConnectivityMutex.Lock()
+	defer fake.onICEConnectivityMutex.Unlock()
+	fake.OnICEConnectivityStub = stub
+}
+
+func (fake *FakeHandler) OnICEConnectivityArgsForCall(i int) *telemetry.ICEConnectivity {
+	fake.onICEConnectivityMutex.RLock()
+	defer fake.onICEConnectivityMutex.RUnlock()
+	argsForCall := fake.onICEConnectivityArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeHandler) OnInitialConnected() {
 	fake.onInitialConnectedMutex.Lock()
 	fake.onInitialConnectedArgsForCall = append(fake.onInitialConnectedArgsForCall, struct {
@@ -452,6 +532,38 @@ func (fake *FakeHandler) OnOfferReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeHandler) OnSessionDescriptionFailed(arg1 *telemetry.NegotiationFailure) {
+	fake.onSessionDescriptionFailedMutex.Lock()
+	fake.onSessionDescriptionFailedArgsForCall = append(fake.onSessionDescriptionFailedArgsForCall, struct {
+		arg1 *telemetry.NegotiationFailure
+	}{arg1})
+	stub := fake.OnSessionDescriptionFailedStub
+	fake.recordInvocation("OnSessionDescriptionFailed", []interface{}{arg1})
+	fake.onSessionDescriptionFailedMutex.Unlock()
+	if stub != nil {
+		fake.OnSessionDescriptionFailedStub(arg1)
+	}
+}
+
+func (fake *FakeHandler) OnSessionDescriptionFailedCallCount() int {
+	fake.onSessionDescriptionFailedMutex.RLock()
+	defer fake.onSessionDescriptionFailedMutex.RUnlock()
+	return len(fake.onSessionDescriptionFailedArgsForCall)
+}
+
+func (fake *FakeHandler) OnSessionDescriptionFailedCalls(stub func(*telemetry.NegotiationFailure)) {
+	fake.onSessionDescriptionFailedMutex.Lock()
+	defer fake.onSessionDescriptionFailedMutex.Unlock()
+	fake.OnSessionDescriptionFailedStub = stub
+}
+
+func (fake *FakeHandler) OnSessionDescriptionFailedArgsForCall(i int) *telemetry.NegotiationFailure {
+	fake.onSessionDescriptionFailedMutex.RLock()
+	defer fake.onSessionDescriptionFailedMutex.RUnlock()
+	argsForCall := fake.onSessionDescriptionFailedArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeHandler) OnStreamStateChange(arg1 *streamallocator.StreamStateUpdate) error {
 	fake.onStreamStateChangeMutex.Lock()
 	ret, specificReturn := fake.onStreamStateChangeReturnsOnCall[len(fake.onStreamStateChangeArgsForCall)]
@@ -551,6 +663,8 @@ func (fake *FakeHandler) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.onAnswerMutex.RLock()
 	defer fake.onAnswerMutex.RUnlock()
+	fake.onAudioOnlyChangeMutex.RLock()
+	defer fake.onAudioOnlyChangeMutex.RUnlock()
 	fake.onDataPacketMutex.RLock()
 	defer fake.onDataPacketMutex.RUnlock()
 	fake.onFailedMutex.RLock()
@@ -559,6 +673,8 @@ func (fake *FakeHandler) Invocations() map[string][][]interface{} {
 	defer fake.onFullyEstablishedMutex.RUnlock()
 	fake.onICECandidateMutex.RLock()
 	defer fake.onICECandidateMutex.RUnlock()
+	fake.onICEConnectivityMutex.RLock()
+	defer fake.onICEConnectivityMutex.RUnlock()
 	fake.onInitialConnectedMutex.RLock()
 	defer fake.onInitialConnectedMutex.RUnlock()
 	fake.onNegotiationFailedMutex.RLock()
@@ -567,6 +683,8 @@ func (fake *FakeHandler) Invocations() map[string][][]interface{} {
 	defer fake.onNegotiationStateChangedMutex.RUnlock()
 	fake.onOfferMutex.RLock()
 	defer fake.onOfferMutex.RUnlock()
+	fake.onSessionDescriptionFailedMutex.RLock()
+	defer fake.onSessionDescriptionFailedMutex.RUnlock()
 	fake.onStreamStateChangeMutex.RLock()
 	defer fake.onStreamStateChangeMutex.RUnlock()
 	fake.onTrackMutex.RLock()
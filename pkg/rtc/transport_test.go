@@ -26,6 +26,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/rtc/transport"
 	"github.com/livekit/livekit-server/pkg/rtc/transport/transportfakes"
 	"github.com/livekit/livekit-server/pkg/testutils"
@@ -416,7 +417,7 @@ func TestFilteringCandidates(t *testing.T) {
 
 	// should not filter out UDP candidates if TCP is not preferred
 	offer = *transport.pc.LocalDescription()
-	filteredOffer := transport.filterCandidates(offer, false)
+	filteredOffer := transport.filterCandidates(offer, false, false)
 	require.EqualValues(t, offer.SDP, filteredOffer.SDP)
 
 	parsed, err := offer.Unmarshal()
@@ -494,7 +495,7 @@ func TestFilteringCandidates(t *testing.T) {
 	require.Equal(t, 2, tcp)
 
 	transport.SetPreferTCP(true)
-	filteredOffer = transport.filterCandidates(offer, true)
+	filteredOffer = transport.filterCandidates(offer, true, false)
 	parsed, err = filteredOffer.Unmarshal()
 	require.NoError(t, err)
 	udp, tcp = getNumTransportTypeCandidates(parsed)
@@ -504,6 +505,53 @@ func TestFilteringCandidates(t *testing.T) {
 	transport.Close()
 }
 
+func TestFilteringCandidatesByPolicy(t *testing.T) {
+	sd := webrtc.SessionDescription{
+		Type: webrtc.SDPTypeOffer,
+		SDP: "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=-\r\nt=0 0\r\n" +
+			"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\nc=IN IP4 0.0.0.0\r\n" +
+			"a=candidate:1 1 udp 2130706431 10.0.0.1 50000 typ host\r\n" +
+			"a=candidate:2 1 udp 1694498815 203.0.113.1 50000 typ srflx raddr 10.0.0.1 rport 50000\r\n" +
+			"a=candidate:3 1 udp 16777215 198.51.100.1 50000 typ relay raddr 203.0.113.1 rport 50000\r\n",
+	}
+	candidateTypes := func(sd webrtc.SessionDescription) []string {
+		parsed, err := sd.Unmarshal()
+		require.NoError(t, err)
+		var types []string
+		for _, a := range parsed.MediaDescriptions[0].Attributes {
+			if a.Key == sdp.AttrKeyCandidate {
+				types = append(types, iceCandidateType(a.Value))
+			}
+		}
+		return types
+	}
+
+	for _, test := range []struct {
+		policy config.ICECandidatePolicy
+		remote []string
+		local  []string
+	}{
+		{policy: config.ICECandidatePolicyAll, remote: []string{"host", "srflx", "relay"}, local: []string{"host", "srflx", "relay"}},
+		{policy: config.ICECandidatePolicyRelay, remote: []string{"relay"}, local: []string{"host", "srflx", "relay"}},
+		{policy: config.ICECandidatePolicyHost, remote: []string{"host"}, local: []string{"host"}},
+	} {
+		t.Run(string(test.policy), func(t *testing.T) {
+			transport, err := NewPCTransport(TransportParams{
+				ParticipantID:       "id",
+				ParticipantIdentity: "identity",
+				Config:              &WebRTCConfig{},
+				Handler:             &transportfakes.FakeHandler{},
+				ICECandidatePolicy:  test.policy,
+			})
+			require.NoError(t, err)
+			defer transport.Close()
+
+			require.Equal(t, test.remote, candidateTypes(transport.filterCandidates(sd, false, true)))
+			require.Equal(t, test.local, candidateTypes(transport.filterCandidates(sd, false, false)))
+		})
+	}
+}
+
 func handleICEExchange(t *testing.T, a, b *PCTransport, ah, bh *transportfakes.FakeHandler) {
 	ah.OnICECandidateCalls(func(candidate *webrtc.ICECandidate, target livekit.SignalTarget) error {
 		if candidate == nil {
@@ -622,3 +670,11 @@ func TestConfigureAudioTransceiver(t *testing.T) {
 		})
 	}
 }
+
+func TestInitialBitrate(t *testing.T) {
+	require.Equal(t, int64(defaultInitialBitrate), initialBitrate(0))
+	require.Equal(t, int64(defaultInitialBitrate), initialBitrate(-1))
+	require.Equal(t, int64(minBandwidthHint), initialBitrate(10_000))
+	require.Equal(t, int64(5_000_000), initialBitrate(5_000_000))
+	require.Equal(t, int64(maxBandwidthHint), initialBitrate(1_000_000_000))
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 	"github.com/livekit/mediatransportutil/pkg/twcc"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
@@ -77,6 +78,7 @@ type TransportManagerParams struct {
 	CongestionControlConfig      config.CongestionControlConfig
 	EnabledSubscribeCodecs       []*livekit.Codec
 	EnabledPublishCodecs         []*livekit.Codec
+	FmtpOverrides                []*livekit.Codec
 	SimTracks                    map[uint32]SimulcastTrackInfo
 	ClientInfo                   ClientInfo
 	Migration                    bool
@@ -86,6 +88,9 @@ type TransportManagerParams struct {
 	TURNSEnabled                 bool
 	AllowPlayoutDelay            bool
 	DataChannelMaxBufferedAmount uint64
+	LossyData                    config.LossyDataConfig
+	BandwidthHint                int64
+	ICECandidatePolicy           config.ICECandidatePolicy
 	Logger                       logger.Logger
 	PublisherHandler             transport.Handler
 	SubscriberHandler            transport.Handler
@@ -137,9 +142,11 @@ func NewTransportManager(params TransportManagerParams) (*TransportManager, erro
 		DirectionConfig:         params.Config.Publisher,
 		CongestionControlConfig: params.CongestionControlConfig,
 		EnabledCodecs:           params.EnabledPublishCodecs,
+		FmtpOverrides:           params.FmtpOverrides,
 		Logger:                  LoggerWithPCTarget(params.Logger, livekit.SignalTarget_PUBLISHER),
 		SimTracks:               params.SimTracks,
 		ClientInfo:              params.ClientInfo,
+		ICECandidatePolicy:      params.ICECandidatePolicy,
 		Transport:               livekit.SignalTarget_PUBLISHER,
 		Handler:                 TransportManagerPublisherTransportHandler{TransportManagerTransportHandler{params.PublisherHandler, t}},
 	})
@@ -156,12 +163,16 @@ func NewTransportManager(params TransportManagerParams) (*TransportManager, erro
 		DirectionConfig:              params.Config.Subscriber,
 		CongestionControlConfig:      params.CongestionControlConfig,
 		EnabledCodecs:                params.EnabledSubscribeCodecs,
+		FmtpOverrides:                params.FmtpOverrides,
 		Logger:                       LoggerWithPCTarget(params.Logger, livekit.SignalTarget_SUBSCRIBER),
 		ClientInfo:                   params.ClientInfo,
 		IsOfferer:                    true,
 		IsSendSide:                   true,
 		AllowPlayoutDelay:            params.AllowPlayoutDelay,
 		DataChannelMaxBufferedAmount: params.DataChannelMaxBufferedAmount,
+		LossyData:                    params.LossyData,
+		BandwidthHint:                params.BandwidthHint,
+		ICECandidatePolicy:           params.ICECandidatePolicy,
 		Transport:                    livekit.SignalTarget_SUBSCRIBER,
 		Handler:                      TransportManagerTransportHandler{params.SubscriberHandler, t},
 	})
@@ -236,6 +247,10 @@ func (t *TransportManager) AddSubscribedTrack(subTrack types.SubscribedTrack) {
 	t.subscriber.AddTrackToStreamAllocator(subTrack)
 }
 
+func (t *TransportManager) UpdateSubscribedTrackRank(subTrack types.SubscribedTrack) {
+	t.subscriber.UpdateTrackRankInStreamAllocator(subTrack)
+}
+
 func (t *TransportManager) RemoveSubscribedTrack(subTrack types.SubscribedTrack) {
 	t.subscriber.RemoveTrackFromStreamAllocator(subTrack)
 }
@@ -487,6 +502,10 @@ func (t *TransportManager) SubscriberAsPrimary() bool {
 	return t.params.SubscriberAsPrimary
 }
 
+func (t *TransportManager) GetICEDiagnostics() []*types.ICEDiagnostics {
+	return []*types.ICEDiagnostics{t.publisher.GetICEDiagnostics(), t.subscriber.GetICEDiagnostics()}
+}
+
 func (t *TransportManager) GetICEConnectionDetails() []*types.ICEConnectionDetails {
 	details := make([]*types.ICEConnectionDetails, 0, 2)
 	for _, pc := range []*PCTransport{t.publisher, t.subscriber} {
@@ -733,10 +752,42 @@ func (t *TransportManager) SetSubscriberAllowPause(allowPause bool) {
 	t.subscriber.SetAllowPauseOfStreamAllocator(allowPause)
 }
 
+func (t *TransportManager) GetSubscriberAllocationInfo(maxDecisions int) *streamallocator.AllocationInfo {
+	return t.subscriber.GetStreamAllocatorInfo(maxDecisions)
+}
+
+func (t *TransportManager) GetSubscriberCongestionTrace() *streamallocator.CongestionTrace {
+	return t.subscriber.GetStreamAllocatorCongestionTrace()
+}
+
+func (t *TransportManager) DebugInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"Publisher":  t.publisher.DebugInfo(),
+		"Subscriber": t.subscriber.DebugInfo(),
+	}
+}
+
+// GetTransportStats returns the report of the transport, without stats of RTP streams
+func (t *TransportManager) GetTransportStats(target livekit.SignalTarget) webrtc.StatsReport {
+	switch target {
+	case livekit.SignalTarget_PUBLISHER:
+		return t.publisher.GetStats()
+	case livekit.SignalTarget_SUBSCRIBER:
+		return t.subscriber.GetStats()
+	default:
+		return nil
+	}
+}
+
 func (t *TransportManager) SetSubscriberChannelCapacity(channelCapacity int64) {
 	t.subscriber.SetChannelCapacityOfStreamAllocator(channelCapacity)
 }
 
+// SetSubscriberBandwidthHint applies a bandwidth hint the client sent after the transports were created, e.g. when resuming
+func (t *TransportManager) SetSubscriberBandwidthHint(bandwidthHint int64) {
+	t.subscriber.SetBandwidthHintOfStreamAllocator(bandwidthHint)
+}
+
 func (t *TransportManager) hasRecentSignalLocked() bool {
 	return time.Since(t.lastSignalAt) < PingTimeoutSeconds*time.Second
 }
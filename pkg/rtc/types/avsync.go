@@ -0,0 +1,34 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// AVSyncStats is the audio/video sync of the microphone and camera of a publisher as forwarded to a subscriber.
+// The offsets are how much later the sender reports sent to the subscriber place the media of the track than
+// the sender reports of the publisher do, they include the difference of the clocks of the publisher and the node.
+type AVSyncStats struct {
+	PublisherID       string  `json:"publisher_id"`
+	PublisherIdentity string  `json:"publisher_identity"`
+	AudioTrackID      string  `json:"audio_track_id"`
+	VideoTrackID      string  `json:"video_track_id"`
+	AudioOffsetMs     float64 `json:"audio_offset_ms"`
+	VideoOffsetMs     float64 `json:"video_offset_ms"`
+	// audio offset less video offset, positive when the audio plays behind the video at the subscriber. It is
+	// the correction the subscriber would have to apply to its audio
+	SkewMs float64 `json:"skew_ms"`
+}
+
+type AVSyncDetails struct {
+	Publishers []*AVSyncStats `json:"publishers"`
+}
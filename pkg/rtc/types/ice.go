@@ -37,6 +37,51 @@ const (
 	ICEConnectionTypeUnknown ICEConnectionType = "unknown"
 )
 
+// reasons a transport failed to connect
+const (
+	// the client did not signal candidates
+	ICEFailureReasonNoRemoteCandidates = "no_remote_candidates"
+	// the candidates of the client were all dropped by the ICE candidate policy
+	ICEFailureReasonCandidatesFiltered = "remote_candidates_filtered"
+	// candidates were exchanged but none of the pairs could be connected
+	ICEFailureReasonChecksFailed = "checks_failed"
+	// connectivity checks over TCP did not complete in time
+	ICEFailureReasonTCPTimeout = "tcp_timeout"
+	// ICE connected but the DTLS handshake or data channels did not complete in time
+	ICEFailureReasonEstablishTimeout = "establish_timeout"
+	// the transport was connected and lost its connection
+	ICEFailureReasonConnectionLost = "connection_lost"
+)
+
+// ICEDiagnostics describes the ICE connectivity of a transport of a participant, the candidates exchanged,
+// the candidate pair in use and why the transport failed to connect, if it did
+type ICEDiagnostics struct {
+	Transport      string            `json:"transport"`
+	State          string            `json:"state"`
+	ConnectionType ICEConnectionType `json:"connection_type"`
+	// candidates by type and protocol, e.g. host/udp or relay/tcp
+	LocalCandidates  map[string]int `json:"local_candidates"`
+	RemoteCandidates map[string]int `json:"remote_candidates"`
+	// remote candidates dropped by the ICE candidate policy
+	FilteredRemoteCandidates int                   `json:"filtered_remote_candidates"`
+	SelectedPair             *ICECandidatePairInfo `json:"selected_pair,omitempty"`
+	// from the start of connectivity checks until ICE connected
+	ConnectTimeMs int64  `json:"connect_time_ms,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+type ICECandidatePairInfo struct {
+	LocalType      string `json:"local_type"`
+	LocalProtocol  string `json:"local_protocol"`
+	RemoteType     string `json:"remote_type"`
+	RemoteProtocol string `json:"remote_protocol"`
+	// left out when the client has to connect through TURN, not to reveal where it connects from
+	RemoteAddress string  `json:"remote_address,omitempty"`
+	RTTMs         float64 `json:"rtt_ms"`
+	BytesSent     uint64  `json:"bytes_sent"`
+	BytesReceived uint64  `json:"bytes_received"`
+}
+
 type ICECandidateExtended struct {
 	// only one of local or remote is set. This is due to type foo in Pion
 	Local    *webrtc.ICECandidate
@@ -145,6 +190,61 @@ func (d *ICEConnectionDetails) Clear() {
 	d.Type = ICEConnectionTypeUnknown
 }
 
+// CandidateCounts returns the number of local and remote candidates by type and protocol, and the number of
+// remote candidates that were filtered
+func (d *ICEConnectionDetails) CandidateCounts() (local map[string]int, remote map[string]int, filtered int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	local = make(map[string]int)
+	for _, c := range d.Local {
+		local[c.Local.Typ.String()+"/"+c.Local.Protocol.String()]++
+	}
+	remote = make(map[string]int)
+	for _, c := range d.Remote {
+		if c.Filtered {
+			filtered++
+			continue
+		}
+		remote[c.Remote.Type().String()+"/"+c.Remote.NetworkType().NetworkShort()]++
+	}
+	return
+}
+
+// FailureReason tells why connectivity checks failed from the candidates exchanged
+func (d *ICEConnectionDetails) FailureReason() string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(d.Remote) == 0 {
+		return ICEFailureReasonNoRemoteCandidates
+	}
+	for _, c := range d.Remote {
+		if !c.Filtered {
+			return ICEFailureReasonChecksFailed
+		}
+	}
+	return ICEFailureReasonCandidatesFiltered
+}
+
+// SelectedCandidateTypes returns the types of the candidates of the selected pair, empty when none is selected
+func (d *ICEConnectionDetails) SelectedCandidateTypes() (local string, remote string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for _, c := range d.Local {
+		if c.Selected {
+			local = c.Local.Typ.String()
+		}
+	}
+	for _, c := range d.Remote {
+		if c.Selected {
+			remote = c.Remote.Type().String()
+		}
+	}
+	return
+}
+
 func (d *ICEConnectionDetails) SetSelectedPair(pair *webrtc.ICECandidatePair) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
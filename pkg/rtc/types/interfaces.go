@@ -26,10 +26,14 @@ import (
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/utils"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
+	"github.com/livekit/livekit-server/pkg/sfu/packetcapture"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -71,6 +75,64 @@ func (m MigrateState) String() string {
 
 // ---------------------------------------------
 
+// ParticipantLifecycleState is the lifecycle of a participant session, finer grained than the state reported
+// to clients in ParticipantInfo. A session moves forward only, except between active and resuming:
+//
+//	joining -> active <-> resuming
+//	joining, active, resuming -> draining -> disconnected
+//	joining, active, resuming -> disconnected
+type ParticipantLifecycleState int32
+
+const (
+	ParticipantLifecycleStateJoining ParticipantLifecycleState = iota
+	ParticipantLifecycleStateActive
+	// signal connection lost, waiting for the client to resume the session
+	ParticipantLifecycleStateResuming
+	// closing, new operations are rejected while the ones in progress complete
+	ParticipantLifecycleStateDraining
+	ParticipantLifecycleStateDisconnected
+)
+
+func (s ParticipantLifecycleState) String() string {
+	switch s {
+	case ParticipantLifecycleStateJoining:
+		return "JOINING"
+	case ParticipantLifecycleStateActive:
+		return "ACTIVE"
+	case ParticipantLifecycleStateResuming:
+		return "RESUMING"
+	case ParticipantLifecycleStateDraining:
+		return "DRAINING"
+	case ParticipantLifecycleStateDisconnected:
+		return "DISCONNECTED"
+	default:
+		return fmt.Sprintf("%d", int(s))
+	}
+}
+
+// CanTransitionTo returns true when to is a valid next state
+func (s ParticipantLifecycleState) CanTransitionTo(to ParticipantLifecycleState) bool {
+	switch s {
+	case ParticipantLifecycleStateJoining:
+		return to != ParticipantLifecycleStateJoining && to != ParticipantLifecycleStateResuming
+	case ParticipantLifecycleStateActive:
+		return to == ParticipantLifecycleStateResuming || to == ParticipantLifecycleStateDraining || to == ParticipantLifecycleStateDisconnected
+	case ParticipantLifecycleStateResuming:
+		return to == ParticipantLifecycleStateActive || to == ParticipantLifecycleStateDraining || to == ParticipantLifecycleStateDisconnected
+	case ParticipantLifecycleStateDraining:
+		return to == ParticipantLifecycleStateDisconnected
+	default:
+		return false
+	}
+}
+
+// IsClosing returns true once the participant has started to close
+func (s ParticipantLifecycleState) IsClosing() bool {
+	return s == ParticipantLifecycleStateDraining || s == ParticipantLifecycleStateDisconnected
+}
+
+// ---------------------------------------------
+
 type SubscribedCodecQuality struct {
 	CodecMime string
 	Quality   livekit.VideoQuality
@@ -104,6 +166,8 @@ const (
 	ParticipantCloseReasonDataChannelError
 	ParticipantCloseReasonMigrateCodecMismatch
 	ParticipantCloseReasonSignalSourceClose
+	ParticipantCloseReasonRedirectRequested
+	ParticipantCloseReasonRoomExpired
 )
 
 func (p ParticipantCloseReason) String() string {
@@ -154,6 +218,10 @@ func (p ParticipantCloseReason) String() string {
 		return "MIGRATE_CODEC_MISMATCH"
 	case ParticipantCloseReasonSignalSourceClose:
 		return "SIGNAL_SOURCE_CLOSE"
+	case ParticipantCloseReasonRedirectRequested:
+		return "REDIRECT_REQUESTED"
+	case ParticipantCloseReasonRoomExpired:
+		return "ROOM_EXPIRED"
 	default:
 		return fmt.Sprintf("%d", int(p))
 	}
@@ -172,11 +240,11 @@ func (p ParticipantCloseReason) ToDisconnectReason() livekit.DisconnectReason {
 		return livekit.DisconnectReason_STATE_MISMATCH
 	case ParticipantCloseReasonDuplicateIdentity, ParticipantCloseReasonStale:
 		return livekit.DisconnectReason_DUPLICATE_IDENTITY
-	case ParticipantCloseReasonMigrationRequested, ParticipantCloseReasonMigrationComplete, ParticipantCloseReasonSimulateMigration:
+	case ParticipantCloseReasonMigrationRequested, ParticipantCloseReasonMigrationComplete, ParticipantCloseReasonSimulateMigration, ParticipantCloseReasonRedirectRequested:
 		return livekit.DisconnectReason_MIGRATION
 	case ParticipantCloseReasonServiceRequestRemoveParticipant:
 		return livekit.DisconnectReason_PARTICIPANT_REMOVED
-	case ParticipantCloseReasonServiceRequestDeleteRoom:
+	case ParticipantCloseReasonServiceRequestDeleteRoom, ParticipantCloseReasonRoomExpired:
 		return livekit.DisconnectReason_ROOM_DELETED
 	case ParticipantCloseReasonSimulateNodeFailure, ParticipantCloseReasonSimulateServerLeave:
 		return livekit.DisconnectReason_SERVER_SHUTDOWN
@@ -206,6 +274,7 @@ const (
 	SignallingCloseReasonParticipantClose
 	SignallingCloseReasonDisconnectOnResume
 	SignallingCloseReasonDisconnectOnResumeNoMessages
+	SignallingCloseReasonRedirect
 )
 
 func (s SignallingCloseReason) String() string {
@@ -232,6 +301,8 @@ func (s SignallingCloseReason) String() string {
 		return "DISCONNECT_ON_RESUME"
 	case SignallingCloseReasonDisconnectOnResumeNoMessages:
 		return "DISCONNECT_ON_RESUME_NO_MESSAGES"
+	case SignallingCloseReasonRedirect:
+		return "REDIRECT"
 	default:
 		return fmt.Sprintf("%d", int(s))
 	}
@@ -308,14 +379,29 @@ type LocalParticipant interface {
 	IsClosed() bool
 	IsReady() bool
 	IsDisconnected() bool
+	LifecycleState() ParticipantLifecycleState
 	IsIdle() bool
 	SubscriberAsPrimary() bool
 	GetClientInfo() *livekit.ClientInfo
 	GetClientConfiguration() *livekit.ClientConfiguration
 	GetBufferFactory() *buffer.Factory
+	// GetResourceTracker returns the tracker of the goroutines and timers of the participant, bound to its room
+	GetResourceTracker() *sutils.ResourceTracker
 	GetPlayoutDelayConfig() *livekit.PlayoutDelay
+	// GetMaxTrackBitrate returns the max bitrate of video forwarded to the participant, 0 for no limit
+	GetMaxTrackBitrate() int64
+	// GetSubscribeDefaults returns the settings subscriptions to tracks of the source start with, nil for none
+	GetSubscribeDefaults(source livekit.TrackSource) *config.SubscribeDefaults
+	// GetSubscribeFilter returns the filter of the tracks the participant may subscribe to, nil when not limited
+	GetSubscribeFilter() *routing.SubscribeFilter
+	// GetAPIKey returns the API key the join token of the participant was signed with
+	GetAPIKey() string
 	GetPendingTrack(trackID livekit.TrackID) *livekit.TrackInfo
 	GetICEConnectionDetails() []*ICEConnectionDetails
+	GetICEDiagnostics() []*ICEDiagnostics
+	GetConnectionQualityDetails() *ConnectionQualityDetails
+	GetAVSyncDetails() *AVSyncDetails
+	GetTrackStatsHistory(trackID livekit.TrackID, window time.Duration) []*TrackStatsSeries
 	HasConnected() bool
 
 	SetResponseSink(sink routing.MessageSink)
@@ -336,6 +422,10 @@ type LocalParticipant interface {
 	HandleOffer(sdp webrtc.SessionDescription)
 	AddTrack(req *livekit.AddTrackRequest)
 	SetTrackMuted(trackID livekit.TrackID, muted bool, fromAdmin bool) *livekit.TrackInfo
+	// SetTracksMuted mutes or unmutes published tracks with a single participant update
+	SetTracksMuted(trackIDs []livekit.TrackID, muted bool, fromAdmin bool) []*livekit.TrackInfo
+	BlockAudio(blocked bool)
+	IsAudioBlocked() bool
 
 	HandleAnswer(sdp webrtc.SessionDescription)
 	Negotiate(force bool)
@@ -371,9 +461,20 @@ type LocalParticipant interface {
 	SendRefreshToken(token string) error
 	HandleReconnectAndSendResponse(reconnectReason livekit.ReconnectReason, reconnectResponse *livekit.ReconnectResponse) error
 	IssueFullReconnect(reason ParticipantCloseReason)
+	// IssueRedirect asks the client to resume its session against target with resumeToken, false when there is
+	// no session to resume
+	IssueRedirect(target *livekit.RegionInfo, resumeToken string) bool
+	// MoveToRoom updates the room the participant is granted access to after it has been moved on this node
+	MoveToRoom(roomName livekit.RoomName)
+
+	// EnterOperation admits an operation that must not race with teardown, ok is false once the participant is closing.
+	// done has to be called when the operation completes, Close waits for admitted operations before tearing down.
+	EnterOperation() (done func(), ok bool)
 
 	// callbacks
 	OnStateChange(func(p LocalParticipant, state livekit.ParticipantInfo_State))
+	// AddLifecycleHook adds a hook called after every lifecycle transition, hooks are not replaced
+	AddLifecycleHook(func(p LocalParticipant, from, to ParticipantLifecycleState))
 	OnMigrateStateChange(func(p LocalParticipant, migrateState MigrateState))
 	// OnTrackPublished - remote added a track
 	OnTrackPublished(func(LocalParticipant, MediaTrack))
@@ -413,6 +514,11 @@ type LocalParticipant interface {
 	// down stream bandwidth management
 	SetSubscriberAllowPause(allowPause bool)
 	SetSubscriberChannelCapacity(channelCapacity int64)
+	SetSubscriberBandwidthHint(bandwidthHint int64)
+	GetSubscriberAllocationInfo(maxDecisions int) *streamallocator.AllocationInfo
+	GetSubscriberCongestionTrace() *streamallocator.CongestionTrace
+
+	GetTransportStats(target livekit.SignalTarget) *TransportStats
 
 	GetPacer() pacer.Pacer
 
@@ -483,10 +589,33 @@ type MediaTrack interface {
 
 	Receivers() []sfu.TrackReceiver
 	ClearAllReceivers(willBeResumed bool)
+	// sets up a receiver transcoding the track into one of the codecs, for a subscriber that decodes none of the
+	// published codecs. returns false when the subscriber cannot be given a transcoded receiver
+	TranscodeFallback(codecs []webrtc.RTPCodecParameters) bool
 
 	IsEncrypted() bool
 }
 
+// Transcoder re-encodes the media of a receiver into another codec. It is not built into the server,
+// deployments that need it supply one
+//
+//counterfeiter:generate . Transcoder
+type Transcoder interface {
+	// Transcode returns a receiver of the media of source in codec, it closes when source closes
+	Transcode(source sfu.TrackReceiver, codec webrtc.RTPCodecParameters) (sfu.TrackReceiver, error)
+}
+
+// VideoProcessor modifies the video of tracks delivered to egress, e.g. to watermark or blur it. It decodes the
+// frames of source, modifies them and encodes them again. Egress participants subscribe to the processed video,
+// other subscribers receive the track as published.
+//
+//counterfeiter:generate . VideoProcessor
+type VideoProcessor interface {
+	// Process returns a receiver of the processed video of source, it closes when source closes.
+	// It returns nil for tracks that are not processed
+	Process(source sfu.TrackReceiver, track *livekit.TrackInfo) (sfu.TrackReceiver, error)
+}
+
 //counterfeiter:generate . LocalMediaTrack
 type LocalMediaTrack interface {
 	MediaTrack
@@ -503,6 +632,8 @@ type LocalMediaTrack interface {
 
 	NotifySubscriberNodeMaxQuality(nodeID livekit.NodeID, qualities []SubscribedCodecQuality)
 	NotifySubscriberNodeMediaLoss(nodeID livekit.NodeID, fractionalLoss uint8)
+
+	SetPacketCapture(capture *packetcapture.Capture)
 }
 
 //counterfeiter:generate . SubscribedTrack
@@ -524,6 +655,9 @@ type SubscribedTrack interface {
 	IsMuted() bool
 	SetPublisherMuted(muted bool)
 	UpdateSubscriberSettings(settings *livekit.UpdateTrackSettings, isImmediate bool)
+	// SubscriberRank returns the rank the subscriber gave the track, 1 being its most important track, 0 when not ranked
+	SubscriberRank() uint32
+	OnSubscriberRankChange(f func())
 	// selects appropriate video layer according to subscriber preferences
 	UpdateVideoLayer()
 	NeedsNegotiation() bool
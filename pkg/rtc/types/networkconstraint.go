@@ -0,0 +1,65 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// NetworkConstraint is the probable cause of a poor connection, from the stats of the uplink and the downlink
+// of a participant
+type NetworkConstraint string
+
+const (
+	// not enough media flowed in the last interval to tell
+	NetworkConstraintUnknown NetworkConstraint = "unknown"
+	NetworkConstraintNone    NetworkConstraint = "none"
+	// the streams published by the participant lose packets or arrive late
+	NetworkConstraintUplinkLimited NetworkConstraint = "uplink_limited"
+	// the network of the participant cannot carry the streams it subscribes to
+	NetworkConstraintDownlinkLimited NetworkConstraint = "downlink_limited"
+	// the round trip time grows well above its minimum without losses, packets are queued on the path
+	NetworkConstraintBufferbloat NetworkConstraint = "bufferbloat"
+)
+
+// NetworkPathStats are the stats of one direction of the network path of a participant over the last
+// connection quality interval. For the uplink they are the stats of the published streams, as acknowledged
+// in transport-wide congestion control feedback, for the downlink those reported by the participant in
+// receiver reports
+type NetworkPathStats struct {
+	Packets              uint32  `json:"packets"`
+	PacketLossPercentage float32 `json:"packet_loss_percentage"`
+	BitrateBps           float64 `json:"bitrate_bps"`
+	JitterMs             float64 `json:"jitter_ms"`
+	RTTMs                uint32  `json:"rtt_ms,omitempty"`
+	MinRTTMs             uint32  `json:"min_rtt_ms,omitempty"`
+	// downlink only, estimated by the send side bandwidth estimator from the feedback of the participant
+	EstimateBps int64 `json:"estimate_bps,omitempty"`
+	// downlink only, bandwidth needed by the subscribed streams at their wanted layers
+	ExpectedBps int64 `json:"expected_bps,omitempty"`
+}
+
+// NetworkAsymmetry compares the uplink with the downlink, positive values when the uplink is worse
+type NetworkAsymmetry struct {
+	PacketLossPercentage float32 `json:"packet_loss_percentage"`
+	JitterMs             float64 `json:"jitter_ms"`
+}
+
+type ConnectionQualityDetails struct {
+	Quality    string            `json:"quality"`
+	Score      float32           `json:"score"`
+	Uplink     *NetworkPathStats `json:"uplink,omitempty"`
+	Downlink   *NetworkPathStats `json:"downlink,omitempty"`
+	Asymmetry  *NetworkAsymmetry `json:"asymmetry,omitempty"`
+	Constraint NetworkConstraint `json:"constraint"`
+	// what the participant could change to improve the connection
+	Advice string `json:"advice,omitempty"`
+}
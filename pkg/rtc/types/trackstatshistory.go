@@ -0,0 +1,79 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type TrackStatsDirection string
+
+const (
+	// a track published by the participant, stats of the receiver
+	TrackStatsDirectionUplink TrackStatsDirection = "uplink"
+	// a track subscribed by the participant, stats of the down track
+	TrackStatsDirectionDownlink TrackStatsDirection = "downlink"
+)
+
+// TrackStatsSeries are the snapshots of the RTP stats of a track kept by the participant, oldest first.
+// Stats are cumulative since the start of the stream, rates over a window are the difference of two samples
+type TrackStatsSeries struct {
+	TrackID   livekit.TrackID     `json:"track_id"`
+	Direction TrackStatsDirection `json:"direction"`
+	Samples   []*TrackStatsSample `json:"samples"`
+}
+
+type TrackStatsSample struct {
+	At    time.Time
+	Stats *livekit.RTPStats
+}
+
+// stats are encoded with their protocol names, as in the rest of the API
+
+func (s *TrackStatsSample) MarshalJSON() ([]byte, error) {
+	stats, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(s.Stats)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		At    time.Time       `json:"at"`
+		Stats json.RawMessage `json:"stats"`
+	}{
+		At:    s.At,
+		Stats: stats,
+	})
+}
+
+func (s *TrackStatsSample) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		At    time.Time       `json:"at"`
+		Stats json.RawMessage `json:"stats"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	s.At = aux.At
+	s.Stats = &livekit.RTPStats{}
+	if len(aux.Stats) > 0 && string(aux.Stats) != "null" {
+		return (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(aux.Stats, s.Stats)
+	}
+	return nil
+}
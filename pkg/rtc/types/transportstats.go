@@ -0,0 +1,51 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// TransportStats is a snapshot of the stats of a publisher or subscriber transport, a report keyed by
+// stats id in the dictionaries of the W3C getStats() API
+type TransportStats struct {
+	Transport string             `json:"transport"`
+	Report    webrtc.StatsReport `json:"report"`
+}
+
+// stats are an interface in a report, each one is decoded by its type
+
+func (s *TransportStats) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		Transport string                     `json:"transport"`
+		Report    map[string]json.RawMessage `json:"report"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	s.Transport = aux.Transport
+	s.Report = make(webrtc.StatsReport, len(aux.Report))
+	for id, raw := range aux.Report {
+		stats, err := webrtc.UnmarshalStatsJSON(raw)
+		if err != nil {
+			return err
+		}
+		s.Report[id] = stats
+	}
+	return nil
+}
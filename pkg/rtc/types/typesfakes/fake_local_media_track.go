@@ -6,7 +6,9 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/packetcapture"
 	"github.com/livekit/protocol/livekit"
+	webrtc "github.com/pion/webrtc/v3"
 )
 
 type FakeLocalMediaTrack struct {
@@ -287,6 +289,11 @@ type FakeLocalMediaTrack struct {
 	setMutedArgsForCall []struct {
 		arg1 bool
 	}
+	SetPacketCaptureStub        func(*packetcapture.Capture)
+	setPacketCaptureMutex       sync.RWMutex
+	setPacketCaptureArgsForCall []struct {
+		arg1 *packetcapture.Capture
+	}
 	SetRTTStub        func(uint32)
 	setRTTMutex       sync.RWMutex
 	setRTTArgsForCall []struct {
@@ -332,6 +339,17 @@ type FakeLocalMediaTrack struct {
 	toProtoReturnsOnCall map[int]struct {
 		result1 *livekit.TrackInfo
 	}
+	TranscodeFallbackStub        func([]webrtc.RTPCodecParameters) bool
+	transcodeFallbackMutex       sync.RWMutex
+	transcodeFallbackArgsForCall []struct {
+		arg1 []webrtc.RTPCodecParameters
+	}
+	transcodeFallbackReturns struct {
+		result1 bool
+	}
+	transcodeFallbackReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	UpdateTrackInfoStub        func(*livekit.TrackInfo)
 	updateTrackInfoMutex       sync.RWMutex
 	updateTrackInfoArgsForCall []struct {
@@ -1833,6 +1851,38 @@ func (fake *FakeLocalMediaTrack) SetMutedArgsForCall(i int) bool {
 	return argsForCall.arg1
 }
 
+func (fake *FakeLocalMediaTrack) SetPacketCapture(arg1 *packetcapture.Capture) {
+	fake.setPacketCaptureMutex.Lock()
+	fake.setPacketCaptureArgsForCall = append(fake.setPacketCaptureArgsForCall, struct {
+		arg1 *packetcapture.Capture
+	}{arg1})
+	stub := fake.SetPacketCaptureStub
+	fake.recordInvocation("SetPacketCapture", []interface{}{arg1})
+	fake.setPacketCaptureMutex.Unlock()
+	if stub != nil {
+		fake.SetPacketCaptureStub(arg1)
+	}
+}
+
+func (fake *FakeLocalMediaTrack) SetPacketCaptureCallCount() int {
+	fake.setPacketCaptureMutex.RLock()
+	defer fake.setPacketCaptureMutex.RUnlock()
+	return len(fake.setPacketCaptureArgsForCall)
+}
+
+func (fake *FakeLocalMediaTrack) SetPacketCaptureCalls(stub func(*packetcapture.Capture)) {
+	fake.setPacketCaptureMutex.Lock()
+	defer fake.setPacketCaptureMutex.Unlock()
+	fake.SetPacketCaptureStub = stub
+}
+
+func (fake *FakeLocalMediaTrack) SetPacketCaptureArgsForCall(i int) *packetcapture.Capture {
+	fake.setPacketCaptureMutex.RLock()
+	defer fake.setPacketCaptureMutex.RUnlock()
+	argsForCall := fake.setPacketCaptureArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalMediaTrack) SetRTT(arg1 uint32) {
 	fake.setRTTMutex.Lock()
 	fake.setRTTArgsForCall = append(fake.setRTTArgsForCall, struct {
@@ -2077,6 +2127,72 @@ func (fake *FakeLocalMediaTrack) ToProtoReturnsOnCall(i int, result1 *livekit.Tr
 	}{result1}
 }
 
+func (fake *FakeLocalMediaTrack) TranscodeFallback(arg1 []webrtc.RTPCodecParameters) bool {
+	var arg1Copy []webrtc.RTPCodecParameters
+	if arg1 != nil {
+		arg1Copy = make([]webrtc.RTPCodecParameters, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.transcodeFallbackMutex.Lock()
+	ret, specificReturn := fake.transcodeFallbackReturnsOnCall[len(fake.transcodeFallbackArgsForCall)]
+	fake.transcodeFallbackArgsForCall = append(fake.transcodeFallbackArgsForCall, struct {
+		arg1 []webrtc.RTPCodecParameters
+	}{arg1Copy})
+	stub := fake.TranscodeFallbackStub
+	fakeReturns := fake.transcodeFallbackReturns
+	fake.recordInvocation("TranscodeFallback", []interface{}{arg1Copy})
+	fake.transcodeFallbackMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalMediaTrack) TranscodeFallbackCallCount() int {
+	fake.transcodeFallbackMutex.RLock()
+	defer fake.transcodeFallbackMutex.RUnlock()
+	return len(fake.transcodeFallbackArgsForCall)
+}
+
+func (fake *FakeLocalMediaTrack) TranscodeFallbackCalls(stub func([]webrtc.RTPCodecParameters) bool) {
+	fake.transcodeFallbackMutex.Lock()
+	defer fake.transcodeFallbackMutex.Unlock()
+	fake.TranscodeFallbackStub = stub
+}
+
+func (fake *FakeLocalMediaTrack) TranscodeFallbackArgsForCall(i int) []webrtc.RTPCodecParameters {
+	fake.transcodeFallbackMutex.RLock()
+	defer fake.transcodeFallbackMutex.RUnlock()
+	argsForCall := fake.transcodeFallbackArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLocalMediaTrack) TranscodeFallbackReturns(result1 bool) {
+	fake.transcodeFallbackMutex.Lock()
+	defer fake.transcodeFallbackMutex.Unlock()
+	fake.TranscodeFallbackStub = nil
+	fake.transcodeFallbackReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocalMediaTrack) TranscodeFallbackReturnsOnCall(i int, result1 bool) {
+	fake.transcodeFallbackMutex.Lock()
+	defer fake.transcodeFallbackMutex.Unlock()
+	fake.TranscodeFallbackStub = nil
+	if fake.transcodeFallbackReturnsOnCall == nil {
+		fake.transcodeFallbackReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.transcodeFallbackReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeLocalMediaTrack) UpdateTrackInfo(arg1 *livekit.TrackInfo) {
 	fake.updateTrackInfoMutex.Lock()
 	fake.updateTrackInfoArgsForCall = append(fake.updateTrackInfoArgsForCall, struct {
@@ -2209,6 +2325,8 @@ func (fake *FakeLocalMediaTrack) Invocations() map[string][][]interface{} {
 	defer fake.revokeDisallowedSubscribersMutex.RUnlock()
 	fake.setMutedMutex.RLock()
 	defer fake.setMutedMutex.RUnlock()
+	fake.setPacketCaptureMutex.RLock()
+	defer fake.setPacketCaptureMutex.RUnlock()
 	fake.setRTTMutex.RLock()
 	defer fake.setRTTMutex.RUnlock()
 	fake.signalCidMutex.RLock()
@@ -2219,6 +2337,8 @@ func (fake *FakeLocalMediaTrack) Invocations() map[string][][]interface{} {
 	defer fake.streamMutex.RUnlock()
 	fake.toProtoMutex.RLock()
 	defer fake.toProtoMutex.RUnlock()
+	fake.transcodeFallbackMutex.RLock()
+	defer fake.transcodeFallbackMutex.RUnlock()
 	fake.updateTrackInfoMutex.RLock()
 	defer fake.updateTrackInfoMutex.RUnlock()
 	fake.updateVideoLayersMutex.RLock()
@@ -5,11 +5,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+	utilsa "github.com/livekit/livekit-server/pkg/utils"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
@@ -25,6 +28,11 @@ type FakeLocalParticipant struct {
 		arg1 webrtc.ICECandidateInit
 		arg2 livekit.SignalTarget
 	}
+	AddLifecycleHookStub        func(func(p types.LocalParticipant, from types.ParticipantLifecycleState, to types.ParticipantLifecycleState))
+	addLifecycleHookMutex       sync.RWMutex
+	addLifecycleHookArgsForCall []struct {
+		arg1 func(p types.LocalParticipant, from types.ParticipantLifecycleState, to types.ParticipantLifecycleState)
+	}
 	AddTrackStub        func(*livekit.AddTrackRequest)
 	addTrackMutex       sync.RWMutex
 	addTrackArgsForCall []struct {
@@ -62,6 +70,11 @@ type FakeLocalParticipant struct {
 		result2 *webrtc.RTPTransceiver
 		result3 error
 	}
+	BlockAudioStub        func(bool)
+	blockAudioMutex       sync.RWMutex
+	blockAudioArgsForCall []struct {
+		arg1 bool
+	}
 	CacheDownTrackStub        func(livekit.TrackID, *webrtc.RTPTransceiver, sfu.DownTrackState)
 	cacheDownTrackMutex       sync.RWMutex
 	cacheDownTrackArgsForCall []struct {
@@ -168,6 +181,38 @@ type FakeLocalParticipant struct {
 	debugInfoReturnsOnCall map[int]struct {
 		result1 map[string]interface{}
 	}
+	EnterOperationStub        func() (func(), bool)
+	enterOperationMutex       sync.RWMutex
+	enterOperationArgsForCall []struct {
+	}
+	enterOperationReturns struct {
+		result1 func()
+		result2 bool
+	}
+	enterOperationReturnsOnCall map[int]struct {
+		result1 func()
+		result2 bool
+	}
+	GetAPIKeyStub        func() string
+	getAPIKeyMutex       sync.RWMutex
+	getAPIKeyArgsForCall []struct {
+	}
+	getAPIKeyReturns struct {
+		result1 string
+	}
+	getAPIKeyReturnsOnCall map[int]struct {
+		result1 string
+	}
+	GetAVSyncDetailsStub        func() *types.AVSyncDetails
+	getAVSyncDetailsMutex       sync.RWMutex
+	getAVSyncDetailsArgsForCall []struct {
+	}
+	getAVSyncDetailsReturns struct {
+		result1 *types.AVSyncDetails
+	}
+	getAVSyncDetailsReturnsOnCall map[int]struct {
+		result1 *types.AVSyncDetails
+	}
 	GetAdaptiveStreamStub        func() bool
 	getAdaptiveStreamMutex       sync.RWMutex
 	getAdaptiveStreamArgsForCall []struct {
@@ -243,6 +288,16 @@ type FakeLocalParticipant struct {
 	getConnectionQualityReturnsOnCall map[int]struct {
 		result1 *livekit.ConnectionQualityInfo
 	}
+	GetConnectionQualityDetailsStub        func() *types.ConnectionQualityDetails
+	getConnectionQualityDetailsMutex       sync.RWMutex
+	getConnectionQualityDetailsArgsForCall []struct {
+	}
+	getConnectionQualityDetailsReturns struct {
+		result1 *types.ConnectionQualityDetails
+	}
+	getConnectionQualityDetailsReturnsOnCall map[int]struct {
+		result1 *types.ConnectionQualityDetails
+	}
 	GetICEConnectionDetailsStub        func() []*types.ICEConnectionDetails
 	getICEConnectionDetailsMutex       sync.RWMutex
 	getICEConnectionDetailsArgsForCall []struct {
@@ -253,6 +308,16 @@ type FakeLocalParticipant struct {
 	getICEConnectionDetailsReturnsOnCall map[int]struct {
 		result1 []*types.ICEConnectionDetails
 	}
+	GetICEDiagnosticsStub        func() []*types.ICEDiagnostics
+	getICEDiagnosticsMutex       sync.RWMutex
+	getICEDiagnosticsArgsForCall []struct {
+	}
+	getICEDiagnosticsReturns struct {
+		result1 []*types.ICEDiagnostics
+	}
+	getICEDiagnosticsReturnsOnCall map[int]struct {
+		result1 []*types.ICEDiagnostics
+	}
 	GetLoggerStub        func() logger.Logger
 	getLoggerMutex       sync.RWMutex
 	getLoggerArgsForCall []struct {
@@ -263,6 +328,16 @@ type FakeLocalParticipant struct {
 	getLoggerReturnsOnCall map[int]struct {
 		result1 logger.Logger
 	}
+	GetMaxTrackBitrateStub        func() int64
+	getMaxTrackBitrateMutex       sync.RWMutex
+	getMaxTrackBitrateArgsForCall []struct {
+	}
+	getMaxTrackBitrateReturns struct {
+		result1 int64
+	}
+	getMaxTrackBitrateReturnsOnCall map[int]struct {
+		result1 int64
+	}
 	GetPacerStub        func() pacer.Pacer
 	getPacerMutex       sync.RWMutex
 	getPacerArgsForCall []struct {
@@ -315,6 +390,37 @@ type FakeLocalParticipant struct {
 	getPublishedTracksReturnsOnCall map[int]struct {
 		result1 []types.MediaTrack
 	}
+	GetResourceTrackerStub        func() *utilsa.ResourceTracker
+	getResourceTrackerMutex       sync.RWMutex
+	getResourceTrackerArgsForCall []struct {
+	}
+	getResourceTrackerReturns struct {
+		result1 *utilsa.ResourceTracker
+	}
+	getResourceTrackerReturnsOnCall map[int]struct {
+		result1 *utilsa.ResourceTracker
+	}
+	GetSubscribeDefaultsStub        func(livekit.TrackSource) *config.SubscribeDefaults
+	getSubscribeDefaultsMutex       sync.RWMutex
+	getSubscribeDefaultsArgsForCall []struct {
+		arg1 livekit.TrackSource
+	}
+	getSubscribeDefaultsReturns struct {
+		result1 *config.SubscribeDefaults
+	}
+	getSubscribeDefaultsReturnsOnCall map[int]struct {
+		result1 *config.SubscribeDefaults
+	}
+	GetSubscribeFilterStub        func() *routing.SubscribeFilter
+	getSubscribeFilterMutex       sync.RWMutex
+	getSubscribeFilterArgsForCall []struct {
+	}
+	getSubscribeFilterReturns struct {
+		result1 *routing.SubscribeFilter
+	}
+	getSubscribeFilterReturnsOnCall map[int]struct {
+		result1 *routing.SubscribeFilter
+	}
 	GetSubscribedParticipantsStub        func() []livekit.ParticipantID
 	getSubscribedParticipantsMutex       sync.RWMutex
 	getSubscribedParticipantsArgsForCall []struct {
@@ -335,6 +441,39 @@ type FakeLocalParticipant struct {
 	getSubscribedTracksReturnsOnCall map[int]struct {
 		result1 []types.SubscribedTrack
 	}
+	GetSubscriberAllocationInfoStub        func(int) *streamallocator.AllocationInfo
+	getSubscriberAllocationInfoMutex       sync.RWMutex
+	getSubscriberAllocationInfoArgsForCall []struct {
+		arg1 int
+	}
+	getSubscriberAllocationInfoReturns struct {
+		result1 *streamallocator.AllocationInfo
+	}
+	getSubscriberAllocationInfoReturnsOnCall map[int]struct {
+		result1 *streamallocator.AllocationInfo
+	}
+	GetSubscriberCongestionTraceStub        func() *streamallocator.CongestionTrace
+	getSubscriberCongestionTraceMutex       sync.RWMutex
+	getSubscriberCongestionTraceArgsForCall []struct {
+	}
+	getSubscriberCongestionTraceReturns struct {
+		result1 *streamallocator.CongestionTrace
+	}
+	getSubscriberCongestionTraceReturnsOnCall map[int]struct {
+		result1 *streamallocator.CongestionTrace
+	}
+	GetTrackStatsHistoryStub        func(livekit.TrackID, time.Duration) []*types.TrackStatsSeries
+	getTrackStatsHistoryMutex       sync.RWMutex
+	getTrackStatsHistoryArgsForCall []struct {
+		arg1 livekit.TrackID
+		arg2 time.Duration
+	}
+	getTrackStatsHistoryReturns struct {
+		result1 []*types.TrackStatsSeries
+	}
+	getTrackStatsHistoryReturnsOnCall map[int]struct {
+		result1 []*types.TrackStatsSeries
+	}
 	GetTrafficLoadStub        func() *types.TrafficLoad
 	getTrafficLoadMutex       sync.RWMutex
 	getTrafficLoadArgsForCall []struct {
@@ -355,6 +494,17 @@ type FakeLocalParticipant struct {
 	getTrailerReturnsOnCall map[int]struct {
 		result1 []byte
 	}
+	GetTransportStatsStub        func(livekit.SignalTarget) *types.TransportStats
+	getTransportStatsMutex       sync.RWMutex
+	getTransportStatsArgsForCall []struct {
+		arg1 livekit.SignalTarget
+	}
+	getTransportStatsReturns struct {
+		result1 *types.TransportStats
+	}
+	getTransportStatsReturnsOnCall map[int]struct {
+		result1 *types.TransportStats
+	}
 	HandleAnswerStub        func(webrtc.SessionDescription)
 	handleAnswerMutex       sync.RWMutex
 	handleAnswerArgsForCall []struct {
@@ -454,6 +604,16 @@ type FakeLocalParticipant struct {
 	isAgentReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	IsAudioBlockedStub        func() bool
+	isAudioBlockedMutex       sync.RWMutex
+	isAudioBlockedArgsForCall []struct {
+	}
+	isAudioBlockedReturns struct {
+		result1 bool
+	}
+	isAudioBlockedReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	IsClosedStub        func() bool
 	isClosedMutex       sync.RWMutex
 	isClosedArgsForCall []struct {
@@ -530,6 +690,28 @@ type FakeLocalParticipant struct {
 	issueFullReconnectArgsForCall []struct {
 		arg1 types.ParticipantCloseReason
 	}
+	IssueRedirectStub        func(*livekit.RegionInfo, string) bool
+	issueRedirectMutex       sync.RWMutex
+	issueRedirectArgsForCall []struct {
+		arg1 *livekit.RegionInfo
+		arg2 string
+	}
+	issueRedirectReturns struct {
+		result1 bool
+	}
+	issueRedirectReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	LifecycleStateStub        func() types.ParticipantLifecycleState
+	lifecycleStateMutex       sync.RWMutex
+	lifecycleStateArgsForCall []struct {
+	}
+	lifecycleStateReturns struct {
+		result1 types.ParticipantLifecycleState
+	}
+	lifecycleStateReturnsOnCall map[int]struct {
+		result1 types.ParticipantLifecycleState
+	}
 	MaybeStartMigrationStub        func(bool, func()) bool
 	maybeStartMigrationMutex       sync.RWMutex
 	maybeStartMigrationArgsForCall []struct {
@@ -552,6 +734,11 @@ type FakeLocalParticipant struct {
 	migrateStateReturnsOnCall map[int]struct {
 		result1 types.MigrateState
 	}
+	MoveToRoomStub        func(livekit.RoomName)
+	moveToRoomMutex       sync.RWMutex
+	moveToRoomArgsForCall []struct {
+		arg1 livekit.RoomName
+	}
 	NegotiateStub        func(bool)
 	negotiateMutex       sync.RWMutex
 	negotiateArgsForCall []struct {
@@ -777,6 +964,11 @@ type FakeLocalParticipant struct {
 	setSubscriberAllowPauseArgsForCall []struct {
 		arg1 bool
 	}
+	SetSubscriberBandwidthHintStub        func(int64)
+	setSubscriberBandwidthHintMutex       sync.RWMutex
+	setSubscriberBandwidthHintArgsForCall []struct {
+		arg1 int64
+	}
 	SetSubscriberChannelCapacityStub        func(int64)
 	setSubscriberChannelCapacityMutex       sync.RWMutex
 	setSubscriberChannelCapacityArgsForCall []struct {
@@ -795,6 +987,19 @@ type FakeLocalParticipant struct {
 	setTrackMutedReturnsOnCall map[int]struct {
 		result1 *livekit.TrackInfo
 	}
+	SetTracksMutedStub        func([]livekit.TrackID, bool, bool) []*livekit.TrackInfo
+	setTracksMutedMutex       sync.RWMutex
+	setTracksMutedArgsForCall []struct {
+		arg1 []livekit.TrackID
+		arg2 bool
+		arg3 bool
+	}
+	setTracksMutedReturns struct {
+		result1 []*livekit.TrackInfo
+	}
+	setTracksMutedReturnsOnCall map[int]struct {
+		result1 []*livekit.TrackInfo
+	}
 	StateStub        func() livekit.ParticipantInfo_State
 	stateMutex       sync.RWMutex
 	stateArgsForCall []struct {
@@ -1016,6 +1221,38 @@ func (fake *FakeLocalParticipant) AddICECandidateArgsForCall(i int) (webrtc.ICEC
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeLocalParticipant) AddLifecycleHook(arg1 func(p types.LocalParticipant, from types.ParticipantLifecycleState, to types.ParticipantLifecycleState)) {
+	fake.addLifecycleHookMutex.Lock()
+	fake.addLifecycleHookArgsForCall = append(fake.addLifecycleHookArgsForCall, struct {
+		arg1 func(p types.LocalParticipant, from types.ParticipantLifecycleState, to types.ParticipantLifecycleState)
+	}{arg1})
+	stub := fake.AddLifecycleHookStub
+	fake.recordInvocation("AddLifecycleHook", []interface{}{arg1})
+	fake.addLifecycleHookMutex.Unlock()
+	if stub != nil {
+		fake.AddLifecycleHookStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) AddLifecycleHookCallCount() int {
+	fake.addLifecycleHookMutex.RLock()
+	defer fake.addLifecycleHookMutex.RUnlock()
+	return len(fake.addLifecycleHookArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) AddLifecycleHookCalls(stub func(func(p types.LocalParticipant, from types.ParticipantLifecycleState, to types.ParticipantLifecycleState))) {
+	fake.addLifecycleHookMutex.Lock()
+	defer fake.addLifecycleHookMutex.Unlock()
+	fake.AddLifecycleHookStub = stub
+}
+
+func (fake *FakeLocalParticipant) AddLifecycleHookArgsForCall(i int) func(p types.LocalParticipant, from types.ParticipantLifecycleState, to types.ParticipantLifecycleState) {
+	fake.addLifecycleHookMutex.RLock()
+	defer fake.addLifecycleHookMutex.RUnlock()
+	argsForCall := fake.addLifecycleHookArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) AddTrack(arg1 *livekit.AddTrackRequest) {
 	fake.addTrackMutex.Lock()
 	fake.addTrackArgsForCall = append(fake.addTrackArgsForCall, struct {
@@ -1184,6 +1421,38 @@ func (fake *FakeLocalParticipant) AddTransceiverFromTrackToSubscriberReturnsOnCa
 	}{result1, result2, result3}
 }
 
+func (fake *FakeLocalParticipant) BlockAudio(arg1 bool) {
+	fake.blockAudioMutex.Lock()
+	fake.blockAudioArgsForCall = append(fake.blockAudioArgsForCall, struct {
+		arg1 bool
+	}{arg1})
+	stub := fake.BlockAudioStub
+	fake.recordInvocation("BlockAudio", []interface{}{arg1})
+	fake.blockAudioMutex.Unlock()
+	if stub != nil {
+		fake.BlockAudioStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) BlockAudioCallCount() int {
+	fake.blockAudioMutex.RLock()
+	defer fake.blockAudioMutex.RUnlock()
+	return len(fake.blockAudioArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) BlockAudioCalls(stub func(bool)) {
+	fake.blockAudioMutex.Lock()
+	defer fake.blockAudioMutex.Unlock()
+	fake.BlockAudioStub = stub
+}
+
+func (fake *FakeLocalParticipant) BlockAudioArgsForCall(i int) bool {
+	fake.blockAudioMutex.RLock()
+	defer fake.blockAudioMutex.RUnlock()
+	argsForCall := fake.blockAudioArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) CacheDownTrack(arg1 livekit.TrackID, arg2 *webrtc.RTPTransceiver, arg3 sfu.DownTrackState) {
 	fake.cacheDownTrackMutex.Lock()
 	fake.cacheDownTrackArgsForCall = append(fake.cacheDownTrackArgsForCall, struct {
@@ -1745,6 +2014,168 @@ func (fake *FakeLocalParticipant) DebugInfoReturnsOnCall(i int, result1 map[stri
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) EnterOperation() (func(), bool) {
+	fake.enterOperationMutex.Lock()
+	ret, specificReturn := fake.enterOperationReturnsOnCall[len(fake.enterOperationArgsForCall)]
+	fake.enterOperationArgsForCall = append(fake.enterOperationArgsForCall, struct {
+	}{})
+	stub := fake.EnterOperationStub
+	fakeReturns := fake.enterOperationReturns
+	fake.recordInvocation("EnterOperation", []interface{}{})
+	fake.enterOperationMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLocalParticipant) EnterOperationCallCount() int {
+	fake.enterOperationMutex.RLock()
+	defer fake.enterOperationMutex.RUnlock()
+	return len(fake.enterOperationArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) EnterOperationCalls(stub func() (func(), bool)) {
+	fake.enterOperationMutex.Lock()
+	defer fake.enterOperationMutex.Unlock()
+	fake.EnterOperationStub = stub
+}
+
+func (fake *FakeLocalParticipant) EnterOperationReturns(result1 func(), result2 bool) {
+	fake.enterOperationMutex.Lock()
+	defer fake.enterOperationMutex.Unlock()
+	fake.EnterOperationStub = nil
+	fake.enterOperationReturns = struct {
+		result1 func()
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeLocalParticipant) EnterOperationReturnsOnCall(i int, result1 func(), result2 bool) {
+	fake.enterOperationMutex.Lock()
+	defer fake.enterOperationMutex.Unlock()
+	fake.EnterOperationStub = nil
+	if fake.enterOperationReturnsOnCall == nil {
+		fake.enterOperationReturnsOnCall = make(map[int]struct {
+			result1 func()
+			result2 bool
+		})
+	}
+	fake.enterOperationReturnsOnCall[i] = struct {
+		result1 func()
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeLocalParticipant) GetAPIKey() string {
+	fake.getAPIKeyMutex.Lock()
+	ret, specificReturn := fake.getAPIKeyReturnsOnCall[len(fake.getAPIKeyArgsForCall)]
+	fake.getAPIKeyArgsForCall = append(fake.getAPIKeyArgsForCall, struct {
+	}{})
+	stub := fake.GetAPIKeyStub
+	fakeReturns := fake.getAPIKeyReturns
+	fake.recordInvocation("GetAPIKey", []interface{}{})
+	fake.getAPIKeyMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetAPIKeyCallCount() int {
+	fake.getAPIKeyMutex.RLock()
+	defer fake.getAPIKeyMutex.RUnlock()
+	return len(fake.getAPIKeyArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetAPIKeyCalls(stub func() string) {
+	fake.getAPIKeyMutex.Lock()
+	defer fake.getAPIKeyMutex.Unlock()
+	fake.GetAPIKeyStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetAPIKeyReturns(result1 string) {
+	fake.getAPIKeyMutex.Lock()
+	defer fake.getAPIKeyMutex.Unlock()
+	fake.GetAPIKeyStub = nil
+	fake.getAPIKeyReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetAPIKeyReturnsOnCall(i int, result1 string) {
+	fake.getAPIKeyMutex.Lock()
+	defer fake.getAPIKeyMutex.Unlock()
+	fake.GetAPIKeyStub = nil
+	if fake.getAPIKeyReturnsOnCall == nil {
+		fake.getAPIKeyReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.getAPIKeyReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetAVSyncDetails() *types.AVSyncDetails {
+	fake.getAVSyncDetailsMutex.Lock()
+	ret, specificReturn := fake.getAVSyncDetailsReturnsOnCall[len(fake.getAVSyncDetailsArgsForCall)]
+	fake.getAVSyncDetailsArgsForCall = append(fake.getAVSyncDetailsArgsForCall, struct {
+	}{})
+	stub := fake.GetAVSyncDetailsStub
+	fakeReturns := fake.getAVSyncDetailsReturns
+	fake.recordInvocation("GetAVSyncDetails", []interface{}{})
+	fake.getAVSyncDetailsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetAVSyncDetailsCallCount() int {
+	fake.getAVSyncDetailsMutex.RLock()
+	defer fake.getAVSyncDetailsMutex.RUnlock()
+	return len(fake.getAVSyncDetailsArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetAVSyncDetailsCalls(stub func() *types.AVSyncDetails) {
+	fake.getAVSyncDetailsMutex.Lock()
+	defer fake.getAVSyncDetailsMutex.Unlock()
+	fake.GetAVSyncDetailsStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetAVSyncDetailsReturns(result1 *types.AVSyncDetails) {
+	fake.getAVSyncDetailsMutex.Lock()
+	defer fake.getAVSyncDetailsMutex.Unlock()
+	fake.GetAVSyncDetailsStub = nil
+	fake.getAVSyncDetailsReturns = struct {
+		result1 *types.AVSyncDetails
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetAVSyncDetailsReturnsOnCall(i int, result1 *types.AVSyncDetails) {
+	fake.getAVSyncDetailsMutex.Lock()
+	defer fake.getAVSyncDetailsMutex.Unlock()
+	fake.GetAVSyncDetailsStub = nil
+	if fake.getAVSyncDetailsReturnsOnCall == nil {
+		fake.getAVSyncDetailsReturnsOnCall = make(map[int]struct {
+			result1 *types.AVSyncDetails
+		})
+	}
+	fake.getAVSyncDetailsReturnsOnCall[i] = struct {
+		result1 *types.AVSyncDetails
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) GetAdaptiveStream() bool {
 	fake.getAdaptiveStreamMutex.Lock()
 	ret, specificReturn := fake.getAdaptiveStreamReturnsOnCall[len(fake.getAdaptiveStreamArgsForCall)]
@@ -2130,6 +2561,59 @@ func (fake *FakeLocalParticipant) GetConnectionQualityReturnsOnCall(i int, resul
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) GetConnectionQualityDetails() *types.ConnectionQualityDetails {
+	fake.getConnectionQualityDetailsMutex.Lock()
+	ret, specificReturn := fake.getConnectionQualityDetailsReturnsOnCall[len(fake.getConnectionQualityDetailsArgsForCall)]
+	fake.getConnectionQualityDetailsArgsForCall = append(fake.getConnectionQualityDetailsArgsForCall, struct {
+	}{})
+	stub := fake.GetConnectionQualityDetailsStub
+	fakeReturns := fake.getConnectionQualityDetailsReturns
+	fake.recordInvocation("GetConnectionQualityDetails", []interface{}{})
+	fake.getConnectionQualityDetailsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetConnectionQualityDetailsCallCount() int {
+	fake.getConnectionQualityDetailsMutex.RLock()
+	defer fake.getConnectionQualityDetailsMutex.RUnlock()
+	return len(fake.getConnectionQualityDetailsArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetConnectionQualityDetailsCalls(stub func() *types.ConnectionQualityDetails) {
+	fake.getConnectionQualityDetailsMutex.Lock()
+	defer fake.getConnectionQualityDetailsMutex.Unlock()
+	fake.GetConnectionQualityDetailsStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetConnectionQualityDetailsReturns(result1 *types.ConnectionQualityDetails) {
+	fake.getConnectionQualityDetailsMutex.Lock()
+	defer fake.getConnectionQualityDetailsMutex.Unlock()
+	fake.GetConnectionQualityDetailsStub = nil
+	fake.getConnectionQualityDetailsReturns = struct {
+		result1 *types.ConnectionQualityDetails
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetConnectionQualityDetailsReturnsOnCall(i int, result1 *types.ConnectionQualityDetails) {
+	fake.getConnectionQualityDetailsMutex.Lock()
+	defer fake.getConnectionQualityDetailsMutex.Unlock()
+	fake.GetConnectionQualityDetailsStub = nil
+	if fake.getConnectionQualityDetailsReturnsOnCall == nil {
+		fake.getConnectionQualityDetailsReturnsOnCall = make(map[int]struct {
+			result1 *types.ConnectionQualityDetails
+		})
+	}
+	fake.getConnectionQualityDetailsReturnsOnCall[i] = struct {
+		result1 *types.ConnectionQualityDetails
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) GetICEConnectionDetails() []*types.ICEConnectionDetails {
 	fake.getICEConnectionDetailsMutex.Lock()
 	ret, specificReturn := fake.getICEConnectionDetailsReturnsOnCall[len(fake.getICEConnectionDetailsArgsForCall)]
@@ -2183,6 +2667,59 @@ func (fake *FakeLocalParticipant) GetICEConnectionDetailsReturnsOnCall(i int, re
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) GetICEDiagnostics() []*types.ICEDiagnostics {
+	fake.getICEDiagnosticsMutex.Lock()
+	ret, specificReturn := fake.getICEDiagnosticsReturnsOnCall[len(fake.getICEDiagnosticsArgsForCall)]
+	fake.getICEDiagnosticsArgsForCall = append(fake.getICEDiagnosticsArgsForCall, struct {
+	}{})
+	stub := fake.GetICEDiagnosticsStub
+	fakeReturns := fake.getICEDiagnosticsReturns
+	fake.recordInvocation("GetICEDiagnostics", []interface{}{})
+	fake.getICEDiagnosticsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetICEDiagnosticsCallCount() int {
+	fake.getICEDiagnosticsMutex.RLock()
+	defer fake.getICEDiagnosticsMutex.RUnlock()
+	return len(fake.getICEDiagnosticsArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetICEDiagnosticsCalls(stub func() []*types.ICEDiagnostics) {
+	fake.getICEDiagnosticsMutex.Lock()
+	defer fake.getICEDiagnosticsMutex.Unlock()
+	fake.GetICEDiagnosticsStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetICEDiagnosticsReturns(result1 []*types.ICEDiagnostics) {
+	fake.getICEDiagnosticsMutex.Lock()
+	defer fake.getICEDiagnosticsMutex.Unlock()
+	fake.GetICEDiagnosticsStub = nil
+	fake.getICEDiagnosticsReturns = struct {
+		result1 []*types.ICEDiagnostics
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetICEDiagnosticsReturnsOnCall(i int, result1 []*types.ICEDiagnostics) {
+	fake.getICEDiagnosticsMutex.Lock()
+	defer fake.getICEDiagnosticsMutex.Unlock()
+	fake.GetICEDiagnosticsStub = nil
+	if fake.getICEDiagnosticsReturnsOnCall == nil {
+		fake.getICEDiagnosticsReturnsOnCall = make(map[int]struct {
+			result1 []*types.ICEDiagnostics
+		})
+	}
+	fake.getICEDiagnosticsReturnsOnCall[i] = struct {
+		result1 []*types.ICEDiagnostics
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) GetLogger() logger.Logger {
 	fake.getLoggerMutex.Lock()
 	ret, specificReturn := fake.getLoggerReturnsOnCall[len(fake.getLoggerArgsForCall)]
@@ -2236,6 +2773,59 @@ func (fake *FakeLocalParticipant) GetLoggerReturnsOnCall(i int, result1 logger.L
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) GetMaxTrackBitrate() int64 {
+	fake.getMaxTrackBitrateMutex.Lock()
+	ret, specificReturn := fake.getMaxTrackBitrateReturnsOnCall[len(fake.getMaxTrackBitrateArgsForCall)]
+	fake.getMaxTrackBitrateArgsForCall = append(fake.getMaxTrackBitrateArgsForCall, struct {
+	}{})
+	stub := fake.GetMaxTrackBitrateStub
+	fakeReturns := fake.getMaxTrackBitrateReturns
+	fake.recordInvocation("GetMaxTrackBitrate", []interface{}{})
+	fake.getMaxTrackBitrateMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetMaxTrackBitrateCallCount() int {
+	fake.getMaxTrackBitrateMutex.RLock()
+	defer fake.getMaxTrackBitrateMutex.RUnlock()
+	return len(fake.getMaxTrackBitrateArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetMaxTrackBitrateCalls(stub func() int64) {
+	fake.getMaxTrackBitrateMutex.Lock()
+	defer fake.getMaxTrackBitrateMutex.Unlock()
+	fake.GetMaxTrackBitrateStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetMaxTrackBitrateReturns(result1 int64) {
+	fake.getMaxTrackBitrateMutex.Lock()
+	defer fake.getMaxTrackBitrateMutex.Unlock()
+	fake.GetMaxTrackBitrateStub = nil
+	fake.getMaxTrackBitrateReturns = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetMaxTrackBitrateReturnsOnCall(i int, result1 int64) {
+	fake.getMaxTrackBitrateMutex.Lock()
+	defer fake.getMaxTrackBitrateMutex.Unlock()
+	fake.GetMaxTrackBitrateStub = nil
+	if fake.getMaxTrackBitrateReturnsOnCall == nil {
+		fake.getMaxTrackBitrateReturnsOnCall = make(map[int]struct {
+			result1 int64
+		})
+	}
+	fake.getMaxTrackBitrateReturnsOnCall[i] = struct {
+		result1 int64
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) GetPacer() pacer.Pacer {
 	fake.getPacerMutex.Lock()
 	ret, specificReturn := fake.getPacerReturnsOnCall[len(fake.getPacerArgsForCall)]
@@ -2469,10 +3059,177 @@ func (fake *FakeLocalParticipant) GetPublishedTracks() []types.MediaTrack {
 	ret, specificReturn := fake.getPublishedTracksReturnsOnCall[len(fake.getPublishedTracksArgsForCall)]
 	fake.getPublishedTracksArgsForCall = append(fake.getPublishedTracksArgsForCall, struct {
 	}{})
-	stub := fake.GetPublishedTracksStub
-	fakeReturns := fake.getPublishedTracksReturns
-	fake.recordInvocation("GetPublishedTracks", []interface{}{})
-	fake.getPublishedTracksMutex.Unlock()
+	stub := fake.GetPublishedTracksStub
+	fakeReturns := fake.getPublishedTracksReturns
+	fake.recordInvocation("GetPublishedTracks", []interface{}{})
+	fake.getPublishedTracksMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetPublishedTracksCallCount() int {
+	fake.getPublishedTracksMutex.RLock()
+	defer fake.getPublishedTracksMutex.RUnlock()
+	return len(fake.getPublishedTracksArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetPublishedTracksCalls(stub func() []types.MediaTrack) {
+	fake.getPublishedTracksMutex.Lock()
+	defer fake.getPublishedTracksMutex.Unlock()
+	fake.GetPublishedTracksStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetPublishedTracksReturns(result1 []types.MediaTrack) {
+	fake.getPublishedTracksMutex.Lock()
+	defer fake.getPublishedTracksMutex.Unlock()
+	fake.GetPublishedTracksStub = nil
+	fake.getPublishedTracksReturns = struct {
+		result1 []types.MediaTrack
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetPublishedTracksReturnsOnCall(i int, result1 []types.MediaTrack) {
+	fake.getPublishedTracksMutex.Lock()
+	defer fake.getPublishedTracksMutex.Unlock()
+	fake.GetPublishedTracksStub = nil
+	if fake.getPublishedTracksReturnsOnCall == nil {
+		fake.getPublishedTracksReturnsOnCall = make(map[int]struct {
+			result1 []types.MediaTrack
+		})
+	}
+	fake.getPublishedTracksReturnsOnCall[i] = struct {
+		result1 []types.MediaTrack
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetResourceTracker() *utilsa.ResourceTracker {
+	fake.getResourceTrackerMutex.Lock()
+	ret, specificReturn := fake.getResourceTrackerReturnsOnCall[len(fake.getResourceTrackerArgsForCall)]
+	fake.getResourceTrackerArgsForCall = append(fake.getResourceTrackerArgsForCall, struct {
+	}{})
+	stub := fake.GetResourceTrackerStub
+	fakeReturns := fake.getResourceTrackerReturns
+	fake.recordInvocation("GetResourceTracker", []interface{}{})
+	fake.getResourceTrackerMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetResourceTrackerCallCount() int {
+	fake.getResourceTrackerMutex.RLock()
+	defer fake.getResourceTrackerMutex.RUnlock()
+	return len(fake.getResourceTrackerArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetResourceTrackerCalls(stub func() *utilsa.ResourceTracker) {
+	fake.getResourceTrackerMutex.Lock()
+	defer fake.getResourceTrackerMutex.Unlock()
+	fake.GetResourceTrackerStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetResourceTrackerReturns(result1 *utilsa.ResourceTracker) {
+	fake.getResourceTrackerMutex.Lock()
+	defer fake.getResourceTrackerMutex.Unlock()
+	fake.GetResourceTrackerStub = nil
+	fake.getResourceTrackerReturns = struct {
+		result1 *utilsa.ResourceTracker
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetResourceTrackerReturnsOnCall(i int, result1 *utilsa.ResourceTracker) {
+	fake.getResourceTrackerMutex.Lock()
+	defer fake.getResourceTrackerMutex.Unlock()
+	fake.GetResourceTrackerStub = nil
+	if fake.getResourceTrackerReturnsOnCall == nil {
+		fake.getResourceTrackerReturnsOnCall = make(map[int]struct {
+			result1 *utilsa.ResourceTracker
+		})
+	}
+	fake.getResourceTrackerReturnsOnCall[i] = struct {
+		result1 *utilsa.ResourceTracker
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetSubscribeDefaults(arg1 livekit.TrackSource) *config.SubscribeDefaults {
+	fake.getSubscribeDefaultsMutex.Lock()
+	ret, specificReturn := fake.getSubscribeDefaultsReturnsOnCall[len(fake.getSubscribeDefaultsArgsForCall)]
+	fake.getSubscribeDefaultsArgsForCall = append(fake.getSubscribeDefaultsArgsForCall, struct {
+		arg1 livekit.TrackSource
+	}{arg1})
+	stub := fake.GetSubscribeDefaultsStub
+	fakeReturns := fake.getSubscribeDefaultsReturns
+	fake.recordInvocation("GetSubscribeDefaults", []interface{}{arg1})
+	fake.getSubscribeDefaultsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetSubscribeDefaultsCallCount() int {
+	fake.getSubscribeDefaultsMutex.RLock()
+	defer fake.getSubscribeDefaultsMutex.RUnlock()
+	return len(fake.getSubscribeDefaultsArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetSubscribeDefaultsCalls(stub func(livekit.TrackSource) *config.SubscribeDefaults) {
+	fake.getSubscribeDefaultsMutex.Lock()
+	defer fake.getSubscribeDefaultsMutex.Unlock()
+	fake.GetSubscribeDefaultsStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetSubscribeDefaultsArgsForCall(i int) livekit.TrackSource {
+	fake.getSubscribeDefaultsMutex.RLock()
+	defer fake.getSubscribeDefaultsMutex.RUnlock()
+	argsForCall := fake.getSubscribeDefaultsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLocalParticipant) GetSubscribeDefaultsReturns(result1 *config.SubscribeDefaults) {
+	fake.getSubscribeDefaultsMutex.Lock()
+	defer fake.getSubscribeDefaultsMutex.Unlock()
+	fake.GetSubscribeDefaultsStub = nil
+	fake.getSubscribeDefaultsReturns = struct {
+		result1 *config.SubscribeDefaults
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetSubscribeDefaultsReturnsOnCall(i int, result1 *config.SubscribeDefaults) {
+	fake.getSubscribeDefaultsMutex.Lock()
+	defer fake.getSubscribeDefaultsMutex.Unlock()
+	fake.GetSubscribeDefaultsStub = nil
+	if fake.getSubscribeDefaultsReturnsOnCall == nil {
+		fake.getSubscribeDefaultsReturnsOnCall = make(map[int]struct {
+			result1 *config.SubscribeDefaults
+		})
+	}
+	fake.getSubscribeDefaultsReturnsOnCall[i] = struct {
+		result1 *config.SubscribeDefaults
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetSubscribeFilter() *routing.SubscribeFilter {
+	fake.getSubscribeFilterMutex.Lock()
+	ret, specificReturn := fake.getSubscribeFilterReturnsOnCall[len(fake.getSubscribeFilterArgsForCall)]
+	fake.getSubscribeFilterArgsForCall = append(fake.getSubscribeFilterArgsForCall, struct {
+	}{})
+	stub := fake.GetSubscribeFilterStub
+	fakeReturns := fake.getSubscribeFilterReturns
+	fake.recordInvocation("GetSubscribeFilter", []interface{}{})
+	fake.getSubscribeFilterMutex.Unlock()
 	if stub != nil {
 		return stub()
 	}
@@ -2482,38 +3239,38 @@ func (fake *FakeLocalParticipant) GetPublishedTracks() []types.MediaTrack {
 	return fakeReturns.result1
 }
 
-func (fake *FakeLocalParticipant) GetPublishedTracksCallCount() int {
-	fake.getPublishedTracksMutex.RLock()
-	defer fake.getPublishedTracksMutex.RUnlock()
-	return len(fake.getPublishedTracksArgsForCall)
+func (fake *FakeLocalParticipant) GetSubscribeFilterCallCount() int {
+	fake.getSubscribeFilterMutex.RLock()
+	defer fake.getSubscribeFilterMutex.RUnlock()
+	return len(fake.getSubscribeFilterArgsForCall)
 }
 
-func (fake *FakeLocalParticipant) GetPublishedTracksCalls(stub func() []types.MediaTrack) {
-	fake.getPublishedTracksMutex.Lock()
-	defer fake.getPublishedTracksMutex.Unlock()
-	fake.GetPublishedTracksStub = stub
+func (fake *FakeLocalParticipant) GetSubscribeFilterCalls(stub func() *routing.SubscribeFilter) {
+	fake.getSubscribeFilterMutex.Lock()
+	defer fake.getSubscribeFilterMutex.Unlock()
+	fake.GetSubscribeFilterStub = stub
 }
 
-func (fake *FakeLocalParticipant) GetPublishedTracksReturns(result1 []types.MediaTrack) {
-	fake.getPublishedTracksMutex.Lock()
-	defer fake.getPublishedTracksMutex.Unlock()
-	fake.GetPublishedTracksStub = nil
-	fake.getPublishedTracksReturns = struct {
-		result1 []types.MediaTrack
+func (fake *FakeLocalParticipant) GetSubscribeFilterReturns(result1 *routing.SubscribeFilter) {
+	fake.getSubscribeFilterMutex.Lock()
+	defer fake.getSubscribeFilterMutex.Unlock()
+	fake.GetSubscribeFilterStub = nil
+	fake.getSubscribeFilterReturns = struct {
+		result1 *routing.SubscribeFilter
 	}{result1}
 }
 
-func (fake *FakeLocalParticipant) GetPublishedTracksReturnsOnCall(i int, result1 []types.MediaTrack) {
-	fake.getPublishedTracksMutex.Lock()
-	defer fake.getPublishedTracksMutex.Unlock()
-	fake.GetPublishedTracksStub = nil
-	if fake.getPublishedTracksReturnsOnCall == nil {
-		fake.getPublishedTracksReturnsOnCall = make(map[int]struct {
-			result1 []types.MediaTrack
+func (fake *FakeLocalParticipant) GetSubscribeFilterReturnsOnCall(i int, result1 *routing.SubscribeFilter) {
+	fake.getSubscribeFilterMutex.Lock()
+	defer fake.getSubscribeFilterMutex.Unlock()
+	fake.GetSubscribeFilterStub = nil
+	if fake.getSubscribeFilterReturnsOnCall == nil {
+		fake.getSubscribeFilterReturnsOnCall = make(map[int]struct {
+			result1 *routing.SubscribeFilter
 		})
 	}
-	fake.getPublishedTracksReturnsOnCall[i] = struct {
-		result1 []types.MediaTrack
+	fake.getSubscribeFilterReturnsOnCall[i] = struct {
+		result1 *routing.SubscribeFilter
 	}{result1}
 }
 
@@ -2623,6 +3380,182 @@ func (fake *FakeLocalParticipant) GetSubscribedTracksReturnsOnCall(i int, result
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) GetSubscriberAllocationInfo(arg1 int) *streamallocator.AllocationInfo {
+	fake.getSubscriberAllocationInfoMutex.Lock()
+	ret, specificReturn := fake.getSubscriberAllocationInfoReturnsOnCall[len(fake.getSubscriberAllocationInfoArgsForCall)]
+	fake.getSubscriberAllocationInfoArgsForCall = append(fake.getSubscriberAllocationInfoArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.GetSubscriberAllocationInfoStub
+	fakeReturns := fake.getSubscriberAllocationInfoReturns
+	fake.recordInvocation("GetSubscriberAllocationInfo", []interface{}{arg1})
+	fake.getSubscriberAllocationInfoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetSubscriberAllocationInfoCallCount() int {
+	fake.getSubscriberAllocationInfoMutex.RLock()
+	defer fake.getSubscriberAllocationInfoMutex.RUnlock()
+	return len(fake.getSubscriberAllocationInfoArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetSubscriberAllocationInfoCalls(stub func(int) *streamallocator.AllocationInfo) {
+	fake.getSubscriberAllocationInfoMutex.Lock()
+	defer fake.getSubscriberAllocationInfoMutex.Unlock()
+	fake.GetSubscriberAllocationInfoStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetSubscriberAllocationInfoArgsForCall(i int) int {
+	fake.getSubscriberAllocationInfoMutex.RLock()
+	defer fake.getSubscriberAllocationInfoMutex.RUnlock()
+	argsForCall := fake.getSubscriberAllocationInfoArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLocalParticipant) GetSubscriberAllocationInfoReturns(result1 *streamallocator.AllocationInfo) {
+	fake.getSubscriberAllocationInfoMutex.Lock()
+	defer fake.getSubscriberAllocationInfoMutex.Unlock()
+	fake.GetSubscriberAllocationInfoStub = nil
+	fake.getSubscriberAllocationInfoReturns = struct {
+		result1 *streamallocator.AllocationInfo
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetSubscriberAllocationInfoReturnsOnCall(i int, result1 *streamallocator.AllocationInfo) {
+	fake.getSubscriberAllocationInfoMutex.Lock()
+	defer fake.getSubscriberAllocationInfoMutex.Unlock()
+	fake.GetSubscriberAllocationInfoStub = nil
+	if fake.getSubscriberAllocationInfoReturnsOnCall == nil {
+		fake.getSubscriberAllocationInfoReturnsOnCall = make(map[int]struct {
+			result1 *streamallocator.AllocationInfo
+		})
+	}
+	fake.getSubscriberAllocationInfoReturnsOnCall[i] = struct {
+		result1 *streamallocator.AllocationInfo
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetSubscriberCongestionTrace() *streamallocator.CongestionTrace {
+	fake.getSubscriberCongestionTraceMutex.Lock()
+	ret, specificReturn := fake.getSubscriberCongestionTraceReturnsOnCall[len(fake.getSubscriberCongestionTraceArgsForCall)]
+	fake.getSubscriberCongestionTraceArgsForCall = append(fake.getSubscriberCongestionTraceArgsForCall, struct {
+	}{})
+	stub := fake.GetSubscriberCongestionTraceStub
+	fakeReturns := fake.getSubscriberCongestionTraceReturns
+	fake.recordInvocation("GetSubscriberCongestionTrace", []interface{}{})
+	fake.getSubscriberCongestionTraceMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetSubscriberCongestionTraceCallCount() int {
+	fake.getSubscriberCongestionTraceMutex.RLock()
+	defer fake.getSubscriberCongestionTraceMutex.RUnlock()
+	return len(fake.getSubscriberCongestionTraceArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetSubscriberCongestionTraceCalls(stub func() *streamallocator.CongestionTrace) {
+	fake.getSubscriberCongestionTraceMutex.Lock()
+	defer fake.getSubscriberCongestionTraceMutex.Unlock()
+	fake.GetSubscriberCongestionTraceStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetSubscriberCongestionTraceReturns(result1 *streamallocator.CongestionTrace) {
+	fake.getSubscriberCongestionTraceMutex.Lock()
+	defer fake.getSubscriberCongestionTraceMutex.Unlock()
+	fake.GetSubscriberCongestionTraceStub = nil
+	fake.getSubscriberCongestionTraceReturns = struct {
+		result1 *streamallocator.CongestionTrace
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetSubscriberCongestionTraceReturnsOnCall(i int, result1 *streamallocator.CongestionTrace) {
+	fake.getSubscriberCongestionTraceMutex.Lock()
+	defer fake.getSubscriberCongestionTraceMutex.Unlock()
+	fake.GetSubscriberCongestionTraceStub = nil
+	if fake.getSubscriberCongestionTraceReturnsOnCall == nil {
+		fake.getSubscriberCongestionTraceReturnsOnCall = make(map[int]struct {
+			result1 *streamallocator.CongestionTrace
+		})
+	}
+	fake.getSubscriberCongestionTraceReturnsOnCall[i] = struct {
+		result1 *streamallocator.CongestionTrace
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetTrackStatsHistory(arg1 livekit.TrackID, arg2 time.Duration) []*types.TrackStatsSeries {
+	fake.getTrackStatsHistoryMutex.Lock()
+	ret, specificReturn := fake.getTrackStatsHistoryReturnsOnCall[len(fake.getTrackStatsHistoryArgsForCall)]
+	fake.getTrackStatsHistoryArgsForCall = append(fake.getTrackStatsHistoryArgsForCall, struct {
+		arg1 livekit.TrackID
+		arg2 time.Duration
+	}{arg1, arg2})
+	stub := fake.GetTrackStatsHistoryStub
+	fakeReturns := fake.getTrackStatsHistoryReturns
+	fake.recordInvocation("GetTrackStatsHistory", []interface{}{arg1, arg2})
+	fake.getTrackStatsHistoryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetTrackStatsHistoryCallCount() int {
+	fake.getTrackStatsHistoryMutex.RLock()
+	defer fake.getTrackStatsHistoryMutex.RUnlock()
+	return len(fake.getTrackStatsHistoryArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetTrackStatsHistoryCalls(stub func(livekit.TrackID, time.Duration) []*types.TrackStatsSeries) {
+	fake.getTrackStatsHistoryMutex.Lock()
+	defer fake.getTrackStatsHistoryMutex.Unlock()
+	fake.GetTrackStatsHistoryStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetTrackStatsHistoryArgsForCall(i int) (livekit.TrackID, time.Duration) {
+	fake.getTrackStatsHistoryMutex.RLock()
+	defer fake.getTrackStatsHistoryMutex.RUnlock()
+	argsForCall := fake.getTrackStatsHistoryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLocalParticipant) GetTrackStatsHistoryReturns(result1 []*types.TrackStatsSeries) {
+	fake.getTrackStatsHistoryMutex.Lock()
+	defer fake.getTrackStatsHistoryMutex.Unlock()
+	fake.GetTrackStatsHistoryStub = nil
+	fake.getTrackStatsHistoryReturns = struct {
+		result1 []*types.TrackStatsSeries
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetTrackStatsHistoryReturnsOnCall(i int, result1 []*types.TrackStatsSeries) {
+	fake.getTrackStatsHistoryMutex.Lock()
+	defer fake.getTrackStatsHistoryMutex.Unlock()
+	fake.GetTrackStatsHistoryStub = nil
+	if fake.getTrackStatsHistoryReturnsOnCall == nil {
+		fake.getTrackStatsHistoryReturnsOnCall = make(map[int]struct {
+			result1 []*types.TrackStatsSeries
+		})
+	}
+	fake.getTrackStatsHistoryReturnsOnCall[i] = struct {
+		result1 []*types.TrackStatsSeries
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) GetTrafficLoad() *types.TrafficLoad {
 	fake.getTrafficLoadMutex.Lock()
 	ret, specificReturn := fake.getTrafficLoadReturnsOnCall[len(fake.getTrafficLoadArgsForCall)]
@@ -2729,6 +3662,67 @@ func (fake *FakeLocalParticipant) GetTrailerReturnsOnCall(i int, result1 []byte)
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) GetTransportStats(arg1 livekit.SignalTarget) *types.TransportStats {
+	fake.getTransportStatsMutex.Lock()
+	ret, specificReturn := fake.getTransportStatsReturnsOnCall[len(fake.getTransportStatsArgsForCall)]
+	fake.getTransportStatsArgsForCall = append(fake.getTransportStatsArgsForCall, struct {
+		arg1 livekit.SignalTarget
+	}{arg1})
+	stub := fake.GetTransportStatsStub
+	fakeReturns := fake.getTransportStatsReturns
+	fake.recordInvocation("GetTransportStats", []interface{}{arg1})
+	fake.getTransportStatsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) GetTransportStatsCallCount() int {
+	fake.getTransportStatsMutex.RLock()
+	defer fake.getTransportStatsMutex.RUnlock()
+	return len(fake.getTransportStatsArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) GetTransportStatsCalls(stub func(livekit.SignalTarget) *types.TransportStats) {
+	fake.getTransportStatsMutex.Lock()
+	defer fake.getTransportStatsMutex.Unlock()
+	fake.GetTransportStatsStub = stub
+}
+
+func (fake *FakeLocalParticipant) GetTransportStatsArgsForCall(i int) livekit.SignalTarget {
+	fake.getTransportStatsMutex.RLock()
+	defer fake.getTransportStatsMutex.RUnlock()
+	argsForCall := fake.getTransportStatsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLocalParticipant) GetTransportStatsReturns(result1 *types.TransportStats) {
+	fake.getTransportStatsMutex.Lock()
+	defer fake.getTransportStatsMutex.Unlock()
+	fake.GetTransportStatsStub = nil
+	fake.getTransportStatsReturns = struct {
+		result1 *types.TransportStats
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) GetTransportStatsReturnsOnCall(i int, result1 *types.TransportStats) {
+	fake.getTransportStatsMutex.Lock()
+	defer fake.getTransportStatsMutex.Unlock()
+	fake.GetTransportStatsStub = nil
+	if fake.getTransportStatsReturnsOnCall == nil {
+		fake.getTransportStatsReturnsOnCall = make(map[int]struct {
+			result1 *types.TransportStats
+		})
+	}
+	fake.getTransportStatsReturnsOnCall[i] = struct {
+		result1 *types.TransportStats
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) HandleAnswer(arg1 webrtc.SessionDescription) {
 	fake.handleAnswerMutex.Lock()
 	fake.handleAnswerArgsForCall = append(fake.handleAnswerArgsForCall, struct {
@@ -3271,6 +4265,59 @@ func (fake *FakeLocalParticipant) IsAgentReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) IsAudioBlocked() bool {
+	fake.isAudioBlockedMutex.Lock()
+	ret, specificReturn := fake.isAudioBlockedReturnsOnCall[len(fake.isAudioBlockedArgsForCall)]
+	fake.isAudioBlockedArgsForCall = append(fake.isAudioBlockedArgsForCall, struct {
+	}{})
+	stub := fake.IsAudioBlockedStub
+	fakeReturns := fake.isAudioBlockedReturns
+	fake.recordInvocation("IsAudioBlocked", []interface{}{})
+	fake.isAudioBlockedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) IsAudioBlockedCallCount() int {
+	fake.isAudioBlockedMutex.RLock()
+	defer fake.isAudioBlockedMutex.RUnlock()
+	return len(fake.isAudioBlockedArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) IsAudioBlockedCalls(stub func() bool) {
+	fake.isAudioBlockedMutex.Lock()
+	defer fake.isAudioBlockedMutex.Unlock()
+	fake.IsAudioBlockedStub = stub
+}
+
+func (fake *FakeLocalParticipant) IsAudioBlockedReturns(result1 bool) {
+	fake.isAudioBlockedMutex.Lock()
+	defer fake.isAudioBlockedMutex.Unlock()
+	fake.IsAudioBlockedStub = nil
+	fake.isAudioBlockedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) IsAudioBlockedReturnsOnCall(i int, result1 bool) {
+	fake.isAudioBlockedMutex.Lock()
+	defer fake.isAudioBlockedMutex.Unlock()
+	fake.IsAudioBlockedStub = nil
+	if fake.isAudioBlockedReturnsOnCall == nil {
+		fake.isAudioBlockedReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isAudioBlockedReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) IsClosed() bool {
 	fake.isClosedMutex.Lock()
 	ret, specificReturn := fake.isClosedReturnsOnCall[len(fake.isClosedArgsForCall)]
@@ -3682,6 +4729,121 @@ func (fake *FakeLocalParticipant) IssueFullReconnectArgsForCall(i int) types.Par
 	return argsForCall.arg1
 }
 
+func (fake *FakeLocalParticipant) IssueRedirect(arg1 *livekit.RegionInfo, arg2 string) bool {
+	fake.issueRedirectMutex.Lock()
+	ret, specificReturn := fake.issueRedirectReturnsOnCall[len(fake.issueRedirectArgsForCall)]
+	fake.issueRedirectArgsForCall = append(fake.issueRedirectArgsForCall, struct {
+		arg1 *livekit.RegionInfo
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.IssueRedirectStub
+	fakeReturns := fake.issueRedirectReturns
+	fake.recordInvocation("IssueRedirect", []interface{}{arg1, arg2})
+	fake.issueRedirectMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) IssueRedirectCallCount() int {
+	fake.issueRedirectMutex.RLock()
+	defer fake.issueRedirectMutex.RUnlock()
+	return len(fake.issueRedirectArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) IssueRedirectCalls(stub func(*livekit.RegionInfo, string) bool) {
+	fake.issueRedirectMutex.Lock()
+	defer fake.issueRedirectMutex.Unlock()
+	fake.IssueRedirectStub = stub
+}
+
+func (fake *FakeLocalParticipant) IssueRedirectArgsForCall(i int) (*livekit.RegionInfo, string) {
+	fake.issueRedirectMutex.RLock()
+	defer fake.issueRedirectMutex.RUnlock()
+	argsForCall := fake.issueRedirectArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLocalParticipant) IssueRedirectReturns(result1 bool) {
+	fake.issueRedirectMutex.Lock()
+	defer fake.issueRedirectMutex.Unlock()
+	fake.IssueRedirectStub = nil
+	fake.issueRedirectReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) IssueRedirectReturnsOnCall(i int, result1 bool) {
+	fake.issueRedirectMutex.Lock()
+	defer fake.issueRedirectMutex.Unlock()
+	fake.IssueRedirectStub = nil
+	if fake.issueRedirectReturnsOnCall == nil {
+		fake.issueRedirectReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.issueRedirectReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) LifecycleState() types.ParticipantLifecycleState {
+	fake.lifecycleStateMutex.Lock()
+	ret, specificReturn := fake.lifecycleStateReturnsOnCall[len(fake.lifecycleStateArgsForCall)]
+	fake.lifecycleStateArgsForCall = append(fake.lifecycleStateArgsForCall, struct {
+	}{})
+	stub := fake.LifecycleStateStub
+	fakeReturns := fake.lifecycleStateReturns
+	fake.recordInvocation("LifecycleState", []interface{}{})
+	fake.lifecycleStateMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) LifecycleStateCallCount() int {
+	fake.lifecycleStateMutex.RLock()
+	defer fake.lifecycleStateMutex.RUnlock()
+	return len(fake.lifecycleStateArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) LifecycleStateCalls(stub func() types.ParticipantLifecycleState) {
+	fake.lifecycleStateMutex.Lock()
+	defer fake.lifecycleStateMutex.Unlock()
+	fake.LifecycleStateStub = stub
+}
+
+func (fake *FakeLocalParticipant) LifecycleStateReturns(result1 types.ParticipantLifecycleState) {
+	fake.lifecycleStateMutex.Lock()
+	defer fake.lifecycleStateMutex.Unlock()
+	fake.LifecycleStateStub = nil
+	fake.lifecycleStateReturns = struct {
+		result1 types.ParticipantLifecycleState
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) LifecycleStateReturnsOnCall(i int, result1 types.ParticipantLifecycleState) {
+	fake.lifecycleStateMutex.Lock()
+	defer fake.lifecycleStateMutex.Unlock()
+	fake.LifecycleStateStub = nil
+	if fake.lifecycleStateReturnsOnCall == nil {
+		fake.lifecycleStateReturnsOnCall = make(map[int]struct {
+			result1 types.ParticipantLifecycleState
+		})
+	}
+	fake.lifecycleStateReturnsOnCall[i] = struct {
+		result1 types.ParticipantLifecycleState
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) MaybeStartMigration(arg1 bool, arg2 func()) bool {
 	fake.maybeStartMigrationMutex.Lock()
 	ret, specificReturn := fake.maybeStartMigrationReturnsOnCall[len(fake.maybeStartMigrationArgsForCall)]
@@ -3797,6 +4959,38 @@ func (fake *FakeLocalParticipant) MigrateStateReturnsOnCall(i int, result1 types
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) MoveToRoom(arg1 livekit.RoomName) {
+	fake.moveToRoomMutex.Lock()
+	fake.moveToRoomArgsForCall = append(fake.moveToRoomArgsForCall, struct {
+		arg1 livekit.RoomName
+	}{arg1})
+	stub := fake.MoveToRoomStub
+	fake.recordInvocation("MoveToRoom", []interface{}{arg1})
+	fake.moveToRoomMutex.Unlock()
+	if stub != nil {
+		fake.MoveToRoomStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomCallCount() int {
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
+	return len(fake.moveToRoomArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomCalls(stub func(livekit.RoomName)) {
+	fake.moveToRoomMutex.Lock()
+	defer fake.moveToRoomMutex.Unlock()
+	fake.MoveToRoomStub = stub
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomArgsForCall(i int) livekit.RoomName {
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
+	argsForCall := fake.moveToRoomArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) Negotiate(arg1 bool) {
 	fake.negotiateMutex.Lock()
 	fake.negotiateArgsForCall = append(fake.negotiateArgsForCall, struct {
@@ -5134,6 +6328,38 @@ func (fake *FakeLocalParticipant) SetSubscriberAllowPauseArgsForCall(i int) bool
 	return argsForCall.arg1
 }
 
+func (fake *FakeLocalParticipant) SetSubscriberBandwidthHint(arg1 int64) {
+	fake.setSubscriberBandwidthHintMutex.Lock()
+	fake.setSubscriberBandwidthHintArgsForCall = append(fake.setSubscriberBandwidthHintArgsForCall, struct {
+		arg1 int64
+	}{arg1})
+	stub := fake.SetSubscriberBandwidthHintStub
+	fake.recordInvocation("SetSubscriberBandwidthHint", []interface{}{arg1})
+	fake.setSubscriberBandwidthHintMutex.Unlock()
+	if stub != nil {
+		fake.SetSubscriberBandwidthHintStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberBandwidthHintCallCount() int {
+	fake.setSubscriberBandwidthHintMutex.RLock()
+	defer fake.setSubscriberBandwidthHintMutex.RUnlock()
+	return len(fake.setSubscriberBandwidthHintArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberBandwidthHintCalls(stub func(int64)) {
+	fake.setSubscriberBandwidthHintMutex.Lock()
+	defer fake.setSubscriberBandwidthHintMutex.Unlock()
+	fake.SetSubscriberBandwidthHintStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberBandwidthHintArgsForCall(i int) int64 {
+	fake.setSubscriberBandwidthHintMutex.RLock()
+	defer fake.setSubscriberBandwidthHintMutex.RUnlock()
+	argsForCall := fake.setSubscriberBandwidthHintArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) SetSubscriberChannelCapacity(arg1 int64) {
 	fake.setSubscriberChannelCapacityMutex.Lock()
 	fake.setSubscriberChannelCapacityArgsForCall = append(fake.setSubscriberChannelCapacityArgsForCall, struct {
@@ -5229,6 +6455,74 @@ func (fake *FakeLocalParticipant) SetTrackMutedReturnsOnCall(i int, result1 *liv
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) SetTracksMuted(arg1 []livekit.TrackID, arg2 bool, arg3 bool) []*livekit.TrackInfo {
+	var arg1Copy []livekit.TrackID
+	if arg1 != nil {
+		arg1Copy = make([]livekit.TrackID, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.setTracksMutedMutex.Lock()
+	ret, specificReturn := fake.setTracksMutedReturnsOnCall[len(fake.setTracksMutedArgsForCall)]
+	fake.setTracksMutedArgsForCall = append(fake.setTracksMutedArgsForCall, struct {
+		arg1 []livekit.TrackID
+		arg2 bool
+		arg3 bool
+	}{arg1Copy, arg2, arg3})
+	stub := fake.SetTracksMutedStub
+	fakeReturns := fake.setTracksMutedReturns
+	fake.recordInvocation("SetTracksMuted", []interface{}{arg1Copy, arg2, arg3})
+	fake.setTracksMutedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) SetTracksMutedCallCount() int {
+	fake.setTracksMutedMutex.RLock()
+	defer fake.setTracksMutedMutex.RUnlock()
+	return len(fake.setTracksMutedArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetTracksMutedCalls(stub func([]livekit.TrackID, bool, bool) []*livekit.TrackInfo) {
+	fake.setTracksMutedMutex.Lock()
+	defer fake.setTracksMutedMutex.Unlock()
+	fake.SetTracksMutedStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetTracksMutedArgsForCall(i int) ([]livekit.TrackID, bool, bool) {
+	fake.setTracksMutedMutex.RLock()
+	defer fake.setTracksMutedMutex.RUnlock()
+	argsForCall := fake.setTracksMutedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeLocalParticipant) SetTracksMutedReturns(result1 []*livekit.TrackInfo) {
+	fake.setTracksMutedMutex.Lock()
+	defer fake.setTracksMutedMutex.Unlock()
+	fake.SetTracksMutedStub = nil
+	fake.setTracksMutedReturns = struct {
+		result1 []*livekit.TrackInfo
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) SetTracksMutedReturnsOnCall(i int, result1 []*livekit.TrackInfo) {
+	fake.setTracksMutedMutex.Lock()
+	defer fake.setTracksMutedMutex.Unlock()
+	fake.SetTracksMutedStub = nil
+	if fake.setTracksMutedReturnsOnCall == nil {
+		fake.setTracksMutedReturnsOnCall = make(map[int]struct {
+			result1 []*livekit.TrackInfo
+		})
+	}
+	fake.setTracksMutedReturnsOnCall[i] = struct {
+		result1 []*livekit.TrackInfo
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) State() livekit.ParticipantInfo_State {
 	fake.stateMutex.Lock()
 	ret, specificReturn := fake.stateReturnsOnCall[len(fake.stateArgsForCall)]
@@ -6212,12 +7506,16 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.addICECandidateMutex.RLock()
 	defer fake.addICECandidateMutex.RUnlock()
+	fake.addLifecycleHookMutex.RLock()
+	defer fake.addLifecycleHookMutex.RUnlock()
 	fake.addTrackMutex.RLock()
 	defer fake.addTrackMutex.RUnlock()
 	fake.addTrackToSubscriberMutex.RLock()
 	defer fake.addTrackToSubscriberMutex.RUnlock()
 	fake.addTransceiverFromTrackToSubscriberMutex.RLock()
 	defer fake.addTransceiverFromTrackToSubscriberMutex.RUnlock()
+	fake.blockAudioMutex.RLock()
+	defer fake.blockAudioMutex.RUnlock()
 	fake.cacheDownTrackMutex.RLock()
 	defer fake.cacheDownTrackMutex.RUnlock()
 	fake.canPublishDataMutex.RLock()
@@ -6240,6 +7538,12 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.connectedAtMutex.RUnlock()
 	fake.debugInfoMutex.RLock()
 	defer fake.debugInfoMutex.RUnlock()
+	fake.enterOperationMutex.RLock()
+	defer fake.enterOperationMutex.RUnlock()
+	fake.getAPIKeyMutex.RLock()
+	defer fake.getAPIKeyMutex.RUnlock()
+	fake.getAVSyncDetailsMutex.RLock()
+	defer fake.getAVSyncDetailsMutex.RUnlock()
 	fake.getAdaptiveStreamMutex.RLock()
 	defer fake.getAdaptiveStreamMutex.RUnlock()
 	fake.getAudioLevelMutex.RLock()
@@ -6254,10 +7558,16 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.getClientInfoMutex.RUnlock()
 	fake.getConnectionQualityMutex.RLock()
 	defer fake.getConnectionQualityMutex.RUnlock()
+	fake.getConnectionQualityDetailsMutex.RLock()
+	defer fake.getConnectionQualityDetailsMutex.RUnlock()
 	fake.getICEConnectionDetailsMutex.RLock()
 	defer fake.getICEConnectionDetailsMutex.RUnlock()
+	fake.getICEDiagnosticsMutex.RLock()
+	defer fake.getICEDiagnosticsMutex.RUnlock()
 	fake.getLoggerMutex.RLock()
 	defer fake.getLoggerMutex.RUnlock()
+	fake.getMaxTrackBitrateMutex.RLock()
+	defer fake.getMaxTrackBitrateMutex.RUnlock()
 	fake.getPacerMutex.RLock()
 	defer fake.getPacerMutex.RUnlock()
 	fake.getPendingTrackMutex.RLock()
@@ -6268,14 +7578,28 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.getPublishedTrackMutex.RUnlock()
 	fake.getPublishedTracksMutex.RLock()
 	defer fake.getPublishedTracksMutex.RUnlock()
+	fake.getResourceTrackerMutex.RLock()
+	defer fake.getResourceTrackerMutex.RUnlock()
+	fake.getSubscribeDefaultsMutex.RLock()
+	defer fake.getSubscribeDefaultsMutex.RUnlock()
+	fake.getSubscribeFilterMutex.RLock()
+	defer fake.getSubscribeFilterMutex.RUnlock()
 	fake.getSubscribedParticipantsMutex.RLock()
 	defer fake.getSubscribedParticipantsMutex.RUnlock()
 	fake.getSubscribedTracksMutex.RLock()
 	defer fake.getSubscribedTracksMutex.RUnlock()
+	fake.getSubscriberAllocationInfoMutex.RLock()
+	defer fake.getSubscriberAllocationInfoMutex.RUnlock()
+	fake.getSubscriberCongestionTraceMutex.RLock()
+	defer fake.getSubscriberCongestionTraceMutex.RUnlock()
+	fake.getTrackStatsHistoryMutex.RLock()
+	defer fake.getTrackStatsHistoryMutex.RUnlock()
 	fake.getTrafficLoadMutex.RLock()
 	defer fake.getTrafficLoadMutex.RUnlock()
 	fake.getTrailerMutex.RLock()
 	defer fake.getTrailerMutex.RUnlock()
+	fake.getTransportStatsMutex.RLock()
+	defer fake.getTransportStatsMutex.RUnlock()
 	fake.handleAnswerMutex.RLock()
 	defer fake.handleAnswerMutex.RUnlock()
 	fake.handleOfferMutex.RLock()
@@ -6300,6 +7624,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.identityMutex.RUnlock()
 	fake.isAgentMutex.RLock()
 	defer fake.isAgentMutex.RUnlock()
+	fake.isAudioBlockedMutex.RLock()
+	defer fake.isAudioBlockedMutex.RUnlock()
 	fake.isClosedMutex.RLock()
 	defer fake.isClosedMutex.RUnlock()
 	fake.isDisconnectedMutex.RLock()
@@ -6316,10 +7642,16 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.isSubscribedToMutex.RUnlock()
 	fake.issueFullReconnectMutex.RLock()
 	defer fake.issueFullReconnectMutex.RUnlock()
+	fake.issueRedirectMutex.RLock()
+	defer fake.issueRedirectMutex.RUnlock()
+	fake.lifecycleStateMutex.RLock()
+	defer fake.lifecycleStateMutex.RUnlock()
 	fake.maybeStartMigrationMutex.RLock()
 	defer fake.maybeStartMigrationMutex.RUnlock()
 	fake.migrateStateMutex.RLock()
 	defer fake.migrateStateMutex.RUnlock()
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
 	fake.negotiateMutex.RLock()
 	defer fake.negotiateMutex.RUnlock()
 	fake.onClaimsChangedMutex.RLock()
@@ -6384,10 +7716,14 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.setSignalSourceValidMutex.RUnlock()
 	fake.setSubscriberAllowPauseMutex.RLock()
 	defer fake.setSubscriberAllowPauseMutex.RUnlock()
+	fake.setSubscriberBandwidthHintMutex.RLock()
+	defer fake.setSubscriberBandwidthHintMutex.RUnlock()
 	fake.setSubscriberChannelCapacityMutex.RLock()
 	defer fake.setSubscriberChannelCapacityMutex.RUnlock()
 	fake.setTrackMutedMutex.RLock()
 	defer fake.setTrackMutedMutex.RUnlock()
+	fake.setTracksMutedMutex.RLock()
+	defer fake.setTracksMutedMutex.RUnlock()
 	fake.stateMutex.RLock()
 	defer fake.stateMutex.RUnlock()
 	fake.subscribeToTrackMutex.RLock()
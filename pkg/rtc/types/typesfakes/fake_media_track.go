@@ -7,6 +7,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/protocol/livekit"
+	webrtc "github.com/pion/webrtc/v3"
 )
 
 type FakeMediaTrack struct {
@@ -268,6 +269,17 @@ type FakeMediaTrack struct {
 	toProtoReturnsOnCall map[int]struct {
 		result1 *livekit.TrackInfo
 	}
+	TranscodeFallbackStub        func([]webrtc.RTPCodecParameters) bool
+	transcodeFallbackMutex       sync.RWMutex
+	transcodeFallbackArgsForCall []struct {
+		arg1 []webrtc.RTPCodecParameters
+	}
+	transcodeFallbackReturns struct {
+		result1 bool
+	}
+	transcodeFallbackReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	UpdateTrackInfoStub        func(*livekit.TrackInfo)
 	updateTrackInfoMutex       sync.RWMutex
 	updateTrackInfoArgsForCall []struct {
@@ -1663,6 +1675,72 @@ func (fake *FakeMediaTrack) ToProtoReturnsOnCall(i int, result1 *livekit.TrackIn
 	}{result1}
 }
 
+func (fake *FakeMediaTrack) TranscodeFallback(arg1 []webrtc.RTPCodecParameters) bool {
+	var arg1Copy []webrtc.RTPCodecParameters
+	if arg1 != nil {
+		arg1Copy = make([]webrtc.RTPCodecParameters, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.transcodeFallbackMutex.Lock()
+	ret, specificReturn := fake.transcodeFallbackReturnsOnCall[len(fake.transcodeFallbackArgsForCall)]
+	fake.transcodeFallbackArgsForCall = append(fake.transcodeFallbackArgsForCall, struct {
+		arg1 []webrtc.RTPCodecParameters
+	}{arg1Copy})
+	stub := fake.TranscodeFallbackStub
+	fakeReturns := fake.transcodeFallbackReturns
+	fake.recordInvocation("TranscodeFallback", []interface{}{arg1Copy})
+	fake.transcodeFallbackMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMediaTrack) TranscodeFallbackCallCount() int {
+	fake.transcodeFallbackMutex.RLock()
+	defer fake.transcodeFallbackMutex.RUnlock()
+	return len(fake.transcodeFallbackArgsForCall)
+}
+
+func (fake *FakeMediaTrack) TranscodeFallbackCalls(stub func([]webrtc.RTPCodecParameters) bool) {
+	fake.transcodeFallbackMutex.Lock()
+	defer fake.transcodeFallbackMutex.Unlock()
+	fake.TranscodeFallbackStub = stub
+}
+
+func (fake *FakeMediaTrack) TranscodeFallbackArgsForCall(i int) []webrtc.RTPCodecParameters {
+	fake.transcodeFallbackMutex.RLock()
+	defer fake.transcodeFallbackMutex.RUnlock()
+	argsForCall := fake.transcodeFallbackArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMediaTrack) TranscodeFallbackReturns(result1 bool) {
+	fake.transcodeFallbackMutex.Lock()
+	defer fake.transcodeFallbackMutex.Unlock()
+	fake.TranscodeFallbackStub = nil
+	fake.transcodeFallbackReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeMediaTrack) TranscodeFallbackReturnsOnCall(i int, result1 bool) {
+	fake.transcodeFallbackMutex.Lock()
+	defer fake.transcodeFallbackMutex.Unlock()
+	fake.TranscodeFallbackStub = nil
+	if fake.transcodeFallbackReturnsOnCall == nil {
+		fake.transcodeFallbackReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.transcodeFallbackReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeMediaTrack) UpdateTrackInfo(arg1 *livekit.TrackInfo) {
 	fake.updateTrackInfoMutex.Lock()
 	fake.updateTrackInfoArgsForCall = append(fake.updateTrackInfoArgsForCall, struct {
@@ -1789,6 +1867,8 @@ func (fake *FakeMediaTrack) Invocations() map[string][][]interface{} {
 	defer fake.streamMutex.RUnlock()
 	fake.toProtoMutex.RLock()
 	defer fake.toProtoMutex.RUnlock()
+	fake.transcodeFallbackMutex.RLock()
+	defer fake.transcodeFallbackMutex.RUnlock()
 	fake.updateTrackInfoMutex.RLock()
 	defer fake.updateTrackInfoMutex.RUnlock()
 	fake.updateVideoLayersMutex.RLock()
@@ -86,6 +86,11 @@ type FakeSubscribedTrack struct {
 	onCloseArgsForCall []struct {
 		arg1 func(willBeResumed bool)
 	}
+	OnSubscriberRankChangeStub        func(func())
+	onSubscriberRankChangeMutex       sync.RWMutex
+	onSubscriberRankChangeArgsForCall []struct {
+		arg1 func()
+	}
 	PublisherIDStub        func() livekit.ParticipantID
 	publisherIDMutex       sync.RWMutex
 	publisherIDArgsForCall []struct {
@@ -161,6 +166,16 @@ type FakeSubscribedTrack struct {
 	subscriberIdentityReturnsOnCall map[int]struct {
 		result1 livekit.ParticipantIdentity
 	}
+	SubscriberRankStub        func() uint32
+	subscriberRankMutex       sync.RWMutex
+	subscriberRankArgsForCall []struct {
+	}
+	subscriberRankReturns struct {
+		result1 uint32
+	}
+	subscriberRankReturnsOnCall map[int]struct {
+		result1 uint32
+	}
 	UpdateSubscriberSettingsStub        func(*livekit.UpdateTrackSettings, bool)
 	updateSubscriberSettingsMutex       sync.RWMutex
 	updateSubscriberSettingsArgsForCall []struct {
@@ -589,6 +604,38 @@ func (fake *FakeSubscribedTrack) OnCloseArgsForCall(i int) func(willBeResumed bo
 	return argsForCall.arg1
 }
 
+func (fake *FakeSubscribedTrack) OnSubscriberRankChange(arg1 func()) {
+	fake.onSubscriberRankChangeMutex.Lock()
+	fake.onSubscriberRankChangeArgsForCall = append(fake.onSubscriberRankChangeArgsForCall, struct {
+		arg1 func()
+	}{arg1})
+	stub := fake.OnSubscriberRankChangeStub
+	fake.recordInvocation("OnSubscriberRankChange", []interface{}{arg1})
+	fake.onSubscriberRankChangeMutex.Unlock()
+	if stub != nil {
+		fake.OnSubscriberRankChangeStub(arg1)
+	}
+}
+
+func (fake *FakeSubscribedTrack) OnSubscriberRankChangeCallCount() int {
+	fake.onSubscriberRankChangeMutex.RLock()
+	defer fake.onSubscriberRankChangeMutex.RUnlock()
+	return len(fake.onSubscriberRankChangeArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) OnSubscriberRankChangeCalls(stub func(func())) {
+	fake.onSubscriberRankChangeMutex.Lock()
+	defer fake.onSubscriberRankChangeMutex.Unlock()
+	fake.OnSubscriberRankChangeStub = stub
+}
+
+func (fake *FakeSubscribedTrack) OnSubscriberRankChangeArgsForCall(i int) func() {
+	fake.onSubscriberRankChangeMutex.RLock()
+	defer fake.onSubscriberRankChangeMutex.RUnlock()
+	argsForCall := fake.onSubscriberRankChangeArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeSubscribedTrack) PublisherID() livekit.ParticipantID {
 	fake.publisherIDMutex.Lock()
 	ret, specificReturn := fake.publisherIDReturnsOnCall[len(fake.publisherIDArgsForCall)]
@@ -992,6 +1039,59 @@ func (fake *FakeSubscribedTrack) SubscriberIdentityReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeSubscribedTrack) SubscriberRank() uint32 {
+	fake.subscriberRankMutex.Lock()
+	ret, specificReturn := fake.subscriberRankReturnsOnCall[len(fake.subscriberRankArgsForCall)]
+	fake.subscriberRankArgsForCall = append(fake.subscriberRankArgsForCall, struct {
+	}{})
+	stub := fake.SubscriberRankStub
+	fakeReturns := fake.subscriberRankReturns
+	fake.recordInvocation("SubscriberRank", []interface{}{})
+	fake.subscriberRankMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) SubscriberRankCallCount() int {
+	fake.subscriberRankMutex.RLock()
+	defer fake.subscriberRankMutex.RUnlock()
+	return len(fake.subscriberRankArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) SubscriberRankCalls(stub func() uint32) {
+	fake.subscriberRankMutex.Lock()
+	defer fake.subscriberRankMutex.Unlock()
+	fake.SubscriberRankStub = stub
+}
+
+func (fake *FakeSubscribedTrack) SubscriberRankReturns(result1 uint32) {
+	fake.subscriberRankMutex.Lock()
+	defer fake.subscriberRankMutex.Unlock()
+	fake.SubscriberRankStub = nil
+	fake.subscriberRankReturns = struct {
+		result1 uint32
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) SubscriberRankReturnsOnCall(i int, result1 uint32) {
+	fake.subscriberRankMutex.Lock()
+	defer fake.subscriberRankMutex.Unlock()
+	fake.SubscriberRankStub = nil
+	if fake.subscriberRankReturnsOnCall == nil {
+		fake.subscriberRankReturnsOnCall = make(map[int]struct {
+			result1 uint32
+		})
+	}
+	fake.subscriberRankReturnsOnCall[i] = struct {
+		result1 uint32
+	}{result1}
+}
+
 func (fake *FakeSubscribedTrack) UpdateSubscriberSettings(arg1 *livekit.UpdateTrackSettings, arg2 bool) {
 	fake.updateSubscriberSettingsMutex.Lock()
 	fake.updateSubscriberSettingsArgsForCall = append(fake.updateSubscriberSettingsArgsForCall, struct {
@@ -1070,6 +1170,8 @@ func (fake *FakeSubscribedTrack) Invocations() map[string][][]interface{} {
 	defer fake.needsNegotiationMutex.RUnlock()
 	fake.onCloseMutex.RLock()
 	defer fake.onCloseMutex.RUnlock()
+	fake.onSubscriberRankChangeMutex.RLock()
+	defer fake.onSubscriberRankChangeMutex.RUnlock()
 	fake.publisherIDMutex.RLock()
 	defer fake.publisherIDMutex.RUnlock()
 	fake.publisherIdentityMutex.RLock()
@@ -1086,6 +1188,8 @@ func (fake *FakeSubscribedTrack) Invocations() map[string][][]interface{} {
 	defer fake.subscriberIDMutex.RUnlock()
 	fake.subscriberIdentityMutex.RLock()
 	defer fake.subscriberIdentityMutex.RUnlock()
+	fake.subscriberRankMutex.RLock()
+	defer fake.subscriberRankMutex.RUnlock()
 	fake.updateSubscriberSettingsMutex.RLock()
 	defer fake.updateSubscriberSettingsMutex.RUnlock()
 	fake.updateVideoLayerMutex.RLock()
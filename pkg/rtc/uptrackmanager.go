@@ -136,6 +136,32 @@ func (u *UpTrackManager) SetPublishedTrackMuted(trackID livekit.TrackID, muted b
 	return track
 }
 
+// SetPublishedTracksMuted mutes or unmutes the published tracks, listeners are notified once for all of them.
+// It returns the tracks whose mute status changed
+func (u *UpTrackManager) SetPublishedTracksMuted(trackIDs []livekit.TrackID, muted bool) []types.MediaTrack {
+	var changed []types.MediaTrack
+	for _, trackID := range trackIDs {
+		u.lock.RLock()
+		track := u.publishedTracks[trackID]
+		u.lock.RUnlock()
+		if track == nil {
+			continue
+		}
+
+		currentMuted := track.IsMuted()
+		track.SetMuted(muted)
+		if currentMuted != track.IsMuted() {
+			u.params.Logger.Debugw("publisher mute status changed", "trackID", trackID, "muted", track.IsMuted())
+			changed = append(changed, track)
+		}
+	}
+
+	if len(changed) > 0 && u.onTrackUpdated != nil {
+		u.onTrackUpdated(changed[len(changed)-1])
+	}
+	return changed
+}
+
 func (u *UpTrackManager) GetPublishedTrack(trackID livekit.TrackID) types.MediaTrack {
 	u.lock.RLock()
 	defer u.lock.RUnlock()
@@ -16,8 +16,10 @@ package rtc
 
 import (
 	"errors"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pion/webrtc/v3"
 	"go.uber.org/atomic"
@@ -26,6 +28,7 @@ import (
 	"github.com/livekit/protocol/logger"
 
 	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 )
 
 // wrapper around WebRTC receiver, overriding its ID
@@ -116,6 +119,17 @@ func (r *WrappedReceiver) Codecs() []webrtc.RTPCodecParameters {
 	return codecs
 }
 
+// HasCodecBitDepthMismatch returns true when the receiver of the codec gets video with a higher bit depth
+// than the negotiated codec profile carries
+func (r *WrappedReceiver) HasCodecBitDepthMismatch(mime string) bool {
+	for _, receiver := range r.receivers {
+		if strings.EqualFold(receiver.Codec().MimeType, mime) {
+			return receiver.HasBitDepthMismatch()
+		}
+	}
+	return false
+}
+
 func (r *WrappedReceiver) DeleteDownTrack(participantID livekit.ParticipantID) {
 	if r.TrackReceiver != nil {
 		r.TrackReceiver.DeleteDownTrack(participantID)
@@ -124,6 +138,19 @@ func (r *WrappedReceiver) DeleteDownTrack(participantID livekit.ParticipantID) {
 
 // --------------------------------------------
 
+const (
+	// on upgrade, subscribers waiting on a DummyReceiver are moved to the real receiver in batches,
+	// and a key frame is requested for each batch, so that a large audience does not flood the publisher
+	// with key frame requests all at once
+	upgradeMigrationBatchSize     = 50
+	upgradeMigrationBatchInterval = 200 * time.Millisecond
+)
+
+type pendingDownTrack struct {
+	sfu.TrackSender
+	seq uint64
+}
+
 type DummyReceiver struct {
 	receiver         atomic.Value
 	trackID          livekit.TrackID
@@ -131,8 +158,13 @@ type DummyReceiver struct {
 	codec            webrtc.RTPCodecParameters
 	headerExtensions []webrtc.RTPHeaderExtensionParameter
 
+	migrationBatchSize     int
+	migrationBatchInterval time.Duration
+	migrationPriority      atomic.Value
+
 	downtrackLock sync.Mutex
-	downtracks    map[livekit.ParticipantID]sfu.TrackSender
+	downtracks    map[livekit.ParticipantID]pendingDownTrack
+	downtrackSeq  uint64
 
 	settingsLock          sync.Mutex
 	maxExpectedLayerValid bool
@@ -147,7 +179,11 @@ func NewDummyReceiver(trackID livekit.TrackID, streamId string, codec webrtc.RTP
 		streamId:         streamId,
 		codec:            codec,
 		headerExtensions: headerExtensions,
-		downtracks:       make(map[livekit.ParticipantID]sfu.TrackSender),
+
+		migrationBatchSize:     upgradeMigrationBatchSize,
+		migrationBatchInterval: upgradeMigrationBatchInterval,
+
+		downtracks: make(map[livekit.ParticipantID]pendingDownTrack),
 	}
 }
 
@@ -159,16 +195,12 @@ func (d *DummyReceiver) Receiver() sfu.TrackReceiver {
 func (d *DummyReceiver) Upgrade(receiver sfu.TrackReceiver) {
 	d.receiver.CompareAndSwap(nil, receiver)
 
-	d.downtrackLock.Lock()
-	for _, t := range d.downtracks {
-		receiver.AddDownTrack(t)
-	}
-	d.downtracks = make(map[livekit.ParticipantID]sfu.TrackSender)
-	d.downtrackLock.Unlock()
-
+	// apply settings before any subscriber is moved, so that forwarding starts at the expected layer
+	keyFrameLayer := buffer.InvalidLayerSpatial
 	d.settingsLock.Lock()
 	if d.maxExpectedLayerValid {
 		receiver.SetMaxExpectedSpatialLayer(d.maxExpectedLayer)
+		keyFrameLayer = d.maxExpectedLayer
 	}
 	d.maxExpectedLayerValid = false
 
@@ -177,6 +209,78 @@ func (d *DummyReceiver) Upgrade(receiver sfu.TrackReceiver) {
 	}
 	d.pausedValid = false
 	d.settingsLock.Unlock()
+
+	d.migrateDownTracks(receiver, keyFrameLayer)
+}
+
+// SetMigrationPriority sets the priority of the subscribers on upgrade, higher priority subscribers are moved to the
+// receiver first
+func (d *DummyReceiver) SetMigrationPriority(f func(subscriberID livekit.ParticipantID) int) {
+	d.migrationPriority.Store(f)
+}
+
+// migrateDownTracks moves the next batch of pending down tracks to the receiver, highest priority subscribers
+// first, earliest subscribers first among the same priority, and schedules the following batch. Down tracks not
+// migrated yet stay pending, so the subscription calls keep working on them until then.
+func (d *DummyReceiver) migrateDownTracks(receiver sfu.TrackReceiver, keyFrameLayer int32) {
+	d.downtrackLock.Lock()
+	if receiver.IsClosed() {
+		d.downtracks = make(map[livekit.ParticipantID]pendingDownTrack)
+		d.downtrackLock.Unlock()
+		return
+	}
+
+	pending := make([]pendingDownTrack, 0, len(d.downtracks))
+	for _, t := range d.downtracks {
+		pending = append(pending, t)
+	}
+	d.downtrackLock.Unlock()
+
+	// priorities are looked up outside the lock, they come from the subscriptions of the track
+	priorities := make(map[livekit.ParticipantID]int, len(pending))
+	if migrationPriority, ok := d.migrationPriority.Load().(func(livekit.ParticipantID) int); ok && migrationPriority != nil {
+		for _, t := range pending {
+			priorities[t.SubscriberID()] = migrationPriority(t.SubscriberID())
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		pi, pj := priorities[pending[i].SubscriberID()], priorities[pending[j].SubscriberID()]
+		if pi != pj {
+			return pi > pj
+		}
+		return pending[i].seq < pending[j].seq
+	})
+	if len(pending) > d.migrationBatchSize {
+		pending = pending[:d.migrationBatchSize]
+	}
+
+	d.downtrackLock.Lock()
+	migrated := 0
+	for _, t := range pending {
+		// removed or replaced while the priorities were looked up
+		t, ok := d.downtracks[t.SubscriberID()]
+		if !ok {
+			continue
+		}
+		delete(d.downtracks, t.SubscriberID())
+		if t.IsClosed() {
+			continue
+		}
+		receiver.AddDownTrack(t.TrackSender)
+		migrated++
+	}
+	remaining := len(d.downtracks)
+	d.downtrackLock.Unlock()
+
+	if migrated > 0 && keyFrameLayer != buffer.InvalidLayerSpatial {
+		receiver.SendPLI(keyFrameLayer, false)
+	}
+
+	if remaining > 0 {
+		time.AfterFunc(d.migrationBatchInterval, func() {
+			d.migrateDownTracks(receiver, keyFrameLayer)
+		})
+	}
 }
 
 func (d *DummyReceiver) TrackID() livekit.TrackID {
@@ -228,6 +332,13 @@ func (d *DummyReceiver) SendPLI(layer int32, force bool) {
 	}
 }
 
+func (d *DummyReceiver) ReplayKeyFrame(layer int32, track sfu.TrackSender) bool {
+	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
+		return r.ReplayKeyFrame(layer, track)
+	}
+	return false
+}
+
 func (d *DummyReceiver) SetUpTrackPaused(paused bool) {
 	d.settingsLock.Lock()
 	defer d.settingsLock.Unlock()
@@ -256,9 +367,16 @@ func (d *DummyReceiver) AddDownTrack(track sfu.TrackSender) error {
 	d.downtrackLock.Lock()
 	defer d.downtrackLock.Unlock()
 	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
+		// replaces a down track still waiting to be migrated
+		delete(d.downtracks, track.SubscriberID())
 		r.AddDownTrack(track)
 	} else {
-		d.downtracks[track.SubscriberID()] = track
+		seq := d.downtracks[track.SubscriberID()].seq
+		if seq == 0 {
+			d.downtrackSeq++
+			seq = d.downtrackSeq
+		}
+		d.downtracks[track.SubscriberID()] = pendingDownTrack{TrackSender: track, seq: seq}
 	}
 	return nil
 }
@@ -266,10 +384,12 @@ func (d *DummyReceiver) AddDownTrack(track sfu.TrackSender) error {
 func (d *DummyReceiver) DeleteDownTrack(participantID livekit.ParticipantID) {
 	d.downtrackLock.Lock()
 	defer d.downtrackLock.Unlock()
+	if _, ok := d.downtracks[participantID]; ok {
+		delete(d.downtracks, participantID)
+		return
+	}
 	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
 		r.DeleteDownTrack(participantID)
-	} else {
-		delete(d.downtracks, participantID)
 	}
 }
 
@@ -330,6 +450,13 @@ func (d *DummyReceiver) GetReferenceLayerRTPTimestamp(ts uint32, layer int32, re
 	return 0, errors.New("receiver not available")
 }
 
+func (d *DummyReceiver) HasBitDepthMismatch() bool {
+	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
+		return r.HasBitDepthMismatch()
+	}
+	return false
+}
+
 func (d *DummyReceiver) GetTrackStats() *livekit.RTPStats {
 	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
 		return r.GetTrackStats()
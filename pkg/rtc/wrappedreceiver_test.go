@@ -0,0 +1,145 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+type testTrackSender struct {
+	sfu.TrackSender
+	subscriberID livekit.ParticipantID
+}
+
+func (t *testTrackSender) SubscriberID() livekit.ParticipantID {
+	return t.subscriberID
+}
+
+func (t *testTrackSender) IsClosed() bool {
+	return false
+}
+
+type testTrackReceiver struct {
+	sfu.TrackReceiver
+
+	lock       sync.Mutex
+	downTracks []livekit.ParticipantID
+	plis       []int32
+}
+
+func (r *testTrackReceiver) AddDownTrack(track sfu.TrackSender) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.downTracks = append(r.downTracks, track.SubscriberID())
+	return nil
+}
+
+func (r *testTrackReceiver) DeleteDownTrack(_ livekit.ParticipantID) {}
+
+func (r *testTrackReceiver) SetMaxExpectedSpatialLayer(_ int32) {}
+
+func (r *testTrackReceiver) SendPLI(layer int32, _ bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.plis = append(r.plis, layer)
+}
+
+func (r *testTrackReceiver) IsClosed() bool {
+	return false
+}
+
+func (r *testTrackReceiver) counts() (int, int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.downTracks), len(r.plis)
+}
+
+func TestDummyReceiverUpgradeInBatches(t *testing.T) {
+	d := NewDummyReceiver("TR_dummy", "PA_publisher", webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9},
+	}, nil)
+	d.migrationBatchSize = 2
+	d.migrationBatchInterval = 20 * time.Millisecond
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, d.AddDownTrack(&testTrackSender{subscriberID: livekit.ParticipantID(fmt.Sprintf("PA_%d", i))}))
+	}
+	d.SetMaxExpectedSpatialLayer(2)
+
+	receiver := &testTrackReceiver{}
+	d.Upgrade(receiver)
+
+	// first batch is migrated right away, with a key frame requested for it
+	added, plis := receiver.counts()
+	require.Equal(t, 2, added)
+	require.Equal(t, 1, plis)
+
+	// removed before its turn, never reaches the receiver
+	d.DeleteDownTrack("PA_4")
+
+	require.Eventually(t, func() bool {
+		added, _ := receiver.counts()
+		return added == 4
+	}, time.Second, 5*time.Millisecond)
+
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+	require.Equal(t, []livekit.ParticipantID{"PA_0", "PA_1", "PA_2", "PA_3"}, receiver.downTracks)
+	require.Equal(t, []int32{2, 2}, receiver.plis)
+}
+
+func TestDummyReceiverUpgradeByPriority(t *testing.T) {
+	d := NewDummyReceiver("TR_dummy", "PA_publisher", webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9},
+	}, nil)
+	d.migrationBatchSize = 2
+	d.migrationBatchInterval = 20 * time.Millisecond
+	// PA_3 is speaking, PA_2 ranked the track
+	d.SetMigrationPriority(func(subscriberID livekit.ParticipantID) int {
+		switch subscriberID {
+		case "PA_3":
+			return 2
+		case "PA_2":
+			return 1
+		}
+		return 0
+	})
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, d.AddDownTrack(&testTrackSender{subscriberID: livekit.ParticipantID(fmt.Sprintf("PA_%d", i))}))
+	}
+
+	receiver := &testTrackReceiver{}
+	d.Upgrade(receiver)
+
+	require.Eventually(t, func() bool {
+		added, _ := receiver.counts()
+		return added == 5
+	}, time.Second, 5*time.Millisecond)
+
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+	require.Equal(t, []livekit.ParticipantID{"PA_3", "PA_2", "PA_0", "PA_1", "PA_4"}, receiver.downTracks)
+}
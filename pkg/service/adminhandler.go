@@ -0,0 +1,256 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// AdminHandler serves pprof, expvar and a snapshot of goroutines and lock contention on the admin port,
+// along with the rooms and participants of the node for the admin command. It answers while admin is enabled
+// in the running config, to tokens with the node admin permission signed with one of the admin API keys.
+type AdminHandler struct {
+	conf        *config.Config
+	roomManager *RoomManager
+	auditLog    *AuditLog
+	mux         *http.ServeMux
+
+	lock             sync.Mutex
+	blockProfileRate int
+}
+
+func NewAdminHandler(conf *config.Config, roomManager *RoomManager, auditLog *AuditLog) *AdminHandler {
+	h := &AdminHandler{
+		conf:        conf,
+		roomManager: roomManager,
+		auditLog:    auditLog,
+		mux:         http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("/debug/pprof/", pprof.Index)
+	h.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	h.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	h.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	h.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	h.mux.Handle("/debug/vars", expvar.Handler())
+	h.mux.HandleFunc("/debug/snapshot", h.snapshot)
+	h.mux.HandleFunc("/admin/rooms", h.listRooms)
+	h.mux.HandleFunc("/admin/participant", h.participantDebugInfo)
+	h.mux.HandleFunc("/admin/ice-restart", h.restartICE)
+	h.mux.HandleFunc("/admin/remove-participant", h.removeParticipant)
+
+	h.applyProfileRates(conf.Reloadable().Admin)
+	conf.OnReload(func(rc *config.ReloadableConfig) {
+		h.applyProfileRates(rc.Admin)
+	})
+	return h
+}
+
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	admin := h.conf.Reloadable().Admin
+	if !admin.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	if GetGrants(r.Context()) == nil {
+		handleError(w, r, http.StatusUnauthorized, ErrMissingAuthorization)
+		return
+	}
+	if err := EnsureNodeAdminPermission(r.Context()); err != nil {
+		handleError(w, r, http.StatusForbidden, err)
+		return
+	}
+	if len(admin.APIKeys) != 0 && !slices.Contains(admin.APIKeys, GetAPIKey(r.Context())) {
+		handleError(w, r, http.StatusForbidden, ErrPermissionDenied)
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+// contention is sampled only while admin is enabled, sampling has a cost on every lock and channel operation
+func (h *AdminHandler) applyProfileRates(admin config.AdminConfig) {
+	mutexProfileFraction, blockProfileRate := 0, 0
+	if admin.Enabled {
+		mutexProfileFraction, blockProfileRate = admin.MutexProfileFraction, admin.BlockProfileRate
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	runtime.SetMutexProfileFraction(mutexProfileFraction)
+	runtime.SetBlockProfileRate(blockProfileRate)
+	h.blockProfileRate = blockProfileRate
+	logger.Infow("admin profiling updated",
+		"enabled", admin.Enabled,
+		"mutexProfileFraction", mutexProfileFraction,
+		"blockProfileRate", blockProfileRate,
+	)
+}
+
+// snapshot writes the goroutines and the mutex and block contention profiles as text,
+// goroutines are written with their full stacks with debug=2
+func (h *AdminHandler) snapshot(w http.ResponseWriter, r *http.Request) {
+	debug := 1
+	if d, err := strconv.Atoi(r.FormValue("debug")); err == nil && d > 1 {
+		debug = 2
+	}
+
+	h.lock.Lock()
+	blockProfileRate := h.blockProfileRate
+	h.lock.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = fmt.Fprintf(w, "goroutines: %d\nmutex profile fraction: %d\nblock profile rate: %d\n",
+		runtime.NumGoroutine(),
+		runtime.SetMutexProfileFraction(-1),
+		blockProfileRate,
+	)
+	for _, name := range []string{"goroutine", "mutex", "block"} {
+		_, _ = fmt.Fprintf(w, "\n--- %s\n", name)
+		profileDebug := 1
+		if name == "goroutine" {
+			profileDebug = debug
+		}
+		if err := rpprof.Lookup(name).WriteTo(w, profileDebug); err != nil {
+			_, _ = fmt.Fprintf(w, "could not write profile: %v\n", err)
+		}
+	}
+}
+
+// listRooms writes the rooms hosted by the node, as a ListRoomsResponse
+func (h *AdminHandler) listRooms(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	res := &livekit.ListRoomsResponse{}
+	if h.roomManager != nil {
+		h.roomManager.lock.RLock()
+		for _, room := range h.roomManager.rooms {
+			res.Rooms = append(res.Rooms, room.ToProto())
+		}
+		h.roomManager.lock.RUnlock()
+	}
+	slices.SortFunc(res.Rooms, func(a, b *livekit.Room) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(res)
+	if err != nil {
+		handleError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// participantDebugInfo writes the debug info of the participant in the room and identity query parameters
+func (h *AdminHandler) participantDebugInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	participant, ok := h.participant(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(participant.DebugInfo())
+}
+
+// restartICE restarts the ICE connections of a participant, as when the client asks for it
+func (h *AdminHandler) restartICE(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	participant, ok := h.participant(w, r)
+	if !ok {
+		return
+	}
+	participant.GetLogger().Infow("restarting ICE from admin")
+	participant.ICERestart(nil)
+	h.auditLog.Record(r.Context(), "AdminRestartICE", livekit.RoomName(r.FormValue("room")), nil, map[string]string{
+		"identity": r.FormValue("identity"),
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) removeParticipant(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := h.participant(w, r); !ok {
+		return
+	}
+	req := &livekit.RoomParticipantIdentity{Room: r.FormValue("room"), Identity: r.FormValue("identity")}
+	_, err := h.roomManager.RemoveParticipant(r.Context(), req)
+	h.auditLog.Record(r.Context(), "AdminRemoveParticipant", livekit.RoomName(req.Room), err, req)
+	if err != nil {
+		handleError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// participant returns the participant of the request, writing not found when it is not on the node
+func (h *AdminHandler) participant(w http.ResponseWriter, r *http.Request) (types.LocalParticipant, bool) {
+	roomName, identity := livekit.RoomName(r.FormValue("room")), livekit.ParticipantIdentity(r.FormValue("identity"))
+	if roomName == "" || identity == "" {
+		http.Error(w, "room and identity are required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var room *rtc.Room
+	if h.roomManager != nil {
+		room = h.roomManager.GetRoom(r.Context(), roomName)
+	}
+	if room == nil {
+		http.Error(w, ErrRoomNotFound.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		http.Error(w, ErrParticipantNotFound.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	return participant, true
+}
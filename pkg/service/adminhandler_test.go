@@ -0,0 +1,77 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestAdminHandler(t *testing.T) {
+	keys := `keys:
+  key1: secret1secret1secret1secret1secret1
+  key2: secret2secret2secret2secret2secret2
+`
+	conf, err := config.NewConfig(keys+`admin:
+  port: 6060
+  api_keys: [key1]`, true, nil, nil)
+	require.NoError(t, err)
+
+	m := service.NewAPIKeyAuthMiddleware(service.NewReloadableKeyProvider(conf), nil)
+	h := service.NewAdminHandler(conf, nil, nil)
+	serve := func(key string, grant *auth.VideoGrant) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/debug/snapshot", nil)
+		if grant != nil {
+			token, err := auth.NewAccessToken(key, conf.Reloadable().Keys[key]).AddGrant(grant).ToJWT()
+			require.NoError(t, err)
+			service.SetAuthorizationToken(r, token)
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, h.ServeHTTP)
+		return w
+	}
+	nodeAdmin := &auth.VideoGrant{RoomAdmin: true, RoomList: true}
+
+	// disabled until enabled by a reload
+	require.Equal(t, http.StatusNotFound, serve("key1", nodeAdmin).Code)
+
+	next, err := config.NewConfig(keys+`admin:
+  port: 6060
+  enabled: true
+  api_keys: [key1]`, true, nil, nil)
+	require.NoError(t, err)
+	_, _, err = conf.Reload(next)
+	require.NoError(t, err)
+
+	w := serve("key1", nodeAdmin)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "goroutines:")
+	require.Contains(t, w.Body.String(), "--- mutex")
+
+	require.Equal(t, http.StatusUnauthorized, serve("key1", nil).Code)
+	// hosts of a room administer that room only
+	require.Equal(t, http.StatusForbidden, serve("key1", &auth.VideoGrant{RoomAdmin: true, RoomList: true, Room: "room"}).Code)
+	require.Equal(t, http.StatusForbidden, serve("key1", &auth.VideoGrant{RoomList: true}).Code)
+	// valid key outside of the admin keys
+	require.Equal(t, http.StatusForbidden, serve("key2", nodeAdmin).Code)
+}
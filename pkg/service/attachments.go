@@ -0,0 +1,331 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	// AttachmentTopic is the data topic attachment announcements are sent to participants on
+	AttachmentTopic = "lk.attachments"
+
+	AttachmentPublished = "published"
+	AttachmentDeleted   = "deleted"
+
+	attachmentPrefix        = "AT_"
+	maxAttachmentNameLength = 255
+	attachmentCheckTimeout  = 10 * time.Second
+)
+
+// Attachment is a file shared with the participants of a room
+type Attachment struct {
+	ID          string `json:"id"`
+	Room        string `json:"room"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// identity of the participant that shared it, empty for attachments created with a server token
+	UploadedBy string `json:"uploaded_by,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+	// Published is set once the file has been uploaded and announced to the room
+	Published bool `json:"published"`
+}
+
+// AttachmentInfo is an attachment with URLs to access its file, valid until URLExpiresAt
+type AttachmentInfo struct {
+	*Attachment
+	UploadURL    string `json:"upload_url,omitempty"`
+	DownloadURL  string `json:"download_url,omitempty"`
+	URLExpiresAt int64  `json:"url_expires_at,omitempty"`
+}
+
+// AttachmentAnnouncement is sent to the participants of a room on AttachmentTopic
+type AttachmentAnnouncement struct {
+	Type       string          `json:"type"`
+	Attachment *AttachmentInfo `json:"attachment"`
+}
+
+type CreateAttachmentRequest struct {
+	Room        string `json:"room"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+type AttachmentRequest struct {
+	Room string `json:"room"`
+	ID   string `json:"id"`
+}
+
+type ListAttachmentsRequest struct {
+	Room string `json:"room"`
+}
+
+type ListAttachmentsResponse struct {
+	Attachments []*AttachmentInfo `json:"attachments"`
+}
+
+// RoomAttachments keeps track of room attachments and signs the URLs for their files in object storage.
+// The server never handles file contents, clients upload with a signed PUT request and the attachment is
+// published once the object exists.
+type RoomAttachments struct {
+	conf       config.AttachmentsConfig
+	store      AttachmentStore
+	presigner  *S3Presigner
+	httpClient *http.Client
+}
+
+// NewRoomAttachments returns nil when attachments are not configured
+func NewRoomAttachments(conf *config.Config, store ObjectStore) (*RoomAttachments, error) {
+	if conf.Attachments.S3 == nil {
+		return nil, nil
+	}
+
+	presigner, err := NewS3Presigner(*conf.Attachments.S3)
+	if err != nil {
+		return nil, err
+	}
+	return &RoomAttachments{
+		conf:       conf.Attachments,
+		store:      store,
+		presigner:  presigner,
+		httpClient: &http.Client{Timeout: attachmentCheckTimeout},
+	}, nil
+}
+
+// Create records an attachment that has yet to be uploaded and returns the URL to upload it to.
+// The upload has to set the Content-Type and Content-Length headers to the values in the request.
+func (a *RoomAttachments) Create(ctx context.Context, req *CreateAttachmentRequest, uploadedBy livekit.ParticipantIdentity) (*AttachmentInfo, error) {
+	if a == nil {
+		return nil, ErrAttachmentsNotEnabled
+	}
+
+	name := path.Base(strings.ReplaceAll(req.Name, "\\", "/"))
+	if !validAttachmentName(name) || req.Size <= 0 || req.Size > a.conf.MaxSize || !a.contentTypeAllowed(req.ContentType) {
+		return nil, ErrAttachmentInvalid
+	}
+
+	roomName := livekit.RoomName(req.Room)
+	if a.conf.MaxPerRoom > 0 {
+		attachments, err := a.store.ListAttachments(ctx, roomName)
+		if err != nil {
+			return nil, err
+		}
+		if len(attachments) >= a.conf.MaxPerRoom {
+			return nil, ErrAttachmentLimitExceeded
+		}
+	}
+
+	attachment := &Attachment{
+		ID:          utils.NewGuid(attachmentPrefix),
+		Room:        req.Room,
+		Name:        name,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		UploadedBy:  string(uploadedBy),
+		CreatedAt:   time.Now().Unix(),
+	}
+	if err := a.store.StoreAttachment(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	uploadURL, err := a.presigner.PresignURL(http.MethodPut, a.objectKey(attachment), http.Header{
+		"Content-Type":   {attachment.ContentType},
+		"Content-Length": {strconv.FormatInt(attachment.Size, 10)},
+	}, a.conf.URLExpiry, now)
+	if err != nil {
+		return nil, err
+	}
+	return &AttachmentInfo{
+		Attachment:   attachment,
+		UploadURL:    uploadURL,
+		URLExpiresAt: now.Add(a.conf.URLExpiry).Unix(),
+	}, nil
+}
+
+func (a *RoomAttachments) Load(ctx context.Context, roomName livekit.RoomName, attachmentID string) (*Attachment, error) {
+	if a == nil {
+		return nil, ErrAttachmentsNotEnabled
+	}
+	return a.store.LoadAttachment(ctx, roomName, attachmentID)
+}
+
+// Publish marks an uploaded attachment as published, it returns ErrAttachmentNotUploaded when its file
+// does not exist in object storage
+func (a *RoomAttachments) Publish(ctx context.Context, attachment *Attachment) (*AttachmentInfo, error) {
+	if a == nil {
+		return nil, ErrAttachmentsNotEnabled
+	}
+
+	if !attachment.Published {
+		if err := a.checkUploaded(ctx, attachment); err != nil {
+			return nil, err
+		}
+		attachment.Published = true
+		if err := a.store.StoreAttachment(ctx, attachment); err != nil {
+			return nil, err
+		}
+	}
+	return a.withDownloadURL(attachment)
+}
+
+// List returns the published attachments of a room
+func (a *RoomAttachments) List(ctx context.Context, roomName livekit.RoomName) ([]*AttachmentInfo, error) {
+	if a == nil {
+		return nil, ErrAttachmentsNotEnabled
+	}
+
+	attachments, err := a.store.ListAttachments(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*AttachmentInfo, 0, len(attachments))
+	for _, attachment := range attachments {
+		if !attachment.Published {
+			continue
+		}
+		info, err := a.withDownloadURL(attachment)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// Delete removes the attachment and its file
+func (a *RoomAttachments) Delete(ctx context.Context, attachment *Attachment) error {
+	if a == nil {
+		return ErrAttachmentsNotEnabled
+	}
+
+	res, err := a.objectRequest(ctx, http.MethodDelete, attachment)
+	if err != nil {
+		return err
+	}
+	// deleting an object that was never uploaded succeeds
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("could not delete attachment object, status %d", res.StatusCode)
+	}
+	return a.store.DeleteAttachment(ctx, livekit.RoomName(attachment.Room), attachment.ID)
+}
+
+func (a *RoomAttachments) checkUploaded(ctx context.Context, attachment *Attachment) error {
+	res, err := a.objectRequest(ctx, http.MethodHead, attachment)
+	if err != nil {
+		return err
+	}
+	switch {
+	case res.StatusCode == http.StatusOK:
+		return nil
+	// without permission to list the bucket, storage responds to missing objects with forbidden
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusForbidden:
+		return ErrAttachmentNotUploaded
+	default:
+		return fmt.Errorf("could not check attachment object, status %d", res.StatusCode)
+	}
+}
+
+func (a *RoomAttachments) objectRequest(ctx context.Context, method string, attachment *Attachment) (*http.Response, error) {
+	signedURL, err := a.presigner.PresignURL(method, a.objectKey(attachment), nil, attachmentCheckTimeout, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, signedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = res.Body.Close()
+	return res, nil
+}
+
+func (a *RoomAttachments) withDownloadURL(attachment *Attachment) (*AttachmentInfo, error) {
+	now := time.Now()
+	downloadURL, err := a.presigner.PresignURL(http.MethodGet, a.objectKey(attachment), nil, a.conf.URLExpiry, now)
+	if err != nil {
+		return nil, err
+	}
+	return &AttachmentInfo{
+		Attachment:   attachment,
+		DownloadURL:  downloadURL,
+		URLExpiresAt: now.Add(a.conf.URLExpiry).Unix(),
+	}, nil
+}
+
+// objects are grouped by room, so that the files of a room can be found in the bucket
+func (a *RoomAttachments) objectKey(attachment *Attachment) string {
+	return a.conf.S3.Prefix + attachment.Room + "/" + attachment.ID + "/" + attachment.Name
+}
+
+func (a *RoomAttachments) contentTypeAllowed(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if !strings.Contains(mediaType, "/") {
+		return false
+	}
+	if len(a.conf.ContentTypes) == 0 {
+		return true
+	}
+	for _, allowed := range a.conf.ContentTypes {
+		allowed = strings.ToLower(allowed)
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func validAttachmentName(name string) bool {
+	if name == "" || name == "." || name == "/" || len(name) > maxAttachmentNameLength {
+		return false
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func attachmentAnnouncement(kind string, info *AttachmentInfo) ([]byte, error) {
+	return json.Marshal(&AttachmentAnnouncement{
+		Type:       kind,
+		Attachment: info,
+	})
+}
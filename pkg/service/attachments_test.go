@@ -0,0 +1,207 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/protocol/rpc/rpcfakes"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+func TestRoomAttachments(t *testing.T) {
+	var lock sync.Mutex
+	objects := make(map[string]string)
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path] = r.Header.Get("Content-Type")
+		case http.MethodHead:
+			if _, ok := objects[r.URL.Path]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer storage.Close()
+
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	conf.Attachments.MaxSize = 1000
+	conf.Attachments.MaxPerRoom = 2
+	conf.Attachments.ContentTypes = []string{"application/pdf", "image/*"}
+	conf.Attachments.S3 = &config.S3StorageConfig{
+		AccessKey:      "key",
+		Secret:         "secret",
+		Region:         "us-east-1",
+		Endpoint:       storage.URL,
+		Bucket:         "attachments",
+		ForcePathStyle: true,
+	}
+
+	store := service.NewLocalStore()
+	require.NoError(t, store.StoreRoom(context.Background(), &livekit.Room{Name: "myroom"}, nil))
+	attachments, err := service.NewRoomAttachments(conf, store)
+	require.NoError(t, err)
+
+	roomClient := &rpcfakes.FakeTypedRoomClient{}
+	svc, err := service.NewRoomService(
+		conf.Room,
+		config.APIConfig{},
+		rpc.PSRPCConfig{},
+		nil,
+		&servicefakes.FakeRoomAllocator{},
+		store,
+		nil,
+		nil,
+		rpc.NewTopicFormatter(),
+		roomClient,
+		&rpcfakes.FakeTypedParticipantClient{},
+		&servicefakes.FakeParticipantExtClient{},
+		&servicefakes.FakeRoomExtClient{},
+		&servicefakes.FakeNodeExtClient{},
+		nil,
+		attachments,
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+
+	participantCtx := func(identity string) context.Context {
+		return service.WithGrants(context.Background(), &auth.ClaimGrants{
+			Identity: identity,
+			Video:    &auth.VideoGrant{RoomJoin: true, Room: "myroom"},
+		})
+	}
+	alice := participantCtx("alice")
+	bob := participantCtx("bob")
+	admin := service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "myroom"},
+	})
+
+	t.Run("rejects invalid attachments", func(t *testing.T) {
+		for _, req := range []*service.CreateAttachmentRequest{
+			{Room: "myroom", Name: "notes.txt", ContentType: "text/plain", Size: 10},
+			{Room: "myroom", Name: "large.pdf", ContentType: "application/pdf", Size: 1001},
+			{Room: "myroom", Name: "", ContentType: "application/pdf", Size: 10},
+		} {
+			_, err := svc.CreateAttachment(alice, req)
+			require.ErrorIs(t, err, service.ErrAttachmentInvalid, req.Name)
+		}
+
+		_, err := svc.CreateAttachment(alice, &service.CreateAttachmentRequest{Room: "other", Name: "a.pdf", ContentType: "application/pdf", Size: 10})
+		require.Error(t, err)
+	})
+
+	var attachmentID string
+	t.Run("uploads and publishes", func(t *testing.T) {
+		info, err := svc.CreateAttachment(alice, &service.CreateAttachmentRequest{
+			Room:        "myroom",
+			Name:        "../board.png",
+			ContentType: "image/png",
+			Size:        4,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "board.png", info.Name)
+		require.Equal(t, "alice", info.UploadedBy)
+		require.False(t, info.Published)
+		require.NotEmpty(t, info.UploadURL)
+		attachmentID = info.ID
+
+		_, err = svc.PublishAttachment(alice, &service.AttachmentRequest{Room: "myroom", ID: attachmentID})
+		require.ErrorIs(t, err, service.ErrAttachmentNotUploaded)
+
+		req, err := http.NewRequest(http.MethodPut, info.UploadURL, bytes.NewReader([]byte("data")))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "image/png")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+
+		_, err = svc.PublishAttachment(bob, &service.AttachmentRequest{Room: "myroom", ID: attachmentID})
+		require.Error(t, err)
+
+		published, err := svc.PublishAttachment(alice, &service.AttachmentRequest{Room: "myroom", ID: attachmentID})
+		require.NoError(t, err)
+		require.True(t, published.Published)
+		require.NotEmpty(t, published.DownloadURL)
+
+		require.Equal(t, 1, roomClient.SendDataCallCount())
+		_, _, sent, _ := roomClient.SendDataArgsForCall(0)
+		require.Equal(t, service.AttachmentTopic, sent.GetTopic())
+		announcement := &service.AttachmentAnnouncement{}
+		require.NoError(t, json.Unmarshal(sent.Data, announcement))
+		require.Equal(t, service.AttachmentPublished, announcement.Type)
+		require.Equal(t, attachmentID, announcement.Attachment.ID)
+
+		// publishing again does not announce it twice
+		_, err = svc.PublishAttachment(alice, &service.AttachmentRequest{Room: "myroom", ID: attachmentID})
+		require.NoError(t, err)
+		require.Equal(t, 1, roomClient.SendDataCallCount())
+	})
+
+	t.Run("lists published attachments", func(t *testing.T) {
+		_, err := svc.CreateAttachment(bob, &service.CreateAttachmentRequest{Room: "myroom", Name: "doc.pdf", ContentType: "application/pdf", Size: 10})
+		require.NoError(t, err)
+		_, err = svc.CreateAttachment(bob, &service.CreateAttachmentRequest{Room: "myroom", Name: "doc2.pdf", ContentType: "application/pdf", Size: 10})
+		require.ErrorIs(t, err, service.ErrAttachmentLimitExceeded)
+
+		res, err := svc.ListAttachments(bob, &service.ListAttachmentsRequest{Room: "myroom"})
+		require.NoError(t, err)
+		require.Len(t, res.Attachments, 1)
+		require.Equal(t, attachmentID, res.Attachments[0].ID)
+		require.NotEmpty(t, res.Attachments[0].DownloadURL)
+	})
+
+	t.Run("deletes attachments", func(t *testing.T) {
+		_, err := svc.DeleteAttachment(bob, &service.AttachmentRequest{Room: "myroom", ID: attachmentID})
+		require.Error(t, err)
+
+		_, err = svc.DeleteAttachment(admin, &service.AttachmentRequest{Room: "myroom", ID: attachmentID})
+		require.NoError(t, err)
+		lock.Lock()
+		require.Empty(t, objects)
+		lock.Unlock()
+
+		require.Equal(t, 2, roomClient.SendDataCallCount())
+		_, _, sent, _ := roomClient.SendDataArgsForCall(1)
+		announcement := &service.AttachmentAnnouncement{}
+		require.NoError(t, json.Unmarshal(sent.Data, announcement))
+		require.Equal(t, service.AttachmentDeleted, announcement.Type)
+
+		_, err = svc.PublishAttachment(admin, &service.AttachmentRequest{Room: "myroom", ID: attachmentID})
+		require.ErrorIs(t, err, service.ErrAttachmentNotFound)
+	})
+}
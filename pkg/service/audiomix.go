@@ -0,0 +1,209 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/rpc"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const (
+	AudioMixFormatOGG = "ogg"
+	AudioMixFormatMP3 = "mp3"
+)
+
+// EncodedFileTypeMP3 is livekit.EncodedFileType_MP3 of newer protocol versions, understood by egress services
+// using them
+const EncodedFileTypeMP3 livekit.EncodedFileType = 3
+
+// speaker markers are kept for this long after their recording stopped
+const speakerMarkersRetention = 24 * time.Hour
+
+// AudioMixEgressOptions are the request fields of StartAudioMixEgress on top of a room composite egress request
+type AudioMixEgressOptions struct {
+	// ogg when empty
+	Format string `json:"format,omitempty"`
+	// records the changes of the loudest speaker, read them with GetSpeakerMarkers
+	SpeakerMarkers bool `json:"speaker_markers,omitempty"`
+}
+
+func (o *AudioMixEgressOptions) fileType() (livekit.EncodedFileType, error) {
+	switch strings.ToLower(o.Format) {
+	case "", AudioMixFormatOGG:
+		return livekit.EncodedFileType_OGG, nil
+	case AudioMixFormatMP3:
+		return EncodedFileTypeMP3, nil
+	default:
+		return 0, ErrAudioMixFormatInvalid
+	}
+}
+
+// StartAudioMixEgress records the mixed audio of a room to a single OGG or MP3 file. It is a room composite
+// egress without video, so no page is rendered and no video is encoded.
+func (s *EgressService) StartAudioMixEgress(ctx context.Context, req *livekit.RoomCompositeEgressRequest, opts *AudioMixEgressOptions) (*livekit.EgressInfo, error) {
+	if opts == nil {
+		opts = &AudioMixEgressOptions{}
+	}
+	fields := []interface{}{
+		"room", req.RoomName,
+		"format", opts.Format,
+		"speakerMarkers", opts.SpeakerMarkers,
+	}
+	defer func() {
+		AppendLogFields(ctx, fields...)
+	}()
+
+	if err := prepareAudioMixRequest(req, opts); err != nil {
+		return nil, err
+	}
+
+	ei, err := s.startEgress(ctx, livekit.RoomName(req.RoomName), &rpc.StartEgressRequest{
+		Request: &rpc.StartEgressRequest_RoomComposite{
+			RoomComposite: req,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	fields = append(fields, "egressID", ei.EgressId)
+
+	if opts.SpeakerMarkers {
+		// the recording is running, markers missing do not fail it
+		if _, err := s.roomExtClient.RecordSpeakerMarkers(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.RoomName)), &RecordSpeakerMarkersRequest{
+			Room:     req.RoomName,
+			EgressID: ei.EgressId,
+		}); err != nil {
+			logger.Warnw("could not record speaker markers", err, "room", req.RoomName, "egressID", ei.EgressId)
+		}
+	}
+	return ei, nil
+}
+
+// prepareAudioMixRequest turns req into an audio only request with a single file output of the format
+func prepareAudioMixRequest(req *livekit.RoomCompositeEgressRequest, opts *AudioMixEgressOptions) error {
+	fileType, err := opts.fileType()
+	if err != nil {
+		return err
+	}
+	if req.VideoOnly || len(req.StreamOutputs) != 0 || len(req.SegmentOutputs) != 0 || len(req.ImageOutputs) != 0 {
+		return ErrAudioMixOutputInvalid
+	}
+
+	var file *livekit.EncodedFileOutput
+	switch {
+	case len(req.FileOutputs) == 1 && req.Output == nil:
+		file = req.FileOutputs[0]
+	case len(req.FileOutputs) == 0 && req.GetFile() != nil:
+		file = req.GetFile()
+	default:
+		return ErrAudioMixOutputInvalid
+	}
+
+	req.AudioOnly = true
+	file.FileType = fileType
+	return nil
+}
+
+// GetSpeakerMarkers returns the loudest speaker changes recorded for an audio mix egress, with their offsets from
+// the start of the egress. They are read from the room while the egress records, and kept for a day once it
+// stopped.
+func (s *EgressService) GetSpeakerMarkers(ctx context.Context, req *GetSpeakerMarkersRequest) (*SpeakerMarkersResponse, error) {
+	AppendLogFields(ctx, "room", req.Room, "egressID", req.EgressID)
+	if err := EnsureRecordPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	res, err := s.loadSpeakerMarkers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// until the egress is active, offsets are from the time markers started recording
+	if info, err := s.io.GetEgress(ctx, &rpc.GetEgressRequest{EgressId: req.EgressID}); err == nil && info.StartedAt > 0 {
+		res.Markers = offsetSpeakerMarkers(res.Markers, info.StartedAt)
+	}
+	return res, nil
+}
+
+func (s *EgressService) loadSpeakerMarkers(ctx context.Context, req *GetSpeakerMarkersRequest) (*SpeakerMarkersResponse, error) {
+	if s.speakerMarkers != nil {
+		markers, err := s.speakerMarkers.LoadSpeakerMarkers(ctx, req.EgressID)
+		if err == nil {
+			return &SpeakerMarkersResponse{EgressID: req.EgressID, Markers: markers}, nil
+		}
+		if !errors.Is(err, ErrSpeakerMarkersNotFound) {
+			return nil, err
+		}
+	}
+
+	// the recording has not stopped yet
+	if _, _, err := s.store.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		if errors.Is(err, ErrRoomNotFound) {
+			return nil, ErrSpeakerMarkersNotFound
+		}
+		return nil, err
+	}
+	return s.roomExtClient.GetSpeakerMarkers(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// offsetSpeakerMarkers measures the offsets of markers from startedAt, in unix nanoseconds. Of the markers before
+// it, only the speaker at the start is kept, at offset 0.
+func offsetSpeakerMarkers(markers []rtc.SpeakerMarker, startedAt int64) []rtc.SpeakerMarker {
+	startMs := startedAt / int64(time.Millisecond)
+	offset := make([]rtc.SpeakerMarker, 0, len(markers))
+	for _, m := range markers {
+		m.OffsetMs = m.Time - startMs
+		if m.OffsetMs <= 0 {
+			m.OffsetMs = 0
+			if n := len(offset); n > 0 && offset[n-1].OffsetMs == 0 {
+				offset[n-1] = m
+				continue
+			}
+		}
+		offset = append(offset, m)
+	}
+	return offset
+}
+
+// TwirpJSONHandlers returns handlers for the Egress methods the server supports on top of the protocol definitions
+func (s *EgressService) TwirpJSONHandlers(hooks *twirp.ServerHooks) []*TwirpJSONHandler {
+	return []*TwirpJSONHandler{
+		NewTwirpJSONHandler("livekit.Egress", "StartAudioMixEgress", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &livekit.RoomCompositeEgressRequest{}
+			opts := &AudioMixEgressOptions{}
+			if err := UnmarshalTwirpJSON(body, req, opts); err != nil {
+				return nil, err
+			}
+			return s.StartAudioMixEgress(ctx, req, opts)
+		}, nil),
+		NewTwirpJSONHandler("livekit.Egress", "GetSpeakerMarkers", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetSpeakerMarkersRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetSpeakerMarkers(ctx, req)
+		}, nil),
+	}
+}
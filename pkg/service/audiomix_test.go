@@ -0,0 +1,151 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+type testEgressLauncher struct {
+	requests []*rpc.StartEgressRequest
+}
+
+func (l *testEgressLauncher) StartEgress(ctx context.Context, req *rpc.StartEgressRequest) (*livekit.EgressInfo, error) {
+	return l.StartEgressWithClusterId(ctx, "", req)
+}
+
+func (l *testEgressLauncher) StartEgressWithClusterId(_ context.Context, _ string, req *rpc.StartEgressRequest) (*livekit.EgressInfo, error) {
+	l.requests = append(l.requests, req)
+	return &livekit.EgressInfo{EgressId: "EG_mix", RoomId: req.RoomId}, nil
+}
+
+func TestStartAudioMixEgress(t *testing.T) {
+	newService := func() (*service.EgressService, *testEgressLauncher, *servicefakes.FakeRoomExtClient) {
+		launcher := &testEgressLauncher{}
+		store := &servicefakes.FakeServiceStore{}
+		store.LoadRoomReturns(&livekit.Room{Name: "podcast", Sid: "RM_podcast"}, nil, nil)
+		roomExt := &servicefakes.FakeRoomExtClient{}
+		s := service.NewEgressService(nil, launcher, store, &servicefakes.FakeIOClient{}, nil, nil, roomExt, rpc.NewTopicFormatter(), nil, nil)
+		return s, launcher, roomExt
+	}
+	ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomRecord: true}})
+	fileRequest := func() *livekit.RoomCompositeEgressRequest {
+		return &livekit.RoomCompositeEgressRequest{
+			RoomName:    "podcast",
+			FileOutputs: []*livekit.EncodedFileOutput{{Filepath: "podcast/{time}"}},
+		}
+	}
+
+	t.Run("records audio only to ogg by default", func(t *testing.T) {
+		s, launcher, roomExt := newService()
+		info, err := s.StartAudioMixEgress(ctx, fileRequest(), nil)
+		require.NoError(t, err)
+		require.Equal(t, "EG_mix", info.EgressId)
+
+		require.Len(t, launcher.requests, 1)
+		req := launcher.requests[0].GetRoomComposite()
+		require.True(t, req.AudioOnly)
+		require.Equal(t, livekit.EncodedFileType_OGG, req.FileOutputs[0].FileType)
+		require.Equal(t, "RM_podcast", launcher.requests[0].RoomId)
+		require.Zero(t, roomExt.RecordSpeakerMarkersCallCount())
+	})
+
+	t.Run("records mp3 with speaker markers", func(t *testing.T) {
+		s, launcher, roomExt := newService()
+		req := &livekit.RoomCompositeEgressRequest{
+			RoomName: "podcast",
+			Output:   &livekit.RoomCompositeEgressRequest_File{File: &livekit.EncodedFileOutput{Filepath: "podcast.mp3"}},
+		}
+		_, err := s.StartAudioMixEgress(ctx, req, &service.AudioMixEgressOptions{Format: "MP3", SpeakerMarkers: true})
+		require.NoError(t, err)
+		require.Equal(t, service.EncodedFileTypeMP3, launcher.requests[0].GetRoomComposite().GetFile().FileType)
+
+		require.Equal(t, 1, roomExt.RecordSpeakerMarkersCallCount())
+		_, topic, markersReq, _ := roomExt.RecordSpeakerMarkersArgsForCall(0)
+		require.Equal(t, rpc.RoomTopic("podcast"), topic)
+		require.Equal(t, "EG_mix", markersReq.EgressID)
+	})
+
+	t.Run("speaker markers outlive the room", func(t *testing.T) {
+		store := &servicefakes.FakeServiceStore{}
+		store.LoadRoomReturns(nil, nil, service.ErrRoomNotFound)
+		io := &servicefakes.FakeIOClient{}
+		io.GetEgressReturns(&livekit.EgressInfo{EgressId: "EG_mix", StartedAt: int64(10_500 * time.Millisecond)}, nil)
+		markerStore := service.NewLocalStore()
+		s := service.NewEgressService(nil, &testEgressLauncher{}, store, io, nil, nil, &servicefakes.FakeRoomExtClient{}, rpc.NewTopicFormatter(), nil, markerStore)
+
+		_, err := s.GetSpeakerMarkers(ctx, &service.GetSpeakerMarkersRequest{Room: "podcast", EgressID: "EG_mix"})
+		require.ErrorIs(t, err, service.ErrSpeakerMarkersNotFound)
+
+		// recorded from the request, the egress started later
+		require.NoError(t, markerStore.StoreSpeakerMarkers(context.Background(), "EG_mix", []rtc.SpeakerMarker{
+			{OffsetMs: 0, Time: 9_000, Identity: "alice"},
+			{OffsetMs: 1_000, Time: 10_000, Identity: "bob"},
+			{OffsetMs: 3_000, Time: 12_000, Identity: "alice"},
+		}, time.Hour))
+		res, err := s.GetSpeakerMarkers(ctx, &service.GetSpeakerMarkersRequest{Room: "podcast", EgressID: "EG_mix"})
+		require.NoError(t, err)
+		require.Equal(t, []rtc.SpeakerMarker{
+			{OffsetMs: 0, Time: 10_000, Identity: "bob"},
+			{OffsetMs: 1_500, Time: 12_000, Identity: "alice"},
+		}, res.Markers)
+	})
+
+	t.Run("rejects other outputs and formats", func(t *testing.T) {
+		s, launcher, _ := newService()
+
+		_, err := s.StartAudioMixEgress(ctx, fileRequest(), &service.AudioMixEgressOptions{Format: "wav"})
+		require.ErrorIs(t, err, service.ErrAudioMixFormatInvalid)
+
+		req := fileRequest()
+		req.StreamOutputs = []*livekit.StreamOutput{{Urls: []string{"rtmp://example.com/live"}}}
+		_, err = s.StartAudioMixEgress(ctx, req, nil)
+		require.ErrorIs(t, err, service.ErrAudioMixOutputInvalid)
+
+		req = fileRequest()
+		req.FileOutputs = append(req.FileOutputs, &livekit.EncodedFileOutput{})
+		_, err = s.StartAudioMixEgress(ctx, req, nil)
+		require.ErrorIs(t, err, service.ErrAudioMixOutputInvalid)
+
+		req = fileRequest()
+		req.VideoOnly = true
+		_, err = s.StartAudioMixEgress(ctx, req, nil)
+		require.ErrorIs(t, err, service.ErrAudioMixOutputInvalid)
+
+		require.Empty(t, launcher.requests)
+	})
+
+	t.Run("requires record permission", func(t *testing.T) {
+		s, launcher, _ := newService()
+		noRecord := service.WithGrants(context.Background(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true}})
+		_, err := s.StartAudioMixEgress(noRecord, fileRequest(), nil)
+		require.Error(t, err)
+		_, err = s.GetSpeakerMarkers(noRecord, &service.GetSpeakerMarkersRequest{Room: "podcast", EgressID: "EG_mix"})
+		require.Error(t, err)
+		require.Empty(t, launcher.requests)
+	})
+}
@@ -0,0 +1,546 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/audioprocessing"
+)
+
+// bidirectional stream of the gRPC service, the messages are JSON in google.protobuf.BytesValue
+const audioProcessingGRPCMethod = "/livekit.AudioProcessor/Process"
+
+// packets of a track on their way to the service and back, a couple of seconds of 20ms packets
+const audioProcessingQueueSize = 128
+
+var (
+	errAudioProcessingTimeout      = errors.New("audio processing timed out")
+	errAudioProcessingStreamClosed = errors.New("audio processing stream closed")
+)
+
+// AudioProcessingPacket is a message of the gRPC stream of a track, in both directions. The first message sent
+// only carries Stream, the service answers every packet with the processed packet of the same sequence
+type AudioProcessingPacket struct {
+	Stream   *audioprocessing.StreamInfo `json:"stream,omitempty"`
+	Sequence uint64                      `json:"sequence"`
+	// opus packet
+	Data []byte `json:"data,omitempty"`
+}
+
+// audioProcessingRoom is the room a track is published in
+type audioProcessingRoom interface {
+	Name() livekit.RoomName
+	Options() *rtc.RoomOptions
+}
+
+// the receivers of published tracks, the processor is applied before packets are forwarded
+type payloadProcessorSetter interface {
+	SetPayloadProcessor(p sfu.PayloadProcessor)
+}
+
+type newPayloadProcessorFunc func(ctx context.Context, info audioprocessing.StreamInfo) (audioprocessing.PayloadProcessor, error)
+
+// AudioProcessing processes the microphone audio published on the node, in rooms that enable it in their options
+type AudioProcessing struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock         sync.Mutex
+	newProcessor newPayloadProcessorFunc
+	closer       io.Closer
+	processors   map[livekit.TrackID]audioprocessing.PayloadProcessor
+}
+
+// NewAudioProcessing returns nil when audio processing is not enabled. Without a gRPC service, audio is not
+// processed until a processor is compiled in with SetProcessor
+func NewAudioProcessing(conf config.AudioProcessingConfig) (*AudioProcessing, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+
+	a := newAudioProcessing()
+	if conf.GRPCAddress != "" {
+		g, err := newGRPCAudioProcessor(conf)
+		if err != nil {
+			return nil, err
+		}
+		a.newProcessor = g.NewPayloadProcessor
+		a.closer = g
+	}
+	return a, nil
+}
+
+func NewAudioProcessingWithProcessor(codec audioprocessing.Codec, processor audioprocessing.Processor) *AudioProcessing {
+	a := newAudioProcessing()
+	a.SetProcessor(codec, processor)
+	return a
+}
+
+func newAudioProcessing() *AudioProcessing {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AudioProcessing{
+		ctx:        ctx,
+		cancel:     cancel,
+		processors: make(map[livekit.TrackID]audioprocessing.PayloadProcessor),
+	}
+}
+
+// SetProcessor processes the audio of tracks published from now on with a processor compiled into the server,
+// the packets are decoded and encoded with codec
+func (a *AudioProcessing) SetProcessor(codec audioprocessing.Codec, processor audioprocessing.Processor) {
+	if a == nil {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.newProcessor = func(_ context.Context, info audioprocessing.StreamInfo) (audioprocessing.PayloadProcessor, error) {
+		return audioprocessing.NewPCMPayloadProcessor(codec, processor, info)
+	}
+}
+
+// TrackPublished processes a microphone track when the room enables audio processing, until it is unpublished
+func (a *AudioProcessing) TrackPublished(room audioProcessingRoom, participant types.LocalParticipant, track types.MediaTrack) {
+	if a == nil || track.Kind() != livekit.TrackType_AUDIO || track.Source() != livekit.TrackSource_MICROPHONE ||
+		participant.IsAgent() || participant.IsRecorder() {
+		return
+	}
+	receivers := track.Receivers()
+	if len(receivers) == 0 {
+		return
+	}
+	// packets of RED tracks carry redundant opus packets, they are not processed
+	if !strings.EqualFold(receivers[0].Codec().MimeType, webrtc.MimeTypeOpus) {
+		return
+	}
+	setter, ok := receivers[0].(payloadProcessorSetter)
+	if !ok {
+		return
+	}
+
+	// the track moved from another room, its processing is replaced
+	a.stop(track.ID(), setter)
+
+	features := room.Options().GetAudioProcessing()
+	a.lock.Lock()
+	newProcessor := a.newProcessor
+	a.lock.Unlock()
+	if features.IsZero() || newProcessor == nil {
+		return
+	}
+
+	pLogger := participant.GetLogger()
+	info := audioprocessing.StreamInfo{
+		Room:                string(room.Name()),
+		ParticipantIdentity: string(participant.Identity()),
+		TrackID:             string(track.ID()),
+		Features:            features,
+		SampleRate:          audioprocessing.SampleRate,
+		Channels:            1,
+	}
+	if track.ToProto().GetStereo() {
+		info.Channels = 2
+	}
+	processor, err := newProcessor(a.ctx, info)
+	if err != nil {
+		pLogger.Warnw("could not start audio processing", err, "trackID", track.ID())
+		return
+	}
+
+	a.lock.Lock()
+	a.processors[track.ID()] = processor
+	a.lock.Unlock()
+	setter.SetPayloadProcessor(processor)
+
+	track.AddOnClose(func() {
+		if a.remove(track.ID(), processor) {
+			closeAudioProcessor(processor, pLogger)
+			pLogger.Infow("audio processing stopped", "trackID", track.ID())
+		}
+	})
+	pLogger.Infow("audio processing started", "trackID", track.ID(), "features", features)
+}
+
+func (a *AudioProcessing) stop(trackID livekit.TrackID, setter payloadProcessorSetter) {
+	a.lock.Lock()
+	processor := a.processors[trackID]
+	delete(a.processors, trackID)
+	a.lock.Unlock()
+
+	if processor != nil {
+		// closed first, packets still waiting for a service go out ahead of the ones forwarded unprocessed
+		closeAudioProcessor(processor, logger.GetLogger())
+		setter.SetPayloadProcessor(nil)
+	}
+}
+
+// remove returns false when the processor of the track has been replaced
+func (a *AudioProcessing) remove(trackID livekit.TrackID, processor audioprocessing.PayloadProcessor) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.processors[trackID] != processor {
+		return false
+	}
+	delete(a.processors, trackID)
+	return true
+}
+
+// Close stops the audio processing of the node
+func (a *AudioProcessing) Close() {
+	if a == nil {
+		return
+	}
+
+	a.lock.Lock()
+	processors := a.processors
+	a.processors = make(map[livekit.TrackID]audioprocessing.PayloadProcessor)
+	a.lock.Unlock()
+
+	for _, processor := range processors {
+		closeAudioProcessor(processor, logger.GetLogger())
+	}
+	a.cancel()
+	if a.closer != nil {
+		_ = a.closer.Close()
+	}
+}
+
+func closeAudioProcessor(processor audioprocessing.PayloadProcessor, l logger.Logger) {
+	if err := processor.Close(); err != nil {
+		l.Debugw("could not close audio processing", "error", err)
+	}
+}
+
+// ------------------------------------------------
+
+// grpcAudioProcessor streams the opus packets of a track to a gRPC service that decodes, processes and
+// encodes them
+type grpcAudioProcessor struct {
+	conf config.AudioProcessingConfig
+	conn *grpc.ClientConn
+}
+
+func newGRPCAudioProcessor(conf config.AudioProcessingConfig) (*grpcAudioProcessor, error) {
+	creds := credentials.NewTLS(&tls.Config{})
+	if conf.GRPCInsecure {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.Dial(conf.GRPCAddress, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &grpcAudioProcessor{
+		conf: conf,
+		conn: conn,
+	}, nil
+}
+
+func (g *grpcAudioProcessor) Close() error {
+	return g.conn.Close()
+}
+
+var audioProcessingStreamDesc = &grpc.StreamDesc{
+	StreamName:    "Process",
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+func (g *grpcAudioProcessor) NewPayloadProcessor(ctx context.Context, info audioprocessing.StreamInfo) (audioprocessing.PayloadProcessor, error) {
+	if g.conf.AuthToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+g.conf.AuthToken)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := g.conn.NewStream(ctx, audioProcessingStreamDesc, audioProcessingGRPCMethod)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &grpcAudioProcessingStream{
+		stream:    stream,
+		cancel:    cancel,
+		timeout:   g.conf.Timeout,
+		outgoing:  make(chan *AudioProcessingPacket, audioProcessingQueueSize),
+		responses: make(chan *AudioProcessingPacket, audioProcessingQueueSize),
+		queued:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	if err := s.send(&AudioProcessingPacket{Stream: &info}); err != nil {
+		cancel()
+		return nil, err
+	}
+	go s.sendWorker()
+	go s.receive()
+	go s.deliverWorker()
+	return s, nil
+}
+
+// grpcAudioProcessingStream pipelines the packets of a track through the service. The forwarding goroutine hands
+// them over without waiting for the service, they are forwarded in order once processed, or as received once
+// they are late.
+type grpcAudioProcessingStream struct {
+	stream    grpc.ClientStream
+	cancel    context.CancelFunc
+	timeout   time.Duration
+	outgoing  chan *AudioProcessingPacket
+	responses chan *AudioProcessingPacket
+	queued    chan struct{}
+	done      chan struct{}
+
+	lock     sync.Mutex
+	sequence uint64
+	pending  []*pendingAudioPacket
+	closed   bool
+
+	// held while forwarding, keeps packets flushed on close ahead of the ones handed over after
+	forwardLock sync.Mutex
+}
+
+type pendingAudioPacket struct {
+	sequence uint64
+	deadline time.Time
+	forward  func(payload []byte, err error)
+}
+
+// ProcessPayload waits for the packet to be processed, the receiver uses ProcessPayloadAsync
+func (s *grpcAudioProcessingStream) ProcessPayload(payload []byte) ([]byte, error) {
+	type result struct {
+		payload []byte
+		err     error
+	}
+	res := make(chan result, 1)
+	s.ProcessPayloadAsync(payload, func(payload []byte, err error) {
+		res <- result{payload, err}
+	})
+	r := <-res
+	return r.payload, r.err
+}
+
+func (s *grpcAudioProcessingStream) ProcessPayloadAsync(payload []byte, forward func(payload []byte, err error)) {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		s.forwardLock.Lock()
+		forward(nil, errAudioProcessingStreamClosed)
+		s.forwardLock.Unlock()
+		return
+	}
+	s.sequence++
+	sequence := s.sequence
+	s.pending = append(s.pending, &pendingAudioPacket{
+		sequence: sequence,
+		deadline: time.Now().Add(s.timeout),
+		forward:  forward,
+	})
+	s.lock.Unlock()
+
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+	select {
+	case s.outgoing <- &AudioProcessingPacket{Sequence: sequence, Data: payload}:
+	default:
+		// the service is not keeping up, the packet is forwarded as received once late
+	}
+}
+
+// Close forwards the packets still waiting for the service as received
+func (s *grpcAudioProcessingStream) Close() error {
+	s.forwardLock.Lock()
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		s.forwardLock.Unlock()
+		return nil
+	}
+	s.closed = true
+	pending := s.pending
+	s.pending = nil
+	s.lock.Unlock()
+
+	close(s.done)
+	for _, pkt := range pending {
+		pkt.forward(nil, errAudioProcessingStreamClosed)
+	}
+	s.forwardLock.Unlock()
+
+	s.cancel()
+	return nil
+}
+
+func (s *grpcAudioProcessingStream) sendWorker() {
+	for {
+		select {
+		case pkt := <-s.outgoing:
+			if err := s.send(pkt); err != nil {
+				logger.Debugw("could not send audio to process", "error", err)
+			}
+		case <-s.done:
+			_ = s.stream.CloseSend()
+			return
+		}
+	}
+}
+
+// deliverWorker forwards the pending packets in order, each with its response or as received once late
+func (s *grpcAudioProcessingStream) deliverWorker() {
+	responses := s.responses
+	// response of a packet after the first pending one, the service skipped that one
+	var ahead *AudioProcessingPacket
+	for {
+		pkt := s.firstPending()
+		if pkt == nil {
+			return
+		}
+
+		var data []byte
+		answered := false
+		if ahead != nil && ahead.Sequence <= pkt.sequence {
+			data, answered = ahead.Data, ahead.Sequence == pkt.sequence
+			ahead = nil
+		}
+		if !answered && ahead == nil {
+			timer := time.NewTimer(time.Until(pkt.deadline))
+		wait:
+			for {
+				select {
+				case res, ok := <-responses:
+					if !ok {
+						// stream ended, packets go out as received once late
+						responses = nil
+						continue
+					}
+					if res.Sequence < pkt.sequence {
+						// response of a packet that was late
+						continue
+					}
+					if res.Sequence == pkt.sequence {
+						data = res.Data
+					} else {
+						ahead = res
+					}
+					break wait
+				case <-timer.C:
+					break wait
+				case <-s.done:
+					break wait
+				}
+			}
+			timer.Stop()
+		}
+
+		s.forwardLock.Lock()
+		if s.popPending(pkt) {
+			if len(data) != 0 {
+				pkt.forward(data, nil)
+			} else {
+				pkt.forward(nil, errAudioProcessingTimeout)
+			}
+		}
+		s.forwardLock.Unlock()
+	}
+}
+
+// firstPending waits for a packet to be handed over, it returns nil once the stream is closed
+func (s *grpcAudioProcessingStream) firstPending() *pendingAudioPacket {
+	for {
+		s.lock.Lock()
+		closed := s.closed
+		var pkt *pendingAudioPacket
+		if len(s.pending) != 0 {
+			pkt = s.pending[0]
+		}
+		s.lock.Unlock()
+
+		if closed {
+			return nil
+		}
+		if pkt != nil {
+			return pkt
+		}
+		select {
+		case <-s.queued:
+		case <-s.done:
+			return nil
+		}
+	}
+}
+
+// popPending returns false when the packet has already been forwarded on close
+func (s *grpcAudioProcessingStream) popPending(pkt *pendingAudioPacket) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.pending) == 0 || s.pending[0] != pkt {
+		return false
+	}
+	s.pending[0] = nil
+	s.pending = s.pending[1:]
+	return true
+}
+
+func (s *grpcAudioProcessingStream) send(pkt *AudioProcessingPacket) error {
+	data, err := json.Marshal(pkt)
+	if err != nil {
+		return err
+	}
+	return s.stream.SendMsg(wrapperspb.Bytes(data))
+}
+
+func (s *grpcAudioProcessingStream) receive() {
+	defer close(s.responses)
+	for {
+		msg := &wrapperspb.BytesValue{}
+		if err := s.stream.RecvMsg(msg); err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Debugw("audio processing stream ended", "error", err)
+			}
+			return
+		}
+		pkt := &AudioProcessingPacket{}
+		if err := json.Unmarshal(msg.Value, pkt); err != nil {
+			logger.Debugw("could not parse processed audio", "error", err)
+			continue
+		}
+		select {
+		case s.responses <- pkt:
+		default:
+			// packets are late, the response would be dropped anyway
+		}
+	}
+}
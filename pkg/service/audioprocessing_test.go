@@ -0,0 +1,237 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/audioprocessing"
+)
+
+type audioProcessingRoom struct {
+	options *rtc.RoomOptions
+}
+
+func (r *audioProcessingRoom) Name() livekit.RoomName {
+	return "room"
+}
+
+func (r *audioProcessingRoom) Options() *rtc.RoomOptions {
+	return r.options
+}
+
+type audioProcessingReceiver struct {
+	sfu.TrackReceiver
+	processor sfu.PayloadProcessor
+}
+
+func (r *audioProcessingReceiver) Codec() webrtc.RTPCodecParameters {
+	return webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}}
+}
+
+func (r *audioProcessingReceiver) SetPayloadProcessor(p sfu.PayloadProcessor) {
+	r.processor = p
+}
+
+// the codec "encodes" samples as bytes
+type byteCodec struct{}
+
+func (byteCodec) NewDecoder(_ int, _ int) (audioprocessing.Decoder, error) { return byteCodec{}, nil }
+func (byteCodec) NewEncoder(_ int, _ int) (audioprocessing.Encoder, error) { return byteCodec{}, nil }
+
+func (byteCodec) Decode(payload []byte, pcm []int16) (int, error) {
+	for i, b := range payload {
+		pcm[i] = int16(b)
+	}
+	return len(payload), nil
+}
+
+func (byteCodec) Encode(pcm []int16, payload []byte) (int, error) {
+	for i, sample := range pcm {
+		payload[i] = byte(sample)
+	}
+	return len(pcm), nil
+}
+
+type gainProcessor struct {
+	infos  []audioprocessing.StreamInfo
+	closed int
+}
+
+func (p *gainProcessor) NewStream(info audioprocessing.StreamInfo) (audioprocessing.Stream, error) {
+	p.infos = append(p.infos, info)
+	return p, nil
+}
+
+func (p *gainProcessor) Process(pcm []int16) ([]int16, error) {
+	for i := range pcm {
+		pcm[i] *= 2
+	}
+	return pcm, nil
+}
+
+func (p *gainProcessor) Close() error {
+	p.closed++
+	return nil
+}
+
+func newAudioProcessingTrack(receiver sfu.TrackReceiver) (*typesfakes.FakeLocalParticipant, *typesfakes.FakeMediaTrack) {
+	participant := &typesfakes.FakeLocalParticipant{}
+	participant.IdentityReturns("speaker")
+	participant.GetLoggerReturns(logger.GetLogger())
+
+	track := &typesfakes.FakeMediaTrack{}
+	track.IDReturns("TR_audio")
+	track.KindReturns(livekit.TrackType_AUDIO)
+	track.SourceReturns(livekit.TrackSource_MICROPHONE)
+	track.ReceiversReturns([]sfu.TrackReceiver{receiver})
+	return participant, track
+}
+
+func TestAudioProcessing(t *testing.T) {
+	processor := &gainProcessor{}
+	a := service.NewAudioProcessingWithProcessor(byteCodec{}, processor)
+	defer a.Close()
+
+	room := &audioProcessingRoom{options: &rtc.RoomOptions{
+		AudioProcessing: &audioprocessing.Features{NoiseSuppression: true},
+	}}
+	receiver := &audioProcessingReceiver{}
+	participant, track := newAudioProcessingTrack(receiver)
+
+	a.TrackPublished(room, participant, track)
+	require.NotNil(t, receiver.processor)
+	require.Len(t, processor.infos, 1)
+	require.Equal(t, "speaker", processor.infos[0].ParticipantIdentity)
+	require.True(t, processor.infos[0].Features.NoiseSuppression)
+	require.Equal(t, 1, processor.infos[0].Channels)
+
+	payload, err := receiver.processor.ProcessPayload([]byte{1, 2, 3})
+	require.NoError(t, err)
+	require.Equal(t, []byte{2, 4, 6}, payload)
+
+	// the processing stops with the track
+	require.Equal(t, 1, track.AddOnCloseCallCount())
+	track.AddOnCloseArgsForCall(0)()
+	require.Equal(t, 1, processor.closed)
+
+	t.Run("rooms without audio processing", func(t *testing.T) {
+		receiver := &audioProcessingReceiver{}
+		participant, track := newAudioProcessingTrack(receiver)
+		a.TrackPublished(&audioProcessingRoom{}, participant, track)
+		require.Nil(t, receiver.processor)
+	})
+
+	t.Run("other tracks", func(t *testing.T) {
+		receiver := &audioProcessingReceiver{}
+		participant, track := newAudioProcessingTrack(receiver)
+		track.SourceReturns(livekit.TrackSource_SCREEN_SHARE_AUDIO)
+		a.TrackPublished(room, participant, track)
+		require.Nil(t, receiver.processor)
+	})
+}
+
+func TestAudioProcessingGRPC(t *testing.T) {
+	infos := make(chan *audioprocessing.StreamInfo, 1)
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		for {
+			msg := &wrapperspb.BytesValue{}
+			if err := stream.RecvMsg(msg); err != nil {
+				return nil
+			}
+			pkt := &service.AudioProcessingPacket{}
+			if err := json.Unmarshal(msg.Value, pkt); err != nil {
+				return err
+			}
+			if pkt.Stream != nil {
+				infos <- pkt.Stream
+				continue
+			}
+			if string(pkt.Data) == "skip" {
+				continue
+			}
+			pkt.Data = bytes.ToUpper(pkt.Data)
+			data, _ := json.Marshal(pkt)
+			if err := stream.SendMsg(wrapperspb.Bytes(data)); err != nil {
+				return err
+			}
+		}
+	}))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	a, err := service.NewAudioProcessing(config.AudioProcessingConfig{
+		Enabled:      true,
+		GRPCAddress:  listener.Addr().String(),
+		GRPCInsecure: true,
+		Timeout:      5 * time.Second,
+	})
+	require.NoError(t, err)
+	defer a.Close()
+
+	room := &audioProcessingRoom{options: &rtc.RoomOptions{
+		AudioProcessing: &audioprocessing.Features{GainNormalization: true},
+	}}
+	receiver := &audioProcessingReceiver{}
+	participant, track := newAudioProcessingTrack(receiver)
+	a.TrackPublished(room, participant, track)
+	require.NotNil(t, receiver.processor)
+
+	payload, err := receiver.processor.ProcessPayload([]byte("opus"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("OPUS"), payload)
+
+	// packets are forwarded in order without waiting for the service, the ones it does not answer as received
+	async, ok := receiver.processor.(sfu.AsyncPayloadProcessor)
+	require.True(t, ok)
+	forwarded := make(chan string, 3)
+	for _, data := range []string{"a", "skip", "b"} {
+		data := data
+		async.ProcessPayloadAsync([]byte(data), func(payload []byte, err error) {
+			if err != nil {
+				payload = []byte(data)
+			}
+			forwarded <- string(payload)
+		})
+	}
+	require.Equal(t, "A", <-forwarded)
+	require.Equal(t, "skip", <-forwarded)
+	require.Equal(t, "B", <-forwarded)
+
+	info := <-infos
+	require.Equal(t, "TR_audio", info.TrackID)
+	require.True(t, info.Features.GainNormalization)
+}
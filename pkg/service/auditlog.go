@@ -0,0 +1,351 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// AuditRecord is an administrative call of the room or egress APIs, made with an API key
+type AuditRecord struct {
+	ID     string    `json:"id"`
+	Time   time.Time `json:"time"`
+	APIKey string    `json:"api_key"`
+	Method string    `json:"method"`
+	// Room is empty for calls that are not about a room, such as draining a node
+	Room string `json:"room,omitempty"`
+	// Arguments are the request as it would be sent with the JSON encoding, with credentials redacted
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	// Error is empty when the call succeeded
+	Error string `json:"error,omitempty"`
+}
+
+type ListAuditRecordsRequest struct {
+	Room string `json:"room"`
+}
+
+type ListAuditRecordsResponse struct {
+	Records []*AuditRecord `json:"records"`
+}
+
+// fields of request arguments that are not recorded, e.g. the secrets of egress uploads. Names are matched
+// exactly, so that fields such as key_frame_interval are kept.
+var auditRedactedFields = map[string]bool{
+	"secret":        true,
+	"password":      true,
+	"passcode":      true,
+	"passcode_hash": true,
+	"token":         true,
+	"auth_token":    true,
+	"credentials":   true,
+	"access_key":    true,
+	"account_key":   true,
+}
+
+type auditSink interface {
+	Write(record *AuditRecord) error
+	Close() error
+}
+
+// AuditLog records the administrative mutations of the room and egress APIs to the configured sink, and to the
+// store so that the records of a room can be listed. Records are written from a single goroutine, calls are
+// never held up by a slow sink.
+type AuditLog struct {
+	conf   config.AuditLogConfig
+	sink   auditSink
+	store  AuditStore
+	queue  chan *AuditRecord
+	closed core.Fuse
+	done   chan struct{}
+}
+
+// NewAuditLog returns nil when the audit log is disabled. Records are not kept for listing when store is nil.
+func NewAuditLog(conf *config.Config, store AuditStore) (*AuditLog, error) {
+	if !conf.AuditLog.Enabled {
+		return nil, nil
+	}
+
+	sink, err := newAuditSink(conf.AuditLog)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &AuditLog{
+		conf:   conf.AuditLog,
+		sink:   sink,
+		store:  store,
+		queue:  make(chan *AuditRecord, conf.AuditLog.QueueSize),
+		closed: core.NewFuse(),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return a, nil
+}
+
+func newAuditSink(conf config.AuditLogConfig) (auditSink, error) {
+	switch conf.Sink {
+	case config.AuditLogSinkFile:
+		file, err := os.OpenFile(conf.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("could not open audit log: %w", err)
+		}
+		return &auditFileSink{file: file}, nil
+	case config.AuditLogSinkSyslog:
+		writer, err := syslog.Dial(conf.SyslogNetwork, conf.SyslogAddress, syslog.LOG_NOTICE|syslog.LOG_AUTH, "livekit-server")
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to syslog: %w", err)
+		}
+		return &auditSyslogSink{writer: writer}, nil
+	default:
+		return &auditHTTPSink{
+			url:       conf.URL,
+			authToken: conf.AuthToken,
+			client:    &http.Client{Timeout: conf.Timeout},
+		}, nil
+	}
+}
+
+// Stop writes the records queued so far and closes the sink
+func (a *AuditLog) Stop() {
+	if a == nil {
+		return
+	}
+	a.closed.Break()
+	<-a.done
+}
+
+// Record queues a call made with the API key of ctx. Each of args is a request, or part of one, they are merged
+// into the arguments of the record. It does nothing when the audit log is disabled.
+func (a *AuditLog) Record(ctx context.Context, method string, roomName livekit.RoomName, err error, args ...any) {
+	if a == nil || a.closed.IsBroken() {
+		return
+	}
+
+	record := &AuditRecord{
+		ID:     utils.NewGuid("AU_"),
+		Time:   time.Now(),
+		APIKey: GetAPIKey(ctx),
+		Method: method,
+		Room:   string(roomName),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	arguments, encodeErr := auditArguments(args...)
+	if encodeErr != nil {
+		logger.Warnw("could not encode audit record arguments", encodeErr, "method", method)
+	}
+	record.Arguments = arguments
+
+	select {
+	case a.queue <- record:
+	default:
+		logger.Warnw("audit log queue is full, dropping record", nil, "method", method, "room", roomName, "apiKey", record.APIKey)
+	}
+}
+
+// List returns the records of a room, oldest first
+func (a *AuditLog) List(ctx context.Context, roomName livekit.RoomName) ([]*AuditRecord, error) {
+	if a == nil || a.store == nil {
+		return nil, ErrAuditLogNotEnabled
+	}
+	return a.store.ListAuditRecords(ctx, roomName)
+}
+
+func (a *AuditLog) run() {
+	defer close(a.done)
+	defer func() {
+		if err := a.sink.Close(); err != nil {
+			logger.Warnw("could not close audit log", err)
+		}
+	}()
+
+	for {
+		select {
+		case record := <-a.queue:
+			a.write(record)
+		case <-a.closed.Watch():
+			for {
+				select {
+				case record := <-a.queue:
+					a.write(record)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *AuditLog) write(record *AuditRecord) {
+	if err := a.sink.Write(record); err != nil {
+		logger.Warnw("could not write audit record", err, "method", record.Method, "room", record.Room, "apiKey", record.APIKey)
+	}
+	if a.store != nil && record.Room != "" {
+		if err := a.store.StoreAuditRecord(context.Background(), record, a.conf.MaxRecordsPerRoom, a.conf.Retention); err != nil {
+			logger.Warnw("could not store audit record", err, "method", record.Method, "room", record.Room)
+		}
+	}
+}
+
+// auditArguments merges the JSON encoding of args into a single object, protocol messages with their proto
+// field names. Fields that may hold credentials are redacted.
+func auditArguments(args ...any) (json.RawMessage, error) {
+	merged := make(map[string]any)
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+
+		var data []byte
+		var err error
+		if msg, ok := arg.(proto.Message); ok {
+			data, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+		} else {
+			data, err = json.Marshal(arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var fields map[string]any
+		if err = json.Unmarshal(data, &fields); err != nil {
+			// nil pointers are encoded as null
+			continue
+		}
+		for name, value := range fields {
+			merged[name] = value
+		}
+	}
+	if len(merged) == 0 {
+		return nil, nil
+	}
+
+	redactAuditFields(merged)
+	return json.Marshal(merged)
+}
+
+func redactAuditFields(value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for name, field := range v {
+			if isRedactedAuditField(name) {
+				if _, ok := field.(string); ok {
+					v[name] = "redacted"
+					continue
+				}
+			}
+			redactAuditFields(field)
+		}
+	case []any:
+		for _, item := range v {
+			redactAuditFields(item)
+		}
+	}
+}
+
+func isRedactedAuditField(name string) bool {
+	return auditRedactedFields[strings.ToLower(name)]
+}
+
+// auditFileSink appends records as JSON lines
+type auditFileSink struct {
+	file *os.File
+}
+
+func (s *auditFileSink) Write(record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+func (s *auditFileSink) Close() error {
+	return s.file.Close()
+}
+
+type auditSyslogSink struct {
+	writer *syslog.Writer
+}
+
+func (s *auditSyslogSink) Write(record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.writer.Notice(string(data))
+}
+
+func (s *auditSyslogSink) Close() error {
+	return s.writer.Close()
+}
+
+// auditHTTPSink posts each record as JSON
+type auditHTTPSink struct {
+	url       string
+	authToken string
+	client    *http.Client
+}
+
+func (s *auditHTTPSink) Write(record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.authToken)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("audit log endpoint returned %s", res.Status)
+	}
+	return nil
+}
+
+func (s *auditHTTPSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/protocol/rpc/rpcfakes"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+func TestAuditLog(t *testing.T) {
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	conf.AuditLog.Enabled = true
+	conf.AuditLog.Sink = config.AuditLogSinkFile
+	conf.AuditLog.Path = filepath.Join(t.TempDir(), "audit.log")
+	conf.AuditLog.MaxRecordsPerRoom = 2
+
+	store := service.NewLocalStore()
+	require.NoError(t, store.StoreRoom(context.Background(), &livekit.Room{Name: "myroom", Sid: "RM_myroom"}, nil))
+	auditLog, err := service.NewAuditLog(conf, store)
+	require.NoError(t, err)
+
+	roomService, err := service.NewRoomService(
+		conf.Room,
+		config.APIConfig{},
+		rpc.PSRPCConfig{},
+		nil,
+		&servicefakes.FakeRoomAllocator{},
+		store,
+		nil,
+		nil,
+		rpc.NewTopicFormatter(),
+		&rpcfakes.FakeTypedRoomClient{},
+		&rpcfakes.FakeTypedParticipantClient{},
+		&servicefakes.FakeParticipantExtClient{},
+		&servicefakes.FakeRoomExtClient{},
+		&servicefakes.FakeNodeExtClient{},
+		nil,
+		nil,
+		auditLog,
+		nil,
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+	egressService := service.NewEgressService(nil, &testEgressLauncher{}, store, &servicefakes.FakeIOClient{}, roomService, nil, nil, rpc.NewTopicFormatter(), auditLog, nil)
+
+	adminCtx := service.WithAPIKey(service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomAdmin: true, Room: "myroom", RoomList: true, RoomRecord: true},
+	}), "APIadmin")
+	userCtx := service.WithAPIKey(service.WithGrants(context.Background(), &auth.ClaimGrants{
+		Video: &auth.VideoGrant{RoomJoin: true, Room: "myroom"},
+	}), "APIuser")
+
+	_, err = roomService.MutePublishedTrack(userCtx, &livekit.MuteRoomTrackRequest{Room: "myroom", Identity: "alice", TrackSid: "TR_1", Muted: true})
+	require.Error(t, err)
+	_, err = roomService.RemoveParticipant(adminCtx, &livekit.RoomParticipantIdentity{Room: "myroom", Identity: "alice"})
+	require.Error(t, err)
+	_, err = egressService.StartRoomCompositeEgress(adminCtx, &livekit.RoomCompositeEgressRequest{
+		RoomName: "myroom",
+		FileOutputs: []*livekit.EncodedFileOutput{{
+			Filepath: "myroom/{time}",
+			Output: &livekit.EncodedFileOutput_S3{S3: &livekit.S3Upload{
+				AccessKey: "access",
+				Secret:    "secret",
+				Bucket:    "recordings",
+				Metadata:  map[string]string{"keywords": "standup"},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	_, err = roomService.SendData(adminCtx, &livekit.SendDataRequest{Room: "myroom", Data: []byte("hello"), Topic: proto.String("chat")})
+	require.NoError(t, err)
+	auditLog.Stop()
+
+	t.Run("records are written to the sink", func(t *testing.T) {
+		file, err := os.Open(conf.AuditLog.Path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		var records []*service.AuditRecord
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			record := &service.AuditRecord{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), record))
+			records = append(records, record)
+		}
+		require.Len(t, records, 4)
+
+		require.Equal(t, "MutePublishedTrack", records[0].Method)
+		require.Equal(t, "APIuser", records[0].APIKey)
+		require.Equal(t, "myroom", records[0].Room)
+		require.NotEmpty(t, records[0].Error)
+		require.JSONEq(t, `{"room":"myroom","identity":"alice","track_sid":"TR_1","muted":true}`, string(records[0].Arguments))
+
+		require.Equal(t, "RemoveParticipant", records[1].Method)
+		require.Equal(t, "APIadmin", records[1].APIKey)
+
+		require.Equal(t, "StartEgress", records[2].Method)
+		require.Empty(t, records[2].Error)
+		require.Contains(t, string(records[2].Arguments), `"bucket":"recordings"`)
+		require.Contains(t, string(records[2].Arguments), `"secret":"redacted"`)
+		require.NotContains(t, string(records[2].Arguments), `"access"`)
+		// only credential fields are redacted
+		require.Contains(t, string(records[2].Arguments), `"keywords":"standup"`)
+
+		require.Equal(t, "SendData", records[3].Method)
+		require.Empty(t, records[3].Error)
+		require.JSONEq(t, `{"room":"myroom","topic":"chat","size":5}`, string(records[3].Arguments))
+	})
+
+	t.Run("records of a room are listed", func(t *testing.T) {
+		res, err := roomService.ListAuditRecords(adminCtx, &service.ListAuditRecordsRequest{Room: "myroom"})
+		require.NoError(t, err)
+		// the latest max records per room are kept
+		require.Len(t, res.Records, 2)
+		require.Equal(t, "StartEgress", res.Records[0].Method)
+		require.Equal(t, "SendData", res.Records[1].Method)
+
+		_, err = roomService.ListAuditRecords(userCtx, &service.ListAuditRecordsRequest{Room: "myroom"})
+		require.Error(t, err)
+	})
+}
@@ -19,11 +19,17 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/twitchtv/twirp"
 
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
 )
 
 const (
@@ -34,6 +40,26 @@ const (
 
 type grantsKey struct{}
 
+type apiKeyKey struct{}
+
+type subscribeFilterKey struct{}
+
+type resumeGrantKey struct{}
+
+// tokenClaims are the claims of tokens beyond auth.ClaimGrants
+type tokenClaims struct {
+	SubscribeFilter *routing.SubscribeFilter `json:"subscribeFilter,omitempty"`
+	Resume          *ResumeGrant             `json:"resume,omitempty"`
+}
+
+// ResumeGrant is carried by the resume token a redirected participant is sent. The token can only resume the
+// session of the participant, on the node it names.
+type ResumeGrant struct {
+	Room          string `json:"room"`
+	ParticipantID string `json:"sid"`
+	NodeID        string `json:"nodeId"`
+}
+
 var (
 	ErrPermissionDenied          = errors.New("permissions denied")
 	ErrMissingAuthorization      = errors.New("invalid authorization header. Must start with " + bearerPrefix)
@@ -41,14 +67,38 @@ var (
 	ErrInvalidAPIKey             = errors.New("invalid API key")
 )
 
+// ReloadableKeyProvider serves the API keys of the running config. Keys added or revoked by a config reload
+// are accepted or rejected from the next request, sessions already connected are kept.
+type ReloadableKeyProvider struct {
+	conf *config.Config
+}
+
+func NewReloadableKeyProvider(conf *config.Config) *ReloadableKeyProvider {
+	return &ReloadableKeyProvider{
+		conf: conf,
+	}
+}
+
+func (p *ReloadableKeyProvider) GetSecret(key string) string {
+	return p.conf.Reloadable().Keys[key]
+}
+
+func (p *ReloadableKeyProvider) NumKeys() int {
+	return len(p.conf.Reloadable().Keys)
+}
+
 // authentication middleware
 type APIKeyAuthMiddleware struct {
 	provider auth.KeyProvider
+	oidc     *OIDCVerifier
 }
 
-func NewAPIKeyAuthMiddleware(provider auth.KeyProvider) *APIKeyAuthMiddleware {
+// NewAPIKeyAuthMiddleware verifies tokens signed with API keys of provider, and tokens issued by the identity
+// providers of oidc when it is not nil
+func NewAPIKeyAuthMiddleware(provider auth.KeyProvider, oidc *OIDCVerifier) *APIKeyAuthMiddleware {
 	return &APIKeyAuthMiddleware{
 		provider: provider,
+		oidc:     oidc,
 	}
 }
 
@@ -73,32 +123,58 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 	}
 
 	if authToken != "" {
-		v, err := auth.ParseAPIToken(authToken)
+		grants, apiKey, err := verifyToken(r.Context(), m.provider, m.oidc, authToken)
 		if err != nil {
-			handleError(w, r, http.StatusUnauthorized, ErrInvalidAuthorizationToken)
+			handleError(w, r, http.StatusUnauthorized, err)
 			return
 		}
 
-		secret := m.provider.GetSecret(v.APIKey())
-		if secret == "" {
-			handleError(w, r, http.StatusUnauthorized, errors.New("invalid API key: "+v.APIKey()))
-			return
-		}
-
-		grants, err := v.Verify(secret)
+		claims, err := parseTokenClaims(authToken)
 		if err != nil {
-			handleError(w, r, http.StatusUnauthorized, errors.New("invalid token: "+authToken+", error: "+err.Error()))
+			handleError(w, r, http.StatusUnauthorized, err)
 			return
 		}
 
 		// set grants in context
 		ctx := r.Context()
-		r = r.WithContext(context.WithValue(ctx, grantsKey{}, grants))
+		ctx = context.WithValue(ctx, grantsKey{}, grants)
+		ctx = WithSubscribeFilter(ctx, claims.SubscribeFilter)
+		ctx = WithResumeGrant(ctx, claims.Resume)
+		r = r.WithContext(WithAPIKey(ctx, apiKey))
 	}
 
 	next.ServeHTTP(w, r)
 }
 
+// verifyToken returns the grants of a token signed with an API key of provider or issued by oidc, and the API key
+func verifyToken(ctx context.Context, provider auth.KeyProvider, oidc *OIDCVerifier, authToken string) (*auth.ClaimGrants, string, error) {
+	v, err := auth.ParseAPIToken(authToken)
+	if err != nil {
+		return nil, "", ErrInvalidAuthorizationToken
+	}
+
+	apiKey := v.APIKey()
+	if oidc.HasIssuer(apiKey) {
+		// the issuer of a token signed with an API key is the key
+		grants, apiKey, err := oidc.Verify(ctx, authToken)
+		if err != nil {
+			return nil, "", errors.New("invalid identity provider token, error: " + err.Error())
+		}
+		return grants, apiKey, nil
+	}
+
+	secret := provider.GetSecret(apiKey)
+	if secret == "" {
+		return nil, "", errors.New("invalid API key: " + apiKey)
+	}
+
+	grants, err := v.Verify(secret)
+	if err != nil {
+		return nil, "", errors.New("invalid token: " + authToken + ", error: " + err.Error())
+	}
+	return grants, apiKey, nil
+}
+
 func GetGrants(ctx context.Context) *auth.ClaimGrants {
 	val := ctx.Value(grantsKey{})
 	claims, ok := val.(*auth.ClaimGrants)
@@ -112,6 +188,76 @@ func WithGrants(ctx context.Context, grants *auth.ClaimGrants) context.Context {
 	return context.WithValue(ctx, grantsKey{}, grants)
 }
 
+// GetAPIKey returns the API key the request token was signed with
+func GetAPIKey(ctx context.Context) string {
+	apiKey, _ := ctx.Value(apiKeyKey{}).(string)
+	return apiKey
+}
+
+func WithAPIKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, apiKey)
+}
+
+// parseTokenClaims returns the claims beyond auth.ClaimGrants of a token that has been verified
+func parseTokenClaims(authToken string) (*tokenClaims, error) {
+	tok, err := jwt.ParseSigned(authToken)
+	if err != nil {
+		return nil, ErrInvalidAuthorizationToken
+	}
+	claims := &tokenClaims{}
+	if err := tok.UnsafeClaimsWithoutVerification(claims); err != nil {
+		return nil, ErrInvalidAuthorizationToken
+	}
+	if err := claims.SubscribeFilter.Validate(); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// signToken signs a token as auth.AccessToken does, with the claims it has no support for added
+func signToken(
+	apiKey, secret string,
+	grants *auth.ClaimGrants,
+	validFor time.Duration,
+	claims tokenClaims,
+) (string, error) {
+	sig, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	cl := jwt.Claims{
+		Issuer:    apiKey,
+		NotBefore: jwt.NewNumericDate(time.Now()),
+		Expiry:    jwt.NewNumericDate(time.Now().Add(validFor)),
+		Subject:   grants.Identity,
+	}
+	return jwt.Signed(sig).Claims(cl).Claims(grants).Claims(claims).CompactSerialize()
+}
+
+// GetSubscribeFilter returns the subscribe filter of the request token, nil when it has none
+func GetSubscribeFilter(ctx context.Context) *routing.SubscribeFilter {
+	filter, _ := ctx.Value(subscribeFilterKey{}).(*routing.SubscribeFilter)
+	return filter
+}
+
+func WithSubscribeFilter(ctx context.Context, filter *routing.SubscribeFilter) context.Context {
+	return context.WithValue(ctx, subscribeFilterKey{}, filter)
+}
+
+// GetResumeGrant returns the resume grant of the request token, nil when it is not a resume token
+func GetResumeGrant(ctx context.Context) *ResumeGrant {
+	grant, _ := ctx.Value(resumeGrantKey{}).(*ResumeGrant)
+	return grant
+}
+
+func WithResumeGrant(ctx context.Context, grant *ResumeGrant) context.Context {
+	return context.WithValue(ctx, resumeGrantKey{}, grant)
+}
+
 func SetAuthorizationToken(r *http.Request, token string) {
 	r.Header.Set(authorizationHeader, bearerPrefix+token)
 }
@@ -176,6 +322,34 @@ func EnsureIngressAdminPermission(ctx context.Context) error {
 	return nil
 }
 
+// EnsureNodeAdminPermission allows tokens that can administer and list every room, for operations on the node
+func EnsureNodeAdminPermission(ctx context.Context) error {
+	claims := GetGrants(ctx)
+	if claims == nil || claims.Video == nil || !claims.Video.RoomAdmin || !claims.Video.RoomList || claims.Video.Room != "" {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// ensureAttachmentPermission allows room admins, and participants of the room. Participants need to be able to
+// publish data to share attachments when publish is set
+func ensureAttachmentPermission(ctx context.Context, room livekit.RoomName, publish bool) (identity livekit.ParticipantIdentity, admin bool, err error) {
+	claims := GetGrants(ctx)
+	if claims == nil || claims.Video == nil || room != livekit.RoomName(claims.Video.Room) {
+		err = ErrPermissionDenied
+		return
+	}
+
+	identity = livekit.ParticipantIdentity(claims.Identity)
+	switch {
+	case claims.Video.RoomAdmin:
+		admin = true
+	case !claims.Video.RoomJoin || (publish && !claims.Video.GetCanPublishData()):
+		err = ErrPermissionDenied
+	}
+	return
+}
+
 // wraps authentication errors around Twirp
 func twirpAuthError(err error) error {
 	return twirp.NewError(twirp.Unauthenticated, err.Error())
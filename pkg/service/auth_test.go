@@ -18,12 +18,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/auth/authfakes"
 
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/service"
 )
 
@@ -33,7 +38,7 @@ func TestAuthMiddleware(t *testing.T) {
 	provider := &authfakes.FakeKeyProvider{}
 	provider.GetSecretReturns(secret)
 
-	m := service.NewAPIKeyAuthMiddleware(provider)
+	m := service.NewAPIKeyAuthMiddleware(provider, nil)
 	var grants *auth.ClaimGrants
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		grants = service.GetGrants(r.Context())
@@ -72,3 +77,124 @@ func TestAuthMiddleware(t *testing.T) {
 	require.Nil(t, grants)
 	require.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAuthMiddlewareSubscribeFilter(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	m := service.NewAPIKeyAuthMiddleware(provider, nil)
+	var filter *routing.SubscribeFilter
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter = service.GetSubscribeFilter(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(claim map[string]any) int {
+		sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)}, (&jose.SignerOptions{}).WithType("JWT"))
+		require.NoError(t, err)
+		token, err := jwt.Signed(sig).
+			Claims(jwt.Claims{Issuer: api, Subject: "viewer", Expiry: jwt.NewNumericDate(time.Now().Add(time.Minute))}).
+			Claims(&auth.ClaimGrants{Video: &auth.VideoGrant{Room: "webinar", RoomJoin: true}}).
+			Claims(claim).
+			CompactSerialize()
+		require.NoError(t, err)
+
+		filter = nil
+		r := &http.Request{Header: http.Header{}}
+		w := httptest.NewRecorder()
+		service.SetAuthorizationToken(r, token)
+		m.ServeHTTP(w, r, handler)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve(map[string]any{}))
+	require.Nil(t, filter)
+
+	require.Equal(t, http.StatusOK, serve(map[string]any{
+		"subscribeFilter": map[string]any{"identities": []string{"host-*"}, "sources": []string{"camera"}},
+	}))
+	require.Equal(t, &routing.SubscribeFilter{Identities: []string{"host-*"}, Sources: []string{"camera"}}, filter)
+
+	// a filter that cannot be enforced rejects the token
+	require.Equal(t, http.StatusUnauthorized, serve(map[string]any{
+		"subscribeFilter": map[string]any{"sources": []string{"webcam"}},
+	}))
+	require.Nil(t, filter)
+}
+
+func TestAuthMiddlewareResumeGrant(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	m := service.NewAPIKeyAuthMiddleware(provider, nil)
+	var grant *service.ResumeGrant
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		grant = service.GetResumeGrant(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(claim map[string]any) int {
+		sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)}, (&jose.SignerOptions{}).WithType("JWT"))
+		require.NoError(t, err)
+		token, err := jwt.Signed(sig).
+			Claims(jwt.Claims{Issuer: api, Subject: "alice", Expiry: jwt.NewNumericDate(time.Now().Add(time.Minute))}).
+			Claims(&auth.ClaimGrants{Video: &auth.VideoGrant{Room: "room", RoomJoin: true}}).
+			Claims(claim).
+			CompactSerialize()
+		require.NoError(t, err)
+
+		grant = nil
+		r := &http.Request{Header: http.Header{}}
+		w := httptest.NewRecorder()
+		service.SetAuthorizationToken(r, token)
+		m.ServeHTTP(w, r, handler)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve(map[string]any{}))
+	require.Nil(t, grant)
+
+	require.Equal(t, http.StatusOK, serve(map[string]any{
+		"resume": map[string]any{"room": "room", "sid": "PA_alice", "nodeId": "ND_target"},
+	}))
+	require.Equal(t, &service.ResumeGrant{Room: "room", ParticipantID: "PA_alice", NodeID: "ND_target"}, grant)
+}
+
+func TestReloadableKeyProvider(t *testing.T) {
+	conf, err := config.NewConfig(`keys:
+  key1: secret1secret1secret1secret1secret1`, true, nil, nil)
+	require.NoError(t, err)
+
+	m := service.NewAPIKeyAuthMiddleware(service.NewReloadableKeyProvider(conf), nil)
+	var apiKey string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = service.GetAPIKey(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(key, secret string) int {
+		token, err := auth.NewAccessToken(key, secret).AddGrant(&auth.VideoGrant{RoomList: true}).ToJWT()
+		require.NoError(t, err)
+		r := &http.Request{Header: http.Header{}}
+		w := httptest.NewRecorder()
+		service.SetAuthorizationToken(r, token)
+		m.ServeHTTP(w, r, handler)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve("key1", "secret1secret1secret1secret1secret1"))
+	require.Equal(t, "key1", apiKey)
+	require.Equal(t, http.StatusUnauthorized, serve("key2", "secret2secret2secret2secret2secret2"))
+
+	// key1 is revoked and key2 added without a restart
+	next, err := config.NewConfig(`keys:
+  key2: secret2secret2secret2secret2secret2`, true, nil, nil)
+	require.NoError(t, err)
+	_, _, err = conf.Reload(next)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusUnauthorized, serve("key1", "secret1secret1secret1secret1secret1"))
+	require.Equal(t, http.StatusOK, serve("key2", "secret2secret2secret2secret2secret2"))
+	require.Equal(t, "key2", apiKey)
+}
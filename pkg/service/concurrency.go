@@ -0,0 +1,173 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/routing/selector"
+)
+
+// bitrates of a node are averaged over at least this window, readings closer together return the previous bitrates
+const minBitrateWindow = time.Second
+
+// ConcurrencyTotals counts what is currently served by a node, or by the whole cluster
+type ConcurrencyTotals struct {
+	Rooms           int `json:"rooms"`
+	Participants    int `json:"participants"`
+	PublishedTracks int `json:"published_tracks"`
+	DownTracks      int `json:"down_tracks"`
+	// media received from and sent to participants, in bits per second
+	IngressBitrate float64 `json:"ingress_bitrate"`
+	EgressBitrate  float64 `json:"egress_bitrate"`
+}
+
+func (t *ConcurrencyTotals) add(other *ConcurrencyTotals) {
+	t.Rooms += other.Rooms
+	t.Participants += other.Participants
+	t.PublishedTracks += other.PublishedTracks
+	t.DownTracks += other.DownTracks
+	t.IngressBitrate += other.IngressBitrate
+	t.EgressBitrate += other.EgressBitrate
+}
+
+type NodeConcurrency struct {
+	NodeID livekit.NodeID `json:"node_id"`
+	ConcurrencyTotals
+}
+
+// ConcurrencySnapshot is the sum of the totals of every node that answered
+type ConcurrencySnapshot struct {
+	ConcurrencyTotals
+	Nodes []*NodeConcurrency `json:"nodes"`
+	// registered nodes that did not answer in time, what they serve is missing from the totals
+	MissingNodes []livekit.NodeID `json:"missing_nodes,omitempty"`
+	CollectedAt  time.Time        `json:"collected_at"`
+}
+
+// bitrateMeter turns the byte counters of the node into bitrates, averaged since the previous reading
+type bitrateMeter struct {
+	lock     sync.Mutex
+	at       time.Time
+	bytesIn  uint64
+	bytesOut uint64
+	ingress  float64
+	egress   float64
+}
+
+func newBitrateMeter(at time.Time, bytesIn uint64, bytesOut uint64) *bitrateMeter {
+	return &bitrateMeter{
+		at:       at,
+		bytesIn:  bytesIn,
+		bytesOut: bytesOut,
+	}
+}
+
+func (m *bitrateMeter) read(at time.Time, bytesIn uint64, bytesOut uint64) (ingress float64, egress float64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	elapsed := at.Sub(m.at)
+	if elapsed < minBitrateWindow {
+		return m.ingress, m.egress
+	}
+	m.ingress = float64(bytesIn-m.bytesIn) * 8 / elapsed.Seconds()
+	m.egress = float64(bytesOut-m.bytesOut) * 8 / elapsed.Seconds()
+	m.at, m.bytesIn, m.bytesOut = at, bytesIn, bytesOut
+	return m.ingress, m.egress
+}
+
+// concurrencyCache shares a snapshot between the callers of a ttl, callers that arrive while it is collected wait for it
+type concurrencyCache struct {
+	lock     sync.Mutex
+	snapshot *ConcurrencySnapshot
+}
+
+func (c *concurrencyCache) get(
+	ctx context.Context,
+	ttl time.Duration,
+	collect func(ctx context.Context) (*ConcurrencySnapshot, error),
+) (*ConcurrencySnapshot, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.snapshot != nil && time.Since(c.snapshot.CollectedAt) < ttl {
+		return c.snapshot, nil
+	}
+	snapshot, err := collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.snapshot = snapshot
+	return snapshot, nil
+}
+
+// collectConcurrency asks every node for its totals, it returns once the nodes hosting rooms have all answered,
+// or when the request times out
+func collectConcurrency(
+	ctx context.Context,
+	nodeExtClient NodeExtClient,
+	nodes []*livekit.Node,
+	timeout time.Duration,
+) (*ConcurrencySnapshot, error) {
+	pending := make(map[livekit.NodeID]bool)
+	for _, node := range nodes {
+		if node.Type == livekit.NodeType_SERVER && selector.IsAvailable(node) {
+			pending[livekit.NodeID(node.Id)] = true
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resChan, err := nodeExtClient.GetNodeConcurrency(ctx, &GetNodeConcurrencyRequest{}, psrpc.WithRequestTimeout(timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &ConcurrencySnapshot{
+		Nodes: make([]*NodeConcurrency, 0, len(pending)),
+	}
+	for res := range resChan {
+		if res.Err != nil {
+			logger.Warnw("could not get node concurrency", res.Err)
+			continue
+		}
+		snapshot.add(&res.Result.ConcurrencyTotals)
+		snapshot.Nodes = append(snapshot.Nodes, res.Result)
+		delete(pending, res.Result.NodeID)
+		if len(pending) == 0 {
+			break
+		}
+	}
+	for nodeID := range pending {
+		snapshot.MissingNodes = append(snapshot.MissingNodes, nodeID)
+	}
+
+	sort.Slice(snapshot.Nodes, func(i, j int) bool {
+		return snapshot.Nodes[i].NodeID < snapshot.Nodes[j].NodeID
+	})
+	sort.Slice(snapshot.MissingNodes, func(i, j int) bool {
+		return snapshot.MissingNodes[i] < snapshot.MissingNodes[j]
+	})
+	snapshot.CollectedAt = time.Now()
+	return snapshot, nil
+}
@@ -0,0 +1,250 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const drainCheckInterval = 500 * time.Millisecond
+
+// DrainStatus describes how far a node is in draining its participants
+type DrainStatus struct {
+	NodeID   livekit.NodeID `json:"node_id"`
+	Draining bool           `json:"draining"`
+	// no participant is left on the node
+	Drained   bool       `json:"drained"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// participants still on the node at this time are disconnected
+	Deadline *time.Time `json:"deadline,omitempty"`
+
+	Rooms        int `json:"rooms"`
+	Participants int `json:"participants"`
+	// rooms moved to other nodes, and the participants asked to follow them
+	MigratedRooms        int `json:"migrated_rooms"`
+	MigratedParticipants int `json:"migrated_participants"`
+}
+
+type nodeDrain struct {
+	startedAt time.Time
+	deadline  time.Time
+
+	migratedRooms        map[livekit.RoomName]*rtc.Room
+	migratedParticipants int
+	lastMigrationAt      time.Time
+
+	drained bool
+	done    chan struct{}
+}
+
+// Drain stops the node from taking new participants. With drain.migrate_participants, the rooms it hosts are moved
+// to other nodes and their participants are redirected there with resume tokens, their sessions carry on.
+// Participants left after the deadline, the drain deadline of the config when 0, are disconnected. Draining again
+// can only bring the deadline forward.
+func (r *RoomManager) Drain(deadline time.Duration) *DrainStatus {
+	if deadline == 0 {
+		deadline = r.config.Drain.Deadline
+	}
+
+	r.lock.Lock()
+	d := r.drain
+	started := d == nil
+	if started {
+		d = &nodeDrain{
+			startedAt:     time.Now(),
+			migratedRooms: make(map[livekit.RoomName]*rtc.Room),
+			done:          make(chan struct{}),
+		}
+		r.drain = d
+	}
+	if deadline > 0 {
+		if at := time.Now().Add(deadline); d.deadline.IsZero() || at.Before(d.deadline) {
+			d.deadline = at
+		}
+	}
+	r.lock.Unlock()
+
+	if started {
+		logger.Infow("draining node", "deadline", deadline, "migrateParticipants", r.config.Drain.MigrateParticipants)
+		r.router.Drain()
+		go r.drainWorker(d)
+	}
+	return r.DrainStatus()
+}
+
+// DrainDone is closed once no participant is left on a draining node, it is nil until the node drains
+func (r *RoomManager) DrainDone() <-chan struct{} {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.drain == nil {
+		return nil
+	}
+	return r.drain.done
+}
+
+func (r *RoomManager) DrainStatus() *DrainStatus {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	status := &DrainStatus{
+		NodeID: livekit.NodeID(r.currentNode.Id),
+		Rooms:  len(r.rooms),
+	}
+	for _, room := range r.rooms {
+		status.Participants += len(room.GetParticipants())
+	}
+	if d := r.drain; d != nil {
+		status.Draining = true
+		status.Drained = d.drained
+		startedAt := d.startedAt
+		status.StartedAt = &startedAt
+		if !d.deadline.IsZero() {
+			deadline := d.deadline
+			status.Deadline = &deadline
+		}
+		status.MigratedRooms = len(d.migratedRooms)
+		status.MigratedParticipants = d.migratedParticipants
+	}
+	return status
+}
+
+func (r *RoomManager) DrainNode(ctx context.Context, req *DrainNodeRequest) (*DrainStatus, error) {
+	return r.Drain(time.Duration(req.Deadline) * time.Second), nil
+}
+
+func (r *RoomManager) GetDrainStatus(ctx context.Context, req *GetDrainStatusRequest) (*DrainStatus, error) {
+	return r.DrainStatus(), nil
+}
+
+func (r *RoomManager) drainWorker(d *nodeDrain) {
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if !r.HasParticipants() {
+			break
+		}
+
+		r.lock.RLock()
+		deadline := d.deadline
+		r.lock.RUnlock()
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			r.lock.RLock()
+			rooms := maps.Values(r.rooms)
+			r.lock.RUnlock()
+
+			logger.Infow("drain deadline reached, closing rooms", "rooms", len(rooms))
+			for _, room := range rooms {
+				room.Close(types.ParticipantCloseReasonRoomManagerStop)
+			}
+			break
+		}
+
+		if r.config.Drain.MigrateParticipants {
+			r.migrateRooms(d)
+		}
+		<-ticker.C
+	}
+
+	r.lock.Lock()
+	d.drained = true
+	r.lock.Unlock()
+	close(d.done)
+	logger.Infow("node drained", "migratedRooms", len(d.migratedRooms), "migratedParticipants", d.migratedParticipants)
+}
+
+// migrateRooms moves a room to another node every migration interval. Rooms already assigned to another node,
+// e.g. by a participant joining meanwhile, have their participants migrated right away.
+func (r *RoomManager) migrateRooms(d *nodeDrain) {
+	ctx := context.Background()
+
+	r.lock.RLock()
+	rooms := make([]*rtc.Room, 0, len(r.rooms))
+	for _, room := range r.rooms {
+		if _, ok := d.migratedRooms[room.Name()]; !ok {
+			rooms = append(rooms, room)
+		}
+	}
+	lastMigrationAt := d.lastMigrationAt
+	r.lock.RUnlock()
+
+	var nodes []*livekit.Node
+	for _, room := range rooms {
+		if len(room.GetParticipants()) == 0 {
+			continue
+		}
+
+		if node, err := r.router.GetNodeForRoom(ctx, room.Name()); err == nil && node.Id != r.currentNode.Id {
+			r.migrateRoom(d, room, node)
+			continue
+		}
+
+		if time.Since(lastMigrationAt) < r.config.Drain.MigrationInterval {
+			continue
+		}
+
+		if nodes == nil {
+			var err error
+			if nodes, err = r.otherNodes(); err != nil {
+				logger.Warnw("could not list nodes to migrate rooms to", err)
+				return
+			}
+		}
+		node, err := r.drainSelector.SelectNode(nodes)
+		if err != nil {
+			// the node is the only one left, its participants stay until they leave or the deadline
+			room.Logger.Debugw("no node to migrate room to", "error", err)
+			return
+		}
+		if err := r.router.SetNodeForRoom(ctx, room.Name(), livekit.NodeID(node.Id)); err != nil {
+			room.Logger.Warnw("could not move room to node", err, "nodeID", node.Id)
+			continue
+		}
+		r.migrateRoom(d, room, node)
+		lastMigrationAt = time.Now()
+	}
+
+	r.lock.Lock()
+	if lastMigrationAt.After(d.lastMigrationAt) {
+		d.lastMigrationAt = lastMigrationAt
+	}
+	r.lock.Unlock()
+}
+
+// migrateRoom redirects the participants of a room assigned to another node there, with resume tokens for that node.
+// Their sessions are handed off to it, so they carry on whether sessions resume across nodes or not. The room state
+// is left to that node.
+func (r *RoomManager) migrateRoom(d *nodeDrain, room *rtc.Room, node *livekit.Node) {
+	r.lock.Lock()
+	d.migratedRooms[room.Name()] = room
+	r.lock.Unlock()
+
+	redirected, reconnected := r.redirectRoom(context.Background(), room, node, r.redirectTarget(node))
+
+	r.lock.Lock()
+	d.migratedParticipants += redirected + reconnected
+	r.lock.Unlock()
+	room.Logger.Infow("room migrated", "nodeID", node.Id, "redirected", redirected, "reconnected", reconnected)
+}
@@ -0,0 +1,174 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/clientconfiguration"
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
+)
+
+// participants of a drained node are asked to resume, the resume reaches the node taking over their room
+func TestDrainMigrationResume(t *testing.T) {
+	ctx := context.Background()
+
+	// handedOff resumes with the resume token of a drain, which hands the session off to the node taking over.
+	// The session was joined with a token of APIalice, apiKey signed the token it resumes with
+	resume := func(t *testing.T, resumeAcrossNodes bool, handedOff bool, apiKey string) (*service.RoomManager, *routingfakes.FakeMessageSink, error) {
+		conf, err := config.NewConfig("", true, nil, nil)
+		require.NoError(t, err)
+		conf.Room.ResumeAcrossNodes = resumeAcrossNodes
+		store := service.NewLocalStore()
+		rm, nodeID := newTestRoomManager(t, conf, store)
+
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: "room", Sid: "RM_room"}, nil))
+		require.NoError(t, store.StoreParticipant(ctx, "room", &livekit.ParticipantInfo{
+			Sid:      "PA_alice",
+			Identity: "alice",
+			Metadata: "metadata",
+			Version:  3,
+		}))
+		// stored by the drained node
+		session := &service.ParticipantSession{
+			Room:          "room",
+			Identity:      "alice",
+			ParticipantID: "PA_alice",
+			NodeID:        "ND_drained",
+			APIKey:        "APIalice",
+		}
+		var resumeNodeID livekit.NodeID
+		if handedOff {
+			session.HandoffNodeID = nodeID
+			session.HandoffExpiresAt = time.Now().Add(time.Minute)
+			resumeNodeID = nodeID
+		}
+		require.NoError(t, store.StoreParticipantSession(ctx, session))
+
+		source := &routingfakes.FakeMessageSource{}
+		source.ReadChanReturns(make(chan proto.Message))
+		sink := &routingfakes.FakeMessageSink{}
+		err = rm.StartSession(ctx, "room", routing.ParticipantInit{
+			Identity:        "alice",
+			ID:              "PA_alice",
+			Reconnect:       true,
+			ReconnectReason: livekit.ReconnectReason_RR_SIGNAL_DISCONNECTED,
+			ResumeNodeID:    resumeNodeID,
+			APIKey:          apiKey,
+			Client:          &livekit.ClientInfo{Protocol: types.CurrentProtocol},
+			Grants:          &auth.ClaimGrants{Identity: "alice", Video: &auth.VideoGrant{RoomJoin: true, Room: "room"}},
+		}, source, sink)
+		return rm, sink, err
+	}
+
+	t.Run("participants join again without a handoff unless sessions resume across nodes", func(t *testing.T) {
+		rm, sink, err := resume(t, false, false, "APIalice")
+		require.Error(t, err)
+
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+		leave := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse).GetLeave()
+		require.NotNil(t, leave)
+		require.Equal(t, livekit.LeaveRequest_RECONNECT, leave.Action)
+		require.Equal(t, livekit.DisconnectReason_STATE_MISMATCH, leave.Reason)
+		require.Nil(t, rm.GetRoom(ctx, "room").GetParticipant("alice"))
+	})
+
+	for _, tc := range []struct {
+		name              string
+		resumeAcrossNodes bool
+		handedOff         bool
+	}{
+		{name: "sessions resume across nodes", resumeAcrossNodes: true},
+		{name: "sessions handed off by a drain resume", handedOff: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rm, sink, err := resume(t, tc.resumeAcrossNodes, tc.handedOff, "APIalice")
+			require.NoError(t, err)
+
+			for i := 0; i < sink.WriteMessageCallCount(); i++ {
+				require.Nil(t, sink.WriteMessageArgsForCall(i).(*livekit.SignalResponse).GetLeave())
+			}
+			participant := rm.GetRoom(ctx, "room").GetParticipant("alice")
+			require.NotNil(t, participant)
+			require.Equal(t, livekit.ParticipantID("PA_alice"), participant.ID())
+			require.Equal(t, "metadata", participant.ToProto().Metadata)
+		})
+	}
+
+	t.Run("resume tokens are only signed with the key the participant joined with", func(t *testing.T) {
+		rm, _, err := resume(t, true, true, "APIalice")
+		require.NoError(t, err)
+
+		// APIalice is not a key of this node, the resume token is not signed with another one
+		_, err = rm.RedirectParticipant(ctx, &service.RedirectParticipantRequest{Room: "room", Identity: "alice", Url: "wss://other"})
+		require.ErrorIs(t, err, service.ErrResumeKeyMissing)
+	})
+
+	t.Run("sessions do not resume with a token of another API key", func(t *testing.T) {
+		rm, sink, err := resume(t, true, true, "APIother")
+		require.Error(t, err)
+
+		leave := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse).GetLeave()
+		require.NotNil(t, leave)
+		require.Nil(t, rm.GetRoom(ctx, "room").GetParticipant("alice"))
+	})
+}
+
+func newTestRoomManager(t *testing.T, conf *config.Config, store service.ObjectStore) (*service.RoomManager, livekit.NodeID) {
+	currentNode, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+	bus := psrpc.NewLocalMessageBus()
+	roomEvents, err := service.NewRoomEventService(conf, bus, rpc.ClientParams{})
+	require.NoError(t, err)
+
+	rm, err := service.NewLocalRoomManager(
+		conf,
+		store,
+		currentNode,
+		&routingfakes.FakeRouter{},
+		&telemetryfakes.FakeTelemetryService{},
+		clientconfiguration.NewStaticClientConfigurationManager(nil),
+		nil,
+		nil,
+		utils.NewDefaultTimedVersionGenerator(),
+		service.NewTURNAuthHandler(auth.NewFileBasedKeyProviderFromMap(conf.Keys)),
+		bus,
+		roomEvents,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+	t.Cleanup(rm.Stop)
+	return rm, livekit.NodeID(currentNode.Id)
+}
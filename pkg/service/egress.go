@@ -34,6 +34,12 @@ type EgressService struct {
 	io          IOClient
 	roomService livekit.RoomService
 	store       ServiceStore
+	keyQuotas   *KeyQuotas
+
+	roomExtClient  RoomExtClient
+	topicFormatter rpc.TopicFormatter
+	auditLog       *AuditLog
+	speakerMarkers SpeakerMarkerStore
 }
 
 func NewEgressService(
@@ -42,13 +48,23 @@ func NewEgressService(
 	store ServiceStore,
 	io IOClient,
 	rs livekit.RoomService,
+	keyQuotas *KeyQuotas,
+	roomExtClient RoomExtClient,
+	topicFormatter rpc.TopicFormatter,
+	auditLog *AuditLog,
+	speakerMarkers SpeakerMarkerStore,
 ) *EgressService {
 	return &EgressService{
-		client:      client,
-		store:       store,
-		io:          io,
-		roomService: rs,
-		launcher:    launcher,
+		client:         client,
+		store:          store,
+		io:             io,
+		roomService:    rs,
+		launcher:       launcher,
+		keyQuotas:      keyQuotas,
+		roomExtClient:  roomExtClient,
+		topicFormatter: topicFormatter,
+		auditLog:       auditLog,
+		speakerMarkers: speakerMarkers,
 	}
 }
 
@@ -156,12 +172,20 @@ func (s *EgressService) StartTrackEgress(ctx context.Context, req *livekit.Track
 	return ei, err
 }
 
-func (s *EgressService) startEgress(ctx context.Context, roomName livekit.RoomName, req *rpc.StartEgressRequest) (*livekit.EgressInfo, error) {
+func (s *EgressService) startEgress(ctx context.Context, roomName livekit.RoomName, req *rpc.StartEgressRequest) (_ *livekit.EgressInfo, err error) {
+	defer func() {
+		s.auditLog.Record(ctx, "StartEgress", roomName, err, req)
+	}()
+
 	if err := EnsureRecordPermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
 	} else if s.launcher == nil {
 		return nil, ErrEgressNotConnected
 	}
+	apiKey := GetAPIKey(ctx)
+	if err := s.keyQuotas.CheckEgress(ctx, apiKey); err != nil {
+		return nil, err
+	}
 	if roomName != "" {
 		room, _, err := s.store.LoadRoom(ctx, roomName, false)
 		if err != nil {
@@ -169,15 +193,25 @@ func (s *EgressService) startEgress(ctx context.Context, roomName livekit.RoomNa
 		}
 		req.RoomId = room.Sid
 	}
-	return s.launcher.StartEgress(ctx, req)
+	info, err := s.launcher.StartEgress(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.keyQuotas.EgressStarted(ctx, info.EgressId, apiKey)
+	return info, nil
 }
 
 type LayoutMetadata struct {
 	Layout string `json:"layout"`
 }
 
-func (s *EgressService) UpdateLayout(ctx context.Context, req *livekit.UpdateLayoutRequest) (*livekit.EgressInfo, error) {
+func (s *EgressService) UpdateLayout(ctx context.Context, req *livekit.UpdateLayoutRequest) (_ *livekit.EgressInfo, err error) {
 	AppendLogFields(ctx, "egressID", req.EgressId, "layout", req.Layout)
+	var roomName livekit.RoomName
+	defer func() {
+		// the room is known once the egress is loaded
+		s.auditLog.Record(ctx, "UpdateLayout", roomName, err, req)
+	}()
 	if err := EnsureRecordPermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
 	}
@@ -186,6 +220,7 @@ func (s *EgressService) UpdateLayout(ctx context.Context, req *livekit.UpdateLay
 	if err != nil {
 		return nil, err
 	}
+	roomName = livekit.RoomName(info.RoomName)
 
 	metadata, err := json.Marshal(&LayoutMetadata{Layout: req.Layout})
 	if err != nil {
@@ -208,8 +243,12 @@ func (s *EgressService) UpdateLayout(ctx context.Context, req *livekit.UpdateLay
 	return info, nil
 }
 
-func (s *EgressService) UpdateStream(ctx context.Context, req *livekit.UpdateStreamRequest) (*livekit.EgressInfo, error) {
+func (s *EgressService) UpdateStream(ctx context.Context, req *livekit.UpdateStreamRequest) (info *livekit.EgressInfo, err error) {
 	AppendLogFields(ctx, "egressID", req.EgressId, "addUrls", req.AddOutputUrls, "removeUrls", req.RemoveOutputUrls)
+	defer func() {
+		// the room is known once the egress is loaded
+		s.auditLog.Record(ctx, "UpdateStream", livekit.RoomName(info.GetRoomName()), err, req)
+	}()
 	if err := EnsureRecordPermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
 	}
@@ -218,7 +257,7 @@ func (s *EgressService) UpdateStream(ctx context.Context, req *livekit.UpdateStr
 		return nil, ErrEgressNotConnected
 	}
 
-	info, err := s.client.UpdateStream(ctx, req.EgressId, req)
+	info, err = s.client.UpdateStream(ctx, req.EgressId, req)
 	if err != nil {
 		var loadErr error
 		info, loadErr = s.io.GetEgress(ctx, &rpc.GetEgressRequest{EgressId: req.EgressId})
@@ -249,8 +288,12 @@ func (s *EgressService) ListEgress(ctx context.Context, req *livekit.ListEgressR
 	return s.io.ListEgress(ctx, req)
 }
 
-func (s *EgressService) StopEgress(ctx context.Context, req *livekit.StopEgressRequest) (*livekit.EgressInfo, error) {
+func (s *EgressService) StopEgress(ctx context.Context, req *livekit.StopEgressRequest) (info *livekit.EgressInfo, err error) {
 	AppendLogFields(ctx, "egressID", req.EgressId)
+	defer func() {
+		// the room is known once the egress is loaded
+		s.auditLog.Record(ctx, "StopEgress", livekit.RoomName(info.GetRoomName()), err, req)
+	}()
 	if err := EnsureRecordPermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
 	}
@@ -259,7 +302,7 @@ func (s *EgressService) StopEgress(ctx context.Context, req *livekit.StopEgressR
 		return nil, ErrEgressNotConnected
 	}
 
-	info, err := s.client.StopEgress(ctx, req.EgressId, req)
+	info, err = s.client.StopEgress(ctx, req.EgressId, req)
 	if err != nil {
 		var loadErr error
 		info, loadErr = s.io.GetEgress(ctx, &rpc.GetEgressRequest{EgressId: req.EgressId})
@@ -19,23 +19,81 @@ import (
 )
 
 var (
-	ErrEgressNotFound          = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
-	ErrEgressNotConnected      = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
-	ErrIdentityEmpty           = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
-	ErrIngressNotConnected     = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
-	ErrIngressNotFound         = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
-	ErrIngressNonReusable      = psrpc.NewErrorf(psrpc.InvalidArgument, "ingress is not reusable and cannot be modified")
-	ErrMetadataExceedsLimits   = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata size exceeds limits")
-	ErrOperationFailed         = psrpc.NewErrorf(psrpc.Internal, "operation cannot be completed")
-	ErrParticipantNotFound     = psrpc.NewErrorf(psrpc.NotFound, "participant does not exist")
-	ErrRoomNotFound            = psrpc.NewErrorf(psrpc.NotFound, "requested room does not exist")
-	ErrRoomLockFailed          = psrpc.NewErrorf(psrpc.Internal, "could not lock room")
-	ErrRoomUnlockFailed        = psrpc.NewErrorf(psrpc.Internal, "could not unlock room, lock token does not match")
-	ErrRemoteUnmuteNoteEnabled = psrpc.NewErrorf(psrpc.FailedPrecondition, "remote unmute not enabled")
-	ErrTrackNotFound           = psrpc.NewErrorf(psrpc.NotFound, "track is not found")
-	ErrWebHookMissingAPIKey    = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key is required to use webhooks")
-	ErrSIPNotConnected         = psrpc.NewErrorf(psrpc.Internal, "sip not connected (redis required)")
-	ErrSIPTrunkNotFound        = psrpc.NewErrorf(psrpc.NotFound, "requested sip trunk does not exist")
-	ErrSIPDispatchRuleNotFound = psrpc.NewErrorf(psrpc.NotFound, "requested sip dispatch rule does not exist")
-	ErrSIPParticipantNotFound  = psrpc.NewErrorf(psrpc.NotFound, "requested sip participant does not exist")
+	ErrAPIKeyEgressQuotaExceeded      = psrpc.NewErrorf(psrpc.ResourceExhausted, "egress minutes of the API key are used up")
+	ErrAPIKeyParticipantQuotaExceeded = psrpc.NewErrorf(psrpc.ResourceExhausted, "participant quota of the API key exceeded")
+	ErrAPIKeyRoomQuotaExceeded        = psrpc.NewErrorf(psrpc.ResourceExhausted, "room quota of the API key exceeded")
+	ErrAttachmentInvalid              = psrpc.NewErrorf(psrpc.InvalidArgument, "attachment name, content type or size is invalid")
+	ErrAttachmentLimitExceeded        = psrpc.NewErrorf(psrpc.ResourceExhausted, "room has the max number of attachments")
+	ErrAttachmentNotFound             = psrpc.NewErrorf(psrpc.NotFound, "attachment does not exist")
+	ErrAttachmentNotUploaded          = psrpc.NewErrorf(psrpc.FailedPrecondition, "attachment has not been uploaded")
+	ErrAttachmentsNotEnabled          = psrpc.NewErrorf(psrpc.Unimplemented, "attachments are not enabled")
+	ErrAudioMixFormatInvalid          = psrpc.NewErrorf(psrpc.InvalidArgument, "audio mix format must be ogg or mp3")
+	ErrAudioMixOutputInvalid          = psrpc.NewErrorf(psrpc.InvalidArgument, "audio mix egress requires a single file output and cannot be video only")
+	ErrAuditLogNotEnabled             = psrpc.NewErrorf(psrpc.Unimplemented, "audit log is not enabled, or its records are not kept")
+	ErrCodecRestrictionInvalid        = psrpc.NewErrorf(psrpc.InvalidArgument, "codec restrictions must be audio or video mime types, not both allowed and denied")
+	ErrCongestionTraceMissing         = psrpc.NewErrorf(psrpc.Unavailable, "congestion trace of the subscriber is not available, tracing may not be enabled")
+	ErrEgressNotFound                 = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
+	ErrEgressNotConnected             = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
+	ErrFloorQueueEmpty                = psrpc.NewErrorf(psrpc.FailedPrecondition, "no participant is waiting for the floor")
+	ErrIdentityEmpty                  = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
+	ErrIngressNotConnected            = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
+	ErrIngressNotFound                = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
+	ErrIngressNonReusable             = psrpc.NewErrorf(psrpc.InvalidArgument, "ingress is not reusable and cannot be modified")
+	ErrJoinAuthorizationFailed        = psrpc.NewErrorf(psrpc.Unavailable, "join could not be authorized")
+	ErrJoinRejected                   = psrpc.NewErrorf(psrpc.PermissionDenied, "join rejected by authorization service")
+	ErrJoinRestricted                 = psrpc.NewErrorf(psrpc.PermissionDenied, "join is not allowed from the network of the client")
+	ErrJoinRestrictionInvalid         = psrpc.NewErrorf(psrpc.InvalidArgument, "join restrictions must have two letter country codes and CIDR ranges")
+	ErrLatencyProfileInvalid          = psrpc.NewErrorf(psrpc.InvalidArgument, "latency profile must be interactive or streaming")
+	ErrMetadataExceedsLimits          = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata size exceeds limits")
+	ErrMetadataNotObject              = psrpc.NewErrorf(psrpc.FailedPrecondition, "metadata is not a JSON object and cannot be patched")
+	ErrMetadataPatchInvalid           = psrpc.NewErrorf(psrpc.InvalidArgument, "metadata patch must be valid JSON")
+	ErrMetadataVersionMismatch        = psrpc.NewErrorf(psrpc.Aborted, "metadata has changed since the expected version")
+	ErrMoveDestinationInvalid         = psrpc.NewErrorf(psrpc.InvalidArgument, "destination room must be different from the current room")
+	ErrMoveDestinationRemote          = psrpc.NewErrorf(psrpc.FailedPrecondition, "destination room is hosted on another node")
+	ErrMoveParticipantPending         = psrpc.NewErrorf(psrpc.FailedPrecondition, "participant waiting to be admitted cannot be moved")
+	ErrNotFloorHolder                 = psrpc.NewErrorf(psrpc.FailedPrecondition, "participant does not hold or wait for the floor")
+	ErrOperationFailed                = psrpc.NewErrorf(psrpc.Internal, "operation cannot be completed")
+	ErrPacketCaptureFormatInvalid     = psrpc.NewErrorf(psrpc.InvalidArgument, "packet capture format must be pcap or rtpdump")
+	ErrPacketCaptureLimitExceeded     = psrpc.NewErrorf(psrpc.ResourceExhausted, "node has the max number of active packet captures")
+	ErrPacketCaptureNotEnabled        = psrpc.NewErrorf(psrpc.Unimplemented, "packet captures are not enabled")
+	ErrPacketCaptureNotFound          = psrpc.NewErrorf(psrpc.NotFound, "packet capture does not exist")
+	ErrPacketCaptureTrackBusy         = psrpc.NewErrorf(psrpc.FailedPrecondition, "track is already being captured")
+	ErrParticipantBanNotFound         = psrpc.NewErrorf(psrpc.NotFound, "participant is not banned from the room")
+	ErrParticipantBanned              = psrpc.NewErrorf(psrpc.PermissionDenied, "participant is banned from the room")
+	ErrParticipantNotFound            = psrpc.NewErrorf(psrpc.NotFound, "participant does not exist")
+	ErrParticipantSessionNotFound     = psrpc.NewErrorf(psrpc.NotFound, "participant session does not exist")
+	ErrParticipantNotPending          = psrpc.NewErrorf(psrpc.FailedPrecondition, "participant is not waiting to be admitted")
+	ErrPermissionMissing              = psrpc.NewErrorf(psrpc.InvalidArgument, "permission is required")
+	ErrPushToTalkDisabled             = psrpc.NewErrorf(psrpc.FailedPrecondition, "push to talk is not enabled in the room")
+	ErrPushToTalkInvalid              = psrpc.NewErrorf(psrpc.InvalidArgument, "push to talk floor policy is unknown")
+	ErrRedirectNodeInvalid            = psrpc.NewErrorf(psrpc.InvalidArgument, "redirect node must be another node of the cluster")
+	ErrRedirectNoNode                 = psrpc.NewErrorf(psrpc.Unavailable, "no other node to redirect the room to")
+	ErrRedirectTargetMissing          = psrpc.NewErrorf(psrpc.InvalidArgument, "redirect target url is required")
+	ErrRedirectNotConnected           = psrpc.NewErrorf(psrpc.FailedPrecondition, "participant has no session to redirect yet")
+	ErrResumeKeyMissing               = psrpc.NewErrorf(psrpc.FailedPrecondition, "API key the participant joined with is not configured")
+	ErrResumeTokenMismatch            = psrpc.NewErrorf(psrpc.PermissionDenied, "resume token can only resume the session it was issued for")
+	ErrRoomNotFound                   = psrpc.NewErrorf(psrpc.NotFound, "requested room does not exist")
+	ErrRoomPasscodeInvalid            = psrpc.NewErrorf(psrpc.PermissionDenied, "room passcode is missing or incorrect")
+	ErrRoomPasscodeLockedOut          = psrpc.NewErrorf(psrpc.ResourceExhausted, "too many incorrect room passcodes, try again later")
+	ErrRoomScheduleInvalid            = psrpc.NewErrorf(psrpc.InvalidArgument, "room activation window must end in the future and after it starts")
+	ErrRoomTemplateNotFound           = psrpc.NewErrorf(psrpc.InvalidArgument, "requested room template does not exist")
+	ErrRoomConfigOverrideInvalid      = psrpc.NewErrorf(psrpc.InvalidArgument, "room config overrides are out of range")
+	ErrRoomLockFailed                 = psrpc.NewErrorf(psrpc.Internal, "could not lock room")
+	ErrRoomUnlockFailed               = psrpc.NewErrorf(psrpc.Internal, "could not unlock room, lock token does not match")
+	ErrRemoteUnmuteNoteEnabled        = psrpc.NewErrorf(psrpc.FailedPrecondition, "remote unmute not enabled")
+	ErrSpeakerMarkersNotFound         = psrpc.NewErrorf(psrpc.NotFound, "speaker markers are not recorded for the egress in the room")
+	ErrSubscribeDefaultsInvalid       = psrpc.NewErrorf(psrpc.InvalidArgument, "subscribe defaults have an unknown track source or quality")
+	ErrSubscriberAllocationMissing    = psrpc.NewErrorf(psrpc.Unavailable, "subscriber bandwidth allocation could not be read")
+	ErrTrackNotFound                  = psrpc.NewErrorf(psrpc.NotFound, "track is not found")
+	ErrTrackSourceInvalid             = psrpc.NewErrorf(psrpc.InvalidArgument, "track source must be camera, microphone, screen_share or screen_share_audio")
+	ErrTrackStatsHistoryMissing       = psrpc.NewErrorf(psrpc.Unavailable, "track stats history of the participant is not available, it may not be enabled")
+	ErrTrackStatsHistoryNotFound      = psrpc.NewErrorf(psrpc.NotFound, "track stats history of the participant is not kept anymore")
+	ErrTransportInvalid               = psrpc.NewErrorf(psrpc.InvalidArgument, "transport must be publisher or subscriber")
+	ErrUpdateParticipantPending       = psrpc.NewErrorf(psrpc.FailedPrecondition, "participant waiting to be admitted cannot be updated, admit it first")
+	ErrWebHookMissingAPIKey           = psrpc.NewErrorf(psrpc.InvalidArgument, "api_key is required to use webhooks")
+	ErrSIPNotConnected                = psrpc.NewErrorf(psrpc.Internal, "sip not connected (redis required)")
+	ErrSIPTrunkNotFound               = psrpc.NewErrorf(psrpc.NotFound, "requested sip trunk does not exist")
+	ErrSIPDispatchRuleNotFound        = psrpc.NewErrorf(psrpc.NotFound, "requested sip dispatch rule does not exist")
+	ErrSIPParticipantNotFound         = psrpc.NewErrorf(psrpc.NotFound, "requested sip participant does not exist")
+	ErrSIPNoAPIKey                    = psrpc.NewErrorf(psrpc.Unavailable, "no API key to create the token of the sip participant")
 )
@@ -0,0 +1,1215 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/client"
+	"github.com/livekit/psrpc/pkg/info"
+	"github.com/livekit/psrpc/pkg/middleware"
+	"github.com/livekit/psrpc/pkg/rand"
+	"github.com/livekit/psrpc/pkg/server"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+)
+
+// psrpc services for server-side features that have no definition in the protocol module.
+// they follow the layout of the generated rpc clients and servers, and are routed using
+// the same room and participant topics
+
+const (
+	participantExtService = "ParticipantExt"
+	roomExtService        = "RoomExt"
+	nodeExtService        = "NodeExt"
+	roomEventsService     = "RoomEvents"
+)
+
+// requests without a protocol message are sent JSON encoded as wrapperspb.BytesValue
+
+type MoveParticipantRequest struct {
+	Room            string `json:"room"`
+	Identity        string `json:"identity"`
+	DestinationRoom string `json:"destination_room"`
+	// admin token of the destination room
+	DestinationToken string `json:"destination_token,omitempty"`
+}
+
+func (r *MoveParticipantRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *MoveParticipantRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type GetSubscriberAllocationRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// number of the latest allocation decisions returned, all decisions that are kept when 0
+	Decisions int `json:"decisions,omitempty"`
+}
+
+func (r *GetSubscriberAllocationRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *GetSubscriberAllocationRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type GetCongestionTraceRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+func (r *GetCongestionTraceRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *GetCongestionTraceRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type GetICEDiagnosticsRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+func (r *GetICEDiagnosticsRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *GetICEDiagnosticsRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type GetConnectionQualityDetailsRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+func (r *GetConnectionQualityDetailsRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *GetConnectionQualityDetailsRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type GetTrackStatsHistoryRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// a single track published or subscribed by the participant, all of them when empty
+	TrackID string `json:"track_id,omitempty"`
+	// samples of the last minutes returned, all samples that are kept when 0. For a participant that left the
+	// room, the minutes before it left
+	Minutes int `json:"minutes,omitempty"`
+}
+
+func (r *GetTrackStatsHistoryRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *GetTrackStatsHistoryRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type GetAVSyncStatsRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+func (r *GetAVSyncStatsRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *GetAVSyncStatsRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type BanParticipantRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// bans the IP the participant is connected from as well
+	BanIP bool `json:"ban_ip,omitempty"`
+}
+
+func (r *BanParticipantRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *BanParticipantRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type RedirectParticipantRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// signal URL the participant resumes its session through
+	Url    string `json:"url"`
+	Region string `json:"region,omitempty"`
+}
+
+func (r *RedirectParticipantRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *RedirectParticipantRequest) GetIdentity() string {
+	return r.Identity
+}
+
+// PatchParticipantMetadataRequest applies a JSON merge patch (RFC 7386) to the metadata of a participant
+type PatchParticipantMetadataRequest struct {
+	Room     string          `json:"room"`
+	Identity string          `json:"identity"`
+	Patch    json.RawMessage `json:"patch"`
+	// version of the metadata the patch was made against, the patch is applied regardless when empty
+	Version string `json:"version,omitempty"`
+}
+
+func (r *PatchParticipantMetadataRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *PatchParticipantMetadataRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type TrackStatsHistoryResponse struct {
+	Room     string                    `json:"room"`
+	Identity string                    `json:"identity"`
+	Tracks   []*types.TrackStatsSeries `json:"tracks"`
+}
+
+type ICEDiagnosticsResponse struct {
+	Room       string                  `json:"room"`
+	Identity   string                  `json:"identity"`
+	Transports []*types.ICEDiagnostics `json:"transports"`
+}
+
+type GetTransportStatsRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// publisher or subscriber
+	Transport string `json:"transport"`
+}
+
+func (r *GetTransportStatsRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *GetTransportStatsRequest) GetIdentity() string {
+	return r.Identity
+}
+
+func (r *GetTransportStatsRequest) signalTarget() (livekit.SignalTarget, error) {
+	switch strings.ToLower(r.Transport) {
+	case "publisher":
+		return livekit.SignalTarget_PUBLISHER, nil
+	case "subscriber":
+		return livekit.SignalTarget_SUBSCRIBER, nil
+	default:
+		return 0, ErrTransportInvalid
+	}
+}
+
+type MuteAllParticipantsRequest struct {
+	Room string `json:"room"`
+	// leave microphones of participants with the room admin grant unmuted
+	ExcludeHosts bool `json:"exclude_hosts,omitempty"`
+	// identities of participants whose microphones are left unmuted
+	Except []string `json:"except,omitempty"`
+}
+
+type MuteTracksBySourceRequest struct {
+	Room string `json:"room"`
+	// camera, microphone, screen_share or screen_share_audio
+	Source string `json:"source"`
+	// unmutes the tracks when false, which requires remote unmute to be enabled
+	Muted bool `json:"muted"`
+	// leave tracks of participants with the room admin grant untouched
+	ExcludeHosts bool `json:"exclude_hosts,omitempty"`
+	// identities of participants whose tracks are left untouched
+	Except []string `json:"except,omitempty"`
+}
+
+func (r *MuteTracksBySourceRequest) trackSource() (livekit.TrackSource, error) {
+	source, ok := livekit.TrackSource_value[strings.ToUpper(r.Source)]
+	if !ok || livekit.TrackSource(source) == livekit.TrackSource_UNKNOWN {
+		return livekit.TrackSource_UNKNOWN, ErrTrackSourceInvalid
+	}
+	return livekit.TrackSource(source), nil
+}
+
+type SetPushToTalkRequest struct {
+	Room string `json:"room"`
+	// push to talk is disabled when not set or not enabled
+	PushToTalk *rtc.PushToTalkOptions `json:"push_to_talk,omitempty"`
+}
+
+type GrantFloorRequest struct {
+	Room string `json:"room"`
+	// grants the floor to the first participant waiting for it when empty
+	Identity string `json:"identity,omitempty"`
+	// in seconds, bounded by the max floor duration of the room
+	Duration uint32 `json:"duration,omitempty"`
+}
+
+type ReleaseFloorRequest struct {
+	Room string `json:"room"`
+	// releases the floor of whoever holds it when empty, a participant waiting for the floor is removed from the queue
+	Identity string `json:"identity,omitempty"`
+}
+
+type GetRoomDebugInfoRequest struct {
+	Room string `json:"room"`
+}
+
+// RoomDebugInfo is the debug info tree of a room: participants with their published tracks, receivers,
+// down tracks of the subscribers and transports with their ICE candidate pairs
+type RoomDebugInfo map[string]interface{}
+
+type RecordSpeakerMarkersRequest struct {
+	Room     string `json:"room"`
+	EgressID string `json:"egress_id"`
+}
+
+type GetSpeakerMarkersRequest struct {
+	Room     string `json:"room"`
+	EgressID string `json:"egress_id"`
+}
+
+type SpeakerMarkersResponse struct {
+	EgressID string              `json:"egress_id"`
+	Markers  []rtc.SpeakerMarker `json:"markers"`
+}
+
+type GetTrackSubscriberCountsRequest struct {
+	Room string `json:"room"`
+	// only the tracks published by the participant when set
+	Identity string `json:"identity,omitempty"`
+}
+
+// TrackSubscriberCounts has the number of subscribers of each track published in a room
+type TrackSubscriberCounts struct {
+	Tracks []TrackSubscriberCount `json:"tracks"`
+}
+
+type TrackSubscriberCount struct {
+	Identity string `json:"identity"`
+	TrackID  string `json:"track_id"`
+	Name     string `json:"name,omitempty"`
+	// camera, microphone, screen_share or screen_share_audio
+	Source      string `json:"source"`
+	Subscribers int    `json:"subscribers"`
+}
+
+type MergeRoomsRequest struct {
+	// room whose participants are moved
+	Room            string `json:"room"`
+	DestinationRoom string `json:"destination_room"`
+	// admin token of the destination room
+	DestinationToken string `json:"destination_token,omitempty"`
+	// deletes the merged room once every participant has been moved
+	DeleteRoom bool `json:"delete_room,omitempty"`
+}
+
+type MergeRoomsResponse struct {
+	// identities of the participants moved
+	Moved  []string            `json:"moved"`
+	Failed []MergeRoomsFailure `json:"failed,omitempty"`
+	// set when the merged room has been deleted
+	Deleted bool `json:"deleted"`
+}
+
+type MergeRoomsFailure struct {
+	Identity string `json:"identity"`
+	Error    string `json:"error"`
+}
+
+type RedirectRoomRequest struct {
+	Room string `json:"room"`
+	// node the room is moved to, picked as for a drain when empty
+	NodeID string `json:"node_id,omitempty"`
+	// signal URL the participants resume their sessions through, the URL configured for the region of the node when
+	// empty, or the URL they are connected to when there is none
+	Url string `json:"url,omitempty"`
+}
+
+type RedirectRoomResponse struct {
+	NodeID string `json:"node_id"`
+	// participants redirected with a resume token
+	Redirected int `json:"redirected"`
+	// participants without a session to resume, asked to join again
+	Reconnected int `json:"reconnected"`
+}
+
+type GetNodeConcurrencyRequest struct{}
+
+type DrainNodeRequest struct {
+	NodeID string `json:"node_id"`
+	// seconds before the participants left on the node are disconnected, the drain deadline of the node config when 0
+	Deadline int `json:"deadline,omitempty"`
+}
+
+type GetDrainStatusRequest struct {
+	NodeID string `json:"node_id"`
+}
+
+// PatchRoomMetadataRequest applies a JSON merge patch (RFC 7386) to the metadata of a room
+type PatchRoomMetadataRequest struct {
+	Room  string          `json:"room"`
+	Patch json.RawMessage `json:"patch"`
+	// version of the metadata the patch was made against, the patch is applied regardless when empty
+	Version string `json:"version,omitempty"`
+}
+
+type MetadataPatchResponse struct {
+	Metadata string `json:"metadata"`
+	Version  string `json:"version"`
+}
+
+type LockRoomRequest struct {
+	Room   string `json:"room"`
+	Locked bool   `json:"locked"`
+}
+
+type UpdateParticipantsPermissionRequest struct {
+	Room string `json:"room"`
+	// participants to update, all participants of the room when empty
+	Identities   []string                       `json:"identities,omitempty"`
+	ExcludeHosts bool                           `json:"exclude_hosts,omitempty"`
+	Permission   *livekit.ParticipantPermission `json:"permission"`
+}
+
+// the permission is encoded as protojson, so that track sources can be given by name
+
+func (r *UpdateParticipantsPermissionRequest) MarshalJSON() ([]byte, error) {
+	type request UpdateParticipantsPermissionRequest
+	aux := struct {
+		*request
+		Permission json.RawMessage `json:"permission,omitempty"`
+	}{request: (*request)(r)}
+	if r.Permission != nil {
+		permission, err := protojson.Marshal(r.Permission)
+		if err != nil {
+			return nil, err
+		}
+		aux.Permission = permission
+	}
+	return json.Marshal(aux)
+}
+
+func (r *UpdateParticipantsPermissionRequest) UnmarshalJSON(data []byte) error {
+	type request UpdateParticipantsPermissionRequest
+	aux := struct {
+		*request
+		Permission json.RawMessage `json:"permission"`
+	}{request: (*request)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Permission = nil
+	if len(aux.Permission) > 0 && string(aux.Permission) != "null" {
+		r.Permission = &livekit.ParticipantPermission{}
+		return (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(aux.Permission, r.Permission)
+	}
+	return nil
+}
+
+//counterfeiter:generate . ParticipantExtClient
+type ParticipantExtClient interface {
+	AdmitParticipant(ctx context.Context, participant rpc.ParticipantTopic, req *livekit.RoomParticipantIdentity, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error)
+	MoveParticipant(ctx context.Context, participant rpc.ParticipantTopic, req *MoveParticipantRequest, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error)
+	GetSubscriberAllocation(ctx context.Context, participant rpc.ParticipantTopic, req *GetSubscriberAllocationRequest, opts ...psrpc.RequestOption) (*streamallocator.AllocationInfo, error)
+	GetCongestionTrace(ctx context.Context, participant rpc.ParticipantTopic, req *GetCongestionTraceRequest, opts ...psrpc.RequestOption) (*streamallocator.CongestionTrace, error)
+	StartPacketCapture(ctx context.Context, participant rpc.ParticipantTopic, req *StartPacketCaptureRequest, opts ...psrpc.RequestOption) (*PacketCaptureInfo, error)
+	StopPacketCapture(ctx context.Context, participant rpc.ParticipantTopic, req *StopPacketCaptureRequest, opts ...psrpc.RequestOption) (*PacketCaptureInfo, error)
+	GetTransportStats(ctx context.Context, participant rpc.ParticipantTopic, req *GetTransportStatsRequest, opts ...psrpc.RequestOption) (*types.TransportStats, error)
+	GetICEDiagnostics(ctx context.Context, participant rpc.ParticipantTopic, req *GetICEDiagnosticsRequest, opts ...psrpc.RequestOption) (*ICEDiagnosticsResponse, error)
+	GetConnectionQualityDetails(ctx context.Context, participant rpc.ParticipantTopic, req *GetConnectionQualityDetailsRequest, opts ...psrpc.RequestOption) (*types.ConnectionQualityDetails, error)
+	GetTrackStatsHistory(ctx context.Context, participant rpc.ParticipantTopic, req *GetTrackStatsHistoryRequest, opts ...psrpc.RequestOption) (*TrackStatsHistoryResponse, error)
+	GetAVSyncStats(ctx context.Context, participant rpc.ParticipantTopic, req *GetAVSyncStatsRequest, opts ...psrpc.RequestOption) (*types.AVSyncDetails, error)
+	PatchParticipantMetadata(ctx context.Context, participant rpc.ParticipantTopic, req *PatchParticipantMetadataRequest, opts ...psrpc.RequestOption) (*MetadataPatchResponse, error)
+	BanParticipant(ctx context.Context, participant rpc.ParticipantTopic, req *BanParticipantRequest, opts ...psrpc.RequestOption) (*ParticipantBan, error)
+	RedirectParticipant(ctx context.Context, participant rpc.ParticipantTopic, req *RedirectParticipantRequest, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error)
+}
+
+type ParticipantExtServerImpl interface {
+	AdmitParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error)
+	MoveParticipant(ctx context.Context, req *MoveParticipantRequest) (*livekit.ParticipantInfo, error)
+	GetSubscriberAllocation(ctx context.Context, req *GetSubscriberAllocationRequest) (*streamallocator.AllocationInfo, error)
+	GetCongestionTrace(ctx context.Context, req *GetCongestionTraceRequest) (*streamallocator.CongestionTrace, error)
+	StartPacketCapture(ctx context.Context, req *StartPacketCaptureRequest) (*PacketCaptureInfo, error)
+	StopPacketCapture(ctx context.Context, req *StopPacketCaptureRequest) (*PacketCaptureInfo, error)
+	GetTransportStats(ctx context.Context, req *GetTransportStatsRequest) (*types.TransportStats, error)
+	GetICEDiagnostics(ctx context.Context, req *GetICEDiagnosticsRequest) (*ICEDiagnosticsResponse, error)
+	GetConnectionQualityDetails(ctx context.Context, req *GetConnectionQualityDetailsRequest) (*types.ConnectionQualityDetails, error)
+	GetTrackStatsHistory(ctx context.Context, req *GetTrackStatsHistoryRequest) (*TrackStatsHistoryResponse, error)
+	GetAVSyncStats(ctx context.Context, req *GetAVSyncStatsRequest) (*types.AVSyncDetails, error)
+	PatchParticipantMetadata(ctx context.Context, req *PatchParticipantMetadataRequest) (*MetadataPatchResponse, error)
+	BanParticipant(ctx context.Context, req *BanParticipantRequest) (*ParticipantBan, error)
+	RedirectParticipant(ctx context.Context, req *RedirectParticipantRequest) (*livekit.ParticipantInfo, error)
+}
+
+type ParticipantExtServer interface {
+	RegisterAllParticipantTopics(participant rpc.ParticipantTopic) error
+	DeregisterAllParticipantTopics(participant rpc.ParticipantTopic)
+
+	// Close and wait for pending RPCs to complete
+	Shutdown()
+
+	// Close immediately, without waiting for pending RPCs
+	Kill()
+}
+
+func participantExtServiceDefinition(id string) *info.ServiceDefinition {
+	sd := &info.ServiceDefinition{
+		Name: participantExtService,
+		ID:   id,
+	}
+	sd.RegisterMethod("AdmitParticipant", false, false, true, true)
+	sd.RegisterMethod("MoveParticipant", false, false, true, true)
+	sd.RegisterMethod("GetSubscriberAllocation", false, false, true, true)
+	sd.RegisterMethod("GetCongestionTrace", false, false, true, true)
+	sd.RegisterMethod("StartPacketCapture", false, false, true, true)
+	sd.RegisterMethod("StopPacketCapture", false, false, true, true)
+	sd.RegisterMethod("GetTransportStats", false, false, true, true)
+	sd.RegisterMethod("GetICEDiagnostics", false, false, true, true)
+	sd.RegisterMethod("GetConnectionQualityDetails", false, false, true, true)
+	sd.RegisterMethod("GetTrackStatsHistory", false, false, true, true)
+	sd.RegisterMethod("GetAVSyncStats", false, false, true, true)
+	sd.RegisterMethod("PatchParticipantMetadata", false, false, true, true)
+	sd.RegisterMethod("BanParticipant", false, false, true, true)
+	sd.RegisterMethod("RedirectParticipant", false, false, true, true)
+	return sd
+}
+
+type participantExtClient struct {
+	client *client.RPCClient
+}
+
+func NewParticipantExtClient(params rpc.ClientParams) (ParticipantExtClient, error) {
+	rpcClient, err := client.NewRPCClient(participantExtServiceDefinition(rand.NewClientID()), params.Bus, extClientOptions(params)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &participantExtClient{
+		client: rpcClient,
+	}, nil
+}
+
+func (c *participantExtClient) AdmitParticipant(ctx context.Context, participant rpc.ParticipantTopic, req *livekit.RoomParticipantIdentity, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error) {
+	return client.RequestSingle[*livekit.ParticipantInfo](ctx, c.client, "AdmitParticipant", []string{string(participant)}, req, opts...)
+}
+
+func (c *participantExtClient) MoveParticipant(ctx context.Context, participant rpc.ParticipantTopic, req *MoveParticipantRequest, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error) {
+	return requestJSON[*livekit.ParticipantInfo](ctx, c.client, "MoveParticipant", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) GetSubscriberAllocation(ctx context.Context, participant rpc.ParticipantTopic, req *GetSubscriberAllocationRequest, opts ...psrpc.RequestOption) (*streamallocator.AllocationInfo, error) {
+	return requestJSONValue[streamallocator.AllocationInfo](ctx, c.client, "GetSubscriberAllocation", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) GetCongestionTrace(ctx context.Context, participant rpc.ParticipantTopic, req *GetCongestionTraceRequest, opts ...psrpc.RequestOption) (*streamallocator.CongestionTrace, error) {
+	return requestJSONValue[streamallocator.CongestionTrace](ctx, c.client, "GetCongestionTrace", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) StartPacketCapture(ctx context.Context, participant rpc.ParticipantTopic, req *StartPacketCaptureRequest, opts ...psrpc.RequestOption) (*PacketCaptureInfo, error) {
+	return requestJSONValue[PacketCaptureInfo](ctx, c.client, "StartPacketCapture", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) StopPacketCapture(ctx context.Context, participant rpc.ParticipantTopic, req *StopPacketCaptureRequest, opts ...psrpc.RequestOption) (*PacketCaptureInfo, error) {
+	return requestJSONValue[PacketCaptureInfo](ctx, c.client, "StopPacketCapture", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) GetTransportStats(ctx context.Context, participant rpc.ParticipantTopic, req *GetTransportStatsRequest, opts ...psrpc.RequestOption) (*types.TransportStats, error) {
+	return requestJSONValue[types.TransportStats](ctx, c.client, "GetTransportStats", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) GetICEDiagnostics(ctx context.Context, participant rpc.ParticipantTopic, req *GetICEDiagnosticsRequest, opts ...psrpc.RequestOption) (*ICEDiagnosticsResponse, error) {
+	return requestJSONValue[ICEDiagnosticsResponse](ctx, c.client, "GetICEDiagnostics", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) GetConnectionQualityDetails(ctx context.Context, participant rpc.ParticipantTopic, req *GetConnectionQualityDetailsRequest, opts ...psrpc.RequestOption) (*types.ConnectionQualityDetails, error) {
+	return requestJSONValue[types.ConnectionQualityDetails](ctx, c.client, "GetConnectionQualityDetails", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) GetTrackStatsHistory(ctx context.Context, participant rpc.ParticipantTopic, req *GetTrackStatsHistoryRequest, opts ...psrpc.RequestOption) (*TrackStatsHistoryResponse, error) {
+	return requestJSONValue[TrackStatsHistoryResponse](ctx, c.client, "GetTrackStatsHistory", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) GetAVSyncStats(ctx context.Context, participant rpc.ParticipantTopic, req *GetAVSyncStatsRequest, opts ...psrpc.RequestOption) (*types.AVSyncDetails, error) {
+	return requestJSONValue[types.AVSyncDetails](ctx, c.client, "GetAVSyncStats", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) PatchParticipantMetadata(ctx context.Context, participant rpc.ParticipantTopic, req *PatchParticipantMetadataRequest, opts ...psrpc.RequestOption) (*MetadataPatchResponse, error) {
+	return requestJSONValue[MetadataPatchResponse](ctx, c.client, "PatchParticipantMetadata", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) BanParticipant(ctx context.Context, participant rpc.ParticipantTopic, req *BanParticipantRequest, opts ...psrpc.RequestOption) (*ParticipantBan, error) {
+	return requestJSONValue[ParticipantBan](ctx, c.client, "BanParticipant", string(participant), req, opts...)
+}
+
+func (c *participantExtClient) RedirectParticipant(ctx context.Context, participant rpc.ParticipantTopic, req *RedirectParticipantRequest, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error) {
+	return requestJSON[*livekit.ParticipantInfo](ctx, c.client, "RedirectParticipant", string(participant), req, opts...)
+}
+
+type participantExtServer struct {
+	svc ParticipantExtServerImpl
+	rpc *server.RPCServer
+}
+
+func NewParticipantExtServer(svc ParticipantExtServerImpl, bus psrpc.MessageBus, opts ...psrpc.ServerOption) (ParticipantExtServer, error) {
+	return &participantExtServer{
+		svc: svc,
+		rpc: server.NewRPCServer(participantExtServiceDefinition(rand.NewServerID()), bus, opts...),
+	}, nil
+}
+
+func (s *participantExtServer) allParticipantTopicRegisterers() server.RegistererSlice {
+	return server.RegistererSlice{
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "AdmitParticipant", []string{string(participant)}, s.svc.AdmitParticipant, nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("AdmitParticipant", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "MoveParticipant", []string{string(participant)}, handleJSON(s.svc.MoveParticipant), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("MoveParticipant", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "GetSubscriberAllocation", []string{string(participant)}, handleJSONValue(s.svc.GetSubscriberAllocation), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("GetSubscriberAllocation", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "GetCongestionTrace", []string{string(participant)}, handleJSONValue(s.svc.GetCongestionTrace), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("GetCongestionTrace", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "StartPacketCapture", []string{string(participant)}, handleJSONValue(s.svc.StartPacketCapture), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("StartPacketCapture", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "StopPacketCapture", []string{string(participant)}, handleJSONValue(s.svc.StopPacketCapture), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("StopPacketCapture", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "GetTransportStats", []string{string(participant)}, handleJSONValue(s.svc.GetTransportStats), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("GetTransportStats", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "GetICEDiagnostics", []string{string(participant)}, handleJSONValue(s.svc.GetICEDiagnostics), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("GetICEDiagnostics", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "GetConnectionQualityDetails", []string{string(participant)}, handleJSONValue(s.svc.GetConnectionQualityDetails), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("GetConnectionQualityDetails", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "GetTrackStatsHistory", []string{string(participant)}, handleJSONValue(s.svc.GetTrackStatsHistory), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("GetTrackStatsHistory", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "GetAVSyncStats", []string{string(participant)}, handleJSONValue(s.svc.GetAVSyncStats), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("GetAVSyncStats", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "PatchParticipantMetadata", []string{string(participant)}, handleJSONValue(s.svc.PatchParticipantMetadata), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("PatchParticipantMetadata", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "BanParticipant", []string{string(participant)}, handleJSONValue(s.svc.BanParticipant), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("BanParticipant", []string{string(participant)})
+		}),
+		server.NewRegisterer(func(participant rpc.ParticipantTopic) error {
+			return server.RegisterHandler(s.rpc, "RedirectParticipant", []string{string(participant)}, handleJSON(s.svc.RedirectParticipant), nil)
+		}, func(participant rpc.ParticipantTopic) {
+			s.rpc.DeregisterHandler("RedirectParticipant", []string{string(participant)})
+		}),
+	}
+}
+
+func (s *participantExtServer) RegisterAllParticipantTopics(participant rpc.ParticipantTopic) error {
+	return s.allParticipantTopicRegisterers().Register(participant)
+}
+
+func (s *participantExtServer) DeregisterAllParticipantTopics(participant rpc.ParticipantTopic) {
+	s.allParticipantTopicRegisterers().Deregister(participant)
+}
+
+func (s *participantExtServer) Shutdown() {
+	s.rpc.Close(false)
+}
+
+func (s *participantExtServer) Kill() {
+	s.rpc.Close(true)
+}
+
+//counterfeiter:generate . RoomExtClient
+type RoomExtClient interface {
+	MuteAllParticipants(ctx context.Context, room rpc.RoomTopic, req *MuteAllParticipantsRequest, opts ...psrpc.RequestOption) (*livekit.ListParticipantsResponse, error)
+	MuteTracksBySource(ctx context.Context, room rpc.RoomTopic, req *MuteTracksBySourceRequest, opts ...psrpc.RequestOption) (*livekit.ListParticipantsResponse, error)
+	LockRoom(ctx context.Context, room rpc.RoomTopic, req *LockRoomRequest, opts ...psrpc.RequestOption) (*livekit.Room, error)
+	UpdateParticipantsPermission(ctx context.Context, room rpc.RoomTopic, req *UpdateParticipantsPermissionRequest, opts ...psrpc.RequestOption) (*livekit.ListParticipantsResponse, error)
+	SetPushToTalk(ctx context.Context, room rpc.RoomTopic, req *SetPushToTalkRequest, opts ...psrpc.RequestOption) (*rtc.FloorState, error)
+	GrantFloor(ctx context.Context, room rpc.RoomTopic, req *GrantFloorRequest, opts ...psrpc.RequestOption) (*rtc.FloorState, error)
+	ReleaseFloor(ctx context.Context, room rpc.RoomTopic, req *ReleaseFloorRequest, opts ...psrpc.RequestOption) (*rtc.FloorState, error)
+	GetRoomDebugInfo(ctx context.Context, room rpc.RoomTopic, req *GetRoomDebugInfoRequest, opts ...psrpc.RequestOption) (*RoomDebugInfo, error)
+	RecordSpeakerMarkers(ctx context.Context, room rpc.RoomTopic, req *RecordSpeakerMarkersRequest, opts ...psrpc.RequestOption) (*SpeakerMarkersResponse, error)
+	GetSpeakerMarkers(ctx context.Context, room rpc.RoomTopic, req *GetSpeakerMarkersRequest, opts ...psrpc.RequestOption) (*SpeakerMarkersResponse, error)
+	GetTrackSubscriberCounts(ctx context.Context, room rpc.RoomTopic, req *GetTrackSubscriberCountsRequest, opts ...psrpc.RequestOption) (*TrackSubscriberCounts, error)
+	PatchRoomMetadata(ctx context.Context, room rpc.RoomTopic, req *PatchRoomMetadataRequest, opts ...psrpc.RequestOption) (*MetadataPatchResponse, error)
+	MergeRooms(ctx context.Context, room rpc.RoomTopic, req *MergeRoomsRequest, opts ...psrpc.RequestOption) (*MergeRoomsResponse, error)
+	RedirectRoom(ctx context.Context, room rpc.RoomTopic, req *RedirectRoomRequest, opts ...psrpc.RequestOption) (*RedirectRoomResponse, error)
+}
+
+type RoomExtServerImpl interface {
+	MuteAllParticipants(ctx context.Context, req *MuteAllParticipantsRequest) (*livekit.ListParticipantsResponse, error)
+	MuteTracksBySource(ctx context.Context, req *MuteTracksBySourceRequest) (*livekit.ListParticipantsResponse, error)
+	LockRoom(ctx context.Context, req *LockRoomRequest) (*livekit.Room, error)
+	UpdateParticipantsPermission(ctx context.Context, req *UpdateParticipantsPermissionRequest) (*livekit.ListParticipantsResponse, error)
+	SetPushToTalk(ctx context.Context, req *SetPushToTalkRequest) (*rtc.FloorState, error)
+	GrantFloor(ctx context.Context, req *GrantFloorRequest) (*rtc.FloorState, error)
+	ReleaseFloor(ctx context.Context, req *ReleaseFloorRequest) (*rtc.FloorState, error)
+	GetRoomDebugInfo(ctx context.Context, req *GetRoomDebugInfoRequest) (*RoomDebugInfo, error)
+	RecordSpeakerMarkers(ctx context.Context, req *RecordSpeakerMarkersRequest) (*SpeakerMarkersResponse, error)
+	GetSpeakerMarkers(ctx context.Context, req *GetSpeakerMarkersRequest) (*SpeakerMarkersResponse, error)
+	GetTrackSubscriberCounts(ctx context.Context, req *GetTrackSubscriberCountsRequest) (*TrackSubscriberCounts, error)
+	PatchRoomMetadata(ctx context.Context, req *PatchRoomMetadataRequest) (*MetadataPatchResponse, error)
+	MergeRooms(ctx context.Context, req *MergeRoomsRequest) (*MergeRoomsResponse, error)
+	RedirectRoom(ctx context.Context, req *RedirectRoomRequest) (*RedirectRoomResponse, error)
+}
+
+type RoomExtServer interface {
+	RegisterAllRoomTopics(room rpc.RoomTopic) error
+	DeregisterAllRoomTopics(room rpc.RoomTopic)
+
+	// Close and wait for pending RPCs to complete
+	Shutdown()
+
+	// Close immediately, without waiting for pending RPCs
+	Kill()
+}
+
+func roomExtServiceDefinition(id string) *info.ServiceDefinition {
+	sd := &info.ServiceDefinition{
+		Name: roomExtService,
+		ID:   id,
+	}
+	sd.RegisterMethod("MuteAllParticipants", false, false, true, true)
+	sd.RegisterMethod("MuteTracksBySource", false, false, true, true)
+	sd.RegisterMethod("LockRoom", false, false, true, true)
+	sd.RegisterMethod("UpdateParticipantsPermission", false, false, true, true)
+	sd.RegisterMethod("SetPushToTalk", false, false, true, true)
+	sd.RegisterMethod("GrantFloor", false, false, true, true)
+	sd.RegisterMethod("ReleaseFloor", false, false, true, true)
+	sd.RegisterMethod("GetRoomDebugInfo", false, false, true, true)
+	sd.RegisterMethod("RecordSpeakerMarkers", false, false, true, true)
+	sd.RegisterMethod("GetSpeakerMarkers", false, false, true, true)
+	sd.RegisterMethod("GetTrackSubscriberCounts", false, false, true, true)
+	sd.RegisterMethod("PatchRoomMetadata", false, false, true, true)
+	sd.RegisterMethod("MergeRooms", false, false, true, true)
+	sd.RegisterMethod("RedirectRoom", false, false, true, true)
+	return sd
+}
+
+type roomExtClient struct {
+	client *client.RPCClient
+}
+
+func NewRoomExtClient(params rpc.ClientParams) (RoomExtClient, error) {
+	rpcClient, err := client.NewRPCClient(roomExtServiceDefinition(rand.NewClientID()), params.Bus, extClientOptions(params)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &roomExtClient{
+		client: rpcClient,
+	}, nil
+}
+
+func (c *roomExtClient) MuteAllParticipants(ctx context.Context, room rpc.RoomTopic, req *MuteAllParticipantsRequest, opts ...psrpc.RequestOption) (*livekit.ListParticipantsResponse, error) {
+	return requestJSON[*livekit.ListParticipantsResponse](ctx, c.client, "MuteAllParticipants", string(room), req, opts...)
+}
+
+func (c *roomExtClient) MuteTracksBySource(ctx context.Context, room rpc.RoomTopic, req *MuteTracksBySourceRequest, opts ...psrpc.RequestOption) (*livekit.ListParticipantsResponse, error) {
+	return requestJSON[*livekit.ListParticipantsResponse](ctx, c.client, "MuteTracksBySource", string(room), req, opts...)
+}
+
+func (c *roomExtClient) LockRoom(ctx context.Context, room rpc.RoomTopic, req *LockRoomRequest, opts ...psrpc.RequestOption) (*livekit.Room, error) {
+	return requestJSON[*livekit.Room](ctx, c.client, "LockRoom", string(room), req, opts...)
+}
+
+func (c *roomExtClient) UpdateParticipantsPermission(ctx context.Context, room rpc.RoomTopic, req *UpdateParticipantsPermissionRequest, opts ...psrpc.RequestOption) (*livekit.ListParticipantsResponse, error) {
+	return requestJSON[*livekit.ListParticipantsResponse](ctx, c.client, "UpdateParticipantsPermission", string(room), req, opts...)
+}
+
+func (c *roomExtClient) SetPushToTalk(ctx context.Context, room rpc.RoomTopic, req *SetPushToTalkRequest, opts ...psrpc.RequestOption) (*rtc.FloorState, error) {
+	return requestJSONValue[rtc.FloorState](ctx, c.client, "SetPushToTalk", string(room), req, opts...)
+}
+
+func (c *roomExtClient) GrantFloor(ctx context.Context, room rpc.RoomTopic, req *GrantFloorRequest, opts ...psrpc.RequestOption) (*rtc.FloorState, error) {
+	return requestJSONValue[rtc.FloorState](ctx, c.client, "GrantFloor", string(room), req, opts...)
+}
+
+func (c *roomExtClient) ReleaseFloor(ctx context.Context, room rpc.RoomTopic, req *ReleaseFloorRequest, opts ...psrpc.RequestOption) (*rtc.FloorState, error) {
+	return requestJSONValue[rtc.FloorState](ctx, c.client, "ReleaseFloor", string(room), req, opts...)
+}
+
+func (c *roomExtClient) GetRoomDebugInfo(ctx context.Context, room rpc.RoomTopic, req *GetRoomDebugInfoRequest, opts ...psrpc.RequestOption) (*RoomDebugInfo, error) {
+	return requestJSONValue[RoomDebugInfo](ctx, c.client, "GetRoomDebugInfo", string(room), req, opts...)
+}
+
+func (c *roomExtClient) RecordSpeakerMarkers(ctx context.Context, room rpc.RoomTopic, req *RecordSpeakerMarkersRequest, opts ...psrpc.RequestOption) (*SpeakerMarkersResponse, error) {
+	return requestJSONValue[SpeakerMarkersResponse](ctx, c.client, "RecordSpeakerMarkers", string(room), req, opts...)
+}
+
+func (c *roomExtClient) GetSpeakerMarkers(ctx context.Context, room rpc.RoomTopic, req *GetSpeakerMarkersRequest, opts ...psrpc.RequestOption) (*SpeakerMarkersResponse, error) {
+	return requestJSONValue[SpeakerMarkersResponse](ctx, c.client, "GetSpeakerMarkers", string(room), req, opts...)
+}
+
+func (c *roomExtClient) GetTrackSubscriberCounts(ctx context.Context, room rpc.RoomTopic, req *GetTrackSubscriberCountsRequest, opts ...psrpc.RequestOption) (*TrackSubscriberCounts, error) {
+	return requestJSONValue[TrackSubscriberCounts](ctx, c.client, "GetTrackSubscriberCounts", string(room), req, opts...)
+}
+
+func (c *roomExtClient) PatchRoomMetadata(ctx context.Context, room rpc.RoomTopic, req *PatchRoomMetadataRequest, opts ...psrpc.RequestOption) (*MetadataPatchResponse, error) {
+	return requestJSONValue[MetadataPatchResponse](ctx, c.client, "PatchRoomMetadata", string(room), req, opts...)
+}
+
+func (c *roomExtClient) MergeRooms(ctx context.Context, room rpc.RoomTopic, req *MergeRoomsRequest, opts ...psrpc.RequestOption) (*MergeRoomsResponse, error) {
+	return requestJSONValue[MergeRoomsResponse](ctx, c.client, "MergeRooms", string(room), req, opts...)
+}
+
+func (c *roomExtClient) RedirectRoom(ctx context.Context, room rpc.RoomTopic, req *RedirectRoomRequest, opts ...psrpc.RequestOption) (*RedirectRoomResponse, error) {
+	return requestJSONValue[RedirectRoomResponse](ctx, c.client, "RedirectRoom", string(room), req, opts...)
+}
+
+type roomExtServer struct {
+	svc RoomExtServerImpl
+	rpc *server.RPCServer
+}
+
+func NewRoomExtServer(svc RoomExtServerImpl, bus psrpc.MessageBus, opts ...psrpc.ServerOption) (RoomExtServer, error) {
+	return &roomExtServer{
+		svc: svc,
+		rpc: server.NewRPCServer(roomExtServiceDefinition(rand.NewServerID()), bus, opts...),
+	}, nil
+}
+
+func (s *roomExtServer) allRoomTopicRegisterers() server.RegistererSlice {
+	return server.RegistererSlice{
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "MuteAllParticipants", []string{string(room)}, handleJSON(s.svc.MuteAllParticipants), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("MuteAllParticipants", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "MuteTracksBySource", []string{string(room)}, handleJSON(s.svc.MuteTracksBySource), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("MuteTracksBySource", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "LockRoom", []string{string(room)}, handleJSON(s.svc.LockRoom), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("LockRoom", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "UpdateParticipantsPermission", []string{string(room)}, handleJSON(s.svc.UpdateParticipantsPermission), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("UpdateParticipantsPermission", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "SetPushToTalk", []string{string(room)}, handleJSONValue(s.svc.SetPushToTalk), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("SetPushToTalk", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "GrantFloor", []string{string(room)}, handleJSONValue(s.svc.GrantFloor), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("GrantFloor", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "ReleaseFloor", []string{string(room)}, handleJSONValue(s.svc.ReleaseFloor), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("ReleaseFloor", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "GetRoomDebugInfo", []string{string(room)}, handleJSONValue(s.svc.GetRoomDebugInfo), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("GetRoomDebugInfo", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "RecordSpeakerMarkers", []string{string(room)}, handleJSONValue(s.svc.RecordSpeakerMarkers), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("RecordSpeakerMarkers", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "GetSpeakerMarkers", []string{string(room)}, handleJSONValue(s.svc.GetSpeakerMarkers), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("GetSpeakerMarkers", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "GetTrackSubscriberCounts", []string{string(room)}, handleJSONValue(s.svc.GetTrackSubscriberCounts), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("GetTrackSubscriberCounts", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "PatchRoomMetadata", []string{string(room)}, handleJSONValue(s.svc.PatchRoomMetadata), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("PatchRoomMetadata", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "MergeRooms", []string{string(room)}, handleJSONValue(s.svc.MergeRooms), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("MergeRooms", []string{string(room)})
+		}),
+		server.NewRegisterer(func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s.rpc, "RedirectRoom", []string{string(room)}, handleJSONValue(s.svc.RedirectRoom), nil)
+		}, func(room rpc.RoomTopic) {
+			s.rpc.DeregisterHandler("RedirectRoom", []string{string(room)})
+		}),
+	}
+}
+
+func (s *roomExtServer) RegisterAllRoomTopics(room rpc.RoomTopic) error {
+	return s.allRoomTopicRegisterers().Register(room)
+}
+
+func (s *roomExtServer) DeregisterAllRoomTopics(room rpc.RoomTopic) {
+	s.allRoomTopicRegisterers().Deregister(room)
+}
+
+func (s *roomExtServer) Shutdown() {
+	s.rpc.Close(false)
+}
+
+func (s *roomExtServer) Kill() {
+	s.rpc.Close(true)
+}
+
+//counterfeiter:generate . NodeExtClient
+type NodeExtClient interface {
+	// GetNodeConcurrency is answered by every node, responses are delivered until the request times out
+	GetNodeConcurrency(ctx context.Context, req *GetNodeConcurrencyRequest, opts ...psrpc.RequestOption) (<-chan *JSONResponse[NodeConcurrency], error)
+	DrainNode(ctx context.Context, nodeID livekit.NodeID, req *DrainNodeRequest, opts ...psrpc.RequestOption) (*DrainStatus, error)
+	GetDrainStatus(ctx context.Context, nodeID livekit.NodeID, req *GetDrainStatusRequest, opts ...psrpc.RequestOption) (*DrainStatus, error)
+}
+
+type NodeExtServerImpl interface {
+	GetNodeConcurrency(ctx context.Context, req *GetNodeConcurrencyRequest) (*NodeConcurrency, error)
+	DrainNode(ctx context.Context, req *DrainNodeRequest) (*DrainStatus, error)
+	GetDrainStatus(ctx context.Context, req *GetDrainStatusRequest) (*DrainStatus, error)
+}
+
+type NodeExtServer interface {
+	RegisterAllNodeTopics(nodeID livekit.NodeID) error
+	DeregisterAllNodeTopics(nodeID livekit.NodeID)
+
+	// Close and wait for pending RPCs to complete
+	Shutdown()
+
+	// Close immediately, without waiting for pending RPCs
+	Kill()
+}
+
+func nodeExtServiceDefinition(id string) *info.ServiceDefinition {
+	sd := &info.ServiceDefinition{
+		Name: nodeExtService,
+		ID:   id,
+	}
+	sd.RegisterMethod("GetNodeConcurrency", false, true, false, false)
+	sd.RegisterMethod("DrainNode", false, false, true, true)
+	sd.RegisterMethod("GetDrainStatus", false, false, true, true)
+	return sd
+}
+
+type nodeExtClient struct {
+	client *client.RPCClient
+}
+
+func NewNodeExtClient(params rpc.ClientParams) (NodeExtClient, error) {
+	rpcClient, err := client.NewRPCClient(nodeExtServiceDefinition(rand.NewClientID()), params.Bus, extClientOptions(params)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodeExtClient{
+		client: rpcClient,
+	}, nil
+}
+
+func (c *nodeExtClient) GetNodeConcurrency(ctx context.Context, req *GetNodeConcurrencyRequest, opts ...psrpc.RequestOption) (<-chan *JSONResponse[NodeConcurrency], error) {
+	return requestJSONMulti[NodeConcurrency](ctx, c.client, "GetNodeConcurrency", req, opts...)
+}
+
+func (c *nodeExtClient) DrainNode(ctx context.Context, nodeID livekit.NodeID, req *DrainNodeRequest, opts ...psrpc.RequestOption) (*DrainStatus, error) {
+	return requestJSONValue[DrainStatus](ctx, c.client, "DrainNode", string(nodeID), req, opts...)
+}
+
+func (c *nodeExtClient) GetDrainStatus(ctx context.Context, nodeID livekit.NodeID, req *GetDrainStatusRequest, opts ...psrpc.RequestOption) (*DrainStatus, error) {
+	return requestJSONValue[DrainStatus](ctx, c.client, "GetDrainStatus", string(nodeID), req, opts...)
+}
+
+type nodeExtServer struct {
+	svc NodeExtServerImpl
+	rpc *server.RPCServer
+}
+
+func NewNodeExtServer(svc NodeExtServerImpl, bus psrpc.MessageBus, opts ...psrpc.ServerOption) (NodeExtServer, error) {
+	s := server.NewRPCServer(nodeExtServiceDefinition(rand.NewServerID()), bus, opts...)
+	if err := server.RegisterHandler(s, "GetNodeConcurrency", nil, handleJSONValue(svc.GetNodeConcurrency), nil); err != nil {
+		s.Close(false)
+		return nil, err
+	}
+
+	return &nodeExtServer{
+		svc: svc,
+		rpc: s,
+	}, nil
+}
+
+func (s *nodeExtServer) allNodeTopicRegisterers() server.RegistererSlice {
+	return server.RegistererSlice{
+		server.NewRegisterer(func(nodeID livekit.NodeID) error {
+			return server.RegisterHandler(s.rpc, "DrainNode", []string{string(nodeID)}, handleJSONValue(s.svc.DrainNode), nil)
+		}, func(nodeID livekit.NodeID) {
+			s.rpc.DeregisterHandler("DrainNode", []string{string(nodeID)})
+		}),
+		server.NewRegisterer(func(nodeID livekit.NodeID) error {
+			return server.RegisterHandler(s.rpc, "GetDrainStatus", []string{string(nodeID)}, handleJSONValue(s.svc.GetDrainStatus), nil)
+		}, func(nodeID livekit.NodeID) {
+			s.rpc.DeregisterHandler("GetDrainStatus", []string{string(nodeID)})
+		}),
+	}
+}
+
+func (s *nodeExtServer) RegisterAllNodeTopics(nodeID livekit.NodeID) error {
+	return s.allNodeTopicRegisterers().Register(nodeID)
+}
+
+func (s *nodeExtServer) DeregisterAllNodeTopics(nodeID livekit.NodeID) {
+	s.allNodeTopicRegisterers().Deregister(nodeID)
+}
+
+func (s *nodeExtServer) Shutdown() {
+	s.rpc.Close(false)
+}
+
+func (s *nodeExtServer) Kill() {
+	s.rpc.Close(true)
+}
+
+//counterfeiter:generate . RoomEventsClient
+type RoomEventsClient interface {
+	// SubscribeRoomEvent receives the room events published by every node
+	SubscribeRoomEvent(ctx context.Context) (psrpc.Subscription[*livekit.WebhookEvent], error)
+}
+
+type RoomEventsServer interface {
+	PublishRoomEvent(ctx context.Context, event *livekit.WebhookEvent) error
+
+	// Close and wait for pending RPCs to complete
+	Shutdown()
+
+	// Close immediately, without waiting for pending RPCs
+	Kill()
+}
+
+func roomEventsServiceDefinition(id string) *info.ServiceDefinition {
+	sd := &info.ServiceDefinition{
+		Name: roomEventsService,
+		ID:   id,
+	}
+	sd.RegisterMethod("RoomEvent", false, true, false, false)
+	return sd
+}
+
+type roomEventsClient struct {
+	client *client.RPCClient
+}
+
+func NewRoomEventsClient(params rpc.ClientParams) (RoomEventsClient, error) {
+	rpcClient, err := client.NewRPCClient(roomEventsServiceDefinition(rand.NewClientID()), params.Bus, extClientOptions(params)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &roomEventsClient{
+		client: rpcClient,
+	}, nil
+}
+
+func (c *roomEventsClient) SubscribeRoomEvent(ctx context.Context) (psrpc.Subscription[*livekit.WebhookEvent], error) {
+	return client.Join[*livekit.WebhookEvent](ctx, c.client, "RoomEvent", nil)
+}
+
+type roomEventsServer struct {
+	rpc *server.RPCServer
+}
+
+func NewRoomEventsServer(bus psrpc.MessageBus, opts ...psrpc.ServerOption) (RoomEventsServer, error) {
+	return &roomEventsServer{
+		rpc: server.NewRPCServer(roomEventsServiceDefinition(rand.NewServerID()), bus, opts...),
+	}, nil
+}
+
+func (s *roomEventsServer) PublishRoomEvent(ctx context.Context, event *livekit.WebhookEvent) error {
+	return s.rpc.Publish(ctx, "RoomEvent", nil, event)
+}
+
+func (s *roomEventsServer) Shutdown() {
+	s.rpc.Close(false)
+}
+
+func (s *roomEventsServer) Kill() {
+	s.rpc.Close(true)
+}
+
+func requestJSON[ResponseType proto.Message](ctx context.Context, c *client.RPCClient, method string, topic string, req any, opts ...psrpc.RequestOption) (ResponseType, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		var res ResponseType
+		return res, psrpc.NewError(psrpc.MalformedRequest, err)
+	}
+	return client.RequestSingle[ResponseType](ctx, c, method, []string{topic}, wrapperspb.Bytes(data), opts...)
+}
+
+func handleJSON[RequestType any, ResponseType proto.Message](
+	handler func(ctx context.Context, req *RequestType) (ResponseType, error),
+) func(ctx context.Context, req *wrapperspb.BytesValue) (ResponseType, error) {
+	return func(ctx context.Context, req *wrapperspb.BytesValue) (ResponseType, error) {
+		r := new(RequestType)
+		if err := json.Unmarshal(req.GetValue(), r); err != nil {
+			var res ResponseType
+			return res, psrpc.NewError(psrpc.MalformedRequest, err)
+		}
+		return handler(ctx, r)
+	}
+}
+
+// mirrors the client options applied to the typed clients in the rpc package
+func extClientOptions(params rpc.ClientParams) []psrpc.ClientOption {
+	opts := make([]psrpc.ClientOption, 0, 4)
+	if params.BufferSize != 0 {
+		opts = append(opts, psrpc.WithClientChannelSize(params.BufferSize))
+	}
+	if params.Observer != nil {
+		opts = append(opts, middleware.WithClientMetrics(params.Observer))
+	}
+	if params.Logger != nil {
+		opts = append(opts, rpc.WithClientLogger(params.Logger))
+	}
+	if params.MaxAttempts != 0 || params.Timeout != 0 || params.Backoff != 0 {
+		opts = append(opts, middleware.WithRPCRetries(middleware.RetryOptions{
+			MaxAttempts: params.MaxAttempts,
+			Timeout:     params.Timeout,
+			Backoff:     params.Backoff,
+		}))
+	}
+	return opts
+}
+
+// requestJSONValue is requestJSON for responses that are not protobuf messages, they are encoded as json as well
+func requestJSONValue[ResponseType any](ctx context.Context, c *client.RPCClient, method string, topic string, req any, opts ...psrpc.RequestOption) (*ResponseType, error) {
+	res, err := requestJSON[*wrapperspb.BytesValue](ctx, c, method, topic, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	v := new(ResponseType)
+	if err := json.Unmarshal(res.GetValue(), v); err != nil {
+		return nil, psrpc.NewError(psrpc.MalformedResponse, err)
+	}
+	return v, nil
+}
+
+func handleJSONValue[RequestType any, ResponseType any](
+	handler func(ctx context.Context, req *RequestType) (*ResponseType, error),
+) func(ctx context.Context, req *wrapperspb.BytesValue) (*wrapperspb.BytesValue, error) {
+	return handleJSON(func(ctx context.Context, req *RequestType) (*wrapperspb.BytesValue, error) {
+		res, err := handler(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(res)
+		if err != nil {
+			return nil, psrpc.NewError(psrpc.Internal, err)
+		}
+		return wrapperspb.Bytes(data), nil
+	})
+}
+
+// JSONResponse is a response of a multi-node request, with the json encoded result decoded
+type JSONResponse[ResponseType any] struct {
+	Result *ResponseType
+	Err    error
+}
+
+// requestJSONMulti is requestJSONValue for requests answered by every server. The responses are drained
+// until the request times out even when the caller stops reading early, the caller cancels ctx for that.
+func requestJSONMulti[ResponseType any](ctx context.Context, c *client.RPCClient, method string, req any, opts ...psrpc.RequestOption) (<-chan *JSONResponse[ResponseType], error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, psrpc.NewError(psrpc.MalformedRequest, err)
+	}
+	resChan, err := client.RequestMulti[*wrapperspb.BytesValue](ctx, c, method, nil, wrapperspb.Bytes(data), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := make(chan *JSONResponse[ResponseType], cap(resChan))
+	go func() {
+		defer close(decoded)
+		for res := range resChan {
+			r := &JSONResponse[ResponseType]{Err: res.Err}
+			if r.Err == nil {
+				r.Result = new(ResponseType)
+				if err := json.Unmarshal(res.Result.GetValue(), r.Result); err != nil {
+					r.Result, r.Err = nil, psrpc.NewError(psrpc.MalformedResponse, err)
+				}
+			}
+			select {
+			case decoded <- r:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return decoded, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// GeoIPProvider looks up the countries of client IPs for join restrictions. Another provider, e.g. a GeoIP service,
+// can be wired in place of the database of the config
+type GeoIPProvider interface {
+	// Country returns the ISO 3166-1 alpha-2 code of the country of ip, empty when it is not known
+	Country(ip net.IP) (string, error)
+}
+
+// NewGeoIPProvider loads the database of the config, it returns nil when there is none
+func NewGeoIPProvider(conf *config.Config) (GeoIPProvider, error) {
+	if conf.GeoIP.Database == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(conf.GeoIP.Database)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	db, err := NewGeoIPDatabase(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not load GeoIP database %s: %w", conf.GeoIP.Database, err)
+	}
+	logger.Infow("loaded GeoIP database", "path", conf.GeoIP.Database, "networks", len(db.networks))
+	return db, nil
+}
+
+// clientRegion returns the region listing the country of the client IP, empty when the country is not known or no
+// region lists it
+func clientRegion(geoIP GeoIPProvider, regions []config.RegionConfig, clientInfo *livekit.ClientInfo) string {
+	ip := parseClientIP(clientInfo.GetAddress())
+	if ip == nil || geoIP == nil {
+		return ""
+	}
+	country, err := geoIP.Country(ip)
+	if err != nil {
+		logger.Warnw("could not look up country of client", err, "clientIP", ip.String())
+		return ""
+	}
+	for _, region := range regions {
+		if containsCountry(region.Countries, country) {
+			return region.Name
+		}
+	}
+	return ""
+}
+
+type geoIPNetwork struct {
+	first   net.IP
+	last    net.IP
+	country string
+}
+
+// GeoIPDatabase is an in memory database of networks and their countries. Networks are not expected to overlap,
+// an IP in overlapping networks gets the country of either
+type GeoIPDatabase struct {
+	// sorted by first IP
+	networks []geoIPNetwork
+}
+
+// NewGeoIPDatabase reads network,country rows, e.g. 203.0.113.0/24,AU. A header row and rows without a country
+// are skipped.
+func NewGeoIPDatabase(r io.Reader) (*GeoIPDatabase, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	db := &GeoIPDatabase{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 || record[1] == "" {
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				// header
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		db.networks = append(db.networks, geoIPNetwork{
+			first:   network.IP.To16(),
+			last:    lastIP(network),
+			country: strings.ToUpper(strings.TrimSpace(record[1])),
+		})
+	}
+
+	sort.Slice(db.networks, func(i, j int) bool {
+		return bytes.Compare(db.networks[i].first, db.networks[j].first) < 0
+	})
+	return db, nil
+}
+
+func (d *GeoIPDatabase) Country(ip net.IP) (string, error) {
+	ip = ip.To16()
+	if ip == nil {
+		return "", nil
+	}
+
+	// the last network starting at or before ip
+	i := sort.Search(len(d.networks), func(i int) bool {
+		return bytes.Compare(d.networks[i].first, ip) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(ip, d.networks[i].last) > 0 {
+		return "", nil
+	}
+	return d.networks[i].country, nil
+}
+
+func lastIP(network *net.IPNet) net.IP {
+	ip := network.IP.To16()
+	mask := network.Mask
+	if len(mask) == net.IPv4len {
+		// the mask of the IPv4 part of the IPv4-mapped address
+		mask = append(net.CIDRMask(96, 128)[:12:12], mask...)
+	}
+
+	last := make(net.IP, net.IPv6len)
+	for i := range ip {
+		last[i] = ip[i] | ^mask[i]
+	}
+	return last
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/twitchtv/twirp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const roomServiceGRPCName = "livekit.RoomService"
+
+// the metadata key of gRPC requests holding the token, as the Authorization header of Twirp requests
+const grpcAuthorizationKey = "authorization"
+
+// NewRoomServiceGRPCServer serves roomService over gRPC, with the service and method names of the Twirp server.
+// Requests are authenticated by authMiddleware when it is not nil
+func NewRoomServiceGRPCServer(roomService livekit.RoomService, authMiddleware *APIKeyAuthMiddleware) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{grpcLoggingInterceptor}
+	if authMiddleware != nil {
+		interceptors = append(interceptors, authMiddleware.UnaryServerInterceptor)
+	}
+
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	s.RegisterService(&roomServiceGRPCDesc, roomService)
+	return s
+}
+
+var roomServiceGRPCDesc = grpc.ServiceDesc{
+	ServiceName: roomServiceGRPCName,
+	HandlerType: (*livekit.RoomService)(nil),
+	Methods: []grpc.MethodDesc{
+		grpcRoomServiceMethod("CreateRoom", livekit.RoomService.CreateRoom),
+		grpcRoomServiceMethod("ListRooms", livekit.RoomService.ListRooms),
+		grpcRoomServiceMethod("DeleteRoom", livekit.RoomService.DeleteRoom),
+		grpcRoomServiceMethod("ListParticipants", livekit.RoomService.ListParticipants),
+		grpcRoomServiceMethod("GetParticipant", livekit.RoomService.GetParticipant),
+		grpcRoomServiceMethod("RemoveParticipant", livekit.RoomService.RemoveParticipant),
+		grpcRoomServiceMethod("MutePublishedTrack", livekit.RoomService.MutePublishedTrack),
+		grpcRoomServiceMethod("UpdateParticipant", livekit.RoomService.UpdateParticipant),
+		grpcRoomServiceMethod("UpdateSubscriptions", livekit.RoomService.UpdateSubscriptions),
+		grpcRoomServiceMethod("SendData", livekit.RoomService.SendData),
+		grpcRoomServiceMethod("UpdateRoomMetadata", livekit.RoomService.UpdateRoomMetadata),
+	},
+	Metadata: "livekit_room.proto",
+}
+
+func grpcRoomServiceMethod[Req any, Res proto.Message, PReq interface {
+	*Req
+	proto.Message
+}](
+	name string,
+	call func(livekit.RoomService, context.Context, PReq) (Res, error),
+) grpc.MethodDesc {
+	fullMethod := "/" + roomServiceGRPCName + "/" + name
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			in := PReq(new(Req))
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+
+			handler := func(ctx context.Context, req any) (any, error) {
+				res, err := call(srv.(livekit.RoomService), ctx, req.(PReq))
+				if err != nil {
+					return nil, grpcError(err)
+				}
+				return res, nil
+			}
+			if interceptor == nil {
+				return handler(ctx, in)
+			}
+			return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}, handler)
+		},
+	}
+}
+
+// UnaryServerInterceptor authenticates gRPC requests as ServeHTTP does Twirp requests. Requests without a token
+// are passed on without grants
+func (m *APIKeyAuthMiddleware) UnaryServerInterceptor(
+	ctx context.Context,
+	req any,
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(grpcAuthorizationKey); len(values) != 0 {
+		if !strings.HasPrefix(values[0], bearerPrefix) {
+			return nil, status.Error(codes.Unauthenticated, ErrMissingAuthorization.Error())
+		}
+
+		grants, apiKey, err := verifyToken(ctx, m.provider, m.oidc, values[0][len(bearerPrefix):])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		ctx = WithAPIKey(WithGrants(ctx, grants), apiKey)
+	}
+
+	return handler(ctx, req)
+}
+
+func grpcLoggingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	r := &requestLogger{startedAt: time.Now()}
+	r.service, r.method, _ = strings.Cut(strings.TrimPrefix(info.FullMethod, "/"), "/")
+	r.fields = append(r.fields, "service", r.service, "method", r.method, "transport", "grpc")
+
+	res, err := handler(context.WithValue(ctx, twirpLoggerContext{}, r), req)
+
+	r.fields = append(r.fields, "duration", time.Since(r.startedAt), "code", status.Code(err))
+	if err != nil {
+		r.fields = append(r.fields, "error", status.Convert(err).Message())
+	}
+	utils.GetLogger(ctx).WithComponent(utils.ComponentAPI).Infow("API "+r.service+"."+r.method, r.fields...)
+	return res, err
+}
+
+// grpcError converts the errors of the Twirp handlers to a gRPC status, errors that are not Twirp errors
+// are internal errors as for Twirp clients
+func grpcError(err error) error {
+	var terr twirp.Error
+	if !errors.As(err, &terr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return status.Error(grpcCodes[terr.Code()], terr.Msg())
+}
+
+var grpcCodes = map[twirp.ErrorCode]codes.Code{
+	twirp.Canceled:           codes.Canceled,
+	twirp.Unknown:            codes.Unknown,
+	twirp.InvalidArgument:    codes.InvalidArgument,
+	twirp.Malformed:          codes.InvalidArgument,
+	twirp.DeadlineExceeded:   codes.DeadlineExceeded,
+	twirp.NotFound:           codes.NotFound,
+	twirp.BadRoute:           codes.Unimplemented,
+	twirp.AlreadyExists:      codes.AlreadyExists,
+	twirp.PermissionDenied:   codes.PermissionDenied,
+	twirp.Unauthenticated:    codes.Unauthenticated,
+	twirp.ResourceExhausted:  codes.ResourceExhausted,
+	twirp.FailedPrecondition: codes.FailedPrecondition,
+	twirp.Aborted:            codes.Aborted,
+	twirp.OutOfRange:         codes.OutOfRange,
+	twirp.Unimplemented:      codes.Unimplemented,
+	twirp.Internal:           codes.Internal,
+	twirp.Unavailable:        codes.Unavailable,
+	twirp.DataLoss:           codes.DataLoss,
+}
+
+// stopGRPCServer waits for pending requests to complete until ctx is done
+func stopGRPCServer(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
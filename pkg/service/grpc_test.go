@@ -0,0 +1,106 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/auth/authfakes"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+type listRoomsService struct {
+	livekit.RoomService
+}
+
+func (s *listRoomsService) ListRooms(ctx context.Context, req *livekit.ListRoomsRequest) (*livekit.ListRoomsResponse, error) {
+	if err := service.EnsureListPermission(ctx); err != nil {
+		return nil, twirp.NewError(twirp.PermissionDenied, err.Error())
+	}
+	res := &livekit.ListRoomsResponse{}
+	for _, name := range req.Names {
+		res.Rooms = append(res.Rooms, &livekit.Room{Name: name})
+	}
+	return res, nil
+}
+
+func TestRoomServiceGRPC(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	ln := bufconn.Listen(1 << 16)
+	s := service.NewRoomServiceGRPCServer(&listRoomsService{}, service.NewAPIKeyAuthMiddleware(provider, nil))
+	go s.Serve(ln)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	listRooms := func(ctx context.Context) (*livekit.ListRoomsResponse, error) {
+		res := &livekit.ListRoomsResponse{}
+		err := conn.Invoke(ctx, "/livekit.RoomService/ListRooms", &livekit.ListRoomsRequest{Names: []string{"room"}}, res)
+		return res, err
+	}
+
+	t.Run("token grants are checked by the handler", func(t *testing.T) {
+		token, err := auth.NewAccessToken(api, secret).AddGrant(&auth.VideoGrant{RoomList: true}).ToJWT()
+		require.NoError(t, err)
+
+		res, err := listRooms(metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token))
+		require.NoError(t, err)
+		require.Len(t, res.Rooms, 1)
+		require.Equal(t, "room", res.Rooms[0].Name)
+	})
+
+	t.Run("twirp errors are converted", func(t *testing.T) {
+		_, err := listRooms(context.Background())
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := listRooms(metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer invalid"))
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = listRooms(metadata.AppendToOutgoingContext(context.Background(), "authorization", "invalid"))
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("unimplemented methods", func(t *testing.T) {
+		err := conn.Invoke(context.Background(), "/livekit.RoomService/Unknown", &livekit.ListRoomsRequest{}, &livekit.ListRoomsResponse{})
+		require.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
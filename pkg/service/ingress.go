@@ -136,7 +136,7 @@ func (s *IngressService) CreateIngressWithUrl(ctx context.Context, urlStr string
 		if err != nil {
 			return nil, psrpc.NewError(psrpc.InvalidArgument, err)
 		}
-		if urlObj.Scheme != "http" && urlObj.Scheme != "https" && urlObj.Scheme != "srt" {
+		if urlObj.Scheme != "http" && urlObj.Scheme != "https" && urlObj.Scheme != "srt" && !isRTSPScheme(urlObj.Scheme) {
 			return nil, ingress.ErrInvalidIngress(fmt.Sprintf("invalid url scheme %s", urlObj.Scheme))
 		}
 		// Marshall the URL again for sanitization
@@ -172,17 +172,13 @@ func (s *IngressService) CreateIngressWithUrl(ctx context.Context, urlStr string
 			return nil, err
 		}
 	case livekit.IngressInput_URL_INPUT:
-		if err := ingress.Validate(info); err != nil {
+		if err := validatePullIngress(info); err != nil {
 			return nil, err
 		}
 	default:
 		return nil, ingress.ErrInvalidIngressType
 	}
 
-	if err := ingress.ValidateForSerialization(info); err != nil {
-		return nil, err
-	}
-
 	if req.InputType == livekit.IngressInput_URL_INPUT {
 		retInfo, err := s.launcher.LaunchPullIngress(ctx, info)
 		if retInfo != nil {
@@ -0,0 +1,204 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/ingress"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/rpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func isRTSPScheme(scheme string) bool {
+	return strings.EqualFold(scheme, "rtsp") || strings.EqualFold(scheme, "rtsps")
+}
+
+func isRTSPIngress(info *livekit.IngressInfo) bool {
+	if info.InputType != livekit.IngressInput_URL_INPUT {
+		return false
+	}
+	u, err := url.Parse(info.Url)
+	return err == nil && isRTSPScheme(u.Scheme)
+}
+
+// validatePullIngress validates a URL ingress. The protocol only allows bypassing transcoding for WHIP,
+// RTSP cameras commonly send H.264 and Opus or G.711 which can be forwarded as is, so RTSP ingresses may bypass it too.
+func validatePullIngress(info *livekit.IngressInfo) error {
+	if !info.BypassTranscoding || !isRTSPIngress(info) {
+		return ingress.Validate(info)
+	}
+
+	if (info.Video != nil && info.Video.EncodingOptions != nil) || (info.Audio != nil && info.Audio.EncodingOptions != nil) {
+		return ingress.NewInvalidTranscodingBypassError("encoding options must be empty if transcoding bypass is selected")
+	}
+	transcoded := proto.Clone(info).(*livekit.IngressInfo)
+	transcoded.BypassTranscoding = false
+	return ingress.Validate(transcoded)
+}
+
+type psrpcIngressLauncher struct {
+	client rpc.IngressClient
+}
+
+func (l psrpcIngressLauncher) LaunchPullIngress(ctx context.Context, info *livekit.IngressInfo) (*livekit.IngressInfo, error) {
+	return l.client.StartIngress(ctx, &rpc.StartIngressRequest{Info: info})
+}
+
+// RTSPIngressReconnector restarts RTSP ingresses when the ingress service reports that the stream ended or failed,
+// cameras drop their streams on network or power loss and are expected to come back. Restarts back off exponentially,
+// the attempts are reset once the ingress publishes again.
+type RTSPIngressReconnector struct {
+	conf     config.RTSPIngressConfig
+	store    IngressStore
+	launcher IngressLauncher
+
+	lock     sync.Mutex
+	attempts map[string]int
+	timers   map[string]*time.Timer
+	closed   bool
+}
+
+func NewRTSPIngressReconnector(conf *config.IngressConfig, client rpc.IngressClient, store IngressStore) *RTSPIngressReconnector {
+	if client == nil || store == nil {
+		return nil
+	}
+	return NewRTSPIngressReconnectorWithLauncher(conf.RTSP, store, psrpcIngressLauncher{client: client})
+}
+
+func NewRTSPIngressReconnectorWithLauncher(conf config.RTSPIngressConfig, store IngressStore, launcher IngressLauncher) *RTSPIngressReconnector {
+	if conf.DisableReconnect {
+		return nil
+	}
+	if conf.ReconnectInterval <= 0 {
+		conf.ReconnectInterval = config.DefaultConfig.Ingress.RTSP.ReconnectInterval
+	}
+	if conf.MaxReconnectInterval < conf.ReconnectInterval {
+		conf.MaxReconnectInterval = conf.ReconnectInterval
+	}
+	return &RTSPIngressReconnector{
+		conf:     conf,
+		store:    store,
+		launcher: launcher,
+		attempts: make(map[string]int),
+		timers:   make(map[string]*time.Timer),
+	}
+}
+
+// IngressStateUpdated is called with an ingress and its new state
+func (r *RTSPIngressReconnector) IngressStateUpdated(info *livekit.IngressInfo) {
+	if r == nil || info.State == nil || !isRTSPIngress(info) {
+		return
+	}
+
+	switch info.State.Status {
+	case livekit.IngressState_ENDPOINT_PUBLISHING:
+		r.lock.Lock()
+		delete(r.attempts, info.IngressId)
+		r.lock.Unlock()
+
+	case livekit.IngressState_ENDPOINT_ERROR,
+		livekit.IngressState_ENDPOINT_COMPLETE:
+		r.scheduleRestart(info.IngressId)
+	}
+}
+
+func (r *RTSPIngressReconnector) Stop() {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.closed = true
+	for _, t := range r.timers {
+		t.Stop()
+	}
+	r.timers = make(map[string]*time.Timer)
+}
+
+func (r *RTSPIngressReconnector) scheduleRestart(ingressID string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed || r.timers[ingressID] != nil {
+		return
+	}
+
+	attempt := r.attempts[ingressID]
+	if r.conf.MaxReconnectAttempts > 0 && attempt >= r.conf.MaxReconnectAttempts {
+		logger.Infow("giving up restarting rtsp ingress", "ingressID", ingressID, "attempts", attempt)
+		delete(r.attempts, ingressID)
+		return
+	}
+
+	delay := r.conf.ReconnectInterval
+	for i := 0; i < attempt && delay < r.conf.MaxReconnectInterval; i++ {
+		delay *= 2
+	}
+	if delay > r.conf.MaxReconnectInterval {
+		delay = r.conf.MaxReconnectInterval
+	}
+
+	r.attempts[ingressID] = attempt + 1
+	r.timers[ingressID] = time.AfterFunc(delay, func() {
+		r.restart(ingressID)
+	})
+}
+
+func (r *RTSPIngressReconnector) restart(ingressID string) {
+	r.lock.Lock()
+	delete(r.timers, ingressID)
+	closed := r.closed
+	r.lock.Unlock()
+	if closed {
+		return
+	}
+
+	ctx := context.Background()
+	info, err := r.store.LoadIngress(ctx, ingressID)
+	if err != nil {
+		if errors.Is(err, ErrIngressNotFound) {
+			r.lock.Lock()
+			delete(r.attempts, ingressID)
+			r.lock.Unlock()
+			return
+		}
+		logger.Warnw("could not load rtsp ingress", err, "ingressID", ingressID)
+		r.scheduleRestart(ingressID)
+		return
+	}
+
+	// restarted or updated in the meantime
+	if info.State != nil && info.State.Status != livekit.IngressState_ENDPOINT_ERROR && info.State.Status != livekit.IngressState_ENDPOINT_COMPLETE {
+		return
+	}
+
+	logger.Infow("restarting rtsp ingress", "ingressID", ingressID)
+	info.State = &livekit.IngressState{}
+	if _, err := r.launcher.LaunchPullIngress(ctx, info); err != nil {
+		logger.Warnw("could not restart rtsp ingress", err, "ingressID", ingressID)
+		r.scheduleRestart(ingressID)
+	}
+}
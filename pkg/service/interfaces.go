@@ -19,6 +19,9 @@ import (
 	"time"
 
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -28,6 +31,10 @@ import (
 //counterfeiter:generate . ObjectStore
 type ObjectStore interface {
 	ServiceStore
+	KeyUsageStore
+	AttachmentStore
+	ParticipantSessionStore
+	PasscodeAttemptStore
 
 	// enable locking on a specific room to prevent race
 	// returns a (lock uuid, error)
@@ -35,6 +42,7 @@ type ObjectStore interface {
 	UnlockRoom(ctx context.Context, roomName livekit.RoomName, uid string) error
 
 	StoreRoom(ctx context.Context, room *livekit.Room, internal *livekit.RoomInternal) error
+	StoreRoomOptions(ctx context.Context, roomName livekit.RoomName, options *rtc.RoomOptions) error
 
 	StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error
 	DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error
@@ -42,8 +50,14 @@ type ObjectStore interface {
 
 //counterfeiter:generate . ServiceStore
 type ServiceStore interface {
+	ParticipantBanStore
+
 	LoadRoom(ctx context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error)
 	DeleteRoom(ctx context.Context, roomName livekit.RoomName) error
+	// LoadRoomOptions returns empty options for rooms created without any
+	LoadRoomOptions(ctx context.Context, roomName livekit.RoomName) (*rtc.RoomOptions, error)
+	// ListRoomOptions returns the options of rooms created with any, keyed by room name
+	ListRoomOptions(ctx context.Context) (map[livekit.RoomName]*rtc.RoomOptions, error)
 
 	// ListRooms returns currently active rooms. if names is not nil, it'll filter and return
 	// only rooms that match
@@ -52,6 +66,65 @@ type ServiceStore interface {
 	ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error)
 }
 
+// usage of API keys that outlives their rooms
+type KeyUsageStore interface {
+	// StoreEgressAPIKey records the API key an egress was started with
+	StoreEgressAPIKey(ctx context.Context, egressID string, apiKey string) error
+	// LoadEgressAPIKey returns an empty key for egress started without one, or already accounted for
+	LoadEgressAPIKey(ctx context.Context, egressID string) (string, error)
+	// AddAPIKeyEgressUsage adds the duration of an ended egress to the usage of the key in period
+	// and forgets the key of the egress
+	AddAPIKeyEgressUsage(ctx context.Context, apiKey string, period string, egressID string, duration time.Duration) error
+	LoadAPIKeyEgressUsage(ctx context.Context, apiKey string, period string) (time.Duration, error)
+	// LoadAPIKeyRoomUsage returns the rooms owned by apiKey and the participants in them. They are counted as they
+	// are stored and deleted: a room once its options record the key as its owner, a participant when first stored
+	LoadAPIKeyRoomUsage(ctx context.Context, apiKey string) (rooms int32, participants int32, err error)
+}
+
+// attachments shared with rooms, removed with their room. The files are kept in object storage
+type AttachmentStore interface {
+	StoreAttachment(ctx context.Context, attachment *Attachment) error
+	LoadAttachment(ctx context.Context, roomName livekit.RoomName, attachmentID string) (*Attachment, error)
+	ListAttachments(ctx context.Context, roomName livekit.RoomName) ([]*Attachment, error)
+	DeleteAttachment(ctx context.Context, roomName livekit.RoomName, attachmentID string) error
+}
+
+// sessions of participants, kept so that they can resume on another node. They are removed with their participant
+type ParticipantSessionStore interface {
+	StoreParticipantSession(ctx context.Context, session *ParticipantSession) error
+	LoadParticipantSession(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*ParticipantSession, error)
+}
+
+// attempts of identities at the passcode of a room, kept in the store so that lockouts hold across nodes
+type PasscodeAttemptStore interface {
+	// IncrementPasscodeAttempts counts an attempt and returns the attempts made in a row. They expire ttl after the
+	// last attempt counted up to maxAttempts, attempts beyond it do not push the expiry back
+	IncrementPasscodeAttempts(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, maxAttempts int, ttl time.Duration) (int, error)
+	DeletePasscodeAttempts(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error
+}
+
+// records of administrative calls, kept per room for the retention of the audit log
+type AuditStore interface {
+	// StoreAuditRecord appends a record to its room, keeping the latest maxRecords
+	StoreAuditRecord(ctx context.Context, record *AuditRecord, maxRecords int, retention time.Duration) error
+	ListAuditRecords(ctx context.Context, roomName livekit.RoomName) ([]*AuditRecord, error)
+}
+
+// speaker changes recorded for audio mix egresses, kept once their recording stopped so they outlive the room
+type SpeakerMarkerStore interface {
+	StoreSpeakerMarkers(ctx context.Context, egressID string, markers []rtc.SpeakerMarker, ttl time.Duration) error
+	// LoadSpeakerMarkers returns ErrSpeakerMarkersNotFound when none are stored for the egress
+	LoadSpeakerMarkers(ctx context.Context, egressID string) ([]rtc.SpeakerMarker, error)
+}
+
+// track stats history of participants that left, kept for the configured period so it can be queried after they
+// disconnect
+type TrackStatsHistoryStore interface {
+	StoreTrackStatsHistory(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, tracks []*types.TrackStatsSeries, ttl time.Duration) error
+	// LoadTrackStatsHistory returns ErrTrackStatsHistoryNotFound when none is stored for the participant
+	LoadTrackStatsHistory(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) ([]*types.TrackStatsSeries, error)
+}
+
 //counterfeiter:generate . EgressStore
 type EgressStore interface {
 	StoreEgress(ctx context.Context, info *livekit.EgressInfo) error
@@ -71,10 +144,20 @@ type IngressStore interface {
 	DeleteIngress(ctx context.Context, info *livekit.IngressInfo) error
 }
 
+type ParticipantBanStore interface {
+	StoreParticipantBan(ctx context.Context, ban *ParticipantBan) error
+	ListParticipantBans(ctx context.Context, roomName livekit.RoomName) ([]*ParticipantBan, error)
+	// DeleteParticipantBan returns ErrParticipantBanNotFound when the participant is not banned
+	DeleteParticipantBan(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error
+}
+
 //counterfeiter:generate . RoomAllocator
 type RoomAllocator interface {
-	CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, bool, error)
+	// CreateRoom creates or updates a room, options are stored when not nil
+	CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest, options *rtc.RoomOptions) (*livekit.Room, bool, error)
 	ValidateCreateRoom(ctx context.Context, roomName livekit.RoomName) error
+	// PatchRoomMetadata applies a JSON merge patch to the stored metadata of a room that is not running on a node
+	PatchRoomMetadata(ctx context.Context, roomName livekit.RoomName, patch []byte, version string) (*livekit.Room, error)
 }
 
 //counterfeiter:generate . SIPStore
@@ -34,6 +34,9 @@ type IOInfoService struct {
 	is        IngressStore
 	ss        SIPStore
 	telemetry telemetry.TelemetryService
+	keyQuotas *KeyQuotas
+
+	rtspReconnector *RTSPIngressReconnector
 
 	shutdown chan struct{}
 }
@@ -44,13 +47,17 @@ func NewIOInfoService(
 	is IngressStore,
 	ss SIPStore,
 	ts telemetry.TelemetryService,
+	keyQuotas *KeyQuotas,
+	rtspReconnector *RTSPIngressReconnector,
 ) (*IOInfoService, error) {
 	s := &IOInfoService{
-		es:        es,
-		is:        is,
-		ss:        ss,
-		telemetry: ts,
-		shutdown:  make(chan struct{}),
+		es:              es,
+		is:              is,
+		ss:              ss,
+		telemetry:       ts,
+		keyQuotas:       keyQuotas,
+		rtspReconnector: rtspReconnector,
+		shutdown:        make(chan struct{}),
 	}
 
 	if bus != nil {
@@ -79,6 +86,7 @@ func (s *IOInfoService) Start() error {
 
 func (s *IOInfoService) Stop() {
 	close(s.shutdown)
+	s.rtspReconnector.Stop()
 
 	if s.ioServer != nil {
 		s.ioServer.Shutdown()
@@ -115,6 +123,7 @@ func (s *IOInfoService) UpdateEgress(ctx context.Context, info *livekit.EgressIn
 		livekit.EgressStatus_EGRESS_ABORTED,
 		livekit.EgressStatus_EGRESS_LIMIT_REACHED:
 		s.telemetry.EgressEnded(ctx, info)
+		s.keyQuotas.EgressEnded(ctx, info)
 	}
 
 	if err != nil {
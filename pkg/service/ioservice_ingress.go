@@ -100,5 +100,7 @@ func (s *IOInfoService) UpdateIngressState(ctx context.Context, req *rpc.UpdateI
 		logger.Infow("ingress state updated", "ingressID", req.IngressId, "status", info.State.Status)
 	}
 
+	s.rtspReconnector.IngressStateUpdated(info)
+
 	return &emptypb.Empty{}, nil
 }
@@ -0,0 +1,215 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// JoinAuthorizationRequest is posted to the join authorization service for each participant joining a room
+type JoinAuthorizationRequest struct {
+	Room       string         `json:"room"`
+	Identity   string         `json:"identity"`
+	Name       string         `json:"name,omitempty"`
+	Metadata   string         `json:"metadata,omitempty"`
+	Permission JoinPermission `json:"permission"`
+	Client     JoinClient     `json:"client"`
+}
+
+type JoinClient struct {
+	SDK     string `json:"sdk,omitempty"`
+	Version string `json:"version,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Browser string `json:"browser,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// JoinPermission is the permission a participant joins with
+type JoinPermission struct {
+	CanSubscribe      bool `json:"can_subscribe"`
+	CanPublish        bool `json:"can_publish"`
+	CanPublishData    bool `json:"can_publish_data"`
+	CanUpdateMetadata bool `json:"can_update_metadata"`
+	// camera, microphone, screen_share or screen_share_audio, all sources when empty
+	CanPublishSources []string `json:"can_publish_sources,omitempty"`
+	Hidden            bool     `json:"hidden,omitempty"`
+}
+
+// JoinAuthorizationResponse is the answer of the join authorization service
+type JoinAuthorizationResponse struct {
+	Allow bool `json:"allow"`
+	// returned to the client when the join is rejected
+	Reason string `json:"reason,omitempty"`
+	// replaces the permission of the token when set
+	Permission *JoinPermission `json:"permission,omitempty"`
+	// replaces the metadata of the token when set
+	Metadata *string `json:"metadata,omitempty"`
+}
+
+// JoinAuthorizer asks the join authorization service whether a participant may join, before the session is started.
+// It is nil when join authorization is not enabled
+type JoinAuthorizer struct {
+	conf   config.JoinAuthorizationConfig
+	client *http.Client
+}
+
+func NewJoinAuthorizer(conf *config.Config) *JoinAuthorizer {
+	if !conf.JoinAuthorization.Enabled {
+		return nil
+	}
+	return &JoinAuthorizer{
+		conf:   conf.JoinAuthorization,
+		client: &http.Client{Timeout: conf.JoinAuthorization.Timeout},
+	}
+}
+
+// Authorize applies the answer of the service to claims. It returns an error wrapping ErrJoinRejected when the service
+// rejects the join, and ErrJoinAuthorizationFailed when the service cannot be reached or answers with an error, unless
+// the failure policy lets the participant in.
+func (a *JoinAuthorizer) Authorize(ctx context.Context, roomName livekit.RoomName, claims *auth.ClaimGrants, clientInfo *livekit.ClientInfo) error {
+	if a == nil {
+		return nil
+	}
+
+	res, err := a.request(ctx, &JoinAuthorizationRequest{
+		Room:       string(roomName),
+		Identity:   claims.Identity,
+		Name:       claims.Name,
+		Metadata:   claims.Metadata,
+		Permission: joinPermissionFromGrant(claims.Video),
+		Client: JoinClient{
+			SDK:     strings.ToLower(clientInfo.GetSdk().String()),
+			Version: clientInfo.GetVersion(),
+			OS:      clientInfo.GetOs(),
+			Browser: clientInfo.GetBrowser(),
+			Address: clientInfo.GetAddress(),
+		},
+	})
+	if err == nil {
+		err = validateJoinAuthorization(res)
+	}
+	if err != nil {
+		if a.conf.FailurePolicy == config.JoinAuthorizationFailOpen {
+			logger.Warnw("join authorization failed, letting participant in", err, "room", roomName, "participant", claims.Identity)
+			return nil
+		}
+		return fmt.Errorf("%w: %v", ErrJoinAuthorizationFailed, err)
+	}
+
+	if !res.Allow {
+		if res.Reason != "" {
+			return fmt.Errorf("%w: %s", ErrJoinRejected, res.Reason)
+		}
+		return ErrJoinRejected
+	}
+	if res.Permission != nil {
+		res.Permission.apply(claims.Video)
+	}
+	if res.Metadata != nil {
+		claims.Metadata = *res.Metadata
+	}
+	return nil
+}
+
+func (a *JoinAuthorizer) request(ctx context.Context, joinReq *JoinAuthorizationRequest) (*JoinAuthorizationResponse, error) {
+	body, err := json.Marshal(joinReq)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.conf.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.conf.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.conf.AuthToken)
+	}
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("join authorization service returned %s", res.Status)
+	}
+
+	joinRes := &JoinAuthorizationResponse{}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(joinRes); err != nil {
+		return nil, err
+	}
+	return joinRes, nil
+}
+
+func validateJoinAuthorization(res *JoinAuthorizationResponse) error {
+	if res.Permission == nil {
+		return nil
+	}
+	for _, source := range res.Permission.CanPublishSources {
+		if _, ok := trackSourceFromName(source); !ok {
+			return fmt.Errorf("unknown track source %q in permission", source)
+		}
+	}
+	return nil
+}
+
+func trackSourceFromName(name string) (livekit.TrackSource, bool) {
+	source, ok := livekit.TrackSource_value[strings.ToUpper(name)]
+	return livekit.TrackSource(source), ok && livekit.TrackSource(source) != livekit.TrackSource_UNKNOWN
+}
+
+func joinPermissionFromGrant(grant *auth.VideoGrant) JoinPermission {
+	permission := JoinPermission{
+		CanSubscribe:      grant.GetCanSubscribe(),
+		CanPublish:        grant.GetCanPublish(),
+		CanPublishData:    grant.GetCanPublishData(),
+		CanUpdateMetadata: grant.GetCanUpdateOwnMetadata(),
+		Hidden:            grant.Hidden,
+	}
+	for _, source := range grant.GetCanPublishSources() {
+		permission.CanPublishSources = append(permission.CanPublishSources, strings.ToLower(source.String()))
+	}
+	return permission
+}
+
+func (p *JoinPermission) apply(grant *auth.VideoGrant) {
+	grant.SetCanSubscribe(p.CanSubscribe)
+	grant.SetCanPublish(p.CanPublish)
+	grant.SetCanPublishData(p.CanPublishData)
+	grant.SetCanUpdateOwnMetadata(p.CanUpdateMetadata)
+	sources := make([]livekit.TrackSource, 0, len(p.CanPublishSources))
+	for _, name := range p.CanPublishSources {
+		if source, ok := trackSourceFromName(name); ok {
+			sources = append(sources, source)
+		}
+	}
+	grant.SetCanPublishSources(sources)
+	grant.Hidden = p.Hidden
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestJoinAuthorizer(t *testing.T) {
+	var lastReq service.JoinAuthorizationRequest
+	respond := func(w http.ResponseWriter, res *service.JoinAuthorizationResponse) {
+		_ = json.NewEncoder(w).Encode(res)
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&lastReq))
+		switch lastReq.Identity {
+		case "banned":
+			respond(w, &service.JoinAuthorizationResponse{Reason: "banned from the room"})
+		case "viewer":
+			metadata := `{"role":"viewer"}`
+			respond(w, &service.JoinAuthorizationResponse{
+				Allow:      true,
+				Permission: &service.JoinPermission{CanSubscribe: true, CanPublishData: true},
+				Metadata:   &metadata,
+			})
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+			respond(w, &service.JoinAuthorizationResponse{Allow: true})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newAuthorizer := func(policy config.JoinAuthorizationPolicy) *service.JoinAuthorizer {
+		conf, err := config.NewConfig("", true, nil, nil)
+		require.NoError(t, err)
+		conf.JoinAuthorization = config.JoinAuthorizationConfig{
+			Enabled:       true,
+			URL:           server.URL,
+			AuthToken:     "secret",
+			Timeout:       50 * time.Millisecond,
+			FailurePolicy: policy,
+		}
+		return service.NewJoinAuthorizer(conf)
+	}
+	claims := func(identity string) *auth.ClaimGrants {
+		return &auth.ClaimGrants{Identity: identity, Video: &auth.VideoGrant{RoomJoin: true, Room: "lobby"}}
+	}
+	ctx := context.Background()
+	clientInfo := &livekit.ClientInfo{Sdk: livekit.ClientInfo_JS, Version: "2.0.0"}
+
+	t.Run("disabled", func(t *testing.T) {
+		conf, err := config.NewConfig("", true, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, service.NewJoinAuthorizer(conf).Authorize(ctx, "lobby", claims("banned"), clientInfo))
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		err := newAuthorizer(config.JoinAuthorizationFailOpen).Authorize(ctx, "lobby", claims("banned"), clientInfo)
+		require.True(t, errors.Is(err, service.ErrJoinRejected))
+		require.Contains(t, err.Error(), "banned from the room")
+		require.Equal(t, "lobby", lastReq.Room)
+		require.Equal(t, "js", lastReq.Client.SDK)
+		require.True(t, lastReq.Permission.CanPublish)
+	})
+
+	t.Run("permission and metadata are replaced", func(t *testing.T) {
+		c := claims("viewer")
+		require.NoError(t, newAuthorizer(config.JoinAuthorizationFailClosed).Authorize(ctx, "lobby", c, clientInfo))
+		require.False(t, c.Video.GetCanPublish())
+		require.True(t, c.Video.GetCanSubscribe())
+		require.True(t, c.Video.GetCanPublishData())
+		require.Equal(t, `{"role":"viewer"}`, c.Metadata)
+	})
+
+	t.Run("failure policy", func(t *testing.T) {
+		for _, identity := range []string{"erroring", "slow"} {
+			err := newAuthorizer(config.JoinAuthorizationFailClosed).Authorize(ctx, "lobby", claims(identity), clientInfo)
+			require.True(t, errors.Is(err, service.ErrJoinAuthorizationFailed), identity)
+
+			c := claims(identity)
+			require.NoError(t, newAuthorizer(config.JoinAuthorizationFailOpen).Authorize(ctx, "lobby", c, clientInfo), identity)
+			require.True(t, c.Video.GetCanPublish())
+		}
+	})
+}
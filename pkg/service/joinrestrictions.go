@@ -0,0 +1,216 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+const (
+	joinRestrictedByAPIKey = "api_key"
+	joinRestrictedByRoom   = "room"
+	joinRestrictedByBan    = "ban"
+)
+
+// JoinRestrictions rejects participants joining from networks the API key of their token, or the room, does not
+// allow, and participants banned from the room. Joins are audited with a participant_join_restricted event when
+// they are rejected
+type JoinRestrictions struct {
+	config    *config.Config
+	store     ObjectStore
+	geoIP     GeoIPProvider
+	telemetry telemetry.TelemetryService
+}
+
+func NewJoinRestrictions(
+	conf *config.Config,
+	store ObjectStore,
+	geoIP GeoIPProvider,
+	telemetry telemetry.TelemetryService,
+) *JoinRestrictions {
+	return &JoinRestrictions{
+		config:    conf,
+		store:     store,
+		geoIP:     geoIP,
+		telemetry: telemetry,
+	}
+}
+
+// CheckJoin returns an error wrapping ErrJoinRestricted when the client IP is not allowed by the restrictions of
+// apiKey or of the room
+func (j *JoinRestrictions) CheckJoin(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	apiKey string,
+	identity livekit.ParticipantIdentity,
+	clientInfo *livekit.ClientInfo,
+) error {
+	if j == nil {
+		return nil
+	}
+
+	var keyRestriction *config.JoinRestrictionConfig
+	if r, ok := j.config.Reloadable().Limit.KeyJoinRestrictions[apiKey]; ok && apiKey != "" {
+		keyRestriction = &r
+	}
+	options, err := j.store.LoadRoomOptions(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	roomRestriction := options.JoinRestriction
+	if !keyRestriction.IsEnabled() && !roomRestriction.IsEnabled() {
+		return nil
+	}
+
+	check := &joinRestrictionCheck{
+		ip:    parseClientIP(clientInfo.GetAddress()),
+		geoIP: j.geoIP,
+	}
+	restriction := &telemetry.JoinRestriction{}
+	if restriction.Reason = check.evaluate(keyRestriction); restriction.Reason != "" {
+		restriction.RestrictedBy = joinRestrictedByAPIKey
+	} else if restriction.Reason = check.evaluate(roomRestriction); restriction.Reason != "" {
+		restriction.RestrictedBy = joinRestrictedByRoom
+	} else {
+		return nil
+	}
+	restriction.Country = check.country
+
+	j.telemetry.ParticipantJoinRestricted(ctx, roomName, identity, clientInfo, restriction)
+	return fmt.Errorf("%w: %s", ErrJoinRestricted, restriction.Reason)
+}
+
+// CheckBan returns ErrParticipantBanned when the identity, or the client IP, is banned from the room
+func (j *JoinRestrictions) CheckBan(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	clientInfo *livekit.ClientInfo,
+) error {
+	if j == nil {
+		return nil
+	}
+
+	ban, err := loadParticipantBan(ctx, j.store, roomName, identity, clientInfo.GetAddress())
+	if err != nil || ban == nil {
+		return err
+	}
+
+	reason := "identity is banned"
+	if ban.Identity != string(identity) {
+		reason = "network is banned"
+	}
+	j.telemetry.ParticipantJoinRestricted(ctx, roomName, identity, clientInfo, &telemetry.JoinRestriction{
+		RestrictedBy: joinRestrictedByBan,
+		Reason:       reason,
+	})
+	return ErrParticipantBanned
+}
+
+// joinRestrictionCheck evaluates the restrictions of a join, the country is looked up once and only when a
+// restriction has countries
+type joinRestrictionCheck struct {
+	ip      net.IP
+	geoIP   GeoIPProvider
+	country string
+	looked  bool
+}
+
+// evaluate returns why the join is rejected, empty when it is allowed
+func (c *joinRestrictionCheck) evaluate(r *config.JoinRestrictionConfig) string {
+	if !r.IsEnabled() {
+		return ""
+	}
+
+	if c.inRanges(r.DenyCIDRs) {
+		return "network is denied"
+	}
+	if len(r.DenyCountries) != 0 && containsCountry(r.DenyCountries, c.lookupCountry()) {
+		return fmt.Sprintf("country %s is denied", c.country)
+	}
+
+	if len(r.AllowCIDRs) == 0 && len(r.AllowCountries) == 0 {
+		return ""
+	}
+	if c.inRanges(r.AllowCIDRs) {
+		return ""
+	}
+	if len(r.AllowCountries) != 0 && containsCountry(r.AllowCountries, c.lookupCountry()) {
+		return ""
+	}
+	if len(r.AllowCountries) == 0 {
+		return "network is not allowed"
+	}
+	if c.country == "" {
+		return "country is not known"
+	}
+	return fmt.Sprintf("country %s is not allowed", c.country)
+}
+
+func (c *joinRestrictionCheck) lookupCountry() string {
+	if c.looked {
+		return c.country
+	}
+	c.looked = true
+
+	if c.ip == nil || c.geoIP == nil {
+		return ""
+	}
+	country, err := c.geoIP.Country(c.ip)
+	if err != nil {
+		logger.Warnw("could not look up country of client", err, "clientIP", c.ip.String())
+		return ""
+	}
+	c.country = strings.ToUpper(country)
+	return c.country
+}
+
+func (c *joinRestrictionCheck) inRanges(cidrs []string) bool {
+	if c.ip == nil {
+		return false
+	}
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(c.ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCountry(countries []string, country string) bool {
+	if country == "" {
+		return false
+	}
+	for _, c := range countries {
+		if strings.EqualFold(c, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClientIP returns the IP of the client address, as resolved by GetClientIP
+func parseClientIP(address string) net.IP {
+	return net.ParseIP(strings.TrimSpace(address))
+}
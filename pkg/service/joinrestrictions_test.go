@@ -0,0 +1,153 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/telemetryfakes"
+)
+
+func TestGeoIPDatabase(t *testing.T) {
+	db, err := service.NewGeoIPDatabase(strings.NewReader(`network,country
+# documentation ranges
+203.0.113.0/24,AU
+198.51.100.0/25,nz
+2001:db8::/32,DE
+192.0.2.0/24,
+`))
+	require.NoError(t, err)
+
+	for ip, country := range map[string]string{
+		"203.0.113.0":    "AU",
+		"203.0.113.255":  "AU",
+		"203.0.114.1":    "",
+		"198.51.100.127": "NZ",
+		"198.51.100.128": "",
+		"2001:db8::1":    "DE",
+		"2001:db9::1":    "",
+		"192.0.2.1":      "",
+	} {
+		c, err := db.Country(net.ParseIP(ip))
+		require.NoError(t, err)
+		require.Equal(t, country, c, ip)
+	}
+
+	_, err = service.NewGeoIPDatabase(strings.NewReader("203.0.113.0/24,AU\nnot-a-network,NZ\n"))
+	require.Error(t, err)
+}
+
+func TestJoinRestrictions(t *testing.T) {
+	ctx := context.Background()
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	conf.Limit.KeyJoinRestrictions = map[string]config.JoinRestrictionConfig{
+		"eu":   {AllowCountries: []string{"de"}, AllowCIDRs: []string{"10.0.0.0/8"}},
+		"open": {DenyCIDRs: []string{"192.0.2.0/24"}},
+	}
+
+	db, err := service.NewGeoIPDatabase(strings.NewReader("203.0.113.0/24,AU\n2001:db8::/32,DE\n"))
+	require.NoError(t, err)
+
+	store := service.NewLocalStore()
+	require.NoError(t, store.StoreRoomOptions(ctx, "no-au", &rtc.RoomOptions{
+		JoinRestriction: &config.JoinRestrictionConfig{DenyCountries: []string{"AU"}},
+	}))
+
+	ts := &telemetryfakes.FakeTelemetryService{}
+	restrictions := service.NewJoinRestrictions(conf, store, db, ts)
+	checkJoin := func(room livekit.RoomName, apiKey string, address string) error {
+		return restrictions.CheckJoin(ctx, room, apiKey, "alice", &livekit.ClientInfo{Address: address})
+	}
+
+	t.Run("restrictions of the API key", func(t *testing.T) {
+		require.NoError(t, checkJoin("room", "eu", "2001:db8::1"))
+		require.NoError(t, checkJoin("room", "eu", "10.1.2.3"))
+		require.ErrorIs(t, checkJoin("room", "eu", "203.0.113.1"), service.ErrJoinRestricted)
+		require.ErrorIs(t, checkJoin("room", "eu", "198.51.100.1"), service.ErrJoinRestricted)
+
+		require.NoError(t, checkJoin("room", "open", "203.0.113.1"))
+		require.ErrorIs(t, checkJoin("room", "open", "192.0.2.1"), service.ErrJoinRestricted)
+
+		// keys without restrictions
+		require.NoError(t, checkJoin("room", "other", "192.0.2.1"))
+	})
+
+	t.Run("restrictions of the room", func(t *testing.T) {
+		require.ErrorIs(t, checkJoin("no-au", "other", "203.0.113.1"), service.ErrJoinRestricted)
+		require.NoError(t, checkJoin("no-au", "other", "198.51.100.1"))
+	})
+
+	t.Run("rejections are audited", func(t *testing.T) {
+		calls := ts.ParticipantJoinRestrictedCallCount()
+		require.ErrorIs(t, checkJoin("no-au", "other", "203.0.113.1"), service.ErrJoinRestricted)
+		require.Equal(t, calls+1, ts.ParticipantJoinRestrictedCallCount())
+
+		_, room, identity, _, restriction := ts.ParticipantJoinRestrictedArgsForCall(calls)
+		require.Equal(t, livekit.RoomName("no-au"), room)
+		require.Equal(t, livekit.ParticipantIdentity("alice"), identity)
+		require.Equal(t, &telemetry.JoinRestriction{
+			RestrictedBy: "room",
+			Country:      "AU",
+			Reason:       "country AU is denied",
+		}, restriction)
+
+		require.NoError(t, checkJoin("no-au", "other", "198.51.100.1"))
+		require.Equal(t, calls+1, ts.ParticipantJoinRestrictedCallCount())
+	})
+}
+
+func TestParticipantBans(t *testing.T) {
+	ctx := context.Background()
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+
+	store := service.NewLocalStore()
+	require.NoError(t, store.StoreParticipantBan(ctx, &service.ParticipantBan{Room: "room", Identity: "mallory", IP: "203.0.113.7"}))
+	require.NoError(t, store.StoreParticipantBan(ctx, &service.ParticipantBan{Room: "room", Identity: "eve"}))
+
+	ts := &telemetryfakes.FakeTelemetryService{}
+	restrictions := service.NewJoinRestrictions(conf, store, nil, ts)
+	checkBan := func(room livekit.RoomName, identity livekit.ParticipantIdentity, address string) error {
+		return restrictions.CheckBan(ctx, room, identity, &livekit.ClientInfo{Address: address})
+	}
+
+	require.ErrorIs(t, checkBan("room", "mallory", "198.51.100.1"), service.ErrParticipantBanned)
+	require.ErrorIs(t, checkBan("room", "eve", ""), service.ErrParticipantBanned)
+	// the banned IP is rejected with another identity
+	require.ErrorIs(t, checkBan("room", "trent", "203.0.113.7"), service.ErrParticipantBanned)
+	require.NoError(t, checkBan("room", "trent", "203.0.113.8"))
+	// bans are per room
+	require.NoError(t, checkBan("other", "mallory", "203.0.113.7"))
+
+	require.Equal(t, 3, ts.ParticipantJoinRestrictedCallCount())
+	_, _, _, _, restriction := ts.ParticipantJoinRestrictedArgsForCall(2)
+	require.Equal(t, &telemetry.JoinRestriction{RestrictedBy: "ban", Reason: "network is banned"}, restriction)
+
+	require.NoError(t, store.DeleteParticipantBan(ctx, "room", "mallory"))
+	require.ErrorIs(t, store.DeleteParticipantBan(ctx, "room", "mallory"), service.ErrParticipantBanNotFound)
+	require.NoError(t, checkBan("room", "mallory", "203.0.113.7"))
+}
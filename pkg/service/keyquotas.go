@@ -0,0 +1,223 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// APIKeyQuota is the quota of an API key, 0 means no limit
+type APIKeyQuota struct {
+	MaxRooms        int32 `json:"max_rooms,omitempty"`
+	MaxParticipants int32 `json:"max_participants,omitempty"`
+	EgressMinutes   int64 `json:"egress_minutes,omitempty"`
+}
+
+// APIKeyUsage is the usage of the rooms owned by an API key, and of the egress started with it
+type APIKeyUsage struct {
+	APIKey       string `json:"api_key"`
+	Rooms        int32  `json:"rooms"`
+	Participants int32  `json:"participants"`
+	// Period is the calendar month, in UTC, EgressMinutes are counted in
+	Period        string `json:"period"`
+	EgressMinutes int64  `json:"egress_minutes"`
+	// Quota is empty for keys without one
+	Quota *APIKeyQuota `json:"quota,omitempty"`
+}
+
+// KeyQuotas enforces the quotas of API keys from the server config. Rooms and participants are counted across
+// the cluster by the store, as rooms and participants are stored and deleted. Rooms created before their key was
+// counted, e.g. by an older version, are not counted. Participants joining concurrently may exceed the quota.
+type KeyQuotas struct {
+	config *config.Config
+	store  ObjectStore
+}
+
+func NewKeyQuotas(conf *config.Config, store ObjectStore) *KeyQuotas {
+	return &KeyQuotas{
+		config: conf,
+		store:  store,
+	}
+}
+
+func (q *KeyQuotas) quota(apiKey string) (config.KeyQuotaConfig, bool) {
+	if q == nil || apiKey == "" {
+		return config.KeyQuotaConfig{}, false
+	}
+	quota, ok := q.config.Reloadable().Limit.KeyQuotas[apiKey]
+	return quota, ok
+}
+
+// CheckCreateRoom returns ErrAPIKeyRoomQuotaExceeded when apiKey cannot own another room
+func (q *KeyQuotas) CheckCreateRoom(ctx context.Context, apiKey string) error {
+	quota, ok := q.quota(apiKey)
+	if !ok || quota.MaxRooms <= 0 {
+		return nil
+	}
+
+	rooms, _, err := q.store.LoadAPIKeyRoomUsage(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+	if rooms >= quota.MaxRooms {
+		return ErrAPIKeyRoomQuotaExceeded
+	}
+	return nil
+}
+
+// CheckRoomCreated returns ErrAPIKeyRoomQuotaExceeded when apiKey owns more rooms than its quota once a room it
+// created is counted, as rooms created concurrently all pass CheckCreateRoom. The room should then be deleted.
+func (q *KeyQuotas) CheckRoomCreated(ctx context.Context, apiKey string) error {
+	quota, ok := q.quota(apiKey)
+	if !ok || quota.MaxRooms <= 0 {
+		return nil
+	}
+
+	rooms, _, err := q.store.LoadAPIKeyRoomUsage(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+	if rooms > quota.MaxRooms {
+		return ErrAPIKeyRoomQuotaExceeded
+	}
+	return nil
+}
+
+// CheckJoin returns ErrAPIKeyParticipantQuotaExceeded when the key owning the room, or apiKey for a room
+// that does not exist yet, cannot have another participant
+func (q *KeyQuotas) CheckJoin(ctx context.Context, roomName livekit.RoomName, apiKey string) error {
+	if q == nil {
+		return nil
+	}
+
+	options, err := q.store.LoadRoomOptions(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	if options.APIKey != "" {
+		apiKey = options.APIKey
+	}
+
+	quota, ok := q.quota(apiKey)
+	if !ok || quota.MaxParticipants <= 0 {
+		return nil
+	}
+
+	_, participants, err := q.store.LoadAPIKeyRoomUsage(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+	if participants >= quota.MaxParticipants {
+		return ErrAPIKeyParticipantQuotaExceeded
+	}
+	return nil
+}
+
+// CheckEgress returns ErrAPIKeyEgressQuotaExceeded when the egress minutes of apiKey are used up for the month
+func (q *KeyQuotas) CheckEgress(ctx context.Context, apiKey string) error {
+	quota, ok := q.quota(apiKey)
+	if !ok || quota.EgressMinutes <= 0 {
+		return nil
+	}
+
+	used, err := q.store.LoadAPIKeyEgressUsage(ctx, apiKey, usagePeriod(time.Now()))
+	if err != nil {
+		return err
+	}
+	if int64(used/time.Minute) >= quota.EgressMinutes {
+		return ErrAPIKeyEgressQuotaExceeded
+	}
+	return nil
+}
+
+// EgressStarted records apiKey as the key the egress is accounted to
+func (q *KeyQuotas) EgressStarted(ctx context.Context, egressID string, apiKey string) {
+	if q == nil || apiKey == "" {
+		return
+	}
+	if err := q.store.StoreEgressAPIKey(ctx, egressID, apiKey); err != nil {
+		logger.Warnw("could not store egress API key", err, "egressID", egressID)
+	}
+}
+
+// EgressEnded adds the duration of an ended egress to the usage of the key it was started with,
+// in the month it ended
+func (q *KeyQuotas) EgressEnded(ctx context.Context, info *livekit.EgressInfo) {
+	if q == nil {
+		return
+	}
+
+	apiKey, err := q.store.LoadEgressAPIKey(ctx, info.EgressId)
+	if err != nil {
+		logger.Warnw("could not load egress API key", err, "egressID", info.EgressId)
+		return
+	}
+	if apiKey == "" {
+		return
+	}
+
+	var duration time.Duration
+	if info.StartedAt > 0 && info.EndedAt > info.StartedAt {
+		duration = time.Duration(info.EndedAt - info.StartedAt)
+	}
+	endedAt := time.Now()
+	if info.EndedAt > 0 {
+		endedAt = time.Unix(0, info.EndedAt)
+	}
+	if err = q.store.AddAPIKeyEgressUsage(ctx, apiKey, usagePeriod(endedAt), info.EgressId, duration); err != nil {
+		logger.Warnw("could not add egress usage", err, "egressID", info.EgressId, "apiKey", apiKey)
+	}
+}
+
+// Usage returns the current usage of apiKey
+func (q *KeyQuotas) Usage(ctx context.Context, apiKey string) (*APIKeyUsage, error) {
+	now := time.Now()
+	usage := &APIKeyUsage{
+		APIKey: apiKey,
+		Period: usagePeriod(now),
+	}
+	if q == nil || apiKey == "" {
+		return usage, nil
+	}
+
+	var err error
+	if usage.Rooms, usage.Participants, err = q.store.LoadAPIKeyRoomUsage(ctx, apiKey); err != nil {
+		return nil, err
+	}
+	egress, err := q.store.LoadAPIKeyEgressUsage(ctx, apiKey, usage.Period)
+	if err != nil {
+		return nil, err
+	}
+	usage.EgressMinutes = int64(egress / time.Minute)
+
+	if quota, ok := q.quota(apiKey); ok {
+		usage.Quota = &APIKeyQuota{
+			MaxRooms:        quota.MaxRooms,
+			MaxParticipants: quota.MaxParticipants,
+			EgressMinutes:   quota.EgressMinutes,
+		}
+	}
+	return usage, nil
+}
+
+func usagePeriod(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestKeyQuotas(t *testing.T) {
+	ctx := context.Background()
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	conf.Limit.KeyQuotas = map[string]config.KeyQuotaConfig{
+		"team": {MaxRooms: 2, MaxParticipants: 3, EgressMinutes: 10},
+	}
+
+	store := service.NewLocalStore()
+	quotas := service.NewKeyQuotas(conf, store)
+
+	storeRoom := func(name string, apiKey string, numParticipants int) {
+		require.NoError(t, store.StoreRoom(ctx, &livekit.Room{Name: name}, nil))
+		require.NoError(t, store.StoreRoomOptions(ctx, livekit.RoomName(name), &rtc.RoomOptions{APIKey: apiKey}))
+		for i := 0; i < numParticipants; i++ {
+			require.NoError(t, store.StoreParticipant(ctx, livekit.RoomName(name), &livekit.ParticipantInfo{Identity: fmt.Sprint(i)}))
+		}
+	}
+	storeRoom("a", "team", 1)
+	storeRoom("b", "team", 2)
+	storeRoom("c", "other", 5)
+
+	t.Run("rooms", func(t *testing.T) {
+		require.ErrorIs(t, quotas.CheckCreateRoom(ctx, "team"), service.ErrAPIKeyRoomQuotaExceeded)
+		require.NoError(t, quotas.CheckCreateRoom(ctx, "other"))
+		require.NoError(t, quotas.CheckCreateRoom(ctx, ""))
+	})
+
+	t.Run("participants are limited by the room owner", func(t *testing.T) {
+		require.ErrorIs(t, quotas.CheckJoin(ctx, "a", "other"), service.ErrAPIKeyParticipantQuotaExceeded)
+		require.NoError(t, quotas.CheckJoin(ctx, "c", "team"))
+		// rooms that do not exist yet are owned by the joining key
+		require.ErrorIs(t, quotas.CheckJoin(ctx, "new", "team"), service.ErrAPIKeyParticipantQuotaExceeded)
+		require.NoError(t, quotas.CheckJoin(ctx, "new", "other"))
+	})
+
+	t.Run("egress minutes", func(t *testing.T) {
+		require.NoError(t, quotas.CheckEgress(ctx, "team"))
+
+		now := time.Now()
+		quotas.EgressStarted(ctx, "EG_1", "team")
+		quotas.EgressEnded(ctx, &livekit.EgressInfo{
+			EgressId:  "EG_1",
+			StartedAt: now.Add(-11 * time.Minute).UnixNano(),
+			EndedAt:   now.UnixNano(),
+		})
+		require.ErrorIs(t, quotas.CheckEgress(ctx, "team"), service.ErrAPIKeyEgressQuotaExceeded)
+
+		// egress is accounted once
+		quotas.EgressEnded(ctx, &livekit.EgressInfo{
+			EgressId:  "EG_1",
+			StartedAt: now.Add(-11 * time.Minute).UnixNano(),
+			EndedAt:   now.UnixNano(),
+		})
+		usage, err := quotas.Usage(ctx, "team")
+		require.NoError(t, err)
+		require.EqualValues(t, 11, usage.EgressMinutes)
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		usage, err := quotas.Usage(ctx, "team")
+		require.NoError(t, err)
+		require.Equal(t, "team", usage.APIKey)
+		require.EqualValues(t, 2, usage.Rooms)
+		require.EqualValues(t, 3, usage.Participants)
+		require.Equal(t, &service.APIKeyQuota{MaxRooms: 2, MaxParticipants: 3, EgressMinutes: 10}, usage.Quota)
+
+		usage, err = quotas.Usage(ctx, "other")
+		require.NoError(t, err)
+		require.EqualValues(t, 1, usage.Rooms)
+		require.EqualValues(t, 5, usage.Participants)
+		require.Nil(t, usage.Quota)
+	})
+
+	t.Run("counts follow the store", func(t *testing.T) {
+		storeRoom("d", "counted", 2)
+		// updates and options stored again are not counted again
+		require.NoError(t, store.StoreParticipant(ctx, "d", &livekit.ParticipantInfo{Identity: "0", Name: "updated"}))
+		require.NoError(t, store.StoreRoomOptions(ctx, "d", &rtc.RoomOptions{APIKey: "counted"}))
+		require.NoError(t, store.DeleteParticipant(ctx, "d", "1"))
+		require.NoError(t, store.DeleteParticipant(ctx, "d", "1"))
+
+		usage, err := quotas.Usage(ctx, "counted")
+		require.NoError(t, err)
+		require.EqualValues(t, 1, usage.Rooms)
+		require.EqualValues(t, 1, usage.Participants)
+
+		// the room is uncounted with its participants, once
+		require.NoError(t, store.DeleteRoom(ctx, "d"))
+		require.NoError(t, store.DeleteRoom(ctx, "d"))
+		usage, err = quotas.Usage(ctx, "counted")
+		require.NoError(t, err)
+		require.Zero(t, usage.Rooms)
+		require.Zero(t, usage.Participants)
+	})
+}
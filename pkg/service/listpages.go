@@ -0,0 +1,208 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/twitchtv/twirp"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// ListRoomsOptions are the paging and filters of ListRooms on top of the protocol request. Rooms are
+// returned ordered by name, a page starts after the last room of the previous page.
+type ListRoomsOptions struct {
+	// rooms in a page, all rooms when 0
+	Limit int `json:"limit,omitempty"`
+	// next_page_token of the previous page
+	PageToken string `json:"page_token,omitempty"`
+	// rooms whose metadata contains the string
+	MetadataContains string `json:"metadata_contains,omitempty"`
+	// active rooms have participants, empty rooms have none
+	State string `json:"state,omitempty"`
+	// unix time in seconds the rooms are created after or before
+	CreatedAfter  int64 `json:"created_after,omitempty"`
+	CreatedBefore int64 `json:"created_before,omitempty"`
+}
+
+const (
+	listRoomsStateActive = "active"
+	listRoomsStateEmpty  = "empty"
+)
+
+// IsZero returns true when no option has been set
+func (o *ListRoomsOptions) IsZero() bool {
+	return o == nil || reflect.ValueOf(*o).IsZero()
+}
+
+func (o *ListRoomsOptions) validate() error {
+	if o.Limit < 0 {
+		return twirp.InvalidArgumentError("limit", "cannot be negative")
+	}
+	if o.State != "" && o.State != listRoomsStateActive && o.State != listRoomsStateEmpty {
+		return twirp.InvalidArgumentError("state", "must be active or empty")
+	}
+	if _, err := decodePageToken(o.PageToken); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *ListRoomsOptions) matches(room *livekit.Room) bool {
+	switch {
+	case o.MetadataContains != "" && !strings.Contains(room.Metadata, o.MetadataContains):
+		return false
+	case o.State == listRoomsStateActive && room.NumParticipants == 0,
+		o.State == listRoomsStateEmpty && room.NumParticipants != 0:
+		return false
+	case o.CreatedAfter != 0 && room.CreationTime <= o.CreatedAfter,
+		o.CreatedBefore != 0 && room.CreationTime >= o.CreatedBefore:
+		return false
+	}
+	return true
+}
+
+// ListParticipantsOptions are the paging and filters of ListParticipants on top of the protocol request.
+// Participants are returned ordered by identity.
+type ListParticipantsOptions struct {
+	// participants in a page, all participants when 0
+	Limit int `json:"limit,omitempty"`
+	// next_page_token of the previous page
+	PageToken string `json:"page_token,omitempty"`
+	// participants whose metadata contains the string
+	MetadataContains string `json:"metadata_contains,omitempty"`
+	// participants in any of the states, e.g. ACTIVE
+	States []string `json:"states,omitempty"`
+	// unix time in seconds the participants joined after or before
+	JoinedAfter  int64 `json:"joined_after,omitempty"`
+	JoinedBefore int64 `json:"joined_before,omitempty"`
+}
+
+// IsZero returns true when no option has been set
+func (o *ListParticipantsOptions) IsZero() bool {
+	return o == nil || reflect.ValueOf(*o).IsZero()
+}
+
+func (o *ListParticipantsOptions) validate() error {
+	if o.Limit < 0 {
+		return twirp.InvalidArgumentError("limit", "cannot be negative")
+	}
+	for _, state := range o.States {
+		if _, ok := livekit.ParticipantInfo_State_value[state]; !ok {
+			return twirp.InvalidArgumentError("states", "must be JOINING, JOINED, ACTIVE or DISCONNECTED")
+		}
+	}
+	if _, err := decodePageToken(o.PageToken); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *ListParticipantsOptions) matches(participant *livekit.ParticipantInfo) bool {
+	switch {
+	case o.MetadataContains != "" && !strings.Contains(participant.Metadata, o.MetadataContains):
+		return false
+	case len(o.States) != 0 && !slices.Contains(o.States, participant.State.String()):
+		return false
+	case o.JoinedAfter != 0 && participant.JoinedAt <= o.JoinedAfter,
+		o.JoinedBefore != 0 && participant.JoinedAt >= o.JoinedBefore:
+		return false
+	}
+	return true
+}
+
+// ListRoomsPage is a ListRoomsResponse with the token of the next page, empty on the last page
+type ListRoomsPage struct {
+	Response      *livekit.ListRoomsResponse
+	NextPageToken string
+}
+
+func (p *ListRoomsPage) MarshalJSON() ([]byte, error) {
+	return marshalListPage(p.Response, p.NextPageToken)
+}
+
+// ListParticipantsPage is a ListParticipantsResponse with the token of the next page, empty on the last page
+type ListParticipantsPage struct {
+	Response      *livekit.ListParticipantsResponse
+	NextPageToken string
+}
+
+func (p *ListParticipantsPage) MarshalJSON() ([]byte, error) {
+	return marshalListPage(p.Response, p.NextPageToken)
+}
+
+// marshalListPage encodes res as the Twirp servers do, with the next page token along with its fields
+func marshalListPage(res proto.Message, nextPageToken string) ([]byte, error) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(res)
+	if err != nil || nextPageToken == "" {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields["next_page_token"], err = json.Marshal(nextPageToken); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// paginate sorts items by key and returns the page of limit items after the key of the page token,
+// with the token of the next page
+func paginate[T any](items []T, key func(T) string, pageToken string, limit int) ([]T, string) {
+	slices.SortFunc(items, func(a, b T) int {
+		return strings.Compare(key(a), key(b))
+	})
+
+	// validated with the options
+	after, _ := decodePageToken(pageToken)
+	if after != "" {
+		start, _ := slices.BinarySearchFunc(items, after, func(item T, after string) int {
+			if key(item) <= after {
+				return -1
+			}
+			return 1
+		})
+		items = items[start:]
+	}
+
+	if limit == 0 || len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, encodePageToken(key(items[limit-1]))
+}
+
+// page tokens carry the key of the last item of the previous page, so that pages stay
+// consistent while items are added and removed
+func encodePageToken(after string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(after))
+}
+
+func decodePageToken(token string) (string, error) {
+	after, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", twirp.InvalidArgumentError("page_token", "is not a token returned by a previous page")
+	}
+	return string(after), nil
+}
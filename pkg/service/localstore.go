@@ -22,6 +22,9 @@ import (
 	"github.com/thoas/go-funk"
 
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
 )
 
 // encapsulates CRUD operations for room settings
@@ -29,8 +32,30 @@ type LocalStore struct {
 	// map of roomName => room
 	rooms        map[livekit.RoomName]*livekit.Room
 	roomInternal map[livekit.RoomName]*livekit.RoomInternal
+	roomOptions  map[livekit.RoomName]*rtc.RoomOptions
 	// map of roomName => { identity: participant }
 	participants map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo
+	// map of roomName => { attachmentID: attachment }
+	attachments map[livekit.RoomName]map[string]*Attachment
+	sessions    map[livekit.RoomName]map[livekit.ParticipantIdentity]*ParticipantSession
+	bans        map[livekit.RoomName]map[livekit.ParticipantIdentity]*ParticipantBan
+	// map of roomName => { identity: attempts at the passcode }
+	passcodeAttempts map[livekit.RoomName]map[livekit.ParticipantIdentity]*localPasscodeAttempts
+	// map of egressID => API key
+	egressAPIKeys map[string]string
+	// map of period => { API key: egress duration }
+	egressUsage map[string]map[string]time.Duration
+	// map of roomName => API key owning the room
+	roomAPIKeys map[livekit.RoomName]string
+	// map of API key => rooms it owns, and participants in them
+	apiKeyRooms        map[string]int32
+	apiKeyParticipants map[string]int32
+	// map of roomName => audit records, oldest first
+	auditRecords map[livekit.RoomName]*localAuditRecords
+	// map of egressID => speaker markers
+	speakerMarkers map[string]*localSpeakerMarkers
+	// map of roomName => { identity: track stats history of participants that left }
+	trackStatsHistories map[livekit.RoomName]map[livekit.ParticipantIdentity]*localTrackStatsHistory
 
 	lock       sync.RWMutex
 	globalLock sync.Mutex
@@ -38,10 +63,23 @@ type LocalStore struct {
 
 func NewLocalStore() *LocalStore {
 	return &LocalStore{
-		rooms:        make(map[livekit.RoomName]*livekit.Room),
-		roomInternal: make(map[livekit.RoomName]*livekit.RoomInternal),
-		participants: make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
-		lock:         sync.RWMutex{},
+		rooms:               make(map[livekit.RoomName]*livekit.Room),
+		roomInternal:        make(map[livekit.RoomName]*livekit.RoomInternal),
+		roomOptions:         make(map[livekit.RoomName]*rtc.RoomOptions),
+		participants:        make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
+		attachments:         make(map[livekit.RoomName]map[string]*Attachment),
+		sessions:            make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*ParticipantSession),
+		bans:                make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*ParticipantBan),
+		passcodeAttempts:    make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*localPasscodeAttempts),
+		egressAPIKeys:       make(map[string]string),
+		egressUsage:         make(map[string]map[string]time.Duration),
+		roomAPIKeys:         make(map[livekit.RoomName]string),
+		apiKeyRooms:         make(map[string]int32),
+		apiKeyParticipants:  make(map[string]int32),
+		auditRecords:        make(map[livekit.RoomName]*localAuditRecords),
+		speakerMarkers:      make(map[string]*localSpeakerMarkers),
+		trackStatsHistories: make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*localTrackStatsHistory),
+		lock:                sync.RWMutex{},
 	}
 }
 
@@ -76,6 +114,40 @@ func (s *LocalStore) LoadRoom(_ context.Context, roomName livekit.RoomName, incl
 	return room, internal, nil
 }
 
+func (s *LocalStore) StoreRoomOptions(_ context.Context, roomName livekit.RoomName, options *rtc.RoomOptions) error {
+	s.lock.Lock()
+	s.roomOptions[roomName] = options.Clone()
+	if apiKey := options.APIKey; apiKey != "" && s.roomAPIKeys[roomName] == "" {
+		s.roomAPIKeys[roomName] = apiKey
+		s.apiKeyRooms[apiKey]++
+		s.apiKeyParticipants[apiKey] += int32(len(s.participants[roomName]))
+	}
+	s.lock.Unlock()
+
+	return nil
+}
+
+func (s *LocalStore) LoadRoomOptions(_ context.Context, roomName livekit.RoomName) (*rtc.RoomOptions, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if options := s.roomOptions[roomName]; options != nil {
+		return options.Clone(), nil
+	}
+	return &rtc.RoomOptions{}, nil
+}
+
+func (s *LocalStore) ListRoomOptions(_ context.Context) (map[livekit.RoomName]*rtc.RoomOptions, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	options := make(map[livekit.RoomName]*rtc.RoomOptions, len(s.roomOptions))
+	for roomName, o := range s.roomOptions {
+		options[roomName] = o.Clone()
+	}
+	return options, nil
+}
+
 func (s *LocalStore) ListRooms(_ context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
@@ -99,9 +171,17 @@ func (s *LocalStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName)
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if apiKey, ok := s.roomAPIKeys[roomName]; ok {
+		s.apiKeyRooms[apiKey]--
+		s.apiKeyParticipants[apiKey] -= int32(len(s.participants[roomName]))
+		delete(s.roomAPIKeys, roomName)
+	}
 	delete(s.participants, livekit.RoomName(room.Name))
 	delete(s.rooms, livekit.RoomName(room.Name))
 	delete(s.roomInternal, livekit.RoomName(room.Name))
+	delete(s.roomOptions, livekit.RoomName(room.Name))
+	delete(s.attachments, livekit.RoomName(room.Name))
+	delete(s.sessions, livekit.RoomName(room.Name))
 	return nil
 }
 
@@ -124,7 +204,13 @@ func (s *LocalStore) StoreParticipant(_ context.Context, roomName livekit.RoomNa
 		roomParticipants = make(map[livekit.ParticipantIdentity]*livekit.ParticipantInfo)
 		s.participants[roomName] = roomParticipants
 	}
-	roomParticipants[livekit.ParticipantIdentity(participant.Identity)] = participant
+	identity := livekit.ParticipantIdentity(participant.Identity)
+	if _, ok := roomParticipants[identity]; !ok {
+		if apiKey := s.roomAPIKeys[roomName]; apiKey != "" {
+			s.apiKeyParticipants[apiKey]++
+		}
+	}
+	roomParticipants[identity] = participant
 	return nil
 }
 
@@ -165,9 +251,348 @@ func (s *LocalStore) DeleteParticipant(_ context.Context, roomName livekit.RoomN
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	roomParticipants := s.participants[roomName]
-	if roomParticipants != nil {
-		delete(roomParticipants, identity)
+	if _, ok := s.participants[roomName][identity]; ok {
+		delete(s.participants[roomName], identity)
+		if apiKey := s.roomAPIKeys[roomName]; apiKey != "" {
+			s.apiKeyParticipants[apiKey]--
+		}
+	}
+	if roomSessions := s.sessions[roomName]; roomSessions != nil {
+		delete(roomSessions, identity)
+	}
+	return nil
+}
+
+func (s *LocalStore) StoreParticipantSession(_ context.Context, session *ParticipantSession) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	roomSessions := s.sessions[session.Room]
+	if roomSessions == nil {
+		roomSessions = make(map[livekit.ParticipantIdentity]*ParticipantSession)
+		s.sessions[session.Room] = roomSessions
+	}
+	stored := *session
+	roomSessions[session.Identity] = &stored
+	return nil
+}
+
+func (s *LocalStore) LoadParticipantSession(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*ParticipantSession, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	session, ok := s.sessions[roomName][identity]
+	if !ok {
+		return nil, ErrParticipantSessionNotFound
+	}
+	loaded := *session
+	return &loaded, nil
+}
+
+func (s *LocalStore) StoreEgressAPIKey(_ context.Context, egressID string, apiKey string) error {
+	s.lock.Lock()
+	s.egressAPIKeys[egressID] = apiKey
+	s.lock.Unlock()
+	return nil
+}
+
+func (s *LocalStore) LoadEgressAPIKey(_ context.Context, egressID string) (string, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.egressAPIKeys[egressID], nil
+}
+
+func (s *LocalStore) AddAPIKeyEgressUsage(_ context.Context, apiKey string, period string, egressID string, duration time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	usage := s.egressUsage[period]
+	if usage == nil {
+		usage = make(map[string]time.Duration)
+		s.egressUsage[period] = usage
 	}
+	usage[apiKey] += duration
+	delete(s.egressAPIKeys, egressID)
+	return nil
+}
+
+func (s *LocalStore) LoadAPIKeyEgressUsage(_ context.Context, apiKey string, period string) (time.Duration, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.egressUsage[period][apiKey], nil
+}
+
+func (s *LocalStore) LoadAPIKeyRoomUsage(_ context.Context, apiKey string) (int32, int32, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.apiKeyRooms[apiKey], s.apiKeyParticipants[apiKey], nil
+}
+
+type localAuditRecords struct {
+	records   []*AuditRecord
+	expiresAt time.Time
+}
+
+func (s *LocalStore) StoreAuditRecord(_ context.Context, record *AuditRecord, maxRecords int, retention time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for roomName, room := range s.auditRecords {
+		if !room.expiresAt.IsZero() && now.After(room.expiresAt) {
+			delete(s.auditRecords, roomName)
+		}
+	}
+
+	roomName := livekit.RoomName(record.Room)
+	room := s.auditRecords[roomName]
+	if room == nil {
+		room = &localAuditRecords{}
+		s.auditRecords[roomName] = room
+	}
+	stored := *record
+	room.records = append(room.records, &stored)
+	if maxRecords > 0 && len(room.records) > maxRecords {
+		room.records = append([]*AuditRecord(nil), room.records[len(room.records)-maxRecords:]...)
+	}
+	if retention > 0 {
+		room.expiresAt = now.Add(retention)
+	}
+	return nil
+}
+
+func (s *LocalStore) ListAuditRecords(_ context.Context, roomName livekit.RoomName) ([]*AuditRecord, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	room := s.auditRecords[roomName]
+	if room == nil || (!room.expiresAt.IsZero() && time.Now().After(room.expiresAt)) {
+		return []*AuditRecord{}, nil
+	}
+	records := make([]*AuditRecord, 0, len(room.records))
+	for _, record := range room.records {
+		loaded := *record
+		records = append(records, &loaded)
+	}
+	return records, nil
+}
+
+type localSpeakerMarkers struct {
+	markers   []rtc.SpeakerMarker
+	expiresAt time.Time
+}
+
+func (s *LocalStore) StoreSpeakerMarkers(_ context.Context, egressID string, markers []rtc.SpeakerMarker, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for id, stored := range s.speakerMarkers {
+		if !stored.expiresAt.IsZero() && now.After(stored.expiresAt) {
+			delete(s.speakerMarkers, id)
+		}
+	}
+
+	stored := &localSpeakerMarkers{markers: make([]rtc.SpeakerMarker, len(markers))}
+	copy(stored.markers, markers)
+	if ttl > 0 {
+		stored.expiresAt = now.Add(ttl)
+	}
+	s.speakerMarkers[egressID] = stored
+	return nil
+}
+
+func (s *LocalStore) LoadSpeakerMarkers(_ context.Context, egressID string) ([]rtc.SpeakerMarker, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	stored := s.speakerMarkers[egressID]
+	if stored == nil || (!stored.expiresAt.IsZero() && time.Now().After(stored.expiresAt)) {
+		return nil, ErrSpeakerMarkersNotFound
+	}
+	markers := make([]rtc.SpeakerMarker, len(stored.markers))
+	copy(markers, stored.markers)
+	return markers, nil
+}
+
+type localTrackStatsHistory struct {
+	tracks    []*types.TrackStatsSeries
+	expiresAt time.Time
+}
+
+func (s *LocalStore) StoreTrackStatsHistory(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, tracks []*types.TrackStatsSeries, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for name, histories := range s.trackStatsHistories {
+		for id, stored := range histories {
+			if !stored.expiresAt.IsZero() && now.After(stored.expiresAt) {
+				delete(histories, id)
+			}
+		}
+		if len(histories) == 0 {
+			delete(s.trackStatsHistories, name)
+		}
+	}
+
+	histories := s.trackStatsHistories[roomName]
+	if histories == nil {
+		histories = make(map[livekit.ParticipantIdentity]*localTrackStatsHistory)
+		s.trackStatsHistories[roomName] = histories
+	}
+	stored := &localTrackStatsHistory{tracks: tracks}
+	if ttl > 0 {
+		stored.expiresAt = now.Add(ttl)
+	}
+	histories[identity] = stored
+	return nil
+}
+
+func (s *LocalStore) LoadTrackStatsHistory(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) ([]*types.TrackStatsSeries, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	stored := s.trackStatsHistories[roomName][identity]
+	if stored == nil || (!stored.expiresAt.IsZero() && time.Now().After(stored.expiresAt)) {
+		return nil, ErrTrackStatsHistoryNotFound
+	}
+	return stored.tracks, nil
+}
+
+type localPasscodeAttempts struct {
+	count     int
+	expiresAt time.Time
+}
+
+func (s *LocalStore) IncrementPasscodeAttempts(
+	_ context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	maxAttempts int,
+	ttl time.Duration,
+) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	roomAttempts := s.passcodeAttempts[roomName]
+	if roomAttempts == nil {
+		roomAttempts = make(map[livekit.ParticipantIdentity]*localPasscodeAttempts)
+		s.passcodeAttempts[roomName] = roomAttempts
+	}
+	attempts := roomAttempts[identity]
+	if attempts == nil || !now.Before(attempts.expiresAt) {
+		// expired attempts of other identities are dropped along the way
+		for id, a := range roomAttempts {
+			if !now.Before(a.expiresAt) {
+				delete(roomAttempts, id)
+			}
+		}
+		attempts = &localPasscodeAttempts{}
+		roomAttempts[identity] = attempts
+	}
+	attempts.count++
+	if attempts.count <= maxAttempts {
+		attempts.expiresAt = now.Add(ttl)
+	}
+	return attempts.count, nil
+}
+
+func (s *LocalStore) DeletePasscodeAttempts(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if roomAttempts := s.passcodeAttempts[roomName]; roomAttempts != nil {
+		delete(roomAttempts, identity)
+		if len(roomAttempts) == 0 {
+			delete(s.passcodeAttempts, roomName)
+		}
+	}
+	return nil
+}
+
+func (s *LocalStore) StoreParticipantBan(_ context.Context, ban *ParticipantBan) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	roomName := livekit.RoomName(ban.Room)
+	roomBans := s.bans[roomName]
+	if roomBans == nil {
+		roomBans = make(map[livekit.ParticipantIdentity]*ParticipantBan)
+		s.bans[roomName] = roomBans
+	}
+	stored := *ban
+	roomBans[livekit.ParticipantIdentity(ban.Identity)] = &stored
+	return nil
+}
+
+func (s *LocalStore) ListParticipantBans(_ context.Context, roomName livekit.RoomName) ([]*ParticipantBan, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	bans := make([]*ParticipantBan, 0, len(s.bans[roomName]))
+	for _, ban := range s.bans[roomName] {
+		loaded := *ban
+		bans = append(bans, &loaded)
+	}
+	return bans, nil
+}
+
+func (s *LocalStore) DeleteParticipantBan(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.bans[roomName][identity]; !ok {
+		return ErrParticipantBanNotFound
+	}
+	delete(s.bans[roomName], identity)
+	return nil
+}
+
+func (s *LocalStore) StoreAttachment(_ context.Context, attachment *Attachment) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	roomName := livekit.RoomName(attachment.Room)
+	roomAttachments := s.attachments[roomName]
+	if roomAttachments == nil {
+		roomAttachments = make(map[string]*Attachment)
+		s.attachments[roomName] = roomAttachments
+	}
+	stored := *attachment
+	roomAttachments[attachment.ID] = &stored
+	return nil
+}
+
+func (s *LocalStore) LoadAttachment(_ context.Context, roomName livekit.RoomName, attachmentID string) (*Attachment, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	attachment, ok := s.attachments[roomName][attachmentID]
+	if !ok {
+		return nil, ErrAttachmentNotFound
+	}
+	loaded := *attachment
+	return &loaded, nil
+}
+
+func (s *LocalStore) ListAttachments(_ context.Context, roomName livekit.RoomName) ([]*Attachment, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	attachments := make([]*Attachment, 0, len(s.attachments[roomName]))
+	for _, attachment := range s.attachments[roomName] {
+		loaded := *attachment
+		attachments = append(attachments, &loaded)
+	}
+	return attachments, nil
+}
+
+func (s *LocalStore) DeleteAttachment(_ context.Context, roomName livekit.RoomName, attachmentID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.attachments[roomName], attachmentID)
 	return nil
 }
@@ -0,0 +1,667 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const (
+	NATSRoomsBucket = "rooms"
+
+	// key groups of the rooms bucket, the counterparts of the redis keys
+	natsRoomsGroup                   = "rooms"
+	natsRoomInternalGroup            = "room_internal"
+	natsRoomOptionsGroup             = "room_options"
+	natsRoomParticipantsGroup        = "room_participants"
+	natsRoomParticipantSessionsGroup = "room_participant_sessions"
+	natsRoomAttachmentsGroup         = "room_attachments"
+	natsRoomParticipantBansGroup     = "room_participant_bans"
+	natsRoomPasscodeAttemptsGroup    = "room_passcode_attempts"
+	natsRoomLockGroup                = "room_lock"
+	natsEgressAPIKeyGroup            = "egress_api_key"
+	natsAPIKeyEgressUsageGroup       = "api_key_egress_usage"
+	natsRoomAPIKeyGroup              = "room_api_key"
+	natsAPIKeyRoomsGroup             = "api_key_rooms"
+	natsAPIKeyParticipantsGroup      = "api_key_participants"
+
+	natsRoomLockRetryInterval  = 100 * time.Millisecond
+	natsRoomLockValueSeparator = "|"
+)
+
+var errNATSRoomLocked = errors.New("room locked")
+
+var _ ObjectStore = (*NATSStore)(nil)
+
+// NATSStore keeps rooms and their participants in a JetStream key-value bucket, for deployments coordinating
+// their nodes through NATS. Egress, ingress and SIP are not supported.
+type NATSStore struct {
+	kv  jetstream.KeyValue
+	ctx context.Context
+}
+
+func NewNATSStore(kv jetstream.KeyValue) *NATSStore {
+	return &NATSStore{
+		kv:  kv,
+		ctx: context.Background(),
+	}
+}
+
+func (s *NATSStore) StoreRoom(_ context.Context, room *livekit.Room, internal *livekit.RoomInternal) error {
+	if room.CreationTime == 0 {
+		room.CreationTime = time.Now().Unix()
+	}
+
+	roomData, err := proto.Marshal(room)
+	if err != nil {
+		return err
+	}
+	if _, err = s.kv.Put(s.ctx, routing.NATSKey(natsRoomsGroup, room.Name), roomData); err != nil {
+		return errors.Wrap(err, "could not create room")
+	}
+
+	internalKey := routing.NATSKey(natsRoomInternalGroup, room.Name)
+	if internal == nil {
+		return s.kv.Delete(s.ctx, internalKey)
+	}
+	internalData, err := proto.Marshal(internal)
+	if err != nil {
+		return err
+	}
+	_, err = s.kv.Put(s.ctx, internalKey, internalData)
+	return err
+}
+
+func (s *NATSStore) LoadRoom(_ context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
+	room := &livekit.Room{}
+	if err := s.loadOne(routing.NATSKey(natsRoomsGroup, string(roomName)), room, ErrRoomNotFound); err != nil {
+		return nil, nil, err
+	}
+
+	var internal *livekit.RoomInternal
+	if includeInternal {
+		internal = &livekit.RoomInternal{}
+		err := s.loadOne(routing.NATSKey(natsRoomInternalGroup, string(roomName)), internal, ErrRoomNotFound)
+		if err == ErrRoomNotFound {
+			internal = nil
+		} else if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return room, internal, nil
+}
+
+func (s *NATSStore) StoreRoomOptions(_ context.Context, roomName livekit.RoomName, options *rtc.RoomOptions) error {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.kv.Put(s.ctx, routing.NATSKey(natsRoomOptionsGroup, string(roomName)), data); err != nil {
+		return err
+	}
+	if options.APIKey == "" {
+		return nil
+	}
+
+	// the room is counted once, when its owner is first recorded
+	_, err = s.kv.Create(s.ctx, routing.NATSKey(natsRoomAPIKeyGroup, string(roomName)), []byte(options.APIKey))
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	participants, err := routing.NATSList(s.ctx, s.kv, routing.NATSKey(natsRoomParticipantsGroup, string(roomName)))
+	if err != nil {
+		return err
+	}
+	if err = s.addAPIKeyCount(natsAPIKeyRoomsGroup, options.APIKey, 1); err != nil {
+		return err
+	}
+	return s.addAPIKeyCount(natsAPIKeyParticipantsGroup, options.APIKey, int64(len(participants)))
+}
+
+func (s *NATSStore) LoadRoomOptions(_ context.Context, roomName livekit.RoomName) (*rtc.RoomOptions, error) {
+	options := &rtc.RoomOptions{}
+	entry, err := s.kv.Get(s.ctx, routing.NATSKey(natsRoomOptionsGroup, string(roomName)))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return options, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(entry.Value(), options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+func (s *NATSStore) ListRoomOptions(_ context.Context) (map[livekit.RoomName]*rtc.RoomOptions, error) {
+	items, err := routing.NATSList(s.ctx, s.kv, natsRoomOptionsGroup)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get room options")
+	}
+
+	options := make(map[livekit.RoomName]*rtc.RoomOptions, len(items))
+	for roomName, data := range items {
+		o := &rtc.RoomOptions{}
+		if err = json.Unmarshal(data, o); err != nil {
+			return nil, err
+		}
+		options[livekit.RoomName(roomName)] = o
+	}
+	return options, nil
+}
+
+func (s *NATSStore) ListRooms(_ context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
+	var items [][]byte
+	if roomNames == nil {
+		values, err := routing.NATSList(s.ctx, s.kv, natsRoomsGroup)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get rooms")
+		}
+		for _, value := range values {
+			items = append(items, value)
+		}
+	} else {
+		for _, roomName := range roomNames {
+			entry, err := s.kv.Get(s.ctx, routing.NATSKey(natsRoomsGroup, string(roomName)))
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				continue
+			} else if err != nil {
+				return nil, errors.Wrap(err, "could not get rooms by names")
+			}
+			items = append(items, entry.Value())
+		}
+	}
+
+	rooms := make([]*livekit.Room, 0, len(items))
+	for _, item := range items {
+		room := livekit.Room{}
+		if err := proto.Unmarshal(item, &room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, &room)
+	}
+	return rooms, nil
+}
+
+func (s *NATSStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName) error {
+	_, _, err := s.LoadRoom(ctx, roomName, false)
+	if err == ErrRoomNotFound {
+		return nil
+	}
+
+	if err = s.deleteRoomAPIKey(roomName); err != nil {
+		return err
+	}
+	for _, group := range []string{natsRoomsGroup, natsRoomInternalGroup, natsRoomOptionsGroup} {
+		if err = s.kv.Delete(s.ctx, routing.NATSKey(group, string(roomName))); err != nil {
+			return err
+		}
+	}
+	for _, group := range []string{natsRoomParticipantsGroup, natsRoomParticipantSessionsGroup, natsRoomAttachmentsGroup} {
+		if err = routing.NATSDeleteAll(s.ctx, s.kv, routing.NATSKey(group, string(roomName))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteRoomAPIKey uncounts the room, and its participants, from the usage of the key owning it
+func (s *NATSStore) deleteRoomAPIKey(roomName livekit.RoomName) error {
+	key := routing.NATSKey(natsRoomAPIKeyGroup, string(roomName))
+	entry, err := s.kv.Get(s.ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	participants, err := routing.NATSList(s.ctx, s.kv, routing.NATSKey(natsRoomParticipantsGroup, string(roomName)))
+	if err != nil {
+		return err
+	}
+
+	err = s.kv.Delete(s.ctx, key, jetstream.LastRevision(entry.Revision()))
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		// deleted concurrently, the room is uncounted once
+		return nil
+	} else if err != nil {
+		return err
+	}
+	apiKey := string(entry.Value())
+	if err = s.addAPIKeyCount(natsAPIKeyRoomsGroup, apiKey, -1); err != nil {
+		return err
+	}
+	return s.addAPIKeyCount(natsAPIKeyParticipantsGroup, apiKey, -int64(len(participants)))
+}
+
+// LockRoom stores the lock token with its expiry, an expired lock is taken over by the next caller
+func (s *NATSStore) LockRoom(_ context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
+	token := utils.NewGuid("LOCK")
+	key := routing.NATSKey(natsRoomLockGroup, string(roomName))
+
+	startTime := time.Now()
+	for {
+		err := routing.NATSUpdate(s.ctx, s.kv, key, func(value []byte) ([]byte, error) {
+			if _, expiresAt, ok := parseNATSRoomLock(value); ok && time.Now().Before(expiresAt) {
+				return nil, errNATSRoomLocked
+			}
+			return natsRoomLockValue(token, time.Now().Add(duration)), nil
+		})
+		if err == nil {
+			return token, nil
+		}
+		if err != errNATSRoomLocked {
+			return "", err
+		}
+
+		// stop waiting past lock duration
+		if time.Since(startTime) > duration {
+			break
+		}
+
+		time.Sleep(natsRoomLockRetryInterval)
+	}
+
+	return "", ErrRoomLockFailed
+}
+
+func (s *NATSStore) UnlockRoom(_ context.Context, roomName livekit.RoomName, uid string) error {
+	key := routing.NATSKey(natsRoomLockGroup, string(roomName))
+	entry, err := s.kv.Get(s.ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return ErrRoomUnlockFailed
+	} else if err != nil {
+		return err
+	}
+
+	// uid does not match
+	if token, _, ok := parseNATSRoomLock(entry.Value()); !ok || token != uid {
+		return ErrRoomUnlockFailed
+	}
+
+	err = s.kv.Delete(s.ctx, key, jetstream.LastRevision(entry.Revision()))
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		// expired and taken over meanwhile
+		return ErrRoomUnlockFailed
+	}
+	return err
+}
+
+func natsRoomLockValue(token string, expiresAt time.Time) []byte {
+	return []byte(token + natsRoomLockValueSeparator + strconv.FormatInt(expiresAt.UnixNano(), 10))
+}
+
+func parseNATSRoomLock(value []byte) (token string, expiresAt time.Time, ok bool) {
+	token, expiry, found := strings.Cut(string(value), natsRoomLockValueSeparator)
+	if !found {
+		return "", time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return token, time.Unix(0, nanos), true
+}
+
+func (s *NATSStore) StoreParticipant(_ context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error {
+	data, err := proto.Marshal(participant)
+	if err != nil {
+		return err
+	}
+
+	key := routing.NATSKey(natsRoomParticipantsGroup, string(roomName), participant.Identity)
+	_, err = s.kv.Create(s.ctx, key, data)
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		_, err = s.kv.Put(s.ctx, key, data)
+		return err
+	} else if err != nil {
+		return err
+	}
+	return s.addAPIKeyParticipants(roomName, 1)
+}
+
+func (s *NATSStore) LoadParticipant(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	pi := &livekit.ParticipantInfo{}
+	key := routing.NATSKey(natsRoomParticipantsGroup, string(roomName), string(identity))
+	if err := s.loadOne(key, pi, ErrParticipantNotFound); err != nil {
+		return nil, err
+	}
+	return pi, nil
+}
+
+func (s *NATSStore) ListParticipants(_ context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
+	items, err := routing.NATSList(s.ctx, s.kv, routing.NATSKey(natsRoomParticipantsGroup, string(roomName)))
+	if err != nil {
+		return nil, err
+	}
+
+	participants := make([]*livekit.ParticipantInfo, 0, len(items))
+	for _, item := range items {
+		pi := livekit.ParticipantInfo{}
+		if err := proto.Unmarshal(item, &pi); err != nil {
+			return nil, err
+		}
+		participants = append(participants, &pi)
+	}
+	return participants, nil
+}
+
+func (s *NATSStore) DeleteParticipant(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	if err := s.kv.Delete(s.ctx, routing.NATSKey(natsRoomParticipantSessionsGroup, string(roomName), string(identity))); err != nil {
+		return err
+	}
+
+	// the participant is uncounted by the call deleting it
+	key := routing.NATSKey(natsRoomParticipantsGroup, string(roomName), string(identity))
+	for {
+		entry, err := s.kv.Get(s.ctx, key)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		err = s.kv.Delete(s.ctx, key, jetstream.LastRevision(entry.Revision()))
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			// updated or deleted meanwhile
+			continue
+		} else if err != nil {
+			return err
+		}
+		return s.addAPIKeyParticipants(roomName, -1)
+	}
+}
+
+// addAPIKeyParticipants counts participants joining or leaving the room in the usage of the key owning it
+func (s *NATSStore) addAPIKeyParticipants(roomName livekit.RoomName, delta int64) error {
+	entry, err := s.kv.Get(s.ctx, routing.NATSKey(natsRoomAPIKeyGroup, string(roomName)))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return s.addAPIKeyCount(natsAPIKeyParticipantsGroup, string(entry.Value()), delta)
+}
+
+func (s *NATSStore) addAPIKeyCount(group string, apiKey string, delta int64) error {
+	return routing.NATSUpdate(s.ctx, s.kv, routing.NATSKey(group, apiKey), func(value []byte) ([]byte, error) {
+		count, err := parseNATSCount(value)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strconv.FormatInt(count+delta, 10)), nil
+	})
+}
+
+func (s *NATSStore) loadAPIKeyCount(group string, apiKey string) (int64, error) {
+	entry, err := s.kv.Get(s.ctx, routing.NATSKey(group, apiKey))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return parseNATSCount(entry.Value())
+}
+
+func parseNATSCount(value []byte) (int64, error) {
+	if value == nil {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
+
+func (s *NATSStore) StoreParticipantSession(_ context.Context, session *ParticipantSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	_, err = s.kv.Put(s.ctx, routing.NATSKey(natsRoomParticipantSessionsGroup, string(session.Room), string(session.Identity)), data)
+	return err
+}
+
+func (s *NATSStore) LoadParticipantSession(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*ParticipantSession, error) {
+	entry, err := s.kv.Get(s.ctx, routing.NATSKey(natsRoomParticipantSessionsGroup, string(roomName), string(identity)))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, ErrParticipantSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	session := &ParticipantSession{}
+	if err = json.Unmarshal(entry.Value(), session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *NATSStore) StoreEgressAPIKey(_ context.Context, egressID string, apiKey string) error {
+	_, err := s.kv.Put(s.ctx, routing.NATSKey(natsEgressAPIKeyGroup, egressID), []byte(apiKey))
+	return err
+}
+
+func (s *NATSStore) LoadEgressAPIKey(_ context.Context, egressID string) (string, error) {
+	entry, err := s.kv.Get(s.ctx, routing.NATSKey(natsEgressAPIKeyGroup, egressID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return string(entry.Value()), nil
+}
+
+func (s *NATSStore) AddAPIKeyEgressUsage(_ context.Context, apiKey string, period string, egressID string, duration time.Duration) error {
+	key := routing.NATSKey(natsAPIKeyEgressUsageGroup, period, apiKey)
+	err := routing.NATSUpdate(s.ctx, s.kv, key, func(value []byte) ([]byte, error) {
+		var seconds int64
+		if value != nil {
+			var err error
+			if seconds, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+				return nil, err
+			}
+		}
+		return []byte(strconv.FormatInt(seconds+int64(duration/time.Second), 10)), nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.kv.Delete(s.ctx, routing.NATSKey(natsEgressAPIKeyGroup, egressID))
+}
+
+func (s *NATSStore) LoadAPIKeyEgressUsage(_ context.Context, apiKey string, period string) (time.Duration, error) {
+	entry, err := s.kv.Get(s.ctx, routing.NATSKey(natsAPIKeyEgressUsageGroup, period, apiKey))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.ParseInt(string(entry.Value()), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func (s *NATSStore) LoadAPIKeyRoomUsage(_ context.Context, apiKey string) (int32, int32, error) {
+	rooms, err := s.loadAPIKeyCount(natsAPIKeyRoomsGroup, apiKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	participants, err := s.loadAPIKeyCount(natsAPIKeyParticipantsGroup, apiKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int32(rooms), int32(participants), nil
+}
+
+// IncrementPasscodeAttempts stores the attempts with their expiry, expired attempts are counted anew
+func (s *NATSStore) IncrementPasscodeAttempts(
+	_ context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	maxAttempts int,
+	ttl time.Duration,
+) (int, error) {
+	var attempts int
+	key := routing.NATSKey(natsRoomPasscodeAttemptsGroup, string(roomName), string(identity))
+	err := routing.NATSUpdate(s.ctx, s.kv, key, func(value []byte) ([]byte, error) {
+		now := time.Now()
+		var expiresAt time.Time
+		attempts, expiresAt = parseNATSPasscodeAttempts(value)
+		if !now.Before(expiresAt) {
+			attempts = 0
+		}
+		attempts++
+		if attempts <= maxAttempts {
+			expiresAt = now.Add(ttl)
+		}
+		return natsPasscodeAttemptsValue(attempts, expiresAt), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return attempts, nil
+}
+
+func (s *NATSStore) DeletePasscodeAttempts(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	return s.kv.Delete(s.ctx, routing.NATSKey(natsRoomPasscodeAttemptsGroup, string(roomName), string(identity)))
+}
+
+func natsPasscodeAttemptsValue(attempts int, expiresAt time.Time) []byte {
+	return []byte(strconv.Itoa(attempts) + natsRoomLockValueSeparator + strconv.FormatInt(expiresAt.UnixNano(), 10))
+}
+
+// parseNATSPasscodeAttempts returns no attempts, already expired, for values that cannot be parsed
+func parseNATSPasscodeAttempts(value []byte) (attempts int, expiresAt time.Time) {
+	count, expiry, found := strings.Cut(string(value), natsRoomLockValueSeparator)
+	if !found {
+		return 0, time.Time{}
+	}
+	attempts, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, time.Time{}
+	}
+	nanos, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return 0, time.Time{}
+	}
+	return attempts, time.Unix(0, nanos)
+}
+
+func (s *NATSStore) StoreParticipantBan(_ context.Context, ban *ParticipantBan) error {
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return err
+	}
+	_, err = s.kv.Put(s.ctx, routing.NATSKey(natsRoomParticipantBansGroup, ban.Room, ban.Identity), data)
+	return err
+}
+
+func (s *NATSStore) ListParticipantBans(_ context.Context, roomName livekit.RoomName) ([]*ParticipantBan, error) {
+	items, err := routing.NATSList(s.ctx, s.kv, routing.NATSKey(natsRoomParticipantBansGroup, string(roomName)))
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make([]*ParticipantBan, 0, len(items))
+	for _, item := range items {
+		ban := &ParticipantBan{}
+		if err := json.Unmarshal(item, ban); err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	return bans, nil
+}
+
+func (s *NATSStore) DeleteParticipantBan(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	key := routing.NATSKey(natsRoomParticipantBansGroup, string(roomName), string(identity))
+	if _, err := s.kv.Get(s.ctx, key); errors.Is(err, jetstream.ErrKeyNotFound) {
+		return ErrParticipantBanNotFound
+	} else if err != nil {
+		return err
+	}
+	return s.kv.Delete(s.ctx, key)
+}
+
+func (s *NATSStore) StoreAttachment(_ context.Context, attachment *Attachment) error {
+	data, err := json.Marshal(attachment)
+	if err != nil {
+		return err
+	}
+	_, err = s.kv.Put(s.ctx, routing.NATSKey(natsRoomAttachmentsGroup, attachment.Room, attachment.ID), data)
+	return err
+}
+
+func (s *NATSStore) LoadAttachment(_ context.Context, roomName livekit.RoomName, attachmentID string) (*Attachment, error) {
+	entry, err := s.kv.Get(s.ctx, routing.NATSKey(natsRoomAttachmentsGroup, string(roomName), attachmentID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, ErrAttachmentNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	attachment := &Attachment{}
+	if err = json.Unmarshal(entry.Value(), attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (s *NATSStore) ListAttachments(_ context.Context, roomName livekit.RoomName) ([]*Attachment, error) {
+	items, err := routing.NATSList(s.ctx, s.kv, routing.NATSKey(natsRoomAttachmentsGroup, string(roomName)))
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := make([]*Attachment, 0, len(items))
+	for _, item := range items {
+		attachment := &Attachment{}
+		if err := json.Unmarshal(item, attachment); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+func (s *NATSStore) DeleteAttachment(_ context.Context, roomName livekit.RoomName, attachmentID string) error {
+	return s.kv.Delete(s.ctx, routing.NATSKey(natsRoomAttachmentsGroup, string(roomName), attachmentID))
+}
+
+func (s *NATSStore) loadOne(key string, info proto.Message, notFoundErr error) error {
+	entry, err := s.kv.Get(s.ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return notFoundErr
+	} else if err != nil {
+		return err
+	}
+	return proto.Unmarshal(entry.Value(), info)
+}
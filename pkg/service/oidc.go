@@ -0,0 +1,456 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	oidcDiscoveryPath          = "/.well-known/openid-configuration"
+	defaultOIDCRefreshInterval = time.Hour
+	// keys are fetched at most this often for tokens signed with an unknown key
+	oidcMinFetchInterval = time.Minute
+	oidcFetchTimeout     = 10 * time.Second
+)
+
+var (
+	// only asymmetric algorithms, tokens of a provider can never be verified with a shared secret
+	oidcAlgorithms = map[string]bool{
+		string(jose.RS256): true,
+		string(jose.RS384): true,
+		string(jose.RS512): true,
+		string(jose.PS256): true,
+		string(jose.PS384): true,
+		string(jose.PS512): true,
+		string(jose.ES256): true,
+		string(jose.ES384): true,
+		string(jose.ES512): true,
+	}
+
+	errOIDCUnknownIssuer = errors.New("token issuer is not a configured identity provider")
+	errOIDCUnknownKey    = errors.New("token is signed with an unknown key")
+	errOIDCMissingExpiry = errors.New("token has no expiry")
+)
+
+// OIDCVerifier verifies access tokens issued by the identity providers of the OIDC config with the keys
+// they publish, and maps their claims to grants
+type OIDCVerifier struct {
+	providers map[string]*oidcProvider
+}
+
+// NewOIDCVerifier returns nil when no identity providers are configured
+func NewOIDCVerifier(conf *config.Config) (*OIDCVerifier, error) {
+	if len(conf.OIDC.Providers) == 0 {
+		return nil, nil
+	}
+
+	v := &OIDCVerifier{
+		providers: make(map[string]*oidcProvider, len(conf.OIDC.Providers)),
+	}
+	for _, providerConf := range conf.OIDC.Providers {
+		p, err := newOIDCProvider(providerConf)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.providers[providerConf.Issuer]; ok {
+			return nil, fmt.Errorf("oidc issuer %s is configured more than once", providerConf.Issuer)
+		}
+		v.providers[providerConf.Issuer] = p
+	}
+	return v, nil
+}
+
+// HasIssuer returns true when tokens of the issuer are verified by v
+func (v *OIDCVerifier) HasIssuer(issuer string) bool {
+	if v == nil {
+		return false
+	}
+	_, ok := v.providers[issuer]
+	return ok
+}
+
+// Verify returns the grants of a token issued by one of the identity providers, and the API key requests
+// with tokens of the provider are made as
+func (v *OIDCVerifier) Verify(ctx context.Context, raw string) (*auth.ClaimGrants, string, error) {
+	if v == nil {
+		return nil, "", errOIDCUnknownIssuer
+	}
+
+	tok, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tok.Headers) != 1 {
+		return nil, "", errors.New("token must have a single signature")
+	}
+	header := tok.Headers[0]
+	if !oidcAlgorithms[header.Algorithm] {
+		return nil, "", fmt.Errorf("unsupported token algorithm %s", header.Algorithm)
+	}
+
+	unverified := jwt.Claims{}
+	if err = tok.UnsafeClaimsWithoutVerification(&unverified); err != nil {
+		return nil, "", err
+	}
+	p := v.providers[unverified.Issuer]
+	if p == nil {
+		return nil, "", errOIDCUnknownIssuer
+	}
+
+	key, err := p.key(ctx, header.KeyID)
+	if err != nil {
+		return nil, "", err
+	}
+	if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+		return nil, "", fmt.Errorf("token algorithm %s does not match the algorithm of its key", header.Algorithm)
+	}
+
+	claims := jwt.Claims{}
+	custom := map[string]any{}
+	if err = tok.Claims(key.Key, &claims, &custom); err != nil {
+		return nil, "", err
+	}
+	// exp is only checked by Validate when it is set
+	if claims.Expiry == nil {
+		return nil, "", errOIDCMissingExpiry
+	}
+	if err = claims.Validate(jwt.Expected{Issuer: p.conf.Issuer, Time: time.Now()}); err != nil {
+		return nil, "", err
+	}
+	if !p.audienceAllowed(claims.Audience) {
+		return nil, "", jwt.ErrInvalidAudience
+	}
+
+	grants, err := p.grants(custom)
+	if err != nil {
+		return nil, "", err
+	}
+	return grants, p.conf.APIKey, nil
+}
+
+type oidcProvider struct {
+	conf            config.OIDCProviderConfig
+	refreshInterval time.Duration
+	roleGrants      map[string]map[string]any
+	httpClient      *http.Client
+
+	// held while fetching keys, so that concurrent requests fetch once
+	fetchLock sync.Mutex
+	jwksURL   string
+
+	lock        sync.RWMutex
+	keys        *jose.JSONWebKeySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	fetchErr    error
+}
+
+func newOIDCProvider(conf config.OIDCProviderConfig) (*oidcProvider, error) {
+	if conf.Issuer == "" {
+		return nil, errors.New("oidc provider requires an issuer")
+	}
+	if conf.APIKey == "" {
+		return nil, fmt.Errorf("oidc provider %s requires an api_key", conf.Issuer)
+	}
+
+	p := &oidcProvider{
+		conf:            conf,
+		refreshInterval: conf.RefreshInterval,
+		roleGrants:      make(map[string]map[string]any, len(conf.ClaimMappings.RoleGrants)),
+		httpClient:      &http.Client{Timeout: oidcFetchTimeout},
+		jwksURL:         conf.JWKSURL,
+	}
+	if p.refreshInterval <= 0 {
+		p.refreshInterval = defaultOIDCRefreshInterval
+	}
+
+	// grants are normalized to their JSON form, as claims of tokens are
+	for role, grant := range conf.ClaimMappings.RoleGrants {
+		data, err := json.Marshal(grant)
+		if err != nil {
+			return nil, err
+		}
+		normalized := map[string]any{}
+		if err = json.Unmarshal(data, &normalized); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &auth.VideoGrant{}); err != nil {
+			return nil, fmt.Errorf("invalid grants of oidc role %s: %v", role, err)
+		}
+		p.roleGrants[role] = normalized
+	}
+	return p, nil
+}
+
+func (p *oidcProvider) key(ctx context.Context, keyID string) (*jose.JSONWebKey, error) {
+	p.lock.RLock()
+	key := p.findKeyLocked(keyID)
+	fetchedAt, attemptedAt, fetchErr := p.fetchedAt, p.attemptedAt, p.fetchErr
+	p.lock.RUnlock()
+
+	if key != nil && (time.Since(fetchedAt) < p.refreshInterval || time.Since(attemptedAt) < oidcMinFetchInterval) {
+		return key, nil
+	}
+	if key == nil && time.Since(attemptedAt) < oidcMinFetchInterval {
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return nil, errOIDCUnknownKey
+	}
+
+	if err := p.fetchKeys(ctx, attemptedAt); err != nil {
+		if key != nil {
+			// keep using the keys while the provider cannot be reached
+			logger.Warnw("could not refresh oidc keys", err, "issuer", p.conf.Issuer)
+			return key, nil
+		}
+		return nil, err
+	}
+
+	p.lock.RLock()
+	key = p.findKeyLocked(keyID)
+	p.lock.RUnlock()
+	if key == nil {
+		return nil, errOIDCUnknownKey
+	}
+	return key, nil
+}
+
+func (p *oidcProvider) findKeyLocked(keyID string) *jose.JSONWebKey {
+	if p.keys == nil {
+		return nil
+	}
+
+	var found *jose.JSONWebKey
+	for i := range p.keys.Keys {
+		key := &p.keys.Keys[i]
+		if key.Use == "enc" || !key.IsPublic() {
+			continue
+		}
+		if key.KeyID == keyID {
+			return key
+		}
+		// tokens without a key ID can be verified by the only signing key of the provider
+		if keyID == "" {
+			if found != nil {
+				return nil
+			}
+			found = key
+		}
+	}
+	return found
+}
+
+// fetchKeys fetches the keys of the provider, unless they were fetched after seen by another request
+func (p *oidcProvider) fetchKeys(ctx context.Context, seen time.Time) error {
+	p.fetchLock.Lock()
+	defer p.fetchLock.Unlock()
+
+	p.lock.RLock()
+	attemptedAt, fetchErr := p.attemptedAt, p.fetchErr
+	p.lock.RUnlock()
+	if attemptedAt.After(seen) {
+		return fetchErr
+	}
+
+	keys, err := p.loadKeys(ctx)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.attemptedAt = time.Now()
+	p.fetchErr = err
+	if err != nil {
+		return err
+	}
+	p.keys = keys
+	p.fetchedAt = p.attemptedAt
+	logger.Debugw("fetched oidc keys", "issuer", p.conf.Issuer, "numKeys", len(keys.Keys))
+	return nil
+}
+
+func (p *oidcProvider) loadKeys(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	if p.jwksURL == "" {
+		discovery := struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}{}
+		if err := p.getJSON(ctx, strings.TrimSuffix(p.conf.Issuer, "/")+oidcDiscoveryPath, &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.Issuer != p.conf.Issuer || discovery.JWKSURI == "" {
+			return nil, fmt.Errorf("invalid oidc discovery document of %s", p.conf.Issuer)
+		}
+		p.jwksURL = discovery.JWKSURI
+	}
+
+	keys := &jose.JSONWebKeySet{}
+	if err := p.getJSON(ctx, p.jwksURL, keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (p *oidcProvider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not fetch %s, status %d", url, res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (p *oidcProvider) audienceAllowed(audience jwt.Audience) bool {
+	if len(p.conf.Audiences) == 0 {
+		return true
+	}
+	for _, aud := range p.conf.Audiences {
+		if audience.Contains(aud) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *oidcProvider) grants(claims map[string]any) (*auth.ClaimGrants, error) {
+	mapping := p.conf.ClaimMappings
+	grants := &auth.ClaimGrants{
+		Identity: claimString(claims, mapping.Identity, "sub"),
+		Name:     claimString(claims, mapping.Name, "name"),
+		Metadata: claimString(claims, mapping.Metadata, ""),
+	}
+
+	video := map[string]any{}
+	for _, role := range claimStrings(lookupClaim(claims, mapping.Roles)) {
+		mergeGrants(video, p.roleGrants[role])
+	}
+	if tokenGrants, ok := lookupClaim(claims, mapping.Video).(map[string]any); ok {
+		for name, value := range tokenGrants {
+			video[name] = value
+		}
+	}
+	if room := claimString(claims, mapping.Room, ""); room != "" {
+		video["room"] = room
+	}
+
+	data, err := json.Marshal(video)
+	if err != nil {
+		return nil, err
+	}
+	grants.Video = &auth.VideoGrant{}
+	if err = json.Unmarshal(data, grants.Video); err != nil {
+		return nil, fmt.Errorf("invalid video grants in token: %v", err)
+	}
+	return grants, nil
+}
+
+// mergeGrants adds grants to video, permissions granted by either are granted and lists are combined
+func mergeGrants(video map[string]any, grants map[string]any) {
+	for name, value := range grants {
+		switch v := value.(type) {
+		case bool:
+			if existing, ok := video[name].(bool); ok {
+				v = v || existing
+			}
+			video[name] = v
+		case []any:
+			existing, _ := video[name].([]any)
+			for _, item := range v {
+				found := false
+				for _, e := range existing {
+					if e == item {
+						found = true
+						break
+					}
+				}
+				if !found {
+					existing = append(existing, item)
+				}
+			}
+			video[name] = existing
+		default:
+			video[name] = value
+		}
+	}
+}
+
+// lookupClaim returns the claim at path, claims named with dots are matched before nested claims
+func lookupClaim(claims map[string]any, path string) any {
+	if path == "" {
+		return nil
+	}
+	if value, ok := claims[path]; ok {
+		return value
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		if nested, ok := claims[path[:i]].(map[string]any); ok {
+			if value := lookupClaim(nested, path[i+1:]); value != nil {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+func claimString(claims map[string]any, path string, defaultPath string) string {
+	if path == "" {
+		path = defaultPath
+	}
+	s, _ := lookupClaim(claims, path).(string)
+	return s
+}
+
+func claimStrings(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
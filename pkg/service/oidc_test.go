@@ -0,0 +1,176 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestOIDCVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var issuer string
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"issuer":   issuer,
+				"jwks_uri": issuer + "/keys",
+			})
+		case "/keys":
+			_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{Key: &key.PublicKey, KeyID: "k1", Algorithm: string(jose.RS256), Use: "sig"},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer idp.Close()
+	issuer = idp.URL
+
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	conf.OIDC.Providers = []config.OIDCProviderConfig{{
+		Issuer:    issuer,
+		Audiences: []string{"livekit"},
+		APIKey:    "idp",
+		ClaimMappings: config.OIDCClaimMapping{
+			Roles: "realm_access.roles",
+			Room:  "https://example.com/room",
+			RoleGrants: map[string]map[string]any{
+				"member":  {"roomJoin": true, "canPublish": false, "canPublishSources": []any{"microphone"}},
+				"speaker": {"canPublish": true, "canPublishSources": []any{"camera", "microphone"}},
+				"host":    {"roomAdmin": true},
+			},
+		},
+	}}
+	verifier, err := service.NewOIDCVerifier(conf)
+	require.NoError(t, err)
+	require.True(t, verifier.HasIssuer(issuer))
+
+	// tokens of a provider are used as an API key
+	withoutAPIKey, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	withoutAPIKey.OIDC.Providers = []config.OIDCProviderConfig{{Issuer: issuer}}
+	_, err = service.NewOIDCVerifier(withoutAPIKey)
+	require.Error(t, err)
+	require.False(t, verifier.HasIssuer("APIKey"))
+
+	sign := func(alg jose.SignatureAlgorithm, signingKey any, kid string, claims jwt.Claims, custom map[string]any) string {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: signingKey}, (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid))
+		require.NoError(t, err)
+		token, err := jwt.Signed(signer).Claims(claims).Claims(custom).CompactSerialize()
+		require.NoError(t, err)
+		return token
+	}
+	validClaims := func() jwt.Claims {
+		return jwt.Claims{
+			Issuer:   issuer,
+			Subject:  "user-1",
+			Audience: jwt.Audience{"livekit"},
+			Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}
+	}
+	custom := map[string]any{
+		"name":                     "User One",
+		"https://example.com/room": "myroom",
+		"realm_access": map[string]any{
+			"roles": []string{"member", "speaker", "unknown"},
+		},
+	}
+
+	t.Run("maps claims to grants", func(t *testing.T) {
+		grants, apiKey, err := verifier.Verify(context.Background(), sign(jose.RS256, key, "k1", validClaims(), custom))
+		require.NoError(t, err)
+		require.Equal(t, "idp", apiKey)
+		require.Equal(t, "user-1", grants.Identity)
+		require.Equal(t, "User One", grants.Name)
+		require.True(t, grants.Video.RoomJoin)
+		require.False(t, grants.Video.RoomAdmin)
+		require.Equal(t, "myroom", grants.Video.Room)
+		require.True(t, grants.Video.GetCanPublish())
+		require.ElementsMatch(t, []string{"microphone", "camera"}, grants.Video.CanPublishSources)
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		wrongAudience := validClaims()
+		wrongAudience.Audience = jwt.Audience{"other"}
+		expired := validClaims()
+		expired.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		noExpiry := validClaims()
+		noExpiry.Expiry = nil
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+
+		for name, token := range map[string]string{
+			"audience":    sign(jose.RS256, key, "k1", wrongAudience, custom),
+			"expired":     sign(jose.RS256, key, "k1", expired, custom),
+			"no expiry":   sign(jose.RS256, key, "k1", noExpiry, custom),
+			"signature":   sign(jose.RS256, otherKey, "k1", validClaims(), custom),
+			"unknown key": sign(jose.RS256, key, "k2", validClaims(), custom),
+			"shared key":  sign(jose.HS256, []byte("secret"), "k1", validClaims(), custom),
+		} {
+			_, _, err := verifier.Verify(context.Background(), token)
+			require.Error(t, err, name)
+		}
+	})
+
+	t.Run("authenticates requests", func(t *testing.T) {
+		m := service.NewAPIKeyAuthMiddleware(auth.NewSimpleKeyProvider("APIKey", "secret"), verifier)
+
+		var grants *auth.ClaimGrants
+		var apiKey string
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			grants = service.GetGrants(r.Context())
+			apiKey = service.GetAPIKey(r.Context())
+		})
+
+		r := &http.Request{Header: http.Header{}}
+		service.SetAuthorizationToken(r, sign(jose.RS256, key, "k1", validClaims(), custom))
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, handler)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "idp", apiKey)
+		require.Equal(t, "user-1", grants.Identity)
+
+		// tokens signed with API keys are still accepted
+		token, err := auth.NewAccessToken("APIKey", "secret").SetIdentity("user-2").AddGrant(&auth.VideoGrant{RoomJoin: true, Room: "myroom"}).ToJWT()
+		require.NoError(t, err)
+		r = &http.Request{Header: http.Header{}}
+		service.SetAuthorizationToken(r, token)
+		w = httptest.NewRecorder()
+		m.ServeHTTP(w, r, handler)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "APIKey", apiKey)
+		require.Equal(t, "user-2", grants.Identity)
+	})
+}
@@ -0,0 +1,401 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/packetcapture"
+)
+
+const (
+	PacketCaptureActive    = "active"
+	PacketCaptureUploading = "uploading"
+	PacketCaptureComplete  = "complete"
+	PacketCaptureFailed    = "failed"
+
+	packetCapturePrefix    = "PC_"
+	packetCaptureUploadURL = 15 * time.Minute
+	// stopped captures are kept for their status to be read with StopPacketCapture
+	packetCaptureRetention = 10 * time.Minute
+)
+
+type StartPacketCaptureRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// captures the tracks published by the participant when empty, tracks published later are left out
+	TrackID string `json:"track_id,omitempty"`
+	// pcap (default) or rtpdump
+	Format string `json:"format,omitempty"`
+	// in seconds, bounded by packet_capture.max_duration
+	Duration uint32 `json:"duration,omitempty"`
+}
+
+func (r *StartPacketCaptureRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *StartPacketCaptureRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type StopPacketCaptureRequest struct {
+	Room      string `json:"room"`
+	Identity  string `json:"identity"`
+	CaptureID string `json:"capture_id"`
+}
+
+func (r *StopPacketCaptureRequest) GetRoom() string {
+	return r.Room
+}
+
+func (r *StopPacketCaptureRequest) GetIdentity() string {
+	return r.Identity
+}
+
+type PacketCaptureInfo struct {
+	CaptureID string   `json:"capture_id"`
+	Room      string   `json:"room"`
+	Identity  string   `json:"identity"`
+	TrackIDs  []string `json:"track_ids"`
+	Format    string   `json:"format"`
+	Status    string   `json:"status"`
+	// path of the file on the node, or key of the object in the bucket once uploaded
+	Location  string `json:"location"`
+	Node      string `json:"node"`
+	Packets   int64  `json:"packets"`
+	Bytes     int64  `json:"bytes"`
+	StartedAt int64  `json:"started_at"`
+	EndedAt   int64  `json:"ended_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type packetCapture struct {
+	info    PacketCaptureInfo
+	path    string
+	file    *os.File
+	capture *packetcapture.Capture
+	tracks  []types.LocalMediaTrack
+	timer   *time.Timer
+}
+
+// PacketCaptures runs the packet captures of the tracks published on the node. Captures are written to a
+// directory of the node and uploaded to object storage once they stop, if configured.
+type PacketCaptures struct {
+	conf       config.PacketCaptureConfig
+	nodeID     string
+	presigner  *S3Presigner
+	httpClient *http.Client
+
+	lock     sync.Mutex
+	captures map[string]*packetCapture
+	// tracks being captured, a track is in one capture at a time
+	tracks map[livekit.TrackID]string
+}
+
+// NewPacketCaptures returns nil when packet captures are not configured
+func NewPacketCaptures(conf config.PacketCaptureConfig, nodeID string) (*PacketCaptures, error) {
+	if conf.Directory == "" {
+		return nil, nil
+	}
+
+	p := &PacketCaptures{
+		conf:       conf,
+		nodeID:     nodeID,
+		httpClient: &http.Client{},
+		captures:   make(map[string]*packetCapture),
+		tracks:     make(map[livekit.TrackID]string),
+	}
+	if conf.S3 != nil {
+		presigner, err := NewS3Presigner(*conf.S3)
+		if err != nil {
+			return nil, err
+		}
+		p.presigner = presigner
+	}
+	return p, nil
+}
+
+// Start captures the packets of tracks published by participant until the capture is stopped, reaches its
+// duration or the max bytes of a capture
+func (p *PacketCaptures) Start(
+	room types.Room,
+	participant types.LocalParticipant,
+	tracks []types.LocalMediaTrack,
+	req *StartPacketCaptureRequest,
+) (*PacketCaptureInfo, error) {
+	if p == nil {
+		return nil, ErrPacketCaptureNotEnabled
+	}
+
+	format := packetcapture.Format(req.Format)
+	if format == "" {
+		format = packetcapture.FormatPCAP
+	}
+	if format != packetcapture.FormatPCAP && format != packetcapture.FormatRTPDump {
+		return nil, ErrPacketCaptureFormatInvalid
+	}
+
+	duration := p.conf.MaxDuration
+	if req.Duration > 0 && time.Duration(req.Duration)*time.Second < duration {
+		duration = time.Duration(req.Duration) * time.Second
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.numActiveLocked() >= p.conf.MaxActive {
+		return nil, ErrPacketCaptureLimitExceeded
+	}
+	for _, track := range tracks {
+		if _, ok := p.tracks[track.ID()]; ok {
+			return nil, ErrPacketCaptureTrackBusy
+		}
+	}
+
+	if err := os.MkdirAll(p.conf.Directory, 0755); err != nil {
+		return nil, err
+	}
+	id := utils.NewGuid(packetCapturePrefix)
+	path := filepath.Join(p.conf.Directory, id+"."+format.Extension())
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	capture, err := packetcapture.NewCapture(packetcapture.CaptureParams{
+		Format:   format,
+		Writer:   file,
+		MaxBytes: p.conf.MaxBytes,
+		OnStopped: func(err error) {
+			// called from the goroutine of a stream, the tracks of the capture are released elsewhere
+			go p.stop(id, err)
+		},
+	})
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	pc := &packetCapture{
+		info: PacketCaptureInfo{
+			CaptureID: id,
+			Room:      string(room.Name()),
+			Identity:  string(participant.Identity()),
+			Format:    string(format),
+			Status:    PacketCaptureActive,
+			Location:  path,
+			Node:      p.nodeID,
+			StartedAt: time.Now().Unix(),
+		},
+		path:    path,
+		file:    file,
+		capture: capture,
+		tracks:  tracks,
+	}
+	for _, track := range tracks {
+		pc.info.TrackIDs = append(pc.info.TrackIDs, string(track.ID()))
+		p.tracks[track.ID()] = id
+		track.SetPacketCapture(capture)
+	}
+	pc.timer = time.AfterFunc(duration, func() {
+		p.stop(id, nil)
+	})
+	p.captures[id] = pc
+
+	participant.GetLogger().Infow("packet capture started",
+		"captureID", id,
+		"trackIDs", pc.info.TrackIDs,
+		"format", format,
+		"duration", duration,
+	)
+	info := pc.info
+	return &info, nil
+}
+
+// Stop stops a capture and returns its status, or the status of the capture if it has already stopped.
+// Captures are uploaded in the background, the upload is complete once the status is PacketCaptureComplete.
+func (p *PacketCaptures) Stop(req *StopPacketCaptureRequest) (*PacketCaptureInfo, error) {
+	if p == nil {
+		return nil, ErrPacketCaptureNotEnabled
+	}
+
+	p.lock.Lock()
+	pc, ok := p.captures[req.CaptureID]
+	p.lock.Unlock()
+	if !ok || pc.info.Room != req.Room || pc.info.Identity != req.Identity {
+		return nil, ErrPacketCaptureNotFound
+	}
+
+	return p.stop(req.CaptureID, nil), nil
+}
+
+// Close stops all active captures, they are left in the directory without being uploaded
+func (p *PacketCaptures) Close() {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	ids := make([]string, 0, len(p.captures))
+	for id := range p.captures {
+		ids = append(ids, id)
+	}
+	p.lock.Unlock()
+
+	for _, id := range ids {
+		p.stopCapture(id, nil, false)
+	}
+}
+
+func (p *PacketCaptures) stop(id string, stopErr error) *PacketCaptureInfo {
+	return p.stopCapture(id, stopErr, true)
+}
+
+func (p *PacketCaptures) stopCapture(id string, stopErr error, upload bool) *PacketCaptureInfo {
+	p.lock.Lock()
+	pc, ok := p.captures[id]
+	if !ok {
+		p.lock.Unlock()
+		return nil
+	}
+	if pc.info.Status != PacketCaptureActive {
+		info := pc.info
+		p.lock.Unlock()
+		return &info
+	}
+
+	pc.timer.Stop()
+	for _, track := range pc.tracks {
+		track.SetPacketCapture(nil)
+		delete(p.tracks, track.ID())
+	}
+
+	if err := pc.capture.Stop(); err != nil && stopErr == nil {
+		stopErr = err
+	}
+	if err := pc.file.Close(); err != nil && stopErr == nil {
+		stopErr = err
+	}
+	pc.info.Packets, pc.info.Bytes = pc.capture.Stats()
+	pc.info.EndedAt = time.Now().Unix()
+	switch {
+	case stopErr != nil:
+		pc.info.Status = PacketCaptureFailed
+		pc.info.Error = stopErr.Error()
+	case p.presigner != nil && upload:
+		pc.info.Status = PacketCaptureUploading
+		go p.upload(pc)
+	default:
+		pc.info.Status = PacketCaptureComplete
+	}
+	time.AfterFunc(packetCaptureRetention, func() {
+		p.lock.Lock()
+		delete(p.captures, id)
+		p.lock.Unlock()
+	})
+	info := pc.info
+	p.lock.Unlock()
+
+	logger.Infow("packet capture stopped",
+		"captureID", id,
+		"room", info.Room,
+		"participant", info.Identity,
+		"status", info.Status,
+		"packets", info.Packets,
+		"bytes", info.Bytes,
+		"error", stopErr,
+	)
+	return &info
+}
+
+func (p *PacketCaptures) upload(pc *packetCapture) {
+	key := p.conf.S3.Prefix + pc.info.Room + "/" + pc.info.CaptureID + "." + packetcapture.Format(pc.info.Format).Extension()
+	err := p.uploadFile(pc.path, key)
+
+	p.lock.Lock()
+	if err != nil {
+		pc.info.Status = PacketCaptureFailed
+		pc.info.Error = err.Error()
+	} else {
+		pc.info.Status = PacketCaptureComplete
+		pc.info.Location = key
+	}
+	p.lock.Unlock()
+
+	if err != nil {
+		logger.Warnw("could not upload packet capture", err, "captureID", pc.info.CaptureID, "path", pc.path)
+		return
+	}
+	_ = os.Remove(pc.path)
+}
+
+func (p *PacketCaptures) uploadFile(path string, key string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	signedURL, err := p.presigner.PresignURL(http.MethodPut, key, nil, packetCaptureUploadURL, time.Now())
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), packetCaptureUploadURL)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, signedURL, file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = stat.Size()
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("upload of packet capture failed with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+func (p *PacketCaptures) numActiveLocked() int {
+	active := 0
+	for _, pc := range p.captures {
+		if pc.info.Status == PacketCaptureActive {
+			active++
+		}
+	}
+	return active
+}
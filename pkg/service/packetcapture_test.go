@@ -0,0 +1,155 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/sfu/packetcapture"
+)
+
+func TestPacketCaptures(t *testing.T) {
+	newTrack := func(id livekit.TrackID) *typesfakes.FakeLocalMediaTrack {
+		track := &typesfakes.FakeLocalMediaTrack{}
+		track.IDReturns(id)
+		return track
+	}
+	room := &typesfakes.FakeRoom{}
+	room.NameReturns("myroom")
+	participant := &typesfakes.FakeLocalParticipant{}
+	participant.IdentityReturns("publisher")
+	participant.GetLoggerReturns(logger.GetLogger())
+
+	conf := config.DefaultConfig.PacketCapture
+
+	t.Run("disabled", func(t *testing.T) {
+		captures, err := service.NewPacketCaptures(conf, "node")
+		require.NoError(t, err)
+		require.Nil(t, captures)
+		_, err = captures.Start(room, participant, nil, &service.StartPacketCaptureRequest{})
+		require.ErrorIs(t, err, service.ErrPacketCaptureNotEnabled)
+	})
+
+	t.Run("written to the directory", func(t *testing.T) {
+		conf := conf
+		conf.Directory = t.TempDir()
+		conf.MaxActive = 1
+		captures, err := service.NewPacketCaptures(conf, "node")
+		require.NoError(t, err)
+
+		track := newTrack("TR_video")
+		_, err = captures.Start(room, participant, []types.LocalMediaTrack{track}, &service.StartPacketCaptureRequest{Format: "mkv"})
+		require.ErrorIs(t, err, service.ErrPacketCaptureFormatInvalid)
+
+		info, err := captures.Start(room, participant, []types.LocalMediaTrack{track}, &service.StartPacketCaptureRequest{
+			Room:     "myroom",
+			Identity: "publisher",
+			Format:   "rtpdump",
+		})
+		require.NoError(t, err)
+		require.Equal(t, service.PacketCaptureActive, info.Status)
+		require.Equal(t, []string{"TR_video"}, info.TrackIDs)
+		require.Equal(t, filepath.Join(conf.Directory, info.CaptureID+".rtpdump"), info.Location)
+
+		require.Equal(t, 1, track.SetPacketCaptureCallCount())
+		capture := track.SetPacketCaptureArgsForCall(0)
+		require.NotNil(t, capture)
+		capture.WriteRTP(packetcapture.DirectionInbound, []byte{0x80, 96, 0, 1, 0, 0, 0, 0, 0, 0, 0, 1})
+
+		// one capture at a time
+		_, err = captures.Start(room, participant, []types.LocalMediaTrack{newTrack("TR_audio")}, &service.StartPacketCaptureRequest{})
+		require.ErrorIs(t, err, service.ErrPacketCaptureLimitExceeded)
+
+		_, err = captures.Stop(&service.StopPacketCaptureRequest{Room: "other", Identity: "publisher", CaptureID: info.CaptureID})
+		require.ErrorIs(t, err, service.ErrPacketCaptureNotFound)
+
+		stopped, err := captures.Stop(&service.StopPacketCaptureRequest{Room: "myroom", Identity: "publisher", CaptureID: info.CaptureID})
+		require.NoError(t, err)
+		require.Equal(t, service.PacketCaptureComplete, stopped.Status)
+		require.Equal(t, int64(1), stopped.Packets)
+		require.Equal(t, 2, track.SetPacketCaptureCallCount())
+		require.Nil(t, track.SetPacketCaptureArgsForCall(1))
+
+		content, err := os.ReadFile(info.Location)
+		require.NoError(t, err)
+		require.Contains(t, string(content), "#!rtpplay1.0")
+
+		// the track can be captured again
+		_, err = captures.Start(room, participant, []types.LocalMediaTrack{track}, &service.StartPacketCaptureRequest{})
+		require.NoError(t, err)
+	})
+
+	t.Run("uploaded once stopped", func(t *testing.T) {
+		var lock sync.Mutex
+		objects := make(map[string][]byte)
+		storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			lock.Lock()
+			objects[r.URL.Path] = body
+			lock.Unlock()
+		}))
+		defer storage.Close()
+
+		conf := conf
+		conf.Directory = t.TempDir()
+		conf.MaxDuration = 50 * time.Millisecond
+		conf.S3 = &config.S3StorageConfig{
+			AccessKey:      "key",
+			Secret:         "secret",
+			Region:         "us-east-1",
+			Endpoint:       storage.URL,
+			Bucket:         "captures",
+			ForcePathStyle: true,
+			Prefix:         "captures/",
+		}
+		captures, err := service.NewPacketCaptures(conf, "node")
+		require.NoError(t, err)
+
+		info, err := captures.Start(room, participant, []types.LocalMediaTrack{newTrack("TR_video")}, &service.StartPacketCaptureRequest{
+			Room:     "myroom",
+			Identity: "publisher",
+		})
+		require.NoError(t, err)
+
+		// stops after the max duration
+		var stopped *service.PacketCaptureInfo
+		require.Eventually(t, func() bool {
+			stopped, err = captures.Stop(&service.StopPacketCaptureRequest{Room: "myroom", Identity: "publisher", CaptureID: info.CaptureID})
+			return err == nil && stopped.Status == service.PacketCaptureComplete
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Equal(t, "captures/myroom/"+info.CaptureID+".pcap", stopped.Location)
+
+		lock.Lock()
+		defer lock.Unlock()
+		require.Len(t, objects["/captures/"+stopped.Location], 24)
+		require.NoFileExists(t, info.Location)
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// ParticipantBan keeps a participant removed from a room from joining it again, until it is lifted with
+// UnbanParticipant. Bans are stored apart from the room, so that they outlive it when it is closed and created again.
+type ParticipantBan struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	// IP the participant was connected from, joins from it are rejected whatever their identity
+	IP       string    `json:"ip,omitempty"`
+	BannedAt time.Time `json:"banned_at"`
+}
+
+// RemoveParticipantOptions extends RemoveParticipant requests with the fields livekit.RoomParticipantIdentity does not define
+type RemoveParticipantOptions struct {
+	// Ban keeps the participant from joining the room again
+	Ban bool `json:"ban,omitempty"`
+	// BanIP bans the IP the participant is connected from as well
+	BanIP bool `json:"ban_ip,omitempty"`
+}
+
+func (o *RemoveParticipantOptions) IsZero() bool {
+	return o == nil || reflect.ValueOf(*o).IsZero()
+}
+
+func (o *RemoveParticipantOptions) GetBan() bool {
+	return o != nil && o.Ban
+}
+
+func (o *RemoveParticipantOptions) GetBanIP() bool {
+	return o != nil && o.BanIP
+}
+
+type UnbanParticipantRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+type UnbanParticipantResponse struct{}
+
+// loadParticipantBan returns the ban keeping the identity, or the client address, out of the room, nil when
+// there is none
+func loadParticipantBan(
+	ctx context.Context,
+	store ParticipantBanStore,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	address string,
+) (*ParticipantBan, error) {
+	bans, err := store.ListParticipantBans(ctx, roomName)
+	if err != nil || len(bans) == 0 {
+		return nil, err
+	}
+	return findParticipantBan(bans, identity, parseClientIP(address)), nil
+}
+
+// findParticipantBan returns the ban of the identity, or of the IP when the address of the client is banned
+func findParticipantBan(bans []*ParticipantBan, identity livekit.ParticipantIdentity, ip net.IP) *ParticipantBan {
+	for _, ban := range bans {
+		if ban.Identity == string(identity) {
+			return ban
+		}
+		if ip != nil && ban.IP != "" && ip.Equal(net.ParseIP(ban.IP)) {
+			return ban
+		}
+	}
+	return nil
+}
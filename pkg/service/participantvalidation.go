@@ -0,0 +1,178 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+type ParticipantValidationReason string
+
+const (
+	ParticipantValidationReasonInvalidCharacters ParticipantValidationReason = "invalid_characters"
+	ParticipantValidationReasonTooShort          ParticipantValidationReason = "too_short"
+	ParticipantValidationReasonTooLong           ParticipantValidationReason = "too_long"
+	ParticipantValidationReasonPatternMismatch   ParticipantValidationReason = "pattern_mismatch"
+	ParticipantValidationReasonReservedPrefix    ParticipantValidationReason = "reserved_prefix"
+)
+
+// ParticipantValidationError is the rejection of a join whose identity or name breaks a validation rule,
+// it is returned to the client as JSON
+type ParticipantValidationError struct {
+	// identity or name
+	Field  string                      `json:"field"`
+	Reason ParticipantValidationReason `json:"reason"`
+	Detail string                      `json:"detail"`
+}
+
+func (e *ParticipantValidationError) Error() string {
+	return fmt.Sprintf("participant %s is invalid (%s): %s", e.Field, e.Reason, e.Detail)
+}
+
+type participantFieldValidator struct {
+	field   string
+	config  config.ParticipantFieldValidationConfig
+	pattern *regexp.Regexp
+}
+
+// ParticipantValidator validates and normalizes the identities and names of joining participants.
+// Malformed identities otherwise propagate to track stream IDs, egress file names and webhooks that
+// downstream services parse.
+type ParticipantValidator struct {
+	identity *participantFieldValidator
+	name     *participantFieldValidator
+}
+
+func NewParticipantValidator(conf *config.Config) (*ParticipantValidator, error) {
+	identity, err := newParticipantFieldValidator("identity", conf.ParticipantValidation.Identity)
+	if err != nil {
+		return nil, err
+	}
+	name, err := newParticipantFieldValidator("name", conf.ParticipantValidation.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &ParticipantValidator{
+		identity: identity,
+		name:     name,
+	}, nil
+}
+
+func newParticipantFieldValidator(field string, conf config.ParticipantFieldValidationConfig) (*participantFieldValidator, error) {
+	v := &participantFieldValidator{
+		field:  field,
+		config: conf,
+	}
+	if conf.Pattern != "" {
+		pattern, err := regexp.Compile(conf.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		v.pattern = pattern
+	}
+	return v, nil
+}
+
+// ValidateIdentity returns the normalized identity, or a *ParticipantValidationError. Identities cannot be empty.
+// The rules are for identities clients pick: those of participants the server creates, e.g. egress, agents or SIP
+// callers, use the reserved prefixes and their own formats, they are only checked for invalid characters.
+func (v *ParticipantValidator) ValidateIdentity(identity string, kind livekit.ParticipantInfo_Kind) (string, error) {
+	if identity == "" {
+		return "", ErrIdentityEmpty
+	}
+	if serverIssuedKind(kind) {
+		if err := v.identity.validateCharacters(identity); err != nil {
+			return "", err
+		}
+		return identity, nil
+	}
+	return v.identity.validate(identity)
+}
+
+// ValidateName returns the normalized name, or a *ParticipantValidationError. Names are optional,
+// the length and pattern rules only apply to names that are set
+func (v *ParticipantValidator) ValidateName(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	return v.name.validate(name)
+}
+
+func (v *participantFieldValidator) validate(value string) (string, error) {
+	if err := v.validateCharacters(value); err != nil {
+		return "", err
+	}
+
+	switch v.config.Normalization {
+	case config.UnicodeNormalizationNFC:
+		value = norm.NFC.String(value)
+	case config.UnicodeNormalizationNFKC:
+		value = norm.NFKC.String(value)
+	}
+
+	length := utf8.RuneCountInString(value)
+	if v.config.MinLength > 0 && length < v.config.MinLength {
+		return "", v.error(ParticipantValidationReasonTooShort, fmt.Sprintf("%d characters, at least %d required", length, v.config.MinLength))
+	}
+	if v.config.MaxLength > 0 && length > v.config.MaxLength {
+		return "", v.error(ParticipantValidationReasonTooLong, fmt.Sprintf("%d characters, at most %d allowed", length, v.config.MaxLength))
+	}
+	if v.pattern != nil && !v.pattern.MatchString(value) {
+		return "", v.error(ParticipantValidationReasonPatternMismatch, fmt.Sprintf("does not match %s", v.config.Pattern))
+	}
+	for _, prefix := range v.config.ReservedPrefixes {
+		if prefix != "" && strings.HasPrefix(value, prefix) {
+			return "", v.error(ParticipantValidationReasonReservedPrefix, fmt.Sprintf("starts with reserved prefix %q", prefix))
+		}
+	}
+	return value, nil
+}
+
+func (v *participantFieldValidator) validateCharacters(value string) error {
+	if !utf8.ValidString(value) {
+		return v.error(ParticipantValidationReasonInvalidCharacters, "not valid UTF-8")
+	}
+	if i := strings.IndexFunc(value, unicode.IsControl); i >= 0 {
+		return v.error(ParticipantValidationReasonInvalidCharacters, fmt.Sprintf("control character at byte %d", i))
+	}
+	return nil
+}
+
+func (v *participantFieldValidator) error(reason ParticipantValidationReason, detail string) *ParticipantValidationError {
+	return &ParticipantValidationError{
+		Field:  v.field,
+		Reason: reason,
+		Detail: detail,
+	}
+}
+
+// serverIssuedKind returns true for the participants the server or its services create, with tokens of their own
+func serverIssuedKind(kind livekit.ParticipantInfo_Kind) bool {
+	switch kind {
+	case livekit.ParticipantInfo_INGRESS, livekit.ParticipantInfo_EGRESS, livekit.ParticipantInfo_SIP, livekit.ParticipantInfo_AGENT:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestParticipantValidator(t *testing.T) {
+	v, err := service.NewParticipantValidator(&config.Config{
+		ParticipantValidation: config.ParticipantValidationConfig{
+			Identity: config.ParticipantFieldValidationConfig{
+				Normalization:    config.UnicodeNormalizationNFKC,
+				MaxLength:        8,
+				Pattern:          `^[a-z0-9_-]+$`,
+				ReservedPrefixes: []string{"EG_", "sip_"},
+			},
+			Name: config.ParticipantFieldValidationConfig{
+				Normalization: config.UnicodeNormalizationNFC,
+				MinLength:     2,
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	reason := func(err error) service.ParticipantValidationReason {
+		var validationErr *service.ParticipantValidationError
+		require.True(t, errors.As(err, &validationErr), "%v", err)
+		return validationErr.Reason
+	}
+
+	t.Run("identity", func(t *testing.T) {
+		identity, err := v.ValidateIdentity("alice", livekit.ParticipantInfo_STANDARD)
+		require.NoError(t, err)
+		require.Equal(t, "alice", identity)
+
+		// full width characters fold to ascii before matching
+		identity, err = v.ValidateIdentity("ｂｏｂ", livekit.ParticipantInfo_STANDARD)
+		require.NoError(t, err)
+		require.Equal(t, "bob", identity)
+
+		_, err = v.ValidateIdentity("", livekit.ParticipantInfo_STANDARD)
+		require.ErrorIs(t, err, service.ErrIdentityEmpty)
+
+		_, err = v.ValidateIdentity("al\nice", livekit.ParticipantInfo_STANDARD)
+		require.Equal(t, service.ParticipantValidationReasonInvalidCharacters, reason(err))
+
+		_, err = v.ValidateIdentity("\xffalice", livekit.ParticipantInfo_STANDARD)
+		require.Equal(t, service.ParticipantValidationReasonInvalidCharacters, reason(err))
+
+		_, err = v.ValidateIdentity("alice-and-bob", livekit.ParticipantInfo_STANDARD)
+		require.Equal(t, service.ParticipantValidationReasonTooLong, reason(err))
+
+		_, err = v.ValidateIdentity("al|ice", livekit.ParticipantInfo_STANDARD)
+		require.Equal(t, service.ParticipantValidationReasonPatternMismatch, reason(err))
+
+		_, err = v.ValidateIdentity("sip_bob", livekit.ParticipantInfo_STANDARD)
+		require.Equal(t, service.ParticipantValidationReasonReservedPrefix, reason(err))
+	})
+
+	t.Run("server issued identity", func(t *testing.T) {
+		// reserved prefixes and formats the pattern does not allow are kept for these participants
+		for kind, identity := range map[livekit.ParticipantInfo_Kind]string{
+			livekit.ParticipantInfo_SIP:     "sip_+15550100",
+			livekit.ParticipantInfo_EGRESS:  "EG_hR3kd8Zb",
+			livekit.ParticipantInfo_INGRESS: "IN_Stream Key",
+			livekit.ParticipantInfo_AGENT:   "agent-AJ_xC9mQ2tVh",
+		} {
+			validated, err := v.ValidateIdentity(identity, kind)
+			require.NoError(t, err)
+			require.Equal(t, identity, validated)
+		}
+
+		// clients cannot use them
+		_, err = v.ValidateIdentity("sip_+1", livekit.ParticipantInfo_STANDARD)
+		require.Equal(t, service.ParticipantValidationReasonPatternMismatch, reason(err))
+
+		_, err = v.ValidateIdentity("sip_+1555\n0100", livekit.ParticipantInfo_SIP)
+		require.Equal(t, service.ParticipantValidationReasonInvalidCharacters, reason(err))
+	})
+
+	t.Run("name", func(t *testing.T) {
+		// names are optional
+		name, err := v.ValidateName("")
+		require.NoError(t, err)
+		require.Empty(t, name)
+
+		// e followed by a combining acute accent composes into a single character
+		_, err = v.ValidateName("e\u0301")
+		require.Equal(t, service.ParticipantValidationReasonTooShort, reason(err))
+
+		name, err = v.ValidateName("Rene\u0301")
+		require.NoError(t, err)
+		require.Equal(t, "Ren\u00e9", name)
+	})
+
+	t.Run("without rules", func(t *testing.T) {
+		v, err := service.NewParticipantValidator(&config.Config{})
+		require.NoError(t, err)
+
+		identity, err := v.ValidateIdentity("EG_ｂｏｂ|TR_1", livekit.ParticipantInfo_STANDARD)
+		require.NoError(t, err)
+		require.Equal(t, "EG_ｂｏｂ|TR_1", identity)
+
+		_, err = v.ValidateIdentity("bob\x00", livekit.ParticipantInfo_STANDARD)
+		require.Equal(t, service.ParticipantValidationReasonInvalidCharacters, reason(err))
+	})
+}
@@ -0,0 +1,191 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// a redirected participant has this long to resume its session with the resume token it was sent
+const resumeTokenTTL = 2 * time.Minute
+
+// RedirectParticipant asks a participant to resume its session through another URL, e.g. the signal URL of the
+// region it moved to. The participant stays in the room on this node, its resume is relayed back here.
+func (r *RoomManager) RedirectParticipant(ctx context.Context, req *RedirectParticipantRequest) (*livekit.ParticipantInfo, error) {
+	room, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Url == "" {
+		return nil, ErrRedirectTargetMissing
+	}
+
+	token, err := r.createResumeToken(room, participant, livekit.NodeID(r.currentNode.Id))
+	if err != nil {
+		return nil, err
+	}
+	if !participant.IssueRedirect(&livekit.RegionInfo{Region: req.Region, Url: req.Url}, token) {
+		return nil, ErrRedirectNotConnected
+	}
+	return participant.ToProto(), nil
+}
+
+// RedirectRoom moves the room to another node, e.g. to rebalance the cluster, and redirects its participants there.
+// They resume their sessions on that node with the resume tokens they are sent. The node is picked as for a drain
+// when the request does not name one.
+func (r *RoomManager) RedirectRoom(ctx context.Context, req *RedirectRoomRequest) (*RedirectRoomResponse, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	nodes, err := r.otherNodes()
+	if err != nil {
+		return nil, err
+	}
+	var node *livekit.Node
+	if req.NodeID != "" {
+		for _, n := range nodes {
+			if n.Id == req.NodeID {
+				node = n
+				break
+			}
+		}
+		if node == nil {
+			return nil, ErrRedirectNodeInvalid
+		}
+	} else if node, err = r.drainSelector.SelectNode(nodes); err != nil {
+		return nil, ErrRedirectNoNode
+	}
+
+	if err := r.router.SetNodeForRoom(ctx, room.Name(), livekit.NodeID(node.Id)); err != nil {
+		return nil, err
+	}
+
+	target := r.redirectTarget(node)
+	if req.Url != "" {
+		target = &livekit.RegionInfo{Region: node.Region, Url: req.Url}
+	}
+	redirected, reconnected := r.redirectRoom(ctx, room, node, target)
+	room.Logger.Infow("room redirected", "nodeID", node.Id, "redirected", redirected, "reconnected", reconnected)
+	return &RedirectRoomResponse{
+		NodeID:      node.Id,
+		Redirected:  redirected,
+		Reconnected: reconnected,
+	}, nil
+}
+
+// redirectRoom hands off the sessions of the participants of a room assigned to node, and redirects them to target,
+// the URL the client is connected to when nil. Participants whose transports never connected have no session to
+// resume and are asked to join again. The room state is left to that node.
+func (r *RoomManager) redirectRoom(ctx context.Context, room *rtc.Room, node *livekit.Node, target *livekit.RegionInfo) (redirected int, reconnected int) {
+	r.lock.Lock()
+	r.movedRooms[room.Name()] = room
+	r.lock.Unlock()
+
+	for _, p := range room.GetParticipants() {
+		if r.redirectToNode(ctx, room, p, livekit.NodeID(node.Id), target) {
+			redirected++
+		} else {
+			p.IssueFullReconnect(types.ParticipantCloseReasonMigrationRequested)
+			reconnected++
+		}
+	}
+	return
+}
+
+func (r *RoomManager) redirectToNode(
+	ctx context.Context,
+	room *rtc.Room,
+	participant types.LocalParticipant,
+	nodeID livekit.NodeID,
+	target *livekit.RegionInfo,
+) bool {
+	if err := r.handOffParticipantSession(ctx, room, participant, nodeID); err != nil {
+		participant.GetLogger().Warnw("could not hand off participant session", err, "nodeID", nodeID)
+		return false
+	}
+	token, err := r.createResumeToken(room, participant, nodeID)
+	if err != nil {
+		participant.GetLogger().Warnw("could not create resume token", err)
+		return false
+	}
+	return participant.IssueRedirect(target, token)
+}
+
+// redirectTarget returns the signal URL of the region of node, nil when it is not configured
+func (r *RoomManager) redirectTarget(node *livekit.Node) *livekit.RegionInfo {
+	for _, region := range r.config.NodeSelector.Regions {
+		if region.Name == node.Region && region.URL != "" {
+			return &livekit.RegionInfo{Region: region.Name, Url: region.URL}
+		}
+	}
+	return nil
+}
+
+func (r *RoomManager) otherNodes() ([]*livekit.Node, error) {
+	all, err := r.router.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*livekit.Node
+	for _, node := range all {
+		if node.Id != r.currentNode.Id {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// createResumeToken returns a token that can only resume the session of the participant, on nodeID. It carries the
+// grants of the participant, the node it resumes on refreshes it with a regular token. It is signed with the key of
+// the join token of the participant, a participant whose key was revoked cannot resume.
+func (r *RoomManager) createResumeToken(room *rtc.Room, participant types.LocalParticipant, nodeID livekit.NodeID) (string, error) {
+	key := participant.GetAPIKey()
+	secret := r.config.Reloadable().Keys[key]
+	if key == "" || secret == "" {
+		return "", ErrResumeKeyMissing
+	}
+
+	grants := participant.ClaimGrants()
+	return signToken(key, secret, &auth.ClaimGrants{
+		Identity: string(participant.Identity()),
+		Name:     grants.Name,
+		Metadata: grants.Metadata,
+		Video:    grants.Video,
+	}, resumeTokenTTL, tokenClaims{
+		SubscribeFilter: participant.GetSubscribeFilter(),
+		Resume: &ResumeGrant{
+			Room:          string(room.Name()),
+			ParticipantID: string(participant.ID()),
+			NodeID:        string(nodeID),
+		},
+	})
+}
+
+// isMigratedRoom returns true for a room moved to another node by a drain or redirect, the other node owns its state
+func (r *RoomManager) isMigratedRoom(room *rtc.Room) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.movedRooms[room.Name()] == room
+}
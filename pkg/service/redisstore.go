@@ -16,6 +16,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -31,6 +32,8 @@ import (
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/utils"
 
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/version"
 )
 
@@ -40,12 +43,25 @@ const (
 	// RoomsKey is hash of room_name => Room proto
 	RoomsKey        = "rooms"
 	RoomInternalKey = "room_internal"
+	// RoomOptionsKey is hash of room_name => JSON encoded rtc.RoomOptions
+	RoomOptionsKey = "room_options"
 
 	// EgressKey is a hash of egressID => egress info
 	EgressKey        = "egress"
 	EndedEgressKey   = "ended_egress"
 	RoomEgressPrefix = "egress:room:"
 
+	// EgressAPIKeyKey is a hash of egressID => API key the egress was started with
+	EgressAPIKeyKey = "egress_api_key"
+	// APIKeyEgressUsagePrefix is a hash of API key => seconds of egress ended in the period
+	APIKeyEgressUsagePrefix = "api_key_egress_usage:"
+
+	// RoomAPIKeyKey is a hash of room_name => API key owning the room
+	RoomAPIKeyKey = "room_api_key"
+	// APIKeyRoomsKey and APIKeyParticipantsKey are hashes of API key => rooms it owns, and participants in them
+	APIKeyRoomsKey        = "api_key_rooms"
+	APIKeyParticipantsKey = "api_key_participants"
+
 	// IngressKey is a hash of ingressID => ingress info
 	IngressKey         = "ingress"
 	StreamKeyKey       = "{ingress}_stream_key"
@@ -58,6 +74,30 @@ const (
 	// RoomParticipantsPrefix is hash of participant_name => ParticipantInfo
 	RoomParticipantsPrefix = "room_participants:"
 
+	// RoomParticipantSessionsPrefix is hash of participant_name => JSON encoded ParticipantSession
+	RoomParticipantSessionsPrefix = "room_participant_sessions:"
+
+	// RoomAttachmentsPrefix is hash of attachmentID => JSON encoded Attachment
+	RoomAttachmentsPrefix = "room_attachments:"
+
+	// RoomParticipantBansPrefix is hash of participant_name => JSON encoded ParticipantBan.
+	// it is kept when the room is deleted
+	RoomParticipantBansPrefix = "room_participant_bans:"
+
+	// RoomPasscodeAttemptsPrefix is a counter of the attempts of an identity at the passcode of a room, it
+	// expires with the lockout
+	RoomPasscodeAttemptsPrefix = "room_passcode_attempts:"
+
+	// RoomAuditRecordsPrefix is a list of JSON encoded AuditRecord, oldest first
+	RoomAuditRecordsPrefix = "room_audit_records:"
+
+	// EgressSpeakerMarkersPrefix is the JSON encoded speaker markers of an egress, it expires with their retention
+	EgressSpeakerMarkersPrefix = "egress_speaker_markers:"
+
+	// ParticipantTrackStatsHistoryPrefix is the JSON encoded track stats history of a participant that left the room,
+	// it expires with its retention
+	ParticipantTrackStatsHistoryPrefix = "participant_track_stats_history:"
+
 	// RoomLockPrefix is a simple key containing a provided lock uid
 	RoomLockPrefix = "room_lock:"
 
@@ -65,10 +105,11 @@ const (
 )
 
 type RedisStore struct {
-	rc           redis.UniversalClient
-	unlockScript *redis.Script
-	ctx          context.Context
-	done         chan struct{}
+	rc                     redis.UniversalClient
+	unlockScript           *redis.Script
+	passcodeAttemptsScript *redis.Script
+	ctx                    context.Context
+	done                   chan struct{}
 }
 
 func NewRedisStore(rc redis.UniversalClient) *RedisStore {
@@ -76,11 +117,17 @@ func NewRedisStore(rc redis.UniversalClient) *RedisStore {
 						return redis.call("del", KEYS[1])
 					 else return 0
 					 end`
+	passcodeAttemptsScript := `local attempts = redis.call("incr", KEYS[1])
+								if attempts <= tonumber(ARGV[1]) then
+									redis.call("pexpire", KEYS[1], ARGV[2])
+								end
+								return attempts`
 
 	return &RedisStore{
-		ctx:          context.Background(),
-		rc:           rc,
-		unlockScript: redis.NewScript(unlockScript),
+		ctx:                    context.Background(),
+		rc:                     rc,
+		unlockScript:           redis.NewScript(unlockScript),
+		passcodeAttemptsScript: redis.NewScript(passcodeAttemptsScript),
 	}
 }
 
@@ -189,6 +236,67 @@ func (s *RedisStore) LoadRoom(_ context.Context, roomName livekit.RoomName, incl
 	return room, internal, nil
 }
 
+func (s *RedisStore) StoreRoomOptions(_ context.Context, roomName livekit.RoomName, options *rtc.RoomOptions) error {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	if err = s.rc.HSet(s.ctx, RoomOptionsKey, string(roomName), data).Err(); err != nil {
+		return err
+	}
+	if options.APIKey == "" {
+		return nil
+	}
+
+	// the room is counted once, when its owner is first recorded
+	added, err := s.rc.HSetNX(s.ctx, RoomAPIKeyKey, string(roomName), options.APIKey).Result()
+	if err != nil || !added {
+		return err
+	}
+	participants, err := s.rc.HLen(s.ctx, RoomParticipantsPrefix+string(roomName)).Result()
+	if err != nil {
+		return err
+	}
+	pp := s.rc.Pipeline()
+	pp.HIncrBy(s.ctx, APIKeyRoomsKey, options.APIKey, 1)
+	pp.HIncrBy(s.ctx, APIKeyParticipantsKey, options.APIKey, participants)
+	_, err = pp.Exec(s.ctx)
+	return err
+}
+
+func (s *RedisStore) LoadRoomOptions(_ context.Context, roomName livekit.RoomName) (*rtc.RoomOptions, error) {
+	options := &rtc.RoomOptions{}
+	data, err := s.rc.HGet(s.ctx, RoomOptionsKey, string(roomName)).Result()
+	if err == redis.Nil {
+		return options, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal([]byte(data), options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+func (s *RedisStore) ListRoomOptions(_ context.Context) (map[livekit.RoomName]*rtc.RoomOptions, error) {
+	items, err := s.rc.HGetAll(s.ctx, RoomOptionsKey).Result()
+	if err != nil && err != redis.Nil {
+		return nil, errors.Wrap(err, "could not get room options")
+	}
+
+	options := make(map[livekit.RoomName]*rtc.RoomOptions, len(items))
+	for roomName, data := range items {
+		o := &rtc.RoomOptions{}
+		if err = json.Unmarshal([]byte(data), o); err != nil {
+			return nil, err
+		}
+		options[livekit.RoomName(roomName)] = o
+	}
+	return options, nil
+}
+
 func (s *RedisStore) ListRooms(_ context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
 	var items []string
 	var err error
@@ -230,11 +338,31 @@ func (s *RedisStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName)
 		return nil
 	}
 
+	apiKey, err := s.rc.HGet(s.ctx, RoomAPIKeyKey, string(roomName)).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
 	pp := s.rc.Pipeline()
 	pp.HDel(s.ctx, RoomsKey, string(roomName))
 	pp.HDel(s.ctx, RoomInternalKey, string(roomName))
+	pp.HDel(s.ctx, RoomOptionsKey, string(roomName))
+	ownerDeleted := pp.HDel(s.ctx, RoomAPIKeyKey, string(roomName))
+	participants := pp.HLen(s.ctx, RoomParticipantsPrefix+string(roomName))
 	pp.Del(s.ctx, RoomParticipantsPrefix+string(roomName))
+	pp.Del(s.ctx, RoomParticipantSessionsPrefix+string(roomName))
+	pp.Del(s.ctx, RoomAttachmentsPrefix+string(roomName))
 
+	if _, err = pp.Exec(s.ctx); err != nil {
+		return err
+	}
+	// a room deleted concurrently is uncounted once
+	if apiKey == "" || ownerDeleted.Val() == 0 {
+		return nil
+	}
+	pp = s.rc.Pipeline()
+	pp.HIncrBy(s.ctx, APIKeyRoomsKey, apiKey, -1)
+	pp.HIncrBy(s.ctx, APIKeyParticipantsKey, apiKey, -participants.Val())
 	_, err = pp.Exec(s.ctx)
 	return err
 }
@@ -287,7 +415,11 @@ func (s *RedisStore) StoreParticipant(_ context.Context, roomName livekit.RoomNa
 		return err
 	}
 
-	return s.rc.HSet(s.ctx, key, participant.Identity, data).Err()
+	added, err := s.rc.HSet(s.ctx, key, participant.Identity, data).Result()
+	if err != nil || added == 0 {
+		return err
+	}
+	return s.addAPIKeyParticipants(roomName, 1)
 }
 
 func (s *RedisStore) LoadParticipant(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
@@ -327,9 +459,49 @@ func (s *RedisStore) ListParticipants(_ context.Context, roomName livekit.RoomNa
 }
 
 func (s *RedisStore) DeleteParticipant(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
-	key := RoomParticipantsPrefix + string(roomName)
+	pp := s.rc.Pipeline()
+	deleted := pp.HDel(s.ctx, RoomParticipantsPrefix+string(roomName), string(identity))
+	pp.HDel(s.ctx, RoomParticipantSessionsPrefix+string(roomName), string(identity))
+
+	if _, err := pp.Exec(s.ctx); err != nil || deleted.Val() == 0 {
+		return err
+	}
+	return s.addAPIKeyParticipants(roomName, -1)
+}
+
+// addAPIKeyParticipants counts participants joining or leaving the room in the usage of the key owning it
+func (s *RedisStore) addAPIKeyParticipants(roomName livekit.RoomName, delta int64) error {
+	apiKey, err := s.rc.HGet(s.ctx, RoomAPIKeyKey, string(roomName)).Result()
+	if err == redis.Nil {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return s.rc.HIncrBy(s.ctx, APIKeyParticipantsKey, apiKey, delta).Err()
+}
 
-	return s.rc.HDel(s.ctx, key, string(identity)).Err()
+func (s *RedisStore) StoreParticipantSession(_ context.Context, session *ParticipantSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return s.rc.HSet(s.ctx, RoomParticipantSessionsPrefix+string(session.Room), string(session.Identity), data).Err()
+}
+
+func (s *RedisStore) LoadParticipantSession(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*ParticipantSession, error) {
+	data, err := s.rc.HGet(s.ctx, RoomParticipantSessionsPrefix+string(roomName), string(identity)).Result()
+	if err == redis.Nil {
+		return nil, ErrParticipantSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	session := &ParticipantSession{}
+	if err = json.Unmarshal([]byte(data), session); err != nil {
+		return nil, err
+	}
+	return session, nil
 }
 
 func (s *RedisStore) StoreEgress(_ context.Context, info *livekit.EgressInfo) error {
@@ -817,6 +989,103 @@ func (s *RedisStore) DeleteIngress(_ context.Context, info *livekit.IngressInfo)
 	return nil
 }
 
+func (s *RedisStore) StoreAuditRecord(_ context.Context, record *AuditRecord, maxRecords int, retention time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	key := RoomAuditRecordsPrefix + record.Room
+	tx := s.rc.TxPipeline()
+	tx.RPush(s.ctx, key, data)
+	if maxRecords > 0 {
+		tx.LTrim(s.ctx, key, int64(-maxRecords), -1)
+	}
+	if retention > 0 {
+		tx.Expire(s.ctx, key, retention)
+	}
+	if _, err = tx.Exec(s.ctx); err != nil {
+		return errors.Wrap(err, "could not store audit record")
+	}
+	return nil
+}
+
+func (s *RedisStore) ListAuditRecords(_ context.Context, roomName livekit.RoomName) ([]*AuditRecord, error) {
+	items, err := s.rc.LRange(s.ctx, RoomAuditRecordsPrefix+string(roomName), 0, -1).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	records := make([]*AuditRecord, 0, len(items))
+	for _, item := range items {
+		record := &AuditRecord{}
+		if err := json.Unmarshal([]byte(item), record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (s *RedisStore) StoreSpeakerMarkers(_ context.Context, egressID string, markers []rtc.SpeakerMarker, ttl time.Duration) error {
+	data, err := json.Marshal(markers)
+	if err != nil {
+		return err
+	}
+	if err = s.rc.Set(s.ctx, EgressSpeakerMarkersPrefix+egressID, data, ttl).Err(); err != nil {
+		return errors.Wrap(err, "could not store speaker markers")
+	}
+	return nil
+}
+
+func (s *RedisStore) LoadSpeakerMarkers(_ context.Context, egressID string) ([]rtc.SpeakerMarker, error) {
+	data, err := s.rc.Get(s.ctx, EgressSpeakerMarkersPrefix+egressID).Result()
+	switch err {
+	case nil:
+		markers := []rtc.SpeakerMarker{}
+		if err = json.Unmarshal([]byte(data), &markers); err != nil {
+			return nil, err
+		}
+		return markers, nil
+	case redis.Nil:
+		return nil, ErrSpeakerMarkersNotFound
+	default:
+		return nil, err
+	}
+}
+
+func (s *RedisStore) StoreTrackStatsHistory(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, tracks []*types.TrackStatsSeries, ttl time.Duration) error {
+	data, err := json.Marshal(tracks)
+	if err != nil {
+		return err
+	}
+	if err = s.rc.Set(s.ctx, trackStatsHistoryKey(roomName, identity), data, ttl).Err(); err != nil {
+		return errors.Wrap(err, "could not store track stats history")
+	}
+	return nil
+}
+
+func (s *RedisStore) LoadTrackStatsHistory(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) ([]*types.TrackStatsSeries, error) {
+	data, err := s.rc.Get(s.ctx, trackStatsHistoryKey(roomName, identity)).Result()
+	switch err {
+	case nil:
+		tracks := []*types.TrackStatsSeries{}
+		if err = json.Unmarshal([]byte(data), &tracks); err != nil {
+			return nil, err
+		}
+		return tracks, nil
+	case redis.Nil:
+		return nil, ErrTrackStatsHistoryNotFound
+	default:
+		return nil, err
+	}
+}
+
+// trackStatsHistoryKey is prefixed with the length of the room name, as passcodeAttemptsKey
+func trackStatsHistoryKey(roomName livekit.RoomName, identity livekit.ParticipantIdentity) string {
+	return ParticipantTrackStatsHistoryPrefix + strconv.Itoa(len(roomName)) + ":" + string(roomName) + ":" + string(identity)
+}
+
 func (s *RedisStore) loadOne(ctx context.Context, key, id string, info proto.Message, notFoundErr error) error {
 	data, err := s.rc.HGet(s.ctx, key, id).Result()
 	switch err {
@@ -908,3 +1177,152 @@ func (s *RedisStore) ListSIPDispatchRule(ctx context.Context) (infos []*livekit.
 
 	return infos, err
 }
+
+func (s *RedisStore) StoreEgressAPIKey(_ context.Context, egressID string, apiKey string) error {
+	return s.rc.HSet(s.ctx, EgressAPIKeyKey, egressID, apiKey).Err()
+}
+
+func (s *RedisStore) LoadEgressAPIKey(_ context.Context, egressID string) (string, error) {
+	apiKey, err := s.rc.HGet(s.ctx, EgressAPIKeyKey, egressID).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return apiKey, err
+}
+
+func (s *RedisStore) AddAPIKeyEgressUsage(_ context.Context, apiKey string, period string, egressID string, duration time.Duration) error {
+	key := APIKeyEgressUsagePrefix + period
+
+	pp := s.rc.Pipeline()
+	pp.HIncrBy(s.ctx, key, apiKey, int64(duration/time.Second))
+	pp.HDel(s.ctx, EgressAPIKeyKey, egressID)
+	_, err := pp.Exec(s.ctx)
+	return err
+}
+
+func (s *RedisStore) LoadAPIKeyEgressUsage(_ context.Context, apiKey string, period string) (time.Duration, error) {
+	seconds, err := s.rc.HGet(s.ctx, APIKeyEgressUsagePrefix+period, apiKey).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func (s *RedisStore) LoadAPIKeyRoomUsage(_ context.Context, apiKey string) (int32, int32, error) {
+	rooms, err := s.rc.HGet(s.ctx, APIKeyRoomsKey, apiKey).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, 0, err
+	}
+	participants, err := s.rc.HGet(s.ctx, APIKeyParticipantsKey, apiKey).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, 0, err
+	}
+	return int32(rooms), int32(participants), nil
+}
+
+func (s *RedisStore) IncrementPasscodeAttempts(
+	_ context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	maxAttempts int,
+	ttl time.Duration,
+) (int, error) {
+	key := passcodeAttemptsKey(roomName, identity)
+	attempts, err := s.passcodeAttemptsScript.Run(s.ctx, s.rc, []string{key}, maxAttempts, ttl.Milliseconds()).Int()
+	if err != nil {
+		return 0, err
+	}
+	return attempts, nil
+}
+
+func (s *RedisStore) DeletePasscodeAttempts(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	return s.rc.Del(s.ctx, passcodeAttemptsKey(roomName, identity)).Err()
+}
+
+// passcodeAttemptsKey is prefixed with the length of the room name, so that the names of rooms and identities
+// containing the separator cannot run into each other
+func passcodeAttemptsKey(roomName livekit.RoomName, identity livekit.ParticipantIdentity) string {
+	return RoomPasscodeAttemptsPrefix + strconv.Itoa(len(roomName)) + ":" + string(roomName) + ":" + string(identity)
+}
+
+func (s *RedisStore) StoreParticipantBan(_ context.Context, ban *ParticipantBan) error {
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return err
+	}
+	return s.rc.HSet(s.ctx, RoomParticipantBansPrefix+ban.Room, ban.Identity, data).Err()
+}
+
+func (s *RedisStore) ListParticipantBans(_ context.Context, roomName livekit.RoomName) ([]*ParticipantBan, error) {
+	items, err := s.rc.HVals(s.ctx, RoomParticipantBansPrefix+string(roomName)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make([]*ParticipantBan, 0, len(items))
+	for _, item := range items {
+		ban := &ParticipantBan{}
+		if err := json.Unmarshal([]byte(item), ban); err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	return bans, nil
+}
+
+func (s *RedisStore) DeleteParticipantBan(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	deleted, err := s.rc.HDel(s.ctx, RoomParticipantBansPrefix+string(roomName), string(identity)).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrParticipantBanNotFound
+	}
+	return nil
+}
+
+func (s *RedisStore) StoreAttachment(_ context.Context, attachment *Attachment) error {
+	data, err := json.Marshal(attachment)
+	if err != nil {
+		return err
+	}
+	return s.rc.HSet(s.ctx, RoomAttachmentsPrefix+attachment.Room, attachment.ID, data).Err()
+}
+
+func (s *RedisStore) LoadAttachment(_ context.Context, roomName livekit.RoomName, attachmentID string) (*Attachment, error) {
+	data, err := s.rc.HGet(s.ctx, RoomAttachmentsPrefix+string(roomName), attachmentID).Result()
+	if err == redis.Nil {
+		return nil, ErrAttachmentNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	attachment := &Attachment{}
+	if err = json.Unmarshal([]byte(data), attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (s *RedisStore) ListAttachments(_ context.Context, roomName livekit.RoomName) ([]*Attachment, error) {
+	items, err := s.rc.HVals(s.ctx, RoomAttachmentsPrefix+string(roomName)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	attachments := make([]*Attachment, 0, len(items))
+	for _, item := range items {
+		attachment := &Attachment{}
+		if err := json.Unmarshal([]byte(item), attachment); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+func (s *RedisStore) DeleteAttachment(_ context.Context, roomName livekit.RoomName, attachmentID string) error {
+	return s.rc.HDel(s.ctx, RoomAttachmentsPrefix+string(roomName), attachmentID).Err()
+}
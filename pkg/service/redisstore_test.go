@@ -27,6 +27,7 @@ import (
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/utils"
 
+	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/service"
 )
 
@@ -105,6 +106,95 @@ func TestParticipantPersistence(t *testing.T) {
 	require.Equal(t, err, service.ErrParticipantNotFound)
 }
 
+func TestParticipantSessionPersistence(t *testing.T) {
+	ctx := context.Background()
+	rs := service.NewRedisStore(redisClient())
+
+	roomName := livekit.RoomName("room1")
+	_ = rs.DeleteRoom(ctx, roomName)
+
+	session := &service.ParticipantSession{
+		Room:          roomName,
+		Identity:      "test",
+		ParticipantID: "PA_test",
+		NodeID:        "ND_test",
+		SubscriptionPermission: &livekit.SubscriptionPermission{
+			TrackPermissions: []*livekit.TrackPermission{{ParticipantIdentity: "other", AllTracks: true}},
+		},
+	}
+	require.NoError(t, rs.StoreParticipantSession(ctx, session))
+
+	loaded, err := rs.LoadParticipantSession(ctx, roomName, "test")
+	require.NoError(t, err)
+	require.Equal(t, session.ParticipantID, loaded.ParticipantID)
+	require.Equal(t, session.NodeID, loaded.NodeID)
+	require.Equal(t, "other", loaded.SubscriptionPermission.TrackPermissions[0].ParticipantIdentity)
+
+	// the session goes with its participant
+	require.NoError(t, rs.DeleteParticipant(ctx, roomName, "test"))
+	_, err = rs.LoadParticipantSession(ctx, roomName, "test")
+	require.Equal(t, service.ErrParticipantSessionNotFound, err)
+}
+
+func TestPasscodeAttempts(t *testing.T) {
+	ctx := context.Background()
+	rs := service.NewRedisStore(redisClient())
+
+	roomName := livekit.RoomName("room1")
+	require.NoError(t, rs.DeletePasscodeAttempts(ctx, roomName, "test"))
+
+	for i := 1; i <= 3; i++ {
+		attempts, err := rs.IncrementPasscodeAttempts(ctx, roomName, "test", 2, 200*time.Millisecond)
+		require.NoError(t, err)
+		require.Equal(t, i, attempts)
+	}
+	// attempts beyond the max do not push the expiry back
+	time.Sleep(250 * time.Millisecond)
+	attempts, err := rs.IncrementPasscodeAttempts(ctx, roomName, "test", 2, 200*time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts)
+
+	require.NoError(t, rs.DeletePasscodeAttempts(ctx, roomName, "test"))
+	attempts, err = rs.IncrementPasscodeAttempts(ctx, roomName, "test", 2, 200*time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts)
+}
+
+func TestAPIKeyRoomUsage(t *testing.T) {
+	ctx := context.Background()
+	rs := service.NewRedisStore(redisClient())
+
+	roomName := livekit.RoomName("room1")
+	apiKey := utils.NewGuid("API")
+	_ = rs.DeleteRoom(ctx, roomName)
+
+	require.NoError(t, rs.StoreRoom(ctx, &livekit.Room{Name: string(roomName)}, nil))
+	require.NoError(t, rs.StoreParticipant(ctx, roomName, &livekit.ParticipantInfo{Identity: "first"}))
+	// participants stored before the owner are counted with the room
+	require.NoError(t, rs.StoreRoomOptions(ctx, roomName, &rtc.RoomOptions{APIKey: apiKey}))
+	require.NoError(t, rs.StoreRoomOptions(ctx, roomName, &rtc.RoomOptions{APIKey: apiKey}))
+	require.NoError(t, rs.StoreParticipant(ctx, roomName, &livekit.ParticipantInfo{Identity: "second"}))
+	require.NoError(t, rs.StoreParticipant(ctx, roomName, &livekit.ParticipantInfo{Identity: "second", Name: "updated"}))
+
+	rooms, participants, err := rs.LoadAPIKeyRoomUsage(ctx, apiKey)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rooms)
+	require.EqualValues(t, 2, participants)
+
+	require.NoError(t, rs.DeleteParticipant(ctx, roomName, "first"))
+	require.NoError(t, rs.DeleteParticipant(ctx, roomName, "first"))
+	rooms, participants, err = rs.LoadAPIKeyRoomUsage(ctx, apiKey)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rooms)
+	require.EqualValues(t, 1, participants)
+
+	require.NoError(t, rs.DeleteRoom(ctx, roomName))
+	rooms, participants, err = rs.LoadAPIKeyRoomUsage(ctx, apiKey)
+	require.NoError(t, err)
+	require.Zero(t, rooms)
+	require.Zero(t, participants)
+}
+
 func TestRoomLock(t *testing.T) {
 	ctx := context.Background()
 	rs := service.NewRedisStore(redisClient())
@@ -0,0 +1,243 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pion/turn/v2"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/atomic"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/version"
+)
+
+// time for nodes hosting rooms to see a relay node draining, before its TURN server is closed
+const relayNodeDrainDelay = 5 * time.Second
+
+// RelayServer runs a node with the relay role. It registers with the cluster and relays the media of participants
+// to the nodes hosting their rooms through a TURN server, it does not host rooms or handle signaling.
+// Its HTTP port only answers health checks
+type RelayServer struct {
+	config      *config.Config
+	router      routing.Router
+	authHandler turn.AuthHandler
+	currentNode routing.LocalNode
+	httpServer  *http.Server
+	promServer  *http.Server
+	turnServer  *turn.Server
+	running     atomic.Bool
+	doneChan    chan struct{}
+	closedChan  chan struct{}
+}
+
+func NewRelayServer(
+	conf *config.Config,
+	router routing.Router,
+	authHandler turn.AuthHandler,
+	currentNode routing.LocalNode,
+) (*RelayServer, error) {
+	if !conf.IsRelayNode() {
+		return nil, errors.New("node role is not relay")
+	}
+
+	s := &RelayServer{
+		config:      conf,
+		router:      router,
+		authHandler: authHandler,
+		currentNode: currentNode,
+		closedChan:  make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.healthCheck)
+	s.httpServer = &http.Server{
+		Handler: mux,
+	}
+	if conf.PrometheusPort > 0 {
+		s.promServer = &http.Server{
+			Handler: promhttp.Handler(),
+		}
+	}
+
+	if err := router.RemoveDeadNodes(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *RelayServer) Node() routing.LocalNode {
+	return s.currentNode
+}
+
+func (s *RelayServer) IsRunning() bool {
+	return s.running.Load()
+}
+
+func (s *RelayServer) Start() error {
+	if s.running.Load() {
+		return errors.New("already running")
+	}
+	s.doneChan = make(chan struct{})
+
+	addresses := s.config.BindAddresses
+	if addresses == nil {
+		addresses = []string{""}
+	}
+	listeners := make([]net.Listener, 0, len(addresses))
+	promListeners := make([]net.Listener, 0, len(addresses))
+	for _, addr := range addresses {
+		ln, err := net.Listen("tcp", net.JoinHostPort(addr, strconv.Itoa(int(s.config.Port))))
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, ln)
+
+		if s.promServer != nil {
+			ln, err = net.Listen("tcp", net.JoinHostPort(addr, strconv.Itoa(int(s.config.PrometheusPort))))
+			if err != nil {
+				return err
+			}
+			promListeners = append(promListeners, ln)
+		}
+	}
+
+	turnServer, err := NewTurnServer(s.config, s.authHandler, true)
+	if err != nil {
+		return err
+	}
+	s.turnServer = turnServer
+
+	if err := s.router.RegisterNode(); err != nil {
+		_ = s.turnServer.Close()
+		return err
+	}
+	defer func() {
+		if err := s.router.UnregisterNode(); err != nil {
+			logger.Errorw("could not unregister node", err)
+		}
+	}()
+	if err := s.router.Start(); err != nil {
+		_ = s.turnServer.Close()
+		return err
+	}
+
+	values := []interface{}{
+		"portHttp", s.config.Port,
+		"nodeID", s.currentNode.Id,
+		"nodeIP", s.currentNode.Ip,
+		"version", version.Version,
+	}
+	if s.config.PrometheusPort != 0 {
+		values = append(values, "portPrometheus", s.config.PrometheusPort)
+	}
+	if s.config.Region != "" {
+		values = append(values, "region", s.config.Region)
+	}
+	logger.Infow("starting LiveKit relay node", values...)
+
+	for _, promLn := range promListeners {
+		go s.promServer.Serve(promLn)
+	}
+	httpGroup := &errgroup.Group{}
+	for _, ln := range listeners {
+		l := ln
+		httpGroup.Go(func() error {
+			return s.httpServer.Serve(l)
+		})
+	}
+	go func() {
+		if err := httpGroup.Wait(); err != http.ErrServerClosed {
+			logger.Errorw("could not start server", err)
+			s.Stop(true)
+		}
+	}()
+
+	s.running.Store(true)
+
+	<-s.doneChan
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_ = s.httpServer.Shutdown(ctx)
+	if s.promServer != nil {
+		_ = s.promServer.Shutdown(ctx)
+	}
+	_ = s.turnServer.Close()
+
+	close(s.closedChan)
+	return nil
+}
+
+// Stop marks the node as draining so that it is no longer given to participants, then closes the TURN server
+func (s *RelayServer) Stop(force bool) {
+	s.router.Drain()
+	if !force {
+		time.Sleep(relayNodeDrainDelay)
+	}
+
+	if !s.running.Swap(false) {
+		return
+	}
+
+	s.router.Stop()
+	close(s.doneChan)
+
+	<-s.closedChan
+}
+
+// ReloadConfig applies the settings of next that can be changed while running, the API keys TURN allocations are
+// authenticated with and log levels
+func (s *RelayServer) ReloadConfig(next *config.Config) error {
+	changed, ignored, err := s.config.Reload(next)
+	if err != nil {
+		return err
+	}
+	if ignored {
+		logger.Warnw("config changes other than log levels and api keys require a restart", nil)
+	}
+	logger.Infow("config reloaded", "changed", changed)
+	return nil
+}
+
+func (s *RelayServer) healthCheck(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var updatedAt time.Time
+	if s.currentNode.Stats != nil {
+		updatedAt = time.Unix(s.currentNode.Stats.UpdatedAt, 0)
+	}
+	if !s.running.Load() || time.Since(updatedAt) > 4*time.Second {
+		w.WriteHeader(http.StatusNotAcceptable)
+		_, _ = w.Write([]byte(fmt.Sprintf("Not Ready\nNode Updated At %s", updatedAt)))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+}
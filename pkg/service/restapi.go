@@ -0,0 +1,615 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/twitchtv/twirp"
+	"github.com/twitchtv/twirp/ctxsetters"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/version"
+)
+
+const restAPIPrefix = "/api/v1"
+
+// RESTHandler serves the room, participant and egress management APIs as REST resources with JSON bodies, for
+// clients without protobuf tooling. Requests are served by the Twirp handlers, with the same server hooks and
+// JSON encoding, so errors are Twirp errors with the matching HTTP status. The OpenAPI document of the routes is
+// served at /api/v1/openapi.json
+type RESTHandler struct {
+	hooks   *twirp.ServerHooks
+	routes  []*restRoute
+	openAPI []byte
+}
+
+type restRoute struct {
+	method   string
+	path     string
+	segments []string
+	service  string
+	rpc      string
+	// operation ID of the OpenAPI document, the Twirp method when not set
+	operation string
+	summary   string
+	query     []restQueryParam
+	request   proto.Message
+	response  proto.Message
+	handle    func(ctx context.Context, req *restRequest) (proto.Message, error)
+}
+
+func (r *restRoute) operationID() string {
+	if r.operation != "" {
+		return r.operation
+	}
+	return r.rpc
+}
+
+type restQueryParam struct {
+	name        string
+	description string
+	kind        string
+	repeated    bool
+}
+
+type restRequest struct {
+	params map[string]string
+	query  url.Values
+	body   []byte
+}
+
+// decode reads the body into msg, fields taken from the path are set by the routes after decoding
+func (r *restRequest) decode(msg proto.Message) error {
+	if len(r.body) == 0 {
+		return nil
+	}
+	return UnmarshalTwirpJSON(r.body, msg)
+}
+
+func NewRESTHandler(roomService livekit.RoomService, egressService livekit.Egress, hooks *twirp.ServerHooks) *RESTHandler {
+	h := &RESTHandler{
+		hooks:  hooks,
+		routes: restRoutes(roomService, egressService),
+	}
+	for _, route := range h.routes {
+		route.segments = strings.Split(strings.Trim(route.path, "/"), "/")
+	}
+
+	var err error
+	if h.openAPI, err = json.Marshal(h.OpenAPISpec()); err != nil {
+		panic(err)
+	}
+	return h
+}
+
+func (h *RESTHandler) PathPrefix() string {
+	return restAPIPrefix + "/"
+}
+
+func (h *RESTHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.EscapedPath(), restAPIPrefix)
+	if path == "/openapi.json" && r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(h.openAPI)
+		return
+	}
+
+	route, params, pathFound := h.match(r.Method, path)
+	ctx := r.Context()
+	if route == nil {
+		msg := "no route for " + r.Method + " " + r.URL.Path
+		if pathFound {
+			msg = fmt.Sprintf("unsupported method %q for %s", r.Method, r.URL.Path)
+		}
+		writeTwirpJSONError(ctx, w, nil, twirp.NewError(twirp.BadRoute, msg))
+		return
+	}
+
+	pkg, service, _ := strings.Cut(route.service, ".")
+	ctx = ctxsetters.WithPackageName(ctx, pkg)
+	ctx = ctxsetters.WithServiceName(ctx, service)
+	ctx = ctxsetters.WithResponseWriter(ctx, w)
+
+	var err error
+	if h.hooks != nil && h.hooks.RequestReceived != nil {
+		if ctx, err = h.hooks.RequestReceived(ctx); err != nil {
+			writeTwirpJSONError(ctx, w, h.hooks, err)
+			return
+		}
+	}
+	ctx = ctxsetters.WithMethodName(ctx, route.rpc)
+	if h.hooks != nil && h.hooks.RequestRouted != nil {
+		if ctx, err = h.hooks.RequestRouted(ctx); err != nil {
+			writeTwirpJSONError(ctx, w, h.hooks, err)
+			return
+		}
+	}
+
+	req := &restRequest{params: params, query: r.URL.Query()}
+	if route.request != nil {
+		if req.body, err = io.ReadAll(r.Body); err != nil {
+			writeTwirpJSONError(ctx, w, h.hooks, twirp.WrapError(twirp.NewError(twirp.Malformed, "failed to read request body"), err))
+			return
+		}
+	}
+
+	res, err := route.handle(ctx, req)
+	if err != nil {
+		writeTwirpJSONError(ctx, w, h.hooks, err)
+		return
+	}
+	writeTwirpJSONResponse(ctx, w, h.hooks, res)
+}
+
+// match returns the route of the method and path with its path parameters, and whether any route has the path
+func (h *RESTHandler) match(method string, path string) (*restRoute, map[string]string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	pathFound := false
+	for _, route := range h.routes {
+		params, ok := matchRESTPath(route.segments, segments)
+		if !ok {
+			continue
+		}
+		if route.method == method {
+			return route, params, true
+		}
+		pathFound = true
+	}
+	return nil, nil, pathFound
+}
+
+func matchRESTPath(pattern []string, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			value, err := url.PathUnescape(segments[i])
+			if err != nil || value == "" {
+				return nil, false
+			}
+			params[p[1:len(p)-1]] = value
+		} else if p != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func restRoutes(roomService livekit.RoomService, egressService livekit.Egress) []*restRoute {
+	const (
+		roomSvc   = "livekit.RoomService"
+		egressSvc = "livekit.Egress"
+	)
+
+	routes := []*restRoute{
+		{
+			method: http.MethodGet, path: "/rooms", service: roomSvc, rpc: "ListRooms",
+			summary:  "List active rooms",
+			query:    []restQueryParam{{name: "names", description: "rooms to list, all when not set", kind: "string", repeated: true}},
+			response: &livekit.ListRoomsResponse{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				return roomService.ListRooms(ctx, &livekit.ListRoomsRequest{Names: req.query["names"]})
+			},
+		},
+		{
+			method: http.MethodPost, path: "/rooms", service: roomSvc, rpc: "CreateRoom",
+			summary:  "Create a room",
+			request:  &livekit.CreateRoomRequest{},
+			response: &livekit.Room{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				in := &livekit.CreateRoomRequest{}
+				if err := req.decode(in); err != nil {
+					return nil, err
+				}
+				return roomService.CreateRoom(ctx, in)
+			},
+		},
+		{
+			method: http.MethodGet, path: "/rooms/{room}", service: roomSvc, rpc: "ListRooms", operation: "GetRoom",
+			summary:  "Get an active room",
+			response: &livekit.Room{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				res, err := roomService.ListRooms(ctx, &livekit.ListRoomsRequest{Names: []string{req.params["room"]}})
+				if err != nil {
+					return nil, err
+				}
+				if len(res.Rooms) == 0 {
+					return nil, twirp.NotFoundError(ErrRoomNotFound.Error())
+				}
+				return res.Rooms[0], nil
+			},
+		},
+		{
+			method: http.MethodDelete, path: "/rooms/{room}", service: roomSvc, rpc: "DeleteRoom",
+			summary:  "Close a room and disconnect its participants",
+			response: &livekit.DeleteRoomResponse{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				return roomService.DeleteRoom(ctx, &livekit.DeleteRoomRequest{Room: req.params["room"]})
+			},
+		},
+		{
+			method: http.MethodPut, path: "/rooms/{room}/metadata", service: roomSvc, rpc: "UpdateRoomMetadata",
+			summary:  "Update the metadata of a room",
+			request:  &livekit.UpdateRoomMetadataRequest{},
+			response: &livekit.Room{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				in := &livekit.UpdateRoomMetadataRequest{}
+				if err := req.decode(in); err != nil {
+					return nil, err
+				}
+				in.Room = req.params["room"]
+				return roomService.UpdateRoomMetadata(ctx, in)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/rooms/{room}/data", service: roomSvc, rpc: "SendData",
+			summary:  "Send a data packet to the participants of a room",
+			request:  &livekit.SendDataRequest{},
+			response: &livekit.SendDataResponse{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				in := &livekit.SendDataRequest{}
+				if err := req.decode(in); err != nil {
+					return nil, err
+				}
+				in.Room = req.params["room"]
+				return roomService.SendData(ctx, in)
+			},
+		},
+		{
+			method: http.MethodGet, path: "/rooms/{room}/participants", service: roomSvc, rpc: "ListParticipants",
+			summary:  "List the participants of a room",
+			response: &livekit.ListParticipantsResponse{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				return roomService.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: req.params["room"]})
+			},
+		},
+		{
+			method: http.MethodGet, path: "/rooms/{room}/participants/{identity}", service: roomSvc, rpc: "GetParticipant",
+			summary:  "Get a participant",
+			response: &livekit.ParticipantInfo{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				return roomService.GetParticipant(ctx, restParticipantIdentity(req))
+			},
+		},
+		{
+			method: http.MethodPatch, path: "/rooms/{room}/participants/{identity}", service: roomSvc, rpc: "UpdateParticipant",
+			summary:  "Update the metadata, name or permission of a participant",
+			request:  &livekit.UpdateParticipantRequest{},
+			response: &livekit.ParticipantInfo{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				in := &livekit.UpdateParticipantRequest{}
+				if err := req.decode(in); err != nil {
+					return nil, err
+				}
+				in.Room, in.Identity = req.params["room"], req.params["identity"]
+				return roomService.UpdateParticipant(ctx, in)
+			},
+		},
+		{
+			method: http.MethodDelete, path: "/rooms/{room}/participants/{identity}", service: roomSvc, rpc: "RemoveParticipant",
+			summary:  "Disconnect a participant from a room",
+			response: &livekit.RemoveParticipantResponse{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				return roomService.RemoveParticipant(ctx, restParticipantIdentity(req))
+			},
+		},
+		{
+			method: http.MethodPost, path: "/rooms/{room}/participants/{identity}/subscriptions", service: roomSvc, rpc: "UpdateSubscriptions",
+			summary:  "Subscribe a participant to tracks or unsubscribe it",
+			request:  &livekit.UpdateSubscriptionsRequest{},
+			response: &livekit.UpdateSubscriptionsResponse{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				in := &livekit.UpdateSubscriptionsRequest{}
+				if err := req.decode(in); err != nil {
+					return nil, err
+				}
+				in.Room, in.Identity = req.params["room"], req.params["identity"]
+				return roomService.UpdateSubscriptions(ctx, in)
+			},
+		},
+		{
+			method: http.MethodPut, path: "/rooms/{room}/participants/{identity}/tracks/{track_sid}/muted", service: roomSvc, rpc: "MutePublishedTrack",
+			summary:  "Mute or unmute a track published by a participant",
+			request:  &livekit.MuteRoomTrackRequest{},
+			response: &livekit.MuteRoomTrackResponse{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				in := &livekit.MuteRoomTrackRequest{}
+				if err := req.decode(in); err != nil {
+					return nil, err
+				}
+				in.Room, in.Identity, in.TrackSid = req.params["room"], req.params["identity"], req.params["track_sid"]
+				return roomService.MutePublishedTrack(ctx, in)
+			},
+		},
+		{
+			method: http.MethodGet, path: "/egress", service: egressSvc, rpc: "ListEgress",
+			summary: "List egresses",
+			query: []restQueryParam{
+				{name: "room_name", description: "egresses of the room", kind: "string"},
+				{name: "egress_id", description: "a single egress", kind: "string"},
+				{name: "active", description: "only egresses that have not ended", kind: "boolean"},
+			},
+			response: &livekit.ListEgressResponse{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				in := &livekit.ListEgressRequest{
+					RoomName: req.query.Get("room_name"),
+					EgressId: req.query.Get("egress_id"),
+				}
+				if active := req.query.Get("active"); active != "" {
+					var err error
+					if in.Active, err = strconv.ParseBool(active); err != nil {
+						return nil, twirp.InvalidArgumentError("active", err.Error())
+					}
+				}
+				return egressService.ListEgress(ctx, in)
+			},
+		},
+		restStartEgressRoute(egressService, "/egress/room-composite", "StartRoomCompositeEgress", "Start recording or streaming a room",
+			livekit.Egress.StartRoomCompositeEgress),
+		restStartEgressRoute(egressService, "/egress/web", "StartWebEgress", "Start recording or streaming a web page",
+			livekit.Egress.StartWebEgress),
+		restStartEgressRoute(egressService, "/egress/participant", "StartParticipantEgress", "Start recording or streaming a participant",
+			livekit.Egress.StartParticipantEgress),
+		restStartEgressRoute(egressService, "/egress/track-composite", "StartTrackCompositeEgress", "Start recording or streaming an audio and a video track",
+			livekit.Egress.StartTrackCompositeEgress),
+		restStartEgressRoute(egressService, "/egress/track", "StartTrackEgress", "Start exporting a track without transcoding",
+			livekit.Egress.StartTrackEgress),
+		{
+			method: http.MethodPut, path: "/egress/{egress_id}/layout", service: egressSvc, rpc: "UpdateLayout",
+			summary:  "Update the layout of a room composite egress",
+			request:  &livekit.UpdateLayoutRequest{},
+			response: &livekit.EgressInfo{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				in := &livekit.UpdateLayoutRequest{}
+				if err := req.decode(in); err != nil {
+					return nil, err
+				}
+				in.EgressId = req.params["egress_id"]
+				return egressService.UpdateLayout(ctx, in)
+			},
+		},
+		{
+			method: http.MethodPut, path: "/egress/{egress_id}/stream", service: egressSvc, rpc: "UpdateStream",
+			summary:  "Add or remove the stream outputs of an egress",
+			request:  &livekit.UpdateStreamRequest{},
+			response: &livekit.EgressInfo{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				in := &livekit.UpdateStreamRequest{}
+				if err := req.decode(in); err != nil {
+					return nil, err
+				}
+				in.EgressId = req.params["egress_id"]
+				return egressService.UpdateStream(ctx, in)
+			},
+		},
+		{
+			method: http.MethodPost, path: "/egress/{egress_id}/stop", service: egressSvc, rpc: "StopEgress",
+			summary:  "Stop an egress",
+			response: &livekit.EgressInfo{},
+			handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+				return egressService.StopEgress(ctx, &livekit.StopEgressRequest{EgressId: req.params["egress_id"]})
+			},
+		},
+	}
+	return routes
+}
+
+func restParticipantIdentity(req *restRequest) *livekit.RoomParticipantIdentity {
+	return &livekit.RoomParticipantIdentity{
+		Room:     req.params["room"],
+		Identity: req.params["identity"],
+	}
+}
+
+func restStartEgressRoute[Req any, PReq interface {
+	*Req
+	proto.Message
+}](
+	egressService livekit.Egress,
+	path string,
+	rpc string,
+	summary string,
+	start func(livekit.Egress, context.Context, PReq) (*livekit.EgressInfo, error),
+) *restRoute {
+	return &restRoute{
+		method:   http.MethodPost,
+		path:     path,
+		service:  "livekit.Egress",
+		rpc:      rpc,
+		summary:  summary,
+		request:  PReq(new(Req)),
+		response: &livekit.EgressInfo{},
+		handle: func(ctx context.Context, req *restRequest) (proto.Message, error) {
+			in := PReq(new(Req))
+			if err := req.decode(in); err != nil {
+				return nil, err
+			}
+			return start(egressService, ctx, in)
+		},
+	}
+}
+
+// OpenAPISpec returns the OpenAPI 3 document of the routes, with the schemas of the protocol messages
+// in the JSON encoding of the responses
+func (h *RESTHandler) OpenAPISpec() map[string]any {
+	schemas := &openAPISchemas{schemas: make(map[string]any)}
+	schemas.schemas["twirp.Error"] = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"code": map[string]any{"type": "string"},
+			"msg":  map[string]any{"type": "string"},
+			"meta": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+		},
+	}
+
+	paths := make(map[string]any)
+	for _, route := range h.routes {
+		var params []any
+		for _, segment := range route.segments {
+			if strings.HasPrefix(segment, "{") {
+				params = append(params, map[string]any{
+					"name":     segment[1 : len(segment)-1],
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]any{"type": "string"},
+				})
+			}
+		}
+		for _, q := range route.query {
+			schema := map[string]any{"type": q.kind}
+			if q.repeated {
+				schema = map[string]any{"type": "array", "items": schema}
+			}
+			params = append(params, map[string]any{
+				"name":        q.name,
+				"in":          "query",
+				"description": q.description,
+				"schema":      schema,
+			})
+		}
+
+		_, service, _ := strings.Cut(route.service, ".")
+		op := map[string]any{
+			"operationId": route.operationID(),
+			"summary":     route.summary,
+			"tags":        []string{service},
+			"responses": map[string]any{
+				"200": map[string]any{
+					"description": "OK",
+					"content":     openAPIJSONContent(schemas.ref(route.response.ProtoReflect().Descriptor())),
+				},
+				"default": map[string]any{
+					"description": "Twirp error",
+					"content":     openAPIJSONContent(map[string]any{"$ref": "#/components/schemas/twirp.Error"}),
+				},
+			},
+		}
+		if len(params) != 0 {
+			op["parameters"] = params
+		}
+		if route.request != nil {
+			op["requestBody"] = map[string]any{
+				"description": "fields in the path are taken from the path",
+				"content":     openAPIJSONContent(schemas.ref(route.request.ProtoReflect().Descriptor())),
+			}
+		}
+
+		path := restAPIPrefix + route.path
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			item = make(map[string]any)
+			paths[path] = item
+		}
+		item[strings.ToLower(route.method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "LiveKit management API",
+			"version": version.Version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+		"security": []any{map[string]any{"bearerAuth": []string{}}},
+	}
+}
+
+func openAPIJSONContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// openAPISchemas collects the schemas of protocol messages, as encoded by protojson with proto field names
+type openAPISchemas struct {
+	schemas map[string]any
+}
+
+func (s *openAPISchemas) ref(md protoreflect.MessageDescriptor) map[string]any {
+	name := string(md.FullName())
+	if _, ok := s.schemas[name]; !ok {
+		// set before the fields, for messages that reference themselves
+		s.schemas[name] = nil
+
+		properties := make(map[string]any)
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			properties[string(fd.Name())] = s.field(fd)
+		}
+		s.schemas[name] = map[string]any{"type": "object", "properties": properties}
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func (s *openAPISchemas) field(fd protoreflect.FieldDescriptor) map[string]any {
+	switch {
+	case fd.IsMap():
+		return map[string]any{"type": "object", "additionalProperties": s.value(fd.MapValue())}
+	case fd.IsList():
+		return map[string]any{"type": "array", "items": s.value(fd)}
+	default:
+		return s.value(fd)
+	}
+}
+
+func (s *openAPISchemas) value(fd protoreflect.FieldDescriptor) map[string]any {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]any{"type": "integer", "format": "int64", "minimum": 0}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// 64 bit integers are strings in the JSON encoding
+		return map[string]any{"type": "string", "format": "int64"}
+	case protoreflect.FloatKind:
+		return map[string]any{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return map[string]any{"type": "number", "format": "double"}
+	case protoreflect.BytesKind:
+		return map[string]any{"type": "string", "format": "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]any{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return s.ref(fd.Message())
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+type restRoomService struct {
+	listRoomsService
+
+	updated *livekit.UpdateParticipantRequest
+}
+
+func (s *restRoomService) UpdateParticipant(_ context.Context, req *livekit.UpdateParticipantRequest) (*livekit.ParticipantInfo, error) {
+	s.updated = req
+	return &livekit.ParticipantInfo{Identity: req.Identity, Metadata: req.Metadata}, nil
+}
+
+func TestRESTHandler(t *testing.T) {
+	roomService := &restRoomService{}
+	h := service.NewRESTHandler(roomService, nil, nil)
+
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("handlers check the grants", func(t *testing.T) {
+		w := serve(http.MethodGet, "/api/v1/rooms/room", "")
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("path parameters", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/rooms/my%20room", nil)
+		h.ServeHTTP(w, r.WithContext(service.WithGrants(r.Context(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomList: true}})))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		room := &livekit.Room{}
+		require.NoError(t, protojson.Unmarshal(w.Body.Bytes(), room))
+		require.Equal(t, "my room", room.Name)
+	})
+
+	t.Run("path parameters take precedence over the body", func(t *testing.T) {
+		w := serve(http.MethodPatch, "/api/v1/rooms/room/participants/alice", `{"identity": "bob", "metadata": "meta"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "room", roomService.updated.Room)
+		require.Equal(t, "alice", roomService.updated.Identity)
+		require.Equal(t, "meta", roomService.updated.Metadata)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		w := serve(http.MethodPatch, "/api/v1/rooms/room/participants/alice", `{`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown routes", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/api/v1/unknown", "").Code)
+		require.Equal(t, http.StatusNotFound, serve(http.MethodPut, "/api/v1/rooms", "").Code)
+	})
+
+	t.Run("openapi document", func(t *testing.T) {
+		w := serve(http.MethodGet, "/api/v1/openapi.json", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var doc struct {
+			Paths      map[string]map[string]any `json:"paths"`
+			Components struct {
+				Schemas map[string]any `json:"schemas"`
+			} `json:"components"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+		require.Contains(t, doc.Paths["/api/v1/rooms/{room}/participants/{identity}"], "patch")
+		require.Contains(t, doc.Paths["/api/v1/egress/{egress_id}/stop"], "post")
+		require.Contains(t, doc.Components.Schemas, "livekit.ParticipantInfo")
+		require.Contains(t, doc.Components.Schemas, "livekit.EgressInfo")
+	})
+}
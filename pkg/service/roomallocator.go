@@ -25,6 +25,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/routing/selector"
+	"github.com/livekit/livekit-server/pkg/rtc"
 )
 
 type StandardRoomAllocator struct {
@@ -32,9 +33,10 @@ type StandardRoomAllocator struct {
 	router    routing.Router
 	selector  selector.NodeSelector
 	roomStore ObjectStore
+	keyQuotas *KeyQuotas
 }
 
-func NewRoomAllocator(conf *config.Config, router routing.Router, rs ObjectStore) (RoomAllocator, error) {
+func NewRoomAllocator(conf *config.Config, router routing.Router, rs ObjectStore, keyQuotas *KeyQuotas) (RoomAllocator, error) {
 	ns, err := selector.CreateNodeSelector(conf)
 	if err != nil {
 		return nil, err
@@ -45,12 +47,13 @@ func NewRoomAllocator(conf *config.Config, router routing.Router, rs ObjectStore
 		router:    router,
 		selector:  ns,
 		roomStore: rs,
+		keyQuotas: keyQuotas,
 	}, nil
 }
 
 // CreateRoom creates a new room from a request and allocates it to a node to handle
 // it'll also monitor its state, and cleans it up when appropriate
-func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, bool, error) {
+func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest, options *rtc.RoomOptions) (*livekit.Room, bool, error) {
 	token, err := r.roomStore.LockRoom(ctx, livekit.RoomName(req.Name), 5*time.Second)
 	if err != nil {
 		return nil, false, err
@@ -59,10 +62,23 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 		_ = r.roomStore.UnlockRoom(ctx, livekit.RoomName(req.Name), token)
 	}()
 
+	var template *config.RoomTemplate
+	if options != nil && options.Template != "" {
+		t, ok := r.config.Reloadable().Room.Templates[options.Template]
+		if !ok {
+			return nil, false, ErrRoomTemplateNotFound
+		}
+		template = &t
+	}
+
 	// find existing room and update it
 	var created bool
+	apiKey := GetAPIKey(ctx)
 	rm, internal, err := r.roomStore.LoadRoom(ctx, livekit.RoomName(req.Name), true)
 	if err == ErrRoomNotFound {
+		if err = r.keyQuotas.CheckCreateRoom(ctx, apiKey); err != nil {
+			return nil, false, err
+		}
 		created = true
 		rm = &livekit.Room{
 			Sid:          utils.NewGuid(utils.RoomPrefix),
@@ -71,7 +87,10 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 			TurnPassword: utils.RandomSecret(),
 		}
 		internal = &livekit.RoomInternal{}
-		applyDefaultRoomConfig(rm, internal, &r.config.Room)
+		applyDefaultRoomConfig(rm, internal, &r.config.Reloadable().Room)
+		if template != nil {
+			applyRoomTemplate(rm, internal, template)
+		}
 	} else if err != nil {
 		return nil, false, err
 	}
@@ -102,6 +121,33 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 	if err = r.roomStore.StoreRoom(ctx, rm, internal); err != nil {
 		return nil, false, err
 	}
+	// the room is owned by the key it was created with, options sent with later requests keep the owner
+	if options != nil || (created && apiKey != "") {
+		owner := apiKey
+		if !created {
+			existing, err := r.roomStore.LoadRoomOptions(ctx, livekit.RoomName(rm.Name))
+			if err != nil {
+				return nil, false, err
+			}
+			owner = existing.APIKey
+		}
+		options = options.Clone()
+		if options == nil {
+			options = &rtc.RoomOptions{}
+		}
+		options.APIKey = owner
+		if err = r.roomStore.StoreRoomOptions(ctx, livekit.RoomName(rm.Name), options); err != nil {
+			return nil, false, err
+		}
+	}
+	if created {
+		if err = r.keyQuotas.CheckRoomCreated(ctx, apiKey); err != nil {
+			if err2 := r.roomStore.DeleteRoom(ctx, livekit.RoomName(rm.Name)); err2 != nil {
+				logger.Warnw("could not delete room over quota", err2, "room", rm.Name)
+			}
+			return nil, false, err
+		}
+	}
 
 	// check if room already assigned
 	existing, err := r.router.GetNodeForRoom(ctx, livekit.RoomName(rm.Name))
@@ -109,10 +155,11 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 		return nil, false, err
 	}
 
-	// if already assigned and still available, keep it on that node
-	if err == nil && selector.IsAvailable(existing) {
+	// if already assigned and still available, keep it on that node.
+	// a draining node takes no new participants, the room moves to another node unless there is none
+	if err == nil && selector.IsAvailable(existing) && !r.canMoveOffDrainingNode(existing) {
 		// if node hosting the room is full, deny entry
-		if selector.LimitsReached(r.config.Limit, existing.Stats) {
+		if selector.LimitsReached(r.config.Reloadable().Limit, existing.Stats) {
 			return nil, false, routing.ErrNodeLimitReached
 		}
 
@@ -144,14 +191,61 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 	return rm, true, nil
 }
 
+func (r *StandardRoomAllocator) canMoveOffDrainingNode(node *livekit.Node) bool {
+	if node.State != livekit.NodeState_SHUTTING_DOWN {
+		return false
+	}
+	nodes, err := r.router.ListNodes()
+	if err != nil {
+		return false
+	}
+	return len(selector.GetAvailableNodes(nodes)) != 0
+}
+
+func (r *StandardRoomAllocator) PatchRoomMetadata(ctx context.Context, roomName livekit.RoomName, patch []byte, version string) (*livekit.Room, error) {
+	token, err := r.roomStore.LockRoom(ctx, roomName, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.roomStore.UnlockRoom(ctx, roomName, token)
+	}()
+
+	rm, internal, err := r.roomStore.LoadRoom(ctx, roomName, true)
+	if err != nil {
+		return nil, err
+	}
+	maxMetadataSize := int(r.config.Reloadable().Room.MaxMetadataSize)
+	if rm.Metadata, err = rtc.ApplyMetadataPatch(rm.Metadata, patch, version, maxMetadataSize); err != nil {
+		return nil, err
+	}
+	if err = r.roomStore.StoreRoom(ctx, rm, internal); err != nil {
+		return nil, err
+	}
+	return rm, nil
+}
+
 func (r *StandardRoomAllocator) ValidateCreateRoom(ctx context.Context, roomName livekit.RoomName) error {
 	// when auto create is disabled, we'll check to ensure it's already created
-	if !r.config.Room.AutoCreate {
+	if !r.config.Reloadable().Room.AutoCreate {
 		_, _, err := r.roomStore.LoadRoom(ctx, roomName, false)
 		if err != nil {
 			return err
 		}
 	}
+
+	// scheduled rooms only accept participants within their activation window
+	options, err := r.roomStore.LoadRoomOptions(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if options.IsPendingActivation(now) {
+		return rtc.ErrRoomNotActive
+	}
+	if options.IsExpired(now) {
+		return rtc.ErrRoomExpired
+	}
 	return nil
 }
 
@@ -171,3 +265,47 @@ func applyDefaultRoomConfig(room *livekit.Room, internal *livekit.RoomInternal,
 	}
 	internal.SyncStreams = conf.SyncStreams
 }
+
+func applyRoomTemplate(room *livekit.Room, internal *livekit.RoomInternal, template *config.RoomTemplate) {
+	if template.EmptyTimeout > 0 {
+		room.EmptyTimeout = template.EmptyTimeout
+	}
+	if template.MaxParticipants > 0 {
+		room.MaxParticipants = template.MaxParticipants
+	}
+	if len(template.EnabledCodecs) > 0 {
+		room.EnabledCodecs = nil
+		for _, codec := range template.EnabledCodecs {
+			room.EnabledCodecs = append(room.EnabledCodecs, &livekit.Codec{
+				Mime:     codec.Mime,
+				FmtpLine: codec.FmtpLine,
+			})
+		}
+	}
+	if egress := roomTemplateEgress(&template.Egress); egress != nil && egress.Tracks != nil {
+		internal.TrackEgress = egress.Tracks
+	}
+}
+
+// roomTemplateEgress returns the egress requests of a room template, nil when it has none
+func roomTemplateEgress(conf *config.RoomTemplateEgress) *livekit.RoomEgress {
+	if conf.RoomFilepath == "" && conf.TrackFilepath == "" {
+		return nil
+	}
+
+	egress := &livekit.RoomEgress{}
+	if conf.RoomFilepath != "" {
+		egress.Room = &livekit.RoomCompositeEgressRequest{
+			Layout: conf.RoomLayout,
+			FileOutputs: []*livekit.EncodedFileOutput{
+				{Filepath: conf.RoomFilepath},
+			},
+		}
+	}
+	if conf.TrackFilepath != "" {
+		egress.Tracks = &livekit.AutoTrackEgress{
+			Filepath: conf.TrackFilepath,
+		}
+	}
+	return egress
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/livekit-server/pkg/service/servicefakes"
 )
@@ -39,12 +40,43 @@ func TestCreateRoom(t *testing.T) {
 
 		ra, conf := newTestRoomAllocator(t, conf, node)
 
-		room, _, err := ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "myroom"})
+		room, _, err := ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "myroom"}, nil)
 		require.NoError(t, err)
 		require.Equal(t, conf.Room.EmptyTimeout, room.EmptyTimeout)
 		require.NotEmpty(t, room.EnabledCodecs)
 	})
 
+	t.Run("room template overrides defaults", func(t *testing.T) {
+		conf, err := config.NewConfig("", true, nil, nil)
+		require.NoError(t, err)
+		conf.Room.Templates = map[string]config.RoomTemplate{
+			"webinar": {
+				MaxParticipants: 500,
+				EmptyTimeout:    600,
+				EnabledCodecs:   []config.CodecSpec{{Mime: "video/h264"}},
+			},
+		}
+
+		node, err := routing.NewLocalNode(conf)
+		require.NoError(t, err)
+
+		ra, _ := newTestRoomAllocator(t, conf, node)
+
+		room, _, err := ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{
+			Name:            "webinar",
+			MaxParticipants: 100,
+		}, &rtc.RoomOptions{Template: "webinar"})
+		require.NoError(t, err)
+		require.EqualValues(t, 600, room.EmptyTimeout)
+		// request takes precedence over the template
+		require.EqualValues(t, 100, room.MaxParticipants)
+		require.Len(t, room.EnabledCodecs, 1)
+		require.Equal(t, "video/h264", room.EnabledCodecs[0].Mime)
+
+		_, _, err = ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "webinar"}, &rtc.RoomOptions{Template: "unknown"})
+		require.ErrorIs(t, err, service.ErrRoomTemplateNotFound)
+	})
+
 	t.Run("reject new participants when track limit has been reached", func(t *testing.T) {
 		conf, err := config.NewConfig("", true, nil, nil)
 		require.NoError(t, err)
@@ -57,7 +89,7 @@ func TestCreateRoom(t *testing.T) {
 
 		ra, _ := newTestRoomAllocator(t, conf, node)
 
-		_, _, err = ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "low-limit-room"})
+		_, _, err = ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "low-limit-room"}, nil)
 		require.ErrorIs(t, err, routing.ErrNodeLimitReached)
 	})
 
@@ -73,11 +105,94 @@ func TestCreateRoom(t *testing.T) {
 
 		ra, _ := newTestRoomAllocator(t, conf, node)
 
-		_, _, err = ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "low-limit-room"})
+		_, _, err = ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "low-limit-room"}, nil)
 		require.ErrorIs(t, err, routing.ErrNodeLimitReached)
 	})
 }
 
+func TestCreateRoomOnDrainingNode(t *testing.T) {
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+
+	draining, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+	draining.State = livekit.NodeState_SHUTTING_DOWN
+
+	newAllocator := func(nodes ...*livekit.Node) (service.RoomAllocator, *routingfakes.FakeRouter) {
+		store := &servicefakes.FakeObjectStore{}
+		store.LoadRoomReturns(&livekit.Room{Name: "myroom"}, nil, nil)
+		router := &routingfakes.FakeRouter{}
+		router.GetNodeForRoomReturns(draining, nil)
+		router.ListNodesReturns(append([]*livekit.Node{draining}, nodes...), nil)
+		ra, err := service.NewRoomAllocator(conf, router, store, service.NewKeyQuotas(conf, store))
+		require.NoError(t, err)
+		return ra, router
+	}
+
+	t.Run("room moves to another node", func(t *testing.T) {
+		ra, router := newAllocator(&livekit.Node{Id: "other", Type: livekit.NodeType_SERVER, State: livekit.NodeState_SERVING})
+		_, _, err := ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "myroom"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, router.SetNodeForRoomCallCount())
+		_, roomName, nodeID := router.SetNodeForRoomArgsForCall(0)
+		require.Equal(t, livekit.RoomName("myroom"), roomName)
+		require.Equal(t, livekit.NodeID("other"), nodeID)
+	})
+
+	t.Run("room stays when there is no other node", func(t *testing.T) {
+		ra, router := newAllocator()
+		_, _, err := ra.CreateRoom(context.Background(), &livekit.CreateRoomRequest{Name: "myroom"}, nil)
+		require.NoError(t, err)
+		require.Zero(t, router.SetNodeForRoomCallCount())
+	})
+}
+
+func TestCreateRoomAPIKeyQuota(t *testing.T) {
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	conf.Limit.KeyQuotas = map[string]config.KeyQuotaConfig{
+		"team": {MaxRooms: 1},
+	}
+
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+
+	store := &servicefakes.FakeObjectStore{}
+	store.LoadRoomReturns(nil, nil, service.ErrRoomNotFound)
+	store.LoadRoomOptionsReturns(&rtc.RoomOptions{}, nil)
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomReturns(node, nil)
+	ra, err := service.NewRoomAllocator(conf, router, store, service.NewKeyQuotas(conf, store))
+	require.NoError(t, err)
+
+	ctx := service.WithAPIKey(context.Background(), "team")
+	_, _, err = ra.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: "first"}, nil)
+	require.NoError(t, err)
+	// the room is owned by the key it was created with
+	require.Equal(t, 1, store.StoreRoomOptionsCallCount())
+	_, roomName, options := store.StoreRoomOptionsArgsForCall(0)
+	require.Equal(t, livekit.RoomName("first"), roomName)
+	require.Equal(t, "team", options.APIKey)
+
+	store.LoadAPIKeyRoomUsageReturns(1, 0, nil)
+	_, _, err = ra.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: "second"}, nil)
+	require.ErrorIs(t, err, service.ErrAPIKeyRoomQuotaExceeded)
+	require.Equal(t, 1, store.StoreRoomCallCount())
+
+	// a room created concurrently is counted before this one, which is deleted
+	store.LoadAPIKeyRoomUsageReturnsOnCall(store.LoadAPIKeyRoomUsageCallCount(), 0, 0, nil)
+	store.LoadAPIKeyRoomUsageReturnsOnCall(store.LoadAPIKeyRoomUsageCallCount()+1, 2, 0, nil)
+	_, _, err = ra.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: "second"}, nil)
+	require.ErrorIs(t, err, service.ErrAPIKeyRoomQuotaExceeded)
+	require.Equal(t, 1, store.DeleteRoomCallCount())
+	_, roomName = store.DeleteRoomArgsForCall(0)
+	require.Equal(t, livekit.RoomName("second"), roomName)
+
+	// other keys are not limited
+	_, _, err = ra.CreateRoom(service.WithAPIKey(context.Background(), "other"), &livekit.CreateRoomRequest{Name: "second"}, nil)
+	require.NoError(t, err)
+}
+
 func newTestRoomAllocator(t *testing.T, conf *config.Config, node *livekit.Node) (service.RoomAllocator, *config.Config) {
 	store := &servicefakes.FakeObjectStore{}
 	store.LoadRoomReturns(nil, nil, service.ErrRoomNotFound)
@@ -85,7 +200,7 @@ func newTestRoomAllocator(t *testing.T, conf *config.Config, node *livekit.Node)
 
 	router.GetNodeForRoomReturns(node, nil)
 
-	ra, err := service.NewRoomAllocator(conf, router, store)
+	ra, err := service.NewRoomAllocator(conf, router, store, service.NewKeyQuotas(conf, store))
 	require.NoError(t, err)
 	return ra, conf
 }
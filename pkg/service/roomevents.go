@@ -0,0 +1,376 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/protocol/webhook"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// room events streamed on top of those of webhooks
+const (
+	RoomEventRoomMetadataChanged        = "room_metadata_changed"
+	RoomEventParticipantMetadataChanged = "participant_metadata_changed"
+)
+
+var ErrRoomEventsDisabled = errors.New("room event stream is not enabled")
+
+// RoomEventService streams room events to backend services, as server-sent events or over a WebSocket. The events
+// are those sent to webhooks, and the metadata changes of rooms and participants. Every node publishes the events
+// of the rooms it hosts, so that a stream from any node carries the events of the cluster.
+//
+// A token with the room list grant receives the events of every room, a token with the room admin grant those of
+// its room. Streams are filtered with the room and events query parameters, e.g.
+// /room_events?room=my-room&events=participant_joined,participant_left
+type RoomEventService struct {
+	conf     config.EventStreamConfig
+	server   RoomEventsServer
+	client   RoomEventsClient
+	upgrader websocket.Upgrader
+}
+
+func NewRoomEventService(conf *config.Config, bus psrpc.MessageBus, clientParams rpc.ClientParams) (*RoomEventService, error) {
+	s := &RoomEventService{
+		conf: conf.EventStream,
+		upgrader: websocket.Upgrader{
+			// consumers are backend services authenticated by their token
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+	if !s.conf.Enabled {
+		return s, nil
+	}
+
+	var err error
+	if s.server, err = NewRoomEventsServer(bus); err != nil {
+		return nil, err
+	}
+	if s.client, err = NewRoomEventsClient(clientParams); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *RoomEventService) Enabled() bool {
+	return s != nil && s.conf.Enabled
+}
+
+// Publish sends event to the streams of the cluster, events sent to webhooks already have an ID
+func (s *RoomEventService) Publish(ctx context.Context, event *livekit.WebhookEvent) {
+	if !s.Enabled() {
+		return
+	}
+	if event.Id == "" {
+		event.Id = utils.NewGuid("EV_")
+		event.CreatedAt = time.Now().Unix()
+	}
+	if err := s.server.PublishRoomEvent(ctx, event); err != nil {
+		logger.Warnw("failed to publish room event", err, "event", event.Event)
+	}
+}
+
+// Notifier returns a notifier that publishes the events queued for webhooks to the streams, and queues them
+// with notifier
+func (s *RoomEventService) Notifier(notifier webhook.QueuedNotifier) webhook.QueuedNotifier {
+	if !s.Enabled() {
+		return notifier
+	}
+	return &roomEventNotifier{events: s, notifier: notifier}
+}
+
+type roomEventNotifier struct {
+	events   *RoomEventService
+	notifier webhook.QueuedNotifier
+}
+
+func (n *roomEventNotifier) QueueNotify(ctx context.Context, event *livekit.WebhookEvent) error {
+	n.events.Publish(ctx, event)
+	if n.notifier == nil {
+		return nil
+	}
+	return n.notifier.QueueNotify(ctx, event)
+}
+
+func (s *RoomEventService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.Enabled() {
+		handleError(w, r, http.StatusNotFound, ErrRoomEventsDisabled)
+		return
+	}
+
+	filter, status, err := newRoomEventFilter(r)
+	if err != nil {
+		handleError(w, r, status, err)
+		return
+	}
+
+	// events published until the stream is served are not lost
+	sub, err := s.client.SubscribeRoomEvent(r.Context())
+	if err != nil {
+		handleError(w, r, http.StatusServiceUnavailable, err)
+		return
+	}
+	defer sub.Close()
+
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWebSocket(w, r, sub, filter)
+	} else {
+		s.serveEventStream(w, r, sub, filter)
+	}
+}
+
+func (s *RoomEventService) serveEventStream(
+	w http.ResponseWriter,
+	r *http.Request,
+	sub psrpc.Subscription[*livekit.WebhookEvent],
+	filter *roomEventFilter,
+) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		handleError(w, r, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginx would buffer the stream otherwise
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(s.conf.KeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case event, ok := <-sub.Channel():
+			if !ok {
+				return
+			}
+			if !filter.matches(event) {
+				continue
+			}
+			data, err := protojson.Marshal(event)
+			if err != nil {
+				logger.Warnw("could not marshal room event", err, "event", event.Event)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Id, event.Event, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *RoomEventService) serveWebSocket(
+	w http.ResponseWriter,
+	r *http.Request,
+	sub psrpc.Subscription[*livekit.WebhookEvent],
+	filter *roomEventFilter,
+) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has replied with the error
+		return
+	}
+	defer conn.Close()
+
+	// the stream is one way, reading handles control messages and detects the close of the connection
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(s.conf.KeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+
+		case <-keepAlive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.conf.KeepAliveInterval)); err != nil {
+				return
+			}
+
+		case event, ok := <-sub.Channel():
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+				return
+			}
+			if !filter.matches(event) {
+				continue
+			}
+			data, err := protojson.Marshal(event)
+			if err != nil {
+				logger.Warnw("could not marshal room event", err, "event", event.Event)
+				continue
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+type roomEventFilter struct {
+	room   string
+	events []string
+}
+
+// newRoomEventFilter checks the grants of the request for the rooms it streams
+func newRoomEventFilter(r *http.Request) (*roomEventFilter, int, error) {
+	ctx := r.Context()
+	if GetGrants(ctx) == nil {
+		return nil, http.StatusUnauthorized, ErrMissingAuthorization
+	}
+
+	f := &roomEventFilter{
+		room: r.URL.Query().Get("room"),
+	}
+	if events := r.URL.Query().Get("events"); events != "" {
+		f.events = strings.Split(events, ",")
+	}
+
+	if err := EnsureListPermission(ctx); err != nil {
+		if f.room == "" {
+			return nil, http.StatusForbidden, err
+		}
+		if err := EnsureAdminPermission(ctx, livekit.RoomName(f.room)); err != nil {
+			return nil, http.StatusForbidden, err
+		}
+	}
+	return f, http.StatusOK, nil
+}
+
+func (f *roomEventFilter) matches(event *livekit.WebhookEvent) bool {
+	if len(f.events) != 0 && !slices.Contains(f.events, event.Event) {
+		return false
+	}
+	return f.room == "" || roomEventRoomName(event) == f.room
+}
+
+func roomEventRoomName(event *livekit.WebhookEvent) string {
+	switch {
+	case event.Room != nil:
+		return event.Room.Name
+	case event.EgressInfo != nil:
+		return event.EgressInfo.RoomName
+	case event.IngressInfo != nil:
+		return event.IngressInfo.RoomName
+	default:
+		return ""
+	}
+}
+
+// roomMetadataEvents publishes the metadata changes of a room and its participants. Participants are known with the
+// metadata of their first change, which is that of their join event
+type roomMetadataEvents struct {
+	events *RoomEventService
+
+	lock         sync.Mutex
+	metadata     string
+	participants map[livekit.ParticipantIdentity]string
+}
+
+// newRoomMetadataEvents returns nil when streams are disabled
+func (s *RoomEventService) newRoomMetadataEvents(room *livekit.Room) *roomMetadataEvents {
+	if !s.Enabled() {
+		return nil
+	}
+	return &roomMetadataEvents{
+		events:       s,
+		metadata:     room.Metadata,
+		participants: make(map[livekit.ParticipantIdentity]string),
+	}
+}
+
+func (e *roomMetadataEvents) RoomUpdated(ctx context.Context, r *rtc.Room) {
+	if e == nil {
+		return
+	}
+
+	room := r.ToProto()
+	e.lock.Lock()
+	changed := e.metadata != room.Metadata
+	e.metadata = room.Metadata
+	e.lock.Unlock()
+
+	if changed {
+		e.events.Publish(ctx, &livekit.WebhookEvent{
+			Event: RoomEventRoomMetadataChanged,
+			Room:  room,
+		})
+	}
+}
+
+func (e *roomMetadataEvents) ParticipantChanged(ctx context.Context, room *rtc.Room, p types.LocalParticipant) {
+	if e == nil {
+		return
+	}
+
+	participant := p.ToProto()
+	identity := livekit.ParticipantIdentity(participant.Identity)
+	e.lock.Lock()
+	if participant.State == livekit.ParticipantInfo_DISCONNECTED {
+		delete(e.participants, identity)
+		e.lock.Unlock()
+		return
+	}
+	prev, known := e.participants[identity]
+	e.participants[identity] = participant.Metadata
+	e.lock.Unlock()
+
+	if known && prev != participant.Metadata {
+		e.events.Publish(ctx, &livekit.WebhookEvent{
+			Event:       RoomEventParticipantMetadataChanged,
+			Room:        room.ToProto(),
+			Participant: participant,
+		})
+	}
+}
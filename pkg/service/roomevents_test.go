@@ -0,0 +1,114 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestRoomEventStream(t *testing.T) {
+	bus := psrpc.NewLocalMessageBus()
+	conf := &config.Config{EventStream: config.EventStreamConfig{Enabled: true, KeepAliveInterval: time.Minute}}
+	events, err := service.NewRoomEventService(conf, bus, rpc.ClientParams{Bus: bus})
+	require.NoError(t, err)
+
+	var grants *auth.ClaimGrants
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if grants != nil {
+			r = r.WithContext(service.WithGrants(r.Context(), grants))
+		}
+		events.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+
+	get := func(t *testing.T, query string) *http.Response {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+"/room_events"+query, nil)
+		require.NoError(t, err)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = res.Body.Close() })
+		return res
+	}
+
+	t.Run("grants", func(t *testing.T) {
+		grants = nil
+		require.Equal(t, http.StatusUnauthorized, get(t, "").StatusCode)
+
+		grants = &auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true, Room: "room"}}
+		require.Equal(t, http.StatusForbidden, get(t, "").StatusCode)
+		require.Equal(t, http.StatusForbidden, get(t, "?room=other").StatusCode)
+		require.Equal(t, http.StatusOK, get(t, "?room=room").StatusCode)
+	})
+
+	t.Run("events are filtered", func(t *testing.T) {
+		grants = &auth.ClaimGrants{Video: &auth.VideoGrant{RoomList: true}}
+		res := get(t, "?room=room&events=participant_joined")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		ctx := context.Background()
+		events.Publish(ctx, &livekit.WebhookEvent{Event: "participant_joined", Room: &livekit.Room{Name: "other"}})
+		events.Publish(ctx, &livekit.WebhookEvent{Event: "track_published", Room: &livekit.Room{Name: "room"}})
+		events.Publish(ctx, &livekit.WebhookEvent{
+			Event:       "participant_joined",
+			Room:        &livekit.Room{Name: "room"},
+			Participant: &livekit.ParticipantInfo{Identity: "alice"},
+		})
+
+		reader := bufio.NewReader(res.Body)
+		var lines []string
+		for len(lines) < 3 {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+		require.True(t, strings.HasPrefix(lines[0], "id: EV_"))
+		require.Equal(t, "event: participant_joined", lines[1])
+
+		event := &livekit.WebhookEvent{}
+		require.NoError(t, protojson.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), event))
+		require.Equal(t, "room", event.Room.Name)
+		require.Equal(t, "alice", event.Participant.Identity)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		disabled, err := service.NewRoomEventService(&config.Config{}, bus, rpc.ClientParams{Bus: bus})
+		require.NoError(t, err)
+		require.False(t, disabled.Enabled())
+
+		w := httptest.NewRecorder()
+		disabled.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/room_events", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
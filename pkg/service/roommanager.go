@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -37,10 +38,15 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/clientconfiguration"
 	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/privacy"
 	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/selector"
 	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/audioprocessing"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
 )
 
 const (
@@ -48,6 +54,8 @@ const (
 	tokenRefreshInterval = 5 * time.Minute
 	tokenDefaultTTL      = 10 * time.Minute
 	iceConfigTTL         = 5 * time.Minute
+
+	maxResourceLeaks = 32
 )
 
 var affinityEpoch = time.Date(2000, 0, 0, 0, 0, 0, 0, time.UTC)
@@ -57,6 +65,45 @@ type iceConfigCacheEntry struct {
 	modifiedAt time.Time
 }
 
+// participantSession holds the room a participant hosted on this node is currently in,
+// and the message bus servers that are registered for it. Both change when the participant is moved.
+type participantSession struct {
+	lock        sync.Mutex
+	room        *rtc.Room
+	killServers func()
+	// the participant logs with the fields of the room it is in
+	logger *rtc.RoomLogger
+}
+
+func newParticipantSession(room *rtc.Room, roomLogger *rtc.RoomLogger) *participantSession {
+	return &participantSession{room: room, logger: roomLogger}
+}
+
+func (s *participantSession) Room() *rtc.Room {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.room
+}
+
+func (s *participantSession) setRoom(room *rtc.Room, killServers func()) {
+	s.lock.Lock()
+	prevKillServers := s.killServers
+	if s.room != room && s.logger != nil {
+		s.logger.MoveTo(room.Name(), room.ID())
+	}
+	s.room = room
+	s.killServers = killServers
+	s.lock.Unlock()
+
+	if prevKillServers != nil {
+		prevKillServers()
+	}
+}
+
+func (s *participantSession) close() {
+	s.setRoom(s.Room(), nil)
+}
+
 // RoomManager manages rooms and its interaction with participants.
 // It's responsible for creating, deleting rooms, as well as running sessions for participants
 type RoomManager struct {
@@ -75,13 +122,48 @@ type RoomManager struct {
 	versionGenerator  utils.TimedVersionGenerator
 	turnAuthHandler   *TURNAuthHandler
 	bus               psrpc.MessageBus
+	roomEvents        *RoomEventService
+	passcodes         *RoomPasscodes
+	restrictions      *JoinRestrictions
+	geoIP             GeoIPProvider
+	speakerMarkers    SpeakerMarkerStore
+	statsHistories    TrackStatsHistoryStore
+	packetCaptures    *PacketCaptures
+	transcriptions    *Transcriptions
+	audioProcessing   *AudioProcessing
+	transcoder        types.Transcoder
+	videoProcessor    EgressVideoProcessor
 
 	rooms map[livekit.RoomName]*rtc.Room
+	// rooms moved to other nodes by a drain or redirect, the other node owns their state
+	movedRooms map[livekit.RoomName]*rtc.Room
+
+	participantSessions map[livekit.ParticipantID]*participantSession
 
 	roomServers        utils.MultitonService[rpc.RoomTopic]
 	participantServers utils.MultitonService[rpc.ParticipantTopic]
 
+	roomExtServers        utils.MultitonService[rpc.RoomTopic]
+	participantExtServers utils.MultitonService[rpc.ParticipantTopic]
+	nodeExtServer         NodeExtServer
+
+	bitrates *bitrateMeter
+
+	drain         *nodeDrain
+	drainSelector selector.NodeSelector
+
 	iceConfigCache map[livekit.ParticipantIdentity]*iceConfigCacheEntry
+
+	// rooms that closed with goroutines or timers still running, most recent last
+	resourceLeaks []*RoomResourceLeak
+}
+
+// RoomResourceLeak describes the goroutines and timers of a room that were still running after it closed
+type RoomResourceLeak struct {
+	Room       livekit.RoomName      `json:"room"`
+	RoomID     livekit.RoomID        `json:"room_id"`
+	DetectedAt time.Time             `json:"detected_at"`
+	Resources  sutils.ResourceCounts `json:"resources"`
 }
 
 func NewLocalRoomManager(
@@ -96,13 +178,40 @@ func NewLocalRoomManager(
 	versionGenerator utils.TimedVersionGenerator,
 	turnAuthHandler *TURNAuthHandler,
 	bus psrpc.MessageBus,
+	roomEvents *RoomEventService,
+	passcodes *RoomPasscodes,
+	restrictions *JoinRestrictions,
+	geoIP GeoIPProvider,
+	speakerMarkers SpeakerMarkerStore,
+	statsHistories TrackStatsHistoryStore,
 ) (*RoomManager, error) {
 	rtcConf, err := rtc.NewWebRTCConfig(conf)
 	if err != nil {
 		return nil, err
 	}
+	if err := validateRoomRules(&conf.Room); err != nil {
+		return nil, err
+	}
+	conf.OnReload(func(rc *config.ReloadableConfig) {
+		if err := validateRoomRules(&rc.Room); err != nil {
+			logger.Warnw("room rules are not valid, they are left out of new rooms", err)
+		}
+	})
+	packetCaptures, err := NewPacketCaptures(conf.PacketCapture, currentNode.Id)
+	if err != nil {
+		return nil, err
+	}
+	transcriptions, err := NewTranscriptions(conf.Transcription)
+	if err != nil {
+		return nil, err
+	}
+	audioProcessing, err := NewAudioProcessing(conf.AudioProcessing)
+	if err != nil {
+		return nil, err
+	}
 
-	return &RoomManager{
+	bytesIn, bytesOut := prometheus.GetBytes()
+	r := &RoomManager{
 		config:            conf,
 		rtcConfig:         rtcConf,
 		currentNode:       currentNode,
@@ -115,8 +224,20 @@ func NewLocalRoomManager(
 		versionGenerator:  versionGenerator,
 		turnAuthHandler:   turnAuthHandler,
 		bus:               bus,
+		roomEvents:        roomEvents,
+		passcodes:         passcodes,
+		restrictions:      restrictions,
+		geoIP:             geoIP,
+		speakerMarkers:    speakerMarkers,
+		statsHistories:    statsHistories,
+		packetCaptures:    packetCaptures,
+		transcriptions:    transcriptions,
+		audioProcessing:   audioProcessing,
 
-		rooms: make(map[livekit.RoomName]*rtc.Room),
+		rooms:      make(map[livekit.RoomName]*rtc.Room),
+		movedRooms: make(map[livekit.RoomName]*rtc.Room),
+
+		participantSessions: make(map[livekit.ParticipantID]*participantSession),
 
 		iceConfigCache: make(map[livekit.ParticipantIdentity]*iceConfigCacheEntry),
 
@@ -127,7 +248,45 @@ func NewLocalRoomManager(
 			Region:   conf.Region,
 			NodeId:   currentNode.Id,
 		},
-	}, nil
+
+		bitrates: newBitrateMeter(time.Now(), bytesIn, bytesOut),
+	}
+
+	if r.drainSelector, err = selector.CreateNodeSelector(conf); err != nil {
+		return nil, err
+	}
+	if r.nodeExtServer, err = NewNodeExtServer(r, bus); err != nil {
+		return nil, err
+	}
+	if err = r.nodeExtServer.RegisterAllNodeTopics(livekit.NodeID(currentNode.Id)); err != nil {
+		r.nodeExtServer.Kill()
+		return nil, err
+	}
+	return r, nil
+}
+
+func validateRoomRules(conf *config.RoomConfig) error {
+	if _, err := rtc.NewRoomRules(conf.Rules); err != nil {
+		return err
+	}
+	for name, template := range conf.Templates {
+		if _, err := rtc.NewRoomRules(template.Rules); err != nil {
+			return fmt.Errorf("room template %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// roomRules returns the rules of the room config, and of the room template the room was created from
+func (r *RoomManager) roomRules(options *rtc.RoomOptions) []*rtc.RoomRule {
+	roomConf := r.config.Reloadable().Room
+	confs := roomConf.Rules
+	if options != nil && options.Template != "" {
+		confs = append(append([]config.RoomRuleConfig(nil), confs...), roomConf.Templates[options.Template].Rules...)
+	}
+	// invalid rules are reported when the config is loaded
+	rules, _ := rtc.NewRoomRules(confs)
+	return rules
 }
 
 func (r *RoomManager) GetRoom(_ context.Context, roomName livekit.RoomName) *rtc.Room {
@@ -136,6 +295,22 @@ func (r *RoomManager) GetRoom(_ context.Context, roomName livekit.RoomName) *rtc
 	return r.rooms[roomName]
 }
 
+// ResourceLeaks returns the rooms that closed with goroutines or timers still running
+func (r *RoomManager) ResourceLeaks() []*RoomResourceLeak {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return append([]*RoomResourceLeak(nil), r.resourceLeaks...)
+}
+
+func (r *RoomManager) addResourceLeak(leak *RoomResourceLeak) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.resourceLeaks = append(r.resourceLeaks, leak)
+	if len(r.resourceLeaks) > maxResourceLeaks {
+		r.resourceLeaks = r.resourceLeaks[len(r.resourceLeaks)-maxResourceLeaks:]
+	}
+}
+
 // deleteRoom completely deletes all room information, including active sessions, room store, and routing info
 func (r *RoomManager) deleteRoom(ctx context.Context, roomName livekit.RoomName) error {
 	logger.Infow("deleting room state", "room", roomName)
@@ -191,6 +366,7 @@ func (r *RoomManager) CloseIdleRooms() {
 	r.lock.RUnlock()
 
 	for _, room := range rooms {
+		room.CheckSchedule()
 		room.CloseIfEmpty()
 	}
 }
@@ -217,8 +393,15 @@ func (r *RoomManager) Stop() {
 		room.Close(types.ParticipantCloseReasonRoomManagerStop)
 	}
 
+	r.packetCaptures.Close()
+	r.transcriptions.Close()
+	r.audioProcessing.Close()
+
 	r.roomServers.Kill()
 	r.participantServers.Kill()
+	r.roomExtServers.Kill()
+	r.participantExtServers.Kill()
+	r.nodeExtServer.Kill()
 
 	if r.rtcConfig != nil {
 		if r.rtcConfig.UDPMux != nil {
@@ -227,6 +410,9 @@ func (r *RoomManager) Stop() {
 		if r.rtcConfig.TCPMuxListener != nil {
 			_ = r.rtcConfig.TCPMuxListener.Close()
 		}
+		if r.rtcConfig.Receiver.FanOutPool != nil {
+			r.rtcConfig.Receiver.FanOutPool.Close()
+		}
 	}
 }
 
@@ -257,6 +443,8 @@ func (r *RoomManager) StartSession(
 	// since this is used for TURN server credentials, we don't want to fail the request even if there's no TURN for the session
 	apiKey, _, _ := r.getFirstKeyPair()
 
+	var resumedSession *ParticipantSession
+	var resumedInfo *livekit.ParticipantInfo
 	participant := room.GetParticipant(pi.Identity)
 	if participant != nil {
 		// When reconnecting, it means WS has interrupted but underlying peer connection is still ok in this state,
@@ -319,8 +507,11 @@ func (r *RoomManager) StartSession(
 				logger.Warnw("could not resume participant", err, "participant", pi.Identity)
 				return err
 			}
+			if pi.BandwidthHint > 0 {
+				participant.SetSubscriberBandwidthHint(pi.BandwidthHint)
+			}
 			r.telemetry.ParticipantResumed(ctx, room.ToProto(), participant.ToProto(), livekit.NodeID(r.currentNode.Id), pi.ReconnectReason)
-			go r.rtcSessionWorker(room, participant, requestSource)
+			go r.rtcSessionWorker(r.getParticipantSession(room, participant), participant, requestSource)
 			return nil
 		}
 
@@ -328,6 +519,9 @@ func (r *RoomManager) StartSession(
 		participant.GetLogger().Infow("removing duplicate participant")
 		room.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonDuplicateIdentity)
 	} else if pi.Reconnect {
+		resumedSession, resumedInfo = r.loadResumableSession(ctx, roomName, pi)
+	}
+	if pi.Reconnect && participant == nil && resumedSession == nil {
 		// send leave request if participant is trying to reconnect without keep subscribe state
 		// but missing from the room
 		var leave *livekit.LeaveRequest
@@ -355,7 +549,7 @@ func (r *RoomManager) StartSession(
 		"room", roomName,
 		"nodeID", r.currentNode.Id,
 		"participant", pi.Identity,
-		"clientInfo", logger.Proto(pi.Client),
+		"clientInfo", logger.Proto(privacy.ClientInfo(pi.Client)),
 		"reconnect", pi.Reconnect,
 		"reconnectReason", pi.ReconnectReason,
 		"adaptiveStream", pi.AdaptiveStream,
@@ -368,8 +562,22 @@ func (r *RoomManager) StartSession(
 	rtcConf := *r.rtcConfig
 	rtcConf.SetBufferFactory(room.GetBufferFactory())
 	sid := livekit.ParticipantID(utils.NewGuid(utils.ParticipantPrefix))
+	initialVersion := uint32(0)
+	if resumedSession != nil {
+		// others know the participant from the previous node, its updates carry on from there
+		sid = resumedSession.ParticipantID
+		initialVersion = resumedInfo.Version + 1
+		logger.Infow("resuming RTC session lost with another node",
+			"room", roomName,
+			"participant", pi.Identity,
+			"pID", sid,
+			"previousNodeID", resumedSession.NodeID,
+			"reason", pi.ReconnectReason,
+		)
+	}
+	roomLogger := rtc.NewRoomLogger(logger.GetLogger(), room.Name(), room.ID())
 	pLogger := rtc.LoggerWithParticipant(
-		rtc.LoggerWithRoom(logger.GetLogger(), room.Name(), room.ID()),
+		roomLogger,
 		pi.Identity,
 		sid,
 		false)
@@ -393,36 +601,69 @@ func (r *RoomManager) StartSession(
 	if r.config.RTC.ReconnectOnDataChannelError != nil {
 		reconnectOnDataChannelError = *r.config.RTC.ReconnectOnDataChannelError
 	}
-	subscriberAllowPause := r.config.RTC.CongestionControl.AllowPause
+	// server config values the room can override, on top of those of its latency profile
+	confOverrides := room.Options().ConfigOverrides
+	latencyProfile := room.Options().GetLatencyProfile()
+	audioConf := confOverrides.ApplyAudio(r.config.Audio)
+	congestionControlConf := confOverrides.ApplyCongestionControl(latencyProfile.ApplyCongestionControl(r.config.RTC.CongestionControl))
+	subscriberAllowPause := congestionControlConf.AllowPause
 	if pi.SubscriberAllowPause != nil {
 		subscriberAllowPause = *pi.SubscriberAllowPause
 	}
+	codecDeprecations := r.config.Reloadable().Room.DeprecatedCodecs
+	// participant can be moved to another room, anything bound to the room is resolved through the session
+	session := newParticipantSession(room, roomLogger)
+	clientMeta := &livekit.AnalyticsClientMeta{Region: r.currentNode.Region, Node: r.currentNode.Id}
+	// the participant joined event of a new session is sent with the latency breakdown once the join completes
+	var joinLatency telemetry.JoinLatency
+	var onJoinCompleted func(participant types.LocalParticipant, latency telemetry.JoinLatency)
+	if resumedSession == nil {
+		joinLatency = signalJoinLatency(pi, sessionStartTime)
+		onJoinCompleted = func(participant types.LocalParticipant, latency telemetry.JoinLatency) {
+			r.telemetry.ParticipantJoinCompleted(
+				telemetry.ContextWithAPIKey(ctx, pi.APIKey),
+				session.Room().ToProto(),
+				participant.ToProto(),
+				pi.Client,
+				clientMeta,
+				sessionStartTime,
+				latency,
+			)
+		}
+	}
 	participant, err = rtc.NewParticipant(rtc.ParticipantParams{
 		Identity:                pi.Identity,
 		Name:                    pi.Name,
 		SID:                     sid,
 		Config:                  &rtcConf,
 		Sink:                    responseSink,
-		AudioConfig:             r.config.Audio,
+		AudioConfig:             audioConf,
 		VideoConfig:             r.config.Video,
 		ProtocolVersion:         pv,
 		SessionStartTime:        sessionStartTime,
 		Telemetry:               r.telemetry,
 		Trailer:                 room.Trailer(),
 		PLIThrottleConfig:       r.config.RTC.PLIThrottle,
-		CongestionControlConfig: r.config.RTC.CongestionControl,
-		PublishEnabledCodecs:    protoRoom.EnabledCodecs,
+		CongestionControlConfig: congestionControlConf,
+		PublishEnabledCodecs:    rtc.FilterCutOffCodecs(protoRoom.EnabledCodecs, codecDeprecations, time.Now()),
 		SubscribeEnabledCodecs:  protoRoom.EnabledCodecs,
+		FmtpOverrides:           r.fmtpOverridesForRoom(room),
+		CodecDeprecations:       codecDeprecations,
+		CodecRestriction:        room.Options().Codecs,
 		Grants:                  pi.Grants,
+		APIKey:                  pi.APIKey,
 		Logger:                  pLogger,
 		ClientConf:              clientConf,
 		ClientInfo:              rtc.ClientInfo{ClientInfo: pi.Client},
 		Region:                  pi.Region,
+		Migration:               resumedSession != nil,
+		InitialVersion:          initialVersion,
 		AdaptiveStream:          pi.AdaptiveStream,
 		AllowTCPFallback:        allowFallback,
 		TURNSEnabled:            r.config.IsTURNSEnabled(),
+		ICECandidatePolicy:      r.config.RTC.ICECandidatePolicies[pi.Grants.Kind],
 		GetParticipantInfo: func(pID livekit.ParticipantID) *livekit.ParticipantInfo {
-			if p := room.GetParticipantByID(pID); p != nil {
+			if p := session.Room().GetParticipantByID(pID); p != nil {
 				return p.ToProto()
 			}
 			return nil
@@ -431,13 +672,26 @@ func (r *RoomManager) StartSession(
 		ReconnectOnSubscriptionError: reconnectOnSubscriptionError,
 		ReconnectOnDataChannelError:  reconnectOnDataChannelError,
 		DataChannelMaxBufferedAmount: r.config.RTC.DataChannelMaxBufferedAmount,
+		LossyData:                    r.config.RTC.LossyData,
+		Transcoder:                   r.getTranscoder(),
+		MaxTranscodedCodecs:          r.config.RTC.TranscodeFallback.MaxCodecsPerTrack,
+		VideoProcessor:               newRoomVideoProcessor(r.getVideoProcessor(), pi.Identity, session.Room),
 		VersionGenerator:             r.versionGenerator,
-		TrackResolver:                room.ResolveMediaTrackForSubscriber,
-		SubscriberAllowPause:         subscriberAllowPause,
-		SubscriptionLimitAudio:       r.config.Limit.SubscriptionLimitAudio,
-		SubscriptionLimitVideo:       r.config.Limit.SubscriptionLimitVideo,
-		PlayoutDelay:                 roomInternal.GetPlayoutDelay(),
-		SyncStreams:                  roomInternal.GetSyncStreams(),
+		TrackResolver: func(subIdentity livekit.ParticipantIdentity, trackID livekit.TrackID) types.MediaResolverResult {
+			return session.Room().ResolveMediaTrackForSubscriber(subIdentity, trackID)
+		},
+		SubscriberAllowPause:   subscriberAllowPause,
+		SubscriptionLimitAudio: r.config.Reloadable().Limit.SubscriptionLimitAudio,
+		SubscriptionLimitVideo: r.config.Reloadable().Limit.SubscriptionLimitVideo,
+		PlayoutDelay:           confOverrides.ApplyPlayoutDelay(latencyProfile.ApplyPlayoutDelay(roomInternal.GetPlayoutDelay())),
+		SyncStreams:            roomInternal.GetSyncStreams(),
+		MaxTrackBitrate:        confOverrides.ApplyMaxTrackBitrate(r.config.RTC.MaxTrackBitrate),
+		SubscribeDefaults:      room.Options().ApplySubscribeDefaults(r.config.Reloadable().Room.SubscribeDefaults),
+		SubscribeFilter:        pi.SubscribeFilter,
+		BandwidthHint:          pi.BandwidthHint,
+		ResourceTracker:        room.NewParticipantResources(),
+		JoinLatency:            joinLatency,
+		OnJoinCompleted:        onJoinCompleted,
 	})
 	if err != nil {
 		return err
@@ -449,51 +703,63 @@ func (r *RoomManager) StartSession(
 		AutoSubscribe: pi.AutoSubscribe,
 	}
 	iceServers := r.iceServersForParticipant(apiKey, participant, iceConfig.PreferenceSubscriber == livekit.ICECandidateType_ICT_TLS)
-	if err = room.Join(participant, requestSource, &opts, iceServers); err != nil {
+	if resumedSession != nil {
+		restoreParticipantSession(participant, resumedInfo)
+		err = room.JoinResumed(participant, requestSource, &opts, iceServers, pi.ReconnectReason)
+	} else {
+		err = room.Join(participant, requestSource, &opts, iceServers)
+	}
+	if err != nil {
 		pLogger.Errorw("could not join room", err)
 		_ = participant.Close(true, types.ParticipantCloseReasonJoinFailed, false)
 		return err
 	}
+	if resumedSession != nil {
+		restoreSubscriptionPermission(room, participant, resumedSession)
+	}
 
-	participantTopic := rpc.FormatParticipantTopic(roomName, participant.Identity())
-	participantServer := must.Get(rpc.NewTypedParticipantServer(r, r.bus))
-	killParticipantServer := r.participantServers.Replace(participantTopic, participantServer)
-	if err := participantServer.RegisterAllParticipantTopics(participantTopic); err != nil {
-		killParticipantServer()
+	killServers, err := r.registerParticipantServers(roomName, participant.Identity())
+	if err != nil {
 		pLogger.Errorw("could not join register participant topic", err)
 		_ = participant.Close(true, types.ParticipantCloseReasonMessageBusFailed, false)
 		return err
 	}
+	session.setRoom(room, killServers)
+	r.lock.Lock()
+	r.participantSessions[participant.ID()] = session
+	r.lock.Unlock()
 
 	if err = r.roomStore.StoreParticipant(ctx, roomName, participant.ToProto()); err != nil {
 		pLogger.Errorw("could not store participant", err)
 	}
 
-	persistRoomForParticipantCount := func(proto *livekit.Room) {
-		if !participant.Hidden() && !room.IsClosed() {
-			err = r.roomStore.StoreRoom(ctx, proto, room.Internal())
-			if err != nil {
-				logger.Errorw("could not store room", err)
-			}
-		}
-	}
-
 	// update room store with new numParticipants
-	persistRoomForParticipantCount(room.ToProto())
+	r.persistRoomForParticipantCount(ctx, room, participant)
 
-	clientMeta := &livekit.AnalyticsClientMeta{Region: r.currentNode.Region, Node: r.currentNode.Id}
-	r.telemetry.ParticipantJoined(ctx, protoRoom, participant.ToProto(), pi.Client, clientMeta, true)
+	if resumedSession != nil {
+		r.telemetry.ParticipantResumed(ctx, room.ToProto(), participant.ToProto(), livekit.NodeID(r.currentNode.Id), pi.ReconnectReason)
+	} else {
+		r.telemetry.ParticipantJoined(telemetry.ContextWithAPIKey(ctx, pi.APIKey), protoRoom, participant.ToProto(), pi.Client, clientMeta, false)
+	}
 	participant.OnClose(func(p types.LocalParticipant) {
-		killParticipantServer()
-
-		if err := r.roomStore.DeleteParticipant(ctx, roomName, p.Identity()); err != nil {
-			pLogger.Errorw("could not delete participant", err)
+		session.close()
+		r.lock.Lock()
+		if r.participantSessions[p.ID()] == session {
+			delete(r.participantSessions, p.ID())
 		}
+		r.lock.Unlock()
+
+		room := session.Room()
+		if !r.isMigratedRoom(room) {
+			if err := r.roomStore.DeleteParticipant(ctx, room.Name(), p.Identity()); err != nil {
+				pLogger.Errorw("could not delete participant", err)
+			}
 
-		// update room store with new numParticipants
-		proto := room.ToProto()
-		persistRoomForParticipantCount(proto)
-		r.telemetry.ParticipantLeft(ctx, proto, p.ToProto(), true)
+			// update room store with new numParticipants
+			r.persistRoomForParticipantCount(ctx, room, p)
+			r.retainTrackStatsHistory(ctx, room, p)
+		}
+		r.telemetry.ParticipantLeft(ctx, room.ToProto(), p.ToProto(), true)
 	})
 	participant.OnClaimsChanged(func(participant types.LocalParticipant) {
 		pLogger.Debugw("refreshing client token after claims change")
@@ -510,10 +776,67 @@ func (r *RoomManager) StartSession(
 		r.lock.Unlock()
 	})
 
-	go r.rtcSessionWorker(room, participant, requestSource)
+	go r.rtcSessionWorker(session, participant, requestSource)
 	return nil
 }
 
+func (r *RoomManager) registerParticipantServers(roomName livekit.RoomName, identity livekit.ParticipantIdentity) (func(), error) {
+	participantTopic := rpc.FormatParticipantTopic(roomName, identity)
+	participantServer := must.Get(rpc.NewTypedParticipantServer(r, r.bus))
+	killParticipantServer := r.participantServers.Replace(participantTopic, participantServer)
+	if err := participantServer.RegisterAllParticipantTopics(participantTopic); err != nil {
+		killParticipantServer()
+		return nil, err
+	}
+
+	participantExtServer := must.Get(NewParticipantExtServer(r, r.bus))
+	killParticipantExtServer := r.participantExtServers.Replace(participantTopic, participantExtServer)
+	if err := participantExtServer.RegisterAllParticipantTopics(participantTopic); err != nil {
+		killParticipantServer()
+		killParticipantExtServer()
+		return nil, err
+	}
+
+	return func() {
+		killParticipantServer()
+		killParticipantExtServer()
+	}, nil
+}
+
+// fmtpOverridesForRoom returns the overrides of the server config followed by the ones of the room,
+// so that the room overrides take precedence
+func (r *RoomManager) fmtpOverridesForRoom(room *rtc.Room) []*livekit.Codec {
+	roomOverrides := room.Options().FmtpOverrides
+	confOverrides := r.config.Reloadable().Room.FmtpOverrides
+	overrides := make([]*livekit.Codec, 0, len(confOverrides)+len(roomOverrides))
+	for _, codec := range confOverrides {
+		overrides = append(overrides, &livekit.Codec{
+			Mime:     codec.Mime,
+			FmtpLine: codec.FmtpLine,
+		})
+	}
+	return append(overrides, roomOverrides...)
+}
+
+func (r *RoomManager) persistRoomForParticipantCount(ctx context.Context, room *rtc.Room, participant types.LocalParticipant) {
+	if !participant.Hidden() && !room.IsClosed() {
+		if err := r.roomStore.StoreRoom(ctx, room.ToProto(), room.Internal()); err != nil {
+			logger.Errorw("could not store room", err)
+		}
+	}
+}
+
+func (r *RoomManager) getParticipantSession(room *rtc.Room, participant types.LocalParticipant) *participantSession {
+	r.lock.RLock()
+	session := r.participantSessions[participant.ID()]
+	r.lock.RUnlock()
+
+	if session == nil {
+		session = newParticipantSession(room, nil)
+	}
+	return session
+}
+
 // create the actual room object, to be used on RTC node
 func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.RoomName) (*rtc.Room, error) {
 	r.lock.RLock()
@@ -529,6 +852,12 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 	if err != nil {
 		return nil, err
 	}
+	options, err := r.roomStore.LoadRoomOptions(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	// room events are attributed to the key owning the room
+	ctx = telemetry.ContextWithAPIKey(ctx, options.APIKey)
 
 	r.lock.Lock()
 
@@ -545,7 +874,7 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 	}
 
 	// construct ice servers
-	newRoom := rtc.NewRoom(ri, internal, *r.rtcConfig, &r.config.Audio, r.serverInfo, r.telemetry, r.agentClient, r.egressLauncher)
+	newRoom := rtc.NewRoom(ri, internal, options, *r.rtcConfig, &r.config.Audio, r.serverInfo, r.telemetry, r.agentClient, r.egressLauncher)
 
 	roomTopic := rpc.FormatRoomTopic(roomName)
 	roomServer := must.Get(rpc.NewTypedRoomServer(r, r.bus))
@@ -555,34 +884,98 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 		r.lock.Unlock()
 		return nil, err
 	}
+	roomExtServer := must.Get(NewRoomExtServer(r, r.bus))
+	killRoomExtServer := r.roomExtServers.Replace(roomTopic, roomExtServer)
+	if err := roomExtServer.RegisterAllRoomTopics(roomTopic); err != nil {
+		killRoomExtServer()
+		killRoomServer()
+		r.lock.Unlock()
+		return nil, err
+	}
 
 	newRoom.OnClose(func() {
 		killRoomServer()
+		killRoomExtServer()
 
 		roomInfo := newRoom.ToProto()
 		r.telemetry.RoomEnded(ctx, roomInfo)
 		prometheus.RoomEnded(time.Unix(roomInfo.CreationTime, 0))
-		if err := r.deleteRoom(ctx, roomName); err != nil {
+		if r.isMigratedRoom(newRoom) {
+			// routing and store now belong to the node the room moved to
+			r.lock.Lock()
+			if r.rooms[roomName] == newRoom {
+				delete(r.rooms, roomName)
+			}
+			if r.movedRooms[roomName] == newRoom {
+				delete(r.movedRooms, roomName)
+			}
+			r.lock.Unlock()
+		} else if err := r.deleteRoom(ctx, roomName); err != nil {
 			newRoom.Logger.Errorw("could not delete room", err)
 		}
 
 		newRoom.Logger.Infow("room closed")
 	})
 
+	newRoom.Resources().SetBudget(int(r.config.Reloadable().Room.GoroutineBudget))
+	newRoom.OnResourceLeak(func(counts sutils.ResourceCounts) {
+		prometheus.RecordRoomResourceLeak()
+		r.addResourceLeak(&RoomResourceLeak{
+			Room:       newRoom.Name(),
+			RoomID:     newRoom.ID(),
+			DetectedAt: time.Now(),
+			Resources:  counts,
+		})
+	})
+
+	newRoom.OnOptionsChanged(func(options *rtc.RoomOptions) {
+		if r.isMigratedRoom(newRoom) {
+			return
+		}
+		if err := r.roomStore.StoreRoomOptions(ctx, roomName, options); err != nil {
+			newRoom.Logger.Errorw("could not store room options", err)
+		}
+	})
+
+	metadataEvents := r.roomEvents.newRoomMetadataEvents(newRoom.ToProto())
 	newRoom.OnRoomUpdated(func() {
+		metadataEvents.RoomUpdated(ctx, newRoom)
+		if r.isMigratedRoom(newRoom) {
+			return
+		}
 		if err := r.roomStore.StoreRoom(ctx, newRoom.ToProto(), newRoom.Internal()); err != nil {
 			newRoom.Logger.Errorw("could not handle metadata update", err)
 		}
 	})
 
 	newRoom.OnParticipantChanged(func(p types.LocalParticipant) {
-		if !p.IsDisconnected() {
+		metadataEvents.ParticipantChanged(ctx, newRoom, p)
+		if !p.IsDisconnected() && !r.isMigratedRoom(newRoom) {
 			if err := r.roomStore.StoreParticipant(ctx, roomName, p.ToProto()); err != nil {
 				newRoom.Logger.Errorw("could not handle participant change", err)
 			}
+			r.storeParticipantSession(ctx, newRoom, p)
+		}
+	})
+
+	newRoom.OnSpeakerMarkersStopped(func(egressID string, markers []rtc.SpeakerMarker) {
+		if r.speakerMarkers == nil {
+			return
 		}
+		if err := r.speakerMarkers.StoreSpeakerMarkers(ctx, egressID, markers, speakerMarkersRetention); err != nil {
+			newRoom.Logger.Errorw("could not store speaker markers", err, "egressID", egressID)
+		}
+	})
+
+	newRoom.OnTrackPublished(func(p types.LocalParticipant, track types.MediaTrack) {
+		r.transcriptions.TrackPublished(newRoom, p, track)
+		r.audioProcessing.TrackPublished(newRoom, p, track)
 	})
 
+	newRoom.SetRules(r.roomRules(options))
+	newRoom.SetDataTopicLimits(r.config.Reloadable().Room.DataTopicLimits)
+	newRoom.SetDataReplay(r.config.Reloadable().Room.DataReplay)
+
 	r.rooms[roomName] = newRoom
 
 	r.lock.Unlock()
@@ -596,7 +989,8 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, roomName livekit.Room
 }
 
 // manages an RTC session for a participant, runs on the RTC node
-func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalParticipant, requestSource routing.MessageSource) {
+func (r *RoomManager) rtcSessionWorker(session *participantSession, participant types.LocalParticipant, requestSource routing.MessageSource) {
+	room := session.Room()
 	pLogger := rtc.LoggerWithParticipant(
 		rtc.LoggerWithRoom(logger.GetLogger(), room.Name(), room.ID()),
 		participant.Identity(),
@@ -635,6 +1029,7 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalPa
 		case obj := <-requestSource.ReadChan():
 			// In single node mode, the request source is directly tied to the signal message channel
 			// this means ICE restart isn't possible in single node mode
+			room := session.Room()
 			if obj == nil {
 				if room.GetParticipantRequestSource(participant.Identity()) == requestSource {
 					participant.HandleSignalSourceClose()
@@ -682,6 +1077,36 @@ func (r *RoomManager) RemoveParticipant(ctx context.Context, req *livekit.RoomPa
 	return &livekit.RemoveParticipantResponse{}, nil
 }
 
+// BanParticipant stores a ban of the participant, with the IP it is connected from when asked to, and removes it
+// from the room
+func (r *RoomManager) BanParticipant(ctx context.Context, req *BanParticipantRequest) (*ParticipantBan, error) {
+	room, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	ban := &ParticipantBan{
+		Room:     string(room.Name()),
+		Identity: string(participant.Identity()),
+		BannedAt: time.Now(),
+	}
+	if req.BanIP {
+		// the address is the peer of the signal connection, or the client a trusted proxy forwarded it for
+		if ip := parseClientIP(participant.GetClientInfo().GetAddress()); ip != nil {
+			ban.IP = ip.String()
+		} else {
+			participant.GetLogger().Warnw("could not ban participant IP, client address is unknown", nil)
+		}
+	}
+	if err = r.roomStore.StoreParticipantBan(ctx, ban); err != nil {
+		return nil, err
+	}
+
+	participant.GetLogger().Infow("banning participant", "banIP", ban.IP != "")
+	room.RemoveParticipant(participant.Identity(), "", types.ParticipantCloseReasonServiceRequestRemoveParticipant)
+	return ban, nil
+}
+
 func (r *RoomManager) MutePublishedTrack(ctx context.Context, req *livekit.MuteRoomTrackRequest) (*livekit.MuteRoomTrackResponse, error) {
 	_, participant, err := r.roomAndParticipantForReq(ctx, req)
 	if err != nil {
@@ -690,7 +1115,7 @@ func (r *RoomManager) MutePublishedTrack(ctx context.Context, req *livekit.MuteR
 
 	participant.GetLogger().Debugw("setting track muted",
 		"trackID", req.TrackSid, "muted", req.Muted)
-	if !req.Muted && !r.config.Room.EnableRemoteUnmute {
+	if !req.Muted && !r.config.Reloadable().Room.EnableRemoteUnmute {
 		participant.GetLogger().Errorw("cannot unmute track, remote unmute is disabled", nil)
 		return nil, ErrRemoteUnmuteNoteEnabled
 	}
@@ -703,6 +1128,10 @@ func (r *RoomManager) UpdateParticipant(ctx context.Context, req *livekit.Update
 	if err != nil {
 		return nil, err
 	}
+	// the update would let the participant out of the waiting room without admitting it
+	if room.IsPendingAdmission(participant.Identity()) {
+		return nil, ErrUpdateParticipantPending
+	}
 
 	participant.GetLogger().Debugw("updating participant",
 		"metadata", req.Metadata, "permission", req.Permission)
@@ -713,80 +1142,731 @@ func (r *RoomManager) UpdateParticipant(ctx context.Context, req *livekit.Update
 	return participant.ToProto(), nil
 }
 
-func (r *RoomManager) DeleteRoom(ctx context.Context, req *livekit.DeleteRoomRequest) (*livekit.DeleteRoomResponse, error) {
-	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
-	if room == nil {
-		// special case of a non-RTC room e.g. room created but no participants joined
-		logger.Debugw("Deleting non-rtc room, loading from roomstore")
-		err := r.roomStore.DeleteRoom(ctx, livekit.RoomName(req.Room))
-		if err != nil {
-			logger.Debugw("Error deleting non-rtc room", "err", err)
-			return nil, err
-		}
-	} else {
-		room.Logger.Infow("deleting room")
-		room.Close(types.ParticipantCloseReasonServiceRequestDeleteRoom)
+// PatchParticipantMetadata applies a JSON merge patch to the metadata of the participant, when the version matches
+func (r *RoomManager) PatchParticipantMetadata(ctx context.Context, req *PatchParticipantMetadataRequest) (*MetadataPatchResponse, error) {
+	room, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
 	}
-	return &livekit.DeleteRoomResponse{}, nil
+
+	maxMetadataSize := int(r.config.Reloadable().Room.MaxMetadataSize)
+	metadata, err := room.PatchParticipantMetadata(participant, req.Patch, req.Version, maxMetadataSize)
+	if err != nil {
+		return nil, metadataPatchError(err)
+	}
+	participant.GetLogger().Debugw("patched participant metadata", "metadata", metadata)
+	return &MetadataPatchResponse{
+		Metadata: metadata,
+		Version:  rtc.MetadataVersion(metadata),
+	}, nil
 }
 
-func (r *RoomManager) UpdateSubscriptions(ctx context.Context, req *livekit.UpdateSubscriptionsRequest) (*livekit.UpdateSubscriptionsResponse, error) {
-	room, participant, err := r.roomAndParticipantForReq(ctx, req)
+func (r *RoomManager) AdmitParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	room, _, err := r.roomAndParticipantForReq(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	participant.GetLogger().Debugw("updating participant subscriptions")
-	room.UpdateSubscriptions(
-		participant,
-		livekit.StringsAsIDs[livekit.TrackID](req.TrackSids),
-		req.ParticipantTracks,
-		req.Subscribe,
-	)
-	return &livekit.UpdateSubscriptionsResponse{}, nil
+	participant, err := room.AdmitParticipant(livekit.ParticipantIdentity(req.Identity))
+	if err == rtc.ErrParticipantNotPending {
+		return nil, ErrParticipantNotPending
+	} else if err != nil {
+		return nil, err
+	}
+	return participant.ToProto(), nil
 }
 
-func (r *RoomManager) SendData(ctx context.Context, req *livekit.SendDataRequest) (*livekit.SendDataResponse, error) {
-	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
-	if room == nil {
-		return nil, ErrRoomNotFound
+// GetSubscriberAllocation returns the bandwidth estimate of the subscriber, how it is allocated to the video
+// tracks it subscribes to and the latest allocation decisions
+func (r *RoomManager) GetSubscriberAllocation(ctx context.Context, req *GetSubscriberAllocationRequest) (*streamallocator.AllocationInfo, error) {
+	_, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	room.Logger.Debugw("api send data", "size", len(req.Data))
-	up := &livekit.UserPacket{
-		Payload:               req.Data,
-		DestinationSids:       req.DestinationSids,
-		DestinationIdentities: req.DestinationIdentities,
-		Topic:                 req.Topic,
+	info := participant.GetSubscriberAllocationInfo(req.Decisions)
+	if info == nil {
+		return nil, ErrSubscriberAllocationMissing
 	}
-	room.SendDataPacket(up, req.Kind)
-	return &livekit.SendDataResponse{}, nil
+	return info, nil
 }
 
-func (r *RoomManager) UpdateRoomMetadata(ctx context.Context, req *livekit.UpdateRoomMetadataRequest) (*livekit.Room, error) {
-	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
-	if room == nil {
-		return nil, ErrRoomNotFound
+// GetCongestionTrace returns the congestion trace recorded by the stream allocator of the subscriber,
+// tagged with the room and participant it belongs to
+func (r *RoomManager) GetCongestionTrace(ctx context.Context, req *GetCongestionTraceRequest) (*streamallocator.CongestionTrace, error) {
+	room, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	room.Logger.Debugw("updating room")
-	done := room.SetMetadata(req.Metadata)
-	// wait till the update is applied
-	<-done
-	return room.ToProto(), nil
+	trace := participant.GetSubscriberCongestionTrace()
+	if trace == nil {
+		return nil, ErrCongestionTraceMissing
+	}
+	trace.OtherData["room"] = string(room.Name())
+	trace.OtherData["roomID"] = string(room.ID())
+	trace.OtherData["participant"] = string(participant.Identity())
+	trace.OtherData["participantID"] = string(participant.ID())
+	trace.OtherData["node"] = r.currentNode.Id
+	return trace, nil
 }
 
-func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.LocalParticipant, tlsOnly bool) []*livekit.ICEServer {
-	var iceServers []*livekit.ICEServer
-	rtcConf := r.config.RTC
+// SetTranscoder sets the transcoder of tracks subscribers cannot decode, used when rtc.transcode_fallback is enabled.
+// It applies to participants joining after it is set.
+func (r *RoomManager) SetTranscoder(transcoder types.Transcoder) {
+	r.lock.Lock()
+	r.transcoder = transcoder
+	r.lock.Unlock()
+}
 
-	if tlsOnly && r.config.TURN.TLSPort == 0 {
-		logger.Warnw("tls only enabled but no turn tls config", nil)
-		tlsOnly = false
+// SetVideoProcessor sets the processor of the video delivered to egress, for the tracks selected by the video
+// processing options of their room. It applies to participants joining after it is set.
+func (r *RoomManager) SetVideoProcessor(processor EgressVideoProcessor) {
+	r.lock.Lock()
+	r.videoProcessor = processor
+	r.lock.Unlock()
+}
+
+func (r *RoomManager) getVideoProcessor() EgressVideoProcessor {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.videoProcessor
+}
+
+// SetAudioProcessor sets the processor compiled into the server for the audio of rooms enabling audio processing,
+// used when audio_processing is enabled without a gRPC service. It applies to tracks published after it is set.
+func (r *RoomManager) SetAudioProcessor(codec audioprocessing.Codec, processor audioprocessing.Processor) {
+	r.audioProcessing.SetProcessor(codec, processor)
+}
+
+func (r *RoomManager) getTranscoder() types.Transcoder {
+	if !r.config.RTC.TranscodeFallback.Enabled {
+		return nil
 	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.transcoder
+}
 
-	hasSTUN := false
-	if r.config.TURN.Enabled {
+// StartPacketCapture starts capturing the packets of a track published by the participant, or of all the
+// tracks it publishes when no track is given
+func (r *RoomManager) StartPacketCapture(ctx context.Context, req *StartPacketCaptureRequest) (*PacketCaptureInfo, error) {
+	room, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracks []types.LocalMediaTrack
+	for _, track := range participant.GetPublishedTracks() {
+		if req.TrackID != "" && track.ID() != livekit.TrackID(req.TrackID) {
+			continue
+		}
+		if lmt, ok := track.(types.LocalMediaTrack); ok {
+			tracks = append(tracks, lmt)
+		}
+	}
+	if len(tracks) == 0 {
+		return nil, ErrTrackNotFound
+	}
+
+	return r.packetCaptures.Start(room, participant, tracks, req)
+}
+
+// StopPacketCapture stops a packet capture of the participant, or returns its status when it has stopped
+func (r *RoomManager) StopPacketCapture(ctx context.Context, req *StopPacketCaptureRequest) (*PacketCaptureInfo, error) {
+	return r.packetCaptures.Stop(req)
+}
+
+// GetICEDiagnostics returns how the transports of the participant connected, or why they failed to
+func (r *RoomManager) GetICEDiagnostics(ctx context.Context, req *GetICEDiagnosticsRequest) (*ICEDiagnosticsResponse, error) {
+	room, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ICEDiagnosticsResponse{
+		Room:       string(room.Name()),
+		Identity:   string(participant.Identity()),
+		Transports: participant.GetICEDiagnostics(),
+	}, nil
+}
+
+// GetConnectionQualityDetails returns the connection quality of the participant with the stats of its uplink
+// and downlink, and the probable constraint of its network
+func (r *RoomManager) GetConnectionQualityDetails(ctx context.Context, req *GetConnectionQualityDetailsRequest) (*types.ConnectionQualityDetails, error) {
+	_, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return participant.GetConnectionQualityDetails(), nil
+}
+
+// GetAVSyncStats returns the audio/video sync skew of the publishers the participant is subscribed to
+func (r *RoomManager) GetAVSyncStats(ctx context.Context, req *GetAVSyncStatsRequest) (*types.AVSyncDetails, error) {
+	_, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return participant.GetAVSyncDetails(), nil
+}
+
+// GetTrackStatsHistory returns the RTP stats snapshots of the tracks of the participant kept over the retention
+func (r *RoomManager) GetTrackStatsHistory(ctx context.Context, req *GetTrackStatsHistoryRequest) (*TrackStatsHistoryResponse, error) {
+	room, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := participant.GetTrackStatsHistory(livekit.TrackID(req.TrackID), time.Duration(req.Minutes)*time.Minute)
+	if tracks == nil {
+		return nil, ErrTrackStatsHistoryMissing
+	}
+	if req.TrackID != "" && len(tracks) == 0 {
+		return nil, ErrTrackNotFound
+	}
+	return &TrackStatsHistoryResponse{
+		Room:     string(room.Name()),
+		Identity: string(participant.Identity()),
+		Tracks:   tracks,
+	}, nil
+}
+
+// retainTrackStatsHistory stores the track stats history of a participant that left, so that it can still be
+// queried for the configured period
+func (r *RoomManager) retainTrackStatsHistory(ctx context.Context, room *rtc.Room, participant types.LocalParticipant) {
+	retainAfterLeave := r.config.RTC.TrackStatsHistory.RetainAfterLeave
+	if r.statsHistories == nil || retainAfterLeave <= 0 {
+		return
+	}
+	tracks := participant.GetTrackStatsHistory("", 0)
+	if len(tracks) == 0 {
+		return
+	}
+	if err := r.statsHistories.StoreTrackStatsHistory(ctx, room.Name(), participant.Identity(), tracks, retainAfterLeave); err != nil {
+		participant.GetLogger().Errorw("could not store track stats history", err)
+	}
+}
+
+// GetTransportStats returns a snapshot of the stats of the publisher or subscriber transport of the participant
+func (r *RoomManager) GetTransportStats(ctx context.Context, req *GetTransportStatsRequest) (*types.TransportStats, error) {
+	target, err := req.signalTarget()
+	if err != nil {
+		return nil, err
+	}
+
+	_, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return participant.GetTransportStats(target), nil
+}
+
+// MoveParticipant transfers a participant with its published tracks to another room on this node,
+// the client stays connected and is informed about the new room through signal updates
+func (r *RoomManager) MoveParticipant(ctx context.Context, req *MoveParticipantRequest) (*livekit.ParticipantInfo, error) {
+	if req.DestinationRoom == "" || req.DestinationRoom == req.Room {
+		return nil, ErrMoveDestinationInvalid
+	}
+
+	source, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	destinationName := livekit.RoomName(req.DestinationRoom)
+	destination, err := r.getOrCreateMoveDestination(ctx, destinationName)
+	if err != nil {
+		return nil, err
+	}
+	defer destination.Release()
+
+	// checked before the participant leaves its room, so that it stays there when it cannot be moved
+	if err = r.checkMoveDestination(ctx, destination, participant); err != nil {
+		participant.GetLogger().Infow("cannot move participant", "destination", destinationName, "error", err)
+		return nil, err
+	}
+
+	killServers, err := r.registerParticipantServers(destinationName, participant.Identity())
+	if err != nil {
+		return nil, err
+	}
+
+	detached, err := source.DetachParticipant(participant.Identity())
+	if err == rtc.ErrParticipantPending {
+		killServers()
+		return nil, ErrMoveParticipantPending
+	} else if err != nil {
+		killServers()
+		return nil, err
+	}
+
+	if err = r.roomStore.DeleteParticipant(ctx, source.Name(), participant.Identity()); err != nil {
+		participant.GetLogger().Errorw("could not delete participant", err)
+	}
+	r.persistRoomForParticipantCount(ctx, source, participant)
+
+	r.lock.RLock()
+	session := r.participantSessions[participant.ID()]
+	r.lock.RUnlock()
+	if session != nil {
+		session.setRoom(destination, killServers)
+	} else {
+		killServers()
+	}
+	participant.MoveToRoom(destinationName)
+
+	if err = destination.AttachParticipant(detached); err != nil {
+		participant.GetLogger().Warnw("could not attach participant to destination room", err)
+		_ = participant.Close(true, types.ParticipantCloseReasonJoinFailed, false)
+		return nil, err
+	}
+	participant.GetLogger().Infow("moved participant", "source", source.Name(), "destination", destinationName)
+
+	r.persistRoomForParticipantCount(ctx, destination, participant)
+
+	clientMeta := &livekit.AnalyticsClientMeta{Region: r.currentNode.Region, Node: r.currentNode.Id}
+	r.telemetry.ParticipantLeft(ctx, source.ToProto(), participant.ToProto(), true)
+	r.telemetry.ParticipantJoined(ctx, destination.ToProto(), participant.ToProto(), participant.GetClientInfo(), clientMeta, true)
+
+	return participant.ToProto(), nil
+}
+
+// MergeRooms moves the participants of the room into the destination room, one at a time with MoveParticipant.
+// Recorders and agents stay, they work for the room they were started for. Participants that cannot be moved,
+// such as those waiting to be admitted, are reported as failed.
+func (r *RoomManager) MergeRooms(ctx context.Context, req *MergeRoomsRequest) (*MergeRoomsResponse, error) {
+	if req.DestinationRoom == "" || req.DestinationRoom == req.Room {
+		return nil, ErrMoveDestinationInvalid
+	}
+
+	source := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if source == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	// keeps the destination open between moves
+	destinationName := livekit.RoomName(req.DestinationRoom)
+	destination, err := r.getOrCreateMoveDestination(ctx, destinationName)
+	if err != nil {
+		return nil, err
+	}
+	defer destination.Release()
+
+	res := &MergeRoomsResponse{Moved: []string{}}
+	var moved []livekit.ParticipantIdentity
+	for _, p := range source.GetParticipants() {
+		if p.IsRecorder() || p.IsAgent() {
+			continue
+		}
+		if _, err := r.MoveParticipant(ctx, &MoveParticipantRequest{
+			Room:            req.Room,
+			Identity:        string(p.Identity()),
+			DestinationRoom: req.DestinationRoom,
+		}); err != nil {
+			res.Failed = append(res.Failed, MergeRoomsFailure{Identity: string(p.Identity()), Error: err.Error()})
+			continue
+		}
+		moved = append(moved, p.Identity())
+		res.Moved = append(res.Moved, string(p.Identity()))
+	}
+
+	source.Logger.Infow("merged room", "destination", destinationName, "moved", len(moved), "failed", len(res.Failed))
+	r.telemetry.RoomMerged(ctx, source.ToProto(), destinationName, moved)
+	return res, nil
+}
+
+// checkMoveDestination returns why the destination room would not let the participant join it: a ban, its join
+// restrictions, a passcode, a lock or no room left
+func (r *RoomManager) checkMoveDestination(ctx context.Context, destination *rtc.Room, participant types.LocalParticipant) error {
+	clientInfo := participant.GetClientInfo()
+	ban, err := loadParticipantBan(ctx, r.roomStore, destination.Name(), participant.Identity(), clientInfo.GetAddress())
+	if err != nil {
+		return err
+	} else if ban != nil {
+		return ErrParticipantBanned
+	}
+	// the join restrictions of the API key were checked when the participant joined
+	if err = r.restrictions.CheckJoin(ctx, destination.Name(), "", participant.Identity(), clientInfo); err != nil {
+		return err
+	}
+	if err = r.passcodes.CheckMove(ctx, destination.Name(), participant.ClaimGrants()); err != nil {
+		return err
+	}
+	return destination.CheckAttachParticipant(participant)
+}
+
+// a participant can only be moved into a room that is, or can be, hosted on this node
+func (r *RoomManager) getOrCreateMoveDestination(ctx context.Context, roomName livekit.RoomName) (*rtc.Room, error) {
+	if room := r.GetRoom(ctx, roomName); room != nil && room.Hold() {
+		return room, nil
+	}
+
+	node, err := r.router.GetNodeForRoom(ctx, roomName)
+	if err == nil && node.Id != r.currentNode.Id && selector.IsAvailable(node) {
+		return nil, ErrMoveDestinationRemote
+	} else if err != nil && err != routing.ErrNotFound {
+		return nil, err
+	}
+
+	if _, _, err = r.roomStore.LoadRoom(ctx, roomName, false); err != nil {
+		return nil, err
+	}
+	if err = r.router.SetNodeForRoom(ctx, roomName, livekit.NodeID(r.currentNode.Id)); err != nil {
+		return nil, err
+	}
+	return r.getOrCreateRoom(ctx, roomName)
+}
+
+func (r *RoomManager) DeleteRoom(ctx context.Context, req *livekit.DeleteRoomRequest) (*livekit.DeleteRoomResponse, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		// special case of a non-RTC room e.g. room created but no participants joined
+		logger.Debugw("Deleting non-rtc room, loading from roomstore")
+		err := r.roomStore.DeleteRoom(ctx, livekit.RoomName(req.Room))
+		if err != nil {
+			logger.Debugw("Error deleting non-rtc room", "err", err)
+			return nil, err
+		}
+	} else {
+		room.Logger.Infow("deleting room")
+		room.Close(types.ParticipantCloseReasonServiceRequestDeleteRoom)
+	}
+	return &livekit.DeleteRoomResponse{}, nil
+}
+
+func (r *RoomManager) UpdateSubscriptions(ctx context.Context, req *livekit.UpdateSubscriptionsRequest) (*livekit.UpdateSubscriptionsResponse, error) {
+	room, participant, err := r.roomAndParticipantForReq(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	participant.GetLogger().Debugw("updating participant subscriptions")
+	room.UpdateSubscriptions(
+		participant,
+		livekit.StringsAsIDs[livekit.TrackID](req.TrackSids),
+		req.ParticipantTracks,
+		req.Subscribe,
+	)
+	return &livekit.UpdateSubscriptionsResponse{}, nil
+}
+
+func (r *RoomManager) SendData(ctx context.Context, req *livekit.SendDataRequest) (*livekit.SendDataResponse, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	room.Logger.Debugw("api send data", "size", len(req.Data))
+	up := &livekit.UserPacket{
+		Payload:               req.Data,
+		DestinationSids:       req.DestinationSids,
+		DestinationIdentities: req.DestinationIdentities,
+		Topic:                 req.Topic,
+	}
+	room.SendDataPacket(up, req.Kind)
+	return &livekit.SendDataResponse{}, nil
+}
+
+// PatchRoomMetadata applies a JSON merge patch to the metadata of the room, when the version matches
+func (r *RoomManager) PatchRoomMetadata(ctx context.Context, req *PatchRoomMetadataRequest) (*MetadataPatchResponse, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	maxMetadataSize := int(r.config.Reloadable().Room.MaxMetadataSize)
+	metadata, done, err := room.PatchMetadata(req.Patch, req.Version, maxMetadataSize)
+	if err != nil {
+		return nil, metadataPatchError(err)
+	}
+	room.Logger.Debugw("patched room metadata")
+	// wait till the update is applied
+	<-done
+	return &MetadataPatchResponse{
+		Metadata: metadata,
+		Version:  rtc.MetadataVersion(metadata),
+	}, nil
+}
+
+func metadataPatchError(err error) error {
+	switch err {
+	case rtc.ErrMetadataVersionMismatch:
+		return ErrMetadataVersionMismatch
+	case rtc.ErrMetadataNotObject:
+		return ErrMetadataNotObject
+	case rtc.ErrMetadataPatchInvalid:
+		return ErrMetadataPatchInvalid
+	case rtc.ErrMetadataTooLarge:
+		return ErrMetadataExceedsLimits
+	default:
+		return err
+	}
+}
+
+func (r *RoomManager) UpdateRoomMetadata(ctx context.Context, req *livekit.UpdateRoomMetadataRequest) (*livekit.Room, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	room.Logger.Debugw("updating room")
+	done := room.SetMetadata(req.Metadata)
+	// wait till the update is applied
+	<-done
+	return room.ToProto(), nil
+}
+
+// MuteAllParticipants mutes the microphones of everyone in the room, optionally leaving hosts unmuted
+func (r *RoomManager) MuteAllParticipants(ctx context.Context, req *MuteAllParticipantsRequest) (*livekit.ListParticipantsResponse, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	except := make([]livekit.ParticipantIdentity, 0, len(req.Except))
+	for _, identity := range req.Except {
+		except = append(except, livekit.ParticipantIdentity(identity))
+	}
+	room.Logger.Infow("muting all participants", "excludeHosts", req.ExcludeHosts, "except", req.Except)
+	return participantsResponse(room.MuteAllMicrophones(req.ExcludeHosts, except)), nil
+}
+
+// MuteTracksBySource mutes or unmutes the tracks of a source published in the room, with a single participant
+// update per participant
+func (r *RoomManager) MuteTracksBySource(ctx context.Context, req *MuteTracksBySourceRequest) (*livekit.ListParticipantsResponse, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	source, err := req.trackSource()
+	if err != nil {
+		return nil, err
+	}
+	if !req.Muted && !r.config.Reloadable().Room.EnableRemoteUnmute {
+		return nil, ErrRemoteUnmuteNoteEnabled
+	}
+
+	except := make([]livekit.ParticipantIdentity, 0, len(req.Except))
+	for _, identity := range req.Except {
+		except = append(except, livekit.ParticipantIdentity(identity))
+	}
+	room.Logger.Infow("muting tracks by source", "source", source, "muted", req.Muted, "excludeHosts", req.ExcludeHosts, "except", req.Except)
+	return participantsResponse(room.MuteTracksBySource(source, req.Muted, req.ExcludeHosts, except)), nil
+}
+
+// LockRoom locks or unlocks the room against new joins, the change is stored with the room options
+// so that new sessions are rejected as well
+func (r *RoomManager) LockRoom(ctx context.Context, req *LockRoomRequest) (*livekit.Room, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	options := room.SetLocked(req.Locked)
+	if err := r.roomStore.StoreRoomOptions(ctx, room.Name(), options); err != nil {
+		room.Logger.Errorw("could not store room options", err)
+		return nil, err
+	}
+	return room.ToProto(), nil
+}
+
+// SetPushToTalk enables or disables push to talk, the change is stored with the room options
+func (r *RoomManager) SetPushToTalk(ctx context.Context, req *SetPushToTalkRequest) (*rtc.FloorState, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	if !req.PushToTalk.IsValid() {
+		return nil, ErrPushToTalkInvalid
+	}
+
+	options := room.SetPushToTalk(req.PushToTalk)
+	if err := r.roomStore.StoreRoomOptions(ctx, room.Name(), options); err != nil {
+		room.Logger.Errorw("could not store room options", err)
+		return nil, err
+	}
+	return room.FloorState(), nil
+}
+
+// GrantFloor lets a participant speak in a push to talk room, replacing the current floor holder,
+// or the first participant waiting for the floor when no identity is given
+func (r *RoomManager) GrantFloor(ctx context.Context, req *GrantFloorRequest) (*rtc.FloorState, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	state, err := room.GrantFloor(livekit.ParticipantIdentity(req.Identity), time.Duration(req.Duration)*time.Second)
+	return state, floorError(err)
+}
+
+func (r *RoomManager) ReleaseFloor(ctx context.Context, req *ReleaseFloorRequest) (*rtc.FloorState, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	state, err := room.ReleaseFloor(livekit.ParticipantIdentity(req.Identity))
+	return state, floorError(err)
+}
+
+// GetRoomDebugInfo returns the debug info tree of the room, as served by /debug/rooms in development mode
+func (r *RoomManager) GetRoomDebugInfo(ctx context.Context, req *GetRoomDebugInfoRequest) (*RoomDebugInfo, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	info := RoomDebugInfo(room.DebugInfo())
+	return &info, nil
+}
+
+// RecordSpeakerMarkers starts recording the loudest speaker changes of the room for an egress
+func (r *RoomManager) RecordSpeakerMarkers(ctx context.Context, req *RecordSpeakerMarkersRequest) (*SpeakerMarkersResponse, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	room.RecordSpeakerMarkers(req.EgressID)
+	return &SpeakerMarkersResponse{EgressID: req.EgressID, Markers: []rtc.SpeakerMarker{}}, nil
+}
+
+func (r *RoomManager) GetSpeakerMarkers(ctx context.Context, req *GetSpeakerMarkersRequest) (*SpeakerMarkersResponse, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	markers, ok := room.SpeakerMarkers(req.EgressID)
+	if !ok {
+		return nil, ErrSpeakerMarkersNotFound
+	}
+	return &SpeakerMarkersResponse{EgressID: req.EgressID, Markers: markers}, nil
+}
+
+// GetTrackSubscriberCounts returns the number of subscribers of each track published in the room,
+// or by one participant of the room
+func (r *RoomManager) GetTrackSubscriberCounts(ctx context.Context, req *GetTrackSubscriberCountsRequest) (*TrackSubscriberCounts, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	var participants []types.LocalParticipant
+	if req.Identity != "" {
+		p := room.GetParticipant(livekit.ParticipantIdentity(req.Identity))
+		if p == nil {
+			return nil, ErrParticipantNotFound
+		}
+		participants = append(participants, p)
+	} else {
+		participants = room.GetParticipants()
+	}
+
+	counts := &TrackSubscriberCounts{Tracks: []TrackSubscriberCount{}}
+	for _, p := range participants {
+		for _, track := range p.GetPublishedTracks() {
+			counts.Tracks = append(counts.Tracks, TrackSubscriberCount{
+				Identity:    string(p.Identity()),
+				TrackID:     string(track.ID()),
+				Name:        track.Name(),
+				Source:      strings.ToLower(track.Source().String()),
+				Subscribers: track.GetNumSubscribers(),
+			})
+		}
+	}
+	return counts, nil
+}
+
+// GetNodeConcurrency returns the totals of the rooms hosted by this node
+func (r *RoomManager) GetNodeConcurrency(_ context.Context, _ *GetNodeConcurrencyRequest) (*NodeConcurrency, error) {
+	r.lock.RLock()
+	rooms := maps.Values(r.rooms)
+	r.lock.RUnlock()
+
+	c := &NodeConcurrency{
+		NodeID: livekit.NodeID(r.currentNode.Id),
+	}
+	for _, room := range rooms {
+		c.Rooms++
+		for _, p := range room.GetParticipants() {
+			c.Participants++
+			c.PublishedTracks += len(p.GetPublishedTracks())
+			c.DownTracks += len(p.GetSubscribedTracks())
+		}
+	}
+	bytesIn, bytesOut := prometheus.GetBytes()
+	c.IngressBitrate, c.EgressBitrate = r.bitrates.read(time.Now(), bytesIn, bytesOut)
+	return c, nil
+}
+
+// signalJoinLatency returns the stages of a join that took place on the signal node, routing lasts until the session starts
+func signalJoinLatency(pi routing.ParticipantInit, sessionStartTime time.Time) telemetry.JoinLatency {
+	latency := telemetry.JoinLatency{TokenValidation: pi.TokenValidation}
+	if !pi.JoinRequestedAt.IsZero() {
+		// clocks of the signal and the media node can be apart, routing is not known then
+		if routing := sessionStartTime.Sub(pi.JoinRequestedAt) - pi.TokenValidation; routing > 0 {
+			latency.Routing = routing
+		}
+	}
+	return latency
+}
+
+func floorError(err error) error {
+	switch err {
+	case rtc.ErrPushToTalkDisabled:
+		return ErrPushToTalkDisabled
+	case rtc.ErrFloorQueueEmpty:
+		return ErrFloorQueueEmpty
+	case rtc.ErrNotFloorHolder:
+		return ErrNotFloorHolder
+	case rtc.ErrParticipantNotInRoom:
+		return ErrParticipantNotFound
+	}
+	return err
+}
+
+// UpdateParticipantsPermission sets the permission of several participants at once
+func (r *RoomManager) UpdateParticipantsPermission(ctx context.Context, req *UpdateParticipantsPermissionRequest) (*livekit.ListParticipantsResponse, error) {
+	room := r.GetRoom(ctx, livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	identities := make([]livekit.ParticipantIdentity, 0, len(req.Identities))
+	for _, identity := range req.Identities {
+		identities = append(identities, livekit.ParticipantIdentity(identity))
+	}
+	room.Logger.Infow("updating participants permission",
+		"identities", req.Identities, "permission", req.Permission, "excludeHosts", req.ExcludeHosts)
+	return participantsResponse(room.UpdateParticipantsPermission(identities, req.Permission, req.ExcludeHosts)), nil
+}
+
+func participantsResponse(participants []types.LocalParticipant) *livekit.ListParticipantsResponse {
+	res := &livekit.ListParticipantsResponse{
+		Participants: make([]*livekit.ParticipantInfo, 0, len(participants)),
+	}
+	for _, p := range participants {
+		res.Participants = append(res.Participants, p.ToProto())
+	}
+	return res
+}
+
+func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.LocalParticipant, tlsOnly bool) []*livekit.ICEServer {
+	var iceServers []*livekit.ICEServer
+	rtcConf := r.config.RTC
+
+	if tlsOnly && r.config.TURN.TLSPort == 0 {
+		logger.Warnw("tls only enabled but no turn tls config", nil)
+		tlsOnly = false
+	}
+
+	hasSTUN := false
+	if r.config.TURN.Enabled {
 		var urls []string
 		if r.config.TURN.UDPPort > 0 && !tlsOnly {
 			// UDP TURN is used as STUN
@@ -813,9 +1893,15 @@ func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.
 		}
 	}
 
-	if len(rtcConf.TURNServers) > 0 {
+	if relayServer := r.relayNodesICEServer(apiKey, participant, tlsOnly); relayServer != nil {
+		// relay nodes answer STUN on their TURN UDP port
 		hasSTUN = true
-		for _, s := range r.config.RTC.TURNServers {
+		iceServers = append(iceServers, relayServer)
+	}
+
+	if turnServers := r.config.Reloadable().TURNServers; len(turnServers) > 0 {
+		hasSTUN = true
+		for _, s := range turnServers {
 			scheme := "turn"
 			transport := "tcp"
 			if s.Protocol == "tls" {
@@ -845,28 +1931,79 @@ func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.
 	return iceServers
 }
 
+// relayNodesICEServer returns the TURN servers of the relay nodes nearest to the participant, from the region its
+// client is located in or the region of the node when that is not known. Relay nodes authenticate with the keys of
+// the cluster, the credentials of the embedded TURN server are valid on them
+func (r *RoomManager) relayNodesICEServer(apiKey string, participant types.LocalParticipant, tlsOnly bool) *livekit.ICEServer {
+	conf := r.config.RelayNodes
+	if conf.MaxPerParticipant <= 0 || conf.UDPPort <= 0 || tlsOnly {
+		return nil
+	}
+
+	nodes, err := r.router.ListNodes()
+	if err != nil {
+		participant.GetLogger().Warnw("could not list relay nodes", err)
+		return nil
+	}
+	regions := r.config.NodeSelector.Regions
+	region := clientRegion(r.geoIP, regions, participant.GetClientInfo())
+	if region == "" {
+		region = r.config.Region
+	}
+	relayNodes := selector.SelectRelayNodes(nodes, region, regions, conf.MaxPerParticipant)
+	if len(relayNodes) == 0 {
+		return nil
+	}
+
+	password, err := r.turnAuthHandler.CreatePassword(apiKey, participant.ID())
+	if err != nil {
+		participant.GetLogger().Warnw("could not create turn password", err)
+		return nil
+	}
+	urls := make([]string, 0, len(relayNodes))
+	for _, node := range relayNodes {
+		urls = append(urls, fmt.Sprintf("turn:%s:%d?transport=udp", node.Ip, conf.UDPPort))
+	}
+	return &livekit.ICEServer{
+		Urls:       urls,
+		Username:   r.turnAuthHandler.CreateUsername(apiKey, participant.ID()),
+		Credential: password,
+	}
+}
+
 func (r *RoomManager) refreshToken(participant types.LocalParticipant) error {
-	key, secret, err := r.getFirstKeyPair()
+	jwt, err := r.createToken(participant)
 	if err != nil {
 		return err
 	}
 
+	return participant.SendRefreshToken(jwt)
+}
+
+func (r *RoomManager) createToken(participant types.LocalParticipant) (string, error) {
+	key, secret, err := r.getFirstKeyPair()
+	if err != nil {
+		return "", err
+	}
+
 	grants := participant.ClaimGrants()
+	if filter := participant.GetSubscribeFilter(); filter != nil {
+		// the filter has to carry over to refreshed tokens, auth.AccessToken has no custom claims
+		return signToken(key, secret, &auth.ClaimGrants{
+			Identity: string(participant.Identity()),
+			Name:     grants.Name,
+			Metadata: grants.Metadata,
+			Video:    grants.Video,
+		}, tokenDefaultTTL, tokenClaims{SubscribeFilter: filter})
+	}
+
 	token := auth.NewAccessToken(key, secret)
 	token.SetName(grants.Name).
 		SetIdentity(string(participant.Identity())).
 		SetValidFor(tokenDefaultTTL).
 		SetMetadata(grants.Metadata).
 		AddGrant(grants.Video)
-	jwt, err := token.ToJWT()
-	if err == nil {
-		err = participant.SendRefreshToken(jwt)
-	}
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return token.ToJWT()
 }
 
 func (r *RoomManager) setIceConfig(participant types.LocalParticipant) *livekit.ICEConfig {
@@ -890,7 +2027,7 @@ func (r *RoomManager) getIceConfig(participant types.LocalParticipant) *livekit.
 }
 
 func (r *RoomManager) getFirstKeyPair() (string, string, error) {
-	for key, secret := range r.config.Keys {
+	if key, secret, ok := r.config.Reloadable().FirstKey(); ok {
 		return key, secret, nil
 	}
 	return "", "", errors.New("no API keys configured")
@@ -0,0 +1,119 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// RoomPasscodes checks the passcode presented by participants joining rooms created with one. Identities that fail
+// room.passcode.max_failures times in a row are locked out of the room for room.passcode.lockout_duration.
+// Attempts are counted in the store, so the lockout holds whichever node the participant connects to.
+type RoomPasscodes struct {
+	config *config.Config
+	store  ObjectStore
+}
+
+func NewRoomPasscodes(conf *config.Config, store ObjectStore) *RoomPasscodes {
+	return &RoomPasscodes{
+		config: conf,
+		store:  store,
+	}
+}
+
+// CheckJoin returns ErrRoomPasscodeInvalid when the room has a passcode and passcode does not match it, and
+// ErrRoomPasscodeLockedOut while the identity is locked out of the room. Hosts, recorders, agents and hidden
+// participants join without a passcode.
+func (p *RoomPasscodes) CheckJoin(ctx context.Context, roomName livekit.RoomName, claims *auth.ClaimGrants, passcode string) error {
+	if p == nil || passcodeExempt(claims) {
+		return nil
+	}
+
+	options, err := p.store.LoadRoomOptions(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	if !options.HasPasscode() {
+		return nil
+	}
+
+	conf := p.config.Reloadable().Room.Passcode
+	if conf.MaxFailures <= 0 {
+		if !options.CheckPasscode(passcode) {
+			return ErrRoomPasscodeInvalid
+		}
+		return nil
+	}
+
+	// the attempt is counted before the passcode is checked, so that concurrent attempts cannot get past the
+	// lockout. The attempt after max_failures failures in a row is the first one locked out
+	identity := livekit.ParticipantIdentity(claims.Identity)
+	attempts, err := p.store.IncrementPasscodeAttempts(ctx, roomName, identity, conf.MaxFailures, conf.LockoutDuration)
+	if err != nil {
+		return err
+	}
+	if attempts > conf.MaxFailures {
+		return ErrRoomPasscodeLockedOut
+	}
+
+	if options.CheckPasscode(passcode) {
+		if err := p.store.DeletePasscodeAttempts(ctx, roomName, identity); err != nil {
+			logger.Warnw("could not reset room passcode attempts", err, "room", roomName, "participant", identity)
+		}
+		return nil
+	}
+
+	if attempts == conf.MaxFailures {
+		logger.Infow("locking out participant after incorrect room passcodes",
+			"room", roomName,
+			"participant", identity,
+			"failures", attempts,
+			"lockedUntil", time.Now().Add(conf.LockoutDuration),
+		)
+	}
+	return ErrRoomPasscodeInvalid
+}
+
+// CheckMove returns ErrRoomPasscodeInvalid when a participant moved into the room would have to present its
+// passcode, a moved participant has no way to present one
+func (p *RoomPasscodes) CheckMove(ctx context.Context, roomName livekit.RoomName, claims *auth.ClaimGrants) error {
+	if p == nil || passcodeExempt(claims) {
+		return nil
+	}
+
+	options, err := p.store.LoadRoomOptions(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	if options.HasPasscode() {
+		return ErrRoomPasscodeInvalid
+	}
+	return nil
+}
+
+func passcodeExempt(claims *auth.ClaimGrants) bool {
+	if claims.GetParticipantKind() == livekit.ParticipantInfo_AGENT {
+		return true
+	}
+	video := claims.Video
+	return video != nil && (video.RoomAdmin || video.Recorder || video.Hidden)
+}
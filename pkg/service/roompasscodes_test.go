@@ -0,0 +1,94 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestRoomPasscodes(t *testing.T) {
+	ctx := context.Background()
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	conf.Room.Passcode = config.RoomPasscodeConfig{MaxFailures: 3, LockoutDuration: 200 * time.Millisecond}
+
+	store := service.NewLocalStore()
+	options := &rtc.RoomOptions{}
+	options.SetPasscode("1234")
+	require.NoError(t, store.StoreRoomOptions(ctx, "locked", options))
+	passcodes := service.NewRoomPasscodes(conf, store)
+
+	participant := func(identity string) *auth.ClaimGrants {
+		return &auth.ClaimGrants{Identity: identity, Video: &auth.VideoGrant{RoomJoin: true}}
+	}
+
+	t.Run("rooms without a passcode", func(t *testing.T) {
+		require.NoError(t, passcodes.CheckJoin(ctx, "open", participant("a"), ""))
+		require.NoError(t, passcodes.CheckJoin(ctx, "open", participant("a"), "1234"))
+	})
+
+	t.Run("passcode is required", func(t *testing.T) {
+		require.NoError(t, passcodes.CheckJoin(ctx, "locked", participant("a"), "1234"))
+		require.ErrorIs(t, passcodes.CheckJoin(ctx, "locked", participant("a"), ""), service.ErrRoomPasscodeInvalid)
+		require.ErrorIs(t, passcodes.CheckJoin(ctx, "locked", participant("a"), "0000"), service.ErrRoomPasscodeInvalid)
+		// a correct passcode resets the failures
+		require.NoError(t, passcodes.CheckJoin(ctx, "locked", participant("a"), "1234"))
+		require.ErrorIs(t, passcodes.CheckJoin(ctx, "locked", participant("a"), "0000"), service.ErrRoomPasscodeInvalid)
+		require.ErrorIs(t, passcodes.CheckJoin(ctx, "locked", participant("a"), "0000"), service.ErrRoomPasscodeInvalid)
+		require.NoError(t, passcodes.CheckJoin(ctx, "locked", participant("a"), "1234"))
+	})
+
+	t.Run("hosts and recorders are exempt", func(t *testing.T) {
+		host := participant("host")
+		host.Video.RoomAdmin = true
+		require.NoError(t, passcodes.CheckJoin(ctx, "locked", host, ""))
+
+		recorder := participant("recorder")
+		recorder.Video.Recorder = true
+		require.NoError(t, passcodes.CheckJoin(ctx, "locked", recorder, ""))
+	})
+
+	t.Run("identity is locked out after repeated failures", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			require.ErrorIs(t, passcodes.CheckJoin(ctx, "locked", participant("b"), "0000"), service.ErrRoomPasscodeInvalid)
+		}
+		require.ErrorIs(t, passcodes.CheckJoin(ctx, "locked", participant("b"), "1234"), service.ErrRoomPasscodeLockedOut)
+		// other identities are not affected
+		require.NoError(t, passcodes.CheckJoin(ctx, "locked", participant("c"), "1234"))
+
+		time.Sleep(250 * time.Millisecond)
+		require.NoError(t, passcodes.CheckJoin(ctx, "locked", participant("b"), "1234"))
+		require.ErrorIs(t, passcodes.CheckJoin(ctx, "locked", participant("b"), "0000"), service.ErrRoomPasscodeInvalid)
+	})
+
+	t.Run("lockout holds on other nodes", func(t *testing.T) {
+		// nodes sharing a store count the same attempts
+		other := service.NewRoomPasscodes(conf, store)
+		require.ErrorIs(t, passcodes.CheckJoin(ctx, "locked", participant("d"), "0000"), service.ErrRoomPasscodeInvalid)
+		require.ErrorIs(t, other.CheckJoin(ctx, "locked", participant("d"), "0000"), service.ErrRoomPasscodeInvalid)
+		require.ErrorIs(t, passcodes.CheckJoin(ctx, "locked", participant("d"), "0000"), service.ErrRoomPasscodeInvalid)
+		require.ErrorIs(t, other.CheckJoin(ctx, "locked", participant("d"), "1234"), service.ErrRoomPasscodeLockedOut)
+	})
+}
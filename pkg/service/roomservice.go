@@ -16,15 +16,23 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/avast/retry-go/v4"
 	"github.com/pkg/errors"
 	"github.com/twitchtv/twirp"
+	"go.uber.org/atomic"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/rpc"
 	"github.com/livekit/protocol/utils"
@@ -33,10 +41,10 @@ import (
 
 // A rooms service that supports a single node
 type RoomService struct {
-	roomConf          config.RoomConfig
+	roomConf          *atomic.Pointer[config.RoomConfig]
 	apiConf           config.APIConfig
 	psrpcConf         rpc.PSRPCConfig
-	router            routing.MessageRouter
+	router            routing.Router
 	roomAllocator     RoomAllocator
 	roomStore         ServiceStore
 	agentClient       rtc.AgentClient
@@ -44,13 +52,24 @@ type RoomService struct {
 	topicFormatter    rpc.TopicFormatter
 	roomClient        rpc.TypedRoomClient
 	participantClient rpc.TypedParticipantClient
+
+	participantExtClient ParticipantExtClient
+	roomExtClient        RoomExtClient
+	nodeExtClient        NodeExtClient
+	keyQuotas            *KeyQuotas
+	attachments          *RoomAttachments
+	concurrency          *concurrencyCache
+	auditLog             *AuditLog
+	statsHistories       TrackStatsHistoryStore
+	keyProvider          auth.KeyProvider
+	oidc                 *OIDCVerifier
 }
 
 func NewRoomService(
 	roomConf config.RoomConfig,
 	apiConf config.APIConfig,
 	psrpcConf rpc.PSRPCConfig,
-	router routing.MessageRouter,
+	router routing.Router,
 	roomAllocator RoomAllocator,
 	serviceStore ServiceStore,
 	agentClient rtc.AgentClient,
@@ -58,9 +77,18 @@ func NewRoomService(
 	topicFormatter rpc.TopicFormatter,
 	roomClient rpc.TypedRoomClient,
 	participantClient rpc.TypedParticipantClient,
+	participantExtClient ParticipantExtClient,
+	roomExtClient RoomExtClient,
+	nodeExtClient NodeExtClient,
+	keyQuotas *KeyQuotas,
+	attachments *RoomAttachments,
+	auditLog *AuditLog,
+	statsHistories TrackStatsHistoryStore,
+	keyProvider auth.KeyProvider,
+	oidc *OIDCVerifier,
 ) (svc *RoomService, err error) {
 	svc = &RoomService{
-		roomConf:          roomConf,
+		roomConf:          atomic.NewPointer(&roomConf),
 		apiConf:           apiConf,
 		psrpcConf:         psrpcConf,
 		router:            router,
@@ -71,19 +99,89 @@ func NewRoomService(
 		topicFormatter:    topicFormatter,
 		roomClient:        roomClient,
 		participantClient: participantClient,
+
+		participantExtClient: participantExtClient,
+		roomExtClient:        roomExtClient,
+		nodeExtClient:        nodeExtClient,
+		keyQuotas:            keyQuotas,
+		attachments:          attachments,
+		concurrency:          &concurrencyCache{},
+		auditLog:             auditLog,
+		statsHistories:       statsHistories,
+		keyProvider:          keyProvider,
+		oidc:                 oidc,
 	}
 	return
 }
 
+// SetRoomConfig replaces the room config on reload
+func (s *RoomService) SetRoomConfig(roomConf config.RoomConfig) {
+	s.roomConf.Store(&roomConf)
+}
+
 func (s *RoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
-	AppendLogFields(ctx, "room", req.Name, "request", req)
+	return s.CreateRoomWithOptions(ctx, req, nil)
+}
+
+// CreateRoomWithOptions creates a room like CreateRoom, storing options that are not part of the protocol.
+// Existing options of the room are left unchanged when options is nil.
+func (s *RoomService) CreateRoomWithOptions(ctx context.Context, req *livekit.CreateRoomRequest, options *rtc.RoomOptions) (_ *livekit.Room, err error) {
+	// only the hash of the passcode is logged and stored
+	if options != nil && options.Passcode != "" {
+		options = options.Clone()
+		options.SetPasscode(options.Passcode)
+	}
+	AppendLogFields(ctx, "room", req.Name, "request", req, "options", options)
+	defer func() {
+		s.auditLog.Record(ctx, "CreateRoom", livekit.RoomName(req.Name), err, req, options)
+	}()
 	if err := EnsureCreatePermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
-	} else if req.Egress != nil && s.egressLauncher == nil {
+	}
+
+	if options != nil && options.NotAfter > 0 {
+		if options.NotAfter <= options.NotBefore || options.IsExpired(time.Now()) {
+			return nil, ErrRoomScheduleInvalid
+		}
+	}
+
+	if options != nil && !options.ConfigOverrides.IsValid() {
+		return nil, ErrRoomConfigOverrideInvalid
+	}
+
+	if options != nil && options.SubscribeDefaults.Validate() != nil {
+		return nil, ErrSubscribeDefaultsInvalid
+	}
+
+	if options != nil && !options.Codecs.IsValid() {
+		return nil, ErrCodecRestrictionInvalid
+	}
+
+	if options != nil && !options.LatencyProfile.IsValid() {
+		return nil, ErrLatencyProfileInvalid
+	}
+
+	if options != nil && options.JoinRestriction.Validate() != nil {
+		return nil, ErrJoinRestrictionInvalid
+	}
+
+	if options != nil && options.Template != "" {
+		template, ok := s.roomConf.Load().Templates[options.Template]
+		if !ok {
+			return nil, ErrRoomTemplateNotFound
+		}
+		// track egress of the template is applied by the allocator when the room is created
+		if egress := roomTemplateEgress(&template.Egress); egress != nil && egress.Room != nil && req.Egress == nil {
+			req = proto.Clone(req).(*livekit.CreateRoomRequest)
+			egress.Room.RoomName = req.Name
+			req.Egress = &livekit.RoomEgress{Room: egress.Room}
+		}
+	}
+	if req.Egress != nil && s.egressLauncher == nil {
 		return nil, ErrEgressNotConnected
 	}
 
-	rm, created, err := s.roomAllocator.CreateRoom(ctx, req)
+	rm, created, err := s.roomAllocator.CreateRoom(ctx, req, options)
 	if err != nil {
 		err = errors.Wrap(err, "could not create room")
 		return nil, err
@@ -126,11 +224,26 @@ func (s *RoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomReq
 }
 
 func (s *RoomService) ListRooms(ctx context.Context, req *livekit.ListRoomsRequest) (*livekit.ListRoomsResponse, error) {
-	AppendLogFields(ctx, "room", req.Names)
+	page, err := s.ListRoomsWithOptions(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return page.Response, nil
+}
+
+// ListRoomsWithOptions lists rooms like ListRooms, a page of the rooms that match the filters of options
+// when it is not nil. Rooms are loaded from the store and filtered by the service.
+func (s *RoomService) ListRoomsWithOptions(ctx context.Context, req *livekit.ListRoomsRequest, options *ListRoomsOptions) (*ListRoomsPage, error) {
+	AppendLogFields(ctx, "room", req.Names, "options", options)
 	err := EnsureListPermission(ctx)
 	if err != nil {
 		return nil, twirpAuthError(err)
 	}
+	if options != nil {
+		if err = options.validate(); err != nil {
+			return nil, err
+		}
+	}
 
 	var names []livekit.RoomName
 	if len(req.Names) > 0 {
@@ -142,19 +255,29 @@ func (s *RoomService) ListRooms(ctx context.Context, req *livekit.ListRoomsReque
 		return nil, err
 	}
 
-	res := &livekit.ListRoomsResponse{
-		Rooms: rooms,
+	page := &ListRoomsPage{Response: &livekit.ListRoomsResponse{Rooms: rooms}}
+	if options != nil {
+		matching := rooms[:0]
+		for _, room := range rooms {
+			if options.matches(room) {
+				matching = append(matching, room)
+			}
+		}
+		page.Response.Rooms, page.NextPageToken = paginate(matching, (*livekit.Room).GetName, options.PageToken, options.Limit)
 	}
-	return res, nil
+	return page, nil
 }
 
-func (s *RoomService) DeleteRoom(ctx context.Context, req *livekit.DeleteRoomRequest) (*livekit.DeleteRoomResponse, error) {
+func (s *RoomService) DeleteRoom(ctx context.Context, req *livekit.DeleteRoomRequest) (_ *livekit.DeleteRoomResponse, err error) {
 	AppendLogFields(ctx, "room", req.Room)
+	defer func() {
+		s.auditLog.Record(ctx, "DeleteRoom", livekit.RoomName(req.Room), err, req)
+	}()
 	if err := EnsureCreatePermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
 	}
 
-	_, err := s.roomClient.DeleteRoom(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+	_, err = s.roomClient.DeleteRoom(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
 	if !errors.Is(err, psrpc.ErrNoResponse) {
 		return &livekit.DeleteRoomResponse{}, err
 	}
@@ -164,20 +287,42 @@ func (s *RoomService) DeleteRoom(ctx context.Context, req *livekit.DeleteRoomReq
 }
 
 func (s *RoomService) ListParticipants(ctx context.Context, req *livekit.ListParticipantsRequest) (*livekit.ListParticipantsResponse, error) {
-	AppendLogFields(ctx, "room", req.Room)
+	page, err := s.ListParticipantsWithOptions(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return page.Response, nil
+}
+
+// ListParticipantsWithOptions lists participants like ListParticipants, a page of the participants that match
+// the filters of options when it is not nil
+func (s *RoomService) ListParticipantsWithOptions(ctx context.Context, req *livekit.ListParticipantsRequest, options *ListParticipantsOptions) (*ListParticipantsPage, error) {
+	AppendLogFields(ctx, "room", req.Room, "options", options)
 	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
 		return nil, twirpAuthError(err)
 	}
+	if options != nil {
+		if err := options.validate(); err != nil {
+			return nil, err
+		}
+	}
 
 	participants, err := s.roomStore.ListParticipants(ctx, livekit.RoomName(req.Room))
 	if err != nil {
 		return nil, err
 	}
 
-	res := &livekit.ListParticipantsResponse{
-		Participants: participants,
+	page := &ListParticipantsPage{Response: &livekit.ListParticipantsResponse{Participants: participants}}
+	if options != nil {
+		matching := participants[:0]
+		for _, participant := range participants {
+			if options.matches(participant) {
+				matching = append(matching, participant)
+			}
+		}
+		page.Response.Participants, page.NextPageToken = paginate(matching, (*livekit.ParticipantInfo).GetIdentity, options.PageToken, options.Limit)
 	}
-	return res, nil
+	return page, nil
 }
 
 func (s *RoomService) GetParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
@@ -195,132 +340,1301 @@ func (s *RoomService) GetParticipant(ctx context.Context, req *livekit.RoomParti
 }
 
 func (s *RoomService) RemoveParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.RemoveParticipantResponse, error) {
+	return s.RemoveParticipantWithOptions(ctx, req, nil)
+}
+
+// RemoveParticipantWithOptions removes a participant from the room. When options ban it, the participant, and its
+// IP with BanIP, cannot join the room again until UnbanParticipant is called.
+func (s *RoomService) RemoveParticipantWithOptions(ctx context.Context, req *livekit.RoomParticipantIdentity, options *RemoveParticipantOptions) (_ *livekit.RemoveParticipantResponse, err error) {
 	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	defer func() {
+		s.auditLog.Record(ctx, "RemoveParticipant", livekit.RoomName(req.Room), err, req, options)
+	}()
 
 	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
 		return nil, twirpAuthError(err)
 	}
+	if options.GetBanIP() && !options.Ban {
+		return nil, twirp.InvalidArgumentError("ban_ip", "requires ban")
+	}
 
 	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
 		return nil, twirp.NotFoundError("participant not found")
 	}
 
-	return s.participantClient.RemoveParticipant(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+	topic := s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity))
+	if options.GetBan() {
+		if _, err = s.participantExtClient.BanParticipant(ctx, topic, &BanParticipantRequest{
+			Room:     req.Room,
+			Identity: req.Identity,
+			BanIP:    options.BanIP,
+		}); err != nil {
+			return nil, err
+		}
+		return &livekit.RemoveParticipantResponse{}, nil
+	}
+	return s.participantClient.RemoveParticipant(ctx, topic, req)
 }
 
-func (s *RoomService) MutePublishedTrack(ctx context.Context, req *livekit.MuteRoomTrackRequest) (*livekit.MuteRoomTrackResponse, error) {
-	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "trackID", req.TrackSid, "muted", req.Muted)
+// UnbanParticipant lifts the ban of a participant removed from the room with RemoveParticipant
+func (s *RoomService) UnbanParticipant(ctx context.Context, req *UnbanParticipantRequest) (_ *UnbanParticipantResponse, err error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	defer func() {
+		s.auditLog.Record(ctx, "UnbanParticipant", livekit.RoomName(req.Room), err, req)
+	}()
+
 	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
 		return nil, twirpAuthError(err)
 	}
+	if req.Identity == "" {
+		return nil, ErrIdentityEmpty
+	}
 
-	return s.participantClient.MutePublishedTrack(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+	if err = s.roomStore.DeleteParticipantBan(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err != nil {
+		return nil, err
+	}
+	return &UnbanParticipantResponse{}, nil
 }
 
-func (s *RoomService) UpdateParticipant(ctx context.Context, req *livekit.UpdateParticipantRequest) (*livekit.ParticipantInfo, error) {
+// AdmitParticipant lets a participant waiting in the room's waiting room join the session
+func (s *RoomService) AdmitParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (_ *livekit.ParticipantInfo, err error) {
 	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
-	maxMetadataSize := int(s.roomConf.MaxMetadataSize)
-	if maxMetadataSize > 0 && len(req.Metadata) > maxMetadataSize {
-		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
+	defer func() {
+		s.auditLog.Record(ctx, "AdmitParticipant", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	return s.participantExtClient.AdmitParticipant(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+// MoveParticipant transfers a participant to another room without the client reconnecting. The token needs to be
+// an admin of the room, and the request to carry an admin token of the destination room. The destination room needs
+// to exist, and can only be hosted by the node the participant is connected to. Participants the destination room
+// would not let join are not moved.
+func (s *RoomService) MoveParticipant(ctx context.Context, req *MoveParticipantRequest) (_ *livekit.ParticipantInfo, err error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "destinationRoom", req.DestinationRoom)
+	defer func() {
+		s.auditLog.Record(ctx, "MoveParticipant", livekit.RoomName(req.Room), err, req)
+	}()
+
+	// the destination token is not forwarded nor recorded
+	destinationToken := req.DestinationToken
+	req.DestinationToken = ""
+	if err := s.ensureMovePermission(ctx, livekit.RoomName(req.Room), livekit.RoomName(req.DestinationRoom), destinationToken); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if req.DestinationRoom == "" || req.DestinationRoom == req.Room {
+		return nil, ErrMoveDestinationInvalid
+	}
+	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
+		return nil, twirp.NotFoundError("participant not found")
+	}
+
+	return s.participantExtClient.MoveParticipant(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+// ensureMovePermission allows moving participants out of room into destination. An admin token grants a single
+// room, so the request token needs to be an admin of room and destinationToken an admin of destination.
+func (s *RoomService) ensureMovePermission(ctx context.Context, room livekit.RoomName, destination livekit.RoomName, destinationToken string) error {
+	if err := EnsureAdminPermission(ctx, room); err != nil {
+		return err
+	}
+	if destinationToken == "" {
+		return ErrPermissionDenied
+	}
+	grants, _, err := verifyToken(ctx, s.keyProvider, s.oidc, destinationToken)
+	if err != nil {
+		return ErrPermissionDenied
+	}
+	return EnsureAdminPermission(WithGrants(ctx, grants), destination)
+}
+
+// MergeRooms moves every participant of a room into another room, e.g. to bring breakout rooms back into the main
+// room. Moves follow MoveParticipant, so both rooms need to be hosted by the same node. The merged room is deleted
+// when asked to and every participant could be moved.
+func (s *RoomService) MergeRooms(ctx context.Context, req *MergeRoomsRequest) (_ *MergeRoomsResponse, err error) {
+	AppendLogFields(ctx, "room", req.Room, "destinationRoom", req.DestinationRoom, "deleteRoom", req.DeleteRoom)
+	defer func() {
+		s.auditLog.Record(ctx, "MergeRooms", livekit.RoomName(req.Room), err, req)
+	}()
+
+	// the destination token is not forwarded nor recorded
+	destinationToken := req.DestinationToken
+	req.DestinationToken = ""
+	if err := s.ensureMovePermission(ctx, livekit.RoomName(req.Room), livekit.RoomName(req.DestinationRoom), destinationToken); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if req.DestinationRoom == "" || req.DestinationRoom == req.Room {
+		return nil, ErrMoveDestinationInvalid
+	}
+	for _, roomName := range []string{req.Room, req.DestinationRoom} {
+		if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(roomName), false); err == ErrRoomNotFound {
+			return nil, twirp.NotFoundError("room not found")
+		}
+	}
+
+	res, err := s.roomExtClient.MergeRooms(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+	if errors.Is(err, psrpc.ErrNoResponse) {
+		// no one has joined the room, there is no one to move
+		res, err = &MergeRoomsResponse{Moved: []string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DeleteRoom && len(res.Failed) == 0 {
+		if _, err = s.DeleteRoom(ctx, &livekit.DeleteRoomRequest{Room: req.Room}); err != nil {
+			return nil, err
+		}
+		res.Deleted = true
 	}
+	return res, nil
+}
+
+// RedirectParticipant has a participant resume its session through another signal URL, e.g. when it moved to
+// another region. It is sent a resume token for its session, which stays on the node it is connected to.
+func (s *RoomService) RedirectParticipant(ctx context.Context, req *RedirectParticipantRequest) (_ *livekit.ParticipantInfo, err error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "url", req.Url, "region", req.Region)
+	defer func() {
+		s.auditLog.Record(ctx, "RedirectParticipant", livekit.RoomName(req.Room), err, req)
+	}()
 
 	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
 		return nil, twirpAuthError(err)
 	}
+	if req.Url == "" {
+		return nil, ErrRedirectTargetMissing
+	}
 
-	return s.participantClient.UpdateParticipant(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+	return s.participantExtClient.RedirectParticipant(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
 }
 
-func (s *RoomService) UpdateSubscriptions(ctx context.Context, req *livekit.UpdateSubscriptionsRequest) (*livekit.UpdateSubscriptionsResponse, error) {
-	trackSIDs := append(make([]string, 0), req.TrackSids...)
-	for _, pt := range req.ParticipantTracks {
-		trackSIDs = append(trackSIDs, pt.TrackSids...)
+// RedirectRoom moves a room to another node, e.g. to rebalance the cluster, and redirects its participants there
+// with resume tokens for that node, so they keep their sessions.
+func (s *RoomService) RedirectRoom(ctx context.Context, req *RedirectRoomRequest) (_ *RedirectRoomResponse, err error) {
+	AppendLogFields(ctx, "room", req.Room, "nodeID", req.NodeID, "url", req.Url)
+	defer func() {
+		s.auditLog.Record(ctx, "RedirectRoom", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureNodeAdminPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
 	}
-	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "trackID", trackSIDs)
+
+	res, err := s.roomExtClient.RedirectRoom(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+	if errors.Is(err, psrpc.ErrNoResponse) {
+		// no node hosts the room
+		return nil, twirp.NotFoundError("room not found")
+	}
+	return res, err
+}
+
+// GetSubscriberAllocation returns the bandwidth estimate of a subscriber, the bitrate allocated to each of its
+// video tracks and the latest allocation decisions with what led to them
+func (s *RoomService) GetSubscriberAllocation(ctx context.Context, req *GetSubscriberAllocationRequest) (*streamallocator.AllocationInfo, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
 
 	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
 		return nil, twirpAuthError(err)
 	}
 
-	return s.participantClient.UpdateSubscriptions(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
+		return nil, twirp.NotFoundError("participant not found")
+	}
+
+	return s.participantExtClient.GetSubscriberAllocation(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
 }
 
-func (s *RoomService) SendData(ctx context.Context, req *livekit.SendDataRequest) (*livekit.SendDataResponse, error) {
-	roomName := livekit.RoomName(req.Room)
-	AppendLogFields(ctx, "room", roomName, "size", len(req.Data))
-	if err := EnsureAdminPermission(ctx, roomName); err != nil {
+// StartPacketCapture captures the RTP and RTCP packets of a track, or of all the tracks of a participant, on the
+// node of the participant. The capture stops on its own after its duration or max size.
+func (s *RoomService) StartPacketCapture(ctx context.Context, req *StartPacketCaptureRequest) (_ *PacketCaptureInfo, err error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "trackID", req.TrackID)
+	defer func() {
+		s.auditLog.Record(ctx, "StartPacketCapture", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
 		return nil, twirpAuthError(err)
 	}
 
-	return s.roomClient.SendData(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
+		return nil, twirp.NotFoundError("participant not found")
+	}
+
+	return s.participantExtClient.StartPacketCapture(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
 }
 
-func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.UpdateRoomMetadataRequest) (*livekit.Room, error) {
-	AppendLogFields(ctx, "room", req.Room, "size", len(req.Metadata))
-	maxMetadataSize := int(s.roomConf.MaxMetadataSize)
-	if maxMetadataSize > 0 && len(req.Metadata) > maxMetadataSize {
-		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
+// StopPacketCapture stops a packet capture and returns where it is stored. Captures uploaded to object storage
+// are complete once the status is complete, calling it again returns the status of the upload.
+func (s *RoomService) StopPacketCapture(ctx context.Context, req *StopPacketCaptureRequest) (_ *PacketCaptureInfo, err error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "captureID", req.CaptureID)
+	defer func() {
+		s.auditLog.Record(ctx, "StopPacketCapture", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
 	}
 
+	return s.participantExtClient.StopPacketCapture(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+// GetCongestionTrace returns the estimates, target layers and actions of the stream allocator of a subscriber
+// over time, in the trace event format that trace viewers such as Perfetto load
+func (s *RoomService) GetCongestionTrace(ctx context.Context, req *GetCongestionTraceRequest) (*streamallocator.CongestionTrace, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+
 	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
 		return nil, twirpAuthError(err)
 	}
 
-	room, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false)
-	if err != nil {
-		return nil, err
+	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
+		return nil, twirp.NotFoundError("participant not found")
 	}
 
-	// no one has joined the room, would not have been created on an RTC node.
-	// in this case, we'd want to run create again
-	room, created, err := s.roomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{
-		Name:     req.Room,
-		Metadata: req.Metadata,
-	})
-	if err != nil {
+	return s.participantExtClient.GetCongestionTrace(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+// GetTransportStats returns a snapshot of the stats of the publisher or subscriber transport of a participant,
+// in the dictionaries of the W3C getStats() API
+func (s *RoomService) GetTransportStats(ctx context.Context, req *GetTransportStatsRequest) (*types.TransportStats, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "transport", req.Transport)
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, err := req.signalTarget(); err != nil {
 		return nil, err
 	}
+	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
+		return nil, twirp.NotFoundError("participant not found")
+	}
 
-	_, err = s.roomClient.UpdateRoomMetadata(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
-	if err != nil {
+	return s.participantExtClient.GetTransportStats(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+// GetICEDiagnostics returns the candidates exchanged by the transports of a participant, the candidate pair each
+// of them uses with its round trip time, and the reason a transport failed to connect
+func (s *RoomService) GetICEDiagnostics(ctx context.Context, req *GetICEDiagnosticsRequest) (*ICEDiagnosticsResponse, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
+		return nil, twirp.NotFoundError("participant not found")
+	}
+
+	return s.participantExtClient.GetICEDiagnostics(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+// GetConnectionQualityDetails returns the connection quality of a participant with the loss, jitter and round trip
+// time of its uplink and downlink, and whether its uplink, downlink or bufferbloat is the probable constraint
+func (s *RoomService) GetConnectionQualityDetails(ctx context.Context, req *GetConnectionQualityDetailsRequest) (*types.ConnectionQualityDetails, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
+		return nil, twirp.NotFoundError("participant not found")
+	}
+
+	return s.participantExtClient.GetConnectionQualityDetails(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+// GetTrackStatsHistory returns the RTP stats of the tracks of a participant sampled over the last minutes, for
+// post-incident analysis without an external metrics pipeline
+func (s *RoomService) GetTrackStatsHistory(ctx context.Context, req *GetTrackStatsHistoryRequest) (*TrackStatsHistoryResponse, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "trackID", req.TrackID, "minutes", req.Minutes)
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if req.Minutes < 0 {
+		return nil, twirp.InvalidArgumentError("minutes", "cannot be negative")
+	}
+	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
+		return s.retainedTrackStatsHistory(ctx, req)
+	}
+
+	return s.participantExtClient.GetTrackStatsHistory(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+// retainedTrackStatsHistory returns the history kept for a participant that left the room
+func (s *RoomService) retainedTrackStatsHistory(ctx context.Context, req *GetTrackStatsHistoryRequest) (*TrackStatsHistoryResponse, error) {
+	if s.statsHistories == nil {
+		return nil, twirp.NotFoundError("participant not found")
+	}
+	tracks, err := s.statsHistories.LoadTrackStatsHistory(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity))
+	if err == ErrTrackStatsHistoryNotFound {
+		return nil, twirp.NotFoundError("participant not found")
+	} else if err != nil {
 		return nil, err
 	}
 
-	err = s.confirmExecution(ctx, func() error {
-		room, _, err = s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false)
-		if err != nil {
-			return err
-		}
-		if room.Metadata != req.Metadata {
-			return ErrOperationFailed
+	tracks = retainedTrackStatsWithin(tracks, livekit.TrackID(req.TrackID), time.Duration(req.Minutes)*time.Minute)
+	if req.TrackID != "" && len(tracks) == 0 {
+		return nil, ErrTrackNotFound
+	}
+	return &TrackStatsHistoryResponse{
+		Room:     req.Room,
+		Identity: req.Identity,
+		Tracks:   tracks,
+	}, nil
+}
+
+// retainedTrackStatsWithin returns the samples of a track, or of all the tracks when trackID is empty, taken in
+// the window before the participant left, all of them when window is 0
+func retainedTrackStatsWithin(tracks []*types.TrackStatsSeries, trackID livekit.TrackID, window time.Duration) []*types.TrackStatsSeries {
+	var leftAt time.Time
+	for _, track := range tracks {
+		if n := len(track.Samples); n != 0 && track.Samples[n-1].At.After(leftAt) {
+			leftAt = track.Samples[n-1].At
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
-	if created {
-		go func() {
-			s.agentClient.JobRequest(ctx, &livekit.Job{
-				Id:   utils.NewGuid("JR_"),
-				Type: livekit.JobType_JT_ROOM,
-				Room: room,
+	filtered := make([]*types.TrackStatsSeries, 0, len(tracks))
+	for _, track := range tracks {
+		if trackID != "" && track.TrackID != trackID {
+			continue
+		}
+		samples := track.Samples
+		if window > 0 {
+			samples = make([]*types.TrackStatsSample, 0, len(track.Samples))
+			for _, sample := range track.Samples {
+				if leftAt.Sub(sample.At) < window {
+					samples = append(samples, sample)
+				}
+			}
+		}
+		if len(samples) != 0 {
+			filtered = append(filtered, &types.TrackStatsSeries{
+				TrackID:   track.TrackID,
+				Direction: track.Direction,
+				Samples:   samples,
 			})
-		}()
+		}
 	}
+	return filtered
+}
 
-	return room, nil
+// GetAVSyncStats returns the audio/video sync skew of each publisher a participant is subscribed to, to tell
+// lip sync complaints caused by the SFU from those caused by the publisher or the client
+func (s *RoomService) GetAVSyncStats(ctx context.Context, req *GetAVSyncStatsRequest) (*types.AVSyncDetails, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
+		return nil, twirp.NotFoundError("participant not found")
+	}
+
+	return s.participantExtClient.GetAVSyncStats(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
 }
 
-func (s *RoomService) confirmExecution(ctx context.Context, f func() error) error {
-	ctx, cancel := context.WithTimeout(ctx, s.apiConf.ExecutionTimeout)
-	defer cancel()
-	return retry.Do(
-		f,
-		retry.Context(ctx),
-		retry.Delay(s.apiConf.CheckInterval),
-		retry.MaxDelay(s.apiConf.MaxCheckInterval),
-		retry.DelayType(retry.BackOffDelay),
-	)
+// MuteAllParticipants mutes the microphone of every participant in the room, hosts and the participants
+// in except can be left unmuted
+func (s *RoomService) MuteAllParticipants(ctx context.Context, req *MuteAllParticipantsRequest) (_ *livekit.ListParticipantsResponse, err error) {
+	AppendLogFields(ctx, "room", req.Room, "excludeHosts", req.ExcludeHosts, "except", req.Except)
+	defer func() {
+		s.auditLog.Record(ctx, "MuteAllParticipants", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	return s.roomExtClient.MuteAllParticipants(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// MuteTracksBySource mutes or unmutes every track of a source in the room, e.g. all cameras during a presentation.
+// Unmuting requires remote unmute to be enabled
+func (s *RoomService) MuteTracksBySource(ctx context.Context, req *MuteTracksBySourceRequest) (_ *livekit.ListParticipantsResponse, err error) {
+	AppendLogFields(ctx, "room", req.Room, "source", req.Source, "muted", req.Muted, "excludeHosts", req.ExcludeHosts, "except", req.Except)
+	defer func() {
+		s.auditLog.Record(ctx, "MuteTracksBySource", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if _, err := req.trackSource(); err != nil {
+		return nil, twirp.InvalidArgumentError("source", "must be camera, microphone, screen_share or screen_share_audio")
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	return s.roomExtClient.MuteTracksBySource(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// LockRoom prevents new participants from joining the room, other than hosts and service participants such
+// as recorders and agents. Participants already in the room are not affected.
+func (s *RoomService) LockRoom(ctx context.Context, req *LockRoomRequest) (_ *livekit.Room, err error) {
+	AppendLogFields(ctx, "room", req.Room, "locked", req.Locked)
+	defer func() {
+		s.auditLog.Record(ctx, "LockRoom", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	res, err := s.roomExtClient.LockRoom(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+	if !errors.Is(err, psrpc.ErrNoResponse) {
+		return res, err
+	}
+
+	// no one has joined the room yet, store the lock with its options to be applied when it is started
+	options, err := s.roomStore.LoadRoomOptions(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &rtc.RoomOptions{}
+	}
+	options.Locked = req.Locked
+	room, _, err := s.roomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: req.Room}, options)
+	return room, err
+}
+
+// SetPushToTalk enables or disables push to talk in the room. Only the participant holding the floor has its
+// audio forwarded, others are kept muted by the server.
+func (s *RoomService) SetPushToTalk(ctx context.Context, req *SetPushToTalkRequest) (_ *rtc.FloorState, err error) {
+	AppendLogFields(ctx, "room", req.Room, "pushToTalk", req.PushToTalk)
+	defer func() {
+		s.auditLog.Record(ctx, "SetPushToTalk", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if !req.PushToTalk.IsValid() {
+		return nil, ErrPushToTalkInvalid
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	res, err := s.roomExtClient.SetPushToTalk(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+	if !errors.Is(err, psrpc.ErrNoResponse) {
+		return res, err
+	}
+
+	// no one has joined the room yet, store the setting with its options to be applied when it is started
+	options, err := s.roomStore.LoadRoomOptions(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &rtc.RoomOptions{}
+	}
+	options.PushToTalk = nil
+	if req.PushToTalk != nil && req.PushToTalk.Enabled {
+		options.PushToTalk = req.PushToTalk
+	}
+	if _, _, err = s.roomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: req.Room}, options); err != nil {
+		return nil, err
+	}
+	state := &rtc.FloorState{PushToTalk: options.IsPushToTalk()}
+	if state.PushToTalk {
+		state.Policy = options.PushToTalk.GetPolicy()
+	}
+	return state, nil
+}
+
+// GrantFloor lets a participant speak in a push to talk room, replacing the current floor holder.
+// Without an identity the floor goes to the first participant in the queue.
+func (s *RoomService) GrantFloor(ctx context.Context, req *GrantFloorRequest) (_ *rtc.FloorState, err error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "duration", req.Duration)
+	defer func() {
+		s.auditLog.Record(ctx, "GrantFloor", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	return s.roomExtClient.GrantFloor(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// ReleaseFloor revokes the floor of a push to talk room, held by the given participant or by anyone,
+// or removes a participant from the queue
+func (s *RoomService) ReleaseFloor(ctx context.Context, req *ReleaseFloorRequest) (_ *rtc.FloorState, err error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	defer func() {
+		s.auditLog.Record(ctx, "ReleaseFloor", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	return s.roomExtClient.ReleaseFloor(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// GetRoomDebugInfo returns the debug info tree of a room from the node hosting it, for support without access to
+// the node. It has the addresses of participants, the token needs the room list grant along with room admin.
+func (s *RoomService) GetRoomDebugInfo(ctx context.Context, req *GetRoomDebugInfoRequest) (*RoomDebugInfo, error) {
+	AppendLogFields(ctx, "room", req.Room)
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if err := EnsureListPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	return s.roomExtClient.GetRoomDebugInfo(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// GetTrackSubscriberCounts returns the number of subscribers of the tracks published in a room, from the node hosting it
+func (s *RoomService) GetTrackSubscriberCounts(ctx context.Context, req *GetTrackSubscriberCountsRequest) (*TrackSubscriberCounts, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	return s.roomExtClient.GetTrackSubscriberCounts(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// UpdateParticipantsPermission sets the permission of the given participants, or of all regular participants
+// of the room when no identities are given
+func (s *RoomService) UpdateParticipantsPermission(ctx context.Context, req *UpdateParticipantsPermissionRequest) (_ *livekit.ListParticipantsResponse, err error) {
+	AppendLogFields(ctx, "room", req.Room, "identities", req.Identities, "excludeHosts", req.ExcludeHosts)
+	defer func() {
+		s.auditLog.Record(ctx, "UpdateParticipantsPermission", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if req.Permission == nil {
+		return nil, ErrPermissionMissing
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	return s.roomExtClient.UpdateParticipantsPermission(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// GetAPIKeyUsage returns the usage and quota of the API key the request is signed with
+func (s *RoomService) GetAPIKeyUsage(ctx context.Context) (*APIKeyUsage, error) {
+	if err := EnsureListPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	return s.keyQuotas.Usage(ctx, GetAPIKey(ctx))
+}
+
+// GetConcurrency returns the rooms, participants and tracks currently served by the cluster, summed over the nodes.
+// Snapshots are shared for the concurrency cache ttl, so that dashboards polling it do not each reach every node.
+func (s *RoomService) GetConcurrency(ctx context.Context) (*ConcurrencySnapshot, error) {
+	if err := EnsureListPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	return s.concurrency.get(ctx, s.apiConf.ConcurrencyCacheTTL, func(ctx context.Context) (*ConcurrencySnapshot, error) {
+		nodes, err := s.router.ListNodes()
+		if err != nil {
+			return nil, err
+		}
+		return collectConcurrency(ctx, s.nodeExtClient, nodes, s.apiConf.ExecutionTimeout)
+	})
+}
+
+// ListAuditRecords returns the administrative calls made about a room, oldest first. Records have the API keys and
+// arguments of the calls, the token needs the room list grant along with room admin.
+func (s *RoomService) ListAuditRecords(ctx context.Context, req *ListAuditRecordsRequest) (*ListAuditRecordsResponse, error) {
+	AppendLogFields(ctx, "room", req.Room)
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if err := EnsureListPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	records, err := s.auditLog.List(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	return &ListAuditRecordsResponse{Records: records}, nil
+}
+
+// DrainNode has a node stop taking participants and move its rooms to other nodes. It returns right away,
+// GetDrainStatus follows the migration of the participants.
+func (s *RoomService) DrainNode(ctx context.Context, req *DrainNodeRequest) (_ *DrainStatus, err error) {
+	AppendLogFields(ctx, "nodeID", req.NodeID, "deadline", req.Deadline)
+	defer func() {
+		s.auditLog.Record(ctx, "DrainNode", "", err, req)
+	}()
+	if err := EnsureNodeAdminPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if req.Deadline < 0 {
+		return nil, twirp.InvalidArgumentError("deadline", "cannot be negative")
+	}
+	if err := s.ensureServerNode(livekit.NodeID(req.NodeID)); err != nil {
+		return nil, err
+	}
+
+	return s.nodeExtClient.DrainNode(ctx, livekit.NodeID(req.NodeID), req)
+}
+
+func (s *RoomService) GetDrainStatus(ctx context.Context, req *GetDrainStatusRequest) (*DrainStatus, error) {
+	AppendLogFields(ctx, "nodeID", req.NodeID)
+	if err := EnsureNodeAdminPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if err := s.ensureServerNode(livekit.NodeID(req.NodeID)); err != nil {
+		return nil, err
+	}
+
+	return s.nodeExtClient.GetDrainStatus(ctx, livekit.NodeID(req.NodeID), req)
+}
+
+func (s *RoomService) ensureServerNode(nodeID livekit.NodeID) error {
+	nodes, err := s.router.ListNodes()
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if livekit.NodeID(node.Id) == nodeID && node.Type == livekit.NodeType_SERVER {
+			return nil
+		}
+	}
+	return twirp.NotFoundError("node not found")
+}
+
+// CreateAttachment returns the URL to upload a file shared with the room to. Participants of the room that
+// can publish data and room admins can share files, the file is announced by PublishAttachment once uploaded.
+func (s *RoomService) CreateAttachment(ctx context.Context, req *CreateAttachmentRequest) (_ *AttachmentInfo, err error) {
+	AppendLogFields(ctx, "room", req.Room, "name", req.Name, "contentType", req.ContentType, "size", req.Size)
+	defer func() {
+		s.auditLog.Record(ctx, "CreateAttachment", livekit.RoomName(req.Room), err, req)
+	}()
+
+	identity, _, err := ensureAttachmentPermission(ctx, livekit.RoomName(req.Room), true)
+	if err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	return s.attachments.Create(ctx, req, identity)
+}
+
+// PublishAttachment announces an uploaded attachment to the participants of the room, on AttachmentTopic
+func (s *RoomService) PublishAttachment(ctx context.Context, req *AttachmentRequest) (_ *AttachmentInfo, err error) {
+	AppendLogFields(ctx, "room", req.Room, "attachmentID", req.ID)
+	defer func() {
+		s.auditLog.Record(ctx, "PublishAttachment", livekit.RoomName(req.Room), err, req)
+	}()
+
+	attachment, err := s.loadOwnAttachment(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// publishing again refreshes the download URL without announcing the attachment again
+	announce := !attachment.Published
+	info, err := s.attachments.Publish(ctx, attachment)
+	if err != nil {
+		return nil, err
+	}
+	if announce {
+		if err = s.announceAttachment(ctx, AttachmentPublished, info); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// ListAttachments returns the published attachments of the room, with download URLs
+func (s *RoomService) ListAttachments(ctx context.Context, req *ListAttachmentsRequest) (*ListAttachmentsResponse, error) {
+	AppendLogFields(ctx, "room", req.Room)
+
+	if _, _, err := ensureAttachmentPermission(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	attachments, err := s.attachments.List(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	return &ListAttachmentsResponse{Attachments: attachments}, nil
+}
+
+// DeleteAttachment removes an attachment and its file, the participant that shared it or room admins can delete it
+func (s *RoomService) DeleteAttachment(ctx context.Context, req *AttachmentRequest) (_ *AttachmentInfo, err error) {
+	AppendLogFields(ctx, "room", req.Room, "attachmentID", req.ID)
+	defer func() {
+		s.auditLog.Record(ctx, "DeleteAttachment", livekit.RoomName(req.Room), err, req)
+	}()
+
+	attachment, err := s.loadOwnAttachment(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.attachments.Delete(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	info := &AttachmentInfo{Attachment: attachment}
+	if attachment.Published {
+		if err = s.announceAttachment(ctx, AttachmentDeleted, info); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// loadOwnAttachment loads the attachment when it was shared by the participant making the request, or
+// the request is made by a room admin
+func (s *RoomService) loadOwnAttachment(ctx context.Context, req *AttachmentRequest) (*Attachment, error) {
+	identity, admin, err := ensureAttachmentPermission(ctx, livekit.RoomName(req.Room), true)
+	if err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	attachment, err := s.attachments.Load(ctx, livekit.RoomName(req.Room), req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !admin && attachment.UploadedBy != string(identity) {
+		return nil, twirpAuthError(ErrPermissionDenied)
+	}
+	return attachment, nil
+}
+
+func (s *RoomService) announceAttachment(ctx context.Context, kind string, info *AttachmentInfo) error {
+	data, err := attachmentAnnouncement(kind, info)
+	if err != nil {
+		return err
+	}
+
+	topic := AttachmentTopic
+	_, err = s.roomClient.SendData(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(info.Room)), &livekit.SendDataRequest{
+		Room:  info.Room,
+		Data:  data,
+		Kind:  livekit.DataPacket_RELIABLE,
+		Topic: &topic,
+	})
+	// no one has joined the room, participants list attachments when they join
+	if errors.Is(err, psrpc.ErrNoResponse) || errors.Is(err, ErrRoomNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *RoomService) MutePublishedTrack(ctx context.Context, req *livekit.MuteRoomTrackRequest) (_ *livekit.MuteRoomTrackResponse, err error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "trackID", req.TrackSid, "muted", req.Muted)
+	defer func() {
+		s.auditLog.Record(ctx, "MutePublishedTrack", livekit.RoomName(req.Room), err, req)
+	}()
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	return s.participantClient.MutePublishedTrack(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+func (s *RoomService) UpdateParticipant(ctx context.Context, req *livekit.UpdateParticipantRequest) (_ *livekit.ParticipantInfo, err error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	defer func() {
+		s.auditLog.Record(ctx, "UpdateParticipant", livekit.RoomName(req.Room), err, req)
+	}()
+	maxMetadataSize := int(s.roomConf.Load().MaxMetadataSize)
+	if maxMetadataSize > 0 && len(req.Metadata) > maxMetadataSize {
+		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
+	}
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	return s.participantClient.UpdateParticipant(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+func (s *RoomService) UpdateSubscriptions(ctx context.Context, req *livekit.UpdateSubscriptionsRequest) (_ *livekit.UpdateSubscriptionsResponse, err error) {
+	trackSIDs := append(make([]string, 0), req.TrackSids...)
+	for _, pt := range req.ParticipantTracks {
+		trackSIDs = append(trackSIDs, pt.TrackSids...)
+	}
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "trackID", trackSIDs)
+	defer func() {
+		s.auditLog.Record(ctx, "UpdateSubscriptions", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	return s.participantClient.UpdateSubscriptions(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+func (s *RoomService) SendData(ctx context.Context, req *livekit.SendDataRequest) (_ *livekit.SendDataResponse, err error) {
+	roomName := livekit.RoomName(req.Room)
+	AppendLogFields(ctx, "room", roomName, "size", len(req.Data))
+	defer func() {
+		// the payload is not recorded, only its size
+		args := proto.Clone(req).(*livekit.SendDataRequest)
+		args.Data = nil
+		s.auditLog.Record(ctx, "SendData", roomName, err, args, map[string]int{"size": len(req.Data)})
+	}()
+	if err := EnsureAdminPermission(ctx, roomName); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	return s.roomClient.SendData(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.UpdateRoomMetadataRequest) (_ *livekit.Room, err error) {
+	AppendLogFields(ctx, "room", req.Room, "size", len(req.Metadata))
+	defer func() {
+		s.auditLog.Record(ctx, "UpdateRoomMetadata", livekit.RoomName(req.Room), err, req)
+	}()
+	maxMetadataSize := int(s.roomConf.Load().MaxMetadataSize)
+	if maxMetadataSize > 0 && len(req.Metadata) > maxMetadataSize {
+		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
+	}
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	room, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false)
+	if err != nil {
+		return nil, err
+	}
+
+	// no one has joined the room, would not have been created on an RTC node.
+	// in this case, we'd want to run create again
+	room, created, err := s.roomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{
+		Name:     req.Room,
+		Metadata: req.Metadata,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.roomClient.UpdateRoomMetadata(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.confirmExecution(ctx, func() error {
+		room, _, err = s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false)
+		if err != nil {
+			return err
+		}
+		if room.Metadata != req.Metadata {
+			return ErrOperationFailed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		go func() {
+			s.agentClient.JobRequest(ctx, &livekit.Job{
+				Id:   utils.NewGuid("JR_"),
+				Type: livekit.JobType_JT_ROOM,
+				Room: room,
+			})
+		}()
+	}
+
+	return room, nil
+}
+
+// PatchRoomMetadata applies a JSON merge patch to the metadata of the room. With a version, the patch is applied only
+// when the metadata has not changed since it was read, so that services updating parts of the metadata do not
+// overwrite each other.
+func (s *RoomService) PatchRoomMetadata(ctx context.Context, req *PatchRoomMetadataRequest) (_ *MetadataPatchResponse, err error) {
+	AppendLogFields(ctx, "room", req.Room, "size", len(req.Patch), "version", req.Version)
+	defer func() {
+		s.auditLog.Record(ctx, "PatchRoomMetadata", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if len(req.Patch) == 0 {
+		return nil, twirp.RequiredArgumentError("patch")
+	}
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err == ErrRoomNotFound {
+		return nil, twirp.NotFoundError("room not found")
+	}
+
+	res, err := s.roomExtClient.PatchRoomMetadata(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+	if !errors.Is(err, psrpc.ErrNoResponse) {
+		return res, err
+	}
+
+	// no one has joined the room yet, patch the stored room
+	room, err := s.roomAllocator.PatchRoomMetadata(ctx, livekit.RoomName(req.Room), req.Patch, req.Version)
+	if err != nil {
+		return nil, metadataPatchError(err)
+	}
+	return &MetadataPatchResponse{
+		Metadata: room.Metadata,
+		Version:  rtc.MetadataVersion(room.Metadata),
+	}, nil
+}
+
+// PatchParticipantMetadata applies a JSON merge patch to the metadata of a participant, with the same version
+// check as PatchRoomMetadata
+func (s *RoomService) PatchParticipantMetadata(ctx context.Context, req *PatchParticipantMetadataRequest) (_ *MetadataPatchResponse, err error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "size", len(req.Patch), "version", req.Version)
+	defer func() {
+		s.auditLog.Record(ctx, "PatchParticipantMetadata", livekit.RoomName(req.Room), err, req)
+	}()
+
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if req.Identity == "" {
+		return nil, ErrIdentityEmpty
+	}
+	if len(req.Patch) == 0 {
+		return nil, twirp.RequiredArgumentError("patch")
+	}
+
+	return s.participantExtClient.PatchParticipantMetadata(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
+func (s *RoomService) confirmExecution(ctx context.Context, f func() error) error {
+	ctx, cancel := context.WithTimeout(ctx, s.apiConf.ExecutionTimeout)
+	defer cancel()
+	return retry.Do(
+		f,
+		retry.Context(ctx),
+		retry.Delay(s.apiConf.CheckInterval),
+		retry.MaxDelay(s.apiConf.MaxCheckInterval),
+		retry.DelayType(retry.BackOffDelay),
+	)
+}
+
+// TwirpJSONHandlers returns handlers for the methods and request fields the RoomService supports on top
+// of the protocol definitions. Requests they cannot serve are passed to roomServer.
+func (s *RoomService) TwirpJSONHandlers(hooks *twirp.ServerHooks, roomServer http.Handler) []*TwirpJSONHandler {
+	return []*TwirpJSONHandler{
+		NewTwirpJSONHandler("livekit.RoomService", "CreateRoom", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &livekit.CreateRoomRequest{}
+			options := &rtc.RoomOptions{}
+			if err := UnmarshalTwirpJSON(body, req, options); err != nil {
+				return nil, err
+			}
+			if options.IsZero() {
+				options = nil
+			}
+			return s.CreateRoomWithOptions(ctx, req, options)
+		}, roomServer),
+		NewTwirpJSONHandler("livekit.RoomService", "ListRooms", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &livekit.ListRoomsRequest{}
+			options := &ListRoomsOptions{}
+			if err := UnmarshalTwirpJSON(body, req, options); err != nil {
+				return nil, err
+			}
+			if options.IsZero() {
+				options = nil
+			}
+			return s.ListRoomsWithOptions(ctx, req, options)
+		}, roomServer),
+		NewTwirpJSONHandler("livekit.RoomService", "ListParticipants", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &livekit.ListParticipantsRequest{}
+			options := &ListParticipantsOptions{}
+			if err := UnmarshalTwirpJSON(body, req, options); err != nil {
+				return nil, err
+			}
+			if options.IsZero() {
+				options = nil
+			}
+			return s.ListParticipantsWithOptions(ctx, req, options)
+		}, roomServer),
+		NewTwirpJSONHandler("livekit.RoomService", "RemoveParticipant", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &livekit.RoomParticipantIdentity{}
+			options := &RemoveParticipantOptions{}
+			if err := UnmarshalTwirpJSON(body, req, options); err != nil {
+				return nil, err
+			}
+			if options.IsZero() {
+				options = nil
+			}
+			return s.RemoveParticipantWithOptions(ctx, req, options)
+		}, roomServer),
+		NewTwirpJSONHandler("livekit.RoomService", "UnbanParticipant", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &UnbanParticipantRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.UnbanParticipant(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "AdmitParticipant", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &livekit.RoomParticipantIdentity{}
+			if err := UnmarshalTwirpJSON(body, req); err != nil {
+				return nil, err
+			}
+			return s.AdmitParticipant(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "MoveParticipant", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &MoveParticipantRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.MoveParticipant(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetSubscriberAllocation", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetSubscriberAllocationRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetSubscriberAllocation(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetCongestionTrace", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetCongestionTraceRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetCongestionTrace(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "StartPacketCapture", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &StartPacketCaptureRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.StartPacketCapture(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "StopPacketCapture", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &StopPacketCaptureRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.StopPacketCapture(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetTransportStats", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetTransportStatsRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetTransportStats(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetICEDiagnostics", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetICEDiagnosticsRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetICEDiagnostics(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetConnectionQualityDetails", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetConnectionQualityDetailsRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetConnectionQualityDetails(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetTrackStatsHistory", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetTrackStatsHistoryRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetTrackStatsHistory(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetAVSyncStats", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetAVSyncStatsRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetAVSyncStats(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "MuteAllParticipants", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &MuteAllParticipantsRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.MuteAllParticipants(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "MuteTracksBySource", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &MuteTracksBySourceRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.MuteTracksBySource(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "LockRoom", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &LockRoomRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.LockRoom(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "UpdateParticipantsPermission", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &UpdateParticipantsPermissionRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.UpdateParticipantsPermission(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "SetPushToTalk", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &SetPushToTalkRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.SetPushToTalk(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GrantFloor", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GrantFloorRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GrantFloor(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "ReleaseFloor", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &ReleaseFloorRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.ReleaseFloor(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetRoomDebugInfo", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetRoomDebugInfoRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetRoomDebugInfo(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetTrackSubscriberCounts", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetTrackSubscriberCountsRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetTrackSubscriberCounts(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetAPIKeyUsage", hooks, func(ctx context.Context, _ []byte) (any, error) {
+			return s.GetAPIKeyUsage(ctx)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetConcurrency", hooks, func(ctx context.Context, _ []byte) (any, error) {
+			return s.GetConcurrency(ctx)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "ListAuditRecords", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &ListAuditRecordsRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.ListAuditRecords(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "MergeRooms", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &MergeRoomsRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.MergeRooms(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "RedirectParticipant", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &RedirectParticipantRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.RedirectParticipant(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "RedirectRoom", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &RedirectRoomRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.RedirectRoom(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "PatchRoomMetadata", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &PatchRoomMetadataRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.PatchRoomMetadata(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "PatchParticipantMetadata", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &PatchParticipantMetadataRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.PatchParticipantMetadata(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "DrainNode", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &DrainNodeRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.DrainNode(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "GetDrainStatus", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &GetDrainStatusRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.GetDrainStatus(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "CreateAttachment", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &CreateAttachmentRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.CreateAttachment(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "PublishAttachment", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &AttachmentRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.PublishAttachment(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "ListAttachments", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &ListAttachmentsRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.ListAttachments(ctx, req)
+		}, nil),
+		NewTwirpJSONHandler("livekit.RoomService", "DeleteAttachment", hooks, func(ctx context.Context, body []byte) (any, error) {
+			req := &AttachmentRequest{}
+			if err := json.Unmarshal(body, req); err != nil {
+				return nil, malformedJSONError(err)
+			}
+			return s.DeleteAttachment(ctx, req)
+		}, nil),
+	}
 }
@@ -16,20 +16,33 @@ package service_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
 	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/rpc"
 	"github.com/livekit/protocol/rpc/rpcfakes"
+	"github.com/livekit/psrpc"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 )
 
 func TestDeleteRoom(t *testing.T) {
@@ -104,13 +117,846 @@ func TestMetaDataLimits(t *testing.T) {
 	}
 }
 
+func TestCreateRoomJSON(t *testing.T) {
+	create := func(t *testing.T, body string) *TestRoomService {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.allocator.CreateRoomReturns(nil, false, errors.New("not allocated"))
+
+		handlers := svc.TwirpJSONHandlers(nil, nil)
+		req := httptest.NewRequest(http.MethodPost, handlers[0].Path(), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(service.WithGrants(req.Context(), &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomCreate: true},
+		}))
+		handlers[0].ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Equal(t, 1, svc.allocator.CreateRoomCallCount())
+		return svc
+	}
+
+	t.Run("room options are passed to the allocator", func(t *testing.T) {
+		svc := create(t, `{"name": "testroom", "empty_timeout": 10, "waiting_room": true}`)
+		_, req, options := svc.allocator.CreateRoomArgsForCall(0)
+		require.Equal(t, "testroom", req.Name)
+		require.EqualValues(t, 10, req.EmptyTimeout)
+		require.Equal(t, &rtc.RoomOptions{WaitingRoom: true}, options)
+	})
+
+	t.Run("existing options are kept when none are set", func(t *testing.T) {
+		svc := create(t, `{"name": "testroom"}`)
+		_, _, options := svc.allocator.CreateRoomArgsForCall(0)
+		require.Nil(t, options)
+	})
+
+	t.Run("config overrides are passed to the allocator", func(t *testing.T) {
+		svc := create(t, `{"name": "testroom", "config_overrides": {"active_red_encoding": false, "max_track_bitrate": 1500000, "congestion_control": {"allow_pause": true}}}`)
+		_, _, options := svc.allocator.CreateRoomArgsForCall(0)
+		overrides := options.ConfigOverrides
+		require.NotNil(t, overrides)
+		require.False(t, *overrides.ActiveREDEncoding)
+		require.EqualValues(t, 1500000, *overrides.MaxTrackBitrate)
+		require.True(t, *overrides.CongestionControl.AllowPause)
+		require.Nil(t, overrides.CongestionControl.Enabled)
+		require.Nil(t, overrides.PlayoutDelay)
+	})
+
+	t.Run("only the hash of the passcode is passed to the allocator", func(t *testing.T) {
+		svc := create(t, `{"name": "testroom", "passcode": "1234"}`)
+		_, _, options := svc.allocator.CreateRoomArgsForCall(0)
+		require.Empty(t, options.Passcode)
+		require.True(t, options.HasPasscode())
+		require.NotContains(t, options.PasscodeHash, "1234")
+		require.True(t, options.CheckPasscode("1234"))
+		require.False(t, options.CheckPasscode("4321"))
+		require.False(t, options.CheckPasscode(""))
+	})
+
+	t.Run("invalid config overrides are rejected", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		_, err := svc.CreateRoomWithOptions(service.WithGrants(context.Background(), &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomCreate: true},
+		}), &livekit.CreateRoomRequest{Name: "testroom"}, &rtc.RoomOptions{
+			ConfigOverrides: &rtc.RoomConfigOverrides{MaxTrackBitrate: proto.Int64(-1)},
+		})
+		require.ErrorIs(t, err, service.ErrRoomConfigOverrideInvalid)
+		require.Zero(t, svc.allocator.CreateRoomCallCount())
+	})
+
+	t.Run("subscribe defaults are passed to the allocator", func(t *testing.T) {
+		svc := create(t, `{"name": "testroom", "subscribe_defaults": {"camera": {"quality": "low"}, "screen_share_audio": {"disabled": true}}}`)
+		_, _, options := svc.allocator.CreateRoomArgsForCall(0)
+		require.Equal(t, config.SubscribeDefaultsConfig{
+			"camera":             {Quality: "low"},
+			"screen_share_audio": {Disabled: true},
+		}, options.SubscribeDefaults)
+	})
+
+	t.Run("unknown subscribe defaults are rejected", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		_, err := svc.CreateRoomWithOptions(service.WithGrants(context.Background(), &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomCreate: true},
+		}), &livekit.CreateRoomRequest{Name: "testroom"}, &rtc.RoomOptions{
+			SubscribeDefaults: config.SubscribeDefaultsConfig{"camera": {Quality: "ultra"}},
+		})
+		require.ErrorIs(t, err, service.ErrSubscribeDefaultsInvalid)
+		require.Zero(t, svc.allocator.CreateRoomCallCount())
+	})
+
+	t.Run("invalid codec restrictions are rejected", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		_, err := svc.CreateRoomWithOptions(service.WithGrants(context.Background(), &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomCreate: true},
+		}), &livekit.CreateRoomRequest{Name: "testroom"}, &rtc.RoomOptions{
+			Codecs: &rtc.CodecRestriction{Allow: []string{"vp8"}},
+		})
+		require.ErrorIs(t, err, service.ErrCodecRestrictionInvalid)
+		require.Zero(t, svc.allocator.CreateRoomCallCount())
+	})
+
+	t.Run("unknown latency profiles are rejected", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		_, err := svc.CreateRoomWithOptions(service.WithGrants(context.Background(), &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomCreate: true},
+		}), &livekit.CreateRoomRequest{Name: "testroom"}, &rtc.RoomOptions{
+			LatencyProfile: "realtime",
+		})
+		require.ErrorIs(t, err, service.ErrLatencyProfileInvalid)
+		require.Zero(t, svc.allocator.CreateRoomCallCount())
+	})
+
+	t.Run("invalid join restrictions are rejected", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		_, err := svc.CreateRoomWithOptions(service.WithGrants(context.Background(), &auth.ClaimGrants{
+			Video: &auth.VideoGrant{RoomCreate: true},
+		}), &livekit.CreateRoomRequest{Name: "testroom"}, &rtc.RoomOptions{
+			JoinRestriction: &config.JoinRestrictionConfig{DenyCIDRs: []string{"10.0.0.300/8"}},
+		})
+		require.ErrorIs(t, err, service.ErrJoinRestrictionInvalid)
+		require.Zero(t, svc.allocator.CreateRoomCallCount())
+	})
+}
+
+func TestRoomModerationJSON(t *testing.T) {
+	serveWithGrant := func(svc *TestRoomService, grant *auth.VideoGrant, method string, body string) *httptest.ResponseRecorder {
+		for _, h := range svc.TwirpJSONHandlers(nil, nil) {
+			if strings.HasSuffix(h.Path(), "/"+method) {
+				req := httptest.NewRequest(http.MethodPost, h.Path(), strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req = req.WithContext(service.WithGrants(req.Context(), &auth.ClaimGrants{Video: grant}))
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, req)
+				return w
+			}
+		}
+		t.Fatalf("no handler for %s", method)
+		return nil
+	}
+	serve := func(svc *TestRoomService, method string, body string) *httptest.ResponseRecorder {
+		return serveWithGrant(svc, &auth.VideoGrant{RoomAdmin: true, Room: "testroom"}, method, body)
+	}
+
+	t.Run("permission track sources are decoded by name", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.roomExt.UpdateParticipantsPermissionReturns(&livekit.ListParticipantsResponse{}, nil)
+
+		w := serve(svc, "UpdateParticipantsPermission",
+			`{"room": "testroom", "exclude_hosts": true, "permission": {"can_publish": true, "can_publish_sources": ["MICROPHONE"]}}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, 1, svc.roomExt.UpdateParticipantsPermissionCallCount())
+		_, topic, req, _ := svc.roomExt.UpdateParticipantsPermissionArgsForCall(0)
+		require.Equal(t, rpc.FormatRoomTopic("testroom"), topic)
+		require.True(t, req.ExcludeHosts)
+		require.True(t, req.Permission.CanPublish)
+		require.Equal(t, []livekit.TrackSource{livekit.TrackSource_MICROPHONE}, req.Permission.CanPublishSources)
+	})
+
+	t.Run("permission is required", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		w := serve(svc, "UpdateParticipantsPermission", `{"room": "testroom"}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Zero(t, svc.roomExt.UpdateParticipantsPermissionCallCount())
+	})
+
+	t.Run("lock is stored when the room is not running", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.roomExt.LockRoomReturns(nil, psrpc.ErrNoResponse)
+		svc.store.LoadRoomOptionsReturns(&rtc.RoomOptions{WaitingRoom: true}, nil)
+		svc.allocator.CreateRoomReturns(&livekit.Room{Name: "testroom"}, false, nil)
+
+		w := serve(svc, "LockRoom", `{"room": "testroom", "locked": true}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, 1, svc.allocator.CreateRoomCallCount())
+		_, _, options := svc.allocator.CreateRoomArgsForCall(0)
+		require.Equal(t, &rtc.RoomOptions{WaitingRoom: true, Locked: true}, options)
+	})
+
+	t.Run("push to talk is stored when the room is not running", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.roomExt.SetPushToTalkReturns(nil, psrpc.ErrNoResponse)
+		svc.allocator.CreateRoomReturns(&livekit.Room{Name: "testroom"}, false, nil)
+
+		w := serve(svc, "SetPushToTalk", `{"room": "testroom", "push_to_talk": {"enabled": true, "max_floor_duration": 30}}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"push_to_talk": true, "policy": "fifo", "version": 0}`, w.Body.String())
+		_, _, options := svc.allocator.CreateRoomArgsForCall(0)
+		require.Equal(t, &rtc.PushToTalkOptions{Enabled: true, MaxFloorDuration: 30}, options.PushToTalk)
+	})
+
+	t.Run("floor is granted to an identity", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.roomExt.GrantFloorReturns(&rtc.FloorState{PushToTalk: true, Holder: "speaker"}, nil)
+		w := serve(svc, "GrantFloor", `{"room": "testroom", "identity": "speaker", "duration": 10}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, _, req, _ := svc.roomExt.GrantFloorArgsForCall(0)
+		require.Equal(t, &service.GrantFloorRequest{Room: "testroom", Identity: "speaker", Duration: 10}, req)
+	})
+
+	t.Run("subscriber allocation is read from the node of the participant", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.participantExt.GetSubscriberAllocationReturns(&streamallocator.AllocationInfo{
+			State:                    "DEFICIENT",
+			CommittedChannelCapacity: 500_000,
+		}, nil)
+		w := serve(svc, "GetSubscriberAllocation", `{"room": "testroom", "identity": "viewer", "decisions": 5}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.participantExt.GetSubscriberAllocationArgsForCall(0)
+		require.Equal(t, rpc.NewTopicFormatter().ParticipantTopic(context.Background(), "testroom", "viewer"), topic)
+		require.Equal(t, &service.GetSubscriberAllocationRequest{Room: "testroom", Identity: "viewer", Decisions: 5}, req)
+
+		var info streamallocator.AllocationInfo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		require.Equal(t, "DEFICIENT", info.State)
+		require.Equal(t, int64(500_000), info.CommittedChannelCapacity)
+	})
+
+	t.Run("congestion trace is read from the node of the participant", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.participantExt.GetCongestionTraceReturns(&streamallocator.CongestionTrace{
+			DisplayTimeUnit: "ms",
+			TraceEvents:     []streamallocator.TraceEvent{{Name: "channel", Phase: "C", Timestamp: 1000}},
+		}, nil)
+		w := serve(svc, "GetCongestionTrace", `{"room": "testroom", "identity": "viewer"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.participantExt.GetCongestionTraceArgsForCall(0)
+		require.Equal(t, rpc.NewTopicFormatter().ParticipantTopic(context.Background(), "testroom", "viewer"), topic)
+		require.Equal(t, &service.GetCongestionTraceRequest{Room: "testroom", Identity: "viewer"}, req)
+
+		var trace streamallocator.CongestionTrace
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trace))
+		require.Len(t, trace.TraceEvents, 1)
+		require.Equal(t, "channel", trace.TraceEvents[0].Name)
+	})
+
+	t.Run("packet capture is started on the node of the participant", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.participantExt.StartPacketCaptureReturns(&service.PacketCaptureInfo{CaptureID: "PC_1", Status: service.PacketCaptureActive}, nil)
+		w := serve(svc, "StartPacketCapture", `{"room": "testroom", "identity": "viewer", "track_id": "TR_1", "format": "rtpdump", "duration": 10}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.participantExt.StartPacketCaptureArgsForCall(0)
+		require.Equal(t, rpc.NewTopicFormatter().ParticipantTopic(context.Background(), "testroom", "viewer"), topic)
+		require.Equal(t, &service.StartPacketCaptureRequest{Room: "testroom", Identity: "viewer", TrackID: "TR_1", Format: "rtpdump", Duration: 10}, req)
+
+		var info service.PacketCaptureInfo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		require.Equal(t, "PC_1", info.CaptureID)
+	})
+
+	t.Run("transport stats are read from the node of the participant", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.participantExt.GetTransportStatsReturns(&types.TransportStats{
+			Transport: "subscriber",
+			Report: webrtc.StatsReport{
+				"outbound-rtp-1234": webrtc.OutboundRTPStreamStats{
+					Type:        webrtc.StatsTypeOutboundRTP,
+					ID:          "outbound-rtp-1234",
+					SSRC:        1234,
+					PacketsSent: 100,
+				},
+			},
+		}, nil)
+		w := serve(svc, "GetTransportStats", `{"room": "testroom", "identity": "viewer", "transport": "subscriber"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.participantExt.GetTransportStatsArgsForCall(0)
+		require.Equal(t, rpc.NewTopicFormatter().ParticipantTopic(context.Background(), "testroom", "viewer"), topic)
+		require.Equal(t, &service.GetTransportStatsRequest{Room: "testroom", Identity: "viewer", Transport: "subscriber"}, req)
+
+		var stats types.TransportStats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		require.Equal(t, "subscriber", stats.Transport)
+		outbound, ok := stats.Report["outbound-rtp-1234"].(webrtc.OutboundRTPStreamStats)
+		require.True(t, ok)
+		require.Equal(t, uint32(100), outbound.PacketsSent)
+	})
+
+	t.Run("transport stats need a known transport", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		w := serve(svc, "GetTransportStats", `{"room": "testroom", "identity": "viewer", "transport": "signal"}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Zero(t, svc.participantExt.GetTransportStatsCallCount())
+	})
+
+	t.Run("ice diagnostics are read from the node of the participant", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.participantExt.GetICEDiagnosticsReturns(&service.ICEDiagnosticsResponse{
+			Room:     "testroom",
+			Identity: "viewer",
+			Transports: []*types.ICEDiagnostics{
+				{
+					Transport:      "subscriber",
+					State:          "connected",
+					ConnectionType: types.ICEConnectionTypeTURN,
+					SelectedPair: &types.ICECandidatePairInfo{
+						LocalType:  "host",
+						RemoteType: "relay",
+						RTTMs:      42,
+					},
+				},
+				{
+					Transport:     "publisher",
+					State:         "failed",
+					FailureReason: types.ICEFailureReasonChecksFailed,
+				},
+			},
+		}, nil)
+		w := serve(svc, "GetICEDiagnostics", `{"room": "testroom", "identity": "viewer"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.participantExt.GetICEDiagnosticsArgsForCall(0)
+		require.Equal(t, rpc.NewTopicFormatter().ParticipantTopic(context.Background(), "testroom", "viewer"), topic)
+		require.Equal(t, &service.GetICEDiagnosticsRequest{Room: "testroom", Identity: "viewer"}, req)
+
+		var res service.ICEDiagnosticsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Transports, 2)
+		require.Equal(t, "relay", res.Transports[0].SelectedPair.RemoteType)
+		require.Equal(t, types.ICEFailureReasonChecksFailed, res.Transports[1].FailureReason)
+	})
+
+	t.Run("track stats history is read from the node of the participant", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		at := time.Unix(1700000000, 0).UTC()
+		svc.participantExt.GetTrackStatsHistoryReturns(&service.TrackStatsHistoryResponse{
+			Room:     "testroom",
+			Identity: "viewer",
+			Tracks: []*types.TrackStatsSeries{{
+				TrackID:   "TR_camera",
+				Direction: types.TrackStatsDirectionDownlink,
+				Samples:   []*types.TrackStatsSample{{At: at, Stats: &livekit.RTPStats{Packets: 1200, PacketsLost: 12}}},
+			}},
+		}, nil)
+		w := serve(svc, "GetTrackStatsHistory", `{"room": "testroom", "identity": "viewer", "minutes": 5}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.participantExt.GetTrackStatsHistoryArgsForCall(0)
+		require.Equal(t, rpc.NewTopicFormatter().ParticipantTopic(context.Background(), "testroom", "viewer"), topic)
+		require.Equal(t, &service.GetTrackStatsHistoryRequest{Room: "testroom", Identity: "viewer", Minutes: 5}, req)
+
+		var res service.TrackStatsHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Tracks, 1)
+		require.Equal(t, types.TrackStatsDirectionDownlink, res.Tracks[0].Direction)
+		require.True(t, at.Equal(res.Tracks[0].Samples[0].At))
+		require.Equal(t, uint32(1200), res.Tracks[0].Samples[0].Stats.Packets)
+		require.Equal(t, uint32(12), res.Tracks[0].Samples[0].Stats.PacketsLost)
+
+		w = serve(svc, "GetTrackStatsHistory", `{"room": "testroom", "identity": "viewer", "minutes": -1}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Equal(t, 1, svc.participantExt.GetTrackStatsHistoryCallCount())
+	})
+
+	t.Run("track stats history is kept after the participant left", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.store.LoadParticipantReturns(nil, service.ErrParticipantNotFound)
+		leftAt := time.Now().Add(-time.Hour)
+		require.NoError(t, svc.statsHistories.StoreTrackStatsHistory(context.Background(), "testroom", "viewer", []*types.TrackStatsSeries{{
+			TrackID:   "TR_camera",
+			Direction: types.TrackStatsDirectionDownlink,
+			Samples: []*types.TrackStatsSample{
+				{At: leftAt.Add(-5 * time.Minute), Stats: &livekit.RTPStats{Packets: 600}},
+				{At: leftAt, Stats: &livekit.RTPStats{Packets: 1200}},
+			},
+		}}, 2*time.Hour))
+
+		// minutes are counted back from when the participant left
+		w := serve(svc, "GetTrackStatsHistory", `{"room": "testroom", "identity": "viewer", "minutes": 1}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Zero(t, svc.participantExt.GetTrackStatsHistoryCallCount())
+
+		var res service.TrackStatsHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Tracks, 1)
+		require.Len(t, res.Tracks[0].Samples, 1)
+		require.Equal(t, uint32(1200), res.Tracks[0].Samples[0].Stats.Packets)
+
+		w = serve(svc, "GetTrackStatsHistory", `{"room": "testroom", "identity": "viewer", "track_id": "TR_other"}`)
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		w = serve(svc, "GetTrackStatsHistory", `{"room": "testroom", "identity": "other"}`)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("connection quality details are read from the node of the participant", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.participantExt.GetConnectionQualityDetailsReturns(&types.ConnectionQualityDetails{
+			Quality:    livekit.ConnectionQuality_POOR.String(),
+			Score:      2.1,
+			Uplink:     &types.NetworkPathStats{Packets: 1000, PacketLossPercentage: 9},
+			Downlink:   &types.NetworkPathStats{Packets: 2000, PacketLossPercentage: 0.5},
+			Constraint: types.NetworkConstraintUplinkLimited,
+		}, nil)
+		w := serve(svc, "GetConnectionQualityDetails", `{"room": "testroom", "identity": "viewer"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.participantExt.GetConnectionQualityDetailsArgsForCall(0)
+		require.Equal(t, rpc.NewTopicFormatter().ParticipantTopic(context.Background(), "testroom", "viewer"), topic)
+		require.Equal(t, &service.GetConnectionQualityDetailsRequest{Room: "testroom", Identity: "viewer"}, req)
+
+		var details types.ConnectionQualityDetails
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+		require.Equal(t, "POOR", details.Quality)
+		require.Equal(t, types.NetworkConstraintUplinkLimited, details.Constraint)
+		require.Equal(t, float32(9), details.Uplink.PacketLossPercentage)
+	})
+
+	t.Run("av sync stats are read from the node of the participant", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.participantExt.GetAVSyncStatsReturns(&types.AVSyncDetails{
+			Publishers: []*types.AVSyncStats{{
+				PublisherIdentity: "presenter",
+				AudioOffsetMs:     120,
+				VideoOffsetMs:     20,
+				SkewMs:            100,
+			}},
+		}, nil)
+		w := serve(svc, "GetAVSyncStats", `{"room": "testroom", "identity": "viewer"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.participantExt.GetAVSyncStatsArgsForCall(0)
+		require.Equal(t, rpc.NewTopicFormatter().ParticipantTopic(context.Background(), "testroom", "viewer"), topic)
+		require.Equal(t, &service.GetAVSyncStatsRequest{Room: "testroom", Identity: "viewer"}, req)
+
+		var details types.AVSyncDetails
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+		require.Len(t, details.Publishers, 1)
+		require.Equal(t, "presenter", details.Publishers[0].PublisherIdentity)
+		require.Equal(t, float64(100), details.Publishers[0].SkewMs)
+	})
+
+	t.Run("room debug info is read from the node of the room", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.roomExt.GetRoomDebugInfoReturns(&service.RoomDebugInfo{"Name": "testroom"}, nil)
+		w := serveWithGrant(svc, &auth.VideoGrant{RoomAdmin: true, RoomList: true, Room: "testroom"},
+			"GetRoomDebugInfo", `{"room": "testroom"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.roomExt.GetRoomDebugInfoArgsForCall(0)
+		require.Equal(t, rpc.FormatRoomTopic("testroom"), topic)
+		require.Equal(t, &service.GetRoomDebugInfoRequest{Room: "testroom"}, req)
+		require.JSONEq(t, `{"Name": "testroom"}`, w.Body.String())
+	})
+
+	t.Run("room debug info needs the room list grant", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		w := serve(svc, "GetRoomDebugInfo", `{"room": "testroom"}`)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Zero(t, svc.roomExt.GetRoomDebugInfoCallCount())
+	})
+
+	t.Run("track subscriber counts are read from the node of the room", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.roomExt.GetTrackSubscriberCountsReturns(&service.TrackSubscriberCounts{
+			Tracks: []service.TrackSubscriberCount{{Identity: "host", TrackID: "TR_cam", Source: "camera", Subscribers: 3}},
+		}, nil)
+		w := serve(svc, "GetTrackSubscriberCounts", `{"room": "testroom", "identity": "host"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.roomExt.GetTrackSubscriberCountsArgsForCall(0)
+		require.Equal(t, rpc.FormatRoomTopic("testroom"), topic)
+		require.Equal(t, &service.GetTrackSubscriberCountsRequest{Room: "testroom", Identity: "host"}, req)
+		require.JSONEq(t, `{"tracks": [{"identity": "host", "track_id": "TR_cam", "source": "camera", "subscribers": 3}]}`, w.Body.String())
+	})
+
+	t.Run("floor policy must be known", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		w := serve(svc, "SetPushToTalk", `{"room": "testroom", "push_to_talk": {"enabled": true, "policy": "loudest"}}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Zero(t, svc.roomExt.SetPushToTalkCallCount())
+	})
+
+	t.Run("removed participants are banned on their node", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.participantExt.BanParticipantReturns(&service.ParticipantBan{Room: "testroom", Identity: "guest"}, nil)
+
+		w := serve(svc, "RemoveParticipant", `{"room": "testroom", "identity": "guest", "ban": true, "ban_ip": true}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.participantExt.BanParticipantArgsForCall(0)
+		require.Equal(t, rpc.FormatParticipantTopic("testroom", "guest"), topic)
+		require.Equal(t, &service.BanParticipantRequest{Room: "testroom", Identity: "guest", BanIP: true}, req)
+
+		w = serve(svc, "RemoveParticipant", `{"room": "testroom", "identity": "guest", "ban_ip": true}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Equal(t, 1, svc.participantExt.BanParticipantCallCount())
+	})
+
+	t.Run("bans are lifted from the store", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		w := serve(svc, "UnbanParticipant", `{"room": "testroom", "identity": "guest"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, roomName, identity := svc.store.DeleteParticipantBanArgsForCall(0)
+		require.Equal(t, livekit.RoomName("testroom"), roomName)
+		require.Equal(t, livekit.ParticipantIdentity("guest"), identity)
+
+		svc.store.DeleteParticipantBanReturns(service.ErrParticipantBanNotFound)
+		w = serve(svc, "UnbanParticipant", `{"room": "testroom", "identity": "guest"}`)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	// admin tokens of the destination room are minted like any other RoomService token
+	destinationToken := func(key string, secret string, grant *auth.VideoGrant) string {
+		token, err := auth.NewAccessToken(key, secret).AddGrant(grant).ToJWT()
+		require.NoError(t, err)
+		return token
+	}
+	mainAdminToken := destinationToken(testAPIKey, testAPISecret, &auth.VideoGrant{RoomAdmin: true, Room: "main"})
+
+	t.Run("participants are moved on their node", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.participantExt.MoveParticipantReturns(&livekit.ParticipantInfo{Identity: "guest"}, nil)
+		grant := &auth.VideoGrant{RoomAdmin: true, Room: "breakout"}
+
+		w := serveWithGrant(svc, grant, "MoveParticipant", fmt.Sprintf(`{"room": "breakout", "identity": "guest", "destination_room": "main", "destination_token": %q}`, mainAdminToken))
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.participantExt.MoveParticipantArgsForCall(0)
+		require.Equal(t, rpc.FormatParticipantTopic("breakout", "guest"), topic)
+		require.Equal(t, &service.MoveParticipantRequest{Room: "breakout", Identity: "guest", DestinationRoom: "main"}, req)
+	})
+
+	// an admin token grants a single room, the destination needs an admin token of its own
+	t.Run("moves need admin of the room and of the destination", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		for _, tc := range []struct {
+			grant            *auth.VideoGrant
+			destinationToken string
+		}{
+			{grant: &auth.VideoGrant{RoomAdmin: true, Room: "breakout"}},
+			{grant: &auth.VideoGrant{RoomAdmin: true, Room: "breakout", RoomCreate: true}},
+			{grant: &auth.VideoGrant{RoomAdmin: true, Room: "breakout"}, destinationToken: "invalid"},
+			{grant: &auth.VideoGrant{RoomAdmin: true, Room: "breakout"}, destinationToken: destinationToken(testAPIKey, testAPISecret, &auth.VideoGrant{RoomAdmin: true, Room: "other"})},
+			{grant: &auth.VideoGrant{RoomAdmin: true, Room: "breakout"}, destinationToken: destinationToken(testAPIKey, testAPISecret, &auth.VideoGrant{RoomJoin: true, Room: "main"})},
+			{grant: &auth.VideoGrant{RoomAdmin: true, Room: "breakout"}, destinationToken: destinationToken(testAPIKey, "othersecret", &auth.VideoGrant{RoomAdmin: true, Room: "main"})},
+			{grant: &auth.VideoGrant{RoomAdmin: true, Room: "main"}, destinationToken: mainAdminToken},
+			{grant: &auth.VideoGrant{RoomCreate: true}, destinationToken: mainAdminToken},
+		} {
+			w := serveWithGrant(svc, tc.grant, "MoveParticipant", fmt.Sprintf(`{"room": "breakout", "identity": "guest", "destination_room": "main", "destination_token": %q}`, tc.destinationToken))
+			require.Equal(t, http.StatusUnauthorized, w.Code)
+			w = serveWithGrant(svc, tc.grant, "MergeRooms", fmt.Sprintf(`{"room": "breakout", "destination_room": "main", "destination_token": %q}`, tc.destinationToken))
+			require.Equal(t, http.StatusUnauthorized, w.Code)
+		}
+		require.Zero(t, svc.participantExt.MoveParticipantCallCount())
+		require.Zero(t, svc.roomExt.MergeRoomsCallCount())
+	})
+
+	t.Run("rooms are merged on the node of the merged room", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.roomExt.MergeRoomsReturns(&service.MergeRoomsResponse{Moved: []string{"guest"}}, nil)
+		// deleting the merged room needs the room create grant
+		grant := &auth.VideoGrant{RoomAdmin: true, Room: "breakout", RoomCreate: true}
+		body := fmt.Sprintf(`{"room": "breakout", "destination_room": "main", "destination_token": %q, "delete_room": true}`, mainAdminToken)
+
+		w := serveWithGrant(svc, grant, "MergeRooms", body)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.roomExt.MergeRoomsArgsForCall(0)
+		require.Equal(t, rpc.FormatRoomTopic("breakout"), topic)
+		require.Equal(t, &service.MergeRoomsRequest{Room: "breakout", DestinationRoom: "main", DeleteRoom: true}, req)
+		require.JSONEq(t, `{"moved": ["guest"], "deleted": true}`, w.Body.String())
+
+		// the merged room is kept while participants are left in it
+		svc.roomExt.MergeRoomsReturns(&service.MergeRoomsResponse{
+			Moved:  []string{},
+			Failed: []service.MergeRoomsFailure{{Identity: "pending", Error: "participant waiting to be admitted cannot be moved"}},
+		}, nil)
+		w = serveWithGrant(svc, grant, "MergeRooms", body)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"moved": [], "failed": [{"identity": "pending", "error": "participant waiting to be admitted cannot be moved"}], "deleted": false}`, w.Body.String())
+
+		w = serveWithGrant(svc, &auth.VideoGrant{RoomAdmin: true, Room: "main"}, "MergeRooms", fmt.Sprintf(`{"room": "main", "destination_room": "main", "destination_token": %q}`, mainAdminToken))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Equal(t, 2, svc.roomExt.MergeRoomsCallCount())
+	})
+
+	t.Run("metadata patch is sent to the node of the room", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.roomExt.PatchRoomMetadataReturns(&service.MetadataPatchResponse{Metadata: `{"stage":"live"}`, Version: "v2"}, nil)
+		w := serve(svc, "PatchRoomMetadata", `{"room": "testroom", "patch": {"stage": "live"}, "version": "v1"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, req, _ := svc.roomExt.PatchRoomMetadataArgsForCall(0)
+		require.Equal(t, rpc.FormatRoomTopic("testroom"), topic)
+		require.JSONEq(t, `{"stage": "live"}`, string(req.Patch))
+		require.Equal(t, "v1", req.Version)
+		require.JSONEq(t, `{"metadata": "{\"stage\":\"live\"}", "version": "v2"}`, w.Body.String())
+
+		svc.roomExt.PatchRoomMetadataReturns(nil, service.ErrMetadataVersionMismatch)
+		w = serve(svc, "PatchRoomMetadata", `{"room": "testroom", "patch": {"stage": "live"}, "version": "v1"}`)
+		require.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("metadata patch is applied to the stored room when the room is not running", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.roomExt.PatchRoomMetadataReturns(nil, psrpc.ErrNoResponse)
+		svc.allocator.PatchRoomMetadataReturns(&livekit.Room{Name: "testroom", Metadata: `{"stage":"live"}`}, nil)
+		w := serve(svc, "PatchRoomMetadata", `{"room": "testroom", "patch": {"stage": "live"}}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, roomName, patch, version := svc.allocator.PatchRoomMetadataArgsForCall(0)
+		require.Equal(t, livekit.RoomName("testroom"), roomName)
+		require.JSONEq(t, `{"stage": "live"}`, string(patch))
+		require.Empty(t, version)
+		require.JSONEq(t, fmt.Sprintf(`{"metadata": "{\"stage\":\"live\"}", "version": %q}`, rtc.MetadataVersion(`{"stage":"live"}`)), w.Body.String())
+	})
+
+	t.Run("participant metadata patch needs an identity and a patch", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		w := serve(svc, "PatchParticipantMetadata", `{"room": "testroom", "patch": {"hand": true}}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		w = serve(svc, "PatchParticipantMetadata", `{"room": "testroom", "identity": "guest"}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Zero(t, svc.participantExt.PatchParticipantMetadataCallCount())
+
+		svc.participantExt.PatchParticipantMetadataReturns(&service.MetadataPatchResponse{}, nil)
+		w = serve(svc, "PatchParticipantMetadata", `{"room": "testroom", "identity": "guest", "patch": {"hand": true}}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		_, topic, _, _ := svc.participantExt.PatchParticipantMetadataArgsForCall(0)
+		require.Equal(t, rpc.FormatParticipantTopic("testroom", "guest"), topic)
+	})
+}
+
+func TestListPagesJSON(t *testing.T) {
+	serve := func(svc *TestRoomService, method string, body string) *httptest.ResponseRecorder {
+		for _, h := range svc.TwirpJSONHandlers(nil, nil) {
+			if strings.HasSuffix(h.Path(), "/"+method) {
+				req := httptest.NewRequest(http.MethodPost, h.Path(), strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req = req.WithContext(service.WithGrants(req.Context(), &auth.ClaimGrants{
+					Video: &auth.VideoGrant{RoomList: true, RoomAdmin: true, Room: "testroom"},
+				}))
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, req)
+				return w
+			}
+		}
+		t.Fatalf("no handler for %s", method)
+		return nil
+	}
+	type page struct {
+		Rooms []struct {
+			Name string `json:"name"`
+		} `json:"rooms"`
+		Participants []struct {
+			Identity string `json:"identity"`
+		} `json:"participants"`
+		NextPageToken string `json:"next_page_token"`
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) page {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var p page
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+		return p
+	}
+
+	t.Run("rooms are paged in name order", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		rooms := func() []*livekit.Room {
+			return []*livekit.Room{
+				{Name: "c", NumParticipants: 1, CreationTime: 300},
+				{Name: "a", NumParticipants: 2, CreationTime: 100, Metadata: `{"team":"red"}`},
+				{Name: "d", NumParticipants: 0, CreationTime: 400},
+				{Name: "b", NumParticipants: 1, CreationTime: 200, Metadata: `{"team":"red"}`},
+			}
+		}
+		svc.store.ListRoomsCalls(func(context.Context, []livekit.RoomName) ([]*livekit.Room, error) {
+			return rooms(), nil
+		})
+
+		first := decode(t, serve(svc, "ListRooms", `{"limit":2,"state":"active"}`))
+		require.Len(t, first.Rooms, 2)
+		require.Equal(t, "a", first.Rooms[0].Name)
+		require.Equal(t, "b", first.Rooms[1].Name)
+		require.NotEmpty(t, first.NextPageToken)
+
+		second := decode(t, serve(svc, "ListRooms", `{"limit":2,"state":"active","page_token":"`+first.NextPageToken+`"}`))
+		require.Len(t, second.Rooms, 1)
+		require.Equal(t, "c", second.Rooms[0].Name)
+		require.Empty(t, second.NextPageToken)
+
+		filtered := decode(t, serve(svc, "ListRooms", `{"metadata_contains":"\"red\"","created_after":100}`))
+		require.Len(t, filtered.Rooms, 1)
+		require.Equal(t, "b", filtered.Rooms[0].Name)
+
+		// without options every room is returned as stored
+		require.Len(t, decode(t, serve(svc, "ListRooms", `{}`)).Rooms, 4)
+
+		require.Equal(t, http.StatusBadRequest, serve(svc, "ListRooms", `{"state":"closed"}`).Code)
+		require.Equal(t, http.StatusBadRequest, serve(svc, "ListRooms", `{"page_token":"!"}`).Code)
+	})
+
+	t.Run("participants are filtered by state", func(t *testing.T) {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.store.ListParticipantsReturns([]*livekit.ParticipantInfo{
+			{Identity: "bob", State: livekit.ParticipantInfo_ACTIVE, JoinedAt: 20},
+			{Identity: "alice", State: livekit.ParticipantInfo_JOINING, JoinedAt: 10},
+			{Identity: "carol", State: livekit.ParticipantInfo_ACTIVE, JoinedAt: 30},
+		}, nil)
+
+		p := decode(t, serve(svc, "ListParticipants", `{"room":"testroom","states":["ACTIVE"],"limit":1}`))
+		require.Len(t, p.Participants, 1)
+		require.Equal(t, "bob", p.Participants[0].Identity)
+		require.NotEmpty(t, p.NextPageToken)
+
+		require.Equal(t, http.StatusBadRequest, serve(svc, "ListParticipants", `{"room":"testroom","states":["GONE"]}`).Code)
+	})
+}
+
+func TestGetConcurrency(t *testing.T) {
+	listCtx := service.WithGrants(context.Background(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomList: true}})
+
+	newService := func(ttl time.Duration) *TestRoomService {
+		svc := newTestRoomServiceWithAPIConfig(config.RoomConfig{}, config.APIConfig{
+			ExecutionTimeout:    time.Second,
+			ConcurrencyCacheTTL: ttl,
+		})
+		svc.router.ListNodesReturns([]*livekit.Node{
+			{Id: "node-a", Type: livekit.NodeType_SERVER, State: livekit.NodeState_SERVING},
+			{Id: "node-b", Type: livekit.NodeType_SERVER, State: livekit.NodeState_SERVING},
+			{Id: "node-c", Type: livekit.NodeType_SERVER, State: livekit.NodeState_SERVING},
+			{Id: "relay", Type: livekit.NodeType_TURN, State: livekit.NodeState_SERVING},
+		}, nil)
+		svc.nodeExt.GetNodeConcurrencyStub = func(context.Context, *service.GetNodeConcurrencyRequest, ...psrpc.RequestOption) (<-chan *service.JSONResponse[service.NodeConcurrency], error) {
+			resChan := make(chan *service.JSONResponse[service.NodeConcurrency], 3)
+			resChan <- &service.JSONResponse[service.NodeConcurrency]{Result: &service.NodeConcurrency{
+				NodeID: "node-b",
+				ConcurrencyTotals: service.ConcurrencyTotals{
+					Rooms: 1, Participants: 3, PublishedTracks: 2, DownTracks: 4, IngressBitrate: 1000, EgressBitrate: 2000,
+				},
+			}}
+			resChan <- &service.JSONResponse[service.NodeConcurrency]{Err: psrpc.NewErrorf(psrpc.Internal, "failed")}
+			resChan <- &service.JSONResponse[service.NodeConcurrency]{Result: &service.NodeConcurrency{
+				NodeID: "node-a",
+				ConcurrencyTotals: service.ConcurrencyTotals{
+					Rooms: 2, Participants: 5, PublishedTracks: 6, DownTracks: 10, IngressBitrate: 500, EgressBitrate: 700,
+				},
+			}}
+			close(resChan)
+			return resChan, nil
+		}
+		return svc
+	}
+
+	t.Run("totals are summed over the nodes that answered", func(t *testing.T) {
+		svc := newService(0)
+		snapshot, err := svc.GetConcurrency(listCtx)
+		require.NoError(t, err)
+		require.Equal(t, service.ConcurrencyTotals{
+			Rooms: 3, Participants: 8, PublishedTracks: 8, DownTracks: 14, IngressBitrate: 1500, EgressBitrate: 2700,
+		}, snapshot.ConcurrencyTotals)
+		require.Len(t, snapshot.Nodes, 2)
+		require.Equal(t, livekit.NodeID("node-a"), snapshot.Nodes[0].NodeID)
+		require.Equal(t, []livekit.NodeID{"node-c"}, snapshot.MissingNodes)
+	})
+
+	t.Run("snapshots are cached", func(t *testing.T) {
+		svc := newService(time.Minute)
+		first, err := svc.GetConcurrency(listCtx)
+		require.NoError(t, err)
+		second, err := svc.GetConcurrency(listCtx)
+		require.NoError(t, err)
+		require.Same(t, first, second)
+		require.Equal(t, 1, svc.nodeExt.GetNodeConcurrencyCallCount())
+
+		svc = newService(0)
+		_, _ = svc.GetConcurrency(listCtx)
+		_, _ = svc.GetConcurrency(listCtx)
+		require.Equal(t, 2, svc.nodeExt.GetNodeConcurrencyCallCount())
+	})
+
+	t.Run("needs the room list grant", func(t *testing.T) {
+		svc := newService(0)
+		ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true, Room: "testroom"}})
+		_, err := svc.GetConcurrency(ctx)
+		require.Error(t, err)
+		require.Zero(t, svc.nodeExt.GetNodeConcurrencyCallCount())
+	})
+}
+
+func TestDrainNode(t *testing.T) {
+	adminCtx := service.WithGrants(context.Background(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true, RoomList: true}})
+
+	newService := func() *TestRoomService {
+		svc := newTestRoomService(config.RoomConfig{})
+		svc.router.ListNodesReturns([]*livekit.Node{
+			{Id: "node-a", Type: livekit.NodeType_SERVER, State: livekit.NodeState_SERVING},
+			{Id: "relay", Type: livekit.NodeType_TURN, State: livekit.NodeState_SERVING},
+		}, nil)
+		svc.nodeExt.DrainNodeReturns(&service.DrainStatus{NodeID: "node-a", Draining: true}, nil)
+		return svc
+	}
+
+	t.Run("drain is sent to the node", func(t *testing.T) {
+		svc := newService()
+		status, err := svc.DrainNode(adminCtx, &service.DrainNodeRequest{NodeID: "node-a", Deadline: 60})
+		require.NoError(t, err)
+		require.True(t, status.Draining)
+		require.Equal(t, 1, svc.nodeExt.DrainNodeCallCount())
+		_, nodeID, req, _ := svc.nodeExt.DrainNodeArgsForCall(0)
+		require.Equal(t, livekit.NodeID("node-a"), nodeID)
+		require.Equal(t, 60, req.Deadline)
+	})
+
+	t.Run("node must be a server node", func(t *testing.T) {
+		svc := newService()
+		for _, nodeID := range []string{"relay", "unknown"} {
+			_, err := svc.DrainNode(adminCtx, &service.DrainNodeRequest{NodeID: nodeID})
+			var terr twirp.Error
+			require.ErrorAs(t, err, &terr)
+			require.Equal(t, twirp.NotFound, terr.Code())
+		}
+		_, err := svc.GetDrainStatus(adminCtx, &service.GetDrainStatusRequest{NodeID: "unknown"})
+		require.Error(t, err)
+		require.Zero(t, svc.nodeExt.DrainNodeCallCount())
+		require.Zero(t, svc.nodeExt.GetDrainStatusCallCount())
+	})
+
+	t.Run("deadline cannot be negative", func(t *testing.T) {
+		svc := newService()
+		_, err := svc.DrainNode(adminCtx, &service.DrainNodeRequest{NodeID: "node-a", Deadline: -1})
+		require.Error(t, err)
+		require.Zero(t, svc.nodeExt.DrainNodeCallCount())
+	})
+
+	t.Run("needs the node admin grant", func(t *testing.T) {
+		svc := newService()
+		ctx := service.WithGrants(context.Background(), &auth.ClaimGrants{Video: &auth.VideoGrant{RoomAdmin: true, Room: "testroom"}})
+		_, err := svc.DrainNode(ctx, &service.DrainNodeRequest{NodeID: "node-a"})
+		require.Error(t, err)
+		_, err = svc.GetDrainStatus(ctx, &service.GetDrainStatusRequest{NodeID: "node-a"})
+		require.Error(t, err)
+		require.Zero(t, svc.nodeExt.DrainNodeCallCount())
+		require.Zero(t, svc.nodeExt.GetDrainStatusCallCount())
+	})
+}
+
+const (
+	testAPIKey    = "APIroomservice"
+	testAPISecret = "roomservicesecret"
+)
+
 func newTestRoomService(conf config.RoomConfig) *TestRoomService {
+	return newTestRoomServiceWithAPIConfig(conf, config.APIConfig{ExecutionTimeout: 2})
+}
+
+func newTestRoomServiceWithAPIConfig(conf config.RoomConfig, apiConf config.APIConfig) *TestRoomService {
 	router := &routingfakes.FakeRouter{}
 	allocator := &servicefakes.FakeRoomAllocator{}
 	store := &servicefakes.FakeServiceStore{}
+	roomExtClient := &servicefakes.FakeRoomExtClient{}
+	participantExtClient := &servicefakes.FakeParticipantExtClient{}
+	nodeExtClient := &servicefakes.FakeNodeExtClient{}
+	statsHistories := service.NewLocalStore()
 	svc, err := service.NewRoomService(
 		conf,
-		config.APIConfig{ExecutionTimeout: 2},
+		apiConf,
 		rpc.PSRPCConfig{},
 		router,
 		allocator,
@@ -120,21 +966,38 @@ func newTestRoomService(conf config.RoomConfig) *TestRoomService {
 		rpc.NewTopicFormatter(),
 		&rpcfakes.FakeTypedRoomClient{},
 		&rpcfakes.FakeTypedParticipantClient{},
+		participantExtClient,
+		roomExtClient,
+		nodeExtClient,
+		nil,
+		nil,
+		nil,
+		statsHistories,
+		auth.NewSimpleKeyProvider(testAPIKey, testAPISecret),
+		nil,
 	)
 	if err != nil {
 		panic(err)
 	}
 	return &TestRoomService{
-		RoomService: *svc,
-		router:      router,
-		allocator:   allocator,
-		store:       store,
+		RoomService:    *svc,
+		router:         router,
+		allocator:      allocator,
+		store:          store,
+		roomExt:        roomExtClient,
+		participantExt: participantExtClient,
+		nodeExt:        nodeExtClient,
+		statsHistories: statsHistories,
 	}
 }
 
 type TestRoomService struct {
 	service.RoomService
-	router    *routingfakes.FakeRouter
-	allocator *servicefakes.FakeRoomAllocator
-	store     *servicefakes.FakeServiceStore
+	router         *routingfakes.FakeRouter
+	allocator      *servicefakes.FakeRoomAllocator
+	store          *servicefakes.FakeServiceStore
+	roomExt        *servicefakes.FakeRoomExtClient
+	participantExt *servicefakes.FakeParticipantExtClient
+	nodeExt        *servicefakes.FakeNodeExtClient
+	statsHistories *service.LocalStore
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
@@ -52,10 +53,16 @@ type RTCService struct {
 	currentNode   routing.LocalNode
 	config        *config.Config
 	isDev         bool
-	limits        config.LimitConfig
 	parser        *uaparser.Parser
 	agentClient   rtc.AgentClient
 	telemetry     telemetry.TelemetryService
+	keyQuotas     *KeyQuotas
+	passcodes     *RoomPasscodes
+	validator     *ParticipantValidator
+	authorizer    *JoinAuthorizer
+	restrictions  *JoinRestrictions
+	// proxies whose forwarding headers carry the client IP
+	trustedProxies []*net.IPNet
 
 	mu          sync.Mutex
 	connections map[*websocket.Conn]struct{}
@@ -69,20 +76,30 @@ func NewRTCService(
 	currentNode routing.LocalNode,
 	agentClient rtc.AgentClient,
 	telemetry telemetry.TelemetryService,
+	keyQuotas *KeyQuotas,
+	passcodes *RoomPasscodes,
+	validator *ParticipantValidator,
+	authorizer *JoinAuthorizer,
+	restrictions *JoinRestrictions,
 ) *RTCService {
 	s := &RTCService{
-		router:        router,
-		roomAllocator: ra,
-		store:         store,
-		upgrader:      websocket.Upgrader{},
-		currentNode:   currentNode,
-		config:        conf,
-		isDev:         conf.Development,
-		limits:        conf.Limit,
-		parser:        uaparser.NewFromSaved(),
-		agentClient:   agentClient,
-		telemetry:     telemetry,
-		connections:   map[*websocket.Conn]struct{}{},
+		router:         router,
+		roomAllocator:  ra,
+		store:          store,
+		upgrader:       websocket.Upgrader{},
+		currentNode:    currentNode,
+		config:         conf,
+		isDev:          conf.Development,
+		parser:         uaparser.NewFromSaved(),
+		keyQuotas:      keyQuotas,
+		passcodes:      passcodes,
+		validator:      validator,
+		authorizer:     authorizer,
+		restrictions:   restrictions,
+		trustedProxies: ParseTrustedProxies(conf.TrustedProxies),
+		agentClient:    agentClient,
+		telemetry:      telemetry,
+		connections:    map[*websocket.Conn]struct{}{},
 	}
 
 	// allow connections from any origin, since script may be hosted anywhere
@@ -117,8 +134,11 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 		return "", pi, http.StatusUnauthorized, err
 	}
 
-	if claims.Identity == "" {
-		return "", pi, http.StatusBadRequest, ErrIdentityEmpty
+	if claims.Identity, err = s.validator.ValidateIdentity(claims.Identity, claims.GetParticipantKind()); err != nil {
+		return "", pi, http.StatusBadRequest, err
+	}
+	if claims.Name, err = s.validator.ValidateName(claims.Name); err != nil {
+		return "", pi, http.StatusBadRequest, err
 	}
 
 	roomName := livekit.RoomName(r.FormValue("room"))
@@ -129,11 +149,30 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 	adaptiveStreamParam := r.FormValue("adaptive_stream")
 	participantID := r.FormValue("sid")
 	subscriberAllowPauseParam := r.FormValue("subscriber_allow_pause")
+	// downstream bandwidth the client expects, in bps, e.g. the estimate of its previous session
+	bandwidthHint, _ := strconv.ParseInt(r.FormValue("bandwidth_hint"), 10, 64)
 
 	if onlyName != "" {
 		roomName = onlyName
 	}
 
+	// a resume token only carries on the session it was handed out for
+	resume := GetResumeGrant(r.Context())
+	if resume != nil && (!boolValue(reconnectParam) || resume.ParticipantID != participantID || livekit.RoomName(resume.Room) != roomName) {
+		return "", pi, http.StatusUnauthorized, ErrResumeTokenMismatch
+	}
+
+	clientInfo := s.ParseClientInfo(r)
+
+	// bans apply to reconnects as well, a banned participant is not let back with its session.
+	// they are checked before the identity of publish only connections is suffixed
+	if err = s.restrictions.CheckBan(r.Context(), roomName, livekit.ParticipantIdentity(claims.Identity), clientInfo); err != nil {
+		if errors.Is(err, ErrParticipantBanned) {
+			return "", pi, http.StatusForbidden, err
+		}
+		return "", pi, http.StatusInternalServerError, err
+	}
+
 	// this is new connection for existing participant -  with publish only permissions
 	if publishParam != "" {
 		// Make sure grant has GetCanPublish set,
@@ -150,16 +189,54 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 	if err != nil {
 		if errors.Is(err, ErrRoomNotFound) {
 			return "", pi, http.StatusNotFound, err
+		} else if errors.Is(err, rtc.ErrRoomNotActive) || errors.Is(err, rtc.ErrRoomExpired) {
+			return "", pi, http.StatusForbidden, err
 		} else {
 			return "", pi, http.StatusInternalServerError, err
 		}
 	}
 
+	// reconnecting participants are already counted, and have presented the passcode and been authorized
+	if !boolValue(reconnectParam) {
+		if err = s.keyQuotas.CheckJoin(r.Context(), roomName, GetAPIKey(r.Context())); err != nil {
+			if errors.Is(err, ErrAPIKeyParticipantQuotaExceeded) {
+				return "", pi, http.StatusTooManyRequests, err
+			}
+			return "", pi, http.StatusInternalServerError, err
+		}
+
+		if err = s.restrictions.CheckJoin(r.Context(), roomName, GetAPIKey(r.Context()), livekit.ParticipantIdentity(claims.Identity), clientInfo); err != nil {
+			// a code of its own, so that clients can tell restricted networks apart from other rejections
+			if errors.Is(err, ErrJoinRestricted) {
+				return "", pi, http.StatusUnavailableForLegalReasons, err
+			}
+			return "", pi, http.StatusInternalServerError, err
+		}
+
+		if err = s.passcodes.CheckJoin(r.Context(), roomName, claims, r.FormValue("passcode")); err != nil {
+			switch {
+			case errors.Is(err, ErrRoomPasscodeInvalid):
+				return "", pi, http.StatusForbidden, err
+			case errors.Is(err, ErrRoomPasscodeLockedOut):
+				return "", pi, http.StatusTooManyRequests, err
+			default:
+				return "", pi, http.StatusInternalServerError, err
+			}
+		}
+
+		if err = s.authorizer.Authorize(r.Context(), roomName, claims, clientInfo); err != nil {
+			if errors.Is(err, ErrJoinRejected) {
+				return "", pi, http.StatusForbidden, err
+			}
+			return "", pi, http.StatusServiceUnavailable, err
+		}
+	}
+
 	region := ""
 	if router, ok := s.router.(routing.Router); ok {
 		region = router.GetRegion()
 		if foundNode, err := router.GetNodeForRoom(r.Context(), roomName); err == nil {
-			if selector.LimitsReached(s.limits, foundNode.Stats) {
+			if selector.LimitsReached(s.config.Reloadable().Limit, foundNode.Stats) {
 				return "", pi, http.StatusServiceUnavailable, rtc.ErrLimitExceeded
 			}
 		}
@@ -171,13 +248,21 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 		Identity:        livekit.ParticipantIdentity(claims.Identity),
 		Name:            livekit.ParticipantName(claims.Name),
 		AutoSubscribe:   true,
-		Client:          s.ParseClientInfo(r),
+		Client:          clientInfo,
 		Grants:          claims,
 		Region:          region,
+		APIKey:          GetAPIKey(r.Context()),
+		SubscribeFilter: GetSubscribeFilter(r.Context()),
+	}
+	if bandwidthHint > 0 {
+		pi.BandwidthHint = bandwidthHint
 	}
 	if pi.Reconnect {
 		pi.ID = livekit.ParticipantID(participantID)
 	}
+	if resume != nil {
+		pi.ResumeNodeID = livekit.NodeID(resume.NodeID)
+	}
 
 	if autoSubParam != "" {
 		pi.AutoSubscribe = boolValue(autoSubParam)
@@ -200,11 +285,16 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	joinRequestedAt := time.Now()
 	roomName, pi, code, err := s.validate(r)
 	if err != nil {
 		handleError(w, r, code, err)
 		return
 	}
+	if !pi.Reconnect {
+		pi.JoinRequestedAt = joinRequestedAt
+		pi.TokenValidation = time.Since(joinRequestedAt)
+	}
 
 	// for logger
 	loggerFields := []interface{}{
@@ -226,7 +316,7 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		connectionTimeout := 3 * time.Second * time.Duration(i+1)
 		ctx := utils.ContextWithAttempt(r.Context(), i)
 		cr, initialResponse, err = s.startConnection(ctx, roomName, pi, connectionTimeout)
-		if err == nil {
+		if err == nil || errors.Is(err, ErrAPIKeyRoomQuotaExceeded) {
 			break
 		}
 		if i < 2 {
@@ -237,7 +327,11 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		prometheus.IncrementParticipantJoinFail(1)
-		handleError(w, r, http.StatusInternalServerError, err, loggerFields...)
+		code := http.StatusInternalServerError
+		if errors.Is(err, ErrAPIKeyRoomQuotaExceeded) {
+			code = http.StatusTooManyRequests
+		}
+		handleError(w, r, code, err, loggerFields...)
 		return
 	}
 
@@ -472,8 +566,8 @@ func (s *RTCService) ParseClientInfo(r *http.Request) *livekit.ClientInfo {
 	ci.BrowserVersion = values.Get("browser_version")
 	ci.DeviceModel = values.Get("device_model")
 	ci.Network = values.Get("network")
-	// get real address (forwarded http header) - check Cloudflare headers first, fall back to X-Forwarded-For
-	ci.Address = GetClientIP(r)
+	// get real address, from forwarding headers when the request comes through a trusted proxy
+	ci.Address = GetClientIP(r, s.trustedProxies)
 
 	// attempt to parse types for SDKs that support browser as a platform
 	if ci.Sdk == livekit.ClientInfo_JS ||
@@ -533,7 +627,7 @@ func (s *RTCService) startConnection(
 	var cr connectionResult
 	var created bool
 	var err error
-	cr.Room, created, err = s.roomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: string(roomName)})
+	cr.Room, created, err = s.roomAllocator.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: string(roomName)}, nil)
 	if err != nil {
 		return cr, nil, err
 	}
@@ -0,0 +1,184 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3Service       = "s3"
+	s3DateFormat    = "20060102"
+	s3TimeFormat    = "20060102T150405Z"
+	s3MaxURLExpiry  = 7 * 24 * time.Hour
+	s3UnsignedBody  = "UNSIGNED-PAYLOAD"
+	s3RequestSuffix = "aws4_request"
+)
+
+// S3Presigner signs URLs for objects of an S3 compatible bucket with signature version 4 query parameters,
+// letting clients upload and download objects without credentials of their own
+type S3Presigner struct {
+	conf     config.S3StorageConfig
+	endpoint *url.URL
+}
+
+func NewS3Presigner(conf config.S3StorageConfig) (*S3Presigner, error) {
+	if conf.Bucket == "" || conf.Region == "" || conf.AccessKey == "" || conf.Secret == "" {
+		return nil, errors.New("s3 storage requires bucket, region, access_key and secret")
+	}
+
+	endpoint := conf.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", conf.Region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+
+	return &S3Presigner{
+		conf:     conf,
+		endpoint: u,
+	}, nil
+}
+
+// PresignURL returns a URL for a request with method on the object key that is valid for expiry from now.
+// headers are signed as well, requests must set them with the same values.
+func (p *S3Presigner) PresignURL(method string, key string, headers http.Header, expiry time.Duration, now time.Time) (string, error) {
+	if expiry <= 0 || expiry > s3MaxURLExpiry {
+		return "", fmt.Errorf("s3 url expiry must be between 1s and %s", s3MaxURLExpiry)
+	}
+	now = now.UTC()
+
+	u := *p.endpoint
+	if p.conf.ForcePathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + p.conf.Bucket + "/" + key
+	} else {
+		u.Host = p.conf.Bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	}
+
+	signedHeaders := map[string]string{"host": u.Host}
+	for name, values := range headers {
+		signedHeaders[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	headerNames := make([]string, 0, len(signedHeaders))
+	for name := range signedHeaders {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(signedHeaders[name]) + "\n")
+	}
+
+	date := now.Format(s3DateFormat)
+	scope := strings.Join([]string{date, p.conf.Region, s3Service, s3RequestSuffix}, "/")
+	query := url.Values{
+		"X-Amz-Algorithm":     {s3Algorithm},
+		"X-Amz-Credential":    {p.conf.AccessKey + "/" + scope},
+		"X-Amz-Date":          {now.Format(s3TimeFormat)},
+		"X-Amz-Expires":       {strconv.Itoa(int(expiry / time.Second))},
+		"X-Amz-SignedHeaders": {strings.Join(headerNames, ";")},
+	}
+	canonicalQuery := s3EncodeQuery(query)
+	canonicalURI := s3EscapePath(u.Path)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI,
+		canonicalQuery,
+		canonicalHeaders.String(),
+		strings.Join(headerNames, ";"),
+		s3UnsignedBody,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3TimeFormat),
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	signingKey := s3HMAC([]byte("AWS4"+p.conf.Secret), date)
+	signingKey = s3HMAC(signingKey, p.conf.Region)
+	signingKey = s3HMAC(signingKey, s3Service)
+	signingKey = s3HMAC(signingKey, s3RequestSuffix)
+	signature := hex.EncodeToString(s3HMAC(signingKey, stringToSign))
+
+	u.RawPath = canonicalURI
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3EncodeQuery encodes query parameters sorted by name, escaping everything but unreserved characters
+func s3EncodeQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range query[name] {
+			params = append(params, s3Escape(name, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func s3EscapePath(path string) string {
+	return s3Escape(path, false)
+}
+
+func s3Escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
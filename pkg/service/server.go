@@ -22,21 +22,24 @@ import (
 	"net"
 	"net/http"
 	_ "net/http/pprof"
+	"reflect"
 	"runtime"
 	"runtime/pprof"
 	"strconv"
 	"time"
 
-	"github.com/pion/turn/v2"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	"github.com/twitchtv/twirp"
 	"github.com/urfave/negroni/v3"
 	"go.uber.org/atomic"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
 	"github.com/livekit/livekit-server/version"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
@@ -50,10 +53,14 @@ type LivekitServer struct {
 	agentService *AgentService
 	httpServer   *http.Server
 	promServer   *http.Server
+	adminServer  *http.Server
+	grpcServer   *grpc.Server
 	router       routing.Router
 	roomManager  *RoomManager
 	signalServer *SignalServer
-	turnServer   *turn.Server
+	turnServer   *InProcessTURNServer
+	auditLog     *AuditLog
+	analytics    telemetry.AnalyticsService
 	currentNode  routing.LocalNode
 	running      atomic.Bool
 	doneChan     chan struct{}
@@ -61,18 +68,22 @@ type LivekitServer struct {
 }
 
 func NewLivekitServer(conf *config.Config,
-	roomService livekit.RoomService,
+	roomService *RoomService,
 	egressService *EgressService,
 	ingressService *IngressService,
 	sipService *SIPService,
 	ioService *IOInfoService,
 	rtcService *RTCService,
 	agentService *AgentService,
+	roomEvents *RoomEventService,
 	keyProvider auth.KeyProvider,
+	oidcVerifier *OIDCVerifier,
 	router routing.Router,
 	roomManager *RoomManager,
 	signalServer *SignalServer,
-	turnServer *turn.Server,
+	turnServer *InProcessTURNServer,
+	auditLog *AuditLog,
+	analytics telemetry.AnalyticsService,
 	currentNode routing.LocalNode,
 ) (s *LivekitServer, err error) {
 	s = &LivekitServer{
@@ -83,8 +94,10 @@ func NewLivekitServer(conf *config.Config,
 		router:       router,
 		roomManager:  roomManager,
 		signalServer: signalServer,
-		// turn server starts automatically
+		// turn server starts automatically, unless initialized lazily
 		turnServer:  turnServer,
+		auditLog:    auditLog,
+		analytics:   analytics,
 		currentNode: currentNode,
 		closedChan:  make(chan struct{}),
 	}
@@ -102,8 +115,10 @@ func NewLivekitServer(conf *config.Config,
 			MaxAge: 86400,
 		}),
 	}
+	var authMiddleware *APIKeyAuthMiddleware
 	if keyProvider != nil {
-		middlewares = append(middlewares, NewAPIKeyAuthMiddleware(keyProvider))
+		authMiddleware = NewAPIKeyAuthMiddleware(keyProvider, oidcVerifier)
+		middlewares = append(middlewares, authMiddleware)
 	}
 
 	twirpLoggingHook := TwirpLogger()
@@ -124,13 +139,25 @@ func NewLivekitServer(conf *config.Config,
 		mux = http.DefaultServeMux
 		mux.HandleFunc("/debug/goroutine", s.debugGoroutines)
 		mux.HandleFunc("/debug/rooms", s.debugInfo)
+		mux.HandleFunc("/debug/resources", s.debugResources)
 	}
 	mux.Handle(roomServer.PathPrefix(), roomServer)
+	for _, h := range roomService.TwirpJSONHandlers(twirpLoggingHook, roomServer) {
+		mux.Handle(h.Path(), h)
+	}
 	mux.Handle(egressServer.PathPrefix(), egressServer)
+	for _, h := range egressService.TwirpJSONHandlers(twirp.ChainHooks(twirpLoggingHook, twirpRequestStatusHook)) {
+		mux.Handle(h.Path(), h)
+	}
+	restHandler := NewRESTHandler(roomService, egressService, twirp.ChainHooks(twirpLoggingHook, twirpRequestStatusHook))
+	mux.Handle(restHandler.PathPrefix(), restHandler)
 	mux.Handle(ingressServer.PathPrefix(), ingressServer)
 	mux.Handle(sipServer.PathPrefix(), sipServer)
 	mux.Handle("/rtc", rtcService)
 	mux.Handle("/agent", agentService)
+	if roomEvents.Enabled() {
+		mux.Handle("/room_events", roomEvents)
+	}
 	mux.HandleFunc("/rtc/validate", rtcService.Validate)
 	mux.HandleFunc("/", s.defaultHandler)
 
@@ -144,6 +171,24 @@ func NewLivekitServer(conf *config.Config,
 		}
 	}
 
+	if conf.GRPCPort > 0 {
+		s.grpcServer = NewRoomServiceGRPCServer(roomService, authMiddleware)
+	}
+
+	if conf.Admin.Port > 0 {
+		adminMiddlewares := []negroni.Handler{negroni.NewRecovery()}
+		if keyProvider != nil {
+			adminMiddlewares = append(adminMiddlewares, NewAPIKeyAuthMiddleware(keyProvider, oidcVerifier))
+		}
+		s.adminServer = &http.Server{
+			Handler: configureMiddlewares(NewAdminHandler(conf, roomManager, auditLog), adminMiddlewares...),
+		}
+	}
+
+	conf.OnReload(func(rc *config.ReloadableConfig) {
+		roomService.SetRoomConfig(rc.Room)
+	})
+
 	// clean up old rooms on startup
 	if err = roomManager.CleanupRooms(); err != nil {
 		return
@@ -186,8 +231,10 @@ func (s *LivekitServer) Start() error {
 		return err
 	}
 
-	if err := s.ioService.Start(); err != nil {
-		return err
+	if !s.config.Startup.LazyInit {
+		if err := s.ioService.Start(); err != nil {
+			return err
+		}
 	}
 
 	addresses := s.config.BindAddresses
@@ -198,6 +245,8 @@ func (s *LivekitServer) Start() error {
 	// ensure we could listen
 	listeners := make([]net.Listener, 0)
 	promListeners := make([]net.Listener, 0)
+	adminListeners := make([]net.Listener, 0)
+	grpcListeners := make([]net.Listener, 0)
 	for _, addr := range addresses {
 		ln, err := net.Listen("tcp", net.JoinHostPort(addr, strconv.Itoa(int(s.config.Port))))
 		if err != nil {
@@ -212,6 +261,22 @@ func (s *LivekitServer) Start() error {
 			}
 			promListeners = append(promListeners, ln)
 		}
+
+		if s.adminServer != nil {
+			ln, err = net.Listen("tcp", net.JoinHostPort(addr, strconv.Itoa(int(s.config.Admin.Port))))
+			if err != nil {
+				return err
+			}
+			adminListeners = append(adminListeners, ln)
+		}
+
+		if s.grpcServer != nil {
+			ln, err = net.Listen("tcp", net.JoinHostPort(addr, strconv.Itoa(int(s.config.GRPCPort))))
+			if err != nil {
+				return err
+			}
+			grpcListeners = append(grpcListeners, ln)
+		}
 	}
 
 	values := []interface{}{
@@ -236,6 +301,12 @@ func (s *LivekitServer) Start() error {
 	if s.config.PrometheusPort != 0 {
 		values = append(values, "portPrometheus", s.config.PrometheusPort)
 	}
+	if s.config.Admin.Port != 0 {
+		values = append(values, "portAdmin", s.config.Admin.Port)
+	}
+	if s.config.GRPCPort != 0 {
+		values = append(values, "portGrpc", s.config.GRPCPort)
+	}
 	if s.config.Region != "" {
 		values = append(values, "region", s.config.Region)
 	}
@@ -247,6 +318,12 @@ func (s *LivekitServer) Start() error {
 	for _, promLn := range promListeners {
 		go s.promServer.Serve(promLn)
 	}
+	for _, adminLn := range adminListeners {
+		go s.adminServer.Serve(adminLn)
+	}
+	for _, grpcLn := range grpcListeners {
+		go s.grpcServer.Serve(grpcLn)
+	}
 
 	if err := s.signalServer.Start(); err != nil {
 		return err
@@ -273,12 +350,22 @@ func (s *LivekitServer) Start() error {
 
 	s.running.Store(true)
 
+	if s.config.Startup.LazyInit {
+		go s.startDeferred()
+	}
+
 	<-s.doneChan
 
 	// wait for shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	_ = s.httpServer.Shutdown(ctx)
+	if s.adminServer != nil {
+		_ = s.adminServer.Shutdown(ctx)
+	}
+	if s.grpcServer != nil {
+		stopGRPCServer(ctx, s.grpcServer)
+	}
 
 	if s.turnServer != nil {
 		_ = s.turnServer.Close()
@@ -292,27 +379,93 @@ func (s *LivekitServer) Start() error {
 	return nil
 }
 
+// startDeferred starts the subsystems left out of a lazy startup, that sessions can do without for a moment
+func (s *LivekitServer) startDeferred() {
+	start := time.Now()
+	if err := s.ioService.Start(); err != nil {
+		logger.Errorw("could not start io service", err)
+		s.Stop(true)
+		return
+	}
+	if err := s.turnServer.Start(); err != nil {
+		logger.Errorw("could not start TURN server", err)
+		s.Stop(true)
+		return
+	}
+	logger.Infow("deferred subsystems started", "duration", time.Since(start))
+}
+
 func (s *LivekitServer) Stop(force bool) {
-	// wait for all participants to exit
-	s.router.Drain()
-	partTicker := time.NewTicker(5 * time.Second)
-	waitingForParticipants := !force && s.roomManager.HasParticipants()
-	for waitingForParticipants {
-		<-partTicker.C
-		logger.Infow("waiting for participants to exit")
-		waitingForParticipants = s.roomManager.HasParticipants()
+	if force {
+		// stop taking participants, those left are disconnected rather than migrated
+		s.router.Drain()
+	} else {
+		// wait for all participants to exit or move to other nodes
+		s.roomManager.Drain(0)
+		partTicker := time.NewTicker(5 * time.Second)
+	waitForParticipants:
+		for {
+			select {
+			case <-s.roomManager.DrainDone():
+				break waitForParticipants
+			case <-partTicker.C:
+				status := s.roomManager.DrainStatus()
+				logger.Infow("waiting for participants to exit or migrate",
+					"participants", status.Participants,
+					"migratedParticipants", status.MigratedParticipants,
+				)
+			}
+		}
+		partTicker.Stop()
 	}
-	partTicker.Stop()
 
 	if !s.running.Swap(false) {
 		return
 	}
 
 	s.router.Stop()
+	s.auditLog.Stop()
 	close(s.doneChan)
 
 	// wait for fully closed
 	<-s.closedChan
+	// telemetry of the rooms closed while draining is exported before the node exits
+	s.analytics.Stop()
+}
+
+// ReloadConfig applies the settings of next that can be changed while running: log levels, webhook urls,
+// room defaults, limits, TURN servers, API keys and admin settings. Rooms and sessions in progress are kept.
+func (s *LivekitServer) ReloadConfig(next *config.Config) error {
+	prevKeys := s.config.Reloadable().Keys
+	changed, ignored, err := s.config.Reload(next)
+	if err != nil {
+		return err
+	}
+	if ignored {
+		logger.Warnw("config changes other than log levels, webhook urls, room defaults, limits, turn servers, api keys and admin settings require a restart", nil)
+	}
+	if keys := s.config.Reloadable().Keys; !reflect.DeepEqual(prevKeys, keys) {
+		var added, rotated, revoked []string
+		for key, secret := range keys {
+			if prevSecret, ok := prevKeys[key]; !ok {
+				added = append(added, key)
+			} else if prevSecret != secret {
+				rotated = append(rotated, key)
+			}
+		}
+		for key := range prevKeys {
+			if _, ok := keys[key]; !ok {
+				revoked = append(revoked, key)
+			}
+		}
+		logger.Infow("api keys reloaded", "added", added, "rotated", rotated, "revoked", revoked)
+	}
+	if len(changed) == 0 {
+		logger.Infow("config reloaded, no changes")
+	} else {
+		logger.Infow("config reloaded", "changed", changed)
+	}
+	return nil
 }
 
 func (s *LivekitServer) RoomManager() *RoomManager {
@@ -340,6 +493,27 @@ func (s *LivekitServer) debugInfo(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+// debugResources reports the goroutines and timers tracked for each room, and rooms that leaked them
+func (s *LivekitServer) debugResources(w http.ResponseWriter, _ *http.Request) {
+	rooms := make(map[livekit.RoomName]sutils.ResourceCounts)
+	s.roomManager.lock.RLock()
+	for name, room := range s.roomManager.rooms {
+		rooms[name] = room.Resources().Counts()
+	}
+	s.roomManager.lock.RUnlock()
+
+	b, err := json.Marshal(map[string]interface{}{
+		"rooms":  rooms,
+		"leaked": s.roomManager.ResourceLeaks(),
+	})
+	if err != nil {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte(err.Error()))
+	} else {
+		_, _ = w.Write(b)
+	}
+}
+
 func (s *LivekitServer) defaultHandler(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path == "/" {
 		s.healthCheck(w, r)
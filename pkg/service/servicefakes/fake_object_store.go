@@ -6,11 +6,40 @@ import (
 	"sync"
 	"time"
 
+	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/protocol/livekit"
 )
 
 type FakeObjectStore struct {
+	AddAPIKeyEgressUsageStub        func(context.Context, string, string, string, time.Duration) error
+	addAPIKeyEgressUsageMutex       sync.RWMutex
+	addAPIKeyEgressUsageArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 time.Duration
+	}
+	addAPIKeyEgressUsageReturns struct {
+		result1 error
+	}
+	addAPIKeyEgressUsageReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteAttachmentStub        func(context.Context, livekit.RoomName, string) error
+	deleteAttachmentMutex       sync.RWMutex
+	deleteAttachmentArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 string
+	}
+	deleteAttachmentReturns struct {
+		result1 error
+	}
+	deleteAttachmentReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteParticipantStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error
 	deleteParticipantMutex       sync.RWMutex
 	deleteParticipantArgsForCall []struct {
@@ -24,6 +53,32 @@ type FakeObjectStore struct {
 	deleteParticipantReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteParticipantBanStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error
+	deleteParticipantBanMutex       sync.RWMutex
+	deleteParticipantBanArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}
+	deleteParticipantBanReturns struct {
+		result1 error
+	}
+	deleteParticipantBanReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeletePasscodeAttemptsStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error
+	deletePasscodeAttemptsMutex       sync.RWMutex
+	deletePasscodeAttemptsArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}
+	deletePasscodeAttemptsReturns struct {
+		result1 error
+	}
+	deletePasscodeAttemptsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteRoomStub        func(context.Context, livekit.RoomName) error
 	deleteRoomMutex       sync.RWMutex
 	deleteRoomArgsForCall []struct {
@@ -36,6 +91,51 @@ type FakeObjectStore struct {
 	deleteRoomReturnsOnCall map[int]struct {
 		result1 error
 	}
+	IncrementPasscodeAttemptsStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity, int, time.Duration) (int, error)
+	incrementPasscodeAttemptsMutex       sync.RWMutex
+	incrementPasscodeAttemptsArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+		arg4 int
+		arg5 time.Duration
+	}
+	incrementPasscodeAttemptsReturns struct {
+		result1 int
+		result2 error
+	}
+	incrementPasscodeAttemptsReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	ListAttachmentsStub        func(context.Context, livekit.RoomName) ([]*service.Attachment, error)
+	listAttachmentsMutex       sync.RWMutex
+	listAttachmentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	listAttachmentsReturns struct {
+		result1 []*service.Attachment
+		result2 error
+	}
+	listAttachmentsReturnsOnCall map[int]struct {
+		result1 []*service.Attachment
+		result2 error
+	}
+	ListParticipantBansStub        func(context.Context, livekit.RoomName) ([]*service.ParticipantBan, error)
+	listParticipantBansMutex       sync.RWMutex
+	listParticipantBansArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	listParticipantBansReturns struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}
+	listParticipantBansReturnsOnCall map[int]struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}
 	ListParticipantsStub        func(context.Context, livekit.RoomName) ([]*livekit.ParticipantInfo, error)
 	listParticipantsMutex       sync.RWMutex
 	listParticipantsArgsForCall []struct {
@@ -50,6 +150,19 @@ type FakeObjectStore struct {
 		result1 []*livekit.ParticipantInfo
 		result2 error
 	}
+	ListRoomOptionsStub        func(context.Context) (map[livekit.RoomName]*rtc.RoomOptions, error)
+	listRoomOptionsMutex       sync.RWMutex
+	listRoomOptionsArgsForCall []struct {
+		arg1 context.Context
+	}
+	listRoomOptionsReturns struct {
+		result1 map[livekit.RoomName]*rtc.RoomOptions
+		result2 error
+	}
+	listRoomOptionsReturnsOnCall map[int]struct {
+		result1 map[livekit.RoomName]*rtc.RoomOptions
+		result2 error
+	}
 	ListRoomsStub        func(context.Context, []livekit.RoomName) ([]*livekit.Room, error)
 	listRoomsMutex       sync.RWMutex
 	listRoomsArgsForCall []struct {
@@ -64,6 +177,66 @@ type FakeObjectStore struct {
 		result1 []*livekit.Room
 		result2 error
 	}
+	LoadAPIKeyEgressUsageStub        func(context.Context, string, string) (time.Duration, error)
+	loadAPIKeyEgressUsageMutex       sync.RWMutex
+	loadAPIKeyEgressUsageArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	loadAPIKeyEgressUsageReturns struct {
+		result1 time.Duration
+		result2 error
+	}
+	loadAPIKeyEgressUsageReturnsOnCall map[int]struct {
+		result1 time.Duration
+		result2 error
+	}
+	LoadAPIKeyRoomUsageStub        func(context.Context, string) (int32, int32, error)
+	loadAPIKeyRoomUsageMutex       sync.RWMutex
+	loadAPIKeyRoomUsageArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	loadAPIKeyRoomUsageReturns struct {
+		result1 int32
+		result2 int32
+		result3 error
+	}
+	loadAPIKeyRoomUsageReturnsOnCall map[int]struct {
+		result1 int32
+		result2 int32
+		result3 error
+	}
+	LoadAttachmentStub        func(context.Context, livekit.RoomName, string) (*service.Attachment, error)
+	loadAttachmentMutex       sync.RWMutex
+	loadAttachmentArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 string
+	}
+	loadAttachmentReturns struct {
+		result1 *service.Attachment
+		result2 error
+	}
+	loadAttachmentReturnsOnCall map[int]struct {
+		result1 *service.Attachment
+		result2 error
+	}
+	LoadEgressAPIKeyStub        func(context.Context, string) (string, error)
+	loadEgressAPIKeyMutex       sync.RWMutex
+	loadEgressAPIKeyArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	loadEgressAPIKeyReturns struct {
+		result1 string
+		result2 error
+	}
+	loadEgressAPIKeyReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	LoadParticipantStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error)
 	loadParticipantMutex       sync.RWMutex
 	loadParticipantArgsForCall []struct {
@@ -79,6 +252,21 @@ type FakeObjectStore struct {
 		result1 *livekit.ParticipantInfo
 		result2 error
 	}
+	LoadParticipantSessionStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) (*service.ParticipantSession, error)
+	loadParticipantSessionMutex       sync.RWMutex
+	loadParticipantSessionArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}
+	loadParticipantSessionReturns struct {
+		result1 *service.ParticipantSession
+		result2 error
+	}
+	loadParticipantSessionReturnsOnCall map[int]struct {
+		result1 *service.ParticipantSession
+		result2 error
+	}
 	LoadRoomStub        func(context.Context, livekit.RoomName, bool) (*livekit.Room, *livekit.RoomInternal, error)
 	loadRoomMutex       sync.RWMutex
 	loadRoomArgsForCall []struct {
@@ -96,6 +284,20 @@ type FakeObjectStore struct {
 		result2 *livekit.RoomInternal
 		result3 error
 	}
+	LoadRoomOptionsStub        func(context.Context, livekit.RoomName) (*rtc.RoomOptions, error)
+	loadRoomOptionsMutex       sync.RWMutex
+	loadRoomOptionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	loadRoomOptionsReturns struct {
+		result1 *rtc.RoomOptions
+		result2 error
+	}
+	loadRoomOptionsReturnsOnCall map[int]struct {
+		result1 *rtc.RoomOptions
+		result2 error
+	}
 	LockRoomStub        func(context.Context, livekit.RoomName, time.Duration) (string, error)
 	lockRoomMutex       sync.RWMutex
 	lockRoomArgsForCall []struct {
@@ -111,6 +313,31 @@ type FakeObjectStore struct {
 		result1 string
 		result2 error
 	}
+	StoreAttachmentStub        func(context.Context, *service.Attachment) error
+	storeAttachmentMutex       sync.RWMutex
+	storeAttachmentArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.Attachment
+	}
+	storeAttachmentReturns struct {
+		result1 error
+	}
+	storeAttachmentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StoreEgressAPIKeyStub        func(context.Context, string, string) error
+	storeEgressAPIKeyMutex       sync.RWMutex
+	storeEgressAPIKeyArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	storeEgressAPIKeyReturns struct {
+		result1 error
+	}
+	storeEgressAPIKeyReturnsOnCall map[int]struct {
+		result1 error
+	}
 	StoreParticipantStub        func(context.Context, livekit.RoomName, *livekit.ParticipantInfo) error
 	storeParticipantMutex       sync.RWMutex
 	storeParticipantArgsForCall []struct {
@@ -124,6 +351,30 @@ type FakeObjectStore struct {
 	storeParticipantReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StoreParticipantBanStub        func(context.Context, *service.ParticipantBan) error
+	storeParticipantBanMutex       sync.RWMutex
+	storeParticipantBanArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.ParticipantBan
+	}
+	storeParticipantBanReturns struct {
+		result1 error
+	}
+	storeParticipantBanReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StoreParticipantSessionStub        func(context.Context, *service.ParticipantSession) error
+	storeParticipantSessionMutex       sync.RWMutex
+	storeParticipantSessionArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.ParticipantSession
+	}
+	storeParticipantSessionReturns struct {
+		result1 error
+	}
+	storeParticipantSessionReturnsOnCall map[int]struct {
+		result1 error
+	}
 	StoreRoomStub        func(context.Context, *livekit.Room, *livekit.RoomInternal) error
 	storeRoomMutex       sync.RWMutex
 	storeRoomArgsForCall []struct {
@@ -137,6 +388,19 @@ type FakeObjectStore struct {
 	storeRoomReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StoreRoomOptionsStub        func(context.Context, livekit.RoomName, *rtc.RoomOptions) error
+	storeRoomOptionsMutex       sync.RWMutex
+	storeRoomOptionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 *rtc.RoomOptions
+	}
+	storeRoomOptionsReturns struct {
+		result1 error
+	}
+	storeRoomOptionsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UnlockRoomStub        func(context.Context, livekit.RoomName, string) error
 	unlockRoomMutex       sync.RWMutex
 	unlockRoomArgsForCall []struct {
@@ -154,6 +418,134 @@ type FakeObjectStore struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeObjectStore) AddAPIKeyEgressUsage(arg1 context.Context, arg2 string, arg3 string, arg4 string, arg5 time.Duration) error {
+	fake.addAPIKeyEgressUsageMutex.Lock()
+	ret, specificReturn := fake.addAPIKeyEgressUsageReturnsOnCall[len(fake.addAPIKeyEgressUsageArgsForCall)]
+	fake.addAPIKeyEgressUsageArgsForCall = append(fake.addAPIKeyEgressUsageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 time.Duration
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.AddAPIKeyEgressUsageStub
+	fakeReturns := fake.addAPIKeyEgressUsageReturns
+	fake.recordInvocation("AddAPIKeyEgressUsage", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.addAPIKeyEgressUsageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) AddAPIKeyEgressUsageCallCount() int {
+	fake.addAPIKeyEgressUsageMutex.RLock()
+	defer fake.addAPIKeyEgressUsageMutex.RUnlock()
+	return len(fake.addAPIKeyEgressUsageArgsForCall)
+}
+
+func (fake *FakeObjectStore) AddAPIKeyEgressUsageCalls(stub func(context.Context, string, string, string, time.Duration) error) {
+	fake.addAPIKeyEgressUsageMutex.Lock()
+	defer fake.addAPIKeyEgressUsageMutex.Unlock()
+	fake.AddAPIKeyEgressUsageStub = stub
+}
+
+func (fake *FakeObjectStore) AddAPIKeyEgressUsageArgsForCall(i int) (context.Context, string, string, string, time.Duration) {
+	fake.addAPIKeyEgressUsageMutex.RLock()
+	defer fake.addAPIKeyEgressUsageMutex.RUnlock()
+	argsForCall := fake.addAPIKeyEgressUsageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeObjectStore) AddAPIKeyEgressUsageReturns(result1 error) {
+	fake.addAPIKeyEgressUsageMutex.Lock()
+	defer fake.addAPIKeyEgressUsageMutex.Unlock()
+	fake.AddAPIKeyEgressUsageStub = nil
+	fake.addAPIKeyEgressUsageReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) AddAPIKeyEgressUsageReturnsOnCall(i int, result1 error) {
+	fake.addAPIKeyEgressUsageMutex.Lock()
+	defer fake.addAPIKeyEgressUsageMutex.Unlock()
+	fake.AddAPIKeyEgressUsageStub = nil
+	if fake.addAPIKeyEgressUsageReturnsOnCall == nil {
+		fake.addAPIKeyEgressUsageReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addAPIKeyEgressUsageReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteAttachment(arg1 context.Context, arg2 livekit.RoomName, arg3 string) error {
+	fake.deleteAttachmentMutex.Lock()
+	ret, specificReturn := fake.deleteAttachmentReturnsOnCall[len(fake.deleteAttachmentArgsForCall)]
+	fake.deleteAttachmentArgsForCall = append(fake.deleteAttachmentArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteAttachmentStub
+	fakeReturns := fake.deleteAttachmentReturns
+	fake.recordInvocation("DeleteAttachment", []interface{}{arg1, arg2, arg3})
+	fake.deleteAttachmentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) DeleteAttachmentCallCount() int {
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	return len(fake.deleteAttachmentArgsForCall)
+}
+
+func (fake *FakeObjectStore) DeleteAttachmentCalls(stub func(context.Context, livekit.RoomName, string) error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = stub
+}
+
+func (fake *FakeObjectStore) DeleteAttachmentArgsForCall(i int) (context.Context, livekit.RoomName, string) {
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	argsForCall := fake.deleteAttachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) DeleteAttachmentReturns(result1 error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = nil
+	fake.deleteAttachmentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteAttachmentReturnsOnCall(i int, result1 error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = nil
+	if fake.deleteAttachmentReturnsOnCall == nil {
+		fake.deleteAttachmentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteAttachmentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) DeleteParticipant(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) error {
 	fake.deleteParticipantMutex.Lock()
 	ret, specificReturn := fake.deleteParticipantReturnsOnCall[len(fake.deleteParticipantArgsForCall)]
@@ -217,6 +609,132 @@ func (fake *FakeObjectStore) DeleteParticipantReturnsOnCall(i int, result1 error
 	}{result1}
 }
 
+func (fake *FakeObjectStore) DeleteParticipantBan(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) error {
+	fake.deleteParticipantBanMutex.Lock()
+	ret, specificReturn := fake.deleteParticipantBanReturnsOnCall[len(fake.deleteParticipantBanArgsForCall)]
+	fake.deleteParticipantBanArgsForCall = append(fake.deleteParticipantBanArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteParticipantBanStub
+	fakeReturns := fake.deleteParticipantBanReturns
+	fake.recordInvocation("DeleteParticipantBan", []interface{}{arg1, arg2, arg3})
+	fake.deleteParticipantBanMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) DeleteParticipantBanCallCount() int {
+	fake.deleteParticipantBanMutex.RLock()
+	defer fake.deleteParticipantBanMutex.RUnlock()
+	return len(fake.deleteParticipantBanArgsForCall)
+}
+
+func (fake *FakeObjectStore) DeleteParticipantBanCalls(stub func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error) {
+	fake.deleteParticipantBanMutex.Lock()
+	defer fake.deleteParticipantBanMutex.Unlock()
+	fake.DeleteParticipantBanStub = stub
+}
+
+func (fake *FakeObjectStore) DeleteParticipantBanArgsForCall(i int) (context.Context, livekit.RoomName, livekit.ParticipantIdentity) {
+	fake.deleteParticipantBanMutex.RLock()
+	defer fake.deleteParticipantBanMutex.RUnlock()
+	argsForCall := fake.deleteParticipantBanArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) DeleteParticipantBanReturns(result1 error) {
+	fake.deleteParticipantBanMutex.Lock()
+	defer fake.deleteParticipantBanMutex.Unlock()
+	fake.DeleteParticipantBanStub = nil
+	fake.deleteParticipantBanReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteParticipantBanReturnsOnCall(i int, result1 error) {
+	fake.deleteParticipantBanMutex.Lock()
+	defer fake.deleteParticipantBanMutex.Unlock()
+	fake.DeleteParticipantBanStub = nil
+	if fake.deleteParticipantBanReturnsOnCall == nil {
+		fake.deleteParticipantBanReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteParticipantBanReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeletePasscodeAttempts(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) error {
+	fake.deletePasscodeAttemptsMutex.Lock()
+	ret, specificReturn := fake.deletePasscodeAttemptsReturnsOnCall[len(fake.deletePasscodeAttemptsArgsForCall)]
+	fake.deletePasscodeAttemptsArgsForCall = append(fake.deletePasscodeAttemptsArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}{arg1, arg2, arg3})
+	stub := fake.DeletePasscodeAttemptsStub
+	fakeReturns := fake.deletePasscodeAttemptsReturns
+	fake.recordInvocation("DeletePasscodeAttempts", []interface{}{arg1, arg2, arg3})
+	fake.deletePasscodeAttemptsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) DeletePasscodeAttemptsCallCount() int {
+	fake.deletePasscodeAttemptsMutex.RLock()
+	defer fake.deletePasscodeAttemptsMutex.RUnlock()
+	return len(fake.deletePasscodeAttemptsArgsForCall)
+}
+
+func (fake *FakeObjectStore) DeletePasscodeAttemptsCalls(stub func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error) {
+	fake.deletePasscodeAttemptsMutex.Lock()
+	defer fake.deletePasscodeAttemptsMutex.Unlock()
+	fake.DeletePasscodeAttemptsStub = stub
+}
+
+func (fake *FakeObjectStore) DeletePasscodeAttemptsArgsForCall(i int) (context.Context, livekit.RoomName, livekit.ParticipantIdentity) {
+	fake.deletePasscodeAttemptsMutex.RLock()
+	defer fake.deletePasscodeAttemptsMutex.RUnlock()
+	argsForCall := fake.deletePasscodeAttemptsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) DeletePasscodeAttemptsReturns(result1 error) {
+	fake.deletePasscodeAttemptsMutex.Lock()
+	defer fake.deletePasscodeAttemptsMutex.Unlock()
+	fake.DeletePasscodeAttemptsStub = nil
+	fake.deletePasscodeAttemptsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeletePasscodeAttemptsReturnsOnCall(i int, result1 error) {
+	fake.deletePasscodeAttemptsMutex.Lock()
+	defer fake.deletePasscodeAttemptsMutex.Unlock()
+	fake.DeletePasscodeAttemptsStub = nil
+	if fake.deletePasscodeAttemptsReturnsOnCall == nil {
+		fake.deletePasscodeAttemptsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deletePasscodeAttemptsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) DeleteRoom(arg1 context.Context, arg2 livekit.RoomName) error {
 	fake.deleteRoomMutex.Lock()
 	ret, specificReturn := fake.deleteRoomReturnsOnCall[len(fake.deleteRoomArgsForCall)]
@@ -279,7 +797,205 @@ func (fake *FakeObjectStore) DeleteRoomReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeObjectStore) ListParticipants(arg1 context.Context, arg2 livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
+func (fake *FakeObjectStore) IncrementPasscodeAttempts(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity, arg4 int, arg5 time.Duration) (int, error) {
+	fake.incrementPasscodeAttemptsMutex.Lock()
+	ret, specificReturn := fake.incrementPasscodeAttemptsReturnsOnCall[len(fake.incrementPasscodeAttemptsArgsForCall)]
+	fake.incrementPasscodeAttemptsArgsForCall = append(fake.incrementPasscodeAttemptsArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+		arg4 int
+		arg5 time.Duration
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.IncrementPasscodeAttemptsStub
+	fakeReturns := fake.incrementPasscodeAttemptsReturns
+	fake.recordInvocation("IncrementPasscodeAttempts", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.incrementPasscodeAttemptsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) IncrementPasscodeAttemptsCallCount() int {
+	fake.incrementPasscodeAttemptsMutex.RLock()
+	defer fake.incrementPasscodeAttemptsMutex.RUnlock()
+	return len(fake.incrementPasscodeAttemptsArgsForCall)
+}
+
+func (fake *FakeObjectStore) IncrementPasscodeAttemptsCalls(stub func(context.Context, livekit.RoomName, livekit.ParticipantIdentity, int, time.Duration) (int, error)) {
+	fake.incrementPasscodeAttemptsMutex.Lock()
+	defer fake.incrementPasscodeAttemptsMutex.Unlock()
+	fake.IncrementPasscodeAttemptsStub = stub
+}
+
+func (fake *FakeObjectStore) IncrementPasscodeAttemptsArgsForCall(i int) (context.Context, livekit.RoomName, livekit.ParticipantIdentity, int, time.Duration) {
+	fake.incrementPasscodeAttemptsMutex.RLock()
+	defer fake.incrementPasscodeAttemptsMutex.RUnlock()
+	argsForCall := fake.incrementPasscodeAttemptsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeObjectStore) IncrementPasscodeAttemptsReturns(result1 int, result2 error) {
+	fake.incrementPasscodeAttemptsMutex.Lock()
+	defer fake.incrementPasscodeAttemptsMutex.Unlock()
+	fake.IncrementPasscodeAttemptsStub = nil
+	fake.incrementPasscodeAttemptsReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) IncrementPasscodeAttemptsReturnsOnCall(i int, result1 int, result2 error) {
+	fake.incrementPasscodeAttemptsMutex.Lock()
+	defer fake.incrementPasscodeAttemptsMutex.Unlock()
+	fake.IncrementPasscodeAttemptsStub = nil
+	if fake.incrementPasscodeAttemptsReturnsOnCall == nil {
+		fake.incrementPasscodeAttemptsReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.incrementPasscodeAttemptsReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListAttachments(arg1 context.Context, arg2 livekit.RoomName) ([]*service.Attachment, error) {
+	fake.listAttachmentsMutex.Lock()
+	ret, specificReturn := fake.listAttachmentsReturnsOnCall[len(fake.listAttachmentsArgsForCall)]
+	fake.listAttachmentsArgsForCall = append(fake.listAttachmentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.ListAttachmentsStub
+	fakeReturns := fake.listAttachmentsReturns
+	fake.recordInvocation("ListAttachments", []interface{}{arg1, arg2})
+	fake.listAttachmentsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) ListAttachmentsCallCount() int {
+	fake.listAttachmentsMutex.RLock()
+	defer fake.listAttachmentsMutex.RUnlock()
+	return len(fake.listAttachmentsArgsForCall)
+}
+
+func (fake *FakeObjectStore) ListAttachmentsCalls(stub func(context.Context, livekit.RoomName) ([]*service.Attachment, error)) {
+	fake.listAttachmentsMutex.Lock()
+	defer fake.listAttachmentsMutex.Unlock()
+	fake.ListAttachmentsStub = stub
+}
+
+func (fake *FakeObjectStore) ListAttachmentsArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.listAttachmentsMutex.RLock()
+	defer fake.listAttachmentsMutex.RUnlock()
+	argsForCall := fake.listAttachmentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) ListAttachmentsReturns(result1 []*service.Attachment, result2 error) {
+	fake.listAttachmentsMutex.Lock()
+	defer fake.listAttachmentsMutex.Unlock()
+	fake.ListAttachmentsStub = nil
+	fake.listAttachmentsReturns = struct {
+		result1 []*service.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListAttachmentsReturnsOnCall(i int, result1 []*service.Attachment, result2 error) {
+	fake.listAttachmentsMutex.Lock()
+	defer fake.listAttachmentsMutex.Unlock()
+	fake.ListAttachmentsStub = nil
+	if fake.listAttachmentsReturnsOnCall == nil {
+		fake.listAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 []*service.Attachment
+			result2 error
+		})
+	}
+	fake.listAttachmentsReturnsOnCall[i] = struct {
+		result1 []*service.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListParticipantBans(arg1 context.Context, arg2 livekit.RoomName) ([]*service.ParticipantBan, error) {
+	fake.listParticipantBansMutex.Lock()
+	ret, specificReturn := fake.listParticipantBansReturnsOnCall[len(fake.listParticipantBansArgsForCall)]
+	fake.listParticipantBansArgsForCall = append(fake.listParticipantBansArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.ListParticipantBansStub
+	fakeReturns := fake.listParticipantBansReturns
+	fake.recordInvocation("ListParticipantBans", []interface{}{arg1, arg2})
+	fake.listParticipantBansMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) ListParticipantBansCallCount() int {
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
+	return len(fake.listParticipantBansArgsForCall)
+}
+
+func (fake *FakeObjectStore) ListParticipantBansCalls(stub func(context.Context, livekit.RoomName) ([]*service.ParticipantBan, error)) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = stub
+}
+
+func (fake *FakeObjectStore) ListParticipantBansArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
+	argsForCall := fake.listParticipantBansArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) ListParticipantBansReturns(result1 []*service.ParticipantBan, result2 error) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = nil
+	fake.listParticipantBansReturns = struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListParticipantBansReturnsOnCall(i int, result1 []*service.ParticipantBan, result2 error) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = nil
+	if fake.listParticipantBansReturnsOnCall == nil {
+		fake.listParticipantBansReturnsOnCall = make(map[int]struct {
+			result1 []*service.ParticipantBan
+			result2 error
+		})
+	}
+	fake.listParticipantBansReturnsOnCall[i] = struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListParticipants(arg1 context.Context, arg2 livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
 	fake.listParticipantsMutex.Lock()
 	ret, specificReturn := fake.listParticipantsReturnsOnCall[len(fake.listParticipantsArgsForCall)]
 	fake.listParticipantsArgsForCall = append(fake.listParticipantsArgsForCall, struct {
@@ -338,28 +1054,357 @@ func (fake *FakeObjectStore) ListParticipantsReturnsOnCall(i int, result1 []*liv
 			result2 error
 		})
 	}
-	fake.listParticipantsReturnsOnCall[i] = struct {
-		result1 []*livekit.ParticipantInfo
+	fake.listParticipantsReturnsOnCall[i] = struct {
+		result1 []*livekit.ParticipantInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListRoomOptions(arg1 context.Context) (map[livekit.RoomName]*rtc.RoomOptions, error) {
+	fake.listRoomOptionsMutex.Lock()
+	ret, specificReturn := fake.listRoomOptionsReturnsOnCall[len(fake.listRoomOptionsArgsForCall)]
+	fake.listRoomOptionsArgsForCall = append(fake.listRoomOptionsArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.ListRoomOptionsStub
+	fakeReturns := fake.listRoomOptionsReturns
+	fake.recordInvocation("ListRoomOptions", []interface{}{arg1})
+	fake.listRoomOptionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) ListRoomOptionsCallCount() int {
+	fake.listRoomOptionsMutex.RLock()
+	defer fake.listRoomOptionsMutex.RUnlock()
+	return len(fake.listRoomOptionsArgsForCall)
+}
+
+func (fake *FakeObjectStore) ListRoomOptionsCalls(stub func(context.Context) (map[livekit.RoomName]*rtc.RoomOptions, error)) {
+	fake.listRoomOptionsMutex.Lock()
+	defer fake.listRoomOptionsMutex.Unlock()
+	fake.ListRoomOptionsStub = stub
+}
+
+func (fake *FakeObjectStore) ListRoomOptionsArgsForCall(i int) context.Context {
+	fake.listRoomOptionsMutex.RLock()
+	defer fake.listRoomOptionsMutex.RUnlock()
+	argsForCall := fake.listRoomOptionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeObjectStore) ListRoomOptionsReturns(result1 map[livekit.RoomName]*rtc.RoomOptions, result2 error) {
+	fake.listRoomOptionsMutex.Lock()
+	defer fake.listRoomOptionsMutex.Unlock()
+	fake.ListRoomOptionsStub = nil
+	fake.listRoomOptionsReturns = struct {
+		result1 map[livekit.RoomName]*rtc.RoomOptions
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListRoomOptionsReturnsOnCall(i int, result1 map[livekit.RoomName]*rtc.RoomOptions, result2 error) {
+	fake.listRoomOptionsMutex.Lock()
+	defer fake.listRoomOptionsMutex.Unlock()
+	fake.ListRoomOptionsStub = nil
+	if fake.listRoomOptionsReturnsOnCall == nil {
+		fake.listRoomOptionsReturnsOnCall = make(map[int]struct {
+			result1 map[livekit.RoomName]*rtc.RoomOptions
+			result2 error
+		})
+	}
+	fake.listRoomOptionsReturnsOnCall[i] = struct {
+		result1 map[livekit.RoomName]*rtc.RoomOptions
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListRooms(arg1 context.Context, arg2 []livekit.RoomName) ([]*livekit.Room, error) {
+	var arg2Copy []livekit.RoomName
+	if arg2 != nil {
+		arg2Copy = make([]livekit.RoomName, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.listRoomsMutex.Lock()
+	ret, specificReturn := fake.listRoomsReturnsOnCall[len(fake.listRoomsArgsForCall)]
+	fake.listRoomsArgsForCall = append(fake.listRoomsArgsForCall, struct {
+		arg1 context.Context
+		arg2 []livekit.RoomName
+	}{arg1, arg2Copy})
+	stub := fake.ListRoomsStub
+	fakeReturns := fake.listRoomsReturns
+	fake.recordInvocation("ListRooms", []interface{}{arg1, arg2Copy})
+	fake.listRoomsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) ListRoomsCallCount() int {
+	fake.listRoomsMutex.RLock()
+	defer fake.listRoomsMutex.RUnlock()
+	return len(fake.listRoomsArgsForCall)
+}
+
+func (fake *FakeObjectStore) ListRoomsCalls(stub func(context.Context, []livekit.RoomName) ([]*livekit.Room, error)) {
+	fake.listRoomsMutex.Lock()
+	defer fake.listRoomsMutex.Unlock()
+	fake.ListRoomsStub = stub
+}
+
+func (fake *FakeObjectStore) ListRoomsArgsForCall(i int) (context.Context, []livekit.RoomName) {
+	fake.listRoomsMutex.RLock()
+	defer fake.listRoomsMutex.RUnlock()
+	argsForCall := fake.listRoomsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) ListRoomsReturns(result1 []*livekit.Room, result2 error) {
+	fake.listRoomsMutex.Lock()
+	defer fake.listRoomsMutex.Unlock()
+	fake.ListRoomsStub = nil
+	fake.listRoomsReturns = struct {
+		result1 []*livekit.Room
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) ListRoomsReturnsOnCall(i int, result1 []*livekit.Room, result2 error) {
+	fake.listRoomsMutex.Lock()
+	defer fake.listRoomsMutex.Unlock()
+	fake.ListRoomsStub = nil
+	if fake.listRoomsReturnsOnCall == nil {
+		fake.listRoomsReturnsOnCall = make(map[int]struct {
+			result1 []*livekit.Room
+			result2 error
+		})
+	}
+	fake.listRoomsReturnsOnCall[i] = struct {
+		result1 []*livekit.Room
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyEgressUsage(arg1 context.Context, arg2 string, arg3 string) (time.Duration, error) {
+	fake.loadAPIKeyEgressUsageMutex.Lock()
+	ret, specificReturn := fake.loadAPIKeyEgressUsageReturnsOnCall[len(fake.loadAPIKeyEgressUsageArgsForCall)]
+	fake.loadAPIKeyEgressUsageArgsForCall = append(fake.loadAPIKeyEgressUsageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.LoadAPIKeyEgressUsageStub
+	fakeReturns := fake.loadAPIKeyEgressUsageReturns
+	fake.recordInvocation("LoadAPIKeyEgressUsage", []interface{}{arg1, arg2, arg3})
+	fake.loadAPIKeyEgressUsageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyEgressUsageCallCount() int {
+	fake.loadAPIKeyEgressUsageMutex.RLock()
+	defer fake.loadAPIKeyEgressUsageMutex.RUnlock()
+	return len(fake.loadAPIKeyEgressUsageArgsForCall)
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyEgressUsageCalls(stub func(context.Context, string, string) (time.Duration, error)) {
+	fake.loadAPIKeyEgressUsageMutex.Lock()
+	defer fake.loadAPIKeyEgressUsageMutex.Unlock()
+	fake.LoadAPIKeyEgressUsageStub = stub
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyEgressUsageArgsForCall(i int) (context.Context, string, string) {
+	fake.loadAPIKeyEgressUsageMutex.RLock()
+	defer fake.loadAPIKeyEgressUsageMutex.RUnlock()
+	argsForCall := fake.loadAPIKeyEgressUsageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyEgressUsageReturns(result1 time.Duration, result2 error) {
+	fake.loadAPIKeyEgressUsageMutex.Lock()
+	defer fake.loadAPIKeyEgressUsageMutex.Unlock()
+	fake.LoadAPIKeyEgressUsageStub = nil
+	fake.loadAPIKeyEgressUsageReturns = struct {
+		result1 time.Duration
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyEgressUsageReturnsOnCall(i int, result1 time.Duration, result2 error) {
+	fake.loadAPIKeyEgressUsageMutex.Lock()
+	defer fake.loadAPIKeyEgressUsageMutex.Unlock()
+	fake.LoadAPIKeyEgressUsageStub = nil
+	if fake.loadAPIKeyEgressUsageReturnsOnCall == nil {
+		fake.loadAPIKeyEgressUsageReturnsOnCall = make(map[int]struct {
+			result1 time.Duration
+			result2 error
+		})
+	}
+	fake.loadAPIKeyEgressUsageReturnsOnCall[i] = struct {
+		result1 time.Duration
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyRoomUsage(arg1 context.Context, arg2 string) (int32, int32, error) {
+	fake.loadAPIKeyRoomUsageMutex.Lock()
+	ret, specificReturn := fake.loadAPIKeyRoomUsageReturnsOnCall[len(fake.loadAPIKeyRoomUsageArgsForCall)]
+	fake.loadAPIKeyRoomUsageArgsForCall = append(fake.loadAPIKeyRoomUsageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.LoadAPIKeyRoomUsageStub
+	fakeReturns := fake.loadAPIKeyRoomUsageReturns
+	fake.recordInvocation("LoadAPIKeyRoomUsage", []interface{}{arg1, arg2})
+	fake.loadAPIKeyRoomUsageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyRoomUsageCallCount() int {
+	fake.loadAPIKeyRoomUsageMutex.RLock()
+	defer fake.loadAPIKeyRoomUsageMutex.RUnlock()
+	return len(fake.loadAPIKeyRoomUsageArgsForCall)
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyRoomUsageCalls(stub func(context.Context, string) (int32, int32, error)) {
+	fake.loadAPIKeyRoomUsageMutex.Lock()
+	defer fake.loadAPIKeyRoomUsageMutex.Unlock()
+	fake.LoadAPIKeyRoomUsageStub = stub
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyRoomUsageArgsForCall(i int) (context.Context, string) {
+	fake.loadAPIKeyRoomUsageMutex.RLock()
+	defer fake.loadAPIKeyRoomUsageMutex.RUnlock()
+	argsForCall := fake.loadAPIKeyRoomUsageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyRoomUsageReturns(result1 int32, result2 int32, result3 error) {
+	fake.loadAPIKeyRoomUsageMutex.Lock()
+	defer fake.loadAPIKeyRoomUsageMutex.Unlock()
+	fake.LoadAPIKeyRoomUsageStub = nil
+	fake.loadAPIKeyRoomUsageReturns = struct {
+		result1 int32
+		result2 int32
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeObjectStore) LoadAPIKeyRoomUsageReturnsOnCall(i int, result1 int32, result2 int32, result3 error) {
+	fake.loadAPIKeyRoomUsageMutex.Lock()
+	defer fake.loadAPIKeyRoomUsageMutex.Unlock()
+	fake.LoadAPIKeyRoomUsageStub = nil
+	if fake.loadAPIKeyRoomUsageReturnsOnCall == nil {
+		fake.loadAPIKeyRoomUsageReturnsOnCall = make(map[int]struct {
+			result1 int32
+			result2 int32
+			result3 error
+		})
+	}
+	fake.loadAPIKeyRoomUsageReturnsOnCall[i] = struct {
+		result1 int32
+		result2 int32
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeObjectStore) LoadAttachment(arg1 context.Context, arg2 livekit.RoomName, arg3 string) (*service.Attachment, error) {
+	fake.loadAttachmentMutex.Lock()
+	ret, specificReturn := fake.loadAttachmentReturnsOnCall[len(fake.loadAttachmentArgsForCall)]
+	fake.loadAttachmentArgsForCall = append(fake.loadAttachmentArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.LoadAttachmentStub
+	fakeReturns := fake.loadAttachmentReturns
+	fake.recordInvocation("LoadAttachment", []interface{}{arg1, arg2, arg3})
+	fake.loadAttachmentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) LoadAttachmentCallCount() int {
+	fake.loadAttachmentMutex.RLock()
+	defer fake.loadAttachmentMutex.RUnlock()
+	return len(fake.loadAttachmentArgsForCall)
+}
+
+func (fake *FakeObjectStore) LoadAttachmentCalls(stub func(context.Context, livekit.RoomName, string) (*service.Attachment, error)) {
+	fake.loadAttachmentMutex.Lock()
+	defer fake.loadAttachmentMutex.Unlock()
+	fake.LoadAttachmentStub = stub
+}
+
+func (fake *FakeObjectStore) LoadAttachmentArgsForCall(i int) (context.Context, livekit.RoomName, string) {
+	fake.loadAttachmentMutex.RLock()
+	defer fake.loadAttachmentMutex.RUnlock()
+	argsForCall := fake.loadAttachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) LoadAttachmentReturns(result1 *service.Attachment, result2 error) {
+	fake.loadAttachmentMutex.Lock()
+	defer fake.loadAttachmentMutex.Unlock()
+	fake.LoadAttachmentStub = nil
+	fake.loadAttachmentReturns = struct {
+		result1 *service.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadAttachmentReturnsOnCall(i int, result1 *service.Attachment, result2 error) {
+	fake.loadAttachmentMutex.Lock()
+	defer fake.loadAttachmentMutex.Unlock()
+	fake.LoadAttachmentStub = nil
+	if fake.loadAttachmentReturnsOnCall == nil {
+		fake.loadAttachmentReturnsOnCall = make(map[int]struct {
+			result1 *service.Attachment
+			result2 error
+		})
+	}
+	fake.loadAttachmentReturnsOnCall[i] = struct {
+		result1 *service.Attachment
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeObjectStore) ListRooms(arg1 context.Context, arg2 []livekit.RoomName) ([]*livekit.Room, error) {
-	var arg2Copy []livekit.RoomName
-	if arg2 != nil {
-		arg2Copy = make([]livekit.RoomName, len(arg2))
-		copy(arg2Copy, arg2)
-	}
-	fake.listRoomsMutex.Lock()
-	ret, specificReturn := fake.listRoomsReturnsOnCall[len(fake.listRoomsArgsForCall)]
-	fake.listRoomsArgsForCall = append(fake.listRoomsArgsForCall, struct {
+func (fake *FakeObjectStore) LoadEgressAPIKey(arg1 context.Context, arg2 string) (string, error) {
+	fake.loadEgressAPIKeyMutex.Lock()
+	ret, specificReturn := fake.loadEgressAPIKeyReturnsOnCall[len(fake.loadEgressAPIKeyArgsForCall)]
+	fake.loadEgressAPIKeyArgsForCall = append(fake.loadEgressAPIKeyArgsForCall, struct {
 		arg1 context.Context
-		arg2 []livekit.RoomName
-	}{arg1, arg2Copy})
-	stub := fake.ListRoomsStub
-	fakeReturns := fake.listRoomsReturns
-	fake.recordInvocation("ListRooms", []interface{}{arg1, arg2Copy})
-	fake.listRoomsMutex.Unlock()
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.LoadEgressAPIKeyStub
+	fakeReturns := fake.loadEgressAPIKeyReturns
+	fake.recordInvocation("LoadEgressAPIKey", []interface{}{arg1, arg2})
+	fake.loadEgressAPIKeyMutex.Unlock()
 	if stub != nil {
 		return stub(arg1, arg2)
 	}
@@ -369,47 +1414,47 @@ func (fake *FakeObjectStore) ListRooms(arg1 context.Context, arg2 []livekit.Room
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeObjectStore) ListRoomsCallCount() int {
-	fake.listRoomsMutex.RLock()
-	defer fake.listRoomsMutex.RUnlock()
-	return len(fake.listRoomsArgsForCall)
+func (fake *FakeObjectStore) LoadEgressAPIKeyCallCount() int {
+	fake.loadEgressAPIKeyMutex.RLock()
+	defer fake.loadEgressAPIKeyMutex.RUnlock()
+	return len(fake.loadEgressAPIKeyArgsForCall)
 }
 
-func (fake *FakeObjectStore) ListRoomsCalls(stub func(context.Context, []livekit.RoomName) ([]*livekit.Room, error)) {
-	fake.listRoomsMutex.Lock()
-	defer fake.listRoomsMutex.Unlock()
-	fake.ListRoomsStub = stub
+func (fake *FakeObjectStore) LoadEgressAPIKeyCalls(stub func(context.Context, string) (string, error)) {
+	fake.loadEgressAPIKeyMutex.Lock()
+	defer fake.loadEgressAPIKeyMutex.Unlock()
+	fake.LoadEgressAPIKeyStub = stub
 }
 
-func (fake *FakeObjectStore) ListRoomsArgsForCall(i int) (context.Context, []livekit.RoomName) {
-	fake.listRoomsMutex.RLock()
-	defer fake.listRoomsMutex.RUnlock()
-	argsForCall := fake.listRoomsArgsForCall[i]
+func (fake *FakeObjectStore) LoadEgressAPIKeyArgsForCall(i int) (context.Context, string) {
+	fake.loadEgressAPIKeyMutex.RLock()
+	defer fake.loadEgressAPIKeyMutex.RUnlock()
+	argsForCall := fake.loadEgressAPIKeyArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeObjectStore) ListRoomsReturns(result1 []*livekit.Room, result2 error) {
-	fake.listRoomsMutex.Lock()
-	defer fake.listRoomsMutex.Unlock()
-	fake.ListRoomsStub = nil
-	fake.listRoomsReturns = struct {
-		result1 []*livekit.Room
+func (fake *FakeObjectStore) LoadEgressAPIKeyReturns(result1 string, result2 error) {
+	fake.loadEgressAPIKeyMutex.Lock()
+	defer fake.loadEgressAPIKeyMutex.Unlock()
+	fake.LoadEgressAPIKeyStub = nil
+	fake.loadEgressAPIKeyReturns = struct {
+		result1 string
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeObjectStore) ListRoomsReturnsOnCall(i int, result1 []*livekit.Room, result2 error) {
-	fake.listRoomsMutex.Lock()
-	defer fake.listRoomsMutex.Unlock()
-	fake.ListRoomsStub = nil
-	if fake.listRoomsReturnsOnCall == nil {
-		fake.listRoomsReturnsOnCall = make(map[int]struct {
-			result1 []*livekit.Room
+func (fake *FakeObjectStore) LoadEgressAPIKeyReturnsOnCall(i int, result1 string, result2 error) {
+	fake.loadEgressAPIKeyMutex.Lock()
+	defer fake.loadEgressAPIKeyMutex.Unlock()
+	fake.LoadEgressAPIKeyStub = nil
+	if fake.loadEgressAPIKeyReturnsOnCall == nil {
+		fake.loadEgressAPIKeyReturnsOnCall = make(map[int]struct {
+			result1 string
 			result2 error
 		})
 	}
-	fake.listRoomsReturnsOnCall[i] = struct {
-		result1 []*livekit.Room
+	fake.loadEgressAPIKeyReturnsOnCall[i] = struct {
+		result1 string
 		result2 error
 	}{result1, result2}
 }
@@ -480,6 +1525,72 @@ func (fake *FakeObjectStore) LoadParticipantReturnsOnCall(i int, result1 *liveki
 	}{result1, result2}
 }
 
+func (fake *FakeObjectStore) LoadParticipantSession(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) (*service.ParticipantSession, error) {
+	fake.loadParticipantSessionMutex.Lock()
+	ret, specificReturn := fake.loadParticipantSessionReturnsOnCall[len(fake.loadParticipantSessionArgsForCall)]
+	fake.loadParticipantSessionArgsForCall = append(fake.loadParticipantSessionArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}{arg1, arg2, arg3})
+	stub := fake.LoadParticipantSessionStub
+	fakeReturns := fake.loadParticipantSessionReturns
+	fake.recordInvocation("LoadParticipantSession", []interface{}{arg1, arg2, arg3})
+	fake.loadParticipantSessionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) LoadParticipantSessionCallCount() int {
+	fake.loadParticipantSessionMutex.RLock()
+	defer fake.loadParticipantSessionMutex.RUnlock()
+	return len(fake.loadParticipantSessionArgsForCall)
+}
+
+func (fake *FakeObjectStore) LoadParticipantSessionCalls(stub func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) (*service.ParticipantSession, error)) {
+	fake.loadParticipantSessionMutex.Lock()
+	defer fake.loadParticipantSessionMutex.Unlock()
+	fake.LoadParticipantSessionStub = stub
+}
+
+func (fake *FakeObjectStore) LoadParticipantSessionArgsForCall(i int) (context.Context, livekit.RoomName, livekit.ParticipantIdentity) {
+	fake.loadParticipantSessionMutex.RLock()
+	defer fake.loadParticipantSessionMutex.RUnlock()
+	argsForCall := fake.loadParticipantSessionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) LoadParticipantSessionReturns(result1 *service.ParticipantSession, result2 error) {
+	fake.loadParticipantSessionMutex.Lock()
+	defer fake.loadParticipantSessionMutex.Unlock()
+	fake.LoadParticipantSessionStub = nil
+	fake.loadParticipantSessionReturns = struct {
+		result1 *service.ParticipantSession
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadParticipantSessionReturnsOnCall(i int, result1 *service.ParticipantSession, result2 error) {
+	fake.loadParticipantSessionMutex.Lock()
+	defer fake.loadParticipantSessionMutex.Unlock()
+	fake.LoadParticipantSessionStub = nil
+	if fake.loadParticipantSessionReturnsOnCall == nil {
+		fake.loadParticipantSessionReturnsOnCall = make(map[int]struct {
+			result1 *service.ParticipantSession
+			result2 error
+		})
+	}
+	fake.loadParticipantSessionReturnsOnCall[i] = struct {
+		result1 *service.ParticipantSession
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeObjectStore) LoadRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 bool) (*livekit.Room, *livekit.RoomInternal, error) {
 	fake.loadRoomMutex.Lock()
 	ret, specificReturn := fake.loadRoomReturnsOnCall[len(fake.loadRoomArgsForCall)]
@@ -549,6 +1660,71 @@ func (fake *FakeObjectStore) LoadRoomReturnsOnCall(i int, result1 *livekit.Room,
 	}{result1, result2, result3}
 }
 
+func (fake *FakeObjectStore) LoadRoomOptions(arg1 context.Context, arg2 livekit.RoomName) (*rtc.RoomOptions, error) {
+	fake.loadRoomOptionsMutex.Lock()
+	ret, specificReturn := fake.loadRoomOptionsReturnsOnCall[len(fake.loadRoomOptionsArgsForCall)]
+	fake.loadRoomOptionsArgsForCall = append(fake.loadRoomOptionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.LoadRoomOptionsStub
+	fakeReturns := fake.loadRoomOptionsReturns
+	fake.recordInvocation("LoadRoomOptions", []interface{}{arg1, arg2})
+	fake.loadRoomOptionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) LoadRoomOptionsCallCount() int {
+	fake.loadRoomOptionsMutex.RLock()
+	defer fake.loadRoomOptionsMutex.RUnlock()
+	return len(fake.loadRoomOptionsArgsForCall)
+}
+
+func (fake *FakeObjectStore) LoadRoomOptionsCalls(stub func(context.Context, livekit.RoomName) (*rtc.RoomOptions, error)) {
+	fake.loadRoomOptionsMutex.Lock()
+	defer fake.loadRoomOptionsMutex.Unlock()
+	fake.LoadRoomOptionsStub = stub
+}
+
+func (fake *FakeObjectStore) LoadRoomOptionsArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.loadRoomOptionsMutex.RLock()
+	defer fake.loadRoomOptionsMutex.RUnlock()
+	argsForCall := fake.loadRoomOptionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) LoadRoomOptionsReturns(result1 *rtc.RoomOptions, result2 error) {
+	fake.loadRoomOptionsMutex.Lock()
+	defer fake.loadRoomOptionsMutex.Unlock()
+	fake.LoadRoomOptionsStub = nil
+	fake.loadRoomOptionsReturns = struct {
+		result1 *rtc.RoomOptions
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadRoomOptionsReturnsOnCall(i int, result1 *rtc.RoomOptions, result2 error) {
+	fake.loadRoomOptionsMutex.Lock()
+	defer fake.loadRoomOptionsMutex.Unlock()
+	fake.LoadRoomOptionsStub = nil
+	if fake.loadRoomOptionsReturnsOnCall == nil {
+		fake.loadRoomOptionsReturnsOnCall = make(map[int]struct {
+			result1 *rtc.RoomOptions
+			result2 error
+		})
+	}
+	fake.loadRoomOptionsReturnsOnCall[i] = struct {
+		result1 *rtc.RoomOptions
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeObjectStore) LockRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 time.Duration) (string, error) {
 	fake.lockRoomMutex.Lock()
 	ret, specificReturn := fake.lockRoomReturnsOnCall[len(fake.lockRoomArgsForCall)]
@@ -615,6 +1791,131 @@ func (fake *FakeObjectStore) LockRoomReturnsOnCall(i int, result1 string, result
 	}{result1, result2}
 }
 
+func (fake *FakeObjectStore) StoreAttachment(arg1 context.Context, arg2 *service.Attachment) error {
+	fake.storeAttachmentMutex.Lock()
+	ret, specificReturn := fake.storeAttachmentReturnsOnCall[len(fake.storeAttachmentArgsForCall)]
+	fake.storeAttachmentArgsForCall = append(fake.storeAttachmentArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.Attachment
+	}{arg1, arg2})
+	stub := fake.StoreAttachmentStub
+	fakeReturns := fake.storeAttachmentReturns
+	fake.recordInvocation("StoreAttachment", []interface{}{arg1, arg2})
+	fake.storeAttachmentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreAttachmentCallCount() int {
+	fake.storeAttachmentMutex.RLock()
+	defer fake.storeAttachmentMutex.RUnlock()
+	return len(fake.storeAttachmentArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreAttachmentCalls(stub func(context.Context, *service.Attachment) error) {
+	fake.storeAttachmentMutex.Lock()
+	defer fake.storeAttachmentMutex.Unlock()
+	fake.StoreAttachmentStub = stub
+}
+
+func (fake *FakeObjectStore) StoreAttachmentArgsForCall(i int) (context.Context, *service.Attachment) {
+	fake.storeAttachmentMutex.RLock()
+	defer fake.storeAttachmentMutex.RUnlock()
+	argsForCall := fake.storeAttachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) StoreAttachmentReturns(result1 error) {
+	fake.storeAttachmentMutex.Lock()
+	defer fake.storeAttachmentMutex.Unlock()
+	fake.StoreAttachmentStub = nil
+	fake.storeAttachmentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreAttachmentReturnsOnCall(i int, result1 error) {
+	fake.storeAttachmentMutex.Lock()
+	defer fake.storeAttachmentMutex.Unlock()
+	fake.StoreAttachmentStub = nil
+	if fake.storeAttachmentReturnsOnCall == nil {
+		fake.storeAttachmentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeAttachmentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreEgressAPIKey(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.storeEgressAPIKeyMutex.Lock()
+	ret, specificReturn := fake.storeEgressAPIKeyReturnsOnCall[len(fake.storeEgressAPIKeyArgsForCall)]
+	fake.storeEgressAPIKeyArgsForCall = append(fake.storeEgressAPIKeyArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.StoreEgressAPIKeyStub
+	fakeReturns := fake.storeEgressAPIKeyReturns
+	fake.recordInvocation("StoreEgressAPIKey", []interface{}{arg1, arg2, arg3})
+	fake.storeEgressAPIKeyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreEgressAPIKeyCallCount() int {
+	fake.storeEgressAPIKeyMutex.RLock()
+	defer fake.storeEgressAPIKeyMutex.RUnlock()
+	return len(fake.storeEgressAPIKeyArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreEgressAPIKeyCalls(stub func(context.Context, string, string) error) {
+	fake.storeEgressAPIKeyMutex.Lock()
+	defer fake.storeEgressAPIKeyMutex.Unlock()
+	fake.StoreEgressAPIKeyStub = stub
+}
+
+func (fake *FakeObjectStore) StoreEgressAPIKeyArgsForCall(i int) (context.Context, string, string) {
+	fake.storeEgressAPIKeyMutex.RLock()
+	defer fake.storeEgressAPIKeyMutex.RUnlock()
+	argsForCall := fake.storeEgressAPIKeyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) StoreEgressAPIKeyReturns(result1 error) {
+	fake.storeEgressAPIKeyMutex.Lock()
+	defer fake.storeEgressAPIKeyMutex.Unlock()
+	fake.StoreEgressAPIKeyStub = nil
+	fake.storeEgressAPIKeyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreEgressAPIKeyReturnsOnCall(i int, result1 error) {
+	fake.storeEgressAPIKeyMutex.Lock()
+	defer fake.storeEgressAPIKeyMutex.Unlock()
+	fake.StoreEgressAPIKeyStub = nil
+	if fake.storeEgressAPIKeyReturnsOnCall == nil {
+		fake.storeEgressAPIKeyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeEgressAPIKeyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) StoreParticipant(arg1 context.Context, arg2 livekit.RoomName, arg3 *livekit.ParticipantInfo) error {
 	fake.storeParticipantMutex.Lock()
 	ret, specificReturn := fake.storeParticipantReturnsOnCall[len(fake.storeParticipantArgsForCall)]
@@ -678,6 +1979,130 @@ func (fake *FakeObjectStore) StoreParticipantReturnsOnCall(i int, result1 error)
 	}{result1}
 }
 
+func (fake *FakeObjectStore) StoreParticipantBan(arg1 context.Context, arg2 *service.ParticipantBan) error {
+	fake.storeParticipantBanMutex.Lock()
+	ret, specificReturn := fake.storeParticipantBanReturnsOnCall[len(fake.storeParticipantBanArgsForCall)]
+	fake.storeParticipantBanArgsForCall = append(fake.storeParticipantBanArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.ParticipantBan
+	}{arg1, arg2})
+	stub := fake.StoreParticipantBanStub
+	fakeReturns := fake.storeParticipantBanReturns
+	fake.recordInvocation("StoreParticipantBan", []interface{}{arg1, arg2})
+	fake.storeParticipantBanMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreParticipantBanCallCount() int {
+	fake.storeParticipantBanMutex.RLock()
+	defer fake.storeParticipantBanMutex.RUnlock()
+	return len(fake.storeParticipantBanArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreParticipantBanCalls(stub func(context.Context, *service.ParticipantBan) error) {
+	fake.storeParticipantBanMutex.Lock()
+	defer fake.storeParticipantBanMutex.Unlock()
+	fake.StoreParticipantBanStub = stub
+}
+
+func (fake *FakeObjectStore) StoreParticipantBanArgsForCall(i int) (context.Context, *service.ParticipantBan) {
+	fake.storeParticipantBanMutex.RLock()
+	defer fake.storeParticipantBanMutex.RUnlock()
+	argsForCall := fake.storeParticipantBanArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) StoreParticipantBanReturns(result1 error) {
+	fake.storeParticipantBanMutex.Lock()
+	defer fake.storeParticipantBanMutex.Unlock()
+	fake.StoreParticipantBanStub = nil
+	fake.storeParticipantBanReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreParticipantBanReturnsOnCall(i int, result1 error) {
+	fake.storeParticipantBanMutex.Lock()
+	defer fake.storeParticipantBanMutex.Unlock()
+	fake.StoreParticipantBanStub = nil
+	if fake.storeParticipantBanReturnsOnCall == nil {
+		fake.storeParticipantBanReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeParticipantBanReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreParticipantSession(arg1 context.Context, arg2 *service.ParticipantSession) error {
+	fake.storeParticipantSessionMutex.Lock()
+	ret, specificReturn := fake.storeParticipantSessionReturnsOnCall[len(fake.storeParticipantSessionArgsForCall)]
+	fake.storeParticipantSessionArgsForCall = append(fake.storeParticipantSessionArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.ParticipantSession
+	}{arg1, arg2})
+	stub := fake.StoreParticipantSessionStub
+	fakeReturns := fake.storeParticipantSessionReturns
+	fake.recordInvocation("StoreParticipantSession", []interface{}{arg1, arg2})
+	fake.storeParticipantSessionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreParticipantSessionCallCount() int {
+	fake.storeParticipantSessionMutex.RLock()
+	defer fake.storeParticipantSessionMutex.RUnlock()
+	return len(fake.storeParticipantSessionArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreParticipantSessionCalls(stub func(context.Context, *service.ParticipantSession) error) {
+	fake.storeParticipantSessionMutex.Lock()
+	defer fake.storeParticipantSessionMutex.Unlock()
+	fake.StoreParticipantSessionStub = stub
+}
+
+func (fake *FakeObjectStore) StoreParticipantSessionArgsForCall(i int) (context.Context, *service.ParticipantSession) {
+	fake.storeParticipantSessionMutex.RLock()
+	defer fake.storeParticipantSessionMutex.RUnlock()
+	argsForCall := fake.storeParticipantSessionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) StoreParticipantSessionReturns(result1 error) {
+	fake.storeParticipantSessionMutex.Lock()
+	defer fake.storeParticipantSessionMutex.Unlock()
+	fake.StoreParticipantSessionStub = nil
+	fake.storeParticipantSessionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreParticipantSessionReturnsOnCall(i int, result1 error) {
+	fake.storeParticipantSessionMutex.Lock()
+	defer fake.storeParticipantSessionMutex.Unlock()
+	fake.StoreParticipantSessionStub = nil
+	if fake.storeParticipantSessionReturnsOnCall == nil {
+		fake.storeParticipantSessionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeParticipantSessionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) StoreRoom(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.RoomInternal) error {
 	fake.storeRoomMutex.Lock()
 	ret, specificReturn := fake.storeRoomReturnsOnCall[len(fake.storeRoomArgsForCall)]
@@ -741,6 +2166,69 @@ func (fake *FakeObjectStore) StoreRoomReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeObjectStore) StoreRoomOptions(arg1 context.Context, arg2 livekit.RoomName, arg3 *rtc.RoomOptions) error {
+	fake.storeRoomOptionsMutex.Lock()
+	ret, specificReturn := fake.storeRoomOptionsReturnsOnCall[len(fake.storeRoomOptionsArgsForCall)]
+	fake.storeRoomOptionsArgsForCall = append(fake.storeRoomOptionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 *rtc.RoomOptions
+	}{arg1, arg2, arg3})
+	stub := fake.StoreRoomOptionsStub
+	fakeReturns := fake.storeRoomOptionsReturns
+	fake.recordInvocation("StoreRoomOptions", []interface{}{arg1, arg2, arg3})
+	fake.storeRoomOptionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreRoomOptionsCallCount() int {
+	fake.storeRoomOptionsMutex.RLock()
+	defer fake.storeRoomOptionsMutex.RUnlock()
+	return len(fake.storeRoomOptionsArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreRoomOptionsCalls(stub func(context.Context, livekit.RoomName, *rtc.RoomOptions) error) {
+	fake.storeRoomOptionsMutex.Lock()
+	defer fake.storeRoomOptionsMutex.Unlock()
+	fake.StoreRoomOptionsStub = stub
+}
+
+func (fake *FakeObjectStore) StoreRoomOptionsArgsForCall(i int) (context.Context, livekit.RoomName, *rtc.RoomOptions) {
+	fake.storeRoomOptionsMutex.RLock()
+	defer fake.storeRoomOptionsMutex.RUnlock()
+	argsForCall := fake.storeRoomOptionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeObjectStore) StoreRoomOptionsReturns(result1 error) {
+	fake.storeRoomOptionsMutex.Lock()
+	defer fake.storeRoomOptionsMutex.Unlock()
+	fake.StoreRoomOptionsStub = nil
+	fake.storeRoomOptionsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreRoomOptionsReturnsOnCall(i int, result1 error) {
+	fake.storeRoomOptionsMutex.Lock()
+	defer fake.storeRoomOptionsMutex.Unlock()
+	fake.StoreRoomOptionsStub = nil
+	if fake.storeRoomOptionsReturnsOnCall == nil {
+		fake.storeRoomOptionsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeRoomOptionsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) UnlockRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 string) error {
 	fake.unlockRoomMutex.Lock()
 	ret, specificReturn := fake.unlockRoomReturnsOnCall[len(fake.unlockRoomArgsForCall)]
@@ -807,24 +2295,62 @@ func (fake *FakeObjectStore) UnlockRoomReturnsOnCall(i int, result1 error) {
 func (fake *FakeObjectStore) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.addAPIKeyEgressUsageMutex.RLock()
+	defer fake.addAPIKeyEgressUsageMutex.RUnlock()
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
 	fake.deleteParticipantMutex.RLock()
 	defer fake.deleteParticipantMutex.RUnlock()
+	fake.deleteParticipantBanMutex.RLock()
+	defer fake.deleteParticipantBanMutex.RUnlock()
+	fake.deletePasscodeAttemptsMutex.RLock()
+	defer fake.deletePasscodeAttemptsMutex.RUnlock()
 	fake.deleteRoomMutex.RLock()
 	defer fake.deleteRoomMutex.RUnlock()
+	fake.incrementPasscodeAttemptsMutex.RLock()
+	defer fake.incrementPasscodeAttemptsMutex.RUnlock()
+	fake.listAttachmentsMutex.RLock()
+	defer fake.listAttachmentsMutex.RUnlock()
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
 	fake.listParticipantsMutex.RLock()
 	defer fake.listParticipantsMutex.RUnlock()
+	fake.listRoomOptionsMutex.RLock()
+	defer fake.listRoomOptionsMutex.RUnlock()
 	fake.listRoomsMutex.RLock()
 	defer fake.listRoomsMutex.RUnlock()
+	fake.loadAPIKeyEgressUsageMutex.RLock()
+	defer fake.loadAPIKeyEgressUsageMutex.RUnlock()
+	fake.loadAPIKeyRoomUsageMutex.RLock()
+	defer fake.loadAPIKeyRoomUsageMutex.RUnlock()
+	fake.loadAttachmentMutex.RLock()
+	defer fake.loadAttachmentMutex.RUnlock()
+	fake.loadEgressAPIKeyMutex.RLock()
+	defer fake.loadEgressAPIKeyMutex.RUnlock()
 	fake.loadParticipantMutex.RLock()
 	defer fake.loadParticipantMutex.RUnlock()
+	fake.loadParticipantSessionMutex.RLock()
+	defer fake.loadParticipantSessionMutex.RUnlock()
 	fake.loadRoomMutex.RLock()
 	defer fake.loadRoomMutex.RUnlock()
+	fake.loadRoomOptionsMutex.RLock()
+	defer fake.loadRoomOptionsMutex.RUnlock()
 	fake.lockRoomMutex.RLock()
 	defer fake.lockRoomMutex.RUnlock()
+	fake.storeAttachmentMutex.RLock()
+	defer fake.storeAttachmentMutex.RUnlock()
+	fake.storeEgressAPIKeyMutex.RLock()
+	defer fake.storeEgressAPIKeyMutex.RUnlock()
 	fake.storeParticipantMutex.RLock()
 	defer fake.storeParticipantMutex.RUnlock()
+	fake.storeParticipantBanMutex.RLock()
+	defer fake.storeParticipantBanMutex.RUnlock()
+	fake.storeParticipantSessionMutex.RLock()
+	defer fake.storeParticipantSessionMutex.RUnlock()
 	fake.storeRoomMutex.RLock()
 	defer fake.storeRoomMutex.RUnlock()
+	fake.storeRoomOptionsMutex.RLock()
+	defer fake.storeRoomOptionsMutex.RUnlock()
 	fake.unlockRoomMutex.RLock()
 	defer fake.unlockRoomMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
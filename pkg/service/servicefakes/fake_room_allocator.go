@@ -5,16 +5,18 @@ import (
 	"context"
 	"sync"
 
+	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/protocol/livekit"
 )
 
 type FakeRoomAllocator struct {
-	CreateRoomStub        func(context.Context, *livekit.CreateRoomRequest) (*livekit.Room, bool, error)
+	CreateRoomStub        func(context.Context, *livekit.CreateRoomRequest, *rtc.RoomOptions) (*livekit.Room, bool, error)
 	createRoomMutex       sync.RWMutex
 	createRoomArgsForCall []struct {
 		arg1 context.Context
 		arg2 *livekit.CreateRoomRequest
+		arg3 *rtc.RoomOptions
 	}
 	createRoomReturns struct {
 		result1 *livekit.Room
@@ -26,6 +28,22 @@ type FakeRoomAllocator struct {
 		result2 bool
 		result3 error
 	}
+	PatchRoomMetadataStub        func(context.Context, livekit.RoomName, []byte, string) (*livekit.Room, error)
+	patchRoomMetadataMutex       sync.RWMutex
+	patchRoomMetadataArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 []byte
+		arg4 string
+	}
+	patchRoomMetadataReturns struct {
+		result1 *livekit.Room
+		result2 error
+	}
+	patchRoomMetadataReturnsOnCall map[int]struct {
+		result1 *livekit.Room
+		result2 error
+	}
 	ValidateCreateRoomStub        func(context.Context, livekit.RoomName) error
 	validateCreateRoomMutex       sync.RWMutex
 	validateCreateRoomArgsForCall []struct {
@@ -42,19 +60,20 @@ type FakeRoomAllocator struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeRoomAllocator) CreateRoom(arg1 context.Context, arg2 *livekit.CreateRoomRequest) (*livekit.Room, bool, error) {
+func (fake *FakeRoomAllocator) CreateRoom(arg1 context.Context, arg2 *livekit.CreateRoomRequest, arg3 *rtc.RoomOptions) (*livekit.Room, bool, error) {
 	fake.createRoomMutex.Lock()
 	ret, specificReturn := fake.createRoomReturnsOnCall[len(fake.createRoomArgsForCall)]
 	fake.createRoomArgsForCall = append(fake.createRoomArgsForCall, struct {
 		arg1 context.Context
 		arg2 *livekit.CreateRoomRequest
-	}{arg1, arg2})
+		arg3 *rtc.RoomOptions
+	}{arg1, arg2, arg3})
 	stub := fake.CreateRoomStub
 	fakeReturns := fake.createRoomReturns
-	fake.recordInvocation("CreateRoom", []interface{}{arg1, arg2})
+	fake.recordInvocation("CreateRoom", []interface{}{arg1, arg2, arg3})
 	fake.createRoomMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2, ret.result3
@@ -68,17 +87,17 @@ func (fake *FakeRoomAllocator) CreateRoomCallCount() int {
 	return len(fake.createRoomArgsForCall)
 }
 
-func (fake *FakeRoomAllocator) CreateRoomCalls(stub func(context.Context, *livekit.CreateRoomRequest) (*livekit.Room, bool, error)) {
+func (fake *FakeRoomAllocator) CreateRoomCalls(stub func(context.Context, *livekit.CreateRoomRequest, *rtc.RoomOptions) (*livekit.Room, bool, error)) {
 	fake.createRoomMutex.Lock()
 	defer fake.createRoomMutex.Unlock()
 	fake.CreateRoomStub = stub
 }
 
-func (fake *FakeRoomAllocator) CreateRoomArgsForCall(i int) (context.Context, *livekit.CreateRoomRequest) {
+func (fake *FakeRoomAllocator) CreateRoomArgsForCall(i int) (context.Context, *livekit.CreateRoomRequest, *rtc.RoomOptions) {
 	fake.createRoomMutex.RLock()
 	defer fake.createRoomMutex.RUnlock()
 	argsForCall := fake.createRoomArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeRoomAllocator) CreateRoomReturns(result1 *livekit.Room, result2 bool, result3 error) {
@@ -110,6 +129,78 @@ func (fake *FakeRoomAllocator) CreateRoomReturnsOnCall(i int, result1 *livekit.R
 	}{result1, result2, result3}
 }
 
+func (fake *FakeRoomAllocator) PatchRoomMetadata(arg1 context.Context, arg2 livekit.RoomName, arg3 []byte, arg4 string) (*livekit.Room, error) {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.patchRoomMetadataMutex.Lock()
+	ret, specificReturn := fake.patchRoomMetadataReturnsOnCall[len(fake.patchRoomMetadataArgsForCall)]
+	fake.patchRoomMetadataArgsForCall = append(fake.patchRoomMetadataArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 []byte
+		arg4 string
+	}{arg1, arg2, arg3Copy, arg4})
+	stub := fake.PatchRoomMetadataStub
+	fakeReturns := fake.patchRoomMetadataReturns
+	fake.recordInvocation("PatchRoomMetadata", []interface{}{arg1, arg2, arg3Copy, arg4})
+	fake.patchRoomMetadataMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRoomAllocator) PatchRoomMetadataCallCount() int {
+	fake.patchRoomMetadataMutex.RLock()
+	defer fake.patchRoomMetadataMutex.RUnlock()
+	return len(fake.patchRoomMetadataArgsForCall)
+}
+
+func (fake *FakeRoomAllocator) PatchRoomMetadataCalls(stub func(context.Context, livekit.RoomName, []byte, string) (*livekit.Room, error)) {
+	fake.patchRoomMetadataMutex.Lock()
+	defer fake.patchRoomMetadataMutex.Unlock()
+	fake.PatchRoomMetadataStub = stub
+}
+
+func (fake *FakeRoomAllocator) PatchRoomMetadataArgsForCall(i int) (context.Context, livekit.RoomName, []byte, string) {
+	fake.patchRoomMetadataMutex.RLock()
+	defer fake.patchRoomMetadataMutex.RUnlock()
+	argsForCall := fake.patchRoomMetadataArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeRoomAllocator) PatchRoomMetadataReturns(result1 *livekit.Room, result2 error) {
+	fake.patchRoomMetadataMutex.Lock()
+	defer fake.patchRoomMetadataMutex.Unlock()
+	fake.PatchRoomMetadataStub = nil
+	fake.patchRoomMetadataReturns = struct {
+		result1 *livekit.Room
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomAllocator) PatchRoomMetadataReturnsOnCall(i int, result1 *livekit.Room, result2 error) {
+	fake.patchRoomMetadataMutex.Lock()
+	defer fake.patchRoomMetadataMutex.Unlock()
+	fake.PatchRoomMetadataStub = nil
+	if fake.patchRoomMetadataReturnsOnCall == nil {
+		fake.patchRoomMetadataReturnsOnCall = make(map[int]struct {
+			result1 *livekit.Room
+			result2 error
+		})
+	}
+	fake.patchRoomMetadataReturnsOnCall[i] = struct {
+		result1 *livekit.Room
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRoomAllocator) ValidateCreateRoom(arg1 context.Context, arg2 livekit.RoomName) error {
 	fake.validateCreateRoomMutex.Lock()
 	ret, specificReturn := fake.validateCreateRoomReturnsOnCall[len(fake.validateCreateRoomArgsForCall)]
@@ -177,6 +268,8 @@ func (fake *FakeRoomAllocator) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.createRoomMutex.RLock()
 	defer fake.createRoomMutex.RUnlock()
+	fake.patchRoomMetadataMutex.RLock()
+	defer fake.patchRoomMetadataMutex.RUnlock()
 	fake.validateCreateRoomMutex.RLock()
 	defer fake.validateCreateRoomMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
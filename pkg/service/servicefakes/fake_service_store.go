@@ -5,11 +5,25 @@ import (
 	"context"
 	"sync"
 
+	"github.com/livekit/livekit-server/pkg/rtc"
 	"github.com/livekit/livekit-server/pkg/service"
 	"github.com/livekit/protocol/livekit"
 )
 
 type FakeServiceStore struct {
+	DeleteParticipantBanStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error
+	deleteParticipantBanMutex       sync.RWMutex
+	deleteParticipantBanArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}
+	deleteParticipantBanReturns struct {
+		result1 error
+	}
+	deleteParticipantBanReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteRoomStub        func(context.Context, livekit.RoomName) error
 	deleteRoomMutex       sync.RWMutex
 	deleteRoomArgsForCall []struct {
@@ -22,6 +36,20 @@ type FakeServiceStore struct {
 	deleteRoomReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ListParticipantBansStub        func(context.Context, livekit.RoomName) ([]*service.ParticipantBan, error)
+	listParticipantBansMutex       sync.RWMutex
+	listParticipantBansArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	listParticipantBansReturns struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}
+	listParticipantBansReturnsOnCall map[int]struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}
 	ListParticipantsStub        func(context.Context, livekit.RoomName) ([]*livekit.ParticipantInfo, error)
 	listParticipantsMutex       sync.RWMutex
 	listParticipantsArgsForCall []struct {
@@ -36,6 +64,19 @@ type FakeServiceStore struct {
 		result1 []*livekit.ParticipantInfo
 		result2 error
 	}
+	ListRoomOptionsStub        func(context.Context) (map[livekit.RoomName]*rtc.RoomOptions, error)
+	listRoomOptionsMutex       sync.RWMutex
+	listRoomOptionsArgsForCall []struct {
+		arg1 context.Context
+	}
+	listRoomOptionsReturns struct {
+		result1 map[livekit.RoomName]*rtc.RoomOptions
+		result2 error
+	}
+	listRoomOptionsReturnsOnCall map[int]struct {
+		result1 map[livekit.RoomName]*rtc.RoomOptions
+		result2 error
+	}
 	ListRoomsStub        func(context.Context, []livekit.RoomName) ([]*livekit.Room, error)
 	listRoomsMutex       sync.RWMutex
 	listRoomsArgsForCall []struct {
@@ -82,10 +123,99 @@ type FakeServiceStore struct {
 		result2 *livekit.RoomInternal
 		result3 error
 	}
+	LoadRoomOptionsStub        func(context.Context, livekit.RoomName) (*rtc.RoomOptions, error)
+	loadRoomOptionsMutex       sync.RWMutex
+	loadRoomOptionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	loadRoomOptionsReturns struct {
+		result1 *rtc.RoomOptions
+		result2 error
+	}
+	loadRoomOptionsReturnsOnCall map[int]struct {
+		result1 *rtc.RoomOptions
+		result2 error
+	}
+	StoreParticipantBanStub        func(context.Context, *service.ParticipantBan) error
+	storeParticipantBanMutex       sync.RWMutex
+	storeParticipantBanArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.ParticipantBan
+	}
+	storeParticipantBanReturns struct {
+		result1 error
+	}
+	storeParticipantBanReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeServiceStore) DeleteParticipantBan(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity) error {
+	fake.deleteParticipantBanMutex.Lock()
+	ret, specificReturn := fake.deleteParticipantBanReturnsOnCall[len(fake.deleteParticipantBanArgsForCall)]
+	fake.deleteParticipantBanArgsForCall = append(fake.deleteParticipantBanArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+	}{arg1, arg2, arg3})
+	stub := fake.DeleteParticipantBanStub
+	fakeReturns := fake.deleteParticipantBanReturns
+	fake.recordInvocation("DeleteParticipantBan", []interface{}{arg1, arg2, arg3})
+	fake.deleteParticipantBanMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeServiceStore) DeleteParticipantBanCallCount() int {
+	fake.deleteParticipantBanMutex.RLock()
+	defer fake.deleteParticipantBanMutex.RUnlock()
+	return len(fake.deleteParticipantBanArgsForCall)
+}
+
+func (fake *FakeServiceStore) DeleteParticipantBanCalls(stub func(context.Context, livekit.RoomName, livekit.ParticipantIdentity) error) {
+	fake.deleteParticipantBanMutex.Lock()
+	defer fake.deleteParticipantBanMutex.Unlock()
+	fake.DeleteParticipantBanStub = stub
+}
+
+func (fake *FakeServiceStore) DeleteParticipantBanArgsForCall(i int) (context.Context, livekit.RoomName, livekit.ParticipantIdentity) {
+	fake.deleteParticipantBanMutex.RLock()
+	defer fake.deleteParticipantBanMutex.RUnlock()
+	argsForCall := fake.deleteParticipantBanArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeServiceStore) DeleteParticipantBanReturns(result1 error) {
+	fake.deleteParticipantBanMutex.Lock()
+	defer fake.deleteParticipantBanMutex.Unlock()
+	fake.DeleteParticipantBanStub = nil
+	fake.deleteParticipantBanReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeServiceStore) DeleteParticipantBanReturnsOnCall(i int, result1 error) {
+	fake.deleteParticipantBanMutex.Lock()
+	defer fake.deleteParticipantBanMutex.Unlock()
+	fake.DeleteParticipantBanStub = nil
+	if fake.deleteParticipantBanReturnsOnCall == nil {
+		fake.deleteParticipantBanReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteParticipantBanReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeServiceStore) DeleteRoom(arg1 context.Context, arg2 livekit.RoomName) error {
 	fake.deleteRoomMutex.Lock()
 	ret, specificReturn := fake.deleteRoomReturnsOnCall[len(fake.deleteRoomArgsForCall)]
@@ -148,6 +278,71 @@ func (fake *FakeServiceStore) DeleteRoomReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeServiceStore) ListParticipantBans(arg1 context.Context, arg2 livekit.RoomName) ([]*service.ParticipantBan, error) {
+	fake.listParticipantBansMutex.Lock()
+	ret, specificReturn := fake.listParticipantBansReturnsOnCall[len(fake.listParticipantBansArgsForCall)]
+	fake.listParticipantBansArgsForCall = append(fake.listParticipantBansArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.ListParticipantBansStub
+	fakeReturns := fake.listParticipantBansReturns
+	fake.recordInvocation("ListParticipantBans", []interface{}{arg1, arg2})
+	fake.listParticipantBansMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) ListParticipantBansCallCount() int {
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
+	return len(fake.listParticipantBansArgsForCall)
+}
+
+func (fake *FakeServiceStore) ListParticipantBansCalls(stub func(context.Context, livekit.RoomName) ([]*service.ParticipantBan, error)) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = stub
+}
+
+func (fake *FakeServiceStore) ListParticipantBansArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
+	argsForCall := fake.listParticipantBansArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServiceStore) ListParticipantBansReturns(result1 []*service.ParticipantBan, result2 error) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = nil
+	fake.listParticipantBansReturns = struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) ListParticipantBansReturnsOnCall(i int, result1 []*service.ParticipantBan, result2 error) {
+	fake.listParticipantBansMutex.Lock()
+	defer fake.listParticipantBansMutex.Unlock()
+	fake.ListParticipantBansStub = nil
+	if fake.listParticipantBansReturnsOnCall == nil {
+		fake.listParticipantBansReturnsOnCall = make(map[int]struct {
+			result1 []*service.ParticipantBan
+			result2 error
+		})
+	}
+	fake.listParticipantBansReturnsOnCall[i] = struct {
+		result1 []*service.ParticipantBan
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeServiceStore) ListParticipants(arg1 context.Context, arg2 livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
 	fake.listParticipantsMutex.Lock()
 	ret, specificReturn := fake.listParticipantsReturnsOnCall[len(fake.listParticipantsArgsForCall)]
@@ -213,6 +408,70 @@ func (fake *FakeServiceStore) ListParticipantsReturnsOnCall(i int, result1 []*li
 	}{result1, result2}
 }
 
+func (fake *FakeServiceStore) ListRoomOptions(arg1 context.Context) (map[livekit.RoomName]*rtc.RoomOptions, error) {
+	fake.listRoomOptionsMutex.Lock()
+	ret, specificReturn := fake.listRoomOptionsReturnsOnCall[len(fake.listRoomOptionsArgsForCall)]
+	fake.listRoomOptionsArgsForCall = append(fake.listRoomOptionsArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.ListRoomOptionsStub
+	fakeReturns := fake.listRoomOptionsReturns
+	fake.recordInvocation("ListRoomOptions", []interface{}{arg1})
+	fake.listRoomOptionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) ListRoomOptionsCallCount() int {
+	fake.listRoomOptionsMutex.RLock()
+	defer fake.listRoomOptionsMutex.RUnlock()
+	return len(fake.listRoomOptionsArgsForCall)
+}
+
+func (fake *FakeServiceStore) ListRoomOptionsCalls(stub func(context.Context) (map[livekit.RoomName]*rtc.RoomOptions, error)) {
+	fake.listRoomOptionsMutex.Lock()
+	defer fake.listRoomOptionsMutex.Unlock()
+	fake.ListRoomOptionsStub = stub
+}
+
+func (fake *FakeServiceStore) ListRoomOptionsArgsForCall(i int) context.Context {
+	fake.listRoomOptionsMutex.RLock()
+	defer fake.listRoomOptionsMutex.RUnlock()
+	argsForCall := fake.listRoomOptionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeServiceStore) ListRoomOptionsReturns(result1 map[livekit.RoomName]*rtc.RoomOptions, result2 error) {
+	fake.listRoomOptionsMutex.Lock()
+	defer fake.listRoomOptionsMutex.Unlock()
+	fake.ListRoomOptionsStub = nil
+	fake.listRoomOptionsReturns = struct {
+		result1 map[livekit.RoomName]*rtc.RoomOptions
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) ListRoomOptionsReturnsOnCall(i int, result1 map[livekit.RoomName]*rtc.RoomOptions, result2 error) {
+	fake.listRoomOptionsMutex.Lock()
+	defer fake.listRoomOptionsMutex.Unlock()
+	fake.ListRoomOptionsStub = nil
+	if fake.listRoomOptionsReturnsOnCall == nil {
+		fake.listRoomOptionsReturnsOnCall = make(map[int]struct {
+			result1 map[livekit.RoomName]*rtc.RoomOptions
+			result2 error
+		})
+	}
+	fake.listRoomOptionsReturnsOnCall[i] = struct {
+		result1 map[livekit.RoomName]*rtc.RoomOptions
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeServiceStore) ListRooms(arg1 context.Context, arg2 []livekit.RoomName) ([]*livekit.Room, error) {
 	var arg2Copy []livekit.RoomName
 	if arg2 != nil {
@@ -418,19 +677,156 @@ func (fake *FakeServiceStore) LoadRoomReturnsOnCall(i int, result1 *livekit.Room
 	}{result1, result2, result3}
 }
 
+func (fake *FakeServiceStore) LoadRoomOptions(arg1 context.Context, arg2 livekit.RoomName) (*rtc.RoomOptions, error) {
+	fake.loadRoomOptionsMutex.Lock()
+	ret, specificReturn := fake.loadRoomOptionsReturnsOnCall[len(fake.loadRoomOptionsArgsForCall)]
+	fake.loadRoomOptionsArgsForCall = append(fake.loadRoomOptionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.LoadRoomOptionsStub
+	fakeReturns := fake.loadRoomOptionsReturns
+	fake.recordInvocation("LoadRoomOptions", []interface{}{arg1, arg2})
+	fake.loadRoomOptionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeServiceStore) LoadRoomOptionsCallCount() int {
+	fake.loadRoomOptionsMutex.RLock()
+	defer fake.loadRoomOptionsMutex.RUnlock()
+	return len(fake.loadRoomOptionsArgsForCall)
+}
+
+func (fake *FakeServiceStore) LoadRoomOptionsCalls(stub func(context.Context, livekit.RoomName) (*rtc.RoomOptions, error)) {
+	fake.loadRoomOptionsMutex.Lock()
+	defer fake.loadRoomOptionsMutex.Unlock()
+	fake.LoadRoomOptionsStub = stub
+}
+
+func (fake *FakeServiceStore) LoadRoomOptionsArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.loadRoomOptionsMutex.RLock()
+	defer fake.loadRoomOptionsMutex.RUnlock()
+	argsForCall := fake.loadRoomOptionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServiceStore) LoadRoomOptionsReturns(result1 *rtc.RoomOptions, result2 error) {
+	fake.loadRoomOptionsMutex.Lock()
+	defer fake.loadRoomOptionsMutex.Unlock()
+	fake.LoadRoomOptionsStub = nil
+	fake.loadRoomOptionsReturns = struct {
+		result1 *rtc.RoomOptions
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) LoadRoomOptionsReturnsOnCall(i int, result1 *rtc.RoomOptions, result2 error) {
+	fake.loadRoomOptionsMutex.Lock()
+	defer fake.loadRoomOptionsMutex.Unlock()
+	fake.LoadRoomOptionsStub = nil
+	if fake.loadRoomOptionsReturnsOnCall == nil {
+		fake.loadRoomOptionsReturnsOnCall = make(map[int]struct {
+			result1 *rtc.RoomOptions
+			result2 error
+		})
+	}
+	fake.loadRoomOptionsReturnsOnCall[i] = struct {
+		result1 *rtc.RoomOptions
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeServiceStore) StoreParticipantBan(arg1 context.Context, arg2 *service.ParticipantBan) error {
+	fake.storeParticipantBanMutex.Lock()
+	ret, specificReturn := fake.storeParticipantBanReturnsOnCall[len(fake.storeParticipantBanArgsForCall)]
+	fake.storeParticipantBanArgsForCall = append(fake.storeParticipantBanArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.ParticipantBan
+	}{arg1, arg2})
+	stub := fake.StoreParticipantBanStub
+	fakeReturns := fake.storeParticipantBanReturns
+	fake.recordInvocation("StoreParticipantBan", []interface{}{arg1, arg2})
+	fake.storeParticipantBanMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeServiceStore) StoreParticipantBanCallCount() int {
+	fake.storeParticipantBanMutex.RLock()
+	defer fake.storeParticipantBanMutex.RUnlock()
+	return len(fake.storeParticipantBanArgsForCall)
+}
+
+func (fake *FakeServiceStore) StoreParticipantBanCalls(stub func(context.Context, *service.ParticipantBan) error) {
+	fake.storeParticipantBanMutex.Lock()
+	defer fake.storeParticipantBanMutex.Unlock()
+	fake.StoreParticipantBanStub = stub
+}
+
+func (fake *FakeServiceStore) StoreParticipantBanArgsForCall(i int) (context.Context, *service.ParticipantBan) {
+	fake.storeParticipantBanMutex.RLock()
+	defer fake.storeParticipantBanMutex.RUnlock()
+	argsForCall := fake.storeParticipantBanArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeServiceStore) StoreParticipantBanReturns(result1 error) {
+	fake.storeParticipantBanMutex.Lock()
+	defer fake.storeParticipantBanMutex.Unlock()
+	fake.StoreParticipantBanStub = nil
+	fake.storeParticipantBanReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeServiceStore) StoreParticipantBanReturnsOnCall(i int, result1 error) {
+	fake.storeParticipantBanMutex.Lock()
+	defer fake.storeParticipantBanMutex.Unlock()
+	fake.StoreParticipantBanStub = nil
+	if fake.storeParticipantBanReturnsOnCall == nil {
+		fake.storeParticipantBanReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeParticipantBanReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeServiceStore) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.deleteParticipantBanMutex.RLock()
+	defer fake.deleteParticipantBanMutex.RUnlock()
 	fake.deleteRoomMutex.RLock()
 	defer fake.deleteRoomMutex.RUnlock()
+	fake.listParticipantBansMutex.RLock()
+	defer fake.listParticipantBansMutex.RUnlock()
 	fake.listParticipantsMutex.RLock()
 	defer fake.listParticipantsMutex.RUnlock()
+	fake.listRoomOptionsMutex.RLock()
+	defer fake.listRoomOptionsMutex.RUnlock()
 	fake.listRoomsMutex.RLock()
 	defer fake.listRoomsMutex.RUnlock()
 	fake.loadParticipantMutex.RLock()
 	defer fake.loadParticipantMutex.RUnlock()
 	fake.loadRoomMutex.RLock()
 	defer fake.loadRoomMutex.RUnlock()
+	fake.loadRoomOptionsMutex.RLock()
+	defer fake.loadRoomOptionsMutex.RUnlock()
+	fake.storeParticipantBanMutex.RLock()
+	defer fake.storeParticipantBanMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
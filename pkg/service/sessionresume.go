@@ -0,0 +1,143 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// ParticipantSession is kept in the store along with the participant info when sessions can resume across nodes.
+// A client whose node is lost resumes on the node its room moves to with the same participant, the tracks it
+// publishes and its subscriptions are set up again from the state the client replays.
+type ParticipantSession struct {
+	Room          livekit.RoomName            `json:"room"`
+	Identity      livekit.ParticipantIdentity `json:"identity"`
+	ParticipantID livekit.ParticipantID       `json:"participant_id"`
+	NodeID        livekit.NodeID              `json:"node_id"`
+	// key the join token of the participant was signed with, the session only resumes with a token of the same key
+	APIKey string `json:"api_key,omitempty"`
+	// who can subscribe to the tracks of the participant, everyone when not set
+	SubscriptionPermission *livekit.SubscriptionPermission `json:"subscription_permission,omitempty"`
+	// node a redirect handed the session off to, the participant can resume there with its resume token until
+	// the handoff expires, whether sessions resume across nodes or not
+	HandoffNodeID    livekit.NodeID `json:"handoff_node_id,omitempty"`
+	HandoffExpiresAt time.Time      `json:"handoff_expires_at,omitempty"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// handedOffTo returns true when the session was handed off to nodeID and can still resume there
+func (s *ParticipantSession) handedOffTo(nodeID livekit.NodeID) bool {
+	return s.HandoffNodeID != "" && s.HandoffNodeID == nodeID && time.Now().Before(s.HandoffExpiresAt)
+}
+
+func (r *RoomManager) storeParticipantSession(ctx context.Context, room *rtc.Room, participant types.LocalParticipant) {
+	if !r.config.Reloadable().Room.ResumeAcrossNodes {
+		return
+	}
+
+	if err := r.roomStore.StoreParticipantSession(ctx, newStoredSession(room, participant, livekit.NodeID(r.currentNode.Id))); err != nil {
+		participant.GetLogger().Errorw("could not store participant session", err)
+	}
+}
+
+// handOffParticipantSession stores the session of a participant being redirected, so that it can resume on nodeID
+// although sessions do not resume across nodes
+func (r *RoomManager) handOffParticipantSession(ctx context.Context, room *rtc.Room, participant types.LocalParticipant, nodeID livekit.NodeID) error {
+	session := newStoredSession(room, participant, livekit.NodeID(r.currentNode.Id))
+	session.HandoffNodeID = nodeID
+	session.HandoffExpiresAt = session.UpdatedAt.Add(resumeTokenTTL)
+	return r.roomStore.StoreParticipantSession(ctx, session)
+}
+
+func newStoredSession(room *rtc.Room, participant types.LocalParticipant, nodeID livekit.NodeID) *ParticipantSession {
+	permission, _ := participant.SubscriptionPermission()
+	return &ParticipantSession{
+		Room:                   room.Name(),
+		Identity:               participant.Identity(),
+		ParticipantID:          participant.ID(),
+		NodeID:                 nodeID,
+		APIKey:                 participant.GetAPIKey(),
+		SubscriptionPermission: permission,
+		UpdatedAt:              time.Now(),
+	}
+}
+
+// loadResumableSession returns the stored session of a participant resuming on this node although it is not in the
+// room, when the session was on another node. Sessions resume across nodes with room.resume_across_nodes, or when a
+// redirect handed them off to this node. It returns nil when the participant has to join again.
+func (r *RoomManager) loadResumableSession(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	pi routing.ParticipantInit,
+) (*ParticipantSession, *livekit.ParticipantInfo) {
+	nodeID := livekit.NodeID(r.currentNode.Id)
+	handedOff := pi.ResumeNodeID != "" && pi.ResumeNodeID == nodeID
+	if (!r.config.Reloadable().Room.ResumeAcrossNodes && !handedOff) || pi.ID == "" {
+		return nil, nil
+	}
+
+	session, err := r.roomStore.LoadParticipantSession(ctx, roomName, pi.Identity)
+	if err != nil {
+		if err != ErrParticipantSessionNotFound {
+			logger.Warnw("could not load participant session", err, "room", roomName, "participant", pi.Identity)
+		}
+		return nil, nil
+	}
+	// a session of this node that is gone has ended, it was not lost
+	if session.ParticipantID != pi.ID || session.NodeID == nodeID {
+		return nil, nil
+	}
+	if session.APIKey != pi.APIKey {
+		logger.Infow("not resuming participant session of another API key", "room", roomName, "participant", pi.Identity)
+		return nil, nil
+	}
+	if !r.config.Reloadable().Room.ResumeAcrossNodes && !session.handedOffTo(nodeID) {
+		return nil, nil
+	}
+
+	info, err := r.roomStore.LoadParticipant(ctx, roomName, pi.Identity)
+	if err != nil || livekit.ParticipantID(info.Sid) != pi.ID {
+		return nil, nil
+	}
+	return session, info
+}
+
+// restoreParticipantSession applies what changed during the session on the previous node to a resumed participant
+func restoreParticipantSession(participant types.LocalParticipant, info *livekit.ParticipantInfo) {
+	if info.Metadata != "" {
+		participant.SetMetadata(info.Metadata)
+	}
+	if info.Permission != nil {
+		participant.SetPermission(info.Permission)
+	}
+}
+
+func restoreSubscriptionPermission(room *rtc.Room, participant types.LocalParticipant, session *ParticipantSession) {
+	if session.SubscriptionPermission == nil {
+		return
+	}
+	// participants it references may not have resumed yet, they are resolved the next time it changes
+	if err := room.UpdateSubscriptionPermission(participant, session.SubscriptionPermission); err != nil {
+		participant.GetLogger().Warnw("could not restore subscription permission", err)
+	}
+}
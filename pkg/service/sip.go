@@ -18,6 +18,9 @@ import (
 	"context"
 	"time"
 
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/rpc"
@@ -28,8 +31,12 @@ import (
 	"github.com/livekit/livekit-server/pkg/telemetry"
 )
 
+const (
+	sipIdentityPrefix = "sip_"
+)
+
 type SIPService struct {
-	conf        *config.SIPConfig
+	conf        *config.Config
 	nodeID      livekit.NodeID
 	bus         psrpc.MessageBus
 	psrpcClient rpc.SIPClient
@@ -38,7 +45,7 @@ type SIPService struct {
 }
 
 func NewSIPService(
-	conf *config.SIPConfig,
+	conf *config.Config,
 	nodeID livekit.NodeID,
 	bus psrpc.MessageBus,
 	psrpcClient rpc.SIPClient,
@@ -204,6 +211,52 @@ func (s *SIPService) CreateSIPParticipantWithToken(ctx context.Context, req *liv
 		RoomName:            req.RoomName,
 	}, nil
 }
+
+// CreateSIPParticipant dials out through a trunk. When sip.ws_url is configured, the call joins the room as an
+// audio only participant with a token created here, following the settings of the trunk.
 func (s *SIPService) CreateSIPParticipant(ctx context.Context, req *livekit.CreateSIPParticipantRequest) (*livekit.SIPParticipantInfo, error) {
-	return s.CreateSIPParticipantWithToken(ctx, req, "", "")
+	if s.conf.SIP.WsURL == "" {
+		return s.CreateSIPParticipantWithToken(ctx, req, "", "")
+	}
+
+	trunkConf := s.conf.SIP.Trunks[req.SipTrunkId]
+	req = proto.Clone(req).(*livekit.CreateSIPParticipantRequest)
+	if req.ParticipantIdentity == "" {
+		prefix := trunkConf.IdentityPrefix
+		if prefix == "" {
+			prefix = sipIdentityPrefix
+		}
+		req.ParticipantIdentity = prefix + req.SipCallTo
+	}
+	token, err := s.sipParticipantToken(req, trunkConf)
+	if err != nil {
+		return nil, err
+	}
+	return s.CreateSIPParticipantWithToken(ctx, req, s.conf.SIP.WsURL, token)
+}
+
+// sipParticipantToken creates the token of a call, it can publish its microphone and, unless the trunk
+// disables DTMF, the tones of the caller as data messages
+func (s *SIPService) sipParticipantToken(req *livekit.CreateSIPParticipantRequest, trunkConf config.SIPTrunkConfig) (string, error) {
+	key, secret, ok := s.conf.Reloadable().FirstKey()
+	if !ok {
+		return "", ErrSIPNoAPIKey
+	}
+
+	canPublishData := !trunkConf.DisableDTMF
+	canSubscribe := true
+	token := auth.NewAccessToken(key, secret)
+	token.SetIdentity(req.ParticipantIdentity).
+		SetName("Phone " + req.SipCallTo).
+		SetKind(livekit.ParticipantInfo_SIP).
+		SetMetadata(trunkConf.Metadata).
+		SetValidFor(tokenDefaultTTL).
+		AddGrant(&auth.VideoGrant{
+			RoomJoin:          true,
+			Room:              req.RoomName,
+			CanPublishSources: []string{"microphone"},
+			CanPublishData:    &canPublishData,
+			CanSubscribe:      &canSubscribe,
+		})
+	return token.ToJWT()
 }
@@ -0,0 +1,102 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+type testSIPClient struct {
+	rpc.SIPClient
+	req *rpc.InternalCreateSIPParticipantRequest
+}
+
+func (c *testSIPClient) CreateSIPParticipant(
+	_ context.Context,
+	_ string,
+	req *rpc.InternalCreateSIPParticipantRequest,
+	_ ...psrpc.RequestOption,
+) (*rpc.InternalCreateSIPParticipantResponse, error) {
+	c.req = req
+	return &rpc.InternalCreateSIPParticipantResponse{ParticipantId: "PA_sip", ParticipantIdentity: req.ParticipantIdentity}, nil
+}
+
+func TestCreateSIPParticipant(t *testing.T) {
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	conf.Keys = map[string]string{"key": "secret"}
+	conf.SIP = config.SIPConfig{
+		WsURL: "wss://livekit.example.com",
+		Trunks: map[string]config.SIPTrunkConfig{
+			"ST_support": {IdentityPrefix: "support_", Metadata: "support line", DisableDTMF: true},
+		},
+	}
+
+	store := &servicefakes.FakeSIPStore{}
+	store.LoadSIPTrunkReturns(&livekit.SIPTrunkInfo{SipTrunkId: "ST_support", OutboundAddress: "sip.example.com"}, nil)
+
+	t.Run("outbound calls join with a token of the server", func(t *testing.T) {
+		client := &testSIPClient{}
+		sipService := service.NewSIPService(conf, "node", nil, client, store, nil, nil)
+		info, err := sipService.CreateSIPParticipant(context.Background(), &livekit.CreateSIPParticipantRequest{
+			SipTrunkId: "ST_support",
+			SipCallTo:  "+15550100",
+			RoomName:   "room",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "support_+15550100", info.ParticipantIdentity)
+
+		require.Equal(t, "wss://livekit.example.com", client.req.WsUrl)
+		require.Equal(t, "sip.example.com", client.req.Address)
+		verifier, err := auth.ParseAPIToken(client.req.Token)
+		require.NoError(t, err)
+		grants, err := verifier.Verify("secret")
+		require.NoError(t, err)
+		require.Equal(t, "support_+15550100", grants.Identity)
+		require.Equal(t, livekit.ParticipantInfo_SIP, grants.GetParticipantKind())
+		require.Equal(t, "support line", grants.Metadata)
+		require.Equal(t, "room", grants.Video.Room)
+		require.Equal(t, []string{"microphone"}, grants.Video.CanPublishSources)
+		require.False(t, grants.Video.GetCanPublishData())
+	})
+
+	t.Run("the SIP service creates the token without a URL", func(t *testing.T) {
+		conf, err := config.NewConfig("", true, nil, nil)
+		require.NoError(t, err)
+		conf.Keys = map[string]string{"key": "secret"}
+		client := &testSIPClient{}
+		sipService := service.NewSIPService(conf, "node", nil, client, store, nil, nil)
+		_, err = sipService.CreateSIPParticipant(context.Background(), &livekit.CreateSIPParticipantRequest{
+			SipTrunkId: "ST_support",
+			SipCallTo:  "+15550100",
+			RoomName:   "room",
+		})
+		require.NoError(t, err)
+		require.Empty(t, client.req.Token)
+		require.Empty(t, client.req.ParticipantIdentity)
+	})
+}
@@ -0,0 +1,505 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/audiofork"
+)
+
+const (
+	// TranscriptionTopic is the topic of the data messages transcripts are published with, a server topic
+	// delivered to every participant
+	TranscriptionTopic = "lk.transcription"
+
+	// subscriber ID of the audio forks, unlike participant IDs that start with PA_
+	transcriptionSubscriberPrefix = "TR_"
+	// bidirectional stream of the gRPC service, the messages are JSON in google.protobuf.BytesValue
+	transcriptionGRPCMethod = "/livekit.SpeechToText/Transcribe"
+)
+
+// TranscriptionStreamInfo describes the audio of a track
+type TranscriptionStreamInfo struct {
+	Room                string `json:"room"`
+	ParticipantIdentity string `json:"participant_identity"`
+	TrackID             string `json:"track_id"`
+	Language            string `json:"language,omitempty"`
+	// audio/opus, sampled at 48kHz
+	Codec string `json:"codec"`
+}
+
+// TranscriptionFrame is an Opus packet of a track, the RTP payload received from the publisher. The service decodes
+// it, frames are never PCM.
+type TranscriptionFrame struct {
+	// offset from the start of the audio of the track
+	OffsetMs int64  `json:"offset_ms"`
+	Data     []byte `json:"data"`
+}
+
+// TranscriptionAudio is sent to the speech to text service, the body of webhook requests and the messages of a
+// gRPC stream. The first message of a gRPC stream only carries Stream.
+type TranscriptionAudio struct {
+	Stream *TranscriptionStreamInfo `json:"stream,omitempty"`
+	// number of the webhook request of the track, from 0
+	Sequence int                  `json:"sequence"`
+	Frames   []TranscriptionFrame `json:"frames,omitempty"`
+	// last audio of the track, the track was unpublished
+	Final bool `json:"final,omitempty"`
+}
+
+// TranscriptionResult is received from the speech to text service, the body of webhook responses and the
+// messages of a gRPC stream
+type TranscriptionResult struct {
+	Transcripts []Transcript `json:"transcripts"`
+}
+
+// Transcript is a text recognized by the speech to text service
+type Transcript struct {
+	Text string `json:"text"`
+	// false for interim results, replaced by later transcripts with the same start
+	Final    bool   `json:"final"`
+	Language string `json:"language,omitempty"`
+	// offsets from the start of the audio of the track
+	StartOffsetMs int64 `json:"start_offset_ms"`
+	EndOffsetMs   int64 `json:"end_offset_ms"`
+}
+
+// TranscriptMessage is the payload of the data messages transcripts are published with, the data messages are
+// attributed to the speaker
+type TranscriptMessage struct {
+	ParticipantIdentity string `json:"participant_identity"`
+	TrackID             string `json:"track_id"`
+	Text                string `json:"text"`
+	Final               bool   `json:"final"`
+	Language            string `json:"language,omitempty"`
+	// unix milliseconds of the speech
+	StartTime int64 `json:"start_time"`
+	EndTime   int64 `json:"end_time"`
+}
+
+// SpeechToText is the plugin interface of the speech to text services audio is forked to
+type SpeechToText interface {
+	// NewStream starts transcribing the audio of a track, onTranscript may be called from any goroutine
+	// until the stream is closed
+	NewStream(ctx context.Context, info TranscriptionStreamInfo, onTranscript func(Transcript)) (SpeechToTextStream, error)
+}
+
+type SpeechToTextStream interface {
+	// WriteFrame is called with the frames of the track in order, from a single goroutine
+	WriteFrame(frame audiofork.Frame) error
+	// Close ends the audio of the track
+	Close() error
+}
+
+// transcriptionRoom is the room transcripts are published in
+type transcriptionRoom interface {
+	Name() livekit.RoomName
+	SendDataPacket(up *livekit.UserPacket, kind livekit.DataPacket_Kind)
+}
+
+// Transcriptions forks the microphone audio published on the node to a speech to text service and publishes
+// the transcripts in the rooms
+type Transcriptions struct {
+	conf   config.TranscriptionConfig
+	stt    SpeechToText
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock  sync.Mutex
+	forks map[livekit.TrackID]*audiofork.Fork
+}
+
+// NewTranscriptions returns nil when transcription is not enabled
+func NewTranscriptions(conf config.TranscriptionConfig) (*Transcriptions, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+
+	var stt SpeechToText
+	if conf.GRPCAddress != "" {
+		grpcSTT, err := newGRPCSpeechToText(conf)
+		if err != nil {
+			return nil, err
+		}
+		stt = grpcSTT
+	} else {
+		stt = newWebhookSpeechToText(conf)
+	}
+	return NewTranscriptionsWithSpeechToText(conf, stt), nil
+}
+
+func NewTranscriptionsWithSpeechToText(conf config.TranscriptionConfig, stt SpeechToText) *Transcriptions {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Transcriptions{
+		conf:   conf,
+		stt:    stt,
+		ctx:    ctx,
+		cancel: cancel,
+		forks:  make(map[livekit.TrackID]*audiofork.Fork),
+	}
+}
+
+// TrackPublished starts transcribing a microphone track, until it is unpublished
+func (t *Transcriptions) TrackPublished(room transcriptionRoom, participant types.LocalParticipant, track types.MediaTrack) {
+	if t == nil || track.Kind() != livekit.TrackType_AUDIO || track.Source() != livekit.TrackSource_MICROPHONE ||
+		participant.IsAgent() || participant.IsRecorder() {
+		return
+	}
+	receivers := track.Receivers()
+	if len(receivers) == 0 {
+		return
+	}
+	receiver := receivers[0].GetPrimaryReceiverForRed()
+	if !strings.EqualFold(receiver.Codec().MimeType, webrtc.MimeTypeOpus) {
+		return
+	}
+
+	pLogger := participant.GetLogger()
+	info := TranscriptionStreamInfo{
+		Room:                string(room.Name()),
+		ParticipantIdentity: string(participant.Identity()),
+		TrackID:             string(track.ID()),
+		Language:            t.conf.Language,
+		Codec:               webrtc.MimeTypeOpus,
+	}
+	// wall clock time of the start of the audio, the first frame of the fork
+	var startedAt atomic.Int64
+	stream, err := t.stt.NewStream(t.ctx, info, func(transcript Transcript) {
+		start := startedAt.Load()
+		payload, err := json.Marshal(&TranscriptMessage{
+			ParticipantIdentity: info.ParticipantIdentity,
+			TrackID:             info.TrackID,
+			Text:                transcript.Text,
+			Final:               transcript.Final,
+			Language:            transcript.Language,
+			StartTime:           start + transcript.StartOffsetMs,
+			EndTime:             start + transcript.EndOffsetMs,
+		})
+		if err != nil {
+			return
+		}
+		room.SendDataPacket(&livekit.UserPacket{
+			ParticipantSid:      string(participant.ID()),
+			ParticipantIdentity: info.ParticipantIdentity,
+			Payload:             payload,
+			Topic:               proto.String(TranscriptionTopic),
+		}, livekit.DataPacket_RELIABLE)
+	})
+	if err != nil {
+		pLogger.Warnw("could not start transcription", err, "trackID", track.ID())
+		return
+	}
+
+	var fork *audiofork.Fork
+	fork = audiofork.NewFork(audiofork.ForkParams{
+		ID:           transcriptionSubscriberPrefix + string(track.ID()),
+		SubscriberID: livekit.ParticipantID(transcriptionSubscriberPrefix + string(track.ID())),
+		QueueSize:    t.conf.QueueSize,
+		OnFrame: func(frame audiofork.Frame) {
+			startedAt.CompareAndSwap(0, frame.ReceivedAt.Add(-frame.Offset).UnixMilli())
+			if err := stream.WriteFrame(frame); err != nil {
+				pLogger.Debugw("could not write transcription audio", "error", err, "trackID", track.ID())
+			}
+		},
+		OnClose: func() {
+			if err := stream.Close(); err != nil {
+				pLogger.Debugw("could not close transcription", "error", err, "trackID", track.ID())
+			}
+			t.lock.Lock()
+			if t.forks[track.ID()] == fork {
+				delete(t.forks, track.ID())
+			}
+			t.lock.Unlock()
+			pLogger.Infow("transcription stopped", "trackID", track.ID())
+		},
+		Logger: pLogger,
+	})
+
+	t.lock.Lock()
+	// the track moved from another room, its fork is replaced in the receiver
+	previous := t.forks[track.ID()]
+	t.forks[track.ID()] = fork
+	t.lock.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+
+	if err := receiver.AddDownTrack(fork); err != nil {
+		fork.Close()
+		return
+	}
+	pLogger.Infow("transcription started", "trackID", track.ID())
+}
+
+// Close stops the transcriptions of the node
+func (t *Transcriptions) Close() {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	forks := make([]*audiofork.Fork, 0, len(t.forks))
+	for _, fork := range t.forks {
+		forks = append(forks, fork)
+	}
+	t.lock.Unlock()
+
+	for _, fork := range forks {
+		fork.Close()
+		<-fork.Done()
+	}
+	t.cancel()
+	if closer, ok := t.stt.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+func transcriptionFrame(frame audiofork.Frame) TranscriptionFrame {
+	return TranscriptionFrame{
+		OffsetMs: frame.Offset.Milliseconds(),
+		Data:     frame.Payload,
+	}
+}
+
+// ------------------------------------------------
+
+// webhookSpeechToText posts the audio of a track in segments, the response of each request carries the
+// transcripts of the segment
+type webhookSpeechToText struct {
+	conf   config.TranscriptionConfig
+	client *http.Client
+}
+
+func newWebhookSpeechToText(conf config.TranscriptionConfig) *webhookSpeechToText {
+	return &webhookSpeechToText{
+		conf:   conf,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (w *webhookSpeechToText) NewStream(ctx context.Context, info TranscriptionStreamInfo, onTranscript func(Transcript)) (SpeechToTextStream, error) {
+	return &webhookTranscriptionStream{
+		stt:          w,
+		ctx:          ctx,
+		info:         info,
+		onTranscript: onTranscript,
+	}, nil
+}
+
+type webhookTranscriptionStream struct {
+	stt          *webhookSpeechToText
+	ctx          context.Context
+	info         TranscriptionStreamInfo
+	onTranscript func(Transcript)
+
+	sequence     int
+	segmentStart time.Duration
+	frames       []TranscriptionFrame
+}
+
+func (s *webhookTranscriptionStream) WriteFrame(frame audiofork.Frame) error {
+	if len(s.frames) == 0 {
+		s.segmentStart = frame.Offset
+	}
+	s.frames = append(s.frames, transcriptionFrame(frame))
+	if frame.Offset-s.segmentStart < s.stt.conf.SegmentDuration {
+		return nil
+	}
+	return s.post(false)
+}
+
+func (s *webhookTranscriptionStream) Close() error {
+	return s.post(true)
+}
+
+func (s *webhookTranscriptionStream) post(final bool) error {
+	audio := &TranscriptionAudio{
+		Stream:   &s.info,
+		Sequence: s.sequence,
+		Frames:   s.frames,
+		Final:    final,
+	}
+	s.sequence++
+	s.frames = nil
+
+	body, err := json.Marshal(audio)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.stt.conf.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.stt.conf.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.stt.conf.AuthToken)
+	}
+
+	res, err := s.stt.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("transcription webhook returned %s", res.Status)
+	}
+
+	var result TranscriptionResult
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&result); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	for _, transcript := range result.Transcripts {
+		s.onTranscript(transcript)
+	}
+	return nil
+}
+
+// ------------------------------------------------
+
+// grpcSpeechToText streams the audio of a track to a gRPC service, frame by frame, and receives the
+// transcripts on the same stream
+type grpcSpeechToText struct {
+	conf config.TranscriptionConfig
+	conn *grpc.ClientConn
+}
+
+func newGRPCSpeechToText(conf config.TranscriptionConfig) (*grpcSpeechToText, error) {
+	creds := credentials.NewTLS(&tls.Config{})
+	if conf.GRPCInsecure {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.Dial(conf.GRPCAddress, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &grpcSpeechToText{
+		conf: conf,
+		conn: conn,
+	}, nil
+}
+
+func (g *grpcSpeechToText) Close() error {
+	return g.conn.Close()
+}
+
+var transcriptionStreamDesc = &grpc.StreamDesc{
+	StreamName:    "Transcribe",
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+func (g *grpcSpeechToText) NewStream(ctx context.Context, info TranscriptionStreamInfo, onTranscript func(Transcript)) (SpeechToTextStream, error) {
+	if g.conf.AuthToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+g.conf.AuthToken)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := g.conn.NewStream(ctx, transcriptionStreamDesc, transcriptionGRPCMethod)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &grpcTranscriptionStream{
+		stream: stream,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	if err := s.send(&TranscriptionAudio{Stream: &info}); err != nil {
+		cancel()
+		return nil, err
+	}
+	go s.receive(onTranscript)
+	return s, nil
+}
+
+type grpcTranscriptionStream struct {
+	stream   grpc.ClientStream
+	cancel   context.CancelFunc
+	done     chan struct{}
+	sequence int
+}
+
+func (s *grpcTranscriptionStream) WriteFrame(frame audiofork.Frame) error {
+	s.sequence++
+	return s.send(&TranscriptionAudio{
+		Sequence: s.sequence,
+		Frames:   []TranscriptionFrame{transcriptionFrame(frame)},
+	})
+}
+
+// Close waits for the service to end the stream after the last transcripts, or for the stream to time out
+func (s *grpcTranscriptionStream) Close() error {
+	err := s.stream.CloseSend()
+	select {
+	case <-s.done:
+	case <-time.After(10 * time.Second):
+	}
+	s.cancel()
+	return err
+}
+
+func (s *grpcTranscriptionStream) send(audio *TranscriptionAudio) error {
+	data, err := json.Marshal(audio)
+	if err != nil {
+		return err
+	}
+	return s.stream.SendMsg(wrapperspb.Bytes(data))
+}
+
+func (s *grpcTranscriptionStream) receive(onTranscript func(Transcript)) {
+	defer close(s.done)
+	for {
+		msg := &wrapperspb.BytesValue{}
+		if err := s.stream.RecvMsg(msg); err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Debugw("transcription stream ended", "error", err)
+			}
+			return
+		}
+		var result TranscriptionResult
+		if err := json.Unmarshal(msg.Value, &result); err != nil {
+			logger.Debugw("could not parse transcription result", "error", err)
+			continue
+		}
+		for _, transcript := range result.Transcripts {
+			onTranscript(transcript)
+		}
+	}
+}
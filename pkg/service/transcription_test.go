@@ -0,0 +1,180 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+type transcriptionRoom struct {
+	lock    sync.Mutex
+	packets []*livekit.UserPacket
+}
+
+func (r *transcriptionRoom) Name() livekit.RoomName {
+	return "room"
+}
+
+func (r *transcriptionRoom) SendDataPacket(up *livekit.UserPacket, _ livekit.DataPacket_Kind) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.packets = append(r.packets, up)
+}
+
+func (r *transcriptionRoom) Packets() []*livekit.UserPacket {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*livekit.UserPacket(nil), r.packets...)
+}
+
+type transcriptionReceiver struct {
+	sfu.TrackReceiver
+	sender sfu.TrackSender
+}
+
+func (r *transcriptionReceiver) Codec() webrtc.RTPCodecParameters {
+	return webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}}
+}
+
+func (r *transcriptionReceiver) GetPrimaryReceiverForRed() sfu.TrackReceiver {
+	return r
+}
+
+func (r *transcriptionReceiver) AddDownTrack(track sfu.TrackSender) error {
+	r.sender = track
+	return nil
+}
+
+func TestTranscriptions(t *testing.T) {
+	requests := make(chan *service.TranscriptionAudio, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		audio := &service.TranscriptionAudio{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(audio))
+		requests <- audio
+
+		var result service.TranscriptionResult
+		if !audio.Final {
+			result.Transcripts = []service.Transcript{{Text: "hello", Final: true, StartOffsetMs: 0, EndOffsetMs: 20}}
+		}
+		_ = json.NewEncoder(w).Encode(&result)
+	}))
+	defer server.Close()
+
+	transcriptions, err := service.NewTranscriptions(config.TranscriptionConfig{
+		Enabled:         true,
+		WebhookURL:      server.URL,
+		AuthToken:       "token",
+		SegmentDuration: 20 * time.Millisecond,
+		QueueSize:       10,
+	})
+	require.NoError(t, err)
+	defer transcriptions.Close()
+
+	room := &transcriptionRoom{}
+	participant := &typesfakes.FakeLocalParticipant{}
+	participant.IdentityReturns("speaker")
+	participant.IDReturns("PA_speaker")
+	participant.GetLoggerReturns(logger.GetLogger())
+
+	receiver := &transcriptionReceiver{}
+	track := &typesfakes.FakeMediaTrack{}
+	track.IDReturns("TR_audio")
+	track.KindReturns(livekit.TrackType_AUDIO)
+	track.SourceReturns(livekit.TrackSource_MICROPHONE)
+	track.ReceiversReturns([]sfu.TrackReceiver{receiver})
+
+	transcriptions.TrackPublished(room, participant, track)
+	require.NotNil(t, receiver.sender)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, receiver.sender.WriteRTP(&buffer.ExtPacket{
+			Arrival:      time.Now(),
+			ExtTimestamp: uint64(1000 + i*960),
+			Packet:       &rtp.Packet{Payload: []byte{byte(i)}},
+		}, 0))
+	}
+
+	select {
+	case audio := <-requests:
+		require.Equal(t, "speaker", audio.Stream.ParticipantIdentity)
+		require.Equal(t, "TR_audio", audio.Stream.TrackID)
+		require.Equal(t, []service.TranscriptionFrame{
+			{OffsetMs: 0, Data: []byte{0}},
+			{OffsetMs: 20, Data: []byte{1}},
+		}, audio.Frames)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no transcription request")
+	}
+
+	require.Eventually(t, func() bool {
+		return len(room.Packets()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	packet := room.Packets()[0]
+	require.Equal(t, service.TranscriptionTopic, packet.GetTopic())
+	require.Equal(t, "speaker", packet.ParticipantIdentity)
+	msg := &service.TranscriptMessage{}
+	require.NoError(t, json.Unmarshal(packet.Payload, msg))
+	require.Equal(t, "hello", msg.Text)
+	require.True(t, msg.Final)
+	require.Equal(t, int64(20), msg.EndTime-msg.StartTime)
+
+	// the receiver closes its down tracks when the track is unpublished
+	receiver.sender.Close()
+	select {
+	case audio := <-requests:
+		require.True(t, audio.Final)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no final transcription request")
+	}
+}
+
+func TestTranscriptionsSkipsOtherTracks(t *testing.T) {
+	transcriptions, err := service.NewTranscriptions(config.TranscriptionConfig{
+		Enabled:         true,
+		WebhookURL:      "http://localhost",
+		SegmentDuration: time.Second,
+		QueueSize:       10,
+	})
+	require.NoError(t, err)
+	defer transcriptions.Close()
+
+	receiver := &transcriptionReceiver{}
+	track := &typesfakes.FakeMediaTrack{}
+	track.KindReturns(livekit.TrackType_AUDIO)
+	track.SourceReturns(livekit.TrackSource_SCREEN_SHARE_AUDIO)
+	track.ReceiversReturns([]sfu.TrackReceiver{receiver})
+
+	transcriptions.TrackPublished(&transcriptionRoom{}, &typesfakes.FakeLocalParticipant{}, track)
+	require.Nil(t, receiver.sender)
+}
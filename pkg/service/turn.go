@@ -21,6 +21,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/jxskiss/base62"
 	"github.com/pion/turn/v2"
@@ -83,7 +84,32 @@ func NewTurnServer(conf *config.Config, authHandler turn.AuthHandler, standalone
 			return nil, errors.New("TURN domain is not correct")
 		}
 
-		if !turnConf.ExternalTLS {
+		if turnConf.ACME.Enabled {
+			certACME, err := newTURNACME(turnConf)
+			if err != nil {
+				return nil, err
+			}
+
+			tcpListener, err := tls.Listen("tcp4", "0.0.0.0:"+strconv.Itoa(turnConf.TLSPort), certACME.TLSConfig())
+			if err != nil {
+				return nil, errors.Wrap(err, "could not listen on TURN TCP port")
+			}
+			tlsListener, err := certACME.Listen(tcpListener)
+			if err != nil {
+				_ = tcpListener.Close()
+				return nil, err
+			}
+			if standalone {
+				tlsListener = telemetry.NewListener(tlsListener)
+			}
+
+			listenerConfig := turn.ListenerConfig{
+				Listener:              tlsListener,
+				RelayAddressGenerator: relayAddrGen,
+			}
+			serverConfig.ListenerConfigs = append(serverConfig.ListenerConfigs, listenerConfig)
+			logValues = append(logValues, "turn.acme", true)
+		} else if !turnConf.ExternalTLS {
 			cert, err := tls.LoadX509KeyPair(turnConf.CertFile, turnConf.KeyFile)
 			if err != nil {
 				return nil, errors.Wrap(err, "TURN tls cert required")
@@ -146,6 +172,56 @@ func NewTurnServer(conf *config.Config, authHandler turn.AuthHandler, standalone
 	return turn.NewServer(serverConfig)
 }
 
+// InProcessTURNServer is the TURN server embedded in the node. It is created with the node, or in the background
+// once the node serves requests when initialized lazily.
+type InProcessTURNServer struct {
+	conf        *config.Config
+	authHandler turn.AuthHandler
+
+	lock   sync.Mutex
+	server *turn.Server
+	closed bool
+}
+
+func NewInProcessTURNServer(conf *config.Config, authHandler turn.AuthHandler) (*InProcessTURNServer, error) {
+	s := &InProcessTURNServer{
+		conf:        conf,
+		authHandler: authHandler,
+	}
+	if conf.Startup.LazyInit {
+		return s, nil
+	}
+	return s, s.Start()
+}
+
+// Start creates the TURN server if it is not running yet
+func (s *InProcessTURNServer) Start() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.server != nil || s.closed {
+		return nil
+	}
+
+	server, err := NewTurnServer(s.conf, s.authHandler, false)
+	if err != nil {
+		return err
+	}
+	s.server = server
+	return nil
+}
+
+func (s *InProcessTURNServer) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
 func getTURNAuthHandlerFunc(handler *TURNAuthHandler) turn.AuthHandler {
 	return handler.HandleAuth
 }
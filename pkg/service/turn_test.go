@@ -0,0 +1,59 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestInProcessTURNServerLazyInit(t *testing.T) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := pc.LocalAddr().(*net.UDPAddr).Port
+	require.NoError(t, pc.Close())
+
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	conf.TURN.Enabled = true
+	conf.TURN.UDPPort = port
+	conf.Startup.LazyInit = true
+
+	s, err := service.NewInProcessTURNServer(conf, nil)
+	require.NoError(t, err)
+
+	// the port is free until the server is started
+	pc, err = net.ListenPacket("udp4", net.JoinHostPort("0.0.0.0", strconv.Itoa(port)))
+	require.NoError(t, err)
+	require.NoError(t, pc.Close())
+
+	require.NoError(t, s.Start())
+	require.NoError(t, s.Start())
+	_, err = net.ListenPacket("udp4", net.JoinHostPort("0.0.0.0", strconv.Itoa(port)))
+	require.Error(t, err)
+
+	require.NoError(t, s.Close())
+	// a deferred start after close does not create the server
+	require.NoError(t, s.Start())
+	pc, err = net.ListenPacket("udp4", net.JoinHostPort("0.0.0.0", strconv.Itoa(port)))
+	require.NoError(t, err)
+	require.NoError(t, pc.Close())
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// turnALPNProto is the ALPN protocol of TURN over TLS (RFC 7443)
+const turnALPNProto = "stun.turn"
+
+// turnACME obtains the TURN/TLS certificate from an ACME certificate authority. Certificates are renewed
+// before they expire and used for new connections without a restart.
+type turnACME struct {
+	domain  string
+	manager *autocert.Manager
+
+	challengeServer *http.Server
+}
+
+func newTURNACME(conf config.TURNConfig) (*turnACME, error) {
+	if conf.ExternalTLS {
+		return nil, errors.New("TURN acme cannot be used with external_tls")
+	}
+	if conf.ACME.CacheDir == "" {
+		return nil, errors.New("TURN acme cache_dir required")
+	}
+	if conf.ACME.HTTPPort == 0 && conf.TLSPort != 443 {
+		logger.Infow("TURN acme uses tls-alpn-01 challenges, the TLS port has to be reachable on port 443",
+			"turn.portTLS", conf.TLSPort)
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(conf.ACME.CacheDir),
+		HostPolicy: autocert.HostWhitelist(conf.Domain),
+		Email:      conf.ACME.Email,
+	}
+	if conf.ACME.DirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: conf.ACME.DirectoryURL}
+	}
+
+	a := &turnACME{
+		domain:  conf.Domain,
+		manager: m,
+	}
+	if conf.ACME.HTTPPort > 0 {
+		a.challengeServer = &http.Server{
+			Addr:              ":" + strconv.Itoa(conf.ACME.HTTPPort),
+			Handler:           m.HTTPHandler(http.NotFoundHandler()),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+	return a, nil
+}
+
+func (a *turnACME) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// TURN clients connecting by address do not send a server name
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName == "" {
+				hello.ServerName = a.domain
+			}
+			return a.manager.GetCertificate(hello)
+		},
+		// clients offering stun.turn are rejected unless it is listed along with the tls-alpn-01 challenge protocol
+		NextProtos: []string{turnALPNProto, acme.ALPNProto},
+	}
+}
+
+// Listen starts answering challenges and wraps the TURN/TLS listener, so that challenges stop with the TURN server.
+// The certificate is fetched in the background when it is not cached, rather than by the first client.
+func (a *turnACME) Listen(tlsListener net.Listener) (net.Listener, error) {
+	if a.challengeServer != nil {
+		challengeListener, err := net.Listen("tcp", a.challengeServer.Addr)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not listen on TURN acme http port")
+		}
+		go func() {
+			if err := a.challengeServer.Serve(challengeListener); err != nil && err != http.ErrServerClosed {
+				logger.Errorw("TURN acme challenge server failed", err)
+			}
+		}()
+	}
+
+	go func() {
+		// an ECDSA certificate, the one modern clients get
+		hello := &tls.ClientHelloInfo{
+			ServerName:   a.domain,
+			CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		}
+		if _, err := a.manager.GetCertificate(hello); err != nil {
+			logger.Errorw("could not obtain TURN certificate", err, "domain", a.domain)
+			return
+		}
+		logger.Infow("TURN certificate ready", "domain", a.domain)
+	}()
+
+	return &turnACMEListener{Listener: tlsListener, acme: a}, nil
+}
+
+func (a *turnACME) Close() {
+	if a.challengeServer != nil {
+		_ = a.challengeServer.Close()
+	}
+}
+
+type turnACMEListener struct {
+	net.Listener
+	acme      *turnACME
+	closeOnce sync.Once
+}
+
+func (l *turnACMEListener) Close() error {
+	l.closeOnce.Do(l.acme.Close)
+	return l.Listener.Close()
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestTURNACME(t *testing.T) {
+	const domain = "turn.example.com"
+
+	newConf := func(t *testing.T) *config.Config {
+		conf, err := config.NewConfig("", true, nil, nil)
+		require.NoError(t, err)
+		conf.RTC.NodeIP = "127.0.0.1"
+		conf.TURN.Enabled = true
+		conf.TURN.Domain = domain
+		conf.TURN.TLSPort = freeTCPPort(t)
+		conf.TURN.ACME = config.TURNACMEConfig{
+			Enabled:  true,
+			CacheDir: t.TempDir(),
+			// nothing is requested from the certificate authority while the cached certificate is valid
+			DirectoryURL: "http://127.0.0.1:1/directory",
+		}
+		return conf
+	}
+
+	t.Run("serves the cached certificate", func(t *testing.T) {
+		conf := newConf(t)
+		writeACMECachedCert(t, conf.TURN.ACME.CacheDir, domain)
+
+		server, err := service.NewTurnServer(conf, nil, false)
+		require.NoError(t, err)
+		defer server.Close()
+
+		// clients connecting by address do not send a server name
+		conn, err := tls.Dial("tcp4", "127.0.0.1:"+strconv.Itoa(conf.TURN.TLSPort), &tls.Config{InsecureSkipVerify: true})
+		require.NoError(t, err)
+		defer conn.Close()
+		require.Equal(t, []string{domain}, conn.ConnectionState().PeerCertificates[0].DNSNames)
+
+		// TURN clients negotiating the protocol of RFC 7443
+		turnConn, err := tls.Dial("tcp4", "127.0.0.1:"+strconv.Itoa(conf.TURN.TLSPort), &tls.Config{
+			InsecureSkipVerify: true,
+			NextProtos:         []string{"stun.turn"},
+		})
+		require.NoError(t, err)
+		defer turnConn.Close()
+		require.Equal(t, "stun.turn", turnConn.ConnectionState().NegotiatedProtocol)
+	})
+
+	t.Run("cannot be used with external tls", func(t *testing.T) {
+		conf := newConf(t)
+		conf.TURN.ExternalTLS = true
+		_, err := service.NewTurnServer(conf, nil, false)
+		require.Error(t, err)
+	})
+}
+
+func freeTCPPort(t *testing.T) int {
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// writeACMECachedCert stores a certificate for domain in the layout of the autocert cache
+func writeACMECachedCert(t *testing.T, dir string, domain string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, pem.Encode(&buf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	require.NoError(t, pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, domain), buf.Bytes(), 0600))
+}
@@ -0,0 +1,189 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/twitchtv/twirp"
+	"github.com/twitchtv/twirp/ctxsetters"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// TwirpJSONHandler serves a single Twirp method using the JSON encoding. It is used for methods and
+// request fields the server supports on top of the protocol definitions, and runs the same server
+// hooks as the generated Twirp servers. Requests in any other encoding are passed to fallback.
+type TwirpJSONHandler struct {
+	pkg      string
+	service  string
+	method   string
+	hooks    *twirp.ServerHooks
+	handle   func(ctx context.Context, body []byte) (any, error)
+	fallback http.Handler
+}
+
+// NewTwirpJSONHandler creates a handler for the method of a fully qualified service, e.g. livekit.RoomService
+func NewTwirpJSONHandler(
+	service string,
+	method string,
+	hooks *twirp.ServerHooks,
+	handle func(ctx context.Context, body []byte) (any, error),
+	fallback http.Handler,
+) *TwirpJSONHandler {
+	pkg, name, _ := strings.Cut(service, ".")
+	return &TwirpJSONHandler{
+		pkg:      pkg,
+		service:  name,
+		method:   method,
+		hooks:    hooks,
+		handle:   handle,
+		fallback: fallback,
+	}
+}
+
+func (h *TwirpJSONHandler) Path() string {
+	return fmt.Sprintf("/twirp/%s.%s/%s", h.pkg, h.service, h.method)
+}
+
+func (h *TwirpJSONHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	contentType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";")
+	isJSON := strings.EqualFold(strings.TrimSpace(contentType), "application/json")
+	if !isJSON && h.fallback != nil {
+		h.fallback.ServeHTTP(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	ctx = ctxsetters.WithPackageName(ctx, h.pkg)
+	ctx = ctxsetters.WithServiceName(ctx, h.service)
+	ctx = ctxsetters.WithResponseWriter(ctx, w)
+
+	var err error
+	if h.hooks != nil && h.hooks.RequestReceived != nil {
+		if ctx, err = h.hooks.RequestReceived(ctx); err != nil {
+			h.writeError(ctx, w, err)
+			return
+		}
+	}
+
+	if r.Method != http.MethodPost {
+		h.writeError(ctx, w, twirp.NewError(twirp.BadRoute, fmt.Sprintf("unsupported method %q (only POST is allowed)", r.Method)))
+		return
+	}
+	if !isJSON {
+		h.writeError(ctx, w, twirp.NewError(twirp.BadRoute, fmt.Sprintf("unexpected Content-Type: %q", r.Header.Get("Content-Type"))))
+		return
+	}
+
+	ctx = ctxsetters.WithMethodName(ctx, h.method)
+	if h.hooks != nil && h.hooks.RequestRouted != nil {
+		if ctx, err = h.hooks.RequestRouted(ctx); err != nil {
+			h.writeError(ctx, w, err)
+			return
+		}
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.writeError(ctx, w, twirp.WrapError(twirp.NewError(twirp.Malformed, "failed to read request body"), err))
+		return
+	}
+
+	res, err := h.handle(ctx, body)
+	if err != nil {
+		h.writeError(ctx, w, err)
+		return
+	}
+
+	writeTwirpJSONResponse(ctx, w, h.hooks, res)
+}
+
+func (h *TwirpJSONHandler) writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	writeTwirpJSONError(ctx, w, h.hooks, err)
+}
+
+// writeTwirpJSONResponse encodes res as the generated Twirp servers do, protocol messages with their proto field names
+func writeTwirpJSONResponse(ctx context.Context, w http.ResponseWriter, hooks *twirp.ServerHooks, res any) {
+	if hooks != nil && hooks.ResponsePrepared != nil {
+		ctx = hooks.ResponsePrepared(ctx)
+	}
+
+	var data []byte
+	var err error
+	if msg, ok := res.(proto.Message); ok {
+		data, err = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(msg)
+	} else {
+		data, err = json.Marshal(res)
+	}
+	if err != nil {
+		writeTwirpJSONError(ctx, w, hooks, twirp.InternalErrorWith(err))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+
+	if hooks != nil && hooks.ResponseSent != nil {
+		hooks.ResponseSent(ctx)
+	}
+}
+
+func writeTwirpJSONError(ctx context.Context, w http.ResponseWriter, hooks *twirp.ServerHooks, err error) {
+	var twerr twirp.Error
+	if !errors.As(err, &twerr) {
+		twerr = twirp.InternalErrorWith(err)
+	}
+
+	statusCode := twirp.ServerHTTPStatusFromErrorCode(twerr.Code())
+	ctx = ctxsetters.WithStatusCode(ctx, statusCode)
+	if hooks != nil && hooks.Error != nil {
+		ctx = hooks.Error(ctx, twerr)
+	}
+
+	_ = twirp.WriteError(w, twerr)
+
+	if hooks != nil && hooks.ResponseSent != nil {
+		hooks.ResponseSent(ctx)
+	}
+}
+
+// UnmarshalTwirpJSON decodes a request body into its protocol message, ignoring unknown fields, and
+// into any extensions that carry the fields the protocol message does not define
+func UnmarshalTwirpJSON(body []byte, msg proto.Message, extensions ...any) error {
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, msg); err != nil {
+		return malformedJSONError(err)
+	}
+	for _, ext := range extensions {
+		if err := json.Unmarshal(body, ext); err != nil {
+			return malformedJSONError(err)
+		}
+	}
+	return nil
+}
+
+func malformedJSONError(err error) twirp.Error {
+	return twirp.WrapError(twirp.NewError(twirp.Malformed, "the json request could not be decoded"), err)
+}
@@ -16,10 +16,12 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net"
 	"net/http"
 	"regexp"
+	"strings"
 
 	"github.com/livekit/protocol/logger"
 )
@@ -32,6 +34,13 @@ func handleError(w http.ResponseWriter, r *http.Request, status int, err error,
 	if !errors.Is(err, context.Canceled) {
 		logger.GetLogger().WithCallDepth(1).Warnw("error handling request", err, keysAndValues...)
 	}
+	var validationErr *ParticipantValidationError
+	if errors.As(err, &validationErr) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(validationErr)
+		return
+	}
 	w.WriteHeader(status)
 	_, _ = w.Write([]byte(err.Error()))
 }
@@ -45,17 +54,60 @@ func IsValidDomain(domain string) bool {
 	return domainRegexp.MatchString(domain)
 }
 
-func GetClientIP(r *http.Request) string {
-	// CF proxy typically is first thing the user reaches
-	if ip := r.Header.Get("CF-Connecting-IP"); ip != "" {
-		return ip
+// GetClientIP returns the IP of the client of r. Forwarding headers are set by the client unless a proxy
+// overwrites them, so they are only used when the peer of r is one of trustedProxies. The client is then the
+// right-most hop of X-Forwarded-For that is not a trusted proxy
+func GetClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
 	}
-	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
+	if !ipInNetworks(net.ParseIP(ip), trustedProxies) {
 		return ip
 	}
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) != 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := net.ParseIP(strings.TrimSpace(hops[i]))
+			if hop == nil {
+				break
+			}
+			ip = hop.String()
+			if !ipInNetworks(hop, trustedProxies) {
+				break
+			}
+		}
 		return ip
 	}
-	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
+	// CF proxy typically is first thing the user reaches
+	for _, header := range []string{"CF-Connecting-IP", "X-Real-IP"} {
+		if hop := net.ParseIP(strings.TrimSpace(r.Header.Get(header))); hop != nil {
+			return hop.String()
+		}
+	}
 	return ip
 }
+
+// ParseTrustedProxies returns the networks of CIDR ranges, ranges that cannot be parsed are skipped
+func ParseTrustedProxies(cidrs []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+func ipInNetworks(ip net.IP, networks []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
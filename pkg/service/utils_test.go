@@ -15,6 +15,8 @@
 package service_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/redis/go-redis/v9"
@@ -41,3 +43,47 @@ func TestIsValidDomain(t *testing.T) {
 		require.Equal(t, service.IsValidDomain(key), result)
 	}
 }
+
+func TestGetClientIP(t *testing.T) {
+	trustedProxies := service.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	clientIP := func(remoteAddr string, headers map[string]string) string {
+		r := httptest.NewRequest(http.MethodGet, "/rtc", nil)
+		r.RemoteAddr = remoteAddr
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+		return service.GetClientIP(r, trustedProxies)
+	}
+
+	t.Run("headers of untrusted peers are ignored", func(t *testing.T) {
+		require.Equal(t, "203.0.113.1", clientIP("203.0.113.1:5000", nil))
+		require.Equal(t, "203.0.113.1", clientIP("203.0.113.1:5000", map[string]string{
+			"X-Forwarded-For":  "198.51.100.1",
+			"CF-Connecting-IP": "198.51.100.2",
+			"X-Real-IP":        "198.51.100.3",
+		}))
+	})
+
+	t.Run("right-most untrusted hop of a trusted peer", func(t *testing.T) {
+		require.Equal(t, "203.0.113.1", clientIP("10.0.0.1:5000", map[string]string{
+			"X-Forwarded-For": "198.51.100.1, 203.0.113.1, 10.0.0.2",
+		}))
+		require.Equal(t, "198.51.100.1", clientIP("10.0.0.1:5000", map[string]string{
+			"X-Forwarded-For": "198.51.100.1, 10.0.0.3, 10.0.0.2",
+		}))
+		require.Equal(t, "10.0.0.1", clientIP("10.0.0.1:5000", map[string]string{
+			"X-Forwarded-For": "unknown",
+		}))
+	})
+
+	t.Run("other headers of a trusted peer", func(t *testing.T) {
+		require.Equal(t, "198.51.100.2", clientIP("10.0.0.1:5000", map[string]string{
+			"CF-Connecting-IP": "198.51.100.2",
+			"X-Real-IP":        "198.51.100.3",
+		}))
+		require.Equal(t, "198.51.100.3", clientIP("10.0.0.1:5000", map[string]string{
+			"X-Real-IP": "198.51.100.3",
+		}))
+		require.Equal(t, "10.0.0.1", clientIP("10.0.0.1:5000", nil))
+	})
+}
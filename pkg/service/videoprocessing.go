@@ -0,0 +1,78 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+// VideoProcessingInfo describes a track processed for egress
+type VideoProcessingInfo struct {
+	Room                livekit.RoomName
+	ParticipantIdentity livekit.ParticipantIdentity
+	Track               *livekit.TrackInfo
+	// video_processing params of the room options
+	Params map[string]string
+}
+
+// EgressVideoProcessor is the plugin interface of the processing of the video delivered to egress, e.g. watermarks
+// or blurring. It is compiled into the server and set with RoomManager.SetVideoProcessor, rooms select the tracks
+// it processes with their video_processing options.
+type EgressVideoProcessor interface {
+	// Process decodes the frames of source, modifies them and encodes them again. The returned receiver closes
+	// when source closes, egress participants subscribe to it in place of source
+	Process(source sfu.TrackReceiver, info VideoProcessingInfo) (sfu.TrackReceiver, error)
+}
+
+// roomVideoProcessor processes the tracks of a participant selected by the options of its room
+type roomVideoProcessor struct {
+	processor EgressVideoProcessor
+	identity  livekit.ParticipantIdentity
+	// the room of the participant, which changes when it moves
+	room func() *rtc.Room
+}
+
+func newRoomVideoProcessor(processor EgressVideoProcessor, identity livekit.ParticipantIdentity, room func() *rtc.Room) types.VideoProcessor {
+	if processor == nil {
+		return nil
+	}
+	return &roomVideoProcessor{
+		processor: processor,
+		identity:  identity,
+		room:      room,
+	}
+}
+
+func (p *roomVideoProcessor) Process(source sfu.TrackReceiver, track *livekit.TrackInfo) (sfu.TrackReceiver, error) {
+	room := p.room()
+	if room == nil {
+		return nil, nil
+	}
+	options := room.Options().VideoProcessing
+	if !options.Selects(p.identity, track) {
+		return nil, nil
+	}
+
+	return p.processor.Process(source, VideoProcessingInfo{
+		Room:                room.Name(),
+		ParticipantIdentity: p.identity,
+		Track:               track,
+		Params:              options.Params,
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// ReloadableNotifier sends webhooks to the URLs and endpoints of the current config, they can be replaced on reload
+// while events queued for the previous ones are still delivered. URLs get all events signed with the webhook
+// API key, endpoints get the events they filter for signed with their own key.
+type ReloadableNotifier struct {
+	apiKey   string
+	provider auth.KeyProvider
+
+	lock      sync.RWMutex
+	urls      []string
+	endpoints []config.WebHookEndpointConfig
+	// secrets of the signing keys, the notifiers are replaced when one of them is rotated
+	secrets   map[string]string
+	notifiers []*webhookEndpointNotifier
+}
+
+type webhookEndpointNotifier struct {
+	// all events when nil
+	events   map[string]struct{}
+	notifier webhook.QueuedNotifier
+}
+
+func (e *webhookEndpointNotifier) wants(event string) bool {
+	if e.events == nil {
+		return true
+	}
+	_, ok := e.events[event]
+	return ok
+}
+
+func NewReloadableNotifier(apiKey string, provider auth.KeyProvider, urls []string, endpoints []config.WebHookEndpointConfig) *ReloadableNotifier {
+	n := &ReloadableNotifier{
+		apiKey:   apiKey,
+		provider: provider,
+	}
+	n.SetEndpoints(urls, endpoints)
+	return n
+}
+
+func (n *ReloadableNotifier) SetEndpoints(urls []string, endpoints []config.WebHookEndpointConfig) {
+	secrets := n.signingSecrets(urls, endpoints)
+
+	n.lock.Lock()
+	if reflect.DeepEqual(n.urls, urls) && reflect.DeepEqual(n.endpoints, endpoints) && maps.Equal(n.secrets, secrets) {
+		n.lock.Unlock()
+		return
+	}
+	prev := n.notifiers
+	n.urls = urls
+	n.endpoints = endpoints
+	n.secrets = secrets
+	n.notifiers = n.newNotifiers(urls, endpoints)
+	n.lock.Unlock()
+
+	for _, p := range prev {
+		if p, ok := p.notifier.(*webhook.DefaultNotifier); ok {
+			// let the previous notifier drain its queue
+			go p.Stop(false)
+		}
+	}
+}
+
+func (n *ReloadableNotifier) signingSecrets(urls []string, endpoints []config.WebHookEndpointConfig) map[string]string {
+	secrets := make(map[string]string)
+	if len(urls) > 0 {
+		secrets[n.apiKey] = n.provider.GetSecret(n.apiKey)
+	}
+	for _, endpoint := range endpoints {
+		apiKey := endpoint.SigningKey(n.apiKey)
+		secrets[apiKey] = n.provider.GetSecret(apiKey)
+	}
+	return secrets
+}
+
+func (n *ReloadableNotifier) newNotifiers(urls []string, endpoints []config.WebHookEndpointConfig) []*webhookEndpointNotifier {
+	var notifiers []*webhookEndpointNotifier
+	if len(urls) > 0 {
+		if secret := n.provider.GetSecret(n.apiKey); secret != "" {
+			notifiers = append(notifiers, &webhookEndpointNotifier{
+				notifier: webhook.NewDefaultNotifier(n.apiKey, secret, urls),
+			})
+		} else {
+			logger.Warnw("ignoring webhook urls", ErrWebHookMissingAPIKey, "apiKey", n.apiKey)
+		}
+	}
+
+	for _, endpoint := range endpoints {
+		apiKey := endpoint.SigningKey(n.apiKey)
+		secret := n.provider.GetSecret(apiKey)
+		if secret == "" {
+			logger.Warnw("ignoring webhook endpoint", ErrWebHookMissingAPIKey, "url", endpoint.URL, "apiKey", apiKey)
+			continue
+		}
+
+		en := &webhookEndpointNotifier{
+			notifier: webhook.NewDefaultNotifier(apiKey, secret, []string{endpoint.URL}),
+		}
+		if len(endpoint.Events) > 0 {
+			en.events = make(map[string]struct{}, len(endpoint.Events))
+			for _, event := range endpoint.Events {
+				en.events[event] = struct{}{}
+			}
+		}
+		notifiers = append(notifiers, en)
+	}
+	return notifiers
+}
+
+func (n *ReloadableNotifier) QueueNotify(ctx context.Context, event *livekit.WebhookEvent) error {
+	n.lock.RLock()
+	notifiers := n.notifiers
+	n.lock.RUnlock()
+
+	var errs []error
+	for _, en := range notifiers {
+		if !en.wants(event.Event) {
+			continue
+		}
+		if err := en.notifier.QueueNotify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *ReloadableNotifier) onConfigReload(rc *config.ReloadableConfig) {
+	n.SetEndpoints(rc.WebHookURLs, rc.WebHookEndpoints)
+}
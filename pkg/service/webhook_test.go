@@ -0,0 +1,94 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestReloadableNotifierEndpoints(t *testing.T) {
+	keys := map[string]string{
+		"default": "default-secret",
+		"billing": "billing-secret",
+	}
+	provider := auth.NewFileBasedKeyProviderFromMap(keys)
+	receiver := func(verifyWith auth.KeyProvider) (*httptest.Server, chan string) {
+		events := make(chan string, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			event, err := webhook.ReceiveWebhookEvent(r, verifyWith)
+			require.NoError(t, err)
+			events <- event.Event
+		}))
+		return server, events
+	}
+	all, allEvents := receiver(auth.NewSimpleKeyProvider("default", "default-secret"))
+	defer all.Close()
+	billing, billingEvents := receiver(auth.NewSimpleKeyProvider("billing", "billing-secret"))
+	defer billing.Close()
+	moderation, moderationEvents := receiver(auth.NewSimpleKeyProvider("default", "default-secret"))
+	defer moderation.Close()
+
+	n := service.NewReloadableNotifier("default", provider, []string{all.URL}, []config.WebHookEndpointConfig{
+		{URL: billing.URL, APIKey: "billing", Events: []string{webhook.EventRoomFinished}},
+		{URL: moderation.URL, Events: []string{webhook.EventTrackPublished}},
+	})
+	ctx := context.Background()
+	for _, event := range []string{webhook.EventTrackPublished, webhook.EventRoomFinished} {
+		require.NoError(t, n.QueueNotify(ctx, &livekit.WebhookEvent{Event: event, Room: &livekit.Room{Name: "room"}}))
+	}
+
+	receive := func(events chan string) []string {
+		var received []string
+		for {
+			select {
+			case event := <-events:
+				received = append(received, event)
+			case <-time.After(200 * time.Millisecond):
+				return received
+			}
+		}
+	}
+	require.Equal(t, []string{webhook.EventTrackPublished, webhook.EventRoomFinished}, receive(allEvents))
+	require.Equal(t, []string{webhook.EventRoomFinished}, receive(billingEvents))
+	require.Equal(t, []string{webhook.EventTrackPublished}, receive(moderationEvents))
+
+	// endpoints whose key has no secret are skipped
+	n.SetEndpoints(nil, []config.WebHookEndpointConfig{{URL: billing.URL, APIKey: "unknown"}})
+	require.NoError(t, n.QueueNotify(ctx, &livekit.WebhookEvent{Event: webhook.EventRoomFinished}))
+	require.Empty(t, receive(billingEvents))
+
+	// a rotated key is picked up on reload, even though the endpoints did not change
+	rotated, rotatedEvents := receiver(auth.NewSimpleKeyProvider("billing", "rotated-secret"))
+	defer rotated.Close()
+	endpoints := []config.WebHookEndpointConfig{{URL: rotated.URL, APIKey: "billing"}}
+	n.SetEndpoints(nil, endpoints)
+	keys["billing"] = "rotated-secret"
+	n.SetEndpoints(nil, endpoints)
+	require.NoError(t, n.QueueNotify(ctx, &livekit.WebhookEvent{Event: webhook.EventRoomFinished}))
+	require.Equal(t, []string{webhook.EventRoomFinished}, receive(rotatedEvents))
+}
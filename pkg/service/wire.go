@@ -18,10 +18,12 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/google/wire"
+	"github.com/nats-io/nats.go"
 	"github.com/pion/turn/v2"
 	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
@@ -35,7 +37,6 @@ import (
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
-	redisLiveKit "github.com/livekit/protocol/redis"
 	"github.com/livekit/protocol/rpc"
 	"github.com/livekit/protocol/utils"
 	"github.com/livekit/protocol/webhook"
@@ -46,9 +47,23 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	wire.Build(
 		getNodeID,
 		createRedisClient,
+		createNATSConn,
+		createNodeStore,
 		createStore,
 		wire.Bind(new(ServiceStore), new(ObjectStore)),
+		NewKeyQuotas,
+		NewRoomPasscodes,
+		NewParticipantValidator,
+		NewJoinAuthorizer,
+		NewGeoIPProvider,
+		NewJoinRestrictions,
+		NewRoomAttachments,
+		getAuditStore,
+		getSpeakerMarkerStore,
+		getTrackStatsHistoryStore,
+		NewAuditLog,
 		createKeyProvider,
+		NewOIDCVerifier,
 		createWebhookNotifier,
 		createClientConfiguration,
 		routing.CreateRouter,
@@ -59,6 +74,7 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		telemetry.NewAnalyticsService,
 		telemetry.NewTelemetryService,
 		getMessageBus,
+		NewRTSPIngressReconnector,
 		NewIOInfoService,
 		wire.Bind(new(IOClient), new(*IOInfoService)),
 		rpc.NewEgressClient,
@@ -71,7 +87,6 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		NewIngressService,
 		rpc.NewSIPClient,
 		getSIPStore,
-		getSIPConfig,
 		NewSIPService,
 		NewRoomAllocator,
 		NewRoomService,
@@ -87,6 +102,10 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		rpc.NewTopicFormatter,
 		rpc.NewTypedRoomClient,
 		rpc.NewTypedParticipantClient,
+		NewParticipantExtClient,
+		NewRoomExtClient,
+		NewNodeExtClient,
+		NewRoomEventService,
 		NewLocalRoomManager,
 		NewTURNAuthHandler,
 		getTURNAuthHandlerFunc,
@@ -97,9 +116,32 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	return &LivekitServer{}, nil
 }
 
+func InitializeRelayServer(conf *config.Config, currentNode routing.LocalNode) (*RelayServer, error) {
+	wire.Build(
+		createRedisClient,
+		createNATSConn,
+		createNodeStore,
+		getNodeID,
+		getMessageBus,
+		getSignalRelayConfig,
+		getPSRPCConfig,
+		getPSRPCClientParams,
+		routing.NewSignalClient,
+		rpc.NewKeepalivePubSub,
+		routing.CreateRouter,
+		createKeyProvider,
+		NewTURNAuthHandler,
+		getTURNAuthHandlerFunc,
+		NewRelayServer,
+	)
+	return &RelayServer{}, nil
+}
+
 func InitializeRouter(conf *config.Config, currentNode routing.LocalNode) (routing.Router, error) {
 	wire.Build(
 		createRedisClient,
+		createNATSConn,
+		createNodeStore,
 		getNodeID,
 		getMessageBus,
 		getSignalRelayConfig,
@@ -143,41 +185,87 @@ func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
 		return nil, errors.New("one of key-file or keys must be provided in order to support a secure installation")
 	}
 
-	return auth.NewFileBasedKeyProviderFromMap(conf.Keys), nil
+	return NewReloadableKeyProvider(conf), nil
 }
 
-func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.QueuedNotifier, error) {
+func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider, roomEvents *RoomEventService) (webhook.QueuedNotifier, error) {
 	wc := conf.WebHook
-	if len(wc.URLs) == 0 {
-		return nil, nil
-	}
-	secret := provider.GetSecret(wc.APIKey)
-	if secret == "" {
+	if len(wc.URLs) > 0 && provider.GetSecret(wc.APIKey) == "" {
 		return nil, ErrWebHookMissingAPIKey
 	}
+	for _, endpoint := range wc.Endpoints {
+		if provider.GetSecret(endpoint.SigningKey(wc.APIKey)) == "" {
+			return nil, ErrWebHookMissingAPIKey
+		}
+	}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+	// urls and endpoints can be configured later on reload
+	n := NewReloadableNotifier(wc.APIKey, provider, wc.URLs, wc.Endpoints)
+	conf.OnReload(n.onConfigReload)
+	return roomEvents.Notifier(n), nil
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
 	if !conf.Redis.IsConfigured() {
 		return nil, nil
 	}
-	return redisLiveKit.GetRedisClient(&conf.Redis)
+	clusterTLS, err := routing.NewClusterTLSConfig(&conf.ClusterTLS)
+	if err != nil {
+		return nil, err
+	}
+	return routing.NewRedisClient(&conf.Redis, clusterTLS)
+}
+
+func createNATSConn(conf *config.Config) (*nats.Conn, error) {
+	if !conf.NATS.IsConfigured() {
+		return nil, nil
+	}
+	clusterTLS, err := routing.NewClusterTLSConfig(&conf.ClusterTLS)
+	if err != nil {
+		return nil, err
+	}
+	return routing.NewNATSConn(&conf.NATS, clusterTLS)
+}
+
+func createNodeStore(conf *config.Config, rc redis.UniversalClient, nc *nats.Conn) (routing.NodeStore, error) {
+	switch {
+	case rc != nil:
+		return routing.NewRedisNodeStore(rc), nil
+	case nc != nil:
+		kv, err := routing.NATSBucket(context.Background(), nc, &conf.NATS, routing.NATSRoutingBucket)
+		if err != nil {
+			return nil, err
+		}
+		return routing.NewNATSNodeStore(kv), nil
+	default:
+		return nil, nil
+	}
 }
 
-func createStore(rc redis.UniversalClient) ObjectStore {
-	if rc != nil {
-		return NewRedisStore(rc)
+func createStore(conf *config.Config, rc redis.UniversalClient, nc *nats.Conn) (ObjectStore, error) {
+	switch {
+	case rc != nil:
+		return NewRedisStore(rc), nil
+	case nc != nil:
+		kv, err := routing.NATSBucket(context.Background(), nc, &conf.NATS, NATSRoomsBucket)
+		if err != nil {
+			return nil, err
+		}
+		return NewNATSStore(kv), nil
+	default:
+		return NewLocalStore(), nil
 	}
-	return NewLocalStore()
 }
 
-func getMessageBus(rc redis.UniversalClient) psrpc.MessageBus {
-	if rc == nil {
+func getMessageBus(rc redis.UniversalClient, nc *nats.Conn) psrpc.MessageBus {
+	switch {
+	case rc != nil:
+		return psrpc.NewRedisMessageBus(rc)
+	case nc != nil:
+		return psrpc.NewNatsMessageBus(nc)
+	default:
 		return psrpc.NewLocalMessageBus()
 	}
-	return psrpc.NewRedisMessageBus(rc)
 }
 
 func getEgressStore(s ObjectStore) EgressStore {
@@ -189,6 +277,42 @@ func getEgressStore(s ObjectStore) EgressStore {
 	}
 }
 
+// records are kept in redis for the cluster, or in memory on a single node
+func getAuditStore(s ObjectStore) AuditStore {
+	switch store := s.(type) {
+	case *RedisStore:
+		return store
+	case *LocalStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+// histories are kept in redis for the cluster, or in memory on a single node
+func getTrackStatsHistoryStore(s ObjectStore) TrackStatsHistoryStore {
+	switch store := s.(type) {
+	case *RedisStore:
+		return store
+	case *LocalStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+// markers are kept in redis for the cluster, or in memory on a single node
+func getSpeakerMarkerStore(s ObjectStore) SpeakerMarkerStore {
+	switch store := s.(type) {
+	case *RedisStore:
+		return store
+	case *LocalStore:
+		return store
+	default:
+		return nil
+	}
+}
+
 func getIngressStore(s ObjectStore) IngressStore {
 	switch store := s.(type) {
 	case *RedisStore:
@@ -211,10 +335,6 @@ func getSIPStore(s ObjectStore) SIPStore {
 	}
 }
 
-func getSIPConfig(conf *config.Config) *config.SIPConfig {
-	return &conf.SIP
-}
-
 func createClientConfiguration() clientconfiguration.ClientConfigurationManager {
 	return clientconfiguration.NewStaticClientConfigurationManager(clientconfiguration.StaticConfigurations)
 }
@@ -235,6 +355,6 @@ func getPSRPCClientParams(config rpc.PSRPCConfig, bus psrpc.MessageBus) rpc.Clie
 	return rpc.NewClientParams(config, bus, logger.GetLogger(), rpc.PSRPCMetricsObserver{})
 }
 
-func newInProcessTurnServer(conf *config.Config, authHandler turn.AuthHandler) (*turn.Server, error) {
-	return NewTurnServer(conf, authHandler, false)
+func newInProcessTurnServer(conf *config.Config, authHandler turn.AuthHandler) (*InProcessTURNServer, error) {
+	return NewInProcessTURNServer(conf, authHandler)
 }
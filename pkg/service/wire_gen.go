@@ -7,6 +7,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"github.com/livekit/livekit-server/pkg/clientconfiguration"
 	"github.com/livekit/livekit-server/pkg/config"
@@ -16,11 +17,11 @@ import (
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
-	redis2 "github.com/livekit/protocol/redis"
 	"github.com/livekit/protocol/rpc"
 	"github.com/livekit/protocol/utils"
 	"github.com/livekit/protocol/webhook"
 	"github.com/livekit/psrpc"
+	"github.com/nats-io/nats.go"
 	"github.com/pion/turn/v2"
 	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
@@ -42,8 +43,12 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
+	conn, err := createNATSConn(conf)
+	if err != nil {
+		return nil, err
+	}
 	nodeID := getNodeID(currentNode)
-	messageBus := getMessageBus(universalClient)
+	messageBus := getMessageBus(universalClient, conn)
 	signalRelayConfig := getSignalRelayConfig(conf)
 	signalClient, err := routing.NewSignalClient(nodeID, messageBus, signalRelayConfig)
 	if err != nil {
@@ -54,9 +59,17 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	router := routing.CreateRouter(universalClient, currentNode, signalClient, keepalivePubSub)
-	objectStore := createStore(universalClient)
-	roomAllocator, err := NewRoomAllocator(conf, router, objectStore)
+	nodeStore, err := createNodeStore(conf, universalClient, conn)
+	if err != nil {
+		return nil, err
+	}
+	router := routing.CreateRouter(nodeStore, currentNode, signalClient, keepalivePubSub)
+	objectStore, err := createStore(conf, universalClient, conn)
+	if err != nil {
+		return nil, err
+	}
+	keyQuotas := NewKeyQuotas(conf, objectStore)
+	roomAllocator, err := NewRoomAllocator(conf, router, objectStore, keyQuotas)
 	if err != nil {
 		return nil, err
 	}
@@ -75,13 +88,23 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	queuedNotifier, err := createWebhookNotifier(conf, keyProvider)
+	roomEventService, err := NewRoomEventService(conf, messageBus, clientParams)
+	if err != nil {
+		return nil, err
+	}
+	queuedNotifier, err := createWebhookNotifier(conf, keyProvider, roomEventService)
 	if err != nil {
 		return nil, err
 	}
 	analyticsService := telemetry.NewAnalyticsService(conf, currentNode)
 	telemetryService := telemetry.NewTelemetryService(queuedNotifier, analyticsService)
-	ioInfoService, err := NewIOInfoService(messageBus, egressStore, ingressStore, sipStore, telemetryService)
+	ingressConfig := getIngressConfig(conf)
+	ingressClient, err := rpc.NewIngressClient(clientParams)
+	if err != nil {
+		return nil, err
+	}
+	rtspIngressReconnector := NewRTSPIngressReconnector(ingressConfig, ingressClient, ingressStore)
+	ioInfoService, err := NewIOInfoService(messageBus, egressStore, ingressStore, sipStore, telemetryService, keyQuotas, rtspIngressReconnector)
 	if err != nil {
 		return nil, err
 	}
@@ -95,24 +118,56 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	roomService, err := NewRoomService(roomConfig, apiConfig, psrpcConfig, router, roomAllocator, objectStore, agentClient, rtcEgressLauncher, topicFormatter, roomClient, participantClient)
+	participantExtClient, err := NewParticipantExtClient(clientParams)
 	if err != nil {
 		return nil, err
 	}
-	egressService := NewEgressService(egressClient, rtcEgressLauncher, objectStore, ioInfoService, roomService)
-	ingressConfig := getIngressConfig(conf)
-	ingressClient, err := rpc.NewIngressClient(clientParams)
+	roomExtClient, err := NewRoomExtClient(clientParams)
+	if err != nil {
+		return nil, err
+	}
+	nodeExtClient, err := NewNodeExtClient(clientParams)
+	if err != nil {
+		return nil, err
+	}
+	roomAttachments, err := NewRoomAttachments(conf, objectStore)
+	if err != nil {
+		return nil, err
+	}
+	auditStore := getAuditStore(objectStore)
+	auditLog, err := NewAuditLog(conf, auditStore)
+	if err != nil {
+		return nil, err
+	}
+	trackStatsHistoryStore := getTrackStatsHistoryStore(objectStore)
+	oidcVerifier, err := NewOIDCVerifier(conf)
+	if err != nil {
+		return nil, err
+	}
+	roomService, err := NewRoomService(roomConfig, apiConfig, psrpcConfig, router, roomAllocator, objectStore, agentClient, rtcEgressLauncher, topicFormatter, roomClient, participantClient, participantExtClient, roomExtClient, nodeExtClient, keyQuotas, roomAttachments, auditLog, trackStatsHistoryStore, keyProvider, oidcVerifier)
 	if err != nil {
 		return nil, err
 	}
+	speakerMarkerStore := getSpeakerMarkerStore(objectStore)
+	egressService := NewEgressService(egressClient, rtcEgressLauncher, objectStore, ioInfoService, roomService, keyQuotas, roomExtClient, topicFormatter, auditLog, speakerMarkerStore)
 	ingressService := NewIngressService(ingressConfig, nodeID, messageBus, ingressClient, ingressStore, ioInfoService, roomService, telemetryService)
-	sipConfig := getSIPConfig(conf)
 	sipClient, err := rpc.NewSIPClient(messageBus)
 	if err != nil {
 		return nil, err
 	}
-	sipService := NewSIPService(sipConfig, nodeID, messageBus, sipClient, sipStore, roomService, telemetryService)
-	rtcService := NewRTCService(conf, roomAllocator, objectStore, router, currentNode, agentClient, telemetryService)
+	sipService := NewSIPService(conf, nodeID, messageBus, sipClient, sipStore, roomService, telemetryService)
+	roomPasscodes := NewRoomPasscodes(conf, objectStore)
+	participantValidator, err := NewParticipantValidator(conf)
+	if err != nil {
+		return nil, err
+	}
+	joinAuthorizer := NewJoinAuthorizer(conf)
+	geoIPProvider, err := NewGeoIPProvider(conf)
+	if err != nil {
+		return nil, err
+	}
+	joinRestrictions := NewJoinRestrictions(conf, objectStore, geoIPProvider, telemetryService)
+	rtcService := NewRTCService(conf, roomAllocator, objectStore, router, currentNode, agentClient, telemetryService, keyQuotas, roomPasscodes, participantValidator, joinAuthorizer, joinRestrictions)
 	agentService, err := NewAgentService(messageBus)
 	if err != nil {
 		return nil, err
@@ -120,7 +175,7 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	clientConfigurationManager := createClientConfiguration()
 	timedVersionGenerator := utils.NewDefaultTimedVersionGenerator()
 	turnAuthHandler := NewTURNAuthHandler(keyProvider)
-	roomManager, err := NewLocalRoomManager(conf, objectStore, currentNode, router, telemetryService, clientConfigurationManager, agentClient, rtcEgressLauncher, timedVersionGenerator, turnAuthHandler, messageBus)
+	roomManager, err := NewLocalRoomManager(conf, objectStore, currentNode, router, telemetryService, clientConfigurationManager, agentClient, rtcEgressLauncher, timedVersionGenerator, turnAuthHandler, messageBus, roomEventService, roomPasscodes, joinRestrictions, geoIPProvider, speakerMarkerStore, trackStatsHistoryStore)
 	if err != nil {
 		return nil, err
 	}
@@ -133,20 +188,64 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	livekitServer, err := NewLivekitServer(conf, roomService, egressService, ingressService, sipService, ioInfoService, rtcService, agentService, keyProvider, router, roomManager, signalServer, server, currentNode)
+	livekitServer, err := NewLivekitServer(conf, roomService, egressService, ingressService, sipService, ioInfoService, rtcService, agentService, roomEventService, keyProvider, oidcVerifier, router, roomManager, signalServer, server, auditLog, analyticsService, currentNode)
 	if err != nil {
 		return nil, err
 	}
 	return livekitServer, nil
 }
 
+func InitializeRelayServer(conf *config.Config, currentNode routing.LocalNode) (*RelayServer, error) {
+	universalClient, err := createRedisClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := createNATSConn(conf)
+	if err != nil {
+		return nil, err
+	}
+	nodeID := getNodeID(currentNode)
+	messageBus := getMessageBus(universalClient, conn)
+	signalRelayConfig := getSignalRelayConfig(conf)
+	signalClient, err := routing.NewSignalClient(nodeID, messageBus, signalRelayConfig)
+	if err != nil {
+		return nil, err
+	}
+	psrpcConfig := getPSRPCConfig(conf)
+	clientParams := getPSRPCClientParams(psrpcConfig, messageBus)
+	keepalivePubSub, err := rpc.NewKeepalivePubSub(clientParams)
+	if err != nil {
+		return nil, err
+	}
+	nodeStore, err := createNodeStore(conf, universalClient, conn)
+	if err != nil {
+		return nil, err
+	}
+	router := routing.CreateRouter(nodeStore, currentNode, signalClient, keepalivePubSub)
+	keyProvider, err := createKeyProvider(conf)
+	if err != nil {
+		return nil, err
+	}
+	turnAuthHandler := NewTURNAuthHandler(keyProvider)
+	authHandler := getTURNAuthHandlerFunc(turnAuthHandler)
+	relayServer, err := NewRelayServer(conf, router, authHandler, currentNode)
+	if err != nil {
+		return nil, err
+	}
+	return relayServer, nil
+}
+
 func InitializeRouter(conf *config.Config, currentNode routing.LocalNode) (routing.Router, error) {
 	universalClient, err := createRedisClient(conf)
 	if err != nil {
 		return nil, err
 	}
+	conn, err := createNATSConn(conf)
+	if err != nil {
+		return nil, err
+	}
 	nodeID := getNodeID(currentNode)
-	messageBus := getMessageBus(universalClient)
+	messageBus := getMessageBus(universalClient, conn)
 	signalRelayConfig := getSignalRelayConfig(conf)
 	signalClient, err := routing.NewSignalClient(nodeID, messageBus, signalRelayConfig)
 	if err != nil {
@@ -158,7 +257,11 @@ func InitializeRouter(conf *config.Config, currentNode routing.LocalNode) (routi
 	if err != nil {
 		return nil, err
 	}
-	router := routing.CreateRouter(universalClient, currentNode, signalClient, keepalivePubSub)
+	nodeStore, err := createNodeStore(conf, universalClient, conn)
+	if err != nil {
+		return nil, err
+	}
+	router := routing.CreateRouter(nodeStore, currentNode, signalClient, keepalivePubSub)
 	return router, nil
 }
 
@@ -194,41 +297,87 @@ func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
 		return nil, errors.New("one of key-file or keys must be provided in order to support a secure installation")
 	}
 
-	return auth.NewFileBasedKeyProviderFromMap(conf.Keys), nil
+	return NewReloadableKeyProvider(conf), nil
 }
 
-func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.QueuedNotifier, error) {
+func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider, roomEvents *RoomEventService) (webhook.QueuedNotifier, error) {
 	wc := conf.WebHook
-	if len(wc.URLs) == 0 {
-		return nil, nil
-	}
-	secret := provider.GetSecret(wc.APIKey)
-	if secret == "" {
+	if len(wc.URLs) > 0 && provider.GetSecret(wc.APIKey) == "" {
 		return nil, ErrWebHookMissingAPIKey
 	}
+	for _, endpoint := range wc.Endpoints {
+		if provider.GetSecret(endpoint.SigningKey(wc.APIKey)) == "" {
+			return nil, ErrWebHookMissingAPIKey
+		}
+	}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+	// urls and endpoints can be configured later on reload
+	n := NewReloadableNotifier(wc.APIKey, provider, wc.URLs, wc.Endpoints)
+	conf.OnReload(n.onConfigReload)
+	return roomEvents.Notifier(n), nil
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
 	if !conf.Redis.IsConfigured() {
 		return nil, nil
 	}
-	return redis2.GetRedisClient(&conf.Redis)
+	clusterTLS, err := routing.NewClusterTLSConfig(&conf.ClusterTLS)
+	if err != nil {
+		return nil, err
+	}
+	return routing.NewRedisClient(&conf.Redis, clusterTLS)
+}
+
+func createNATSConn(conf *config.Config) (*nats.Conn, error) {
+	if !conf.NATS.IsConfigured() {
+		return nil, nil
+	}
+	clusterTLS, err := routing.NewClusterTLSConfig(&conf.ClusterTLS)
+	if err != nil {
+		return nil, err
+	}
+	return routing.NewNATSConn(&conf.NATS, clusterTLS)
 }
 
-func createStore(rc redis.UniversalClient) ObjectStore {
-	if rc != nil {
-		return NewRedisStore(rc)
+func createNodeStore(conf *config.Config, rc redis.UniversalClient, nc *nats.Conn) (routing.NodeStore, error) {
+	switch {
+	case rc != nil:
+		return routing.NewRedisNodeStore(rc), nil
+	case nc != nil:
+		kv, err := routing.NATSBucket(context.Background(), nc, &conf.NATS, routing.NATSRoutingBucket)
+		if err != nil {
+			return nil, err
+		}
+		return routing.NewNATSNodeStore(kv), nil
+	default:
+		return nil, nil
 	}
-	return NewLocalStore()
 }
 
-func getMessageBus(rc redis.UniversalClient) psrpc.MessageBus {
-	if rc == nil {
+func createStore(conf *config.Config, rc redis.UniversalClient, nc *nats.Conn) (ObjectStore, error) {
+	switch {
+	case rc != nil:
+		return NewRedisStore(rc), nil
+	case nc != nil:
+		kv, err := routing.NATSBucket(context.Background(), nc, &conf.NATS, NATSRoomsBucket)
+		if err != nil {
+			return nil, err
+		}
+		return NewNATSStore(kv), nil
+	default:
+		return NewLocalStore(), nil
+	}
+}
+
+func getMessageBus(rc redis.UniversalClient, nc *nats.Conn) psrpc.MessageBus {
+	switch {
+	case rc != nil:
+		return psrpc.NewRedisMessageBus(rc)
+	case nc != nil:
+		return psrpc.NewNatsMessageBus(nc)
+	default:
 		return psrpc.NewLocalMessageBus()
 	}
-	return psrpc.NewRedisMessageBus(rc)
 }
 
 func getEgressStore(s ObjectStore) EgressStore {
@@ -240,6 +389,42 @@ func getEgressStore(s ObjectStore) EgressStore {
 	}
 }
 
+// records are kept in redis for the cluster, or in memory on a single node
+func getAuditStore(s ObjectStore) AuditStore {
+	switch store := s.(type) {
+	case *RedisStore:
+		return store
+	case *LocalStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+// histories are kept in redis for the cluster, or in memory on a single node
+func getTrackStatsHistoryStore(s ObjectStore) TrackStatsHistoryStore {
+	switch store := s.(type) {
+	case *RedisStore:
+		return store
+	case *LocalStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+// markers are kept in redis for the cluster, or in memory on a single node
+func getSpeakerMarkerStore(s ObjectStore) SpeakerMarkerStore {
+	switch store := s.(type) {
+	case *RedisStore:
+		return store
+	case *LocalStore:
+		return store
+	default:
+		return nil
+	}
+}
+
 func getIngressStore(s ObjectStore) IngressStore {
 	switch store := s.(type) {
 	case *RedisStore:
@@ -262,10 +447,6 @@ func getSIPStore(s ObjectStore) SIPStore {
 	}
 }
 
-func getSIPConfig(conf *config.Config) *config.SIPConfig {
-	return &conf.SIP
-}
-
 func createClientConfiguration() clientconfiguration.ClientConfigurationManager {
 	return clientconfiguration.NewStaticClientConfigurationManager(clientconfiguration.StaticConfigurations)
 }
@@ -286,6 +467,6 @@ func getPSRPCClientParams(config2 rpc.PSRPCConfig, bus psrpc.MessageBus) rpc.Cli
 	return rpc.NewClientParams(config2, bus, logger.GetLogger(), rpc.PSRPCMetricsObserver{})
 }
 
-func newInProcessTurnServer(conf *config.Config, authHandler turn.AuthHandler) (*turn.Server, error) {
-	return NewTurnServer(conf, authHandler, false)
+func newInProcessTurnServer(conf *config.Config, authHandler turn.AuthHandler) (*InProcessTURNServer, error) {
+	return NewInProcessTURNServer(conf, authHandler)
 }
@@ -0,0 +1,168 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audiofork
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// opusClockRate is the RTP clock rate of Opus whatever the sampling rate of the audio
+const opusClockRate = 48000
+
+// Frame is an Opus packet of a track, the payload is not decoded
+type Frame struct {
+	// offset of the frame from the first frame of the fork, from the RTP timestamps
+	Offset time.Duration
+	// time the packet was received from the publisher
+	ReceivedAt time.Time
+	Payload    []byte
+}
+
+type ForkParams struct {
+	// ID of the fork among the down tracks of the receiver
+	ID string
+	// subscriber ID of the fork, it must not be the ID of a participant
+	SubscriberID livekit.ParticipantID
+	// number of frames buffered for OnFrame, frames are dropped while it is full
+	QueueSize int
+	// called from the goroutine of the fork for each frame
+	OnFrame func(frame Frame)
+	// called once when the fork closes, after the last OnFrame
+	OnClose func()
+	Logger  logger.Logger
+}
+
+// Fork receives the packets of an audio track as a down track of its receiver and hands copies of their payloads
+// to OnFrame, on a goroutine of its own so that a slow consumer does not hold up forwarding to subscribers.
+type Fork struct {
+	params ForkParams
+
+	// held for writing when closing the frames channel
+	lock    sync.RWMutex
+	frames  chan Frame
+	closed  atomic.Bool
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	// only accessed from the forwarding goroutine of the receiver
+	firstTimestamp uint64
+	started        bool
+}
+
+func NewFork(params ForkParams) *Fork {
+	f := &Fork{
+		params: params,
+		frames: make(chan Frame, params.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go f.run()
+	return f
+}
+
+func (f *Fork) run() {
+	for frame := range f.frames {
+		f.params.OnFrame(frame)
+	}
+	if dropped := f.dropped.Load(); dropped > 0 {
+		f.params.Logger.Infow("audio fork dropped frames", "dropped", dropped)
+	}
+	if f.params.OnClose != nil {
+		f.params.OnClose()
+	}
+	close(f.done)
+}
+
+func (f *Fork) WriteRTP(pkt *buffer.ExtPacket, _ int32) error {
+	if len(pkt.Packet.Payload) == 0 {
+		return nil
+	}
+
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	if f.closed.Load() {
+		return nil
+	}
+
+	if !f.started {
+		f.firstTimestamp = pkt.ExtTimestamp
+		f.started = true
+	}
+	frame := Frame{
+		Offset:     time.Duration(pkt.ExtTimestamp-f.firstTimestamp) * time.Second / opusClockRate,
+		ReceivedAt: pkt.Arrival,
+		// the packet buffer is reused by the receiver
+		Payload: append([]byte(nil), pkt.Packet.Payload...),
+	}
+	select {
+	case f.frames <- frame:
+	default:
+		f.dropped.Inc()
+	}
+	return nil
+}
+
+// Close stops the fork, OnClose runs once the buffered frames are handed to OnFrame
+func (f *Fork) Close() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if !f.closed.Swap(true) {
+		close(f.frames)
+	}
+}
+
+// Done is closed when the fork has stopped
+func (f *Fork) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *Fork) IsClosed() bool {
+	return f.closed.Load()
+}
+
+func (f *Fork) ID() string {
+	return f.params.ID
+}
+
+func (f *Fork) SubscriberID() livekit.ParticipantID {
+	return f.params.SubscriberID
+}
+
+func (f *Fork) UpTrackLayersChange()                           {}
+func (f *Fork) UpTrackBitrateAvailabilityChange()              {}
+func (f *Fork) UpTrackMaxPublishedLayerChange(_ int32)         {}
+func (f *Fork) UpTrackMaxTemporalLayerSeenChange(_ int32)      {}
+func (f *Fork) UpTrackBitrateReport(_ []int32, _ sfu.Bitrates) {}
+func (f *Fork) TrackInfoAvailable()                            {}
+func (f *Fork) HandleRTCPSenderReportData(
+	_ webrtc.PayloadType,
+	_ bool,
+	_ int32,
+	_ *buffer.RTCPSenderReportData,
+	_ *buffer.RTCPSenderReportData,
+) error {
+	return nil
+}
+
+var _ sfu.TrackSender = (*Fork)(nil)
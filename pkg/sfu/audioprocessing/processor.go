@@ -0,0 +1,154 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audioprocessing
+
+import (
+	"errors"
+)
+
+// opus decodes at 48kHz whatever the sampling rate of the encoder, 120ms is the longest packet
+const (
+	SampleRate        = 48000
+	maxPacketDuration = 120
+)
+
+var ErrNoCodec = errors.New("audio processing needs an opus codec")
+
+// Features are the processing enabled for the audio of a room
+type Features struct {
+	NoiseSuppression  bool `json:"noise_suppression,omitempty"`
+	GainNormalization bool `json:"gain_normalization,omitempty"`
+}
+
+// IsZero returns true when no processing is enabled
+func (f Features) IsZero() bool {
+	return !f.NoiseSuppression && !f.GainNormalization
+}
+
+// StreamInfo describes the audio of a track
+type StreamInfo struct {
+	Room                string   `json:"room"`
+	ParticipantIdentity string   `json:"participant_identity"`
+	TrackID             string   `json:"track_id"`
+	Features            Features `json:"features"`
+	// PCM of the processor, opus packets for the payload processors of gRPC services
+	SampleRate int `json:"sample_rate"`
+	Channels   int `json:"channels"`
+}
+
+// Processor is the plugin interface of the server side processing of published audio, e.g. noise suppression
+// or gain normalization, compiled into the server. Audio is 16 bit PCM with the channels interleaved.
+type Processor interface {
+	// NewStream starts processing the audio of a track
+	NewStream(info StreamInfo) (Stream, error)
+}
+
+type Stream interface {
+	// Process is called with the audio of each packet of the track in order, from a single goroutine. It returns
+	// the processed audio, of the same duration, and may reuse pcm for it
+	Process(pcm []int16) ([]int16, error)
+	// Close ends the audio of the track
+	Close() error
+}
+
+// Codec decodes and encodes the opus packets around a Processor, the server does not link an opus implementation
+type Codec interface {
+	NewDecoder(sampleRate int, channels int) (Decoder, error)
+	NewEncoder(sampleRate int, channels int) (Encoder, error)
+}
+
+type Decoder interface {
+	// Decode decodes a packet into pcm and returns the number of samples per channel
+	Decode(payload []byte, pcm []int16) (int, error)
+}
+
+type Encoder interface {
+	// Encode encodes pcm into payload and returns the number of bytes written
+	Encode(pcm []int16, payload []byte) (int, error)
+}
+
+// PayloadProcessor processes the opus packets of a track, it is what a receiver applies
+type PayloadProcessor interface {
+	// ProcessPayload returns the processed packet, the receiver keeps it after later calls
+	ProcessPayload(payload []byte) ([]byte, error)
+	// Close ends the audio of the track
+	Close() error
+}
+
+// ------------------------------------------------
+
+// pcmPayloadProcessor decodes the packets of a track, has a Stream process them and encodes the result
+type pcmPayloadProcessor struct {
+	stream   Stream
+	channels int
+	decoder  Decoder
+	encoder  Encoder
+
+	pcm     []int16
+	payload []byte
+}
+
+// NewPCMPayloadProcessor processes the packets of a track with a stream of a PCM processor
+func NewPCMPayloadProcessor(codec Codec, processor Processor, info StreamInfo) (PayloadProcessor, error) {
+	if codec == nil {
+		return nil, ErrNoCodec
+	}
+	info.SampleRate = SampleRate
+	if info.Channels == 0 {
+		info.Channels = 1
+	}
+
+	decoder, err := codec.NewDecoder(info.SampleRate, info.Channels)
+	if err != nil {
+		return nil, err
+	}
+	encoder, err := codec.NewEncoder(info.SampleRate, info.Channels)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := processor.NewStream(info)
+	if err != nil {
+		return nil, err
+	}
+	return &pcmPayloadProcessor{
+		stream:   stream,
+		channels: info.Channels,
+		decoder:  decoder,
+		encoder:  encoder,
+		pcm:      make([]int16, info.SampleRate/1000*maxPacketDuration*info.Channels),
+		payload:  make([]byte, 1500),
+	}, nil
+}
+
+func (p *pcmPayloadProcessor) ProcessPayload(payload []byte) ([]byte, error) {
+	samples, err := p.decoder.Decode(payload, p.pcm)
+	if err != nil {
+		return nil, err
+	}
+	processed, err := p.stream.Process(p.pcm[:samples*p.channels])
+	if err != nil {
+		return nil, err
+	}
+	n, err := p.encoder.Encode(processed, p.payload)
+	if err != nil {
+		return nil, err
+	}
+	// the packet is kept by the RED encoder and the pacer after the next one is processed
+	return append([]byte(nil), p.payload[:n]...), nil
+}
+
+func (p *pcmPayloadProcessor) Close() error {
+	return p.stream.Close()
+}
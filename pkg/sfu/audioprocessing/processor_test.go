@@ -0,0 +1,101 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audioprocessing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCodec struct {
+	channels  int
+	decodeErr error
+}
+
+func (c *testCodec) NewDecoder(_ int, channels int) (Decoder, error) {
+	c.channels = channels
+	return c, nil
+}
+
+func (c *testCodec) NewEncoder(_ int, _ int) (Encoder, error) { return c, nil }
+
+// a packet is one byte per sample
+func (c *testCodec) Decode(payload []byte, pcm []int16) (int, error) {
+	if c.decodeErr != nil {
+		return 0, c.decodeErr
+	}
+	for i, b := range payload {
+		pcm[i] = int16(b)
+	}
+	return len(payload) / c.channels, nil
+}
+
+func (c *testCodec) Encode(pcm []int16, payload []byte) (int, error) {
+	for i, sample := range pcm {
+		payload[i] = byte(sample)
+	}
+	return len(pcm), nil
+}
+
+type testProcessor struct {
+	info StreamInfo
+}
+
+func (p *testProcessor) NewStream(info StreamInfo) (Stream, error) {
+	p.info = info
+	return p, nil
+}
+
+func (p *testProcessor) Process(pcm []int16) ([]int16, error) {
+	out := make([]int16, len(pcm))
+	for i, sample := range pcm {
+		out[i] = sample + 1
+	}
+	return out, nil
+}
+
+func (p *testProcessor) Close() error {
+	return nil
+}
+
+func TestPCMPayloadProcessor(t *testing.T) {
+	_, err := NewPCMPayloadProcessor(nil, &testProcessor{}, StreamInfo{})
+	require.ErrorIs(t, err, ErrNoCodec)
+
+	codec := &testCodec{}
+	processor := &testProcessor{}
+	p, err := NewPCMPayloadProcessor(codec, processor, StreamInfo{TrackID: "TR_audio", Channels: 2})
+	require.NoError(t, err)
+	require.Equal(t, SampleRate, processor.info.SampleRate)
+	require.Equal(t, 2, processor.info.Channels)
+
+	payload, err := p.ProcessPayload([]byte{1, 2, 3, 4})
+	require.NoError(t, err)
+	require.Equal(t, []byte{2, 3, 4, 5}, payload)
+
+	// processed packets do not share memory
+	next, err := p.ProcessPayload([]byte{5, 6})
+	require.NoError(t, err)
+	require.Equal(t, []byte{6, 7}, next)
+	require.Equal(t, []byte{2, 3, 4, 5}, payload)
+
+	// packets that cannot be decoded are forwarded as received by the receiver
+	codec.decodeErr = errors.New("corrupted")
+	_, err = p.ProcessPayload([]byte{1})
+	require.Error(t, err)
+	require.NoError(t, p.Close())
+}
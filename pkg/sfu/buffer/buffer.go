@@ -32,6 +32,7 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/sfu/audio"
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
+	"github.com/livekit/livekit-server/pkg/sfu/packetcapture"
 	"github.com/livekit/livekit-server/pkg/sfu/utils"
 	sutils "github.com/livekit/livekit-server/pkg/utils"
 	"github.com/livekit/mediatransportutil"
@@ -84,6 +85,10 @@ type Buffer struct {
 	closed        atomic.Bool
 	mime          string
 
+	// bit depth of the video detected from key frames, and whether the negotiated codec profile carries high bit depth
+	bitDepth            int
+	highBitDepthProfile bool
+
 	snRangeMap *utils.RangeMap[uint64, uint64]
 
 	latestTSForAudioLevelInitialized bool
@@ -126,6 +131,18 @@ type Buffer struct {
 	extPacketTooMuchCount atomic.Uint32
 
 	primaryBufferForRTX *Buffer
+
+	// nil when the checks are disabled
+	payloadIntegrity         *payloadIntegrityChecker
+	onPayloadIntegrityReport func(*PayloadIntegrityReport)
+	// nil when key frames are not cached
+	keyFrameCache *keyFrameCache
+	// packets kept for NACK when sized for the stream, the buffer of the pool is used when 0
+	packetBufferSize int
+	// nil when packets are forwarded in the order they are received
+	reorder *reorderWindow
+
+	capture atomic.Pointer[packetcapture.Capture]
 }
 
 // NewBuffer constructs a new Buffer
@@ -192,12 +209,11 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 	b.clockRate = codec.ClockRate
 	b.lastReport = time.Now()
 	b.mime = strings.ToLower(codec.MimeType)
-	for _, codecParameter := range params.Codecs {
-		if strings.EqualFold(codecParameter.MimeType, codec.MimeType) {
-			b.payloadType = uint8(codecParameter.PayloadType)
-			break
-		}
+	// match the fmtp line too, so that a profile of a codec is not taken for another one of the same codec
+	if codecParameter, err := utils.CodecParametersFuzzySearch(webrtc.RTPCodecParameters{RTPCodecCapability: codec}, params.Codecs); err == nil {
+		b.payloadType = uint8(codecParameter.PayloadType)
 	}
+	b.highBitDepthProfile = utils.IsHighBitDepthProfile(codec)
 
 	if b.payloadType == 0 {
 		b.logger.Warnw("could not find payload type for codec", nil, "codec", codec.MimeType, "parameters", params)
@@ -228,7 +244,12 @@ func (b *Buffer) Bind(params webrtc.RTPParameters, codec webrtc.RTPCodecCapabili
 		b.bucket = bucket.NewBucket(b.audioPool.Get().(*[]byte))
 	case strings.HasPrefix(b.mime, "video/"):
 		b.codecType = webrtc.RTPCodecTypeVideo
-		b.bucket = bucket.NewBucket(b.videoPool.Get().(*[]byte))
+		if b.packetBufferSize > 0 {
+			buf := make([]byte, b.packetBufferSize*bucket.MaxPktSize)
+			b.bucket = bucket.NewBucket(&buf)
+		} else {
+			b.bucket = bucket.NewBucket(b.videoPool.Get().(*[]byte))
+		}
 		if b.frameRateCalculator[0] == nil {
 			if strings.EqualFold(codec.MimeType, webrtc.MimeTypeVP8) {
 				b.frameRateCalculator[0] = NewFrameRateCalculatorVP8(b.clockRate, b.logger)
@@ -285,6 +306,13 @@ func (b *Buffer) Write(pkt []byte) (n int, err error) {
 		return
 	}
 
+	// RTX packets are captured along with the packets of the stream they repair
+	if pb := b.primaryBufferForRTX; pb != nil {
+		pb.capture.Load().WriteRTP(packetcapture.DirectionInbound, pkt)
+	} else {
+		b.capture.Load().WriteRTP(packetcapture.DirectionInbound, pkt)
+	}
+
 	if b.twcc != nil && b.twccExt != 0 && !b.closed.Load() {
 		if ext := rtpPacket.GetExtension(b.twccExt); ext != nil {
 			b.twcc.Push(rtpPacket.SSRC, binary.BigEndian.Uint16(ext[0:2]), time.Now().UnixNano(), rtpPacket.Marker)
@@ -396,6 +424,11 @@ func (b *Buffer) ReadExtended(buf []byte) (*ExtPacket, error) {
 			return nil, io.EOF
 		}
 		b.Lock()
+		if b.reorder != nil {
+			for _, ep := range b.reorder.expire(time.Now()) {
+				b.extPackets.PushBack(ep)
+			}
+		}
 		if b.extPackets.Len() > 0 {
 			ep := b.extPackets.PopFront()
 			ep = b.patchExtPacket(ep, buf)
@@ -403,6 +436,9 @@ func (b *Buffer) ReadExtended(buf []byte) (*ExtPacket, error) {
 				b.Unlock()
 				continue
 			}
+			if b.keyFrameCache != nil {
+				b.keyFrameCache.add(ep)
+			}
 
 			b.Unlock()
 			return ep, nil
@@ -417,7 +453,7 @@ func (b *Buffer) Close() error {
 	defer b.Unlock()
 
 	b.closeOnce.Do(func() {
-		if b.bucket != nil && b.codecType == webrtc.RTPCodecTypeVideo {
+		if b.bucket != nil && b.codecType == webrtc.RTPCodecTypeVideo && b.packetBufferSize == 0 {
 			b.videoPool.Put(b.bucket.Src())
 		}
 		if b.bucket != nil && b.codecType == webrtc.RTPCodecTypeAudio {
@@ -457,7 +493,7 @@ func (b *Buffer) SetPLIThrottle(duration int64) {
 
 func (b *Buffer) SendPLI(force bool) {
 	b.RLock()
-	if (b.rtpStats == nil || b.rtpStats.TimeSinceLastPli() < b.pliThrottle) && !force {
+	if b.rtpStats == nil || (!force && b.rtpStats.TimeSinceLastPli() < b.pliThrottle) {
 		b.RUnlock()
 		return
 	}
@@ -470,8 +506,107 @@ func (b *Buffer) SendPLI(force bool) {
 		&rtcp.PictureLossIndication{SenderSSRC: b.mediaSSRC, MediaSSRC: b.mediaSSRC},
 	}
 
-	if b.onRtcpFeedback != nil {
-		b.onRtcpFeedback(pli)
+	b.sendRTCPFeedback(pli)
+}
+
+// sendPLILocked requests a key frame from the packet processing, with the lock held
+func (b *Buffer) sendPLILocked() {
+	if b.rtpStats == nil || b.rtpStats.TimeSinceLastPli() < b.pliThrottle {
+		return
+	}
+
+	b.rtpStats.UpdatePliAndTime(1)
+	b.logger.Debugw("send pli", "ssrc", b.mediaSSRC, "reason", "payload integrity")
+	b.sendRTCPFeedback([]rtcp.Packet{
+		&rtcp.PictureLossIndication{SenderSSRC: b.mediaSSRC, MediaSSRC: b.mediaSSRC},
+	})
+}
+
+// SetPacketBufferSize keeps up to packets video packets for NACK instead of the size of the pool,
+// it has to be set before the buffer is bound
+func (b *Buffer) SetPacketBufferSize(packets int) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.bound || packets < 0 {
+		return
+	}
+	b.packetBufferSize = packets
+}
+
+// SetReorder holds back packets received after a gap in sequence numbers to forward them in order,
+// it has to be set before the buffer is bound
+func (b *Buffer) SetReorder(params ReorderParams) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.bound {
+		return
+	}
+	if params.Depth > 0 {
+		b.reorder = newReorderWindow(params)
+	} else {
+		b.reorder = nil
+	}
+}
+
+// SetKeyFrameCache keeps up to maxPackets packets of the stream since its last key frame
+func (b *Buffer) SetKeyFrameCache(maxPackets int) {
+	b.Lock()
+	defer b.Unlock()
+
+	if maxPackets > 0 {
+		b.keyFrameCache = newKeyFrameCache(maxPackets)
+	} else {
+		b.keyFrameCache = nil
+	}
+}
+
+// KeyFramePackets returns the packets since the last key frame in the order they were read, nil when there
+// is no complete run of them. The packets must not be modified.
+func (b *Buffer) KeyFramePackets() []*ExtPacket {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.keyFrameCache == nil {
+		return nil
+	}
+	return b.keyFrameCache.get()
+}
+
+// SetPayloadIntegrity enables the checks of the payloads of video packets, a key frame is requested when too many
+// packets of a window fail them
+func (b *Buffer) SetPayloadIntegrity(params PayloadIntegrityParams) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.payloadIntegrity = newPayloadIntegrityChecker(params)
+}
+
+// OnPayloadIntegrityReport is called with the lock of the buffer held, fn must not call back into the buffer
+func (b *Buffer) OnPayloadIntegrityReport(fn func(report *PayloadIntegrityReport)) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.onPayloadIntegrityReport = fn
+}
+
+func (b *Buffer) updatePayloadIntegrity(reason PayloadIntegrityReason, arrivalTime time.Time) {
+	if b.payloadIntegrity == nil {
+		return
+	}
+
+	sendPLI, report := b.payloadIntegrity.add(reason, arrivalTime)
+	if report != nil {
+		if report.FlagChanged {
+			b.logger.Infow("payload integrity changed", "flagged", report.Flagged, "errorRate", report.ErrorRate, "errors", report.Errors)
+		}
+		if b.onPayloadIntegrityReport != nil {
+			b.onPayloadIntegrityReport(report)
+		}
+	}
+	if sendPLI {
+		b.sendPLILocked()
 	}
 }
 
@@ -565,7 +700,13 @@ func (b *Buffer) calc(rawPkt []byte, rtpPacket *rtp.Packet, arrivalTime time.Tim
 	if ep == nil {
 		return
 	}
-	b.extPackets.PushBack(ep)
+	if b.reorder != nil {
+		for _, rp := range b.reorder.add(ep) {
+			b.extPackets.PushBack(rp)
+		}
+	} else {
+		b.extPackets.PushBack(ep)
+	}
 
 	if b.extPackets.Len() > b.bucket.Capacity() {
 		if (b.extPacketTooMuchCount.Inc()-1)%100 == 0 {
@@ -708,9 +849,19 @@ func (b *Buffer) getExtPacket(rtpPacket *rtp.Packet, arrivalTime time.Time, flow
 		vp8Packet := VP8{}
 		if err := vp8Packet.Unmarshal(rtpPacket.Payload); err != nil {
 			b.logger.Warnw("could not unmarshal VP8 packet", err)
+			b.updatePayloadIntegrity(PayloadIntegrityReasonUndecodableHeader, arrivalTime)
 			return nil
 		}
 		ep.KeyFrame = vp8Packet.IsKeyFrame
+		if b.payloadIntegrity != nil {
+			var reason PayloadIntegrityReason
+			if ep.KeyFrame && !vp8KeyFrameHeaderValid(&vp8Packet, rtpPacket.Payload) {
+				reason = PayloadIntegrityReasonUndecodableHeader
+			} else if vp8Packet.I {
+				reason = b.payloadIntegrity.checkPictureID(ep.ExtTimestamp, vp8Packet.PictureID)
+			}
+			b.updatePayloadIntegrity(reason, arrivalTime)
+		}
 		if ep.DependencyDescriptor == nil {
 			ep.Temporal = int32(vp8Packet.TID)
 		} else {
@@ -725,8 +876,16 @@ func (b *Buffer) getExtPacket(rtpPacket *rtp.Packet, arrivalTime time.Time, flow
 			_, err := vp9Packet.Unmarshal(rtpPacket.Payload)
 			if err != nil {
 				b.logger.Warnw("could not unmarshal VP9 packet", err)
+				b.updatePayloadIntegrity(PayloadIntegrityReasonUndecodableHeader, arrivalTime)
 				return nil
 			}
+			if b.payloadIntegrity != nil {
+				var reason PayloadIntegrityReason
+				if vp9Packet.I {
+					reason = b.payloadIntegrity.checkPictureID(ep.ExtTimestamp, vp9Packet.PictureID)
+				}
+				b.updatePayloadIntegrity(reason, arrivalTime)
+			}
 			ep.VideoLayer = VideoLayer{
 				Spatial:  int32(vp9Packet.SID),
 				Temporal: int32(vp9Packet.TID),
@@ -734,10 +893,34 @@ func (b *Buffer) getExtPacket(rtpPacket *rtp.Packet, arrivalTime time.Time, flow
 			ep.Payload = vp9Packet
 		}
 		ep.KeyFrame = IsVP9KeyFrame(rtpPacket.Payload)
+		if ep.KeyFrame {
+			if bitDepth, ok := VP9KeyFrameBitDepth(rtpPacket.Payload); ok {
+				b.updateBitDepth(bitDepth)
+			}
+		}
 	case "video/h264":
 		ep.KeyFrame = IsH264KeyFrame(rtpPacket.Payload)
+		if b.payloadIntegrity != nil {
+			var reason PayloadIntegrityReason
+			if !h264HeaderValid(rtpPacket.Payload) {
+				reason = PayloadIntegrityReasonUndecodableHeader
+			}
+			b.updatePayloadIntegrity(reason, arrivalTime)
+		}
 	case "video/av1":
 		ep.KeyFrame = IsAV1KeyFrame(rtpPacket.Payload)
+		if b.payloadIntegrity != nil {
+			var reason PayloadIntegrityReason
+			if !av1HeaderValid(rtpPacket.Payload) {
+				reason = PayloadIntegrityReasonUndecodableHeader
+			}
+			b.updatePayloadIntegrity(reason, arrivalTime)
+		}
+		if ep.KeyFrame {
+			if bitDepth, ok := AV1KeyFrameBitDepth(rtpPacket.Payload); ok {
+				b.updateBitDepth(bitDepth)
+			}
+		}
 	}
 
 	if ep.KeyFrame {
@@ -749,15 +932,49 @@ func (b *Buffer) getExtPacket(rtpPacket *rtp.Packet, arrivalTime time.Time, flow
 	return ep
 }
 
+func (b *Buffer) updateBitDepth(bitDepth int) {
+	if bitDepth == b.bitDepth {
+		return
+	}
+
+	b.bitDepth = bitDepth
+	if bitDepth > 8 {
+		b.logger.Infow("high bit depth video detected", "bitDepth", bitDepth, "mime", b.mime)
+	}
+	if b.bitDepthMismatch() {
+		b.logger.Warnw("video bit depth does not match negotiated codec profile", nil, "bitDepth", bitDepth, "mime", b.mime)
+	}
+}
+
+// BitDepth returns the bit depth of the video detected from its key frames, 0 if not known
+func (b *Buffer) BitDepth() int {
+	b.RLock()
+	defer b.RUnlock()
+
+	return b.bitDepth
+}
+
+// HasBitDepthMismatch returns true when the video is sent with a higher bit depth than the negotiated codec profile
+// carries, subscribers negotiating that profile cannot decode it
+func (b *Buffer) HasBitDepthMismatch() bool {
+	b.RLock()
+	defer b.RUnlock()
+
+	return b.bitDepthMismatch()
+}
+
+// a high bit depth VP9 stream sent with a profile negotiated for 8 bit cannot be decoded by subscribers
+func (b *Buffer) bitDepthMismatch() bool {
+	return b.mime == "video/vp9" && b.bitDepth > 8 && !b.highBitDepthProfile
+}
+
 func (b *Buffer) doNACKs() {
 	if b.nacker == nil {
 		return
 	}
 
 	if r, numSeqNumsNacked := b.buildNACKPacket(); r != nil {
-		if b.onRtcpFeedback != nil {
-			b.onRtcpFeedback(r)
-		}
+		b.sendRTCPFeedback(r)
 		if b.rtpStats != nil {
 			b.rtpStats.UpdateNack(uint32(numSeqNumsNacked))
 		}
@@ -772,8 +989,19 @@ func (b *Buffer) doReports(arrivalTime time.Time) {
 	b.lastReport = arrivalTime
 
 	// RTCP reports
-	pkts := b.getRTCP()
-	if pkts != nil && b.onRtcpFeedback != nil {
+	if pkts := b.getRTCP(); pkts != nil {
+		b.sendRTCPFeedback(pkts)
+	}
+}
+
+func (b *Buffer) sendRTCPFeedback(pkts []rtcp.Packet) {
+	if capture := b.capture.Load(); capture != nil {
+		if raw, err := rtcp.Marshal(pkts); err == nil {
+			capture.WriteRTCP(packetcapture.DirectionOutbound, raw)
+		}
+	}
+
+	if b.onRtcpFeedback != nil {
 		b.onRtcpFeedback(pkts)
 	}
 }
@@ -862,6 +1090,17 @@ func (b *Buffer) getPacket(buff []byte, sn uint16) (int, error) {
 	return b.bucket.GetPacket(buff, sn)
 }
 
+// SetPacketCapture starts writing the RTP packets of the stream, its RTX packets included, and the RTCP
+// packets of the stream to capture. Capture stops when set to nil.
+func (b *Buffer) SetPacketCapture(capture *packetcapture.Capture) {
+	b.capture.Store(capture)
+}
+
+// CaptureRTCP writes RTCP packets received from the publisher of the stream to the packet capture, if any
+func (b *Buffer) CaptureRTCP(pkt []byte) {
+	b.capture.Load().WriteRTCP(packetcapture.DirectionInbound, pkt)
+}
+
 func (b *Buffer) OnRtcpFeedback(fn func(fb []rtcp.Packet)) {
 	b.onRtcpFeedback = fn
 }
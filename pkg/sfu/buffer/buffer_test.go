@@ -261,3 +261,140 @@ func TestFractionLostReport(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestKeyFrameCache(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 1500)
+			return &b
+		},
+	}
+	buff := NewBuffer(123, pool, pool)
+	buff.codecType = webrtc.RTPCodecTypeVideo
+	buff.OnRtcpFeedback(func(_ []rtcp.Packet) {})
+	buff.Bind(webrtc.RTPParameters{
+		HeaderExtensions: nil,
+		Codecs:           []webrtc.RTPCodecParameters{vp8Codec},
+	}, vp8Codec.RTPCodecCapability)
+	buff.SetKeyFrameCache(4)
+
+	keyFrame := []byte{0x10, 0x00, 0x01}
+	continuation := []byte{0x00, 0x01}
+	delta := []byte{0x10, 0x01, 0x01}
+	write := func(sn uint16, ts uint32, payload []byte) {
+		pkt := &rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: sn, Timestamp: ts, SSRC: 123},
+			Payload: payload,
+		}
+		buf, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = buff.Write(buf)
+		require.NoError(t, err)
+
+		readBuf := make([]byte, 1500)
+		_, err = buff.ReadExtended(readBuf)
+		require.NoError(t, err)
+		// the buffer of the packet processing is reused for the next packets
+		for i := range readBuf {
+			readBuf[i] = 0
+		}
+	}
+	sequenceNumbers := func() []uint16 {
+		var sns []uint16
+		for _, ep := range buff.KeyFramePackets() {
+			sns = append(sns, ep.Packet.SequenceNumber)
+		}
+		return sns
+	}
+
+	// nothing cached before the first key frame
+	write(1, 1000, delta)
+	require.Empty(t, buff.KeyFramePackets())
+
+	// a key frame spread over two packets, then its dependent frames
+	write(2, 2000, keyFrame)
+	write(3, 2000, continuation)
+	write(4, 3000, delta)
+	require.Equal(t, []uint16{2, 3, 4}, sequenceNumbers())
+	cached := buff.KeyFramePackets()
+	require.True(t, cached[0].KeyFrame)
+	require.Equal(t, continuation, cached[1].Packet.Payload)
+
+	// the next key frame starts over, packets handed out before are kept as they were
+	write(5, 4000, keyFrame)
+	require.Equal(t, []uint16{5}, sequenceNumbers())
+	require.Len(t, cached, 3)
+	require.Equal(t, uint16(4), cached[2].Packet.SequenceNumber)
+
+	// too many packets since the key frame
+	for sn := uint16(6); sn < 10; sn++ {
+		write(sn, 5000+uint32(sn), delta)
+	}
+	require.Empty(t, buff.KeyFramePackets())
+}
+
+func TestPacketBufferSize(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 1500*10)
+			return &b
+		},
+	}
+	bind := func(buff *Buffer) {
+		buff.Bind(webrtc.RTPParameters{
+			HeaderExtensions: nil,
+			Codecs:           []webrtc.RTPCodecParameters{vp8Codec},
+		}, vp8Codec.RTPCodecCapability)
+	}
+
+	buff := NewBuffer(123, pool, pool)
+	bind(buff)
+	require.Equal(t, 10, buff.bucket.Capacity())
+
+	buff = NewBuffer(123, pool, pool)
+	buff.SetPacketBufferSize(50)
+	bind(buff)
+	require.Equal(t, 50, buff.bucket.Capacity())
+
+	// too late once bound
+	buff.SetPacketBufferSize(100)
+	require.Equal(t, 50, buff.packetBufferSize)
+	require.NoError(t, buff.Close())
+}
+
+func TestBitDepthMismatch(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 1500)
+			return &b
+		},
+	}
+	bind := func(buff *Buffer, fmtp string) {
+		codec := webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{
+				MimeType:    "video/vp9",
+				ClockRate:   90000,
+				SDPFmtpLine: fmtp,
+			},
+			PayloadType: 98,
+		}
+		buff.Bind(webrtc.RTPParameters{
+			Codecs: []webrtc.RTPCodecParameters{codec},
+		}, codec.RTPCodecCapability)
+	}
+
+	buff := NewBuffer(123, pool, pool)
+	bind(buff, "profile-id=0")
+	require.False(t, buff.HasBitDepthMismatch())
+	buff.updateBitDepth(8)
+	require.False(t, buff.HasBitDepthMismatch())
+	buff.updateBitDepth(10)
+	require.True(t, buff.HasBitDepthMismatch())
+	require.NoError(t, buff.Close())
+
+	buff = NewBuffer(123, pool, pool)
+	bind(buff, "profile-id=2")
+	buff.updateBitDepth(10)
+	require.False(t, buff.HasBitDepthMismatch())
+	require.NoError(t, buff.Close())
+}
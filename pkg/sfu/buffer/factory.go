@@ -23,26 +23,47 @@ import (
 	"github.com/livekit/mediatransportutil/pkg/bucket"
 )
 
+// FactoryOfBufferFactory holds the packet buffer pools shared by the buffer factories it creates
 type FactoryOfBufferFactory struct {
-	videoPool *sync.Pool
-	audioPool *sync.Pool
+	videoPool    *sync.Pool
+	audioPool    *sync.Pool
+	videoReserve *bufferReserve
+	audioReserve *bufferReserve
+	videoSize    int
+	audioSize    int
 }
 
 func NewFactoryOfBufferFactory(trackingPacketsVideo int, trackingPacketsAudio int) *FactoryOfBufferFactory {
-	return &FactoryOfBufferFactory{
-		videoPool: &sync.Pool{
-			New: func() interface{} {
-				b := make([]byte, trackingPacketsVideo*bucket.MaxPktSize)
-				return &b
-			},
+	f := &FactoryOfBufferFactory{
+		videoReserve: &bufferReserve{},
+		audioReserve: &bufferReserve{},
+		videoSize:    trackingPacketsVideo * bucket.MaxPktSize,
+		audioSize:    trackingPacketsAudio * bucket.MaxPktSize,
+	}
+	f.videoPool = &sync.Pool{
+		New: func() interface{} {
+			return f.videoReserve.getOrNew(f.videoSize)
 		},
-		audioPool: &sync.Pool{
-			New: func() interface{} {
-				b := make([]byte, trackingPacketsAudio*bucket.MaxPktSize)
-				return &b
-			},
+	}
+	f.audioPool = &sync.Pool{
+		New: func() interface{} {
+			return f.audioReserve.getOrNew(f.audioSize)
 		},
 	}
+	return f
+}
+
+// Prewarm allocates the packet buffers of the given number of streams up front. They are handed out before
+// new buffers are allocated and, unlike buffers returned to the pools, are not released by garbage collection
+// until they are used.
+func (f *FactoryOfBufferFactory) Prewarm(videoStreams int, audioStreams int) {
+	f.videoReserve.fill(videoStreams, f.videoSize)
+	f.audioReserve.fill(audioStreams, f.audioSize)
+}
+
+// Reserved returns the number of prewarmed buffers not handed out yet
+func (f *FactoryOfBufferFactory) Reserved() (video int, audio int) {
+	return f.videoReserve.len(), f.audioReserve.len()
 }
 
 func (f *FactoryOfBufferFactory) CreateBufferFactory() *Factory {
@@ -141,3 +162,47 @@ func (f *Factory) SetRTXPair(repair, base uint32) {
 		repairBuffer.SetPrimaryBufferForRTX(baseBuffer)
 	}
 }
+
+// ----------------------------------
+
+type bufferReserve struct {
+	lock    sync.Mutex
+	buffers []*[]byte
+}
+
+func (r *bufferReserve) fill(count int, size int) {
+	buffers := make([]*[]byte, 0, count)
+	for i := 0; i < count; i++ {
+		b := make([]byte, size)
+		// touch every page, so that the memory is mapped before the first packets arrive
+		for j := 0; j < len(b); j += 4096 {
+			b[j] = 0
+		}
+		buffers = append(buffers, &b)
+	}
+
+	r.lock.Lock()
+	r.buffers = append(r.buffers, buffers...)
+	r.lock.Unlock()
+}
+
+func (r *bufferReserve) getOrNew(size int) *[]byte {
+	r.lock.Lock()
+	if n := len(r.buffers); n > 0 {
+		b := r.buffers[n-1]
+		r.buffers[n-1] = nil
+		r.buffers = r.buffers[:n-1]
+		r.lock.Unlock()
+		return b
+	}
+	r.lock.Unlock()
+
+	b := make([]byte, size)
+	return &b
+}
+
+func (r *bufferReserve) len() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.buffers)
+}
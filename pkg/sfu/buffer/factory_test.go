@@ -0,0 +1,48 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/mediatransportutil/pkg/bucket"
+)
+
+func TestFactoryOfBufferFactoryPrewarm(t *testing.T) {
+	f := NewFactoryOfBufferFactory(10, 5)
+	f.Prewarm(2, 1)
+	video, audio := f.Reserved()
+	require.Equal(t, 2, video)
+	require.Equal(t, 1, audio)
+
+	// prewarmed buffers are handed out first
+	b := f.videoPool.New().(*[]byte)
+	require.Len(t, *b, 10*bucket.MaxPktSize)
+	video, _ = f.Reserved()
+	require.Equal(t, 1, video)
+
+	f.videoPool.New()
+	b = f.videoPool.New().(*[]byte)
+	require.Len(t, *b, 10*bucket.MaxPktSize)
+	video, _ = f.Reserved()
+	require.Zero(t, video)
+
+	b = f.audioPool.New().(*[]byte)
+	require.Len(t, *b, 5*bucket.MaxPktSize)
+	_, audio = f.Reserved()
+	require.Zero(t, audio)
+}
@@ -340,6 +340,46 @@ func IsVP9KeyFrame(payload []byte) bool {
 
 // -------------------------------------
 
+// VP9KeyFrameBitDepth returns the bit depth of a VP9 key frame, from the profile and color config
+// of its uncompressed header. ok is false if the payload does not start a key frame.
+func VP9KeyFrameBitDepth(payload []byte) (bitDepth int, ok bool) {
+	var vp9 codecs.VP9Packet
+	if _, err := vp9.Unmarshal(payload); err != nil || !vp9.B {
+		return 0, false
+	}
+
+	r := bitReader{data: vp9.Payload}
+	if r.read(2) != 2 { // frame_marker
+		return 0, false
+	}
+	profile := r.read(1)
+	profile |= r.read(1) << 1
+	if profile == 3 {
+		r.read(1) // reserved_zero
+	}
+	if r.read(1) != 0 { // show_existing_frame
+		return 0, false
+	}
+	if r.read(1) != 0 { // frame_type, a key frame is 0
+		return 0, false
+	}
+	r.read(2)                   // show_frame, error_resilient_mode
+	if r.read(24) != 0x498342 { // frame_sync_code
+		return 0, false
+	}
+
+	bitDepth = 8
+	if profile >= 2 {
+		bitDepth = 10
+		if r.read(1) == 1 { // ten_or_twelve_bit
+			bitDepth = 12
+		}
+	}
+	return bitDepth, !r.overrun
+}
+
+// -------------------------------------
+
 // IsAV1KeyFrame detects if av1 payload is a keyframe
 // taken from https://github.com/jech/galene/blob/master/codecs/codecs.go
 // all credits belongs to Juliusz Chroboczek @jech and the awesome Galene SFU
@@ -416,3 +456,173 @@ func IsAV1KeyFrame(payload []byte) bool {
 }
 
 // -------------------------------------
+
+// AV1KeyFrameBitDepth returns the bit depth from the sequence header that a key frame packet starts with,
+// see section 5.5 of the AV1 bitstream specification. ok is false if the packet does not carry a sequence header.
+func AV1KeyFrameBitDepth(payload []byte) (bitDepth int, ok bool) {
+	if len(payload) < 2 {
+		return 0, false
+	}
+	// Z=0, N=1
+	if (payload[0] & 0x88) != 0x08 {
+		return 0, false
+	}
+
+	obu := payload[1:]
+	if (payload[0]&0x30)>>4 != 1 {
+		// the first element has a length field unless it is the only one
+		length, n := readLeb128(obu)
+		if n == 0 || len(obu) < n+length {
+			return 0, false
+		}
+		obu = obu[n : n+length]
+	}
+	if len(obu) < 1 || (obu[0]&0x78)>>3 != 1 { // OBU_SEQUENCE_HEADER
+		return 0, false
+	}
+	offset := 1
+	if obu[0]&0x04 != 0 { // obu_extension_flag
+		offset++
+	}
+	if obu[0]&0x02 != 0 { // obu_has_size_field
+		if len(obu) < offset {
+			return 0, false
+		}
+		_, n := readLeb128(obu[offset:])
+		if n == 0 {
+			return 0, false
+		}
+		offset += n
+	}
+	if len(obu) < offset {
+		return 0, false
+	}
+
+	r := bitReader{data: obu[offset:]}
+	seqProfile := r.read(3)
+	r.read(1) // still_picture
+	reducedStillPictureHeader := r.read(1) == 1
+	if reducedStillPictureHeader {
+		r.read(5) // seq_level_idx[0]
+	} else {
+		decoderModelInfoPresent := false
+		bufferDelayLength := uint32(0)
+		if r.read(1) == 1 { // timing_info_present_flag
+			r.read(32)          // num_units_in_display_tick
+			r.read(32)          // time_scale
+			if r.read(1) == 1 { // equal_picture_interval
+				r.readUvlc() // num_ticks_per_picture_minus_1
+			}
+			decoderModelInfoPresent = r.read(1) == 1
+			if decoderModelInfoPresent {
+				bufferDelayLength = r.read(5) + 1
+				r.read(32) // num_units_in_decoding_tick
+				r.read(5)  // buffer_removal_time_length_minus_1
+				r.read(5)  // frame_presentation_time_length_minus_1
+			}
+		}
+		initialDisplayDelayPresent := r.read(1) == 1
+		operatingPoints := int(r.read(5)) + 1
+		for i := 0; i < operatingPoints && !r.overrun; i++ {
+			r.read(12)         // operating_point_idc
+			if r.read(5) > 7 { // seq_level_idx
+				r.read(1) // seq_tier
+			}
+			if decoderModelInfoPresent && r.read(1) == 1 { // decoder_model_present_for_this_op
+				r.read(bufferDelayLength) // decoder_buffer_delay
+				r.read(bufferDelayLength) // encoder_buffer_delay
+				r.read(1)                 // low_delay_mode_flag
+			}
+			if initialDisplayDelayPresent && r.read(1) == 1 { // initial_display_delay_present_for_this_op
+				r.read(4) // initial_display_delay_minus_1
+			}
+		}
+	}
+
+	frameWidthBits := r.read(4) + 1
+	frameHeightBits := r.read(4) + 1
+	r.read(frameWidthBits)                            // max_frame_width_minus_1
+	r.read(frameHeightBits)                           // max_frame_height_minus_1
+	if !reducedStillPictureHeader && r.read(1) == 1 { // frame_id_numbers_present_flag
+		r.read(4) // delta_frame_id_length_minus_2
+		r.read(3) // additional_frame_id_length_minus_1
+	}
+	r.read(3) // use_128x128_superblock, enable_filter_intra, enable_intra_edge_filter
+	if !reducedStillPictureHeader {
+		r.read(4) // enable_interintra_compound, enable_masked_compound, enable_warped_motion, enable_dual_filter
+		enableOrderHint := r.read(1) == 1
+		if enableOrderHint {
+			r.read(2) // enable_jnt_comp, enable_ref_frame_mvs
+		}
+		seqForceScreenContentTools := uint32(2)
+		if r.read(1) == 0 { // seq_choose_screen_content_tools
+			seqForceScreenContentTools = r.read(1)
+		}
+		if seqForceScreenContentTools > 0 {
+			if r.read(1) == 0 { // seq_choose_integer_mv
+				r.read(1) // seq_force_integer_mv
+			}
+		}
+		if enableOrderHint {
+			r.read(3) // order_hint_bits_minus_1
+		}
+	}
+	r.read(3) // enable_superres, enable_cdef, enable_restoration
+
+	// color_config
+	bitDepth = 8
+	if r.read(1) == 1 { // high_bitdepth
+		bitDepth = 10
+		if seqProfile == 2 && r.read(1) == 1 { // twelve_bit
+			bitDepth = 12
+		}
+	}
+	return bitDepth, !r.overrun
+}
+
+func readLeb128(data []byte) (value int, n int) {
+	for n < len(data) && n < 8 {
+		b := data[n]
+		value |= int(b&0x7f) << (n * 7)
+		n++
+		if b&0x80 == 0 {
+			return value, n
+		}
+	}
+	return 0, 0
+}
+
+// bitReader reads MSB first, reads past the end return zeros and set overrun
+type bitReader struct {
+	data    []byte
+	pos     int
+	overrun bool
+}
+
+func (r *bitReader) read(bits uint32) uint32 {
+	var v uint32
+	for i := uint32(0); i < bits; i++ {
+		v <<= 1
+		if r.pos >= len(r.data)*8 {
+			r.overrun = true
+			continue
+		}
+		v |= uint32(r.data[r.pos/8]>>(7-r.pos%8)) & 1
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) readUvlc() uint32 {
+	leadingZeros := uint32(0)
+	for r.read(1) == 0 {
+		if r.overrun {
+			return 0
+		}
+		leadingZeros++
+	}
+	if leadingZeros >= 32 {
+		return 1<<32 - 1
+	}
+	return r.read(leadingZeros) + (1 << leadingZeros) - 1
+}
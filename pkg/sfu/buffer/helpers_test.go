@@ -108,3 +108,110 @@ func TestVP8Helper_Unmarshal(t *testing.T) {
 }
 
 // ------------------------------------------
+
+func TestVP9KeyFrameBitDepth(t *testing.T) {
+	// payload descriptor with B set, followed by the uncompressed header of a key frame
+	profile0 := []byte{0x08, 0x82, 0x49, 0x83, 0x42, 0x00}
+	bitDepth, ok := VP9KeyFrameBitDepth(profile0)
+	require.True(t, ok)
+	require.Equal(t, 8, bitDepth)
+
+	profile2 := []byte{0x08, 0x92, 0x49, 0x83, 0x42, 0x00}
+	require.True(t, IsVP9KeyFrame(profile2))
+	bitDepth, ok = VP9KeyFrameBitDepth(profile2)
+	require.True(t, ok)
+	require.Equal(t, 10, bitDepth)
+
+	profile2TwelveBit := []byte{0x08, 0x92, 0x49, 0x83, 0x42, 0x80}
+	bitDepth, ok = VP9KeyFrameBitDepth(profile2TwelveBit)
+	require.True(t, ok)
+	require.Equal(t, 12, bitDepth)
+
+	// not starting a frame
+	_, ok = VP9KeyFrameBitDepth([]byte{0x00, 0x92, 0x49, 0x83, 0x42, 0x00})
+	require.False(t, ok)
+
+	// inter frame
+	_, ok = VP9KeyFrameBitDepth([]byte{0x08, 0x96, 0x49, 0x83, 0x42, 0x00})
+	require.False(t, ok)
+}
+
+func TestAV1KeyFrameBitDepth(t *testing.T) {
+	sequenceHeader := func(reduced bool, highBitDepth bool) []byte {
+		w := &testBitWriter{}
+		w.write(0, 3) // seq_profile
+		w.write(0, 1) // still_picture
+		if reduced {
+			w.write(1, 1)  // reduced_still_picture_header
+			w.write(31, 5) // seq_level_idx[0]
+		} else {
+			w.write(0, 1)  // reduced_still_picture_header
+			w.write(0, 1)  // timing_info_present_flag
+			w.write(0, 1)  // initial_display_delay_present_flag
+			w.write(0, 5)  // operating_points_cnt_minus_1
+			w.write(0, 12) // operating_point_idc
+			w.write(8, 5)  // seq_level_idx
+			w.write(0, 1)  // seq_tier
+		}
+		w.write(10, 4)    // frame_width_bits_minus_1
+		w.write(10, 4)    // frame_height_bits_minus_1
+		w.write(1279, 11) // max_frame_width_minus_1
+		w.write(719, 11)  // max_frame_height_minus_1
+		if !reduced {
+			w.write(0, 1) // frame_id_numbers_present_flag
+		}
+		w.write(0, 3) // use_128x128_superblock, enable_filter_intra, enable_intra_edge_filter
+		if !reduced {
+			w.write(0, 4) // enable_interintra_compound, enable_masked_compound, enable_warped_motion, enable_dual_filter
+			w.write(1, 1) // enable_order_hint
+			w.write(0, 2) // enable_jnt_comp, enable_ref_frame_mvs
+			w.write(1, 1) // seq_choose_screen_content_tools
+			w.write(1, 1) // seq_choose_integer_mv
+			w.write(6, 3) // order_hint_bits_minus_1
+		}
+		w.write(0, 3) // enable_superres, enable_cdef, enable_restoration
+		if highBitDepth {
+			w.write(1, 1)
+		} else {
+			w.write(0, 1)
+		}
+
+		// aggregation header with a single element and N set, followed by the OBU header
+		return append([]byte{0x18, 0x08}, w.data...)
+	}
+
+	for _, reduced := range []bool{false, true} {
+		bitDepth, ok := AV1KeyFrameBitDepth(sequenceHeader(reduced, true))
+		require.True(t, ok)
+		require.Equal(t, 10, bitDepth)
+
+		bitDepth, ok = AV1KeyFrameBitDepth(sequenceHeader(reduced, false))
+		require.True(t, ok)
+		require.Equal(t, 8, bitDepth)
+	}
+
+	// OBU_FRAME instead of a sequence header
+	_, ok := AV1KeyFrameBitDepth([]byte{0x18, 0x30, 0x10})
+	require.False(t, ok)
+
+	// truncated sequence header
+	_, ok = AV1KeyFrameBitDepth(sequenceHeader(false, true)[:4])
+	require.False(t, ok)
+}
+
+type testBitWriter struct {
+	data []byte
+	bits int
+}
+
+func (w *testBitWriter) write(value uint32, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.bits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if (value>>i)&1 == 1 {
+			w.data[len(w.data)-1] |= 1 << (7 - w.bits%8)
+		}
+		w.bits++
+	}
+}
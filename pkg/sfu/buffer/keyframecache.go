@@ -0,0 +1,78 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"github.com/pion/rtp"
+)
+
+// keyFrameCache keeps the packets of a layer since its last key frame. The frames after a key frame depend on it
+// and on each other, a subscriber starting with all of them decodes the current picture right away. For SVC codecs
+// the packets of the upper spatial layers of the key frame picture, and after, are kept along with the base layer.
+// Packets are copied, so that they outlive the buffers of the packet processing.
+type keyFrameCache struct {
+	maxPackets int
+
+	keyFrameTS uint64
+	// a new slice is started on every key frame, slices handed out are only appended to
+	packets []*ExtPacket
+}
+
+func newKeyFrameCache(maxPackets int) *keyFrameCache {
+	return &keyFrameCache{maxPackets: maxPackets}
+}
+
+func (c *keyFrameCache) add(ep *ExtPacket) {
+	if len(ep.Packet.Payload) == 0 {
+		return
+	}
+
+	if ep.KeyFrame && (len(c.packets) == 0 || ep.ExtTimestamp > c.keyFrameTS) {
+		c.keyFrameTS = ep.ExtTimestamp
+		c.packets = make([]*ExtPacket, 0, 64)
+	} else if len(c.packets) == 0 || ep.ExtSequenceNumber < c.packets[0].ExtSequenceNumber {
+		return
+	}
+
+	if len(c.packets) >= c.maxPackets {
+		// too long between key frames, waiting for the next one
+		c.packets = nil
+		return
+	}
+	if cp := copyExtPacket(ep); cp != nil {
+		c.packets = append(c.packets, cp)
+	}
+}
+
+func (c *keyFrameCache) get() []*ExtPacket {
+	return c.packets
+}
+
+func copyExtPacket(ep *ExtPacket) *ExtPacket {
+	raw := make([]byte, len(ep.RawPacket))
+	copy(raw, ep.RawPacket)
+
+	pkt := &rtp.Packet{}
+	if err := pkt.Unmarshal(raw); err != nil {
+		return nil
+	}
+	// sequence number adjusted for the padding only packets that were dropped
+	pkt.SequenceNumber = ep.Packet.SequenceNumber
+
+	cp := *ep
+	cp.Packet = pkt
+	cp.RawPacket = raw
+	return &cp
+}
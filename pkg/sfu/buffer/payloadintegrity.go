@@ -0,0 +1,220 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"bytes"
+	"time"
+)
+
+// PayloadIntegrityReason is why a video packet failed the checks of its payload
+type PayloadIntegrityReason string
+
+const (
+	// the payload descriptor or the codec headers it starts with cannot be parsed, or carry invalid values
+	PayloadIntegrityReasonUndecodableHeader PayloadIntegrityReason = "undecodable_header"
+	// packets of a frame carry different picture ids, or a new frame reuses the picture id of the previous one
+	PayloadIntegrityReasonPictureIDMismatch PayloadIntegrityReason = "picture_id_mismatch"
+)
+
+// windows with fewer packets are extended, so that a single bad packet of a low bitrate stream does not count much
+const payloadIntegrityMinWindowPackets = 50
+
+var vp8StartCode = []byte{0x9d, 0x01, 0x2a}
+
+type PayloadIntegrityParams struct {
+	// fraction of the packets of a window failing the checks over which a key frame is requested
+	PLIThreshold float64
+	// fraction over which the stream is flagged as corrupted, the flag is cleared once it goes below the PLI threshold
+	FlagThreshold float64
+	Window        time.Duration
+}
+
+// PayloadIntegrityReport is the result of the checks over a window. It is reported for windows with packets
+// failing the checks, and when the stream is flagged or cleared.
+type PayloadIntegrityReport struct {
+	Packets   int
+	Errors    map[PayloadIntegrityReason]int
+	ErrorRate float64
+	Flagged   bool
+	// the stream was flagged or cleared at the end of this window
+	FlagChanged bool
+}
+
+// payloadIntegrityChecker checks the payloads of the packets of a video stream, corrupted streams from buggy
+// encoders would otherwise be forwarded to every subscriber. It is used with the lock of the buffer held.
+type payloadIntegrityChecker struct {
+	params PayloadIntegrityParams
+
+	hasPicture   bool
+	pictureExtTS uint64
+	pictureID    uint16
+
+	windowStart time.Time
+	packets     int
+	errors      map[PayloadIntegrityReason]int
+	flagged     bool
+}
+
+func newPayloadIntegrityChecker(params PayloadIntegrityParams) *payloadIntegrityChecker {
+	return &payloadIntegrityChecker{
+		params: params,
+		errors: make(map[PayloadIntegrityReason]int),
+	}
+}
+
+// checkPictureID returns a mismatch when the packets of a frame, those with the same timestamp, carry different
+// picture ids, or when a newer frame carries the picture id of the previous one. Older frames are not checked.
+func (c *payloadIntegrityChecker) checkPictureID(extTimestamp uint64, pictureID uint16) PayloadIntegrityReason {
+	if !c.hasPicture || extTimestamp > c.pictureExtTS {
+		reused := c.hasPicture && pictureID == c.pictureID
+		c.hasPicture = true
+		c.pictureExtTS = extTimestamp
+		c.pictureID = pictureID
+		if reused {
+			return PayloadIntegrityReasonPictureIDMismatch
+		}
+		return ""
+	}
+
+	if extTimestamp == c.pictureExtTS && pictureID != c.pictureID {
+		return PayloadIntegrityReasonPictureIDMismatch
+	}
+	return ""
+}
+
+// add counts a checked packet, reason is empty when it passed the checks. Once the window is over, it returns
+// whether a key frame should be requested, and the report of the window when there is something to report.
+func (c *payloadIntegrityChecker) add(reason PayloadIntegrityReason, at time.Time) (bool, *PayloadIntegrityReport) {
+	if c.windowStart.IsZero() {
+		c.windowStart = at
+	}
+	c.packets++
+	if reason != "" {
+		c.errors[reason]++
+	}
+	if at.Sub(c.windowStart) < c.params.Window || c.packets < payloadIntegrityMinWindowPackets {
+		return false, nil
+	}
+
+	numErrors := 0
+	for _, n := range c.errors {
+		numErrors += n
+	}
+	errorRate := float64(numErrors) / float64(c.packets)
+
+	flagged := c.flagged
+	if errorRate > c.params.FlagThreshold {
+		flagged = true
+	} else if errorRate < c.params.PLIThreshold {
+		flagged = false
+	}
+
+	var report *PayloadIntegrityReport
+	if numErrors != 0 || flagged != c.flagged {
+		report = &PayloadIntegrityReport{
+			Packets:     c.packets,
+			Errors:      c.errors,
+			ErrorRate:   errorRate,
+			Flagged:     flagged,
+			FlagChanged: flagged != c.flagged,
+		}
+		c.errors = make(map[PayloadIntegrityReason]int)
+	}
+	c.flagged = flagged
+	c.windowStart = at
+	c.packets = 0
+	return errorRate > c.params.PLIThreshold, report
+}
+
+// -------------------------------------
+
+// vp8KeyFrameHeaderValid checks the start code of the uncompressed header of a VP8 key frame, RFC 6386 section 9.1.
+// payload is the RTP payload, the payload header follows the payload descriptor.
+func vp8KeyFrameHeaderValid(vp8 *VP8, payload []byte) bool {
+	if vp8.FirstByte&0x07 != 0 {
+		// not the first partition
+		return true
+	}
+	header := payload[vp8.HeaderSize:]
+	return len(header) >= 6 && bytes.Equal(header[3:6], vp8StartCode)
+}
+
+// h264HeaderValid checks the NAL unit headers of a H264 payload, RFC 6184
+func h264HeaderValid(payload []byte) bool {
+	if len(payload) < 1 || payload[0]&0x80 != 0 {
+		// forbidden_zero_bit
+		return false
+	}
+
+	switch nalu := payload[0] & 0x1f; {
+	case nalu == 0 || nalu >= 30:
+		// reserved
+		return false
+
+	case nalu == 24:
+		// STAP-A, the aggregated NAL units have to fill the payload exactly
+		i := 1
+		for i < len(payload) {
+			if i+2 > len(payload) {
+				return false
+			}
+			length := int(payload[i])<<8 | int(payload[i+1])
+			i += 2
+			if length == 0 || i+length > len(payload) || payload[i]&0x80 != 0 {
+				return false
+			}
+			i += length
+		}
+		return i > 1
+
+	case nalu == 28:
+		// FU-A, a fragment cannot both start and end a NAL unit
+		if len(payload) < 3 {
+			return false
+		}
+		fuHeader := payload[1]
+		return fuHeader&0xc0 != 0xc0 && fuHeader&0x1f != 0
+	}
+	return true
+}
+
+// av1HeaderValid checks the aggregation header of an AV1 payload and the header of the OBU it starts
+func av1HeaderValid(payload []byte) bool {
+	if len(payload) < 2 {
+		return false
+	}
+	z := payload[0]&0x80 != 0
+	w := (payload[0] & 0x30) >> 4
+	n := payload[0]&0x08 != 0
+	if n && z {
+		// a new coded video sequence cannot start with the continuation of an OBU
+		return false
+	}
+	if z {
+		return true
+	}
+
+	offset := 1
+	if w != 1 {
+		length, size := readLeb128(payload[offset:])
+		if size == 0 || length == 0 || offset+size+length > len(payload) {
+			return false
+		}
+		offset += size
+	}
+	// obu_forbidden_bit
+	return payload[offset]&0x80 == 0
+}
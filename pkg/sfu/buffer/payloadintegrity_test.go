@@ -0,0 +1,175 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadIntegrityChecker(t *testing.T) {
+	t.Run("picture ids", func(t *testing.T) {
+		c := newPayloadIntegrityChecker(PayloadIntegrityParams{})
+		require.Empty(t, c.checkPictureID(1000, 1))
+		require.Empty(t, c.checkPictureID(1000, 1))
+		require.Equal(t, PayloadIntegrityReasonPictureIDMismatch, c.checkPictureID(1000, 2))
+		require.Empty(t, c.checkPictureID(4000, 2))
+		// a new frame with the picture id of the previous one
+		require.Equal(t, PayloadIntegrityReasonPictureIDMismatch, c.checkPictureID(7000, 2))
+		// lost frames skip picture ids, older frames are not checked
+		require.Empty(t, c.checkPictureID(13000, 5))
+		require.Empty(t, c.checkPictureID(10000, 5))
+	})
+
+	t.Run("thresholds", func(t *testing.T) {
+		c := newPayloadIntegrityChecker(PayloadIntegrityParams{
+			PLIThreshold:  0.02,
+			FlagThreshold: 0.1,
+			Window:        time.Second,
+		})
+		start := time.Now()
+		window := func(at time.Time, numErrors int) (bool, *PayloadIntegrityReport) {
+			for i := 0; i < 99; i++ {
+				reason := PayloadIntegrityReason("")
+				if i < numErrors {
+					reason = PayloadIntegrityReasonUndecodableHeader
+				}
+				sendPLI, report := c.add(reason, at)
+				require.False(t, sendPLI)
+				require.Nil(t, report)
+			}
+			return c.add("", at.Add(time.Second))
+		}
+
+		// clean windows are not reported
+		sendPLI, report := window(start, 0)
+		require.False(t, sendPLI)
+		require.Nil(t, report)
+
+		// over the PLI threshold
+		sendPLI, report = window(start.Add(time.Second), 5)
+		require.True(t, sendPLI)
+		require.Equal(t, 5, report.Errors[PayloadIntegrityReasonUndecodableHeader])
+		require.InDelta(t, 0.05, report.ErrorRate, 0.001)
+		require.False(t, report.Flagged)
+
+		// over the flag threshold, the flag stays until the rate goes below the PLI threshold
+		sendPLI, report = window(start.Add(2*time.Second), 20)
+		require.True(t, sendPLI)
+		require.True(t, report.Flagged)
+		require.True(t, report.FlagChanged)
+
+		_, report = window(start.Add(3*time.Second), 5)
+		require.True(t, report.Flagged)
+		require.False(t, report.FlagChanged)
+
+		sendPLI, report = window(start.Add(4*time.Second), 0)
+		require.False(t, sendPLI)
+		require.False(t, report.Flagged)
+		require.True(t, report.FlagChanged)
+	})
+}
+
+func TestPayloadIntegrityHeaders(t *testing.T) {
+	t.Run("vp8", func(t *testing.T) {
+		payload := []byte{0x10, 0x00, 0x00, 0x00, 0x9d, 0x01, 0x2a, 0x80, 0x02}
+		vp8 := VP8{}
+		require.NoError(t, vp8.Unmarshal(payload))
+		require.True(t, vp8.IsKeyFrame)
+		require.True(t, vp8KeyFrameHeaderValid(&vp8, payload))
+
+		payload[5] = 0x02
+		require.False(t, vp8KeyFrameHeaderValid(&vp8, payload))
+	})
+
+	t.Run("h264", func(t *testing.T) {
+		require.True(t, h264HeaderValid([]byte{0x65, 0x88}))
+		// forbidden zero bit, reserved type
+		require.False(t, h264HeaderValid([]byte{0xe5, 0x88}))
+		require.False(t, h264HeaderValid([]byte{0x1e, 0x88}))
+		// STAP-A with SPS and PPS, and with a length past the payload
+		require.True(t, h264HeaderValid([]byte{0x18, 0x00, 0x02, 0x67, 0x42, 0x00, 0x02, 0x68, 0xce}))
+		require.False(t, h264HeaderValid([]byte{0x18, 0x00, 0x02, 0x67, 0x42, 0x00, 0x05, 0x68, 0xce}))
+		// FU-A starting and ending a NAL unit
+		require.True(t, h264HeaderValid([]byte{0x7c, 0x85, 0x88}))
+		require.False(t, h264HeaderValid([]byte{0x7c, 0xc5, 0x88}))
+	})
+
+	t.Run("av1", func(t *testing.T) {
+		// W=1, N=1 with a sequence header OBU
+		require.True(t, av1HeaderValid([]byte{0x18, 0x0a, 0x00}))
+		// W=1 with the forbidden bit of the OBU set, and a new sequence starting with a continuation
+		require.False(t, av1HeaderValid([]byte{0x10, 0x8a, 0x00}))
+		require.False(t, av1HeaderValid([]byte{0x98, 0x0a, 0x00}))
+		// W=2, the first OBU has a length
+		require.True(t, av1HeaderValid([]byte{0x20, 0x02, 0x32, 0x00, 0x01, 0x32}))
+		require.False(t, av1HeaderValid([]byte{0x20, 0x09, 0x32, 0x00}))
+	})
+}
+
+func TestBufferPayloadIntegrity(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 1500)
+			return &b
+		},
+	}
+
+	buff := NewBuffer(123, pool, pool)
+	buff.codecType = webrtc.RTPCodecTypeVideo
+	var numPLIs int
+	buff.OnRtcpFeedback(func(fb []rtcp.Packet) {
+		for _, pkt := range fb {
+			if _, ok := pkt.(*rtcp.PictureLossIndication); ok {
+				numPLIs++
+			}
+		}
+	})
+	var reports []*PayloadIntegrityReport
+	buff.OnPayloadIntegrityReport(func(report *PayloadIntegrityReport) {
+		reports = append(reports, report)
+	})
+	buff.SetPayloadIntegrity(PayloadIntegrityParams{
+		PLIThreshold:  0.01,
+		FlagThreshold: 0.05,
+		Window:        time.Nanosecond,
+	})
+	buff.Bind(webrtc.RTPParameters{
+		Codecs: []webrtc.RTPCodecParameters{vp8Codec},
+	}, vp8Codec.RTPCodecCapability)
+
+	// every frame reuses the picture id
+	for i := 0; i < payloadIntegrityMinWindowPackets; i++ {
+		pkt := rtp.Packet{
+			Header:  rtp.Header{SequenceNumber: uint16(i), Timestamp: uint32(i * 3000)},
+			Payload: []byte{0x90, 0x80, 0x05, 0x01, 0x00, 0x00},
+		}
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = buff.Write(b)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, reports, 1)
+	require.True(t, reports[0].Flagged)
+	require.Equal(t, payloadIntegrityMinWindowPackets-1, reports[0].Errors[PayloadIntegrityReasonPictureIDMismatch])
+	require.Equal(t, 1, numPLIs)
+}
@@ -0,0 +1,138 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"time"
+)
+
+// ReorderParams holds back packets received after a gap in sequence numbers, for the missing ones to arrive late
+// or to be retransmitted, so that packets are forwarded in order. It trades latency for fewer out of order packets
+// at subscribers, whose jitter buffers may give up on frames that are completed too late.
+type ReorderParams struct {
+	// packets held back at most, the missing packets are given up on when more are held. 0 disables reordering
+	Depth int
+	// longest a packet is held back waiting for the missing packets before it
+	MaxLatency time.Duration
+}
+
+type reorderWindow struct {
+	params ReorderParams
+
+	initialized bool
+	nextSN      uint64
+	// sorted by extended sequence number
+	held  []*ExtPacket
+	ready []*ExtPacket
+}
+
+func newReorderWindow(params ReorderParams) *reorderWindow {
+	return &reorderWindow{
+		params: params,
+		held:   make([]*ExtPacket, 0, params.Depth+1),
+	}
+}
+
+// add returns the packets to forward in order with the arrival of ep, the returned slice is only valid until
+// the next call
+func (r *reorderWindow) add(ep *ExtPacket) []*ExtPacket {
+	r.ready = r.ready[:0]
+
+	esn := ep.ExtSequenceNumber
+	if !r.initialized {
+		r.initialized = true
+		r.nextSN = esn
+	}
+
+	switch {
+	case esn < r.nextSN:
+		// too late to be reordered, the packets after it were forwarded already
+		r.ready = append(r.ready, ep)
+
+	case esn == r.nextSN:
+		r.ready = append(r.ready, ep)
+		r.nextSN++
+		r.releaseInOrder()
+
+	default:
+		idx := len(r.held)
+		for i, h := range r.held {
+			if h.ExtSequenceNumber == esn {
+				return r.ready
+			}
+			if h.ExtSequenceNumber > esn {
+				idx = i
+				break
+			}
+		}
+		r.held = append(r.held, nil)
+		copy(r.held[idx+1:], r.held[idx:])
+		r.held[idx] = ep
+
+		for len(r.held) > r.params.Depth {
+			r.releaseHead()
+		}
+	}
+	return r.ready
+}
+
+// expire returns the packets held back longer than the max latency along with the held packets before them,
+// the returned slice is only valid until the next call
+func (r *reorderWindow) expire(now time.Time) []*ExtPacket {
+	r.ready = r.ready[:0]
+	if r.params.MaxLatency <= 0 {
+		return r.ready
+	}
+
+	expired := -1
+	for i, h := range r.held {
+		if now.Sub(h.Arrival) >= r.params.MaxLatency {
+			expired = i
+		}
+	}
+	if expired < 0 {
+		return r.ready
+	}
+
+	lastSN := r.held[expired].ExtSequenceNumber
+	for len(r.held) > 0 && r.held[0].ExtSequenceNumber <= lastSN {
+		r.releaseHead()
+	}
+	return r.ready
+}
+
+// releaseHead gives up on the packets missing before the first held packet
+func (r *reorderWindow) releaseHead() {
+	head := r.popHead()
+	r.ready = append(r.ready, head)
+	r.nextSN = head.ExtSequenceNumber + 1
+	r.releaseInOrder()
+}
+
+func (r *reorderWindow) releaseInOrder() {
+	for len(r.held) > 0 && r.held[0].ExtSequenceNumber == r.nextSN {
+		r.ready = append(r.ready, r.popHead())
+		r.nextSN++
+	}
+}
+
+// popHead removes the first held packet, shifting the others to keep the capacity of the window
+func (r *reorderWindow) popHead() *ExtPacket {
+	head := r.held[0]
+	copy(r.held, r.held[1:])
+	r.held[len(r.held)-1] = nil
+	r.held = r.held[:len(r.held)-1]
+	return head
+}
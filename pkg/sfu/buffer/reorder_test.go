@@ -0,0 +1,70 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func reorderedSNs(eps []*ExtPacket) []uint64 {
+	sns := make([]uint64, 0, len(eps))
+	for _, ep := range eps {
+		sns = append(sns, ep.ExtSequenceNumber)
+	}
+	return sns
+}
+
+func TestReorderWindow(t *testing.T) {
+	now := time.Now()
+	packet := func(esn uint64, arrival time.Time) *ExtPacket {
+		return &ExtPacket{ExtSequenceNumber: esn, Arrival: arrival}
+	}
+
+	t.Run("fills gaps", func(t *testing.T) {
+		r := newReorderWindow(ReorderParams{Depth: 5, MaxLatency: 100 * time.Millisecond})
+		require.Equal(t, []uint64{10}, reorderedSNs(r.add(packet(10, now))))
+		require.Empty(t, r.add(packet(12, now)))
+		require.Empty(t, r.add(packet(13, now)))
+		// duplicates of held packets are dropped
+		require.Empty(t, r.add(packet(13, now)))
+		require.Equal(t, []uint64{11, 12, 13}, reorderedSNs(r.add(packet(11, now))))
+		require.Equal(t, []uint64{14}, reorderedSNs(r.add(packet(14, now))))
+	})
+
+	t.Run("gives up when full", func(t *testing.T) {
+		r := newReorderWindow(ReorderParams{Depth: 2, MaxLatency: time.Second})
+		r.add(packet(10, now))
+		require.Empty(t, r.add(packet(13, now)))
+		require.Empty(t, r.add(packet(12, now)))
+		require.Equal(t, []uint64{12, 13}, reorderedSNs(r.add(packet(15, now))))
+		// forwarded as is once the packets after it are gone
+		require.Equal(t, []uint64{11}, reorderedSNs(r.add(packet(11, now))))
+		require.Equal(t, []uint64{14, 15}, reorderedSNs(r.add(packet(14, now))))
+	})
+
+	t.Run("gives up after max latency", func(t *testing.T) {
+		r := newReorderWindow(ReorderParams{Depth: 10, MaxLatency: 100 * time.Millisecond})
+		r.add(packet(10, now))
+		require.Empty(t, r.add(packet(12, now)))
+		require.Empty(t, r.add(packet(15, now.Add(50*time.Millisecond))))
+		require.Empty(t, r.expire(now.Add(90*time.Millisecond)))
+		require.Equal(t, []uint64{12}, reorderedSNs(r.expire(now.Add(100*time.Millisecond))))
+		require.Equal(t, []uint64{13}, reorderedSNs(r.add(packet(13, now.Add(110*time.Millisecond)))))
+		require.Equal(t, []uint64{15}, reorderedSNs(r.expire(now.Add(150*time.Millisecond))))
+	})
+}
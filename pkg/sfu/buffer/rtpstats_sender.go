@@ -596,6 +596,23 @@ func (r *RTPStatsSender) MaybeAdjustFirstPacketTime(srFirst *RTCPSenderReportDat
 	r.maybeAdjustFirstPacketTime(ts, uint32(r.extStartTS))
 }
 
+// GetSenderReportOffset returns how much later the last sender report sent maps the time of the last sender report of
+// the feed than the feed does, tsOffset maps RTP timestamps of the feed to the ones sent. The offset includes the
+// difference of the clock of the feed and the local one, so only offsets of streams of the same feed compare.
+func (r *RTPStatsSender) GetSenderReportOffset(tsOffset uint64) (time.Duration, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.srFeedNewest == nil || r.srNewest == nil || r.params.ClockRate == 0 {
+		return 0, false
+	}
+
+	ts := r.srFeedNewest.RTPTimestamp + uint32(tsOffset)
+	samplesDiff := int32(ts - r.srNewest.RTPTimestamp)
+	at := r.srNewest.NTPTimestamp.Time().Add(time.Duration(float64(samplesDiff) / float64(r.params.ClockRate) * float64(time.Second)))
+	return at.Sub(r.srFeedNewest.NTPTimestamp.Time()), true
+}
+
 func (r *RTPStatsSender) GetExpectedRTPTimestamp(at time.Time) (expectedTSExt uint64, err error) {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
@@ -0,0 +1,60 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bwe
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/interceptor/pkg/gcc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+var ErrBandwidthEstimatorClosed = errors.New("bandwidth estimator closed")
+
+type Params struct {
+	InitialBitrate int
+	// bounds of the estimate, defaults of the algorithm when 0
+	MinBitrate int
+	MaxBitrate int
+}
+
+// NewSendSideBWE creates the send side bandwidth estimator of a peer connection with the given algorithm,
+// defaulting to Google congestion control. All of them are fed transport-wide congestion control feedback
+// by the cc interceptor and report to the stream allocator through cc.BandwidthEstimator.
+func NewSendSideBWE(algorithm config.CongestionControlBWEAlgorithm, params Params) (cc.BandwidthEstimator, error) {
+	switch algorithm {
+	case "", config.CongestionControlBWEAlgorithmGCC:
+		opts := []gcc.Option{
+			gcc.SendSideBWEInitialBitrate(params.InitialBitrate),
+			gcc.SendSideBWEPacer(gcc.NewNoOpPacer()),
+		}
+		if params.MinBitrate > 0 {
+			opts = append(opts, gcc.SendSideBWEMinBitrate(params.MinBitrate))
+		}
+		if params.MaxBitrate > 0 {
+			opts = append(opts, gcc.SendSideBWEMaxBitrate(params.MaxBitrate))
+		}
+		return gcc.NewSendSideBWE(opts...)
+
+	case config.CongestionControlBWEAlgorithmNADA:
+		return NewNADA(params), nil
+
+	default:
+		return nil, fmt.Errorf("unknown send side bandwidth estimator %q", algorithm)
+	}
+}
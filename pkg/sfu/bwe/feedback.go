@@ -0,0 +1,106 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bwe
+
+import (
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+// packets sent over about four seconds at 5 Mbps
+const packetHistorySize = 1 << 11
+
+type sentPacket struct {
+	departure time.Duration
+	size      uint16
+	seq       uint16
+	valid     bool
+	reported  bool
+}
+
+type packetFeedback struct {
+	departure time.Duration
+	// in the clock of the receiver
+	arrival time.Duration
+	size    int
+	lost    bool
+}
+
+// packetHistory keeps packets sent with a transport-wide sequence number until they are reported,
+// a packet is reported once even if feedback for it arrives more than once
+type packetHistory struct {
+	packets [packetHistorySize]sentPacket
+}
+
+func (h *packetHistory) add(seq uint16, departure time.Duration, size int) {
+	if size > 0xffff {
+		size = 0xffff
+	}
+	h.packets[seq%packetHistorySize] = sentPacket{
+		departure: departure,
+		size:      uint16(size),
+		seq:       seq,
+		valid:     true,
+	}
+}
+
+func (h *packetHistory) onTransportCCFeedback(fb *rtcp.TransportLayerCC, out []packetFeedback) []packetFeedback {
+	arrival := time.Duration(fb.ReferenceTime) * 64 * time.Millisecond
+	seq := fb.BaseSequenceNumber
+	remaining := fb.PacketStatusCount
+	deltas := fb.RecvDeltas
+
+	onStatus := func(symbol uint16) {
+		received := symbol == rtcp.TypeTCCPacketReceivedSmallDelta || symbol == rtcp.TypeTCCPacketReceivedLargeDelta
+		if received && len(deltas) > 0 {
+			arrival += time.Duration(deltas[0].Delta) * time.Microsecond
+			deltas = deltas[1:]
+		}
+
+		p := &h.packets[seq%packetHistorySize]
+		if p.valid && p.seq == seq && !p.reported {
+			p.reported = true
+			out = append(out, packetFeedback{
+				departure: p.departure,
+				arrival:   arrival,
+				size:      int(p.size),
+				lost:      !received,
+			})
+		}
+		seq++
+		remaining--
+	}
+
+	for _, chunk := range fb.PacketChunks {
+		if remaining == 0 {
+			break
+		}
+		switch c := chunk.(type) {
+		case *rtcp.RunLengthChunk:
+			for i := uint16(0); i < c.RunLength && remaining > 0; i++ {
+				onStatus(c.PacketStatusSymbol)
+			}
+		case *rtcp.StatusVectorChunk:
+			for _, symbol := range c.SymbolList {
+				if remaining == 0 {
+					break
+				}
+				onStatus(symbol)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,329 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bwe
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+)
+
+// parameters of RFC 8698, section 6.3, durations in seconds
+const (
+	nadaPrio     = 1.0
+	nadaXRef     = 0.010
+	nadaKappa    = 0.5
+	nadaEta      = 2.0
+	nadaTau      = 0.5
+	nadaDelta    = 0.1
+	nadaLogWin   = 500 * time.Millisecond
+	nadaQEps     = 0.010
+	nadaDFilt    = 0.120
+	nadaGammaMax = 0.5
+	nadaQBound   = 0.050
+	nadaDLoss    = 0.010
+	nadaPLRRef   = 0.01
+	// weight of the latest feedback in the loss ratio
+	nadaLossAlpha = 0.1
+	// taps of the minimum filter on queuing delay samples
+	nadaDelayFilterTaps = 15
+	// the base delay is the minimum one-way delay seen over one to two windows
+	nadaBaseDelayWindow = 10 * time.Second
+
+	nadaDefaultMinBitrate = 50_000
+	nadaDefaultMaxBitrate = 10_000_000
+)
+
+type nadaMode int
+
+const (
+	nadaModeAcceleratedRampUp nadaMode = iota
+	nadaModeGradualUpdate
+)
+
+func (m nadaMode) String() string {
+	if m == nadaModeAcceleratedRampUp {
+		return "accelerated_ramp_up"
+	}
+	return "gradual_update"
+}
+
+type receivedBytes struct {
+	arrival time.Duration
+	size    int
+}
+
+// NADA estimates bandwidth with network-assisted dynamic adaptation (RFC 8698) from transport-wide
+// congestion control feedback. It reacts to queuing delay as well as loss, without the overuse detection
+// of GCC. Queuing delay is measured against the minimum one-way delay seen by the estimator.
+// ECN marking is not used.
+type NADA struct {
+	minBitrate float64
+	maxBitrate float64
+	createdAt  time.Time
+
+	lock    sync.Mutex
+	history packetHistory
+	acks    []packetFeedback
+
+	baseDelay        time.Duration
+	windowBaseDelay  time.Duration
+	baseDelayResetAt time.Time
+
+	delaySamples [nadaDelayFilterTaps]float64
+	numSamples   int
+
+	lossRatio  float64
+	lastLossAt time.Duration
+	received   []receivedBytes
+	rtt        float64
+
+	mode         nadaMode
+	refRate      float64
+	prevSignal   float64
+	lastUpdateAt time.Time
+
+	reportedBitrate       int
+	onTargetBitrateChange func(bitrate int)
+
+	closed bool
+}
+
+func NewNADA(params Params) *NADA {
+	n := &NADA{
+		minBitrate:      float64(params.MinBitrate),
+		maxBitrate:      float64(params.MaxBitrate),
+		createdAt:       time.Now(),
+		baseDelay:       math.MaxInt64,
+		windowBaseDelay: math.MaxInt64,
+		lastLossAt:      -nadaLogWin,
+	}
+	if n.minBitrate <= 0 {
+		n.minBitrate = nadaDefaultMinBitrate
+	}
+	if n.maxBitrate <= 0 {
+		n.maxBitrate = nadaDefaultMaxBitrate
+	}
+	n.refRate = math.Min(math.Max(float64(params.InitialBitrate), n.minBitrate), n.maxBitrate)
+	n.reportedBitrate = int(n.refRate)
+	return n
+}
+
+func (n *NADA) AddStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	var hdrExtID uint8
+	for _, ext := range info.RTPHeaderExtensions {
+		if ext.URI == sdp.TransportCCURI {
+			hdrExtID = uint8(ext.ID)
+			break
+		}
+	}
+	if hdrExtID == 0 {
+		return writer
+	}
+
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+		if ext := header.GetExtension(hdrExtID); len(ext) >= 2 {
+			n.onSent(binary.BigEndian.Uint16(ext), header.MarshalSize()+len(payload))
+		}
+		return writer.Write(header, payload, attributes)
+	})
+}
+
+func (n *NADA) onSent(seq uint16, size int) {
+	n.lock.Lock()
+	n.history.add(seq, time.Since(n.createdAt), size)
+	n.lock.Unlock()
+}
+
+func (n *NADA) WriteRTCP(pkts []rtcp.Packet, _ interceptor.Attributes) error {
+	n.lock.Lock()
+	if n.closed {
+		n.lock.Unlock()
+		return ErrBandwidthEstimatorClosed
+	}
+
+	for _, pkt := range pkts {
+		if fb, ok := pkt.(*rtcp.TransportLayerCC); ok {
+			n.acks = n.history.onTransportCCFeedback(fb, n.acks[:0])
+			if len(n.acks) != 0 {
+				n.updateLocked(time.Now(), n.acks)
+			}
+		}
+	}
+
+	var onTargetBitrateChange func(bitrate int)
+	bitrate := int(n.refRate)
+	if bitrate != n.reportedBitrate {
+		n.reportedBitrate = bitrate
+		onTargetBitrateChange = n.onTargetBitrateChange
+	}
+	n.lock.Unlock()
+
+	if onTargetBitrateChange != nil {
+		onTargetBitrateChange(bitrate)
+	}
+	return nil
+}
+
+func (n *NADA) updateLocked(at time.Time, acks []packetFeedback) {
+	now := at.Sub(n.createdAt)
+
+	if at.Sub(n.baseDelayResetAt) > nadaBaseDelayWindow {
+		n.baseDelay = n.windowBaseDelay
+		n.windowBaseDelay = math.MaxInt64
+		n.baseDelayResetAt = at
+	}
+
+	numLost := 0
+	var lastDeparture time.Duration
+	for _, ack := range acks {
+		if ack.lost {
+			numLost++
+			continue
+		}
+
+		owd := ack.arrival - ack.departure
+		if owd < n.windowBaseDelay {
+			n.windowBaseDelay = owd
+		}
+		if owd < n.baseDelay {
+			n.baseDelay = owd
+		}
+		n.delaySamples[n.numSamples%nadaDelayFilterTaps] = (owd - n.baseDelay).Seconds()
+		n.numSamples++
+
+		n.received = append(n.received, receivedBytes{arrival: ack.arrival, size: ack.size})
+		if ack.departure > lastDeparture {
+			lastDeparture = ack.departure
+		}
+	}
+
+	n.lossRatio += nadaLossAlpha * (float64(numLost)/float64(len(acks)) - n.lossRatio)
+	if numLost > 0 {
+		n.lastLossAt = now
+	}
+	if lastDeparture > 0 {
+		// includes the time the feedback was held by the receiver, this estimate is an upper bound
+		n.rtt = 0.9*n.rtt + 0.1*(now-lastDeparture).Seconds()
+	}
+	if n.numSamples == 0 {
+		return
+	}
+
+	delay := n.filteredDelay()
+	signal := delay + nadaDLoss*math.Pow(n.lossRatio/nadaPLRRef, 2)
+
+	interval := nadaDelta
+	if !n.lastUpdateAt.IsZero() {
+		interval = math.Min(at.Sub(n.lastUpdateAt).Seconds(), nadaTau)
+	}
+	n.lastUpdateAt = at
+
+	if now-n.lastLossAt > nadaLogWin && delay < nadaQEps {
+		n.mode = nadaModeAcceleratedRampUp
+		gamma := math.Min(nadaGammaMax, nadaQBound/(n.rtt+nadaDelta+nadaDFilt))
+		n.refRate = math.Max(n.refRate, (1+gamma)*n.receiveRate())
+	} else {
+		n.mode = nadaModeGradualUpdate
+		offset := signal - nadaPrio*nadaXRef*n.maxBitrate/n.refRate
+		diff := signal - n.prevSignal
+		n.refRate -= nadaKappa * (interval / nadaTau) * (offset / nadaTau) * n.refRate
+		n.refRate -= nadaKappa * nadaEta * (diff / nadaTau) * n.refRate
+	}
+	n.refRate = math.Min(math.Max(n.refRate, n.minBitrate), n.maxBitrate)
+	n.prevSignal = signal
+}
+
+func (n *NADA) filteredDelay() float64 {
+	taps := n.numSamples
+	if taps > nadaDelayFilterTaps {
+		taps = nadaDelayFilterTaps
+	}
+	delay := math.MaxFloat64
+	for _, sample := range n.delaySamples[:taps] {
+		delay = math.Min(delay, sample)
+	}
+	return delay
+}
+
+// receiveRate is the rate of packets received over the last log window, by their arrival times
+func (n *NADA) receiveRate() float64 {
+	if len(n.received) == 0 {
+		return 0
+	}
+
+	latest := n.received[len(n.received)-1].arrival
+	first := 0
+	for first < len(n.received) && latest-n.received[first].arrival > nadaLogWin {
+		first++
+	}
+	n.received = append(n.received[:0], n.received[first:]...)
+
+	bytes := 0
+	for _, r := range n.received {
+		bytes += r.size
+	}
+	span := (latest - n.received[0].arrival).Seconds()
+	if span < nadaDelta {
+		span = nadaDelta
+	}
+	return float64(bytes*8) / span
+}
+
+func (n *NADA) GetTargetBitrate() int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return int(n.refRate)
+}
+
+func (n *NADA) OnTargetBitrateChange(f func(bitrate int)) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.onTargetBitrateChange = f
+}
+
+func (n *NADA) GetStats() map[string]interface{} {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	queuingDelay := 0.0
+	if n.numSamples != 0 {
+		queuingDelay = n.filteredDelay()
+	}
+	return map[string]interface{}{
+		"algorithm":     "nada",
+		"mode":          n.mode.String(),
+		"targetBitrate": int(n.refRate),
+		"queuingDelay":  queuingDelay,
+		"lossRatio":     n.lossRatio,
+		"rtt":           n.rtt,
+	}
+}
+
+func (n *NADA) Close() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.closed = true
+	return nil
+}
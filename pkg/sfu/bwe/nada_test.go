@@ -0,0 +1,171 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor/pkg/gcc"
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestNewSendSideBWE(t *testing.T) {
+	estimator, err := NewSendSideBWE("", Params{InitialBitrate: 1_000_000})
+	require.NoError(t, err)
+	require.IsType(t, &gcc.SendSideBWE{}, estimator)
+
+	estimator, err = NewSendSideBWE(config.CongestionControlBWEAlgorithmNADA, Params{InitialBitrate: 1_000_000})
+	require.NoError(t, err)
+	require.IsType(t, &NADA{}, estimator)
+	require.Equal(t, 1_000_000, estimator.GetTargetBitrate())
+
+	_, err = NewSendSideBWE("scream", Params{})
+	require.Error(t, err)
+}
+
+func TestPacketHistoryFeedback(t *testing.T) {
+	var h packetHistory
+	for seq := uint16(10); seq < 15; seq++ {
+		h.add(seq, time.Duration(seq)*time.Millisecond, 1000)
+	}
+
+	fb := &rtcp.TransportLayerCC{
+		BaseSequenceNumber: 10,
+		PacketStatusCount:  5,
+		ReferenceTime:      1,
+		PacketChunks: []rtcp.PacketStatusChunk{
+			&rtcp.RunLengthChunk{PacketStatusSymbol: rtcp.TypeTCCPacketReceivedSmallDelta, RunLength: 2},
+			&rtcp.StatusVectorChunk{
+				SymbolSize: rtcp.TypeTCCSymbolSizeTwoBit,
+				SymbolList: []uint16{
+					rtcp.TypeTCCPacketReceivedSmallDelta,
+					rtcp.TypeTCCPacketNotReceived,
+					rtcp.TypeTCCPacketReceivedLargeDelta,
+					// padding past the status count
+					rtcp.TypeTCCPacketNotReceived,
+					rtcp.TypeTCCPacketNotReceived,
+				},
+			},
+		},
+		RecvDeltas: []*rtcp.RecvDelta{
+			{Type: rtcp.TypeTCCPacketReceivedSmallDelta, Delta: 1000},
+			{Type: rtcp.TypeTCCPacketReceivedSmallDelta, Delta: 2000},
+			{Type: rtcp.TypeTCCPacketReceivedSmallDelta, Delta: 1000},
+			{Type: rtcp.TypeTCCPacketReceivedLargeDelta, Delta: 10000},
+		},
+	}
+
+	acks := h.onTransportCCFeedback(fb, nil)
+	require.Len(t, acks, 5)
+	expectedArrivals := []time.Duration{65 * time.Millisecond, 67 * time.Millisecond, 68 * time.Millisecond, 0, 78 * time.Millisecond}
+	for i, ack := range acks {
+		require.Equal(t, time.Duration(10+i)*time.Millisecond, ack.departure)
+		require.Equal(t, 1000, ack.size)
+		require.Equal(t, i == 3, ack.lost)
+		if !ack.lost {
+			require.Equal(t, expectedArrivals[i], ack.arrival)
+		}
+	}
+
+	// feedback for packets that were already reported is dropped, as is feedback for unknown packets
+	require.Empty(t, h.onTransportCCFeedback(fb, nil))
+	fb.BaseSequenceNumber = 1000
+	require.Empty(t, h.onTransportCCFeedback(fb, nil))
+}
+
+// nadaLink feeds an estimator with feedback for packets sent at a constant rate,
+// each packet arriving after the one-way delay returned for it
+type nadaLink struct {
+	n          *NADA
+	now        time.Time
+	sendRate   int
+	packetSize int
+	sent       time.Duration
+}
+
+func newNADALink(initialBitrate, sendRate int) *nadaLink {
+	n := NewNADA(Params{InitialBitrate: initialBitrate, MaxBitrate: 5_000_000})
+	return &nadaLink{
+		n:          n,
+		now:        n.createdAt,
+		sendRate:   sendRate,
+		packetSize: 1200,
+	}
+}
+
+func (l *nadaLink) run(feedbacks int, owd func(i int) time.Duration, lost func(i int) bool) {
+	interval := time.Duration(l.packetSize*8) * time.Second / time.Duration(l.sendRate)
+	perFeedback := int(100 * time.Millisecond / interval)
+	for f := 0; f < feedbacks; f++ {
+		acks := make([]packetFeedback, 0, perFeedback)
+		for i := 0; i < perFeedback; i++ {
+			l.sent += interval
+			acks = append(acks, packetFeedback{
+				departure: l.sent,
+				arrival:   l.sent + owd(f),
+				size:      l.packetSize,
+				lost:      lost(f),
+			})
+		}
+		l.now = l.now.Add(100 * time.Millisecond)
+		l.n.updateLocked(l.now, acks)
+	}
+}
+
+func TestNADA(t *testing.T) {
+	constantDelay := func(int) time.Duration { return 20 * time.Millisecond }
+	noLoss := func(int) bool { return false }
+
+	t.Run("ramps up to the receive rate", func(t *testing.T) {
+		l := newNADALink(300_000, 1_000_000)
+		l.run(20, constantDelay, noLoss)
+		require.Equal(t, nadaModeAcceleratedRampUp, l.n.mode)
+		require.Greater(t, l.n.GetTargetBitrate(), 1_000_000)
+		require.Less(t, l.n.GetTargetBitrate(), 1_500_000)
+	})
+
+	t.Run("backs off on growing queuing delay", func(t *testing.T) {
+		l := newNADALink(300_000, 1_000_000)
+		l.run(20, constantDelay, noLoss)
+		before := l.n.GetTargetBitrate()
+
+		l.run(20, func(i int) time.Duration {
+			return 20*time.Millisecond + time.Duration(i+1)*20*time.Millisecond
+		}, noLoss)
+		require.Equal(t, nadaModeGradualUpdate, l.n.mode)
+		require.Less(t, l.n.GetTargetBitrate(), before)
+	})
+
+	t.Run("backs off on loss", func(t *testing.T) {
+		l := newNADALink(300_000, 1_000_000)
+		l.run(20, constantDelay, noLoss)
+		before := l.n.GetTargetBitrate()
+
+		l.run(20, constantDelay, func(i int) bool { return i%2 == 0 })
+		require.Equal(t, nadaModeGradualUpdate, l.n.mode)
+		require.Less(t, l.n.GetTargetBitrate(), before)
+	})
+
+	t.Run("stays within bounds", func(t *testing.T) {
+		l := newNADALink(300_000, 1_000_000)
+		l.run(50, constantDelay, func(int) bool { return true })
+		l.run(50, func(i int) time.Duration { return time.Duration(i+1) * 100 * time.Millisecond }, noLoss)
+		require.Equal(t, nadaDefaultMinBitrate, l.n.GetTargetBitrate())
+	})
+}
@@ -44,11 +44,13 @@ type ConnectionStatsSenderProvider interface {
 }
 
 type ConnectionStatsParams struct {
-	UpdateInterval   time.Duration
-	MimeType         string
-	IsFECEnabled     bool
-	IncludeRTT       bool
-	IncludeJitter    bool
+	UpdateInterval time.Duration
+	MimeType       string
+	IsFECEnabled   bool
+	IncludeRTT     bool
+	IncludeJitter  bool
+	// scores the analysis windows, DefaultScorer when nil
+	Scorer           Scorer
 	ReceiverProvider ConnectionStatsReceiverProvider
 	SenderProvider   ConnectionStatsSenderProvider
 	Logger           logger.Logger
@@ -72,12 +74,16 @@ type ConnectionStats struct {
 }
 
 func NewConnectionStats(params ConnectionStatsParams) *ConnectionStats {
+	if params.Scorer == nil {
+		params.Scorer = DefaultScorer
+	}
 	return &ConnectionStats{
 		params: params,
 		scorer: newQualityScorer(qualityScorerParams{
 			PacketLossWeight: getPacketLossWeight(params.MimeType, params.IsFECEnabled), // LK-TODO: have to notify codec change?
 			IncludeRTT:       params.IncludeRTT,
 			IncludeJitter:    params.IncludeJitter,
+			Scorer:           params.Scorer,
 			Logger:           params.Logger,
 		}),
 		done: core.NewFuse(),
@@ -199,6 +205,11 @@ func (cs *ConnectionStats) GetScoreAndQuality() (float32, livekit.ConnectionQual
 	return cs.scorer.GetMOSAndQuality()
 }
 
+// GetSubScores returns the sub-scores of the last analysis window, ok is false when it was not scored
+func (cs *ConnectionStats) GetSubScores() (SubScores, bool) {
+	return cs.scorer.GetSubScores()
+}
+
 func (cs *ConnectionStats) updateScoreWithAggregate(agg *buffer.RTPDeltaInfo, at time.Time) float32 {
 	var stat windowStat
 	if agg != nil {
@@ -16,7 +16,6 @@ package connectionquality
 
 import (
 	"fmt"
-	"math"
 	"sync"
 	"time"
 
@@ -62,24 +61,18 @@ type windowStat struct {
 	jitterMax         float64
 }
 
-func (w *windowStat) calculatePacketScore(plw float64, includeRTT bool, includeJitter bool) float64 {
-	// this is based on simplified E-model based on packet loss, rtt, jitter as
-	// outlined at https://www.pingman.com/kb/article/how-is-mos-calculated-in-pingplotter-pro-50.html.
-	effectiveDelay := 0.0
+func (w *windowStat) toWindowStats(plw float64, includeRTT bool, includeJitter bool, expectedBits int64, expectedDistance float64) *WindowStats {
 	// discount the dependent factors if dependency indicated.
 	// for example,
 	// 1. in the up stream, RTT cannot be measured without RTCP-XR, it is using down stream RTT.
 	// 2. in the down stream, up stream jitter affects it. although jitter can be adjusted to account for up stream
 	//    jitter, this lever can be used to discount jitter in scoring.
+	var rttMs, jitterMs float64
 	if includeRTT {
-		effectiveDelay += float64(w.rttMax) / 2.0
+		rttMs = float64(w.rttMax)
 	}
 	if includeJitter {
-		effectiveDelay += (w.jitterMax * 2.0) / 1000.0
-	}
-	delayEffect := effectiveDelay / 40.0
-	if effectiveDelay > 160.0 {
-		delayEffect = (effectiveDelay - 120.0) / 10.0
+		jitterMs = w.jitterMax / 1000.0
 	}
 
 	// discount out-of-order packets from loss to deal with a scenario like
@@ -108,41 +101,17 @@ func (w *windowStat) calculatePacketScore(plw float64, includeRTT bool, includeJ
 		actualLost = 0
 	}
 
-	var lossEffect float64
-	if w.packetsExpected > 0 {
-		lossEffect = float64(actualLost) * 100.0 / float64(w.packetsExpected)
-	}
-	lossEffect *= plw
-
-	score := cMaxScore - delayEffect - lossEffect
-	if score < 0.0 {
-		score = 0.0
-	}
-
-	return score
-}
-
-func (w *windowStat) calculateBitrateScore(expectedBitrate int64) float64 {
-	if expectedBitrate == 0 {
-		// unsupported mode OR all layers stopped
-		return cMaxScore
-	}
-
-	var score float64
-	if w.bytes != 0 {
-		// using the ratio of expectedBitrate / actualBitrate
-		// the quality inflection points are approximately
-		// GOOD at ~2.7x, POOR at ~20.1x
-		score = cMaxScore - 20*math.Log(float64(expectedBitrate)/float64(w.bytes*8))
-		if score > cMaxScore {
-			score = cMaxScore
-		}
-		if score < 0.0 {
-			score = 0.0
-		}
+	return &WindowStats{
+		Duration:         w.duration,
+		PacketsExpected:  w.packetsExpected,
+		PacketsLost:      actualLost,
+		PacketLossWeight: plw,
+		RTTMs:            rttMs,
+		JitterMs:         jitterMs,
+		Bits:             int64(w.bytes * 8),
+		ExpectedBits:     expectedBits,
+		LayerDistance:    expectedDistance,
 	}
-
-	return score
 }
 
 func (w *windowStat) String() string {
@@ -165,6 +134,7 @@ type qualityScorerParams struct {
 	PacketLossWeight float64
 	IncludeRTT       bool
 	IncludeJitter    bool
+	Scorer           Scorer
 	Logger           logger.Logger
 }
 
@@ -174,8 +144,9 @@ type qualityScorer struct {
 	lock         sync.RWMutex
 	lastUpdateAt time.Time
 
-	score float64
-	stat  windowStat
+	score     float64
+	stat      windowStat
+	subScores *SubScores
 
 	mutedAt   time.Time
 	unmutedAt time.Time
@@ -366,6 +337,7 @@ func (q *qualityScorer) updateAtLocked(stat *windowStat, at time.Time) {
 	//       set to cMinScore for responsiveness. The layer transision is reest.
 	//       On a resume, quality climbs back up using normal operation.
 	if q.isMuted() || !q.isUnmutedEnough(at) || q.isLayerMuted() || q.isPaused() {
+		q.subScores = nil
 		q.lastUpdateAt = at
 		return
 	}
@@ -373,30 +345,14 @@ func (q *qualityScorer) updateAtLocked(stat *windowStat, at time.Time) {
 	plw := q.getPacketLossWeight(stat)
 	reason := "none"
 	var score float64
+	var subScores *SubScores
 	if stat.packetsExpected == 0 {
 		reason = "dry"
 		score = qualityTransitionScore[livekit.ConnectionQuality_LOST]
 	} else {
-		packetScore := stat.calculatePacketScore(plw, q.params.IncludeRTT, q.params.IncludeJitter)
-		bitrateScore := stat.calculateBitrateScore(expectedBitrate)
-		layerScore := math.Max(math.Min(cMaxScore, cMaxScore-(expectedDistance*distanceWeight)), 0.0)
-
-		minScore := math.Min(packetScore, bitrateScore)
-		minScore = math.Min(minScore, layerScore)
-
-		switch {
-		case packetScore == minScore:
-			reason = "packet"
-			score = packetScore
-
-		case bitrateScore == minScore:
-			reason = "bitrate"
-			score = bitrateScore
-
-		case layerScore == minScore:
-			reason = "layer"
-			score = layerScore
-		}
+		sub := q.params.Scorer.Score(stat.toWindowStats(plw, q.params.IncludeRTT, q.params.IncludeJitter, expectedBitrate, expectedDistance))
+		subScores = &sub
+		score, reason = sub.Min()
 
 		factor := increaseFactor
 		if score < q.score {
@@ -426,11 +382,13 @@ func (q *qualityScorer) updateAtLocked(stat *windowStat, at time.Time) {
 			"maxPPS", q.maxPPS,
 			"expectedBitrate", expectedBitrate,
 			"expectedDistance", expectedDistance,
+			"subScores", subScores,
 		)
 	}
 
 	q.score = score
 	q.stat = *stat
+	q.subScores = subScores
 	q.lastUpdateAt = at
 }
 
@@ -516,6 +474,17 @@ func (q *qualityScorer) GetScoreAndQuality() (float32, livekit.ConnectionQuality
 	return float32(q.score), scoreToConnectionQuality(q.score)
 }
 
+// GetSubScores returns the sub-scores of the last window, ok is false when it was not scored, e.g. while muted or dry
+func (q *qualityScorer) GetSubScores() (SubScores, bool) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if q.subScores == nil {
+		return SubScores{}, false
+	}
+	return *q.subScores, true
+}
+
 func (q *qualityScorer) GetMOSAndQuality() (float32, livekit.ConnectionQuality) {
 	q.lock.RLock()
 	defer q.lock.RUnlock()
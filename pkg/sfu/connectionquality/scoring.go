@@ -0,0 +1,192 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectionquality
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	// points taken off by the linear scorer for each ms of effective delay and for a bitrate shortfall
+	// of 100%. Both fall to GOOD at a third of the way to POOR, 160ms of delay or a third of the
+	// expected bitrate missing.
+	linearDelayPenalty   = float64(1.0 / 8.0)
+	linearBitratePenalty = float64(60.0)
+)
+
+// WindowStats are what a track did over an analysis window, the input of a Scorer.
+type WindowStats struct {
+	Duration        time.Duration
+	PacketsExpected uint32
+	// lost packets, discounting those that were missing in the up stream or arrived out-of-order
+	PacketsLost uint32
+	// scales the effect of loss, lower for codecs that repair loss and for sparse streams like DTX audio
+	PacketLossWeight float64
+	// 0 when RTT or jitter of the direction is not scored
+	RTTMs    float64
+	JitterMs float64
+	// media payload sent or received and what was expected of the layers being sent, 0 when unknown
+	Bits         int64
+	ExpectedBits int64
+	// average number of spatial layers below the expected layer
+	LayerDistance float64
+}
+
+func (w *WindowStats) lossPercentage() float64 {
+	if w.PacketsExpected == 0 {
+		return 0.0
+	}
+	return float64(w.PacketsLost) * 100.0 / float64(w.PacketsExpected)
+}
+
+// SubScores are the scores of a window for each aspect of quality, from 0 to 100.
+// The score of the window is the lowest of the packet, bitrate and layer scores.
+type SubScores struct {
+	Loss    float64
+	Delay   float64
+	Bitrate float64
+	Layer   float64
+}
+
+// Packet combines the loss and delay scores, as both degrade the same packets
+func (s SubScores) Packet() float64 {
+	return clampScore(s.Loss + s.Delay - cMaxScore)
+}
+
+// Min returns the score of the window and what caused it
+func (s SubScores) Min() (float64, string) {
+	packetScore := s.Packet()
+	minScore := math.Min(math.Min(packetScore, s.Bitrate), s.Layer)
+	switch minScore {
+	case packetScore:
+		return minScore, "packet"
+	case s.Bitrate:
+		return minScore, "bitrate"
+	default:
+		return minScore, "layer"
+	}
+}
+
+func (s SubScores) String() string {
+	return fmt.Sprintf("loss: %0.2f, delay: %0.2f, bitrate: %0.2f, layer: %0.2f", s.Loss, s.Delay, s.Bitrate, s.Layer)
+}
+
+// Scorer computes the sub-scores of an analysis window, the score is smoothed across windows
+// and mapped to a quality by the connection stats.
+type Scorer interface {
+	Score(stats *WindowStats) SubScores
+}
+
+// NewScorer creates the scorer of the kind configured, weighing loss, jitter, RTT and bitrate with the configured weights.
+func NewScorer(conf config.ConnectionQualityConfig) (Scorer, error) {
+	switch conf.Scorer {
+	case "", config.ConnectionQualityScorerEModel:
+		return &eModelScorer{weights: conf.Weights}, nil
+
+	case config.ConnectionQualityScorerLinear:
+		return &linearScorer{weights: conf.Weights}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown connection quality scorer %q", conf.Scorer)
+	}
+}
+
+// DefaultScorer is the scorer of connection stats that are not given one, the E-model with every aspect weighed in full
+var DefaultScorer Scorer = &eModelScorer{
+	weights: config.ConnectionQualityWeights{
+		Loss:    1.0,
+		Jitter:  1.0,
+		RTT:     1.0,
+		Bitrate: 1.0,
+	},
+}
+
+// ------------------------------------------
+
+// eModelScorer scores loss and delay after a simplified E-model as
+// outlined at https://www.pingman.com/kb/article/how-is-mos-calculated-in-pingplotter-pro-50.html,
+// and the bitrate by the ratio of the expected to the actual bitrate.
+type eModelScorer struct {
+	weights config.ConnectionQualityWeights
+}
+
+func (e *eModelScorer) Score(stats *WindowStats) SubScores {
+	effectiveDelay := e.weights.RTT*stats.RTTMs/2.0 + e.weights.Jitter*stats.JitterMs*2.0
+	delayEffect := effectiveDelay / 40.0
+	if effectiveDelay > 160.0 {
+		delayEffect = (effectiveDelay - 120.0) / 10.0
+	}
+
+	lossEffect := e.weights.Loss * stats.PacketLossWeight * stats.lossPercentage()
+
+	bitrateScore := cMaxScore
+	if stats.ExpectedBits != 0 {
+		bitrateScore = 0.0
+		if stats.Bits != 0 {
+			// using the ratio of expectedBitrate / actualBitrate
+			// the quality inflection points are approximately
+			// GOOD at ~2.7x, POOR at ~20.1x
+			bitrateScore = cMaxScore - e.weights.Bitrate*20*math.Log(float64(stats.ExpectedBits)/float64(stats.Bits))
+		}
+	}
+
+	return SubScores{
+		Loss:    clampScore(cMaxScore - lossEffect),
+		Delay:   clampScore(cMaxScore - delayEffect),
+		Bitrate: clampScore(bitrateScore),
+		Layer:   layerScore(stats.LayerDistance),
+	}
+}
+
+// ------------------------------------------
+
+// linearScorer takes points off in proportion to loss, delay and the shortfall of the bitrate,
+// without the grace the E-model gives to moderate delay.
+type linearScorer struct {
+	weights config.ConnectionQualityWeights
+}
+
+func (l *linearScorer) Score(stats *WindowStats) SubScores {
+	delayEffect := (l.weights.RTT*stats.RTTMs/2.0 + l.weights.Jitter*stats.JitterMs*2.0) * linearDelayPenalty
+
+	lossEffect := l.weights.Loss * stats.PacketLossWeight * stats.lossPercentage()
+
+	var bitrateEffect float64
+	if stats.ExpectedBits != 0 {
+		shortfall := 1.0 - float64(stats.Bits)/float64(stats.ExpectedBits)
+		bitrateEffect = l.weights.Bitrate * linearBitratePenalty * math.Max(shortfall, 0.0)
+	}
+
+	return SubScores{
+		Loss:    clampScore(cMaxScore - lossEffect),
+		Delay:   clampScore(cMaxScore - delayEffect),
+		Bitrate: clampScore(cMaxScore - bitrateEffect),
+		Layer:   layerScore(stats.LayerDistance),
+	}
+}
+
+// ------------------------------------------
+
+func layerScore(distance float64) float64 {
+	return clampScore(cMaxScore - distance*distanceWeight)
+}
+
+func clampScore(score float64) float64 {
+	return math.Max(math.Min(score, cMaxScore), 0.0)
+}
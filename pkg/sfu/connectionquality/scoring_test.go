@@ -0,0 +1,107 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectionquality
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestScorer(t *testing.T) {
+	weights := config.ConnectionQualityWeights{Loss: 1, Jitter: 1, RTT: 1, Bitrate: 1}
+
+	t.Run("unknown scorer", func(t *testing.T) {
+		_, err := NewScorer(config.ConnectionQualityConfig{Scorer: "mos"})
+		require.Error(t, err)
+	})
+
+	t.Run("e-model", func(t *testing.T) {
+		scorer, err := NewScorer(config.ConnectionQualityConfig{Weights: weights})
+		require.NoError(t, err)
+
+		// 2% loss of video with 80ms of effective delay
+		sub := scorer.Score(&WindowStats{
+			PacketsExpected:  100,
+			PacketsLost:      2,
+			PacketLossWeight: 10,
+			RTTMs:            100,
+			JitterMs:         15,
+			Bits:             1_000_000,
+			ExpectedBits:     1_000_000,
+		})
+		require.InDelta(t, 80.0, sub.Loss, 0.01)
+		require.InDelta(t, 98.0, sub.Delay, 0.01)
+		require.InDelta(t, 100.0, sub.Bitrate, 0.01)
+		require.InDelta(t, 100.0, sub.Layer, 0.01)
+		score, reason := sub.Min()
+		require.InDelta(t, 78.0, score, 0.01)
+		require.Equal(t, "packet", reason)
+
+		// nothing received while expecting media
+		sub = scorer.Score(&WindowStats{PacketsExpected: 100, ExpectedBits: 1_000_000, LayerDistance: 1})
+		score, reason = sub.Min()
+		require.Zero(t, score)
+		require.Equal(t, "bitrate", reason)
+		require.InDelta(t, 65.0, sub.Layer, 0.01)
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		scorer, err := NewScorer(config.ConnectionQualityConfig{
+			Weights: config.ConnectionQualityWeights{Loss: 0.5, Jitter: 0, RTT: 1, Bitrate: 2},
+		})
+		require.NoError(t, err)
+
+		sub := scorer.Score(&WindowStats{
+			PacketsExpected:  100,
+			PacketsLost:      2,
+			PacketLossWeight: 10,
+			JitterMs:         500,
+			Bits:             500_000,
+			ExpectedBits:     1_000_000,
+		})
+		require.InDelta(t, 90.0, sub.Loss, 0.01)
+		// jitter is ignored
+		require.InDelta(t, 100.0, sub.Delay, 0.01)
+		require.InDelta(t, 72.27, sub.Bitrate, 0.01)
+		score, reason := sub.Min()
+		require.InDelta(t, 72.27, score, 0.01)
+		require.Equal(t, "bitrate", reason)
+	})
+
+	t.Run("linear", func(t *testing.T) {
+		scorer, err := NewScorer(config.ConnectionQualityConfig{Scorer: config.ConnectionQualityScorerLinear, Weights: weights})
+		require.NoError(t, err)
+
+		// the E-model barely penalises 160ms of effective delay, linear falls to GOOD
+		stats := &WindowStats{
+			PacketsExpected: 100,
+			RTTMs:           200,
+			JitterMs:        30,
+			Bits:            500_000,
+			ExpectedBits:    1_000_000,
+		}
+		sub := scorer.Score(stats)
+		require.InDelta(t, 100.0, sub.Loss, 0.01)
+		require.InDelta(t, 80.0, sub.Delay, 0.01)
+		require.InDelta(t, 70.0, sub.Bitrate, 0.01)
+
+		sub = DefaultScorer.Score(stats)
+		require.InDelta(t, 96.0, sub.Delay, 0.01)
+		require.InDelta(t, 86.14, sub.Bitrate, 0.01)
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"sync"
+	"time"
+)
+
+// DecodeFailureReason is why a subscriber is taken to be unable to decode a down track
+type DecodeFailureReason string
+
+const (
+	// the subscriber keeps asking for key frames, the ones forwarded do not get its decoder going
+	DecodeFailureReasonKeyFrameRequests DecodeFailureReason = "key_frame_requests"
+	// the receiver reports of the subscriber do not progress while packets are sent to it
+	DecodeFailureReasonReceiverStalled DecodeFailureReason = "receiver_stalled"
+)
+
+const (
+	decodeFailureWindow           = 10 * time.Second
+	decodeFailureKeyFrameRequests = 10
+	decodeFailureStalledReports   = 5
+	// packets to be sent between two receiver reports for a report that does not progress to count as stalled
+	decodeFailureStalledMinPackets = 10
+)
+
+// decodeFailureDetector tells a subscriber failing to decode a down track, once
+type decodeFailureDetector struct {
+	lock     sync.Mutex
+	requests []time.Time
+
+	reportValid       bool
+	lastReportSN      uint32
+	lastReportPackets uint64
+	stalledReports    int
+
+	detected bool
+}
+
+// onKeyFrameRequest returns true when the key frame requests in the window reach the threshold
+func (d *decodeFailureDetector) onKeyFrameRequest(at time.Time) (DecodeFailureReason, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.detected {
+		return "", false
+	}
+
+	requests := d.requests[:0]
+	for _, r := range d.requests {
+		if at.Sub(r) < decodeFailureWindow {
+			requests = append(requests, r)
+		}
+	}
+	d.requests = append(requests, at)
+	if len(d.requests) < decodeFailureKeyFrameRequests {
+		return "", false
+	}
+
+	d.detected = true
+	return DecodeFailureReasonKeyFrameRequests, true
+}
+
+// onReceiverReport returns true when the extended highest sequence number received has not moved
+// over consecutive reports while packets were sent
+func (d *decodeFailureDetector) onReceiverReport(highestSN uint32, packetsSent uint64) (DecodeFailureReason, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.detected {
+		return "", false
+	}
+
+	if d.reportValid && highestSN == d.lastReportSN && packetsSent-d.lastReportPackets >= decodeFailureStalledMinPackets {
+		d.stalledReports++
+	} else if !d.reportValid || highestSN != d.lastReportSN {
+		d.stalledReports = 0
+	}
+	d.reportValid = true
+	d.lastReportPackets = packetsSent
+	d.lastReportSN = highestSN
+	if d.stalledReports < decodeFailureStalledReports {
+		return "", false
+	}
+
+	d.detected = true
+	return DecodeFailureReasonReceiverStalled, true
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeFailureDetector(t *testing.T) {
+	t.Run("key frame requests", func(t *testing.T) {
+		var d decodeFailureDetector
+		now := time.Now()
+		for i := 0; i < decodeFailureKeyFrameRequests-1; i++ {
+			_, failed := d.onKeyFrameRequest(now)
+			require.False(t, failed)
+		}
+
+		// earlier requests have left the window
+		later := now.Add(decodeFailureWindow)
+		for i := 0; i < decodeFailureKeyFrameRequests-1; i++ {
+			_, failed := d.onKeyFrameRequest(later)
+			require.False(t, failed)
+		}
+
+		reason, failed := d.onKeyFrameRequest(later.Add(time.Second))
+		require.True(t, failed)
+		require.Equal(t, DecodeFailureReasonKeyFrameRequests, reason)
+
+		// detected once
+		_, failed = d.onKeyFrameRequest(later.Add(2 * time.Second))
+		require.False(t, failed)
+	})
+
+	t.Run("receiver stalled", func(t *testing.T) {
+		var d decodeFailureDetector
+		packets := uint64(100)
+		_, failed := d.onReceiverReport(1000, packets)
+		require.False(t, failed)
+
+		// progressing reports and reports without packets sent do not count
+		for i := 0; i < decodeFailureStalledReports; i++ {
+			packets += 50
+			_, failed = d.onReceiverReport(uint32(1000+packets), packets)
+			require.False(t, failed)
+		}
+		for i := 0; i < decodeFailureStalledReports; i++ {
+			_, failed = d.onReceiverReport(uint32(1000+packets), packets)
+			require.False(t, failed)
+		}
+
+		for i := 0; i < decodeFailureStalledReports-1; i++ {
+			packets += 50
+			_, failed = d.onReceiverReport(uint32(1000+packets-50*uint64(i+1)), packets)
+			require.False(t, failed)
+		}
+		packets += 50
+		reason, failed := d.onReceiverReport(uint32(1000+packets-50*decodeFailureStalledReports), packets)
+		require.True(t, failed)
+		require.Equal(t, DecodeFailureReasonReceiverStalled, reason)
+	})
+}
@@ -33,12 +33,14 @@ import (
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
 	"github.com/livekit/livekit-server/pkg/sfu/rtpextension"
 	"github.com/livekit/livekit-server/pkg/sfu/utils"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
 )
 
 // TrackSender defines an interface send media to remote peer
@@ -94,6 +96,20 @@ var (
 	ErrDownTrackAlreadyBound             = errors.New("already bound")
 )
 
+// UnsupportedCodecError is reported on binding when the subscriber supports none of the upstream codecs,
+// it carries the codecs the subscriber negotiated. It unwraps to webrtc.ErrUnsupportedCodec.
+type UnsupportedCodecError struct {
+	Codecs []webrtc.RTPCodecParameters
+}
+
+func (e *UnsupportedCodecError) Error() string {
+	return webrtc.ErrUnsupportedCodec.Error()
+}
+
+func (e *UnsupportedCodecError) Unwrap() error {
+	return webrtc.ErrUnsupportedCodec
+}
+
 var (
 	VP8KeyFrame8x8 = []byte{
 		0x10, 0x02, 0x00, 0x9d, 0x01, 0x2a, 0x08, 0x00,
@@ -206,6 +222,16 @@ type DowntrackParams struct {
 	Pacer             pacer.Pacer
 	Logger            logger.Logger
 	Trailer           []byte
+	ResourceTracker   *sutils.ResourceTracker
+	// video layers with a bitrate, in bps, over it are not forwarded, 0 for no limit
+	MaxTrackBitrate int64
+	// bitrates expected of the video qualities by codec, the measured bitrates are used when none are configured
+	VideoBitrates config.VideoBitrateConfig
+	ScreenShare   bool
+	// how layers are lowered when bandwidth is short, balanced when empty
+	ForwardingPolicy config.ForwardingPolicy
+	// scores the connection quality, the default scorer when nil
+	ConnectionQualityScorer connectionquality.Scorer
 }
 
 // DownTrack implements TrackLocal, is the track used to write packets
@@ -234,8 +260,10 @@ type DownTrack struct {
 	dependencyDescriptorExtID int
 	playoutDelayExtID         int
 	transceiver               atomic.Pointer[webrtc.RTPTransceiver]
-	writeStream               webrtc.TrackLocalWriter
-	rtcpReader                *buffer.RTCPReader
+	// bitrates expected of the video qualities of the bound codec, nil when none are configured
+	bitrateRanges atomic.Pointer[config.VideoQualityBitrates]
+	writeStream   webrtc.TrackLocalWriter
+	rtcpReader    *buffer.RTCPReader
 
 	listenerLock            sync.RWMutex
 	receiverReportListeners []ReceiverReportListener
@@ -259,9 +287,13 @@ type DownTrack struct {
 	deltaStatsSenderSnapshotId uint32
 
 	isNACKThrottled atomic.Bool
+	// the cached packets since the last key frame are asked for once, before forwarding starts
+	keyFrameReplayed atomic.Bool
 
 	activePaddingOnMuteUpTrack atomic.Bool
 
+	decodeFailure decodeFailureDetector
+
 	streamAllocatorLock             sync.RWMutex
 	streamAllocatorListener         DownTrackStreamAllocatorListener
 	streamAllocatorReportGeneration int
@@ -285,6 +317,7 @@ type DownTrack struct {
 	onStatsUpdate               func(dt *DownTrack, stat *livekit.AnalyticsStat)
 	onMaxSubscribedLayerChanged func(dt *DownTrack, layer int32)
 	onRttUpdate                 func(dt *DownTrack, rtt uint32)
+	onDecodeFailure             func(dt *DownTrack, reason DecodeFailureReason)
 	onCloseHandler              func(willBeResumed bool)
 }
 
@@ -317,6 +350,7 @@ func NewDownTrack(params DowntrackParams) (*DownTrack, error) {
 		d.params.Receiver.GetReferenceLayerRTPTimestamp,
 		d.getExpectedRTPTimestamp,
 	)
+	d.forwarder.SetForwardingPolicy(params.ForwardingPolicy)
 
 	d.rtpStats = buffer.NewRTPStatsSender(buffer.RTPStatsParams{
 		ClockRate: d.codec.ClockRate,
@@ -335,6 +369,7 @@ func NewDownTrack(params DowntrackParams) (*DownTrack, error) {
 	d.connectionStats = connectionquality.NewConnectionStats(connectionquality.ConnectionStatsParams{
 		MimeType:       codecs[0].MimeType, // LK-TODO have to notify on codec change
 		IsFECEnabled:   strings.EqualFold(codecs[0].MimeType, webrtc.MimeTypeOpus) && strings.Contains(strings.ToLower(codecs[0].SDPFmtpLine), "fec"),
+		Scorer:         params.ConnectionQualityScorer,
 		SenderProvider: d,
 		Logger:         params.Logger.WithValues("direction", "down"),
 	})
@@ -345,8 +380,8 @@ func NewDownTrack(params DowntrackParams) (*DownTrack, error) {
 	})
 
 	if d.kind == webrtc.RTPCodecTypeVideo {
-		go d.maxLayerNotifierWorker()
-		go d.keyFrameRequester()
+		d.params.ResourceTracker.Go("downtrack.maxLayerNotifierWorker", d.maxLayerNotifierWorker)
+		d.params.ResourceTracker.Go("downtrack.keyFrameRequester", d.keyFrameRequester)
 	}
 	d.params.Logger.Debugw("downtrack created")
 
@@ -375,9 +410,14 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 		err := webrtc.ErrUnsupportedCodec
 		onBinding := d.onBinding
 		d.bindLock.Unlock()
-		d.params.Logger.Infow("bind error for unsupported codec", "codecs", d.upstreamCodecs, "remoteParameters", t.CodecParameters())
+		if hasIncompatibleCodecProfile(d.upstreamCodecs, t.CodecParameters()) {
+			// subscriber supports the codec, but not the profile it is published with, e.g. 10 bit VP9
+			d.params.Logger.Infow("bind error for unsupported codec profile", "codecs", d.upstreamCodecs, "remoteParameters", t.CodecParameters())
+		} else {
+			d.params.Logger.Infow("bind error for unsupported codec", "codecs", d.upstreamCodecs, "remoteParameters", t.CodecParameters())
+		}
 		if onBinding != nil {
-			onBinding(err)
+			onBinding(&UnsupportedCodecError{Codecs: t.CodecParameters()})
 		}
 		return webrtc.RTPCodecParameters{}, err
 	}
@@ -404,6 +444,9 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 	d.sequencer = newSequencer(d.params.MaxTrack, d.kind == webrtc.RTPCodecTypeVideo, d.params.Logger)
 
 	d.codec = codec.RTPCodecCapability
+	if d.kind == webrtc.RTPCodecTypeVideo {
+		d.bitrateRanges.Store(d.params.VideoBitrates.For(codec.MimeType, d.params.ScreenShare))
+	}
 	if d.onBinding != nil {
 		d.onBinding(nil)
 	}
@@ -428,6 +471,17 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 	return codec, nil
 }
 
+func hasIncompatibleCodecProfile(upstreamCodecs []webrtc.RTPCodecParameters, remoteCodecs []webrtc.RTPCodecParameters) bool {
+	for _, uc := range upstreamCodecs {
+		for _, rc := range remoteCodecs {
+			if strings.EqualFold(uc.MimeType, rc.MimeType) && !utils.CodecProfilesCompatible(uc.RTPCodecCapability, rc.RTPCodecCapability) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Unbind implements the teardown logic when the track is no longer needed. This happens
 // because a track has been stopped.
 func (d *DownTrack) Unbind(_ webrtc.TrackLocalContext) error {
@@ -483,7 +537,8 @@ func (d *DownTrack) SetStreamAllocatorReportInterval(interval time.Duration) {
 	gen := d.streamAllocatorReportGeneration
 	d.streamAllocatorLock.Unlock()
 
-	go func(generation int) {
+	d.params.ResourceTracker.Go("downtrack.streamAllocatorReport", func() {
+		generation := gen
 		timer := time.NewTimer(interval)
 		for {
 			<-timer.C
@@ -504,7 +559,7 @@ func (d *DownTrack) SetStreamAllocatorReportInterval(interval time.Duration) {
 
 			timer.Reset(interval)
 		}
-	}(gen)
+	})
 }
 
 func (d *DownTrack) ClearStreamAllocatorReportInterval() {
@@ -633,9 +688,13 @@ func (d *DownTrack) keyFrameRequester() {
 
 		locked, layer := d.forwarder.CheckSync()
 		if !locked && layer != buffer.InvalidLayerSpatial && d.writable.Load() {
-			d.params.Logger.Debugw("sending PLI for layer lock", "layer", layer)
-			d.params.Receiver.SendPLI(layer, false)
-			d.rtpStats.UpdateLayerLockPliAndTime(1)
+			if !d.forwarder.IsStarted() && !d.keyFrameReplayed.Swap(true) && d.params.Receiver.ReplayKeyFrame(layer, d) {
+				d.params.Logger.Debugw("replaying cached key frame for layer lock", "layer", layer)
+			} else {
+				d.params.Logger.Debugw("sending PLI for layer lock", "layer", layer)
+				d.params.Receiver.SendPLI(layer, false)
+				d.rtpStats.UpdateLayerLockPliAndTime(1)
+			}
 		}
 
 		ticker.Reset(getInterval())
@@ -687,26 +746,22 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		return err
 	}
 
-	poolEntity := PacketFactory.Get().(*[]byte)
-	payload := *poolEntity
+	buf := pacer.GetPacketBuffer()
+	payload := buf.Payload
 	shouldForward, incomingHeaderSize, outgoingHeaderSize, err := d.forwarder.TranslateCodecHeader(extPkt, &tp.rtp, payload)
 	if !shouldForward {
-		PacketFactory.Put(poolEntity)
+		pacer.PutPacketBuffer(buf)
 		return err
 	}
 	copy(payload[outgoingHeaderSize:], extPkt.Packet.Payload[incomingHeaderSize:])
 	payload = payload[:outgoingHeaderSize+len(extPkt.Packet.Payload)-incomingHeaderSize]
 
-	hdr, err := d.getTranslatedRTPHeader(extPkt, &tp)
-	if err != nil {
-		d.params.Logger.Errorw("could not get translated RTP header", err)
-		PacketFactory.Put(poolEntity)
-		return err
-	}
+	hdr := &buf.Header
+	d.translateRTPHeader(hdr, extPkt, &tp)
 
-	var extensions []pacer.ExtensionData
+	extensions := buf.Extensions()
 	if tp.ddBytes != nil {
-		extensions = []pacer.ExtensionData{{ID: uint8(d.dependencyDescriptorExtID), Payload: tp.ddBytes}}
+		extensions = append(extensions, pacer.ExtensionData{ID: uint8(d.dependencyDescriptorExtID), Payload: tp.ddBytes})
 	}
 	if d.playoutDelayExtID != 0 && d.playoutDelay != nil {
 		if val := d.playoutDelay.GetDelayExtension(hdr.SequenceNumber); val != nil {
@@ -746,8 +801,7 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
 		TransportWideExtID: uint8(d.transportWideExtID),
 		WriteStream:        d.writeStream,
-		Pool:               PacketFactory,
-		PoolEntity:         poolEntity,
+		Buffer:             buf,
 	})
 	return nil
 }
@@ -1085,7 +1139,19 @@ func (d *DownTrack) maybeAddTransition(_bitrate int64, distance float64, pauseRe
 	} else {
 		d.connectionStats.UpdatePause(false)
 		d.connectionStats.AddLayerTransition(distance)
+		if ranges := d.bitrateRanges.Load(); ranges != nil {
+			d.connectionStats.AddBitrateTransition(d.expectedBitrate(ranges))
+		}
+	}
+}
+
+// expectedBitrate is the minimum bitrate of the quality targeted, 0 when nothing is expected
+func (d *DownTrack) expectedBitrate(ranges *config.VideoQualityBitrates) int64 {
+	target := d.forwarder.TargetLayer()
+	if !target.IsValid() {
+		return 0
 	}
+	return ranges.Range(buffer.SpatialLayerToVideoQuality(target.Spatial, d.params.Receiver.TrackInfo())).Min
 }
 
 func (d *DownTrack) UpTrackBitrateReport(availableLayers []int32, bitrates Bitrates) {
@@ -1153,6 +1219,32 @@ func (d *DownTrack) getOnRttUpdate() func(dt *DownTrack, rtt uint32) {
 	return d.onRttUpdate
 }
 
+// OnDecodeFailure is called once when the subscriber appears unable to decode the track, fn must not block
+func (d *DownTrack) OnDecodeFailure(fn func(dt *DownTrack, reason DecodeFailureReason)) {
+	d.cbMu.Lock()
+	defer d.cbMu.Unlock()
+
+	d.onDecodeFailure = fn
+}
+
+func (d *DownTrack) getOnDecodeFailure() func(dt *DownTrack, reason DecodeFailureReason) {
+	d.cbMu.RLock()
+	defer d.cbMu.RUnlock()
+
+	return d.onDecodeFailure
+}
+
+func (d *DownTrack) checkDecodeFailure(reason DecodeFailureReason, failed bool) {
+	if !failed {
+		return
+	}
+
+	d.params.Logger.Infow("subscriber failing to decode", "reason", reason, "codec", d.codec.MimeType)
+	if onDecodeFailure := d.getOnDecodeFailure(); onDecodeFailure != nil {
+		onDecodeFailure(d, reason)
+	}
+}
+
 func (d *DownTrack) OnMaxLayerChanged(fn func(dt *DownTrack, layer int32)) {
 	d.cbMu.Lock()
 	defer d.cbMu.Unlock()
@@ -1171,18 +1263,89 @@ func (d *DownTrack) IsDeficient() bool {
 	return d.forwarder.IsDeficient()
 }
 
+// getLayeredBitrate returns the available layers and bitrates of the receiver,
+// leaving out the layers with a bitrate over MaxTrackBitrate
+func (d *DownTrack) getLayeredBitrate() ([]int32, Bitrates) {
+	availableLayers, brs := d.params.Receiver.GetLayeredBitrate()
+	if d.kind != webrtc.RTPCodecTypeVideo {
+		return availableLayers, brs
+	}
+	if ranges := d.bitrateRanges.Load(); ranges != nil {
+		brs = rangeLayeredBitrate(brs, ranges, d.params.Receiver.TrackInfo())
+	}
+	if d.params.MaxTrackBitrate <= 0 {
+		return availableLayers, brs
+	}
+	return capLayeredBitrate(availableLayers, brs, d.params.MaxTrackBitrate)
+}
+
+// rangeLayeredBitrate brings the bitrate of each spatial layer into the range of its quality, the temporal layers
+// are scaled along with the highest one measured, so that they keep their share of the spatial layer
+func rangeLayeredBitrate(brs Bitrates, ranges *config.VideoQualityBitrates, trackInfo *livekit.TrackInfo) Bitrates {
+	for s := range brs {
+		top := int64(0)
+		for t := len(brs[s]) - 1; t >= 0; t-- {
+			if brs[s][t] > 0 {
+				top = brs[s][t]
+				break
+			}
+		}
+		if top == 0 {
+			continue
+		}
+
+		r := ranges.Range(buffer.SpatialLayerToVideoQuality(int32(s), trackInfo))
+		target := top
+		if r.Min > 0 && target < r.Min {
+			target = r.Min
+		}
+		if r.Max > 0 && target > r.Max {
+			target = r.Max
+		}
+		if target == top {
+			continue
+		}
+		for t := range brs[s] {
+			brs[s][t] = brs[s][t] * target / top
+		}
+	}
+	return brs
+}
+
+func capLayeredBitrate(availableLayers []int32, brs Bitrates, maxBitrate int64) ([]int32, Bitrates) {
+	var overLimit [len(brs)]bool
+	for s := range brs {
+		// even the lowest temporal layer goes over the limit
+		overLimit[s] = brs[s][0] > maxBitrate
+		for t := range brs[s] {
+			if brs[s][t] > maxBitrate {
+				brs[s][t] = 0
+			}
+		}
+	}
+
+	capped := make([]int32, 0, len(availableLayers))
+	for _, layer := range availableLayers {
+		if layer >= 0 && int(layer) < len(overLimit) && overLimit[layer] {
+			continue
+		}
+		capped = append(capped, layer)
+	}
+	return capped, brs
+}
+
 func (d *DownTrack) BandwidthRequested() int64 {
-	_, brs := d.params.Receiver.GetLayeredBitrate()
+	_, brs := d.getLayeredBitrate()
 	return d.forwarder.BandwidthRequested(brs)
 }
 
 func (d *DownTrack) DistanceToDesired() float64 {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	return d.forwarder.DistanceToDesired(al, brs)
 }
 
 func (d *DownTrack) AllocateOptimal(allowOvershoot bool) VideoAllocation {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	allocation := d.forwarder.AllocateOptimal(al, brs, allowOvershoot)
 	d.postKeyFrameRequestEvent()
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
@@ -1190,7 +1353,7 @@ func (d *DownTrack) AllocateOptimal(allowOvershoot bool) VideoAllocation {
 }
 
 func (d *DownTrack) ProvisionalAllocatePrepare() {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	d.forwarder.ProvisionalAllocatePrepare(al, brs)
 }
 
@@ -1232,7 +1395,7 @@ func (d *DownTrack) ProvisionalAllocateCommit() VideoAllocation {
 }
 
 func (d *DownTrack) AllocateNextHigher(availableChannelCapacity int64, allowOvershoot bool) (VideoAllocation, bool) {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	allocation, available := d.forwarder.AllocateNextHigher(availableChannelCapacity, al, brs, allowOvershoot)
 	d.postKeyFrameRequestEvent()
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
@@ -1240,7 +1403,7 @@ func (d *DownTrack) AllocateNextHigher(availableChannelCapacity int64, allowOver
 }
 
 func (d *DownTrack) GetNextHigherTransition(allowOvershoot bool) (VideoTransition, bool) {
-	availableLayers, brs := d.params.Receiver.GetLayeredBitrate()
+	availableLayers, brs := d.getLayeredBitrate()
 	transition, available := d.forwarder.GetNextHigherTransition(brs, allowOvershoot)
 	d.params.Logger.Debugw(
 		"stream: get next higher layer",
@@ -1253,7 +1416,7 @@ func (d *DownTrack) GetNextHigherTransition(allowOvershoot bool) (VideoTransitio
 }
 
 func (d *DownTrack) Pause() VideoAllocation {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	allocation := d.forwarder.Pause(al, brs)
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
 	return allocation
@@ -1521,6 +1684,10 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 					rttToReport = rtt
 				}
 
+				if d.kind == webrtc.RTPCodecTypeVideo {
+					d.checkDecodeFailure(d.decodeFailure.onReceiverReport(r.LastSequenceNumber, d.GetTotalPacketsSent()))
+				}
+
 				if sal := d.getStreamAllocatorListener(); sal != nil {
 					sal.OnRTCPReceiverReport(d, r)
 				}
@@ -1557,6 +1724,11 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 		}
 	}
 
+	// requests before anything is forwarded are waiting for the first key frame
+	if numPLIs+numFIRs > 0 && d.GetTotalPacketsSent() > 0 {
+		d.checkDecodeFailure(d.decodeFailure.onKeyFrameRequest(time.Now()))
+	}
+
 	d.rtpStats.UpdateNack(numNACKs)
 	d.rtpStats.UpdatePli(numPLIs)
 	d.rtpStats.UpdateFir(numFIRs)
@@ -1646,8 +1818,9 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 		pkt.Header.SSRC = d.ssrc
 		pkt.Header.PayloadType = d.payloadType
 
-		poolEntity := PacketFactory.Get().(*[]byte)
-		payload := *poolEntity
+		buf := pacer.GetPacketBuffer()
+		buf.Header = pkt.Header
+		payload := buf.Payload
 		if len(epm.codecBytesSlice) != 0 {
 			n := copy(payload, epm.codecBytesSlice)
 			m := copy(payload[n:], pkt.Payload[epm.numCodecBytesIn:])
@@ -1666,7 +1839,7 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 		}
 
 		d.sendingPacket(
-			&pkt.Header,
+			&buf.Header,
 			len(payload),
 			&sendPacketMetadata{
 				layer:             int32(epm.layer),
@@ -1677,14 +1850,13 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 			},
 		)
 		d.pacer.Enqueue(pacer.Packet{
-			Header:             &pkt.Header,
-			Extensions:         []pacer.ExtensionData{{ID: uint8(d.dependencyDescriptorExtID), Payload: ddBytes}},
+			Header:             &buf.Header,
+			Extensions:         append(buf.Extensions(), pacer.ExtensionData{ID: uint8(d.dependencyDescriptorExtID), Payload: ddBytes}),
 			Payload:            payload,
 			AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
 			TransportWideExtID: uint8(d.transportWideExtID),
 			WriteStream:        d.writeStream,
-			Pool:               PacketFactory,
-			PoolEntity:         poolEntity,
+			Buffer:             buf,
 		})
 	}
 
@@ -1708,8 +1880,9 @@ func (d *DownTrack) retransmitPackets(nacks []uint16) {
 	}
 }
 
-func (d *DownTrack) getTranslatedRTPHeader(extPkt *buffer.ExtPacket, tp *TranslationParams) (*rtp.Header, error) {
-	hdr := extPkt.Packet.Header
+// translateRTPHeader writes the header of extPkt, rewritten for this down track, to hdr
+func (d *DownTrack) translateRTPHeader(hdr *rtp.Header, extPkt *buffer.ExtPacket, tp *TranslationParams) {
+	*hdr = extPkt.Packet.Header
 	hdr.PayloadType = d.payloadType
 	hdr.Timestamp = uint32(tp.rtp.extTimestamp)
 	hdr.SequenceNumber = uint16(tp.rtp.extSequenceNumber)
@@ -1717,8 +1890,6 @@ func (d *DownTrack) getTranslatedRTPHeader(extPkt *buffer.ExtPacket, tp *Transla
 	if tp.marker {
 		hdr.Marker = tp.marker
 	}
-
-	return &hdr, nil
 }
 
 func (d *DownTrack) DebugInfo() map[string]interface{} {
@@ -1733,6 +1904,9 @@ func (d *DownTrack) DebugInfo() map[string]interface{} {
 		stats["RTPTime"] = senderReport.RTPTime
 		stats["PacketCount"] = senderReport.PacketCount
 	}
+	if offset, ok := d.GetSyncOffset(); ok {
+		stats["SyncOffsetMs"] = float64(offset) / float64(time.Millisecond)
+	}
 
 	return map[string]interface{}{
 		"SubscriberID":        d.params.SubID,
@@ -1748,6 +1922,16 @@ func (d *DownTrack) DebugInfo() map[string]interface{} {
 	}
 }
 
+// GetSyncOffset returns how much later the sender reports sent to the subscriber place the media than the sender reports
+// of the publisher do. The offsets of the tracks of a publisher differ by the audio/video sync skew of the subscriber
+// introduced while forwarding, ok is false until both sides have sent a sender report.
+func (d *DownTrack) GetSyncOffset() (offset time.Duration, ok bool) {
+	if !d.bound.Load() {
+		return 0, false
+	}
+	return d.rtpStats.GetSenderReportOffset(d.forwarder.GetReferenceTimestampOffset())
+}
+
 func (d *DownTrack) getExpectedRTPTimestamp(at time.Time) (uint64, error) {
 	return d.rtpStats.GetExpectedRTPTimestamp(at)
 }
@@ -1756,6 +1940,10 @@ func (d *DownTrack) GetConnectionScoreAndQuality() (float32, livekit.ConnectionQ
 	return d.connectionStats.GetScoreAndQuality()
 }
 
+func (d *DownTrack) GetConnectionSubScores() (connectionquality.SubScores, bool) {
+	return d.connectionStats.GetSubScores()
+}
+
 func (d *DownTrack) GetTrackStats() *livekit.RTPStats {
 	return d.rtpStats.ToProto()
 }
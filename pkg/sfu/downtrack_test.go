@@ -0,0 +1,75 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestCapLayeredBitrate(t *testing.T) {
+	brs := Bitrates{
+		{100_000, 150_000, 200_000, 0},
+		{400_000, 600_000, 800_000, 0},
+		{1_200_000, 1_800_000, 2_400_000, 0},
+	}
+
+	availableLayers, capped := capLayeredBitrate([]int32{0, 1, 2}, brs, 700_000)
+	require.Equal(t, []int32{0, 1}, availableLayers)
+	require.Equal(t, Bitrates{
+		{100_000, 150_000, 200_000, 0},
+		{400_000, 600_000, 0, 0},
+	}, capped)
+
+	// layers with unknown bitrates are left available
+	availableLayers, _ = capLayeredBitrate([]int32{0, 1}, Bitrates{}, 700_000)
+	require.Equal(t, []int32{0, 1}, availableLayers)
+}
+
+func TestRangeLayeredBitrate(t *testing.T) {
+	trackInfo := &livekit.TrackInfo{
+		Layers: []*livekit.VideoLayer{
+			{Quality: livekit.VideoQuality_LOW},
+			{Quality: livekit.VideoQuality_MEDIUM},
+			{Quality: livekit.VideoQuality_HIGH},
+		},
+	}
+	ranges := &config.VideoQualityBitrates{
+		Low:  config.BitrateRange{Min: 200_000},
+		High: config.BitrateRange{Min: 800_000, Max: 1_200_000},
+	}
+
+	ranged := rangeLayeredBitrate(Bitrates{
+		{50_000, 100_000, 0, 0},
+		{400_000, 600_000, 800_000, 0},
+		{1_200_000, 1_800_000, 2_400_000, 0},
+	}, ranges, trackInfo)
+	require.Equal(t, Bitrates{
+		// raised to the minimum, temporal layers keep their share
+		{100_000, 200_000, 0, 0},
+		// no range for the quality
+		{400_000, 600_000, 800_000, 0},
+		// lowered to the maximum
+		{600_000, 900_000, 1_200_000, 0},
+	}, ranged)
+
+	// layers that are not measured stay unknown
+	require.Equal(t, Bitrates{}, rangeLayeredBitrate(Bitrates{}, ranges, trackInfo))
+}
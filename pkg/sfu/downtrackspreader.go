@@ -24,7 +24,9 @@ import (
 
 type DownTrackSpreaderParams struct {
 	Threshold int
-	Logger    logger.Logger
+	// when set, packets for more than Threshold down tracks are written by the pool
+	FanOutPool *FanOutPool
+	Logger     logger.Logger
 }
 
 type DownTrackSpreader struct {
@@ -88,6 +90,11 @@ func (d *DownTrackSpreader) HasDownTrack(subscriberID livekit.ParticipantID) boo
 
 func (d *DownTrackSpreader) Broadcast(writer func(TrackSender)) {
 	downTracks := d.GetDownTracks()
+	if d.params.FanOutPool != nil && d.params.Threshold > 0 && len(downTracks) > d.params.Threshold {
+		d.params.FanOutPool.Write(downTracks, writer)
+		return
+	}
+
 	threshold := uint64(d.params.Threshold)
 	if threshold == 0 {
 		threshold = 1000000
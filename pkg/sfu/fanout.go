@@ -0,0 +1,152 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"runtime"
+	"sync"
+
+	"go.uber.org/atomic"
+)
+
+const (
+	defaultFanOutBatchSize = 16
+	defaultFanOutQueueSize = 64
+)
+
+type FanOutPoolParams struct {
+	Workers   int
+	BatchSize int
+	QueueSize int
+	// called for every batch with the number of batches still queued,
+	// inline is set when the batch was written by the caller because the queue of its worker was full
+	OnBatch func(queued int, inline bool)
+}
+
+type fanOutJob struct {
+	downTracks []TrackSender
+	writer     func(TrackSender)
+	wg         *sync.WaitGroup
+}
+
+// FanOutPool writes packets to the down tracks of large tracks with a fixed set of workers shared by all tracks,
+// splitting the down tracks into batches. A batch whose worker is backed up is written inline by the caller.
+type FanOutPool struct {
+	params FanOutPoolParams
+
+	queues []chan fanOutJob
+	next   atomic.Uint32
+	queued atomic.Int32
+
+	// held to queue batches, batches are written inline once the pool is closed
+	lock   sync.RWMutex
+	closed bool
+}
+
+func NewFanOutPool(params FanOutPoolParams) *FanOutPool {
+	if params.Workers <= 0 {
+		params.Workers = runtime.NumCPU()
+	}
+	if params.BatchSize <= 0 {
+		params.BatchSize = defaultFanOutBatchSize
+	}
+	if params.QueueSize <= 0 {
+		params.QueueSize = defaultFanOutQueueSize
+	}
+
+	p := &FanOutPool{
+		params: params,
+		queues: make([]chan fanOutJob, params.Workers),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan fanOutJob, params.QueueSize)
+		go p.worker(p.queues[i])
+	}
+	return p
+}
+
+// Close stops the workers once the batches queued are written
+func (p *FanOutPool) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+	for _, q := range p.queues {
+		close(q)
+	}
+}
+
+// Write calls writer for each down track and returns once all of them are written,
+// which keeps packets of a track in order on each down track.
+func (p *FanOutPool) Write(downTracks []TrackSender, writer func(TrackSender)) {
+	if len(downTracks) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	var inline [][]TrackSender
+	start := p.next.Inc()
+	p.lock.RLock()
+	for i, idx := 0, uint32(0); i < len(downTracks); i, idx = i+p.params.BatchSize, idx+1 {
+		end := i + p.params.BatchSize
+		if end > len(downTracks) {
+			end = len(downTracks)
+		}
+		batch := downTracks[i:end]
+
+		if p.closed {
+			inline = append(inline, batch)
+			continue
+		}
+
+		wg.Add(1)
+		select {
+		case p.queues[(start+idx)%uint32(len(p.queues))] <- fanOutJob{downTracks: batch, writer: writer, wg: &wg}:
+			p.onBatch(int(p.queued.Inc()), false)
+		default:
+			wg.Done()
+			inline = append(inline, batch)
+		}
+	}
+	p.lock.RUnlock()
+
+	// batches that could not be queued are written while the workers handle the rest
+	for _, batch := range inline {
+		p.onBatch(int(p.queued.Load()), true)
+		for _, dt := range batch {
+			writer(dt)
+		}
+	}
+	wg.Wait()
+}
+
+func (p *FanOutPool) worker(queue chan fanOutJob) {
+	for job := range queue {
+		p.queued.Dec()
+		for _, dt := range job.downTracks {
+			job.writer(dt)
+		}
+		job.wg.Done()
+	}
+}
+
+func (p *FanOutPool) onBatch(queued int, inline bool) {
+	if p.params.OnBatch != nil {
+		p.params.OnBatch(queued, inline)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func TestFanOutPool(t *testing.T) {
+	t.Run("writes packets in order", func(t *testing.T) {
+		var batches atomic.Int32
+		pool := NewFanOutPool(FanOutPoolParams{
+			Workers:   4,
+			BatchSize: 3,
+			OnBatch: func(_ int, _ bool) {
+				batches.Inc()
+			},
+		})
+		defer pool.Close()
+
+		dummies := make([]*dummyDowntrack, 10)
+		downTracks := make([]TrackSender, len(dummies))
+		for i := range dummies {
+			dummies[i] = &dummyDowntrack{TrackSender: &DownTrack{}}
+			downTracks[i] = dummies[i]
+		}
+
+		for sn := uint16(0); sn < 100; sn++ {
+			pkt := &buffer.ExtPacket{Packet: &rtp.Packet{Header: rtp.Header{SequenceNumber: sn}}}
+			pool.Write(downTracks, func(dt TrackSender) {
+				_ = dt.WriteRTP(pkt, 0)
+			})
+		}
+
+		require.Equal(t, int32(100*4), batches.Load())
+		for _, dt := range dummies {
+			require.Len(t, dt.receivedPkts, 100)
+			for sn, pkt := range dt.receivedPkts {
+				require.Equal(t, uint16(sn), pkt.SequenceNumber)
+			}
+		}
+	})
+
+	t.Run("writes inline when the queue is full", func(t *testing.T) {
+		var inlineBatches atomic.Int32
+		pool := NewFanOutPool(FanOutPoolParams{
+			Workers:   1,
+			BatchSize: 1,
+			QueueSize: 1,
+			OnBatch: func(_ int, inline bool) {
+				if inline {
+					inlineBatches.Inc()
+				}
+			},
+		})
+		defer pool.Close()
+
+		downTracks := []TrackSender{
+			&dummyDowntrack{TrackSender: &DownTrack{}},
+			&dummyDowntrack{TrackSender: &DownTrack{}},
+		}
+
+		// keep the worker busy and its queue full
+		started := make(chan struct{})
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			pool.Write(downTracks[:1], func(TrackSender) {
+				close(started)
+				<-release
+			})
+		}()
+		<-started
+		go func() {
+			defer wg.Done()
+			pool.Write(downTracks[:1], func(TrackSender) {})
+		}()
+		require.Eventually(t, func() bool { return pool.queued.Load() == 1 }, time.Second, time.Millisecond)
+
+		var written atomic.Int32
+		pool.Write(downTracks, func(TrackSender) { written.Inc() })
+		require.Equal(t, int32(2), written.Load())
+		require.Equal(t, int32(2), inlineBatches.Load())
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("writes inline once closed", func(t *testing.T) {
+		pool := NewFanOutPool(FanOutPoolParams{Workers: 2, BatchSize: 1})
+		pool.Close()
+		pool.Close()
+
+		downTracks := []TrackSender{&dummyDowntrack{TrackSender: &DownTrack{}}, &dummyDowntrack{TrackSender: &DownTrack{}}}
+		var written atomic.Int32
+		pool.Write(downTracks, func(TrackSender) { written.Inc() })
+		require.Equal(t, int32(2), written.Load())
+	})
+}
+
+func BenchmarkFanOutPool(b *testing.B) {
+	pool := NewFanOutPool(FanOutPoolParams{})
+	defer pool.Close()
+
+	downTracks := make([]TrackSender, 1000)
+	for i := range downTracks {
+		downTracks[i] = &dummyDowntrack{TrackSender: &DownTrack{}}
+	}
+	var written atomic.Int64
+	writer := func(TrackSender) { written.Inc() }
+
+	b.Run("pool", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pool.Write(downTracks, writer)
+		}
+	})
+	b.Run("spreader", func(b *testing.B) {
+		d := NewDownTrackSpreader(DownTrackSpreaderParams{Threshold: 20})
+		d.downTracksShadow = downTracks
+		for i := 0; i < b.N; i++ {
+			d.Broadcast(writer)
+		}
+	})
+}
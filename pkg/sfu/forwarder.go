@@ -28,6 +28,7 @@ import (
 
 	"github.com/livekit/protocol/logger"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/codecmunger"
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
@@ -194,6 +195,7 @@ type Forwarder struct {
 	muted                 bool
 	pubMuted              bool
 	resumeBehindThreshold float64
+	policy                config.ForwardingPolicy
 
 	started               bool
 	preStartTime          time.Time
@@ -237,6 +239,19 @@ func NewForwarder(
 	return f
 }
 
+// SetForwardingPolicy sets how layers are lowered when bandwidth is short, with maintain resolution temporal
+// layers are dropped before spatial layers and going up prefers a higher spatial layer
+func (f *Forwarder) SetForwardingPolicy(policy config.ForwardingPolicy) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.policy = policy
+}
+
+func (f *Forwarder) maintainsResolutionLocked() bool {
+	return f.policy == config.ForwardingPolicyMaintainResolution
+}
+
 func (f *Forwarder) SetMaxPublishedLayer(maxPublishedLayer int32) bool {
 	f.lock.Lock()
 	defer f.lock.Unlock()
@@ -987,34 +1002,45 @@ func (f *Forwarder) ProvisionalAllocateGetBestWeightedTransition() (VideoTransit
 	bestLayer := buffer.InvalidLayer
 	bestBandwidthDelta := int64(0)
 	bestValue := float32(0)
-	for s := int32(0); s <= targetLayer.Spatial; s++ {
-		for t := int32(0); t <= targetLayer.Temporal; t++ {
-			if s == targetLayer.Spatial && t == targetLayer.Temporal {
-				break
-			}
+	findBest := func(minSpatial int32) {
+		for s := minSpatial; s <= targetLayer.Spatial; s++ {
+			for t := int32(0); t <= targetLayer.Temporal; t++ {
+				if s == targetLayer.Spatial && t == targetLayer.Temporal {
+					break
+				}
 
-			bandwidthDelta := int64(math.Max(float64(0), float64(existingBandwidthNeeded-f.provisional.bitrates[s][t])))
+				bandwidthDelta := int64(math.Max(float64(0), float64(existingBandwidthNeeded-f.provisional.bitrates[s][t])))
 
-			transitionCost := int32(0)
-			// SVC-TODO: SVC will need a different cost transition
-			if targetLayer.Spatial != s {
-				transitionCost = TransitionCostSpatial
-			}
+				transitionCost := int32(0)
+				// SVC-TODO: SVC will need a different cost transition
+				if targetLayer.Spatial != s {
+					transitionCost = TransitionCostSpatial
+				}
 
-			qualityCost := (maxReachableLayerTemporal+1)*(targetLayer.Spatial-s) + (targetLayer.Temporal - t)
+				qualityCost := (maxReachableLayerTemporal+1)*(targetLayer.Spatial-s) + (targetLayer.Temporal - t)
 
-			value := float32(0)
-			if (transitionCost + qualityCost) != 0 {
-				value = float32(bandwidthDelta) / float32(transitionCost+qualityCost)
-			}
-			if value > bestValue || (value == bestValue && bandwidthDelta > bestBandwidthDelta) {
-				bestValue = value
-				bestBandwidthDelta = bandwidthDelta
-				bestLayer = buffer.VideoLayer{Spatial: s, Temporal: t}
+				value := float32(0)
+				if (transitionCost + qualityCost) != 0 {
+					value = float32(bandwidthDelta) / float32(transitionCost+qualityCost)
+				}
+				if value > bestValue || (value == bestValue && bandwidthDelta > bestBandwidthDelta) {
+					bestValue = value
+					bestBandwidthDelta = bandwidthDelta
+					bestLayer = buffer.VideoLayer{Spatial: s, Temporal: t}
+				}
 			}
 		}
 	}
 
+	// maintaining resolution gives up frame rate in the current spatial layer first,
+	// lower spatial layers are considered only when that does not save any bits
+	if f.maintainsResolutionLocked() && targetLayer.Temporal > 0 {
+		findBest(targetLayer.Spatial)
+	}
+	if !bestLayer.IsValid() {
+		findBest(0)
+	}
+
 	f.provisional.allocatedLayer = bestLayer
 	return VideoTransition{
 		From:           targetLayer,
@@ -1182,6 +1208,17 @@ func (f *Forwarder) AllocateNextHigher(availableChannelCapacity int64, available
 	var allocation VideoAllocation
 	boosted := false
 
+	// maintaining resolution tries moving spatial layer up before the temporal layer
+	if f.maintainsResolutionLocked() {
+		done, allocation, boosted = doAllocation(
+			targetLayer.Spatial+1, maxLayer.Spatial,
+			0, maxLayer.Temporal,
+		)
+		if done {
+			return allocation, boosted
+		}
+	}
+
 	// try moving temporal layer up in currently streaming spatial layer
 	if targetLayer.IsValid() {
 		done, allocation, boosted = doAllocation(
@@ -1194,12 +1231,14 @@ func (f *Forwarder) AllocateNextHigher(availableChannelCapacity int64, available
 	}
 
 	// try moving spatial layer up if temporal layer move up is not available
-	done, allocation, boosted = doAllocation(
-		targetLayer.Spatial+1, maxLayer.Spatial,
-		0, maxLayer.Temporal,
-	)
-	if done {
-		return allocation, boosted
+	if !f.maintainsResolutionLocked() {
+		done, allocation, boosted = doAllocation(
+			targetLayer.Spatial+1, maxLayer.Spatial,
+			0, maxLayer.Temporal,
+		)
+		if done {
+			return allocation, boosted
+		}
 	}
 
 	if allowOvershoot && f.vls.IsOvershootOkay() && maxLayer.IsValid() {
@@ -1270,8 +1309,19 @@ func (f *Forwarder) GetNextHigherTransition(brs Bitrates, allowOvershoot bool) (
 	var transition VideoTransition
 	isAvailable := false
 
-	// try moving temporal layer up in currently streaming spatial layer
+	// maintaining resolution probes for a higher spatial layer before a higher temporal layer
 	maxLayer := f.vls.GetMax()
+	if f.maintainsResolutionLocked() {
+		done, transition, isAvailable = findNextHigher(
+			targetLayer.Spatial+1, maxLayer.Spatial,
+			0, maxLayer.Temporal,
+		)
+		if done {
+			return transition, isAvailable
+		}
+	}
+
+	// try moving temporal layer up in currently streaming spatial layer
 	if targetLayer.IsValid() {
 		done, transition, isAvailable = findNextHigher(
 			targetLayer.Spatial, targetLayer.Spatial,
@@ -1283,12 +1333,14 @@ func (f *Forwarder) GetNextHigherTransition(brs Bitrates, allowOvershoot bool) (
 	}
 
 	// try moving spatial layer up if temporal layer move up is not available
-	done, transition, isAvailable = findNextHigher(
-		targetLayer.Spatial+1, maxLayer.Spatial,
-		0, maxLayer.Temporal,
-	)
-	if done {
-		return transition, isAvailable
+	if !f.maintainsResolutionLocked() {
+		done, transition, isAvailable = findNextHigher(
+			targetLayer.Spatial+1, maxLayer.Spatial,
+			0, maxLayer.Temporal,
+		)
+		if done {
+			return transition, isAvailable
+		}
 	}
 
 	if allowOvershoot && f.vls.IsOvershootOkay() && maxLayer.IsValid() {
@@ -1395,6 +1447,14 @@ func (f *Forwarder) resyncLocked() {
 	}
 }
 
+// IsStarted is false until the first packet is forwarded
+func (f *Forwarder) IsStarted() bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.started
+}
+
 func (f *Forwarder) CheckSync() (bool, int32) {
 	f.lock.RLock()
 	defer f.lock.RUnlock()
@@ -22,6 +22,7 @@ import (
 
 	"github.com/livekit/protocol/logger"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/testutils"
 )
@@ -886,6 +887,54 @@ func TestForwarderProvisionalAllocateGetBestWeightedTransition(t *testing.T) {
 	require.Equal(t, bitrates, brs)
 }
 
+func TestForwarderMaintainResolution(t *testing.T) {
+	availableLayers := []int32{0, 1, 2}
+	bitrates := Bitrates{
+		{1, 2, 3, 4},
+		{5, 6, 7, 8},
+		{990, 995, 998, 1000},
+	}
+
+	bestWeighted := func(policy config.ForwardingPolicy) VideoTransition {
+		f := newForwarder(testutils.TestVP8Codec, webrtc.RTPCodecTypeVideo)
+		f.SetForwardingPolicy(policy)
+		f.SetMaxSpatialLayer(buffer.DefaultMaxLayerSpatial)
+		f.SetMaxTemporalLayer(buffer.DefaultMaxLayerTemporal)
+		f.ProvisionalAllocatePrepare(availableLayers, bitrates)
+		f.vls.SetTarget(buffer.VideoLayer{Spatial: 2, Temporal: 3})
+		f.lastAllocation.BandwidthRequested = bitrates[2][3]
+
+		transition, _, _ := f.ProvisionalAllocateGetBestWeightedTransition()
+		return transition
+	}
+
+	// dropping the temporal layers of the top spatial layer saves little, balanced goes down a spatial layer
+	require.Equal(t, buffer.VideoLayer{Spatial: 1, Temporal: 3}, bestWeighted(config.ForwardingPolicyBalanced).To)
+
+	// maintaining resolution gives up frame rate first
+	transition := bestWeighted(config.ForwardingPolicyMaintainResolution)
+	require.Equal(t, buffer.VideoLayer{Spatial: 2, Temporal: 0}, transition.To)
+	require.Equal(t, int64(-10), transition.BandwidthDelta)
+
+	nextHigher := func(policy config.ForwardingPolicy) VideoTransition {
+		f := newForwarder(testutils.TestVP8Codec, webrtc.RTPCodecTypeVideo)
+		f.SetForwardingPolicy(policy)
+		f.SetMaxSpatialLayer(buffer.DefaultMaxLayerSpatial)
+		f.SetMaxTemporalLayer(buffer.DefaultMaxLayerTemporal)
+		f.lastAllocation.IsDeficient = true
+		f.vls.SetTarget(buffer.VideoLayer{Spatial: 0, Temporal: 0})
+		f.vls.SetCurrent(buffer.VideoLayer{Spatial: 0, Temporal: 0})
+
+		transition, available := f.GetNextHigherTransition(bitrates, false)
+		require.True(t, available)
+		return transition
+	}
+
+	// balanced probes for a higher temporal layer, maintaining resolution for a higher spatial layer
+	require.Equal(t, buffer.VideoLayer{Spatial: 0, Temporal: 1}, nextHigher(config.ForwardingPolicyBalanced).To)
+	require.Equal(t, buffer.VideoLayer{Spatial: 1, Temporal: 0}, nextHigher(config.ForwardingPolicyMaintainResolution).To)
+}
+
 func TestForwarderAllocateNextHigher(t *testing.T) {
 	f := newForwarder(testutils.TestOpusCodec, webrtc.RTPCodecTypeAudio)
 	f.SetMaxSpatialLayer(buffer.DefaultMaxLayerSpatial)
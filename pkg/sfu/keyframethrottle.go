@@ -0,0 +1,148 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	defaultKeyFrameLayerInterval = 500 * time.Millisecond
+	keyFrameRateWindow           = 10 * time.Second
+)
+
+// KeyFrameRequestStats are the key frame requests made by the subscribers of a track, requests are
+// suppressed when the publisher was asked for a key frame too recently
+type KeyFrameRequestStats struct {
+	Requested  uint64 `json:"requested"`
+	Sent       uint64 `json:"sent"`
+	Suppressed uint64 `json:"suppressed"`
+	// requests per second over the last seconds
+	RequestRate float64 `json:"request_rate"`
+}
+
+// KeyFrameThrottle paces the key frame requests sent to the publisher of a track. Each down track asks for
+// a key frame when it starts or switches layers, and keeps asking until it gets one, so that subscribers
+// joining or switching together in a large room would otherwise ask the publisher for key frames faster
+// than it can encode them. A request is sent when its layer has not had one for the layer interval and
+// the track has not had one for the track interval, the key frame of the request sent serves the others.
+type KeyFrameThrottle struct {
+	layerIntervals [buffer.DefaultMaxLayerSpatial + 1]time.Duration
+	trackInterval  time.Duration
+	// called for every request, fn must not block
+	onRequest func(suppressed bool)
+
+	lock         sync.Mutex
+	lastLayer    [buffer.DefaultMaxLayerSpatial + 1]time.Time
+	lastTrack    time.Time
+	stats        KeyFrameRequestStats
+	windowStart  time.Time
+	windowCount  uint64
+	previousRate float64
+}
+
+func NewKeyFrameThrottle(conf config.PLIThrottleConfig, onRequest func(suppressed bool)) *KeyFrameThrottle {
+	k := &KeyFrameThrottle{
+		layerIntervals: [buffer.DefaultMaxLayerSpatial + 1]time.Duration{conf.LowQuality, conf.MidQuality, conf.HighQuality},
+		trackInterval:  conf.Track,
+		onRequest:      onRequest,
+	}
+	for i, interval := range k.layerIntervals {
+		if interval <= 0 {
+			k.layerIntervals[i] = defaultKeyFrameLayerInterval
+		}
+	}
+	return k
+}
+
+// Request returns true when a key frame request for the layer is to be sent to the publisher.
+// Forced requests are always sent.
+func (k *KeyFrameThrottle) Request(layer int32, force bool) bool {
+	if k == nil {
+		return true
+	}
+	return k.request(layer, force, time.Now())
+}
+
+func (k *KeyFrameThrottle) request(layer int32, force bool, now time.Time) bool {
+	if layer < 0 || int(layer) >= len(k.layerIntervals) {
+		layer = 0
+	}
+
+	k.lock.Lock()
+	k.stats.Requested++
+	k.countLocked(now)
+
+	send := force ||
+		(now.Sub(k.lastLayer[layer]) >= k.layerIntervals[layer] && (k.trackInterval <= 0 || now.Sub(k.lastTrack) >= k.trackInterval))
+	if send {
+		k.stats.Sent++
+		k.lastLayer[layer] = now
+		k.lastTrack = now
+	} else {
+		k.stats.Suppressed++
+	}
+	k.lock.Unlock()
+
+	if k.onRequest != nil {
+		k.onRequest(!send)
+	}
+	return send
+}
+
+// countLocked counts a request in the current rate window, the rate of the last complete window
+// is kept for the stats
+func (k *KeyFrameThrottle) countLocked(now time.Time) {
+	if elapsed := now.Sub(k.windowStart); elapsed >= keyFrameRateWindow {
+		if elapsed < 2*keyFrameRateWindow {
+			k.previousRate = float64(k.windowCount) / elapsed.Seconds()
+		} else {
+			k.previousRate = 0
+		}
+		k.windowStart = now
+		k.windowCount = 0
+	}
+	k.windowCount++
+}
+
+// Stats returns the requests since the track was published
+func (k *KeyFrameThrottle) Stats() KeyFrameRequestStats {
+	if k == nil {
+		return KeyFrameRequestStats{}
+	}
+	return k.getStats(time.Now())
+}
+
+func (k *KeyFrameThrottle) getStats(now time.Time) KeyFrameRequestStats {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	stats := k.stats
+	switch elapsed := now.Sub(k.windowStart); {
+	case k.windowStart.IsZero() || elapsed >= 2*keyFrameRateWindow:
+		stats.RequestRate = 0
+	case elapsed >= keyFrameRateWindow:
+		stats.RequestRate = float64(k.windowCount) / elapsed.Seconds()
+	default:
+		// the current window is blended with the previous one, so that the rate does not drop with each new window
+		weight := elapsed.Seconds() / keyFrameRateWindow.Seconds()
+		stats.RequestRate = float64(k.windowCount)/keyFrameRateWindow.Seconds() + (1-weight)*k.previousRate
+	}
+	return stats
+}
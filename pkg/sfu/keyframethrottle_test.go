@@ -0,0 +1,77 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestKeyFrameThrottle(t *testing.T) {
+	suppressed := 0
+	k := NewKeyFrameThrottle(config.PLIThrottleConfig{
+		LowQuality:  500 * time.Millisecond,
+		MidQuality:  time.Second,
+		HighQuality: time.Second,
+		Track:       200 * time.Millisecond,
+	}, func(s bool) {
+		if s {
+			suppressed++
+		}
+	})
+
+	now := time.Now()
+	require.True(t, k.request(0, false, now))
+
+	// subscribers joining together share the key frame of the first request
+	for i := 0; i < 10; i++ {
+		require.False(t, k.request(0, false, now.Add(time.Duration(i)*10*time.Millisecond)))
+	}
+
+	// another layer waits for the track interval
+	require.False(t, k.request(2, false, now.Add(100*time.Millisecond)))
+	require.True(t, k.request(2, false, now.Add(200*time.Millisecond)))
+
+	// a layer waits for its own interval
+	require.False(t, k.request(0, false, now.Add(400*time.Millisecond)))
+	require.True(t, k.request(0, false, now.Add(500*time.Millisecond)))
+
+	// forced requests are always sent
+	require.True(t, k.request(0, true, now.Add(510*time.Millisecond)))
+
+	stats := k.getStats(now.Add(time.Second))
+	require.Equal(t, uint64(16), stats.Requested)
+	require.Equal(t, uint64(4), stats.Sent)
+	require.Equal(t, uint64(12), stats.Suppressed)
+	require.Equal(t, 12, suppressed)
+	require.InDelta(t, 1.6, stats.RequestRate, 0.01)
+
+	// no requests for a while
+	require.Zero(t, k.getStats(now.Add(time.Minute)).RequestRate)
+}
+
+func TestKeyFrameThrottleLayersOnly(t *testing.T) {
+	k := NewKeyFrameThrottle(config.PLIThrottleConfig{}, nil)
+
+	now := time.Now()
+	require.True(t, k.request(0, false, now))
+	require.True(t, k.request(1, false, now))
+	require.False(t, k.request(1, false, now.Add(100*time.Millisecond)))
+	require.True(t, k.request(1, false, now.Add(defaultKeyFrameLayerInterval)))
+}
@@ -44,8 +44,8 @@ func (b *Base) SetBitrate(_bitrate int) {
 
 func (b *Base) SendPacket(p *Packet) (int, error) {
 	defer func() {
-		if p.Pool != nil && p.PoolEntity != nil {
-			p.Pool.Put(p.PoolEntity)
+		if p.Buffer != nil {
+			PutPacketBuffer(p.Buffer)
 		}
 	}()
 
@@ -69,10 +69,15 @@ func (b *Base) SendPacket(p *Packet) (int, error) {
 
 // writes RTP header extensions of track
 func (b *Base) writeRTPHeaderExtensions(p *Packet) (time.Time, error) {
-	// clear out extensions that may have been in the forwarded header
+	// clear out extensions that may have been in the forwarded header,
+	// its extensions are shared with the other down tracks and must not be written to
 	p.Header.Extension = false
 	p.Header.ExtensionProfile = 0
-	p.Header.Extensions = []rtp.Extension{}
+	if p.Buffer != nil {
+		p.Header.Extensions = p.Buffer.headerExtensions[:0]
+	} else {
+		p.Header.Extensions = []rtp.Extension{}
+	}
 
 	for _, ext := range p.Extensions {
 		if ext.ID == 0 || len(ext.Payload) == 0 {
@@ -84,13 +89,14 @@ func (b *Base) writeRTPHeaderExtensions(p *Packet) (time.Time, error) {
 
 	sendingAt := b.packetTime.Get()
 	if p.AbsSendTimeExtID != 0 {
-		sendTime := rtp.NewAbsSendTimeExtension(sendingAt)
-		b, err := sendTime.Marshal()
-		if err != nil {
-			return time.Time{}, err
+		var absSendTime []byte
+		if p.Buffer != nil {
+			absSendTime = marshalAbsSendTime(p.Buffer.absSendTime[:], sendingAt)
+		} else {
+			absSendTime = marshalAbsSendTime(make([]byte, 3), sendingAt)
 		}
 
-		err = p.Header.SetExtension(p.AbsSendTimeExtID, b)
+		err := p.Header.SetExtension(p.AbsSendTimeExtID, absSendTime)
 		if err != nil {
 			return time.Time{}, err
 		}
@@ -99,4 +105,13 @@ func (b *Base) writeRTPHeaderExtensions(p *Packet) (time.Time, error) {
 	return sendingAt, nil
 }
 
+// marshalAbsSendTime writes the abs-send-time extension of sendingAt to b, like rtp.AbsSendTimeExtension.Marshal
+func marshalAbsSendTime(b []byte, sendingAt time.Time) []byte {
+	ts := rtp.NewAbsSendTimeExtension(sendingAt).Timestamp
+	b[0] = byte(ts >> 16)
+	b[1] = byte(ts >> 8)
+	b[2] = byte(ts)
+	return b[:3]
+}
+
 // ------------------------------------------------
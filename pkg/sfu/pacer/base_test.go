@@ -0,0 +1,99 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacer
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+)
+
+type marshalingWriter struct {
+	buf        []byte
+	extensions int
+}
+
+func (w *marshalingWriter) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
+	n, err := header.MarshalTo(w.buf)
+	if err != nil {
+		return 0, err
+	}
+	w.extensions = len(header.Extensions)
+	return n + copy(w.buf[n:], payload), nil
+}
+
+func (w *marshalingWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func TestSendPacket(t *testing.T) {
+	base := NewBase(logger.GetLogger())
+	w := &marshalingWriter{buf: make([]byte, 1500)}
+
+	// extensions of the forwarded packet are shared with other down tracks
+	forwarded := rtp.Header{Version: 2, SequenceNumber: 1, SSRC: 1234}
+	require.NoError(t, forwarded.SetExtension(5, []byte{0xaa}))
+
+	buf := GetPacketBuffer()
+	buf.Header = forwarded
+	payload := buf.Payload[:copy(buf.Payload, []byte{1, 2, 3})]
+	n, err := base.SendPacket(&Packet{
+		Header:           &buf.Header,
+		Extensions:       append(buf.Extensions(), ExtensionData{ID: 1, Payload: []byte{0xbb, 0xcc}}),
+		Payload:          payload,
+		AbsSendTimeExtID: 2,
+		WriteStream:      w,
+		Buffer:           buf,
+	})
+	require.NoError(t, err)
+	require.Len(t, forwarded.Extensions, 1)
+	require.Equal(t, []byte{0xaa}, forwarded.GetExtension(5))
+	require.Equal(t, 2, w.extensions)
+
+	var sent rtp.Packet
+	require.NoError(t, sent.Unmarshal(w.buf[:n]))
+	require.Equal(t, []byte{0xbb, 0xcc}, sent.GetExtension(1))
+	require.Len(t, sent.GetExtension(2), 3)
+	require.Nil(t, sent.GetExtension(5))
+	require.Equal(t, []byte{1, 2, 3}, sent.Payload)
+}
+
+func BenchmarkSendPacket(b *testing.B) {
+	base := NewBase(logger.GetLogger())
+	w := &marshalingWriter{buf: make([]byte, 1500)}
+	incoming := rtp.Header{Version: 2, SequenceNumber: 1, SSRC: 1234}
+	dd := make([]byte, 8)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf := GetPacketBuffer()
+		buf.Header = incoming
+		payload := buf.Payload[:1000]
+		if _, err := base.SendPacket(&Packet{
+			Header:           &buf.Header,
+			Extensions:       append(buf.Extensions(), ExtensionData{ID: 1, Payload: dd}),
+			Payload:          payload,
+			AbsSendTimeExtID: 2,
+			WriteStream:      w,
+			Buffer:           buf,
+		}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
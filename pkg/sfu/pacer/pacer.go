@@ -15,7 +15,6 @@
 package pacer
 
 import (
-	"sync"
 	"time"
 
 	"github.com/pion/rtp"
@@ -34,8 +33,8 @@ type Packet struct {
 	AbsSendTimeExtID   uint8
 	TransportWideExtID uint8
 	WriteStream        webrtc.TrackLocalWriter
-	Pool               *sync.Pool
-	PoolEntity         *[]byte
+	// Buffer holds the header, extensions and payload above, it is returned to the pool once the packet is sent
+	Buffer *PacketBuffer
 }
 
 type Pacer interface {
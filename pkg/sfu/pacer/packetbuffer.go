@@ -0,0 +1,71 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacer
+
+import (
+	"sync"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// large enough for any packet that fits into the MTU
+	maxPacketSize = 1460
+
+	// extensions the pacer is given, dependency descriptor and playout delay
+	maxPacketExtensions = 2
+	// header extensions written to an outgoing packet, the ones above plus abs-send-time
+	// and transport-wide-cc, which is written by the interceptor
+	maxHeaderExtensions = 4
+)
+
+var packetBufferPool = sync.Pool{
+	New: func() interface{} {
+		return &PacketBuffer{
+			Payload: make([]byte, maxPacketSize),
+		}
+	},
+}
+
+// PacketBuffer is the storage of an outgoing packet, the rewritten header, its extensions and payload, so that
+// forwarding a packet does not allocate. It is taken with GetPacketBuffer and returned to the pool by the pacer
+// once the packet has been written, nothing may hold on to it afterwards.
+type PacketBuffer struct {
+	Header rtp.Header
+	// full capacity, sliced to the size of the packet being sent
+	Payload []byte
+
+	extensions       [maxPacketExtensions]ExtensionData
+	headerExtensions [maxHeaderExtensions]rtp.Extension
+	absSendTime      [3]byte
+}
+
+func GetPacketBuffer() *PacketBuffer {
+	return packetBufferPool.Get().(*PacketBuffer)
+}
+
+// PutPacketBuffer returns a buffer that is not handed to the pacer to the pool
+func PutPacketBuffer(b *PacketBuffer) {
+	// do not keep header fields that reference memory of the forwarded packet
+	b.Header = rtp.Header{}
+	b.extensions = [maxPacketExtensions]ExtensionData{}
+	b.headerExtensions = [maxHeaderExtensions]rtp.Extension{}
+	packetBufferPool.Put(b)
+}
+
+// Extensions returns an empty slice to append the extensions of the packet to
+func (b *PacketBuffer) Extensions() []ExtensionData {
+	return b.extensions[:0]
+}
@@ -0,0 +1,316 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type Format string
+
+const (
+	// FormatPCAP writes packets as UDP datagrams in a libpcap file, read by Wireshark and tcpdump.
+	// Packets from the publisher go from 192.0.2.1 to 192.0.2.2, packets to the publisher the other way.
+	FormatPCAP Format = "pcap"
+	// FormatRTPDump writes packets in the rtpdump format of rtptools, also read by the video_replay tool of
+	// libwebrtc. It does not record the direction of packets.
+	FormatRTPDump Format = "rtpdump"
+)
+
+func (f Format) Extension() string {
+	switch f {
+	case FormatRTPDump:
+		return "rtpdump"
+	default:
+		return "pcap"
+	}
+}
+
+type Direction int
+
+const (
+	// DirectionInbound is for packets received from the publisher
+	DirectionInbound Direction = iota
+	// DirectionOutbound is for packets sent to the publisher
+	DirectionOutbound
+)
+
+var (
+	ErrInvalidFormat = errors.New("invalid packet capture format")
+)
+
+type CaptureParams struct {
+	Format Format
+	Writer io.Writer
+	// the capture stops once this many bytes of packets are written, no limit when 0
+	MaxBytes int64
+	// called once when the capture stops on its own, after reaching MaxBytes or failing to write
+	OnStopped func(err error)
+}
+
+// Capture writes copies of the RTP and RTCP packets of tracks. It is safe to write to from the goroutines
+// of several streams, writes after the capture stops are dropped.
+type Capture struct {
+	params CaptureParams
+
+	lock      sync.Mutex
+	w         *bufio.Writer
+	format    packetWriter
+	startedAt time.Time
+	stopped   bool
+	err       error
+	packets   int64
+	bytes     int64
+}
+
+type packetWriter interface {
+	writeHeader(w *bufio.Writer, startedAt time.Time) error
+	writePacket(w *bufio.Writer, startedAt time.Time, at time.Time, direction Direction, isRTCP bool, pkt []byte) error
+}
+
+func NewCapture(params CaptureParams) (*Capture, error) {
+	var format packetWriter
+	switch params.Format {
+	case FormatPCAP, "":
+		format = &pcapWriter{}
+	case FormatRTPDump:
+		format = &rtpDumpWriter{}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFormat, params.Format)
+	}
+
+	c := &Capture{
+		params:    params,
+		w:         bufio.NewWriter(params.Writer),
+		format:    format,
+		startedAt: time.Now(),
+	}
+	if err := format.writeHeader(c.w, c.startedAt); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Capture) WriteRTP(direction Direction, pkt []byte) {
+	c.write(direction, false, pkt)
+}
+
+func (c *Capture) WriteRTCP(direction Direction, pkt []byte) {
+	c.write(direction, true, pkt)
+}
+
+func (c *Capture) write(direction Direction, isRTCP bool, pkt []byte) {
+	if c == nil || len(pkt) == 0 {
+		return
+	}
+
+	c.lock.Lock()
+	if c.stopped {
+		c.lock.Unlock()
+		return
+	}
+
+	err := c.format.writePacket(c.w, c.startedAt, time.Now(), direction, isRTCP, pkt)
+	if err == nil {
+		c.packets++
+		c.bytes += int64(len(pkt))
+		if c.params.MaxBytes == 0 || c.bytes < c.params.MaxBytes {
+			c.lock.Unlock()
+			return
+		}
+	}
+
+	err = c.stopLocked(err)
+	c.lock.Unlock()
+
+	if c.params.OnStopped != nil {
+		c.params.OnStopped(err)
+	}
+}
+
+// Stop flushes the packets written so far and stops the capture. It returns the error the capture stopped
+// with, if any.
+func (c *Capture) Stop() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stopped {
+		return c.err
+	}
+	return c.stopLocked(nil)
+}
+
+func (c *Capture) stopLocked(err error) error {
+	c.stopped = true
+	if err == nil {
+		err = c.w.Flush()
+	}
+	c.err = err
+	return err
+}
+
+// Stats returns the number of packets and the bytes of packets written so far
+func (c *Capture) Stats() (packets int64, bytes int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.packets, c.bytes
+}
+
+// ---------------------------------------------
+
+const (
+	pcapMagic        = 0xa1b2c3d4
+	pcapSnapLen      = 65535
+	pcapLinkTypeRaw  = 101
+	pcapVersionMajor = 2
+	pcapVersionMinor = 4
+
+	ipv4HeaderSize = 20
+	udpHeaderSize  = 8
+	udpPort        = 5004
+)
+
+var (
+	publisherAddr = [4]byte{192, 0, 2, 1}
+	sfuAddr       = [4]byte{192, 0, 2, 2}
+)
+
+// pcapWriter writes raw IPv4 packets, RTP and RTCP are multiplexed on the same port as in WebRTC
+type pcapWriter struct {
+	scratch []byte
+}
+
+func (p *pcapWriter) writeHeader(w *bufio.Writer, _ time.Time) error {
+	var header [24]byte
+	binary.LittleEndian.PutUint32(header[0:], pcapMagic)
+	binary.LittleEndian.PutUint16(header[4:], pcapVersionMajor)
+	binary.LittleEndian.PutUint16(header[6:], pcapVersionMinor)
+	binary.LittleEndian.PutUint32(header[16:], pcapSnapLen)
+	binary.LittleEndian.PutUint32(header[20:], pcapLinkTypeRaw)
+	_, err := w.Write(header[:])
+	return err
+}
+
+func (p *pcapWriter) writePacket(w *bufio.Writer, _ time.Time, at time.Time, direction Direction, _ bool, pkt []byte) error {
+	size := ipv4HeaderSize + udpHeaderSize + len(pkt)
+	if size > pcapSnapLen {
+		return nil
+	}
+
+	if cap(p.scratch) < 16+size {
+		p.scratch = make([]byte, 16+size)
+	}
+	b := p.scratch[:16+size]
+
+	usec := at.UnixMicro()
+	binary.LittleEndian.PutUint32(b[0:], uint32(usec/1e6))
+	binary.LittleEndian.PutUint32(b[4:], uint32(usec%1e6))
+	binary.LittleEndian.PutUint32(b[8:], uint32(size))
+	binary.LittleEndian.PutUint32(b[12:], uint32(size))
+
+	src, dst := publisherAddr, sfuAddr
+	if direction == DirectionOutbound {
+		src, dst = sfuAddr, publisherAddr
+	}
+
+	ip := b[16 : 16+ipv4HeaderSize]
+	ip[0] = 0x45
+	ip[1] = 0
+	binary.BigEndian.PutUint16(ip[2:], uint16(size))
+	binary.BigEndian.PutUint32(ip[4:], 0)
+	ip[8] = 64
+	ip[9] = 17
+	binary.BigEndian.PutUint16(ip[10:], 0)
+	copy(ip[12:16], src[:])
+	copy(ip[16:20], dst[:])
+	binary.BigEndian.PutUint16(ip[10:], ipv4Checksum(ip))
+
+	udp := b[16+ipv4HeaderSize : 16+ipv4HeaderSize+udpHeaderSize]
+	binary.BigEndian.PutUint16(udp[0:], udpPort)
+	binary.BigEndian.PutUint16(udp[2:], udpPort)
+	binary.BigEndian.PutUint16(udp[4:], uint16(udpHeaderSize+len(pkt)))
+	binary.BigEndian.PutUint16(udp[6:], 0) // no checksum, allowed over IPv4
+
+	copy(b[16+ipv4HeaderSize+udpHeaderSize:], pkt)
+	_, err := w.Write(b)
+	return err
+}
+
+func ipv4Checksum(header []byte) uint16 {
+	var sum uint32
+	for i := 0; i < len(header); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(header[i:]))
+	}
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return ^uint16(sum)
+}
+
+// ---------------------------------------------
+
+const (
+	rtpDumpPacketHeaderSize = 8
+)
+
+// rtpDumpWriter writes the rtpdump format of rtptools, RTCP packets have a zero RTP length
+type rtpDumpWriter struct {
+	scratch []byte
+}
+
+func (r *rtpDumpWriter) writeHeader(w *bufio.Writer, startedAt time.Time) error {
+	if _, err := fmt.Fprintf(w, "#!rtpplay1.0 %d.%d.%d.%d/%d\n", publisherAddr[0], publisherAddr[1], publisherAddr[2], publisherAddr[3], udpPort); err != nil {
+		return err
+	}
+
+	var header [16]byte
+	usec := startedAt.UnixMicro()
+	binary.BigEndian.PutUint32(header[0:], uint32(usec/1e6))
+	binary.BigEndian.PutUint32(header[4:], uint32(usec%1e6))
+	copy(header[8:12], publisherAddr[:])
+	binary.BigEndian.PutUint16(header[12:], udpPort)
+	_, err := w.Write(header[:])
+	return err
+}
+
+func (r *rtpDumpWriter) writePacket(w *bufio.Writer, startedAt time.Time, at time.Time, _ Direction, isRTCP bool, pkt []byte) error {
+	size := rtpDumpPacketHeaderSize + len(pkt)
+	if size > 0xffff {
+		return nil
+	}
+
+	if cap(r.scratch) < size {
+		r.scratch = make([]byte, size)
+	}
+	b := r.scratch[:size]
+
+	binary.BigEndian.PutUint16(b[0:], uint16(size))
+	if isRTCP {
+		binary.BigEndian.PutUint16(b[2:], 0)
+	} else {
+		binary.BigEndian.PutUint16(b[2:], uint16(len(pkt)))
+	}
+	binary.BigEndian.PutUint32(b[4:], uint32(at.Sub(startedAt).Milliseconds()))
+	copy(b[rtpDumpPacketHeaderSize:], pkt)
+	_, err := w.Write(b)
+	return err
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func testRTP(t *testing.T, sn uint16) []byte {
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: sn,
+			SSRC:           1234,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	}
+	raw, err := pkt.Marshal()
+	require.NoError(t, err)
+	return raw
+}
+
+func TestPCAP(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := NewCapture(CaptureParams{Format: FormatPCAP, Writer: &buf})
+	require.NoError(t, err)
+
+	pkt := testRTP(t, 1)
+	c.WriteRTP(DirectionInbound, pkt)
+	rr, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 1234}})
+	require.NoError(t, err)
+	c.WriteRTCP(DirectionOutbound, rr)
+	require.NoError(t, c.Stop())
+
+	packets, size := c.Stats()
+	require.Equal(t, int64(2), packets)
+	require.Equal(t, int64(len(pkt)+len(rr)), size)
+
+	b := buf.Bytes()
+	require.Equal(t, uint32(pcapMagic), binary.LittleEndian.Uint32(b[0:]))
+	require.Equal(t, uint32(pcapLinkTypeRaw), binary.LittleEndian.Uint32(b[20:]))
+
+	// first record, IPv4 and UDP headers before the packet
+	record := b[24:]
+	length := int(binary.LittleEndian.Uint32(record[8:]))
+	require.Equal(t, ipv4HeaderSize+udpHeaderSize+len(pkt), length)
+	ip := record[16 : 16+ipv4HeaderSize]
+	require.Equal(t, publisherAddr[:], ip[12:16])
+	require.Equal(t, sfuAddr[:], ip[16:20])
+	require.Equal(t, uint16(0), ipv4Checksum(ip))
+	require.Equal(t, pkt, record[16+ipv4HeaderSize+udpHeaderSize:16+length])
+
+	// second record goes to the publisher
+	record = record[16+length:]
+	ip = record[16 : 16+ipv4HeaderSize]
+	require.Equal(t, sfuAddr[:], ip[12:16])
+	require.Equal(t, rr, record[16+ipv4HeaderSize+udpHeaderSize:])
+
+	// dropped once stopped
+	c.WriteRTP(DirectionInbound, pkt)
+	packets, _ = c.Stats()
+	require.Equal(t, int64(2), packets)
+}
+
+func TestRTPDump(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := NewCapture(CaptureParams{Format: FormatRTPDump, Writer: &buf})
+	require.NoError(t, err)
+
+	pkt := testRTP(t, 1)
+	c.WriteRTP(DirectionInbound, pkt)
+	c.WriteRTCP(DirectionInbound, []byte{0x80, 0xc8, 0, 0})
+	require.NoError(t, c.Stop())
+
+	line, err := buf.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "#!rtpplay1.0 192.0.2.1/5004\n", line)
+	buf.Next(16)
+
+	b := buf.Bytes()
+	require.Equal(t, uint16(rtpDumpPacketHeaderSize+len(pkt)), binary.BigEndian.Uint16(b[0:]))
+	require.Equal(t, uint16(len(pkt)), binary.BigEndian.Uint16(b[2:]))
+	require.Equal(t, pkt, b[rtpDumpPacketHeaderSize:rtpDumpPacketHeaderSize+len(pkt)])
+
+	b = b[rtpDumpPacketHeaderSize+len(pkt):]
+	require.Equal(t, uint16(rtpDumpPacketHeaderSize+4), binary.BigEndian.Uint16(b[0:]))
+	require.Equal(t, uint16(0), binary.BigEndian.Uint16(b[2:]))
+}
+
+func TestCaptureMaxBytes(t *testing.T) {
+	var stopped []error
+	c, err := NewCapture(CaptureParams{
+		Writer:   &bytes.Buffer{},
+		MaxBytes: 40,
+		OnStopped: func(err error) {
+			stopped = append(stopped, err)
+		},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		c.WriteRTP(DirectionInbound, testRTP(t, uint16(i)))
+	}
+	packets, _ := c.Stats()
+	require.Equal(t, int64(3), packets)
+	require.Equal(t, []error{nil}, stopped)
+	require.NoError(t, c.Stop())
+
+	_, err = NewCapture(CaptureParams{Format: "mp4", Writer: &bytes.Buffer{}})
+	require.ErrorIs(t, err, ErrInvalidFormat)
+}
@@ -36,6 +36,9 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	dd "github.com/livekit/livekit-server/pkg/sfu/dependencydescriptor"
+	"github.com/livekit/livekit-server/pkg/sfu/packetcapture"
+	"github.com/livekit/livekit-server/pkg/sfu/streamtracker"
+	sutils "github.com/livekit/livekit-server/pkg/utils"
 )
 
 var (
@@ -63,6 +66,9 @@ type TrackReceiver interface {
 	GetAudioLevel() (float64, bool)
 
 	SendPLI(layer int32, force bool)
+	// replays the packets since the last key frame of the layer to a down track that has not started,
+	// false when there is none cached
+	ReplayKeyFrame(layer int32, track TrackSender) bool
 
 	SetUpTrackPaused(paused bool)
 	SetMaxExpectedSpatialLayer(layer int32)
@@ -87,13 +93,35 @@ type TrackReceiver interface {
 	GetReferenceLayerRTPTimestamp(ts uint32, layer int32, referenceLayer int32) (uint32, error)
 
 	GetTrackStats() *livekit.RTPStats
+
+	// true when the video is sent with a higher bit depth than the negotiated codec profile carries,
+	// subscribers cannot decode it
+	HasBitDepthMismatch() bool
+}
+
+// PayloadProcessor replaces the payloads of the packets of a track before they are forwarded, e.g. with
+// processed audio. It is called from the forwarding goroutine of the layer, the packet is forwarded as received
+// when it returns an error. Retransmissions are served from the received packets.
+type PayloadProcessor interface {
+	ProcessPayload(payload []byte) ([]byte, error)
+}
+
+// AsyncPayloadProcessor is a PayloadProcessor whose results arrive later, e.g. from a remote service. Packets are
+// handed over without blocking the forwarding goroutine, forward is called for each packet in the order they were
+// handed over, with an error when the packet is to be forwarded as received.
+type AsyncPayloadProcessor interface {
+	PayloadProcessor
+	ProcessPayloadAsync(payload []byte, forward func(payload []byte, err error))
 }
 
 // WebRTCReceiver receives a media track
 type WebRTCReceiver struct {
-	logger logger.Logger
+	logger    logger.Logger
+	resources *sutils.ResourceTracker
 
 	pliThrottleConfig config.PLIThrottleConfig
+	onKeyFrameRequest func(suppressed bool)
+	keyFrameThrottle  *KeyFrameThrottle
 	audioConfig       config.AudioConfig
 
 	trackID        livekit.TrackID
@@ -117,18 +145,38 @@ type WebRTCReceiver struct {
 	buffers  [buffer.DefaultMaxLayerSpatial + 1]*buffer.Buffer
 	upTracks [buffer.DefaultMaxLayerSpatial + 1]*webrtc.TrackRemote
 	rtt      uint32
+	capture  *packetcapture.Capture
+	// nil when packets are forwarded as received
+	payloadProcessor PayloadProcessor
 
 	lbThreshold int
+	fanOutPool  *FanOutPool
 
 	streamTrackerManager *StreamTrackerManager
 
 	downTrackSpreader *DownTrackSpreader
 
-	connectionStats *connectionquality.ConnectionStats
+	connectionStats  *connectionquality.ConnectionStats
+	connectionScorer connectionquality.Scorer
 
 	onStatsUpdate    func(w *WebRTCReceiver, stat *livekit.AnalyticsStat)
 	onMaxLayerChange func(maxLayer int32)
 
+	// 0 when key frames are not cached
+	keyFrameCacheSize int
+	// video packets kept for NACK, the size of the buffer pool when 0
+	packetBufferSize int
+	// packets are forwarded as received when the depth is 0
+	reorder buffer.ReorderParams
+	// down tracks waiting for the cached packets of a layer, replayed by the forwarding goroutine of the layer
+	keyFrameReplaysMu      sync.Mutex
+	keyFrameReplays        [buffer.DefaultMaxLayerSpatial + 1][]TrackSender
+	pendingKeyFrameReplays atomic.Int32
+
+	// nil when payload integrity checks are disabled
+	payloadIntegrity         *buffer.PayloadIntegrityParams
+	onPayloadIntegrityReport func(layer int32, report *buffer.PayloadIntegrityReport)
+
 	primaryReceiver atomic.Pointer[RedPrimaryReceiver]
 	redReceiver     atomic.Pointer[RedReceiver]
 	redPktWriter    func(pkt *buffer.ExtPacket, spatialLayer int32)
@@ -162,6 +210,15 @@ func WithPliThrottleConfig(pliThrottleConfig config.PLIThrottleConfig) ReceiverO
 	}
 }
 
+// WithOnKeyFrameRequest is called for every key frame request of the subscribers, suppressed is set
+// when the request was not sent to the publisher
+func WithOnKeyFrameRequest(fn func(suppressed bool)) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.onKeyFrameRequest = fn
+		return w
+	}
+}
+
 // WithAudioConfig sets up parameters for active speaker detection
 func WithAudioConfig(audioConfig config.AudioConfig) ReceiverOpts {
 	return func(w *WebRTCReceiver) *WebRTCReceiver {
@@ -190,6 +247,64 @@ func WithLoadBalanceThreshold(downTracks int) ReceiverOpts {
 	}
 }
 
+// WithFanOutPool writes packets with the pool instead of parallelizing per packet once down tracks exceed
+// the load balance threshold
+func WithFanOutPool(pool *FanOutPool) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.fanOutPool = pool
+		return w
+	}
+}
+
+// WithConnectionQualityScorer scores the connection quality of the track with the scorer instead of the default one
+func WithConnectionQualityScorer(scorer connectionquality.Scorer) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.connectionScorer = scorer
+		return w
+	}
+}
+
+// WithPayloadIntegrity checks the payloads of video packets, a key frame is requested when too many fail the checks
+func WithPayloadIntegrity(params *buffer.PayloadIntegrityParams) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.payloadIntegrity = params
+		return w
+	}
+}
+
+// WithKeyFrameCache keeps up to maxPackets packets of each video layer since its last key frame, replayed to new
+// down tracks so that they start without waiting for a key frame
+func WithKeyFrameCache(maxPackets int) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.keyFrameCacheSize = maxPackets
+		return w
+	}
+}
+
+// WithPacketBufferSize keeps packets video packets of each layer for NACK instead of the size of the buffer pool
+func WithPacketBufferSize(packets int) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.packetBufferSize = packets
+		return w
+	}
+}
+
+// WithReorder holds back the packets of each layer received after a gap in sequence numbers to forward them in order
+func WithReorder(params buffer.ReorderParams) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.reorder = params
+		return w
+	}
+}
+
+// WithResourceTracker counts the forwarding goroutines of the receiver
+func WithResourceTracker(resources *sutils.ResourceTracker) ReceiverOpts {
+	return func(w *WebRTCReceiver) *WebRTCReceiver {
+		w.resources = resources
+		return w
+	}
+}
+
 // NewWebRTCReceiver creates a new webrtc track receiver
 func NewWebRTCReceiver(
 	receiver *webrtc.RTPReceiver,
@@ -216,15 +331,18 @@ func NewWebRTCReceiver(
 		w = opt(w)
 	}
 	w.trackInfo.Store(proto.Clone(trackInfo).(*livekit.TrackInfo))
+	w.keyFrameThrottle = NewKeyFrameThrottle(w.pliThrottleConfig, w.onKeyFrameRequest)
 
 	w.downTrackSpreader = NewDownTrackSpreader(DownTrackSpreaderParams{
-		Threshold: w.lbThreshold,
-		Logger:    logger,
+		Threshold:  w.lbThreshold,
+		FanOutPool: w.fanOutPool,
+		Logger:     logger,
 	})
 
 	w.connectionStats = connectionquality.NewConnectionStats(connectionquality.ConnectionStatsParams{
 		MimeType:         w.codec.MimeType,
 		IsFECEnabled:     strings.EqualFold(w.codec.MimeType, webrtc.MimeTypeOpus) && strings.Contains(strings.ToLower(w.codec.SDPFmtpLine), "fec"),
+		Scorer:           w.connectionScorer,
 		ReceiverProvider: w,
 		Logger:           w.logger.WithValues("direction", "up"),
 	})
@@ -263,6 +381,27 @@ func (w *WebRTCReceiver) OnStatsUpdate(fn func(w *WebRTCReceiver, stat *livekit.
 	w.onStatsUpdate = fn
 }
 
+// OnPayloadIntegrityReport is called from the packet processing of the layer, fn must not block
+func (w *WebRTCReceiver) OnPayloadIntegrityReport(fn func(layer int32, report *buffer.PayloadIntegrityReport)) {
+	w.bufferMu.Lock()
+	w.onPayloadIntegrityReport = fn
+	w.bufferMu.Unlock()
+}
+
+func (w *WebRTCReceiver) getOnPayloadIntegrityReport() func(layer int32, report *buffer.PayloadIntegrityReport) {
+	w.bufferMu.RLock()
+	defer w.bufferMu.RUnlock()
+
+	return w.onPayloadIntegrityReport
+}
+
+// SetPayloadProcessor processes the payloads of the packets forwarded from now on, nil forwards them as received
+func (w *WebRTCReceiver) SetPayloadProcessor(p PayloadProcessor) {
+	w.bufferMu.Lock()
+	w.payloadProcessor = p
+	w.bufferMu.Unlock()
+}
+
 func (w *WebRTCReceiver) OnMaxLayerChange(fn func(maxLayer int32)) {
 	w.bufferMu.Lock()
 	w.onMaxLayerChange = fn
@@ -280,6 +419,10 @@ func (w *WebRTCReceiver) GetConnectionScoreAndQuality() (float32, livekit.Connec
 	return w.connectionStats.GetScoreAndQuality()
 }
 
+func (w *WebRTCReceiver) GetConnectionSubScores() (connectionquality.SubScores, bool) {
+	return w.connectionStats.GetSubScores()
+}
+
 func (w *WebRTCReceiver) IsClosed() bool {
 	return w.closed.Load()
 }
@@ -372,6 +515,27 @@ func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buff
 		buff.SetPLIThrottle(duration.Nanoseconds())
 	}
 
+	if w.Kind() == webrtc.RTPCodecTypeVideo && w.keyFrameCacheSize > 0 {
+		buff.SetKeyFrameCache(w.keyFrameCacheSize)
+	}
+
+	if w.Kind() == webrtc.RTPCodecTypeVideo && w.packetBufferSize > 0 {
+		buff.SetPacketBufferSize(w.packetBufferSize)
+	}
+
+	if w.reorder.Depth > 0 {
+		buff.SetReorder(w.reorder)
+	}
+
+	if w.Kind() == webrtc.RTPCodecTypeVideo && w.payloadIntegrity != nil {
+		buff.SetPayloadIntegrity(*w.payloadIntegrity)
+		buff.OnPayloadIntegrityReport(func(report *buffer.PayloadIntegrityReport) {
+			if onPayloadIntegrityReport := w.getOnPayloadIntegrityReport(); onPayloadIntegrityReport != nil {
+				onPayloadIntegrityReport(layer, report)
+			}
+		})
+	}
+
 	w.bufferMu.Lock()
 	if w.upTracks[layer] != nil {
 		w.bufferMu.Unlock()
@@ -380,16 +544,20 @@ func (w *WebRTCReceiver) AddUpTrack(track *webrtc.TrackRemote, buff *buffer.Buff
 	w.upTracks[layer] = track
 	w.buffers[layer] = buff
 	rtt := w.rtt
+	capture := w.capture
 	w.bufferMu.Unlock()
 
 	buff.SetRTT(rtt)
+	buff.SetPacketCapture(capture)
 	buff.SetPaused(w.streamTrackerManager.IsPaused())
 
 	if w.Kind() == webrtc.RTPCodecTypeVideo && w.useTrackers {
 		w.streamTrackerManager.AddTracker(layer)
 	}
 
-	go w.forwardRTP(layer)
+	w.resources.Go("receiver.forwardRTP", func() {
+		w.forwardRTP(layer)
+	})
 	return nil
 }
 
@@ -526,8 +694,72 @@ func (w *WebRTCReceiver) SendPLI(layer int32, force bool) {
 	if buff == nil {
 		return
 	}
+	if w.isSVC {
+		layer = 0
+	}
+
+	// paced here for all the layers of the track, the buffer does not throttle again
+	if w.keyFrameThrottle.Request(layer, force) {
+		buff.SendPLI(true)
+	}
+}
+
+func (w *WebRTCReceiver) ReplayKeyFrame(layer int32, track TrackSender) bool {
+	if w.keyFrameCacheSize <= 0 || w.closed.Load() {
+		return false
+	}
+	buff := w.getBuffer(layer)
+	if buff == nil || len(buff.KeyFramePackets()) == 0 {
+		return false
+	}
+	if w.isSVC {
+		layer = 0
+	}
+
+	w.keyFrameReplaysMu.Lock()
+	w.keyFrameReplays[layer] = append(w.keyFrameReplays[layer], track)
+	w.keyFrameReplaysMu.Unlock()
+	w.pendingKeyFrameReplays.Inc()
+	return true
+}
+
+// replayKeyFrames writes the cached packets that precede pkt to the down tracks waiting for them, before pkt is
+// forwarded, so that the down tracks get an unbroken run of packets from the key frame on
+func (w *WebRTCReceiver) replayKeyFrames(layer int32, buff *buffer.Buffer, pkt *buffer.ExtPacket) {
+	if w.pendingKeyFrameReplays.Load() == 0 {
+		return
+	}
 
-	buff.SendPLI(force)
+	w.keyFrameReplaysMu.Lock()
+	tracks := w.keyFrameReplays[layer]
+	w.keyFrameReplays[layer] = nil
+	w.keyFrameReplaysMu.Unlock()
+	if len(tracks) == 0 {
+		return
+	}
+	w.pendingKeyFrameReplays.Sub(int32(len(tracks)))
+
+	packets := buff.KeyFramePackets()
+	for _, track := range tracks {
+		if track.IsClosed() {
+			continue
+		}
+		for _, cached := range packets {
+			if cached.ExtSequenceNumber >= pkt.ExtSequenceNumber {
+				break
+			}
+			spatialLayer := layer
+			if cached.Spatial >= 0 {
+				spatialLayer = cached.Spatial
+			}
+			_ = track.WriteRTP(cached, spatialLayer)
+		}
+	}
+}
+
+// KeyFrameRequestStats returns the key frame requests of the subscribers of the track
+func (w *WebRTCReceiver) KeyFrameRequestStats() KeyFrameRequestStats {
+	return w.keyFrameThrottle.Stats()
 }
 
 func (w *WebRTCReceiver) getBuffer(layer int32) *buffer.Buffer {
@@ -551,6 +783,21 @@ func (w *WebRTCReceiver) getBufferLocked(layer int32) *buffer.Buffer {
 	return w.buffers[layer]
 }
 
+// SetPacketCapture writes the packets of all layers of the receiver to capture, including layers that are
+// published later. Capture stops when set to nil.
+func (w *WebRTCReceiver) SetPacketCapture(capture *packetcapture.Capture) {
+	w.bufferMu.Lock()
+	w.capture = capture
+	buffers := w.buffers
+	w.bufferMu.Unlock()
+
+	for _, buff := range buffers {
+		if buff != nil {
+			buff.SetPacketCapture(capture)
+		}
+	}
+}
+
 func (w *WebRTCReceiver) ReadRTP(buf []byte, layer uint8, sn uint16) (int, error) {
 	b := w.getBuffer(int32(layer))
 	if b == nil {
@@ -560,6 +807,18 @@ func (w *WebRTCReceiver) ReadRTP(buf []byte, layer uint8, sn uint16) (int, error
 	return b.GetPacket(buf, sn)
 }
 
+func (w *WebRTCReceiver) HasBitDepthMismatch() bool {
+	w.bufferMu.RLock()
+	defer w.bufferMu.RUnlock()
+
+	for _, buff := range w.buffers {
+		if buff != nil && buff.HasBitDepthMismatch() {
+			return true
+		}
+	}
+	return false
+}
+
 func (w *WebRTCReceiver) GetTrackStats() *livekit.RTPStats {
 	w.bufferMu.RLock()
 	defer w.bufferMu.RUnlock()
@@ -581,6 +840,24 @@ func (w *WebRTCReceiver) GetTrackStats() *livekit.RTPStats {
 	return buffer.AggregateRTPStats(stats)
 }
 
+// GetStreamTrackStats returns the stats of each layer received, keyed by SSRC
+func (w *WebRTCReceiver) GetStreamTrackStats() map[uint32]*livekit.RTPStats {
+	w.bufferMu.RLock()
+	defer w.bufferMu.RUnlock()
+
+	stats := make(map[uint32]*livekit.RTPStats, len(w.buffers))
+	for _, buff := range w.buffers {
+		if buff == nil {
+			continue
+		}
+
+		if bs := buff.GetStats(); bs != nil {
+			stats[buff.GetMediaSSRC()] = bs
+		}
+	}
+	return stats
+}
+
 func (w *WebRTCReceiver) GetAudioLevel() (float64, bool) {
 	if w.Kind() == webrtc.RTPCodecTypeVideo {
 		return 0, false
@@ -653,42 +930,79 @@ func (w *WebRTCReceiver) forwardRTP(layer int32) {
 		w.bufferMu.RLock()
 		buf := w.buffers[layer]
 		redPktWriter := w.redPktWriter
+		payloadProcessor := w.payloadProcessor
 		w.bufferMu.RUnlock()
 		pkt, err := buf.ReadExtended(pktBuf)
 		if err == io.EOF {
 			return
 		}
 
-		spatialTracker := tracker
-		spatialLayer := layer
-		if pkt.Spatial >= 0 {
-			// svc packet, dispatch to correct tracker
-			spatialLayer = pkt.Spatial
-			spatialTracker = w.streamTrackerManager.GetTracker(pkt.Spatial)
-			if spatialTracker == nil {
-				spatialTracker = w.streamTrackerManager.AddTracker(pkt.Spatial)
+		if payloadProcessor != nil && len(pkt.Packet.Payload) != 0 {
+			if async, ok := payloadProcessor.(AsyncPayloadProcessor); ok {
+				pkt = cloneExtPacket(pkt)
+				async.ProcessPayloadAsync(pkt.Packet.Payload, func(payload []byte, err error) {
+					if err == nil {
+						pkt.Packet.Payload = payload
+					}
+					w.forwardPacket(layer, tracker, buf, redPktWriter, pkt)
+				})
+				continue
+			}
+			if payload, err := payloadProcessor.ProcessPayload(pkt.Packet.Payload); err == nil {
+				pkt.Packet.Payload = payload
 			}
 		}
 
-		w.downTrackSpreader.Broadcast(func(dt TrackSender) {
-			_ = dt.WriteRTP(pkt, spatialLayer)
-		})
+		w.forwardPacket(layer, tracker, buf, redPktWriter, pkt)
+	}
+}
 
-		if redPktWriter != nil {
-			redPktWriter(pkt, spatialLayer)
+func (w *WebRTCReceiver) forwardPacket(
+	layer int32,
+	tracker streamtracker.StreamTrackerWorker,
+	buf *buffer.Buffer,
+	redPktWriter func(pkt *buffer.ExtPacket, spatialLayer int32),
+	pkt *buffer.ExtPacket,
+) {
+	w.replayKeyFrames(layer, buf, pkt)
+
+	spatialTracker := tracker
+	spatialLayer := layer
+	if pkt.Spatial >= 0 {
+		// svc packet, dispatch to correct tracker
+		spatialLayer = pkt.Spatial
+		spatialTracker = w.streamTrackerManager.GetTracker(pkt.Spatial)
+		if spatialTracker == nil {
+			spatialTracker = w.streamTrackerManager.AddTracker(pkt.Spatial)
 		}
+	}
 
-		if spatialTracker != nil {
-			spatialTracker.Observe(
-				pkt.Temporal,
-				len(pkt.RawPacket),
-				len(pkt.Packet.Payload),
-				pkt.Packet.Marker,
-				pkt.Packet.Timestamp,
-				pkt.DependencyDescriptor,
-			)
-		}
+	w.downTrackSpreader.Broadcast(func(dt TrackSender) {
+		_ = dt.WriteRTP(pkt, spatialLayer)
+	})
+
+	if redPktWriter != nil {
+		redPktWriter(pkt, spatialLayer)
 	}
+
+	if spatialTracker != nil {
+		spatialTracker.Observe(
+			pkt.Temporal,
+			len(pkt.RawPacket),
+			len(pkt.Packet.Payload),
+			pkt.Packet.Marker,
+			pkt.Packet.Timestamp,
+			pkt.DependencyDescriptor,
+		)
+	}
+}
+
+// cloneExtPacket copies a packet out of the read buffer of the forwarding goroutine, for packets forwarded later
+func cloneExtPacket(pkt *buffer.ExtPacket) *buffer.ExtPacket {
+	clone := *pkt
+	clone.Packet = pkt.Packet.Clone()
+	clone.RawPacket = append([]byte(nil), pkt.RawPacket...)
+	return &clone
 }
 
 // closeTracks close all tracks from Receiver
@@ -727,6 +1041,7 @@ func (w *WebRTCReceiver) DebugInfo() map[string]interface{} {
 	}
 	w.bufferMu.RUnlock()
 	info["UpTracks"] = upTrackInfo
+	info["KeyFrameRequests"] = w.keyFrameThrottle.Stats()
 
 	return info
 }
@@ -738,8 +1053,9 @@ func (w *WebRTCReceiver) GetPrimaryReceiverForRed() TrackReceiver {
 
 	if w.primaryReceiver.Load() == nil {
 		pr := NewRedPrimaryReceiver(w, DownTrackSpreaderParams{
-			Threshold: w.lbThreshold,
-			Logger:    w.logger,
+			Threshold:  w.lbThreshold,
+			FanOutPool: w.fanOutPool,
+			Logger:     w.logger,
 		})
 		if w.primaryReceiver.CompareAndSwap(nil, pr) {
 			w.bufferMu.Lock()
@@ -757,8 +1073,9 @@ func (w *WebRTCReceiver) GetRedReceiver() TrackReceiver {
 
 	if w.redReceiver.Load() == nil {
 		pr := NewRedReceiver(w, DownTrackSpreaderParams{
-			Threshold: w.lbThreshold,
-			Logger:    w.logger,
+			Threshold:  w.lbThreshold,
+			FanOutPool: w.fanOutPool,
+			Logger:     w.logger,
 		})
 		if w.redReceiver.CompareAndSwap(nil, pr) {
 			w.bufferMu.Lock()
@@ -23,8 +23,15 @@ import (
 	"testing"
 
 	"github.com/gammazero/workerpool"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/protocol/logger"
 )
 
 func TestWebRTCReceiver_OnCloseHandler(t *testing.T) {
@@ -52,6 +59,64 @@ func TestWebRTCReceiver_OnCloseHandler(t *testing.T) {
 	}
 }
 
+func TestWebRTCReceiver_ReplayKeyFrame(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, 1500)
+			return &b
+		},
+	}
+	vp8 := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+		PayloadType:        96,
+	}
+	buff := buffer.NewBuffer(123, pool, pool)
+	buff.OnRtcpFeedback(func(_ []rtcp.Packet) {})
+	buff.Bind(webrtc.RTPParameters{Codecs: []webrtc.RTPCodecParameters{vp8}}, vp8.RTPCodecCapability)
+	buff.SetKeyFrameCache(10)
+
+	w := &WebRTCReceiver{
+		kind:              webrtc.RTPCodecTypeVideo,
+		logger:            logger.GetLogger(),
+		keyFrameCacheSize: 10,
+	}
+	w.buffers[0] = buff
+
+	read := func(sn uint16, payload []byte) *buffer.ExtPacket {
+		pkt := &rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: sn, Timestamp: uint32(sn) * 3000, SSRC: 123},
+			Payload: payload,
+		}
+		buf, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = buff.Write(buf)
+		require.NoError(t, err)
+		ep, err := buff.ReadExtended(make([]byte, 1500))
+		require.NoError(t, err)
+		return ep
+	}
+
+	dt := &dummyDowntrack{TrackSender: &DownTrack{}}
+
+	// nothing to replay before the first key frame
+	read(1, []byte{0x10, 0x01, 0x01})
+	require.False(t, w.ReplayKeyFrame(0, dt))
+
+	read(2, []byte{0x10, 0x00, 0x01})
+	read(3, []byte{0x10, 0x01, 0x01})
+	require.True(t, w.ReplayKeyFrame(0, dt))
+
+	// replayed by the forwarding goroutine ahead of the next packet, which is forwarded as usual
+	w.replayKeyFrames(0, buff, read(4, []byte{0x10, 0x01, 0x01}))
+	require.Len(t, dt.receivedPkts, 2)
+	require.Equal(t, uint16(2), dt.receivedPkts[0].SequenceNumber)
+	require.Equal(t, uint16(3), dt.receivedPkts[1].SequenceNumber)
+
+	// once
+	w.replayKeyFrames(0, buff, read(5, []byte{0x10, 0x01, 0x01}))
+	require.Len(t, dt.receivedPkts, 2)
+}
+
 func BenchmarkWriteRTP(b *testing.B) {
 	cases := []int{1, 2, 5, 10, 100, 250, 500}
 	workers := runtime.NumCPU()
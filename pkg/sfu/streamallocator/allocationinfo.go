@@ -0,0 +1,200 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streamallocator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	allocationReasonTrackChanged            = "track_changed"
+	allocationReasonPriorityChanged         = "priority_changed"
+	allocationReasonChannelCapacityOverride = "channel_capacity_override"
+	allocationReasonChannelCapacityHint     = "channel_capacity_hint"
+	allocationReasonCongestionEstimate      = "congestion_estimate"
+	allocationReasonCongestionLoss          = "congestion_loss"
+	allocationReasonProbeDone               = "probe_done"
+	allocationReasonMediaProbe              = "media_probe"
+	allocationReasonAudioOnly               = "audio_only"
+
+	// MaxAllocationDecisions is the number of allocation decisions kept for introspection
+	MaxAllocationDecisions = 32
+
+	allocationInfoTimeout = time.Second
+)
+
+type AllocationLayer struct {
+	Spatial  int32 `json:"spatial"`
+	Temporal int32 `json:"temporal"`
+}
+
+func allocationLayer(layer buffer.VideoLayer) AllocationLayer {
+	return AllocationLayer{Spatial: layer.Spatial, Temporal: layer.Temporal}
+}
+
+// AllocationDecision is a change of the layer forwarded to a subscriber, or of whether it is paused
+// or deficient, along with what led to it
+type AllocationDecision struct {
+	At      time.Time       `json:"at"`
+	TrackID livekit.TrackID `json:"track_id"`
+	// what the allocation was made for, e.g. track_changed or congestion_loss
+	Reason string `json:"reason"`
+	// channel capacity available to the subscriber when the decision was made
+	ChannelCapacity    int64           `json:"channel_capacity"`
+	TargetLayer        AllocationLayer `json:"target_layer"`
+	BandwidthRequested int64           `json:"bandwidth_requested"`
+	PauseReason        string          `json:"pause_reason"`
+	IsDeficient        bool            `json:"is_deficient"`
+}
+
+type TrackAllocationInfo struct {
+	TrackID            livekit.TrackID       `json:"track_id"`
+	PublisherID        livekit.ParticipantID `json:"publisher_id"`
+	Source             string                `json:"source"`
+	IsManaged          bool                  `json:"is_managed"`
+	Priority           uint8                 `json:"priority"`
+	SubscriberRank     uint32                `json:"subscriber_rank,omitempty"`
+	StreamState        string                `json:"stream_state"`
+	TargetLayer        AllocationLayer       `json:"target_layer"`
+	MaxLayer           AllocationLayer       `json:"max_layer"`
+	BandwidthRequested int64                 `json:"bandwidth_requested"`
+	DistanceToDesired  float64               `json:"distance_to_desired"`
+	PauseReason        string                `json:"pause_reason"`
+	IsDeficient        bool                  `json:"is_deficient"`
+}
+
+// AllocationInfo is the bandwidth estimate of a subscriber and how it is allocated to its video tracks
+type AllocationInfo struct {
+	State                     string                 `json:"state"`
+	LastReceivedEstimate      int64                  `json:"last_received_estimate"`
+	CommittedChannelCapacity  int64                  `json:"committed_channel_capacity"`
+	OverriddenChannelCapacity int64                  `json:"overridden_channel_capacity,omitempty"`
+	ExpectedBandwidthUsage    int64                  `json:"expected_bandwidth_usage"`
+	IsProbing                 bool                   `json:"is_probing"`
+	AudioOnly                 bool                   `json:"audio_only,omitempty"`
+	EstimatorStats            map[string]interface{} `json:"estimator_stats,omitempty"`
+	Tracks                    []TrackAllocationInfo  `json:"tracks"`
+	// latest decisions last
+	Decisions []AllocationDecision `json:"decisions"`
+}
+
+type allocationInfoRequest struct {
+	maxDecisions int
+	result       chan *AllocationInfo
+}
+
+// GetAllocationInfo returns the current allocation and up to maxDecisions of the latest allocation decisions.
+// It returns nil if the allocator is stopped or does not answer in time.
+func (s *StreamAllocator) GetAllocationInfo(maxDecisions int) *AllocationInfo {
+	if s.isStopped.Load() {
+		return nil
+	}
+
+	req := allocationInfoRequest{
+		maxDecisions: maxDecisions,
+		result:       make(chan *AllocationInfo, 1),
+	}
+	s.postEvent(Event{
+		Signal: streamAllocatorSignalGetAllocationInfo,
+		Data:   req,
+	})
+
+	select {
+	case info := <-req.result:
+		return info
+	case <-time.After(allocationInfoTimeout):
+		return nil
+	}
+}
+
+func (s *StreamAllocator) handleSignalGetAllocationInfo(event *Event) {
+	req := event.Data.(allocationInfoRequest)
+
+	info := &AllocationInfo{
+		State:                     s.state.String(),
+		LastReceivedEstimate:      s.lastReceivedEstimate,
+		CommittedChannelCapacity:  s.committedChannelCapacity,
+		OverriddenChannelCapacity: s.overriddenChannelCapacity,
+		AudioOnly:                 s.audioOnly,
+		ExpectedBandwidthUsage:    s.getExpectedBandwidthUsage(),
+		IsProbing:                 s.probeController.IsInProbe(),
+		Decisions:                 s.getDecisions(req.maxDecisions),
+	}
+	if s.bwe != nil {
+		info.EstimatorStats = s.bwe.GetStats()
+	}
+	for _, track := range s.getTracks() {
+		allocation := track.Allocation()
+		info.Tracks = append(info.Tracks, TrackAllocationInfo{
+			TrackID:            track.ID(),
+			PublisherID:        track.PublisherID(),
+			Source:             track.source.String(),
+			IsManaged:          track.IsManaged(),
+			Priority:           track.Priority(),
+			SubscriberRank:     track.SubscriberRank(),
+			StreamState:        track.streamState.String(),
+			TargetLayer:        allocationLayer(allocation.TargetLayer),
+			MaxLayer:           allocationLayer(track.maxLayer),
+			BandwidthRequested: track.BandwidthRequested(),
+			DistanceToDesired:  track.DistanceToDesired(),
+			PauseReason:        allocation.PauseReason.String(),
+			IsDeficient:        track.IsDeficient(),
+		})
+	}
+
+	sort.Slice(info.Tracks, func(i, j int) bool {
+		return info.Tracks[i].TrackID < info.Tracks[j].TrackID
+	})
+
+	req.result <- info
+}
+
+func (s *StreamAllocator) recordDecision(trackID livekit.TrackID, allocation sfu.VideoAllocation) {
+	decision := AllocationDecision{
+		At:                 time.Now(),
+		TrackID:            trackID,
+		Reason:             s.allocationReason,
+		ChannelCapacity:    s.getAvailableChannelCapacity(true),
+		TargetLayer:        allocationLayer(allocation.TargetLayer),
+		BandwidthRequested: allocation.BandwidthRequested,
+		PauseReason:        allocation.PauseReason.String(),
+		IsDeficient:        allocation.IsDeficient,
+	}
+	if len(s.decisions) < MaxAllocationDecisions {
+		s.decisions = append(s.decisions, decision)
+		return
+	}
+	s.decisions[s.decisionsHead] = decision
+	s.decisionsHead = (s.decisionsHead + 1) % MaxAllocationDecisions
+}
+
+func (s *StreamAllocator) getDecisions(maxDecisions int) []AllocationDecision {
+	n := len(s.decisions)
+	if maxDecisions <= 0 || maxDecisions > n {
+		maxDecisions = n
+	}
+
+	decisions := make([]AllocationDecision, 0, maxDecisions)
+	for i := n - maxDecisions; i < n; i++ {
+		decisions = append(decisions, s.decisions[(s.decisionsHead+i)%n])
+	}
+	return decisions
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streamallocator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func TestAllocationInfo(t *testing.T) {
+	s := NewStreamAllocator(StreamAllocatorParams{
+		Config:                 config.DefaultConfig.RTC.CongestionControl,
+		InitialChannelCapacity: 1_000_000,
+		Logger:                 logger.GetLogger(),
+	})
+	s.Start()
+	defer s.Stop()
+
+	info := s.GetAllocationInfo(0)
+	require.NotNil(t, info)
+	require.Equal(t, "STABLE", info.State)
+	require.Equal(t, int64(1_000_000), info.CommittedChannelCapacity)
+	require.Empty(t, info.Tracks)
+	require.Empty(t, info.Decisions)
+
+	s.Stop()
+	require.Nil(t, s.GetAllocationInfo(0))
+}
+
+func TestAllocationDecisions(t *testing.T) {
+	s := NewStreamAllocator(StreamAllocatorParams{
+		Config: config.DefaultConfig.RTC.CongestionControl,
+		Logger: logger.GetLogger(),
+	})
+
+	s.allocationReason = allocationReasonCongestionLoss
+	for i := 0; i < MaxAllocationDecisions+5; i++ {
+		s.recordDecision(livekit.TrackID(fmt.Sprintf("TR_%d", i)), sfu.VideoAllocation{
+			PauseReason: sfu.VideoPauseReasonBandwidth,
+			TargetLayer: buffer.VideoLayer{Spatial: 1, Temporal: 2},
+		})
+	}
+
+	// oldest decisions are dropped, latest last
+	decisions := s.getDecisions(0)
+	require.Len(t, decisions, MaxAllocationDecisions)
+	require.Equal(t, livekit.TrackID("TR_5"), decisions[0].TrackID)
+	require.Equal(t, livekit.TrackID(fmt.Sprintf("TR_%d", MaxAllocationDecisions+4)), decisions[len(decisions)-1].TrackID)
+
+	decisions = s.getDecisions(3)
+	require.Len(t, decisions, 3)
+	require.Equal(t, livekit.TrackID(fmt.Sprintf("TR_%d", MaxAllocationDecisions+2)), decisions[0].TrackID)
+	require.Equal(t, allocationReasonCongestionLoss, decisions[0].Reason)
+	require.Equal(t, AllocationLayer{Spatial: 1, Temporal: 2}, decisions[0].TargetLayer)
+	require.Equal(t, sfu.VideoPauseReasonBandwidth.String(), decisions[0].PauseReason)
+}
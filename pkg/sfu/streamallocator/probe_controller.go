@@ -44,6 +44,7 @@ type ProbeController struct {
 	probeTrendObserved        bool
 	probeEndTime              time.Time
 	probeDuration             time.Duration
+	cooldownEndTime           time.Time
 }
 
 func NewProbeController(params ProbeControllerParams) *ProbeController {
@@ -207,10 +208,18 @@ func (p *ProbeController) InitProbe(probeGoalDeltaBps int64, expectedBandwidthUs
 
 	// overshoot a bit to account for noise (in measurement/estimate etc)
 	desiredIncreaseBps := (probeGoalDeltaBps * p.params.Config.OveragePct) / 100
+	if p.params.Config.MaxPaddingRatio > 0 {
+		if maxIncreaseBps := int64(float64(expectedBandwidthUsage) * p.params.Config.MaxPaddingRatio); desiredIncreaseBps > maxIncreaseBps {
+			desiredIncreaseBps = maxIncreaseBps
+		}
+	}
 	if desiredIncreaseBps < p.params.Config.MinBps {
 		desiredIncreaseBps = p.params.Config.MinBps
 	}
 	p.probeGoalBps = expectedBandwidthUsage + desiredIncreaseBps
+	if p.params.Config.MaxBps > 0 && p.probeGoalBps > p.params.Config.MaxBps {
+		p.probeGoalBps = p.params.Config.MaxBps
+	}
 
 	p.doneProbeClusterInfo = ProbeClusterInfo{Id: ProbeClusterIdInvalid}
 	p.abortedProbeClusterId = ProbeClusterIdInvalid
@@ -220,6 +229,12 @@ func (p *ProbeController) InitProbe(probeGoalDeltaBps int64, expectedBandwidthUs
 
 	p.probeEndTime = time.Time{}
 
+	if p.probeGoalBps <= expectedBandwidthUsage {
+		// already at the maximum probe bitrate
+		p.probeClusterId = ProbeClusterIdInvalid
+		return p.probeClusterId, p.probeGoalBps
+	}
+
 	p.probeClusterId = p.params.Prober.AddCluster(
 		ProbeClusterModeUniform,
 		int(p.probeGoalBps),
@@ -276,6 +291,18 @@ func (p *ProbeController) abortProbeLocked() {
 	p.StopProbe()
 }
 
+// StartCooldown holds off probing for the configured loss cooldown
+func (p *ProbeController) StartCooldown() {
+	if p.params.Config.LossCooldown <= 0 {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.cooldownEndTime = time.Now().Add(p.params.Config.LossCooldown)
+}
+
 func (p *ProbeController) IsInProbe() bool {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -291,7 +318,8 @@ func (p *ProbeController) CanProbe() bool {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 
-	return time.Since(p.lastProbeStartTime) >= p.probeInterval && p.probeClusterId == ProbeClusterIdInvalid
+	now := time.Now()
+	return now.Sub(p.lastProbeStartTime) >= p.probeInterval && !now.Before(p.cooldownEndTime) && p.probeClusterId == ProbeClusterIdInvalid
 }
 
 // ------------------------------------------------
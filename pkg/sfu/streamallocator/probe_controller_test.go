@@ -0,0 +1,89 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streamallocator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/protocol/logger"
+)
+
+func newTestProbeController(t *testing.T, update func(conf *config.CongestionControlProbeConfig)) *ProbeController {
+	conf := config.DefaultConfig.RTC.CongestionControl.ProbeConfig
+	conf.BaseInterval = 0
+	update(&conf)
+
+	prober := NewProber(ProberParams{Logger: logger.GetLogger()})
+	t.Cleanup(prober.Reset)
+	return NewProbeController(ProbeControllerParams{
+		Config: conf,
+		Prober: prober,
+		Logger: logger.GetLogger(),
+	})
+}
+
+func TestProbeControllerGoal(t *testing.T) {
+	t.Run("padding ratio", func(t *testing.T) {
+		p := newTestProbeController(t, func(conf *config.CongestionControlProbeConfig) {
+			conf.MaxPaddingRatio = 0.5
+		})
+		id, goal := p.InitProbe(2_000_000, 1_000_000)
+		require.NotEqual(t, ProbeClusterIdInvalid, id)
+		require.Equal(t, int64(1_500_000), goal)
+	})
+
+	t.Run("padding ratio does not go below minimum", func(t *testing.T) {
+		p := newTestProbeController(t, func(conf *config.CongestionControlProbeConfig) {
+			conf.MaxPaddingRatio = 0.1
+		})
+		_, goal := p.InitProbe(2_000_000, 100_000)
+		require.Equal(t, int64(100_000+p.params.Config.MinBps), goal)
+	})
+
+	t.Run("max bitrate", func(t *testing.T) {
+		p := newTestProbeController(t, func(conf *config.CongestionControlProbeConfig) {
+			conf.MaxBps = 1_200_000
+		})
+		id, goal := p.InitProbe(2_000_000, 1_000_000)
+		require.NotEqual(t, ProbeClusterIdInvalid, id)
+		require.Equal(t, int64(1_200_000), goal)
+		p.StopProbe()
+		p.Reset()
+
+		// already at the maximum
+		id, _ = p.InitProbe(2_000_000, 1_200_000)
+		require.Equal(t, ProbeClusterIdInvalid, id)
+		require.False(t, p.IsInProbe())
+	})
+}
+
+func TestProbeControllerLossCooldown(t *testing.T) {
+	p := newTestProbeController(t, func(conf *config.CongestionControlProbeConfig) {
+		conf.LossCooldown = 100 * time.Millisecond
+	})
+	require.True(t, p.CanProbe())
+
+	p.StartCooldown()
+	require.False(t, p.CanProbe())
+
+	// a reset does not cut the cooldown short
+	p.Reset()
+	require.False(t, p.CanProbe())
+	require.Eventually(t, p.CanProbe, time.Second, 10*time.Millisecond)
+}
@@ -85,8 +85,11 @@ const (
 	streamAllocatorSignalResume
 	streamAllocatorSignalSetAllowPause
 	streamAllocatorSignalSetChannelCapacity
+	streamAllocatorSignalSetChannelCapacityHint
 	streamAllocatorSignalNACK
 	streamAllocatorSignalRTCPReceiverReport
+	streamAllocatorSignalGetAllocationInfo
+	streamAllocatorSignalGetCongestionTrace
 )
 
 func (s streamAllocatorSignal) String() string {
@@ -111,10 +114,16 @@ func (s streamAllocatorSignal) String() string {
 		return "SET_ALLOW_PAUSE"
 	case streamAllocatorSignalSetChannelCapacity:
 		return "SET_CHANNEL_CAPACITY"
+	case streamAllocatorSignalSetChannelCapacityHint:
+		return "SET_CHANNEL_CAPACITY_HINT"
 	case streamAllocatorSignalNACK:
 		return "NACK"
 	case streamAllocatorSignalRTCPReceiverReport:
 		return "RTCP_RECEIVER_REPORT"
+	case streamAllocatorSignalGetAllocationInfo:
+		return "GET_ALLOCATION_INFO"
+	case streamAllocatorSignalGetCongestionTrace:
+		return "GET_CONGESTION_TRACE"
 	default:
 		return fmt.Sprintf("%d", int(s))
 	}
@@ -136,13 +145,16 @@ func (e Event) String() string {
 
 type StreamAllocatorParams struct {
 	Config config.CongestionControlConfig
-	Logger logger.Logger
+	// seeds the committed channel capacity until the first estimate is received, 0 to start without one
+	InitialChannelCapacity int64
+	Logger                 logger.Logger
 }
 
 type StreamAllocator struct {
 	params StreamAllocatorParams
 
 	onStreamStateChange func(update *StreamStateUpdate) error
+	onAudioOnlyChange   func(audioOnly bool)
 
 	bwe cc.BandwidthEstimator
 
@@ -166,6 +178,15 @@ type StreamAllocator struct {
 
 	state streamAllocatorState
 
+	// all video is paused while the channel capacity is too low, see config.CongestionControlAudioOnlyConfig
+	audioOnly           bool
+	audioOnlyRecoveryAt time.Time
+
+	allocationReason string
+	decisions        []AllocationDecision
+	decisionsHead    int
+	trace            *congestionTrace
+
 	eventsQueue *utils.OpsQueue
 
 	isStopped atomic.Bool
@@ -173,13 +194,15 @@ type StreamAllocator struct {
 
 func NewStreamAllocator(params StreamAllocatorParams) *StreamAllocator {
 	s := &StreamAllocator{
-		params:     params,
-		allowPause: params.Config.AllowPause,
+		params:                   params,
+		allowPause:               params.Config.AllowPause,
+		committedChannelCapacity: params.InitialChannelCapacity,
 		prober: NewProber(ProberParams{
 			Logger: params.Logger,
 		}),
 		rateMonitor: NewRateMonitor(),
 		videoTracks: make(map[livekit.TrackID]*Track),
+		trace:       newCongestionTrace(params.Config.Trace),
 		eventsQueue: utils.NewOpsQueue("stream-allocator", 64, true),
 	}
 
@@ -215,6 +238,12 @@ func (s *StreamAllocator) OnStreamStateChange(f func(update *StreamStateUpdate)
 	s.onStreamStateChange = f
 }
 
+// OnAudioOnlyChange sets the callback fired when all video of the subscriber is paused
+// because of a collapsed channel capacity, and when video resumes
+func (s *StreamAllocator) OnAudioOnlyChange(f func(audioOnly bool)) {
+	s.onAudioOnlyChange = f
+}
+
 func (s *StreamAllocator) SetBandwidthEstimator(bwe cc.BandwidthEstimator) {
 	if bwe != nil {
 		bwe.OnTargetBitrateChange(s.onTargetBitrateChange)
@@ -227,6 +256,8 @@ type AddTrackParams struct {
 	Priority    uint8
 	IsSimulcast bool
 	PublisherID livekit.ParticipantID
+	// rank the subscriber gave the track, 1 being its most important track, 0 when not ranked
+	SubscriberRank uint32
 }
 
 func (s *StreamAllocator) AddTrack(downTrack *sfu.DownTrack, params AddTrackParams) {
@@ -236,6 +267,7 @@ func (s *StreamAllocator) AddTrack(downTrack *sfu.DownTrack, params AddTrackPara
 
 	track := NewTrack(downTrack, params.Source, params.IsSimulcast, params.PublisherID, s.params.Logger)
 	track.SetPriority(params.Priority)
+	track.SetSubscriberRank(params.SubscriberRank)
 
 	s.videoTracksMu.Lock()
 	s.videoTracks[livekit.TrackID(downTrack.ID())] = track
@@ -278,6 +310,21 @@ func (s *StreamAllocator) SetTrackPriority(downTrack *sfu.DownTrack, priority ui
 	s.videoTracksMu.Unlock()
 }
 
+// SetTrackSubscriberRank sets the rank the subscriber gave a track, tracks are reallocated when it changes
+func (s *StreamAllocator) SetTrackSubscriberRank(downTrack *sfu.DownTrack, rank uint32) {
+	s.videoTracksMu.Lock()
+	if track := s.videoTracks[livekit.TrackID(downTrack.ID())]; track != nil {
+		changed := track.SetSubscriberRank(rank)
+		if changed && !s.isAllocateAllPending {
+			s.isAllocateAllPending = true
+			s.postEvent(Event{
+				Signal: streamAllocatorSignalAllocateAllTracks,
+			})
+		}
+	}
+	s.videoTracksMu.Unlock()
+}
+
 func (s *StreamAllocator) SetAllowPause(allowPause bool) {
 	s.postEvent(Event{
 		Signal: streamAllocatorSignalSetAllowPause,
@@ -292,6 +339,15 @@ func (s *StreamAllocator) SetChannelCapacity(channelCapacity int64) {
 	})
 }
 
+// SetChannelCapacityHint re-seeds the committed channel capacity, for e.g. a client which reports a new bandwidth
+// hint when it reconnects. Unlike an override, the estimate takes over again on the next commit.
+func (s *StreamAllocator) SetChannelCapacityHint(channelCapacity int64) {
+	s.postEvent(Event{
+		Signal: streamAllocatorSignalSetChannelCapacityHint,
+		Data:   channelCapacity,
+	})
+}
+
 func (s *StreamAllocator) resetState() {
 	s.channelObserver = s.newChannelObserverNonProbe()
 	s.probeController.Reset()
@@ -588,10 +644,16 @@ func (s *StreamAllocator) handleEvent(event *Event) {
 		s.handleSignalSetAllowPause(event)
 	case streamAllocatorSignalSetChannelCapacity:
 		s.handleSignalSetChannelCapacity(event)
+	case streamAllocatorSignalSetChannelCapacityHint:
+		s.handleSignalSetChannelCapacityHint(event)
 	case streamAllocatorSignalNACK:
 		s.handleSignalNACK(event)
 	case streamAllocatorSignalRTCPReceiverReport:
 		s.handleSignalRTCPReceiverReport(event)
+	case streamAllocatorSignalGetAllocationInfo:
+		s.handleSignalGetAllocationInfo(event)
+	case streamAllocatorSignalGetCongestionTrace:
+		s.handleSignalGetCongestionTrace(event)
 	}
 }
 
@@ -604,6 +666,7 @@ func (s *StreamAllocator) handleSignalAllocateTrack(event *Event) {
 	s.videoTracksMu.Unlock()
 
 	if track != nil {
+		s.allocationReason = allocationReasonTrackChanged
 		s.allocateTrack(track)
 	}
 }
@@ -614,6 +677,7 @@ func (s *StreamAllocator) handleSignalAllocateAllTracks(event *Event) {
 	s.videoTracksMu.Unlock()
 
 	if s.state == streamAllocatorStateDeficient {
+		s.allocationReason = allocationReasonPriorityChanged
 		s.allocateAllTracks()
 	}
 }
@@ -626,6 +690,9 @@ func (s *StreamAllocator) handleSignalEstimate(event *Event) {
 	receivedEstimate, _ := event.Data.(int64)
 	s.lastReceivedEstimate = receivedEstimate
 	s.monitorRate(receivedEstimate)
+	if s.trace.isChannelSampleDue() {
+		s.trace.recordChannel(receivedEstimate, s.committedChannelCapacity, s.getExpectedBandwidthUsage())
+	}
 
 	// while probing, maintain estimate separately to enable keeping current committed estimate if probe fails
 	if s.probeController.IsInProbe() {
@@ -633,6 +700,8 @@ func (s *StreamAllocator) handleSignalEstimate(event *Event) {
 	} else {
 		s.handleNewEstimateInNonProbe()
 	}
+
+	s.maybeExitAudioOnly()
 }
 
 func (s *StreamAllocator) handleSignalPeriodicPing(event *Event) {
@@ -647,6 +716,8 @@ func (s *StreamAllocator) handleSignalPeriodicPing(event *Event) {
 		s.onProbeDone(isNotFailing, isGoalReached)
 	}
 
+	s.maybeExitAudioOnly()
+
 	// probe if necessary and timing is right
 	if s.state == streamAllocatorStateDeficient {
 		s.maybeProbe()
@@ -703,12 +774,30 @@ func (s *StreamAllocator) handleSignalSetChannelCapacity(event *Event) {
 	s.overriddenChannelCapacity = event.Data.(int64)
 	if s.overriddenChannelCapacity > 0 {
 		s.params.Logger.Infow("allocating on override channel capacity", "override", s.overriddenChannelCapacity)
+		s.allocationReason = allocationReasonChannelCapacityOverride
 		s.allocateAllTracks()
 	} else {
 		s.params.Logger.Infow("clearing override channel capacity")
 	}
 }
 
+func (s *StreamAllocator) handleSignalSetChannelCapacityHint(event *Event) {
+	channelCapacity := event.Data.(int64)
+	if channelCapacity <= 0 || channelCapacity == s.committedChannelCapacity {
+		return
+	}
+
+	s.params.Logger.Infow(
+		"allocating on channel capacity hint",
+		"old(bps)", s.committedChannelCapacity,
+		"new(bps)", channelCapacity,
+	)
+	s.committedChannelCapacity = channelCapacity
+	s.allocationReason = allocationReasonChannelCapacityHint
+	s.trace.recordCommit(s.allocationReason, s.lastReceivedEstimate, s.committedChannelCapacity, s.getExpectedBandwidthUsage())
+	s.allocateAllTracks()
+}
+
 func (s *StreamAllocator) handleSignalNACK(event *Event) {
 	nackInfos := event.Data.([]sfu.NackInfo)
 
@@ -740,6 +829,7 @@ func (s *StreamAllocator) setState(state streamAllocatorState) {
 
 	s.params.Logger.Infow("stream allocator: state change", "from", s.state, "to", state)
 	s.state = state
+	s.trace.recordState(state)
 
 	// reset probe to enforce a delay after state change before probing
 	s.probeController.Reset()
@@ -770,7 +860,10 @@ func (s *StreamAllocator) handleNewEstimateInProbe() {
 	s.channelObserver.AddEstimate(s.lastReceivedEstimate)
 	s.channelObserver.AddNack(packetDelta, repeatedNackDelta)
 
-	trend, _ := s.channelObserver.GetTrend()
+	trend, reason := s.channelObserver.GetTrend()
+	if trend == ChannelTrendCongesting && reason == ChannelCongestionReasonLoss {
+		s.probeController.StartCooldown()
+	}
 	s.probeController.CheckProbe(trend, s.channelObserver.GetHighestEstimate())
 }
 
@@ -789,6 +882,7 @@ func (s *StreamAllocator) handleNewEstimateInNonProbe() {
 	expectedBandwidthUsage := s.getExpectedBandwidthUsage()
 	switch reason {
 	case ChannelCongestionReasonLoss:
+		s.probeController.StartCooldown()
 		estimateToCommit = int64(float64(expectedBandwidthUsage) * (1.0 - s.params.Config.NackRatioAttenuator*s.channelObserver.GetNackRatio()))
 	default:
 		estimateToCommit = s.lastReceivedEstimate
@@ -833,18 +927,103 @@ func (s *StreamAllocator) handleNewEstimateInNonProbe() {
 	// reset probe to ensure it does not start too soon after a downward trend
 	s.probeController.Reset()
 
+	if reason == ChannelCongestionReasonLoss {
+		s.allocationReason = allocationReasonCongestionLoss
+	} else {
+		s.allocationReason = allocationReasonCongestionEstimate
+	}
+	s.trace.recordCommit(s.allocationReason, s.lastReceivedEstimate, s.committedChannelCapacity, expectedBandwidthUsage)
+	s.maybeEnterAudioOnly()
+	s.allocateAllTracks()
+}
+
+func (s *StreamAllocator) maybeEnterAudioOnly() {
+	audioOnlyConfig := s.params.Config.AudioOnly
+	if !s.params.Config.Enabled || !audioOnlyConfig.Enabled || s.audioOnly || s.committedChannelCapacity >= audioOnlyConfig.Threshold {
+		return
+	}
+
+	s.params.Logger.Infow(
+		"stream allocator: channel capacity too low, pausing all video",
+		"committed(bps)", s.committedChannelCapacity,
+		"threshold(bps)", audioOnlyConfig.Threshold,
+	)
+	s.setAudioOnly(true)
+}
+
+// maybeExitAudioOnly resumes video once the estimate has stayed at or above the resume threshold long enough,
+// so that video does not flap on and off on a channel hovering around the threshold
+func (s *StreamAllocator) maybeExitAudioOnly() {
+	if !s.audioOnly {
+		return
+	}
+
+	audioOnlyConfig := s.params.Config.AudioOnly
+	if s.lastReceivedEstimate < audioOnlyConfig.ResumeThreshold {
+		s.audioOnlyRecoveryAt = time.Time{}
+		return
+	}
+	if s.audioOnlyRecoveryAt.IsZero() {
+		s.audioOnlyRecoveryAt = time.Now()
+	}
+	if time.Since(s.audioOnlyRecoveryAt) < audioOnlyConfig.ResumeAfter {
+		return
+	}
+
+	s.params.Logger.Infow(
+		"stream allocator: channel capacity recovered, resuming video",
+		"estimate(bps)", s.lastReceivedEstimate,
+		"resumeThreshold(bps)", audioOnlyConfig.ResumeThreshold,
+	)
+	if s.committedChannelCapacity < s.lastReceivedEstimate {
+		s.committedChannelCapacity = s.lastReceivedEstimate
+	}
+	// a fresh channel observer to not act on samples from before the recovery
+	s.channelObserver = s.newChannelObserverNonProbe()
+	s.setAudioOnly(false)
+
+	s.allocationReason = allocationReasonAudioOnly
+	s.trace.recordCommit(s.allocationReason, s.lastReceivedEstimate, s.committedChannelCapacity, s.getExpectedBandwidthUsage())
 	s.allocateAllTracks()
 }
 
+func (s *StreamAllocator) setAudioOnly(audioOnly bool) {
+	s.audioOnly = audioOnly
+	s.audioOnlyRecoveryAt = time.Time{}
+	if s.onAudioOnlyChange != nil {
+		s.onAudioOnlyChange(audioOnly)
+	}
+}
+
+// pauseAllTracks pauses every video track in audio only mode
+func (s *StreamAllocator) pauseAllTracks() {
+	update := NewStreamStateUpdate()
+	for _, track := range s.getTracks() {
+		allocation := track.Pause()
+		s.updateStreamStateChange(track, allocation, update)
+	}
+	s.maybeSendUpdate(update)
+
+	s.adjustState()
+}
+
 func (s *StreamAllocator) allocateTrack(track *Track) {
 	// abort any probe that may be running when a track specific change needs allocation
 	s.probeController.AbortProbe()
 
+	if s.audioOnly {
+		update := NewStreamStateUpdate()
+		s.updateStreamStateChange(track, track.Pause(), update)
+		s.maybeSendUpdate(update)
+		s.adjustState()
+		return
+	}
+
 	// if not deficient, free pass allocate track
 	if !s.params.Config.Enabled || s.state == streamAllocatorStateStable || !track.IsManaged() {
 		update := NewStreamStateUpdate()
 		allocation := track.AllocateOptimal(FlagAllowOvershootWhileOptimal)
-		updateStreamStateChange(track, allocation, update)
+		s.updateStreamStateChange(track, allocation, update)
 		s.maybeSendUpdate(update)
 		return
 	}
@@ -876,7 +1055,7 @@ func (s *StreamAllocator) allocateTrack(track *Track) {
 		allocation := track.ProvisionalAllocateCommit()
 
 		update := NewStreamStateUpdate()
-		updateStreamStateChange(track, allocation, update)
+		s.updateStreamStateChange(track, allocation, update)
 		s.maybeSendUpdate(update)
 
 		s.adjustState()
@@ -932,7 +1111,7 @@ func (s *StreamAllocator) allocateTrack(track *Track) {
 				// found layer that can fit in available headroom, take it if it is better than existing
 				update := NewStreamStateUpdate()
 				allocation := track.ProvisionalAllocateCommit()
-				updateStreamStateChange(track, allocation, update)
+				s.updateStreamStateChange(track, allocation, update)
 				s.maybeSendUpdate(update)
 			}
 
@@ -970,7 +1149,7 @@ func (s *StreamAllocator) allocateTrack(track *Track) {
 		// commit the tracks that contributed
 		for _, t := range contributingTracks {
 			allocation := t.ProvisionalAllocateCommit()
-			updateStreamStateChange(t, allocation, update)
+			s.updateStreamStateChange(t, allocation, update)
 		}
 
 		// STREAM-ALLOCATOR-TODO if got too much extra, can potentially give it to some deficient track
@@ -979,11 +1158,11 @@ func (s *StreamAllocator) allocateTrack(track *Track) {
 	// commit the track that needs change if enough could be acquired or pause not allowed
 	if !s.allowPause || bandwidthAcquired >= transition.BandwidthDelta {
 		allocation := track.ProvisionalAllocateCommit()
-		updateStreamStateChange(track, allocation, update)
+		s.updateStreamStateChange(track, allocation, update)
 	} else {
 		// explicitly pause to ensure stream state update happens if a track coming out of mute cannot be allocated
 		allocation := track.Pause()
-		updateStreamStateChange(track, allocation, update)
+		s.updateStreamStateChange(track, allocation, update)
 	}
 
 	s.maybeSendUpdate(update)
@@ -1015,18 +1194,25 @@ func (s *StreamAllocator) onProbeDone(isNotFailing bool, isGoalReached bool) {
 		"highestEstimate", highestEstimateInProbe,
 		"channel", channelObserverString,
 	)
+	s.trace.recordProbeDone(isNotFailing, isGoalReached)
 	if !isNotFailing {
 		return
 	}
 
 	if highestEstimateInProbe > s.committedChannelCapacity {
 		s.committedChannelCapacity = highestEstimateInProbe
+		s.trace.recordCommit(allocationReasonProbeDone, s.lastReceivedEstimate, s.committedChannelCapacity, s.getExpectedBandwidthUsage())
 	}
 
+	s.allocationReason = allocationReasonProbeDone
 	s.maybeBoostDeficientTracks()
 }
 
 func (s *StreamAllocator) maybeBoostDeficientTracks() {
+	if s.audioOnly {
+		return
+	}
+
 	availableChannelCapacity := s.getAvailableHeadroom(false)
 	if availableChannelCapacity <= 0 {
 		return
@@ -1047,7 +1233,7 @@ boost_loop:
 				continue
 			}
 
-			updateStreamStateChange(track, allocation, update)
+			s.updateStreamStateChange(track, allocation, update)
 
 			availableChannelCapacity -= allocation.BandwidthDelta
 			if availableChannelCapacity <= 0 {
@@ -1073,6 +1259,11 @@ func (s *StreamAllocator) allocateAllTracks() {
 		return
 	}
 
+	if s.audioOnly {
+		s.pauseAllTracks()
+		return
+	}
+
 	//
 	// Goals:
 	//   1. Stream as many tracks as possible, i.e. no pauses.
@@ -1085,6 +1276,9 @@ func (s *StreamAllocator) allocateAllTracks() {
 	//
 	// If there is not enough bandwidth even for the lowest layer, tracks at lower priorities will be paused.
 	//
+	// Tracks ranked by the subscriber are an exception to fairness, once every track had its chance at the lowest
+	// layer, they take the layers they need in rank order before the other tracks go up.
+	//
 	update := NewStreamStateUpdate()
 
 	availableChannelCapacity := s.getAvailableChannelCapacity(true)
@@ -1100,7 +1294,7 @@ func (s *StreamAllocator) allocateAllTracks() {
 		}
 
 		allocation := track.AllocateOptimal(FlagAllowOvershootExemptTrackWhileDeficient)
-		updateStreamStateChange(track, allocation, update)
+		s.updateStreamStateChange(track, allocation, update)
 
 		// STREAM-ALLOCATOR-TODO: optimistic allocation before bitrate is available will return 0. How to account for that?
 		if !s.params.Config.DisableEstimationUnmanagedTracks {
@@ -1119,7 +1313,7 @@ func (s *StreamAllocator) allocateAllTracks() {
 			}
 
 			allocation := track.Pause()
-			updateStreamStateChange(track, allocation, update)
+			s.updateStreamStateChange(track, allocation, update)
 		}
 	} else {
 		sorted := s.getSorted()
@@ -1127,6 +1321,36 @@ func (s *StreamAllocator) allocateAllTracks() {
 			track.ProvisionalAllocatePrepare()
 		}
 
+		provisionalAllocate := func(track *Track, layer buffer.VideoLayer) {
+			_, usedChannelCapacity := track.ProvisionalAllocate(availableChannelCapacity, layer, s.allowPause, FlagAllowOvershootWhileDeficient)
+			availableChannelCapacity -= usedChannelCapacity
+			if availableChannelCapacity < 0 {
+				availableChannelCapacity = 0
+			}
+		}
+
+		// sorted has the tracks ranked by the subscriber first
+		var ranked []*Track
+		for _, track := range sorted {
+			if !track.IsRankedBySubscriber() {
+				break
+			}
+			ranked = append(ranked, track)
+		}
+		unranked := sorted[len(ranked):]
+		if len(ranked) != 0 {
+			for _, track := range sorted {
+				provisionalAllocate(track, buffer.VideoLayer{Spatial: 0, Temporal: 0})
+			}
+			for _, track := range ranked {
+				for spatial := int32(0); spatial <= buffer.DefaultMaxLayerSpatial; spatial++ {
+					for temporal := int32(0); temporal <= buffer.DefaultMaxLayerTemporal; temporal++ {
+						provisionalAllocate(track, buffer.VideoLayer{Spatial: spatial, Temporal: temporal})
+					}
+				}
+			}
+		}
+
 		for spatial := int32(0); spatial <= buffer.DefaultMaxLayerSpatial; spatial++ {
 			for temporal := int32(0); temporal <= buffer.DefaultMaxLayerTemporal; temporal++ {
 				layer := buffer.VideoLayer{
@@ -1134,19 +1358,15 @@ func (s *StreamAllocator) allocateAllTracks() {
 					Temporal: temporal,
 				}
 
-				for _, track := range sorted {
-					_, usedChannelCapacity := track.ProvisionalAllocate(availableChannelCapacity, layer, s.allowPause, FlagAllowOvershootWhileDeficient)
-					availableChannelCapacity -= usedChannelCapacity
-					if availableChannelCapacity < 0 {
-						availableChannelCapacity = 0
-					}
+				for _, track := range unranked {
+					provisionalAllocate(track, layer)
 				}
 			}
 		}
 
 		for _, track := range sorted {
 			allocation := track.ProvisionalAllocateCommit()
-			updateStreamStateChange(track, allocation, update)
+			s.updateStreamStateChange(track, allocation, update)
 		}
 	}
 
@@ -1267,6 +1487,14 @@ func (s *StreamAllocator) newChannelObserverNonProbe() *ChannelObserver {
 func (s *StreamAllocator) initProbe(probeGoalDeltaBps int64) {
 	expectedBandwidthUsage := s.getExpectedBandwidthUsage()
 	probeClusterId, probeGoalBps := s.probeController.InitProbe(probeGoalDeltaBps, expectedBandwidthUsage)
+	if probeClusterId == ProbeClusterIdInvalid {
+		s.params.Logger.Debugw(
+			"stream allocator: not probing, at maximum probe bitrate",
+			"current usage", expectedBandwidthUsage,
+			"goalBps", probeGoalBps,
+		)
+		return
+	}
 
 	channelState := ""
 	if s.channelObserver != nil {
@@ -1274,6 +1502,7 @@ func (s *StreamAllocator) initProbe(probeGoalDeltaBps int64) {
 	}
 	s.channelObserver = s.newChannelObserverProbe()
 	s.channelObserver.SeedEstimate(s.lastReceivedEstimate)
+	s.trace.recordProbeStart(probeGoalBps)
 
 	s.params.Logger.Debugw(
 		"stream allocator: starting probe",
@@ -1292,6 +1521,9 @@ func (s *StreamAllocator) maybeProbe() {
 		// do not probe if channel capacity is overridden
 		return
 	}
+	if s.params.Config.ProbeMode == config.CongestionControlProbeModeDisabled {
+		return
+	}
 	if !s.probeController.CanProbe() {
 		return
 	}
@@ -1306,6 +1538,12 @@ func (s *StreamAllocator) maybeProbe() {
 }
 
 func (s *StreamAllocator) maybeProbeWithMedia() {
+	if s.audioOnly {
+		// media probes resume paused video, the channel recovers with padding probes or on its own
+		return
+	}
+
+	s.allocationReason = allocationReasonMediaProbe
 	// boost deficient track farthest from desired layer
 	for _, track := range s.getMaxDistanceSortedDeficient() {
 		allocation, boosted := track.AllocateNextHigher(ChannelCapacityInfinity, FlagAllowOvershootInBoost)
@@ -1314,7 +1552,7 @@ func (s *StreamAllocator) maybeProbeWithMedia() {
 		}
 
 		update := NewStreamStateUpdate()
-		updateStreamStateChange(track, allocation, update)
+		s.updateStreamStateChange(track, allocation, update)
 		s.maybeSendUpdate(update)
 
 		s.probeController.Reset()
@@ -1442,7 +1680,12 @@ func (s *StreamAllocator) getTracksHistory() map[livekit.TrackID]string {
 
 // ------------------------------------------------
 
-func updateStreamStateChange(track *Track, allocation sfu.VideoAllocation, update *StreamStateUpdate) {
+func (s *StreamAllocator) updateStreamStateChange(track *Track, allocation sfu.VideoAllocation, update *StreamStateUpdate) {
+	if track.SetAllocation(allocation) {
+		s.recordDecision(track.ID(), allocation)
+		s.trace.recordAllocation(track.ID(), allocation)
+	}
+
 	updated := false
 	streamState := StreamStateInactive
 	switch allocation.PauseReason {
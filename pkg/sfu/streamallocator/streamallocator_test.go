@@ -0,0 +1,90 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streamallocator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestAudioOnly(t *testing.T) {
+	conf := config.DefaultConfig.RTC.CongestionControl
+	conf.AudioOnly = config.CongestionControlAudioOnlyConfig{
+		Enabled:         true,
+		Threshold:       100_000,
+		ResumeThreshold: 250_000,
+		ResumeAfter:     100 * time.Millisecond,
+	}
+	s := NewStreamAllocator(StreamAllocatorParams{
+		Config: conf,
+		Logger: logger.GetLogger(),
+	})
+	var changes []bool
+	s.OnAudioOnlyChange(func(audioOnly bool) {
+		changes = append(changes, audioOnly)
+	})
+
+	s.committedChannelCapacity = 150_000
+	s.maybeEnterAudioOnly()
+	require.False(t, s.audioOnly)
+
+	s.committedChannelCapacity = 80_000
+	s.maybeEnterAudioOnly()
+	require.True(t, s.audioOnly)
+	require.Equal(t, []bool{true}, changes)
+
+	// above the threshold but below the resume threshold stays audio only
+	s.lastReceivedEstimate = 200_000
+	s.maybeExitAudioOnly()
+	require.True(t, s.audioOnly)
+
+	// has to hold above the resume threshold, a dip restarts the wait
+	s.lastReceivedEstimate = 300_000
+	s.maybeExitAudioOnly()
+	require.True(t, s.audioOnly)
+	s.lastReceivedEstimate = 200_000
+	s.maybeExitAudioOnly()
+	s.lastReceivedEstimate = 300_000
+	s.maybeExitAudioOnly()
+	require.True(t, s.audioOnly)
+
+	time.Sleep(conf.AudioOnly.ResumeAfter)
+	s.maybeExitAudioOnly()
+	require.False(t, s.audioOnly)
+	require.Equal(t, []bool{true, false}, changes)
+	require.Equal(t, int64(300_000), s.committedChannelCapacity)
+}
+
+func TestChannelCapacityHint(t *testing.T) {
+	s := NewStreamAllocator(StreamAllocatorParams{
+		Config:                 config.DefaultConfig.RTC.CongestionControl,
+		Logger:                 logger.GetLogger(),
+		InitialChannelCapacity: 1_000_000,
+	})
+
+	s.handleSignalSetChannelCapacityHint(&Event{Signal: streamAllocatorSignalSetChannelCapacityHint, Data: int64(3_000_000)})
+	require.Equal(t, int64(3_000_000), s.committedChannelCapacity)
+	require.Equal(t, allocationReasonChannelCapacityHint, s.allocationReason)
+
+	// a missing hint keeps the current capacity
+	s.handleSignalSetChannelCapacityHint(&Event{Signal: streamAllocatorSignalSetChannelCapacityHint, Data: int64(0)})
+	require.Equal(t, int64(3_000_000), s.committedChannelCapacity)
+}
@@ -0,0 +1,383 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streamallocator
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
+)
+
+const (
+	traceProbeResultGoalReached = "goal_reached"
+	traceProbeResultNotFailing  = "not_failing"
+	traceProbeResultFailed      = "failed"
+
+	traceCategory = "congestion_control"
+)
+
+type traceEntryKind uint8
+
+const (
+	// channel capacity values, value is the received estimate, committed and expected usage the other ones
+	traceEntryChannel traceEntryKind = iota
+	// channel capacity committed for text, with the channel capacity values
+	traceEntryCommit
+	// allocation of track, value is the bandwidth requested and text the pause reason
+	traceEntryAllocation
+	// text is the new state
+	traceEntryState
+	// value is the probe goal
+	traceEntryProbeStart
+	// text is the probe result
+	traceEntryProbeDone
+)
+
+// traceEntry is kept small, a trace holds thousands of them for each subscriber
+type traceEntry struct {
+	at        int64 // unix microseconds
+	kind      traceEntryKind
+	spatial   int8
+	temporal  int8
+	deficient bool
+	trackID   livekit.TrackID
+	value     int64
+	committed int64
+	expected  int64
+	text      string
+}
+
+// congestionTrace is a ring buffer of the estimates and actions of a stream allocator. It is only used
+// from the event loop of the allocator.
+type congestionTrace struct {
+	sampleInterval     time.Duration
+	entries            []traceEntry
+	head               int
+	lastChannelEntryAt time.Time
+}
+
+func newCongestionTrace(conf config.CongestionControlTraceConfig) *congestionTrace {
+	if !conf.Enabled || conf.Size <= 0 {
+		return nil
+	}
+
+	return &congestionTrace{
+		sampleInterval: conf.SampleInterval,
+		entries:        make([]traceEntry, 0, conf.Size),
+	}
+}
+
+func (t *congestionTrace) add(entry traceEntry) {
+	if t == nil {
+		return
+	}
+
+	if entry.at == 0 {
+		entry.at = time.Now().UnixMicro()
+	}
+	if len(t.entries) < cap(t.entries) {
+		t.entries = append(t.entries, entry)
+		return
+	}
+	t.entries[t.head] = entry
+	t.head = (t.head + 1) % len(t.entries)
+}
+
+// isChannelSampleDue returns true when the channel capacity values are to be recorded, once per sample interval
+func (t *congestionTrace) isChannelSampleDue() bool {
+	return t != nil && time.Since(t.lastChannelEntryAt) >= t.sampleInterval
+}
+
+func (t *congestionTrace) recordChannel(received, committed, expected int64) {
+	if t == nil {
+		return
+	}
+
+	now := time.Now()
+	t.lastChannelEntryAt = now
+	t.add(traceEntry{
+		at:        now.UnixMicro(),
+		kind:      traceEntryChannel,
+		value:     received,
+		committed: committed,
+		expected:  expected,
+	})
+}
+
+func (t *congestionTrace) recordCommit(reason string, received, committed, expected int64) {
+	t.add(traceEntry{
+		kind:      traceEntryCommit,
+		value:     received,
+		committed: committed,
+		expected:  expected,
+		text:      reason,
+	})
+}
+
+func (t *congestionTrace) recordAllocation(trackID livekit.TrackID, allocation sfu.VideoAllocation) {
+	t.add(traceEntry{
+		kind:      traceEntryAllocation,
+		trackID:   trackID,
+		spatial:   int8(allocation.TargetLayer.Spatial),
+		temporal:  int8(allocation.TargetLayer.Temporal),
+		deficient: allocation.IsDeficient,
+		value:     allocation.BandwidthRequested,
+		text:      allocation.PauseReason.String(),
+	})
+}
+
+func (t *congestionTrace) recordState(state streamAllocatorState) {
+	t.add(traceEntry{
+		kind: traceEntryState,
+		text: state.String(),
+	})
+}
+
+func (t *congestionTrace) recordProbeStart(goalBps int64) {
+	t.add(traceEntry{
+		kind:  traceEntryProbeStart,
+		value: goalBps,
+	})
+}
+
+func (t *congestionTrace) recordProbeDone(isNotFailing bool, isGoalReached bool) {
+	result := traceProbeResultFailed
+	switch {
+	case isGoalReached:
+		result = traceProbeResultGoalReached
+	case isNotFailing:
+		result = traceProbeResultNotFailing
+	}
+	t.add(traceEntry{
+		kind: traceEntryProbeDone,
+		text: result,
+	})
+}
+
+// ordered returns the entries oldest first
+func (t *congestionTrace) ordered() []traceEntry {
+	entries := make([]traceEntry, 0, len(t.entries))
+	entries = append(entries, t.entries[t.head:]...)
+	return append(entries, t.entries[:t.head]...)
+}
+
+// ---------------------------------------------
+
+// CongestionTrace is the trace of a stream allocator in the trace event format read by chrome://tracing and
+// Perfetto (ui.perfetto.dev): counters for the channel capacity and the layer and bitrate of each track,
+// slices for probes and instant events for state changes, commits and allocations
+type CongestionTrace struct {
+	TraceEvents     []TraceEvent      `json:"traceEvents"`
+	DisplayTimeUnit string            `json:"displayTimeUnit"`
+	OtherData       map[string]string `json:"otherData,omitempty"`
+}
+
+type TraceEvent struct {
+	Name      string                 `json:"name"`
+	Category  string                 `json:"cat,omitempty"`
+	Phase     string                 `json:"ph"`
+	Timestamp int64                  `json:"ts"`
+	PID       int                    `json:"pid"`
+	TID       int                    `json:"tid"`
+	Scope     string                 `json:"s,omitempty"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+}
+
+const (
+	tracePhaseCounter  = "C"
+	tracePhaseBegin    = "B"
+	tracePhaseEnd      = "E"
+	tracePhaseInstant  = "i"
+	tracePhaseMetadata = "M"
+
+	traceScopeGlobal = "g"
+	traceScopeThread = "t"
+
+	// threads of the trace, allocations are on a thread for each track after these
+	traceThreadAllocator = 0
+	traceThreadProbe     = 1
+)
+
+type congestionTraceRequest struct {
+	result chan *CongestionTrace
+}
+
+// GetCongestionTrace returns the recorded trace, nil if tracing is disabled, or the allocator is stopped
+// or does not answer in time
+func (s *StreamAllocator) GetCongestionTrace() *CongestionTrace {
+	if s.trace == nil || s.isStopped.Load() {
+		return nil
+	}
+
+	req := congestionTraceRequest{
+		result: make(chan *CongestionTrace, 1),
+	}
+	s.postEvent(Event{
+		Signal: streamAllocatorSignalGetCongestionTrace,
+		Data:   req,
+	})
+
+	select {
+	case trace := <-req.result:
+		return trace
+	case <-time.After(allocationInfoTimeout):
+		return nil
+	}
+}
+
+func (s *StreamAllocator) handleSignalGetCongestionTrace(event *Event) {
+	req := event.Data.(congestionTraceRequest)
+	req.result <- exportCongestionTrace(s.trace.ordered())
+}
+
+func exportCongestionTrace(entries []traceEntry) *CongestionTrace {
+	trace := &CongestionTrace{
+		DisplayTimeUnit: "ms",
+		OtherData: map[string]string{
+			"entries": strconv.Itoa(len(entries)),
+		},
+		TraceEvents: []TraceEvent{
+			threadName(traceThreadAllocator, "allocator"),
+			threadName(traceThreadProbe, "probes"),
+		},
+	}
+
+	trackThreads := make(map[livekit.TrackID]int)
+	for _, e := range entries {
+		switch e.kind {
+		case traceEntryChannel:
+			trace.TraceEvents = append(trace.TraceEvents, channelCounter(e))
+
+		case traceEntryCommit:
+			trace.TraceEvents = append(trace.TraceEvents, channelCounter(e), TraceEvent{
+				Name:      "commit",
+				Category:  traceCategory,
+				Phase:     tracePhaseInstant,
+				Timestamp: e.at,
+				TID:       traceThreadAllocator,
+				Scope:     traceScopeThread,
+				Args: map[string]interface{}{
+					"reason":    e.text,
+					"committed": e.committed,
+				},
+			})
+
+		case traceEntryAllocation:
+			tid, ok := trackThreads[e.trackID]
+			if !ok {
+				tid = traceThreadProbe + 1 + len(trackThreads)
+				trackThreads[e.trackID] = tid
+				trace.TraceEvents = append(trace.TraceEvents, threadName(tid, string(e.trackID)))
+			}
+			trace.TraceEvents = append(trace.TraceEvents,
+				TraceEvent{
+					Name:      "layer " + string(e.trackID),
+					Category:  traceCategory,
+					Phase:     tracePhaseCounter,
+					Timestamp: e.at,
+					Args: map[string]interface{}{
+						"spatial":  e.spatial,
+						"temporal": e.temporal,
+					},
+				},
+				TraceEvent{
+					Name:      "requested " + string(e.trackID),
+					Category:  traceCategory,
+					Phase:     tracePhaseCounter,
+					Timestamp: e.at,
+					Args: map[string]interface{}{
+						"bps": e.value,
+					},
+				},
+				TraceEvent{
+					Name:      "allocation",
+					Category:  traceCategory,
+					Phase:     tracePhaseInstant,
+					Timestamp: e.at,
+					TID:       tid,
+					Scope:     traceScopeThread,
+					Args: map[string]interface{}{
+						"pause_reason": e.text,
+						"deficient":    e.deficient,
+					},
+				},
+			)
+
+		case traceEntryState:
+			trace.TraceEvents = append(trace.TraceEvents, TraceEvent{
+				Name:      "state " + e.text,
+				Category:  traceCategory,
+				Phase:     tracePhaseInstant,
+				Timestamp: e.at,
+				TID:       traceThreadAllocator,
+				Scope:     traceScopeGlobal,
+			})
+
+		case traceEntryProbeStart:
+			trace.TraceEvents = append(trace.TraceEvents, TraceEvent{
+				Name:      "probe",
+				Category:  traceCategory,
+				Phase:     tracePhaseBegin,
+				Timestamp: e.at,
+				TID:       traceThreadProbe,
+				Args: map[string]interface{}{
+					"goal_bps": e.value,
+				},
+			})
+
+		case traceEntryProbeDone:
+			trace.TraceEvents = append(trace.TraceEvents, TraceEvent{
+				Name:      "probe",
+				Category:  traceCategory,
+				Phase:     tracePhaseEnd,
+				Timestamp: e.at,
+				TID:       traceThreadProbe,
+				Args: map[string]interface{}{
+					"result": e.text,
+				},
+			})
+		}
+	}
+	return trace
+}
+
+func channelCounter(e traceEntry) TraceEvent {
+	return TraceEvent{
+		Name:      "channel",
+		Category:  traceCategory,
+		Phase:     tracePhaseCounter,
+		Timestamp: e.at,
+		Args: map[string]interface{}{
+			"received":  e.value,
+			"committed": e.committed,
+			"expected":  e.expected,
+		},
+	}
+}
+
+func threadName(tid int, name string) TraceEvent {
+	return TraceEvent{
+		Name:  "thread_name",
+		Phase: tracePhaseMetadata,
+		TID:   tid,
+		Args: map[string]interface{}{
+			"name": name,
+		},
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streamallocator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func TestCongestionTrace(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		require.Nil(t, newCongestionTrace(config.CongestionControlTraceConfig{Size: 10}))
+
+		s := NewStreamAllocator(StreamAllocatorParams{
+			Config: config.DefaultConfig.RTC.CongestionControl,
+			Logger: logger.GetLogger(),
+		})
+		s.Start()
+		defer s.Stop()
+		require.Nil(t, s.GetCongestionTrace())
+	})
+
+	t.Run("oldest entries are dropped", func(t *testing.T) {
+		trace := newCongestionTrace(config.CongestionControlTraceConfig{Enabled: true, Size: 4})
+		for i := 0; i < 6; i++ {
+			trace.recordProbeStart(int64(i))
+		}
+
+		entries := trace.ordered()
+		require.Len(t, entries, 4)
+		for i, e := range entries {
+			require.Equal(t, int64(i+2), e.value)
+		}
+	})
+
+	t.Run("estimates are sampled", func(t *testing.T) {
+		trace := newCongestionTrace(config.CongestionControlTraceConfig{Enabled: true, Size: 4, SampleInterval: time.Hour})
+		require.True(t, trace.isChannelSampleDue())
+		trace.recordChannel(1_000_000, 900_000, 800_000)
+		require.False(t, trace.isChannelSampleDue())
+	})
+
+	t.Run("export", func(t *testing.T) {
+		trace := newCongestionTrace(config.CongestionControlTraceConfig{Enabled: true, Size: 16})
+		trace.recordChannel(1_000_000, 1_000_000, 800_000)
+		trace.recordState(streamAllocatorStateDeficient)
+		trace.recordCommit("congestion", 500_000, 500_000, 800_000)
+		for i := 0; i < 2; i++ {
+			trace.recordAllocation(livekit.TrackID(fmt.Sprintf("TR_%d", i)), sfu.VideoAllocation{
+				PauseReason:        sfu.VideoPauseReasonBandwidth,
+				TargetLayer:        buffer.VideoLayer{Spatial: 0, Temporal: 1},
+				BandwidthRequested: 300_000,
+				IsDeficient:        true,
+			})
+		}
+		trace.recordProbeStart(700_000)
+		trace.recordProbeDone(true, false)
+
+		exported := exportCongestionTrace(trace.ordered())
+		require.Equal(t, "7", exported.OtherData["entries"])
+
+		counts := make(map[string]int)
+		var threads []interface{}
+		for _, e := range exported.TraceEvents {
+			counts[e.Phase+" "+e.Name]++
+			if e.Name == "thread_name" {
+				threads = append(threads, e.Args["name"])
+			}
+		}
+		require.Equal(t, []interface{}{"allocator", "probes", "TR_0", "TR_1"}, threads)
+		require.Equal(t, 2, counts["C channel"])
+		require.Equal(t, 1, counts["i commit"])
+		require.Equal(t, 1, counts["i state DEFICIENT"])
+		require.Equal(t, 1, counts["C layer TR_1"])
+		require.Equal(t, 1, counts["C requested TR_0"])
+		require.Equal(t, 2, counts["i allocation"])
+		require.Equal(t, 1, counts["B probe"])
+		require.Equal(t, 1, counts["E probe"])
+	})
+}
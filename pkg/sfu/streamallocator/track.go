@@ -36,6 +36,9 @@ type Track struct {
 	publisherID livekit.ParticipantID
 	logger      logger.Logger
 
+	// rank the subscriber gave the track, 1 being its most important track, 0 when not ranked
+	subscriberRank uint32
+
 	maxLayer buffer.VideoLayer
 
 	totalPackets       uint32
@@ -57,6 +60,8 @@ type Track struct {
 	isDirty bool
 
 	streamState StreamState
+
+	allocation sfu.VideoAllocation
 }
 
 func NewTrack(
@@ -101,6 +106,20 @@ func (t *Track) SetStreamState(streamState StreamState) bool {
 	return true
 }
 
+// SetAllocation keeps the last allocation of the track, it returns true when the allocation
+// changes the layer forwarded to the subscriber or whether the track is paused or deficient
+func (t *Track) SetAllocation(allocation sfu.VideoAllocation) bool {
+	changed := t.allocation.PauseReason != allocation.PauseReason ||
+		t.allocation.IsDeficient != allocation.IsDeficient ||
+		t.allocation.TargetLayer != allocation.TargetLayer
+	t.allocation = allocation
+	return changed
+}
+
+func (t *Track) Allocation() sfu.VideoAllocation {
+	return t.allocation
+}
+
 func (t *Track) IsSubscribeMutable() bool {
 	return t.streamState != StreamStatePaused
 }
@@ -127,6 +146,25 @@ func (t *Track) Priority() uint8 {
 	return t.priority
 }
 
+// SetSubscriberRank sets the rank the subscriber gave the track among its subscriptions, 0 to clear it.
+// Ranked tracks are allocated before the others, in rank order.
+func (t *Track) SetSubscriberRank(rank uint32) bool {
+	if t.subscriberRank == rank {
+		return false
+	}
+
+	t.subscriberRank = rank
+	return true
+}
+
+func (t *Track) SubscriberRank() uint32 {
+	return t.subscriberRank
+}
+
+func (t *Track) IsRankedBySubscriber() bool {
+	return t.subscriberRank != 0
+}
+
 func (t *Track) DownTrack() *sfu.DownTrack {
 	return t.downTrack
 }
@@ -381,6 +419,9 @@ func (t TrackSorter) Less(i, j int) bool {
 	// TrackSorter is used to allocate layer-by-layer.
 	// So, higher priority track should come earlier so that it gets an earlier shot at each layer
 	//
+	if moreImportant, ok := compareSubscriberRank(t[i], t[j]); ok {
+		return moreImportant
+	}
 	if t[i].priority != t[j].priority {
 		return t[i].priority > t[j].priority
 	}
@@ -409,6 +450,9 @@ func (m MaxDistanceSorter) Less(i, j int) bool {
 	// MaxDistanceSorter is used to find a deficient track to use for probing during recovery from congestion.
 	// So, higher priority track should come earlier so that they have a chance to recover sooner.
 	//
+	if moreImportant, ok := compareSubscriberRank(m[i], m[j]); ok {
+		return moreImportant
+	}
 	if m[i].priority != m[j].priority {
 		return m[i].priority > m[j].priority
 	}
@@ -433,6 +477,9 @@ func (m MinDistanceSorter) Less(i, j int) bool {
 	// MinDistanceSorter is used to find excess bandwidth in cooperative allocation.
 	// So, lower priority track should come earlier so that they contribute bandwidth to higher priority tracks.
 	//
+	if moreImportant, ok := compareSubscriberRank(m[i], m[j]); ok {
+		return !moreImportant
+	}
 	if m[i].priority != m[j].priority {
 		return m[i].priority < m[j].priority
 	}
@@ -441,3 +488,17 @@ func (m MinDistanceSorter) Less(i, j int) bool {
 }
 
 // ------------------------------------------------
+
+// ------------------------------------------------
+
+// compareSubscriberRank returns whether a is more important than b by the ranks the subscriber gave them, ok is false
+// when the ranks do not decide it. Ranked tracks are more important than tracks that are not ranked.
+func compareSubscriberRank(a, b *Track) (moreImportant bool, ok bool) {
+	if a.subscriberRank == b.subscriberRank {
+		return false, false
+	}
+	if a.subscriberRank == 0 || b.subscriberRank == 0 {
+		return a.subscriberRank != 0, true
+	}
+	return a.subscriberRank < b.subscriberRank, true
+}
@@ -0,0 +1,43 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streamallocator
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackSortersSubscriberRank(t *testing.T) {
+	screenShare := &Track{priority: PriorityDefaultScreenshare}
+	camera := &Track{priority: PriorityDefaultVideo}
+	pinned := &Track{priority: PriorityDefaultVideo, subscriberRank: 1}
+	second := &Track{priority: PriorityDefaultVideo, subscriberRank: 2}
+
+	// ranked tracks come first in rank order, then by priority
+	tracks := TrackSorter{camera, second, screenShare, pinned}
+	sort.Sort(tracks)
+	require.Equal(t, TrackSorter{pinned, second, screenShare, camera}, tracks)
+
+	maxDistance := MaxDistanceSorter{camera, second, screenShare, pinned}
+	sort.Sort(maxDistance)
+	require.Equal(t, MaxDistanceSorter{pinned, second, screenShare, camera}, maxDistance)
+
+	// least important first to give up bandwidth
+	minDistance := MinDistanceSorter{pinned, screenShare, second, camera}
+	sort.Sort(minDistance)
+	require.Equal(t, MinDistanceSorter{camera, screenShare, second, pinned}, minDistance)
+}
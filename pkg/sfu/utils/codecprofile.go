@@ -0,0 +1,72 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// CodecProfile returns the profile signalled in the fmtp line of codecs whose profile has to match between
+// the sender and the receiver, profile-id for VP9 (RFC 9628) and profile for AV1. The profile defaults to 0
+// when it is not set. ok is false for other codecs.
+func CodecProfile(codec webrtc.RTPCodecCapability) (profile int, ok bool) {
+	var key string
+	switch {
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeVP9):
+		key = "profile-id"
+	case strings.EqualFold(codec.MimeType, webrtc.MimeTypeAV1):
+		key = "profile"
+	default:
+		return 0, false
+	}
+
+	for _, param := range strings.Split(codec.SDPFmtpLine, ";") {
+		k, v, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(k), key) {
+			continue
+		}
+		if p, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return p, true
+		}
+	}
+	return 0, true
+}
+
+// IsHighBitDepthProfile returns true for codec profiles that carry 10 or 12 bit video, VP9 profiles 2 and 3.
+// AV1 carries 10 bit video in its main profile, it has to be detected from the bitstream.
+func IsHighBitDepthProfile(codec webrtc.RTPCodecCapability) bool {
+	if !strings.EqualFold(codec.MimeType, webrtc.MimeTypeVP9) {
+		return false
+	}
+	profile, _ := CodecProfile(codec)
+	return profile >= 2
+}
+
+// CodecProfilesCompatible returns false for the same codec with different profiles, a stream forwarded
+// to a receiver that negotiated another profile cannot be decoded or decodes into corrupted frames.
+func CodecProfilesCompatible(a, b webrtc.RTPCodecCapability) bool {
+	if !strings.EqualFold(a.MimeType, b.MimeType) {
+		return true
+	}
+	pa, ok := CodecProfile(a)
+	if !ok {
+		return true
+	}
+	pb, _ := CodecProfile(b)
+	return pa == pb
+}
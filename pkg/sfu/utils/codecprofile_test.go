@@ -0,0 +1,79 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecProfile(t *testing.T) {
+	profile, ok := CodecProfile(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, SDPFmtpLine: "profile-id=2"})
+	require.True(t, ok)
+	require.Equal(t, 2, profile)
+
+	profile, ok = CodecProfile(webrtc.RTPCodecCapability{MimeType: "video/VP9"})
+	require.True(t, ok)
+	require.Equal(t, 0, profile)
+
+	profile, ok = CodecProfile(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeAV1, SDPFmtpLine: "level-idx=5;profile=1;tier=0"})
+	require.True(t, ok)
+	require.Equal(t, 1, profile)
+
+	_, ok = CodecProfile(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, SDPFmtpLine: "profile-level-id=42e01f"})
+	require.False(t, ok)
+
+	require.True(t, IsHighBitDepthProfile(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, SDPFmtpLine: "profile-id=2"}))
+	require.False(t, IsHighBitDepthProfile(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, SDPFmtpLine: "profile-id=1"}))
+}
+
+func TestCodecParametersFuzzySearch(t *testing.T) {
+	vp9Profile0 := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, ClockRate: 90000, SDPFmtpLine: "profile-id=0"},
+		PayloadType:        98,
+	}
+	vp9Profile2 := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, ClockRate: 90000, SDPFmtpLine: "profile-id=2"},
+		PayloadType:        102,
+	}
+	h264 := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f"},
+		PayloadType:        125,
+	}
+
+	codec, err := CodecParametersFuzzySearch(vp9Profile2, []webrtc.RTPCodecParameters{vp9Profile0, vp9Profile2})
+	require.NoError(t, err)
+	require.Equal(t, vp9Profile2, codec)
+
+	// a missing profile-id is profile 0
+	needle := vp9Profile0
+	needle.SDPFmtpLine = ""
+	codec, err = CodecParametersFuzzySearch(needle, []webrtc.RTPCodecParameters{vp9Profile2, vp9Profile0})
+	require.NoError(t, err)
+	require.Equal(t, vp9Profile0, codec)
+
+	// profiles do not fall back to each other
+	_, err = CodecParametersFuzzySearch(vp9Profile2, []webrtc.RTPCodecParameters{vp9Profile0, h264})
+	require.ErrorIs(t, err, webrtc.ErrCodecNotFound)
+
+	// other codecs still match on mime type alone
+	needle = h264
+	needle.SDPFmtpLine = "packetization-mode=1;profile-level-id=640032"
+	codec, err = CodecParametersFuzzySearch(needle, []webrtc.RTPCodecParameters{vp9Profile0, h264})
+	require.NoError(t, err)
+	require.Equal(t, h264, codec)
+}
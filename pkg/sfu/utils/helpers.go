@@ -32,9 +32,10 @@ func CodecParametersFuzzySearch(needle webrtc.RTPCodecParameters, haystack []web
 		}
 	}
 
-	// Fallback to just MimeType
+	// Fallback to just MimeType, as long as the codec profile matches
 	for _, c := range haystack {
-		if strings.EqualFold(c.RTPCodecCapability.MimeType, needle.RTPCodecCapability.MimeType) {
+		if strings.EqualFold(c.RTPCodecCapability.MimeType, needle.RTPCodecCapability.MimeType) &&
+			CodecProfilesCompatible(c.RTPCodecCapability, needle.RTPCodecCapability) {
 			return c, nil
 		}
 	}
@@ -24,6 +24,7 @@ import (
 	"github.com/livekit/protocol/logger"
 
 	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/privacy"
 	"github.com/livekit/livekit-server/pkg/routing"
 )
 
@@ -32,6 +33,8 @@ type AnalyticsService interface {
 	SendStats(ctx context.Context, stats []*livekit.AnalyticsStat)
 	SendEvent(ctx context.Context, events *livekit.AnalyticsEvent)
 	SendNodeRoomStates(ctx context.Context, nodeRooms *livekit.AnalyticsNodeRooms)
+	// Stop exports the stats and events queued so far, later ones are not exported
+	Stop()
 }
 
 type analyticsService struct {
@@ -42,35 +45,53 @@ type analyticsService struct {
 	events    livekit.AnalyticsRecorderService_IngestEventsClient
 	stats     livekit.AnalyticsRecorderService_IngestStatsClient
 	nodeRooms livekit.AnalyticsRecorderService_IngestNodeRoomStatesClient
+
+	exporter *Exporter
+	// nil when personal data is kept in events
+	scrubber *privacy.Scrubber
 }
 
-func NewAnalyticsService(_ *config.Config, currentNode routing.LocalNode) AnalyticsService {
+func NewAnalyticsService(conf *config.Config, currentNode routing.LocalNode) AnalyticsService {
 	return &analyticsService{
 		analyticsKey: "", // TODO: conf.AnalyticsKey
 		nodeID:       currentNode.Id,
+		exporter:     NewExporter(conf.TelemetryExport, currentNode.Id),
+		scrubber:     privacy.NewScrubber(conf.Privacy),
 	}
 }
 
-func (a *analyticsService) SendStats(_ context.Context, stats []*livekit.AnalyticsStat) {
-	if a.stats == nil {
+func (a *analyticsService) SendStats(ctx context.Context, stats []*livekit.AnalyticsStat) {
+	if a.stats == nil && a.exporter == nil {
 		return
 	}
 
+	analyticsKey := a.analyticsKeyFor(ctx)
 	for _, stat := range stats {
-		stat.AnalyticsKey = a.analyticsKey
+		stat.AnalyticsKey = analyticsKey
 		stat.Node = a.nodeID
 	}
+	a.exporter.AddStats(stats)
+
+	if a.stats == nil {
+		return
+	}
 	if err := a.stats.Send(&livekit.AnalyticsStats{Stats: stats}); err != nil {
 		logger.Errorw("failed to send stats", err)
 	}
 }
 
-func (a *analyticsService) SendEvent(_ context.Context, event *livekit.AnalyticsEvent) {
-	if a.events == nil {
+func (a *analyticsService) SendEvent(ctx context.Context, event *livekit.AnalyticsEvent) {
+	if a.events == nil && a.exporter == nil {
 		return
 	}
 
-	event.AnalyticsKey = a.analyticsKey
+	event.AnalyticsKey = a.analyticsKeyFor(ctx)
+	a.scrubber.AnalyticsEvent(event)
+	a.exporter.AddEvent(ctx, event)
+
+	if a.events == nil {
+		return
+	}
 	if err := a.events.Send(&livekit.AnalyticsEvents{
 		Events: []*livekit.AnalyticsEvent{event},
 	}); err != nil {
@@ -90,3 +111,15 @@ func (a *analyticsService) SendNodeRoomStates(_ context.Context, nodeRooms *live
 		logger.Errorw("failed to send node room states", err)
 	}
 }
+
+func (a *analyticsService) Stop() {
+	a.exporter.Stop()
+}
+
+// analyticsKeyFor returns the API key the data sent with ctx is attributed to, the analytics key otherwise
+func (a *analyticsService) analyticsKeyFor(ctx context.Context) string {
+	if apiKey := APIKeyFromContext(ctx); apiKey != "" {
+		return apiKey
+	}
+	return a.analyticsKey
+}
@@ -16,10 +16,12 @@ package telemetry
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/livekit/livekit-server/pkg/privacy"
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
@@ -27,6 +29,105 @@ import (
 	"github.com/livekit/protocol/webhook"
 )
 
+// webhook events in addition to the ones defined in protocol, they have to be listed in config.WebHookEvents too
+const (
+	EventParticipantPending   = "participant_pending"
+	EventParticipantAdmitted  = "participant_admitted"
+	EventRoomActivated        = "room_activated"
+	EventRoomExpired          = "room_expired"
+	EventRoomMerged           = "room_merged"
+	EventTrackCodecDeprecated = "track_codec_deprecated"
+	EventNegotiationFailed    = "negotiation_failed"
+	EventRoomRuleTriggered    = "room_rule_triggered"
+	EventICEConnectionFailed  = "ice_connection_failed"
+	EventJoinRestricted       = "participant_join_restricted"
+
+	EventParticipantConnectionQualityDegraded  = "participant_connection_quality_degraded"
+	EventParticipantConnectionQualityRecovered = "participant_connection_quality_recovered"
+
+	EventTrackPayloadCorrupted = "track_payload_corrupted"
+	EventTrackPayloadRecovered = "track_payload_recovered"
+
+	EventTrackFirstSubscriber = "track_first_subscriber"
+	EventTrackNoSubscribers   = "track_no_subscribers"
+
+	EventTrackSubscriptionCodecRegressed = "track_subscription_codec_regressed"
+)
+
+// NegotiationFailure describes a session description of a participant that could not be applied, or an answer to it
+// that could not be created
+type NegotiationFailure struct {
+	Transport livekit.SignalTarget
+	// remote_description, create_answer or local_description
+	Stage   string
+	SDPType string
+	// attribute of the session description the error points to, when it can be told
+	Attribute string
+	Error     string
+	RemoteSDP string
+	LocalSDP  string
+	// set when the description was applied after dropping optional header extensions and codecs
+	Recovered bool
+}
+
+// JoinRestriction describes a join rejected because of the network of the participant
+type JoinRestriction struct {
+	// api_key or room, whichever restriction rejected the join, or ban for participants banned from the room
+	RestrictedBy string
+	// country of the client IP, empty when it is not known or was not looked up
+	Country string
+	Reason  string
+}
+
+// ICEConnectivity is how a transport of a participant connected through ICE, or why it failed to
+type ICEConnectivity struct {
+	Transport livekit.SignalTarget
+	// udp, tcp or turn, empty when the transport failed to connect
+	ConnectionType string
+	// types of the candidates of the selected pair, host, srflx, prflx or relay
+	LocalCandidateType  string
+	RemoteCandidateType string
+	// from the start of connectivity checks
+	ConnectTime time.Duration
+	// set when the transport failed to connect, or the connection was lost
+	FailureReason string
+}
+
+// ConnectionQualityAlert is a participant whose connection quality stayed at or below the alert threshold,
+// or went back above it
+type ConnectionQualityAlert struct {
+	// false once the quality recovered
+	Degraded bool
+	Quality  livekit.ConnectionQuality
+	Score    float32
+	// how long the quality has been, or was, at or below the threshold
+	Duration time.Duration
+	// probable constraint of the network of the participant, e.g. uplink_limited
+	NetworkConstraint string
+}
+
+// TrackPayloadIntegrity is a published video track whose packets fail the checks of their payload, e.g. from a buggy
+// hardware encoder, or whose packets pass them again
+type TrackPayloadIntegrity struct {
+	// false once the track recovered
+	Corrupted bool
+	Mime      string
+	Layer     int32
+	// fraction of the packets of the last window failing the checks
+	ErrorRate float64
+	// packets failing the checks in the last window by reason, e.g. undecodable_header
+	Errors map[string]int
+}
+
+// CodecRegression is a subscription moved from the codec the subscriber failed to decode to a backup codec
+// of the track
+type CodecRegression struct {
+	FromMime string
+	ToMime   string
+	// why the subscriber was taken to be failing to decode, e.g. key_frame_requests
+	Reason string
+}
+
 func (t *telemetryService) NotifyEvent(ctx context.Context, event *livekit.WebhookEvent) {
 	if t.notifier == nil {
 		return
@@ -100,6 +201,28 @@ func (t *telemetryService) ParticipantJoined(
 	})
 }
 
+func (t *telemetryService) ParticipantJoinCompleted(
+	ctx context.Context,
+	room *livekit.Room,
+	participant *livekit.ParticipantInfo,
+	clientInfo *livekit.ClientInfo,
+	clientMeta *livekit.AnalyticsClientMeta,
+	joinedAt time.Time,
+	latency JoinLatency,
+) {
+	t.enqueue(func() {
+		for stage, d := range latency.Stages() {
+			prometheus.RecordJoinStageTime(stage, clientMeta.GetRegion(), d)
+		}
+
+		ev := newParticipantEvent(livekit.AnalyticsEventType_PARTICIPANT_JOINED, room, participant)
+		ev.Timestamp = timestamppb.New(joinedAt)
+		ev.ClientInfo = clientInfo
+		ev.ClientMeta = clientMeta
+		t.SendEvent(ContextWithJoinLatency(ctx, latency), ev)
+	})
+}
+
 func (t *telemetryService) ParticipantActive(
 	ctx context.Context,
 	room *livekit.Room,
@@ -157,6 +280,62 @@ func (t *telemetryService) ParticipantResumed(
 	})
 }
 
+func (t *telemetryService) ParticipantPending(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event:       EventParticipantPending,
+			Room:        room,
+			Participant: participant,
+		})
+	})
+}
+
+func (t *telemetryService) ParticipantAdmitted(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event:       EventParticipantAdmitted,
+			Room:        room,
+			Participant: participant,
+		})
+	})
+}
+
+func (t *telemetryService) RoomActivated(ctx context.Context, room *livekit.Room) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventRoomActivated,
+			Room:  room,
+		})
+	})
+}
+
+func (t *telemetryService) RoomExpired(ctx context.Context, room *livekit.Room) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventRoomExpired,
+			Room:  room,
+		})
+	})
+}
+
+// RoomMerged is sent for the room that was merged, the moves of its participants are reported by their
+// participant_left and participant_joined events
+func (t *telemetryService) RoomMerged(ctx context.Context, room *livekit.Room, destination livekit.RoomName, moved []livekit.ParticipantIdentity) {
+	t.enqueue(func() {
+		logger.Infow("room merged",
+			"event", EventRoomMerged,
+			"room", room.GetName(),
+			"destination", destination,
+			"moved", moved,
+		)
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventRoomMerged,
+			Room:  room,
+		})
+	})
+}
+
 func (t *telemetryService) ParticipantLeft(ctx context.Context,
 	room *livekit.Room,
 	participant *livekit.ParticipantInfo,
@@ -233,6 +412,272 @@ func (t *telemetryService) TrackPublished(
 	})
 }
 
+func (t *telemetryService) TrackCodecDeprecated(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	identity livekit.ParticipantIdentity,
+	track *livekit.TrackInfo,
+	mime string,
+) {
+	t.enqueue(func() {
+		prometheus.AddDeprecatedCodecPublish(mime)
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventTrackCodecDeprecated,
+			Room:  t.getRoomDetails(participantID),
+			Participant: &livekit.ParticipantInfo{
+				Sid:      string(participantID),
+				Identity: string(identity),
+			},
+			Track: track,
+		})
+	})
+}
+
+func (t *telemetryService) NegotiationFailed(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	identity livekit.ParticipantIdentity,
+	clientInfo *livekit.ClientInfo,
+	failure *NegotiationFailure,
+) {
+	t.enqueue(func() {
+		room := t.getRoomDetails(participantID)
+		logger.Warnw("negotiation failed", nil,
+			"event", EventNegotiationFailed,
+			"room", room.GetName(),
+			"participant", identity,
+			"pID", participantID,
+			"transport", failure.Transport,
+			"stage", failure.Stage,
+			"sdpType", failure.SDPType,
+			"attribute", failure.Attribute,
+			"error", failure.Error,
+			"recovered", failure.Recovered,
+			"clientInfo", logger.Proto(privacy.ClientInfo(clientInfo)),
+			"remoteSDP", failure.RemoteSDP,
+			"localSDP", failure.LocalSDP,
+		)
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventNegotiationFailed,
+			Room:  room,
+			Participant: &livekit.ParticipantInfo{
+				Sid:      string(participantID),
+				Identity: string(identity),
+			},
+		})
+	})
+}
+
+func (t *telemetryService) ParticipantJoinRestricted(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	clientInfo *livekit.ClientInfo,
+	restriction *JoinRestriction,
+) {
+	t.enqueue(func() {
+		logger.Infow("participant join restricted",
+			"event", EventJoinRestricted,
+			"room", roomName,
+			"participant", identity,
+			"restrictedBy", restriction.RestrictedBy,
+			"country", restriction.Country,
+			"reason", restriction.Reason,
+			"clientInfo", logger.Proto(privacy.ClientInfo(clientInfo)),
+		)
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventJoinRestricted,
+			Room:  &livekit.Room{Name: string(roomName)},
+			Participant: &livekit.ParticipantInfo{
+				Identity: string(identity),
+			},
+		})
+	})
+}
+
+func (t *telemetryService) ICEConnectivity(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	identity livekit.ParticipantIdentity,
+	clientInfo *livekit.ClientInfo,
+	connectivity *ICEConnectivity,
+) {
+	t.enqueue(func() {
+		transport := strings.ToLower(connectivity.Transport.String())
+		prometheus.RecordICEConnection(transport, connectivity.ConnectionType, connectivity.FailureReason)
+
+		if connectivity.FailureReason == "" {
+			return
+		}
+
+		room := t.getRoomDetails(participantID)
+		logger.Infow("ice connection failed",
+			"event", EventICEConnectionFailed,
+			"room", room.GetName(),
+			"participant", identity,
+			"pID", participantID,
+			"transport", transport,
+			"reason", connectivity.FailureReason,
+			"connectionType", connectivity.ConnectionType,
+			"clientInfo", logger.Proto(privacy.ClientInfo(clientInfo)),
+		)
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventICEConnectionFailed,
+			Room:  room,
+			Participant: &livekit.ParticipantInfo{
+				Sid:      string(participantID),
+				Identity: string(identity),
+			},
+		})
+	})
+}
+
+func (t *telemetryService) ConnectionQualityAlert(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	identity livekit.ParticipantIdentity,
+	alert *ConnectionQualityAlert,
+) {
+	t.enqueue(func() {
+		event := EventParticipantConnectionQualityRecovered
+		if alert.Degraded {
+			event = EventParticipantConnectionQualityDegraded
+		}
+
+		room := t.getRoomDetails(participantID)
+		logger.Infow("participant connection quality alert",
+			"event", event,
+			"room", room.GetName(),
+			"participant", identity,
+			"pID", participantID,
+			"quality", alert.Quality,
+			"score", alert.Score,
+			"duration", alert.Duration,
+			"networkConstraint", alert.NetworkConstraint,
+		)
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: event,
+			Room:  room,
+			Participant: &livekit.ParticipantInfo{
+				Sid:      string(participantID),
+				Identity: string(identity),
+			},
+		})
+	})
+}
+
+func (t *telemetryService) TrackPayloadIntegrity(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	identity livekit.ParticipantIdentity,
+	track *livekit.TrackInfo,
+	integrity *TrackPayloadIntegrity,
+) {
+	t.enqueue(func() {
+		event := EventTrackPayloadRecovered
+		if integrity.Corrupted {
+			event = EventTrackPayloadCorrupted
+		}
+
+		room := t.getRoomDetails(participantID)
+		logger.Infow("track payload integrity changed",
+			"event", event,
+			"room", room.GetName(),
+			"participant", identity,
+			"pID", participantID,
+			"trackID", track.GetSid(),
+			"mime", integrity.Mime,
+			"layer", integrity.Layer,
+			"errorRate", integrity.ErrorRate,
+			"errors", integrity.Errors,
+		)
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: event,
+			Room:  room,
+			Participant: &livekit.ParticipantInfo{
+				Sid:      string(participantID),
+				Identity: string(identity),
+			},
+			Track: track,
+		})
+	})
+}
+
+func (t *telemetryService) TrackSubscriberCount(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	identity livekit.ParticipantIdentity,
+	track *livekit.TrackInfo,
+	count int,
+) {
+	t.enqueue(func() {
+		event := EventTrackNoSubscribers
+		if count > 0 {
+			event = EventTrackFirstSubscriber
+		}
+
+		room := t.getRoomDetails(participantID)
+		logger.Debugw("track subscriber count changed",
+			"event", event,
+			"room", room.GetName(),
+			"participant", identity,
+			"pID", participantID,
+			"trackID", track.GetSid(),
+			"count", count,
+		)
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: event,
+			Room:  room,
+			Participant: &livekit.ParticipantInfo{
+				Sid:      string(participantID),
+				Identity: string(identity),
+			},
+			Track: track,
+		})
+	})
+}
+
+func (t *telemetryService) TrackSubscribeCodecRegressed(
+	ctx context.Context,
+	participantID livekit.ParticipantID,
+	identity livekit.ParticipantIdentity,
+	track *livekit.TrackInfo,
+	regression *CodecRegression,
+) {
+	t.enqueue(func() {
+		prometheus.AddCodecRegression(regression.FromMime, regression.ToMime, regression.Reason)
+
+		room := t.getRoomDetails(participantID)
+		logger.Infow("subscription codec regressed",
+			"event", EventTrackSubscriptionCodecRegressed,
+			"room", room.GetName(),
+			"participant", identity,
+			"pID", participantID,
+			"trackID", track.GetSid(),
+			"fromMime", regression.FromMime,
+			"toMime", regression.ToMime,
+			"reason", regression.Reason,
+		)
+
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventTrackSubscriptionCodecRegressed,
+			Room:  room,
+			Participant: &livekit.ParticipantInfo{
+				Sid:      string(participantID),
+				Identity: string(identity),
+			},
+			Track: track,
+		})
+	})
+}
+
 func (t *telemetryService) TrackPublishedUpdate(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo) {
 	t.enqueue(func() {
 		room := t.getRoomDetails(participantID)
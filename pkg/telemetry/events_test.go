@@ -22,6 +22,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry"
 )
 
 func Test_OnParticipantJoin_EventIsSent(t *testing.T) {
@@ -74,6 +77,25 @@ func Test_OnParticipantJoin_EventIsSent(t *testing.T) {
 	require.Equal(t, clientMeta.ClientConnectTime, event.ClientMeta.ClientConnectTime)
 }
 
+func Test_ParticipantEvents_AttributedToAPIKey(t *testing.T) {
+	fixture := createFixture()
+
+	room := &livekit.Room{Sid: "RoomSid", Name: "RoomName"}
+	participantInfo := &livekit.ParticipantInfo{Sid: "part1"}
+	trackInfo := &livekit.TrackInfo{Sid: "track1", Type: livekit.TrackType_AUDIO}
+
+	ctx := telemetry.ContextWithAPIKey(context.Background(), "key1")
+	fixture.sut.ParticipantJoined(ctx, room, participantInfo, nil, nil, true)
+	fixture.sut.TrackPublished(context.Background(), livekit.ParticipantID(participantInfo.Sid), "identity", trackInfo)
+	time.Sleep(time.Millisecond * 500)
+
+	require.Equal(t, 2, fixture.analytics.SendEventCallCount())
+	for i := 0; i < 2; i++ {
+		eventCtx, _ := fixture.analytics.SendEventArgsForCall(i)
+		require.Equal(t, "key1", telemetry.APIKeyFromContext(eventCtx))
+	}
+}
+
 func Test_OnParticipantLeft_EventIsSent(t *testing.T) {
 	fixture := createFixture()
 
@@ -236,3 +258,28 @@ func Test_OnTrackSubscribed_EventIsSent(t *testing.T) {
 	require.Equal(t, publisherInfo.Identity, eventTrackSubscribed.Publisher.Identity)
 
 }
+
+func Test_WebHookEvents_KnownToConfig(t *testing.T) {
+	// webhook endpoints of the config are checked against the events the server sends
+	for _, event := range []string{
+		telemetry.EventParticipantPending,
+		telemetry.EventParticipantAdmitted,
+		telemetry.EventRoomActivated,
+		telemetry.EventRoomExpired,
+		telemetry.EventRoomMerged,
+		telemetry.EventTrackCodecDeprecated,
+		telemetry.EventNegotiationFailed,
+		telemetry.EventRoomRuleTriggered,
+		telemetry.EventICEConnectionFailed,
+		telemetry.EventJoinRestricted,
+		telemetry.EventParticipantConnectionQualityDegraded,
+		telemetry.EventParticipantConnectionQualityRecovered,
+		telemetry.EventTrackPayloadCorrupted,
+		telemetry.EventTrackPayloadRecovered,
+		telemetry.EventTrackFirstSubscriber,
+		telemetry.EventTrackNoSubscribers,
+		telemetry.EventTrackSubscriptionCodecRegressed,
+	} {
+		require.Contains(t, config.WebHookEvents, event)
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const (
+	exportRecordTrackStat    = "track_stat"
+	exportRecordSessionEvent = "session_event"
+
+	exportResultExported = "exported"
+	exportResultDropped  = "dropped"
+	exportResultFailed   = "failed"
+
+	exportRetryBackoff = 500 * time.Millisecond
+)
+
+// TrackStatRow is an exported row of the quality of a track over a stats interval,
+// the streams of the track are summed up
+type TrackStatRow struct {
+	Record            string    `json:"record"`
+	Timestamp         time.Time `json:"timestamp"`
+	Node              string    `json:"node"`
+	AnalyticsKey      string    `json:"analytics_key"`
+	Kind              string    `json:"kind"`
+	RoomID            string    `json:"room_id"`
+	RoomName          string    `json:"room_name"`
+	ParticipantID     string    `json:"participant_id"`
+	TrackID           string    `json:"track_id"`
+	Mime              string    `json:"mime"`
+	Score             float32   `json:"score"`
+	MinScore          float32   `json:"min_score"`
+	MedianScore       float32   `json:"median_score"`
+	Packets           uint64    `json:"packets"`
+	Bytes             uint64    `json:"bytes"`
+	RetransmitPackets uint64    `json:"retransmit_packets"`
+	PacketsLost       uint64    `json:"packets_lost"`
+	Frames            uint64    `json:"frames"`
+	Nacks             uint64    `json:"nacks"`
+	Plis              uint64    `json:"plis"`
+	Firs              uint64    `json:"firs"`
+	MaxRTT            uint32    `json:"max_rtt"`
+	MaxJitter         uint32    `json:"max_jitter"`
+}
+
+// SessionEventRow is an exported row of a room, participant or track event
+type SessionEventRow struct {
+	Record              string    `json:"record"`
+	Timestamp           time.Time `json:"timestamp"`
+	Node                string    `json:"node"`
+	AnalyticsKey        string    `json:"analytics_key"`
+	Type                string    `json:"type"`
+	RoomID              string    `json:"room_id"`
+	RoomName            string    `json:"room_name"`
+	ParticipantID       string    `json:"participant_id"`
+	ParticipantIdentity string    `json:"participant_identity"`
+	TrackID             string    `json:"track_id"`
+	TrackSource         string    `json:"track_source"`
+	Mime                string    `json:"mime"`
+	SDK                 string    `json:"sdk"`
+	SDKVersion          string    `json:"sdk_version"`
+	OS                  string    `json:"os"`
+	Browser             string    `json:"browser"`
+	EgressID            string    `json:"egress_id"`
+	IngressID           string    `json:"ingress_id"`
+	Error               string    `json:"error"`
+	// join latency breakdown of participant joined events, in milliseconds
+	JoinTokenValidationMs int64 `json:"join_token_validation_ms,omitempty"`
+	JoinRoutingMs         int64 `json:"join_routing_ms,omitempty"`
+	JoinOfferAnswerMs     int64 `json:"join_offer_answer_ms,omitempty"`
+	JoinICEMs             int64 `json:"join_ice_ms,omitempty"`
+	JoinFirstMediaMs      int64 `json:"join_first_media_ms,omitempty"`
+}
+
+type exportRow struct {
+	record string
+	data   any
+}
+
+// Exporter batches track stats and session events into ClickHouse, or posts them as JSON lines.
+// Rows are queued without blocking and dropped while the queue is full, batches are sent from a
+// single goroutine so a slow destination only fills the queue.
+type Exporter struct {
+	conf   config.TelemetryExportConfig
+	nodeID string
+	client *http.Client
+	queue  chan exportRow
+	closed core.Fuse
+	done   chan struct{}
+}
+
+// NewExporter returns nil when the export is disabled
+func NewExporter(conf config.TelemetryExportConfig, nodeID string) *Exporter {
+	if !conf.Enabled {
+		return nil
+	}
+
+	e := &Exporter{
+		conf:   conf,
+		nodeID: nodeID,
+		client: &http.Client{Timeout: conf.Timeout},
+		queue:  make(chan exportRow, conf.QueueSize),
+		closed: core.NewFuse(),
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Stop sends the rows queued so far and stops the exporter
+func (e *Exporter) Stop() {
+	if e == nil {
+		return
+	}
+	e.closed.Break()
+	<-e.done
+}
+
+func (e *Exporter) AddStats(stats []*livekit.AnalyticsStat) {
+	if e == nil {
+		return
+	}
+	for _, stat := range stats {
+		e.enqueue(exportRow{record: exportRecordTrackStat, data: trackStatRow(stat)})
+	}
+}
+
+// AddEvent exports event along with the join latency attached to ctx
+func (e *Exporter) AddEvent(ctx context.Context, event *livekit.AnalyticsEvent) {
+	if e == nil {
+		return
+	}
+	row := sessionEventRow(event, e.nodeID)
+	if latency, ok := JoinLatencyFromContext(ctx); ok {
+		row.JoinTokenValidationMs = latency.TokenValidation.Milliseconds()
+		row.JoinRoutingMs = latency.Routing.Milliseconds()
+		row.JoinOfferAnswerMs = latency.OfferAnswer.Milliseconds()
+		row.JoinICEMs = latency.ICE.Milliseconds()
+		row.JoinFirstMediaMs = latency.FirstMedia.Milliseconds()
+	}
+	e.enqueue(exportRow{record: exportRecordSessionEvent, data: row})
+}
+
+func (e *Exporter) enqueue(row exportRow) {
+	if e.closed.IsBroken() {
+		return
+	}
+	select {
+	case e.queue <- row:
+	default:
+		prometheus.RecordTelemetryExportRows(exportResultDropped, 1)
+	}
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.conf.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]exportRow, 0, e.conf.BatchSize)
+	flush := func() {
+		prometheus.RecordTelemetryExportQueued(len(e.queue))
+		if len(batch) == 0 {
+			return
+		}
+		e.send(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case row := <-e.queue:
+			batch = append(batch, row)
+			if len(batch) >= e.conf.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.closed.Watch():
+			for {
+				select {
+				case row := <-e.queue:
+					batch = append(batch, row)
+					if len(batch) >= e.conf.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *Exporter) send(batch []exportRow) {
+	if e.conf.Format == config.TelemetryExportFormatJSONL {
+		e.sendWithRetries(batch, e.conf.URL, "application/x-ndjson")
+		return
+	}
+
+	// one insert per table
+	var stats, events []exportRow
+	for _, row := range batch {
+		if row.record == exportRecordTrackStat {
+			stats = append(stats, row)
+		} else {
+			events = append(events, row)
+		}
+	}
+	if len(stats) != 0 {
+		e.sendWithRetries(stats, e.clickHouseInsertURL(e.conf.StatsTable), "application/json")
+	}
+	if len(events) != 0 {
+		e.sendWithRetries(events, e.clickHouseInsertURL(e.conf.EventsTable), "application/json")
+	}
+}
+
+func (e *Exporter) sendWithRetries(rows []exportRow, target string, contentType string) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := encoder.Encode(row.data); err != nil {
+			logger.Warnw("could not encode telemetry row", err, "record", row.record)
+		}
+	}
+
+	var err error
+	for attempt := 0; attempt <= e.conf.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * exportRetryBackoff)
+		}
+		if err = e.post(target, contentType, body.Bytes()); err == nil {
+			prometheus.RecordTelemetryExportRows(exportResultExported, len(rows))
+			return
+		}
+	}
+	logger.Warnw("could not export telemetry", err, "rows", len(rows))
+	prometheus.RecordTelemetryExportRows(exportResultFailed, len(rows))
+}
+
+func (e *Exporter) post(target string, contentType string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	switch {
+	case e.conf.Format == config.TelemetryExportFormatClickHouse && e.conf.Username != "":
+		req.Header.Set("X-ClickHouse-User", e.conf.Username)
+		req.Header.Set("X-ClickHouse-Key", e.conf.Password)
+	case e.conf.AuthToken != "":
+		req.Header.Set("Authorization", "Bearer "+e.conf.AuthToken)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1<<10))
+		return fmt.Errorf("telemetry export returned %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// clickHouseInsertURL inserts newline delimited JSON rows into table, timestamps are RFC 3339
+// and fields the table does not have are skipped
+func (e *Exporter) clickHouseInsertURL(table string) string {
+	query := url.Values{}
+	query.Set("query", fmt.Sprintf("INSERT INTO %s.%s FORMAT JSONEachRow", e.conf.Database, table))
+	query.Set("date_time_input_format", "best_effort")
+	query.Set("input_format_skip_unknown_fields", "1")
+
+	if strings.Contains(e.conf.URL, "?") {
+		return e.conf.URL + "&" + query.Encode()
+	}
+	return e.conf.URL + "?" + query.Encode()
+}
+
+func trackStatRow(stat *livekit.AnalyticsStat) *TrackStatRow {
+	row := &TrackStatRow{
+		Record:        exportRecordTrackStat,
+		Timestamp:     exportTimestamp(stat.TimeStamp),
+		Node:          stat.Node,
+		AnalyticsKey:  stat.AnalyticsKey,
+		Kind:          strings.ToLower(stat.Kind.String()),
+		RoomID:        stat.RoomId,
+		RoomName:      stat.RoomName,
+		ParticipantID: stat.ParticipantId,
+		TrackID:       stat.TrackId,
+		Mime:          stat.Mime,
+		Score:         stat.Score,
+		MinScore:      stat.MinScore,
+		MedianScore:   stat.MedianScore,
+	}
+	for _, stream := range stat.Streams {
+		row.Packets += uint64(stream.PrimaryPackets)
+		row.Bytes += stream.PrimaryBytes
+		row.RetransmitPackets += uint64(stream.RetransmitPackets)
+		row.PacketsLost += uint64(stream.PacketsLost)
+		row.Frames += uint64(stream.Frames)
+		row.Nacks += uint64(stream.Nacks)
+		row.Plis += uint64(stream.Plis)
+		row.Firs += uint64(stream.Firs)
+		if stream.Rtt > row.MaxRTT {
+			row.MaxRTT = stream.Rtt
+		}
+		if stream.Jitter > row.MaxJitter {
+			row.MaxJitter = stream.Jitter
+		}
+	}
+	return row
+}
+
+func sessionEventRow(event *livekit.AnalyticsEvent, nodeID string) *SessionEventRow {
+	row := &SessionEventRow{
+		Record:              exportRecordSessionEvent,
+		Timestamp:           exportTimestamp(event.Timestamp),
+		Node:                nodeID,
+		AnalyticsKey:        event.AnalyticsKey,
+		Type:                strings.ToLower(event.Type.String()),
+		RoomID:              event.RoomId,
+		RoomName:            event.Room.GetName(),
+		ParticipantID:       event.ParticipantId,
+		ParticipantIdentity: event.Participant.GetIdentity(),
+		TrackID:             event.TrackId,
+		Mime:                event.Mime,
+		SDK:                 strings.ToLower(event.ClientInfo.GetSdk().String()),
+		SDKVersion:          event.ClientInfo.GetVersion(),
+		OS:                  event.ClientInfo.GetOs(),
+		Browser:             event.ClientInfo.GetBrowser(),
+		EgressID:            event.EgressId,
+		IngressID:           event.IngressId,
+		Error:               event.Error,
+	}
+	if row.RoomID == "" {
+		row.RoomID = event.Room.GetSid()
+	}
+	if event.Track != nil {
+		row.TrackSource = strings.ToLower(event.Track.Source.String())
+	}
+	return row
+}
+
+func exportTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if !ts.IsValid() {
+		return time.Now().UTC()
+	}
+	return ts.AsTime().UTC()
+}
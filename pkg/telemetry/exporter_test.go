@@ -0,0 +1,182 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+)
+
+type exportRequest struct {
+	query string
+	user  string
+	rows  []map[string]any
+}
+
+func newExportServer(t *testing.T) (*httptest.Server, func() []exportRequest) {
+	var (
+		lock     sync.Mutex
+		requests []exportRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := exportRequest{query: r.URL.Query().Get("query"), user: r.Header.Get("X-ClickHouse-User")}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+			req.rows = append(req.rows, row)
+		}
+		lock.Lock()
+		requests = append(requests, req)
+		lock.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []exportRequest {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]exportRequest{}, requests...)
+	}
+}
+
+func testExportConfig(url string) config.TelemetryExportConfig {
+	conf := config.DefaultConfig.TelemetryExport
+	conf.Enabled = true
+	conf.URL = url
+	conf.Username = "livekit"
+	return conf
+}
+
+func TestExporter(t *testing.T) {
+	require.Nil(t, telemetry.NewExporter(config.TelemetryExportConfig{}, "node"))
+
+	t.Run("inserts into a table per record", func(t *testing.T) {
+		server, requests := newExportServer(t)
+		e := telemetry.NewExporter(testExportConfig(server.URL), "node")
+
+		e.AddStats([]*livekit.AnalyticsStat{{
+			Kind:    livekit.StreamType_UPSTREAM,
+			TrackId: "TR_a",
+			Score:   4.5,
+			Streams: []*livekit.AnalyticsStream{
+				{PrimaryPackets: 10, PrimaryBytes: 1000, Rtt: 20},
+				{PrimaryPackets: 5, PrimaryBytes: 500, Rtt: 40},
+			},
+		}})
+		ctx := telemetry.ContextWithJoinLatency(context.Background(), telemetry.JoinLatency{Routing: 20 * time.Millisecond})
+		e.AddEvent(ctx, &livekit.AnalyticsEvent{
+			Type:        livekit.AnalyticsEventType_PARTICIPANT_JOINED,
+			Participant: &livekit.ParticipantInfo{Identity: "alice"},
+		})
+		e.Stop()
+
+		reqs := requests()
+		require.Len(t, reqs, 2)
+		require.Equal(t, "INSERT INTO default.track_stats FORMAT JSONEachRow", reqs[0].query)
+		require.Equal(t, "livekit", reqs[0].user)
+		require.Len(t, reqs[0].rows, 1)
+		require.EqualValues(t, 15, reqs[0].rows[0]["packets"])
+		require.EqualValues(t, 1500, reqs[0].rows[0]["bytes"])
+		require.EqualValues(t, 40, reqs[0].rows[0]["max_rtt"])
+		require.Equal(t, "upstream", reqs[0].rows[0]["kind"])
+
+		require.Equal(t, "INSERT INTO default.session_events FORMAT JSONEachRow", reqs[1].query)
+		require.Equal(t, "participant_joined", reqs[1].rows[0]["type"])
+		require.Equal(t, "alice", reqs[1].rows[0]["participant_identity"])
+		require.Equal(t, "node", reqs[1].rows[0]["node"])
+		require.EqualValues(t, 20, reqs[1].rows[0]["join_routing_ms"])
+		require.NotContains(t, reqs[1].rows[0], "join_ice_ms")
+	})
+
+	t.Run("sends full batches before the flush interval", func(t *testing.T) {
+		server, requests := newExportServer(t)
+		conf := testExportConfig(server.URL)
+		conf.Format = config.TelemetryExportFormatJSONL
+		conf.BatchSize = 2
+		conf.FlushInterval = time.Hour
+		e := telemetry.NewExporter(conf, "node")
+		defer e.Stop()
+
+		for i := 0; i < 3; i++ {
+			e.AddEvent(context.Background(), &livekit.AnalyticsEvent{Type: livekit.AnalyticsEventType_TRACK_PUBLISHED})
+		}
+		require.Eventually(t, func() bool {
+			return len(requests()) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.Len(t, requests()[0].rows, 2)
+		require.Equal(t, "session_event", requests()[0].rows[0]["record"])
+	})
+
+	t.Run("analytics service sends queued rows when stopped", func(t *testing.T) {
+		server, requests := newExportServer(t)
+		conf, err := config.NewConfig("", true, nil, nil)
+		require.NoError(t, err)
+		conf.TelemetryExport = testExportConfig(server.URL)
+		conf.TelemetryExport.FlushInterval = time.Hour
+		a := telemetry.NewAnalyticsService(conf, &livekit.Node{Id: "node"})
+
+		a.SendEvent(context.Background(), &livekit.AnalyticsEvent{Type: livekit.AnalyticsEventType_ROOM_ENDED})
+		require.Empty(t, requests())
+		a.Stop()
+		require.Len(t, requests(), 1)
+
+		// nothing is exported afterwards
+		a.SendEvent(context.Background(), &livekit.AnalyticsEvent{Type: livekit.AnalyticsEventType_ROOM_ENDED})
+		require.Len(t, requests(), 1)
+	})
+
+	t.Run("drops rows while the queue is full", func(t *testing.T) {
+		unblock := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-unblock
+		}))
+		defer server.Close()
+
+		conf := testExportConfig(server.URL)
+		conf.BatchSize = 1
+		conf.QueueSize = 2
+		conf.MaxRetries = 0
+		e := telemetry.NewExporter(conf, "node")
+
+		done := make(chan struct{})
+		go func() {
+			for i := 0; i < 100; i++ {
+				e.AddEvent(context.Background(), &livekit.AnalyticsEvent{Type: livekit.AnalyticsEventType_TRACK_PUBLISHED})
+			}
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("adding rows blocked on a stalled destination")
+		}
+
+		close(unblock)
+		e.Stop()
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"time"
+)
+
+// stages of a join, as labelled in metrics
+const (
+	JoinStageTokenValidation = "token_validation"
+	JoinStageRouting         = "routing"
+	JoinStageOfferAnswer     = "offer_answer"
+	JoinStageICE             = "ice"
+	JoinStageFirstMedia      = "first_media"
+)
+
+// JoinLatency is the time a join spent in each of its stages, stages that were not reached are zero.
+// Token validation, which includes the join authorization service, and routing take place on the signal node,
+// the others on the media node from the time the session starts.
+type JoinLatency struct {
+	TokenValidation time.Duration
+	Routing         time.Duration
+	// until the first offer is answered, in either direction
+	OfferAnswer time.Duration
+	// until the primary transport is connected
+	ICE time.Duration
+	// until the first packet of a published track arrives or the first subscribed track is bound
+	FirstMedia time.Duration
+}
+
+// Stages returns the time of the stages that were reached, by stage
+func (l JoinLatency) Stages() map[string]time.Duration {
+	stages := map[string]time.Duration{
+		JoinStageTokenValidation: l.TokenValidation,
+		JoinStageRouting:         l.Routing,
+		JoinStageOfferAnswer:     l.OfferAnswer,
+		JoinStageICE:             l.ICE,
+		JoinStageFirstMedia:      l.FirstMedia,
+	}
+	for stage, d := range stages {
+		if d <= 0 {
+			delete(stages, stage)
+		}
+	}
+	return stages
+}
+
+type joinLatencyKey struct{}
+
+// ContextWithJoinLatency attaches the join latency to the participant joined event sent with ctx
+func ContextWithJoinLatency(ctx context.Context, latency JoinLatency) context.Context {
+	return context.WithValue(ctx, joinLatencyKey{}, latency)
+}
+
+func JoinLatencyFromContext(ctx context.Context) (JoinLatency, bool) {
+	latency, ok := ctx.Value(joinLatencyKey{}).(JoinLatency)
+	return latency, ok
+}
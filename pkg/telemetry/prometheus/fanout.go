@@ -0,0 +1,55 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promFanOutBatches *prometheus.CounterVec
+	promFanOutQueued  prometheus.Gauge
+)
+
+func initFanOutStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promFanOutBatches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "fanout",
+		Name:        "batches",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"mode"})
+	promFanOutQueued = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "fanout",
+		Name:        "queued_batches",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	})
+
+	prometheus.MustRegister(promFanOutBatches)
+	prometheus.MustRegister(promFanOutQueued)
+}
+
+// RecordFanOutBatch counts a batch of down tracks written by a fan-out worker, or inline by the forwarding
+// goroutine when the worker was backed up, along with the number of batches waiting for a worker
+func RecordFanOutBatch(queued int, inline bool) {
+	if inline {
+		promFanOutBatches.WithLabelValues("inline").Inc()
+	} else {
+		promFanOutBatches.WithLabelValues("worker").Inc()
+	}
+	promFanOutQueued.Set(float64(queued))
+}
@@ -0,0 +1,47 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var promKeyFrameRequests *prometheus.CounterVec
+
+func initKeyFrameStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promKeyFrameRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "keyframe_request",
+		Name:        "total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"result"})
+
+	prometheus.MustRegister(promKeyFrameRequests)
+}
+
+// RecordKeyFrameRequest counts a key frame request of a subscriber, sent to the publisher or suppressed
+// because the track had one too recently
+func RecordKeyFrameRequest(suppressed bool) {
+	if promKeyFrameRequests == nil {
+		return
+	}
+	if suppressed {
+		promKeyFrameRequests.WithLabelValues("suppressed").Inc()
+	} else {
+		promKeyFrameRequests.WithLabelValues("sent").Inc()
+	}
+}
@@ -111,6 +111,9 @@ func Init(nodeID string, nodeType livekit.NodeType, env string) {
 	initRoomStats(nodeID, nodeType, env)
 	rpc.InitPSRPCStats(prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env})
 	initQualityStats(nodeID, nodeType, env)
+	initFanOutStats(nodeID, nodeType, env)
+	initTelemetryExportStats(nodeID, nodeType, env)
+	initKeyFrameStats(nodeID, nodeType, env)
 }
 
 func GetUpdatedNodeStats(prev *livekit.NodeStats, prevAverage *livekit.NodeStats) (*livekit.NodeStats, bool, error) {
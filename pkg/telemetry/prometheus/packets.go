@@ -212,6 +212,11 @@ func IncrementBytes(direction Direction, count uint64, retransmit bool) {
 	}
 }
 
+// GetBytes returns the media bytes received and sent by the node since it started
+func GetBytes() (in uint64, out uint64) {
+	return bytesIn.Load(), bytesOut.Load()
+}
+
 func IncrementRTCP(direction Direction, nack, pli, fir uint32) {
 	if nack > 0 {
 		promNackTotal.WithLabelValues(string(direction)).Add(float64(nack))
@@ -15,6 +15,8 @@
 package prometheus
 
 import (
+	"math"
+
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/livekit/protocol/livekit"
@@ -24,6 +26,9 @@ var (
 	qualityRating prometheus.Histogram
 	qualityScore  prometheus.Histogram
 	qualityDrop   *prometheus.CounterVec
+
+	qualitySubScore *prometheus.HistogramVec
+	avSyncSkew      prometheus.Histogram
 )
 
 func initQualityStats(nodeID string, nodeType livekit.NodeType, env string) {
@@ -48,9 +53,27 @@ func initQualityStats(nodeID string, nodeType livekit.NodeType, env string) {
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	}, []string{"direction"})
 
+	qualitySubScore = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "quality",
+		Name:        "sub_score",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Buckets:     []float64{0, 20, 40, 60, 70, 80, 85, 90, 95, 100},
+	}, []string{"direction", "kind"})
+
+	avSyncSkew = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "quality",
+		Name:        "av_sync_skew_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Buckets:     []float64{5, 10, 20, 40, 80, 120, 200, 400, 1000},
+	})
+
 	prometheus.MustRegister(qualityRating)
 	prometheus.MustRegister(qualityScore)
 	prometheus.MustRegister(qualityDrop)
+	prometheus.MustRegister(qualitySubScore)
+	prometheus.MustRegister(avSyncSkew)
 }
 
 func RecordQuality(rating livekit.ConnectionQuality, score float32, numUpDrops int, numDownDrops int) {
@@ -59,3 +82,22 @@ func RecordQuality(rating livekit.ConnectionQuality, score float32, numUpDrops i
 	qualityDrop.WithLabelValues("up").Add(float64(numUpDrops))
 	qualityDrop.WithLabelValues("down").Add(float64(numDownDrops))
 }
+
+// RecordQualitySubScores records the sub-scores of an analysis window of a track, to calibrate connection quality scoring
+func RecordQualitySubScores(direction string, loss, delay, bitrate, layer float64) {
+	if qualitySubScore == nil {
+		return
+	}
+	qualitySubScore.WithLabelValues(direction, "loss").Observe(loss)
+	qualitySubScore.WithLabelValues(direction, "delay").Observe(delay)
+	qualitySubScore.WithLabelValues(direction, "bitrate").Observe(bitrate)
+	qualitySubScore.WithLabelValues(direction, "layer").Observe(layer)
+}
+
+// RecordAVSyncSkew records the size of the audio/video sync skew of a publisher as forwarded to a subscriber, either way
+func RecordAVSyncSkew(skewMs float64) {
+	if avSyncSkew == nil {
+		return
+	}
+	avSyncSkew.Observe(math.Abs(skewMs))
+}
@@ -16,6 +16,7 @@ package prometheus
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -39,12 +40,21 @@ var (
 
 	promRoomCurrent            prometheus.Gauge
 	promRoomDuration           prometheus.Histogram
+	promRoomResourceLeaks      prometheus.Counter
 	promParticipantCurrent     prometheus.Gauge
+	promParticipantState       *prometheus.GaugeVec
+	promParticipantTransitions *prometheus.CounterVec
 	promTrackPublishedCurrent  *prometheus.GaugeVec
 	promTrackSubscribedCurrent *prometheus.GaugeVec
 	promTrackPublishCounter    *prometheus.CounterVec
 	promTrackSubscribeCounter  *prometheus.CounterVec
+	promDeprecatedCodecCounter *prometheus.CounterVec
+	promCodecRegressionCounter *prometheus.CounterVec
+	promICEConnectionCounter   *prometheus.CounterVec
 	promSessionStartTime       *prometheus.HistogramVec
+	promJoinStageTime          *prometheus.HistogramVec
+
+	promPayloadIntegrityErrorCounter *prometheus.CounterVec
 )
 
 func initRoomStats(nodeID string, nodeType livekit.NodeType, env string) {
@@ -63,12 +73,30 @@ func initRoomStats(nodeID string, nodeType livekit.NodeType, env string) {
 			5, 10, 60, 5 * 60, 10 * 60, 30 * 60, 60 * 60, 2 * 60 * 60, 5 * 60 * 60, 10 * 60 * 60,
 		},
 	})
+	promRoomResourceLeaks = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "room",
+		Name:        "resource_leaks",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	})
 	promParticipantCurrent = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "participant",
 		Name:        "total",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	})
+	promParticipantState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "participant",
+		Name:        "state_total",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"state"})
+	promParticipantTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "participant",
+		Name:        "state_transitions",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"from", "to"})
 	promTrackPublishedCurrent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "track",
@@ -93,6 +121,30 @@ func initRoomStats(nodeID string, nodeType livekit.NodeType, env string) {
 		Name:        "subscribe_counter",
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 	}, []string{"state", "error"})
+	promDeprecatedCodecCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "track",
+		Name:        "deprecated_codec_publishes",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"mime"})
+	promCodecRegressionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "track",
+		Name:        "subscribe_codec_regressions",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"from", "to", "reason"})
+	promICEConnectionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "ice",
+		Name:        "connections",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"transport", "connection_type", "failure_reason"})
+	promPayloadIntegrityErrorCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "track",
+		Name:        "payload_integrity_errors",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"mime", "reason"})
 	promSessionStartTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace:   livekitNamespace,
 		Subsystem:   "session",
@@ -100,15 +152,30 @@ func initRoomStats(nodeID string, nodeType livekit.NodeType, env string) {
 		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
 		Buckets:     prometheus.ExponentialBucketsRange(100, 10000, 15),
 	}, []string{"protocol_version"})
+	promJoinStageTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "participant",
+		Name:        "join_stage_ms",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+		Buckets:     prometheus.ExponentialBucketsRange(5, 10000, 15),
+	}, []string{"stage", "region"})
 
 	prometheus.MustRegister(promRoomCurrent)
 	prometheus.MustRegister(promRoomDuration)
+	prometheus.MustRegister(promRoomResourceLeaks)
 	prometheus.MustRegister(promParticipantCurrent)
+	prometheus.MustRegister(promParticipantState)
+	prometheus.MustRegister(promParticipantTransitions)
 	prometheus.MustRegister(promTrackPublishedCurrent)
 	prometheus.MustRegister(promTrackSubscribedCurrent)
 	prometheus.MustRegister(promTrackPublishCounter)
 	prometheus.MustRegister(promTrackSubscribeCounter)
+	prometheus.MustRegister(promDeprecatedCodecCounter)
+	prometheus.MustRegister(promCodecRegressionCounter)
+	prometheus.MustRegister(promICEConnectionCounter)
 	prometheus.MustRegister(promSessionStartTime)
+	prometheus.MustRegister(promJoinStageTime)
+	prometheus.MustRegister(promPayloadIntegrityErrorCounter)
 }
 
 func RoomStarted() {
@@ -124,6 +191,11 @@ func RoomEnded(startedAt time.Time) {
 	roomCurrent.Dec()
 }
 
+// RecordRoomResourceLeak counts rooms that closed with goroutines or timers still running
+func RecordRoomResourceLeak() {
+	promRoomResourceLeaks.Inc()
+}
+
 func AddParticipant() {
 	promParticipantCurrent.Add(1)
 	participantCurrent.Inc()
@@ -134,6 +206,18 @@ func SubParticipant() {
 	participantCurrent.Dec()
 }
 
+// RecordParticipantLifecycleTransition tracks the number of participants in each lifecycle state,
+// from is empty for a new participant. Disconnected participants are not counted.
+func RecordParticipantLifecycleTransition(from, to string) {
+	if from != "" {
+		promParticipantState.WithLabelValues(from).Sub(1)
+		promParticipantTransitions.WithLabelValues(from, to).Inc()
+	}
+	if to != "DISCONNECTED" {
+		promParticipantState.WithLabelValues(to).Add(1)
+	}
+}
+
 func AddPublishedTrack(kind string) {
 	promTrackPublishedCurrent.WithLabelValues(kind).Add(1)
 	trackPublishedCurrent.Inc()
@@ -154,6 +238,32 @@ func AddPublishSuccess(kind string) {
 	promTrackPublishCounter.WithLabelValues(kind, "success").Inc()
 }
 
+func AddDeprecatedCodecPublish(mime string) {
+	promDeprecatedCodecCounter.WithLabelValues(strings.ToLower(mime)).Inc()
+}
+
+// AddCodecRegression counts subscriptions moved to a backup codec after the subscriber failed to decode the track
+func AddCodecRegression(from string, to string, reason string) {
+	if promCodecRegressionCounter == nil {
+		return
+	}
+	promCodecRegressionCounter.WithLabelValues(strings.ToLower(from), strings.ToLower(to), reason).Inc()
+}
+
+// RecordICEConnection counts transports connecting by connection type, udp, tcp or turn, and transports failing
+// to connect by failure reason
+func RecordICEConnection(transport string, connectionType string, failureReason string) {
+	promICEConnectionCounter.WithLabelValues(transport, connectionType, failureReason).Inc()
+}
+
+// AddPayloadIntegrityErrors counts received video packets failing the checks of their payload, by reason
+func AddPayloadIntegrityErrors(mime string, reason string, count int) {
+	if promPayloadIntegrityErrorCounter == nil {
+		return
+	}
+	promPayloadIntegrityErrorCounter.WithLabelValues(strings.ToLower(mime), reason).Add(float64(count))
+}
+
 func RecordTrackSubscribeSuccess(kind string) {
 	// modify both current and total counters
 	promTrackSubscribedCurrent.WithLabelValues(kind).Add(1)
@@ -186,3 +296,11 @@ func RecordTrackSubscribeFailure(err error, isUserError bool) {
 func RecordSessionStartTime(protocolVersion int, d time.Duration) {
 	promSessionStartTime.WithLabelValues(strconv.Itoa(protocolVersion)).Observe(float64(d.Milliseconds()))
 }
+
+// RecordJoinStageTime observes the time a join spent in a stage, in the region the participant joined
+func RecordJoinStageTime(stage string, region string, d time.Duration) {
+	if promJoinStageTime == nil {
+		return
+	}
+	promJoinStageTime.WithLabelValues(stage, region).Observe(float64(d.Milliseconds()))
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promTelemetryExportRows   *prometheus.CounterVec
+	promTelemetryExportQueued prometheus.Gauge
+)
+
+func initTelemetryExportStats(nodeID string, nodeType livekit.NodeType, env string) {
+	promTelemetryExportRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "telemetry_export",
+		Name:        "rows",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	}, []string{"result"})
+	promTelemetryExportQueued = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "telemetry_export",
+		Name:        "queued_rows",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String(), "env": env},
+	})
+
+	prometheus.MustRegister(promTelemetryExportRows)
+	prometheus.MustRegister(promTelemetryExportQueued)
+}
+
+// RecordTelemetryExportRows counts rows of the telemetry exporter by result, exported, dropped because the queue
+// was full or failed after retries
+func RecordTelemetryExportRows(result string, count int) {
+	if promTelemetryExportRows == nil || count == 0 {
+		return
+	}
+	promTelemetryExportRows.WithLabelValues(result).Add(float64(count))
+}
+
+// RecordTelemetryExportQueued sets the number of rows waiting to be exported
+func RecordTelemetryExportQueued(queued int) {
+	if promTelemetryExportQueued == nil {
+		return
+	}
+	promTelemetryExportQueued.Set(float64(queued))
+}
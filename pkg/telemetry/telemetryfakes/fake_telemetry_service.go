@@ -4,12 +4,21 @@ package telemetryfakes
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/protocol/livekit"
 )
 
 type FakeTelemetryService struct {
+	ConnectionQualityAlertStub        func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *telemetry.ConnectionQualityAlert)
+	connectionQualityAlertMutex       sync.RWMutex
+	connectionQualityAlertArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *telemetry.ConnectionQualityAlert
+	}
 	EgressEndedStub        func(context.Context, *livekit.EgressInfo)
 	egressEndedMutex       sync.RWMutex
 	egressEndedArgsForCall []struct {
@@ -32,6 +41,15 @@ type FakeTelemetryService struct {
 	flushStatsMutex       sync.RWMutex
 	flushStatsArgsForCall []struct {
 	}
+	ICEConnectivityStub        func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.ClientInfo, *telemetry.ICEConnectivity)
+	iCEConnectivityMutex       sync.RWMutex
+	iCEConnectivityArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.ClientInfo
+		arg5 *telemetry.ICEConnectivity
+	}
 	IngressCreatedStub        func(context.Context, *livekit.IngressInfo)
 	ingressCreatedMutex       sync.RWMutex
 	ingressCreatedArgsForCall []struct {
@@ -68,6 +86,15 @@ type FakeTelemetryService struct {
 		arg1 context.Context
 		arg2 *livekit.AnalyticsNodeRooms
 	}
+	NegotiationFailedStub        func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.ClientInfo, *telemetry.NegotiationFailure)
+	negotiationFailedMutex       sync.RWMutex
+	negotiationFailedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.ClientInfo
+		arg5 *telemetry.NegotiationFailure
+	}
 	NotifyEventStub        func(context.Context, *livekit.WebhookEvent)
 	notifyEventMutex       sync.RWMutex
 	notifyEventArgsForCall []struct {
@@ -83,6 +110,33 @@ type FakeTelemetryService struct {
 		arg4 *livekit.AnalyticsClientMeta
 		arg5 bool
 	}
+	ParticipantAdmittedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo)
+	participantAdmittedMutex       sync.RWMutex
+	participantAdmittedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}
+	ParticipantJoinCompletedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.ClientInfo, *livekit.AnalyticsClientMeta, time.Time, telemetry.JoinLatency)
+	participantJoinCompletedMutex       sync.RWMutex
+	participantJoinCompletedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 *livekit.ClientInfo
+		arg5 *livekit.AnalyticsClientMeta
+		arg6 time.Time
+		arg7 telemetry.JoinLatency
+	}
+	ParticipantJoinRestrictedStub        func(context.Context, livekit.RoomName, livekit.ParticipantIdentity, *livekit.ClientInfo, *telemetry.JoinRestriction)
+	participantJoinRestrictedMutex       sync.RWMutex
+	participantJoinRestrictedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.ClientInfo
+		arg5 *telemetry.JoinRestriction
+	}
 	ParticipantJoinedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.ClientInfo, *livekit.AnalyticsClientMeta, bool)
 	participantJoinedMutex       sync.RWMutex
 	participantJoinedArgsForCall []struct {
@@ -101,6 +155,13 @@ type FakeTelemetryService struct {
 		arg3 *livekit.ParticipantInfo
 		arg4 bool
 	}
+	ParticipantPendingStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo)
+	participantPendingMutex       sync.RWMutex
+	participantPendingArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}
 	ParticipantResumedStub        func(context.Context, *livekit.Room, *livekit.ParticipantInfo, livekit.NodeID, livekit.ReconnectReason)
 	participantResumedMutex       sync.RWMutex
 	participantResumedArgsForCall []struct {
@@ -110,12 +171,32 @@ type FakeTelemetryService struct {
 		arg4 livekit.NodeID
 		arg5 livekit.ReconnectReason
 	}
+	RoomActivatedStub        func(context.Context, *livekit.Room)
+	roomActivatedMutex       sync.RWMutex
+	roomActivatedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}
 	RoomEndedStub        func(context.Context, *livekit.Room)
 	roomEndedMutex       sync.RWMutex
 	roomEndedArgsForCall []struct {
 		arg1 context.Context
 		arg2 *livekit.Room
 	}
+	RoomExpiredStub        func(context.Context, *livekit.Room)
+	roomExpiredMutex       sync.RWMutex
+	roomExpiredArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}
+	RoomMergedStub        func(context.Context, *livekit.Room, livekit.RoomName, []livekit.ParticipantIdentity)
+	roomMergedMutex       sync.RWMutex
+	roomMergedArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 livekit.RoomName
+		arg4 []livekit.ParticipantIdentity
+	}
 	RoomStartedStub        func(context.Context, *livekit.Room)
 	roomStartedMutex       sync.RWMutex
 	roomStartedArgsForCall []struct {
@@ -140,6 +221,19 @@ type FakeTelemetryService struct {
 		arg1 context.Context
 		arg2 []*livekit.AnalyticsStat
 	}
+	StopStub        func()
+	stopMutex       sync.RWMutex
+	stopArgsForCall []struct {
+	}
+	TrackCodecDeprecatedStub        func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, string)
+	trackCodecDeprecatedMutex       sync.RWMutex
+	trackCodecDeprecatedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.TrackInfo
+		arg5 string
+	}
 	TrackMaxSubscribedVideoQualityStub        func(context.Context, livekit.ParticipantID, *livekit.TrackInfo, string, livekit.VideoQuality)
 	trackMaxSubscribedVideoQualityMutex       sync.RWMutex
 	trackMaxSubscribedVideoQualityArgsForCall []struct {
@@ -156,6 +250,15 @@ type FakeTelemetryService struct {
 		arg2 livekit.ParticipantID
 		arg3 *livekit.TrackInfo
 	}
+	TrackPayloadIntegrityStub        func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, *telemetry.TrackPayloadIntegrity)
+	trackPayloadIntegrityMutex       sync.RWMutex
+	trackPayloadIntegrityArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.TrackInfo
+		arg5 *telemetry.TrackPayloadIntegrity
+	}
 	TrackPublishRTPStatsStub        func(context.Context, livekit.ParticipantID, livekit.TrackID, string, int, *livekit.RTPStats)
 	trackPublishRTPStatsMutex       sync.RWMutex
 	trackPublishRTPStatsArgsForCall []struct {
@@ -195,6 +298,15 @@ type FakeTelemetryService struct {
 		arg1 telemetry.StatsKey
 		arg2 *livekit.AnalyticsStat
 	}
+	TrackSubscribeCodecRegressedStub        func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, *telemetry.CodecRegression)
+	trackSubscribeCodecRegressedMutex       sync.RWMutex
+	trackSubscribeCodecRegressedArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.TrackInfo
+		arg5 *telemetry.CodecRegression
+	}
 	TrackSubscribeFailedStub        func(context.Context, livekit.ParticipantID, livekit.TrackID, error, bool)
 	trackSubscribeFailedMutex       sync.RWMutex
 	trackSubscribeFailedArgsForCall []struct {
@@ -229,6 +341,15 @@ type FakeTelemetryService struct {
 		arg4 *livekit.ParticipantInfo
 		arg5 bool
 	}
+	TrackSubscriberCountStub        func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, int)
+	trackSubscriberCountMutex       sync.RWMutex
+	trackSubscriberCountArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.TrackInfo
+		arg5 int
+	}
 	TrackUnmutedStub        func(context.Context, livekit.ParticipantID, *livekit.TrackInfo)
 	trackUnmutedMutex       sync.RWMutex
 	trackUnmutedArgsForCall []struct {
@@ -257,6 +378,41 @@ type FakeTelemetryService struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeTelemetryService) ConnectionQualityAlert(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.ParticipantIdentity, arg4 *telemetry.ConnectionQualityAlert) {
+	fake.connectionQualityAlertMutex.Lock()
+	fake.connectionQualityAlertArgsForCall = append(fake.connectionQualityAlertArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *telemetry.ConnectionQualityAlert
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.ConnectionQualityAlertStub
+	fake.recordInvocation("ConnectionQualityAlert", []interface{}{arg1, arg2, arg3, arg4})
+	fake.connectionQualityAlertMutex.Unlock()
+	if stub != nil {
+		fake.ConnectionQualityAlertStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTelemetryService) ConnectionQualityAlertCallCount() int {
+	fake.connectionQualityAlertMutex.RLock()
+	defer fake.connectionQualityAlertMutex.RUnlock()
+	return len(fake.connectionQualityAlertArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ConnectionQualityAlertCalls(stub func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *telemetry.ConnectionQualityAlert)) {
+	fake.connectionQualityAlertMutex.Lock()
+	defer fake.connectionQualityAlertMutex.Unlock()
+	fake.ConnectionQualityAlertStub = stub
+}
+
+func (fake *FakeTelemetryService) ConnectionQualityAlertArgsForCall(i int) (context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *telemetry.ConnectionQualityAlert) {
+	fake.connectionQualityAlertMutex.RLock()
+	defer fake.connectionQualityAlertMutex.RUnlock()
+	argsForCall := fake.connectionQualityAlertArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
 func (fake *FakeTelemetryService) EgressEnded(arg1 context.Context, arg2 *livekit.EgressInfo) {
 	fake.egressEndedMutex.Lock()
 	fake.egressEndedArgsForCall = append(fake.egressEndedArgsForCall, struct {
@@ -380,6 +536,42 @@ func (fake *FakeTelemetryService) FlushStatsCalls(stub func()) {
 	fake.FlushStatsStub = stub
 }
 
+func (fake *FakeTelemetryService) ICEConnectivity(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.ParticipantIdentity, arg4 *livekit.ClientInfo, arg5 *telemetry.ICEConnectivity) {
+	fake.iCEConnectivityMutex.Lock()
+	fake.iCEConnectivityArgsForCall = append(fake.iCEConnectivityArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.ClientInfo
+		arg5 *telemetry.ICEConnectivity
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.ICEConnectivityStub
+	fake.recordInvocation("ICEConnectivity", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.iCEConnectivityMutex.Unlock()
+	if stub != nil {
+		fake.ICEConnectivityStub(arg1, arg2, arg3, arg4, arg5)
+	}
+}
+
+func (fake *FakeTelemetryService) ICEConnectivityCallCount() int {
+	fake.iCEConnectivityMutex.RLock()
+	defer fake.iCEConnectivityMutex.RUnlock()
+	return len(fake.iCEConnectivityArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ICEConnectivityCalls(stub func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.ClientInfo, *telemetry.ICEConnectivity)) {
+	fake.iCEConnectivityMutex.Lock()
+	defer fake.iCEConnectivityMutex.Unlock()
+	fake.ICEConnectivityStub = stub
+}
+
+func (fake *FakeTelemetryService) ICEConnectivityArgsForCall(i int) (context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.ClientInfo, *telemetry.ICEConnectivity) {
+	fake.iCEConnectivityMutex.RLock()
+	defer fake.iCEConnectivityMutex.RUnlock()
+	argsForCall := fake.iCEConnectivityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
 func (fake *FakeTelemetryService) IngressCreated(arg1 context.Context, arg2 *livekit.IngressInfo) {
 	fake.ingressCreatedMutex.Lock()
 	fake.ingressCreatedArgsForCall = append(fake.ingressCreatedArgsForCall, struct {
@@ -578,6 +770,42 @@ func (fake *FakeTelemetryService) LocalRoomStateArgsForCall(i int) (context.Cont
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeTelemetryService) NegotiationFailed(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.ParticipantIdentity, arg4 *livekit.ClientInfo, arg5 *telemetry.NegotiationFailure) {
+	fake.negotiationFailedMutex.Lock()
+	fake.negotiationFailedArgsForCall = append(fake.negotiationFailedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.ClientInfo
+		arg5 *telemetry.NegotiationFailure
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.NegotiationFailedStub
+	fake.recordInvocation("NegotiationFailed", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.negotiationFailedMutex.Unlock()
+	if stub != nil {
+		fake.NegotiationFailedStub(arg1, arg2, arg3, arg4, arg5)
+	}
+}
+
+func (fake *FakeTelemetryService) NegotiationFailedCallCount() int {
+	fake.negotiationFailedMutex.RLock()
+	defer fake.negotiationFailedMutex.RUnlock()
+	return len(fake.negotiationFailedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) NegotiationFailedCalls(stub func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.ClientInfo, *telemetry.NegotiationFailure)) {
+	fake.negotiationFailedMutex.Lock()
+	defer fake.negotiationFailedMutex.Unlock()
+	fake.NegotiationFailedStub = stub
+}
+
+func (fake *FakeTelemetryService) NegotiationFailedArgsForCall(i int) (context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.ClientInfo, *telemetry.NegotiationFailure) {
+	fake.negotiationFailedMutex.RLock()
+	defer fake.negotiationFailedMutex.RUnlock()
+	argsForCall := fake.negotiationFailedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
 func (fake *FakeTelemetryService) NotifyEvent(arg1 context.Context, arg2 *livekit.WebhookEvent) {
 	fake.notifyEventMutex.Lock()
 	fake.notifyEventArgsForCall = append(fake.notifyEventArgsForCall, struct {
@@ -647,6 +875,114 @@ func (fake *FakeTelemetryService) ParticipantActiveArgsForCall(i int) (context.C
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
+func (fake *FakeTelemetryService) ParticipantAdmitted(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo) {
+	fake.participantAdmittedMutex.Lock()
+	fake.participantAdmittedArgsForCall = append(fake.participantAdmittedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}{arg1, arg2, arg3})
+	stub := fake.ParticipantAdmittedStub
+	fake.recordInvocation("ParticipantAdmitted", []interface{}{arg1, arg2, arg3})
+	fake.participantAdmittedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantAdmittedStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantAdmittedCallCount() int {
+	fake.participantAdmittedMutex.RLock()
+	defer fake.participantAdmittedMutex.RUnlock()
+	return len(fake.participantAdmittedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantAdmittedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo)) {
+	fake.participantAdmittedMutex.Lock()
+	defer fake.participantAdmittedMutex.Unlock()
+	fake.ParticipantAdmittedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantAdmittedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo) {
+	fake.participantAdmittedMutex.RLock()
+	defer fake.participantAdmittedMutex.RUnlock()
+	argsForCall := fake.participantAdmittedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeTelemetryService) ParticipantJoinCompleted(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 *livekit.ClientInfo, arg5 *livekit.AnalyticsClientMeta, arg6 time.Time, arg7 telemetry.JoinLatency) {
+	fake.participantJoinCompletedMutex.Lock()
+	fake.participantJoinCompletedArgsForCall = append(fake.participantJoinCompletedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+		arg4 *livekit.ClientInfo
+		arg5 *livekit.AnalyticsClientMeta
+		arg6 time.Time
+		arg7 telemetry.JoinLatency
+	}{arg1, arg2, arg3, arg4, arg5, arg6, arg7})
+	stub := fake.ParticipantJoinCompletedStub
+	fake.recordInvocation("ParticipantJoinCompleted", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6, arg7})
+	fake.participantJoinCompletedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantJoinCompletedStub(arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantJoinCompletedCallCount() int {
+	fake.participantJoinCompletedMutex.RLock()
+	defer fake.participantJoinCompletedMutex.RUnlock()
+	return len(fake.participantJoinCompletedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantJoinCompletedCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.ClientInfo, *livekit.AnalyticsClientMeta, time.Time, telemetry.JoinLatency)) {
+	fake.participantJoinCompletedMutex.Lock()
+	defer fake.participantJoinCompletedMutex.Unlock()
+	fake.ParticipantJoinCompletedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantJoinCompletedArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo, *livekit.ClientInfo, *livekit.AnalyticsClientMeta, time.Time, telemetry.JoinLatency) {
+	fake.participantJoinCompletedMutex.RLock()
+	defer fake.participantJoinCompletedMutex.RUnlock()
+	argsForCall := fake.participantJoinCompletedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6, argsForCall.arg7
+}
+
+func (fake *FakeTelemetryService) ParticipantJoinRestricted(arg1 context.Context, arg2 livekit.RoomName, arg3 livekit.ParticipantIdentity, arg4 *livekit.ClientInfo, arg5 *telemetry.JoinRestriction) {
+	fake.participantJoinRestrictedMutex.Lock()
+	fake.participantJoinRestrictedArgsForCall = append(fake.participantJoinRestrictedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.ClientInfo
+		arg5 *telemetry.JoinRestriction
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.ParticipantJoinRestrictedStub
+	fake.recordInvocation("ParticipantJoinRestricted", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.participantJoinRestrictedMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantJoinRestrictedStub(arg1, arg2, arg3, arg4, arg5)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantJoinRestrictedCallCount() int {
+	fake.participantJoinRestrictedMutex.RLock()
+	defer fake.participantJoinRestrictedMutex.RUnlock()
+	return len(fake.participantJoinRestrictedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantJoinRestrictedCalls(stub func(context.Context, livekit.RoomName, livekit.ParticipantIdentity, *livekit.ClientInfo, *telemetry.JoinRestriction)) {
+	fake.participantJoinRestrictedMutex.Lock()
+	defer fake.participantJoinRestrictedMutex.Unlock()
+	fake.ParticipantJoinRestrictedStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantJoinRestrictedArgsForCall(i int) (context.Context, livekit.RoomName, livekit.ParticipantIdentity, *livekit.ClientInfo, *telemetry.JoinRestriction) {
+	fake.participantJoinRestrictedMutex.RLock()
+	defer fake.participantJoinRestrictedMutex.RUnlock()
+	argsForCall := fake.participantJoinRestrictedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
 func (fake *FakeTelemetryService) ParticipantJoined(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 *livekit.ClientInfo, arg5 *livekit.AnalyticsClientMeta, arg6 bool) {
 	fake.participantJoinedMutex.Lock()
 	fake.participantJoinedArgsForCall = append(fake.participantJoinedArgsForCall, struct {
@@ -719,6 +1055,40 @@ func (fake *FakeTelemetryService) ParticipantLeftArgsForCall(i int) (context.Con
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
+func (fake *FakeTelemetryService) ParticipantPending(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo) {
+	fake.participantPendingMutex.Lock()
+	fake.participantPendingArgsForCall = append(fake.participantPendingArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 *livekit.ParticipantInfo
+	}{arg1, arg2, arg3})
+	stub := fake.ParticipantPendingStub
+	fake.recordInvocation("ParticipantPending", []interface{}{arg1, arg2, arg3})
+	fake.participantPendingMutex.Unlock()
+	if stub != nil {
+		fake.ParticipantPendingStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeTelemetryService) ParticipantPendingCallCount() int {
+	fake.participantPendingMutex.RLock()
+	defer fake.participantPendingMutex.RUnlock()
+	return len(fake.participantPendingArgsForCall)
+}
+
+func (fake *FakeTelemetryService) ParticipantPendingCalls(stub func(context.Context, *livekit.Room, *livekit.ParticipantInfo)) {
+	fake.participantPendingMutex.Lock()
+	defer fake.participantPendingMutex.Unlock()
+	fake.ParticipantPendingStub = stub
+}
+
+func (fake *FakeTelemetryService) ParticipantPendingArgsForCall(i int) (context.Context, *livekit.Room, *livekit.ParticipantInfo) {
+	fake.participantPendingMutex.RLock()
+	defer fake.participantPendingMutex.RUnlock()
+	argsForCall := fake.participantPendingArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
 func (fake *FakeTelemetryService) ParticipantResumed(arg1 context.Context, arg2 *livekit.Room, arg3 *livekit.ParticipantInfo, arg4 livekit.NodeID, arg5 livekit.ReconnectReason) {
 	fake.participantResumedMutex.Lock()
 	fake.participantResumedArgsForCall = append(fake.participantResumedArgsForCall, struct {
@@ -755,6 +1125,39 @@ func (fake *FakeTelemetryService) ParticipantResumedArgsForCall(i int) (context.
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
+func (fake *FakeTelemetryService) RoomActivated(arg1 context.Context, arg2 *livekit.Room) {
+	fake.roomActivatedMutex.Lock()
+	fake.roomActivatedArgsForCall = append(fake.roomActivatedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}{arg1, arg2})
+	stub := fake.RoomActivatedStub
+	fake.recordInvocation("RoomActivated", []interface{}{arg1, arg2})
+	fake.roomActivatedMutex.Unlock()
+	if stub != nil {
+		fake.RoomActivatedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeTelemetryService) RoomActivatedCallCount() int {
+	fake.roomActivatedMutex.RLock()
+	defer fake.roomActivatedMutex.RUnlock()
+	return len(fake.roomActivatedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) RoomActivatedCalls(stub func(context.Context, *livekit.Room)) {
+	fake.roomActivatedMutex.Lock()
+	defer fake.roomActivatedMutex.Unlock()
+	fake.RoomActivatedStub = stub
+}
+
+func (fake *FakeTelemetryService) RoomActivatedArgsForCall(i int) (context.Context, *livekit.Room) {
+	fake.roomActivatedMutex.RLock()
+	defer fake.roomActivatedMutex.RUnlock()
+	argsForCall := fake.roomActivatedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeTelemetryService) RoomEnded(arg1 context.Context, arg2 *livekit.Room) {
 	fake.roomEndedMutex.Lock()
 	fake.roomEndedArgsForCall = append(fake.roomEndedArgsForCall, struct {
@@ -788,6 +1191,79 @@ func (fake *FakeTelemetryService) RoomEndedArgsForCall(i int) (context.Context,
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeTelemetryService) RoomExpired(arg1 context.Context, arg2 *livekit.Room) {
+	fake.roomExpiredMutex.Lock()
+	fake.roomExpiredArgsForCall = append(fake.roomExpiredArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}{arg1, arg2})
+	stub := fake.RoomExpiredStub
+	fake.recordInvocation("RoomExpired", []interface{}{arg1, arg2})
+	fake.roomExpiredMutex.Unlock()
+	if stub != nil {
+		fake.RoomExpiredStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeTelemetryService) RoomExpiredCallCount() int {
+	fake.roomExpiredMutex.RLock()
+	defer fake.roomExpiredMutex.RUnlock()
+	return len(fake.roomExpiredArgsForCall)
+}
+
+func (fake *FakeTelemetryService) RoomExpiredCalls(stub func(context.Context, *livekit.Room)) {
+	fake.roomExpiredMutex.Lock()
+	defer fake.roomExpiredMutex.Unlock()
+	fake.RoomExpiredStub = stub
+}
+
+func (fake *FakeTelemetryService) RoomExpiredArgsForCall(i int) (context.Context, *livekit.Room) {
+	fake.roomExpiredMutex.RLock()
+	defer fake.roomExpiredMutex.RUnlock()
+	argsForCall := fake.roomExpiredArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTelemetryService) RoomMerged(arg1 context.Context, arg2 *livekit.Room, arg3 livekit.RoomName, arg4 []livekit.ParticipantIdentity) {
+	var arg4Copy []livekit.ParticipantIdentity
+	if arg4 != nil {
+		arg4Copy = make([]livekit.ParticipantIdentity, len(arg4))
+		copy(arg4Copy, arg4)
+	}
+	fake.roomMergedMutex.Lock()
+	fake.roomMergedArgsForCall = append(fake.roomMergedArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+		arg3 livekit.RoomName
+		arg4 []livekit.ParticipantIdentity
+	}{arg1, arg2, arg3, arg4Copy})
+	stub := fake.RoomMergedStub
+	fake.recordInvocation("RoomMerged", []interface{}{arg1, arg2, arg3, arg4Copy})
+	fake.roomMergedMutex.Unlock()
+	if stub != nil {
+		fake.RoomMergedStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTelemetryService) RoomMergedCallCount() int {
+	fake.roomMergedMutex.RLock()
+	defer fake.roomMergedMutex.RUnlock()
+	return len(fake.roomMergedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) RoomMergedCalls(stub func(context.Context, *livekit.Room, livekit.RoomName, []livekit.ParticipantIdentity)) {
+	fake.roomMergedMutex.Lock()
+	defer fake.roomMergedMutex.Unlock()
+	fake.RoomMergedStub = stub
+}
+
+func (fake *FakeTelemetryService) RoomMergedArgsForCall(i int) (context.Context, *livekit.Room, livekit.RoomName, []livekit.ParticipantIdentity) {
+	fake.roomMergedMutex.RLock()
+	defer fake.roomMergedMutex.RUnlock()
+	argsForCall := fake.roomMergedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
 func (fake *FakeTelemetryService) RoomStarted(arg1 context.Context, arg2 *livekit.Room) {
 	fake.roomStartedMutex.Lock()
 	fake.roomStartedArgsForCall = append(fake.roomStartedArgsForCall, struct {
@@ -925,6 +1401,66 @@ func (fake *FakeTelemetryService) SendStatsArgsForCall(i int) (context.Context,
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeTelemetryService) Stop() {
+	fake.stopMutex.Lock()
+	fake.stopArgsForCall = append(fake.stopArgsForCall, struct {
+	}{})
+	stub := fake.StopStub
+	fake.recordInvocation("Stop", []interface{}{})
+	fake.stopMutex.Unlock()
+	if stub != nil {
+		fake.StopStub()
+	}
+}
+
+func (fake *FakeTelemetryService) StopCallCount() int {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return len(fake.stopArgsForCall)
+}
+
+func (fake *FakeTelemetryService) StopCalls(stub func()) {
+	fake.stopMutex.Lock()
+	defer fake.stopMutex.Unlock()
+	fake.StopStub = stub
+}
+
+func (fake *FakeTelemetryService) TrackCodecDeprecated(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.ParticipantIdentity, arg4 *livekit.TrackInfo, arg5 string) {
+	fake.trackCodecDeprecatedMutex.Lock()
+	fake.trackCodecDeprecatedArgsForCall = append(fake.trackCodecDeprecatedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.TrackInfo
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.TrackCodecDeprecatedStub
+	fake.recordInvocation("TrackCodecDeprecated", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.trackCodecDeprecatedMutex.Unlock()
+	if stub != nil {
+		fake.TrackCodecDeprecatedStub(arg1, arg2, arg3, arg4, arg5)
+	}
+}
+
+func (fake *FakeTelemetryService) TrackCodecDeprecatedCallCount() int {
+	fake.trackCodecDeprecatedMutex.RLock()
+	defer fake.trackCodecDeprecatedMutex.RUnlock()
+	return len(fake.trackCodecDeprecatedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) TrackCodecDeprecatedCalls(stub func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, string)) {
+	fake.trackCodecDeprecatedMutex.Lock()
+	defer fake.trackCodecDeprecatedMutex.Unlock()
+	fake.TrackCodecDeprecatedStub = stub
+}
+
+func (fake *FakeTelemetryService) TrackCodecDeprecatedArgsForCall(i int) (context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, string) {
+	fake.trackCodecDeprecatedMutex.RLock()
+	defer fake.trackCodecDeprecatedMutex.RUnlock()
+	argsForCall := fake.trackCodecDeprecatedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
 func (fake *FakeTelemetryService) TrackMaxSubscribedVideoQuality(arg1 context.Context, arg2 livekit.ParticipantID, arg3 *livekit.TrackInfo, arg4 string, arg5 livekit.VideoQuality) {
 	fake.trackMaxSubscribedVideoQualityMutex.Lock()
 	fake.trackMaxSubscribedVideoQualityArgsForCall = append(fake.trackMaxSubscribedVideoQualityArgsForCall, struct {
@@ -995,6 +1531,42 @@ func (fake *FakeTelemetryService) TrackMutedArgsForCall(i int) (context.Context,
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
+func (fake *FakeTelemetryService) TrackPayloadIntegrity(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.ParticipantIdentity, arg4 *livekit.TrackInfo, arg5 *telemetry.TrackPayloadIntegrity) {
+	fake.trackPayloadIntegrityMutex.Lock()
+	fake.trackPayloadIntegrityArgsForCall = append(fake.trackPayloadIntegrityArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.TrackInfo
+		arg5 *telemetry.TrackPayloadIntegrity
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.TrackPayloadIntegrityStub
+	fake.recordInvocation("TrackPayloadIntegrity", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.trackPayloadIntegrityMutex.Unlock()
+	if stub != nil {
+		fake.TrackPayloadIntegrityStub(arg1, arg2, arg3, arg4, arg5)
+	}
+}
+
+func (fake *FakeTelemetryService) TrackPayloadIntegrityCallCount() int {
+	fake.trackPayloadIntegrityMutex.RLock()
+	defer fake.trackPayloadIntegrityMutex.RUnlock()
+	return len(fake.trackPayloadIntegrityArgsForCall)
+}
+
+func (fake *FakeTelemetryService) TrackPayloadIntegrityCalls(stub func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, *telemetry.TrackPayloadIntegrity)) {
+	fake.trackPayloadIntegrityMutex.Lock()
+	defer fake.trackPayloadIntegrityMutex.Unlock()
+	fake.TrackPayloadIntegrityStub = stub
+}
+
+func (fake *FakeTelemetryService) TrackPayloadIntegrityArgsForCall(i int) (context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, *telemetry.TrackPayloadIntegrity) {
+	fake.trackPayloadIntegrityMutex.RLock()
+	defer fake.trackPayloadIntegrityMutex.RUnlock()
+	argsForCall := fake.trackPayloadIntegrityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
 func (fake *FakeTelemetryService) TrackPublishRTPStats(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.TrackID, arg4 string, arg5 int, arg6 *livekit.RTPStats) {
 	fake.trackPublishRTPStatsMutex.Lock()
 	fake.trackPublishRTPStatsArgsForCall = append(fake.trackPublishRTPStatsArgsForCall, struct {
@@ -1169,6 +1741,42 @@ func (fake *FakeTelemetryService) TrackStatsArgsForCall(i int) (telemetry.StatsK
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeTelemetryService) TrackSubscribeCodecRegressed(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.ParticipantIdentity, arg4 *livekit.TrackInfo, arg5 *telemetry.CodecRegression) {
+	fake.trackSubscribeCodecRegressedMutex.Lock()
+	fake.trackSubscribeCodecRegressedArgsForCall = append(fake.trackSubscribeCodecRegressedArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.TrackInfo
+		arg5 *telemetry.CodecRegression
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.TrackSubscribeCodecRegressedStub
+	fake.recordInvocation("TrackSubscribeCodecRegressed", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.trackSubscribeCodecRegressedMutex.Unlock()
+	if stub != nil {
+		fake.TrackSubscribeCodecRegressedStub(arg1, arg2, arg3, arg4, arg5)
+	}
+}
+
+func (fake *FakeTelemetryService) TrackSubscribeCodecRegressedCallCount() int {
+	fake.trackSubscribeCodecRegressedMutex.RLock()
+	defer fake.trackSubscribeCodecRegressedMutex.RUnlock()
+	return len(fake.trackSubscribeCodecRegressedArgsForCall)
+}
+
+func (fake *FakeTelemetryService) TrackSubscribeCodecRegressedCalls(stub func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, *telemetry.CodecRegression)) {
+	fake.trackSubscribeCodecRegressedMutex.Lock()
+	defer fake.trackSubscribeCodecRegressedMutex.Unlock()
+	fake.TrackSubscribeCodecRegressedStub = stub
+}
+
+func (fake *FakeTelemetryService) TrackSubscribeCodecRegressedArgsForCall(i int) (context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, *telemetry.CodecRegression) {
+	fake.trackSubscribeCodecRegressedMutex.RLock()
+	defer fake.trackSubscribeCodecRegressedMutex.RUnlock()
+	argsForCall := fake.trackSubscribeCodecRegressedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
 func (fake *FakeTelemetryService) TrackSubscribeFailed(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.TrackID, arg4 error, arg5 bool) {
 	fake.trackSubscribeFailedMutex.Lock()
 	fake.trackSubscribeFailedArgsForCall = append(fake.trackSubscribeFailedArgsForCall, struct {
@@ -1311,6 +1919,42 @@ func (fake *FakeTelemetryService) TrackSubscribedArgsForCall(i int) (context.Con
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
+func (fake *FakeTelemetryService) TrackSubscriberCount(arg1 context.Context, arg2 livekit.ParticipantID, arg3 livekit.ParticipantIdentity, arg4 *livekit.TrackInfo, arg5 int) {
+	fake.trackSubscriberCountMutex.Lock()
+	fake.trackSubscriberCountArgsForCall = append(fake.trackSubscriberCountArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.ParticipantID
+		arg3 livekit.ParticipantIdentity
+		arg4 *livekit.TrackInfo
+		arg5 int
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.TrackSubscriberCountStub
+	fake.recordInvocation("TrackSubscriberCount", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.trackSubscriberCountMutex.Unlock()
+	if stub != nil {
+		fake.TrackSubscriberCountStub(arg1, arg2, arg3, arg4, arg5)
+	}
+}
+
+func (fake *FakeTelemetryService) TrackSubscriberCountCallCount() int {
+	fake.trackSubscriberCountMutex.RLock()
+	defer fake.trackSubscriberCountMutex.RUnlock()
+	return len(fake.trackSubscriberCountArgsForCall)
+}
+
+func (fake *FakeTelemetryService) TrackSubscriberCountCalls(stub func(context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, int)) {
+	fake.trackSubscriberCountMutex.Lock()
+	defer fake.trackSubscriberCountMutex.Unlock()
+	fake.TrackSubscriberCountStub = stub
+}
+
+func (fake *FakeTelemetryService) TrackSubscriberCountArgsForCall(i int) (context.Context, livekit.ParticipantID, livekit.ParticipantIdentity, *livekit.TrackInfo, int) {
+	fake.trackSubscriberCountMutex.RLock()
+	defer fake.trackSubscriberCountMutex.RUnlock()
+	argsForCall := fake.trackSubscriberCountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
 func (fake *FakeTelemetryService) TrackUnmuted(arg1 context.Context, arg2 livekit.ParticipantID, arg3 *livekit.TrackInfo) {
 	fake.trackUnmutedMutex.Lock()
 	fake.trackUnmutedArgsForCall = append(fake.trackUnmutedArgsForCall, struct {
@@ -1419,6 +2063,8 @@ func (fake *FakeTelemetryService) TrackUnsubscribedArgsForCall(i int) (context.C
 func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.connectionQualityAlertMutex.RLock()
+	defer fake.connectionQualityAlertMutex.RUnlock()
 	fake.egressEndedMutex.RLock()
 	defer fake.egressEndedMutex.RUnlock()
 	fake.egressStartedMutex.RLock()
@@ -1427,6 +2073,8 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.egressUpdatedMutex.RUnlock()
 	fake.flushStatsMutex.RLock()
 	defer fake.flushStatsMutex.RUnlock()
+	fake.iCEConnectivityMutex.RLock()
+	defer fake.iCEConnectivityMutex.RUnlock()
 	fake.ingressCreatedMutex.RLock()
 	defer fake.ingressCreatedMutex.RUnlock()
 	fake.ingressDeletedMutex.RLock()
@@ -1439,18 +2087,34 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.ingressUpdatedMutex.RUnlock()
 	fake.localRoomStateMutex.RLock()
 	defer fake.localRoomStateMutex.RUnlock()
+	fake.negotiationFailedMutex.RLock()
+	defer fake.negotiationFailedMutex.RUnlock()
 	fake.notifyEventMutex.RLock()
 	defer fake.notifyEventMutex.RUnlock()
 	fake.participantActiveMutex.RLock()
 	defer fake.participantActiveMutex.RUnlock()
+	fake.participantAdmittedMutex.RLock()
+	defer fake.participantAdmittedMutex.RUnlock()
+	fake.participantJoinCompletedMutex.RLock()
+	defer fake.participantJoinCompletedMutex.RUnlock()
+	fake.participantJoinRestrictedMutex.RLock()
+	defer fake.participantJoinRestrictedMutex.RUnlock()
 	fake.participantJoinedMutex.RLock()
 	defer fake.participantJoinedMutex.RUnlock()
 	fake.participantLeftMutex.RLock()
 	defer fake.participantLeftMutex.RUnlock()
+	fake.participantPendingMutex.RLock()
+	defer fake.participantPendingMutex.RUnlock()
 	fake.participantResumedMutex.RLock()
 	defer fake.participantResumedMutex.RUnlock()
+	fake.roomActivatedMutex.RLock()
+	defer fake.roomActivatedMutex.RUnlock()
 	fake.roomEndedMutex.RLock()
 	defer fake.roomEndedMutex.RUnlock()
+	fake.roomExpiredMutex.RLock()
+	defer fake.roomExpiredMutex.RUnlock()
+	fake.roomMergedMutex.RLock()
+	defer fake.roomMergedMutex.RUnlock()
 	fake.roomStartedMutex.RLock()
 	defer fake.roomStartedMutex.RUnlock()
 	fake.sendEventMutex.RLock()
@@ -1459,10 +2123,16 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.sendNodeRoomStatesMutex.RUnlock()
 	fake.sendStatsMutex.RLock()
 	defer fake.sendStatsMutex.RUnlock()
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	fake.trackCodecDeprecatedMutex.RLock()
+	defer fake.trackCodecDeprecatedMutex.RUnlock()
 	fake.trackMaxSubscribedVideoQualityMutex.RLock()
 	defer fake.trackMaxSubscribedVideoQualityMutex.RUnlock()
 	fake.trackMutedMutex.RLock()
 	defer fake.trackMutedMutex.RUnlock()
+	fake.trackPayloadIntegrityMutex.RLock()
+	defer fake.trackPayloadIntegrityMutex.RUnlock()
 	fake.trackPublishRTPStatsMutex.RLock()
 	defer fake.trackPublishRTPStatsMutex.RUnlock()
 	fake.trackPublishRequestedMutex.RLock()
@@ -1473,6 +2143,8 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.trackPublishedUpdateMutex.RUnlock()
 	fake.trackStatsMutex.RLock()
 	defer fake.trackStatsMutex.RUnlock()
+	fake.trackSubscribeCodecRegressedMutex.RLock()
+	defer fake.trackSubscribeCodecRegressedMutex.RUnlock()
 	fake.trackSubscribeFailedMutex.RLock()
 	defer fake.trackSubscribeFailedMutex.RUnlock()
 	fake.trackSubscribeRTPStatsMutex.RLock()
@@ -1481,6 +2153,8 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.trackSubscribeRequestedMutex.RUnlock()
 	fake.trackSubscribedMutex.RLock()
 	defer fake.trackSubscribedMutex.RUnlock()
+	fake.trackSubscriberCountMutex.RLock()
+	defer fake.trackSubscriberCountMutex.RUnlock()
 	fake.trackUnmutedMutex.RLock()
 	defer fake.trackUnmutedMutex.RUnlock()
 	fake.trackUnpublishedMutex.RLock()
@@ -35,18 +35,45 @@ type TelemetryService interface {
 	// events
 	RoomStarted(ctx context.Context, room *livekit.Room)
 	RoomEnded(ctx context.Context, room *livekit.Room)
+	// RoomActivated - the activation window of a scheduled room has started
+	RoomActivated(ctx context.Context, room *livekit.Room)
+	// RoomExpired - the activation window of a scheduled room has ended, the room is closed
+	RoomExpired(ctx context.Context, room *livekit.Room)
+	// RoomMerged - the participants of a room have been moved into another room
+	RoomMerged(ctx context.Context, room *livekit.Room, destination livekit.RoomName, moved []livekit.ParticipantIdentity)
 	// ParticipantJoined - a participant establishes signal connection to a room
 	ParticipantJoined(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, clientInfo *livekit.ClientInfo, clientMeta *livekit.AnalyticsClientMeta, shouldSendEvent bool)
+	// ParticipantJoinCompleted - the join of a participant has gone through all of its stages, or stopped at one,
+	// sends the participant joined event that ParticipantJoined did not send with the join latency breakdown
+	ParticipantJoinCompleted(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, clientInfo *livekit.ClientInfo, clientMeta *livekit.AnalyticsClientMeta, joinedAt time.Time, latency JoinLatency)
 	// ParticipantActive - a participant establishes media connection
 	ParticipantActive(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, clientMeta *livekit.AnalyticsClientMeta, isMigration bool)
 	// ParticipantResumed - there has been an ICE restart or connection resume attempt, and we've received their signal connection
 	ParticipantResumed(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, nodeID livekit.NodeID, reason livekit.ReconnectReason)
+	// ParticipantPending - a participant is held in waiting room until admitted
+	ParticipantPending(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	// ParticipantAdmitted - a participant has been let out of waiting room
+	ParticipantAdmitted(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo)
+	// ParticipantJoinRestricted - a participant was not let in because of the network it joins from
+	ParticipantJoinRestricted(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity, clientInfo *livekit.ClientInfo, restriction *JoinRestriction)
 	// ParticipantLeft - the participant leaves the room, only sent if ParticipantActive has been called before
 	ParticipantLeft(ctx context.Context, room *livekit.Room, participant *livekit.ParticipantInfo, shouldSendEvent bool)
 	// TrackPublishRequested - a publication attempt has been received
 	TrackPublishRequested(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo)
 	// TrackPublished - a publication attempt has been successful
 	TrackPublished(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo)
+	// TrackCodecDeprecated - a track has been published with a codec configured as deprecated
+	TrackCodecDeprecated(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo, mime string)
+	// NegotiationFailed - a session description of a participant could not be applied or answered
+	NegotiationFailed(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, clientInfo *livekit.ClientInfo, failure *NegotiationFailure)
+	// ICEConnectivity - a transport of a participant connected through ICE, or failed to
+	ICEConnectivity(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, clientInfo *livekit.ClientInfo, connectivity *ICEConnectivity)
+	// ConnectionQualityAlert - the connection quality of a participant stayed degraded, or recovered
+	ConnectionQualityAlert(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, alert *ConnectionQualityAlert)
+	// TrackPayloadIntegrity - packets of a published track fail the checks of their payload, or pass them again
+	TrackPayloadIntegrity(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo, integrity *TrackPayloadIntegrity)
+	// TrackSubscriberCount - a published track got its first subscriber, or lost its last one
+	TrackSubscriberCount(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo, count int)
 	// TrackUnpublished - a participant unpublished a track
 	TrackUnpublished(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo, shouldSendEvent bool)
 	// TrackSubscribeRequested - a participant requested to subscribe to a track
@@ -57,6 +84,8 @@ type TelemetryService interface {
 	TrackUnsubscribed(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo, shouldSendEvent bool)
 	// TrackSubscribeFailed - failure to subscribe to a track
 	TrackSubscribeFailed(ctx context.Context, participantID livekit.ParticipantID, trackID livekit.TrackID, err error, isUserError bool)
+	// TrackSubscribeCodecRegressed - a subscriber failing to decode a track was moved to a backup codec of the track
+	TrackSubscribeCodecRegressed(ctx context.Context, participantID livekit.ParticipantID, identity livekit.ParticipantIdentity, track *livekit.TrackInfo, regression *CodecRegression)
 	// TrackMuted - the publisher has muted the Track
 	TrackMuted(ctx context.Context, participantID livekit.ParticipantID, track *livekit.TrackInfo)
 	// TrackUnmuted - the publisher has muted the Track
@@ -114,6 +143,31 @@ func NewTelemetryService(notifier webhook.QueuedNotifier, analytics AnalyticsSer
 	return t
 }
 
+type apiKeyKey struct{}
+
+// ContextWithAPIKey attributes the events and stats sent with ctx to apiKey
+func ContextWithAPIKey(ctx context.Context, apiKey string) context.Context {
+	if apiKey == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, apiKeyKey{}, apiKey)
+}
+
+func APIKeyFromContext(ctx context.Context) string {
+	apiKey, _ := ctx.Value(apiKeyKey{}).(string)
+	return apiKey
+}
+
+// SendEvent attributes participant and track events to the API key the participant joined with
+func (t *telemetryService) SendEvent(ctx context.Context, event *livekit.AnalyticsEvent) {
+	if APIKeyFromContext(ctx) == "" && event.ParticipantId != "" {
+		if worker, ok := t.getWorker(livekit.ParticipantID(event.ParticipantId)); ok {
+			ctx = ContextWithAPIKey(ctx, APIKeyFromContext(worker.ctx))
+		}
+	}
+	t.AnalyticsService.SendEvent(ctx, event)
+}
+
 func (t *telemetryService) FlushStats() {
 	t.lock.RLock()
 	workersShadow := t.workersShadow
@@ -159,6 +213,13 @@ func (t *telemetryService) createWorker(ctx context.Context,
 	participantID livekit.ParticipantID,
 	participantIdentity livekit.ParticipantIdentity,
 ) *StatsWorker {
+	// a participant moved to another room keeps its API key
+	if APIKeyFromContext(ctx) == "" {
+		if prev, ok := t.getWorker(participantID); ok {
+			ctx = ContextWithAPIKey(ctx, APIKeyFromContext(prev.ctx))
+		}
+	}
+
 	worker := newStatsWorker(
 		ctx,
 		t,
@@ -0,0 +1,240 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+)
+
+const resourceIdlePollInterval = 50 * time.Millisecond
+
+// ResourceCounts are the goroutines and timers running for a tracker, by label
+type ResourceCounts struct {
+	Goroutines map[string]int `json:"goroutines,omitempty"`
+	Timers     map[string]int `json:"timers,omitempty"`
+	Total      int            `json:"total"`
+}
+
+// Labels returns the labels with resources running, sorted
+func (c ResourceCounts) Labels() []string {
+	labels := make([]string, 0, len(c.Goroutines)+len(c.Timers))
+	for label := range c.Goroutines {
+		labels = append(labels, label)
+	}
+	for label := range c.Timers {
+		if _, ok := c.Goroutines[label]; !ok {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// ResourceTracker counts the goroutines and timers started through it, so that the ones still running after
+// their owner, e.g. a room, has closed can be found. A warning is logged when the number running goes over the
+// budget. A nil tracker starts them untracked.
+//
+// A tracker bound to a parent also counts its resources in the parent, e.g. the ones of a participant in its room.
+// It can be bound to another parent while they run, e.g. when the participant moves to another room.
+type ResourceTracker struct {
+	logger logger.Logger
+
+	lock       sync.Mutex
+	parent     *ResourceTracker
+	goroutines map[string]int
+	timers     map[string]int
+	total      int
+	budget     int
+	overBudget bool
+}
+
+func NewResourceTracker(logger logger.Logger) *ResourceTracker {
+	return &ResourceTracker{
+		logger:     logger,
+		goroutines: make(map[string]int),
+		timers:     make(map[string]int),
+	}
+}
+
+// SetBudget sets the number of goroutines and timers expected to run at most, 0 disables the budget
+func (t *ResourceTracker) SetBudget(budget int) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	t.budget = budget
+	t.overBudget = false
+	t.lock.Unlock()
+}
+
+// Bind counts the resources of the tracker in parent from now on, the ones running are moved over from the
+// previous parent. A nil parent unbinds the tracker.
+func (t *ResourceTracker) Bind(parent *ResourceTracker) {
+	if t == nil || t == parent {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.parent == parent {
+		return
+	}
+	for label, n := range t.goroutines {
+		t.parent.move(resourceGoroutine, label, -n)
+		parent.move(resourceGoroutine, label, n)
+	}
+	for label, n := range t.timers {
+		t.parent.move(resourceTimer, label, -n)
+		parent.move(resourceTimer, label, n)
+	}
+	t.parent = parent
+}
+
+// Go runs fn in a goroutine counted under label
+func (t *ResourceTracker) Go(label string, fn func()) {
+	if t == nil {
+		go fn()
+		return
+	}
+
+	t.move(resourceGoroutine, label, 1)
+	go func() {
+		defer t.move(resourceGoroutine, label, -1)
+		fn()
+	}()
+}
+
+// AfterFunc is time.AfterFunc with the timer counted under label until it fires or is stopped
+func (t *ResourceTracker) AfterFunc(label string, d time.Duration, fn func()) *TrackedTimer {
+	if t == nil {
+		return &TrackedTimer{timer: time.AfterFunc(d, fn)}
+	}
+
+	tt := &TrackedTimer{}
+	t.move(resourceTimer, label, 1)
+	tt.release = func() {
+		t.move(resourceTimer, label, -1)
+	}
+	tt.timer = time.AfterFunc(d, func() {
+		tt.releaseOnce.Do(tt.release)
+		fn()
+	})
+	return tt
+}
+
+type resourceKind int
+
+const (
+	resourceGoroutine resourceKind = iota
+	resourceTimer
+)
+
+// move adds n resources of the kind under label, removes them when n is negative. The parent is updated under
+// the lock of the tracker, so that Bind moves the counts of the tracker consistently.
+func (t *ResourceTracker) move(kind resourceKind, label string, n int) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	counts := t.goroutines
+	if kind == resourceTimer {
+		counts = t.timers
+	}
+	if counts[label] += n; counts[label] <= 0 {
+		delete(counts, label)
+	}
+	t.total += n
+	t.parent.move(kind, label, n)
+
+	if n < 0 || t.budget == 0 || t.total <= t.budget || t.overBudget {
+		if t.overBudget && t.total < t.budget {
+			t.overBudget = false
+		}
+		t.lock.Unlock()
+		return
+	}
+	t.overBudget = true
+	current := t.countsLocked()
+	t.lock.Unlock()
+
+	t.logger.Warnw("goroutine and timer budget exceeded", nil, "budget", t.budget, "resources", current)
+}
+
+// Counts returns the goroutines and timers running
+func (t *ResourceTracker) Counts() ResourceCounts {
+	if t == nil {
+		return ResourceCounts{}
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.countsLocked()
+}
+
+func (t *ResourceTracker) countsLocked() ResourceCounts {
+	counts := ResourceCounts{Total: t.total}
+	if len(t.goroutines) != 0 {
+		counts.Goroutines = make(map[string]int, len(t.goroutines))
+		for label, n := range t.goroutines {
+			counts.Goroutines[label] = n
+		}
+	}
+	if len(t.timers) != 0 {
+		counts.Timers = make(map[string]int, len(t.timers))
+		for label, n := range t.timers {
+			counts.Timers[label] = n
+		}
+	}
+	return counts
+}
+
+// WaitIdle waits for the goroutines and timers to complete, it returns false on timeout
+func (t *ResourceTracker) WaitIdle(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if t.Counts().Total == 0 {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(resourceIdlePollInterval)
+	}
+}
+
+// ---------------------------------------------
+
+// TrackedTimer is a timer started by ResourceTracker.AfterFunc
+type TrackedTimer struct {
+	timer       *time.Timer
+	release     func()
+	releaseOnce sync.Once
+}
+
+// Stop prevents the timer from firing, as time.Timer.Stop
+func (tt *TrackedTimer) Stop() bool {
+	stopped := tt.timer.Stop()
+	if stopped && tt.release != nil {
+		tt.releaseOnce.Do(tt.release)
+	}
+	return stopped
+}
@@ -112,6 +112,11 @@ type Options struct {
 	DisabledCodecs            []webrtc.RTPCodecCapability
 	SignalRequestInterceptor  SignalRequestInterceptor
 	SignalResponseInterceptor SignalResponseInterceptor
+
+	// set to resume the session of participant ParticipantID instead of joining
+	Reconnect       bool
+	ReconnectReason livekit.ReconnectReason
+	ParticipantID   livekit.ParticipantID
 }
 
 func NewWebSocketConn(host, token string, opts *Options) (*websocket.Conn, error) {
@@ -136,6 +141,11 @@ func NewWebSocketConn(host, token string, opts *Options) (*websocket.Conn, error
 				connectUrl += encodeQueryParam("os", opts.ClientInfo.Os)
 			}
 		}
+		if opts.Reconnect {
+			connectUrl += encodeQueryParam("reconnect", "1")
+			connectUrl += encodeQueryParam("reconnect_reason", fmt.Sprintf("%d", opts.ReconnectReason))
+			connectUrl += encodeQueryParam("sid", string(opts.ParticipantID))
+		}
 	}
 	conn, _, err := websocket.DefaultDialer.Dial(connectUrl, requestHeader)
 	return conn, err
@@ -308,7 +318,8 @@ func (c *RTCClient) ID() livekit.ParticipantID {
 
 // create an offer for the server
 func (c *RTCClient) Run() error {
-	c.conn.SetCloseHandler(func(code int, text string) error {
+	conn := c.signalConn()
+	conn.SetCloseHandler(func(code int, text string) error {
 		// when closed, stop connection
 		logger.Infow("connection closed", "code", code, "text", text)
 		c.Stop()
@@ -317,7 +328,7 @@ func (c *RTCClient) Run() error {
 
 	// run the session
 	for {
-		res, err := c.ReadResponse()
+		res, err := c.readResponse(conn)
 		if errors.Is(io.EOF, err) {
 			return nil
 		} else if err != nil {
@@ -444,10 +455,39 @@ func (c *RTCClient) WaitUntilConnected() error {
 	}
 }
 
+// Resume replaces the signal connection with conn, dialed with Options.Reconnect set, and runs the session on it.
+// The publisher restarts ICE when iceRestart is set, as a client does after losing its network path.
+func (c *RTCClient) Resume(conn *websocket.Conn, iceRestart bool) error {
+	c.wsLock.Lock()
+	prev := c.conn
+	c.conn = conn
+	c.wsLock.Unlock()
+	_ = prev.Close()
+
+	go func() {
+		_ = c.Run()
+	}()
+
+	if iceRestart {
+		return c.publisher.ICERestart()
+	}
+	return nil
+}
+
+func (c *RTCClient) signalConn() *websocket.Conn {
+	c.wsLock.Lock()
+	defer c.wsLock.Unlock()
+	return c.conn
+}
+
 func (c *RTCClient) ReadResponse() (*livekit.SignalResponse, error) {
+	return c.readResponse(c.signalConn())
+}
+
+func (c *RTCClient) readResponse(conn *websocket.Conn) (*livekit.SignalResponse, error) {
 	for {
 		// handle special messages and pass on the rest
-		messageType, payload, err := c.conn.ReadMessage()
+		messageType, payload, err := conn.ReadMessage()
 		if err != nil {
 			return nil, err
 		}
@@ -459,7 +499,9 @@ func (c *RTCClient) ReadResponse() (*livekit.SignalResponse, error) {
 		msg := &livekit.SignalResponse{}
 		switch messageType {
 		case websocket.PingMessage:
-			_ = c.conn.WriteMessage(websocket.PongMessage, nil)
+			c.wsLock.Lock()
+			_ = conn.WriteMessage(websocket.PongMessage, nil)
+			c.wsLock.Unlock()
 			continue
 		case websocket.BinaryMessage:
 			// protobuf encoded
@@ -506,7 +548,7 @@ func (c *RTCClient) Stop() {
 	})
 	c.publisherFullyEstablished.Store(false)
 	c.subscriberFullyEstablished.Store(false)
-	_ = c.conn.Close()
+	_ = c.signalConn().Close()
 	c.publisher.Close()
 	c.subscriber.Close()
 	c.cancel()
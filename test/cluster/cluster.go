@@ -0,0 +1,268 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cluster runs livekit-server nodes as separate processes sharing a redis, to test
+// behaviour across nodes: relaying signal between nodes, moving rooms off failed nodes and
+// resuming sessions over impaired networks. Isolated clusters put every node in its own
+// network namespace, which requires linux and root.
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+const (
+	APIKey    = "apikey"
+	APISecret = "apiSecret"
+
+	startTimeout = 30 * time.Second
+)
+
+var ErrNotIsolated = errors.New("network impairment requires an isolated cluster")
+
+type Config struct {
+	Nodes int
+	// Isolate runs every node in its own network namespace so it can be impaired or partitioned
+	Isolate bool
+	// Configure is called with the config of each node before it is written, to add or override settings
+	Configure func(index int, conf map[string]interface{})
+}
+
+// Impairment is applied with netem to the traffic of a node
+type Impairment struct {
+	Delay  time.Duration
+	Jitter time.Duration
+	// Loss is the percentage of packets dropped
+	Loss float64
+}
+
+type Cluster struct {
+	RedisAddress string
+
+	conf    Config
+	dir     string
+	binary  string
+	network *network
+	redis   *redis.Client
+	nodes   []*Node
+}
+
+// Start brings up a cluster and waits for all of its nodes to be ready, it is torn down when the test ends.
+// The test is skipped in short mode or when the environment cannot host the cluster.
+func Start(t *testing.T, conf Config) *Cluster {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping cluster test in short mode")
+	}
+	if conf.Nodes <= 0 {
+		conf.Nodes = 2
+	}
+
+	binary, err := serverBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Cluster{
+		conf:   conf,
+		dir:    t.TempDir(),
+		binary: binary,
+	}
+
+	// servers reach redis on the host side of the bridge when isolated
+	redisIP := "127.0.0.1"
+	if conf.Isolate {
+		c.network = newNetwork(t)
+		redisIP = c.network.hostIP
+	}
+	c.RedisAddress = startRedis(t, redisIP)
+	c.redis = redis.NewClient(&redis.Options{Addr: c.RedisAddress})
+	t.Cleanup(c.stop)
+
+	for i := 0; i < conf.Nodes; i++ {
+		var ns *namespace
+		if conf.Isolate {
+			if ns, err = c.network.addNamespace(i); err != nil {
+				t.Fatalf("could not create namespace for node %d: %v", i, err)
+			}
+		}
+		n, err := newNode(c, i, ns)
+		if err != nil {
+			t.Fatalf("could not configure node %d: %v", i, err)
+		}
+		c.nodes = append(c.nodes, n)
+	}
+
+	// nodes are started one at a time to tell which node registered which ID
+	for _, n := range c.nodes {
+		if err := n.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+func (c *Cluster) Nodes() []*Node {
+	return c.nodes
+}
+
+func (c *Cluster) Node(index int) *Node {
+	return c.nodes[index]
+}
+
+// NodeByID returns the node that registered with id, nil when none of the running nodes did
+func (c *Cluster) NodeByID(id livekit.NodeID) *Node {
+	for _, n := range c.nodes {
+		if n.ID() == id && n.IsRunning() {
+			return n
+		}
+	}
+	return nil
+}
+
+// NodeForRoom returns the node the room is assigned to
+func (c *Cluster) NodeForRoom(ctx context.Context, roomName livekit.RoomName) (*Node, error) {
+	nodeID, err := c.redis.HGet(ctx, routing.NodeRoomKey, string(roomName)).Result()
+	if err == redis.Nil {
+		return nil, routing.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if n := c.NodeByID(livekit.NodeID(nodeID)); n != nil {
+		return n, nil
+	}
+	return nil, fmt.Errorf("room %s is assigned to unknown node %s", roomName, nodeID)
+}
+
+// RoomClient returns a RoomService client talking to the node at index
+func (c *Cluster) RoomClient(index int) livekit.RoomService {
+	return livekit.NewRoomServiceJSONClient(c.nodes[index].URL(), &http.Client{})
+}
+
+// AdminContext returns a context authorized to create, list and administer rooms
+func (c *Cluster) AdminContext(roomName livekit.RoomName) context.Context {
+	token := c.Token(&auth.VideoGrant{RoomCreate: true, RoomList: true, RoomAdmin: true, Room: string(roomName)}, "")
+	header := make(http.Header)
+	testclient.SetAuthorizationToken(header, token)
+	ctx, err := twirp.WithHTTPRequestHeaders(context.Background(), header)
+	if err != nil {
+		panic(err)
+	}
+	return ctx
+}
+
+func (c *Cluster) Token(grant *auth.VideoGrant, identity livekit.ParticipantIdentity) string {
+	at := auth.NewAccessToken(APIKey, APISecret).
+		AddGrant(grant)
+	if identity != "" {
+		at.SetIdentity(string(identity)).SetName(string(identity))
+	}
+	token, err := at.ToJWT()
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+// JoinToken returns a token for identity to join roomName
+func (c *Cluster) JoinToken(roomName livekit.RoomName, identity livekit.ParticipantIdentity) string {
+	return c.Token(&auth.VideoGrant{RoomJoin: true, Room: string(roomName)}, identity)
+}
+
+// Connect joins identity to roomName through the node at index with a synthetic client,
+// the client is stopped when the test ends
+func (c *Cluster) Connect(
+	t *testing.T,
+	index int,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	opts *testclient.Options,
+) (*testclient.RTCClient, error) {
+	t.Helper()
+
+	conn, err := testclient.NewWebSocketConn(c.nodes[index].WebSocketURL(), c.JoinToken(roomName, identity), opts)
+	if err != nil {
+		return nil, err
+	}
+	client, err := testclient.NewRTCClient(conn, opts)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	go func() {
+		_ = client.Run()
+	}()
+	t.Cleanup(client.Stop)
+	return client, nil
+}
+
+// Resume reconnects the signal connection of identity through the node at index, keeping its session
+func (c *Cluster) Resume(
+	index int,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	client *testclient.RTCClient,
+	iceRestart bool,
+) error {
+	conn, err := testclient.NewWebSocketConn(c.nodes[index].WebSocketURL(), c.JoinToken(roomName, identity), &testclient.Options{
+		AutoSubscribe:   true,
+		Reconnect:       true,
+		ReconnectReason: livekit.ReconnectReason_RR_SIGNAL_DISCONNECTED,
+		ParticipantID:   client.ID(),
+	})
+	if err != nil {
+		return err
+	}
+	return client.Resume(conn, iceRestart)
+}
+
+// registeredNodes returns the IDs of the nodes registered in redis, including nodes that went away
+// without deregistering
+func (c *Cluster) registeredNodes(ctx context.Context) (map[livekit.NodeID]bool, error) {
+	keys, err := c.redis.HKeys(ctx, routing.NodesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[livekit.NodeID]bool, len(keys))
+	for _, id := range keys {
+		ids[livekit.NodeID(id)] = true
+	}
+	return ids, nil
+}
+
+func (c *Cluster) stop() {
+	for _, n := range c.nodes {
+		if err := n.Stop(10 * time.Second); err != nil && !errors.Is(err, ErrNodeNotRunning) {
+			_ = n.Kill()
+		}
+	}
+	if c.redis != nil {
+		_ = c.redis.Close()
+	}
+}
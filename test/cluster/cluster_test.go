@@ -0,0 +1,245 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+const (
+	scenarioTimeout = 30 * time.Second
+	// nodes that stop updating their stats are given up on after selector.AvailableSeconds
+	failoverTimeout = 45 * time.Second
+)
+
+func TestRelaySignalAcrossNodes(t *testing.T) {
+	c := Start(t, Config{Nodes: 2})
+	room := createRoomOnNode(t, c, "relay", 0)
+
+	// the subscriber signals through node 1, which relays to the room on node 0
+	pub := connect(t, c, 0, room, "publisher")
+	sub := connect(t, c, 1, room, "subscriber")
+	waitUntilConnected(t, pub, sub)
+
+	writer, err := pub.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	t.Cleanup(writer.Stop)
+
+	waitForTrack(t, sub, pub)
+	remote := sub.GetRemoteParticipant(pub.ID())
+	require.NotNil(t, remote)
+	require.Equal(t, "publisher", remote.Identity)
+
+	n, err := c.NodeForRoom(context.Background(), room)
+	require.NoError(t, err)
+	require.Equal(t, c.Node(0), n)
+}
+
+func TestRoutingFailover(t *testing.T) {
+	c := Start(t, Config{Nodes: 2})
+	room := createRoomOnNode(t, c, "failover", 1)
+
+	first := connect(t, c, 0, room, "first")
+	waitUntilConnected(t, first)
+
+	require.NoError(t, c.Node(1).Kill())
+
+	// once the failed node is no longer available, joining through node 0 moves the room to node 0
+	var second *testclient.RTCClient
+	waitFor(t, failoverTimeout, func() string {
+		client, err := c.Connect(t, 0, room, "second", &testclient.Options{AutoSubscribe: true})
+		if err != nil {
+			return fmt.Sprintf("could not connect: %v", err)
+		}
+		if err := client.WaitUntilConnected(); err != nil {
+			client.Stop()
+			return err.Error()
+		}
+		second = client
+		return ""
+	})
+	require.NotNil(t, second)
+
+	n, err := c.NodeForRoom(context.Background(), room)
+	require.NoError(t, err)
+	require.Equal(t, c.Node(0), n)
+
+	// the replacement node comes back with a new ID and takes new rooms
+	require.NoError(t, c.Node(1).Start(context.Background()))
+	createRoomOnNode(t, c, "after-failover", 1)
+}
+
+func TestResumeAfterSignalPartition(t *testing.T) {
+	c := Start(t, Config{Nodes: 2, Isolate: true})
+	room := createRoomOnNode(t, c, "resume", 0)
+
+	pub := connect(t, c, 0, room, "publisher")
+	sub := connect(t, c, 1, room, "subscriber")
+	waitUntilConnected(t, pub, sub)
+
+	writer, err := pub.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	t.Cleanup(writer.Stop)
+	waitForTrack(t, sub, pub)
+
+	// cutting off the relaying node breaks the signal connection of the subscriber, media flows to node 0
+	require.NoError(t, c.Node(1).Partition())
+	time.Sleep(2 * time.Second)
+	require.NoError(t, c.Node(1).Heal())
+
+	// resume through the node hosting the room, the session and subscriptions are kept
+	subID := sub.ID()
+	require.NoError(t, c.Resume(0, room, "subscriber", sub, false))
+	waitForBytes(t, sub)
+
+	remote := pub.GetRemoteParticipant(subID)
+	require.NotNil(t, remote)
+	require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, remote.State)
+}
+
+func TestResumeWithICERestart(t *testing.T) {
+	c := Start(t, Config{Nodes: 2, Isolate: true})
+	room := createRoomOnNode(t, c, "ice-restart", 0)
+
+	pub := connect(t, c, 0, room, "publisher")
+	sub := connect(t, c, 1, room, "subscriber")
+	waitUntilConnected(t, pub, sub)
+
+	writer, err := pub.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	t.Cleanup(writer.Stop)
+	waitForTrack(t, sub, pub)
+
+	// the publisher loses its path to the node hosting the room, signal and media alike
+	require.NoError(t, c.Node(0).Partition())
+	time.Sleep(3 * time.Second)
+	require.NoError(t, c.Node(0).Heal())
+
+	require.NoError(t, c.Resume(0, room, "publisher", pub, true))
+	waitForBytes(t, sub)
+
+	remote := sub.GetRemoteParticipant(pub.ID())
+	require.NotNil(t, remote)
+	require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, remote.State)
+}
+
+func TestMediaUnderImpairment(t *testing.T) {
+	c := Start(t, Config{Nodes: 2, Isolate: true})
+	room := createRoomOnNode(t, c, "impaired", 0)
+
+	require.NoError(t, c.Node(0).Impair(Impairment{
+		Delay:  80 * time.Millisecond,
+		Jitter: 20 * time.Millisecond,
+		Loss:   3,
+	}))
+
+	pub := connect(t, c, 0, room, "publisher")
+	sub := connect(t, c, 1, room, "subscriber")
+	waitUntilConnected(t, pub, sub)
+
+	audio, err := pub.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	t.Cleanup(audio.Stop)
+	video, err := pub.AddStaticTrack("video/vp8", "video", "webcam")
+	require.NoError(t, err)
+	t.Cleanup(video.Stop)
+
+	waitFor(t, scenarioTimeout, func() string {
+		if n := len(sub.SubscribedTracks()[pub.ID()]); n != 2 {
+			return fmt.Sprintf("subscriber has %d of 2 tracks", n)
+		}
+		return ""
+	})
+	waitForBytes(t, sub)
+}
+
+func createRoomOnNode(t *testing.T, c *Cluster, name string, index int) livekit.RoomName {
+	t.Helper()
+
+	room := livekit.RoomName(name)
+	_, err := c.RoomClient(index).CreateRoom(c.AdminContext(room), &livekit.CreateRoomRequest{
+		Name:   name,
+		NodeId: string(c.Node(index).ID()),
+	})
+	require.NoError(t, err)
+	return room
+}
+
+func connect(t *testing.T, c *Cluster, index int, room livekit.RoomName, identity livekit.ParticipantIdentity) *testclient.RTCClient {
+	t.Helper()
+
+	client, err := c.Connect(t, index, room, identity, &testclient.Options{AutoSubscribe: true})
+	require.NoError(t, err)
+	return client
+}
+
+func waitUntilConnected(t *testing.T, clients ...*testclient.RTCClient) {
+	t.Helper()
+
+	for _, client := range clients {
+		require.NoError(t, client.WaitUntilConnected())
+	}
+}
+
+func waitForTrack(t *testing.T, sub, pub *testclient.RTCClient) {
+	t.Helper()
+
+	waitFor(t, scenarioTimeout, func() string {
+		if len(sub.SubscribedTracks()[pub.ID()]) == 0 {
+			return "subscriber did not receive the published track"
+		}
+		return ""
+	})
+}
+
+// waitForBytes waits for media to keep arriving at the client
+func waitForBytes(t *testing.T, client *testclient.RTCClient) {
+	t.Helper()
+
+	start := client.BytesReceived()
+	waitFor(t, scenarioTimeout, func() string {
+		if client.BytesReceived() <= start {
+			return "no media received"
+		}
+		return ""
+	})
+}
+
+func waitFor(t *testing.T, timeout time.Duration, f func() string) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		lastErr := f()
+		if lastErr == "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("did not reach expected state after %v: %s", timeout, lastErr)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
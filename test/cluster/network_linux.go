@@ -0,0 +1,157 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package cluster
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// network is a bridge on the host with a network namespace per node attached to it through a veth pair,
+// so that traffic to and from each node can be impaired on its own
+type network struct {
+	id     string
+	bridge string
+	subnet string
+	hostIP string
+
+	namespaces []*namespace
+}
+
+type namespace struct {
+	name    string
+	ip      string
+	hostDev string
+	dev     string
+}
+
+func newNetwork(t *testing.T) *network {
+	t.Helper()
+
+	if os.Geteuid() != 0 {
+		t.Skip("isolated cluster requires root to create network namespaces")
+	}
+	for _, tool := range []string{"ip", "tc"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("isolated cluster requires %s", tool)
+		}
+	}
+
+	id := fmt.Sprintf("%04x", rand.Intn(0x10000))
+	n := &network{
+		id:     id,
+		bridge: "lkbr" + id,
+		subnet: fmt.Sprintf("10.251.%d", 1+rand.Intn(250)),
+	}
+	n.hostIP = n.subnet + ".1"
+	t.Cleanup(n.close)
+
+	if err := runAll(
+		[]string{"ip", "link", "add", n.bridge, "type", "bridge"},
+		[]string{"ip", "addr", "add", n.hostIP + "/24", "dev", n.bridge},
+		[]string{"ip", "link", "set", n.bridge, "up"},
+	); err != nil {
+		t.Fatalf("could not create bridge: %v", err)
+	}
+	return n
+}
+
+// addNamespace creates the namespace of the node at index, reachable from the host at the returned IP
+func (n *network) addNamespace(index int) (*namespace, error) {
+	ns := &namespace{
+		name:    fmt.Sprintf("lk%s-%d", n.id, index),
+		ip:      fmt.Sprintf("%s.%d", n.subnet, 10+index),
+		hostDev: fmt.Sprintf("lkv%sh%d", n.id, index),
+		dev:     fmt.Sprintf("lkv%sn%d", n.id, index),
+	}
+	n.namespaces = append(n.namespaces, ns)
+
+	if err := runAll(
+		[]string{"ip", "netns", "add", ns.name},
+		[]string{"ip", "link", "add", ns.hostDev, "type", "veth", "peer", "name", ns.dev},
+		[]string{"ip", "link", "set", ns.dev, "netns", ns.name},
+		[]string{"ip", "link", "set", ns.hostDev, "master", n.bridge},
+		[]string{"ip", "link", "set", ns.hostDev, "up"},
+		[]string{"ip", "-n", ns.name, "addr", "add", ns.ip + "/24", "dev", ns.dev},
+		[]string{"ip", "-n", ns.name, "link", "set", ns.dev, "up"},
+		[]string{"ip", "-n", ns.name, "link", "set", "lo", "up"},
+		[]string{"ip", "-n", ns.name, "route", "add", "default", "via", n.hostIP},
+	); err != nil {
+		return nil, err
+	}
+	return ns, nil
+}
+
+func (n *network) close() {
+	for _, ns := range n.namespaces {
+		// the veth pair goes away with the namespace
+		_ = exec.Command("ip", "netns", "del", ns.name).Run()
+	}
+	_ = exec.Command("ip", "link", "del", n.bridge).Run()
+}
+
+// command runs name inside the namespace
+func (ns *namespace) command(name string, args ...string) *exec.Cmd {
+	return exec.Command("ip", append([]string{"netns", "exec", ns.name, name}, args...)...)
+}
+
+// impair applies the impairment to both directions, replacing the previous one
+func (ns *namespace) impair(imp Impairment) error {
+	netem := imp.netemArgs()
+	return runAll(
+		append([]string{"tc", "qdisc", "replace", "dev", ns.hostDev, "root", "netem"}, netem...),
+		append([]string{"ip", "netns", "exec", ns.name, "tc", "qdisc", "replace", "dev", ns.dev, "root", "netem"}, netem...),
+	)
+}
+
+func (ns *namespace) clearImpairment() error {
+	// deleting a qdisc that is not there fails, ignore it
+	_ = exec.Command("tc", "qdisc", "del", "dev", ns.hostDev, "root").Run()
+	_ = exec.Command("ip", "netns", "exec", ns.name, "tc", "qdisc", "del", "dev", ns.dev, "root").Run()
+	return nil
+}
+
+func (imp Impairment) netemArgs() []string {
+	var args []string
+	if imp.Delay > 0 {
+		args = append(args, "delay", fmt.Sprintf("%dms", imp.Delay.Milliseconds()))
+		if imp.Jitter > 0 {
+			args = append(args, fmt.Sprintf("%dms", imp.Jitter.Milliseconds()))
+		}
+	}
+	if imp.Loss > 0 {
+		args = append(args, "loss", fmt.Sprintf("%g%%", imp.Loss))
+	}
+	if len(args) == 0 {
+		// netem requires at least one parameter
+		args = append(args, "delay", "0ms")
+	}
+	return args
+}
+
+func runAll(commands ...[]string) error {
+	for _, c := range commands {
+		if out, err := exec.Command(c[0], c[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %w: %s", strings.Join(c, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
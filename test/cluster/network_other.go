@@ -0,0 +1,54 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package cluster
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+var errNetworkUnsupported = errors.New("network namespaces are only supported on linux")
+
+type network struct {
+	hostIP string
+}
+
+type namespace struct {
+	ip string
+}
+
+func newNetwork(t *testing.T) *network {
+	t.Skip("isolated cluster requires linux network namespaces")
+	return nil
+}
+
+func (n *network) addNamespace(_ int) (*namespace, error) {
+	return nil, errNetworkUnsupported
+}
+
+func (ns *namespace) command(name string, args ...string) *exec.Cmd {
+	return exec.Command(name, args...)
+}
+
+func (ns *namespace) impair(_ Impairment) error {
+	return errNetworkUnsupported
+}
+
+func (ns *namespace) clearImpairment() error {
+	return errNetworkUnsupported
+}
@@ -0,0 +1,361 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// ServerBinaryEnv points the harness at a prebuilt livekit-server instead of building it once per test binary
+const ServerBinaryEnv = "LIVEKIT_CLUSTER_BINARY"
+
+var (
+	ErrNodeNotRunning = errors.New("node is not running")
+
+	buildOnce   sync.Once
+	builtBinary string
+	buildErr    error
+)
+
+// Node is a livekit-server process of the cluster
+type Node struct {
+	Index   int
+	IP      string
+	Port    int
+	UDPPort int
+	TCPPort int
+
+	cluster    *Cluster
+	ns         *namespace
+	configPath string
+	logPath    string
+
+	lock   sync.Mutex
+	id     livekit.NodeID
+	cmd    *exec.Cmd
+	exited chan struct{}
+}
+
+func newNode(c *Cluster, index int, ns *namespace) (*Node, error) {
+	n := &Node{
+		Index:   index,
+		cluster: c,
+		ns:      ns,
+	}
+	if ns != nil {
+		// every node has its own network stack, the default ports do not clash
+		n.IP = ns.ip
+		n.Port, n.TCPPort, n.UDPPort = 7880, 7881, 7882
+	} else {
+		n.IP = "127.0.0.1"
+		var err error
+		if n.Port, err = freePort("tcp"); err != nil {
+			return nil, err
+		}
+		if n.TCPPort, err = freePort("tcp"); err != nil {
+			return nil, err
+		}
+		if n.UDPPort, err = freePort("udp"); err != nil {
+			return nil, err
+		}
+	}
+
+	dir := filepath.Join(c.dir, fmt.Sprintf("node-%d", index))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	n.configPath = filepath.Join(dir, "config.yaml")
+	n.logPath = filepath.Join(dir, "server.log")
+
+	conf := map[string]interface{}{
+		"port":           n.Port,
+		"bind_addresses": []string{n.IP},
+		"rtc": map[string]interface{}{
+			"udp_port":        fmt.Sprintf("%d", n.UDPPort),
+			"tcp_port":        n.TCPPort,
+			"node_ip":         n.IP,
+			"use_external_ip": false,
+		},
+		"redis": map[string]interface{}{
+			"address": c.RedisAddress,
+		},
+		"keys": map[string]string{
+			APIKey: APISecret,
+		},
+	}
+	if c.conf.Configure != nil {
+		c.conf.Configure(index, conf)
+	}
+	data, err := yaml.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(n.configPath, data, 0644); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// ID returns the node ID the server registered with, it changes when the node is restarted
+func (n *Node) ID() livekit.NodeID {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.id
+}
+
+// URL returns the HTTP address of the node
+func (n *Node) URL() string {
+	return fmt.Sprintf("http://%s:%d", n.IP, n.Port)
+}
+
+// WebSocketURL returns the address clients connect to
+func (n *Node) WebSocketURL() string {
+	return fmt.Sprintf("ws://%s:%d", n.IP, n.Port)
+}
+
+// LogPath returns the file that the server writes its output to
+func (n *Node) LogPath() string {
+	return n.logPath
+}
+
+func (n *Node) IsRunning() bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.cmd != nil
+}
+
+// Start launches the server and waits until it is serving and has registered itself in redis
+func (n *Node) Start(ctx context.Context) error {
+	n.lock.Lock()
+	if n.cmd != nil {
+		n.lock.Unlock()
+		return nil
+	}
+
+	known, err := n.cluster.registeredNodes(ctx)
+	if err != nil {
+		n.lock.Unlock()
+		return err
+	}
+
+	logFile, err := os.OpenFile(n.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		n.lock.Unlock()
+		return err
+	}
+
+	args := []string{"--config", n.configPath}
+	var cmd *exec.Cmd
+	if n.ns != nil {
+		cmd = n.ns.command(n.cluster.binary, args...)
+	} else {
+		cmd = exec.Command(n.cluster.binary, args...)
+	}
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		n.lock.Unlock()
+		_ = logFile.Close()
+		return err
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		_ = logFile.Close()
+		close(exited)
+
+		n.lock.Lock()
+		if n.cmd == cmd {
+			n.cmd = nil
+		}
+		n.lock.Unlock()
+	}()
+	n.cmd = cmd
+	n.exited = exited
+	n.lock.Unlock()
+
+	id, err := n.waitReady(ctx, known, exited)
+	if err != nil {
+		_ = n.Kill()
+		return fmt.Errorf("node %d did not start, see %s: %w", n.Index, n.logPath, err)
+	}
+
+	n.lock.Lock()
+	n.id = id
+	n.lock.Unlock()
+	return nil
+}
+
+// Stop shuts the server down gracefully, letting it drain its participants
+func (n *Node) Stop(timeout time.Duration) error {
+	return n.signal(syscall.SIGTERM, timeout)
+}
+
+// Kill terminates the server without giving it a chance to clean up, as in a crash.
+// The node stays registered in redis until its stats go stale.
+func (n *Node) Kill() error {
+	return n.signal(syscall.SIGKILL, 10*time.Second)
+}
+
+// Restart stops the node and starts it again, the node comes back with a new ID
+func (n *Node) Restart(ctx context.Context) error {
+	if err := n.Stop(30 * time.Second); err != nil && !errors.Is(err, ErrNodeNotRunning) {
+		return err
+	}
+	return n.Start(ctx)
+}
+
+// Impair degrades the network of the node in both directions, signalling, media and redis alike
+func (n *Node) Impair(imp Impairment) error {
+	if n.ns == nil {
+		return ErrNotIsolated
+	}
+	return n.ns.impair(imp)
+}
+
+// Partition drops all traffic to and from the node
+func (n *Node) Partition() error {
+	return n.Impair(Impairment{Loss: 100})
+}
+
+// Heal removes impairments and partitions of the node
+func (n *Node) Heal() error {
+	if n.ns == nil {
+		return ErrNotIsolated
+	}
+	return n.ns.clearImpairment()
+}
+
+func (n *Node) signal(sig syscall.Signal, timeout time.Duration) error {
+	n.lock.Lock()
+	cmd, exited := n.cmd, n.exited
+	n.lock.Unlock()
+	if cmd == nil {
+		return ErrNodeNotRunning
+	}
+
+	// in a namespace cmd is `ip netns exec`, which execs into the server and keeps its pid
+	_ = cmd.Process.Signal(sig)
+
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		<-exited
+		return fmt.Errorf("node %d did not exit after %s", n.Index, timeout)
+	}
+}
+
+func (n *Node) waitReady(ctx context.Context, known map[livekit.NodeID]bool, exited <-chan struct{}) (livekit.NodeID, error) {
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+
+	serving := false
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-exited:
+			return "", errors.New("server exited")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		if !serving {
+			resp, err := http.Get(n.URL())
+			if err != nil {
+				continue
+			}
+			_ = resp.Body.Close()
+			serving = resp.StatusCode == http.StatusOK
+			if !serving {
+				continue
+			}
+		}
+
+		nodes, err := n.cluster.registeredNodes(ctx)
+		if err != nil {
+			continue
+		}
+		for id := range nodes {
+			if !known[id] {
+				return id, nil
+			}
+		}
+	}
+}
+
+func freePort(network string) (int, error) {
+	if strings.HasPrefix(network, "udp") {
+		conn, err := net.ListenPacket(network, "127.0.0.1:0")
+		if err != nil {
+			return 0, err
+		}
+		defer conn.Close()
+		return conn.LocalAddr().(*net.UDPAddr).Port, nil
+	}
+
+	l, err := net.Listen(network, "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// serverBinary builds livekit-server once for all clusters of the test binary
+func serverBinary() (string, error) {
+	if path := os.Getenv(ServerBinaryEnv); path != "" {
+		return path, nil
+	}
+
+	buildOnce.Do(func() {
+		out, err := exec.Command("go", "env", "GOMOD").Output()
+		if err != nil {
+			buildErr = commandError(err)
+			return
+		}
+		root := filepath.Dir(strings.TrimSpace(string(out)))
+
+		dir, err := os.MkdirTemp("", "livekit-cluster")
+		if err != nil {
+			buildErr = err
+			return
+		}
+		builtBinary = filepath.Join(dir, "livekit-server")
+
+		cmd := exec.Command("go", "build", "-o", builtBinary, "./cmd/server")
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			buildErr = fmt.Errorf("could not build server: %w: %s", err, out)
+		}
+	})
+	return builtBinary, buildErr
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisAddressEnv points the harness at an existing redis instead of starting a container.
+// The address has to be reachable from the node namespaces when the cluster is isolated.
+const RedisAddressEnv = "LIVEKIT_CLUSTER_REDIS"
+
+const redisImage = "redis:7-alpine"
+
+// startRedis returns the address of the redis shared by the nodes, bound to bindIP when a container is started
+func startRedis(t *testing.T, bindIP string) string {
+	t.Helper()
+
+	if addr := os.Getenv(RedisAddressEnv); addr != "" {
+		waitForRedis(t, addr)
+		return addr
+	}
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skipf("docker is required to start redis, or set %s", RedisAddressEnv)
+	}
+
+	out, err := exec.Command("docker", "run", "--rm", "-d", "-p", bindIP+"::6379", redisImage).Output()
+	if err != nil {
+		t.Fatalf("could not start redis container: %v", commandError(err))
+	}
+	containerID := strings.TrimSpace(string(out))
+	t.Cleanup(func() {
+		_ = exec.Command("docker", "rm", "-f", containerID).Run()
+	})
+
+	out, err = exec.Command("docker", "port", containerID, "6379/tcp").Output()
+	if err != nil {
+		t.Fatalf("could not get redis port: %v", commandError(err))
+	}
+	// docker lists one mapping per line, IPv4 first
+	mapping := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	idx := strings.LastIndex(mapping, ":")
+	if idx < 0 {
+		t.Fatalf("unexpected redis port mapping %q", mapping)
+	}
+	addr := fmt.Sprintf("%s:%s", bindIP, mapping[idx+1:])
+
+	waitForRedis(t, addr)
+	return addr
+}
+
+func waitForRedis(t *testing.T, addr string) {
+	t.Helper()
+
+	rc := redis.NewClient(&redis.Options{Addr: addr})
+	defer rc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for {
+		err := rc.Ping(ctx).Err()
+		if err == nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("redis at %s is not reachable: %v", addr, err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func commandError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}